@@ -23,6 +23,7 @@ import (
 
 	"github.com/0x6d61/sqleech/internal/detector"
 	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/enum"
 	"github.com/0x6d61/sqleech/internal/fingerprint"
 	"github.com/0x6d61/sqleech/internal/technique"
 	"github.com/0x6d61/sqleech/internal/technique/boolean"
@@ -86,6 +87,11 @@ func (a *techniqueAdapter) Detect(ctx context.Context, req *engine.TechniqueRequ
 		Baseline:  req.Baseline,
 		DBMS:      req.DBMS,
 		Client:    req.Client,
+		Context:   req.Context,
+
+		SleepSeconds: req.SleepSeconds,
+		Level:        req.Level,
+		Risk:         req.Risk,
 	}
 	r, err := a.inner.Detect(ctx, innerReq)
 	if err != nil {
@@ -103,6 +109,28 @@ func (a *techniqueAdapter) Detect(ctx context.Context, req *engine.TechniqueRequ
 	return dr, nil
 }
 
+func (a *techniqueAdapter) Extract(ctx context.Context, req *engine.ExtractionRequest) (*engine.ExtractionResult, error) {
+	r, err := a.inner.Extract(ctx, &technique.ExtractionRequest{
+		InjectionRequest: technique.InjectionRequest{
+			Target:    req.Target,
+			Parameter: req.Parameter,
+			Baseline:  req.Baseline,
+			DBMS:      req.DBMS,
+			Client:    req.Client,
+			Context:   req.Context,
+
+			SleepSeconds: req.SleepSeconds,
+			Level:        req.Level,
+			Risk:         req.Risk,
+		},
+		Query: req.Query,
+	})
+	if r == nil {
+		return nil, err
+	}
+	return &engine.ExtractionResult{Value: r.Value, Partial: r.Partial, Requests: r.Requests}, err
+}
+
 func wrapTechniques(techs ...technique.Technique) []engine.Technique {
 	out := make([]engine.Technique, len(techs))
 	for i, t := range techs {
@@ -129,6 +157,7 @@ func makeHeuristicFunc(client transport.Client) engine.HeuristicDetectorFunc {
 				ErrorSignatures: r.ErrorSignatures,
 				PageRatio:       r.PageRatio,
 				IsInjectable:    r.IsInjectable,
+				Context:         r.Context,
 			}
 		}
 		return out, nil
@@ -214,7 +243,7 @@ func TestE2E_MySQL_ErrorBased(t *testing.T) {
 	for _, vuln := range result.Vulnerabilities {
 		if vuln.Injectable {
 			foundInjectable = true
-			if vuln.Technique == "error-based" {
+			if vuln.ConfirmedWith("error-based") {
 				foundErrorBased = true
 			}
 		}
@@ -265,6 +294,72 @@ func TestE2E_PostgreSQL_ErrorBased(t *testing.T) {
 	t.Logf("DBMS: %s, request count: %d", result.DBMS, result.RequestCount)
 }
 
+// bannerThroughScan scans path error-based only and reads the banner,
+// current user and current database through the finding.
+func bannerThroughScan(t *testing.T, path string) (banner, user, db string) {
+	t.Helper()
+	base := e2eBaseURL(t)
+	client := newE2EClient(t)
+	cfg := engine.DefaultScanConfig()
+	cfg.Techniques = []string{"E"}
+	scanner := newFullScanner(client, cfg)
+
+	target := &engine.ScanTarget{URL: base + path, Method: http.MethodGet}
+	ctx := context.Background()
+	result, err := scanner.Scan(ctx, target)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	for _, vuln := range result.Vulnerabilities {
+		if !vuln.Injectable {
+			continue
+		}
+		en, err := enum.New(scanner, target, vuln)
+		if err != nil {
+			t.Fatalf("enum.New: %v", err)
+		}
+		if banner, err = en.Banner(ctx); err != nil {
+			t.Fatalf("Banner: %v", err)
+		}
+		if user, err = en.CurrentUser(ctx); err != nil {
+			t.Fatalf("CurrentUser: %v", err)
+		}
+		if db, err = en.CurrentDB(ctx); err != nil {
+			t.Fatalf("CurrentDB: %v", err)
+		}
+		t.Logf("banner %q, user %q, database %q in %d requests", banner, user, db, en.Requests())
+		return banner, user, db
+	}
+	t.Fatalf("expected an injectable vulnerability on %s", path)
+	return "", "", ""
+}
+
+func TestE2E_MySQL_Banner(t *testing.T) {
+	banner, user, db := bannerThroughScan(t, "/mysql/user?id=1")
+	if !strings.HasPrefix(banner, "8.0.") {
+		t.Errorf("Banner = %q, want the mysql:8.0 image's version", banner)
+	}
+	if !strings.HasPrefix(user, "root@") {
+		t.Errorf("CurrentUser = %q, want root", user)
+	}
+	if db != "testdb" {
+		t.Errorf("CurrentDB = %q, want testdb", db)
+	}
+}
+
+func TestE2E_PostgreSQL_Banner(t *testing.T) {
+	banner, user, db := bannerThroughScan(t, "/pg/user?id=1")
+	if !strings.HasPrefix(banner, "PostgreSQL 15.") {
+		t.Errorf("Banner = %q, want the postgres:15 image's version()", banner)
+	}
+	if user != "postgres" {
+		t.Errorf("CurrentUser = %q, want postgres", user)
+	}
+	if db != "testdb" {
+		t.Errorf("CurrentDB = %q, want testdb", db)
+	}
+}
+
 func TestE2E_MySQL_SearchEndpoint(t *testing.T) {
 	base := e2eBaseURL(t)
 	client := newE2EClient(t)
@@ -419,7 +514,7 @@ func TestE2E_TimeBased_MySQL(t *testing.T) {
 
 	var foundTimeBased bool
 	for _, vuln := range result.Vulnerabilities {
-		if vuln.Injectable && vuln.Technique == "time-based" {
+		if vuln.ConfirmedWith("time-based") {
 			foundTimeBased = true
 		}
 	}
@@ -460,7 +555,7 @@ func TestE2E_TimeBased_PostgreSQL(t *testing.T) {
 
 	var foundTimeBased bool
 	for _, vuln := range result.Vulnerabilities {
-		if vuln.Injectable && vuln.Technique == "time-based" {
+		if vuln.ConfirmedWith("time-based") {
 			foundTimeBased = true
 		}
 	}
@@ -3,8 +3,11 @@ module github.com/0x6d61/sqleech
 go 1.25.0
 
 require (
+	github.com/andybalholm/brotli v1.2.0
 	github.com/google/uuid v1.6.0
 	github.com/spf13/cobra v1.10.2
+	golang.org/x/net v0.46.0
+	golang.org/x/text v0.30.0
 	golang.org/x/time v0.14.0
 	modernc.org/sqlite v1.46.0
 )
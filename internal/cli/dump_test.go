@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0x6d61/sqleech/internal/session"
+	"github.com/0x6d61/sqleech/internal/testutil"
+)
+
+// executeDump runs the CLI with args and returns its output and error.
+// The flags it may set are reset before each run, as they outlive it.
+func executeDump(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	reset := func() {
+		for name, def := range map[string]string{"url": "", "technique": "", "output": ""} {
+			_ = rootCmd.PersistentFlags().Set(name, def)
+		}
+		for name, def := range map[string]string{"db": "", "table": "", "columns": "", "start": "0", "stop": "0", "session": ""} {
+			_ = dumpCmd.Flags().Set(name, def)
+		}
+		rootCmd.SetOut(nil)
+	}
+	reset()
+	t.Cleanup(reset)
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+func TestDump_Range(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+
+	out, err := executeDump(t, "dump", "-u", srv.URL+"/vuln/union-mysql-errors?id=1", "--technique", "U",
+		"-D", "shop", "-T", "members", "-C", "id,username", "--start", "3", "--stop", "5")
+	if err != nil {
+		t.Fatalf("dump: %v", err)
+	}
+	want := "# rows 3-5 of 120\nid,username\n102,member102\n103,member103\n104,member104\n"
+	if out != want {
+		t.Errorf("output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestDump_ResumesFromSession(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+	targetURL := srv.URL + "/vuln/union-mysql-errors?id=1"
+	dir := t.TempDir()
+	sessionPath := filepath.Join(dir, "dump.db")
+	outPath := filepath.Join(dir, "users.csv")
+
+	// An earlier dump wrote its header and first row before it stopped.
+	if err := os.WriteFile(outPath, []byte("id,username\n1,admin\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := session.NewSQLiteStore(sessionPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	if err := store.SaveDumpOffset(context.Background(), targetURL, "shop.users", 1); err != nil {
+		t.Fatalf("SaveDumpOffset: %v", err)
+	}
+	store.Close()
+
+	if _, err := executeDump(t, "dump", "-u", targetURL, "--technique", "U", "--session", sessionPath,
+		"-D", "shop", "-T", "users", "-C", "id,username", "-o", outPath); err != nil {
+		t.Fatalf("dump: %v", err)
+	}
+	b, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "id,username\n1,admin\n2,guest\n"; string(b) != want {
+		t.Errorf("output file:\n%s\nwant:\n%s", b, want)
+	}
+
+	store, err = session.NewSQLiteStore(sessionPath)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	defer store.Close()
+	if next, err := store.LoadDumpOffset(context.Background(), targetURL, "shop.users"); err != nil || next != 0 {
+		t.Errorf("offset after the finished dump = %d, %v; want it forgotten", next, err)
+	}
+}
+
+func TestDump_Errors(t *testing.T) {
+	tests := []struct {
+		args []string
+		msg  string
+	}{
+		{[]string{"dump", "-u", "http://127.0.0.1:1/?id=1", "-T", "users"}, "--db and --table are required"},
+		{[]string{"dump", "-u", "http://127.0.0.1:1/?id=1", "-D", "shop", "-T", "users", "--start", "5", "--stop", "3"}, "--start/--stop"},
+		{[]string{"dump", "-D", "shop", "-T", "users"}, "--url is required"},
+	}
+	for _, tt := range tests {
+		_, err := executeDump(t, tt.args...)
+		if err == nil || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("%v: err = %v, want %q", tt.args, err, tt.msg)
+		}
+	}
+}
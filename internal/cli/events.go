@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+)
+
+// renderEvent returns the line verbose mode prints for ev, or "" for none.
+// Status messages are printed from verbosity 1; technique runs, findings
+// and errors from verbosity 2.
+func renderEvent(ev engine.Event, verbose int) string {
+	if ev.Message != "" {
+		return "[*] " + ev.Message
+	}
+	if verbose < 2 {
+		return ""
+	}
+	switch ev.Kind {
+	case engine.EventTechniqueStart:
+		return fmt.Sprintf("[*] %s testing %q", ev.Technique, ev.Parameter.Name)
+	case engine.EventTechniqueEnd:
+		switch {
+		case ev.Err != nil:
+			return fmt.Sprintf("[!] %s on %q failed: %v", ev.Technique, ev.Parameter.Name, ev.Err)
+		case ev.Injectable:
+			return fmt.Sprintf("[*] %s on %q: injectable (confidence %.0f%%)", ev.Technique, ev.Parameter.Name, ev.Confidence*100)
+		default:
+			return fmt.Sprintf("[*] %s on %q: not injectable", ev.Technique, ev.Parameter.Name)
+		}
+	case engine.EventFinding:
+		if ev.Injectable {
+			return fmt.Sprintf("[+] %q is injectable (%s): %s", ev.Parameter.Name, ev.Technique, ev.Payload)
+		}
+	case engine.EventError:
+		return fmt.Sprintf("[!] %v", ev.Err)
+	}
+	return ""
+}
+
+// jsonEvent is an engine.Event as a line of --events-file.
+type jsonEvent struct {
+	Kind       string           `json:"kind"`
+	Time       time.Time        `json:"time"`
+	URL        string           `json:"url,omitempty"`
+	Parameter  *jsonEventParam  `json:"parameter,omitempty"`
+	Technique  string           `json:"technique,omitempty"`
+	Message    string           `json:"message,omitempty"`
+	Injectable bool             `json:"injectable,omitempty"`
+	Confidence float64          `json:"confidence,omitempty"`
+	Payload    string           `json:"payload,omitempty"`
+	DBMS       string           `json:"dbms,omitempty"`
+	Counts     *jsonEventCounts `json:"counts,omitempty"`
+	Error      string           `json:"error,omitempty"`
+}
+
+type jsonEventParam struct {
+	Name     string `json:"name"`
+	Location string `json:"location"`
+}
+
+type jsonEventCounts struct {
+	Parameters int   `json:"parameters"`
+	Findings   int   `json:"findings"`
+	Injectable int   `json:"injectable"`
+	Requests   int64 `json:"requests"`
+}
+
+// eventWriter returns a subscriber writing every event to w as a line of
+// JSON. Writing stops at the first error, which writeErr returns.
+func eventWriter(w io.Writer) (subscribe func(engine.Event), writeErr func() error) {
+	enc := json.NewEncoder(w)
+	var err error
+	subscribe = func(ev engine.Event) {
+		if err != nil {
+			return
+		}
+		je := jsonEvent{
+			Kind:       ev.Kind.String(),
+			Time:       ev.Time,
+			URL:        ev.URL,
+			Technique:  ev.Technique,
+			Message:    ev.Message,
+			Injectable: ev.Injectable,
+			Confidence: ev.Confidence,
+			Payload:    ev.Payload,
+			DBMS:       ev.DBMS,
+		}
+		if p := ev.Parameter; p != nil {
+			je.Parameter = &jsonEventParam{Name: p.Name, Location: p.Location.String()}
+		}
+		if ev.Kind == engine.EventScanEnd {
+			c := jsonEventCounts(ev.Counts)
+			je.Counts = &c
+		}
+		if ev.Err != nil {
+			je.Error = ev.Err.Error()
+		}
+		err = enc.Encode(je)
+	}
+	return subscribe, func() error { return err }
+}
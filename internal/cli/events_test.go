@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+)
+
+func TestRenderEvent(t *testing.T) {
+	id := &engine.Parameter{Name: "id"}
+	tests := []struct {
+		ev      engine.Event
+		verbose int
+		want    string
+	}{
+		{engine.Event{Kind: engine.EventBaseline, Message: "baseline request completed"}, 1, "[*] baseline request completed"},
+		{engine.Event{Kind: engine.EventTechniqueStart, Parameter: id, Technique: "error-based"}, 1, ""},
+		{engine.Event{Kind: engine.EventTechniqueStart, Parameter: id, Technique: "error-based"}, 2, `[*] error-based testing "id"`},
+		{engine.Event{Kind: engine.EventTechniqueEnd, Parameter: id, Technique: "error-based", Injectable: true, Confidence: 0.95}, 2, `[*] error-based on "id": injectable (confidence 95%)`},
+		{engine.Event{Kind: engine.EventTechniqueEnd, Parameter: id, Technique: "error-based"}, 2, `[*] error-based on "id": not injectable`},
+		{engine.Event{Kind: engine.EventTechniqueEnd, Parameter: id, Technique: "error-based", Err: errors.New("reset")}, 2, `[!] error-based on "id" failed: reset`},
+		{engine.Event{Kind: engine.EventFinding, Parameter: id, Technique: "error-based", Injectable: true, Payload: "1'"}, 2, `[+] "id" is injectable (error-based): 1'`},
+		{engine.Event{Kind: engine.EventFinding, Parameter: id, Technique: "error-based"}, 2, ""},
+		{engine.Event{Kind: engine.EventError, Err: errors.New("fingerprinting: timeout")}, 2, "[!] fingerprinting: timeout"},
+		{engine.Event{Kind: engine.EventScanEnd}, 3, ""},
+	}
+	for _, tt := range tests {
+		if got := renderEvent(tt.ev, tt.verbose); got != tt.want {
+			t.Errorf("renderEvent(%s, %d) = %q, want %q", tt.ev.Kind, tt.verbose, got, tt.want)
+		}
+	}
+}
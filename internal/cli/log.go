@@ -0,0 +1,23 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+)
+
+// newLogger returns the logger the scanner reports the errors it recovers
+// from to: in format (text or json) on w, at the level of verbose (see
+// engine.LogLevel).
+func newLogger(w io.Writer, verbose int, format string) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: engine.LogLevel(verbose)}
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("--log-format must be text or json, got %q", format)
+}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/0x6d61/sqleech/internal/payloadlib"
+)
+
+var payloadsCmd = &cobra.Command{
+	Use:   "payloads",
+	Short: "Inspect the payload corpus",
+}
+
+var payloadsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List payload corpus entries",
+	Long: `List dumps the payload corpus, optionally filtered by DBMS, technique,
+kind, SQL context, risk and level. Use --payloads-file to include the
+entries of a user payload file. Entries whose SQL could modify the target
+are rejected unless --unsafe-allow-writes is given.
+
+Examples:
+  sqleech payloads list --dbms MySQL --technique E
+  sqleech payloads list --kind boundary --context string -f json`,
+	RunE: runPayloadsList,
+}
+
+func init() {
+	rootCmd.AddCommand(payloadsCmd)
+	payloadsCmd.AddCommand(payloadsListCmd)
+	payloadsListCmd.Flags().String("kind", "", "Entry kind (boundary, error-template)")
+	payloadsListCmd.Flags().String("context", "", "SQL context (numeric, string, like, order-by)")
+	payloadsListCmd.Flags().Int("risk", payloadlib.MaxRisk, "Maximum risk level (1-3)")
+	payloadsListCmd.Flags().Int("level", payloadlib.MaxLevel, "Maximum test level (1-5)")
+	payloadsListCmd.Flags().String("payloads-file", "", "JSON file of user payload entries to include")
+}
+
+// runPayloadsList is the payloads list command handler.
+func runPayloadsList(cmd *cobra.Command, args []string) error {
+	dbmsName, _ := cmd.Flags().GetString("dbms")
+	techniqueStr, _ := cmd.Flags().GetString("technique")
+	kind, _ := cmd.Flags().GetString("kind")
+	sqlContext, _ := cmd.Flags().GetString("context")
+	risk, _ := cmd.Flags().GetInt("risk")
+	level, _ := cmd.Flags().GetInt("level")
+	payloadsFile, _ := cmd.Flags().GetString("payloads-file")
+	format, _ := cmd.Flags().GetString("format")
+	allowWrites, _ := cmd.Flags().GetBool("unsafe-allow-writes")
+
+	corpus := payloadlib.Default()
+	if payloadsFile != "" {
+		c, err := corpus.WithFile(payloadsFile)
+		if err != nil {
+			return fmt.Errorf("failed to load payloads file: %w", err)
+		}
+		if !allowWrites {
+			if err := c.CheckReadOnly(); err != nil {
+				return fmt.Errorf("payloads file %s rejected: %w (use --unsafe-allow-writes to allow it)", payloadsFile, err)
+			}
+		}
+		corpus = c
+	}
+
+	f := payloadlib.Filter{
+		Kind:     payloadlib.Kind(kind),
+		DBMS:     dbmsName,
+		Context:  payloadlib.Context(sqlContext),
+		MaxRisk:  risk,
+		MaxLevel: level,
+	}
+	entries := selectEntries(corpus, f, techniqueStr)
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	case "text", "":
+		return writePayloadTable(cmd.OutOrStdout(), entries)
+	default:
+		return fmt.Errorf("unsupported format %q for payloads list (use text or json)", format)
+	}
+}
+
+// selectEntries applies f to corpus once per comma-separated technique code
+// and merges the results in corpus order. An empty technique list selects
+// all techniques.
+func selectEntries(corpus *payloadlib.Corpus, f payloadlib.Filter, techniqueStr string) []payloadlib.Entry {
+	var techniques []string
+	for _, code := range strings.Split(techniqueStr, ",") {
+		if code = strings.TrimSpace(code); code != "" {
+			techniques = append(techniques, code)
+		}
+	}
+	if len(techniques) == 0 {
+		return corpus.Select(f)
+	}
+
+	selected := make(map[string]bool)
+	for _, tech := range techniques {
+		tf := f
+		tf.Technique = tech
+		for _, e := range corpus.Select(tf) {
+			selected[e.ID] = true
+		}
+	}
+	entries := []payloadlib.Entry{}
+	for _, e := range corpus.Entries() {
+		if selected[e.ID] {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// writePayloadTable writes entries as an aligned text table.
+func writePayloadTable(w io.Writer, entries []payloadlib.Entry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tKIND\tTECHNIQUES\tDBMS\tRISK\tLEVEL\tPAYLOAD\tDESCRIPTION")
+	for _, e := range entries {
+		dbmsList := strings.Join(e.DBMS, ",")
+		if dbmsList == "" {
+			dbmsList = "any"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\t%s\t%s\n",
+			e.ID, e.Kind, strings.Join(e.Techniques, ","), dbmsList,
+			e.Risk, e.Level, entryPayload(e), e.Description)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d entries\n", len(entries))
+	return err
+}
+
+// entryPayload renders the payload text of an entry for display.
+func entryPayload(e payloadlib.Entry) string {
+	if e.Kind == payloadlib.KindBoundary {
+		return fmt.Sprintf("%q...%q", e.Prefix, e.Suffix)
+	}
+	return e.Template
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/payloadlib"
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// executePayloads runs the CLI with args and returns its output, restoring
+// the shared persistent flags afterwards.
+func executePayloads(t *testing.T, args ...string) string {
+	t.Helper()
+	t.Cleanup(func() {
+		for name, def := range map[string]string{"format": "text", "dbms": "", "technique": "", "unsafe-allow-writes": "false"} {
+			_ = rootCmd.PersistentFlags().Set(name, def)
+		}
+		rootCmd.SetOut(nil)
+	})
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetArgs(args)
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute(%v): %v", args, err)
+	}
+	return buf.String()
+}
+
+func TestPayloadsList_JSONFilter(t *testing.T) {
+	out := executePayloads(t, "payloads", "list", "--dbms", "MySQL", "--technique", "E", "-f", "json")
+
+	var entries []payloadlib.Entry
+	if err := json.Unmarshal([]byte(out), &entries); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, out)
+	}
+	if len(entries) == 0 {
+		t.Fatal("no entries listed")
+	}
+	for _, e := range entries {
+		if !e.AppliesTo("MySQL") {
+			t.Errorf("%s does not apply to MySQL", e.ID)
+		}
+		if len(e.Techniques) != 1 || e.Techniques[0] != payloadlib.TechniqueError {
+			t.Errorf("%s: techniques = %v, want error-based only", e.ID, e.Techniques)
+		}
+	}
+}
+
+func TestPayloadsList_Text(t *testing.T) {
+	out := executePayloads(t, "payloads", "list", "--technique", "B,U", "--kind", "boundary")
+
+	if !strings.HasPrefix(out, "ID") || !strings.Contains(out, "bnd.squote.comment ") {
+		t.Errorf("unexpected table:\n%s", out)
+	}
+	if strings.Contains(out, "comment-bare") {
+		t.Errorf("error-based boundaries should be filtered out:\n%s", out)
+	}
+	want := len(payloadlib.Default().Select(payloadlib.Filter{Kind: payloadlib.KindBoundary, Technique: "B"}))
+	if !strings.Contains(out, "\n"+strconv.Itoa(want)+" entries\n") {
+		t.Errorf("expected %d entries:\n%s", want, out)
+	}
+}
+
+func TestPayloadsList_RejectsWritingPayloadsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "payloads.json")
+	data := `[{"id":"user.stacked.insert","kind":"boundary","techniques":["boolean-blind"],
+		"prefix":"';","suffix":"INSERT INTO audit VALUES (1)-- -","description":"Stacked insert"}]`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = payloadsListCmd.Flags().Set("payloads-file", "") })
+
+	rootCmd.SetOut(&bytes.Buffer{})
+	rootCmd.SetArgs([]string{"payloads", "list", "--payloads-file", path})
+	err := rootCmd.Execute()
+	rootCmd.SetOut(nil)
+	if err == nil {
+		t.Fatal("expected a write-bearing payloads file to be rejected")
+	}
+	for _, want := range []string{"user.stacked.insert", `"INSERT"`, "--unsafe-allow-writes"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+
+	out := executePayloads(t, "payloads", "list", "--payloads-file", path, "--unsafe-allow-writes")
+	if !strings.Contains(out, "user.stacked.insert") {
+		t.Errorf("--unsafe-allow-writes should list the entry:\n%s", out)
+	}
+}
+
+// TestScanPipeline_PayloadCoverage checks that a full default scan tries
+// exactly the corpus entries the default filter selects for the target's
+// DBMS: no technique keeps a private payload table.
+func TestScanPipeline_PayloadCoverage(t *testing.T) {
+	srv := newMockScanServer()
+	defer srv.Close()
+
+	client, err := transport.NewClient(transport.ClientOptions{})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	cfg := engine.DefaultScanConfig()
+	cfg.DBMSHint = "MySQL"
+	cfg.ForceTest = true
+	scanner := buildScanner(client, cfg)
+
+	result, err := scanner.Scan(context.Background(), &engine.ScanTarget{
+		URL:    srv.URL + "/safe?id=1",
+		Method: "GET",
+	})
+	if err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+
+	wantSet := make(map[string]bool)
+	for _, tech := range scanner.TechniqueNames() {
+		for _, kind := range []payloadlib.Kind{payloadlib.KindBoundary, payloadlib.KindErrorTemplate} {
+			for _, e := range payloadlib.Default().Select(payloadlib.DefaultFilter(kind, tech, "MySQL")) {
+				wantSet[e.ID] = true
+			}
+		}
+	}
+	var want, got []string
+	for id := range wantSet {
+		want = append(want, id)
+	}
+	for id, s := range result.PayloadCoverage {
+		if s.Tried > 0 {
+			got = append(got, id)
+		}
+		if s.Succeeded > 0 {
+			t.Errorf("%s succeeded against a safe endpoint", id)
+		}
+	}
+	sort.Strings(want)
+	sort.Strings(got)
+
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("tried payloads differ from corpus selection:\ngot:  %v\nwant: %v", got, want)
+	}
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+
+	"github.com/0x6d61/sqleech/internal/jsonpath"
+	"github.com/0x6d61/sqleech/internal/payload"
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query SQL",
+	Short: "Run a SQL query through an injection point",
+	Long: `Query reads the result of a SQL query through an injection point and
+prints it, one row per line. The query is either an expression, such as
+@@version, or a SELECT of a single column, whose rows are read one at a
+time.
+
+Statements that could write are refused unless --risk 3 and
+--unsafe-allow-writes are both given.
+
+The injection point is the one a scan saved to --session for the target;
+without a saved finding the target is scanned first, and the finding saved
+when --session is given.
+
+With --extract-filter, rows that are JSON documents are printed as what
+the path selects from them.
+
+Examples:
+  sqleech query -u "http://target.com/page?id=1" "@@version"
+  sqleech query -u "http://target.com/page?id=1" --session scan.db "SELECT username FROM users"
+  sqleech query -u "http://target.com/page?id=1" --extract-filter '$.email' "SELECT profile FROM users"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runQuery,
+}
+
+func init() {
+	rootCmd.AddCommand(queryCmd)
+	queryCmd.Flags().String("session", "", "Session file path to reuse the injection point a scan saved (SQLite)")
+}
+
+// runQuery is the query command handler.
+func runQuery(cmd *cobra.Command, args []string) error {
+	sessionPath, _ := cmd.Flags().GetString("session")
+	outputPath, _ := cmd.Flags().GetString("output")
+	risk, _ := cmd.Flags().GetInt("risk")
+	allowWrites, _ := cmd.Flags().GetBool("unsafe-allow-writes")
+
+	query := args[0]
+	if err := checkUserQuery(query, risk, allowWrites); err != nil {
+		return err
+	}
+	scanner, target, _, err := findingTarget(cmd)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer cancel()
+
+	en, err := sessionEnumerator(ctx, scanner, target, sessionPath)
+	if err != nil {
+		return err
+	}
+	if en == nil {
+		return fmt.Errorf("no injectable finding of a known DBMS on %s to query through", target.URL)
+	}
+
+	out := cmd.OutOrStdout()
+	if outputPath != "" {
+		f, err := os.Create(outputPath)
+		if err != nil {
+			return fmt.Errorf("failed to create output file %q: %w", outputPath, err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	values, err := en.Query(ctx, query)
+	for _, v := range values {
+		fmt.Fprintln(out, v)
+	}
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+	return nil
+}
+
+// extractFilter returns the --extract-filter path, nil when none is given.
+func extractFilter(cmd *cobra.Command) (*jsonpath.Path, error) {
+	expr, _ := cmd.Flags().GetString("extract-filter")
+	if expr == "" {
+		return nil, nil
+	}
+	p, err := jsonpath.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --extract-filter: %w", err)
+	}
+	return p, nil
+}
+
+// checkUserQuery refuses a user query (query, --sql-query) that could
+// write, unless risk is 3 and writes are allowed; the read-only guard
+// would refuse its probes anyway without --unsafe-allow-writes.
+func checkUserQuery(query string, risk int, allowWrites bool) error {
+	v := payload.CheckReadOnly(query)
+	if v == nil {
+		return nil
+	}
+	if risk < 3 {
+		return fmt.Errorf("%v; statements other than SELECT need --risk 3", v)
+	}
+	if !allowWrites {
+		return fmt.Errorf("%v; statements other than SELECT also need --unsafe-allow-writes", v)
+	}
+	return nil
+}
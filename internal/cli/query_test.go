@@ -0,0 +1,565 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/0x6d61/sqleech/internal/testutil"
+)
+
+// executeQuery runs the CLI with args and returns its output and error.
+// The flags the query command and scan --sql-query, --file-read,
+// --test-cookies, --test-path-segments, --cross-param, --extract-filter and
+// custom markers may set are reset before each run, as they outlive it.
+func executeQuery(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	reset := func() {
+		for name, def := range map[string]string{"url": "", "request-file": "", "cookie": "", "technique": "", "output": "", "format": "text", "risk": "1", "data": "", "method": "GET", "extract-filter": ""} {
+			_ = rootCmd.PersistentFlags().Set(name, def)
+		}
+		// A --method read from a request file applies unless the flag is set.
+		rootCmd.PersistentFlags().Lookup("method").Changed = false
+		_ = rootCmd.PersistentFlags().Lookup("header").Value.(interface{ Replace([]string) error }).Replace(nil)
+		_ = queryCmd.Flags().Set("session", "")
+		_ = scanCmd.Flags().Set("sql-query", "")
+		_ = scanCmd.Flags().Lookup("file-read").Value.(interface{ Replace([]string) error }).Replace(nil)
+		_ = scanCmd.Flags().Set("output-dir", ".")
+		_ = scanCmd.Flags().Set("test-cookies", "false")
+		_ = scanCmd.Flags().Set("test-path-segments", "0")
+		_ = scanCmd.Flags().Set("graphql", "false")
+		_ = scanCmd.Flags().Set("cross-param", "false")
+		for _, name := range []string{"csrf-token", "csrf-url", "csrf-regex", "login-url", "login-data", "logout-regex"} {
+			_ = scanCmd.Flags().Set(name, "")
+		}
+		_ = scanCmd.Flags().Lookup("allow-param").Value.(interface{ Replace([]string) error }).Replace(nil)
+		_ = scanCmd.Flags().Lookup("param").Value.(interface{ Replace([]string) error }).Replace(nil)
+		_ = scanCmd.Flags().Lookup("skip").Value.(interface{ Replace([]string) error }).Replace(nil)
+		_ = scanCmd.Flags().Lookup("base64-params").Value.(interface{ Replace([]string) error }).Replace(nil)
+		rootCmd.SetOut(nil)
+	}
+	reset()
+	t.Cleanup(reset)
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+func TestQuery_Version(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+
+	out, err := executeQuery(t, "query", "-u", srv.URL+"/vuln/union-mysql-errors?id=1", "--technique", "U", "@@version")
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if out != "8.0.32\n" {
+		t.Errorf("output = %q, want %q", out, "8.0.32\n")
+	}
+
+	out, err = executeQuery(t, "query", "-u", srv.URL+"/vuln/union-mysql-errors?id=1", "--technique", "U",
+		"SELECT username FROM shop.users")
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if want := "admin\nguest\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
+
+func TestScan_SQLQueryJSON(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	_, err := executeQuery(t, "scan", "-u", srv.URL+"/vuln/union-mysql-errors?id=1", "--technique", "U",
+		"--sql-query", "@@version", "--format", "json", "-o", path)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Extractions []struct {
+			Query     string   `json:"query"`
+			Values    []string `json:"values"`
+			Technique string   `json:"technique"`
+			Parameter string   `json:"parameter"`
+		} `json:"extractions"`
+	}
+	if err := json.Unmarshal(b, &report); err != nil {
+		t.Fatalf("report is not JSON: %v", err)
+	}
+	if len(report.Extractions) != 1 {
+		t.Fatalf("extractions = %+v, want one", report.Extractions)
+	}
+	x := report.Extractions[0]
+	if x.Query != "@@version" || !slices.Equal(x.Values, []string{"8.0.32"}) || x.Technique != "union-based" || x.Parameter != "id" {
+		t.Errorf("extraction = %+v, want @@version = 8.0.32 through union-based on id", x)
+	}
+}
+
+func TestQuery_ExtractFilter(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+
+	out, err := executeQuery(t, "query", "-u", srv.URL+"/vuln/union-mysql-errors?id=1", "--technique", "U",
+		"--extract-filter", "$.contact.emails[1]", "SELECT data FROM shop.profiles")
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if want := "ops@shop.test\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	// A value that is not JSON is printed raw.
+	out, err = executeQuery(t, "query", "-u", srv.URL+"/vuln/union-mysql-errors?id=1", "--technique", "U",
+		"--extract-filter", "$.contact", "@@version")
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if out != "8.0.32\n" {
+		t.Errorf("output = %q, want %q", out, "8.0.32\n")
+	}
+
+	if _, err := executeQuery(t, "query", "-u", srv.URL+"/vuln/union-mysql-errors?id=1", "--extract-filter", "contact[", "@@version"); err == nil || !strings.Contains(err.Error(), "--extract-filter") {
+		t.Errorf("invalid filter: err = %v, want an --extract-filter error", err)
+	}
+}
+
+func TestScan_SQLQueryExtractFilterJSON(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	_, err := executeQuery(t, "scan", "-u", srv.URL+"/vuln/union-mysql-errors?id=1", "--technique", "U",
+		"--sql-query", "SELECT data FROM shop.profiles", "--extract-filter", "$.contact.city", "--format", "json", "-o", path)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Extractions []struct {
+			Values []string `json:"values"`
+			Filter string   `json:"filter"`
+			Raw    []string `json:"raw_values"`
+		} `json:"extractions"`
+	}
+	if err := json.Unmarshal(b, &report); err != nil {
+		t.Fatalf("report is not JSON: %v", err)
+	}
+	if len(report.Extractions) != 1 {
+		t.Fatalf("extractions = %+v, want one", report.Extractions)
+	}
+	x := report.Extractions[0]
+	if !slices.Equal(x.Values, []string{"Paris"}) || x.Filter != "$.contact.city" {
+		t.Errorf("extraction = %+v, want Paris through $.contact.city", x)
+	}
+	if len(x.Raw) != 1 || !strings.Contains(x.Raw[0], `"emails"`) {
+		t.Errorf("raw values = %q, want the profile document", x.Raw)
+	}
+}
+
+func TestQuery_RefusesWrites(t *testing.T) {
+	tests := []struct {
+		args []string
+		msg  string
+	}{
+		{[]string{"query", "-u", "http://127.0.0.1:1/?id=1", "UPDATE users SET name='x'"}, "need --risk 3"},
+		{[]string{"query", "-u", "http://127.0.0.1:1/?id=1", "--risk", "3", "DROP TABLE users"}, "--unsafe-allow-writes"},
+		{[]string{"scan", "-u", "http://127.0.0.1:1/?id=1", "--sql-query", "DELETE FROM users"}, "--sql-query"},
+		{[]string{"query", "@@version"}, "--url is required"},
+	}
+	for _, tt := range tests {
+		_, err := executeQuery(t, tt.args...)
+		if err == nil || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("%v: err = %v, want %q", tt.args, err, tt.msg)
+		}
+	}
+}
+
+func TestScan_CrossParamNeedsRisk3(t *testing.T) {
+	_, err := executeQuery(t, "scan", "-u", "http://127.0.0.1:1/?a=1&b=2", "--cross-param", "--risk", "2")
+	if err == nil || !strings.Contains(err.Error(), "--cross-param") {
+		t.Errorf("error = %v, want --cross-param refused at risk 2", err)
+	}
+}
+
+func TestScan_FileRead(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+
+	dir := filepath.Join(t.TempDir(), "files")
+	_, err := executeQuery(t, "scan", "-u", srv.URL+"/vuln/union-mysql-errors?id=1", "--technique", "U", "--risk", "2",
+		"--file-read", "/etc/passwd", "--file-read", "/etc/shadow", "--output-dir", dir, "-o", filepath.Join(t.TempDir(), "report.txt"))
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "_etc_passwd"))
+	if err != nil {
+		t.Fatalf("reading the saved file: %v", err)
+	}
+	if !strings.HasPrefix(string(b), "root:x:0:0:") {
+		t.Errorf("saved /etc/passwd = %q", b)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "_etc_shadow")); !os.IsNotExist(err) {
+		t.Errorf("unreadable /etc/shadow was saved (stat error %v)", err)
+	}
+
+	_, err = executeQuery(t, "scan", "-u", srv.URL+"/vuln/union-mysql-errors?id=1", "--file-read", "/etc/passwd")
+	if err == nil || !strings.Contains(err.Error(), "--risk 2") {
+		t.Errorf("error = %v, want --file-read refused at risk 1", err)
+	}
+}
+
+func TestFileReadName(t *testing.T) {
+	for path, want := range map[string]string{
+		"/etc/passwd":        "_etc_passwd",
+		`C:\Windows\win.ini`: "C__Windows_win.ini",
+		"../../etc/passwd":   ".._.._etc_passwd",
+		"..":                 "_",
+		"/var/www/html/.env": "_var_www_html_.env",
+	} {
+		if got := fileReadName(path); got != want {
+			t.Errorf("fileReadName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestScan_CustomMarker(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	_, err := executeQuery(t, "scan", "-u", srv.URL+"/vuln/json", "-H", "Content-Type: application/json",
+		"-d", `{"name":"Widget*","qty":1}`, "--technique", "E", "--format", "json", "-o", path)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Vulnerabilities []struct {
+			Parameter struct {
+				Name     string `json:"name"`
+				Location string `json:"location"`
+			} `json:"parameter"`
+		} `json:"vulnerabilities"`
+	}
+	if err := json.Unmarshal(b, &report); err != nil {
+		t.Fatalf("report is not JSON: %v", err)
+	}
+	var found []string
+	for _, v := range report.Vulnerabilities {
+		found = append(found, v.Parameter.Location+":"+v.Parameter.Name)
+	}
+	if !slices.Equal(found, []string{"custom:#1*"}) {
+		t.Errorf("findings on %q, want the marker only", found)
+	}
+}
+
+func TestScan_TestPathSegments(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	_, err := executeQuery(t, "scan", "-u", srv.URL+"/vuln/path/1", "--test-path-segments",
+		"--technique", "B", "--format", "json", "-o", path)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"location": "path"`) || !strings.Contains(string(b), `"name": "/vuln/path/*"`) {
+		t.Errorf("expected a finding on the id segment, got %s", b)
+	}
+
+	if _, err := executeQuery(t, "scan", "-u", srv.URL+"/vuln/path/1", "--test-path-segments=3"); err == nil {
+		t.Error("expected --test-path-segments=3 to be rejected")
+	}
+}
+
+func TestScan_GraphQLPersistedQuery(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+
+	body := `{"variables":{"filter":{"id":"1"}},"extensions":{"persistedQuery":{"version":1,"sha256Hash":"ecf4edb46db40b5132295c0291d62fb65d6759a9eedfa4d5d612dd5ec54a6b38"}}}`
+	path := filepath.Join(t.TempDir(), "report.json")
+	_, err := executeQuery(t, "scan", "-u", srv.URL+"/vuln/graphql", "-d", body, "--graphql",
+		"--technique", "B", "--format", "json", "-o", path)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"location": "graphql"`) || !strings.Contains(string(b), `"name": "filter.id"`) {
+		t.Errorf("expected a finding on filter.id, got %s", b)
+	}
+
+	if _, err := executeQuery(t, "scan", "-u", srv.URL+"/vuln/graphql", "-d", "id=1", "--graphql"); err == nil {
+		t.Error("expected --graphql to reject a body without variables")
+	}
+}
+
+func TestScan_Base64Params(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+
+	// confirmed reports whether the scan confirmed a finding on data.
+	confirmed := func(flags ...string) bool {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "report.json")
+		args := append([]string{"scan", "-u", srv.URL + "/vuln/base64?data=MQ%3D%3D",
+			"--technique", "E", "--format", "json", "-o", path}, flags...)
+		if _, err := executeQuery(t, args...); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var report struct {
+			Vulnerabilities []struct {
+				Parameter struct {
+					Name string `json:"name"`
+				} `json:"parameter"`
+				Confidence float64 `json:"confidence"`
+			} `json:"vulnerabilities"`
+		}
+		if err := json.Unmarshal(b, &report); err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range report.Vulnerabilities {
+			if v.Parameter.Name == "data" && v.Confidence > 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	if confirmed() {
+		t.Error("expected no finding without --base64-params")
+	}
+	for _, flag := range []string{"--base64-params", "--base64-params=data"} {
+		if !confirmed(flag) {
+			t.Errorf("%s: expected a finding on data", flag)
+		}
+	}
+}
+
+func TestScan_RequestFile(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	raw := "POST /vuln/error-mysql HTTP/1.1\r\n" +
+		"Host: " + host + "\r\n" +
+		"Content-Type: application/x-www-form-urlencoded\r\n" +
+		"Content-Length: 4\r\n" +
+		"Accept-Encoding: gzip, deflate, br\r\n" +
+		"Cookie: session=abc\r\n" +
+		"\r\n" +
+		"id=1\n"
+	dir := t.TempDir()
+	requestFile := filepath.Join(dir, "request.txt")
+	if err := os.WriteFile(requestFile, []byte(raw), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "report.json")
+	_, err := executeQuery(t, "scan", "-r", requestFile, "--technique", "E", "--format", "json", "-o", path)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"method": "POST"`) || !strings.Contains(string(b), `"location": "body"`) ||
+		strings.Contains(string(b), `"confidence": 0,`) {
+		t.Errorf("expected a finding on the id body parameter of the POST, got %s", b)
+	}
+
+	if _, err := executeQuery(t, "scan", "-r", requestFile, "-u", srv.URL+"/vuln/error-mysql?id=1"); err == nil {
+		t.Error("expected --url and --request-file together to be rejected")
+	}
+}
+
+func TestScan_PutMethod(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	_, err := executeQuery(t, "scan", "-u", srv.URL+"/vuln/put", "--method", "put", "-d", "id=1",
+		"--allow-param", "id", "--technique", "E", "--format", "json", "-o", path)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"method": "PUT"`) || !strings.Contains(string(b), `"location": "body"`) {
+		t.Errorf("expected a finding on the PUT body, got %s", b)
+	}
+}
+
+func TestScan_ParamFilters(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+
+	target := srv.URL + "/vuln/error-mysql?id=1&sort=name"
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"--param", "i?"}, true},
+		{[]string{"--skip", "sort"}, true},
+		{[]string{"--param", "sort"}, false},
+		{[]string{"--param", "*", "--skip", "ID"}, false},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "report.json")
+		args := append([]string{"scan", "-u", target, "--technique", "E", "--format", "json", "-o", path}, tt.args...)
+		if _, err := executeQuery(t, args...); err != nil {
+			t.Fatalf("scan %v: %v", tt.args, err)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Contains(string(b), `"name": "id"`) && !strings.Contains(string(b), `"confidence": 0,`); got != tt.want {
+			t.Errorf("%v: finding on id = %v, want %v; report %s", tt.args, got, tt.want, b)
+		}
+	}
+}
+
+func TestScan_CSRFToken(t *testing.T) {
+	srv := httptest.NewServer(testutil.RequireCSRFToken(testutil.VulnHandler(), "csrf"))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	_, err := executeQuery(t, "scan", "-u", srv.URL+"/vuln/error-mysql?id=1&csrf=stale", "--csrf-token", "csrf",
+		"--technique", "E", "--format", "json", "-o", path)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"name": "id"`) || strings.Contains(string(b), `"name": "csrf"`) {
+		t.Errorf("expected a finding on id only, got %s", b)
+	}
+
+	if _, err := executeQuery(t, "scan", "-u", srv.URL+"/vuln/error-mysql?id=1", "--csrf-url", srv.URL); err == nil {
+		t.Error("expected --csrf-url without --csrf-token to be rejected")
+	}
+}
+
+func TestScan_LoginSession(t *testing.T) {
+	srv := httptest.NewServer(testutil.RequireLogin(testutil.VulnHandler(), 10))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	_, err := executeQuery(t, "scan", "-u", srv.URL+"/vuln/error-mysql?id=1", "--cookie", testutil.SessionCookie+"=expired",
+		"--login-url", srv.URL+testutil.LoginPath, "--login-data", testutil.LoginData, "--logout-regex", "Please sign in",
+		"--technique", "E", "--format", "json", "-o", path)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"name": "id"`) {
+		t.Errorf("expected a finding on id across session expiries, got %s", b)
+	}
+
+	for _, args := range [][]string{
+		{"--login-url", srv.URL + testutil.LoginPath},
+		{"--logout-regex", "sign in"},
+		{"--login-data", testutil.LoginData},
+		{"--login-url", srv.URL, "--logout-regex", "("},
+	} {
+		if _, err := executeQuery(t, append([]string{"scan", "-u", srv.URL + "/vuln/error-mysql?id=1"}, args...)...); err == nil {
+			t.Errorf("expected scan %v to be rejected", args)
+		}
+	}
+}
+
+func TestBodyContentType(t *testing.T) {
+	soap := map[string]string{"Content-Type": "application/soap+xml; charset=utf-8"}
+	plain := map[string]string{"Content-Type": "application/json"}
+	tests := []struct {
+		headers map[string]string
+		body    string
+		want    string
+	}{
+		{nil, "", ""},
+		{nil, "id=1&name=x", "application/x-www-form-urlencoded"},
+		{nil, `<?xml version="1.0"?><GetUser><id>1</id></GetUser>`, "text/xml"},
+		{soap, "<Envelope/>", "application/soap+xml; charset=utf-8"},
+		{plain, `{"id":1}`, ""},
+		{map[string]string{"Content-Type": "application/x-www-form-urlencoded; charset=UTF-8"}, "id=1", "application/x-www-form-urlencoded; charset=UTF-8"},
+		{nil, `{"query":"query { me { id } }","variables":{"id":"1"}}`, "application/json"},
+	}
+	for _, tt := range tests {
+		if got := bodyContentType(tt.headers, tt.body); got != tt.want {
+			t.Errorf("bodyContentType(%v, %q) = %q, want %q", tt.headers, tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestScan_TestCookies(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	_, err := executeQuery(t, "scan", "-u", srv.URL+"/vuln/cookie", "--cookie", "id=1; lang=en", "--test-cookies",
+		"--technique", "E", "--format", "json", "-o", path)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Vulnerabilities []struct {
+			Parameter struct {
+				Name     string `json:"name"`
+				Location string `json:"location"`
+			} `json:"parameter"`
+		} `json:"vulnerabilities"`
+	}
+	if err := json.Unmarshal(b, &report); err != nil {
+		t.Fatalf("report is not JSON: %v", err)
+	}
+	var found []string
+	for _, v := range report.Vulnerabilities {
+		found = append(found, v.Parameter.Location+":"+v.Parameter.Name)
+	}
+	if !slices.Equal(found, []string{"cookie:id"}) {
+		t.Errorf("findings on %q, want the id cookie only", found)
+	}
+}
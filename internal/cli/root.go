@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/0x6d61/sqleech/internal/transport"
 )
 
 // Version information (set by build flags)
@@ -34,28 +36,52 @@ func init() {
 	rootCmd.AddCommand(versionCmd)
 
 	// Target flags
-	rootCmd.PersistentFlags().StringP("url", "u", "", "Target URL (e.g., http://target.com/page?id=1)")
-	rootCmd.PersistentFlags().String("method", "GET", "HTTP method (GET, POST, PUT, etc.)")
+	rootCmd.PersistentFlags().StringP("url", "u", "", "Target URL (e.g., http://target.com/page?id=1); a * here or in --data, --cookie or --header marks the exact injection point")
+	rootCmd.PersistentFlags().StringP("request-file", "r", "", "Load the target request from a raw HTTP request file (e.g. saved from Burp); --method, --data, --header and --cookie amend it")
+	rootCmd.PersistentFlags().String("method", "GET", "HTTP method (GET, POST, PUT, PATCH, DELETE, etc.); the parameters of PUT, PATCH and DELETE requests are probed only with --allow-param or --allow-risky-params")
 	rootCmd.PersistentFlags().StringP("data", "d", "", "POST data (e.g., id=1&name=test)")
 	rootCmd.PersistentFlags().String("cookie", "", "Cookie string (e.g., PHPSESSID=abc123)")
+	rootCmd.PersistentFlags().Bool("cookie-jar", false, "Keep cookies the target sets, such as a rotated session ID, and send them in place of --cookie values of the same name")
 	rootCmd.PersistentFlags().StringArrayP("header", "H", nil, "Extra header (repeatable, e.g., -H 'X-Custom: value')")
+	rootCmd.PersistentFlags().String("auth-type", "", "HTTP authentication: basic, bearer or ntlm")
+	rootCmd.PersistentFlags().String("auth-cred", "", `Credentials for --auth-type: user:password for basic, the token for bearer, DOMAIN\user:password for ntlm`)
 
 	// Connection flags
-	rootCmd.PersistentFlags().String("proxy", "", "Proxy URL (http://host:port or socks5://host:port)")
+	rootCmd.PersistentFlags().String("proxy", "", "Proxy URL (http://host:port, or socks5://host:port or socks5h://host:port to resolve host names on the proxy; user:pass@ for credentials)")
+	rootCmd.PersistentFlags().String("host", "", "Send this Host header, and TLS server name, with every request, e.g. to reach a virtual host at a --url naming its IP address")
+	rootCmd.PersistentFlags().StringArray("resolve", nil, "Connect to this IP address for a host name, keeping the name in URLs and Host headers (repeatable, e.g. --resolve app.example.com:10.0.0.5)")
+	rootCmd.PersistentFlags().Bool("http1", false, "Speak only HTTP/1.1 to the target, even where it offers HTTP/2")
+	rootCmd.PersistentFlags().Bool("http2", false, "Speak only HTTP/2 to the target: negotiated over TLS, and unencrypted (h2c) for http:// URLs")
+	rootCmd.PersistentFlags().String("client-cert", "", "PEM client certificate for targets that require one (mutual TLS); may hold the key too")
+	rootCmd.PersistentFlags().String("client-key", "", "PEM private key of --client-cert")
+	rootCmd.PersistentFlags().String("ca-cert", "", "PEM certificates of a CA, such as an internal one, to trust besides the system's")
 	rootCmd.PersistentFlags().Int("threads", 10, "Number of concurrent threads")
 	rootCmd.PersistentFlags().Duration("timeout", 30*time.Second, "Request timeout")
+	rootCmd.PersistentFlags().Int64("max-response-bytes", transport.DefaultMaxResponseBytes, "Read at most this many bytes of a response body, discarding the rest (-1 = no limit)")
+	rootCmd.PersistentFlags().Duration("delay", 0, "Wait this long before every request (e.g. 2s), to stay under rate limiters and IDS thresholds")
+	rootCmd.PersistentFlags().Duration("jitter", 0, "Add a random extra wait of up to this long to --delay (e.g. 1s)")
+	rootCmd.PersistentFlags().Int("retries", 2, "Resend a request other than a POST that fails with a network error, a timeout, 429 or 502-504 up to this many times")
+	rootCmd.PersistentFlags().Duration("retry-backoff", 500*time.Millisecond, "Initial wait before a retry, doubled with every further one and randomized; a Retry-After header takes precedence")
 
 	// Output flags
 	rootCmd.PersistentFlags().IntP("verbose", "v", 0, "Verbosity level (0-3)")
 	rootCmd.PersistentFlags().StringP("output", "o", "", "Output file path")
-	rootCmd.PersistentFlags().StringP("format", "f", "text", "Output format (text, json)")
+	rootCmd.PersistentFlags().StringP("format", "f", "text", "Output format (text, json, template)")
+	rootCmd.PersistentFlags().String("traffic-file", "", "Append every request sent and the response to it to this file, for debugging")
+	rootCmd.PersistentFlags().Bool("redact", false, "Leave Authorization and Cookie header values out of --traffic-file")
 
 	// Scan options
 	rootCmd.PersistentFlags().String("dbms", "", "Force DBMS type (MySQL, PostgreSQL)")
 	rootCmd.PersistentFlags().String("technique", "", "Techniques to use (B=Boolean, E=Error, comma-separated)")
+	rootCmd.PersistentFlags().String("technique-timeout", "", "Request timeouts by technique overriding --timeout, e.g. E=10s,T=30s (time-based otherwise times its probes out just past the sleep)")
 	rootCmd.PersistentFlags().Bool("force-ssl", false, "Force HTTPS")
 	rootCmd.PersistentFlags().Bool("random-agent", false, "Use random User-Agent")
 	rootCmd.PersistentFlags().Bool("force-test", false, "Test all parameters even if heuristics say safe")
+	rootCmd.PersistentFlags().Int("risk", 1, "Risk level (1-3); 2 adds the boolean-blind timing fallback, OR-based boolean conditions and MySQL heavy queries, 3 cross-parameter payloads and statements that write")
+	rootCmd.PersistentFlags().Int64("max-requests", 0, "Stop once this many requests are sent and report what was found so far (0 = no limit)")
+	rootCmd.PersistentFlags().Int("level", 1, "Test level (1-5); higher levels try more boundaries and confirmation rounds, 2 tests cookies, 3 the User-Agent and Referer headers")
+	rootCmd.PersistentFlags().Bool("unsafe-allow-writes", false, "Allow payloads and payload files containing SQL that can modify the target")
+	rootCmd.PersistentFlags().String("extract-filter", "", "Show only this JSONPath of extracted values that are JSON documents (e.g. '$.contact.emails[0]'); values it does not apply to are shown raw")
 }
 
 var versionCmd = &cobra.Command{
@@ -1,6 +1,9 @@
 package cli
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -64,6 +67,168 @@ func TestScanCommand_MissingURL(t *testing.T) {
 	}
 }
 
+func TestScanCommand_InvalidNonceHeader(t *testing.T) {
+	t.Cleanup(func() {
+		_ = rootCmd.PersistentFlags().Set("url", "")
+		_ = scanCmd.Flags().Lookup("nonce-header").Value.(interface{ Replace([]string) error }).Replace(nil)
+	})
+	rootCmd.SetArgs([]string{"scan", "-u", "http://127.0.0.1:1/?id=1", "--nonce-header", "X-Nonce:guid"})
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "unknown format") {
+		t.Errorf("expected unknown nonce format error, got %v", err)
+	}
+}
+
+func TestScanCommand_AllowRiskyParamsNeedsBatch(t *testing.T) {
+	t.Cleanup(func() {
+		_ = rootCmd.PersistentFlags().Set("url", "")
+		_ = scanCmd.Flags().Set("allow-risky-params", "false")
+	})
+	rootCmd.SetArgs([]string{"scan", "-u", "http://127.0.0.1:1/?delete=1", "--allow-risky-params"})
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--batch") {
+		t.Errorf("expected --allow-risky-params to require --batch, got %v", err)
+	}
+}
+
+func TestScanCommand_TestCookiesNeedsCookies(t *testing.T) {
+	t.Cleanup(func() {
+		_ = rootCmd.PersistentFlags().Set("url", "")
+		_ = scanCmd.Flags().Set("test-cookies", "false")
+	})
+	rootCmd.SetArgs([]string{"scan", "-u", "http://127.0.0.1:1/cart", "--test-cookies"})
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--cookie") {
+		t.Errorf("expected --test-cookies to require --cookie, got %v", err)
+	}
+}
+
+func TestScanCommand_URLFile(t *testing.T) {
+	list := filepath.Join(t.TempDir(), "urls.txt")
+	if err := os.WriteFile(list, []byte("http://127.0.0.1:1/?id=1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-u", "http://127.0.0.1:1/?id=1"}, "mutually exclusive"},
+		{[]string{"--banner"}, "--banner cannot be used with --url-file"},
+		{[]string{"--csrf-token", "token"}, "needs --csrf-url"},
+		{[]string{"--target-concurrency", "0"}, "--target-concurrency must be at least 1"},
+		{[]string{"--forms"}, "mutually exclusive with --url-file"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			t.Cleanup(func() {
+				_ = rootCmd.PersistentFlags().Set("url", "")
+				_ = scanCmd.Flags().Set("url-file", "")
+				_ = scanCmd.Flags().Set("banner", "false")
+				_ = scanCmd.Flags().Set("csrf-token", "")
+				_ = scanCmd.Flags().Set("target-concurrency", "1")
+				_ = scanCmd.Flags().Set("forms", "false")
+			})
+			rootCmd.SetArgs(append([]string{"scan", "--url-file", list}, tt.args...))
+			err := rootCmd.Execute()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestScanCommand_CrawlAndForms(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--crawl-depth", "-1"}, "--crawl-depth must not be negative"},
+		{[]string{"--crawl-exclude", "logout"}, "need --crawl-depth"},
+		{[]string{"--crawl-depth", "2", "--crawl-exclude", "("}, "invalid --crawl-exclude"},
+		{[]string{"--crawl-depth", "2", "--sql-query", "SELECT 1"}, "--sql-query cannot be used with --crawl-depth"},
+		{[]string{"--forms", "--banner"}, "--banner cannot be used with --forms"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			t.Cleanup(func() {
+				_ = rootCmd.PersistentFlags().Set("url", "")
+				_ = scanCmd.Flags().Set("crawl-depth", "0")
+				_ = scanCmd.Flags().Set("crawl-exclude", "")
+				_ = scanCmd.Flags().Set("sql-query", "")
+				_ = scanCmd.Flags().Set("forms", "false")
+				_ = scanCmd.Flags().Set("banner", "false")
+			})
+			rootCmd.SetArgs(append([]string{"scan", "-u", "http://127.0.0.1:1/"}, tt.args...))
+			err := rootCmd.Execute()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestScanCommand_RiskOutOfRange(t *testing.T) {
+	t.Cleanup(func() {
+		_ = rootCmd.PersistentFlags().Set("url", "")
+		_ = rootCmd.PersistentFlags().Set("risk", "1")
+	})
+	rootCmd.SetArgs([]string{"scan", "-u", "http://127.0.0.1:1/?id=1", "--risk", "4"})
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--risk must be between 1 and 3") {
+		t.Errorf("expected --risk range error, got %v", err)
+	}
+}
+
+func TestScanCommand_NegativeMaxRequests(t *testing.T) {
+	t.Cleanup(func() {
+		_ = rootCmd.PersistentFlags().Set("url", "")
+		_ = rootCmd.PersistentFlags().Set("max-requests", "0")
+	})
+	rootCmd.SetArgs([]string{"scan", "-u", "http://127.0.0.1:1/?id=1", "--max-requests", "-1"})
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--max-requests must not be negative") {
+		t.Errorf("expected --max-requests error, got %v", err)
+	}
+}
+
+func TestScanCommand_LogFormat(t *testing.T) {
+	t.Cleanup(func() {
+		_ = rootCmd.PersistentFlags().Set("url", "")
+		_ = scanCmd.Flags().Set("log-format", "text")
+	})
+	rootCmd.SetArgs([]string{"scan", "-u", "http://127.0.0.1:1/?id=1", "--log-format", "xml"})
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--log-format must be text or json") {
+		t.Errorf("expected --log-format error, got %v", err)
+	}
+}
+
+func TestScanCommand_MatchFlags(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--string", "Welcome", "--not-string", "Sorry"}, "mutually exclusive"},
+		{[]string{"--string", "Welcome", "--regexp", "Wel+come"}, "mutually exclusive"},
+		{[]string{"--regexp", "Wel(come"}, "invalid --regexp"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			t.Cleanup(func() {
+				_ = rootCmd.PersistentFlags().Set("url", "")
+				for _, name := range []string{"string", "not-string", "regexp"} {
+					_ = scanCmd.Flags().Set(name, "")
+				}
+			})
+			rootCmd.SetArgs(append([]string{"scan", "-u", "http://127.0.0.1:1/?id=1"}, tt.args...))
+			err := rootCmd.Execute()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
 func TestGlobalFlags_Defaults(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -111,6 +276,30 @@ func TestGlobalFlags_Defaults(t *testing.T) {
 			},
 			expected: "",
 		},
+		{
+			name:     "host default is empty",
+			flagName: "host",
+			getVal: func() (interface{}, error) {
+				return rootCmd.PersistentFlags().GetString("host")
+			},
+			expected: "",
+		},
+		{
+			name:     "http1 default is false",
+			flagName: "http1",
+			getVal: func() (interface{}, error) {
+				return rootCmd.PersistentFlags().GetBool("http1")
+			},
+			expected: false,
+		},
+		{
+			name:     "http2 default is false",
+			flagName: "http2",
+			getVal: func() (interface{}, error) {
+				return rootCmd.PersistentFlags().GetBool("http2")
+			},
+			expected: false,
+		},
 		{
 			name:     "threads default is 10",
 			flagName: "threads",
@@ -127,6 +316,94 @@ func TestGlobalFlags_Defaults(t *testing.T) {
 			},
 			expected: 30 * time.Second,
 		},
+		{
+			name:     "cookie-jar default is false",
+			flagName: "cookie-jar",
+			getVal: func() (interface{}, error) {
+				return rootCmd.PersistentFlags().GetBool("cookie-jar")
+			},
+			expected: false,
+		},
+		{
+			name:     "auth-type default is empty",
+			flagName: "auth-type",
+			getVal: func() (interface{}, error) {
+				return rootCmd.PersistentFlags().GetString("auth-type")
+			},
+			expected: "",
+		},
+		{
+			name:     "auth-cred default is empty",
+			flagName: "auth-cred",
+			getVal: func() (interface{}, error) {
+				return rootCmd.PersistentFlags().GetString("auth-cred")
+			},
+			expected: "",
+		},
+		{
+			name:     "client-cert default is empty",
+			flagName: "client-cert",
+			getVal: func() (interface{}, error) {
+				return rootCmd.PersistentFlags().GetString("client-cert")
+			},
+			expected: "",
+		},
+		{
+			name:     "client-key default is empty",
+			flagName: "client-key",
+			getVal: func() (interface{}, error) {
+				return rootCmd.PersistentFlags().GetString("client-key")
+			},
+			expected: "",
+		},
+		{
+			name:     "ca-cert default is empty",
+			flagName: "ca-cert",
+			getVal: func() (interface{}, error) {
+				return rootCmd.PersistentFlags().GetString("ca-cert")
+			},
+			expected: "",
+		},
+		{
+			name:     "max-response-bytes default is 5 MiB",
+			flagName: "max-response-bytes",
+			getVal: func() (interface{}, error) {
+				return rootCmd.PersistentFlags().GetInt64("max-response-bytes")
+			},
+			expected: int64(5 << 20),
+		},
+		{
+			name:     "delay default is 0",
+			flagName: "delay",
+			getVal: func() (interface{}, error) {
+				return rootCmd.PersistentFlags().GetDuration("delay")
+			},
+			expected: time.Duration(0),
+		},
+		{
+			name:     "jitter default is 0",
+			flagName: "jitter",
+			getVal: func() (interface{}, error) {
+				return rootCmd.PersistentFlags().GetDuration("jitter")
+			},
+			expected: time.Duration(0),
+		},
+		{
+			name:     "retries default is 2",
+			flagName: "retries",
+			getVal: func() (interface{}, error) {
+				return rootCmd.PersistentFlags().GetInt("retries")
+			},
+			expected: 2,
+		},
+		{
+			name:     "retry-backoff default is 500ms",
+			flagName: "retry-backoff",
+			getVal: func() (interface{}, error) {
+				return rootCmd.PersistentFlags().GetDuration("retry-backoff")
+			},
+			expected: 500 * time.Millisecond,
+		},
 		{
 			name:     "verbose default is 0",
 			flagName: "verbose",
@@ -167,6 +444,14 @@ func TestGlobalFlags_Defaults(t *testing.T) {
 			},
 			expected: "",
 		},
+		{
+			name:     "technique-timeout default is empty",
+			flagName: "technique-timeout",
+			getVal: func() (interface{}, error) {
+				return rootCmd.PersistentFlags().GetString("technique-timeout")
+			},
+			expected: "",
+		},
 		{
 			name:     "force-ssl default is false",
 			flagName: "force-ssl",
@@ -191,6 +476,14 @@ func TestGlobalFlags_Defaults(t *testing.T) {
 			},
 			expected: false,
 		},
+		{
+			name:     "unsafe-allow-writes default is false",
+			flagName: "unsafe-allow-writes",
+			getVal: func() (interface{}, error) {
+				return rootCmd.PersistentFlags().GetBool("unsafe-allow-writes")
+			},
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1,27 +1,48 @@
 package cli
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/0x6d61/sqleech/internal/crawler"
+	"github.com/0x6d61/sqleech/internal/dbms"
 	"github.com/0x6d61/sqleech/internal/detector"
 	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/enum"
 	"github.com/0x6d61/sqleech/internal/fingerprint"
+	"github.com/0x6d61/sqleech/internal/forms"
+	"github.com/0x6d61/sqleech/internal/graphql"
+	"github.com/0x6d61/sqleech/internal/jsonpath"
+	"github.com/0x6d61/sqleech/internal/marker"
+	"github.com/0x6d61/sqleech/internal/metrics"
+	"github.com/0x6d61/sqleech/internal/rawrequest"
 	"github.com/0x6d61/sqleech/internal/report"
 	"github.com/0x6d61/sqleech/internal/session"
 	"github.com/0x6d61/sqleech/internal/technique"
 	"github.com/0x6d61/sqleech/internal/tamper"
 	"github.com/0x6d61/sqleech/internal/technique/boolean"
+	"github.com/0x6d61/sqleech/internal/technique/crossparam"
 	"github.com/0x6d61/sqleech/internal/technique/errorbased"
 	"github.com/0x6d61/sqleech/internal/technique/timebased"
 	"github.com/0x6d61/sqleech/internal/technique/union"
 	"github.com/0x6d61/sqleech/internal/transport"
+	"github.com/0x6d61/sqleech/internal/xmlbody"
 )
 
 var scanCmd = &cobra.Command{
@@ -37,27 +58,144 @@ func init() {
 	// Session flag is scan-specific (not shared with other commands)
 	scanCmd.Flags().String("session", "", "Session file path for saving/resuming scans (SQLite)")
 	scanCmd.Flags().StringSlice("tamper", nil, "Comma-separated tamper scripts for WAF bypass (space2comment,uppercase,charencode,between)")
+	scanCmd.Flags().String("template-file", "", "Go template file for --format template (.html.tmpl enables HTML escaping)")
+	scanCmd.Flags().Bool("template-check", false, "Validate --template-file against a sample result and exit without scanning")
+	scanCmd.Flags().Bool("cross-param", false, "Try payloads split across pairs of live-but-unconfirmed parameters (needs --risk 3)")
+	scanCmd.Flags().Duration("job-timeout", 0, "Give up a technique on a parameter after this long and report it (0 = 10m, 30m for time-based; negative = no limit)")
+	scanCmd.Flags().Bool("stop-at-first", false, "Stop testing a parameter once a technique finds it injectable")
+	scanCmd.Flags().String("log-format", "text", "Format of the scanner's log on stderr, text or json; -v sets how much is logged")
+	scanCmd.Flags().String("events-file", "", "Write the scan events (parameters tested, technique runs, findings, errors) to this file as JSON lines")
+	scanCmd.Flags().Bool("test-cookies", false, "Also test the values of the --cookie cookies as injection points")
+	scanCmd.Flags().Int("test-path-segments", 0, "Also test ID-like URL path segments as injection points: 1 integers (/api/users/123), 2 integers and UUIDs")
+	scanCmd.Flags().Lookup("test-path-segments").NoOptDefVal = "1"
+	scanCmd.Flags().StringSlice("base64-params", nil, "Base64-decode these parameters before injecting and re-encode each payload; alone, every parameter whose value looks base64-encoded")
+	scanCmd.Flags().Lookup("base64-params").NoOptDefVal = "auto"
+	scanCmd.Flags().Bool("graphql", false, "Test the variables of a JSON GraphQL body even when it has no query string (persisted queries)")
+	scanCmd.Flags().Bool("xml-raw", false, "Splice payloads into XML body elements without escaping &, < and >")
+	scanCmd.Flags().StringArray("param", nil, "Test only the parameters whose name matches this glob, e.g. id or *_id (repeatable)")
+	scanCmd.Flags().StringArray("skip", nil, "Do not test the parameters whose name matches this glob, even if --param matches it (repeatable)")
+	scanCmd.Flags().StringArray("allow-param", nil, "Probe this parameter even if it looks state-changing (repeatable)")
+	scanCmd.Flags().StringArray("risky-param", nil, "Treat parameters whose name contains this word as state-changing, in addition to the built-in action verbs (repeatable)")
+	scanCmd.Flags().Bool("allow-risky-params", false, "Probe every parameter that looks state-changing (requires --batch)")
+	scanCmd.Flags().Bool("batch", false, "Run unattended, confirming opt-ins such as --allow-risky-params")
+	scanCmd.Flags().IntSlice("valid-status", nil, "Status codes of the application's real pages, e.g. 200,403 for a target that serves them behind 403 (default 2xx and 3xx)")
+	scanCmd.Flags().Int("time-sec", 0, "Time-based: sleep to inject in seconds (default 5); raised when latency jitter requires it")
+	scanCmd.Flags().Int("time-sec-max", 15, "Time-based: longest sleep the latency gate may raise to before disabling time-based")
+	scanCmd.Flags().Float64("time-jitter-factor", 6, "Time-based: standard deviations of latency jitter the sleep must exceed")
+	scanCmd.Flags().Bool("force-time-based", false, "Time-based: run with the configured sleep even when latency jitter makes it unreliable")
+	scanCmd.Flags().Int("http10-threads", 1, "Maximum threads for targets that speak HTTP/1.0 or close every connection (0 = --threads)")
+	scanCmd.Flags().Bool("time-total", false, "Time-based: compare total response times instead of times to first byte, for targets that send headers before running the query")
+	scanCmd.Flags().Bool("triage", true, "Re-check borderline findings (confidence 50-80%) with repeat, negative and placebo control probes")
+	scanCmd.Flags().Int("triage-rounds", engine.DefaultTriageRounds, "Triage: times to repeat a borderline finding's decisive probes")
+	scanCmd.Flags().String("metrics-listen", "", "Serve Prometheus metrics on this address (e.g. 127.0.0.1:9464) at /metrics while the scan runs")
+	scanCmd.Flags().String("metrics-token", "", "Bearer token required to scrape --metrics-listen")
+	scanCmd.Flags().String("string", "", "Boolean-blind: a page is TRUE when it contains this string, instead of when it resembles the baseline")
+	scanCmd.Flags().String("not-string", "", "Boolean-blind: a page is TRUE when it does not contain this string")
+	scanCmd.Flags().String("regexp", "", "Boolean-blind: a page is TRUE when it matches this regular expression")
+	scanCmd.Flags().Bool("banner", false, "Read the DBMS banner, current user, current database and hostname through a finding")
+	scanCmd.Flags().Bool("is-dba", false, "Check through a finding whether the DBMS user has DBA privileges")
+	scanCmd.Flags().String("sql-query", "", "Run this SQL query (an expression or a single-column SELECT) through a finding and report its rows")
+	scanCmd.Flags().StringArray("file-read", nil, "Read this file from the DBMS server through a finding (MySQL LOAD_FILE, PostgreSQL pg_read_binary_file; risk 2, repeatable)")
+	scanCmd.Flags().String("output-dir", ".", "Directory to save the files --file-read reads")
+	scanCmd.Flags().String("csrf-token", "", "Parameter, header or cookie carrying an anti-CSRF token, refreshed before every request")
+	scanCmd.Flags().String("csrf-url", "", "Page to read the --csrf-token value from (default the target URL)")
+	scanCmd.Flags().String("login-url", "", "Login request to send again whenever a response matches --logout-regex, keeping the cookies it sets for every later request")
+	scanCmd.Flags().String("login-data", "", "Body of the --login-url request, which is then a POST")
+	scanCmd.Flags().String("logout-regex", "", "Regular expression matching the page served once the session has expired, e.g. the login form")
+	scanCmd.Flags().String("csrf-regex", "", "Regular expression extracting the --csrf-token value, from its first group (default a hidden input or meta tag of that name)")
+	scanCmd.Flags().String("url-file", "", "Scan every target listed in this file, one per line as URL [METHOD] [BODY], into one report")
+	scanCmd.Flags().Int("target-concurrency", 1, "Targets of --url-file or --crawl-depth to scan at once, each with --threads workers")
+	scanCmd.Flags().Int("crawl-depth", 0, "Crawl the site of --url up to this many clicks deep and scan every link with a query and every form found, into one report")
+	scanCmd.Flags().String("crawl-exclude", "", "Regular expression matching the URLs the crawl must neither follow nor scan, e.g. logout")
+	scanCmd.Flags().Bool("crawl-ignore-robots", false, "Crawl and scan the paths robots.txt disallows too")
+	scanCmd.Flags().Bool("forms", false, "Scan the forms of the --url page, filled with their default values, into one report; hidden fields are tested with --force-test only")
+	scanCmd.Flags().StringArray("nonce-header", nil, "Header generated fresh for every request, as NAME[:format] with format uuid (default), epoch-ms or random-hex-N (repeatable)")
 }
 
 // runScan is the main scan command handler. It wires up the full scanner
 // pipeline: transport → heuristics → fingerprinting → techniques → report.
 func runScan(cmd *cobra.Command, args []string) error {
+	templateFile, _ := cmd.Flags().GetString("template-file")
+	if check, _ := cmd.Flags().GetBool("template-check"); check {
+		return checkTemplate(templateFile)
+	}
+
 	fmt.Println("[!] Legal disclaimer: Usage of sqleech for attacking targets without prior mutual consent is illegal.")
 
 	// ------------------------------------------------------------------ //
 	// 1. Read flags
 	// ------------------------------------------------------------------ //
-	targetURL, _ := cmd.Flags().GetString("url")
-	if targetURL == "" {
+	urlFile, _ := cmd.Flags().GetString("url-file")
+	if !hasTarget(cmd) && urlFile == "" {
 		return fmt.Errorf("target URL is required (use --url or -u)")
 	}
-
-	method, _ := cmd.Flags().GetString("method")
-	data, _ := cmd.Flags().GetString("data")
-	cookieStr, _ := cmd.Flags().GetString("cookie")
-	rawHeaders, _ := cmd.Flags().GetStringArray("header")
+	if urlFile != "" && hasTarget(cmd) {
+		return fmt.Errorf("--url-file is mutually exclusive with --url and --request-file")
+	}
+	crawlDepth, _ := cmd.Flags().GetInt("crawl-depth")
+	crawlExclude, _ := cmd.Flags().GetString("crawl-exclude")
+	crawlIgnoreRobots, _ := cmd.Flags().GetBool("crawl-ignore-robots")
+	testForms, _ := cmd.Flags().GetBool("forms")
+	if crawlDepth < 0 {
+		return fmt.Errorf("--crawl-depth must not be negative, got %d", crawlDepth)
+	}
+	if (crawlDepth > 0 || testForms) && urlFile != "" {
+		return fmt.Errorf("--crawl-depth and --forms are mutually exclusive with --url-file")
+	}
+	if crawlDepth == 0 && (crawlExclude != "" || crawlIgnoreRobots) {
+		return fmt.Errorf("--crawl-exclude and --crawl-ignore-robots need --crawl-depth")
+	}
+	var crawlExcludeRegexp *regexp.Regexp
+	if crawlExclude != "" {
+		re, err := regexp.Compile(crawlExclude)
+		if err != nil {
+			return fmt.Errorf("invalid --crawl-exclude: %w", err)
+		}
+		crawlExcludeRegexp = re
+	}
+	// The scans of --url-file, --crawl-depth and --forms are merged into
+	// one report. A crawl scans the forms it finds anyway.
+	var batchFlag string
+	switch {
+	case urlFile != "":
+		batchFlag = "--url-file"
+	case crawlDepth > 0:
+		batchFlag = "--crawl-depth"
+	case testForms:
+		batchFlag = "--forms"
+	}
+	request, err := requestTarget(cmd)
+	if err != nil {
+		return err
+	}
+	// Every target of --url-file is request with the URL, method and body
+	// of its line.
+	requests := []*engine.ScanTarget{request}
+	if urlFile != "" {
+		if requests, err = readURLFile(urlFile, request); err != nil {
+			return err
+		}
+	}
+	targetURL, method := request.URL, request.Method
 	proxyURL, _ := cmd.Flags().GetString("proxy")
+	hostOverride, _ := cmd.Flags().GetString("host")
+	resolveSpecs, _ := cmd.Flags().GetStringArray("resolve")
+	forceHTTP1, _ := cmd.Flags().GetBool("http1")
+	forceHTTP2, _ := cmd.Flags().GetBool("http2")
+	clientCert, _ := cmd.Flags().GetString("client-cert")
+	clientKey, _ := cmd.Flags().GetString("client-key")
+	caCert, _ := cmd.Flags().GetString("ca-cert")
+	authType, _ := cmd.Flags().GetString("auth-type")
+	authCred, _ := cmd.Flags().GetString("auth-cred")
+	cookieJar, _ := cmd.Flags().GetBool("cookie-jar")
+	trafficFile, _ := cmd.Flags().GetString("traffic-file")
+	redact, _ := cmd.Flags().GetBool("redact")
+	maxResponse, _ := cmd.Flags().GetInt64("max-response-bytes")
 	timeout, _ := cmd.Flags().GetDuration("timeout")
+	delay, _ := cmd.Flags().GetDuration("delay")
+	jitter, _ := cmd.Flags().GetDuration("jitter")
+	retries, _ := cmd.Flags().GetInt("retries")
+	retryBackoff, _ := cmd.Flags().GetDuration("retry-backoff")
 	forceSSL, _ := cmd.Flags().GetBool("force-ssl")
 	randomAgent, _ := cmd.Flags().GetBool("random-agent")
 	verbose, _ := cmd.Flags().GetInt("verbose")
@@ -65,42 +203,266 @@ func runScan(cmd *cobra.Command, args []string) error {
 	format, _ := cmd.Flags().GetString("format")
 	dbmsHint, _ := cmd.Flags().GetString("dbms")
 	techniqueStr, _ := cmd.Flags().GetString("technique")
+	techniqueTimeoutStr, _ := cmd.Flags().GetString("technique-timeout")
 	forceTest, _ := cmd.Flags().GetBool("force-test")
 	threads, _ := cmd.Flags().GetInt("threads")
+	risk, _ := cmd.Flags().GetInt("risk")
+	level, _ := cmd.Flags().GetInt("level")
+	maxRequests, _ := cmd.Flags().GetInt64("max-requests")
 	sessionPath, _ := cmd.Flags().GetString("session")
 	tamperNames, _ := cmd.Flags().GetStringSlice("tamper")
+	crossParam, _ := cmd.Flags().GetBool("cross-param")
+	stopAtFirst, _ := cmd.Flags().GetBool("stop-at-first")
+	jobTimeout, _ := cmd.Flags().GetDuration("job-timeout")
+	eventsPath, _ := cmd.Flags().GetString("events-file")
+	logFormat, _ := cmd.Flags().GetString("log-format")
+	allowWrites, _ := cmd.Flags().GetBool("unsafe-allow-writes")
+	nonceSpecs, _ := cmd.Flags().GetStringArray("nonce-header")
+	csrfToken, _ := cmd.Flags().GetString("csrf-token")
+	csrfURL, _ := cmd.Flags().GetString("csrf-url")
+	csrfPattern, _ := cmd.Flags().GetString("csrf-regex")
+	loginURL, _ := cmd.Flags().GetString("login-url")
+	loginData, _ := cmd.Flags().GetString("login-data")
+	logoutPattern, _ := cmd.Flags().GetString("logout-regex")
+	paramInclude, _ := cmd.Flags().GetStringArray("param")
+	paramExclude, _ := cmd.Flags().GetStringArray("skip")
+	allowParams, _ := cmd.Flags().GetStringArray("allow-param")
+	riskyParams, _ := cmd.Flags().GetStringArray("risky-param")
+	allowRisky, _ := cmd.Flags().GetBool("allow-risky-params")
+	batch, _ := cmd.Flags().GetBool("batch")
+	timeTotal, _ := cmd.Flags().GetBool("time-total")
+	validStatus, _ := cmd.Flags().GetIntSlice("valid-status")
+	timeSec, _ := cmd.Flags().GetInt("time-sec")
+	timeSecMax, _ := cmd.Flags().GetInt("time-sec-max")
+	jitterFactor, _ := cmd.Flags().GetFloat64("time-jitter-factor")
+	forceTimeBased, _ := cmd.Flags().GetBool("force-time-based")
+	http10Threads, _ := cmd.Flags().GetInt("http10-threads")
+	triage, _ := cmd.Flags().GetBool("triage")
+	triageRounds, _ := cmd.Flags().GetInt("triage-rounds")
+	metricsListen, _ := cmd.Flags().GetString("metrics-listen")
+	metricsToken, _ := cmd.Flags().GetString("metrics-token")
+	matchString, _ := cmd.Flags().GetString("string")
+	notMatchString, _ := cmd.Flags().GetString("not-string")
+	matchPattern, _ := cmd.Flags().GetString("regexp")
+	banner, _ := cmd.Flags().GetBool("banner")
+	isDBA, _ := cmd.Flags().GetBool("is-dba")
+	sqlQuery, _ := cmd.Flags().GetString("sql-query")
+	fileReads, _ := cmd.Flags().GetStringArray("file-read")
+	testCookies, _ := cmd.Flags().GetBool("test-cookies")
+	xmlRaw, _ := cmd.Flags().GetBool("xml-raw")
+	pathSegments, _ := cmd.Flags().GetInt("test-path-segments")
+	forceGraphQL, _ := cmd.Flags().GetBool("graphql")
+	base64Params, _ := cmd.Flags().GetStringSlice("base64-params")
+	outputDir, _ := cmd.Flags().GetString("output-dir")
+	targetConcurrency, _ := cmd.Flags().GetInt("target-concurrency")
+
+	if risk < 1 || risk > 3 {
+		return fmt.Errorf("--risk must be between 1 and 3, got %d", risk)
+	}
+	if level < 1 || level > 5 {
+		return fmt.Errorf("--level must be between 1 and 5, got %d", level)
+	}
+	if maxRequests < 0 {
+		return fmt.Errorf("--max-requests must not be negative")
+	}
+	if delay < 0 || jitter < 0 {
+		return fmt.Errorf("--delay and --jitter must not be negative")
+	}
+	if retries < 0 || retryBackoff < 0 {
+		return fmt.Errorf("--retries and --retry-backoff must not be negative")
+	}
+	logger, err := newLogger(os.Stderr, verbose, logFormat)
+	if err != nil {
+		return err
+	}
+	techniqueTimeouts, err := parseTechniqueTimeouts(techniqueTimeoutStr)
+	if err != nil {
+		return err
+	}
+	resolve, err := parseResolve(resolveSpecs)
+	if err != nil {
+		return err
+	}
+	if forceHTTP1 && forceHTTP2 {
+		return fmt.Errorf("--http1 and --http2 are mutually exclusive")
+	}
+	if batchFlag != "" {
+		// These read from or save one target.
+		for f, set := range map[string]bool{
+			"session":   sessionPath != "",
+			"banner":    banner,
+			"is-dba":    isDBA,
+			"sql-query": sqlQuery != "",
+			"file-read": len(fileReads) > 0,
+		} {
+			if set {
+				return fmt.Errorf("--%s cannot be used with %s", f, batchFlag)
+			}
+		}
+		if csrfToken != "" && csrfURL == "" {
+			return fmt.Errorf("--csrf-token with %s needs --csrf-url", batchFlag)
+		}
+		if targetConcurrency < 1 {
+			return fmt.Errorf("--target-concurrency must be at least 1, got %d", targetConcurrency)
+		}
+	}
+	if sqlQuery != "" {
+		if err := checkUserQuery(sqlQuery, risk, allowWrites); err != nil {
+			return fmt.Errorf("--sql-query: %w", err)
+		}
+	}
+	if crossParam && risk < 3 {
+		return fmt.Errorf("--cross-param sends payloads split across two parameters; it needs --risk 3")
+	}
+	if len(fileReads) > 0 && risk < 2 {
+		return fmt.Errorf("--file-read reads files off the DBMS server; it needs --risk 2 or higher")
+	}
+	if testCookies && len(request.Cookies) == 0 {
+		return fmt.Errorf("--test-cookies tests the cookies given with --cookie or --request-file; there are none")
+	}
+	if pathSegments < 0 || pathSegments > detector.PathSegmentsUUID {
+		return fmt.Errorf("--test-path-segments must be between 0 and %d, got %d", detector.PathSegmentsUUID, pathSegments)
+	}
+	for _, r := range requests {
+		if forceGraphQL && len(detector.ParseGraphQLVariables(r.Body)) == 0 {
+			if urlFile != "" {
+				return fmt.Errorf("--graphql: the body of %s has no variables to test", r.URL)
+			}
+			return fmt.Errorf("--graphql: the body has no variables to test")
+		}
+	}
+	if allowRisky && !batch {
+		return fmt.Errorf("--allow-risky-params probes parameters that may change server-side state; confirm it with --batch")
+	}
+	oracles := 0
+	for _, s := range []string{matchString, notMatchString, matchPattern} {
+		if s != "" {
+			oracles++
+		}
+	}
+	if (csrfURL != "" || csrfPattern != "") && csrfToken == "" {
+		return fmt.Errorf("--csrf-url and --csrf-regex need --csrf-token")
+	}
+	var csrfRegexp *regexp.Regexp
+	if csrfPattern != "" {
+		re, err := regexp.Compile(csrfPattern)
+		if err != nil {
+			return fmt.Errorf("invalid --csrf-regex: %w", err)
+		}
+		csrfRegexp = re
+	}
+	if (loginURL == "") != (logoutPattern == "") || (loginData != "" && loginURL == "") {
+		return fmt.Errorf("--login-url and --logout-regex go together, and --login-data needs them")
+	}
+	var logoutRegexp *regexp.Regexp
+	if logoutPattern != "" {
+		re, err := regexp.Compile(logoutPattern)
+		if err != nil {
+			return fmt.Errorf("invalid --logout-regex: %w", err)
+		}
+		logoutRegexp = re
+	}
+	if oracles > 1 {
+		return fmt.Errorf("--string, --not-string and --regexp are mutually exclusive")
+	}
+	var matchRegexp *regexp.Regexp
+	if matchPattern != "" {
+		re, err := regexp.Compile(matchPattern)
+		if err != nil {
+			return fmt.Errorf("invalid --regexp: %w", err)
+		}
+		matchRegexp = re
+	}
+	filter, err := extractFilter(cmd)
+	if err != nil {
+		return err
+	}
 
 	// ------------------------------------------------------------------ //
 	// 2. Normalize URL and method
 	// ------------------------------------------------------------------ //
 	if forceSSL {
-		targetURL = strings.Replace(targetURL, "http://", "https://", 1)
-		if !strings.HasPrefix(targetURL, "https://") {
-			targetURL = "https://" + targetURL
+		for _, r := range requests {
+			r.URL = strings.Replace(r.URL, "http://", "https://", 1)
+			if !strings.HasPrefix(r.URL, "https://") {
+				r.URL = "https://" + r.URL
+			}
 		}
+		targetURL = request.URL
 	}
-	if data != "" && method == "GET" {
-		method = "POST"
-	}
+	headers, cookies := request.Headers, request.Cookies
 
-	headers := parseHeaders(rawHeaders)
-	cookies := parseCookieString(cookieStr)
+	var nonceHeaders []transport.NonceHeader
+	for _, spec := range nonceSpecs {
+		h, err := transport.ParseNonceHeader(spec)
+		if err != nil {
+			return err
+		}
+		nonceHeaders = append(nonceHeaders, h)
+	}
 
 	// ------------------------------------------------------------------ //
 	// 3. Transport client
 	// ------------------------------------------------------------------ //
+	// Identical pages share one body for the length of the scan.
+	bodies := transport.NewBodyStore()
 	baseClient, err := transport.NewClient(transport.ClientOptions{
-		Timeout:         timeout,
-		ProxyURL:        proxyURL,
-		FollowRedirects: true,
-		RandomUserAgent: randomAgent,
+		Timeout:          timeout,
+		Delay:            delay,
+		Jitter:           jitter,
+		Retries:          retries,
+		RetryBackoff:     retryBackoff,
+		ProxyURL:         proxyURL,
+		HostOverride:     hostOverride,
+		Resolve:          resolve,
+		ForceHTTP1:       forceHTTP1,
+		ForceHTTP2:       forceHTTP2,
+		ClientCertFile:   clientCert,
+		ClientKeyFile:    clientKey,
+		CACertFile:       caCert,
+		AuthType:         authType,
+		AuthCredentials:  authCred,
+		UseCookieJar:     cookieJar,
+		TrafficLogPath:   trafficFile,
+		RedactTraffic:    redact,
+		MaxResponseBytes: maxResponse,
+		FollowRedirects:  true,
+		RandomUserAgent:  randomAgent,
+		Threads:          threads,
+		NonceHeaders:     nonceHeaders,
+		BodyStore:        bodies,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create HTTP client: %w", err)
 	}
+	defer baseClient.Close()
 
-	// Apply tamper scripts if specified; WrapClient returns transport.Client.
+	// Expired sessions are renewed beneath everything else, so the token
+	// page of --csrf-token is fetched logged in too.
 	var client transport.Client = baseClient
+	if loginURL != "" {
+		client = transport.NewSessionKeeper(client, transport.LoginOptions{URL: loginURL, Body: loginData, LoggedOut: logoutRegexp})
+		if verbose > 0 {
+			fmt.Printf("[*] Logging in again at %s when a response matches %q\n", loginURL, logoutPattern)
+		}
+	}
+	// The crawl and --forms fetch pages logged in, but neither with CSRF
+	// tokens nor through the tamper scripts.
+	pageClient := client
+
+	// Fresh CSRF tokens go in beneath the tamper scripts, which would
+	// mangle them, and are fetched without them.
+	if csrfToken != "" {
+		if csrfURL == "" {
+			csrfURL = unmarkedURL(targetURL)
+		}
+		client = transport.NewCSRFClient(client, transport.CSRFOptions{Name: csrfToken, URL: csrfURL, Pattern: csrfRegexp})
+		if verbose > 0 {
+			fmt.Printf("[*] CSRF token %s from %s\n", csrfToken, csrfURL)
+		}
+	}
+
+	// Apply tamper scripts if specified; WrapClient returns transport.Client.
 	if len(tamperNames) > 0 {
 		chain := tamper.BuildChain(tamperNames...)
 		if len(chain) > 0 {
@@ -121,18 +483,48 @@ func runScan(cmd *cobra.Command, args []string) error {
 	cfg := engine.DefaultScanConfig()
 	cfg.Threads = threads
 	cfg.Verbose = verbose
+	// Canonical names keep the hint matching the payload corpus; an
+	// unknown one is passed on for the techniques to report.
+	if d := dbms.Registry(dbmsHint); d != nil {
+		dbmsHint = d.Name()
+	}
 	cfg.DBMSHint = dbmsHint
 	cfg.ForceTest = forceTest
-	if techniqueStr != "" {
-		// Split on comma, normalise to upper-case.
-		// Accepted codes: E (error-based), B (boolean-blind), T (time-based), U (union-based)
-		for _, code := range strings.Split(techniqueStr, ",") {
-			code = strings.TrimSpace(strings.ToUpper(code))
-			if code != "" {
-				cfg.Techniques = append(cfg.Techniques, code)
-			}
-		}
+	cfg.CrossParam = crossParam
+	cfg.StopAtFirst = stopAtFirst
+	cfg.JobTimeout = jobTimeout
+	cfg.ReadOnly = !allowWrites
+	cfg.Risk = risk
+	cfg.Level = level
+	cfg.MaxRequests = maxRequests
+	cfg.TimeTotal = timeTotal
+	cfg.ValidStatusCodes = validStatus
+	cfg.TimeSec = timeSec
+	cfg.TimeSecMax = timeSecMax
+	cfg.TimeJitterFactor = jitterFactor
+	cfg.ForceTimeBased = forceTimeBased
+	cfg.HTTP10Threads = http10Threads
+	cfg.Triage = triage
+	cfg.TriageRounds = triageRounds
+	cfg.ParamInclude = paramInclude
+	cfg.ParamExclude = paramExclude
+	if csrfToken != "" {
+		// Probes in the token would be overwritten with a fresh one.
+		cfg.ParamExclude = append(cfg.ParamExclude, csrfToken)
+	}
+	cfg.AllowParams = allowParams
+	cfg.RiskyParamNames = riskyParams
+	cfg.AllowRiskyParams = allowRisky
+	cfg.MatchString = matchString
+	cfg.NotMatchString = notMatchString
+	cfg.MatchRegexp = matchRegexp
+	cfg.ExtractFilter = filter
+	if allowWrites {
+		fmt.Println("[!] Read-only guard disabled (--unsafe-allow-writes): payloads may modify the target's data.")
 	}
+	cfg.Techniques = parseTechniques(techniqueStr)
+	cfg.TechniqueTimeouts = techniqueTimeouts
+	cfg.TargetConcurrency = targetConcurrency
 
 	// ------------------------------------------------------------------ //
 	// 5. Context (CTRL+C cancels the scan gracefully)
@@ -161,14 +553,43 @@ func runScan(cmd *cobra.Command, args []string) error {
 	// ------------------------------------------------------------------ //
 	// 7. Build scanner
 	// ------------------------------------------------------------------ //
-	scanner := buildScanner(client, cfg)
+	// The scanner turns keep-alives off on the underlying client for
+	// targets that do not keep connections alive, notes the cookies its
+	// --cookie-jar held at baseline time in the evidence, and logs the
+	// failures it recovers from to stderr.
+	scanner := buildScanner(client, cfg, engine.WithKeepAliveSwitch(baseClient), engine.WithCookieSource(baseClient), engine.WithLogger(logger))
 
+	if eventsPath != "" {
+		f, err := os.Create(eventsPath)
+		if err != nil {
+			return fmt.Errorf("failed to create events file %q: %w", eventsPath, err)
+		}
+		defer f.Close()
+		subscribe, writeErr := eventWriter(f)
+		scanner.Subscribe(subscribe)
+		defer func() {
+			if err := writeErr(); err != nil {
+				fmt.Fprintf(os.Stderr, "[!] Failed to write events file: %v\n", err)
+			}
+		}()
+	}
 	if verbose > 0 {
-		scanner.SetProgressCallback(func(msg string) {
-			fmt.Printf("[*] %s\n", msg)
+		scanner.Subscribe(func(ev engine.Event) {
+			if line := renderEvent(ev, verbose); line != "" {
+				fmt.Println(line)
+			}
 		})
-		fmt.Printf("[*] Target: %s\n", targetURL)
-		fmt.Printf("[*] Method: %s\n", method)
+		switch {
+		case urlFile != "":
+			fmt.Printf("[*] Targets: %d from %s, %d at a time\n", len(requests), urlFile, targetConcurrency)
+		case crawlDepth > 0:
+			fmt.Printf("[*] Targets: crawled from %s, %d at a time\n", targetURL, targetConcurrency)
+		case testForms:
+			fmt.Printf("[*] Targets: the forms of %s, %d at a time\n", targetURL, targetConcurrency)
+		default:
+			fmt.Printf("[*] Target: %s\n", targetURL)
+			fmt.Printf("[*] Method: %s\n", method)
+		}
 		if len(cfg.Techniques) > 0 {
 			fmt.Printf("[*] Techniques: %s\n", strings.Join(cfg.Techniques, ","))
 		}
@@ -180,28 +601,141 @@ func runScan(cmd *cobra.Command, args []string) error {
 	// ------------------------------------------------------------------ //
 	// 8. Build ScanTarget
 	// ------------------------------------------------------------------ //
-	target := &engine.ScanTarget{
-		URL:     targetURL,
-		Method:  method,
-		Headers: headers,
-		Body:    data,
-		Cookies: cookies,
+	// Marker parsing strips the markers out of the headers and cookies it
+	// finds them in, so each target gets its own copies.
+	buildTarget := func(r *engine.ScanTarget) *engine.ScanTarget {
+		headers, cookies := maps.Clone(headers), maps.Clone(cookies)
+		target := &engine.ScanTarget{
+			URL:         r.URL,
+			Method:      r.Method,
+			Headers:     headers,
+			Body:        r.Body,
+			Cookies:     cookies,
+			ContentType: bodyContentType(headers, r.Body),
+			RawXML:      xmlRaw,
+			// Form targets leave their hidden fields out.
+			Parameters: slices.Clone(r.Parameters),
+		}
+		if forceGraphQL {
+			if _, ok := headers["Content-Type"]; !ok {
+				target.ContentType = "application/json"
+			}
+		}
+		if testCookies || pathSegments > 0 || forceGraphQL {
+			// Cookies, path segments and the variables of GraphQL bodies only
+			// --graphql identifies are not parsed from the request like the
+			// other parameters, so the target carries them all up front.
+			switch {
+			case len(target.Parameters) > 0:
+				// A form's, without its hidden fields.
+			case forceGraphQL:
+				target.Parameters = append(detector.ParseURLParameters(target.URL), detector.ParseGraphQLVariables(target.Body)...)
+			default:
+				target.Parameters = detector.ParseParameters(target.URL, target.Body, target.ContentType)
+			}
+			target.Parameters = append(target.Parameters, detector.ParsePathParameters(target.URL, pathSegments)...)
+			if testCookies {
+				target.Parameters = append(target.Parameters, detector.ParseCookies(cookies)...)
+			}
+		}
+		if parseMarkers(target) && verbose > 0 {
+			fmt.Printf("[*] Injection markers: %d\n", len(target.Parameters))
+		}
+		if len(base64Params) > 0 {
+			if len(target.Parameters) == 0 {
+				target.Parameters = detector.ParseParameters(target.URL, target.Body, target.ContentType)
+			}
+			target.Parameters = detector.DecodeBase64(target.Parameters, base64Params, slices.Contains(base64Params, "auto"))
+			for _, p := range target.Parameters {
+				if p.Base64 && verbose > 0 {
+					fmt.Printf("[*] Parameter %s is base64-encoded: %q\n", p.Name, p.Value)
+				}
+			}
+		}
+		return target
 	}
-	if data != "" {
-		if _, hasContentType := headers["Content-Type"]; !hasContentType {
-			target.ContentType = "application/x-www-form-urlencoded"
+	if crawlDepth > 0 {
+		// Every target found is request with the URL, method and body of
+		// the link or form.
+		fmt.Printf("[*] Crawling %s, %d clicks deep\n", targetURL, crawlDepth)
+		found, err := crawler.New(pageClient, crawler.Options{
+			Depth:        crawlDepth,
+			Exclude:      crawlExcludeRegexp,
+			IgnoreRobots: crawlIgnoreRobots,
+			TestHidden:   forceTest,
+			Headers:      headers,
+			Cookies:      cookies,
+		}).Crawl(ctx, targetURL)
+		if err != nil {
+			return err
+		}
+		if len(found) == 0 {
+			return fmt.Errorf("the crawl of %s found no links with a query or forms to test", targetURL)
 		}
+		fmt.Printf("[*] Crawl found %d targets\n", len(found))
+		requests = found
+	} else if testForms {
+		found, err := pageForms(ctx, pageClient, request, forceTest)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[*] Found %d forms to test on %s\n", len(found), targetURL)
+		requests = found
+	}
+	targets := make([]*engine.ScanTarget, len(requests))
+	for i, r := range requests {
+		targets[i] = buildTarget(r)
 	}
 
 	// ------------------------------------------------------------------ //
 	// 9. Run scan
 	// ------------------------------------------------------------------ //
+	var scanMetrics *metrics.ScanMetrics
+	if metricsListen != "" {
+		scanMetrics = metrics.New(metrics.Options{})
+		addr, stop, err := serveMetrics(metricsListen, scanMetrics, metricsToken)
+		if err != nil {
+			return err
+		}
+		defer stop()
+		fmt.Printf("[*] Serving metrics on http://%s/metrics\n", addr)
+	}
+
+	if batchFlag != "" {
+		return runBatch(ctx, scanner, targets, scanMetrics, format, templateFile, outputPath)
+	}
+	target := targets[0]
+
 	fmt.Printf("[*] Starting scan against: %s\n", targetURL)
 
-	result, err := scanner.Scan(ctx, target)
-	if err != nil {
+	collector := engine.NewMemoryCollector(target)
+	var sink engine.ResultCollector = collector
+	if scanMetrics != nil {
+		sink = engine.FanOut(collector, scanMetrics.Collector(target))
+	}
+	if err := scanner.ScanInto(ctx, target, sink); err != nil {
 		return fmt.Errorf("scan error: %w", err)
 	}
+	result := collector.Result()
+	if st := bodies.Stats(); verbose > 0 && st.Hits > 0 {
+		fmt.Printf("[*] Shared %d identical response bodies (%d KB not duplicated)\n", st.Hits, st.SavedBytes/1024)
+	}
+	if banner && result != nil {
+		readBanner(ctx, scanner, target, result)
+	}
+	if isDBA && result != nil {
+		checkDBA(ctx, scanner, target, result)
+	}
+	if sqlQuery != "" && result != nil {
+		runSQLQuery(ctx, scanner, target, result, sqlQuery, filter)
+	}
+	if len(fileReads) > 0 && result != nil {
+		readFiles(ctx, scanner, target, result, fileReads, outputDir)
+	}
+	// The budget may run out during the reads after the scan too.
+	if scanner.BudgetSpent() && result != nil {
+		result.Truncated, result.RequestBudget = true, maxRequests
+	}
 
 	// ------------------------------------------------------------------ //
 	// 10. Save to session
@@ -216,9 +750,9 @@ func runScan(cmd *cobra.Command, args []string) error {
 	// ------------------------------------------------------------------ //
 	// 11. Generate report
 	// ------------------------------------------------------------------ //
-	reporter, err := report.New(format)
+	reporter, err := newReporter(format, templateFile)
 	if err != nil {
-		return fmt.Errorf("unknown report format %q: %w", format, err)
+		return err
 	}
 
 	out := os.Stdout
@@ -238,29 +772,242 @@ func runScan(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// runBatch scans the targets of --url-file or --crawl-depth with scanner and writes one
+// report of them all, merged as by "convert --merge".
+func runBatch(ctx context.Context, scanner *engine.Scanner, targets []*engine.ScanTarget, scanMetrics *metrics.ScanMetrics, format, templateFile, outputPath string) error {
+	fmt.Printf("[*] Starting scan against %d targets\n", len(targets))
+
+	merged := engine.NewMergingCollector()
+	collect := merged.Target
+	if scanMetrics != nil {
+		collect = func(t *engine.ScanTarget) engine.ResultCollector {
+			return engine.FanOut(merged.Target(t), scanMetrics.Collector(t))
+		}
+	}
+	if err := scanner.ScanAllInto(ctx, targets, collect); err != nil {
+		return fmt.Errorf("scan error: %w", err)
+	}
+	summary := merged.Summary()
+	fmt.Printf("[*] %d of %d targets vulnerable, %d injectable findings\n",
+		summary.VulnerableTargets, summary.Targets, summary.TotalVulnerabilities)
+
+	reporter, err := newReporter(format, templateFile)
+	if err != nil {
+		return err
+	}
+	out := os.Stdout
+	if outputPath != "" {
+		f, err := os.Create(outputPath)
+		if err != nil {
+			return fmt.Errorf("failed to create output file %q: %w", outputPath, err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := reporter.Render(ctx, report.MergeResults(merged.Results()), out); err != nil {
+		return fmt.Errorf("failed to generate report: %w", err)
+	}
+	return nil
+}
+
+// extractTechniques orders techniques by the requests they spend per
+// extracted value, fewest first.
+var extractTechniques = []string{"union-based", "error-based", "boolean-blind", "time-based"}
+
+// findingEnumerator returns an Enumerator reading through the cheapest
+// finding of result that can extract, or nil when there is none. A finding
+// several techniques confirmed is read with the cheapest of them.
+func findingEnumerator(scanner *engine.Scanner, target *engine.ScanTarget, result *engine.ScanResult) *enum.Enumerator {
+	for _, name := range extractTechniques {
+		for _, v := range result.Vulnerabilities {
+			if !v.ConfirmedWith(name) || v.PairedParameter != nil {
+				continue
+			}
+			v.Technique = name
+			if v.DBMS == "" {
+				v.DBMS = result.DBMS
+			}
+			if en, err := enum.New(scanner, target, v); err == nil {
+				return en
+			}
+		}
+	}
+	return nil
+}
+
+// readBanner fills result's Banner, CurrentUser, CurrentDB and Hostname
+// through findingEnumerator. Values that cannot be read are left empty and
+// the failure added to result.Warnings; the requests spent are added to
+// result.RequestCount.
+func readBanner(ctx context.Context, scanner *engine.Scanner, target *engine.ScanTarget, result *engine.ScanResult) {
+	en := findingEnumerator(scanner, target, result)
+	if en == nil {
+		result.Warnings = append(result.Warnings, "--banner: no finding of a known DBMS to extract through")
+		return
+	}
+
+	for _, f := range []struct {
+		name string
+		read func(context.Context) (string, error)
+		dst  *string
+	}{
+		{"banner", en.Banner, &result.Banner},
+		{"current user", en.CurrentUser, &result.CurrentUser},
+		{"current database", en.CurrentDB, &result.CurrentDB},
+		{"hostname", en.Hostname, &result.Hostname},
+	} {
+		v, err := f.read(ctx)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("--banner: reading the %s: %v", f.name, err))
+			continue
+		}
+		*f.dst = v
+	}
+	result.RequestCount += int64(en.Requests())
+}
+
+// checkDBA sets result.DBA through findingEnumerator, or adds the failure
+// to result.Warnings.
+func checkDBA(ctx context.Context, scanner *engine.Scanner, target *engine.ScanTarget, result *engine.ScanResult) {
+	en := findingEnumerator(scanner, target, result)
+	if en == nil {
+		result.Warnings = append(result.Warnings, "--is-dba: no finding of a known DBMS to extract through")
+		return
+	}
+	dba, err := en.IsDBA(ctx)
+	result.RequestCount += int64(en.Requests())
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("--is-dba: %v", err))
+		return
+	}
+	result.DBA = dba
+}
+
+// runSQLQuery appends the rows of query, read through findingEnumerator,
+// to result.Extractions, or adds the failure to result.Warnings. Rows read
+// before a failure are kept. With filter, the scanner's ExtractFilter, the
+// raw rows and the filter's notes are kept alongside the filtered ones.
+func runSQLQuery(ctx context.Context, scanner *engine.Scanner, target *engine.ScanTarget, result *engine.ScanResult, query string, filter *jsonpath.Path) {
+	en := findingEnumerator(scanner, target, result)
+	if en == nil {
+		result.Warnings = append(result.Warnings, "--sql-query: no finding of a known DBMS to extract through")
+		return
+	}
+	rows, err := en.QueryResults(ctx, query)
+	result.RequestCount += int64(en.Requests())
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("--sql-query: %v", err))
+	}
+	if err == nil || len(rows) > 0 {
+		v := en.Finding()
+		x := engine.Extraction{
+			Query:     query,
+			Technique: v.Technique,
+			Parameter: v.Parameter.Name,
+		}
+		if filter != nil {
+			x.Filter = filter.String()
+		}
+		for _, r := range rows {
+			x.Values = append(x.Values, r.Display())
+			if filter != nil {
+				x.Raw = append(x.Raw, r.Value)
+				x.FilterNotes = append(x.FilterNotes, r.FilterNote)
+			}
+		}
+		result.Extractions = append(result.Extractions, x)
+	}
+}
+
+// readFiles reads each of paths off the DBMS server through
+// findingEnumerator and saves it in dir, named by fileReadName. Files that
+// cannot be read or saved are added to result.Warnings.
+func readFiles(ctx context.Context, scanner *engine.Scanner, target *engine.ScanTarget, result *engine.ScanResult, paths []string, dir string) {
+	en := findingEnumerator(scanner, target, result)
+	if en == nil {
+		result.Warnings = append(result.Warnings, "--file-read: no finding of a known DBMS to extract through")
+		return
+	}
+	defer func() { result.RequestCount += int64(en.Requests()) }()
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("--file-read: %v", err))
+		return
+	}
+	for _, path := range paths {
+		b, err := en.FileRead(ctx, path)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("--file-read %s: %v", path, err))
+			continue
+		}
+		local := filepath.Join(dir, fileReadName(path))
+		if err := os.WriteFile(local, b, 0o600); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("--file-read %s: %v", path, err))
+			continue
+		}
+		fmt.Printf("[+] Read %s (%d bytes) into %s\n", path, len(b), local)
+	}
+}
+
+// fileReadName returns the local file name for a file read off the
+// server: its path with separators replaced, so /etc/passwd is saved as
+// _etc_passwd and no path escapes --output-dir.
+func fileReadName(path string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(path)
+	if name == "" || name == "." || name == ".." {
+		name = "_"
+	}
+	return name
+}
+
 // --------------------------------------------------------------------------
 // Scanner wiring helpers
 // --------------------------------------------------------------------------
 
+// NewScanner returns the scanner the scan command runs, wired exactly as
+// buildScanner does. It lets the regression harness replay its corpus
+// through the shipped pipeline rather than a copy of it.
+func NewScanner(client transport.Client, cfg *engine.ScanConfig) *engine.Scanner {
+	return buildScanner(client, cfg)
+}
+
 // buildScanner creates an engine.Scanner wired with all real implementations:
 // error-based, boolean-blind, time-based, union-based techniques; the heuristic
-// detector; the DBMS fingerprinter; and the parameter parser.
-func buildScanner(client transport.Client, cfg *engine.ScanConfig) *engine.Scanner {
-	return engine.NewScanner(client, cfg,
+// detector; the DBMS fingerprinter; and the parameter parser. Boolean-blind
+// gets the timing fallback from cfg.Risk, and time-based measures total
+// times with cfg.TimeTotal, extracting at most cfg.Threads character
+// positions at once. The heuristic detector's diff engine and the
+// fingerprinter judge statuses by cfg.ValidStatusCodes. The client is
+// wrapped in an outage monitor so decisions made while the target was down
+// are re-run. With cfg.ReadOnly the heuristic detector's probes go through
+// the same read-only guard the scanner applies to its own. opts are applied
+// after the wiring above.
+func buildScanner(client transport.Client, cfg *engine.ScanConfig, opts ...engine.ScannerOption) *engine.Scanner {
+	monitor := transport.NewOutageMonitor(client, transport.OutageOptions{})
+	client = monitor
+	risk, timeTotal, threads := 1, false, 0
+	var validStatus []int
+	if cfg != nil {
+		risk, timeTotal, threads, validStatus = cfg.Risk, cfg.TimeTotal, cfg.Threads, cfg.ValidStatusCodes
+	}
+	return engine.NewScanner(client, cfg, append([]engine.ScannerOption{
 		engine.WithTechniques(
 			wrapTechnique(errorbased.New()),
-			wrapTechnique(boolean.New()),
-			wrapTechnique(timebased.New()),
+			wrapTechnique(boolean.NewWithRisk(risk)),
+			wrapTechnique(timebased.New().WithTotalTime(timeTotal).WithMaxConcurrentProbes(threads)),
 			wrapTechnique(union.New()),
 		),
 		engine.WithParameterParser(buildParamParser()),
-		engine.WithHeuristicDetector(buildHeuristicDetector(client)),
+		engine.WithHeuristicDetector(buildHeuristicDetector(client, cfg == nil || cfg.ReadOnly, validStatus)),
 		engine.WithDBMSIdentifier(buildDBMSIdentifier()),
-		engine.WithFingerprinter(buildFingerprinter()),
-	)
+		engine.WithFingerprinter(buildFingerprinter(validStatus)),
+		engine.WithCrossParamDetector(buildCrossParamDetector()),
+		engine.WithOutageMonitor(monitor),
+	}, opts...)...)
 }
 
-// techniqueAdapter bridges technique.Technique → engine.Technique.
+// techniqueAdapter bridges technique.Technique → engine.Technique and
+// engine.Extractor.
 type techniqueAdapter struct{ inner technique.Technique }
 
 func (a *techniqueAdapter) Name() string  { return a.inner.Name() }
@@ -272,6 +1019,19 @@ func (a *techniqueAdapter) Detect(ctx context.Context, req *engine.TechniqueRequ
 		Baseline:  req.Baseline,
 		DBMS:      req.DBMS,
 		Client:    req.Client,
+		Coverage:  req.Coverage,
+		Context:   req.Context,
+		State:     req.State,
+
+		SleepSeconds: req.SleepSeconds,
+		Timeout:      req.Timeout,
+		Level:        req.Level,
+		Risk:         req.Risk,
+
+		MatchString:    req.MatchString,
+		NotMatchString: req.NotMatchString,
+		MatchRegexp:    req.MatchRegexp,
+		Progress:       req.Progress,
 	})
 	if err != nil {
 		return nil, err
@@ -288,7 +1048,82 @@ func (a *techniqueAdapter) Detect(ctx context.Context, req *engine.TechniqueRequ
 	return dr, nil
 }
 
+func (a *techniqueAdapter) Extract(ctx context.Context, req *engine.ExtractionRequest) (*engine.ExtractionResult, error) {
+	r, err := technique.Extract(ctx, a.inner, &technique.ExtractionRequest{
+		InjectionRequest: technique.InjectionRequest{
+			Target:    req.Target,
+			Parameter: req.Parameter,
+			Baseline:  req.Baseline,
+			DBMS:      req.DBMS,
+			Client:    req.Client,
+			Coverage:  req.Coverage,
+			Context:   req.Context,
+			State:     req.State,
+
+			SleepSeconds: req.SleepSeconds,
+			Timeout:      req.Timeout,
+			Level:        req.Level,
+			Risk:         req.Risk,
+
+			MatchString:    req.MatchString,
+			NotMatchString: req.NotMatchString,
+			MatchRegexp:    req.MatchRegexp,
+			Progress:       req.Progress,
+		},
+		Query:  req.Query,
+		Filter: req.Filter,
+	})
+	if r == nil {
+		return nil, err
+	}
+	return &engine.ExtractionResult{
+		Value:    r.Value,
+		Partial:  r.Partial,
+		Requests: r.Requests,
+
+		Filtered:      r.Filtered,
+		FilterApplied: req.Filter != nil && r.FilterNote == "",
+		FilterNote:    r.FilterNote,
+	}, err
+}
+
+// controllerAdapter is a techniqueAdapter whose technique supports triage
+// controls, bridging technique.Controller → engine.Controller.
+type controllerAdapter struct {
+	techniqueAdapter
+	ctl technique.Controller
+}
+
+func (a *controllerAdapter) Control(ctx context.Context, req *engine.ControlRequest) (bool, error) {
+	return a.ctl.Control(ctx, &technique.ControlRequest{
+		InjectionRequest: technique.InjectionRequest{
+			Target:    req.Target,
+			Parameter: req.Parameter,
+			Baseline:  req.Baseline,
+			DBMS:      req.DBMS,
+			Client:    req.Client,
+			Coverage:  req.Coverage,
+			Context:   req.Context,
+
+			SleepSeconds: req.SleepSeconds,
+			Timeout:      req.Timeout,
+			Level:        req.Level,
+			Risk:         req.Risk,
+
+			MatchString:    req.MatchString,
+			NotMatchString: req.NotMatchString,
+			MatchRegexp:    req.MatchRegexp,
+			Progress:       req.Progress,
+		},
+		Finding: req.Finding,
+		Kind:    req.Kind,
+	})
+}
+
 func wrapTechnique(t technique.Technique) engine.Technique {
+	if ctl, ok := t.(technique.Controller); ok {
+		return &controllerAdapter{techniqueAdapter: techniqueAdapter{inner: t}, ctl: ctl}
+	}
 	return &techniqueAdapter{inner: t}
 }
 
@@ -298,10 +1133,15 @@ func buildParamParser() engine.ParameterParser {
 	}
 }
 
-func buildHeuristicDetector(client transport.Client) engine.HeuristicDetectorFunc {
+func buildHeuristicDetector(client transport.Client, readOnly bool, validStatus []int) engine.HeuristicDetectorFunc {
 	diffEng := detector.NewDiffEngine()
+	diffEng.ValidStatusCodes = validStatus
 	return func(ctx context.Context, target *engine.ScanTarget) ([]engine.HeuristicResult, error) {
-		hd := detector.NewHeuristicDetector(client, diffEng)
+		c := client
+		if readOnly {
+			c = engine.NewReadOnlyClient(client, target)
+		}
+		hd := detector.NewHeuristicDetector(c, diffEng)
 		results, err := hd.DetectAll(ctx, target)
 		if err != nil {
 			return nil, err
@@ -316,6 +1156,7 @@ func buildHeuristicDetector(client transport.Client) engine.HeuristicDetectorFun
 				ErrorSignatures: r.ErrorSignatures,
 				PageRatio:       r.PageRatio,
 				IsInjectable:    r.IsInjectable,
+				Context:         r.Context,
 			}
 		}
 		return out, nil
@@ -333,11 +1174,12 @@ func buildDBMSIdentifier() engine.DBMSIdentifierFunc {
 			Version:    info.Version,
 			Banner:     info.Banner,
 			Confidence: info.Confidence,
+			Tentative:  info.Tentative,
 		}
 	}
 }
 
-func buildFingerprinter() engine.FingerprintFunc {
+func buildFingerprinter(validStatus []int) engine.FingerprintFunc {
 	registry := fingerprint.NewRegistry()
 	return func(ctx context.Context, target *engine.ScanTarget, param *engine.Parameter, baseline *transport.Response, client transport.Client) (*engine.DBMSInfo, error) {
 		info, err := registry.Identify(ctx, &fingerprint.FingerprintRequest{
@@ -345,6 +1187,8 @@ func buildFingerprinter() engine.FingerprintFunc {
 			Parameter: param,
 			Baseline:  baseline,
 			Client:    client,
+
+			ValidStatusCodes: validStatus,
 		})
 		if err != nil {
 			return nil, err
@@ -361,6 +1205,98 @@ func buildFingerprinter() engine.FingerprintFunc {
 	}
 }
 
+func buildCrossParamDetector() engine.CrossParamDetectorFunc {
+	det := crossparam.New()
+	return func(ctx context.Context, target *engine.ScanTarget, params []engine.Parameter, dbmsName string, client transport.Client) ([]engine.Vulnerability, error) {
+		findings, err := det.Detect(ctx, &crossparam.Request{
+			Target:     target,
+			Parameters: params,
+			DBMS:       dbmsName,
+			Client:     client,
+		})
+		vulns := make([]engine.Vulnerability, len(findings))
+		for i, f := range findings {
+			second := f.Second
+			vulns[i] = engine.Vulnerability{
+				Parameter:       f.First,
+				Technique:       "split-" + f.Pattern,
+				DBMS:            dbmsName,
+				Payload:         f.FirstPayload,
+				Confidence:      f.Confidence,
+				Evidence:        f.Evidence,
+				Injectable:      true,
+				PairedParameter: &second,
+				PairedPayload:   f.SecondPayload,
+			}
+		}
+		return vulns, err
+	}
+}
+
+// --------------------------------------------------------------------------
+// Metrics helpers
+// --------------------------------------------------------------------------
+
+// serveMetrics serves m at /metrics on addr, requiring token as bearer
+// credentials when it is set. It returns the address listened on and a func
+// that shuts the server down.
+func serveMetrics(addr string, m *metrics.ScanMetrics, token string) (string, func(), error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to listen for metrics on %q: %w", addr, err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler(m.Registry(), metrics.HandlerOptions{Token: token}))
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = srv.Serve(ln) }()
+	return ln.Addr().String(), func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	}, nil
+}
+
+// --------------------------------------------------------------------------
+// Report helpers
+// --------------------------------------------------------------------------
+
+// newReporter creates the reporter for the --format flag. The template
+// format additionally needs --template-file.
+func newReporter(format, templateFile string) (report.Reporter, error) {
+	if strings.EqualFold(format, "template") {
+		if templateFile == "" {
+			return nil, fmt.Errorf("--format template requires --template-file")
+		}
+		r, err := report.NewTemplateReporter(templateFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %w", err)
+		}
+		return r, nil
+	}
+
+	r, err := report.New(format)
+	if err != nil {
+		return nil, fmt.Errorf("unknown report format %q: %w", format, err)
+	}
+	return r, nil
+}
+
+// checkTemplate parses templateFile and renders it against a sample result.
+func checkTemplate(templateFile string) error {
+	if templateFile == "" {
+		return fmt.Errorf("--template-check requires --template-file")
+	}
+	r, err := report.NewTemplateReporter(templateFile)
+	if err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	if err := r.Check(); err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	fmt.Printf("[+] Template %s is valid\n", templateFile)
+	return nil
+}
+
 // --------------------------------------------------------------------------
 // Session helpers
 // --------------------------------------------------------------------------
@@ -392,6 +1328,65 @@ func scanResultToState(result *engine.ScanResult) *session.ScanState {
 // Flag helpers (kept from original scan.go)
 // --------------------------------------------------------------------------
 
+// parseTechniques splits the --technique flag on commas into upper-case
+// codes: E (error-based), B (boolean-blind), T (time-based), U
+// (union-based).
+func parseTechniques(s string) []string {
+	var codes []string
+	for _, code := range strings.Split(s, ",") {
+		code = strings.TrimSpace(strings.ToUpper(code))
+		if code != "" {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// parseTechniqueTimeouts parses the --technique-timeout flag, comma-separated
+// CODE=DURATION pairs such as E=10s,T=30s with the codes of --technique.
+func parseTechniqueTimeouts(s string) (map[string]time.Duration, error) {
+	var timeouts map[string]time.Duration
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		code, value, ok := strings.Cut(pair, "=")
+		code = strings.TrimSpace(strings.ToUpper(code))
+		if !ok || len(code) != 1 || !strings.Contains("EBTU", code) {
+			return nil, fmt.Errorf("--technique-timeout: %q is not CODE=DURATION with CODE one of E, B, T and U", pair)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("--technique-timeout: %q is not a positive duration", value)
+		}
+		if timeouts == nil {
+			timeouts = make(map[string]time.Duration)
+		}
+		timeouts[code] = d
+	}
+	return timeouts, nil
+}
+
+// parseResolve parses the --resolve flags, HOST:IP each, into the
+// addresses to pin host names to. The IP may be an IPv6 address, in
+// brackets or not.
+func parseResolve(specs []string) (map[string]string, error) {
+	var resolve map[string]string
+	for _, spec := range specs {
+		host, ip, ok := strings.Cut(spec, ":")
+		host = strings.TrimSpace(host)
+		ip = strings.Trim(strings.TrimSpace(ip), "[]")
+		if !ok || host == "" || net.ParseIP(ip) == nil {
+			return nil, fmt.Errorf("--resolve: %q is not HOST:IP", spec)
+		}
+		if resolve == nil {
+			resolve = make(map[string]string)
+		}
+		resolve[host] = ip
+	}
+	return resolve, nil
+}
+
 // parseCookieString parses a cookie header string (e.g., "name1=val1; name2=val2")
 // into a map of name->value pairs.
 func parseCookieString(raw string) map[string]string {
@@ -413,6 +1408,141 @@ func parseCookieString(raw string) map[string]string {
 	return cookies
 }
 
+// hasTarget reports whether a target is given, with --url or
+// --request-file.
+func hasTarget(cmd *cobra.Command) bool {
+	targetURL, _ := cmd.Flags().GetString("url")
+	requestFile, _ := cmd.Flags().GetString("request-file")
+	return targetURL != "" || requestFile != ""
+}
+
+// requestTarget returns the request the target flags describe: --url,
+// --method, --data, --header and --cookie, or the raw request saved in
+// --request-file, which the other flags then amend. A request with a body
+// is sent as a POST unless --method or the file says otherwise.
+func requestTarget(cmd *cobra.Command) (*engine.ScanTarget, error) {
+	targetURL, _ := cmd.Flags().GetString("url")
+	method, _ := cmd.Flags().GetString("method")
+	data, _ := cmd.Flags().GetString("data")
+	cookieStr, _ := cmd.Flags().GetString("cookie")
+	rawHeaders, _ := cmd.Flags().GetStringArray("header")
+	requestFile, _ := cmd.Flags().GetString("request-file")
+	forceSSL, _ := cmd.Flags().GetBool("force-ssl")
+
+	target := &engine.ScanTarget{
+		URL:     targetURL,
+		Method:  strings.ToUpper(method),
+		Headers: parseHeaders(rawHeaders),
+		Body:    data,
+		Cookies: parseCookieString(cookieStr),
+	}
+	switch {
+	case requestFile != "" && targetURL != "":
+		return nil, fmt.Errorf("--url and --request-file are mutually exclusive")
+	case requestFile != "":
+		req, err := rawrequest.ParseFile(requestFile, forceSSL)
+		if err != nil {
+			return nil, fmt.Errorf("--request-file: %w", err)
+		}
+		target.URL = req.URL
+		if !cmd.Flags().Changed("method") {
+			target.Method = req.Method
+		}
+		if data == "" {
+			target.Body = req.Body
+		}
+		// The file's headers are canonically named; the flags' are named
+		// the same way so that they replace them rather than go out twice.
+		for k, v := range target.Headers {
+			req.Headers[textproto.CanonicalMIMEHeaderKey(k)] = v
+		}
+		maps.Copy(req.Cookies, target.Cookies)
+		target.Headers, target.Cookies = req.Headers, req.Cookies
+		return target, nil
+	}
+	if data != "" && target.Method == "GET" {
+		target.Method = "POST"
+	}
+	return target, nil
+}
+
+// readURLFile reads the targets of --url-file, one per line as URL
+// [METHOD] [BODY]: fields separated by spaces or tabs, the body running to
+// the end of the line. Blank lines and lines starting with # are skipped.
+// Each target is base with the URL, method and body of its line; a line
+// that leaves out the method or body takes that of base.
+func readURLFile(path string, base *engine.ScanTarget) ([]*engine.ScanTarget, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("--url-file: %w", err)
+	}
+	var targets []*engine.ScanTarget
+	for i, line := range strings.Split(string(raw), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		t := *base
+		var method, body string
+		t.URL, method = cutField(line)
+		method, body = cutField(method)
+		if method != "" {
+			if strings.Trim(method, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz") != "" {
+				return nil, fmt.Errorf("--url-file: line %d: %q is not an HTTP method", i+1, method)
+			}
+			t.Method = strings.ToUpper(method)
+		}
+		if body != "" {
+			t.Body = body
+		}
+		targets = append(targets, &t)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("--url-file: no targets in %s", path)
+	}
+	return targets, nil
+}
+
+// pageForms sends request and returns the targets of the forms on the page
+// it answers with, their hidden fields tested only when testHidden.
+func pageForms(ctx context.Context, client transport.Client, request *engine.ScanTarget, testHidden bool) ([]*engine.ScanTarget, error) {
+	resp, err := client.Do(ctx, &transport.Request{
+		Method:      request.Method,
+		URL:         unmarkedURL(request.URL),
+		Headers:     request.Headers,
+		Body:        request.Body,
+		Cookies:     request.Cookies,
+		ContentType: request.ContentType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("--forms: fetching the page: %w", err)
+	}
+	page := cmp.Or(resp.URL, unmarkedURL(request.URL))
+	base, err := url.Parse(page)
+	if err != nil {
+		return nil, fmt.Errorf("--forms: %w", err)
+	}
+	var targets []*engine.ScanTarget
+	for _, f := range forms.Parse(base, resp.Body) {
+		if t := f.Target(testHidden); t != nil {
+			targets = append(targets, t)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("--forms: no forms with fields to test on %s", page)
+	}
+	return targets, nil
+}
+
+// cutField returns the first field of s, up to a space or tab, and the
+// rest of s with surrounding blanks trimmed.
+func cutField(s string) (field, rest string) {
+	if i := strings.IndexAny(s, " \t"); i >= 0 {
+		return s[:i], strings.TrimSpace(s[i:])
+	}
+	return s, ""
+}
+
 // parseHeaders parses header strings (e.g., "X-Custom: value") into a map.
 func parseHeaders(rawHeaders []string) map[string]string {
 	headers := make(map[string]string)
@@ -424,3 +1554,56 @@ func parseHeaders(rawHeaders []string) map[string]string {
 	}
 	return headers
 }
+
+// bodyContentType returns the content type the parameter parser reads body
+// as. A body sent without a Content-Type header is form-encoded unless it
+// looks like XML or is a GraphQL request; one sent with a header is parsed
+// only when the header names a form or XML type, since the header itself
+// goes out as given.
+func bodyContentType(headers map[string]string, body string) string {
+	if body == "" {
+		return ""
+	}
+	ct, ok := headers["Content-Type"]
+	switch {
+	case ok && (xmlbody.IsXML(ct) || strings.HasPrefix(strings.ToLower(ct), "application/x-www-form-urlencoded")):
+		return ct
+	case ok:
+		return ""
+	case xmlbody.LooksLikeXML(body):
+		return "text/xml"
+	case graphql.IsRequest(body):
+		return "application/json"
+	}
+	return "application/x-www-form-urlencoded"
+}
+
+// unmarkedURL returns rawURL without the * injection markers in it.
+func unmarkedURL(rawURL string) string {
+	req := &transport.Request{}
+	marker.Parse(rawURL, "", "", nil, nil).Apply(req, nil)
+	return req.URL
+}
+
+// parseMarkers finds * injection markers in the URL, body, cookies and
+// headers of target and reports whether there are any. The target is left
+// with the markers removed; when there are any, it carries a parameter per
+// marker instead of the parameters parsing would find.
+func parseMarkers(target *engine.ScanTarget) bool {
+	contentType := target.ContentType
+	if contentType == "" {
+		contentType = target.Headers["Content-Type"]
+	}
+	m := marker.Parse(target.URL, target.Body, contentType, target.Cookies, target.Headers)
+	base := &transport.Request{Cookies: target.Cookies, Headers: target.Headers}
+	m.Apply(base, nil)
+	target.URL, target.Body = base.URL, base.Body
+
+	params := detector.ParseMarkers(m)
+	if len(params) == 0 {
+		return false
+	}
+	target.Markers = m
+	target.Parameters = params
+	return true
+}
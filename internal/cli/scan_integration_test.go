@@ -4,12 +4,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
+	"maps"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/metrics"
+	"github.com/0x6d61/sqleech/internal/report"
+	"github.com/0x6d61/sqleech/internal/testutil"
 	"github.com/0x6d61/sqleech/internal/transport"
 )
 
@@ -162,6 +170,108 @@ func TestScanPipeline_DetectsInjection(t *testing.T) {
 	}
 }
 
+func TestScanPipeline_ExtractThroughFinding(t *testing.T) {
+	srv := newMockScanServer()
+	defer srv.Close()
+
+	client, err := transport.NewClient(transport.ClientOptions{})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	cfg := engine.DefaultScanConfig()
+	cfg.Techniques = []string{"E"}
+	scanner := buildScanner(client, cfg)
+
+	target := &engine.ScanTarget{URL: srv.URL + "/vuln?id=1", Method: "GET"}
+	result, err := scanner.Scan(context.Background(), target)
+	if err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+
+	for _, v := range result.Vulnerabilities {
+		if !v.Injectable {
+			continue
+		}
+		res, err := scanner.Extract(context.Background(), target, v, "@@version")
+		if err != nil {
+			t.Fatalf("Extract error: %v", err)
+		}
+		if res.Value != "8.0.32" {
+			t.Errorf("Extract Value = %q, want %q", res.Value, "8.0.32")
+		}
+		return
+	}
+	t.Fatal("expected an injectable vulnerability")
+}
+
+func TestReadBanner(t *testing.T) {
+	srv := newMockScanServer()
+	defer srv.Close()
+
+	client, err := transport.NewClient(transport.ClientOptions{})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	cfg := engine.DefaultScanConfig()
+	cfg.Techniques = []string{"E"}
+	scanner := buildScanner(client, cfg)
+
+	target := &engine.ScanTarget{URL: srv.URL + "/vuln?id=1", Method: "GET"}
+	result, err := scanner.Scan(context.Background(), target)
+	if err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+	requests := result.RequestCount
+	readBanner(context.Background(), scanner, target, result)
+
+	// The mock answers every error-based extraction with its version.
+	for name, got := range map[string]string{
+		"Banner": result.Banner, "CurrentUser": result.CurrentUser,
+		"CurrentDB": result.CurrentDB, "Hostname": result.Hostname,
+	} {
+		if got != "8.0.32" {
+			t.Errorf("%s = %q, want %q", name, got, "8.0.32")
+		}
+	}
+	if result.RequestCount <= requests {
+		t.Errorf("RequestCount = %d, want the banner requests added to %d", result.RequestCount, requests)
+	}
+}
+
+func TestCheckDBA_NotBoolean(t *testing.T) {
+	srv := newMockScanServer()
+	defer srv.Close()
+
+	client, err := transport.NewClient(transport.ClientOptions{})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	cfg := engine.DefaultScanConfig()
+	cfg.Techniques = []string{"E"}
+	scanner := buildScanner(client, cfg)
+
+	target := &engine.ScanTarget{URL: srv.URL + "/vuln?id=1", Method: "GET"}
+	result, err := scanner.Scan(context.Background(), target)
+	if err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+	warnings := len(result.Warnings)
+	checkDBA(context.Background(), scanner, target, result)
+
+	// The mock answers the privilege query with its version, no boolean.
+	if result.DBA != nil || len(result.Warnings) != warnings+1 {
+		t.Errorf("DBA = %+v, Warnings = %q; want no answer and a warning", result.DBA, result.Warnings)
+	}
+}
+
+func TestReadBanner_NoFinding(t *testing.T) {
+	result := &engine.ScanResult{}
+	readBanner(context.Background(), buildScanner(nil, nil), &engine.ScanTarget{}, result)
+	if len(result.Warnings) != 1 || result.Banner != "" {
+		t.Errorf("Warnings = %q, Banner = %q; want one warning and no banner", result.Warnings, result.Banner)
+	}
+}
+
 func TestScanPipeline_SafeEndpoint(t *testing.T) {
 	srv := newMockScanServer()
 	defer srv.Close()
@@ -247,13 +357,7 @@ func TestTechniqueFlagParsing(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.input, func(t *testing.T) {
-			var techniques []string
-			for _, code := range strings.Split(tc.input, ",") {
-				code = strings.TrimSpace(strings.ToUpper(code))
-				if code != "" {
-					techniques = append(techniques, code)
-				}
-			}
+			techniques := parseTechniques(tc.input)
 			if len(techniques) != tc.wantLen {
 				t.Errorf("len: got %d, want %d", len(techniques), tc.wantLen)
 			}
@@ -264,6 +368,312 @@ func TestTechniqueFlagParsing(t *testing.T) {
 	}
 }
 
+func TestTechniqueTimeoutFlagParsing(t *testing.T) {
+	got, err := parseTechniqueTimeouts("e=10s, T=1m30s")
+	if err != nil {
+		t.Fatalf("parseTechniqueTimeouts: %v", err)
+	}
+	want := map[string]time.Duration{"E": 10 * time.Second, "T": 90 * time.Second}
+	if !maps.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, err := parseTechniqueTimeouts(""); got != nil || err != nil {
+		t.Errorf("empty flag = %v, %v; want nil, nil", got, err)
+	}
+	for _, bad := range []string{"E", "X=10s", "time-based=10s", "E=10", "T=-1s", "B=0s"} {
+		if _, err := parseTechniqueTimeouts(bad); err == nil {
+			t.Errorf("parseTechniqueTimeouts(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestResolveFlagParsing(t *testing.T) {
+	got, err := parseResolve([]string{"app.example.com:10.0.0.5", " api.example.com : [::1] ", "v6.example.com:fe80::1"})
+	if err != nil {
+		t.Fatalf("parseResolve: %v", err)
+	}
+	want := map[string]string{"app.example.com": "10.0.0.5", "api.example.com": "::1", "v6.example.com": "fe80::1"}
+	if !maps.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, err := parseResolve(nil); got != nil || err != nil {
+		t.Errorf("no flags = %v, %v; want nil, nil", got, err)
+	}
+	for _, bad := range []string{"app.example.com", "app.example.com:", ":10.0.0.5", "app.example.com:other.example.com", "app.example.com:10.0.0.5:443"} {
+		if _, err := parseResolve([]string{bad}); err == nil {
+			t.Errorf("parseResolve(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestReadURLFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.txt")
+	list := "# exported from the crawler\n" +
+		"http://a.test/item?id=1\n" +
+		"\n" +
+		"  http://a.test/login\tpost   username=admin&password=x y \r\n" +
+		"http://b.test/search GET\n"
+	if err := os.WriteFile(path, []byte(list), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	base := &engine.ScanTarget{Method: "GET", Headers: map[string]string{"X-Team": "red"}}
+
+	got, err := readURLFile(path, base)
+	if err != nil {
+		t.Fatalf("readURLFile: %v", err)
+	}
+	want := []engine.ScanTarget{
+		{URL: "http://a.test/item?id=1", Method: "GET"},
+		{URL: "http://a.test/login", Method: "POST", Body: "username=admin&password=x y"},
+		{URL: "http://b.test/search", Method: "GET"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d targets, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.URL != w.URL || g.Method != w.Method || g.Body != w.Body || g.Headers["X-Team"] != "red" {
+			t.Errorf("target %d = %s %s %q %v, want %s %s %q with the base headers", i, g.Method, g.URL, g.Body, g.Headers, w.Method, w.URL, w.Body)
+		}
+	}
+
+	for _, bad := range []string{"# nothing\n\n", "http://a.test/?id=1 id=1\n"} {
+		if err := os.WriteFile(path, []byte(bad), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := readURLFile(path, base); err == nil {
+			t.Errorf("readURLFile(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestScan_URLFile(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+
+	dir := t.TempDir()
+	list := filepath.Join(dir, "urls.txt")
+	out := filepath.Join(dir, "report.json")
+	lines := srv.URL + "/vuln/error-mysql?id=1\n" +
+		srv.URL + "/vuln/error-postgres?id=1 GET\n" +
+		srv.URL + "/vuln/post POST username=admin&password=secret\n"
+	if err := os.WriteFile(list, []byte(lines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	reset := func() {
+		for name, def := range map[string]string{"url": "", "request-file": "", "method": "GET", "data": "", "technique": "", "dbms": "", "format": "text", "output": ""} {
+			_ = rootCmd.PersistentFlags().Set(name, def)
+		}
+		_ = scanCmd.Flags().Set("url-file", "")
+		_ = scanCmd.Flags().Set("target-concurrency", "1")
+	}
+	reset()
+	t.Cleanup(reset)
+	rootCmd.SetArgs([]string{"scan", "--url-file", list, "--target-concurrency", "3", "-f", "json", "-o", out})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+
+	v, err := report.ReadJSONFile(out)
+	if err != nil {
+		t.Fatalf("ReadJSONFile: %v", err)
+	}
+	if len(v.Sources) != 3 {
+		t.Fatalf("report of %d targets, want 3: %+v", len(v.Sources), v.Sources)
+	}
+	dbms := map[string]string{"/vuln/error-mysql": "MySQL", "/vuln/error-postgres": "PostgreSQL", "/vuln/post": "MySQL"}
+	for _, src := range v.Sources {
+		path := strings.TrimPrefix(strings.SplitN(src.Target.URL, "?", 2)[0], srv.URL)
+		if src.DBMS.Name != dbms[path] {
+			t.Errorf("%s: DBMS = %q, want %q", path, src.DBMS.Name, dbms[path])
+		}
+	}
+	vulnerable := make(map[string]bool)
+	for _, vv := range v.Vulnerabilities {
+		vulnerable[vv.Target.Method+" "+vv.Target.URL] = true
+	}
+	for _, want := range []string{"GET " + srv.URL + "/vuln/error-mysql?id=1", "GET " + srv.URL + "/vuln/error-postgres?id=1", "POST " + srv.URL + "/vuln/post"} {
+		if !vulnerable[want] {
+			t.Errorf("no finding on %s", want)
+		}
+	}
+}
+
+func TestScan_EventsFile(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+
+	dir := t.TempDir()
+	events := filepath.Join(dir, "events.jsonl")
+	reset := func() {
+		for name, def := range map[string]string{"url": "", "request-file": "", "method": "GET", "data": "", "technique": "", "dbms": "", "format": "text", "output": ""} {
+			_ = rootCmd.PersistentFlags().Set(name, def)
+		}
+		_ = scanCmd.Flags().Set("events-file", "")
+	}
+	reset()
+	t.Cleanup(reset)
+	rootCmd.SetArgs([]string{"scan", "-u", srv.URL + "/vuln/error-mysql?id=1", "--technique", "E", "--events-file", events, "-f", "json", "-o", filepath.Join(dir, "report.json")})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+
+	data, err := os.ReadFile(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []string
+	var finding *jsonEvent
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var ev jsonEvent
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("event line %q: %v", line, err)
+		}
+		kinds = append(kinds, ev.Kind)
+		if ev.Kind == "finding" && ev.Injectable {
+			finding = &ev
+		}
+	}
+	if kinds[0] != "scan_start" || kinds[len(kinds)-1] != "scan_end" {
+		t.Errorf("events %q do not run from scan_start to scan_end", kinds)
+	}
+	if finding == nil {
+		t.Fatalf("no injectable finding among the events %q", kinds)
+	}
+	if finding.Parameter == nil || finding.Parameter.Name != "id" || finding.Technique != "error-based" || finding.Payload == "" {
+		t.Errorf("finding event = %+v, want error-based on id with its payload", finding)
+	}
+}
+
+func TestScan_LogsToStderr(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+
+	reset := func() {
+		for name, def := range map[string]string{"url": "", "request-file": "", "method": "GET", "data": "", "technique": "", "dbms": "", "format": "text", "output": "", "verbose": "0"} {
+			_ = rootCmd.PersistentFlags().Set(name, def)
+		}
+		_ = scanCmd.Flags().Set("log-format", "text")
+	}
+	reset()
+	t.Cleanup(reset)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+	captured := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(r)
+		captured <- b
+	}()
+
+	// The baseline of a missing page is a 404, so the scanner skips the
+	// target with a warning.
+	rootCmd.SetArgs([]string{"scan", "-u", srv.URL + "/missing?id=1", "-v", "3", "--log-format", "json", "-o", filepath.Join(t.TempDir(), "report.txt")})
+	execErr := rootCmd.Execute()
+	os.Stderr = stderr
+	w.Close()
+	out := <-captured
+	if execErr != nil {
+		t.Fatalf("scan: %v", execErr)
+	}
+
+	var warned bool
+	for _, line := range bytes.Split(bytes.TrimSpace(out), []byte("\n")) {
+		var rec struct {
+			Level string `json:"level"`
+			Msg   string `json:"msg"`
+			Err   string `json:"err"`
+		}
+		if json.Unmarshal(line, &rec) != nil {
+			continue
+		}
+		if rec.Level == "WARN" && rec.Msg == "skipping target" && strings.Contains(rec.Err, "status 404") {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Errorf("no skipping target warning logged to stderr:\n%s", out)
+	}
+}
+
+func TestScan_Crawl(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "report.json")
+	reset := func() {
+		for name, def := range map[string]string{"url": "", "request-file": "", "method": "GET", "data": "", "technique": "", "dbms": "", "format": "text", "output": ""} {
+			_ = rootCmd.PersistentFlags().Set(name, def)
+		}
+		_ = scanCmd.Flags().Set("crawl-depth", "0")
+		_ = scanCmd.Flags().Set("crawl-exclude", "")
+	}
+	reset()
+	t.Cleanup(reset)
+	rootCmd.SetArgs([]string{"scan", "-u", srv.URL + "/shop/", "--crawl-depth", "2", "--crawl-exclude", "logout", "-f", "json", "-o", out})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+
+	v, err := report.ReadJSONFile(out)
+	if err != nil {
+		t.Fatalf("ReadJSONFile: %v", err)
+	}
+	// The product page and the search form of the listing.
+	if len(v.Sources) != 2 {
+		t.Errorf("report of %d targets, want 2: %+v", len(v.Sources), v.Sources)
+	}
+	if len(v.Vulnerabilities) == 0 {
+		t.Fatal("no findings")
+	}
+	for _, vv := range v.Vulnerabilities {
+		if vv.Target.URL != srv.URL+"/shop/product?id=1" || vv.Parameter.Name != "id" {
+			t.Errorf("finding on %s parameter %s, want /shop/product parameter id", vv.Target.URL, vv.Parameter.Name)
+		}
+	}
+}
+
+func TestScan_Forms(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "report.json")
+	reset := func() {
+		for name, def := range map[string]string{"url": "", "request-file": "", "method": "GET", "data": "", "technique": "", "dbms": "", "format": "text", "output": ""} {
+			_ = rootCmd.PersistentFlags().Set(name, def)
+		}
+		_ = scanCmd.Flags().Set("forms", "false")
+	}
+	reset()
+	t.Cleanup(reset)
+	rootCmd.SetArgs([]string{"scan", "-u", srv.URL + "/vuln/login-form", "--forms", "-f", "json", "-o", out})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+
+	v, err := report.ReadJSONFile(out)
+	if err != nil {
+		t.Fatalf("ReadJSONFile: %v", err)
+	}
+	found := false
+	for _, vv := range v.Vulnerabilities {
+		if vv.Parameter.Name == "csrf" {
+			t.Errorf("the hidden csrf field was tested")
+		}
+		if vv.Target.Method == "POST" && vv.Target.URL == srv.URL+"/vuln/login" && vv.Parameter.Name == "username" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("no finding on the username field of the login form: %+v", v.Vulnerabilities)
+	}
+}
+
 // --------------------------------------------------------------------------
 // Report generation via buildScanner + text/JSON format
 // --------------------------------------------------------------------------
@@ -307,3 +717,105 @@ func import_report_pkg_via_cli_internal(t *testing.T, result *engine.ScanResult,
 		t.Error("expected non-empty JSON output")
 	}
 }
+
+// --------------------------------------------------------------------------
+// Template report format
+// --------------------------------------------------------------------------
+
+func TestNewReporter_Template(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "r.tmpl")
+	if err := os.WriteFile(path, []byte("{{.Target.URL}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := newReporter("template", path)
+	if err != nil {
+		t.Fatalf("newReporter: %v", err)
+	}
+	if r.Format() != "template" {
+		t.Errorf("Format() = %q, want template", r.Format())
+	}
+
+	if _, err := newReporter("template", ""); err == nil {
+		t.Error("template format without --template-file should fail")
+	}
+	if _, err := newReporter("json", ""); err != nil {
+		t.Errorf("json format: %v", err)
+	}
+}
+
+func TestCheckTemplate(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.tmpl")
+	bad := filepath.Join(dir, "bad.tmpl")
+	if err := os.WriteFile(good, []byte("{{range .Vulnerabilities}}{{.Payload}}{{end}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(bad, []byte("ok\n{{.Missing}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkTemplate(good); err != nil {
+		t.Errorf("checkTemplate(good): %v", err)
+	}
+	err := checkTemplate(bad)
+	if err == nil || !strings.Contains(err.Error(), "bad.tmpl:2:") {
+		t.Errorf("checkTemplate(bad) = %v, want error with line number", err)
+	}
+	if err := checkTemplate(""); err == nil {
+		t.Error("checkTemplate without a file should fail")
+	}
+}
+
+func TestServeMetrics_ScanFindings(t *testing.T) {
+	srv := newMockScanServer()
+	defer srv.Close()
+
+	client, err := transport.NewClient(transport.ClientOptions{})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	cfg := engine.DefaultScanConfig()
+	cfg.Techniques = []string{"E"}
+	scanner := buildScanner(client, cfg)
+
+	m := metrics.New(metrics.Options{})
+	addr, stop, err := serveMetrics("127.0.0.1:0", m, "s3cret")
+	if err != nil {
+		t.Fatalf("serveMetrics: %v", err)
+	}
+	defer stop()
+
+	target := &engine.ScanTarget{URL: srv.URL + "/vuln?id=1", Method: "GET"}
+	c := engine.NewMemoryCollector(target)
+	if err := scanner.ScanInto(context.Background(), target, engine.FanOut(c, m.Collector(target))); err != nil {
+		t.Fatalf("ScanInto: %v", err)
+	}
+
+	resp, err := http.Get("http://" + addr + "/metrics")
+	if err != nil {
+		t.Fatalf("scrape: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("scrape without token = %d, want 401", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://"+addr+"/metrics", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("scrape: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{
+		`sqleech_findings_total{technique="error-based",severity="critical"}`,
+		`sqleech_http_requests_total{host="` + strings.TrimPrefix(srv.URL, "http://") + `"}`,
+		"sqleech_scans_active 0",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics missing %s:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/0x6d61/sqleech/internal/dbms"
+	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/enum"
+	"github.com/0x6d61/sqleech/internal/session"
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "Find tables and columns whose names contain a keyword",
+	Long: `Search lists the tables and columns whose names contain a keyword,
+ignoring case, reading the DBMS catalog through an injection point. Names
+are qualified: database.table and database.table.column.
+
+The injection point is the one a scan saved to --session for the target;
+without a saved finding the target is scanned first, and the finding saved
+when --session is given.
+
+Examples:
+  sqleech search -u "http://target.com/page?id=1" --column-keyword pass
+  sqleech search -u "http://target.com/page?id=1" --session scan.db --table-keyword user`,
+	RunE: runSearch,
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+	searchCmd.Flags().String("table-keyword", "", "List tables whose names contain this keyword")
+	searchCmd.Flags().String("column-keyword", "", "List columns whose names contain this keyword")
+	searchCmd.Flags().String("session", "", "Session file path to reuse the injection point a scan saved (SQLite)")
+}
+
+// runSearch is the search command handler.
+func runSearch(cmd *cobra.Command, args []string) error {
+	tableKeyword, _ := cmd.Flags().GetString("table-keyword")
+	columnKeyword, _ := cmd.Flags().GetString("column-keyword")
+	sessionPath, _ := cmd.Flags().GetString("session")
+	outputPath, _ := cmd.Flags().GetString("output")
+
+	if tableKeyword == "" && columnKeyword == "" {
+		return fmt.Errorf("--table-keyword or --column-keyword is required")
+	}
+	scanner, target, _, err := findingTarget(cmd)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer cancel()
+
+	en, err := sessionEnumerator(ctx, scanner, target, sessionPath)
+	if err != nil {
+		return err
+	}
+	if en == nil {
+		return fmt.Errorf("no injectable finding of a known DBMS on %s to search through", target.URL)
+	}
+
+	out := cmd.OutOrStdout()
+	if outputPath != "" {
+		f, err := os.Create(outputPath)
+		if err != nil {
+			return fmt.Errorf("failed to create output file %q: %w", outputPath, err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if tableKeyword != "" {
+		names, err := en.SearchTables(ctx, tableKeyword)
+		writeNames(out, fmt.Sprintf("Tables matching %q", tableKeyword), names)
+		if err != nil {
+			return fmt.Errorf("table search: %w", err)
+		}
+	}
+	if columnKeyword != "" {
+		names, err := en.SearchColumns(ctx, columnKeyword)
+		writeNames(out, fmt.Sprintf("Columns matching %q", columnKeyword), names)
+		if err != nil {
+			return fmt.Errorf("column search: %w", err)
+		}
+	}
+	return nil
+}
+
+// findingTarget returns the scanner and target the root flags describe,
+// and the client the scanner sends through, for the commands that read
+// through a finding rather than report on one.
+func findingTarget(cmd *cobra.Command) (*engine.Scanner, *engine.ScanTarget, transport.Client, error) {
+	proxyURL, _ := cmd.Flags().GetString("proxy")
+	hostOverride, _ := cmd.Flags().GetString("host")
+	resolveSpecs, _ := cmd.Flags().GetStringArray("resolve")
+	forceHTTP1, _ := cmd.Flags().GetBool("http1")
+	forceHTTP2, _ := cmd.Flags().GetBool("http2")
+	clientCert, _ := cmd.Flags().GetString("client-cert")
+	clientKey, _ := cmd.Flags().GetString("client-key")
+	caCert, _ := cmd.Flags().GetString("ca-cert")
+	authType, _ := cmd.Flags().GetString("auth-type")
+	authCred, _ := cmd.Flags().GetString("auth-cred")
+	cookieJar, _ := cmd.Flags().GetBool("cookie-jar")
+	trafficFile, _ := cmd.Flags().GetString("traffic-file")
+	redact, _ := cmd.Flags().GetBool("redact")
+	maxResponse, _ := cmd.Flags().GetInt64("max-response-bytes")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	delay, _ := cmd.Flags().GetDuration("delay")
+	jitter, _ := cmd.Flags().GetDuration("jitter")
+	retries, _ := cmd.Flags().GetInt("retries")
+	retryBackoff, _ := cmd.Flags().GetDuration("retry-backoff")
+	randomAgent, _ := cmd.Flags().GetBool("random-agent")
+	threads, _ := cmd.Flags().GetInt("threads")
+	dbmsHint, _ := cmd.Flags().GetString("dbms")
+	forceTest, _ := cmd.Flags().GetBool("force-test")
+	risk, _ := cmd.Flags().GetInt("risk")
+	level, _ := cmd.Flags().GetInt("level")
+	maxRequests, _ := cmd.Flags().GetInt64("max-requests")
+	techniqueStr, _ := cmd.Flags().GetString("technique")
+	techniqueTimeoutStr, _ := cmd.Flags().GetString("technique-timeout")
+	allowWrites, _ := cmd.Flags().GetBool("unsafe-allow-writes")
+
+	if !hasTarget(cmd) {
+		return nil, nil, nil, fmt.Errorf("--url is required")
+	}
+	target, err := requestTarget(cmd)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if risk < 1 || risk > 3 {
+		return nil, nil, nil, fmt.Errorf("--risk must be between 1 and 3, got %d", risk)
+	}
+	if level < 1 || level > 5 {
+		return nil, nil, nil, fmt.Errorf("--level must be between 1 and 5, got %d", level)
+	}
+	if maxRequests < 0 {
+		return nil, nil, nil, fmt.Errorf("--max-requests must not be negative")
+	}
+	if delay < 0 || jitter < 0 {
+		return nil, nil, nil, fmt.Errorf("--delay and --jitter must not be negative")
+	}
+	if retries < 0 || retryBackoff < 0 {
+		return nil, nil, nil, fmt.Errorf("--retries and --retry-backoff must not be negative")
+	}
+	techniqueTimeouts, err := parseTechniqueTimeouts(techniqueTimeoutStr)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	resolve, err := parseResolve(resolveSpecs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if forceHTTP1 && forceHTTP2 {
+		return nil, nil, nil, fmt.Errorf("--http1 and --http2 are mutually exclusive")
+	}
+	filter, err := extractFilter(cmd)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	client, err := transport.NewClient(transport.ClientOptions{
+		Timeout:          timeout,
+		Delay:            delay,
+		Jitter:           jitter,
+		Retries:          retries,
+		RetryBackoff:     retryBackoff,
+		ProxyURL:         proxyURL,
+		HostOverride:     hostOverride,
+		Resolve:          resolve,
+		ForceHTTP1:       forceHTTP1,
+		ForceHTTP2:       forceHTTP2,
+		ClientCertFile:   clientCert,
+		ClientKeyFile:    clientKey,
+		CACertFile:       caCert,
+		AuthType:         authType,
+		AuthCredentials:  authCred,
+		UseCookieJar:     cookieJar,
+		TrafficLogPath:   trafficFile,
+		RedactTraffic:    redact,
+		MaxResponseBytes: maxResponse,
+		FollowRedirects:  true,
+		RandomUserAgent:  randomAgent,
+		Threads:          threads,
+	})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+
+	cfg := engine.DefaultScanConfig()
+	cfg.Threads = threads
+	if d := dbms.Registry(dbmsHint); d != nil {
+		dbmsHint = d.Name()
+	}
+	cfg.DBMSHint = dbmsHint
+	cfg.ForceTest = forceTest
+	cfg.Risk = risk
+	cfg.Level = level
+	cfg.MaxRequests = maxRequests
+	cfg.ReadOnly = !allowWrites
+	cfg.Techniques = parseTechniques(techniqueStr)
+	cfg.TechniqueTimeouts = techniqueTimeouts
+	cfg.ExtractFilter = filter
+
+	target.ContentType = bodyContentType(target.Headers, target.Body)
+	parseMarkers(target)
+	return buildScanner(client, cfg), target, client, nil
+}
+
+// sessionEnumerator returns findingEnumerator over the findings
+// searchFindings loads from, or saves to, the session file at path (none
+// when path is empty). It is nil when there is no finding to read through.
+func sessionEnumerator(ctx context.Context, scanner *engine.Scanner, target *engine.ScanTarget, path string) (*enum.Enumerator, error) {
+	var store session.Store
+	if path != "" {
+		s, err := session.NewSQLiteStore(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open session file %q: %w", path, err)
+		}
+		defer s.Close()
+		store = s
+	}
+	result, err := searchFindings(ctx, scanner, target, store)
+	if err != nil {
+		return nil, err
+	}
+	return findingEnumerator(scanner, target, result), nil
+}
+
+// searchFindings returns the findings store saved for target, or, when it
+// has none, those of a fresh scan, saved to store.
+func searchFindings(ctx context.Context, scanner *engine.Scanner, target *engine.ScanTarget, store session.Store) (*engine.ScanResult, error) {
+	if store != nil {
+		state, err := store.Load(ctx, target.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load session: %w", err)
+		}
+		if state != nil && len(state.Vulnerabilities) > 0 {
+			vulns, err := stateVulnerabilities(state)
+			if err != nil {
+				return nil, err
+			}
+			return &engine.ScanResult{Target: *target, Vulnerabilities: vulns, DBMS: state.DBMS}, nil
+		}
+	}
+
+	result, err := scanner.Scan(ctx, target)
+	if err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+	if store != nil {
+		if err := store.Save(ctx, scanResultToState(result)); err != nil {
+			return nil, fmt.Errorf("failed to save session: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// stateVulnerabilities decodes the findings scanResultToState serialised.
+func stateVulnerabilities(state *session.ScanState) ([]engine.Vulnerability, error) {
+	b, err := json.Marshal(state.Vulnerabilities)
+	if err != nil {
+		return nil, err
+	}
+	var vulns []engine.Vulnerability
+	if err := json.Unmarshal(b, &vulns); err != nil {
+		return nil, fmt.Errorf("session findings are unreadable: %w", err)
+	}
+	return vulns, nil
+}
+
+// writeNames writes names under title, one per line.
+func writeNames(w io.Writer, title string, names []string) {
+	fmt.Fprintf(w, "%s: %d\n", title, len(names))
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\n", strings.TrimSpace(name))
+	}
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/0x6d61/sqleech/internal/testutil"
+)
+
+// executeSearch runs the CLI with args and returns its output and error.
+// The flags it may set are reset before each run, as they outlive it.
+func executeSearch(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	reset := func() {
+		for name, def := range map[string]string{"url": "", "technique": "", "output": ""} {
+			_ = rootCmd.PersistentFlags().Set(name, def)
+		}
+		for name, def := range map[string]string{"table-keyword": "", "column-keyword": "", "session": ""} {
+			_ = searchCmd.Flags().Set(name, def)
+		}
+		rootCmd.SetOut(nil)
+	}
+	reset()
+	t.Cleanup(reset)
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+func TestSearch_Session(t *testing.T) {
+	var requests atomic.Int64
+	handler := testutil.VulnHandler()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		handler.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	args := []string{"search", "-u", srv.URL + "/vuln/union-mysql-errors?id=1", "--technique", "U",
+		"--session", filepath.Join(t.TempDir(), "search.db"),
+		"--table-keyword", "USER", "--column-keyword", "pass"}
+
+	out, err := executeSearch(t, args...)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	want := "Tables matching \"USER\": 1\n  shop.users\n" +
+		"Columns matching \"pass\": 1\n  shop.users.password\n"
+	if out != want {
+		t.Errorf("output:\n%s\nwant:\n%s", out, want)
+	}
+	scanned := requests.Load()
+
+	// The second search reuses the finding the first saved, sending only
+	// the extraction requests.
+	requests.Store(0)
+	out, err = executeSearch(t, args...)
+	if err != nil {
+		t.Fatalf("search with saved session: %v", err)
+	}
+	if out != want {
+		t.Errorf("output with saved session:\n%s\nwant:\n%s", out, want)
+	}
+	if reused := requests.Load(); reused >= scanned {
+		t.Errorf("search with saved session sent %d requests, want fewer than the %d of the first", reused, scanned)
+	}
+}
+
+func TestSearch_Errors(t *testing.T) {
+	tests := []struct {
+		args []string
+		msg  string
+	}{
+		{[]string{"search", "-u", "http://127.0.0.1/?id=1"}, "--table-keyword or --column-keyword is required"},
+		{[]string{"search", "--table-keyword", "user"}, "--url is required"},
+	}
+	for _, tt := range tests {
+		_, err := executeSearch(t, tt.args...)
+		if err == nil || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("%v: err = %v, want %q", tt.args, err, tt.msg)
+		}
+	}
+}
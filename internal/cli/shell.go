@@ -0,0 +1,289 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/enum"
+	"github.com/0x6d61/sqleech/internal/session"
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Run SQL queries interactively through an injection point",
+	Long: `Shell reads SQL queries, one per line, and prints each result as a
+table, reading it through an injection point as the query command does.
+Lines starting with a dot are commands:
+
+  .technique [error|union|boolean|time]  show or switch the extraction technique
+  .requests                              show the requests sent so far
+  .history                               list the lines entered; !N runs line N again
+  .help                                  list the commands
+  .exit                                  leave the shell (as does Ctrl-D)
+
+Statements that could write are refused unless --risk 3 and
+--unsafe-allow-writes are both given.
+
+With --sql-file the lines are read from the file instead, for scripting;
+the command fails if any query did.
+
+Examples:
+  sqleech shell -u "http://target.com/page?id=1" --session scan.db
+  sqleech shell -u "http://target.com/page?id=1" --sql-file queries.sql`,
+	RunE: runShell,
+}
+
+func init() {
+	rootCmd.AddCommand(shellCmd)
+	shellCmd.Flags().String("session", "", "Session file path to reuse the injection point a scan saved (SQLite)")
+	shellCmd.Flags().String("sql-file", "", "Run the queries and commands in this file, one per line, instead of reading them interactively")
+}
+
+// runShell is the shell command handler.
+func runShell(cmd *cobra.Command, args []string) error {
+	sessionPath, _ := cmd.Flags().GetString("session")
+	sqlFile, _ := cmd.Flags().GetString("sql-file")
+	risk, _ := cmd.Flags().GetInt("risk")
+	allowWrites, _ := cmd.Flags().GetBool("unsafe-allow-writes")
+
+	in := cmd.InOrStdin()
+	if sqlFile != "" {
+		f, err := os.Open(sqlFile)
+		if err != nil {
+			return fmt.Errorf("failed to open SQL file %q: %w", sqlFile, err)
+		}
+		defer f.Close()
+		in = f
+	}
+	scanner, target, client, err := findingTarget(cmd)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer cancel()
+
+	var store session.Store
+	if sessionPath != "" {
+		s, err := session.NewSQLiteStore(sessionPath)
+		if err != nil {
+			return fmt.Errorf("failed to open session file %q: %w", sessionPath, err)
+		}
+		defer s.Close()
+		store = s
+	}
+	result, err := searchFindings(ctx, scanner, target, store)
+	if err != nil {
+		return err
+	}
+	en := findingEnumerator(scanner, target, result)
+	if en == nil {
+		return fmt.Errorf("no injectable finding of a known DBMS on %s to query through", target.URL)
+	}
+
+	sh := &sqlShell{
+		scanner:     scanner,
+		target:      target,
+		result:      result,
+		client:      client,
+		en:          en,
+		risk:        risk,
+		allowWrites: allowWrites,
+		out:         cmd.OutOrStdout(),
+	}
+	if sqlFile == "" && isTerminal(in) {
+		sh.prompt = "sql-shell> "
+		fmt.Fprintf(sh.out, "Querying %s through %s on %s; .help lists the commands.\n",
+			target.URL, en.Finding().Technique, en.Finding().Parameter.Name)
+	}
+	if failed := sh.run(ctx, in); failed > 0 && sqlFile != "" {
+		return fmt.Errorf("%d of the queries in %s failed", failed, sqlFile)
+	}
+	return nil
+}
+
+// isTerminal reports whether r is a character device, as a terminal is.
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// shellTechniques maps the names .technique takes to techniques.
+var shellTechniques = map[string]string{
+	"error":   "error-based",
+	"union":   "union-based",
+	"boolean": "boolean-blind",
+	"time":    "time-based",
+}
+
+// sqlShell runs the lines of a shell session.
+type sqlShell struct {
+	scanner     *engine.Scanner
+	target      *engine.ScanTarget
+	result      *engine.ScanResult
+	client      transport.Client
+	en          *enum.Enumerator
+	risk        int
+	allowWrites bool
+
+	out     io.Writer
+	prompt  string   // Written before each line; empty when not interactive
+	history []string // Lines run, meta-commands included
+}
+
+// run executes the lines of in until EOF (Ctrl-D) or .exit, and returns
+// the number of queries that failed.
+func (sh *sqlShell) run(ctx context.Context, in io.Reader) int {
+	failed := 0
+	lines := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(sh.out, sh.prompt)
+		if !lines.Scan() {
+			if sh.prompt != "" {
+				fmt.Fprintln(sh.out)
+			}
+			return failed
+		}
+		line := strings.TrimSpace(lines.Text())
+		if line == "" || strings.HasPrefix(line, "--") {
+			continue
+		}
+		if strings.HasPrefix(line, "!") {
+			n, err := strconv.Atoi(line[1:])
+			if err != nil || n < 1 || n > len(sh.history) {
+				fmt.Fprintf(sh.out, "error: no line %s in the history\n", line[1:])
+				continue
+			}
+			line = sh.history[n-1]
+			fmt.Fprintln(sh.out, line)
+		}
+		sh.history = append(sh.history, line)
+
+		if line == ".exit" || line == ".quit" {
+			return failed
+		}
+		if err := sh.dispatch(ctx, line); err != nil {
+			fmt.Fprintf(sh.out, "error: %v\n", err)
+			if !strings.HasPrefix(line, ".") {
+				failed++
+			}
+		}
+		if ctx.Err() != nil {
+			return failed
+		}
+	}
+}
+
+// dispatch runs one line: a meta-command or a query.
+func (sh *sqlShell) dispatch(ctx context.Context, line string) error {
+	if !strings.HasPrefix(line, ".") {
+		return sh.query(ctx, line)
+	}
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case ".technique":
+		if arg == "" {
+			fmt.Fprintf(sh.out, "technique: %s\n", sh.en.Finding().Technique)
+			return nil
+		}
+		return sh.switchTechnique(arg)
+	case ".requests":
+		fmt.Fprintf(sh.out, "requests: %d\n", sh.client.Stats().TotalRequests)
+		return nil
+	case ".history":
+		for i, h := range sh.history {
+			fmt.Fprintf(sh.out, "%4d  %s\n", i+1, h)
+		}
+		return nil
+	case ".help":
+		fmt.Fprintln(sh.out, "commands: .technique [error|union|boolean|time], .requests, .history, !N, .help, .exit")
+		return nil
+	}
+	return fmt.Errorf("unknown command %s (see .help)", name)
+}
+
+// query runs a user query and prints its rows as a table.
+func (sh *sqlShell) query(ctx context.Context, query string) error {
+	if err := checkUserQuery(query, sh.risk, sh.allowWrites); err != nil {
+		return err
+	}
+	rows, err := sh.en.Query(ctx, query)
+	if len(rows) > 0 || err == nil {
+		writeTable(sh.out, strings.TrimRight(query, "; "), rows)
+	}
+	return err
+}
+
+// switchTechnique makes the shell extract with the technique named by arg:
+// through a finding that technique confirmed if the scan made one,
+// otherwise through the current finding's parameter and boundary.
+func (sh *sqlShell) switchTechnique(arg string) error {
+	name, ok := shellTechniques[strings.ToLower(arg)]
+	if !ok {
+		return fmt.Errorf("unknown technique %q: want error, union, boolean or time", arg)
+	}
+	if !slices.Contains(sh.scanner.TechniqueNames(), name) {
+		return fmt.Errorf("technique %s is not enabled (see --technique)", name)
+	}
+	vuln := sh.en.Finding()
+	vuln.Technique = name
+	for _, v := range sh.result.Vulnerabilities {
+		if v.ConfirmedWith(name) && v.PairedParameter == nil {
+			vuln = v
+			vuln.Technique = name
+			if vuln.DBMS == "" {
+				vuln.DBMS = sh.result.DBMS
+			}
+			break
+		}
+	}
+	en, err := enum.New(sh.scanner, sh.target, vuln)
+	if err != nil {
+		return err
+	}
+	sh.en = en
+	fmt.Fprintf(sh.out, "technique: %s\n", name)
+	return nil
+}
+
+// writeTable writes rows under header as a one-column table, and the row
+// count.
+func writeTable(w io.Writer, header string, rows []string) {
+	width := utf8.RuneCountInString(header)
+	for _, r := range rows {
+		width = max(width, utf8.RuneCountInString(r))
+	}
+	bar := "+" + strings.Repeat("-", width+2) + "+"
+	cell := func(s string) string {
+		return "| " + s + strings.Repeat(" ", width-utf8.RuneCountInString(s)) + " |"
+	}
+	fmt.Fprintln(w, bar)
+	fmt.Fprintln(w, cell(header))
+	fmt.Fprintln(w, bar)
+	for _, r := range rows {
+		fmt.Fprintln(w, cell(r))
+	}
+	fmt.Fprintln(w, bar)
+	if len(rows) == 1 {
+		fmt.Fprintln(w, "1 row")
+	} else {
+		fmt.Fprintf(w, "%d rows\n", len(rows))
+	}
+}
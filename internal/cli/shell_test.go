@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0x6d61/sqleech/internal/testutil"
+)
+
+// executeShell runs the shell command with args, reading stdin, and
+// returns its output and error.
+func executeShell(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+	reset := func() {
+		for name, def := range map[string]string{"url": "", "technique": "", "output": "", "format": "text", "risk": "1"} {
+			_ = rootCmd.PersistentFlags().Set(name, def)
+		}
+		_ = shellCmd.Flags().Set("session", "")
+		_ = shellCmd.Flags().Set("sql-file", "")
+		rootCmd.SetOut(nil)
+		rootCmd.SetIn(nil)
+	}
+	reset()
+	t.Cleanup(reset)
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetIn(strings.NewReader(stdin))
+	rootCmd.SetArgs(append([]string{"shell"}, args...))
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+func TestShell_Queries(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+
+	stdin := "@@version\n\nSELECT username FROM shop.users;\n.requests\n.history\n!1\n"
+	out, err := executeShell(t, stdin, "-u", srv.URL+"/vuln/union-mysql-errors?id=1", "--technique", "U")
+	if err != nil {
+		t.Fatalf("shell: %v", err)
+	}
+	for _, want := range []string{
+		"+-----------+\n| @@version |\n",
+		"| 8.0.32    |\n",
+		"| admin                           |\n| guest                           |\n",
+		"2 rows\n",
+		"   1  @@version\n   2  SELECT username FROM shop.users;\n   3  .requests\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "| 8.0.32    |") != 2 {
+		t.Errorf("!1 did not run @@version again:\n%s", out)
+	}
+	if !strings.Contains(out, "requests: ") || strings.Contains(out, "requests: 0\n") {
+		t.Errorf(".requests did not report the requests sent:\n%s", out)
+	}
+	if strings.Contains(out, "sql-shell> ") {
+		t.Errorf("prompt written for input that is not a terminal:\n%s", out)
+	}
+}
+
+func TestShell_Technique(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+
+	stdin := ".technique\n.technique error\n@@version\n.technique time\n.technique bogus\n.technique\n"
+	out, err := executeShell(t, stdin, "-u", srv.URL+"/vuln/union-mysql-errors?id=1", "--technique", "E,U")
+	if err != nil {
+		t.Fatalf("shell: %v", err)
+	}
+	for _, want := range []string{
+		"technique: union-based\ntechnique: error-based\n",
+		"| 8.0.32    |\n",
+		"error: technique time-based is not enabled (see --technique)\n",
+		`error: unknown technique "bogus": want error, union, boolean or time` + "\n",
+		"technique: error-based\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.HasSuffix(out, "technique: union-based\n") {
+		t.Errorf("failed switches changed the technique:\n%s", out)
+	}
+}
+
+func TestShell_SQLFile(t *testing.T) {
+	srv := httptest.NewServer(testutil.VulnHandler())
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "queries.sql")
+	script := "-- the version\n@@version\n.exit\nSELECT username FROM shop.users\n"
+	if err := os.WriteFile(path, []byte(script), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := executeShell(t, "", "-u", srv.URL+"/vuln/union-mysql-errors?id=1", "--technique", "U", "--sql-file", path)
+	if err != nil {
+		t.Fatalf("shell: %v", err)
+	}
+	if !strings.Contains(out, "| 8.0.32    |\n+-----------+\n1 row\n") {
+		t.Errorf("output missing the version table:\n%s", out)
+	}
+	if strings.Contains(out, "admin") {
+		t.Errorf("query after .exit was run:\n%s", out)
+	}
+
+	if err := os.WriteFile(path, []byte("DROP TABLE shop.users\n@@version\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err = executeShell(t, "", "-u", srv.URL+"/vuln/union-mysql-errors?id=1", "--technique", "U", "--sql-file", path)
+	if err == nil || !strings.Contains(err.Error(), "1 of the queries") {
+		t.Errorf("error = %v, want the write counted as failed", err)
+	}
+	if !strings.Contains(out, "need --risk 3") || !strings.Contains(out, "| 8.0.32    |") {
+		t.Errorf("output = %q, want the write refused and the next query run", out)
+	}
+}
@@ -0,0 +1,248 @@
+// Package crawler discovers scan targets from a seed URL: the links that
+// carry query parameters and the HTML forms of the pages reached by
+// following links from it, on the seed's origin only.
+//
+// Pages are fetched with GET through a transport.Client, so the client's
+// rate limit, proxy and session handling apply. Forms are never submitted
+// while crawling; they only become targets.
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/forms"
+	"github.com/0x6d61/sqleech/internal/rawquery"
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// DefaultMaxPages caps the pages a crawl fetches when Options.MaxPages is
+// zero.
+const DefaultMaxPages = 200
+
+// Options configures a Crawler.
+type Options struct {
+	// Depth is how many clicks from the seed a target may be: the links
+	// and forms on the seed page are one click deep. Pages up to Depth-1
+	// clicks away are fetched (0: 1).
+	Depth int
+
+	// MaxPages caps the pages fetched (0: DefaultMaxPages).
+	MaxPages int
+
+	// Exclude matches the absolute URLs never fetched nor made targets,
+	// such as logout links.
+	Exclude *regexp.Regexp
+
+	// IgnoreRobots fetches and targets the paths the origin's robots.txt
+	// disallows.
+	IgnoreRobots bool
+
+	// TestHidden tests the hidden fields of forms too, which are otherwise
+	// sent as the page set them (see forms.Form.Target).
+	TestHidden bool
+
+	// Headers and Cookies are sent with every page request.
+	Headers map[string]string
+	Cookies map[string]string
+}
+
+// Crawler discovers scan targets by following links.
+type Crawler struct {
+	client transport.Client
+	opts   Options
+}
+
+// New creates a Crawler that fetches pages through client.
+func New(client transport.Client, opts Options) *Crawler {
+	if opts.Depth <= 0 {
+		opts.Depth = 1
+	}
+	if opts.MaxPages <= 0 {
+		opts.MaxPages = DefaultMaxPages
+	}
+	return &Crawler{client: client, opts: opts}
+}
+
+// page is a page to fetch and how many clicks from the seed it is.
+type page struct {
+	url   *url.URL
+	depth int
+}
+
+// Crawl fetches seed and the pages it links to, breadth first, and
+// returns the targets found in the order found: the seed itself when it
+// has a query, links with a query and forms with fields to test. Targets are
+// deduplicated by method, path and parameter names, so a listing's
+// detail?id=1 and detail?id=2 are one target; pages are fetched once per
+// such signature too.
+//
+// Crawl fails when the seed cannot be fetched. When ctx ends, it returns
+// the targets found so far with the context's error.
+func (c *Crawler) Crawl(ctx context.Context, seed string) ([]*engine.ScanTarget, error) {
+	start, err := url.Parse(seed)
+	if err != nil || start.Host == "" || (start.Scheme != "http" && start.Scheme != "https") {
+		return nil, fmt.Errorf("crawl seed %q is not an absolute http(s) URL", seed)
+	}
+	start.Fragment = ""
+
+	s := &crawl{
+		Crawler: c,
+		origin:  origin(start),
+		targets: make(map[string]bool),
+		pages:   make(map[string]bool),
+	}
+	if !c.opts.IgnoreRobots {
+		s.robots = c.fetchRobots(ctx, start)
+	}
+
+	s.addLink(start)
+	s.pages[signature(http.MethodGet, start, nil)] = true
+	queue := []page{{url: start}}
+	for fetched := 0; len(queue) > 0 && fetched < c.opts.MaxPages; fetched++ {
+		if err := ctx.Err(); err != nil {
+			return s.found, err
+		}
+		p := queue[0]
+		queue = queue[1:]
+
+		links, pageForms, err := c.fetch(ctx, p.url)
+		if err != nil {
+			if p.depth == 0 {
+				return nil, fmt.Errorf("crawl seed: %w", err)
+			}
+			continue
+		}
+		for _, l := range links {
+			if !s.inScope(l) {
+				continue
+			}
+			s.addLink(l)
+			if sig := signature(http.MethodGet, l, nil); p.depth+1 < c.opts.Depth && !s.pages[sig] {
+				s.pages[sig] = true
+				queue = append(queue, page{url: l, depth: p.depth + 1})
+			}
+		}
+		for _, f := range pageForms {
+			if s.inScope(f.Action) {
+				s.addForm(f)
+			}
+		}
+	}
+	return s.found, nil
+}
+
+// crawl is the state of one Crawl.
+type crawl struct {
+	*Crawler
+	origin  string
+	robots  *robots
+	targets map[string]bool // Signatures of the targets found
+	pages   map[string]bool // Signatures of the pages fetched or queued
+	found   []*engine.ScanTarget
+}
+
+// inScope reports whether u may be fetched and targeted: it is on the
+// seed's origin, not excluded and not disallowed by robots.txt.
+func (s *crawl) inScope(u *url.URL) bool {
+	if origin(u) != s.origin {
+		return false
+	}
+	if s.opts.Exclude != nil && s.opts.Exclude.MatchString(u.String()) {
+		return false
+	}
+	return s.robots.allowed(u.RequestURI())
+}
+
+// addLink adds a GET target for u unless it has no query or a target of
+// its signature was found before.
+func (s *crawl) addLink(u *url.URL) {
+	if u.RawQuery == "" {
+		return
+	}
+	sig := signature(http.MethodGet, u, nil)
+	if s.targets[sig] {
+		return
+	}
+	s.targets[sig] = true
+	s.found = append(s.found, &engine.ScanTarget{URL: u.String(), Method: http.MethodGet})
+}
+
+// addForm adds the target submitting f would send, unless f has no fields
+// to test or a target of its signature was found before.
+func (s *crawl) addForm(f forms.Form) {
+	t := f.Target(s.opts.TestHidden)
+	if t == nil {
+		return
+	}
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return
+	}
+	var names []string
+	for _, p := range rawquery.Parse(t.Body) {
+		names = append(names, p.Name)
+	}
+	sig := signature(t.Method, u, names)
+	if s.targets[sig] {
+		return
+	}
+	s.targets[sig] = true
+	s.found = append(s.found, t)
+}
+
+// fetch requests the page at u and returns its links and forms, or none
+// when it is not HTML or was redirected off its origin.
+func (c *Crawler) fetch(ctx context.Context, u *url.URL) ([]*url.URL, []forms.Form, error) {
+	resp, err := c.client.Do(ctx, &transport.Request{
+		Method:  http.MethodGet,
+		URL:     u.String(),
+		Headers: c.opts.Headers,
+		Cookies: c.opts.Cookies,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	base := u
+	if resp.URL != "" {
+		if final, err := url.Parse(resp.URL); err == nil {
+			base = final
+		}
+	}
+	if origin(base) != origin(u) || !isHTML(resp) {
+		return nil, nil, nil
+	}
+	return parseLinks(base, resp.Body), forms.Parse(base, resp.Body), nil
+}
+
+// isHTML reports whether resp is an HTML page: declared so, or undeclared
+// and starting like one.
+func isHTML(resp *transport.Response) bool {
+	ct := strings.ToLower(resp.Headers.Get("Content-Type"))
+	if ct != "" {
+		return strings.Contains(ct, "html")
+	}
+	return strings.Contains(http.DetectContentType(resp.Body), "html")
+}
+
+// origin returns the scheme and host of u, lowercased.
+func origin(u *url.URL) string {
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+// signature identifies a request by method, origin, path and the names of
+// its query parameters and fields, sorted.
+func signature(method string, u *url.URL, fields []string) string {
+	names := slices.Clone(fields)
+	for _, p := range rawquery.Parse(u.RawQuery) {
+		names = append(names, p.Name)
+	}
+	slices.Sort(names)
+	return method + " " + origin(u) + u.EscapedPath() + "?" + strings.Join(slices.Compact(names), "&")
+}
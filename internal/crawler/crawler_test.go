@@ -0,0 +1,167 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// site serves HTML pages by path and records the paths requested.
+type site struct {
+	pages     map[string]string
+	requested []string
+}
+
+func (s *site) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requested = append(s.requested, r.URL.RequestURI())
+	if r.URL.Path == "/robots.txt" {
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+		return
+	}
+	body, ok := s.pages[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html")
+	fmt.Fprint(w, body)
+}
+
+func newSite(t *testing.T) (*site, *httptest.Server, transport.Client) {
+	t.Helper()
+	s := &site{pages: map[string]string{
+		"/": `<a href="/list">List</a> <a href="/list#top">List again</a>
+<a href="/private?id=1">Private</a> <a href="/logout?next=/">Log out</a>
+<a href="http://elsewhere.test/?id=1">Elsewhere</a>`,
+		"/list": `<a href="/item?id=1">1</a> <a href="/item?id=2">2</a> <a href="/list?sort=name">Sort</a>
+<form method="POST" action="/login"><input name="user"><input type="password" name="pass"></form>
+<form action="/search?old=1"><input name="q"></form>`,
+		"/item": `<a href="/item/reviews?id=1&page=1">Reviews</a>`,
+	}}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	client, err := transport.NewClient(transport.ClientOptions{})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return s, srv, client
+}
+
+func describe(targets []*engine.ScanTarget, base string) []string {
+	var out []string
+	for _, t := range targets {
+		d := t.Method + " " + strings.TrimPrefix(t.URL, base)
+		if t.Body != "" {
+			d += " " + t.Body
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+func TestCrawl(t *testing.T) {
+	s, srv, client := newSite(t)
+
+	c := New(client, Options{Depth: 2, Exclude: regexp.MustCompile(`logout`)})
+	targets, err := c.Crawl(context.Background(), srv.URL+"/")
+	if err != nil {
+		t.Fatalf("Crawl: %v", err)
+	}
+
+	// item?id=2 is item?id=1 again; the reviews link is three clicks deep.
+	want := []string{
+		"GET /item?id=1",
+		"GET /list?sort=name",
+		"POST /login user=test&pass=test",
+		"GET /search?q=test",
+	}
+	if got := describe(targets, srv.URL); !reflect.DeepEqual(got, want) {
+		t.Errorf("targets = %q, want %q", got, want)
+	}
+	if targets[2].ContentType != "application/x-www-form-urlencoded" {
+		t.Errorf("form target ContentType = %q", targets[2].ContentType)
+	}
+
+	// Pages are fetched once, and the excluded and disallowed never.
+	wantRequested := []string{"/robots.txt", "/", "/list"}
+	if !reflect.DeepEqual(s.requested, wantRequested) {
+		t.Errorf("requested %q, want %q", s.requested, wantRequested)
+	}
+}
+
+func TestCrawl_Depth(t *testing.T) {
+	_, srv, client := newSite(t)
+
+	targets, err := New(client, Options{Depth: 3}).Crawl(context.Background(), srv.URL+"/")
+	if err != nil {
+		t.Fatalf("Crawl: %v", err)
+	}
+	got := describe(targets, srv.URL)
+	for _, want := range []string{"GET /logout?next=/", "GET /item/reviews?id=1&page=1"} {
+		if !slices.Contains(got, want) {
+			t.Errorf("targets %q lack %q", got, want)
+		}
+	}
+
+	targets, err = New(client, Options{Depth: 1}).Crawl(context.Background(), srv.URL+"/")
+	if err != nil {
+		t.Fatalf("Crawl: %v", err)
+	}
+	if got := describe(targets, srv.URL); !reflect.DeepEqual(got, []string{"GET /logout?next=/"}) {
+		t.Errorf("depth 1 targets = %q, want only the logout link", got)
+	}
+}
+
+func TestCrawl_IgnoreRobots(t *testing.T) {
+	s, srv, client := newSite(t)
+
+	targets, err := New(client, Options{Depth: 1, IgnoreRobots: true}).Crawl(context.Background(), srv.URL+"/")
+	if err != nil {
+		t.Fatalf("Crawl: %v", err)
+	}
+	if got := describe(targets, srv.URL); !slices.Contains(got, "GET /private?id=1") {
+		t.Errorf("targets %q lack the disallowed page", got)
+	}
+	if slices.Contains(s.requested, "/robots.txt") {
+		t.Error("robots.txt was fetched")
+	}
+}
+
+func TestCrawl_SeedQuery(t *testing.T) {
+	_, srv, client := newSite(t)
+
+	targets, err := New(client, Options{MaxPages: 1}).Crawl(context.Background(), srv.URL+"/item?id=7")
+	if err != nil {
+		t.Fatalf("Crawl: %v", err)
+	}
+	if got := describe(targets, srv.URL); !reflect.DeepEqual(got, []string{"GET /item?id=7", "GET /item/reviews?id=1&page=1"}) {
+		t.Errorf("targets = %q, want the seed and its link", got)
+	}
+}
+
+func TestCrawl_Errors(t *testing.T) {
+	_, srv, client := newSite(t)
+
+	if _, err := New(client, Options{}).Crawl(context.Background(), "/relative"); err == nil {
+		t.Error("relative seed: no error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := New(client, Options{IgnoreRobots: true}).Crawl(ctx, srv.URL+"/"); err == nil {
+		t.Error("cancelled crawl: no error")
+	} else if !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled crawl: %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,67 @@
+package crawler
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// parseLinks returns the absolute http(s) links of an HTML page at base:
+// of a, area, frame and iframe elements, fragments removed. A <base href>
+// changes what relative links resolve against.
+func parseLinks(base *url.URL, body []byte) []*url.URL {
+	var (
+		links   []*url.URL
+		baseSet bool
+	)
+	z := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return links
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			attrs := attributes(z)
+			var ref string
+			switch string(name) {
+			case "base":
+				if href, ok := attrs["href"]; ok && !baseSet {
+					if u, err := base.Parse(strings.TrimSpace(href)); err == nil {
+						base, baseSet = u, true
+					}
+				}
+				continue
+			case "a", "area":
+				ref = attrs["href"]
+			case "frame", "iframe":
+				ref = attrs["src"]
+			}
+			if ref == "" {
+				continue
+			}
+			u, err := base.Parse(strings.TrimSpace(ref))
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				continue
+			}
+			u.Fragment, u.RawFragment = "", ""
+			links = append(links, u)
+		}
+	}
+}
+
+// attributes returns the attributes of the current tag of z, keys
+// lowercased; the first of a repeated attribute wins, as in browsers.
+func attributes(z *html.Tokenizer) map[string]string {
+	attrs := make(map[string]string)
+	for {
+		k, v, more := z.TagAttr()
+		if _, ok := attrs[string(k)]; !ok && len(k) > 0 {
+			attrs[string(k)] = string(v)
+		}
+		if !more {
+			return attrs
+		}
+	}
+}
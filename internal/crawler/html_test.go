@@ -0,0 +1,42 @@
+package crawler
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParseLinks(t *testing.T) {
+	base, _ := url.Parse("http://shop.test/catalog/index.php?page=1")
+	body := []byte(`<html><body>
+<a href="item.php?id=1#reviews">Item</a>
+<a href="/about">About</a>
+<a href="mailto:shop@shop.test">Mail</a>
+<a name="top">Anchor without a link</a>
+<area href="https://other.test/map?x=1">
+<iframe src="frame.php?f=2"></iframe>
+</body></html>`)
+
+	links := parseLinks(base, body)
+	var got []string
+	for _, l := range links {
+		got = append(got, l.String())
+	}
+	want := []string{
+		"http://shop.test/catalog/item.php?id=1",
+		"http://shop.test/about",
+		"https://other.test/map?x=1",
+		"http://shop.test/catalog/frame.php?f=2",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("links = %q, want %q", got, want)
+	}
+}
+
+func TestParseLinks_BaseHref(t *testing.T) {
+	base, _ := url.Parse("http://shop.test/a/page.html")
+	links := parseLinks(base, []byte(`<head><base href="/b/"></head><a href="x?id=1">x</a>`))
+	if len(links) != 1 || links[0].String() != "http://shop.test/b/x?id=1" {
+		t.Errorf("links = %v, want http://shop.test/b/x?id=1", links)
+	}
+}
@@ -0,0 +1,147 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// userAgent is the robots.txt agent name whose group applies before *.
+const userAgent = "sqleech"
+
+// robots holds the rules of a robots.txt group. A nil *robots allows
+// everything.
+type robots struct {
+	rules []rule
+}
+
+type rule struct {
+	pattern string
+	allow   bool
+}
+
+// fetchRobots returns the rules of the robots.txt on the origin of start,
+// or nil when there is none or it cannot be fetched.
+func (c *Crawler) fetchRobots(ctx context.Context, start *url.URL) *robots {
+	u := url.URL{Scheme: start.Scheme, Host: start.Host, Path: "/robots.txt"}
+	resp, err := c.client.Do(ctx, &transport.Request{
+		Method:  http.MethodGet,
+		URL:     u.String(),
+		Headers: c.opts.Headers,
+		Cookies: c.opts.Cookies,
+	})
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	return parseRobots(resp.Body, userAgent)
+}
+
+// parseRobots returns the rules of the group of body for agent, or of the
+// * group when none names it. Groups are matched by case-insensitive
+// substring of the agent name, as most crawlers do.
+func parseRobots(body []byte, agent string) *robots {
+	var (
+		mine, star     []rule
+		hasMine        bool
+		agents         []string // The User-agent lines of the current group
+		inRules        bool     // The current group's rules have begun
+		inMine, inStar bool     // The current group is for agent, for *
+	)
+	agent = strings.ToLower(agent)
+	sc := bufio.NewScanner(bytes.NewReader(body))
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if inRules {
+				agents, inRules = nil, false
+			}
+			agents = append(agents, strings.ToLower(value))
+			inMine, inStar = false, false
+			for _, a := range agents {
+				switch {
+				case a == "*":
+					inStar = true
+				case a != "" && strings.Contains(agent, a):
+					inMine = true
+				}
+			}
+			hasMine = hasMine || inMine
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				// An empty Disallow allows everything; an empty Allow
+				// means nothing.
+				continue
+			}
+			r := rule{pattern: value, allow: key == "allow"}
+			if inMine {
+				mine = append(mine, r)
+			}
+			if inStar {
+				star = append(star, r)
+			}
+		}
+	}
+	if hasMine {
+		return &robots{rules: mine}
+	}
+	return &robots{rules: star}
+}
+
+// allowed reports whether path, with its query, may be fetched: the longest matching rule
+// decides, Allow winning a tie, and a path no rule matches is allowed.
+func (r *robots) allowed(path string) bool {
+	if r == nil {
+		return true
+	}
+	if path == "" {
+		path = "/"
+	}
+	allow, longest := true, -1
+	for _, rl := range r.rules {
+		if !match(rl.pattern, path) {
+			continue
+		}
+		if n := len(rl.pattern); n > longest || (n == longest && rl.allow) {
+			allow, longest = rl.allow, n
+		}
+	}
+	return allow
+}
+
+// match reports whether path matches a robots.txt pattern: a path prefix
+// in which * matches any characters and a final $ anchors the end.
+func match(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = pattern[:len(pattern)-1]
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, p := range parts[1:] {
+		if i == len(parts)-2 && anchored {
+			return strings.HasSuffix(rest, p)
+		}
+		j := strings.Index(rest, p)
+		if j < 0 {
+			return false
+		}
+		rest = rest[j+len(p):]
+	}
+	return !anchored || rest == ""
+}
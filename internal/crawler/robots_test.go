@@ -0,0 +1,52 @@
+package crawler
+
+import "testing"
+
+func TestRobots_Allowed(t *testing.T) {
+	r := parseRobots([]byte(`# Shop robots
+User-agent: Googlebot
+Disallow: /
+
+User-agent: *
+Disallow: /admin
+Disallow: /*.php$
+Allow: /admin/public
+Disallow: /cart?
+`), userAgent)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/", true},
+		{"/products?id=1", true},
+		{"/admin", false},
+		{"/admin/users?id=1", false},
+		{"/admin/public/faq", true},
+		{"/index.php", false},
+		{"/index.php?id=1", true},
+		{"/cart", true},
+		{"/cart?item=1", false},
+	}
+	for _, tt := range tests {
+		if got := r.allowed(tt.path); got != tt.want {
+			t.Errorf("allowed(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRobots_OwnGroup(t *testing.T) {
+	// The group naming sqleech replaces the * group, and an empty Disallow
+	// allows everything.
+	r := parseRobots([]byte("User-agent: *\nDisallow: /\n\nUser-agent: other\nUser-agent: SQLeech\nDisallow:\n"), userAgent)
+	if !r.allowed("/products?id=1") {
+		t.Error("path disallowed to every agent but sqleech was not allowed")
+	}
+}
+
+func TestRobots_Nil(t *testing.T) {
+	var r *robots
+	if !r.allowed("/admin") {
+		t.Error("nil robots disallowed a path")
+	}
+}
@@ -0,0 +1,28 @@
+package dbms
+
+// CockroachDB implements the DBMS interface for CockroachDB. It speaks
+// PostgreSQL's wire protocol and dialect, so it embeds PostgreSQL and
+// overrides only its name, its error payload templates and its
+// capabilities: it lacks query_to_xml and pg_read_file, and words its cast
+// errors differently.
+type CockroachDB struct {
+	PostgreSQL
+}
+
+// Name returns the canonical DBMS name.
+func (c *CockroachDB) Name() string {
+	return "CockroachDB"
+}
+
+// ErrorPayloads returns the error-based templates the payload corpus lists
+// for CockroachDB.
+func (c *CockroachDB) ErrorPayloads() []PayloadTemplate {
+	return errorPayloadsFor("CockroachDB")
+}
+
+// Capabilities returns PostgreSQL's feature set without file reads.
+func (c *CockroachDB) Capabilities() Capabilities {
+	caps := c.PostgreSQL.Capabilities()
+	caps.FileRead = false
+	return caps
+}
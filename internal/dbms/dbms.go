@@ -1,6 +1,12 @@
 // Package dbms provides DBMS-specific SQL syntax and query knowledge base.
 package dbms
 
+import (
+	"strings"
+
+	"github.com/0x6d61/sqleech/internal/payloadlib"
+)
+
 // DBMS provides database-specific SQL syntax and capabilities.
 type DBMS interface {
 	Name() string
@@ -11,32 +17,79 @@ type DBMS interface {
 	Length(expr string) string
 	ASCII(expr string) string
 	Char(code int) string
+	// WrapWithMarkers returns a string expression for query's value
+	// between CHAR(126) (~) markers, for finding it in a response body.
+	WrapWithMarkers(query string) string
+	// WrapRowsWithMarkers returns a string expression for the rows of
+	// SELECT column FROM from: CHAR(126), the count of non-NULL rows,
+	// CHAR(126), then every non-NULL row followed by CHAR(126), aggregated
+	// with an empty separator. Terminating each row, rather than
+	// separating them, leaves a truncated aggregate with a trailing
+	// partial value that can be told apart from a complete one.
+	WrapRowsWithMarkers(column, from string) string
 
 	// Version and identity
 	VersionQuery() string
 	CurrentUserQuery() string
 	CurrentDBQuery() string
 	HostnameQuery() string
+	// IsDBAQuery returns an expression that is Y, 1, on or true (by DBMS)
+	// when the current user has DBA privileges.
+	IsDBAQuery() string
 
-	// Enumeration queries
+	// Enumeration queries. Each returns a single-column SELECT, ready to
+	// pass as technique.ExtractionRequest.Query; names are quoted as
+	// string literals or identifiers.
 	ListDatabasesQuery() string
 	ListTablesQuery(database string) string
 	ListColumnsQuery(database, table string) string
+	// SearchTablesQuery and SearchColumnsQuery list the tables and
+	// columns whose names contain keyword, case-insensitively, as
+	// database.table and database.table.column. % and _ in keyword are
+	// LIKE wildcards.
+	SearchTablesQuery(keyword string) string
+	SearchColumnsQuery(keyword string) string
 	CountRowsQuery(database, table string) string
+	// QualifyTable returns the quoted name of table in database, as the
+	// FROM clause of CountRowsQuery and DumpQuery refer to it.
+	QualifyTable(database, table string) string
 	DumpQuery(database, table string, columns []string, offset, limit int) string
+	// LimitOffset restricts query, a SELECT without a row-limiting
+	// clause, to limit rows starting at row offset (0-based).
+	LimitOffset(query string, offset, limit int) string
+	// SelectRow returns the query for row (0-based) of
+	// SELECT column FROM from, in whatever order the DBMS returns them.
+	SelectRow(column, from string, row int) string
+	// DualTable returns the table a SELECT of expressions alone must
+	// name in its FROM clause, or "" where FROM is optional.
+	DualTable() string
 
 	// Error-based payloads
 	ErrorPayloads() []PayloadTemplate
+	// ErrorChunkSize is the most characters of a value the error-based
+	// payloads' error messages show; zero means values come back whole.
+	ErrorChunkSize() int
 
 	// Time-based
 	SleepFunction(seconds int) string
 	HeavyQuery() string
+	// SleepIf returns a condition that delays the response by about
+	// seconds when condition holds and returns at once otherwise, for
+	// use in a WHERE clause.
+	SleepIf(condition string, seconds int) string
 
 	// Boolean constructs
 	IfThenElse(condition, trueExpr, falseExpr string) string
 
 	// Quoting and comments
 	QuoteString(s string) string
+	QuoteIdentifier(name string) string
+	// HexString and CharEncode return s as a string expression without
+	// quotes, for filters that reject them: HexString as the DBMS's hex
+	// literal where it has a quote-free one, CharEncode from character
+	// codes. An empty s has no quote-free form and comes back quoted.
+	HexString(s string) string
+	CharEncode(s string) string
 	CommentSequence() string
 	InlineComment() string
 
@@ -63,28 +116,140 @@ type Capabilities struct {
 
 // PayloadTemplate is a parameterized error-based payload.
 type PayloadTemplate struct {
+	ID       string // Payload corpus entry ID
 	Name     string
 	Template string // Use {{.Query}} as placeholder for the expression to extract
 	Columns  int
 	DBMS     string
 }
 
-// Registry returns a DBMS implementation by name.
-// It accepts common name variants (e.g. "MySQL", "mysql", "PostgreSQL", "postgres").
-// Returns nil if the name is not recognized.
+// errorPayloadsFor returns the error-based templates the payload corpus
+// lists for the named DBMS at the default risk and level.
+func errorPayloadsFor(name string) []PayloadTemplate {
+	entries := payloadlib.Default().Select(
+		payloadlib.DefaultFilter(payloadlib.KindErrorTemplate, payloadlib.TechniqueError, name))
+
+	templates := make([]PayloadTemplate, 0, len(entries))
+	for _, e := range entries {
+		templates = append(templates, PayloadTemplate{
+			ID:       e.ID,
+			Name:     e.Name,
+			Template: e.Template,
+			Columns:  e.Columns,
+			DBMS:     e.DBMS[0],
+		})
+	}
+	return templates
+}
+
+// aliases maps the lower-cased names a DBMS goes by, on the command line
+// and in fingerprints, to its canonical name.
+var aliases = map[string]string{
+	"mysql":                "MySQL",
+	"mariadb":              "MariaDB",
+	"postgresql":           "PostgreSQL",
+	"postgres":             "PostgreSQL",
+	"pgsql":                "PostgreSQL",
+	"pg":                   "PostgreSQL",
+	"cockroachdb":          "CockroachDB",
+	"cockroach":            "CockroachDB",
+	"crdb":                 "CockroachDB",
+	"mssql":                "MSSQL",
+	"mssqlserver":          "MSSQL",
+	"sqlserver":            "MSSQL",
+	"sql server":           "MSSQL",
+	"microsoft sql server": "MSSQL",
+	"oracle":               "Oracle",
+	"sqlite":               "SQLite",
+	"sqlite3":              "SQLite",
+}
+
+// Registry returns a DBMS implementation by name. The name is matched
+// case-insensitively against the canonical names and their aliases (e.g.
+// "postgres", "pg", "sqlserver"). Returns nil if the name is not
+// recognized.
 func Registry(name string) DBMS {
-	switch name {
-	case "MySQL", "mysql":
+	switch aliases[strings.ToLower(strings.TrimSpace(name))] {
+	case "MySQL":
 		return &MySQL{}
-	case "PostgreSQL", "postgres", "postgresql":
+	case "MariaDB":
+		return &MariaDB{}
+	case "PostgreSQL":
 		return &PostgreSQL{}
-	case "MSSQL", "mssql", "sqlserver", "MSSQLServer":
+	case "CockroachDB":
+		return &CockroachDB{}
+	case "MSSQL":
 		return &MSSQL{}
-	case "Oracle", "oracle":
+	case "Oracle":
 		return &Oracle{}
-	case "SQLite", "sqlite", "sqlite3":
+	case "SQLite":
 		return &SQLite{}
 	default:
 		return nil
 	}
 }
+
+// containsPattern returns the LIKE pattern, quoted for d, matching
+// lower-cased names that contain keyword.
+func containsPattern(d DBMS, keyword string) string {
+	return d.QuoteString("%" + strings.ToLower(keyword) + "%")
+}
+
+// charCodes returns the code of every rune in s, formatted by code and
+// joined with sep.
+func charCodes(s, sep string, code func(r rune) string) string {
+	parts := make([]string, 0, len(s))
+	for _, r := range s {
+		parts = append(parts, code(r))
+	}
+	return strings.Join(parts, sep)
+}
+
+// EncodeLiterals rewrites every single-quoted string literal in sql with
+// d.HexString, for a filter that blocks quotes. Doubled quotes inside a
+// literal are unescaped first; quoted identifiers are left alone, as are
+// empty literals, which have no quote-free form.
+func EncodeLiterals(d DBMS, sql string) string {
+	var b strings.Builder
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch c {
+		case '"', '`':
+			end := strings.IndexByte(sql[i+1:], c)
+			if end < 0 {
+				b.WriteString(sql[i:])
+				return b.String()
+			}
+			b.WriteString(sql[i : i+end+2])
+			i += end + 1
+		case '\'':
+			var lit strings.Builder
+			j := i + 1
+			for ; j < len(sql); j++ {
+				if sql[j] != '\'' {
+					lit.WriteByte(sql[j])
+					continue
+				}
+				if j+1 < len(sql) && sql[j+1] == '\'' {
+					lit.WriteByte('\'')
+					j++
+					continue
+				}
+				break
+			}
+			if j >= len(sql) {
+				b.WriteString(sql[i:])
+				return b.String()
+			}
+			if lit.Len() == 0 {
+				b.WriteString("''")
+			} else {
+				b.WriteString(d.HexString(lit.String()))
+			}
+			i = j
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
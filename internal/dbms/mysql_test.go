@@ -106,6 +106,14 @@ func TestMySQLHostnameQuery(t *testing.T) {
 	}
 }
 
+func TestMySQLIsDBAQuery(t *testing.T) {
+	m := newMySQL()
+	got := m.IsDBAQuery()
+	if !strings.Contains(got, "information_schema.user_privileges") || !strings.Contains(got, "'SUPER'") {
+		t.Errorf("IsDBAQuery = %q, want a count of SUPER grants in information_schema.user_privileges", got)
+	}
+}
+
 // --- Enumeration queries ---
 
 func TestMySQLListDatabasesQuery(t *testing.T) {
@@ -156,8 +164,8 @@ func TestMySQLCountRowsQuery(t *testing.T) {
 	if !strings.Contains(got, "COUNT(*)") {
 		t.Errorf("CountRowsQuery should contain COUNT(*), got %q", got)
 	}
-	if !strings.Contains(got, "testdb.users") {
-		t.Errorf("CountRowsQuery should reference testdb.users, got %q", got)
+	if !strings.Contains(got, "`testdb`.`users`") {
+		t.Errorf("CountRowsQuery should reference `testdb`.`users`, got %q", got)
 	}
 }
 
@@ -167,8 +175,8 @@ func TestMySQLDumpQuery(t *testing.T) {
 	if !strings.Contains(got, "id,name,email") {
 		t.Errorf("DumpQuery should contain column list, got %q", got)
 	}
-	if !strings.Contains(got, "testdb.users") {
-		t.Errorf("DumpQuery should reference testdb.users, got %q", got)
+	if !strings.Contains(got, "`testdb`.`users`") {
+		t.Errorf("DumpQuery should reference `testdb`.`users`, got %q", got)
 	}
 	if !strings.Contains(got, "LIMIT 5") {
 		t.Errorf("DumpQuery should contain LIMIT 5, got %q", got)
@@ -208,6 +216,10 @@ func TestMySQLErrorPayloads(t *testing.T) {
 	if !names["updatexml"] {
 		t.Error("ErrorPayloads should include updatexml payload")
 	}
+	// The duplicate entry payload is the fallback after the XPATH ones.
+	if last := payloads[len(payloads)-1]; last.Name != "floor-rand" {
+		t.Errorf("last payload = %q, want floor-rand", last.Name)
+	}
 }
 
 // --- Time-based ---
@@ -253,6 +265,7 @@ func TestMySQLQuoteString(t *testing.T) {
 		{"a'b'c", "'a''b''c'"},
 		{"", "''"},
 		{"no quotes", "'no quotes'"},
+		{`C:\tmp\`, `'C:\\tmp\\'`},
 	}
 	for _, tt := range tests {
 		got := m.QuoteString(tt.input)
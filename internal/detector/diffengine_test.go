@@ -4,6 +4,8 @@ import (
 	"math"
 	"strings"
 	"testing"
+
+	"github.com/0x6d61/sqleech/internal/transport"
 )
 
 // --- Ratio tests ---
@@ -211,6 +213,28 @@ func TestDiffDetails_StatusCodeSame(t *testing.T) {
 	}
 }
 
+func TestDiffDetails_StatusCodeValidClasses(t *testing.T) {
+	engine := NewDiffEngine()
+	engine.ValidStatusCodes = []int{200, 403}
+
+	tests := []struct {
+		a, b int
+		want bool
+	}{
+		{200, 403, false}, // Both serve the application
+		{403, 403, false},
+		{403, 500, true},
+		{200, 302, true}, // 302 is not in the allowlist
+		{404, 500, false},
+	}
+	for _, tt := range tests {
+		result := engine.DiffDetails(&ResponseData{StatusCode: tt.a}, &ResponseData{StatusCode: tt.b})
+		if result.StatusCodeChanged != tt.want {
+			t.Errorf("%d -> %d: StatusCodeChanged = %v, want %v", tt.a, tt.b, result.StatusCodeChanged, tt.want)
+		}
+	}
+}
+
 func TestDiffDetails_ContentLengthDelta(t *testing.T) {
 	engine := NewDiffEngine()
 	a := &ResponseData{
@@ -488,3 +512,29 @@ func TestDiffDetails_HeaderMissingInOne(t *testing.T) {
 		t.Errorf("expected diff [value1, ''], got %v", diff)
 	}
 }
+
+func TestResponseRatio_Truncated(t *testing.T) {
+	d := NewDiffEngine()
+	page := []byte(strings.Repeat("<tr><td>row</td></tr>\n", 200) + "<footer>end</footer>\n")
+	cut := page[:1000]
+	other := []byte(strings.Repeat("<p>no results</p>\n", 100))
+
+	tests := []struct {
+		name string
+		a, b *transport.Response
+		want func(float64) bool
+	}{
+		{"cut page against the full one", &transport.Response{Body: page}, &transport.Response{Body: cut, Truncated: true}, func(r float64) bool { return r == 1 }},
+		{"both cut", &transport.Response{Body: cut, Truncated: true}, &transport.Response{Body: page[:1500], Truncated: true}, func(r float64) bool { return r == 1 }},
+		{"cut page against another", &transport.Response{Body: other}, &transport.Response{Body: cut, Truncated: true}, func(r float64) bool { return r < 0.5 }},
+		{"short complete page", &transport.Response{Body: page[:100]}, &transport.Response{Body: cut, Truncated: true}, func(r float64) bool { return r < 1 }},
+	}
+	for _, tt := range tests {
+		if got := d.ResponseRatio(tt.a, tt.b); !tt.want(got) {
+			t.Errorf("%s: ResponseRatio = %.2f", tt.name, got)
+		}
+	}
+	if got := d.Ratio(page, cut); got >= 0.9 {
+		t.Errorf("Ratio of a page and its cut = %.2f; the test needs them to look different whole", got)
+	}
+}
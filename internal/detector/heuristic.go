@@ -5,9 +5,16 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"slices"
+	"strings"
 
 	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/graphql"
+	"github.com/0x6d61/sqleech/internal/payloadlib"
+	"github.com/0x6d61/sqleech/internal/rawquery"
+	"github.com/0x6d61/sqleech/internal/reqparam"
 	"github.com/0x6d61/sqleech/internal/transport"
+	"github.com/0x6d61/sqleech/internal/xmlbody"
 )
 
 // defaultThreshold is the default similarity threshold. Responses with a
@@ -23,22 +30,80 @@ type HeuristicResult struct {
 	ErrorSignatures map[string][]string // DBMS -> matched errors
 	PageRatio       float64             // Similarity between baseline and error probe
 	IsInjectable    bool                // Overall heuristic assessment
+	Context         payloadlib.Context  // Suggested SQL context; empty if none stood out
+	Signals         []Signal            // One per probe sent, in catalog order
+}
+
+// limitNames are parameter names that usually end up in LIMIT or OFFSET.
+var limitNames = map[string]bool{
+	"limit": true, "offset": true, "page": true, "per_page": true,
+	"perpage": true, "page_size": true, "pagesize": true, "size": true,
+	"start": true, "skip": true, "top": true, "count": true, "rows": true,
+}
+
+// limitContext reports whether an integer parameter looks like a LIMIT or
+// OFFSET value: by name, or because a quote errors and so does the TRUE
+// probe, which no boundary would fix since LIMIT takes no condition.
+func limitContext(param engine.Parameter, quoteErrors, trueErrors bool) bool {
+	if param.Type != engine.TypeInteger {
+		return false
+	}
+	return limitNames[strings.ToLower(param.Name)] || (quoteErrors && trueErrors)
 }
 
 // HeuristicDetector performs quick probes to identify injectable parameters.
 type HeuristicDetector struct {
 	client     transport.Client
 	diffEngine *DiffEngine
-	threshold  float64 // Default 0.98 - responses below this ratio are "different"
+	threshold  float64          // Default 0.98 - responses below this ratio are "different"
+	probes     []HeuristicProbe // Sent in order for each parameter
 }
 
-// NewHeuristicDetector creates a new detector with the default threshold.
-func NewHeuristicDetector(client transport.Client, diffEngine *DiffEngine) *HeuristicDetector {
-	return &HeuristicDetector{
+// HeuristicOption configures a HeuristicDetector.
+type HeuristicOption func(*HeuristicDetector)
+
+// WithProbes appends probes to the catalog. Their signals are reported in
+// HeuristicResult.Signals.
+func WithProbes(probes ...HeuristicProbe) HeuristicOption {
+	return func(d *HeuristicDetector) {
+		d.probes = append(d.probes, probes...)
+	}
+}
+
+// WithoutProbes removes the named probes from the catalog. Removing a
+// built-in probe removes what it contributes to the result.
+func WithoutProbes(names ...string) HeuristicOption {
+	return func(d *HeuristicDetector) {
+		d.probes = slices.DeleteFunc(d.probes, func(p HeuristicProbe) bool {
+			return slices.Contains(names, p.Name())
+		})
+	}
+}
+
+// NewHeuristicDetector creates a new detector with the default threshold
+// and the built-in probe catalog: quote, boolean-true, boolean-false and
+// overflow.
+func NewHeuristicDetector(client transport.Client, diffEngine *DiffEngine, opts ...HeuristicOption) *HeuristicDetector {
+	d := &HeuristicDetector{
 		client:     client,
 		diffEngine: diffEngine,
 		threshold:  defaultThreshold,
+		probes:     defaultProbes(diffEngine),
+	}
+	for _, opt := range opts {
+		opt(d)
 	}
+	return d
+}
+
+// Probes returns the names of the probes in the catalog, in the order
+// they are sent.
+func (d *HeuristicDetector) Probes() []string {
+	names := make([]string, len(d.probes))
+	for i, p := range d.probes {
+		names[i] = p.Name()
+	}
+	return names
 }
 
 // DetectAll tests all parameters and returns heuristic results.
@@ -67,85 +132,88 @@ func (d *HeuristicDetector) DetectAll(ctx context.Context, target *engine.ScanTa
 	return results, nil
 }
 
-// detectParameter performs heuristic probes on a single parameter.
+// detectParameter sends each applicable probe of the catalog for param and
+// aggregates their signals. A probe that cannot be sent is recorded in its
+// signal and the others still count; only a cancelled context, or every
+// probe failing, is an error.
 func (d *HeuristicDetector) detectParameter(ctx context.Context, target *engine.ScanTarget, param engine.Parameter, baseline *transport.Response) (*HeuristicResult, error) {
+	var signals []Signal
+	var firstErr error
+	for _, p := range d.probes {
+		payload, ok := p.BuildPayload(param)
+		if !ok {
+			continue
+		}
+		var sig Signal
+		resp, err := d.sendProbe(ctx, target, param, payload)
+		if err != nil {
+			err = fmt.Errorf("%s probe: %w", p.Name(), err)
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			sig.Err = err
+		} else {
+			sig = p.Evaluate(baseline, resp)
+		}
+		sig.Probe, sig.Payload = p.Name(), payload
+		signals = append(signals, sig)
+	}
+	if firstErr != nil && !slices.ContainsFunc(signals, func(s Signal) bool { return s.Err == nil }) {
+		return nil, firstErr
+	}
+	return d.aggregate(param, baseline, signals), nil
+}
+
+// aggregate turns the signals of the built-in probes into a result; those
+// of other probes are only passed through in Signals. A parameter is
+// heuristically injectable if:
+//  1. the quote probe shows SQL error signatures, OR
+//  2. the TRUE probe matches the baseline and the FALSE probe differs, OR
+//  3. it looks like a LIMIT value and the FALSE probe changed the page: the
+//     TRUE probe cannot match there, so 2 never holds.
+//
+// Comparisons involving an anomalous response (e.g. a persistent 304 Not
+// Modified with an empty body) are discarded rather than scored, as are
+// probes that failed.
+func (d *HeuristicDetector) aggregate(param engine.Parameter, baseline *transport.Response, signals []Signal) *HeuristicResult {
 	result := &HeuristicResult{
 		Parameter:       param,
 		Baseline:        baseline,
 		ErrorSignatures: make(map[string][]string),
+		Signals:         signals,
 	}
-
-	// --- Probe 1: Error probe (append single quote) ---
-	errorPayload := param.Value + "'"
-	errorResp, err := d.sendProbe(ctx, target, param, errorPayload)
-	if err != nil {
-		return nil, fmt.Errorf("error probe: %w", err)
+	byName := make(map[string]Signal, len(signals))
+	for _, s := range signals {
+		if s.Err == nil {
+			byName[s.Probe] = s
+		}
 	}
 
-	// Check for SQL error signatures in the error probe response
-	sqlErrors := FindSQLErrors(errorResp.Body)
-	if len(sqlErrors) > 0 {
+	quote := byName[ProbeQuote]
+	if len(quote.SQLErrors) > 0 {
 		result.CausesError = true
-		result.ErrorSignatures = sqlErrors
+		result.ErrorSignatures = quote.SQLErrors
 	}
-
-	// Compute page ratio between baseline and error response
-	result.PageRatio = d.diffEngine.Ratio(baseline.Body, errorResp.Body)
-
-	// --- Probe 2: Boolean TRUE probe ---
-	var truePayload string
-	if param.Type == engine.TypeInteger || param.Type == engine.TypeFloat {
-		truePayload = param.Value + " AND 1=1"
-	} else {
-		truePayload = param.Value + "' AND '1'='1"
+	if quote.Comparable {
+		result.PageRatio = quote.Ratio
 	}
 
-	trueResp, err := d.sendProbe(ctx, target, param, truePayload)
-	if err != nil {
-		return nil, fmt.Errorf("boolean true probe: %w", err)
+	truth, falsity := byName[ProbeTrue], byName[ProbeFalse]
+	if limitContext(param, result.CausesError, len(truth.SQLErrors) > 0) {
+		result.Context = payloadlib.ContextLimit
 	}
 
-	trueRatio := d.diffEngine.Ratio(baseline.Body, trueResp.Body)
+	comparable := truth.Comparable && falsity.Comparable
+	result.DynamicContent = comparable && falsity.Ratio < d.threshold
 
-	// --- Probe 3: Boolean FALSE probe ---
-	var falsePayload string
-	if param.Type == engine.TypeInteger || param.Type == engine.TypeFloat {
-		falsePayload = param.Value + " AND 1=2"
-	} else {
-		falsePayload = param.Value + "' AND '1'='2"
-	}
+	booleanInjectable := comparable && truth.Ratio >= d.threshold && falsity.Ratio < d.threshold
+	limitDynamic := result.Context == payloadlib.ContextLimit && result.DynamicContent
 
-	falseResp, err := d.sendProbe(ctx, target, param, falsePayload)
-	if err != nil {
-		return nil, fmt.Errorf("boolean false probe: %w", err)
-	}
-
-	falseRatio := d.diffEngine.Ratio(baseline.Body, falseResp.Body)
-
-	// Check if response is dynamic (FALSE probe differs from baseline)
-	if d.diffEngine.IsDifferent(baseline.Body, falseResp.Body, d.threshold) {
-		result.DynamicContent = true
-	}
-
-	// --- Probe 4: Numeric overflow (only for integer types) ---
-	if param.Type == engine.TypeInteger {
-		_, err := d.sendProbe(ctx, target, param, "99999999999")
-		if err != nil {
-			return nil, fmt.Errorf("numeric overflow probe: %w", err)
-		}
-		// Overflow response is collected but not currently used in the
-		// injectable decision -- future enhancements may leverage it.
-	}
-
-	// --- Decision logic ---
-	// A parameter is heuristically injectable if:
-	// 1. Error probe causes SQL error signatures, OR
-	// 2. TRUE probe matches baseline AND FALSE probe differs from baseline
-	booleanInjectable := trueRatio >= d.threshold && falseRatio < d.threshold
-
-	result.IsInjectable = result.CausesError || booleanInjectable
-
-	return result, nil
+	result.IsInjectable = result.CausesError || booleanInjectable || limitDynamic
+	return result
 }
 
 // sendProbe sends a request with a modified parameter value and returns the response.
@@ -157,6 +225,9 @@ func (d *HeuristicDetector) sendProbe(ctx context.Context, target *engine.ScanTa
 // buildProbeRequest creates a request with the given parameter modified to the payload value.
 // If param.Location == LocationQuery, the URL query parameter is modified.
 // If param.Location == LocationBody, the POST body parameter is modified.
+// If param.Location == LocationCookie, the cookie is set, URL-encoded.
+// If param.Location == LocationHeader, the header is set as is.
+// If param.Location == LocationPath, the path segment is set, path-escaped.
 // All other parameters are preserved unchanged.
 func buildProbeRequest(target *engine.ScanTarget, param engine.Parameter, payload string) *transport.Request {
 	req := &transport.Request{
@@ -182,11 +253,24 @@ func buildProbeRequest(target *engine.ScanTarget, param engine.Parameter, payloa
 		}
 	}
 
+	payload = param.Encode(payload)
 	switch param.Location {
 	case engine.LocationQuery:
 		req.URL = modifyQueryParam(target.URL, param.Name, payload)
 	case engine.LocationBody:
 		req.Body = modifyBodyParam(target.Body, param.Name, payload)
+	case engine.LocationPath:
+		req.URL = reqparam.SetPath(target.URL, param.Name, payload)
+	case engine.LocationCookie:
+		req.Cookies = reqparam.SetCookie(req.Cookies, param.Name, payload)
+	case engine.LocationHeader:
+		req.Headers = reqparam.SetHeader(req.Headers, param.Name, payload)
+	case engine.LocationXML:
+		req.Body = modifyXMLParam(target.Body, param.Name, payload, target.RawXML)
+	case engine.LocationGraphQL:
+		req.Body = modifyGraphQLParam(target.Body, param.Name, payload)
+	case engine.LocationCustom:
+		target.Markers.Apply(req, map[string]string{param.Name: payload})
 	}
 
 	return req
@@ -220,28 +304,40 @@ func buildBaselineRequest(target *engine.ScanTarget) *transport.Request {
 	return req
 }
 
-// modifyQueryParam replaces the value of a named query parameter in the URL.
+// modifyQueryParam replaces the value of a named query parameter in the URL,
+// leaving the order and encoding of the other parameters as they were.
 func modifyQueryParam(rawURL, paramName, newValue string) string {
 	parsed, err := url.Parse(rawURL)
 	if err != nil {
 		return rawURL
 	}
-
-	q := parsed.Query()
-	q.Set(paramName, newValue)
-	parsed.RawQuery = q.Encode()
-
+	parsed.RawQuery = rawquery.Set(parsed.RawQuery, paramName, newValue)
 	return parsed.String()
 }
 
 // modifyBodyParam replaces the value of a named parameter in a
-// application/x-www-form-urlencoded body.
+// application/x-www-form-urlencoded body, leaving the order and encoding
+// of the other parameters as they were.
 func modifyBodyParam(body, paramName, newValue string) string {
-	values, err := url.ParseQuery(body)
+	return rawquery.Set(body, paramName, newValue)
+}
+
+// modifyXMLParam replaces the text of the leaf element at path in an XML
+// body, leaving the body unchanged when there is none.
+func modifyXMLParam(body, path, newValue string, raw bool) string {
+	modified, err := xmlbody.Set(body, path, newValue, raw)
 	if err != nil {
 		return body
 	}
+	return modified
+}
 
-	values.Set(paramName, newValue)
-	return values.Encode()
+// modifyGraphQLParam sets the named variable of a GraphQL request body,
+// leaving the body unchanged when there is none.
+func modifyGraphQLParam(body, path, newValue string) string {
+	modified, err := graphql.Set(body, path, newValue)
+	if err != nil {
+		return body
+	}
+	return modified
 }
@@ -2,14 +2,20 @@ package detector
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/payloadlib"
 	"github.com/0x6d61/sqleech/internal/transport"
 )
 
@@ -285,6 +291,50 @@ func TestDetectAll_MultipleParameters(t *testing.T) {
 	}
 }
 
+func TestDetectAll_LimitContext(t *testing.T) {
+	// "n" and "limit" both end up in LIMIT, which takes integers only: "n"
+	// shows the database error, "limit" swallows it. "id" is not used.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if _, err := strconv.Atoi(q.Get("n")); err != nil {
+			fmt.Fprintf(w, `<html><body>Error: You have an error in your SQL syntax near '%s' at line 1</body></html>`, q.Get("n"))
+			return
+		}
+		if _, err := strconv.Atoi(q.Get("limit")); err != nil {
+			fmt.Fprint(w, `<html><body><h1>Catalog</h1><p>No more products.</p></body></html>`)
+			return
+		}
+		fmt.Fprint(w, `<html><body><h1>Catalog</h1><p>Widget</p><p>Gadget</p><p>Sprocket</p></body></html>`)
+	}))
+	defer srv.Close()
+
+	target := &engine.ScanTarget{
+		URL:    srv.URL + "/catalog?n=3&limit=3&id=3",
+		Method: "GET",
+		Parameters: []engine.Parameter{
+			{Name: "n", Value: "3", Location: engine.LocationQuery, Type: engine.TypeInteger},
+			{Name: "limit", Value: "3", Location: engine.LocationQuery, Type: engine.TypeInteger},
+			{Name: "id", Value: "3", Location: engine.LocationQuery, Type: engine.TypeInteger},
+		},
+	}
+	results, err := NewHeuristicDetector(newTestClient(), NewDiffEngine()).DetectAll(context.Background(), target)
+	if err != nil {
+		t.Fatalf("DetectAll returned error: %v", err)
+	}
+
+	want := map[string]payloadlib.Context{"n": payloadlib.ContextLimit, "limit": payloadlib.ContextLimit, "id": ""}
+	for _, r := range results {
+		if r.Context != want[r.Parameter.Name] {
+			t.Errorf("%s: Context = %q, want %q", r.Parameter.Name, r.Context, want[r.Parameter.Name])
+		}
+		// "limit" is injectable on the hint alone: no TRUE probe can match
+		// its baseline, and no error shows.
+		if r.IsInjectable != (r.Context != "") {
+			t.Errorf("%s: IsInjectable = %v", r.Parameter.Name, r.IsInjectable)
+		}
+	}
+}
+
 func TestBuildProbeRequest_QueryParam(t *testing.T) {
 	target := &engine.ScanTarget{
 		URL:    "http://example.com/page?id=1&name=test",
@@ -384,6 +434,153 @@ func TestBuildProbeRequest_BodyParam(t *testing.T) {
 	}
 }
 
+func TestBuildProbeRequest_CookieParam(t *testing.T) {
+	target := &engine.ScanTarget{
+		URL:     "http://example.com/cart?page=2",
+		Method:  "GET",
+		Cookies: map[string]string{"cart_id": "7", "lang": "en"},
+	}
+
+	param := engine.Parameter{
+		Name:     "cart_id",
+		Value:    "7",
+		Location: engine.LocationCookie,
+		Type:     engine.TypeInteger,
+	}
+
+	req := buildProbeRequest(target, param, "7' AND '1'='1;")
+
+	// The payload is URL-encoded, as cookie values cannot carry it raw
+	if got := req.Cookies["cart_id"]; got != "7%27+AND+%271%27%3D%271%3B" {
+		t.Errorf("expected cart_id URL-encoded, got %q", got)
+	}
+
+	// Other cookies, the URL and the target's own cookies are unchanged
+	if req.Cookies["lang"] != "en" || req.URL != target.URL || target.Cookies["cart_id"] != "7" {
+		t.Errorf("expected only the probed cookie changed, got %+v (target %+v)", req, target.Cookies)
+	}
+}
+
+func TestBuildProbeRequest_PathParam(t *testing.T) {
+	target := &engine.ScanTarget{
+		URL:    "http://example.com/api/users/123/orders?sort=a%20b",
+		Method: "GET",
+	}
+
+	param := engine.Parameter{
+		Name:     "/api/users/*/orders",
+		Value:    "123",
+		Location: engine.LocationPath,
+		Type:     engine.TypeInteger,
+	}
+
+	// Spaces and slashes are escaped, keeping the payload in its segment
+	req := buildProbeRequest(target, param, "123 AND 1=1/*")
+	if want := "http://example.com/api/users/123%20AND%201=1%2F%2A/orders?sort=a%20b"; req.URL != want {
+		t.Errorf("expected URL %q, got %q", want, req.URL)
+	}
+}
+
+func TestBuildProbeRequest_XMLParam(t *testing.T) {
+	target := &engine.ScanTarget{
+		URL:         "http://example.com/soap",
+		Method:      "POST",
+		Body:        "<Envelope><Body><GetUser><id>7</id><name>bob</name></GetUser></Body></Envelope>",
+		ContentType: "text/xml",
+	}
+
+	param := engine.Parameter{
+		Name:     "/Envelope/Body/GetUser/id",
+		Value:    "7",
+		Location: engine.LocationXML,
+		Type:     engine.TypeInteger,
+	}
+
+	req := buildProbeRequest(target, param, "7 AND 1<2")
+	want := "<Envelope><Body><GetUser><id>7 AND 1&lt;2</id><name>bob</name></GetUser></Body></Envelope>"
+	if req.Body != want || req.ContentType != "text/xml" {
+		t.Errorf("expected the id element escaped and replaced, got %q (%q)", req.Body, req.ContentType)
+	}
+
+	target.RawXML = true
+	req = buildProbeRequest(target, param, "7 AND 1<2")
+	if !strings.Contains(req.Body, "<id>7 AND 1<2</id>") {
+		t.Errorf("expected the payload spliced raw, got %q", req.Body)
+	}
+}
+
+func TestBuildProbeRequest_GraphQLParam(t *testing.T) {
+	query := `query($filter: Filter) { products(filter: $filter) { id name } }`
+	target := &engine.ScanTarget{
+		URL:         "http://example.com/graphql",
+		Method:      "POST",
+		Body:        `{"query":"` + query + `","variables":{"filter":{"id":"1"},"first":10}}`,
+		ContentType: "application/json",
+	}
+
+	param := engine.Parameter{
+		Name:     "filter.id",
+		Value:    "1",
+		Location: engine.LocationGraphQL,
+		Type:     engine.TypeInteger,
+	}
+
+	req := buildProbeRequest(target, param, `1' AND "a"<"b`)
+	var got struct {
+		Query     string
+		Variables struct {
+			Filter struct{ ID string }
+			First  int
+		}
+	}
+	if err := json.Unmarshal([]byte(req.Body), &got); err != nil {
+		t.Fatalf("expected valid JSON, got %q: %v", req.Body, err)
+	}
+	if got.Query != query || got.Variables.Filter.ID != `1' AND "a"<"b` || got.Variables.First != 10 {
+		t.Errorf("expected only filter.id replaced, got %q", req.Body)
+	}
+}
+
+func TestBuildProbeRequest_PreservesRawParams(t *testing.T) {
+	target := &engine.ScanTarget{
+		URL:         "http://example.com/page?b=2&a=1&ts=2026-10-15T09:00:00+02:00&q=C%2b%2b",
+		Method:      "POST",
+		Body:        "z=last&sig=ab+cd/ef=&id=1",
+		ContentType: "application/x-www-form-urlencoded",
+	}
+
+	req := buildProbeRequest(target, engine.Parameter{Name: "a", Value: "1", Location: engine.LocationQuery}, "1'")
+	if want := "http://example.com/page?b=2&a=1%27&ts=2026-10-15T09:00:00+02:00&q=C%2b%2b"; req.URL != want {
+		t.Errorf("URL = %q, want %q", req.URL, want)
+	}
+
+	req = buildProbeRequest(target, engine.Parameter{Name: "id", Value: "1", Location: engine.LocationBody}, "1 AND 1=1")
+	if want := "z=last&sig=ab+cd/ef=&id=1+AND+1%3D1"; req.Body != want {
+		t.Errorf("Body = %q, want %q", req.Body, want)
+	}
+}
+
+func TestBuildProbeRequest_Base64Param(t *testing.T) {
+	target := &engine.ScanTarget{
+		URL:    "http://example.com/page?data=MTIzNA%3D%3D",
+		Method: "GET",
+	}
+
+	param := engine.Parameter{
+		Name:     "data",
+		Value:    "1234",
+		Location: engine.LocationQuery,
+		Type:     engine.TypeInteger,
+		Base64:   true,
+	}
+
+	req := buildProbeRequest(target, param, "1234'")
+	parsed, _ := url.Parse(req.URL)
+	if got := parsed.Query().Get("data"); got != "MTIzNCc=" {
+		t.Errorf("expected the payload base64-encoded, got %q", got)
+	}
+}
+
 func TestBuildBaselineRequest(t *testing.T) {
 	target := &engine.ScanTarget{
 		URL:         "http://example.com/page?id=1",
@@ -582,3 +779,339 @@ func TestDetectAll_PostBodyParameter(t *testing.T) {
 		t.Errorf("expected 1 injectable parameter, got %d", injectableCount)
 	}
 }
+
+// validatorClient simulates a meddling proxy that adds If-None-Match to every
+// outgoing request.
+type validatorClient struct{ transport.Client }
+
+func (c *validatorClient) Do(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	req = req.Clone()
+	if req.Headers == nil {
+		req.Headers = make(map[string]string)
+	}
+	req.Headers["If-None-Match"] = `"v1"`
+	return c.Client.Do(ctx, req)
+}
+
+// notModifiedClient returns a persistent 304 (as the transport reports it
+// after a failed cache-busting retry) for requests whose URL contains match.
+type notModifiedClient struct {
+	transport.Client
+	match     string
+	anomalous bool
+}
+
+func (c *notModifiedClient) Do(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	if !strings.Contains(req.URL, c.match) {
+		return c.Client.Do(ctx, req)
+	}
+	resp := &transport.Response{StatusCode: http.StatusNotModified}
+	if c.anomalous {
+		resp.Anomalies = []transport.Anomaly{transport.AnomalyNotModified}
+	}
+	return resp, nil
+}
+
+// newETagServer serves a static page and answers 304 with an empty body to
+// requests carrying a matching If-None-Match.
+func newETagServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fmt.Fprint(w, `<html><body><h1>Static Page</h1><p>This content never changes.</p></body></html>`)
+	}))
+}
+
+func TestDetectAll_ConditionalHeadersNoFalsePositive(t *testing.T) {
+	srv := newETagServer()
+	defer srv.Close()
+
+	client := &validatorClient{Client: newTestClient()}
+	detector := NewHeuristicDetector(client, NewDiffEngine())
+
+	results, err := detector.DetectAll(context.Background(), &engine.ScanTarget{
+		URL:    srv.URL + "/?id=1",
+		Method: "GET",
+		Parameters: []engine.Parameter{
+			{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+		},
+	})
+	if err != nil {
+		t.Fatalf("DetectAll returned error: %v", err)
+	}
+	if results[0].IsInjectable || results[0].DynamicContent {
+		t.Errorf("injected validators produced a false positive: %+v", results[0])
+	}
+}
+
+func TestDetectAll_NotModifiedDiscarded(t *testing.T) {
+	srv := newVulnSafeServer()
+	defer srv.Close()
+
+	target := &engine.ScanTarget{
+		URL:    srv.URL + "/safe?id=1",
+		Method: "GET",
+		Parameters: []engine.Parameter{
+			{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+		},
+	}
+	// "1=2" only appears (URL-encoded) in the FALSE probe.
+	falseProbe := "1%3D2"
+
+	// Without the anomaly flag, the empty FALSE response looks like a
+	// boolean difference: this is the false positive being guarded against.
+	plain := &notModifiedClient{Client: newTestClient(), match: falseProbe}
+	results, err := NewHeuristicDetector(plain, NewDiffEngine()).DetectAll(context.Background(), target)
+	if err != nil {
+		t.Fatalf("DetectAll returned error: %v", err)
+	}
+	if !results[0].IsInjectable {
+		t.Fatal("test setup: expected an unflagged 304 to look injectable")
+	}
+
+	flagged := &notModifiedClient{Client: newTestClient(), match: falseProbe, anomalous: true}
+	results, err = NewHeuristicDetector(flagged, NewDiffEngine()).DetectAll(context.Background(), target)
+	if err != nil {
+		t.Fatalf("DetectAll returned error: %v", err)
+	}
+	if results[0].IsInjectable || results[0].DynamicContent {
+		t.Errorf("anomalous 304 should be discarded, got %+v", results[0])
+	}
+}
+
+func TestDetectAll_TruncatedDiscarded(t *testing.T) {
+	// A static page streamed in two halves. The FALSE probe's second half
+	// comes too late for the client timeout, so its body is cut short.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><h1>Static Page</h1>`)
+		w.(http.Flusher).Flush()
+		if strings.Contains(r.URL.Query().Get("id"), "1=2") {
+			select {
+			case <-time.After(2 * time.Second):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		fmt.Fprint(w, `<p>This content never changes.</p></body></html>`)
+	}))
+	defer srv.Close()
+
+	client, err := transport.NewClient(transport.ClientOptions{Timeout: 300 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	target := &engine.ScanTarget{
+		URL:    srv.URL + "/?id=1",
+		Method: "GET",
+		Parameters: []engine.Parameter{
+			{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+		},
+	}
+	results, err := NewHeuristicDetector(client, NewDiffEngine()).DetectAll(context.Background(), target)
+	if err != nil {
+		t.Fatalf("DetectAll returned error: %v", err)
+	}
+	if results[0].IsInjectable || results[0].DynamicContent {
+		t.Errorf("truncated FALSE probe should be discarded, got %+v", results[0])
+	}
+}
+
+// failingClient fails requests whose URL fail accepts, as if the
+// connection dropped.
+type failingClient struct {
+	transport.Client
+	fail func(url string) bool
+}
+
+func (c *failingClient) Do(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	if c.fail(req.URL) {
+		return nil, errors.New("connection reset by peer")
+	}
+	return c.Client.Do(ctx, req)
+}
+
+// reflectProbe is a custom probe that looks for its marker in the page.
+type reflectProbe struct{}
+
+func (reflectProbe) Name() string { return "reflect" }
+
+func (reflectProbe) BuildPayload(param engine.Parameter) (string, bool) {
+	return param.Value + "zq7x", true
+}
+
+func (reflectProbe) Evaluate(_, resp *transport.Response) Signal {
+	return Signal{Comparable: true, Ratio: float64(strings.Count(resp.BodyString(), "zq7x"))}
+}
+
+func TestNewHeuristicDetector_Catalog(t *testing.T) {
+	de := NewDiffEngine()
+	tests := []struct {
+		name string
+		opts []HeuristicOption
+		want []string
+	}{
+		{"default", nil, []string{ProbeQuote, ProbeTrue, ProbeFalse, ProbeOverflow}},
+		{"added", []HeuristicOption{WithProbes(reflectProbe{})}, []string{ProbeQuote, ProbeTrue, ProbeFalse, ProbeOverflow, "reflect"}},
+		{"removed", []HeuristicOption{WithoutProbes(ProbeOverflow, ProbeQuote)}, []string{ProbeTrue, ProbeFalse}},
+		{"added then removed", []HeuristicOption{WithProbes(reflectProbe{}), WithoutProbes("reflect")}, []string{ProbeQuote, ProbeTrue, ProbeFalse, ProbeOverflow}},
+	}
+	for _, tt := range tests {
+		got := NewHeuristicDetector(newTestClient(), de, tt.opts...).Probes()
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Probes() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDetectAll_CustomProbeSignals(t *testing.T) {
+	srv := newVulnSafeServer()
+	defer srv.Close()
+
+	target := &engine.ScanTarget{
+		URL:    srv.URL + "/safe?id=1",
+		Method: "GET",
+		Parameters: []engine.Parameter{
+			{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeString},
+		},
+	}
+	d := NewHeuristicDetector(newTestClient(), NewDiffEngine(), WithProbes(reflectProbe{}))
+	results, err := d.DetectAll(context.Background(), target)
+	if err != nil {
+		t.Fatalf("DetectAll returned error: %v", err)
+	}
+
+	// The string parameter skips the overflow probe.
+	var names []string
+	for _, s := range results[0].Signals {
+		names = append(names, s.Probe)
+	}
+	if want := []string{ProbeQuote, ProbeTrue, ProbeFalse, "reflect"}; !slices.Equal(names, want) {
+		t.Fatalf("signals from %v, want %v", names, want)
+	}
+	if s := results[0].Signals[3]; s.Payload != "1zq7x" || s.Ratio != 0 {
+		t.Errorf("reflect signal = %+v, want payload 1zq7x and no reflection", s)
+	}
+	if results[0].IsInjectable {
+		t.Error("a custom probe's signal should not change the decision")
+	}
+}
+
+func TestDetectAll_ProbeIsolation(t *testing.T) {
+	srv := newVulnSafeServer()
+	defer srv.Close()
+
+	target := &engine.ScanTarget{
+		URL:    srv.URL + "/vuln?id=1",
+		Method: "GET",
+		Parameters: []engine.Parameter{
+			{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+		},
+	}
+
+	// Only the quote probe fails: the boolean probes still find the parameter.
+	client := &failingClient{Client: newTestClient(), fail: func(u string) bool {
+		return strings.HasSuffix(u, "id=1%27")
+	}}
+	results, err := NewHeuristicDetector(client, NewDiffEngine()).DetectAll(context.Background(), target)
+	if err != nil {
+		t.Fatalf("DetectAll returned error: %v", err)
+	}
+	r := results[0]
+	if r.CausesError {
+		t.Error("CausesError set from a probe that failed")
+	}
+	if !r.IsInjectable || !r.DynamicContent {
+		t.Errorf("expected the boolean probes to carry the result, got %+v", r)
+	}
+	if r.Signals[0].Probe != ProbeQuote || r.Signals[0].Err == nil {
+		t.Errorf("Signals[0] = %+v, want the failed quote probe", r.Signals[0])
+	}
+	for _, s := range r.Signals[1:] {
+		if s.Err != nil {
+			t.Errorf("%s probe failed: %v", s.Probe, s.Err)
+		}
+	}
+
+	// With every probe failing there is nothing to go on.
+	client = &failingClient{Client: newTestClient(), fail: func(u string) bool {
+		return u != target.URL
+	}}
+	d := NewHeuristicDetector(client, NewDiffEngine())
+	if _, err := d.DetectAll(context.Background(), target); err == nil || !strings.Contains(err.Error(), "quote probe") {
+		t.Errorf("DetectAll error = %v, want the quote probe's failure", err)
+	}
+}
+
+func TestHeuristicDetector_Aggregate(t *testing.T) {
+	mysqlErr := map[string][]string{"MySQL": {"You have an error in your SQL syntax"}}
+	intParam := engine.Parameter{Name: "id", Value: "1", Type: engine.TypeInteger}
+	limitParam := engine.Parameter{Name: "limit", Value: "3", Type: engine.TypeInteger}
+	page := func(probe string, ratio float64) Signal {
+		return Signal{Probe: probe, Ratio: ratio, Comparable: true}
+	}
+
+	tests := []struct {
+		name       string
+		param      engine.Parameter
+		signals    []Signal
+		causes     bool
+		dynamic    bool
+		injectable bool
+		context    payloadlib.Context
+	}{
+		{
+			name:    "static page",
+			param:   intParam,
+			signals: []Signal{page(ProbeQuote, 1), page(ProbeTrue, 1), page(ProbeFalse, 1)},
+		},
+		{
+			name:       "quote error",
+			param:      intParam,
+			signals:    []Signal{{Probe: ProbeQuote, SQLErrors: mysqlErr}, page(ProbeTrue, 1), page(ProbeFalse, 1)},
+			causes:     true,
+			injectable: true,
+		},
+		{
+			name:       "boolean difference",
+			param:      intParam,
+			signals:    []Signal{page(ProbeQuote, 0.5), page(ProbeTrue, 1), page(ProbeFalse, 0.4)},
+			dynamic:    true,
+			injectable: true,
+		},
+		{
+			name:    "both pages differ",
+			param:   intParam,
+			signals: []Signal{page(ProbeQuote, 0.5), page(ProbeTrue, 0.4), page(ProbeFalse, 0.4)},
+			dynamic: true,
+		},
+		{
+			name:    "anomalous FALSE probe",
+			param:   intParam,
+			signals: []Signal{page(ProbeQuote, 1), page(ProbeTrue, 1), {Probe: ProbeFalse}},
+		},
+		{
+			name:    "failed FALSE probe",
+			param:   intParam,
+			signals: []Signal{page(ProbeQuote, 1), page(ProbeTrue, 1), {Probe: ProbeFalse, Ratio: 0.1, Comparable: true, Err: errors.New("timeout")}},
+		},
+		{
+			name:       "limit value",
+			param:      limitParam,
+			signals:    []Signal{page(ProbeQuote, 1), page(ProbeTrue, 0.4), page(ProbeFalse, 0.4)},
+			dynamic:    true,
+			injectable: true,
+			context:    payloadlib.ContextLimit,
+		},
+	}
+	d := NewHeuristicDetector(nil, NewDiffEngine())
+	for _, tt := range tests {
+		r := d.aggregate(tt.param, &transport.Response{}, tt.signals)
+		if r.CausesError != tt.causes || r.DynamicContent != tt.dynamic || r.IsInjectable != tt.injectable || r.Context != tt.context {
+			t.Errorf("%s: got CausesError=%v DynamicContent=%v IsInjectable=%v Context=%q, want %v %v %v %q",
+				tt.name, r.CausesError, r.DynamicContent, r.IsInjectable, r.Context, tt.causes, tt.dynamic, tt.injectable, tt.context)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package detector
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"reflect"
+	"strings"
+)
+
+// DefaultVolatileKeys lists the JSON object keys NewJSONComparator ignores:
+// per-response values such as timestamps and request IDs that APIs add to
+// every body.
+var DefaultVolatileKeys = []string{
+	"timestamp", "time", "date", "now", "generatedAt", "servedAt",
+	"requestId", "traceId", "spanId", "correlationId", "nonce",
+	"took", "elapsed", "duration", "latency",
+}
+
+// DefaultVolatilePaths lists the paths NewJSONComparator ignores: GraphQL
+// servers report per-request timings under extensions.tracing (Apollo
+// tracing), resolver by resolver.
+var DefaultVolatilePaths = []string{"extensions.tracing"}
+
+// JSONComparator compares JSON response bodies structurally: two bodies
+// are the same when they hold the same keys and values once VolatileKeys
+// are removed, however they are formatted. Byte similarity is unreliable
+// for APIs, whose bodies are often a single line that a request ID changes
+// on every response.
+type JSONComparator struct {
+	// VolatileKeys are removed from objects at any depth before comparing.
+	// Keys match case-insensitively, ignoring "_" and "-", so "requestId"
+	// also covers "request_id" and "Request-ID".
+	VolatileKeys []string
+
+	// VolatilePaths are removed before comparing: dot-separated keys from
+	// the root object, e.g. "extensions.tracing". They match exactly.
+	VolatilePaths []string
+}
+
+// NewJSONComparator creates a JSONComparator ignoring DefaultVolatileKeys
+// and DefaultVolatilePaths.
+func NewJSONComparator() *JSONComparator {
+	return &JSONComparator{VolatileKeys: DefaultVolatileKeys, VolatilePaths: DefaultVolatilePaths}
+}
+
+// IsJSON reports whether headers declare a JSON body: application/json or
+// a structured +json type such as application/problem+json.
+func IsJSON(headers map[string][]string) bool {
+	for k, v := range headers {
+		if !strings.EqualFold(k, "Content-Type") || len(v) == 0 {
+			continue
+		}
+		mt, _, err := mime.ParseMediaType(v[0])
+		if err != nil {
+			return false
+		}
+		return mt == "application/json" || strings.HasSuffix(mt, "+json")
+	}
+	return false
+}
+
+// Equal reports whether a and b hold the same JSON value, ignoring
+// VolatileKeys. ok is false when either body is not valid JSON, in which
+// case equal is meaningless.
+func (c *JSONComparator) Equal(a, b []byte) (equal, ok bool) {
+	va, okA := decodeJSON(a)
+	vb, okB := decodeJSON(b)
+	if !okA || !okB {
+		return false, false
+	}
+	volatile := make(map[string]bool, len(c.VolatileKeys))
+	for _, k := range c.VolatileKeys {
+		volatile[normalizeKey(k)] = true
+	}
+	va, vb = stripVolatile(va, volatile), stripVolatile(vb, volatile)
+	for _, path := range c.VolatilePaths {
+		keys := strings.Split(path, ".")
+		stripPath(va, keys)
+		stripPath(vb, keys)
+	}
+	return reflect.DeepEqual(va, vb), true
+}
+
+// stripPath removes the value at keys from v, a copy stripVolatile made.
+func stripPath(v any, keys []string) {
+	for i, k := range keys {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return
+		}
+		if i == len(keys)-1 {
+			delete(obj, k)
+			return
+		}
+		v = obj[k]
+	}
+}
+
+// decodeJSON decodes a single JSON value, keeping numbers as json.Number
+// so that large IDs compare exactly.
+func decodeJSON(body []byte) (any, bool) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, false
+	}
+	if dec.More() {
+		return nil, false
+	}
+	return v, true
+}
+
+// stripVolatile returns v without the object keys whose normalized form is
+// in volatile.
+func stripVolatile(v any, volatile map[string]bool) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			if !volatile[normalizeKey(k)] {
+				out[k] = stripVolatile(e, volatile)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = stripVolatile(e, volatile)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// normalizeKey lowercases key and drops "_" and "-".
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+}
@@ -0,0 +1,118 @@
+package detector
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsJSON(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{"application/json", true},
+		{"application/json; charset=utf-8", true},
+		{"Application/JSON", true},
+		{"application/problem+json", true},
+		{"text/html; charset=utf-8", false},
+		{"text/plain", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsJSON(map[string][]string{"content-type": {tt.contentType}}); got != tt.want {
+			t.Errorf("IsJSON(%q) = %v, want %v", tt.contentType, got, tt.want)
+		}
+	}
+	if IsJSON(nil) {
+		t.Error("IsJSON(nil) = true, want false")
+	}
+}
+
+func TestJSONComparator_Equal(t *testing.T) {
+	base := `{"data":[{"id":1,"name":"Widget"},{"id":2,"name":"Gadget"}],"count":2,"requestId":"req-5f1c9a0e","timestamp":"2026-10-15T09:12:44.118Z"}`
+	tests := []struct {
+		name      string
+		other     string
+		wantEqual bool
+		wantOK    bool
+	}{
+		{
+			"volatile keys only",
+			`{"timestamp":"2026-10-15T09:12:45.902Z","requestId":"req-77a02d4b","count":2,"data":[{"name":"Widget","id":1},{"id":2,"name":"Gadget"}]}`,
+			true, true,
+		},
+		{
+			"reformatted",
+			"{\n  \"data\": [{\"id\": 1, \"name\": \"Widget\"}, {\"id\": 2, \"name\": \"Gadget\"}],\n  \"count\": 2\n}",
+			true, true,
+		},
+		{
+			"shorter data array",
+			`{"data":[{"id":1,"name":"Widget"}],"count":1,"requestId":"req-5f1c9a0e","timestamp":"2026-10-15T09:12:44.118Z"}`,
+			false, true,
+		},
+		{
+			"empty data array",
+			`{"data":[],"count":0,"requestId":"req-0b3e11c2","timestamp":"2026-10-15T09:12:46.004Z"}`,
+			false, true,
+		},
+		{
+			"changed value",
+			`{"data":[{"id":1,"name":"Widget"},{"id":2,"name":"Sprocket"}],"count":2}`,
+			false, true,
+		},
+		{
+			"extra key",
+			`{"data":[{"id":1,"name":"Widget"},{"id":2,"name":"Gadget"}],"count":2,"error":null}`,
+			false, true,
+		},
+		{
+			"html error page",
+			`<html><body><h1>Error</h1></body></html>`,
+			false, false,
+		},
+	}
+	c := NewJSONComparator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			equal, ok := c.Equal([]byte(base), []byte(tt.other))
+			if equal != tt.wantEqual || ok != tt.wantOK {
+				t.Errorf("Equal() = %v, %v, want %v, %v", equal, ok, tt.wantEqual, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestJSONComparator_VolatileKeyForms(t *testing.T) {
+	c := &JSONComparator{VolatileKeys: []string{"requestId"}}
+	a := `{"meta":{"request_id":"a1"},"items":[{"Request-ID":"b2","sku":"X-1"}]}`
+	b := `{"meta":{"request_id":"c3"},"items":[{"Request-ID":"d4","sku":"X-1"}]}`
+	if equal, ok := c.Equal([]byte(a), []byte(b)); !equal || !ok {
+		t.Errorf("Equal() = %v, %v, want nested volatile keys ignored in every form", equal, ok)
+	}
+
+	c.VolatileKeys = nil
+	if equal, _ := c.Equal([]byte(a), []byte(b)); equal {
+		t.Error("Equal() without VolatileKeys = true, want the request IDs compared")
+	}
+}
+
+func TestJSONComparator_VolatilePaths(t *testing.T) {
+	a := `{"data":{"products":[{"id":"1"}]},"extensions":{"tracing":{"version":1,"startTime":"2026-10-15T09:12:44.118Z","execution":{"resolvers":[{"path":["products"],"startOffset":8021}]}},"cost":3}}`
+	b := `{"data":{"products":[{"id":"1"}]},"extensions":{"tracing":{"version":1,"startTime":"2026-10-15T09:12:45.902Z","execution":{"resolvers":[{"path":["products"],"startOffset":7455}]}},"cost":3}}`
+	c := NewJSONComparator()
+	if equal, ok := c.Equal([]byte(a), []byte(b)); !equal || !ok {
+		t.Errorf("Equal() = %v, %v, want GraphQL tracing ignored", equal, ok)
+	}
+
+	// Only the path is ignored: the rest of extensions still counts.
+	other := strings.Replace(b, `"cost":3`, `"cost":4`, 1)
+	if equal, _ := c.Equal([]byte(a), []byte(other)); equal {
+		t.Error("Equal() = true, want extensions outside tracing compared")
+	}
+
+	c.VolatilePaths = nil
+	if equal, _ := c.Equal([]byte(a), []byte(b)); equal {
+		t.Error("Equal() without VolatilePaths = true, want the tracing compared")
+	}
+}
@@ -17,12 +17,26 @@ var dbmsPatterns = map[string][]*regexp.Regexp{
 		regexp.MustCompile(`(?i)MySqlClient\.`),
 		regexp.MustCompile(`(?i)com\.mysql\.jdbc`),
 	},
+	// MariaDB errors also match the MySQL patterns; these tell them apart.
+	"MariaDB": {
+		regexp.MustCompile(`(?i)MariaDB server version`),
+		regexp.MustCompile(`(?i)org\.mariadb\.jdbc`),
+		regexp.MustCompile(`(?i)MariaDB\.Data\.`),
+	},
 	"PostgreSQL": {
 		regexp.MustCompile(`(?i)ERROR:\s+syntax error at or near`),
 		regexp.MustCompile(`(?i)pg_query\(\)`),
 		regexp.MustCompile(`(?i)pg_exec\(\)`),
 		regexp.MustCompile(`(?i)PostgreSQL.*ERROR`),
 		regexp.MustCompile(`(?i)Npgsql\.`),
+		regexp.MustCompile(`(?i)ERROR:\s+at or near "[^"]*": syntax error`),
+	},
+	// CockroachDB errors also match the PostgreSQL patterns; these tell
+	// them apart.
+	"CockroachDB": {
+		regexp.MustCompile(`(?i)ERROR:\s+at or near "[^"]*": syntax error`),
+		regexp.MustCompile(`(?i)\bCockroachDB\b`),
+		regexp.MustCompile(`(?i)crdb_internal`),
 	},
 	"MSSQL": {
 		regexp.MustCompile(`(?i)Unclosed quotation mark`),
@@ -1,8 +1,17 @@
 package detector
 
 import (
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/0x6d61/sqleech/internal/transport"
+	"golang.org/x/text/encoding/japanese"
 )
 
 func TestFindSQLErrors_MySQL(t *testing.T) {
@@ -29,6 +38,24 @@ func TestFindSQLErrors_MySQL(t *testing.T) {
 	}
 }
 
+func TestFindSQLErrors_MariaDB(t *testing.T) {
+	body := []byte("Error: You have an error in your SQL syntax; check the manual that corresponds to your MariaDB server version")
+	result := FindSQLErrors(body)
+
+	// MariaDB errors are also MySQL errors.
+	if len(result["MySQL"]) == 0 {
+		t.Error("expected MySQL errors to be detected")
+	}
+	if len(result["MariaDB"]) == 0 {
+		t.Error("expected MariaDB errors to be detected")
+	}
+
+	result = FindSQLErrors([]byte("Error: You have an error in your SQL syntax; check the manual that corresponds to your MySQL server version"))
+	if _, ok := result["MariaDB"]; ok {
+		t.Errorf("MySQL error matched MariaDB: %v", result["MariaDB"])
+	}
+}
+
 func TestFindSQLErrors_PostgreSQL(t *testing.T) {
 	body := []byte("ERROR: syntax error at or near \"SELECT\"")
 	result := FindSQLErrors(body)
@@ -42,6 +69,24 @@ func TestFindSQLErrors_PostgreSQL(t *testing.T) {
 	}
 }
 
+func TestFindSQLErrors_CockroachDB(t *testing.T) {
+	body := []byte(`ERROR: at or near "'": syntax error: unterminated string (SQLSTATE 42601)`)
+	result := FindSQLErrors(body)
+
+	// CockroachDB errors are also PostgreSQL errors.
+	if len(result["PostgreSQL"]) == 0 {
+		t.Error("expected PostgreSQL errors to be detected")
+	}
+	if len(result["CockroachDB"]) == 0 {
+		t.Error("expected CockroachDB errors to be detected")
+	}
+
+	result = FindSQLErrors([]byte(`ERROR: syntax error at or near "'"`))
+	if _, ok := result["CockroachDB"]; ok {
+		t.Errorf("PostgreSQL error matched CockroachDB: %v", result["CockroachDB"])
+	}
+}
+
 func TestFindSQLErrors_MSSQL(t *testing.T) {
 	body := []byte("Unclosed quotation mark after the character string ''.")
 	result := FindSQLErrors(body)
@@ -56,15 +101,19 @@ func TestFindSQLErrors_MSSQL(t *testing.T) {
 }
 
 func TestFindSQLErrors_Oracle(t *testing.T) {
-	body := []byte("ORA-00933: SQL command not properly ended")
-	result := FindSQLErrors(body)
-
-	oraErrors, ok := result["Oracle"]
-	if !ok {
-		t.Fatal("expected Oracle errors to be detected")
-	}
-	if len(oraErrors) == 0 {
-		t.Fatal("expected at least one Oracle error match")
+	for _, body := range []string{
+		"ORA-00933: SQL command not properly ended",
+		"ORA-01756: quoted string not properly terminated",
+	} {
+		result := FindSQLErrors([]byte(body))
+
+		oraErrors, ok := result["Oracle"]
+		if !ok {
+			t.Fatalf("expected Oracle errors to be detected in %q", body)
+		}
+		if len(oraErrors) == 0 {
+			t.Fatalf("expected at least one Oracle error match in %q", body)
+		}
 	}
 }
 
@@ -158,3 +207,48 @@ func TestFindSQLErrors_GenericErrors(t *testing.T) {
 		t.Errorf("expected 'unexpected end of SQL command' match, got %v", genericErrors)
 	}
 }
+
+// TestFindSQLErrors_EncodedPages checks errors are found in pages served
+// compressed, to a client that asked for it by hand, and in a legacy
+// charset, once the client has decoded them.
+func TestFindSQLErrors_EncodedPages(t *testing.T) {
+	mysqlWarning := "Warning: ユーザー 田中 の mysql_"
+	sjis, _ := japanese.ShiftJIS.NewEncoder().String("<p>" + mysqlWarning + "fetch_array() expects parameter 1</p>")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gzip":
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			gz.Write([]byte("<b>Error:</b> You have an error in your SQL syntax; check the manual near ''' at line 1"))
+			gz.Close()
+		case "/sjis":
+			w.Header().Set("Content-Type", "text/html; charset=Shift_JIS")
+			w.Write([]byte(sjis))
+		}
+	}))
+	defer srv.Close()
+
+	c, err := transport.NewClient(transport.ClientOptions{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	tests := []struct {
+		path, want string
+	}{
+		{"/gzip", "You have an error in your SQL syntax"},
+		{"/sjis", mysqlWarning},
+	}
+	for _, tt := range tests {
+		resp, err := c.Do(context.Background(), &transport.Request{
+			URL:     srv.URL + tt.path,
+			Headers: map[string]string{"Accept-Encoding": "gzip, deflate, br"},
+		})
+		if err != nil {
+			t.Fatalf("%s: Do: %v", tt.path, err)
+		}
+		if got := FindSQLErrors(resp.Body)["MySQL"]; !slices.Contains(got, tt.want) {
+			t.Errorf("%s: MySQL errors = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
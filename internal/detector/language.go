@@ -0,0 +1,131 @@
+package detector
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// minLanguageWords is the minimum number of stopword hits required before
+// the bag-of-words guess commits to a Latin-script language.
+const minLanguageWords = 3
+
+// minScriptRunes is the minimum number of script-specific characters
+// required before the guess commits to a non-Latin language.
+const minScriptRunes = 4
+
+// htmlLangPattern extracts the lang attribute of the <html> element.
+var htmlLangPattern = regexp.MustCompile(`(?is)<html\b[^>]*?\blang\s*=\s*["']?([a-zA-Z]{2,3})`)
+
+// tagPattern matches HTML tags, script and style blocks for text extraction.
+var tagPattern = regexp.MustCompile(`(?is)<(script|style)\b.*?</(script|style)>|<[^>]+>`)
+
+// languageStopwords lists frequent function words per language. Only words
+// that are rare in the other listed languages are included.
+var languageStopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "your", "is", "are", "with", "this", "from", "you", "for"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "mit", "ihre", "für", "auf", "sie", "wird"},
+	"fr": {"le", "les", "et", "des", "est", "une", "pour", "vous", "votre", "avec", "dans", "sur"},
+	"es": {"el", "los", "las", "y", "del", "es", "una", "para", "con", "su", "por", "está"},
+}
+
+// LanguageInfo holds the language signals of a response. Each field is a
+// lowercase primary language subtag ("en", "ja", ...) or empty if the signal
+// is absent.
+type LanguageInfo struct {
+	HTMLLang        string // <html lang="...">
+	ContentLanguage string // Content-Language header
+	Guess           string // Bag-of-words guess from the visible text
+}
+
+// DetectLanguage extracts the language signals from a response. It works on
+// the raw body: dynamic-content stripping must never hide a language shift.
+func DetectLanguage(headers map[string][]string, body []byte) LanguageInfo {
+	var info LanguageInfo
+
+	if m := htmlLangPattern.FindSubmatch(body); m != nil {
+		info.HTMLLang = strings.ToLower(string(m[1]))
+	}
+
+	for k, v := range headers {
+		if strings.EqualFold(k, "Content-Language") && len(v) > 0 {
+			info.ContentLanguage = primarySubtag(v[0])
+			break
+		}
+	}
+
+	info.Guess = guessLanguage(tagPattern.ReplaceAllString(string(body), " "))
+	return info
+}
+
+// Differs reports whether any signal present on both sides disagrees.
+func (l LanguageInfo) Differs(other LanguageInfo) bool {
+	differs := func(a, b string) bool { return a != "" && b != "" && a != b }
+	return differs(l.HTMLLang, other.HTMLLang) ||
+		differs(l.ContentLanguage, other.ContentLanguage) ||
+		differs(l.Guess, other.Guess)
+}
+
+// primarySubtag returns the lowercase primary subtag of the first tag in a
+// Content-Language value, e.g. "en" for "en-US, fr".
+func primarySubtag(v string) string {
+	v = strings.TrimSpace(strings.Split(v, ",")[0])
+	v = strings.Split(v, "-")[0]
+	return strings.ToLower(v)
+}
+
+// guessLanguage returns a best-effort language for text, or "" if the
+// evidence is too weak. CJK, Hangul and Cyrillic are identified by script;
+// Latin-script languages by stopword frequency.
+func guessLanguage(text string) string {
+	var kana, han, hangul, cyrillic int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		}
+	}
+	switch {
+	case kana >= minScriptRunes:
+		return "ja"
+	case hangul >= minScriptRunes:
+		return "ko"
+	case han >= minScriptRunes:
+		return "zh"
+	case cyrillic >= minScriptRunes:
+		return "ru"
+	}
+
+	counts := make(map[string]int, len(languageStopwords))
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		for lang, words := range languageStopwords {
+			for _, sw := range words {
+				if w == sw {
+					counts[lang]++
+				}
+			}
+		}
+	}
+
+	best, bestCount, tie := "", 0, false
+	for lang, n := range counts {
+		switch {
+		case n > bestCount:
+			best, bestCount, tie = lang, n, false
+		case n == bestCount:
+			tie = true
+		}
+	}
+	if bestCount < minLanguageWords || tie {
+		return ""
+	}
+	return best
+}
@@ -0,0 +1,129 @@
+package detector
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/0x6d61/sqleech/internal/testutil"
+)
+
+func TestDetectLanguage_Signals(t *testing.T) {
+	headers := map[string][]string{"Content-Language": {"en-US, fr"}}
+	body := []byte(`<!DOCTYPE html><HTML Lang="DE"><body><p>Die Seite ist nicht verfügbar und wird mit der neuen Version aktualisiert.</p></body></html>`)
+
+	got := DetectLanguage(headers, body)
+	want := LanguageInfo{HTMLLang: "de", ContentLanguage: "en", Guess: "de"}
+	if got != want {
+		t.Errorf("DetectLanguage = %+v, want %+v", got, want)
+	}
+}
+
+func TestGuessLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"english", "Welcome back. The language of this page is loaded from your profile.", "en"},
+		{"french", "Votre compte est prêt pour les achats et les retours dans une boutique.", "fr"},
+		{"spanish", "El usuario está conectado con su cuenta para los pedidos del mes.", "es"},
+		{"japanese", "おかえりなさい。設定を読み込みました。", "ja"},
+		{"korean", "다시 오신 것을 환영합니다", "ko"},
+		{"chinese", "欢迎回来设置已加载", "zh"},
+		{"russian", "Добро пожаловать", "ru"},
+		{"too little text", "Widget 42", ""},
+		{"numbers only", "SKU-1001 1200 SKU-1002 1300", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := guessLanguage(tt.text); got != tt.want {
+				t.Errorf("guessLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLanguageInfo_Differs(t *testing.T) {
+	tests := []struct {
+		a, b LanguageInfo
+		want bool
+	}{
+		{LanguageInfo{HTMLLang: "ja"}, LanguageInfo{HTMLLang: "en"}, true},
+		{LanguageInfo{HTMLLang: "en"}, LanguageInfo{HTMLLang: "en"}, false},
+		{LanguageInfo{HTMLLang: "en"}, LanguageInfo{}, false}, // absent signals never differ
+		{LanguageInfo{ContentLanguage: "en"}, LanguageInfo{ContentLanguage: "fr"}, true},
+		{LanguageInfo{HTMLLang: "en", Guess: "ja"}, LanguageInfo{HTMLLang: "en", Guess: "en"}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Differs(tt.b); got != tt.want {
+			t.Errorf("%+v.Differs(%+v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDiffDetails_LanguageChanged(t *testing.T) {
+	engine := NewDiffEngine()
+	a := &ResponseData{StatusCode: 200, Body: []byte(`<html lang="ja"><p>おかえりなさい</p></html>`)}
+	b := &ResponseData{StatusCode: 200, Body: []byte(`<html lang="en"><p>Welcome back</p></html>`)}
+
+	result := engine.DiffDetails(a, b)
+	if !result.LanguageChanged {
+		t.Error("expected LanguageChanged")
+	}
+	if result.LanguageA.HTMLLang != "ja" || result.LanguageB.HTMLLang != "en" {
+		t.Errorf("languages = %+v / %+v", result.LanguageA, result.LanguageB)
+	}
+
+	if engine.DiffDetails(a, a).LanguageChanged {
+		t.Error("identical responses should not report a language change")
+	}
+}
+
+func TestDiffDetails_LanguageNotMaskedByDynamicPatterns(t *testing.T) {
+	engine := NewDiffEngine()
+	// Strip everything: a dynamic pattern that would hide the whole body.
+	engine.DynamicPatterns = append(engine.DynamicPatterns, regexp.MustCompile(`(?s).+`))
+
+	a := &ResponseData{Body: []byte(`<html lang="ja"><p>おかえりなさい</p></html>`)}
+	b := &ResponseData{Body: []byte(`<html lang="en"><p>Welcome back</p></html>`)}
+	if !engine.DiffDetails(a, b).LanguageChanged {
+		t.Error("language shift must survive dynamic-content stripping")
+	}
+}
+
+// TestDiffDetails_LocaleEndpoint shows why the language signal is needed:
+// on the locale mock the body ratio of the TRUE and FALSE pages sits right at
+// the boolean threshold, while the language signal separates them cleanly.
+func TestDiffDetails_LocaleEndpoint(t *testing.T) {
+	srv := testutil.NewVulnServer()
+	defer srv.Close()
+
+	fetch := func(id string) *ResponseData {
+		t.Helper()
+		resp, err := http.Get(srv.URL + "/vuln/locale?id=" + id)
+		if err != nil {
+			t.Fatalf("GET: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return &ResponseData{StatusCode: resp.StatusCode, Headers: resp.Header, Body: body}
+	}
+
+	trueResp := fetch("1+AND+1%3D1")
+	falseResp := fetch("1+AND+1%3D2")
+
+	result := NewDiffEngine().DiffDetails(trueResp, falseResp)
+	const booleanThreshold = 0.95
+	if math.Abs(result.BodyRatio-booleanThreshold) > 0.02 {
+		t.Errorf("BodyRatio = %.4f, expected it to hover near %.2f", result.BodyRatio, booleanThreshold)
+	}
+	if !result.LanguageChanged {
+		t.Errorf("LanguageChanged = false, want true (%+v vs %+v)", result.LanguageA, result.LanguageB)
+	}
+	if NewDiffEngine().DiffDetails(trueResp, fetch("1")).LanguageChanged {
+		t.Error("TRUE and baseline pages should share a language")
+	}
+}
@@ -2,16 +2,32 @@
 package detector
 
 import (
+	"encoding/base64"
 	"net/url"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/graphql"
+	"github.com/0x6d61/sqleech/internal/marker"
+	"github.com/0x6d61/sqleech/internal/rawquery"
+	"github.com/0x6d61/sqleech/internal/xmlbody"
 )
 
 // integerPattern matches an optional minus sign followed by one or more digits.
 var integerPattern = regexp.MustCompile(`^-?[0-9]+$`)
 
+// uuidPattern matches a UUID in its canonical 8-4-4-4-12 hex form.
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// base64Pattern matches standard base64 with its padding: whole groups of
+// four characters, the last padded with at most two =.
+var base64Pattern = regexp.MustCompile(`^(?:[A-Za-z0-9+/]{4})*(?:[A-Za-z0-9+/]{4}|[A-Za-z0-9+/]{3}=|[A-Za-z0-9+/]{2}==)$`)
+
 // floatPattern matches an optional minus sign, one or more digits, a dot, then one or more digits.
 var floatPattern = regexp.MustCompile(`^-?[0-9]+\.[0-9]+$`)
 
@@ -34,26 +50,146 @@ func ParseURLParameters(rawURL string) []engine.Parameter {
 		return nil
 	}
 
-	return parseFormValues(parsed.Query(), engine.LocationQuery)
+	return parseFormValues(rawquery.Parse(parsed.RawQuery), engine.LocationQuery)
 }
 
 // ParseBodyParameters extracts parameters from POST body.
-// Supports application/x-www-form-urlencoded.
+// Supports application/x-www-form-urlencoded, XML bodies (text/xml,
+// application/soap+xml, ...), whose leaf elements are named by their
+// simplified XPath, e.g. /Envelope/Body/GetUser/id, and GraphQL requests,
+// whatever their content type, whose variables alone are parameters.
 func ParseBodyParameters(body, contentType string) []engine.Parameter {
 	if body == "" {
 		return nil
 	}
 
+	if graphql.IsRequest(body) {
+		return ParseGraphQLVariables(body)
+	}
+
+	if xmlbody.IsXML(contentType) {
+		return parseXMLFields(body)
+	}
+
 	if !isFormURLEncoded(contentType) {
 		return nil
 	}
 
-	values, err := url.ParseQuery(body)
+	return parseFormValues(rawquery.Parse(body), engine.LocationBody)
+}
+
+// Levels of ParsePathParameters.
+const (
+	PathSegmentsNumeric = 1 // Integer segments: /api/users/123/orders
+	PathSegmentsUUID    = 2 // Integer and UUID segments
+)
+
+// ParsePathParameters returns a parameter for each path segment of rawURL
+// that looks like an ID: integers from PathSegmentsNumeric on, UUIDs too
+// from PathSegmentsUUID on. A parameter is named by the path with its
+// segment replaced by *, e.g. /api/users/*/orders.
+func ParsePathParameters(rawURL string, level int) []engine.Parameter {
+	if level < PathSegmentsNumeric {
+		return nil
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+
+	var params []engine.Parameter
+	segments := strings.Split(parsed.Path, "/")
+	for i, seg := range segments {
+		if !integerPattern.MatchString(seg) && (level < PathSegmentsUUID || !uuidPattern.MatchString(seg)) {
+			continue
+		}
+		name := slices.Clone(segments)
+		name[i] = "*"
+		params = append(params, engine.Parameter{
+			Name:     strings.Join(name, "/"),
+			Value:    seg,
+			Location: engine.LocationPath,
+			Type:     InferType(seg),
+		})
+	}
+	return params
+}
+
+// ParseCookies returns a parameter for each of cookies, for testing cookie
+// values as injection points. Parameters are sorted by name.
+func ParseCookies(cookies map[string]string) []engine.Parameter {
+	names := make([]string, 0, len(cookies))
+	for name := range cookies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := make([]engine.Parameter, 0, len(names))
+	for _, name := range names {
+		params = append(params, engine.Parameter{
+			Name:     name,
+			Value:    cookies[name],
+			Location: engine.LocationCookie,
+			Type:     InferType(cookies[name]),
+		})
+	}
+	return params
+}
+
+// ParseMarkers returns a parameter for each injection point of a request
+// written with * markers, in request order.
+func ParseMarkers(m *marker.Request) []engine.Parameter {
+	points := m.Points()
+	params := make([]engine.Parameter, 0, len(points))
+	for _, p := range points {
+		params = append(params, engine.Parameter{
+			Name:     p.Name,
+			Value:    p.Value,
+			Location: engine.LocationCustom,
+			Type:     InferType(p.Value),
+		})
+	}
+	return params
+}
+
+// ParseGraphQLVariables returns a parameter for each variable of a GraphQL
+// request body, named by its path in the variables object (filter.name).
+// The body needs no query, so persisted queries can be forced into GraphQL
+// mode. A body without variables has none.
+func ParseGraphQLVariables(body string) []engine.Parameter {
+	fields, err := graphql.Variables(body)
 	if err != nil {
 		return nil
 	}
+	params := make([]engine.Parameter, 0, len(fields))
+	for _, f := range fields {
+		params = append(params, engine.Parameter{
+			Name:     f.Path,
+			Value:    f.Value,
+			Location: engine.LocationGraphQL,
+			Type:     InferType(f.Value),
+		})
+	}
+	return params
+}
 
-	return parseFormValues(values, engine.LocationBody)
+// parseXMLFields returns a parameter for each leaf element of an XML body,
+// in document order. A malformed body has none.
+func parseXMLFields(body string) []engine.Parameter {
+	fields, err := xmlbody.Fields(body)
+	if err != nil {
+		return nil
+	}
+	params := make([]engine.Parameter, 0, len(fields))
+	for _, f := range fields {
+		params = append(params, engine.Parameter{
+			Name:     f.Path,
+			Value:    f.Value,
+			Location: engine.LocationXML,
+			Type:     InferType(f.Value),
+		})
+	}
+	return params
 }
 
 // InferType guesses the parameter type from its value.
@@ -70,20 +206,74 @@ func InferType(value string) engine.ParameterType {
 	return engine.TypeString
 }
 
-// parseFormValues converts url.Values into a slice of engine.Parameter with the
-// given location. It preserves multiple values for the same key.
-func parseFormValues(values url.Values, location engine.ParameterLocation) []engine.Parameter {
-	var params []engine.Parameter
-	for name, vals := range values {
-		for _, v := range vals {
-			params = append(params, engine.Parameter{
-				Name:     name,
-				Value:    v,
-				Location: location,
-				Type:     InferType(v),
-			})
+// LooksBase64 reports whether value looks base64-encoded and returns it
+// decoded. Besides having the base64 charset and padding, the value must
+// decode to printable UTF-8 text: plain numbers and words such as "1234"
+// or "test" are valid base64 as well, but decode to binary.
+func LooksBase64(value string) (string, bool) {
+	if !base64Pattern.MatchString(value) {
+		return "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(decoded) == 0 || !utf8.Valid(decoded) {
+		return "", false
+	}
+	for _, r := range string(decoded) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return "", false
 		}
 	}
+	return string(decoded), true
+}
+
+// DecodeBase64 returns params with those the application base64-decodes
+// marked engine.Parameter.Base64: the ones named in names and, with auto,
+// every one whose value LooksBase64. Their values are replaced with the
+// decoded values, and their types inferred from those. A named parameter
+// whose value does not decode is left as it is.
+func DecodeBase64(params []engine.Parameter, names []string, auto bool) []engine.Parameter {
+	out := slices.Clone(params)
+	for i, p := range out {
+		var decoded string
+		switch {
+		case p.Base64:
+			continue
+		case slices.Contains(names, p.Name):
+			b, err := base64.StdEncoding.DecodeString(p.Value)
+			if err != nil {
+				continue
+			}
+			decoded = string(b)
+		case auto:
+			d, ok := LooksBase64(p.Value)
+			if !ok {
+				continue
+			}
+			decoded = d
+		default:
+			continue
+		}
+		out[i].Value = decoded
+		out[i].Type = InferType(decoded)
+		out[i].Base64 = true
+	}
+	return out
+}
+
+// parseFormValues converts the pairs of a query string or form body into
+// parameters with the given location, in order. Each element of an id[]
+// array is a parameter of its own, id[0], id[1] and so on, and a key
+// repeated without [] gives a parameter per value.
+func parseFormValues(pairs []rawquery.Pair, location engine.ParameterLocation) []engine.Parameter {
+	var params []engine.Parameter
+	for _, p := range pairs {
+		params = append(params, engine.Parameter{
+			Name:     p.Name,
+			Value:    p.Value,
+			Location: location,
+			Type:     InferType(p.Value),
+		})
+	}
 	return params
 }
 
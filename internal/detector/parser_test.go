@@ -1,6 +1,7 @@
 package detector
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/0x6d61/sqleech/internal/engine"
@@ -151,6 +152,75 @@ func TestParseURLParameters_MultipleValuesSameName(t *testing.T) {
 	}
 }
 
+func TestParseURLParameters_Semicolons(t *testing.T) {
+	params := ParseURLParameters("http://example.com/app.do?id=1;sort=name&page=2")
+	if len(params) != 3 {
+		t.Fatalf("expected 3 params, got %+v", params)
+	}
+	if params[0].Name != "id" || params[1].Name != "sort" || params[2].Name != "page" {
+		t.Errorf("params out of order: %+v", params)
+	}
+	assertParam(t, params, "id", "1", engine.LocationQuery, engine.TypeInteger)
+	assertParam(t, params, "sort", "name", engine.LocationQuery, engine.TypeString)
+}
+
+func TestParseURLParameters_Arrays(t *testing.T) {
+	params := ParseURLParameters("http://example.com/page.php?id[]=1&cat=x&id%5B%5D=abc&tag[]=7")
+	if len(params) != 4 {
+		t.Fatalf("expected 4 params, got %+v", params)
+	}
+	assertParam(t, params, "id[0]", "1", engine.LocationQuery, engine.TypeInteger)
+	assertParam(t, params, "id[1]", "abc", engine.LocationQuery, engine.TypeString)
+	assertParam(t, params, "tag[0]", "7", engine.LocationQuery, engine.TypeInteger)
+	assertParam(t, params, "cat", "x", engine.LocationQuery, engine.TypeString)
+}
+
+func TestParseBodyParameters_SemicolonsAndArrays(t *testing.T) {
+	params := ParseBodyParameters("ids[]=3;ids[]=4;q=a%3Bb", "application/x-www-form-urlencoded")
+	if len(params) != 3 {
+		t.Fatalf("expected 3 params, got %+v", params)
+	}
+	assertParam(t, params, "ids[0]", "3", engine.LocationBody, engine.TypeInteger)
+	assertParam(t, params, "ids[1]", "4", engine.LocationBody, engine.TypeInteger)
+	assertParam(t, params, "q", "a;b", engine.LocationBody, engine.TypeString)
+}
+
+func TestParseCookies(t *testing.T) {
+	params := ParseCookies(map[string]string{"lang": "en", "id": "7", "cart_id": "a1b2"})
+	if len(params) != 3 {
+		t.Fatalf("expected 3 params, got %d", len(params))
+	}
+	if params[0].Name != "cart_id" || params[1].Name != "id" || params[2].Name != "lang" {
+		t.Errorf("params not sorted by name: %+v", params)
+	}
+	assertParam(t, params, "id", "7", engine.LocationCookie, engine.TypeInteger)
+	assertParam(t, params, "cart_id", "a1b2", engine.LocationCookie, engine.TypeString)
+
+	if params := ParseCookies(nil); len(params) != 0 {
+		t.Errorf("expected no params for no cookies, got %d", len(params))
+	}
+}
+
+func TestParsePathParameters(t *testing.T) {
+	rawURL := "http://example.com/api/v2/users/123/orders/0b6e1c8a-4f3e-4d2b-9a7c-5e8f1d2c3b4a?id=1"
+
+	if params := ParsePathParameters(rawURL, 0); len(params) != 0 {
+		t.Errorf("expected no params at level 0, got %+v", params)
+	}
+
+	params := ParsePathParameters(rawURL, PathSegmentsNumeric)
+	if len(params) != 1 {
+		t.Fatalf("expected 1 param, got %+v", params)
+	}
+	assertParam(t, params, "/api/v2/users/*/orders/0b6e1c8a-4f3e-4d2b-9a7c-5e8f1d2c3b4a", "123", engine.LocationPath, engine.TypeInteger)
+
+	params = ParsePathParameters(rawURL, PathSegmentsUUID)
+	if len(params) != 2 {
+		t.Fatalf("expected 2 params, got %+v", params)
+	}
+	assertParam(t, params, "/api/v2/users/123/orders/*", "0b6e1c8a-4f3e-4d2b-9a7c-5e8f1d2c3b4a", engine.LocationPath, engine.TypeString)
+}
+
 func TestParseURLParameters_WithFragment(t *testing.T) {
 	params := ParseURLParameters("http://example.com/page?id=1#section")
 	if len(params) != 1 {
@@ -214,6 +284,93 @@ func TestParseBodyParameters_ContentTypeWithCharset(t *testing.T) {
 	assertParam(t, params, "name", "test", engine.LocationBody, engine.TypeString)
 }
 
+func TestParseBodyParameters_SOAP(t *testing.T) {
+	body := `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">` +
+		`<soap:Body><GetUser><id>7</id><name>bob</name></GetUser></soap:Body></soap:Envelope>`
+	params := ParseBodyParameters(body, "application/soap+xml; charset=utf-8")
+	if len(params) != 2 {
+		t.Fatalf("expected 2 params, got %d", len(params))
+	}
+	assertParam(t, params, "/Envelope/Body/GetUser/id", "7", engine.LocationXML, engine.TypeInteger)
+	assertParam(t, params, "/Envelope/Body/GetUser/name", "bob", engine.LocationXML, engine.TypeString)
+
+	if params := ParseBodyParameters("<a><b>1</b>", "text/xml"); len(params) != 0 {
+		t.Errorf("expected 0 params for malformed XML, got %d", len(params))
+	}
+}
+
+func TestParseBodyParameters_GraphQL(t *testing.T) {
+	body := `{"query":"query($filter: Filter, $first: Int) { products(filter: $filter, first: $first) { id } }",` +
+		`"variables":{"first":10,"filter":{"name":"bob","tags":["new","sale"]}}}`
+	params := ParseBodyParameters(body, "")
+	if len(params) != 4 {
+		t.Fatalf("expected 4 params, got %d", len(params))
+	}
+	assertParam(t, params, "filter.name", "bob", engine.LocationGraphQL, engine.TypeString)
+	assertParam(t, params, "filter.tags[0]", "new", engine.LocationGraphQL, engine.TypeString)
+	assertParam(t, params, "filter.tags[1]", "sale", engine.LocationGraphQL, engine.TypeString)
+	assertParam(t, params, "first", "10", engine.LocationGraphQL, engine.TypeInteger)
+
+	if params := ParseBodyParameters(`{"id":1}`, "application/json"); len(params) != 0 {
+		t.Errorf("expected 0 params for a plain JSON body, got %d", len(params))
+	}
+}
+
+func TestLooksBase64(t *testing.T) {
+	tests := []struct {
+		value   string
+		decoded string
+		want    bool
+	}{
+		{"MTIzNA==", "1234", true},
+		{"eyJ1c2VyIjoxfQ==", `{"user":1}`, true},
+		{"aGVsbG8gd29ybGQ=", "hello world", true},
+		{"1234", "", false},      // valid base64, decodes to binary
+		{"test", "", false},      // likewise
+		{"MTIzNA", "", false},    // padding missing
+		{"MTIz NA==", "", false}, // not the charset
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		decoded, ok := LooksBase64(tt.value)
+		if ok != tt.want || decoded != tt.decoded {
+			t.Errorf("LooksBase64(%q) = %q, %v, want %q, %v", tt.value, decoded, ok, tt.decoded, tt.want)
+		}
+	}
+}
+
+func TestDecodeBase64(t *testing.T) {
+	params := ParseParameters("http://example.com/page?data=MTIzNA==&token=dGVzdA==&id=7&page=AAAA", "", "")
+
+	if got := DecodeBase64(params, nil, false); !slices.Equal(got, params) {
+		t.Errorf("DecodeBase64 without names or auto changed %v", got)
+	}
+
+	auto := DecodeBase64(params, nil, true)
+	assertBase64 := func(params []engine.Parameter, name, value string, typ engine.ParameterType, want bool) {
+		t.Helper()
+		for _, p := range params {
+			if p.Name == name {
+				if p.Value != value || p.Type != typ || p.Base64 != want {
+					t.Errorf("%s = %q (type %d, base64 %v), want %q (type %d, base64 %v)", name, p.Value, p.Type, p.Base64, value, typ, want)
+				}
+				return
+			}
+		}
+		t.Errorf("parameter %s not found", name)
+	}
+	assertBase64(auto, "data", "1234", engine.TypeInteger, true)
+	assertBase64(auto, "token", "test", engine.TypeString, true)
+	assertBase64(auto, "id", "7", engine.TypeInteger, false)
+	assertBase64(auto, "page", "AAAA", engine.TypeString, false)
+
+	// Named parameters decode whatever their content; the others stay.
+	named := DecodeBase64(params, []string{"page", "id"}, false)
+	assertBase64(named, "page", "\x00\x00\x00", engine.TypeString, true)
+	assertBase64(named, "id", "7", engine.TypeInteger, false)
+	assertBase64(named, "data", "MTIzNA==", engine.TypeString, false)
+}
+
 // --- ParseParameters tests (combined) ---
 
 func TestParseParameters_QueryOnly(t *testing.T) {
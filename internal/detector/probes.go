@@ -0,0 +1,142 @@
+package detector
+
+import (
+	"net/http"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// Names of the built-in heuristic probes, in the order they are sent.
+const (
+	ProbeQuote    = "quote"         // Value with a single quote appended
+	ProbeTrue     = "boolean-true"  // Value AND a TRUE condition
+	ProbeFalse    = "boolean-false" // Value AND a FALSE condition
+	ProbeOverflow = "overflow"      // An out-of-range integer, for integer parameters
+)
+
+// HeuristicProbe is one request the heuristic detector sends for each
+// parameter, and what it makes of the response.
+type HeuristicProbe interface {
+	// Name identifies the probe in signals and in WithoutProbes.
+	Name() string
+
+	// BuildPayload returns the value to send in place of param's, or false
+	// when the probe does not apply to param.
+	BuildPayload(param engine.Parameter) (string, bool)
+
+	// Evaluate reads the probe's response against the baseline.
+	Evaluate(baseline, resp *transport.Response) Signal
+}
+
+// Signal is what a heuristic probe observed. The detector fills in Probe,
+// Payload and Err; Evaluate fills in the rest.
+type Signal struct {
+	Probe   string
+	Payload string
+
+	// SQLErrors holds the database error signatures found in the response
+	// (DBMS -> matched errors).
+	SQLErrors map[string][]string
+
+	// Ratio is the body similarity to the baseline. It is only meaningful
+	// when Comparable is set: neither response is anomalous.
+	Ratio      float64
+	Comparable bool
+
+	// Err is set when the probe could not be sent; nothing else is.
+	Err error
+}
+
+// observe is the evaluation the built-in probes share: error signatures
+// in resp, and its similarity to baseline when the two can be compared.
+// A 404 to a probe of a page that exists is a different page however
+// alike the bodies are: a payload in a path segment that the application
+// cannot route, or whose row it cannot find, gets the site's 404 page,
+// which often shares the layout of the real one.
+func observe(diff *DiffEngine, baseline, resp *transport.Response) Signal {
+	s := Signal{SQLErrors: FindSQLErrors(resp.Body)}
+	if !baseline.Anomalous() && !resp.Anomalous() {
+		s.Comparable = true
+		s.Ratio = diff.ResponseRatio(baseline, resp)
+		if resp.StatusCode == http.StatusNotFound && baseline.StatusCode != http.StatusNotFound {
+			s.Ratio = 0
+		}
+	}
+	return s
+}
+
+// numeric reports whether param takes an unquoted number.
+func numeric(param engine.Parameter) bool {
+	return param.Type == engine.TypeInteger || param.Type == engine.TypeFloat
+}
+
+// quoteProbe appends a single quote, which breaks out of a string literal
+// or makes a numeric expression invalid.
+type quoteProbe struct{ diff *DiffEngine }
+
+func (p quoteProbe) Name() string { return ProbeQuote }
+
+func (p quoteProbe) BuildPayload(param engine.Parameter) (string, bool) {
+	return param.Value + "'", true
+}
+
+func (p quoteProbe) Evaluate(baseline, resp *transport.Response) Signal {
+	return observe(p.diff, baseline, resp)
+}
+
+// booleanProbe appends AND with a condition that is always true or always
+// false, quoted to match the parameter's type.
+type booleanProbe struct {
+	diff  *DiffEngine
+	truth bool
+}
+
+func (p booleanProbe) Name() string {
+	if p.truth {
+		return ProbeTrue
+	}
+	return ProbeFalse
+}
+
+func (p booleanProbe) BuildPayload(param engine.Parameter) (string, bool) {
+	rhs := "2"
+	if p.truth {
+		rhs = "1"
+	}
+	if numeric(param) {
+		return param.Value + " AND 1=" + rhs, true
+	}
+	return param.Value + "' AND '1'='" + rhs, true
+}
+
+func (p booleanProbe) Evaluate(baseline, resp *transport.Response) Signal {
+	return observe(p.diff, baseline, resp)
+}
+
+// overflowProbe sends an integer too large for most integer columns. Its
+// signal is collected but not yet used in the injectable decision.
+type overflowProbe struct{ diff *DiffEngine }
+
+func (p overflowProbe) Name() string { return ProbeOverflow }
+
+func (p overflowProbe) BuildPayload(param engine.Parameter) (string, bool) {
+	if param.Type != engine.TypeInteger {
+		return "", false
+	}
+	return "99999999999", true
+}
+
+func (p overflowProbe) Evaluate(baseline, resp *transport.Response) Signal {
+	return observe(p.diff, baseline, resp)
+}
+
+// defaultProbes returns the built-in catalog in the order it is sent.
+func defaultProbes(diff *DiffEngine) []HeuristicProbe {
+	return []HeuristicProbe{
+		quoteProbe{diff: diff},
+		booleanProbe{diff: diff, truth: true},
+		booleanProbe{diff: diff, truth: false},
+		overflowProbe{diff: diff},
+	}
+}
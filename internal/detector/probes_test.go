@@ -0,0 +1,66 @@
+package detector
+
+import (
+	"testing"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+func TestDefaultProbes_BuildPayload(t *testing.T) {
+	intParam := engine.Parameter{Value: "1", Type: engine.TypeInteger}
+	floatParam := engine.Parameter{Value: "1.5", Type: engine.TypeFloat}
+	strParam := engine.Parameter{Value: "abc", Type: engine.TypeString}
+
+	tests := []struct {
+		probe string
+		param engine.Parameter
+		want  string // "" when the probe does not apply
+	}{
+		{ProbeQuote, intParam, "1'"},
+		{ProbeQuote, strParam, "abc'"},
+		{ProbeTrue, intParam, "1 AND 1=1"},
+		{ProbeTrue, floatParam, "1.5 AND 1=1"},
+		{ProbeTrue, strParam, "abc' AND '1'='1"},
+		{ProbeFalse, intParam, "1 AND 1=2"},
+		{ProbeFalse, strParam, "abc' AND '1'='2"},
+		{ProbeOverflow, intParam, "99999999999"},
+		{ProbeOverflow, floatParam, ""},
+		{ProbeOverflow, strParam, ""},
+	}
+	probes := make(map[string]HeuristicProbe)
+	for _, p := range defaultProbes(NewDiffEngine()) {
+		probes[p.Name()] = p
+	}
+	for _, tt := range tests {
+		got, ok := probes[tt.probe].BuildPayload(tt.param)
+		if ok != (tt.want != "") || got != tt.want {
+			t.Errorf("%s on %q: BuildPayload = %q, %v; want %q", tt.probe, tt.param.Value, got, ok, tt.want)
+		}
+	}
+}
+
+func TestObserve(t *testing.T) {
+	de := NewDiffEngine()
+	baseline := &transport.Response{Body: []byte("<p>Widget</p>")}
+
+	s := observe(de, baseline, &transport.Response{Body: []byte("You have an error in your SQL syntax")})
+	if len(s.SQLErrors["MySQL"]) == 0 {
+		t.Errorf("SQLErrors = %v, want a MySQL signature", s.SQLErrors)
+	}
+	if !s.Comparable || s.Ratio != 0 {
+		t.Errorf("Comparable = %v, Ratio = %v; want a comparable, different page", s.Comparable, s.Ratio)
+	}
+
+	s = observe(de, baseline, &transport.Response{Anomalies: []transport.Anomaly{transport.AnomalyTruncated}})
+	if s.Comparable {
+		t.Error("an anomalous response should not be comparable")
+	}
+
+	// A 404 sharing the page's layout is still a different page.
+	baseline.StatusCode = 200
+	s = observe(de, baseline, &transport.Response{StatusCode: 404, Body: []byte("<p>Widget</p>")})
+	if !s.Comparable || s.Ratio != 0 {
+		t.Errorf("404: Comparable = %v, Ratio = %v; want a comparable, different page", s.Comparable, s.Ratio)
+	}
+}
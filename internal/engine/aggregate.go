@@ -0,0 +1,57 @@
+package engine
+
+import "slices"
+
+// aggregateFindings consolidates the verdicts of the techniques into one
+// finding per parameter, unless ScanConfig.PerTechnique is set. The
+// injectable verdicts on a parameter become the finding of the most
+// confident technique, ties going to the technique tried first, with every
+// one of them in ConfirmedBy. Verdicts of not injectable follow, kept only
+// from Verbose 2. Findings are in the order their parameter first appears
+// in vulns.
+func (s *Scanner) aggregateFindings(vulns []Vulnerability) []Vulnerability {
+	if s.config.PerTechnique {
+		return vulns
+	}
+	rank := func(name string) int {
+		return slices.IndexFunc(s.techniques, func(t Technique) bool { return t.Name() == name })
+	}
+
+	groups := make(map[string][]Vulnerability)
+	var order []string
+	var inert []Vulnerability
+	for _, v := range vulns {
+		if !v.Injectable {
+			if s.config.Verbose >= 2 {
+				inert = append(inert, v)
+			}
+			continue
+		}
+		key := paramKey(v.Parameter)
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], v)
+	}
+
+	out := make([]Vulnerability, 0, len(order)+len(inert))
+	for _, key := range order {
+		group := groups[key]
+		slices.SortStableFunc(group, func(a, b Vulnerability) int {
+			switch {
+			case a.Confidence > b.Confidence:
+				return -1
+			case a.Confidence < b.Confidence:
+				return 1
+			}
+			return rank(a.Technique) - rank(b.Technique)
+		})
+		v := group[0]
+		v.ConfirmedBy = make([]string, len(group))
+		for i, g := range group {
+			v.ConfirmedBy[i] = g.Technique
+		}
+		out = append(out, v)
+	}
+	return append(out, inert...)
+}
@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAggregateFindings(t *testing.T) {
+	id := Parameter{Name: "id", Location: LocationQuery}
+	name := Parameter{Name: "name", Location: LocationQuery}
+	idCookie := Parameter{Name: "id", Location: LocationCookie}
+	vulns := []Vulnerability{
+		{Parameter: id, Technique: "error-based", Confidence: 0.9, Injectable: true},
+		{Parameter: name, Technique: "error-based", Confidence: 0.2},
+		{Parameter: id, Technique: "boolean-blind", Confidence: 0.95, Injectable: true},
+		{Parameter: idCookie, Technique: "error-based", Confidence: 0.9, Injectable: true},
+		{Parameter: id, Technique: "time-based", Confidence: 0.95, Injectable: true},
+	}
+	techniques := []Technique{&fakeTechnique{name: "time-based"}, &fakeTechnique{name: "error-based"}, &fakeTechnique{name: "boolean-blind"}}
+
+	tests := []struct {
+		name    string
+		config  ScanConfig
+		want    []string // Technique of each finding
+		confirm [][]string
+	}{
+		{
+			name:    "consolidated",
+			want:    []string{"time-based", "error-based"},
+			confirm: [][]string{{"time-based", "boolean-blind", "error-based"}, {"error-based"}},
+		},
+		{
+			name:    "verbose",
+			config:  ScanConfig{Verbose: 2},
+			want:    []string{"time-based", "error-based", "error-based"},
+			confirm: [][]string{{"time-based", "boolean-blind", "error-based"}, {"error-based"}, nil},
+		},
+		{
+			name:    "per technique",
+			config:  ScanConfig{PerTechnique: true},
+			want:    []string{"error-based", "error-based", "boolean-blind", "error-based", "time-based"},
+			confirm: [][]string{nil, nil, nil, nil, nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scanner{config: &tt.config, techniques: techniques}
+			got := s.aggregateFindings(vulns)
+			var techs []string
+			var confirm [][]string
+			for _, v := range got {
+				techs = append(techs, v.Technique)
+				confirm = append(confirm, v.ConfirmedBy)
+			}
+			if !reflect.DeepEqual(techs, tt.want) || !reflect.DeepEqual(confirm, tt.confirm) {
+				t.Errorf("findings by %q confirmed by %q, want by %q confirmed by %q", techs, confirm, tt.want, tt.confirm)
+			}
+		})
+	}
+}
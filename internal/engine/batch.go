@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ScanAll scans targets, ScanConfig.TargetConcurrency of them at once, and
+// returns the results of those it started, in order, along with a
+// summary. See ScanAllInto.
+//
+// Requests the scans send at the same time are counted in each of their
+// RequestCounts; the summary's TotalRequests is what the client sent for
+// the whole batch.
+func (s *Scanner) ScanAll(ctx context.Context, targets []*ScanTarget) ([]*ScanResult, MergedSummary, error) {
+	var startRequests int64
+	if st := s.client.Stats(); st != nil {
+		startRequests = st.TotalRequests
+	}
+	merged := NewMergingCollector()
+	err := s.ScanAllInto(ctx, targets, merged.Target)
+	summary := merged.Summary()
+	if st := s.client.Stats(); st != nil {
+		summary.TotalRequests = st.TotalRequests - startRequests
+	}
+	return merged.Results(), summary, err
+}
+
+// ScanAllInto scans targets, ScanConfig.TargetConcurrency of them at once,
+// emitting each into the collector collect returns for it. collect is
+// called for one target at a time, in order, as its scan starts; targets
+// left when ctx ends are not started.
+//
+// The scans share the scanner's client, so its rate limit holds across
+// them. Fingerprinting probes are sent once per host: later targets of a
+// host take the DBMS the first to settle on one found instead, unless
+// their own error signatures identify one. A scan that fails is recorded as an error in
+// its collector and the others go on; ScanAllInto only returns an error
+// when ctx ends first.
+func (s *Scanner) ScanAllInto(ctx context.Context, targets []*ScanTarget, collect func(*ScanTarget) ResultCollector) error {
+	hosts := &hostDBMS{known: make(map[string]*DBMSInfo)}
+	sem := make(chan struct{}, max(s.config.TargetConcurrency, 1))
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		c := collect(t)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.scanHost(ctx, t, c, hosts)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// scanHost scans target into c with the DBMS hosts knows for its host,
+// recording there the DBMS the scan settles on.
+func (s *Scanner) scanHost(ctx context.Context, target *ScanTarget, c ResultCollector, hosts *hostDBMS) {
+	host := targetHost(target.URL)
+	rec := &hostRecorder{ResultCollector: c, host: host, hosts: hosts}
+	if err := s.scanInto(ctx, target, rec, hosts.get(host)); err != nil {
+		c.AddError(err)
+	}
+}
+
+// hostDBMS is the DBMS identified per host in a batch.
+type hostDBMS struct {
+	mu    sync.Mutex
+	known map[string]*DBMSInfo
+}
+
+func (h *hostDBMS) get(host string) *DBMSInfo {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.known[host]
+}
+
+// set records name and version for host unless another DBMS is known
+// there; a version fills in one the known DBMS lacks.
+func (h *hostDBMS) set(host, name, version string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	k := h.known[host]
+	if k == nil || k.Name == name && k.Version == "" && version != "" {
+		h.known[host] = &DBMSInfo{Name: name, Version: version}
+	}
+}
+
+// hostRecorder forwards to a ResultCollector, recording the DBMS the scan
+// settles on for its host.
+type hostRecorder struct {
+	ResultCollector
+	host  string
+	hosts *hostDBMS
+}
+
+func (r *hostRecorder) SetDBMS(name, version string) {
+	if name != "" && r.host != "" {
+		r.hosts.set(r.host, name, version)
+	}
+	r.ResultCollector.SetDBMS(name, version)
+}
+
+// targetHost returns the lowercased host and port of rawURL, or "" when it
+// does not parse.
+func targetHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
@@ -0,0 +1,171 @@
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// newBatchScanner returns a scanner whose fingerprinter identifies MySQL
+// and counts its calls in fingerprints.
+func newBatchScanner(client transport.Client, concurrency int, fingerprints *atomic.Int32) *engine.Scanner {
+	cfg := engine.DefaultScanConfig()
+	cfg.Threads = 1
+	cfg.TargetConcurrency = concurrency
+	return engine.NewScanner(client, cfg,
+		engine.WithTechniques(&recordedTechnique{name: "error-based", priority: 1, injectable: map[string]float64{"id": 0.9}}),
+		engine.WithParameterParser(func(rawURL, body, contentType string) []engine.Parameter {
+			return []engine.Parameter{{Name: "id", Value: "1", Location: engine.LocationQuery}}
+		}),
+		engine.WithHeuristicDetector(func(ctx context.Context, target *engine.ScanTarget) ([]engine.HeuristicResult, error) {
+			return []engine.HeuristicResult{{Parameter: target.Parameters[0], IsInjectable: true}}, nil
+		}),
+		engine.WithFingerprinter(func(ctx context.Context, target *engine.ScanTarget, param *engine.Parameter, baseline *transport.Response, client transport.Client) (*engine.DBMSInfo, error) {
+			fingerprints.Add(1)
+			return &engine.DBMSInfo{Name: "MySQL", Version: "8.0.32", Confidence: 0.9}, nil
+		}),
+	)
+}
+
+func TestScanAll_Results(t *testing.T) {
+	client := &recordedClient{}
+	var fingerprints atomic.Int32
+	scanner := newBatchScanner(client, 1, &fingerprints)
+
+	targets := []*engine.ScanTarget{
+		{URL: "http://a.test/one?id=1", Method: "GET"},
+		{URL: "http://a.test/two?id=1", Method: "GET"},
+		{URL: "http://b.test/?id=1", Method: "GET"},
+	}
+	results, summary, err := scanner.ScanAll(context.Background(), targets)
+	if err != nil {
+		t.Fatalf("ScanAll: %v", err)
+	}
+	if len(results) != len(targets) {
+		t.Fatalf("len(results) = %d, want %d", len(results), len(targets))
+	}
+	for i, r := range results {
+		if r.Target.URL != targets[i].URL {
+			t.Errorf("results[%d] is %s, want %s", i, r.Target.URL, targets[i].URL)
+		}
+		if r.DBMS != "MySQL" || r.DBMSVersion != "8.0.32" {
+			t.Errorf("%s: DBMS = %s %s, want MySQL 8.0.32", r.Target.URL, r.DBMS, r.DBMSVersion)
+		}
+		if r.Profile == nil || r.Transport == nil {
+			t.Errorf("%s: profile or transport statistics missing", r.Target.URL)
+		}
+	}
+	// a.test is fingerprinted once, b.test once.
+	if n := fingerprints.Load(); n != 2 {
+		t.Errorf("fingerprinted %d times, want 2", n)
+	}
+
+	if summary.Targets != 3 || summary.CompletedTargets != 3 || summary.VulnerableTargets != 3 {
+		t.Errorf("targets = %d/%d/%d, want 3/3/3", summary.Targets, summary.CompletedTargets, summary.VulnerableTargets)
+	}
+	if summary.TotalRequests != client.requests {
+		t.Errorf("TotalRequests = %d, want %d", summary.TotalRequests, client.requests)
+	}
+}
+
+func TestScanAll_DBMSHint(t *testing.T) {
+	cfg := engine.DefaultScanConfig()
+	cfg.DBMSHint = "PostgreSQL"
+	cfg.TargetConcurrency = 2
+	hinted := engine.NewScanner(&recordedClient{}, cfg,
+		engine.WithTechniques(&recordedTechnique{name: "error-based", priority: 1, injectable: map[string]float64{"id": 0.9}}),
+	)
+	results, _, err := hinted.ScanAll(context.Background(), []*engine.ScanTarget{
+		{URL: "http://a.test/?id=1", Method: "GET", Parameters: []engine.Parameter{{Name: "id", Value: "1", Location: engine.LocationQuery}}},
+		{URL: "http://a.test/x?id=1", Method: "GET", Parameters: []engine.Parameter{{Name: "id", Value: "1", Location: engine.LocationQuery}}},
+	})
+	if err != nil {
+		t.Fatalf("ScanAll: %v", err)
+	}
+	for _, r := range results {
+		if r.DBMS != "PostgreSQL" {
+			t.Errorf("%s: DBMS = %q, want the hint", r.Target.URL, r.DBMS)
+		}
+	}
+}
+
+// gatedClient counts the requests in flight and holds each until release
+// is closed.
+type gatedClient struct {
+	recordedClient
+	inFlight, peak atomic.Int32
+	release        chan struct{}
+}
+
+func (c *gatedClient) Do(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	n := c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	for {
+		p := c.peak.Load()
+		if n <= p || c.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	select {
+	case <-c.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return c.recordedClient.Do(ctx, req)
+}
+
+func TestScanAll_TargetConcurrency(t *testing.T) {
+	client := &gatedClient{release: make(chan struct{})}
+	var fingerprints atomic.Int32
+	scanner := newBatchScanner(client, 2, &fingerprints)
+
+	targets := make([]*engine.ScanTarget, 5)
+	for i := range targets {
+		targets[i] = &engine.ScanTarget{URL: "http://a.test/?id=1", Method: "GET"}
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if _, _, err := scanner.ScanAll(context.Background(), targets); err != nil {
+			t.Errorf("ScanAll: %v", err)
+		}
+	}()
+
+	// Both slots fill with a baseline request each, and no third starts.
+	deadline := time.Now().Add(2 * time.Second)
+	for client.inFlight.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(client.release)
+	wg.Wait()
+
+	if p := client.peak.Load(); p != 2 {
+		t.Errorf("peak requests in flight = %d, want 2", p)
+	}
+}
+
+func TestScanAll_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var fingerprints atomic.Int32
+	scanner := newBatchScanner(&recordedClient{}, 1, &fingerprints)
+
+	results, summary, err := scanner.ScanAll(ctx, []*engine.ScanTarget{
+		{URL: "http://a.test/?id=1", Method: "GET"},
+		{URL: "http://b.test/?id=1", Method: "GET"},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if len(results) != 0 || summary.Targets != 0 {
+		t.Errorf("%d results of %d targets; want none started", len(results), summary.Targets)
+	}
+}
@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// ErrRequestBudget is returned for the requests a Scanner refuses once it
+// has sent ScanConfig.MaxRequests, and is the cause of the cancellation of
+// the scans and extractions it cuts short.
+var ErrRequestBudget = errors.New("scan request budget exhausted")
+
+// requestBudget counts the requests a Scanner sends, across every scan and
+// extraction it runs, and refuses those past limit. The first refusal
+// closes exhausted, cancelling the contexts bound to the budget.
+type requestBudget struct {
+	transport.Client
+	limit     int64
+	sent      atomic.Int64
+	exhausted chan struct{}
+	once      sync.Once
+}
+
+// newRequestBudget wraps client in a budget of limit requests, or returns
+// nil when limit is not positive.
+func newRequestBudget(client transport.Client, limit int64) *requestBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &requestBudget{Client: client, limit: limit, exhausted: make(chan struct{})}
+}
+
+func (b *requestBudget) Do(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	if b.sent.Add(1) > b.limit {
+		b.once.Do(func() { close(b.exhausted) })
+		return nil, ErrRequestBudget
+	}
+	return b.Client.Do(ctx, req)
+}
+
+// spent reports whether a request was refused. It is false for a nil
+// budget.
+func (b *requestBudget) spent() bool {
+	if b == nil {
+		return false
+	}
+	select {
+	case <-b.exhausted:
+		return true
+	default:
+		return false
+	}
+}
+
+// bind returns a context derived from ctx that is cancelled, with cause
+// ErrRequestBudget, once the budget is spent. A nil budget returns ctx.
+// The returned function releases the context.
+func (b *requestBudget) bind(ctx context.Context) (context.Context, context.CancelFunc) {
+	if b == nil {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	go func() {
+		select {
+		case <-b.exhausted:
+			cancel(ErrRequestBudget)
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() { cancel(context.Canceled) }
+}
+
+// interrupted returns ErrRequestBudget once s has spent its request
+// budget, or else ctx's error: why a scan or extraction should stop.
+func (s *Scanner) interrupted(ctx context.Context) error {
+	if s.budget.spent() {
+		return ErrRequestBudget
+	}
+	return ctx.Err()
+}
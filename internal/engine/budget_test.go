@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// countingClient answers every request with an empty 200 and counts them.
+type countingClient struct {
+	transport.Client
+	n atomic.Int64
+}
+
+func (c *countingClient) Do(context.Context, *transport.Request) (*transport.Response, error) {
+	c.n.Add(1)
+	return &transport.Response{StatusCode: 200}, nil
+}
+
+func TestRequestBudget(t *testing.T) {
+	if b := newRequestBudget(&countingClient{}, 0); b != nil || b.spent() {
+		t.Fatalf("newRequestBudget with no limit = %v, want nil", b)
+	}
+
+	inner := &countingClient{}
+	b := newRequestBudget(inner, 2)
+	ctx, release := b.bind(context.Background())
+	defer release()
+
+	req := &transport.Request{Method: "GET", URL: "http://example.test/"}
+	for i := 0; i < 2; i++ {
+		if _, err := b.Do(context.Background(), req); err != nil {
+			t.Fatalf("request %d within the budget: %v", i+1, err)
+		}
+	}
+	if b.spent() || ctx.Err() != nil {
+		t.Fatal("budget spent before a request was refused")
+	}
+	if _, err := b.Do(context.Background(), req); !errors.Is(err, ErrRequestBudget) {
+		t.Fatalf("request past the budget: err = %v, want ErrRequestBudget", err)
+	}
+	if !b.spent() {
+		t.Error("spent = false after a refusal")
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("bound context not cancelled")
+	}
+	if cause := context.Cause(ctx); cause != ErrRequestBudget {
+		t.Errorf("cause = %v, want ErrRequestBudget", cause)
+	}
+	if n := inner.n.Load(); n != 2 {
+		t.Errorf("inner client got %d requests, want 2", n)
+	}
+}
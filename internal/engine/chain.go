@@ -0,0 +1,150 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// ExtractionOrder lists the extracting techniques by the requests they
+// spend per value, fewest first: the order ExtractChain falls back in.
+var ExtractionOrder = []string{"error-based", "union-based", "boolean-blind", "time-based"}
+
+// ErrBudgetExhausted is returned by the requests of a technique that has
+// spent its ChainOptions.Budget.
+var ErrBudgetExhausted = errors.New("extraction request budget exhausted")
+
+// ChainOptions configures ExtractChain.
+type ChainOptions struct {
+	// Order is the techniques to try after the finding's own; nil means
+	// ExtractionOrder.
+	Order []string
+
+	// Budget is the number of requests one technique may send before it
+	// is abandoned for the next; 0 means no limit.
+	Budget int
+}
+
+// ChainAttempt records one technique ExtractChain tried.
+type ChainAttempt struct {
+	Technique string
+	Requests  int
+	Err       error // Nil for a technique that returned an empty value
+}
+
+// ChainResult is the value ExtractChain read and how it was read.
+type ChainResult struct {
+	Value     string
+	Partial   bool   // The value may be incomplete
+	Technique string // Technique that delivered Value
+	Requests  int    // Requests of every attempt
+	Attempts  []ChainAttempt
+}
+
+// ExtractChain evaluates query through vuln's parameter like Extract, but
+// when vuln's technique fails or reads an empty value -- its payload
+// filtered, say -- it falls back to the other techniques in opts.Order,
+// each starting from vuln's boundary, and returns the first non-empty
+// value. A partial value is returned at once when ctx is done, and kept
+// otherwise in case no later technique does better. Techniques are tried
+// whether or not they found the parameter themselves; those the Scanner
+// lacks or that cannot extract are skipped. When every technique read an
+// empty value the result is empty, with no error. Spending the Scanner's
+// ScanConfig.MaxRequests stops the chain like ctx being done, with
+// ErrRequestBudget.
+func (s *Scanner) ExtractChain(ctx context.Context, target *ScanTarget, vuln Vulnerability, query string, opts ChainOptions) (*ChainResult, error) {
+	if !vuln.Injectable || vuln.PairedParameter != nil {
+		return nil, ErrNotExtractable
+	}
+	order := opts.Order
+	if order == nil {
+		order = ExtractionOrder
+	}
+	names := []string{vuln.Technique}
+	for _, name := range order {
+		if name != vuln.Technique {
+			names = append(names, name)
+		}
+	}
+
+	ctx, release := s.budget.bind(ctx)
+	defer release()
+	baseline, err := s.client.Do(ctx, buildBaselineRequest(target))
+	if err != nil {
+		return nil, fmt.Errorf("baseline request failed: %w", err)
+	}
+	client := s.extractClient(target)
+
+	res := &ChainResult{}
+	var partial *ChainResult
+	answered := ""
+	for _, name := range names {
+		ext := s.extractor(name)
+		if ext == nil {
+			continue
+		}
+		v := vuln
+		v.Technique = name
+		budget := &budgetClient{Client: client, limit: int64(opts.Budget)}
+		r, err := s.extractWith(ctx, ext, budget, target, v, baseline, query)
+
+		// A technique that failed may not report the requests it sent.
+		attempt := ChainAttempt{Technique: name, Requests: int(budget.sent.Load()), Err: err}
+		if r != nil {
+			attempt.Requests = max(attempt.Requests, r.Requests)
+		}
+		res.Requests += attempt.Requests
+		res.Attempts = append(res.Attempts, attempt)
+
+		switch {
+		case r != nil && r.Value != "" && !r.Partial && err == nil:
+			res.Value, res.Technique = r.Value, name
+			return res, nil
+		case r != nil && r.Value != "" && (partial == nil || len(r.Value) > len(partial.Value)):
+			partial = &ChainResult{Value: r.Value, Partial: true, Technique: name}
+		case err == nil && answered == "":
+			answered = name
+		}
+		if s.interrupted(ctx) != nil {
+			break
+		}
+	}
+
+	switch {
+	case partial != nil:
+		res.Value, res.Partial, res.Technique = partial.Value, true, partial.Technique
+		return res, s.interrupted(ctx)
+	case s.interrupted(ctx) != nil:
+		return res, s.interrupted(ctx)
+	case answered != "":
+		res.Technique = answered
+		return res, nil
+	case len(res.Attempts) == 0:
+		return nil, fmt.Errorf("%w: no technique with Extract", ErrNotExtractable)
+	}
+	errs := make([]error, len(res.Attempts))
+	for i, a := range res.Attempts {
+		errs[i] = fmt.Errorf("%s: %w", a.Technique, a.Err)
+	}
+	return res, fmt.Errorf("no technique extracted %q: %w", query, errors.Join(errs...))
+}
+
+// budgetClient counts the requests it sends and refuses those past limit,
+// when limit is positive. Concurrent requests may overshoot limit by the
+// number in flight.
+type budgetClient struct {
+	transport.Client
+	limit int64
+	sent  atomic.Int64
+}
+
+func (c *budgetClient) Do(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	if c.limit > 0 && c.sent.Load() >= c.limit {
+		return nil, ErrBudgetExhausted
+	}
+	c.sent.Add(1)
+	return c.Client.Do(ctx, req)
+}
@@ -0,0 +1,155 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// chainTechnique is a fakeTechnique whose Extract runs extract, recording
+// the requests it gets.
+type chainTechnique struct {
+	fakeTechnique
+	extract func(ctx context.Context, req *ExtractionRequest) (*ExtractionResult, error)
+	reqs    []*ExtractionRequest
+}
+
+func (c *chainTechnique) Extract(ctx context.Context, req *ExtractionRequest) (*ExtractionResult, error) {
+	c.reqs = append(c.reqs, req)
+	return c.extract(ctx, req)
+}
+
+func chainTech(name string, extract func(context.Context, *ExtractionRequest) (*ExtractionResult, error)) *chainTechnique {
+	return &chainTechnique{fakeTechnique: fakeTechnique{name: name}, extract: extract}
+}
+
+func chainFinding() Vulnerability {
+	prefix := "'"
+	return Vulnerability{
+		Parameter:  Parameter{Name: "id", Value: "1", Location: LocationQuery},
+		Technique:  "error-based",
+		DBMS:       "MySQL",
+		Injectable: true,
+		Boundary:   &prefix,
+	}
+}
+
+func TestScanner_ExtractChain_FallsBack(t *testing.T) {
+	errTech := chainTech("error-based", func(context.Context, *ExtractionRequest) (*ExtractionResult, error) {
+		return &ExtractionResult{Requests: 3}, errors.New("no error message in the response")
+	})
+	unionTech := chainTech("union-based", func(context.Context, *ExtractionRequest) (*ExtractionResult, error) {
+		return &ExtractionResult{Value: "8.0.32", Requests: 2}, nil
+	})
+	boolTech := chainTech("boolean-blind", func(context.Context, *ExtractionRequest) (*ExtractionResult, error) {
+		t.Error("boolean-blind tried after union-based succeeded")
+		return nil, nil
+	})
+	s, target := newExtractScanner(t, boolTech, unionTech, errTech)
+
+	res, err := s.ExtractChain(context.Background(), target, chainFinding(), "@@version", ChainOptions{})
+	if err != nil {
+		t.Fatalf("ExtractChain: %v", err)
+	}
+	if res.Value != "8.0.32" || res.Partial || res.Technique != "union-based" || res.Requests != 5 {
+		t.Errorf("result = %+v, want 8.0.32 from union-based in 5 requests", res)
+	}
+	if len(res.Attempts) != 2 || res.Attempts[0].Technique != "error-based" || res.Attempts[0].Err == nil {
+		t.Errorf("Attempts = %+v, want the error-based failure, then union-based", res.Attempts)
+	}
+	req := unionTech.reqs[0]
+	if prefix, ok := req.State.Boundary(); !ok || prefix != "'" {
+		t.Errorf("union-based boundary = %q, %v; want the finding's", prefix, ok)
+	}
+	if req.Finding.Technique != "union-based" || req.Parameter.Name != "id" {
+		t.Errorf("union-based request = %+v, want the finding's parameter", req)
+	}
+}
+
+func TestScanner_ExtractChain_EmptyFallsBack(t *testing.T) {
+	errTech := chainTech("error-based", func(context.Context, *ExtractionRequest) (*ExtractionResult, error) {
+		return &ExtractionResult{Requests: 1}, nil
+	})
+	unionTech := chainTech("union-based", func(context.Context, *ExtractionRequest) (*ExtractionResult, error) {
+		return &ExtractionResult{Value: "root@localhost", Requests: 1}, nil
+	})
+	s, target := newExtractScanner(t, errTech, unionTech)
+
+	res, err := s.ExtractChain(context.Background(), target, chainFinding(), "CURRENT_USER()", ChainOptions{})
+	if err != nil || res.Value != "root@localhost" || res.Technique != "union-based" {
+		t.Errorf("ExtractChain = %+v, %v; want union-based's value", res, err)
+	}
+}
+
+func TestScanner_ExtractChain_PartialOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errTech := chainTech("error-based", func(context.Context, *ExtractionRequest) (*ExtractionResult, error) {
+		return nil, errors.New("filtered")
+	})
+	boolTech := chainTech("boolean-blind", func(context.Context, *ExtractionRequest) (*ExtractionResult, error) {
+		cancel()
+		return &ExtractionResult{Value: "8.0", Partial: true, Requests: 40}, context.Canceled
+	})
+	timeTech := chainTech("time-based", func(context.Context, *ExtractionRequest) (*ExtractionResult, error) {
+		t.Error("time-based tried after cancellation")
+		return nil, nil
+	})
+	s, target := newExtractScanner(t, errTech, boolTech, timeTech)
+
+	res, err := s.ExtractChain(ctx, target, chainFinding(), "@@version", ChainOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+	if res == nil || res.Value != "8.0" || !res.Partial || res.Technique != "boolean-blind" || res.Requests != 40 {
+		t.Errorf("result = %+v, want boolean-blind's partial value", res)
+	}
+}
+
+func TestScanner_ExtractChain_Budget(t *testing.T) {
+	// error-based keeps probing until refused; the budget stops it.
+	errTech := chainTech("error-based", func(ctx context.Context, req *ExtractionRequest) (*ExtractionResult, error) {
+		for {
+			if _, err := req.Client.Do(ctx, &transport.Request{Method: "GET", URL: req.Target.URL}); err != nil {
+				return nil, err
+			}
+		}
+	})
+	unionTech := chainTech("union-based", func(context.Context, *ExtractionRequest) (*ExtractionResult, error) {
+		return &ExtractionResult{Value: "shop", Requests: 1}, nil
+	})
+	s, target := newExtractScanner(t, errTech, unionTech)
+
+	res, err := s.ExtractChain(context.Background(), target, chainFinding(), "DATABASE()", ChainOptions{Budget: 5})
+	if err != nil || res.Value != "shop" {
+		t.Fatalf("ExtractChain = %+v, %v; want union-based's value", res, err)
+	}
+	a := res.Attempts[0]
+	if !errors.Is(a.Err, ErrBudgetExhausted) || a.Requests != 5 {
+		t.Errorf("error-based attempt = %+v, want abandoned after 5 requests", a)
+	}
+}
+
+func TestScanner_ExtractChain_AllFail(t *testing.T) {
+	fail := func(context.Context, *ExtractionRequest) (*ExtractionResult, error) {
+		return nil, errors.New("filtered")
+	}
+	s, target := newExtractScanner(t, chainTech("error-based", fail), chainTech("time-based", fail))
+
+	res, err := s.ExtractChain(context.Background(), target, chainFinding(), "@@version", ChainOptions{})
+	if err == nil || !strings.Contains(err.Error(), "error-based: filtered") || !strings.Contains(err.Error(), "time-based: filtered") {
+		t.Errorf("error = %v, want both techniques' failures", err)
+	}
+	if res == nil || len(res.Attempts) != 2 {
+		t.Errorf("result = %+v, want both attempts recorded", res)
+	}
+
+	vuln := chainFinding()
+	vuln.Injectable = false
+	if _, err := s.ExtractChain(context.Background(), target, vuln, "1", ChainOptions{}); !errors.Is(err, ErrNotExtractable) {
+		t.Errorf("not injectable: error = %v, want ErrNotExtractable", err)
+	}
+}
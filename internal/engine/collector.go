@@ -0,0 +1,344 @@
+package engine
+
+import (
+	"sync"
+	"time"
+
+	"github.com/0x6d61/sqleech/internal/payloadlib"
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// ResultCollector receives scan output as it is produced. Scan emits into a
+// collector instead of building a ScanResult itself, so callers that run
+// many scans (bulk, daemon) or that stream output can aggregate incrementally.
+//
+// Scanner.ScanInto calls the methods from a single goroutine, in the order
+// the events occur, and calls Finalize exactly once at the end.
+type ResultCollector interface {
+	// AddFinding records a technique verdict (injectable or not).
+	AddFinding(v Vulnerability)
+
+	// AddError records a non-fatal error encountered during the scan.
+	AddError(err error)
+
+	// AddWarning records an informational warning.
+	AddWarning(msg string)
+
+	// SetDBMS records the DBMS the scan settled on.
+	SetDBMS(name, version string)
+
+	// Finalize records timing and request accounting once the scan ends.
+	Finalize(stats ScanStats)
+}
+
+// ScanStats holds the timing and request accounting of a finished scan.
+type ScanStats struct {
+	StartTime time.Time
+	EndTime   time.Time
+
+	// RequestCount is the client's cumulative request count at the end of
+	// the scan, as reported in ScanResult.RequestCount.
+	RequestCount int64
+
+	// ScanRequests is the number of requests sent during this scan only.
+	ScanRequests int64
+
+	// Truncated is set when the scan stopped on spending RequestBudget,
+	// the scanner's ScanConfig.MaxRequests.
+	Truncated     bool
+	RequestBudget int64
+
+	// Payloads is the tried/succeeded status of each payload corpus entry
+	// the techniques used, keyed by entry ID.
+	Payloads map[string]payloadlib.Status
+
+	// Outages are the target-unavailability windows observed during the
+	// scan, when outage awareness is enabled.
+	Outages []transport.Outage
+
+	// UnsafeWrites is set when the scan ran without the read-only guard.
+	UnsafeWrites bool
+
+	// TechniqueTimings are the per-technique worker pool timings, and
+	// JobTimings those of every job.
+	TechniqueTimings []TechniqueTiming
+	JobTimings       []JobTiming
+
+	// Skipped are the parameters left unprobed for safety.
+	Skipped []SkippedParameter
+
+	// SkippedJobs are the techniques not run on a parameter.
+	SkippedJobs []SkippedJob
+
+	// Unanswered counts technique probes that got no response, by
+	// transport.Failure name.
+	Unanswered map[string]int
+
+	// Profile is the passive target profile taken from the baseline.
+	Profile *TargetProfile
+
+	// Transport is the client's statistics at the end of the scan.
+	Transport *transport.TransportStats
+}
+
+// --------------------------------------------------------------------------
+// MemoryCollector
+// --------------------------------------------------------------------------
+
+// MemoryCollector builds a single ScanResult in memory. It is what Scan uses
+// internally.
+type MemoryCollector struct {
+	result *ScanResult
+}
+
+// NewMemoryCollector creates a collector for the given target. The target is
+// copied as-is into the result.
+func NewMemoryCollector(target *ScanTarget) *MemoryCollector {
+	return &MemoryCollector{
+		result: &ScanResult{Target: *target},
+	}
+}
+
+// AddFinding appends v to the result's vulnerabilities.
+func (c *MemoryCollector) AddFinding(v Vulnerability) {
+	c.result.Vulnerabilities = append(c.result.Vulnerabilities, v)
+}
+
+// AddError appends err to the result's errors.
+func (c *MemoryCollector) AddError(err error) {
+	c.result.Errors = append(c.result.Errors, err)
+}
+
+// AddWarning appends msg to the result's warnings.
+func (c *MemoryCollector) AddWarning(msg string) {
+	c.result.Warnings = append(c.result.Warnings, msg)
+}
+
+// SetDBMS sets the result's DBMS name and version.
+func (c *MemoryCollector) SetDBMS(name, version string) {
+	c.result.DBMS = name
+	c.result.DBMSVersion = version
+}
+
+// Finalize sets the result's timing, request count and budget, payload
+// coverage, outage windows, read-only status, technique timings, skipped
+// parameters and jobs, unanswered probes, target profile and transport
+// statistics.
+func (c *MemoryCollector) Finalize(stats ScanStats) {
+	c.result.StartTime = stats.StartTime
+	c.result.EndTime = stats.EndTime
+	c.result.RequestCount = stats.RequestCount
+	c.result.Truncated = stats.Truncated
+	c.result.RequestBudget = stats.RequestBudget
+	c.result.PayloadCoverage = stats.Payloads
+	c.result.Outages = stats.Outages
+	c.result.UnsafeWrites = stats.UnsafeWrites
+	c.result.TechniqueTimings = stats.TechniqueTimings
+	c.result.JobTimings = stats.JobTimings
+	c.result.Skipped = stats.Skipped
+	c.result.SkippedJobs = stats.SkippedJobs
+	c.result.Unanswered = stats.Unanswered
+	c.result.Profile = stats.Profile
+	c.result.Transport = stats.Transport
+}
+
+// Result returns the collected scan result.
+func (c *MemoryCollector) Result() *ScanResult {
+	return c.result
+}
+
+// Warnings returns the collected warnings.
+func (c *MemoryCollector) Warnings() []string {
+	return c.result.Warnings
+}
+
+// --------------------------------------------------------------------------
+// Fan-out
+// --------------------------------------------------------------------------
+
+// fanOut forwards every event to each collector in turn.
+type fanOut []ResultCollector
+
+// FanOut returns a collector that forwards every event to each of the given
+// collectors, in argument order, before the next event is delivered. It is
+// how streaming reports and notifiers attach alongside the result builder.
+func FanOut(collectors ...ResultCollector) ResultCollector {
+	return fanOut(collectors)
+}
+
+func (f fanOut) AddFinding(v Vulnerability) {
+	for _, c := range f {
+		c.AddFinding(v)
+	}
+}
+
+func (f fanOut) AddError(err error) {
+	for _, c := range f {
+		c.AddError(err)
+	}
+}
+
+func (f fanOut) AddWarning(msg string) {
+	for _, c := range f {
+		c.AddWarning(msg)
+	}
+}
+
+func (f fanOut) SetDBMS(name, version string) {
+	for _, c := range f {
+		c.SetDBMS(name, version)
+	}
+}
+
+func (f fanOut) Finalize(stats ScanStats) {
+	for _, c := range f {
+		c.Finalize(stats)
+	}
+}
+
+// --------------------------------------------------------------------------
+// MergingCollector
+// --------------------------------------------------------------------------
+
+// MergingCollector combines the output of several scans, attributing each
+// event to its target. It is safe for concurrent use by multiple scans.
+type MergingCollector struct {
+	mu      sync.Mutex
+	targets []*mergedTarget
+}
+
+type mergedTarget struct {
+	result   *ScanResult
+	warnings []string
+	done     bool
+}
+
+// MergedSummary aggregates counts across all targets of a MergingCollector.
+type MergedSummary struct {
+	Targets              int
+	CompletedTargets     int
+	VulnerableTargets    int
+	TotalVulnerabilities int // Injectable findings only
+	TotalRequests        int64
+	TotalErrors          int
+	TotalWarnings        int
+	DBMS                 map[string]string // Target URL -> DBMS name
+}
+
+// NewMergingCollector creates an empty MergingCollector.
+func NewMergingCollector() *MergingCollector {
+	return &MergingCollector{}
+}
+
+// Target returns a collector for one scan of target. Results are kept in the
+// order targets were registered.
+func (m *MergingCollector) Target(target *ScanTarget) ResultCollector {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := &mergedTarget{result: &ScanResult{Target: *target}}
+	m.targets = append(m.targets, t)
+	return &targetCollector{parent: m, t: t}
+}
+
+// Results returns a copy of each target's result in registration order.
+// RequestCount is the number of requests sent for that target alone.
+func (m *MergingCollector) Results() []*ScanResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]*ScanResult, len(m.targets))
+	for i, t := range m.targets {
+		r := *t.result
+		r.Vulnerabilities = append([]Vulnerability(nil), t.result.Vulnerabilities...)
+		r.Errors = append([]error(nil), t.result.Errors...)
+		r.Warnings = append([]string(nil), t.warnings...)
+		out[i] = &r
+	}
+	return out
+}
+
+// Summary returns combined counts across all targets.
+func (m *MergingCollector) Summary() MergedSummary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := MergedSummary{
+		Targets: len(m.targets),
+		DBMS:    make(map[string]string),
+	}
+	for _, t := range m.targets {
+		if t.done {
+			s.CompletedTargets++
+		}
+		injectable := 0
+		for _, v := range t.result.Vulnerabilities {
+			if v.Injectable {
+				injectable++
+			}
+		}
+		if injectable > 0 {
+			s.VulnerableTargets++
+		}
+		s.TotalVulnerabilities += injectable
+		s.TotalRequests += t.result.RequestCount
+		s.TotalErrors += len(t.result.Errors)
+		s.TotalWarnings += len(t.warnings)
+		if t.result.DBMS != "" {
+			s.DBMS[t.result.Target.URL] = t.result.DBMS
+		}
+	}
+	return s
+}
+
+// targetCollector is the per-target view handed out by MergingCollector.
+type targetCollector struct {
+	parent *MergingCollector
+	t      *mergedTarget
+}
+
+func (c *targetCollector) AddFinding(v Vulnerability) {
+	c.parent.mu.Lock()
+	defer c.parent.mu.Unlock()
+	c.t.result.Vulnerabilities = append(c.t.result.Vulnerabilities, v)
+}
+
+func (c *targetCollector) AddError(err error) {
+	c.parent.mu.Lock()
+	defer c.parent.mu.Unlock()
+	c.t.result.Errors = append(c.t.result.Errors, err)
+}
+
+func (c *targetCollector) AddWarning(msg string) {
+	c.parent.mu.Lock()
+	defer c.parent.mu.Unlock()
+	c.t.warnings = append(c.t.warnings, msg)
+}
+
+func (c *targetCollector) SetDBMS(name, version string) {
+	c.parent.mu.Lock()
+	defer c.parent.mu.Unlock()
+	c.t.result.DBMS = name
+	c.t.result.DBMSVersion = version
+}
+
+func (c *targetCollector) Finalize(stats ScanStats) {
+	c.parent.mu.Lock()
+	defer c.parent.mu.Unlock()
+	c.t.result.StartTime = stats.StartTime
+	c.t.result.EndTime = stats.EndTime
+	c.t.result.RequestCount = stats.ScanRequests
+	c.t.result.Truncated = stats.Truncated
+	c.t.result.RequestBudget = stats.RequestBudget
+	c.t.result.PayloadCoverage = stats.Payloads
+	c.t.result.Outages = stats.Outages
+	c.t.result.UnsafeWrites = stats.UnsafeWrites
+	c.t.result.TechniqueTimings = stats.TechniqueTimings
+	c.t.result.JobTimings = stats.JobTimings
+	c.t.result.Skipped = stats.Skipped
+	c.t.result.SkippedJobs = stats.SkippedJobs
+	c.t.result.Unanswered = stats.Unanswered
+	c.t.result.Profile = stats.Profile
+	c.t.result.Transport = stats.Transport
+	c.t.done = true
+}
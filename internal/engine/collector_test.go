@@ -0,0 +1,402 @@
+package engine_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata")
+
+// --------------------------------------------------------------------------
+// Recorded scan: fully deterministic stubs for every pipeline stage
+// --------------------------------------------------------------------------
+
+// recordedClient returns a fixed page for every request and counts them.
+type recordedClient struct {
+	mu       sync.Mutex
+	requests int64
+}
+
+func (c *recordedClient) Do(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	c.mu.Lock()
+	c.requests++
+	c.mu.Unlock()
+	return &transport.Response{StatusCode: 200, Protocol: "HTTP/2.0", Body: []byte("<html>recorded</html>")}, nil
+}
+func (c *recordedClient) SetProxy(string) error { return nil }
+func (c *recordedClient) SetRateLimit(float64)  {}
+func (c *recordedClient) Stats() *transport.TransportStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &transport.TransportStats{TotalRequests: c.requests}
+}
+
+// recordedTechnique reports a fixed verdict per parameter name.
+type recordedTechnique struct {
+	name       string
+	priority   int
+	injectable map[string]float64 // parameter name -> confidence
+}
+
+func (t *recordedTechnique) Name() string  { return t.name }
+func (t *recordedTechnique) Priority() int { return t.priority }
+func (t *recordedTechnique) Detect(ctx context.Context, req *engine.TechniqueRequest) (*engine.DetectionResult, error) {
+	_, _ = req.Client.Do(ctx, &transport.Request{URL: req.Target.URL})
+	conf, ok := t.injectable[req.Parameter.Name]
+	if !ok {
+		return &engine.DetectionResult{Technique: t.name}, nil
+	}
+	return &engine.DetectionResult{
+		Injectable: true,
+		Confidence: conf,
+		Technique:  t.name,
+		Payload:    req.Parameter.Value + "' AND 1=1-- -",
+		Evidence:   t.name + " evidence for " + req.Parameter.Name,
+	}, nil
+}
+
+func newRecordedScanner(client transport.Client) *engine.Scanner {
+	cfg := engine.DefaultScanConfig()
+	cfg.Threads = 1
+	cfg.CrossParam = true
+	cfg.Risk = 3
+	cfg.PerTechnique = true
+
+	return engine.NewScanner(client, cfg,
+		engine.WithTechniques(
+			&recordedTechnique{name: "error-based", priority: 1, injectable: map[string]float64{"id": 0.95}},
+			&recordedTechnique{name: "boolean-blind", priority: 2, injectable: map[string]float64{"id": 0.6}},
+		),
+		engine.WithParameterParser(func(rawURL, body, contentType string) []engine.Parameter {
+			return []engine.Parameter{
+				{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+				{Name: "sort", Value: "asc", Location: engine.LocationQuery},
+				{Name: "name", Value: "admin", Location: engine.LocationQuery},
+				{Name: "city", Value: "paris", Location: engine.LocationQuery},
+			}
+		}),
+		engine.WithHeuristicDetector(func(ctx context.Context, target *engine.ScanTarget) ([]engine.HeuristicResult, error) {
+			var out []engine.HeuristicResult
+			for _, p := range target.Parameters {
+				hr := engine.HeuristicResult{Parameter: p, DynamicContent: true}
+				if p.Name == "id" {
+					hr.IsInjectable = true
+				}
+				out = append(out, hr)
+			}
+			return out, nil
+		}),
+		engine.WithFingerprinter(func(ctx context.Context, target *engine.ScanTarget, param *engine.Parameter, baseline *transport.Response, client transport.Client) (*engine.DBMSInfo, error) {
+			return &engine.DBMSInfo{Name: "MySQL", Version: "8.0.32", Confidence: 0.9}, nil
+		}),
+		engine.WithCrossParamDetector(func(ctx context.Context, target *engine.ScanTarget, params []engine.Parameter, dbms string, client transport.Client) ([]engine.Vulnerability, error) {
+			second := params[1]
+			return []engine.Vulnerability{{
+				Parameter:       params[0],
+				Technique:       "split-comment-bridge",
+				DBMS:            dbms,
+				Payload:         params[0].Value + "'/*",
+				Confidence:      0.85,
+				Evidence:        "split",
+				Injectable:      true,
+				PairedParameter: &second,
+				PairedPayload:   "*/ AND 1=1-- -",
+			}}, errors.New("pair budget exhausted")
+		}),
+	)
+}
+
+// encodeResult serialises a ScanResult deterministically for comparison:
+// timestamps are zeroed and errors are rendered as strings.
+func encodeResult(t *testing.T, r *engine.ScanResult) []byte {
+	t.Helper()
+	clone := *r
+	clone.StartTime, clone.EndTime = time.Time{}, time.Time{}
+	clone.TechniqueTimings = make([]engine.TechniqueTiming, len(r.TechniqueTimings))
+	for i, tt := range r.TechniqueTimings {
+		clone.TechniqueTimings[i] = engine.TechniqueTiming{Technique: tt.Technique, Jobs: tt.Jobs}
+	}
+	clone.JobTimings = make([]engine.JobTiming, len(r.JobTimings))
+	for i, jt := range r.JobTimings {
+		clone.JobTimings[i] = engine.JobTiming{Parameter: jt.Parameter, Technique: jt.Technique, Err: jt.Err}
+	}
+	errs := make([]string, len(r.Errors))
+	for i, e := range r.Errors {
+		errs[i] = e.Error()
+	}
+	clone.Errors = nil
+
+	b, err := json.MarshalIndent(struct {
+		Result *engine.ScanResult
+		Errors []string
+	}{&clone, errs}, "", "  ")
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return append(b, '\n')
+}
+
+// TestScan_RecordedGolden checks that the legacy Scan API still produces
+// byte-identical results for a fixed recorded scan.
+func TestScan_RecordedGolden(t *testing.T) {
+	scanner := newRecordedScanner(&recordedClient{})
+	result, err := scanner.Scan(context.Background(), &engine.ScanTarget{
+		URL:    "http://recorded.test/item?id=1&sort=asc&name=admin&city=paris",
+		Method: "GET",
+	})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+
+	got := encodeResult(t, result)
+	golden := filepath.Join("testdata", "recorded_scan.golden.json")
+	if *updateGolden {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("read golden: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Scan result differs from %s:\ngot:\n%s\nwant:\n%s", golden, got, want)
+	}
+}
+
+// TestScan_RecordedPreCollector checks the recorded scan against the result
+// the engine produced before Scan was split into ResultCollector stages.
+// The file was generated from that engine and must not be regenerated: a
+// field it holds has to keep its value, while fields added to ScanResult
+// since are ignored.
+func TestScan_RecordedPreCollector(t *testing.T) {
+	scanner := newRecordedScanner(&recordedClient{})
+	result, err := scanner.Scan(context.Background(), &engine.ScanTarget{
+		URL:    "http://recorded.test/item?id=1&sort=asc&name=admin&city=paris",
+		Method: "GET",
+	})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+
+	var got, want any
+	if err := json.Unmarshal(encodeResult(t, result), &got); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join("testdata", "recorded_scan.pre_collector.json"))
+	if err != nil {
+		t.Fatalf("read pre-collector result: %v", err)
+	}
+	if err := json.Unmarshal(b, &want); err != nil {
+		t.Fatal(err)
+	}
+	checkSubset(t, "", got, want)
+}
+
+// checkSubset reports every value of want that got lacks or holds
+// differently. Objects in got may have keys want does not.
+func checkSubset(t *testing.T, path string, got, want any) {
+	t.Helper()
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			t.Errorf("%s = %v, want an object", path, got)
+			return
+		}
+		for k, wv := range w {
+			gv, ok := g[k]
+			if !ok {
+				t.Errorf("%s.%s missing", path, k)
+				continue
+			}
+			checkSubset(t, path+"."+k, gv, wv)
+		}
+	case []any:
+		g, ok := got.([]any)
+		if !ok || len(g) != len(w) {
+			t.Errorf("%s = %v, want %d elements", path, got, len(w))
+			return
+		}
+		for i := range w {
+			checkSubset(t, fmt.Sprintf("%s[%d]", path, i), g[i], w[i])
+		}
+	default:
+		if got != want {
+			t.Errorf("%s = %v, want %v", path, got, want)
+		}
+	}
+}
+
+// --------------------------------------------------------------------------
+// Fan-out
+// --------------------------------------------------------------------------
+
+// eventLog records events from several collectors into one shared log.
+type eventLog struct {
+	name   string
+	events *[]string
+}
+
+func (l eventLog) add(ev string)                     { *l.events = append(*l.events, l.name+":"+ev) }
+func (l eventLog) AddFinding(v engine.Vulnerability) { l.add("finding " + v.Technique) }
+func (l eventLog) AddError(err error)                { l.add("error " + err.Error()) }
+func (l eventLog) AddWarning(msg string)             { l.add("warning " + msg) }
+func (l eventLog) SetDBMS(name, version string)      { l.add("dbms " + name) }
+func (l eventLog) Finalize(stats engine.ScanStats)   { l.add("finalize") }
+
+func TestFanOut_Ordering(t *testing.T) {
+	var events []string
+	c := engine.FanOut(eventLog{"a", &events}, eventLog{"b", &events})
+
+	c.SetDBMS("MySQL", "8.0")
+	c.AddFinding(engine.Vulnerability{Technique: "error-based"})
+	c.AddWarning("slow")
+	c.AddError(errors.New("boom"))
+	c.Finalize(engine.ScanStats{})
+
+	want := []string{
+		"a:dbms MySQL", "b:dbms MySQL",
+		"a:finding error-based", "b:finding error-based",
+		"a:warning slow", "b:warning slow",
+		"a:error boom", "b:error boom",
+		"a:finalize", "b:finalize",
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %v, want %v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d = %q, want %q", i, events[i], want[i])
+		}
+	}
+}
+
+func TestScanInto_FanOutMatchesScan(t *testing.T) {
+	target := func() *engine.ScanTarget {
+		return &engine.ScanTarget{URL: "http://recorded.test/item?id=1", Method: "GET"}
+	}
+
+	legacy, err := newRecordedScanner(&recordedClient{}).Scan(context.Background(), target())
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+
+	var events []string
+	tgt := target()
+	mem := engine.NewMemoryCollector(tgt)
+	err = newRecordedScanner(&recordedClient{}).ScanInto(context.Background(), tgt, engine.FanOut(mem, eventLog{"log", &events}))
+	if err != nil {
+		t.Fatalf("ScanInto: %v", err)
+	}
+
+	if !bytes.Equal(encodeResult(t, mem.Result()), encodeResult(t, legacy)) {
+		t.Error("fan-out MemoryCollector result differs from Scan result")
+	}
+	if events[0] != "log:dbms MySQL" || events[len(events)-1] != "log:finalize" {
+		t.Errorf("events should start with dbms and end with finalize: %v", events)
+	}
+}
+
+// --------------------------------------------------------------------------
+// MergingCollector
+// --------------------------------------------------------------------------
+
+func TestMergingCollector_Scans(t *testing.T) {
+	client := &recordedClient{}
+	merged := engine.NewMergingCollector()
+
+	// Two scans sharing one client: per-target request counts must not
+	// include the other target's requests.
+	mysql := newRecordedScanner(client)
+	t1 := &engine.ScanTarget{URL: "http://a.test/?id=1", Method: "GET"}
+	if err := mysql.ScanInto(context.Background(), t1, merged.Target(t1)); err != nil {
+		t.Fatalf("ScanInto a: %v", err)
+	}
+
+	cfg := engine.DefaultScanConfig()
+	cfg.DBMSHint = "PostgreSQL"
+	pg := engine.NewScanner(client, cfg,
+		engine.WithTechniques(&recordedTechnique{name: "error-based", priority: 1, injectable: map[string]float64{"q": 0.9}}),
+	)
+	t2 := &engine.ScanTarget{
+		URL:        "http://b.test/?q=x",
+		Method:     "GET",
+		Parameters: []engine.Parameter{{Name: "q", Value: "x", Location: engine.LocationQuery}},
+	}
+	if err := pg.ScanInto(context.Background(), t2, merged.Target(t2)); err != nil {
+		t.Fatalf("ScanInto b: %v", err)
+	}
+
+	results := merged.Results()
+	if len(results) != 2 {
+		t.Fatalf("Results() len = %d, want 2", len(results))
+	}
+	if results[0].Target.URL != t1.URL || results[1].Target.URL != t2.URL {
+		t.Errorf("results out of order: %s, %s", results[0].Target.URL, results[1].Target.URL)
+	}
+	if results[0].DBMS != "MySQL" || results[1].DBMS != "PostgreSQL" {
+		t.Errorf("per-target DBMS = %q, %q; want MySQL, PostgreSQL", results[0].DBMS, results[1].DBMS)
+	}
+	if results[0].RequestCount != 3 || results[1].RequestCount != 2 {
+		t.Errorf("per-target requests = %d, %d; want 3, 2", results[0].RequestCount, results[1].RequestCount)
+	}
+
+	s := merged.Summary()
+	if s.Targets != 2 || s.CompletedTargets != 2 || s.VulnerableTargets != 2 {
+		t.Errorf("targets = %d/%d/%d, want 2/2/2", s.Targets, s.CompletedTargets, s.VulnerableTargets)
+	}
+	if s.TotalVulnerabilities != 4 {
+		t.Errorf("TotalVulnerabilities = %d, want 4 (3 + 1)", s.TotalVulnerabilities)
+	}
+	if s.TotalRequests != client.requests {
+		t.Errorf("TotalRequests = %d, want %d", s.TotalRequests, client.requests)
+	}
+	if s.TotalErrors != 1 {
+		t.Errorf("TotalErrors = %d, want 1", s.TotalErrors)
+	}
+	if s.DBMS[t1.URL] != "MySQL" || s.DBMS[t2.URL] != "PostgreSQL" {
+		t.Errorf("DBMS map = %v", s.DBMS)
+	}
+}
+
+func TestMergingCollector_Concurrent(t *testing.T) {
+	merged := engine.NewMergingCollector()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		c := merged.Target(&engine.ScanTarget{URL: "http://t.test/" + string(rune('a'+i))})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				c.AddFinding(engine.Vulnerability{Injectable: j%2 == 0})
+			}
+			c.AddWarning("w")
+			c.Finalize(engine.ScanStats{ScanRequests: 10})
+		}()
+	}
+	wg.Wait()
+
+	s := merged.Summary()
+	if s.TotalVulnerabilities != 8*25 || s.TotalRequests != 80 || s.TotalWarnings != 8 || s.CompletedTargets != 8 {
+		t.Errorf("summary = %+v", s)
+	}
+	for _, r := range merged.Results() {
+		if len(r.Vulnerabilities) != 50 {
+			t.Errorf("%s: %d findings, want 50", r.Target.URL, len(r.Vulnerabilities))
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package engine
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/0x6d61/sqleech/internal/dbms"
+)
+
+// An identification at or above highDBMSConfidence, or backed by at least
+// highDBMSSignatures error signatures, is strong evidence: reconciliation
+// never overrides it with a weaker one.
+const (
+	highDBMSConfidence = 0.9
+	highDBMSSignatures = 2
+)
+
+// DBMSAssignment is the DBMS identified for one parameter and the evidence
+// behind it.
+type DBMSAssignment struct {
+	Parameter  Parameter
+	Endpoint   string // EndpointKey of the request the parameter is sent in
+	DBMS       string // Empty when nothing was identified
+	Version    string
+	Confidence float64
+	Signatures int  // Error signatures of DBMS in the parameter's responses
+	Tentative  bool // See DBMSInfo.Tentative
+}
+
+// High reports whether a rests on strong evidence: several error
+// signatures, or active probes with a high confidence. A tentative
+// assignment can be strong: only the fork within the family is in doubt.
+func (a DBMSAssignment) High() bool {
+	return a.Signatures >= highDBMSSignatures || a.Confidence >= highDBMSConfidence
+}
+
+// stronger reports whether a rests on stronger evidence than b: strong
+// before weak, then more error signatures, then higher confidence.
+func (a DBMSAssignment) stronger(b DBMSAssignment) bool {
+	if a.High() != b.High() {
+		return a.High()
+	}
+	if a.Signatures != b.Signatures {
+		return a.Signatures > b.Signatures
+	}
+	return a.Confidence > b.Confidence
+}
+
+// DBMSConfirmFunc re-identifies the DBMS behind a parameter with active
+// probes. It returns nil when they identify nothing.
+type DBMSConfirmFunc func(a DBMSAssignment) *DBMSInfo
+
+// ReconcileDBMS checks that the parameters of each endpoint agree on the
+// DBMS, since one query rarely spans two databases: a disagreement is
+// usually a fingerprinting error, and running the wrong payloads degrades
+// detection. Within an endpoint, the assignment on the strongest evidence
+// stands. A dissenting one is re-checked with confirm, when set, and takes
+// the standing DBMS unless the re-check reproduces the dissent. Two strong
+// assignments are both kept: the backend may genuinely be polyglot.
+// Tentative assignments within one family are refined silently.
+//
+// It returns the reconciled assignments, in order, and a warning for each
+// disagreement describing how it was resolved.
+func ReconcileDBMS(assigns []DBMSAssignment, confirm DBMSConfirmFunc) ([]DBMSAssignment, []string) {
+	out := make([]DBMSAssignment, len(assigns))
+	copy(out, assigns)
+
+	var endpoints []string
+	groups := make(map[string][]int)
+	for i, a := range out {
+		if _, ok := groups[a.Endpoint]; !ok {
+			endpoints = append(endpoints, a.Endpoint)
+		}
+		groups[a.Endpoint] = append(groups[a.Endpoint], i)
+	}
+
+	var warnings []string
+	for _, ep := range endpoints {
+		best := -1
+		for _, i := range groups[ep] {
+			if out[i].DBMS != "" && (best < 0 || out[i].stronger(out[best])) {
+				best = i
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		w := out[best]
+
+		for _, i := range groups[ep] {
+			a := out[i]
+			if a.DBMS == "" || a.DBMS == w.DBMS {
+				continue
+			}
+			if dbms.Family(a.DBMS) == dbms.Family(w.DBMS) && (a.Tentative || w.Tentative) {
+				// One names the fork the other could not tell.
+				if a.Tentative {
+					out[i] = adopt(a, w)
+				}
+				continue
+			}
+			if a.High() && w.High() {
+				warnings = append(warnings, fmt.Sprintf(
+					"DBMS disagreement on %s: %q identifies %s and %q %s, both on strong evidence; keeping both",
+					ep, w.Parameter.Name, w.DBMS, a.Parameter.Name, a.DBMS))
+				continue
+			}
+
+			var recheck *DBMSInfo
+			if confirm != nil {
+				recheck = confirm(a)
+			}
+			if recheck != nil && recheck.Name == a.DBMS {
+				warnings = append(warnings, fmt.Sprintf(
+					"DBMS disagreement on %s: %q identifies %s against %s from %q, and a re-check confirmed it; keeping both",
+					ep, a.Parameter.Name, a.DBMS, w.DBMS, w.Parameter.Name))
+				continue
+			}
+			if recheck == nil && confirm == nil && !w.High() {
+				warnings = append(warnings, fmt.Sprintf(
+					"DBMS disagreement on %s: %q identifies %s against %s from %q, neither on strong evidence; keeping both",
+					ep, a.Parameter.Name, a.DBMS, w.DBMS, w.Parameter.Name))
+				continue
+			}
+			found := "nothing"
+			if recheck != nil {
+				found = recheck.Name
+			}
+			warnings = append(warnings, fmt.Sprintf(
+				"DBMS disagreement on %s: %q identified %s on weaker evidence than %s from %q (re-check found %s); using %s",
+				ep, a.Parameter.Name, a.DBMS, w.DBMS, w.Parameter.Name, found, w.DBMS))
+			out[i] = adopt(a, w)
+		}
+	}
+	return out, warnings
+}
+
+// adopt returns a with the identification of w.
+func adopt(a, w DBMSAssignment) DBMSAssignment {
+	a.DBMS, a.Version, a.Confidence, a.Signatures, a.Tentative = w.DBMS, w.Version, w.Confidence, w.Signatures, w.Tentative
+	return a
+}
+
+// EndpointKey canonicalizes the endpoint a request goes to: the method and
+// the URL without query or fragment, with the scheme and host lowercased
+// and any trailing slash dropped. Parameters of one endpoint feed the same
+// code path, and usually the same query.
+func EndpointKey(method, rawURL string) string {
+	if method == "" {
+		method = http.MethodGet
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return strings.ToUpper(method) + " " + rawURL
+	}
+	path := strings.TrimSuffix(u.EscapedPath(), "/")
+	if path == "" {
+		path = "/"
+	}
+	return strings.ToUpper(method) + " " + strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host) + path
+}
@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+const testEndpoint = "GET http://shop.test/item"
+
+func assign(name, dbms string, confidence float64, signatures int) DBMSAssignment {
+	return DBMSAssignment{
+		Parameter:  Parameter{Name: name, Value: "1", Location: LocationQuery},
+		Endpoint:   testEndpoint,
+		DBMS:       dbms,
+		Confidence: confidence,
+		Signatures: signatures,
+	}
+}
+
+func dbmsOf(assigns []DBMSAssignment) []string {
+	out := make([]string, len(assigns))
+	for i, a := range assigns {
+		out[i] = a.DBMS
+	}
+	return out
+}
+
+func TestReconcileDBMS(t *testing.T) {
+	confirmAs := func(name string) DBMSConfirmFunc {
+		return func(DBMSAssignment) *DBMSInfo {
+			if name == "" {
+				return nil
+			}
+			return &DBMSInfo{Name: name, Confidence: 1.0}
+		}
+	}
+
+	tests := []struct {
+		name     string
+		in       []DBMSAssignment
+		confirm  DBMSConfirmFunc
+		want     []string
+		warnings int
+		warning  string // Substring of the first warning
+	}{
+		{
+			name: "agreement",
+			in:   []DBMSAssignment{assign("id", "MySQL", 0.7, 2), assign("q", "MySQL", 0.7, 1), assign("sort", "", 0, 0)},
+			want: []string{"MySQL", "MySQL", ""},
+		},
+		{
+			name:     "noisy dissent resolved by re-check",
+			in:       []DBMSAssignment{assign("id", "MySQL", 0.7, 2), assign("q", "PostgreSQL", 0.7, 1)},
+			confirm:  confirmAs("MySQL"),
+			want:     []string{"MySQL", "MySQL"},
+			warnings: 1,
+			warning:  `"q" identified PostgreSQL on weaker evidence than MySQL from "id" (re-check found MySQL); using MySQL`,
+		},
+		{
+			name:     "noisy dissent, re-check finds nothing",
+			in:       []DBMSAssignment{assign("id", "MySQL", 0.7, 2), assign("q", "PostgreSQL", 0.7, 1)},
+			confirm:  confirmAs(""),
+			want:     []string{"MySQL", "MySQL"},
+			warnings: 1,
+			warning:  "re-check found nothing",
+		},
+		{
+			name:     "dissent reproduced by re-check",
+			in:       []DBMSAssignment{assign("id", "MySQL", 0.7, 2), assign("q", "PostgreSQL", 0.7, 1)},
+			confirm:  confirmAs("PostgreSQL"),
+			want:     []string{"MySQL", "PostgreSQL"},
+			warnings: 1,
+			warning:  "a re-check confirmed it; keeping both",
+		},
+		{
+			name:     "genuine polyglot",
+			in:       []DBMSAssignment{assign("id", "MySQL", 0.7, 2), assign("report", "MSSQL", 0.7, 3)},
+			confirm:  confirmAs("MySQL"),
+			want:     []string{"MySQL", "MSSQL"},
+			warnings: 1,
+			warning:  "both on strong evidence; keeping both",
+		},
+		{
+			name: "strong error signatures never yield to a weak identification",
+			// The weak one comes first; the two-signature one still stands.
+			in:       []DBMSAssignment{assign("q", "SQLite", 0.7, 1), assign("id", "PostgreSQL", 0.7, 2)},
+			want:     []string{"PostgreSQL", "PostgreSQL"},
+			warnings: 1,
+			warning:  `"q" identified SQLite on weaker evidence than PostgreSQL from "id"`,
+		},
+		{
+			name:     "no re-check against a strong identification",
+			in:       []DBMSAssignment{assign("id", "MySQL", 0.7, 2), assign("q", "PostgreSQL", 0.7, 1)},
+			want:     []string{"MySQL", "MySQL"},
+			warnings: 1,
+		},
+		{
+			name:     "no re-check between weak identifications",
+			in:       []DBMSAssignment{assign("id", "MySQL", 0.7, 1), assign("q", "PostgreSQL", 0.7, 1)},
+			want:     []string{"MySQL", "PostgreSQL"},
+			warnings: 1,
+			warning:  "neither on strong evidence",
+		},
+		{
+			name: "other endpoints are not compared",
+			in: []DBMSAssignment{assign("id", "MySQL", 0.7, 2),
+				{Parameter: Parameter{Name: "q"}, Endpoint: "GET http://shop.test/search", DBMS: "PostgreSQL", Confidence: 0.7, Signatures: 1}},
+			want: []string{"MySQL", "PostgreSQL"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warnings := ReconcileDBMS(tt.in, tt.confirm)
+			if strings.Join(dbmsOf(got), ",") != strings.Join(tt.want, ",") {
+				t.Errorf("DBMS = %v, want %v", dbmsOf(got), tt.want)
+			}
+			if len(warnings) != tt.warnings {
+				t.Fatalf("warnings = %q, want %d", warnings, tt.warnings)
+			}
+			if tt.warning != "" && !strings.Contains(warnings[0], tt.warning) {
+				t.Errorf("warning = %q, want it to contain %q", warnings[0], tt.warning)
+			}
+		})
+	}
+}
+
+func TestReconcileDBMS_TentativeRefined(t *testing.T) {
+	mysql := assign("q", "MySQL", 0.7, 1)
+	mysql.Tentative = true
+	got, warnings := ReconcileDBMS([]DBMSAssignment{assign("id", "MariaDB", 0.7, 2), mysql}, nil)
+	if got[1].DBMS != "MariaDB" || got[1].Tentative || len(warnings) != 0 {
+		t.Errorf("got %+v, warnings %q; want a silent refinement to MariaDB", got[1], warnings)
+	}
+}
+
+func TestReconcileDBMS_ForkKept(t *testing.T) {
+	mysql := assign("id", "MySQL", 0.7, 3)
+	mysql.Tentative = true
+	got, warnings := ReconcileDBMS([]DBMSAssignment{mysql, assign("q", "MariaDB", 0.7, 1)}, nil)
+	if got[0].DBMS != "MySQL" || got[1].DBMS != "MariaDB" || len(warnings) != 0 {
+		t.Errorf("DBMS = %v, warnings %q; want the MariaDB identification kept silently", dbmsOf(got), warnings)
+	}
+}
+
+func TestReconcileDBMS_InputUnchanged(t *testing.T) {
+	in := []DBMSAssignment{assign("id", "MySQL", 0.7, 2), assign("q", "PostgreSQL", 0.7, 1)}
+	ReconcileDBMS(in, nil)
+	if in[1].DBMS != "PostgreSQL" {
+		t.Error("ReconcileDBMS modified its input")
+	}
+}
+
+func TestEndpointKey(t *testing.T) {
+	tests := []struct {
+		method, url, want string
+	}{
+		{"GET", "http://Shop.TEST/item?id=1", "GET http://shop.test/item"},
+		{"", "http://shop.test/item/?id=2#top", "GET http://shop.test/item"},
+		{"post", "HTTPS://shop.test", "POST https://shop.test/"},
+		{"GET", "http://shop.test/Item", "GET http://shop.test/Item"},
+	}
+	for _, tt := range tests {
+		if got := EndpointKey(tt.method, tt.url); got != tt.want {
+			t.Errorf("EndpointKey(%q, %q) = %q, want %q", tt.method, tt.url, got, tt.want)
+		}
+	}
+}
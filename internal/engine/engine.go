@@ -1,7 +1,15 @@
 // Package engine provides the core scan orchestration pipeline.
 package engine
 
-import "time"
+import (
+	"encoding/base64"
+	"slices"
+	"time"
+
+	"github.com/0x6d61/sqleech/internal/marker"
+	"github.com/0x6d61/sqleech/internal/payloadlib"
+	"github.com/0x6d61/sqleech/internal/transport"
+)
 
 // ScanTarget represents a single target to scan.
 type ScanTarget struct {
@@ -12,6 +20,15 @@ type ScanTarget struct {
 	ContentType string
 	Cookies     map[string]string
 	Parameters  []Parameter
+
+	// RawXML splices payloads into XML bodies unescaped, for backends
+	// that read the text of an element without decoding entities.
+	RawXML bool
+
+	// Markers is the request as written with * injection markers, whose
+	// points the LocationCustom parameters name. The other fields hold
+	// the request with the markers removed.
+	Markers *marker.Request
 }
 
 // Parameter represents a single injectable parameter.
@@ -20,6 +37,21 @@ type Parameter struct {
 	Value    string
 	Location ParameterLocation
 	Type     ParameterType
+
+	// Base64 marks a parameter the application base64-decodes before
+	// use. Value then holds the decoded value, which payloads build on,
+	// and probes send them encoded again (see Encode).
+	Base64 bool
+}
+
+// Encode returns value as probes of p send it: base64-encoded when the
+// application decodes p, unchanged otherwise. Every probe builder passes
+// its payload through it before substituting it into the request.
+func (p Parameter) Encode(value string) string {
+	if !p.Base64 {
+		return value
+	}
+	return base64.StdEncoding.EncodeToString([]byte(value))
 }
 
 // ParameterLocation indicates where a parameter appears in the request.
@@ -35,13 +67,14 @@ const (
 	LocationGraphQL
 	LocationXML
 	LocationMultipart
+	LocationCustom
 )
 
 // String returns a human-readable name for the location.
 func (l ParameterLocation) String() string {
 	names := [...]string{
 		"query", "body", "header", "cookie", "path",
-		"json", "graphql", "xml", "multipart",
+		"json", "graphql", "xml", "multipart", "custom",
 	}
 	if int(l) < len(names) {
 		return names[l]
@@ -88,6 +121,131 @@ type ScanResult struct {
 	EndTime         time.Time
 	RequestCount    int64
 	Errors          []error
+
+	// Truncated is set when the scan stopped early because the scanner
+	// spent RequestBudget, its ScanConfig.MaxRequests. Parameters and
+	// techniques it had not finished may hide further findings.
+	Truncated     bool
+	RequestBudget int64
+
+	// PayloadCoverage is the tried/succeeded status of each payload corpus
+	// entry used during detection, keyed by entry ID.
+	PayloadCoverage map[string]payloadlib.Status
+
+	// Outages are the windows during which the target was unavailable.
+	// Findings never rest on probes sent inside one; report timestamps can
+	// be checked against them.
+	Outages []transport.Outage
+
+	// UnsafeWrites records that the scan ran with the read-only guard
+	// disabled (--unsafe-allow-writes), so payloads may have modified the
+	// target. Reports must show it prominently.
+	UnsafeWrites bool
+
+	// TechniqueTimings summarizes how long each technique's jobs waited in
+	// the worker pool queue and ran, sorted by technique name.
+	TechniqueTimings []TechniqueTiming
+
+	// JobTimings are the timings of every job the worker pool ran, in the
+	// order they finished.
+	JobTimings []JobTiming
+
+	// Skipped are the parameters the scan did not probe because they look
+	// state-changing (see ScanConfig.ReadOnly). Their absence of findings
+	// says nothing about them.
+	Skipped []SkippedParameter
+
+	// SkippedJobs are the techniques not run on a parameter, such as
+	// time-based when the latency gate disabled it (see ScanConfig.TimeSec).
+	SkippedJobs []SkippedJob
+
+	// Unanswered counts the probes techniques sent that got no response,
+	// by failure (see transport.ClassifyFailure). Techniques move on past
+	// such probes, so a nonzero count means coverage is incomplete.
+	Unanswered map[string]int
+
+	// Warnings are conditions the reader should know about that did not
+	// stop the scan, such as a disabled technique.
+	Warnings []string
+
+	// Transport is the client's statistics at the end of the scan:
+	// responses by status code, failed requests, retries, bytes received
+	// and response times. Like RequestCount it covers everything the
+	// client sent. Nil when the client reports none.
+	Transport *transport.TransportStats
+
+	// Profile is the target environment seen in the baseline response:
+	// banners, security headers, TLS and redirects. It is informational,
+	// never a finding. Nil when no baseline request was sent.
+	Profile *TargetProfile
+
+	// Banner, CurrentUser, CurrentDB and Hostname are read from the DBMS
+	// through a finding after the scan (--banner). Empty when not asked
+	// for or not readable.
+	Banner      string
+	CurrentUser string
+	CurrentDB   string
+	Hostname    string
+
+	// DBA is whether the DBMS user has DBA privileges, read through a
+	// finding after the scan (--is-dba). Nil when not asked for or not
+	// readable.
+	DBA *DBACheck
+
+	// Extractions are the results of user queries (--sql-query) read
+	// through a finding after the scan.
+	Extractions []Extraction
+}
+
+// Extraction is the result of a user query read through a finding.
+type Extraction struct {
+	Query     string
+	Values    []string // One per row; an expression has one
+	Technique string   // Technique of the finding read through
+	Parameter string   // Its parameter
+
+	// Filter is the --extract-filter path the values were read with. Values
+	// then hold what it selected, or the raw value of a row it could not
+	// apply to; Raw holds the raw values and FilterNotes, one per row, why
+	// it did not apply ("" where it did).
+	Filter      string
+	Raw         []string
+	FilterNotes []string
+}
+
+// DBACheck is the answer to whether the DBMS user is a DBA, with the query
+// that gave it as evidence.
+type DBACheck struct {
+	IsDBA bool
+	Query string // Expression extracted, such as IS_SRVROLEMEMBER('sysadmin')
+	Value string // Its value, such as 1
+}
+
+// SkippedJob is a technique the scan did not run on a parameter, and why.
+type SkippedJob struct {
+	Parameter Parameter
+	Technique string
+	Reason    string
+}
+
+// TechniqueTiming aggregates the worker pool timing of one technique's jobs.
+type TechniqueTiming struct {
+	Technique string
+	Jobs      int           // Jobs run, including abandoned ones
+	QueueWait time.Duration // Total time jobs waited for a free worker
+	Exec      time.Duration // Total time spent running jobs
+	MaxExec   time.Duration // Longest single job
+}
+
+// JobTiming is the worker pool timing of one job: a technique run on a
+// parameter. Err is why the job produced no verdict, such as a panic or
+// ScanConfig.JobTimeout, or nil.
+type JobTiming struct {
+	Parameter Parameter
+	Technique string
+	QueueWait time.Duration
+	Exec      time.Duration
+	Err       error
 }
 
 // Vulnerability represents a confirmed SQL injection point.
@@ -100,4 +258,35 @@ type Vulnerability struct {
 	Severity   Severity
 	Evidence   string
 	Injectable bool
+
+	// Protocol is the HTTP version of the responses the finding was
+	// decided on, such as "HTTP/2.0"; empty when unknown.
+	Protocol string
+
+	// PairedParameter and PairedPayload are set for cross-parameter
+	// findings: Payload goes into Parameter and PairedPayload into
+	// PairedParameter within the same request.
+	PairedParameter *Parameter
+	PairedPayload   string
+
+	// Boundary is the prefix that escaped the parameter's SQL context when
+	// it was tested (see ParamState.RecordBoundary), so that extraction by
+	// another technique starts from it; nil when none was recorded.
+	Boundary *string
+
+	// Triage is the counter-evidence gathered for a borderline finding;
+	// nil when it was not triaged.
+	Triage *TriageEvidence
+
+	// ConfirmedBy are the techniques that found Parameter injectable, the
+	// most confident first: Technique, whose payload and evidence the
+	// finding carries, then the others. Empty for findings not
+	// consolidated (see ScanConfig.PerTechnique).
+	ConfirmedBy []string
+}
+
+// ConfirmedWith reports whether the technique named name found v's
+// parameter injectable: v is its finding, or a finding it confirmed.
+func (v Vulnerability) ConfirmedWith(name string) bool {
+	return v.Injectable && (v.Technique == name || slices.Contains(v.ConfirmedBy, name))
 }
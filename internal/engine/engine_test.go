@@ -22,6 +22,17 @@ func TestParameterLocationString(t *testing.T) {
 	}
 }
 
+func TestParameterEncode(t *testing.T) {
+	p := Parameter{Name: "data", Value: "1234"}
+	if got := p.Encode("1234 AND 1=1"); got != "1234 AND 1=1" {
+		t.Errorf("Encode() = %q, want the payload unchanged", got)
+	}
+	p.Base64 = true
+	if got := p.Encode("1234 AND 1=1"); got != "MTIzNCBBTkQgMT0x" {
+		t.Errorf("Encode() = %q, want MTIzNCBBTkQgMT0x", got)
+	}
+}
+
 func TestSeverityString(t *testing.T) {
 	tests := []struct {
 		sev  Severity
@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// EventKind identifies what a scan Event reports.
+type EventKind int
+
+const (
+	EventProgress       EventKind = iota // A status message with nothing more to it
+	EventScanStart                       // A scan of URL begins
+	EventScanEnd                         // The scan of URL ended, with Err if it failed
+	EventBaseline                        // The baseline response came in
+	EventHeuristic                       // The heuristic verdict on Parameter
+	EventFingerprint                     // The scan settled on DBMS
+	EventTechniqueStart                  // Technique starts testing Parameter
+	EventTechniqueEnd                    // Technique finished testing Parameter
+	EventFinding                         // A finding was added to the result
+	EventError                           // A non-fatal error was added to the result
+)
+
+// String returns the kind name, as written to event streams.
+func (k EventKind) String() string {
+	names := [...]string{
+		"progress", "scan_start", "scan_end", "baseline", "heuristic",
+		"fingerprint", "technique_start", "technique_end", "finding", "error",
+	}
+	if int(k) < len(names) {
+		return names[k]
+	}
+	return "unknown"
+}
+
+// Event is a step of a scan, delivered to the functions passed to
+// Scanner.Subscribe. The fields an event sets depend on its Kind.
+type Event struct {
+	Kind EventKind
+	Time time.Time
+
+	// URL is the target of EventScanStart and EventScanEnd.
+	URL string
+
+	Parameter *Parameter // The parameter tested, if any
+	Technique string     // The technique running, if any
+
+	// Message is the status line of the event, as passed to a progress
+	// callback. It is empty for the events a progress callback never
+	// received: scan start and end, technique start and end, findings
+	// and errors.
+	Message string
+
+	// Injectable and Confidence are the verdict of EventHeuristic,
+	// EventTechniqueEnd and EventFinding, with the Payload it was decided
+	// on for the latter two.
+	Injectable bool
+	Confidence float64
+	Payload    string
+
+	// DBMS is the database of EventFingerprint, or that of the finding.
+	DBMS string
+
+	// Counts is set on EventScanEnd.
+	Counts EventCounts
+
+	// Err is the error of EventError, of a technique that failed on
+	// EventTechniqueEnd, or why the scan failed on EventScanEnd.
+	Err error
+}
+
+// EventCounts are the numbers of a scan: the parameters it tests and, once
+// it ended, its findings, those injectable, and the requests it sent.
+type EventCounts struct {
+	Parameters int
+	Findings   int
+	Injectable int
+	Requests   int64
+}
+
+// Subscribe registers fn to receive the events of every scan the Scanner
+// runs from then on. Events are delivered one at a time, in the order they
+// occur, so fn need not be safe for concurrent use, but it must return
+// quickly: every worker with an event to emit waits for it. fn may itself
+// call Subscribe; the new subscriber receives the events after the one
+// being delivered.
+func (s *Scanner) Subscribe(fn func(Event)) {
+	s.eventMu.Lock()
+	defer s.eventMu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+// emit stamps ev and delivers it to the subscribers.
+func (s *Scanner) emit(ev Event) {
+	s.eventMu.Lock()
+	subscribers := slices.Clone(s.subscribers)
+	s.eventMu.Unlock()
+	if len(subscribers) == 0 {
+		return
+	}
+
+	s.deliverMu.Lock()
+	defer s.deliverMu.Unlock()
+	ev.Time = time.Now()
+	for _, fn := range subscribers {
+		fn(ev)
+	}
+}
+
+// progress emits a status message.
+func (s *Scanner) progress(format string, args ...any) {
+	s.emit(Event{Kind: EventProgress, Message: fmt.Sprintf(format, args...)})
+}
+
+// eventCollector emits an event for each finding and error added to the
+// collector it wraps, and counts them for EventScanEnd.
+type eventCollector struct {
+	ResultCollector
+	s                    *Scanner
+	findings, injectable int
+}
+
+func (c *eventCollector) AddFinding(v Vulnerability) {
+	c.findings++
+	if v.Injectable {
+		c.injectable++
+	}
+	c.ResultCollector.AddFinding(v)
+	c.s.emit(Event{
+		Kind:       EventFinding,
+		Parameter:  &v.Parameter,
+		Technique:  v.Technique,
+		Injectable: v.Injectable,
+		Confidence: v.Confidence,
+		Payload:    v.Payload,
+		DBMS:       v.DBMS,
+	})
+}
+
+func (c *eventCollector) AddError(err error) {
+	c.ResultCollector.AddError(err)
+	c.s.emit(Event{Kind: EventError, Err: err})
+}
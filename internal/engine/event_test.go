@@ -0,0 +1,94 @@
+package engine_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+)
+
+// TestScanner_SubscribeFromSubscriber subscribes from inside a callback,
+// which must neither deadlock nor deliver the current event twice.
+func TestScanner_SubscribeFromSubscriber(t *testing.T) {
+	scanner := newRecordedScanner(&recordedClient{})
+	var late []engine.EventKind
+	scanner.Subscribe(func(ev engine.Event) {
+		if ev.Kind == engine.EventScanStart {
+			scanner.Subscribe(func(ev engine.Event) { late = append(late, ev.Kind) })
+		}
+	})
+
+	target := &engine.ScanTarget{URL: "http://recorded.test/item?id=1", Method: "GET"}
+	if _, err := scanner.Scan(context.Background(), target); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if len(late) == 0 || slices.Contains(late, engine.EventScanStart) || late[len(late)-1] != engine.EventScanEnd {
+		t.Errorf("late subscriber got %v, want the events after scan_start through scan_end", late)
+	}
+}
+
+func TestScanner_Subscribe(t *testing.T) {
+	scanner := newRecordedScanner(&recordedClient{})
+	var events []engine.Event
+	scanner.Subscribe(func(ev engine.Event) { events = append(events, ev) })
+	var messages []string
+	scanner.SetProgressCallback(func(msg string) { messages = append(messages, msg) })
+
+	target := &engine.ScanTarget{URL: "http://recorded.test/item?id=1", Method: "GET"}
+	if _, err := scanner.Scan(context.Background(), target); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+
+	// Status messages are left out: they are the progress callback's.
+	var got []string
+	var withMessage []string
+	for _, ev := range events {
+		if ev.Time.IsZero() {
+			t.Errorf("%s event has no time", ev.Kind)
+		}
+		if ev.Message != "" {
+			withMessage = append(withMessage, ev.Message)
+		}
+		if ev.Kind == engine.EventProgress {
+			continue
+		}
+		s := ev.Kind.String()
+		if ev.Parameter != nil {
+			s += " " + ev.Parameter.Name
+		}
+		if ev.Technique != "" {
+			s += " " + ev.Technique
+		}
+		if ev.Injectable {
+			s += " injectable"
+		}
+		got = append(got, s)
+	}
+	want := []string{
+		"scan_start",
+		"baseline",
+		"heuristic id injectable", "heuristic sort", "heuristic name", "heuristic city",
+		"fingerprint",
+		"technique_start id error-based", "technique_end id error-based injectable",
+		"technique_start id boolean-blind", "technique_end id boolean-blind injectable",
+		"finding id error-based injectable", "finding id boolean-blind injectable",
+		"error", // The cross-parameter detector's, before its findings
+		"finding sort split-comment-bridge injectable",
+		"scan_end",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("events:\n got %q\nwant %q", got, want)
+	}
+	if !slices.Equal(messages, withMessage) {
+		t.Errorf("progress callback got %q, want the event messages %q", messages, withMessage)
+	}
+
+	first, last := events[0], events[len(events)-1]
+	if first.URL != target.URL || last.URL != target.URL {
+		t.Errorf("scan start and end URLs = %q, %q, want %q", first.URL, last.URL, target.URL)
+	}
+	if c := last.Counts; c.Parameters != 4 || c.Findings != 3 || c.Injectable != 3 || c.Requests == 0 {
+		t.Errorf("scan end counts = %+v, want 4 parameters, 3 findings, all injectable, and the requests", c)
+	}
+}
@@ -0,0 +1,143 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/0x6d61/sqleech/internal/jsonpath"
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// ExtractionRequest asks a technique to read the value of a SQL expression
+// through the injection point of one of its findings.
+type ExtractionRequest struct {
+	TechniqueRequest
+	Finding Vulnerability
+	Query   string // SQL expression to evaluate, e.g. "@@version"
+
+	// Filter, when set, is applied to the value if it parses as JSON.
+	Filter *jsonpath.Path
+}
+
+// ExtractionResult holds an extracted value.
+type ExtractionResult struct {
+	Value    string
+	Partial  bool // The value may be incomplete
+	Requests int
+
+	// Filtered is what the request's Filter selected from Value, when
+	// FilterApplied. When a filter could not apply, FilterNote says why.
+	Filtered      string
+	FilterApplied bool
+	FilterNote    string
+}
+
+// Display returns the filtered value when a filter applied, else the raw
+// value. Reports show it by default.
+func (r *ExtractionResult) Display() string {
+	if r.FilterApplied {
+		return r.Filtered
+	}
+	return r.Value
+}
+
+// Extractor is implemented by techniques that can read data through their
+// findings.
+type Extractor interface {
+	// Extract evaluates req.Query through req.Parameter, injected as for
+	// req.Finding, and returns its value.
+	Extract(ctx context.Context, req *ExtractionRequest) (*ExtractionResult, error)
+}
+
+// ErrNotExtractable is returned by Scanner.Extract for a finding no
+// configured technique can extract through: not injectable, split across
+// two parameters, or made by a technique without Extract.
+var ErrNotExtractable = errors.New("finding does not support extraction")
+
+// Extract evaluates query through vuln, a finding of a scan of target, with
+// the technique that made the finding. The baseline is fetched afresh, and
+// with ScanConfig.ReadOnly the probes go through a NewReadOnlyClient guard
+// as during the scan. When the Scanner's ScanConfig.MaxRequests runs out
+// midway, the value read so far is returned, marked Partial, with an error
+// wrapping ErrRequestBudget.
+func (s *Scanner) Extract(ctx context.Context, target *ScanTarget, vuln Vulnerability, query string) (*ExtractionResult, error) {
+	if !vuln.Injectable || vuln.PairedParameter != nil {
+		return nil, ErrNotExtractable
+	}
+	ext := s.extractor(vuln.Technique)
+	if ext == nil {
+		return nil, fmt.Errorf("%w: no %s technique with Extract", ErrNotExtractable, vuln.Technique)
+	}
+
+	ctx, release := s.budget.bind(ctx)
+	defer release()
+	baseline, err := s.client.Do(ctx, buildBaselineRequest(target))
+	if err != nil {
+		return nil, fmt.Errorf("baseline request failed: %w", err)
+	}
+	res, err := s.extractWith(ctx, ext, s.extractClient(target), target, vuln, baseline, query)
+	if s.budget.spent() {
+		if res != nil {
+			res.Partial = true
+		}
+		if !errors.Is(err, ErrRequestBudget) {
+			err = errors.Join(err, ErrRequestBudget)
+		}
+	}
+	return res, err
+}
+
+// extractClient returns the client extraction probes go through: with
+// ScanConfig.ReadOnly, behind a NewReadOnlyClient guard.
+func (s *Scanner) extractClient(target *ScanTarget) transport.Client {
+	if s.config.ReadOnly {
+		return NewReadOnlyClient(s.client, target)
+	}
+	return s.client
+}
+
+// extractWith evaluates query with ext through vuln's parameter. The
+// boundary recorded for the finding, if any, is passed on in a fresh
+// ParamState for ext to try first.
+func (s *Scanner) extractWith(ctx context.Context, ext Extractor, client transport.Client, target *ScanTarget, vuln Vulnerability, baseline *transport.Response, query string) (*ExtractionResult, error) {
+	state := NewParamState()
+	if vuln.Boundary != nil {
+		state.RecordBoundary(*vuln.Boundary)
+	}
+	param := vuln.Parameter
+	return ext.Extract(ctx, &ExtractionRequest{
+		TechniqueRequest: TechniqueRequest{
+			Target:    target,
+			Parameter: &param,
+			Baseline:  baseline,
+			DBMS:      vuln.DBMS,
+			Client:    client,
+			State:     state,
+			Timeout:   s.techniqueTimeout(vuln.Technique),
+			Level:     s.config.Level,
+			Risk:      s.config.Risk,
+
+			MatchString:    s.config.MatchString,
+			NotMatchString: s.config.NotMatchString,
+			MatchRegexp:    s.config.MatchRegexp,
+			Progress:       s.notifyOnce,
+		},
+		Finding: vuln,
+		Query:   query,
+		Filter:  s.config.ExtractFilter,
+	})
+}
+
+// extractor returns the technique named name if it supports extraction, or
+// nil.
+func (s *Scanner) extractor(name string) Extractor {
+	for _, t := range s.techniques {
+		if t.Name() == name {
+			if ext, ok := t.(Extractor); ok {
+				return ext
+			}
+		}
+	}
+	return nil
+}
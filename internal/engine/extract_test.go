@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// extractingTechnique is a fakeTechnique that also extracts, recording the
+// requests it gets.
+type extractingTechnique struct {
+	fakeTechnique
+	value string
+	reqs  []*ExtractionRequest
+}
+
+func (e *extractingTechnique) Extract(_ context.Context, req *ExtractionRequest) (*ExtractionResult, error) {
+	e.reqs = append(e.reqs, req)
+	return &ExtractionResult{Value: e.value, Requests: 1}, nil
+}
+
+func newExtractScanner(t *testing.T, techs ...Technique) (*Scanner, *ScanTarget) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("<html>baseline</html>"))
+	}))
+	t.Cleanup(srv.Close)
+	client, err := transport.NewClient(transport.ClientOptions{})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return NewScanner(client, DefaultScanConfig(), WithTechniques(techs...)), &ScanTarget{URL: srv.URL + "/?id=1", Method: "GET"}
+}
+
+func TestScanner_Extract_RoutesToFindingTechnique(t *testing.T) {
+	errTech := &extractingTechnique{fakeTechnique: fakeTechnique{name: "error-based"}, value: "from error-based"}
+	unionTech := &extractingTechnique{fakeTechnique: fakeTechnique{name: "union-based"}, value: "from union-based"}
+	s, target := newExtractScanner(t, errTech, unionTech)
+
+	vuln := Vulnerability{
+		Parameter:  Parameter{Name: "id", Value: "1", Location: LocationQuery},
+		Technique:  "union-based",
+		DBMS:       "MySQL",
+		Injectable: true,
+	}
+	res, err := s.Extract(context.Background(), target, vuln, "@@version")
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if res.Value != "from union-based" {
+		t.Errorf("Value = %q, want the union-based technique's", res.Value)
+	}
+	if len(errTech.reqs) != 0 || len(unionTech.reqs) != 1 {
+		t.Fatalf("requests: error-based %d, union-based %d; want 0 and 1", len(errTech.reqs), len(unionTech.reqs))
+	}
+	req := unionTech.reqs[0]
+	if req.Query != "@@version" || req.DBMS != "MySQL" || req.Parameter.Name != "id" || req.Finding.Technique != "union-based" {
+		t.Errorf("request = %+v, want the finding's parameter, DBMS and query", req)
+	}
+	if req.Baseline == nil || string(req.Baseline.Body) != "<html>baseline</html>" {
+		t.Errorf("Baseline = %v, want the target's page", req.Baseline)
+	}
+}
+
+func TestScanner_Extract_NotExtractable(t *testing.T) {
+	s, target := newExtractScanner(t,
+		&extractingTechnique{fakeTechnique: fakeTechnique{name: "error-based"}},
+		&fakeTechnique{name: "detect-only"},
+	)
+	param := Parameter{Name: "id", Value: "1", Location: LocationQuery}
+
+	tests := []struct {
+		name string
+		vuln Vulnerability
+	}{
+		{"not injectable", Vulnerability{Parameter: param, Technique: "error-based"}},
+		{"cross-parameter", Vulnerability{Parameter: param, Technique: "error-based", Injectable: true, PairedParameter: &param}},
+		{"no Extract", Vulnerability{Parameter: param, Technique: "detect-only", Injectable: true}},
+		{"unknown technique", Vulnerability{Parameter: param, Technique: "stacked", Injectable: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Extract(context.Background(), target, tt.vuln, "1"); !errors.Is(err, ErrNotExtractable) {
+				t.Errorf("Extract error = %v, want ErrNotExtractable", err)
+			}
+		})
+	}
+}
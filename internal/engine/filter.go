@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"regexp"
+	"strings"
+)
+
+// filterParams returns the params ParamInclude and ParamExclude let the
+// scan test, and how many they filtered out. With ParamInclude set only
+// the parameters matching one of its patterns are tested; a parameter
+// matching a ParamExclude pattern never is, even when it also matches an
+// include pattern.
+func (c *ScanConfig) filterParams(params []Parameter) ([]Parameter, int) {
+	if len(c.ParamInclude) == 0 && len(c.ParamExclude) == 0 {
+		return params, 0
+	}
+	include, exclude := compileGlobs(c.ParamInclude), compileGlobs(c.ParamExclude)
+	var kept []Parameter
+	for _, p := range params {
+		if len(include) > 0 && !matchAny(include, p.Name) || matchAny(exclude, p.Name) {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept, len(params) - len(kept)
+}
+
+// compileGlobs compiles parameter name patterns, matched against whole
+// names ignoring case: * matches any run of characters, / and . included,
+// so that *id covers /Envelope/Body/id and filter.id, and ? matches one.
+func compileGlobs(patterns []string) []*regexp.Regexp {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		expr := regexp.QuoteMeta(p)
+		expr = strings.ReplaceAll(expr, `\*`, ".*")
+		expr = strings.ReplaceAll(expr, `\?`, ".")
+		res = append(res, regexp.MustCompile("(?is)^"+expr+"$"))
+	}
+	return res
+}
+
+func matchAny(res []*regexp.Regexp, name string) bool {
+	for _, re := range res {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
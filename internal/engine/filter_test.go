@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilterParams(t *testing.T) {
+	params := []Parameter{
+		{Name: "id"}, {Name: "user_id"}, {Name: "csrf_token"}, {Name: "website"},
+		{Name: "/Envelope/Body/GetUser/id"}, {Name: "filter.id"}, {Name: "q"},
+	}
+
+	tests := []struct {
+		name             string
+		include, exclude []string
+		want             string
+	}{
+		{"none", nil, nil, "id,user_id,csrf_token,website,/Envelope/Body/GetUser/id,filter.id,q"},
+		{"include exact", []string{"id"}, nil, "id"},
+		{"include glob across separators", []string{"*id"}, nil, "id,user_id,/Envelope/Body/GetUser/id,filter.id"},
+		{"include single character", []string{"?"}, nil, "q"},
+		{"include ignores case", []string{"CSRF_*"}, nil, "csrf_token"},
+		{"exclude", nil, []string{"csrf*", "website"}, "id,user_id,/Envelope/Body/GetUser/id,filter.id,q"},
+		{"exclude wins over include", []string{"*id"}, []string{"user_id", "filter.*"}, "id,/Envelope/Body/GetUser/id"},
+		{"same pattern both ways", []string{"id"}, []string{"id"}, ""},
+		{"regexp characters are literal", []string{"filter.id", "(q)"}, nil, "filter.id"},
+		{"no match", []string{"nope"}, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &ScanConfig{ParamInclude: tt.include, ParamExclude: tt.exclude}
+			kept, filtered := cfg.filterParams(params)
+			var names []string
+			for _, p := range kept {
+				names = append(names, p.Name)
+			}
+			if got := strings.Join(names, ","); got != tt.want {
+				t.Errorf("kept %q, want %q", got, tt.want)
+			}
+			if filtered != len(params)-len(kept) {
+				t.Errorf("filtered = %d, want %d", filtered, len(params)-len(kept))
+			}
+		})
+	}
+}
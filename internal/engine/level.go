@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// The locations ScanConfig.Level adds to the parameters parsed from the
+// URL and body: the target's cookies from CookieLevel, and the LevelHeaders
+// from HeaderLevel.
+const (
+	CookieLevel = 2
+	HeaderLevel = 3
+)
+
+// LevelHeaders are the request headers tested from HeaderLevel, the ones
+// applications commonly log or look up: with the target's value, or an
+// empty one when it does not set the header.
+var LevelHeaders = []string{"User-Agent", "Referer"}
+
+// levelParams returns the cookie and header parameters c.Level adds for
+// target, leaving out those already among its parameters. Cookies are
+// sorted by name.
+func (c *ScanConfig) levelParams(target *ScanTarget) []Parameter {
+	has := func(loc ParameterLocation, name string) bool {
+		return slices.ContainsFunc(target.Parameters, func(p Parameter) bool {
+			return p.Location == loc && p.Name == name
+		})
+	}
+	var params []Parameter
+	if c.Level >= CookieLevel {
+		names := make([]string, 0, len(target.Cookies))
+		for name := range target.Cookies {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			if !has(LocationCookie, name) {
+				params = append(params, levelParam(LocationCookie, name, target.Cookies[name]))
+			}
+		}
+	}
+	if c.Level >= HeaderLevel {
+		for _, name := range LevelHeaders {
+			key, value := header(target.Headers, name)
+			if !has(LocationHeader, key) {
+				params = append(params, levelParam(LocationHeader, key, value))
+			}
+		}
+	}
+	return params
+}
+
+// levelParam returns the parameter at loc named name, typed by its value.
+func levelParam(loc ParameterLocation, name, value string) Parameter {
+	typ := TypeString
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		typ = TypeInteger
+	} else if _, err := strconv.ParseFloat(value, 64); err == nil {
+		typ = TypeFloat
+	}
+	return Parameter{Name: name, Value: value, Location: loc, Type: typ}
+}
+
+// header returns the key and value of the named header in headers, whose
+// keys may be in any case, or name and an empty value when it is not set.
+func header(headers map[string]string, name string) (key, value string) {
+	for k, v := range headers {
+		if http.CanonicalHeaderKey(k) == name {
+			return k, v
+		}
+	}
+	return name, ""
+}
@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLevelParams(t *testing.T) {
+	target := &ScanTarget{
+		Parameters: []Parameter{
+			{Name: "id", Value: "1", Location: LocationQuery, Type: TypeInteger},
+			{Name: "lang", Value: "en", Location: LocationCookie, Type: TypeString},
+		},
+		Cookies: map[string]string{"session": "abc", "lang": "en", "cart": "3"},
+		Headers: map[string]string{"user-agent": "Mozilla/5.0"},
+	}
+	cookies := []Parameter{
+		{Name: "cart", Value: "3", Location: LocationCookie, Type: TypeInteger},
+		{Name: "session", Value: "abc", Location: LocationCookie, Type: TypeString},
+	}
+	headers := []Parameter{
+		{Name: "user-agent", Value: "Mozilla/5.0", Location: LocationHeader, Type: TypeString},
+		{Name: "Referer", Value: "", Location: LocationHeader, Type: TypeString},
+	}
+
+	tests := []struct {
+		level int
+		want  []Parameter
+	}{
+		{1, nil},
+		{CookieLevel, cookies},
+		{HeaderLevel, append(append([]Parameter(nil), cookies...), headers...)},
+		{5, append(append([]Parameter(nil), cookies...), headers...)},
+	}
+	for _, tt := range tests {
+		cfg := &ScanConfig{Level: tt.level}
+		if got := cfg.levelParams(target); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("level %d: levelParams = %+v, want %+v", tt.level, got, tt.want)
+		}
+	}
+}
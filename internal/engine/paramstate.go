@@ -0,0 +1,44 @@
+package engine
+
+import "sync"
+
+// ParamState is what the techniques testing one parameter have learned
+// about it, shared by their jobs so that a later technique can start from
+// an earlier one's findings. It is safe for concurrent use. A nil
+// *ParamState ignores records and knows nothing, so techniques can use it
+// unconditionally.
+type ParamState struct {
+	mu        sync.Mutex
+	prefix    string
+	hasPrefix bool
+}
+
+// NewParamState creates an empty parameter state.
+func NewParamState() *ParamState {
+	return &ParamState{}
+}
+
+// RecordBoundary records the prefix of a boundary the parameter was found
+// injectable through, e.g. "')". Boundaries differ between techniques in
+// their suffix, but the prefix that escapes the value's SQL context is the
+// same for all of them. The first record stands.
+func (s *ParamState) RecordBoundary(prefix string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.hasPrefix {
+		s.prefix, s.hasPrefix = prefix, true
+	}
+}
+
+// Boundary returns the recorded boundary prefix, if any.
+func (s *ParamState) Boundary() (prefix string, ok bool) {
+	if s == nil {
+		return "", false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.prefix, s.hasPrefix
+}
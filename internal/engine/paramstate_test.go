@@ -0,0 +1,38 @@
+package engine_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+)
+
+func TestParamState_FirstRecordStands(t *testing.T) {
+	s := engine.NewParamState()
+	if _, ok := s.Boundary(); ok {
+		t.Fatal("Boundary() ok = true on a new state")
+	}
+
+	var wg sync.WaitGroup
+	s.RecordBoundary("')")
+	for _, prefix := range []string{"'", ")", ""} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.RecordBoundary(prefix)
+		}()
+	}
+	wg.Wait()
+
+	if prefix, ok := s.Boundary(); !ok || prefix != "')" {
+		t.Errorf("Boundary() = %q, %v, want \"')\", true", prefix, ok)
+	}
+}
+
+func TestParamState_Nil(t *testing.T) {
+	var s *engine.ParamState
+	s.RecordBoundary("'")
+	if prefix, ok := s.Boundary(); ok || prefix != "" {
+		t.Errorf("nil Boundary() = %q, %v, want \"\", false", prefix, ok)
+	}
+}
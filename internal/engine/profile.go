@@ -0,0 +1,134 @@
+package engine
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// SecurityHeaders are the response headers the target profile reports as
+// present or missing.
+var SecurityHeaders = []string{
+	"Strict-Transport-Security",
+	"Content-Security-Policy",
+	"X-Frame-Options",
+	"X-Content-Type-Options",
+	"Referrer-Policy",
+	"Permissions-Policy",
+}
+
+// TargetProfile is what the baseline response passively reveals about the
+// target's environment. It is context for the report, not a finding, and
+// costs no requests of its own.
+type TargetProfile struct {
+	URL       string   // Final URL of the baseline request
+	Redirects []string // URLs that redirected to URL, oldest first
+	Protocol  string   // e.g. "HTTP/1.1"
+
+	// ClosesConnections is set when the target closed the connection after
+	// the baseline response instead of keeping it alive.
+	ClosesConnections bool
+
+	// Server and PoweredBy are the Server and X-Powered-By banners.
+	Server    string
+	PoweredBy string
+
+	// PresentHeaders and MissingHeaders partition SecurityHeaders by
+	// whether the baseline response carried them. Strict-Transport-Security
+	// is only checked over TLS, where browsers honour it.
+	PresentHeaders []string
+	MissingHeaders []string
+
+	// TLS is nil when the target was reached over plain HTTP.
+	TLS *TLSProfile
+}
+
+// TLSProfile describes the TLS connection and the certificate the target
+// presented.
+type TLSProfile struct {
+	Version     string // e.g. "TLS 1.3"
+	CipherSuite string
+	Subject     string
+	Issuer      string
+	NotBefore   time.Time
+	NotAfter    time.Time
+	DNSNames    []string
+
+	// Issues lists misconfigurations: an expired or not yet valid
+	// certificate, one that does not cover the host, a self-signed one, or
+	// a deprecated protocol version.
+	Issues []string
+}
+
+// profileTarget builds the TargetProfile of the baseline response resp,
+// judging certificate validity at now.
+func profileTarget(resp *transport.Response, now time.Time) *TargetProfile {
+	p := &TargetProfile{
+		URL:       resp.URL,
+		Redirects: resp.Redirects,
+		Protocol:  resp.Protocol,
+		Server:    resp.Headers.Get("Server"),
+		PoweredBy: resp.Headers.Get("X-Powered-By"),
+
+		ClosesConnections: resp.Close,
+	}
+	for _, h := range SecurityHeaders {
+		if h == "Strict-Transport-Security" && resp.TLS == nil {
+			continue
+		}
+		if resp.Headers.Get(h) != "" {
+			p.PresentHeaders = append(p.PresentHeaders, h)
+		} else {
+			p.MissingHeaders = append(p.MissingHeaders, h)
+		}
+	}
+	if resp.TLS != nil {
+		p.TLS = profileTLS(resp.TLS, resp.URL, now)
+	}
+	return p
+}
+
+// profileTLS describes state, checking the leaf certificate against the
+// host of rawURL.
+func profileTLS(state *tls.ConnectionState, rawURL string, now time.Time) *TLSProfile {
+	tp := &TLSProfile{
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+	}
+	if state.Version < tls.VersionTLS12 {
+		tp.Issues = append(tp.Issues, "deprecated protocol "+tp.Version)
+	}
+	if len(state.PeerCertificates) == 0 {
+		return tp
+	}
+
+	cert := state.PeerCertificates[0]
+	tp.Subject = cert.Subject.String()
+	tp.Issuer = cert.Issuer.String()
+	tp.NotBefore = cert.NotBefore
+	tp.NotAfter = cert.NotAfter
+	tp.DNSNames = cert.DNSNames
+
+	switch {
+	case now.After(cert.NotAfter):
+		tp.Issues = append(tp.Issues, fmt.Sprintf("certificate expired on %s", cert.NotAfter.UTC().Format(time.DateOnly)))
+	case now.Before(cert.NotBefore):
+		tp.Issues = append(tp.Issues, fmt.Sprintf("certificate not valid before %s", cert.NotBefore.UTC().Format(time.DateOnly)))
+	}
+	if u, err := url.Parse(rawURL); err == nil && u.Hostname() != "" {
+		if err := cert.VerifyHostname(u.Hostname()); err != nil {
+			tp.Issues = append(tp.Issues, fmt.Sprintf("certificate does not cover host %s", u.Hostname()))
+		}
+	}
+	// CheckSignatureFrom would insist on a CA certificate; self-signed
+	// leaves rarely are one.
+	if bytes.Equal(cert.RawIssuer, cert.RawSubject) &&
+		cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil {
+		tp.Issues = append(tp.Issues, "self-signed certificate")
+	}
+	return tp
+}
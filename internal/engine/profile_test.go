@@ -0,0 +1,175 @@
+package engine
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// newCertServer starts a TLS server presenting a self-signed certificate
+// for names, valid from notBefore to notAfter. Names that are IP addresses
+// go into the certificate's IP SANs.
+func newCertServer(t *testing.T, names []string, notBefore, notAfter time.Time, h http.Handler) *httptest.Server {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: names[0], Organization: []string{"Shop Ltd"}},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, name := range names {
+		if ip := net.ParseIP(name); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, name)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewUnstartedServer(h)
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func fetch(t *testing.T, url string) *transport.Response {
+	t.Helper()
+	client, err := transport.NewClient(transport.ClientOptions{
+		Timeout:            5 * time.Second,
+		FollowRedirects:    true,
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(context.Background(), &transport.Request{URL: url})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestProfileTarget_TLS(t *testing.T) {
+	now := time.Now()
+	srv := newCertServer(t, []string{"shop.example", "www.shop.example"},
+		now.AddDate(-1, 0, 0), now.AddDate(0, 0, -3),
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/" {
+				http.Redirect(w, r, "/home", http.StatusFound)
+				return
+			}
+			w.Header().Set("Server", "nginx/1.18.0")
+			w.Header().Set("X-Powered-By", "PHP/7.4.3")
+			w.Header().Set("Strict-Transport-Security", "max-age=31536000")
+			fmt.Fprint(w, "home")
+		}))
+
+	p := profileTarget(fetch(t, srv.URL+"/"), now)
+
+	if p.Server != "nginx/1.18.0" || p.PoweredBy != "PHP/7.4.3" {
+		t.Errorf("banners = %q, %q", p.Server, p.PoweredBy)
+	}
+	if !slices.Equal(p.Redirects, []string{srv.URL + "/"}) || p.URL != srv.URL+"/home" {
+		t.Errorf("redirects = %v -> %s", p.Redirects, p.URL)
+	}
+	if !slices.Equal(p.PresentHeaders, []string{"Strict-Transport-Security"}) || len(p.MissingHeaders) != len(SecurityHeaders)-1 {
+		t.Errorf("present %v, missing %v", p.PresentHeaders, p.MissingHeaders)
+	}
+	if p.TLS == nil {
+		t.Fatal("TLS profile missing for an HTTPS target")
+	}
+	if p.TLS.Version != "TLS 1.3" || p.TLS.CipherSuite == "" {
+		t.Errorf("connection = %s %s", p.TLS.Version, p.TLS.CipherSuite)
+	}
+	if !strings.Contains(p.TLS.Subject, "CN=shop.example") || p.TLS.Subject != p.TLS.Issuer {
+		t.Errorf("subject %q, issuer %q", p.TLS.Subject, p.TLS.Issuer)
+	}
+	if !slices.Equal(p.TLS.DNSNames, []string{"shop.example", "www.shop.example"}) {
+		t.Errorf("DNSNames = %v", p.TLS.DNSNames)
+	}
+	issues := strings.Join(p.TLS.Issues, "; ")
+	for _, want := range []string{"certificate expired", "does not cover host 127.0.0.1", "self-signed"} {
+		if !strings.Contains(issues, want) {
+			t.Errorf("issues %q, want %q", issues, want)
+		}
+	}
+}
+
+func TestProfileTarget_ValidCertificate(t *testing.T) {
+	now := time.Now()
+	srv := newCertServer(t, []string{"127.0.0.1"}, now.Add(-time.Hour), now.AddDate(1, 0, 0),
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "ok") }))
+	p := profileTarget(fetch(t, srv.URL), now)
+	if !slices.Equal(p.TLS.Issues, []string{"self-signed certificate"}) {
+		t.Errorf("issues = %v, want only self-signed", p.TLS.Issues)
+	}
+
+	p = profileTarget(fetch(t, srv.URL), now.AddDate(-1, 0, 0))
+	if !strings.Contains(strings.Join(p.TLS.Issues, "; "), "not valid before") {
+		t.Errorf("issues = %v, want a not-yet-valid certificate", p.TLS.Issues)
+	}
+}
+
+func TestProfileTLS_DeprecatedProtocol(t *testing.T) {
+	tp := profileTLS(&tls.ConnectionState{Version: tls.VersionTLS10, CipherSuite: tls.TLS_RSA_WITH_AES_128_CBC_SHA}, "https://legacy.example/", time.Now())
+	if tp.Version != "TLS 1.0" || !slices.Equal(tp.Issues, []string{"deprecated protocol TLS 1.0"}) {
+		t.Errorf("profile = %+v", tp)
+	}
+}
+
+func TestScan_ProfilePlainHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "Apache")
+		w.Header().Set("X-Frame-Options", "DENY")
+		fmt.Fprint(w, "item 1")
+	}))
+	defer srv.Close()
+
+	client, err := transport.NewClient(transport.ClientOptions{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := NewScanner(client, DefaultScanConfig()).Scan(context.Background(), &ScanTarget{
+		URL:        srv.URL + "/item?id=1",
+		Method:     "GET",
+		Parameters: []Parameter{{Name: "id", Value: "1", Location: LocationQuery, Type: TypeInteger}},
+	})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	p := result.Profile
+	if p == nil {
+		t.Fatal("Profile not set")
+	}
+	if p.TLS != nil || p.Server != "Apache" || p.Protocol != "HTTP/1.1" {
+		t.Errorf("profile = %+v", p)
+	}
+	if !slices.Equal(p.PresentHeaders, []string{"X-Frame-Options"}) || slices.Contains(p.MissingHeaders, "Strict-Transport-Security") {
+		t.Errorf("present %v, missing %v", p.PresentHeaders, p.MissingHeaders)
+	}
+}
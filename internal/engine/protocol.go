@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// keepsConnections reports whether the profiled target keeps connections
+// alive: it answers in HTTP/1.1 or later and did not close the baseline's.
+func (p *TargetProfile) keepsConnections() bool {
+	return p.Protocol != "HTTP/1.0" && !p.ClosesConnections
+}
+
+// adaptConnections returns the worker count for the target profiled in p.
+// A target that does not keep connections alive gets a new connection per
+// request and at most HTTP10Threads workers, since such servers tend to
+// close or reset connections they did not expect.
+func (s *Scanner) adaptConnections(p *TargetProfile) int {
+	threads := s.config.Threads
+	if p.keepsConnections() {
+		return threads
+	}
+	if s.keepAlive != nil {
+		s.keepAlive.DisableKeepAlives()
+	}
+	if limit := s.config.HTTP10Threads; limit > 0 && threads > limit {
+		threads = limit
+	}
+	s.logger.Info("target does not keep connections alive", "protocol", p.Protocol, "threads", threads)
+	s.progress("target does not keep connections alive (%s); using a connection per request and %d worker(s)", p.Protocol, threads)
+	return threads
+}
+
+// countUnanswered returns the total of the per-failure probe counts.
+func countUnanswered(counts map[string]int) int {
+	n := 0
+	for _, c := range counts {
+		n += c
+	}
+	return n
+}
+
+// formatUnanswered renders counts as "closed 2, reset 1", sorted by
+// failure name.
+func formatUnanswered(counts map[string]int) string {
+	parts := make([]string, 0, len(counts))
+	for _, f := range slices.Sorted(maps.Keys(counts)) {
+		parts = append(parts, fmt.Sprintf("%s %d", f, counts[f]))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,278 @@
+package engine
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/0x6d61/sqleech/internal/payload"
+	"github.com/0x6d61/sqleech/internal/rawquery"
+	"github.com/0x6d61/sqleech/internal/transport"
+	"github.com/0x6d61/sqleech/internal/xmlbody"
+)
+
+// readOnlyClient refuses requests carrying SQL that could modify the
+// target. Only values that differ from the target's original request are
+// checked, so the application's own data never trips the guard.
+type readOnlyClient struct {
+	inner  transport.Client
+	target *ScanTarget
+	query  url.Values
+	path   []string
+}
+
+// NewReadOnlyClient returns a transport.Client that checks every path
+// segment and query, body, header and cookie value that differs from target's with
+// payload.CheckReadOnly, base64-decoded as well, and fails the request instead of sending it when
+// the value could write. The scanner applies it itself when
+// ScanConfig.ReadOnly is set; callers wrap clients they hand to injected
+// detectors (e.g. the heuristic detector) with it.
+func NewReadOnlyClient(client transport.Client, target *ScanTarget) transport.Client {
+	c := &readOnlyClient{inner: client, target: target}
+	if u, err := url.Parse(target.URL); err == nil {
+		c.query = queryValues(u.RawQuery)
+		c.path = strings.Split(u.Path, "/")
+	}
+	return c
+}
+
+func (c *readOnlyClient) Do(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	if err := c.check(req); err != nil {
+		return nil, err
+	}
+	return c.inner.Do(ctx, req)
+}
+
+func (c *readOnlyClient) SetProxy(proxyURL string) error   { return c.inner.SetProxy(proxyURL) }
+func (c *readOnlyClient) SetRateLimit(rps float64)         { c.inner.SetRateLimit(rps) }
+func (c *readOnlyClient) Stats() *transport.TransportStats { return c.inner.Stats() }
+
+// check returns an error naming the parameter and offending token of the
+// first injected value that could write.
+func (c *readOnlyClient) check(req *transport.Request) error {
+	if req.URL != c.target.URL {
+		if u, err := url.Parse(req.URL); err == nil {
+			if err := checkValues("query", queryValues(u.RawQuery), c.query); err != nil {
+				return err
+			}
+			if err := checkPath(u.Path, c.path); err != nil {
+				return err
+			}
+		}
+	}
+	if req.Body != c.target.Body {
+		if err := checkBody(req, c.target.Body); err != nil {
+			return err
+		}
+	}
+	if err := checkMap("header", req.Headers, c.target.Headers); err != nil {
+		return err
+	}
+	return checkCookies(req.Cookies, c.target.Cookies)
+}
+
+// queryValues returns the values of a query string or form body by the
+// names the detectors give them, ; separators and array elements included.
+func queryValues(raw string) url.Values {
+	values := make(url.Values)
+	for _, p := range rawquery.Parse(raw) {
+		values.Add(p.Name, p.Value)
+	}
+	return values
+}
+
+// checkValues checks each value in got that is not among the original
+// values of its key.
+func checkValues(location string, got, orig url.Values) error {
+	for name, values := range got {
+		for _, v := range values {
+			if containsString(orig[name], v) {
+				continue
+			}
+			if err := checkReadOnly(v); err != nil {
+				return fmt.Errorf("refusing %s parameter %q: %w", location, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkPath checks the segments of a path that differ from the original's
+// at the same position: probes of custom injection points (/user/1*/)
+// carry their payloads in them.
+func checkPath(path string, orig []string) error {
+	for i, seg := range strings.Split(path, "/") {
+		if i < len(orig) && orig[i] == seg {
+			continue
+		}
+		if err := checkReadOnly(seg); err != nil {
+			return fmt.Errorf("refusing path segment %q: %w", seg, err)
+		}
+	}
+	return nil
+}
+
+// checkBody checks the values of a changed body: form values, JSON
+// string leaves and XML leaf elements that are not in the original body,
+// or the whole body for any other content.
+func checkBody(req *transport.Request, orig string) error {
+	contentType := req.ContentType
+	if contentType == "" {
+		contentType = req.Headers["Content-Type"]
+	}
+	if contentType == "" || strings.HasPrefix(strings.ToLower(contentType), "application/x-www-form-urlencoded") {
+		return checkValues("body", queryValues(req.Body), queryValues(orig))
+	}
+	var got any
+	if json.Unmarshal([]byte(req.Body), &got) == nil {
+		var origDoc any
+		_ = json.Unmarshal([]byte(orig), &origDoc)
+		known := make(map[string]bool)
+		for _, s := range jsonStrings(origDoc, nil) {
+			known[s] = true
+		}
+		for _, s := range jsonStrings(got, nil) {
+			if known[s] {
+				continue
+			}
+			if err := checkReadOnly(s); err != nil {
+				return fmt.Errorf("refusing JSON body value: %w", err)
+			}
+		}
+		return nil
+	}
+	if xmlbody.IsXML(contentType) || xmlbody.LooksLikeXML(req.Body) {
+		if fields, err := xmlbody.Fields(req.Body); err == nil {
+			return checkXML(fields, orig)
+		}
+		// A raw (--xml-raw) payload can leave the document unparsable.
+		// Probes splice their values into the original, so the part
+		// that differs from it holds the payload.
+		if err := checkReadOnly(changedPart(req.Body, orig)); err != nil {
+			return fmt.Errorf("refusing XML body: %w", err)
+		}
+		return nil
+	}
+	if err := checkReadOnly(req.Body); err != nil {
+		return fmt.Errorf("refusing request body: %w", err)
+	}
+	return nil
+}
+
+// checkXML checks the leaf elements whose text differs from that of the
+// element at the same path in the original body.
+func checkXML(fields []xmlbody.Field, orig string) error {
+	known := make(map[string]string)
+	if origFields, err := xmlbody.Fields(orig); err == nil {
+		for _, f := range origFields {
+			known[f.Path] = f.Value
+		}
+	}
+	for _, f := range fields {
+		if v, ok := known[f.Path]; ok && v == f.Value {
+			continue
+		}
+		if err := checkReadOnly(f.Value); err != nil {
+			return fmt.Errorf("refusing XML body element %s: %w", f.Path, err)
+		}
+	}
+	return nil
+}
+
+// changedPart returns what is left of s once the prefix and suffix it
+// shares with orig are cut off, widened to whole words so that a keyword
+// partly shared with orig is kept whole.
+func changedPart(s, orig string) string {
+	i := 0
+	for i < len(s) && i < len(orig) && s[i] == orig[i] {
+		i++
+	}
+	j := 0
+	for j < len(s)-i && j < len(orig)-i && s[len(s)-1-j] == orig[len(orig)-1-j] {
+		j++
+	}
+	for i > 0 && isWordByte(s[i-1]) {
+		i--
+	}
+	for j > 0 && isWordByte(s[len(s)-j]) {
+		j--
+	}
+	return s[i : len(s)-j]
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || '0' <= b && b <= '9' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+}
+
+// jsonStrings appends the string leaves of a decoded JSON document to out.
+func jsonStrings(v any, out []string) []string {
+	switch v := v.(type) {
+	case string:
+		out = append(out, v)
+	case []any:
+		for _, e := range v {
+			out = jsonStrings(e, out)
+		}
+	case map[string]any:
+		for _, e := range v {
+			out = jsonStrings(e, out)
+		}
+	}
+	return out
+}
+
+// checkMap checks each header or cookie value that differs from orig.
+func checkMap(location string, got, orig map[string]string) error {
+	for name, v := range got {
+		if o, ok := orig[name]; ok && o == v {
+			continue
+		}
+		if err := checkReadOnly(v); err != nil {
+			return fmt.Errorf("refusing %s %q: %w", location, name, err)
+		}
+	}
+	return nil
+}
+
+// checkCookies checks cookie values like checkMap, URL-decoded as well:
+// probes of cookie parameters carry their payloads URL-encoded.
+func checkCookies(got, orig map[string]string) error {
+	if err := checkMap("cookie", got, orig); err != nil {
+		return err
+	}
+	for name, v := range got {
+		if o, ok := orig[name]; ok && o == v {
+			continue
+		}
+		if d, err := url.QueryUnescape(v); err == nil && d != v {
+			if err := checkReadOnly(d); err != nil {
+				return fmt.Errorf("refusing cookie %q: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkReadOnly checks v with payload.CheckReadOnly, base64-decoded as well
+// when it decodes: probes of base64 parameters carry their payloads encoded.
+func checkReadOnly(v string) error {
+	if err := payload.CheckReadOnly(v); err != nil {
+		return err
+	}
+	if d, err := base64.StdEncoding.DecodeString(v); err == nil && len(d) > 0 {
+		return payload.CheckReadOnly(string(d))
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
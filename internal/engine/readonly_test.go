@@ -0,0 +1,204 @@
+package engine_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/payload"
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// recordingClient answers every request with an empty 200 and records
+// the URLs and bodies it was asked to send.
+type recordingClient struct {
+	mu   sync.Mutex
+	sent []*transport.Request
+}
+
+func (c *recordingClient) Do(_ context.Context, req *transport.Request) (*transport.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, req)
+	return &transport.Response{StatusCode: 200}, nil
+}
+
+func (c *recordingClient) SetProxy(_ string) error { return nil }
+func (c *recordingClient) SetRateLimit(_ float64)  {}
+func (c *recordingClient) Stats() *transport.TransportStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &transport.TransportStats{TotalRequests: int64(len(c.sent))}
+}
+
+func (c *recordingClient) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.sent)
+}
+
+func TestReadOnlyClient(t *testing.T) {
+	target := &engine.ScanTarget{
+		URL:     "http://example.test/item?id=1&note=" + url.QueryEscape("drop off; update later"),
+		Method:  "POST",
+		Body:    "name=x",
+		Cookies: map[string]string{"session": "abc"},
+	}
+	withQuery := func(v string) *transport.Request {
+		return &transport.Request{Method: "POST", URL: "http://example.test/item?id=" + url.QueryEscape(v) + "&note=" + url.QueryEscape("drop off; update later"), Body: target.Body}
+	}
+
+	tests := []struct {
+		name  string
+		req   *transport.Request
+		token string // "" means forwarded
+	}{
+		{"baseline with verbs in original value", &transport.Request{Method: "POST", URL: target.URL, Body: target.Body, Cookies: target.Cookies}, ""},
+		{"boolean probe", withQuery("1 AND 1=1"), ""},
+		{"stacked waitfor", withQuery("1;WAITFOR DELAY '0:0:5'-- -"), ""},
+		{"stacked waitfor in string context", withQuery("1';WAITFOR DELAY '0:0:5'-- -"), ""},
+		{"verb in literal", withQuery("1 AND 'UPDATE'='UPDATE'"), ""},
+		{"stacked drop", withQuery("1;DROP TABLE users-- -"), "DROP"},
+		{"stacked drop in string context", withQuery("1';DROP TABLE users-- -"), "DROP"},
+		{"semicolon-separated query", &transport.Request{URL: "http://example.test/item?id=1;sort=" + url.QueryEscape("x';DROP TABLE users-- -"), Body: target.Body}, "DROP"},
+		{"array element", &transport.Request{URL: "http://example.test/item?id[]=1&id[]=" + url.QueryEscape("2;DELETE FROM users-- -"), Body: target.Body}, "DELETE"},
+		{"form body", &transport.Request{URL: target.URL, Body: "name=" + url.QueryEscape("x';DELETE FROM users-- -")}, "DELETE"},
+		{"json body", &transport.Request{URL: target.URL, ContentType: "application/json", Body: `{"name":"x';INSERT INTO t VALUES (1)-- -"}`}, "INSERT"},
+		{"cookie", &transport.Request{URL: target.URL, Body: target.Body, Cookies: map[string]string{"session": "abc';TRUNCATE users-- -"}}, "TRUNCATE"},
+		{"url-encoded cookie", &transport.Request{URL: target.URL, Body: target.Body, Cookies: map[string]string{"session": url.QueryEscape("abc';TRUNCATE users-- -")}}, "TRUNCATE"},
+		{"path segment", &transport.Request{URL: "http://example.test/" + url.PathEscape("1;DROP TABLE users-- -") + "?id=1", Body: target.Body}, "DROP"},
+		{"path segment probe", &transport.Request{URL: "http://example.test/" + url.PathEscape("item AND 1=1") + "?id=1&note=" + url.QueryEscape("drop off; update later"), Body: target.Body}, ""},
+		{"base64 query", withQuery(base64.StdEncoding.EncodeToString([]byte("1;DROP TABLE users-- -"))), "DROP"},
+		{"base64 query probe", withQuery(base64.StdEncoding.EncodeToString([]byte("1 AND 1=1"))), ""},
+		{"url-encoded cookie probe", &transport.Request{URL: target.URL, Body: target.Body, Cookies: map[string]string{"session": url.QueryEscape("abc' AND '1'='1")}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &recordingClient{}
+			client := engine.NewReadOnlyClient(inner, target)
+			_, err := client.Do(context.Background(), tt.req)
+			checkRefusal(t, inner, err, tt.token)
+		})
+	}
+}
+
+// An original XML body whose elements hold DML keywords: only the leaves a
+// probe changed are checked.
+func TestReadOnlyClient_XMLBody(t *testing.T) {
+	const orig = `<?xml version="1.0"?><request><action>update</action><note>drop off; delete later</note><id>1</id></request>`
+	target := &engine.ScanTarget{URL: "http://example.test/api", Method: "POST", Body: orig, ContentType: "application/xml"}
+	withID := func(v string) *transport.Request {
+		return &transport.Request{Method: "POST", URL: target.URL, ContentType: "text/xml",
+			Body: strings.Replace(orig, "<id>1</id>", "<id>"+v+"</id>", 1)}
+	}
+
+	tests := []struct {
+		name  string
+		req   *transport.Request
+		token string // "" means forwarded
+	}{
+		{"baseline", &transport.Request{Method: "POST", URL: target.URL, ContentType: "text/xml", Body: orig}, ""},
+		{"boolean probe", withID("1 AND 1=1"), ""},
+		{"escaped probe", withID("1 AND 2&gt;1"), ""},
+		{"stacked drop", withID("1;DROP TABLE users-- -"), "DROP"},
+		{"changed keyword leaf", &transport.Request{Method: "POST", URL: target.URL, ContentType: "text/xml",
+			Body: strings.Replace(orig, "<action>update</action>", "<action>x';DELETE FROM users-- -</action>", 1)}, "DELETE"},
+		{"unparsable body", &transport.Request{Method: "POST", URL: target.URL, ContentType: "text/xml",
+			Body: strings.Replace(orig, "<id>1</id>", "<id>1;DROP TABLE users-- -", 1)}, "DROP"},
+		{"unparsable body sharing a keyword's start", &transport.Request{Method: "POST", URL: target.URL, ContentType: "text/xml",
+			Body: strings.Replace(orig, "drop off; delete later", "deLETE FROM users WHERE 1<2", 1)}, "DELETE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &recordingClient{}
+			client := engine.NewReadOnlyClient(inner, target)
+			_, err := client.Do(context.Background(), tt.req)
+			checkRefusal(t, inner, err, tt.token)
+		})
+	}
+}
+
+// checkRefusal checks that a request was forwarded when token is empty,
+// or refused with a violation on token and not sent.
+func checkRefusal(t *testing.T, inner *recordingClient, err error, token string) {
+	t.Helper()
+	if token == "" {
+		if err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+		if inner.count() != 1 {
+			t.Errorf("request was not forwarded")
+		}
+		return
+	}
+	var v *payload.WriteViolation
+	if !errors.As(err, &v) || v.Token != token {
+		t.Fatalf("Do error = %v, want violation on %q", err, token)
+	}
+	if inner.count() != 0 {
+		t.Error("refused request was sent")
+	}
+}
+
+// stackedTechnique sends one stacked probe per entry in probes.
+type stackedTechnique struct{ probes []string }
+
+func (s *stackedTechnique) Name() string  { return "stacked" }
+func (s *stackedTechnique) Priority() int { return 1 }
+func (s *stackedTechnique) Detect(ctx context.Context, req *engine.TechniqueRequest) (*engine.DetectionResult, error) {
+	for _, p := range s.probes {
+		u := req.Target.URL[:strings.Index(req.Target.URL, "?")] + "?id=" + url.QueryEscape(p)
+		if _, err := req.Client.Do(ctx, &transport.Request{Method: "GET", URL: u}); err != nil {
+			return nil, err
+		}
+	}
+	return &engine.DetectionResult{Technique: s.Name()}, nil
+}
+
+func TestScanner_ReadOnly(t *testing.T) {
+	scan := func(readOnly bool) (*engine.ScanResult, *recordingClient) {
+		client := &recordingClient{}
+		cfg := engine.DefaultScanConfig()
+		cfg.ReadOnly = readOnly
+		tech := &stackedTechnique{probes: []string{"1;WAITFOR DELAY '0:0:0'-- -", "1;DROP TABLE users-- -"}}
+		scanner := engine.NewScanner(client, cfg, engine.WithTechniques(tech))
+		result, err := scanner.Scan(context.Background(), &engine.ScanTarget{
+			URL:        "http://example.test/item?id=1",
+			Method:     "GET",
+			Parameters: []engine.Parameter{{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger}},
+		})
+		if err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		return result, client
+	}
+
+	result, client := scan(true)
+	if n := client.count(); n != 2 {
+		t.Errorf("read-only scan sent %d requests, want 2 (baseline and WAITFOR probe)", n)
+	}
+	if len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Error(), `"DROP"`) {
+		t.Errorf("Errors = %v, want one refusal naming DROP", result.Errors)
+	}
+	if result.UnsafeWrites {
+		t.Error("UnsafeWrites = true for a read-only scan")
+	}
+
+	result, client = scan(false)
+	if n := client.count(); n != 3 {
+		t.Errorf("unsafe scan sent %d requests, want 3", n)
+	}
+	if len(result.Errors) != 0 {
+		t.Errorf("Errors = %v, want none", result.Errors)
+	}
+	if !result.UnsafeWrites {
+		t.Error("UnsafeWrites = false with the guard disabled")
+	}
+}
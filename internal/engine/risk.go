@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// DefaultRiskyParamNames are the action verbs and quantities whose
+// parameters the scanner treats as state-changing: probing them with
+// junk or always-true values can delete, confirm or pay for real.
+// ScanConfig.RiskyParamNames extends the list.
+var DefaultRiskyParamNames = []string{
+	"delete", "remove", "confirm", "approve", "send", "pay", "quantity", "amount",
+}
+
+// mutatingMethods are the request methods whose every parameter is
+// treated as state-changing. POST is not among them: search and login
+// forms submit through it as often as actions do.
+var mutatingMethods = map[string]bool{
+	"PUT":    true,
+	"PATCH":  true,
+	"DELETE": true,
+}
+
+// SkippedParameter is a parameter the scan did not probe, and why.
+type SkippedParameter struct {
+	Parameter Parameter
+	Reason    string
+}
+
+// classifyParamRisk returns why probing p of a method request may change
+// server-side state, or "" when it looks safe. A name matches a risky name
+// when one of its words does, case-insensitively: "deleteId" and
+// "order_amount" match, "payment" does not match "pay".
+func classifyParamRisk(method string, p Parameter, names []string) string {
+	if m := strings.ToUpper(method); mutatingMethods[m] {
+		return fmt.Sprintf("request method %s may change server-side state", m)
+	}
+	words := nameWords(p.Name)
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		for _, w := range words {
+			if w == name {
+				return fmt.Sprintf("name matches action verb %q", name)
+			}
+		}
+	}
+	return ""
+}
+
+// nameWords splits a parameter name into lower-case words at
+// non-alphanumeric characters and lower-to-upper case changes.
+func nameWords(name string) []string {
+	var words []string
+	var cur []rune
+	flush := func() {
+		if len(cur) > 0 {
+			words = append(words, strings.ToLower(string(cur)))
+			cur = cur[:0]
+		}
+	}
+	var prev rune
+	for _, r := range name {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && unicode.IsLower(prev):
+			flush()
+			cur = append(cur, r)
+		default:
+			cur = append(cur, r)
+		}
+		prev = r
+	}
+	flush()
+	return words
+}
+
+// partitionRisky splits params into those the scan may probe and those it
+// must skip for safety: with ReadOnly, parameters classifyParamRisk flags
+// are skipped unless AllowRiskyParams is set or AllowParams names them.
+func (c *ScanConfig) partitionRisky(method string, params []Parameter) ([]Parameter, []SkippedParameter) {
+	if !c.ReadOnly || c.AllowRiskyParams {
+		return params, nil
+	}
+	names := append(append([]string(nil), DefaultRiskyParamNames...), c.RiskyParamNames...)
+	var probe []Parameter
+	var skipped []SkippedParameter
+	for _, p := range params {
+		reason := classifyParamRisk(method, p, names)
+		if reason == "" || containsFold(c.AllowParams, p.Name) {
+			probe = append(probe, p)
+			continue
+		}
+		skipped = append(skipped, SkippedParameter{Parameter: p, Reason: reason})
+	}
+	return probe, skipped
+}
+
+// containsFold reports whether list contains s, ignoring case.
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClassifyParamRisk(t *testing.T) {
+	tests := []struct {
+		method, name string
+		extra        []string
+		want         string // substring of the reason; "" for safe
+	}{
+		{"GET", "id", nil, ""},
+		{"GET", "delete", nil, `"delete"`},
+		{"GET", "Confirm", nil, `"confirm"`},
+		{"GET", "deleteId", nil, `"delete"`},
+		{"POST", "order_amount", nil, `"amount"`},
+		{"GET", "items[0][quantity]", nil, `"quantity"`},
+		{"GET", "payment", nil, ""},
+		{"GET", "sender", nil, ""},
+		{"POST", "name", nil, ""},
+		{"GET", "refund", []string{"Refund"}, `"refund"`},
+		{"PUT", "name", nil, "method PUT"},
+		{"delete", "id", nil, "method DELETE"},
+	}
+	for _, tt := range tests {
+		names := append(append([]string(nil), DefaultRiskyParamNames...), tt.extra...)
+		got := classifyParamRisk(tt.method, Parameter{Name: tt.name}, names)
+		if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+			t.Errorf("%s %s: reason = %q, want %q", tt.method, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPartitionRisky(t *testing.T) {
+	params := []Parameter{{Name: "id"}, {Name: "delete"}, {Name: "qty"}}
+
+	tests := []struct {
+		name    string
+		cfg     ScanConfig
+		skipped []string
+	}{
+		{"default", ScanConfig{ReadOnly: true}, []string{"delete"}},
+		{"extended", ScanConfig{ReadOnly: true, RiskyParamNames: []string{"qty"}}, []string{"delete", "qty"}},
+		{"allowed by name", ScanConfig{ReadOnly: true, AllowParams: []string{"DELETE"}}, nil},
+		{"allowed all", ScanConfig{ReadOnly: true, AllowRiskyParams: true}, nil},
+		{"writes allowed", ScanConfig{}, nil},
+	}
+	for _, tt := range tests {
+		probe, skipped := tt.cfg.partitionRisky("GET", params)
+		var names []string
+		for _, sp := range skipped {
+			names = append(names, sp.Parameter.Name)
+			if sp.Reason == "" {
+				t.Errorf("%s: %s skipped without a reason", tt.name, sp.Parameter.Name)
+			}
+		}
+		if strings.Join(names, ",") != strings.Join(tt.skipped, ",") {
+			t.Errorf("%s: skipped %v, want %v", tt.name, names, tt.skipped)
+		}
+		if len(probe)+len(skipped) != len(params) {
+			t.Errorf("%s: %d probed + %d skipped of %d", tt.name, len(probe), len(skipped), len(params))
+		}
+	}
+}
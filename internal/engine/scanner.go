@@ -4,9 +4,16 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/0x6d61/sqleech/internal/jsonpath"
+	"github.com/0x6d61/sqleech/internal/payloadlib"
 	"github.com/0x6d61/sqleech/internal/transport"
 )
 
@@ -17,13 +24,134 @@ type ScanConfig struct {
 	Techniques []string // Filter: "E" (error), "B" (boolean). Empty = all.
 	DBMSHint   string   // DBMS hint to skip fingerprinting
 	ForceTest  bool     // Test all params even if heuristics say safe
+	CrossParam bool     // Try split payloads across pairs of live-but-unconfirmed params; needs Risk 3
+	ReadOnly   bool     // Refuse probes carrying SQL that could write (default true)
+	Risk       int      // Risk level 1-3; higher levels enable slower, noisier tests (default 1)
+	Level      int      // Test level 1-5; higher levels try more boundaries and locations (default 1)
+	TimeTotal  bool     // Time-based compares total response times instead of times to first byte
+
+	// DrainTimeout is how long in-flight detection jobs may keep running
+	// after the scan context is cancelled before they are force-cancelled
+	// and recorded as abandoned (default 5s; zero cancels them at once).
+	DrainTimeout time.Duration
+
+	// JobTimeout bounds each detection job, one technique testing one
+	// parameter: past it the job's context is cancelled and the job is
+	// reported among the scan's errors, so that a hung technique cannot
+	// stall the scan (0: DefaultJobTimeout, DefaultTimeBasedJobTimeout for
+	// time-based; negative: no bound). Scans slowed by a request delay may
+	// need it raised.
+	JobTimeout time.Duration
+
+	// MaxRequests, when positive, is the number of requests the Scanner
+	// may send over its lifetime, its scans and extractions together.
+	// Past it requests are refused with ErrRequestBudget and the running
+	// scan or extraction stops at once, in-flight jobs cancelled: the
+	// scan reports what it found so far, marked Truncated, and an
+	// extraction the value read so far, marked Partial. The heuristic
+	// detector sends through a client of its own and is not counted.
+	MaxRequests int64
+
+	// With ReadOnly, parameters that look state-changing -- their name
+	// contains a word of DefaultRiskyParamNames or RiskyParamNames, or the
+	// request method is PUT, PATCH or DELETE -- are not probed at all
+	// unless AllowParams names them or AllowRiskyParams is set. They are
+	// reported in ScanResult.Skipped instead.
+	RiskyParamNames  []string
+	AllowParams      []string
+	AllowRiskyParams bool
+
+	// ParamInclude and ParamExclude are glob patterns of parameter names
+	// (* any run of characters, ? any one, case ignored). With
+	// ParamInclude set only matching parameters are tested; those
+	// matching ParamExclude never are. Untested parameters keep their
+	// original values in every probe.
+	ParamInclude []string
+	ParamExclude []string
+
+	// ValidStatusCodes lists the status codes of responses that show the
+	// application's page (see StatusValid); empty means 2xx and 3xx. A
+	// target whose baseline answers with any other code is not scanned.
+	ValidStatusCodes []int
+
+	// Before time-based jobs run, the latency of the baseline request is
+	// sampled: a sleep of TimeSec seconds (0: DefaultTimeSec, leaving the
+	// technique's own default in place) must be TimeJitterFactor standard
+	// deviations of it long. Otherwise the sleep is raised, up to
+	// TimeSecMax, or time-based is skipped with a warning. ForceTimeBased
+	// skips the check. See EvaluateLatency.
+	TimeSec          int
+	TimeSecMax       int
+	TimeJitterFactor float64
+	ForceTimeBased   bool
+
+	// HTTP10Threads caps the workers for a target whose baseline shows it
+	// speaks HTTP/1.0 or closes the connection after every response; such
+	// targets often reset connections under concurrency (default 1; zero
+	// leaves Threads as is). See WithKeepAliveSwitch.
+	HTTP10Threads int
+
+	// TargetConcurrency is the number of targets ScanAll scans at once,
+	// each with its own Threads workers (0: one at a time).
+	TargetConcurrency int
+
+	// TechniqueTimeouts overrides the client timeout for the probes of a
+	// technique, keyed by its code as in Techniques ("T") or its name
+	// ("time-based"). Time-based otherwise times its sleep probes out just
+	// past the sleep; see TechniqueRequest.Timeout.
+	TechniqueTimeouts map[string]time.Duration
+
+	// Triage re-checks borderline findings after detection with controls:
+	// repeats of the decisive probes, a broken payload variant and the
+	// payload in an inert parameter (default true). TriageRounds is the
+	// number of repeats (0: DefaultTriageRounds). See Controller.
+	Triage       bool
+	TriageRounds int
+
+	// PerTechnique reports every technique's verdict on a parameter as a
+	// finding of its own, as scans did before findings were consolidated.
+	// By default the techniques that found a parameter injectable make one
+	// finding, that of the most confident, listing them all in
+	// ConfirmedBy; verdicts of not injectable are left out below Verbose 2.
+	PerTechnique bool
+
+	// StopAtFirst stops testing a parameter once a technique finds it
+	// injectable, unless the finding is held for triage, which may yet
+	// contradict it: its jobs not yet started are skipped and those
+	// running are cancelled, both listed in SkippedJobs. For triage scans,
+	// which only need to know that a parameter is injectable.
+	StopAtFirst bool
+
+	// MatchString, NotMatchString and MatchRegexp replace the boolean-blind
+	// page comparison with a user-supplied oracle: a page is TRUE when it
+	// contains MatchString, does not contain NotMatchString, or matches
+	// MatchRegexp. Only one should be set; otherwise MatchString wins, then
+	// NotMatchString.
+	MatchString    string
+	NotMatchString string
+	MatchRegexp    *regexp.Regexp
+
+	// ExtractFilter, when set, is passed to the technique of every
+	// extraction, which applies it to values that parse as JSON
+	// (--extract-filter). ExtractionResult.Display returns what it
+	// selected.
+	ExtractFilter *jsonpath.Path
 }
 
 // DefaultScanConfig returns sensible defaults.
 func DefaultScanConfig() *ScanConfig {
 	return &ScanConfig{
-		Threads: 10,
-		Verbose: 0,
+		Threads:      10,
+		Verbose:      0,
+		ReadOnly:     true,
+		Risk:         1,
+		Level:        1,
+		DrainTimeout: 5 * time.Second,
+
+		TimeSecMax:       15,
+		TimeJitterFactor: 6,
+		HTTP10Threads:    1,
+		Triage:           true,
 	}
 }
 
@@ -43,6 +171,10 @@ type HeuristicResult struct {
 	ErrorSignatures map[string][]string
 	PageRatio       float64
 	IsInjectable    bool
+
+	// Context is the SQL context the heuristic probes suggest the value is
+	// used in; empty when nothing stood out.
+	Context payloadlib.Context
 }
 
 // HeuristicDetectorFunc runs heuristic detection on all parameters of a target.
@@ -54,6 +186,7 @@ type DBMSInfo struct {
 	Version    string
 	Banner     string
 	Confidence float64
+	Tentative  bool // Fast-path match the fingerprinter may refine, e.g. MySQL that may be MariaDB
 }
 
 // DBMSIdentifierFunc identifies DBMS from error signatures (fast-path).
@@ -62,6 +195,11 @@ type DBMSIdentifierFunc func(errorSignatures map[string][]string) *DBMSInfo
 // FingerprintFunc runs full DBMS fingerprinting probes.
 type FingerprintFunc func(ctx context.Context, target *ScanTarget, param *Parameter, baseline *transport.Response, client transport.Client) (*DBMSInfo, error)
 
+// CrossParamDetectorFunc tests pairs of parameters with payloads split across
+// both. params holds only live-but-unconfirmed candidates; every returned
+// Vulnerability carries PairedParameter and PairedPayload.
+type CrossParamDetectorFunc func(ctx context.Context, target *ScanTarget, params []Parameter, dbms string, client transport.Client) ([]Vulnerability, error)
+
 // Technique defines a SQL injection detection method.
 type Technique interface {
 	Name() string
@@ -76,6 +214,32 @@ type TechniqueRequest struct {
 	Baseline  *transport.Response
 	DBMS      string
 	Client    transport.Client
+	Coverage  *payloadlib.Coverage // Records the corpus entries the technique tries
+	Context   payloadlib.Context   // Heuristic context hint; empty means unknown
+	State     *ParamState          // Shared by the parameter's jobs; may be nil
+
+	// SleepSeconds is the sleep time-based probes inject, set when the
+	// scan configured or raised it; zero keeps the technique's default.
+	SleepSeconds int
+
+	// Timeout is the technique's ScanConfig.TechniqueTimeouts entry, zero
+	// when it has none.
+	Timeout time.Duration
+
+	// Level and Risk are the ScanConfig fields of the same names; zero
+	// means the default.
+	Level int
+	Risk  int
+
+	// MatchString, NotMatchString and MatchRegexp are the ScanConfig
+	// fields of the same names.
+	MatchString    string
+	NotMatchString string
+	MatchRegexp    *regexp.Regexp
+
+	// Progress reports a status message through the scanner's progress
+	// callback, each distinct message once; may be nil.
+	Progress func(msg string)
 }
 
 // DetectionResult indicates whether injection was detected.
@@ -101,9 +265,18 @@ type Scanner struct {
 	heuristicFunc HeuristicDetectorFunc
 	identifyFunc  DBMSIdentifierFunc
 	fpFunc        FingerprintFunc
-
-	// Progress callback
-	onProgress func(msg string)
+	crossFunc     CrossParamDetectorFunc
+	budget        *requestBudget // Nil without ScanConfig.MaxRequests
+	outages       *transport.OutageMonitor
+	keepAlive     KeepAliveSwitch
+	cookies       CookieSource
+
+	// Event subscribers; see Subscribe. eventMu guards the list and
+	// deliverMu serializes delivery, so that subscribers can subscribe.
+	eventMu     sync.Mutex
+	deliverMu   sync.Mutex
+	subscribers []func(Event)
+	notified    sync.Map // Messages already sent by notifyOnce
 }
 
 // ScannerOption configures a Scanner.
@@ -137,6 +310,29 @@ func WithDBMSIdentifier(fn DBMSIdentifierFunc) ScannerOption {
 	}
 }
 
+// WithLogger sets the logger the scanner reports the errors it recovers
+// from to, such as a failed heuristic, fingerprint or technique run.
+// Without it nothing is logged. See LogLevel.
+func WithLogger(l *slog.Logger) ScannerOption {
+	return func(s *Scanner) {
+		s.logger = l
+	}
+}
+
+// LogLevel returns the minimum level to log at for a ScanConfig.Verbose
+// of verbose: errors at 0, warnings from 1, info from 2, debug from 3.
+func LogLevel(verbose int) slog.Level {
+	switch {
+	case verbose >= 3:
+		return slog.LevelDebug
+	case verbose >= 2:
+		return slog.LevelInfo
+	case verbose >= 1:
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
+
 // WithFingerprinter sets the full DBMS fingerprinting function.
 func WithFingerprinter(fn FingerprintFunc) ScannerOption {
 	return func(s *Scanner) {
@@ -144,6 +340,55 @@ func WithFingerprinter(fn FingerprintFunc) ScannerOption {
 	}
 }
 
+// WithCrossParamDetector sets the split-payload detector used when
+// ScanConfig.CrossParam is enabled.
+func WithCrossParamDetector(fn CrossParamDetectorFunc) ScannerOption {
+	return func(s *Scanner) {
+		s.crossFunc = fn
+	}
+}
+
+// WithOutageMonitor enables outage awareness: technique decisions whose
+// probes overlapped a target-unavailability window are discarded and re-run
+// once the target recovers, and the windows are recorded in the result.
+// m must wrap the client passed to NewScanner.
+func WithOutageMonitor(m *transport.OutageMonitor) ScannerOption {
+	return func(s *Scanner) {
+		s.outages = m
+	}
+}
+
+// KeepAliveSwitch turns off connection reuse in the transport.
+// *transport.DefaultClient implements it.
+type KeepAliveSwitch interface {
+	DisableKeepAlives()
+}
+
+// WithKeepAliveSwitch lets the scanner turn off keep-alive connections for
+// a target that speaks HTTP/1.0 or closes every connection. ks must be the
+// transport underneath the client passed to NewScanner.
+func WithKeepAliveSwitch(ks KeepAliveSwitch) ScannerOption {
+	return func(s *Scanner) {
+		s.keepAlive = ks
+	}
+}
+
+// CookieSource reports the cookies a target has set on the client.
+// *transport.DefaultClient implements it.
+type CookieSource interface {
+	Cookies(rawURL string) []*http.Cookie
+}
+
+// WithCookieSource records, in the evidence of every finding, the cookies
+// the target had set when the baseline was taken, since probes are judged
+// against a page served to that session. cs must be the transport
+// underneath the client passed to NewScanner.
+func WithCookieSource(cs CookieSource) ScannerOption {
+	return func(s *Scanner) {
+		s.cookies = cs
+	}
+}
+
 // techniqueFilterMap maps single-character technique codes to technique names.
 var techniqueFilterMap = map[string]string{
 	"E": "error-based",
@@ -158,26 +403,10 @@ func NewScanner(client transport.Client, config *ScanConfig, opts ...ScannerOpti
 		config = DefaultScanConfig()
 	}
 
-	// Create logger with configured verbosity level.
-	logLevel := slog.LevelError
-	switch {
-	case config.Verbose >= 3:
-		logLevel = slog.LevelDebug
-	case config.Verbose >= 2:
-		logLevel = slog.LevelInfo
-	case config.Verbose >= 1:
-		logLevel = slog.LevelWarn
-	}
-
-	logger := slog.New(slog.NewTextHandler(
-		discardWriter{},
-		&slog.HandlerOptions{Level: logLevel},
-	))
-
 	s := &Scanner{
 		client: client,
 		config: config,
-		logger: logger,
+		logger: slog.New(slog.DiscardHandler),
 	}
 
 	// Apply options.
@@ -185,6 +414,11 @@ func NewScanner(client transport.Client, config *ScanConfig, opts ...ScannerOpti
 		opt(s)
 	}
 
+	if b := newRequestBudget(s.client, config.MaxRequests); b != nil {
+		s.budget = b
+		s.client = b
+	}
+
 	// Filter techniques if a filter is specified.
 	if len(config.Techniques) > 0 && len(s.techniques) > 0 {
 		allowedNames := make(map[string]bool)
@@ -219,91 +453,242 @@ func (s *Scanner) TechniqueNames() []string {
 	return names
 }
 
-// SetProgressCallback sets a function called with status messages.
+// BudgetSpent reports whether the scanner has refused a request for
+// exceeding ScanConfig.MaxRequests. Scans and extractions it runs from
+// then on fail at their first request.
+func (s *Scanner) BudgetSpent() bool {
+	return s.budget.spent()
+}
+
+// SetProgressCallback subscribes fn to the status messages of the scan
+// events, those with a Message. It predates Subscribe.
 func (s *Scanner) SetProgressCallback(fn func(string)) {
-	s.onProgress = fn
+	s.Subscribe(func(ev Event) {
+		if ev.Message != "" {
+			fn(ev.Message)
+		}
+	})
 }
 
-// progress sends a status message via the progress callback if set.
-func (s *Scanner) progress(format string, args ...any) {
-	if s.onProgress != nil {
-		s.onProgress(fmt.Sprintf(format, args...))
+// notifyOnce emits msg as a status message unless it was sent
+// before. Techniques report through it, and every job of a scan would
+// otherwise repeat the same message.
+func (s *Scanner) notifyOnce(msg string) {
+	if _, seen := s.notified.LoadOrStore(msg, true); !seen {
+		s.progress("%s", msg)
 	}
 }
 
-// Scan runs the full pipeline against a target.
+// Scan runs the full pipeline against a target and returns the collected
+// result. It is ScanInto with a MemoryCollector.
+func (s *Scanner) Scan(ctx context.Context, target *ScanTarget) (*ScanResult, error) {
+	c := NewMemoryCollector(target)
+	err := s.ScanInto(ctx, target, c)
+	return c.Result(), err
+}
+
+// ScanInto runs the full pipeline against a target, emitting findings,
+// errors and the detected DBMS into c as they are produced. Finalize is
+// always called before ScanInto returns, including on early exit.
 //
 // Pipeline:
-//  1. Parse parameters (if target.Parameters is empty, parse from URL/body)
-//  2. Send baseline request
+//  1. Parse parameters (if target.Parameters is empty, parse from URL/body),
+//     add the cookies and headers ScanConfig.Level tests (see CookieLevel),
+//     filter them by name (see ScanConfig.ParamInclude) and set aside
+//     those that look state-changing (see ScanConfig.ReadOnly)
+//  2. Send baseline request and profile the target from its response;
+//     adapt to targets that do not keep connections alive
 //  3. Run heuristic detection on all parameters
 //  4. Filter to potentially injectable parameters
-//  5. Run DBMS fingerprinting (use heuristic error signatures as fast-path)
-//  6. For each injectable parameter, run techniques via worker pool
-//  7. Aggregate results
-func (s *Scanner) Scan(ctx context.Context, target *ScanTarget) (*ScanResult, error) {
-	result := &ScanResult{
-		Target:    *target,
-		StartTime: time.Now(),
+//  5. Run DBMS fingerprinting (use heuristic error signatures as fast-path),
+//     per parameter, reconciling parameters that disagree (see ReconcileDBMS)
+//  6. For each injectable parameter, run techniques via worker pool,
+//     time-based only if the target's latency jitter allows it
+//  7. Emit results, one finding per parameter (see ScanConfig.PerTechnique);
+//     decisions that overlapped a target outage are re-run first (see
+//     WithOutageMonitor)
+//  8. Optionally test pairs of live-but-unconfirmed parameters with split payloads
+//
+// With ScanConfig.ReadOnly, every probe past the baseline goes through a
+// NewReadOnlyClient guard; refused probes surface as errors in the result.
+// With ScanConfig.MaxRequests, the scan stops where the budget runs out and
+// its result is marked Truncated; ScanInto returns no error for that.
+func (s *Scanner) ScanInto(ctx context.Context, target *ScanTarget, c ResultCollector) error {
+	return s.scanInto(ctx, target, c, nil)
+}
+
+// scanInto is ScanInto taking known, when not nil, as what the
+// fingerprinting probes would identify instead of sending them.
+func (s *Scanner) scanInto(ctx context.Context, target *ScanTarget, c ResultCollector, known *DBMSInfo) (err error) {
+	stats := ScanStats{StartTime: time.Now(), UnsafeWrites: !s.config.ReadOnly}
+	coverage := payloadlib.NewCoverage()
+	var startRequests int64
+	if st := s.client.Stats(); st != nil {
+		startRequests = st.TotalRequests
 	}
 
+	events := &eventCollector{ResultCollector: c, s: s}
+	c = events
+	var tested int // Parameters probed, for EventScanEnd
+	s.emit(Event{Kind: EventScanStart, URL: target.URL})
+
+	defer func() {
+		stats.EndTime = time.Now()
+		if st := s.client.Stats(); st != nil {
+			stats.RequestCount = st.TotalRequests
+			stats.ScanRequests = st.TotalRequests - startRequests
+			stats.Transport = st
+		}
+		stats.Payloads = coverage.Snapshot()
+		if s.outages != nil {
+			stats.Outages = s.outages.Windows()
+		}
+		if s.budget.spent() {
+			stats.Truncated, stats.RequestBudget = true, s.config.MaxRequests
+		}
+		c.Finalize(stats)
+		s.emit(Event{
+			Kind: EventScanEnd,
+			URL:  target.URL,
+			Counts: EventCounts{
+				Parameters: tested,
+				Findings:   events.findings,
+				Injectable: events.injectable,
+				Requests:   stats.ScanRequests,
+			},
+			Err: err,
+		})
+	}()
+
+	// A scan the request budget cut short still reports what it found,
+	// with the failure that stopped it among its errors.
+	ctx, release := s.budget.bind(ctx)
+	defer release()
 	defer func() {
-		result.EndTime = time.Now()
-		if stats := s.client.Stats(); stats != nil {
-			result.RequestCount = stats.TotalRequests
+		if err != nil && s.budget.spent() {
+			c.AddError(err)
+			err = nil
 		}
 	}()
 
 	// Step 0: Check context before starting.
 	if err := ctx.Err(); err != nil {
-		return result, fmt.Errorf("scan cancelled before start: %w", err)
+		return fmt.Errorf("scan cancelled before start: %w", err)
 	}
 
-	// Step 1: Parse parameters if not provided.
+	// Step 1: Parse parameters if not provided, and add the cookies and
+	// headers the level tests.
 	if len(target.Parameters) == 0 && s.parseParams != nil {
 		target.Parameters = s.parseParams(target.URL, target.Body, target.ContentType)
 	}
+	// They go on a copy, so that scanning the target again does not test
+	// them twice.
+	if extra := s.config.levelParams(target); len(extra) > 0 {
+		t := *target
+		t.Parameters = append(slices.Clone(target.Parameters), extra...)
+		target = &t
+	}
 
 	if len(target.Parameters) == 0 {
 		s.progress("no parameters found in target")
-		return result, nil
+		return nil
 	}
 
 	s.progress("found %d parameter(s) to test", len(target.Parameters))
 
+	// Filtered and skipped parameters are left out of the target every
+	// detector sees, so no probe -- heuristic or technique -- ever carries
+	// a payload in them.
+	params, filtered := s.config.filterParams(target.Parameters)
+	if filtered > 0 {
+		s.progress("filtered out %d of %d parameter(s) by name", filtered, len(target.Parameters))
+	}
+	probeParams, skipped := s.config.partitionRisky(target.Method, params)
+	stats.Skipped = skipped
+	for _, sp := range skipped {
+		s.progress("skipping parameter %q for safety: %s", sp.Parameter.Name, sp.Reason)
+	}
+	probeTarget := target
+	if len(probeParams) != len(target.Parameters) {
+		t := *target
+		t.Parameters = probeParams
+		probeTarget = &t
+	}
+	if len(probeParams) == 0 {
+		s.progress("no parameters left to test")
+		return nil
+	}
+	tested = len(probeParams)
+
 	// Step 2: Send baseline request.
 	baselineReq := buildBaselineRequest(target)
 	baseline, err := s.client.Do(ctx, baselineReq)
 	if err != nil {
-		return result, fmt.Errorf("baseline request failed: %w", err)
+		return fmt.Errorf("baseline request failed: %w", err)
+	}
+	s.emit(Event{
+		Kind:    EventBaseline,
+		Message: fmt.Sprintf("baseline request completed (status %d, %d bytes)", baseline.StatusCode, len(baseline.Body)),
+	})
+	baselineCookies := s.cookieSnapshot(target.URL)
+	stats.Profile = profileTarget(baseline, time.Now())
+	threads := s.adaptConnections(stats.Profile)
+
+	// Probes are judged against the baseline, so an error page there would
+	// make every comparison meaningless.
+	if !StatusValid(s.config.ValidStatusCodes, baseline.StatusCode) {
+		err := fmt.Errorf("baseline answered status %d, not a valid page status; allow it with ValidStatusCodes if it is the application's normal page", baseline.StatusCode)
+		s.logger.Warn("skipping target", "err", err)
+		s.progress("skipping target: %v", err)
+		c.AddError(err)
+		return nil
+	}
+
+	client := s.client
+	if s.config.ReadOnly {
+		client = NewReadOnlyClient(client, target)
 	}
-	s.progress("baseline request completed (status %d, %d bytes)", baseline.StatusCode, len(baseline.Body))
 
 	// Step 3: Run heuristic detection on all parameters.
 	type paramInfo struct {
 		param           Parameter
 		baseline        *transport.Response
 		errorSignatures map[string][]string
+		context         payloadlib.Context
+		dbms            string // Set in step 5
 	}
 
 	var injectableParams []paramInfo
+	var liveParams []Parameter
+	var inertParams []Parameter // Triage placebos
 
 	if s.heuristicFunc != nil {
-		heuristicResults, hErr := s.heuristicFunc(ctx, target)
+		heuristicResults, hErr := s.heuristicFunc(ctx, probeTarget)
 		if hErr != nil {
-			s.logger.Warn("heuristic detection failed", "error", hErr)
-			result.Errors = append(result.Errors, fmt.Errorf("heuristic detection: %w", hErr))
+			s.logger.Warn("heuristic detection failed", "url", target.URL, "err", hErr)
+			c.AddError(fmt.Errorf("heuristic detection: %w", hErr))
 		}
 
 		// Step 4: Filter to injectable parameters.
 		if heuristicResults != nil {
 			for _, hr := range heuristicResults {
-				if hr.IsInjectable || s.config.ForceTest {
-					s.progress("parameter %q is potentially injectable (heuristic)", hr.Parameter.Name)
+				if hr.CausesError || hr.DynamicContent {
+					liveParams = append(liveParams, hr.Parameter)
+				} else if !hr.IsInjectable {
+					inertParams = append(inertParams, hr.Parameter)
+				}
+				test := hr.IsInjectable || s.config.ForceTest
+				ev := Event{Kind: EventHeuristic, Parameter: &hr.Parameter, Injectable: hr.IsInjectable}
+				if test {
+					ev.Message = fmt.Sprintf("parameter %q is potentially injectable (heuristic)", hr.Parameter.Name)
+				}
+				s.emit(ev)
+				if test {
 					pi := paramInfo{
 						param:           hr.Parameter,
 						baseline:        hr.Baseline,
 						errorSignatures: hr.ErrorSignatures,
+						context:         hr.Context,
 					}
 					injectableParams = append(injectableParams, pi)
 				}
@@ -312,7 +697,7 @@ func (s *Scanner) Scan(ctx context.Context, target *ScanTarget) (*ScanResult, er
 
 		// If heuristics failed but ForceTest is on, use all parameters.
 		if heuristicResults == nil && s.config.ForceTest {
-			for _, p := range target.Parameters {
+			for _, p := range probeParams {
 				injectableParams = append(injectableParams, paramInfo{
 					param:    p,
 					baseline: baseline,
@@ -321,7 +706,7 @@ func (s *Scanner) Scan(ctx context.Context, target *ScanTarget) (*ScanResult, er
 		}
 	} else {
 		// No heuristic function configured: test all parameters directly.
-		for _, p := range target.Parameters {
+		for _, p := range probeParams {
 			injectableParams = append(injectableParams, paramInfo{
 				param:    p,
 				baseline: baseline,
@@ -329,86 +714,376 @@ func (s *Scanner) Scan(ctx context.Context, target *ScanTarget) (*ScanResult, er
 		}
 	}
 
-	if len(injectableParams) == 0 {
+	crossParam := s.config.CrossParam && s.config.Risk >= 3 && s.crossFunc != nil && len(liveParams) >= 2
+	if len(injectableParams) == 0 && !crossParam {
 		s.progress("no injectable parameters found")
-		return result, nil
+		return nil
 	}
 
-	// Step 5: DBMS fingerprinting.
+	// Step 5: DBMS fingerprinting. Each parameter is identified from its
+	// own error signatures; parameters left unidentified take the target's
+	// DBMS, that of the strongest identification.
 	dbmsName := s.config.DBMSHint
+	dbmsVersion := ""
+	tentative := false
 	if dbmsName == "" && s.identifyFunc != nil {
-		// Fast-path: try to identify from error signatures already collected.
-		for _, pi := range injectableParams {
-			if len(pi.errorSignatures) > 0 {
-				info := s.identifyFunc(pi.errorSignatures)
-				if info != nil {
-					dbmsName = info.Name
-					s.progress("DBMS identified from error signatures: %s (confidence %.0f%%)", info.Name, info.Confidence*100)
-					break
+		// Fast-path: identify from error signatures already collected.
+		endpoint := EndpointKey(target.Method, target.URL)
+		assigns := make([]DBMSAssignment, len(injectableParams))
+		for i, pi := range injectableParams {
+			assigns[i] = DBMSAssignment{Parameter: pi.param, Endpoint: endpoint}
+			if len(pi.errorSignatures) == 0 {
+				continue
+			}
+			if info := s.identifyFunc(pi.errorSignatures); info != nil {
+				a := &assigns[i]
+				a.DBMS, a.Version, a.Confidence, a.Tentative = info.Name, info.Version, info.Confidence, info.Tentative
+				a.Signatures = len(pi.errorSignatures[info.Name])
+			}
+		}
+
+		// Disagreements are re-checked with the fingerprinter's probes.
+		var confirm DBMSConfirmFunc
+		if s.fpFunc != nil {
+			confirm = func(a DBMSAssignment) *DBMSInfo {
+				i := slices.IndexFunc(injectableParams, func(pi paramInfo) bool { return pi.param == a.Parameter })
+				info, err := s.fpFunc(ctx, target, &injectableParams[i].param, injectableParams[i].baseline, client)
+				if err != nil {
+					s.logger.Warn("DBMS re-check failed", "param", a.Parameter.Name, "err", err)
+					return nil
 				}
+				return info
 			}
 		}
+		assigns, warnings := ReconcileDBMS(assigns, confirm)
+		for _, w := range warnings {
+			s.progress("%s", w)
+			c.AddWarning(w)
+		}
+
+		best := -1
+		for i, a := range assigns {
+			injectableParams[i].dbms = a.DBMS
+			if a.DBMS != "" && (best < 0 || a.stronger(assigns[best])) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			a := assigns[best]
+			dbmsName = a.DBMS
+			tentative = a.Tentative
+			s.progress("DBMS identified from error signatures: %s (confidence %.0f%%)", a.DBMS, a.Confidence*100)
+		}
 	}
 
-	if dbmsName == "" && s.fpFunc != nil && len(injectableParams) > 0 {
-		// Slow-path: run full fingerprinting probes.
-		pi := injectableParams[0]
-		info, fpErr := s.fpFunc(ctx, target, &pi.param, pi.baseline, s.client)
+	if (dbmsName == "" || tentative) && (s.fpFunc != nil || known != nil) && len(injectableParams) > 0 {
+		// Slow-path: run full fingerprinting probes, unless what they
+		// identify is known. A tentative fast-path name stands if they
+		// identify nothing.
+		info := known
+		var fpErr error
+		if info == nil {
+			pi := injectableParams[0]
+			info, fpErr = s.fpFunc(ctx, target, &pi.param, pi.baseline, client)
+		}
 		if fpErr != nil {
-			s.logger.Warn("fingerprinting failed", "error", fpErr)
-			result.Errors = append(result.Errors, fmt.Errorf("fingerprinting: %w", fpErr))
+			s.logger.Warn("fingerprinting failed", "param", injectableParams[0].param.Name, "err", fpErr)
+			c.AddError(fmt.Errorf("fingerprinting: %w", fpErr))
 		} else if info != nil {
+			// The probes refine the tentative identification wherever it
+			// was made.
+			for i := range injectableParams {
+				if injectableParams[i].dbms == dbmsName {
+					injectableParams[i].dbms = info.Name
+				}
+			}
 			dbmsName = info.Name
-			result.DBMSVersion = info.Version
-			s.progress("DBMS identified: %s %s (confidence %.0f%%)", info.Name, info.Version, info.Confidence*100)
+			dbmsVersion = info.Version
+			if info == known {
+				s.progress("DBMS of an earlier target of the host: %s %s", info.Name, info.Version)
+			} else {
+				s.progress("DBMS identified: %s %s (confidence %.0f%%)", info.Name, info.Version, info.Confidence*100)
+			}
+		}
+	}
+	for i := range injectableParams {
+		if injectableParams[i].dbms == "" {
+			injectableParams[i].dbms = dbmsName
 		}
 	}
 
-	result.DBMS = dbmsName
-	s.progress("using DBMS: %s", dbmsName)
+	c.SetDBMS(dbmsName, dbmsVersion)
+	s.emit(Event{Kind: EventFingerprint, DBMS: dbmsName, Message: "using DBMS: " + dbmsName})
 
 	// Step 6: Run techniques via worker pool.
 	if len(s.techniques) == 0 {
 		s.progress("no techniques configured")
-		return result, nil
+		return nil
 	}
 
-	pool := newWorkerPool(s.config.Threads)
-
-	pool.start(ctx, s.client, target)
-
-	// Submit all jobs: each injectable parameter x each technique.
-	jobCount := 0
-	for _, pi := range injectableParams {
-		for _, tech := range s.techniques {
-			pool.submit(job{
-				parameter: pi.param,
-				technique: tech,
-				baseline:  pi.baseline,
-				dbms:      dbmsName,
-			})
-			jobCount++
+	techniques := s.techniques
+	sleepSeconds := s.config.TimeSec
+	if slices.ContainsFunc(techniques, isTimeBased) && len(injectableParams) > 0 {
+		var run bool
+		sleepSeconds, run = s.gateTimeBased(ctx, client, baselineReq, baseline, c)
+		if !run {
+			techniques = slices.DeleteFunc(slices.Clone(techniques), isTimeBased)
+			for _, pi := range injectableParams {
+				stats.SkippedJobs = append(stats.SkippedJobs, SkippedJob{
+					Parameter: pi.param,
+					Technique: timeBasedName,
+					Reason:    "latency jitter too high for a reliable sleep",
+				})
+			}
 		}
 	}
-	s.progress("submitted %d detection jobs to %d workers", jobCount, s.config.Threads)
 
-	pool.close()
+	pool := newWorkerPool(threads)
+	pool.outages = s.outages
+	pool.healthProbe = baselineReq
+	pool.baselineCookies = baselineCookies
+	pool.progress = s.notifyOnce
+	pool.logger = s.logger
+	pool.emit = s.emit
+	pool.heldForTriage = s.heldForTriage
+
+	if err := pool.start(ctx, client, target); err != nil {
+		return err
+	}
 
-	// Step 7: Aggregate results.
-	for vuln := range pool.results {
-		result.Vulnerabilities = append(result.Vulnerabilities, vuln)
+	// Submit all jobs (each injectable parameter x each technique) from a
+	// separate goroutine so results are drained while workers run. A full
+	// queue blocks the submitter until a worker frees up. With StopAtFirst,
+	// each parameter's jobs share a context cancelled once one of them finds
+	// it injectable.
+	jobCount := len(injectableParams) * len(techniques)
+	paramCtxs := make([]context.Context, len(injectableParams))
+	stopParams := make([]context.CancelFunc, len(injectableParams))
+	if s.config.StopAtFirst {
+		for i := range injectableParams {
+			paramCtxs[i], stopParams[i] = context.WithCancel(context.Background())
+			defer stopParams[i]()
+		}
 	}
+	go func() {
+		defer pool.close()
+		for i, pi := range injectableParams {
+			state := NewParamState()
+			for _, tech := range techniques {
+				err := pool.submit(ctx, job{
+					parameter: pi.param,
+					technique: tech,
+					baseline:  pi.baseline,
+					dbms:      pi.dbms,
+					coverage:  coverage,
+					context:   pi.context,
+					state:     state,
+					sleep:     sleepSeconds,
+					timeout:   s.techniqueTimeout(tech.Name()),
+					deadline:  s.jobTimeout(tech),
+					level:     s.config.Level,
+					risk:      s.config.Risk,
+					param:     paramCtxs[i],
+					stopParam: stopParams[i],
+
+					matchString:    s.config.MatchString,
+					notMatchString: s.config.NotMatchString,
+					matchRegexp:    s.config.MatchRegexp,
+				})
+				if err != nil {
+					return
+				}
+			}
+		}
+	}()
+	s.progress("submitted %d detection jobs to %d workers", jobCount, threads)
+
+	// On cancellation, give in-flight jobs DrainTimeout to finish. Jobs
+	// can send nothing more once the request budget is spent, so they are
+	// cancelled at once.
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-pool.done:
+			return
+		}
+		timeout := s.config.DrainTimeout
+		if context.Cause(ctx) == ErrRequestBudget {
+			s.progress("request budget of %d spent, stopping the scan", s.config.MaxRequests)
+			timeout = 0
+		}
+		dctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := pool.Drain(dctx); err != nil && timeout > 0 {
+			s.progress("drain deadline reached, in-flight jobs cancelled")
+		}
+	}()
 
-	// Count injectable findings.
+	// Step 7: Emit results, consolidated per parameter once every job is
+	// done. Borderline findings are triaged first.
+	confirmed := make(map[string]bool)
 	injectableCount := 0
-	for _, v := range result.Vulnerabilities {
-		if v.Injectable {
+	findingCount := 0
+	var findings, held []Vulnerability
+	for vuln := range pool.results {
+		if s.heldForTriage(vuln) {
+			held = append(held, vuln)
+			continue
+		}
+		findings = append(findings, vuln)
+	}
+	for _, vuln := range held {
+		i := slices.IndexFunc(injectableParams, func(pi paramInfo) bool { return pi.param == vuln.Parameter })
+		pi := injectableParams[i]
+		var placebo *Parameter
+		for _, p := range inertParams {
+			if p != vuln.Parameter {
+				placebo = &p
+				break
+			}
+		}
+		s.progress("triaging %s finding on %q (confidence %.0f%%)", vuln.Technique, vuln.Parameter.Name, vuln.Confidence*100)
+		vuln = s.triage(ctx, s.controller(vuln.Technique), vuln, TechniqueRequest{
+			Target:       target,
+			Parameter:    &pi.param,
+			Baseline:     pi.baseline,
+			DBMS:         pi.dbms,
+			Client:       client,
+			Context:      pi.context,
+			SleepSeconds: sleepSeconds,
+			Timeout:      s.techniqueTimeout(vuln.Technique),
+			Level:        s.config.Level,
+			Risk:         s.config.Risk,
+
+			MatchString:    s.config.MatchString,
+			NotMatchString: s.config.NotMatchString,
+			MatchRegexp:    s.config.MatchRegexp,
+			Progress:       s.notifyOnce,
+		}, baselineReq, placebo)
+		s.progress("triage of %s on %q: %s", vuln.Technique, vuln.Parameter.Name, vuln.Triage.Summary())
+		findings = append(findings, vuln)
+	}
+	for _, vuln := range s.aggregateFindings(findings) {
+		if vuln.Injectable {
+			confirmed[paramKey(vuln.Parameter)] = true
 			injectableCount++
 		}
+		findingCount++
+		c.AddFinding(vuln)
+	}
+	for _, err := range pool.jobErrors() {
+		c.AddError(err)
+	}
+	stats.SkippedJobs = append(stats.SkippedJobs, pool.skippedJobs()...)
+	stats.TechniqueTimings = pool.techniqueTimings()
+	stats.JobTimings = pool.jobTimings()
+	stats.Unanswered = pool.unansweredProbes()
+	if n := countUnanswered(stats.Unanswered); n > 0 {
+		msg := fmt.Sprintf("%d probe(s) got no response (%s); techniques may have missed injections they would have found",
+			n, formatUnanswered(stats.Unanswered))
+		s.progress("%s", msg)
+		c.AddWarning(msg)
+	}
+
+	// Step 8: Cross-parameter split payloads.
+	if crossParam {
+		for _, v := range s.runCrossParam(ctx, client, target, liveParams, confirmed, dbmsName, c) {
+			if v.Injectable {
+				injectableCount++
+			}
+			findingCount++
+		}
+	}
+
+	s.progress("scan complete: %d vulnerability findings (%d injectable)", findingCount, injectableCount)
+
+	return nil
+}
+
+// heldForTriage reports whether finding v is held back for triage after
+// detection: it is borderline, triage is on, and its technique supports
+// the controls.
+func (s *Scanner) heldForTriage(v Vulnerability) bool {
+	return s.config.Triage && borderline(v) && s.controller(v.Technique) != nil
+}
+
+// controller returns the technique named name if it supports triage
+// controls, or nil.
+func (s *Scanner) controller(name string) Controller {
+	for _, t := range s.techniques {
+		if t.Name() == name {
+			if ctl, ok := t.(Controller); ok {
+				return ctl
+			}
+		}
+	}
+	return nil
+}
+
+// techniqueTimeout returns the ScanConfig.TechniqueTimeouts entry for the
+// technique named name, under its name or its code, or zero.
+func (s *Scanner) techniqueTimeout(name string) time.Duration {
+	if d, ok := s.config.TechniqueTimeouts[name]; ok {
+		return d
+	}
+	for code, n := range techniqueFilterMap {
+		if n == name {
+			return s.config.TechniqueTimeouts[code]
+		}
+	}
+	return 0
+}
+
+// jobTimeout returns the bound on a job of tech: ScanConfig.JobTimeout, or
+// its default for tech, or zero for none.
+func (s *Scanner) jobTimeout(tech Technique) time.Duration {
+	switch {
+	case s.config.JobTimeout < 0:
+		return 0
+	case s.config.JobTimeout > 0:
+		return s.config.JobTimeout
+	case isTimeBased(tech):
+		return DefaultTimeBasedJobTimeout
+	}
+	return DefaultJobTimeout
+}
+
+// paramKey identifies a parameter by location and name.
+func paramKey(p Parameter) string {
+	return p.Location.String() + ":" + p.Name
+}
+
+// runCrossParam runs the split-payload detector on live parameters that no
+// single-parameter technique confirmed, emits its findings into c and
+// returns them.
+func (s *Scanner) runCrossParam(ctx context.Context, client transport.Client, target *ScanTarget, live []Parameter, confirmed map[string]bool, dbmsName string, c ResultCollector) []Vulnerability {
+	var candidates []Parameter
+	for _, p := range live {
+		if !confirmed[paramKey(p)] {
+			candidates = append(candidates, p)
+		}
+	}
+	if len(candidates) < 2 {
+		return nil
 	}
-	s.progress("scan complete: %d vulnerability findings (%d injectable)", len(result.Vulnerabilities), injectableCount)
 
-	return result, nil
+	s.progress("testing %d live-but-unconfirmed parameter(s) with split payloads", len(candidates))
+	protocol := &protocolRecorder{Client: client}
+	vulns, err := s.crossFunc(ctx, target, candidates, dbmsName, protocol)
+	if err != nil {
+		s.logger.Warn("cross-parameter detection failed", "err", err)
+		c.AddError(fmt.Errorf("cross-parameter detection: %w", err))
+	}
+	for i := range vulns {
+		if vulns[i].Protocol == "" {
+			vulns[i].Protocol = protocol.last()
+		}
+		if vulns[i].Injectable {
+			vulns[i].Severity = classifySeverity(vulns[i].Technique, vulns[i].Confidence)
+		}
+		c.AddFinding(vulns[i])
+	}
+	return vulns
 }
 
 // buildBaselineRequest creates a transport.Request from a ScanTarget with
@@ -438,7 +1113,15 @@ func buildBaselineRequest(target *ScanTarget) *transport.Request {
 	return req
 }
 
-// discardWriter is an io.Writer that discards all data (used for quiet logging).
-type discardWriter struct{}
-
-func (discardWriter) Write(p []byte) (int, error) { return len(p), nil }
+// cookieSnapshot returns the cookies the target has set for rawURL, as a
+// Cookie header, or "" without a CookieSource or cookies.
+func (s *Scanner) cookieSnapshot(rawURL string) string {
+	if s.cookies == nil {
+		return ""
+	}
+	var pairs []string
+	for _, ck := range s.cookies.Cookies(rawURL) {
+		pairs = append(pairs, ck.Name+"="+ck.Value)
+	}
+	return strings.Join(pairs, "; ")
+}
@@ -1,12 +1,22 @@
 package engine_test
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -38,6 +48,17 @@ func (a *techniqueAdapter) Detect(ctx context.Context, req *engine.TechniqueRequ
 		Baseline:  req.Baseline,
 		DBMS:      req.DBMS,
 		Client:    req.Client,
+		Context:   req.Context,
+		State:     req.State,
+
+		SleepSeconds: req.SleepSeconds,
+		Timeout:      req.Timeout,
+		Level:        req.Level,
+		Risk:         req.Risk,
+
+		MatchString:    req.MatchString,
+		NotMatchString: req.NotMatchString,
+		MatchRegexp:    req.MatchRegexp,
 	}
 	r, err := a.inner.Detect(ctx, innerReq)
 	if err != nil {
@@ -83,6 +104,7 @@ func makeHeuristicFunc(client transport.Client) engine.HeuristicDetectorFunc {
 				ErrorSignatures: r.ErrorSignatures,
 				PageRatio:       r.PageRatio,
 				IsInjectable:    r.IsInjectable,
+				Context:         r.Context,
 			}
 		}
 		return out, nil
@@ -101,6 +123,7 @@ func makeDBMSIdentifier() engine.DBMSIdentifierFunc {
 			Version:    info.Version,
 			Banner:     info.Banner,
 			Confidence: info.Confidence,
+			Tentative:  info.Tentative,
 		}
 	}
 }
@@ -243,6 +266,9 @@ func TestScanner_DefaultConfig(t *testing.T) {
 	if cfg.ForceTest {
 		t.Error("ForceTest = true, want false")
 	}
+	if !cfg.ReadOnly {
+		t.Error("ReadOnly = false, want true")
+	}
 }
 
 func TestNewScanner(t *testing.T) {
@@ -614,6 +640,118 @@ func TestScanner_ProgressCallback(t *testing.T) {
 	}
 }
 
+// progressTechnique reports the same notice through req.Progress for every
+// parameter it is run against.
+type progressTechnique struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (p *progressTechnique) Name() string  { return "notice" }
+func (p *progressTechnique) Priority() int { return 1 }
+func (p *progressTechnique) Detect(_ context.Context, req *engine.TechniqueRequest) (*engine.DetectionResult, error) {
+	p.mu.Lock()
+	p.calls++
+	p.mu.Unlock()
+	if req.Progress != nil {
+		req.Progress(`unknown DBMS "Informix", falling back to MySQL syntax`)
+	}
+	return &engine.DetectionResult{Technique: "notice"}, nil
+}
+
+func TestScanner_TechniqueProgressOnce(t *testing.T) {
+	srv := newVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+	cfg := engine.DefaultScanConfig()
+	cfg.DBMSHint = "Informix"
+	cfg.ForceTest = true
+	tech := &progressTechnique{}
+	scanner := engine.NewScanner(client, cfg,
+		engine.WithTechniques(tech),
+		engine.WithParameterParser(makeParamParser()),
+		engine.WithHeuristicDetector(makeHeuristicFunc(client)),
+	)
+	var mu sync.Mutex
+	notices := 0
+	scanner.SetProgressCallback(func(msg string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if strings.Contains(msg, "unknown DBMS") {
+			notices++
+		}
+	})
+
+	_, err := scanner.Scan(context.Background(), &engine.ScanTarget{URL: srv.URL + "/vuln?id=1&name=a", Method: "GET"})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if tech.calls < 2 {
+		t.Fatalf("technique ran %d time(s), want one per parameter", tech.calls)
+	}
+	if notices != 1 {
+		t.Errorf("fallback notice reported %d times, want once per scan", notices)
+	}
+}
+
+// failingTechnique panics, or hangs until its context is cancelled.
+type failingTechnique struct {
+	name string
+	hang bool
+}
+
+func (f *failingTechnique) Name() string  { return f.name }
+func (f *failingTechnique) Priority() int { return 1 }
+func (f *failingTechnique) Detect(ctx context.Context, _ *engine.TechniqueRequest) (*engine.DetectionResult, error) {
+	if f.hang {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	panic("nil map write")
+}
+
+func TestScanner_JobFailures(t *testing.T) {
+	srv := newVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+	cfg := engine.DefaultScanConfig()
+	cfg.JobTimeout = 50 * time.Millisecond
+	scanner := engine.NewScanner(client, cfg,
+		engine.WithTechniques(&failingTechnique{name: "panicking"}, &failingTechnique{name: "hanging", hang: true}),
+		engine.WithParameterParser(makeParamParser()),
+	)
+
+	done := make(chan struct{})
+	var result *engine.ScanResult
+	var err error
+	go func() {
+		defer close(done)
+		result, err = scanner.Scan(context.Background(), &engine.ScanTarget{URL: srv.URL + "/vuln?id=1", Method: "GET"})
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("scan did not complete")
+	}
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+
+	var panicked, timedOut bool
+	for _, e := range result.Errors {
+		panicked = panicked || strings.Contains(e.Error(), `panicking on "id": technique panicked: nil map write`)
+		timedOut = timedOut || strings.Contains(e.Error(), `hanging on "id": job timed out after 50ms`)
+	}
+	if !panicked || !timedOut {
+		t.Errorf("errors = %v, want the panic and the timeout", result.Errors)
+	}
+	if len(result.JobTimings) != 2 {
+		t.Errorf("job timings = %+v, want both jobs", result.JobTimings)
+	}
+}
+
 func TestScanner_DBMSHint(t *testing.T) {
 	srv := newVulnServer()
 	defer srv.Close()
@@ -726,6 +864,345 @@ func TestScanner_ScanTimeout(t *testing.T) {
 	}
 }
 
+func TestScanner_CrossParam(t *testing.T) {
+	// Both parameters raise a SQL error on a quote, but nothing else about
+	// the page changes, so no single-parameter technique can confirm them.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if strings.Contains(q.Get("a"), "'") || strings.Contains(q.Get("b"), "'") {
+			fmt.Fprint(w, `<html><body><p>You have an error in your SQL syntax</p></body></html>`)
+			return
+		}
+		fmt.Fprint(w, `<html><body><p>Listing</p></body></html>`)
+	}))
+	defer srv.Close()
+
+	var calls int
+	var candidates []string
+	crossFunc := func(_ context.Context, _ *engine.ScanTarget, params []engine.Parameter, _ string, _ transport.Client) ([]engine.Vulnerability, error) {
+		calls++
+		candidates = nil
+		for _, p := range params {
+			candidates = append(candidates, p.Name)
+		}
+		second := params[1]
+		return []engine.Vulnerability{{
+			Parameter:       params[0],
+			Technique:       "split-comment-bridge",
+			Payload:         "1'/*",
+			Confidence:      0.85,
+			Injectable:      true,
+			PairedParameter: &second,
+			PairedPayload:   "*/ AND 1=1-- -",
+		}}, nil
+	}
+
+	scan := func(t *testing.T, crossParam bool, risk int, rawURL string) *engine.ScanResult {
+		t.Helper()
+		client := newTestClient()
+		cfg := engine.DefaultScanConfig()
+		cfg.DBMSHint = "MySQL"
+		cfg.CrossParam = crossParam
+		cfg.Risk = risk
+		scanner := engine.NewScanner(client, cfg,
+			engine.WithTechniques(wrapTechniques(errorbased.New(), boolean.New())...),
+			engine.WithParameterParser(makeParamParser()),
+			engine.WithHeuristicDetector(makeHeuristicFunc(client)),
+			engine.WithCrossParamDetector(crossFunc),
+		)
+		result, err := scanner.Scan(context.Background(), &engine.ScanTarget{URL: rawURL, Method: "GET"})
+		if err != nil {
+			t.Fatalf("Scan returned error: %v", err)
+		}
+		return result
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		calls = 0
+		scan(t, false, 3, srv.URL+"/?a=1&b=2")
+		if calls != 0 {
+			t.Errorf("cross-param detector called %d times without opt-in", calls)
+		}
+	})
+
+	t.Run("needs risk 3", func(t *testing.T) {
+		calls = 0
+		scan(t, true, 2, srv.URL+"/?a=1&b=2")
+		if calls != 0 {
+			t.Errorf("cross-param detector called %d times at risk 2", calls)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		calls = 0
+		result := scan(t, true, 3, srv.URL+"/?a=1&b=2")
+		if calls != 1 {
+			t.Fatalf("cross-param detector called %d times, want 1", calls)
+		}
+		if len(candidates) != 2 {
+			t.Errorf("candidates = %v, want both a and b", candidates)
+		}
+
+		var split *engine.Vulnerability
+		for i, v := range result.Vulnerabilities {
+			if v.PairedParameter != nil {
+				split = &result.Vulnerabilities[i]
+			}
+		}
+		if split == nil {
+			t.Fatal("cross-param finding not appended to result")
+		}
+		if split.Severity != engine.SeverityHigh {
+			t.Errorf("Severity = %v, want HIGH for confidence 0.85", split.Severity)
+		}
+	})
+
+	t.Run("confirmed parameters are excluded", func(t *testing.T) {
+		vuln := newVulnServer()
+		defer vuln.Close()
+
+		calls = 0
+		scan(t, true, 3, vuln.URL+"/multi?id=1&name=test")
+		if calls != 0 {
+			t.Errorf("cross-param detector called with fewer than two unconfirmed live params")
+		}
+	})
+}
+
+// --------------------------------------------------------------------------
+// Outage awareness
+// --------------------------------------------------------------------------
+
+// restartingServer serves /item?id=N. While down it answers 502 with
+// Connection: close, as a reverse proxy in front of a restarting app server
+// would. When vulnerable, the FALSE condition "1=2" returns an empty page.
+type restartingServer struct {
+	*httptest.Server
+	down atomic.Bool
+}
+
+func newRestartingServer(vulnerable bool) *restartingServer {
+	rs := &restartingServer{}
+	rs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rs.down.Load() {
+			w.Header().Set("Connection", "close")
+			http.Error(w, "Bad Gateway", http.StatusBadGateway)
+			return
+		}
+		if vulnerable && strings.Contains(r.URL.Query().Get("id"), "1=2") {
+			fmt.Fprint(w, "<html><body>No results</body></html>")
+			return
+		}
+		fmt.Fprint(w, "<html><body><p>Item 1: Widget</p></body></html>")
+	}))
+	return rs
+}
+
+// restart takes the server down for d.
+func (rs *restartingServer) restart(d time.Duration) {
+	rs.down.Store(true)
+	time.AfterFunc(d, func() { rs.down.Store(false) })
+}
+
+// pairTechnique decides from one TRUE and one FALSE probe: pages that differ
+// mean injectable. On its first run the target restarts between the two
+// probes, so the FALSE probe lands in the outage.
+type pairTechnique struct {
+	server *restartingServer
+	outage time.Duration
+	runs   atomic.Int32
+}
+
+func (p *pairTechnique) Name() string  { return "pair" }
+func (p *pairTechnique) Priority() int { return 1 }
+func (p *pairTechnique) Detect(ctx context.Context, req *engine.TechniqueRequest) (*engine.DetectionResult, error) {
+	run := p.runs.Add(1)
+	probe := func(cond string) (*transport.Response, error) {
+		return req.Client.Do(ctx, &transport.Request{
+			Method: "GET",
+			URL:    p.server.URL + "/item?id=" + url.QueryEscape("1 AND "+cond),
+		})
+	}
+
+	trueResp, err := probe("1=1")
+	if err != nil {
+		return nil, err
+	}
+	if run == 1 {
+		p.server.restart(p.outage)
+	}
+	falseResp, err := probe("1=2")
+	if err != nil {
+		return nil, err
+	}
+
+	differs := trueResp.StatusCode != falseResp.StatusCode || string(trueResp.Body) != string(falseResp.Body)
+	return &engine.DetectionResult{Injectable: differs, Confidence: 0.9, Technique: p.Name()}, nil
+}
+
+func scanRestarting(t *testing.T, vulnerable, aware bool) (*engine.ScanResult, *pairTechnique) {
+	t.Helper()
+	srv := newRestartingServer(vulnerable)
+	t.Cleanup(srv.Close)
+
+	base, err := transport.NewClient(transport.ClientOptions{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	var client transport.Client = base
+	tech := &pairTechnique{server: srv, outage: 300 * time.Millisecond}
+	opts := []engine.ScannerOption{engine.WithTechniques(tech)}
+	if aware {
+		monitor := transport.NewOutageMonitor(base, transport.OutageOptions{ProbeInterval: 20 * time.Millisecond})
+		client = monitor
+		opts = append(opts, engine.WithOutageMonitor(monitor))
+	}
+
+	cfg := engine.DefaultScanConfig()
+	cfg.Threads = 1
+	scanner := engine.NewScanner(client, cfg, opts...)
+	result, err := scanner.Scan(context.Background(), &engine.ScanTarget{
+		URL:    srv.URL + "/item?id=1",
+		Method: "GET",
+		Parameters: []engine.Parameter{
+			{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	return result, tech
+}
+
+func injectableCount(result *engine.ScanResult) int {
+	n := 0
+	for _, v := range result.Vulnerabilities {
+		if v.Injectable {
+			n++
+		}
+	}
+	return n
+}
+
+func TestScanner_OutageFalsePositive(t *testing.T) {
+	// Without outage awareness the 502 during the restart reads as signal.
+	result, _ := scanRestarting(t, false, false)
+	if injectableCount(result) != 1 {
+		t.Fatalf("test setup: expected the outage to cause a false positive, got %+v", result.Vulnerabilities)
+	}
+
+	result, tech := scanRestarting(t, false, true)
+	if n := injectableCount(result); n != 0 {
+		t.Errorf("false positive from the outage survived: %+v", result.Vulnerabilities)
+	}
+	if runs := tech.runs.Load(); runs != 2 {
+		t.Errorf("technique ran %d times, want 2 (decision across the outage re-run)", runs)
+	}
+
+	if len(result.Outages) != 1 {
+		t.Fatalf("Outages = %+v, want one window", result.Outages)
+	}
+	w := result.Outages[0]
+	if w.End.IsZero() || w.End.Sub(w.Start) < 200*time.Millisecond {
+		t.Errorf("outage window %v - %v should be closed and span the restart", w.Start, w.End)
+	}
+	if w.Start.Before(result.StartTime) || w.End.After(result.EndTime) {
+		t.Errorf("outage window outside scan interval")
+	}
+}
+
+func TestScanner_OutageGenuineFindingReverified(t *testing.T) {
+	result, tech := scanRestarting(t, true, true)
+	if injectableCount(result) != 1 {
+		t.Errorf("genuine finding lost after re-verification: %+v", result.Vulnerabilities)
+	}
+	if runs := tech.runs.Load(); runs != 2 {
+		t.Errorf("technique ran %d times, want 2", runs)
+	}
+	if len(result.Outages) != 1 {
+		t.Errorf("Outages = %+v, want one window", result.Outages)
+	}
+}
+
+// newActionServer serves /cart?id=1&delete=1, where delete is error-based
+// injectable and id is not. Every request carrying anything but the
+// original value in delete is counted in probes.
+func newActionServer(probes *atomic.Int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d := r.URL.Query().Get("delete")
+		if d != "1" {
+			probes.Add(1)
+		}
+		switch {
+		case strings.Contains(d, "extractvalue") || strings.Contains(d, "updatexml"):
+			fmt.Fprint(w, `<html><body><p>XPATH syntax error: '~8.0.32~'</p></body></html>`)
+		case strings.Contains(d, "'"):
+			fmt.Fprint(w, `<html><body><p>You have an error in your SQL syntax</p></body></html>`)
+		default:
+			fmt.Fprint(w, `<html><body><p>Cart: 1 item</p></body></html>`)
+		}
+	}))
+}
+
+func TestScanner_RiskyParameters(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		setup   func(cfg *engine.ScanConfig)
+		probed  bool // delete receives payloads and is found injectable
+		skipped int
+	}{
+		{"default skips", "GET", func(*engine.ScanConfig) {}, false, 1},
+		{"allow-param", "GET", func(cfg *engine.ScanConfig) { cfg.AllowParams = []string{"delete"} }, true, 0},
+		{"allow all", "GET", func(cfg *engine.ScanConfig) { cfg.AllowRiskyParams = true }, true, 0},
+		{"writes allowed", "GET", func(cfg *engine.ScanConfig) { cfg.ReadOnly = false }, true, 0},
+		{"extended list", "GET", func(cfg *engine.ScanConfig) { cfg.RiskyParamNames = []string{"id"} }, false, 2},
+		{"mutating method", "DELETE", func(*engine.ScanConfig) {}, false, 2},
+		{"mutating method allowed", "DELETE", func(cfg *engine.ScanConfig) { cfg.AllowParams = []string{"delete"} }, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var probes atomic.Int64
+			srv := newActionServer(&probes)
+			defer srv.Close()
+
+			cfg := engine.DefaultScanConfig()
+			tt.setup(cfg)
+			scanner := newFullScanner(newTestClient(), cfg)
+			result, err := scanner.Scan(context.Background(), &engine.ScanTarget{
+				URL:    srv.URL + "/cart?id=1&delete=1",
+				Method: tt.method,
+			})
+			if err != nil {
+				t.Fatalf("Scan returned error: %v", err)
+			}
+
+			found := false
+			for _, v := range result.Vulnerabilities {
+				if v.Injectable && v.Parameter.Name == "delete" {
+					found = true
+				}
+			}
+			if tt.probed {
+				if probes.Load() == 0 || !found {
+					t.Errorf("delete: %d probes, injectable %v; want it fully tested", probes.Load(), found)
+				}
+			} else if n := probes.Load(); n != 0 || found {
+				t.Errorf("delete: %d requests carried payloads, want none", n)
+			}
+
+			if len(result.Skipped) != tt.skipped {
+				t.Fatalf("Skipped = %+v, want %d", result.Skipped, tt.skipped)
+			}
+			for _, sp := range result.Skipped {
+				if sp.Reason == "" {
+					t.Errorf("%s skipped without a reason", sp.Parameter.Name)
+				}
+			}
+		})
+	}
+}
+
 // --------------------------------------------------------------------------
 // Test transport client
 // --------------------------------------------------------------------------
@@ -800,3 +1277,711 @@ func (c *testTransportClient) Stats() *transport.TransportStats {
 		TotalRequests: c.requests,
 	}
 }
+
+// jitterClient reports the latencies in turn as each response's time to
+// first byte, simulating a target with unstable response times.
+type jitterClient struct {
+	*testTransportClient
+	latencies []time.Duration
+	n         atomic.Int64
+}
+
+func (c *jitterClient) Do(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	resp, err := c.testTransportClient.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	i := c.n.Add(1) - 1
+	resp.TTFB = c.latencies[int(i)%len(c.latencies)]
+	resp.Duration = resp.TTFB
+	return resp, nil
+}
+
+// sleepRecorder stands in for the time-based technique and records the
+// sleep of every job it runs.
+type sleepRecorder struct {
+	calls  atomic.Int32
+	sleeps chan int
+}
+
+func (r *sleepRecorder) Name() string  { return "time-based" }
+func (r *sleepRecorder) Priority() int { return 5 }
+func (r *sleepRecorder) Detect(_ context.Context, req *engine.TechniqueRequest) (*engine.DetectionResult, error) {
+	r.calls.Add(1)
+	r.sleeps <- req.SleepSeconds
+	return &engine.DetectionResult{Technique: r.Name()}, nil
+}
+
+func scanJittery(t *testing.T, cfg *engine.ScanConfig, latencies ...time.Duration) (*engine.ScanResult, *sleepRecorder) {
+	t.Helper()
+	srv := newVulnServer()
+	t.Cleanup(srv.Close)
+
+	client := &jitterClient{testTransportClient: newTestClient(), latencies: latencies}
+	rec := &sleepRecorder{sleeps: make(chan int, 8)}
+	scanner := engine.NewScanner(client, cfg, engine.WithTechniques(rec))
+	result, err := scanner.Scan(context.Background(), &engine.ScanTarget{
+		URL:    srv.URL + "/safe?id=1",
+		Method: "GET",
+		Parameters: []engine.Parameter{
+			{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	return result, rec
+}
+
+func TestScanner_LatencyGate(t *testing.T) {
+	wild := []time.Duration{100 * time.Millisecond, 9 * time.Second, 300 * time.Millisecond, 6 * time.Second, 2 * time.Second}
+
+	t.Run("stable", func(t *testing.T) {
+		result, rec := scanJittery(t, engine.DefaultScanConfig(), 100*time.Millisecond, 102*time.Millisecond)
+		if rec.calls.Load() != 1 || len(result.SkippedJobs) != 0 || len(result.Warnings) != 0 {
+			t.Errorf("calls=%d skipped=%v warnings=%v, want a plain run", rec.calls.Load(), result.SkippedJobs, result.Warnings)
+		}
+		if s := <-rec.sleeps; s != 0 {
+			t.Errorf("sleep = %d, want 0 (technique default)", s)
+		}
+	})
+
+	t.Run("raised", func(t *testing.T) {
+		result, rec := scanJittery(t, engine.DefaultScanConfig(),
+			100*time.Millisecond, 2100*time.Millisecond, 300*time.Millisecond, 1900*time.Millisecond, 1100*time.Millisecond)
+		if s := <-rec.sleeps; s != 6 {
+			t.Errorf("sleep = %d, want 6", s)
+		}
+		if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "raised the sleep from 5s to 6s") {
+			t.Errorf("warnings = %v", result.Warnings)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		result, rec := scanJittery(t, engine.DefaultScanConfig(), wild...)
+		if rec.calls.Load() != 0 {
+			t.Errorf("time-based ran %d jobs, want none", rec.calls.Load())
+		}
+		if len(result.SkippedJobs) != 1 {
+			t.Fatalf("skipped jobs = %v, want one", result.SkippedJobs)
+		}
+		sj := result.SkippedJobs[0]
+		if sj.Parameter.Name != "id" || sj.Technique != "time-based" || !strings.Contains(sj.Reason, "latency jitter") {
+			t.Errorf("skipped job = %+v", sj)
+		}
+		if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "--force-time-based") {
+			t.Errorf("warnings = %v", result.Warnings)
+		}
+	})
+
+	t.Run("forced", func(t *testing.T) {
+		cfg := engine.DefaultScanConfig()
+		cfg.ForceTimeBased = true
+		cfg.TimeSec = 3
+		result, rec := scanJittery(t, cfg, wild...)
+		if s := <-rec.sleeps; s != 3 {
+			t.Errorf("sleep = %d, want 3", s)
+		}
+		if len(result.SkippedJobs) != 0 || len(result.Warnings) != 0 {
+			t.Errorf("skipped=%v warnings=%v, want none when forced", result.SkippedJobs, result.Warnings)
+		}
+	})
+}
+
+// retainingTechnique sends probes and keeps every response until it is
+// done, as a technique comparing a batch of pages does, then reports the
+// heap growth they caused.
+type retainingTechnique struct {
+	probes int
+	growth uint64
+}
+
+func (r *retainingTechnique) Name() string  { return "retain" }
+func (r *retainingTechnique) Priority() int { return 1 }
+func (r *retainingTechnique) Detect(ctx context.Context, req *engine.TechniqueRequest) (*engine.DetectionResult, error) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	held := make([]*transport.Response, 0, r.probes)
+	for i := range r.probes {
+		resp, err := req.Client.Do(ctx, &transport.Request{
+			Method: "GET",
+			URL:    req.Target.URL + "&probe=" + strconv.Itoa(i),
+		})
+		if err != nil {
+			return nil, err
+		}
+		held = append(held, resp)
+	}
+
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	r.growth = after.HeapAlloc - min(after.HeapAlloc, before.HeapAlloc)
+
+	same := true
+	for _, resp := range held {
+		same = same && bytes.Equal(resp.Body, req.Baseline.Body)
+	}
+	return &engine.DetectionResult{Injectable: !same, Technique: r.Name()}, nil
+}
+
+func TestScanner_BodyStoreMemory(t *testing.T) {
+	page := bytes.Repeat([]byte("<p>catalogue entry</p>\n"), (1<<20)/23)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(page)
+	}))
+	defer srv.Close()
+
+	scan := func(store *transport.BodyStore) (*engine.ScanResult, uint64) {
+		client, err := transport.NewClient(transport.ClientOptions{Timeout: 5 * time.Second, BodyStore: store})
+		if err != nil {
+			t.Fatalf("NewClient: %v", err)
+		}
+		tech := &retainingTechnique{probes: 20}
+		cfg := engine.DefaultScanConfig()
+		cfg.Threads = 1
+		result, err := engine.NewScanner(client, cfg, engine.WithTechniques(tech)).Scan(context.Background(), &engine.ScanTarget{
+			URL:    srv.URL + "/list?id=1",
+			Method: "GET",
+			Parameters: []engine.Parameter{
+				{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		return result, tech.growth
+	}
+
+	plain, plainGrowth := scan(nil)
+	shared, sharedGrowth := scan(transport.NewBodyStore())
+	t.Logf("heap growth for 20 retained 1 MB pages: %d KB plain, %d KB shared", plainGrowth>>10, sharedGrowth>>10)
+
+	if plainGrowth < 15<<20 {
+		t.Errorf("plain growth = %d KB, expected every page held separately", plainGrowth>>10)
+	}
+	if sharedGrowth > 2<<20 {
+		t.Errorf("shared growth = %d KB, want near flat", sharedGrowth>>10)
+	}
+	if len(plain.Vulnerabilities) != len(shared.Vulnerabilities) {
+		t.Errorf("findings differ: %d plain, %d shared", len(plain.Vulnerabilities), len(shared.Vulnerabilities))
+	}
+}
+
+// http10Server is an HTTP/1.0 server on a raw listener, like those of
+// legacy embedded devices: it answers one request per connection without
+// keep-alive, closes it, and resets any connection that arrives while it
+// is busy with another.
+type http10Server struct {
+	ln      net.Listener
+	handler http.Handler
+	active  atomic.Int32
+	resets  atomic.Int32
+}
+
+func newHTTP10Server(t *testing.T, handler http.Handler) *http10Server {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	s := &http10Server{ln: ln, handler: handler}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *http10Server) URL() string { return "http://" + s.ln.Addr().String() }
+
+func (s *http10Server) serve(conn net.Conn) {
+	defer conn.Close()
+	if s.active.Add(1) > 1 {
+		s.active.Add(-1)
+		s.resets.Add(1)
+		conn.(*net.TCPConn).SetLinger(0)
+		return
+	}
+	req, err := http.ReadRequest(bufio.NewReader(conn))
+	if err != nil {
+		s.active.Add(-1)
+		return
+	}
+	time.Sleep(2 * time.Millisecond) // A slow device widens the window for overlaps
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	s.active.Add(-1)
+
+	fmt.Fprintf(conn, "HTTP/1.0 %d %s\r\nContent-Type: text/html\r\nContent-Length: %d\r\n\r\n",
+		rec.Code, http.StatusText(rec.Code), rec.Body.Len())
+	conn.Write(rec.Body.Bytes())
+}
+
+func scanHTTP10(t *testing.T, srv *http10Server, http10Threads int) *engine.ScanResult {
+	t.Helper()
+	client, err := transport.NewClient(transport.ClientOptions{Timeout: 5 * time.Second, Threads: 10})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	cfg := engine.DefaultScanConfig()
+	cfg.HTTP10Threads = http10Threads
+	scanner := engine.NewScanner(client, cfg,
+		engine.WithTechniques(wrapTechniques(errorbased.New(), boolean.New())...),
+		engine.WithParameterParser(makeParamParser()),
+		engine.WithHeuristicDetector(makeHeuristicFunc(client)),
+		engine.WithDBMSIdentifier(makeDBMSIdentifier()),
+		engine.WithFingerprinter(makeFingerprinter()),
+		engine.WithKeepAliveSwitch(client),
+	)
+	result, err := scanner.Scan(context.Background(), &engine.ScanTarget{URL: srv.URL() + "/vuln?id=1", Method: "GET"})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	return result
+}
+
+func TestScanner_HTTP10Target(t *testing.T) {
+	vuln := newVulnServer()
+	defer vuln.Close()
+
+	// What the scan finds on the same application served over HTTP/1.1.
+	want, err := newFullScanner(newTestClient(), engine.DefaultScanConfig()).Scan(context.Background(),
+		&engine.ScanTarget{URL: vuln.URL + "/vuln?id=1", Method: "GET"})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+
+	srv := newHTTP10Server(t, vuln.Config.Handler)
+	result := scanHTTP10(t, srv, 1)
+
+	if p := result.Profile; p == nil || p.Protocol != "HTTP/1.0" || !p.ClosesConnections {
+		t.Errorf("profile = %+v, want HTTP/1.0 closing connections", result.Profile)
+	}
+	if result.Unanswered != nil || srv.resets.Load() != 0 {
+		t.Errorf("unanswered = %v, resets = %d, want none", result.Unanswered, srv.resets.Load())
+	}
+	if injectableCount(result) == 0 || injectableCount(result) != injectableCount(want) {
+		t.Errorf("injectable findings = %d, want %d as over HTTP/1.1", injectableCount(result), injectableCount(want))
+	}
+}
+
+func TestScanner_HTTP10Target_UnansweredCounted(t *testing.T) {
+	vuln := newVulnServer()
+	defer vuln.Close()
+	srv := newHTTP10Server(t, vuln.Config.Handler)
+
+	// Without the thread cap, concurrent probes get reset; they are
+	// counted rather than silently skipped.
+	result := scanHTTP10(t, srv, 0)
+	if srv.resets.Load() == 0 {
+		t.Skip("no concurrent probes overlapped")
+	}
+	if result.Unanswered["reset"]+result.Unanswered["closed"] == 0 {
+		t.Errorf("unanswered = %v after %d resets", result.Unanswered, srv.resets.Load())
+	}
+	found := false
+	for _, w := range result.Warnings {
+		found = found || strings.Contains(w, "got no response")
+	}
+	if !found {
+		t.Errorf("warnings = %v, want the unanswered probes reported", result.Warnings)
+	}
+}
+
+// matchRecorder stands in for boolean-blind and records the user-supplied
+// oracle of the last job it ran.
+type matchRecorder struct {
+	mu  sync.Mutex
+	req engine.TechniqueRequest
+}
+
+func (r *matchRecorder) Name() string  { return "boolean-blind" }
+func (r *matchRecorder) Priority() int { return 2 }
+func (r *matchRecorder) Detect(_ context.Context, req *engine.TechniqueRequest) (*engine.DetectionResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.req = *req
+	return &engine.DetectionResult{Technique: r.Name()}, nil
+}
+
+func TestScanner_MatchOraclePassedToTechniques(t *testing.T) {
+	srv := newVulnServer()
+	defer srv.Close()
+
+	cfg := engine.DefaultScanConfig()
+	cfg.MatchString = "Welcome"
+	cfg.NotMatchString = "Sorry"
+	cfg.MatchRegexp = regexp.MustCompile(`Item \d+`)
+	rec := &matchRecorder{}
+	scanner := engine.NewScanner(newTestClient(), cfg, engine.WithTechniques(rec))
+	_, err := scanner.Scan(context.Background(), &engine.ScanTarget{
+		URL:    srv.URL + "/safe?id=1",
+		Method: "GET",
+		Parameters: []engine.Parameter{
+			{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if rec.req.MatchString != "Welcome" || rec.req.NotMatchString != "Sorry" || rec.req.MatchRegexp != cfg.MatchRegexp {
+		t.Errorf("job oracle = %q, %q, %v; want the ScanConfig's", rec.req.MatchString, rec.req.NotMatchString, rec.req.MatchRegexp)
+	}
+}
+
+// dbmsRecorder stands in for a technique and records the DBMS each
+// parameter's job was given.
+type dbmsRecorder struct {
+	mu   sync.Mutex
+	dbms map[string]string
+}
+
+func (r *dbmsRecorder) Name() string  { return "record" }
+func (r *dbmsRecorder) Priority() int { return 1 }
+func (r *dbmsRecorder) Detect(_ context.Context, req *engine.TechniqueRequest) (*engine.DetectionResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dbms[req.Parameter.Name] = req.DBMS
+	return &engine.DetectionResult{Technique: r.Name()}, nil
+}
+
+func TestScanner_DBMSDisagreementReconciled(t *testing.T) {
+	// id breaks a MySQL query with two MySQL signatures on the page; q's
+	// error page happens to quote a PostgreSQL function name in a help
+	// text: one noisy signature.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case strings.Contains(q.Get("id"), "'"):
+			fmt.Fprint(w, `<p>You have an error in your SQL syntax</p><p>Warning: mysql_fetch_array() expects parameter 1</p>`)
+		case strings.Contains(q.Get("q"), "'"):
+			fmt.Fprint(w, `<p>Search failed. Legacy help: pg_query() is no longer used.</p>`)
+		default:
+			fmt.Fprint(w, `<p>Results</p>`)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// The fingerprinter's probes, re-run on q, identify MySQL.
+	var fpParams []string
+	fp := func(_ context.Context, _ *engine.ScanTarget, param *engine.Parameter, _ *transport.Response, _ transport.Client) (*engine.DBMSInfo, error) {
+		fpParams = append(fpParams, param.Name)
+		return &engine.DBMSInfo{Name: "MySQL", Version: "8.0", Confidence: 1.0}, nil
+	}
+
+	client := newTestClient()
+	rec := &dbmsRecorder{dbms: make(map[string]string)}
+	scanner := engine.NewScanner(client, engine.DefaultScanConfig(),
+		engine.WithTechniques(rec),
+		engine.WithParameterParser(makeParamParser()),
+		engine.WithHeuristicDetector(makeHeuristicFunc(client)),
+		engine.WithDBMSIdentifier(makeDBMSIdentifier()),
+		engine.WithFingerprinter(fp),
+	)
+	result, err := scanner.Scan(context.Background(), &engine.ScanTarget{URL: srv.URL + "/search?id=1&q=shoes", Method: "GET"})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+
+	if rec.dbms["id"] != "MySQL" || rec.dbms["q"] != "MySQL" {
+		t.Errorf("job DBMS = %v, want MySQL for both", rec.dbms)
+	}
+	if len(fpParams) == 0 || fpParams[0] != "q" {
+		t.Errorf("fingerprinted %v, want the dissenting q re-checked first", fpParams)
+	}
+	if result.DBMS != "MySQL" {
+		t.Errorf("result DBMS = %q, want MySQL", result.DBMS)
+	}
+	found := false
+	for _, w := range result.Warnings {
+		found = found || strings.Contains(w, `"q" identified PostgreSQL on weaker evidence than MySQL from "id"`)
+	}
+	if !found {
+		t.Errorf("warnings = %q, want the disagreement and its resolution", result.Warnings)
+	}
+}
+
+// controlTechnique reports a borderline finding on "id" and answers triage
+// controls from fired, keyed by control kind and parameter name. Repeats of
+// the decisive probes fire on the rounds listed in repeatFires.
+type controlTechnique struct {
+	fired       map[string]bool
+	repeatFires []bool
+
+	mu      sync.Mutex
+	repeats int
+	calls   []string
+}
+
+func (c *controlTechnique) Name() string  { return "boolean-blind" }
+func (c *controlTechnique) Priority() int { return 1 }
+func (c *controlTechnique) Detect(_ context.Context, req *engine.TechniqueRequest) (*engine.DetectionResult, error) {
+	if req.Parameter.Name != "id" {
+		return &engine.DetectionResult{Technique: c.Name()}, nil
+	}
+	return &engine.DetectionResult{Injectable: true, Confidence: 0.7, Technique: c.Name(), Payload: " AND 1=1-- -", Evidence: "timing"}, nil
+}
+
+func (c *controlTechnique) Control(_ context.Context, req *engine.ControlRequest) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := req.Kind.String() + ":" + req.Parameter.Name
+	c.calls = append(c.calls, key)
+	if key == "repeat:id" {
+		fired := c.repeatFires[c.repeats%len(c.repeatFires)]
+		c.repeats++
+		return fired, nil
+	}
+	return c.fired[key], nil
+}
+
+func scanTriage(t *testing.T, cfg *engine.ScanConfig, tech *controlTechnique) engine.Vulnerability {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<p>Results</p>")
+	}))
+	defer srv.Close()
+
+	// Heuristics call id injectable and page inert: page is the placebo.
+	heuristics := func(_ context.Context, target *engine.ScanTarget) ([]engine.HeuristicResult, error) {
+		var out []engine.HeuristicResult
+		for _, p := range target.Parameters {
+			out = append(out, engine.HeuristicResult{Parameter: p, IsInjectable: p.Name == "id"})
+		}
+		return out, nil
+	}
+	scanner := engine.NewScanner(newTestClient(), cfg,
+		engine.WithTechniques(tech),
+		engine.WithParameterParser(makeParamParser()),
+		engine.WithHeuristicDetector(heuristics),
+	)
+	result, err := scanner.Scan(context.Background(), &engine.ScanTarget{URL: srv.URL + "/list?id=1&page=2", Method: "GET"})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	for _, v := range result.Vulnerabilities {
+		if v.Parameter.Name == "id" {
+			return v
+		}
+	}
+	t.Fatal("no finding on id")
+	return engine.Vulnerability{}
+}
+
+func TestScanner_TriageGenuineBorderline(t *testing.T) {
+	tech := &controlTechnique{repeatFires: []bool{true}}
+	v := scanTriage(t, engine.DefaultScanConfig(), tech)
+
+	if v.Triage == nil {
+		t.Fatal("borderline finding was not triaged")
+	}
+	want := []string{"repeat:id", "repeat:id", "repeat:id", "negative:id", "repeat:page"}
+	if strings.Join(tech.calls, ",") != strings.Join(want, ",") {
+		t.Errorf("controls = %v, want %v", tech.calls, want)
+	}
+	if !v.Injectable || v.Confidence <= 0.9 {
+		t.Errorf("Injectable = %v, Confidence = %v; want the confidence raised past 0.9", v.Injectable, v.Confidence)
+	}
+	if v.Severity != engine.SeverityCritical {
+		t.Errorf("Severity = %v, want recomputed to CRITICAL", v.Severity)
+	}
+	tr := v.Triage
+	if tr.RepeatsFired != 3 || tr.NegativeFired || tr.Placebo != "page" || tr.PlaceboFired || tr.Downgraded {
+		t.Errorf("triage = %+v", *tr)
+	}
+	if !strings.HasPrefix(tr.Summary(), "confirmed (reproduced 3/3, negative control held, placebo \"page\" held") {
+		t.Errorf("Summary = %q", tr.Summary())
+	}
+}
+
+func TestScanner_TriageJitterFalsePositive(t *testing.T) {
+	// Jitter: the "oracle" fires on some repeats and on the broken payload.
+	tech := &controlTechnique{
+		repeatFires: []bool{true, false},
+		fired:       map[string]bool{"negative:id": true},
+	}
+	cfg := engine.DefaultScanConfig()
+	cfg.Verbose = 2 // Keeps the verdict of not injectable
+	v := scanTriage(t, cfg, tech)
+
+	if v.Triage == nil || !v.Triage.Downgraded {
+		t.Fatalf("triage = %+v, want the finding downgraded", v.Triage)
+	}
+	if v.Injectable || v.Confidence >= 0.5 || v.Severity != engine.SeverityInfo {
+		t.Errorf("Injectable = %v, Confidence = %v, Severity = %v; want a collapsed INFO verdict", v.Injectable, v.Confidence, v.Severity)
+	}
+	if v.Triage.InitialConfidence != 0.7 {
+		t.Errorf("InitialConfidence = %v, want 0.7", v.Triage.InitialConfidence)
+	}
+}
+
+func TestScanner_TriageDisabled(t *testing.T) {
+	cfg := engine.DefaultScanConfig()
+	cfg.Triage = false
+	tech := &controlTechnique{repeatFires: []bool{true}}
+	v := scanTriage(t, cfg, tech)
+
+	if v.Triage != nil || len(tech.calls) != 0 || v.Confidence != 0.7 {
+		t.Errorf("triage ran with Triage off: %v, confidence %v", tech.calls, v.Confidence)
+	}
+}
+
+func TestScanner_LevelParamsLeaveTargetAlone(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<p>Results</p>")
+	}))
+	defer srv.Close()
+
+	var seen [][]string
+	heuristics := func(_ context.Context, target *engine.ScanTarget) ([]engine.HeuristicResult, error) {
+		var names []string
+		for _, p := range target.Parameters {
+			names = append(names, p.Name)
+		}
+		seen = append(seen, names)
+		return nil, nil
+	}
+	cfg := engine.DefaultScanConfig()
+	cfg.Level = engine.HeaderLevel
+	scanner := engine.NewScanner(newTestClient(), cfg,
+		engine.WithTechniques(&controlTechnique{}),
+		engine.WithParameterParser(makeParamParser()),
+		engine.WithHeuristicDetector(heuristics),
+	)
+	target := &engine.ScanTarget{
+		URL:        srv.URL + "/list?id=1",
+		Method:     "GET",
+		Cookies:    map[string]string{"lang": "en"},
+		Parameters: []engine.Parameter{{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger}},
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := scanner.Scan(context.Background(), target); err != nil {
+			t.Fatalf("Scan %d: %v", i+1, err)
+		}
+	}
+
+	if len(target.Parameters) != 1 {
+		t.Errorf("target parameters = %+v, want only id", target.Parameters)
+	}
+	want := append([]string{"id", "lang"}, engine.LevelHeaders...)
+	if len(seen) != 2 || !slices.Equal(seen[0], want) || !slices.Equal(seen[1], want) {
+		t.Errorf("parameters tested = %q, want %q on both scans", seen, want)
+	}
+}
+
+func TestScanner_BaselineCookiesInEvidence(t *testing.T) {
+	vuln := newVulnServer()
+	defer vuln.Close()
+	// A load balancer pinning the client to a node, rotating it mid-scan.
+	var mu sync.Mutex
+	served := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if served++; served == 1 || served == 20 {
+			http.SetCookie(w, &http.Cookie{Name: "lb", Value: fmt.Sprintf("node%d", served)})
+		}
+		mu.Unlock()
+		vuln.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	client, err := transport.NewClient(transport.ClientOptions{Timeout: 5 * time.Second, UseCookieJar: true})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	scanner := engine.NewScanner(client, engine.DefaultScanConfig(),
+		engine.WithTechniques(wrapTechniques(errorbased.New(), boolean.New())...),
+		engine.WithParameterParser(makeParamParser()),
+		engine.WithHeuristicDetector(makeHeuristicFunc(client)),
+		engine.WithDBMSIdentifier(makeDBMSIdentifier()),
+		engine.WithFingerprinter(makeFingerprinter()),
+		engine.WithCookieSource(client),
+	)
+	result, err := scanner.Scan(context.Background(), &engine.ScanTarget{URL: srv.URL + "/vuln?id=1", Method: "GET"})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	found := 0
+	for _, v := range result.Vulnerabilities {
+		if !v.Injectable {
+			continue
+		}
+		found++
+		if !strings.HasSuffix(v.Evidence, "[baseline cookies: lb=node1]") {
+			t.Errorf("%s evidence = %q, want the cookies at baseline time", v.Technique, v.Evidence)
+		}
+	}
+	if found == 0 {
+		t.Fatal("no findings")
+	}
+	if got := client.Cookies(srv.URL); len(got) != 1 || got[0].Value != "node20" {
+		t.Errorf("Cookies() = %v, want the rotated cookie", got)
+	}
+}
+
+// timeoutRecorder stands in for the technique named name and records the
+// timeout of every job it runs.
+type timeoutRecorder struct {
+	name     string
+	priority int
+	timeouts chan time.Duration
+}
+
+func (r *timeoutRecorder) Name() string  { return r.name }
+func (r *timeoutRecorder) Priority() int { return r.priority }
+func (r *timeoutRecorder) Detect(_ context.Context, req *engine.TechniqueRequest) (*engine.DetectionResult, error) {
+	r.timeouts <- req.Timeout
+	return &engine.DetectionResult{Technique: r.name}, nil
+}
+
+func TestScanner_TechniqueTimeouts(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeouts map[string]time.Duration
+		wantE    time.Duration
+		wantT    time.Duration
+	}{
+		{"none", nil, 0, 0},
+		{"by code", map[string]time.Duration{"E": 10 * time.Second, "T": 30 * time.Second}, 10 * time.Second, 30 * time.Second},
+		{"by name", map[string]time.Duration{"time-based": 20 * time.Second}, 0, 20 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newVulnServer()
+			defer srv.Close()
+
+			e := &timeoutRecorder{name: "error-based", priority: 1, timeouts: make(chan time.Duration, 1)}
+			tb := &timeoutRecorder{name: "time-based", priority: 3, timeouts: make(chan time.Duration, 1)}
+			cfg := engine.DefaultScanConfig()
+			cfg.ForceTimeBased = true
+			cfg.TechniqueTimeouts = tt.timeouts
+			scanner := engine.NewScanner(newTestClient(), cfg, engine.WithTechniques(e, tb))
+			_, err := scanner.Scan(context.Background(), &engine.ScanTarget{
+				URL:    srv.URL + "/safe?id=1",
+				Method: "GET",
+				Parameters: []engine.Parameter{
+					{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+				},
+			})
+			if err != nil {
+				t.Fatalf("Scan: %v", err)
+			}
+			if got := <-e.timeouts; got != tt.wantE {
+				t.Errorf("error-based timeout = %v, want %v", got, tt.wantE)
+			}
+			if got := <-tb.timeouts; got != tt.wantT {
+				t.Errorf("time-based timeout = %v, want %v", got, tt.wantT)
+			}
+		})
+	}
+}
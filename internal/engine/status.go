@@ -0,0 +1,17 @@
+package engine
+
+import "slices"
+
+// StatusValid reports whether a response with status code is a valid page,
+// one that shows the application rather than an error: its code is in the
+// allowlist valid, or, with an empty allowlist, it is a 2xx or 3xx code.
+//
+// Targets that serve their real pages with another status, such as a
+// single-page app answering every route with 404, list it in
+// ScanConfig.ValidStatusCodes.
+func StatusValid(valid []int, code int) bool {
+	if len(valid) == 0 {
+		return code >= 200 && code < 400
+	}
+	return slices.Contains(valid, code)
+}
@@ -0,0 +1,27 @@
+package engine
+
+import "testing"
+
+func TestStatusValid(t *testing.T) {
+	tests := []struct {
+		valid []int
+		code  int
+		want  bool
+	}{
+		{nil, 200, true},
+		{nil, 204, true},
+		{nil, 302, true},
+		{nil, 199, false},
+		{nil, 403, false},
+		{nil, 500, false},
+		{[]int{200, 403}, 403, true},
+		{[]int{200, 403}, 200, true},
+		{[]int{200, 403}, 302, false},
+		{[]int{200, 403}, 500, false},
+	}
+	for _, tt := range tests {
+		if got := StatusValid(tt.valid, tt.code); got != tt.want {
+			t.Errorf("StatusValid(%v, %d) = %v, want %v", tt.valid, tt.code, got, tt.want)
+		}
+	}
+}
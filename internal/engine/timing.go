@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+const (
+	// DefaultTimeSec is the sleep the time-based technique injects when
+	// ScanConfig.TimeSec is not set.
+	DefaultTimeSec = 5
+
+	// timeBasedName is the name of the technique the latency gate guards.
+	timeBasedName = "time-based"
+
+	// latencySamples is the number of baseline response times, the
+	// baseline request included, the latency gate measures.
+	latencySamples = 5
+)
+
+// TimingAction is the latency gate's verdict on time-based detection.
+type TimingAction int
+
+const (
+	TimingFeasible   TimingAction = iota // The sleep stands clear of the latency jitter
+	TimingRaiseSleep                     // A longer sleep, within TimeSecMax, would
+	TimingDisabled                       // No sleep up to TimeSecMax would
+)
+
+// String returns the action name.
+func (a TimingAction) String() string {
+	return [...]string{"feasible", "raise-sleep", "disabled"}[a]
+}
+
+// TimingDecision is the outcome of EvaluateLatency.
+type TimingDecision struct {
+	Action       TimingAction
+	SleepSeconds int           // Sleep to inject: the configured one or the raised one
+	Jitter       time.Duration // Standard deviation of the measured latency
+	Required     time.Duration // Shortest sleep the jitter allows
+}
+
+// EvaluateLatency decides whether time-based detection is reliable given
+// latency samples of the target: a sleep of sleepSeconds must be at least
+// jitterFactor standard deviations of the latency long, or a delayed and
+// an undelayed response cannot be told apart. When it is not, the shortest
+// whole-second sleep that is is chosen, unless that exceeds maxSleepSeconds.
+func EvaluateLatency(samples []time.Duration, sleepSeconds, maxSleepSeconds int, jitterFactor float64) TimingDecision {
+	d := TimingDecision{Action: TimingFeasible, SleepSeconds: sleepSeconds, Jitter: stdDev(samples)}
+	d.Required = time.Duration(jitterFactor * float64(d.Jitter))
+	if time.Duration(sleepSeconds)*time.Second >= d.Required {
+		return d
+	}
+	need := int(math.Ceil(d.Required.Seconds()))
+	if need > maxSleepSeconds {
+		d.Action = TimingDisabled
+		return d
+	}
+	d.Action, d.SleepSeconds = TimingRaiseSleep, need
+	return d
+}
+
+// stdDev returns the sample standard deviation of ds, zero for fewer than
+// two durations.
+func stdDev(ds []time.Duration) time.Duration {
+	if len(ds) < 2 {
+		return 0
+	}
+	var sum float64
+	for _, d := range ds {
+		sum += float64(d)
+	}
+	mean := sum / float64(len(ds))
+	var sq float64
+	for _, d := range ds {
+		sq += (float64(d) - mean) * (float64(d) - mean)
+	}
+	return time.Duration(math.Sqrt(sq / float64(len(ds)-1)))
+}
+
+// measureLatency returns latencySamples response times of the baseline
+// request: resp's and those of fresh requests. Times are to the first
+// byte, as the time-based technique measures them, unless total is set.
+func measureLatency(ctx context.Context, client transport.Client, req *transport.Request, resp *transport.Response, total bool) ([]time.Duration, error) {
+	elapsed := func(r *transport.Response) time.Duration {
+		if total {
+			return r.Duration
+		}
+		return r.FirstByte()
+	}
+	samples := []time.Duration{elapsed(resp)}
+	for len(samples) < latencySamples {
+		r, err := client.Do(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, elapsed(r))
+	}
+	return samples, nil
+}
+
+// isTimeBased reports whether t is the time-based technique.
+func isTimeBased(t Technique) bool {
+	return t.Name() == timeBasedName
+}
+
+// gateTimeBased runs the latency gate and returns the sleep for time-based
+// jobs (zero for the technique's own default) and whether they run at all.
+// Its verdict is recorded in c as a warning unless the sleep stands.
+func (s *Scanner) gateTimeBased(ctx context.Context, client transport.Client, req *transport.Request, baseline *transport.Response, c ResultCollector) (int, bool) {
+	sleep := s.config.TimeSec
+	if s.config.ForceTimeBased {
+		return sleep, true
+	}
+	if sleep <= 0 {
+		sleep = DefaultTimeSec
+	}
+
+	samples, err := measureLatency(ctx, client, req, baseline, s.config.TimeTotal)
+	if err != nil {
+		s.logger.Warn("latency measurement failed", "err", err)
+		return s.config.TimeSec, true
+	}
+	maxSleep := max(s.config.TimeSecMax, sleep)
+	d := EvaluateLatency(samples, sleep, maxSleep, s.config.TimeJitterFactor)
+	s.logger.Info("latency gate", "action", d.Action, "jitter", d.Jitter, "required", d.Required)
+
+	switch d.Action {
+	case TimingRaiseSleep:
+		msg := fmt.Sprintf("time-based: latency jitter %v needs a sleep of at least %v; raised the sleep from %ds to %ds",
+			d.Jitter.Round(time.Millisecond), d.Required.Round(time.Millisecond), sleep, d.SleepSeconds)
+		s.progress("%s", msg)
+		c.AddWarning(msg)
+		return d.SleepSeconds, true
+	case TimingDisabled:
+		msg := fmt.Sprintf("time-based: disabled, latency jitter %v needs a sleep of at least %v, over the %ds maximum; force it with --force-time-based",
+			d.Jitter.Round(time.Millisecond), d.Required.Round(time.Millisecond), maxSleep)
+		s.progress("%s", msg)
+		c.AddWarning(msg)
+		return 0, false
+	}
+	return s.config.TimeSec, true
+}
@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func ms(vals ...int) []time.Duration {
+	ds := make([]time.Duration, len(vals))
+	for i, v := range vals {
+		ds[i] = time.Duration(v) * time.Millisecond
+	}
+	return ds
+}
+
+func TestEvaluateLatency(t *testing.T) {
+	tests := []struct {
+		name      string
+		samples   []time.Duration
+		wantMode  TimingAction
+		wantSleep int
+	}{
+		// ~100ms with a couple of ms of jitter: a 5s sleep is unmistakable.
+		{"feasible", ms(98, 100, 102, 99, 101), TimingFeasible, 5},
+		// ~0.9s of jitter needs 6 stddevs (5.4s) of sleep: raised to 6s.
+		{"raise", ms(100, 2100, 300, 1900, 1100), TimingRaiseSleep, 6},
+		// Several seconds of jitter need more than the 15s ceiling.
+		{"hopeless", ms(100, 6000, 300, 9000, 2000), TimingDisabled, 5},
+		// A single sample has no spread to judge.
+		{"one sample", ms(4000), TimingFeasible, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := EvaluateLatency(tt.samples, 5, 15, 6)
+			if d.Action != tt.wantMode {
+				t.Errorf("Action = %v, want %v (jitter %v, required %v)", d.Action, tt.wantMode, d.Jitter, d.Required)
+			}
+			if d.SleepSeconds != tt.wantSleep {
+				t.Errorf("SleepSeconds = %d, want %d", d.SleepSeconds, tt.wantSleep)
+			}
+			if d.Action == TimingRaiseSleep && time.Duration(d.SleepSeconds)*time.Second < d.Required {
+				t.Errorf("raised sleep %ds is under the required %v", d.SleepSeconds, d.Required)
+			}
+		})
+	}
+}
+
+func TestEvaluateLatency_Factor(t *testing.T) {
+	samples := ms(100, 2100, 300, 1900, 1100)
+	if d := EvaluateLatency(samples, 5, 15, 2); d.Action != TimingFeasible {
+		t.Errorf("factor 2: Action = %v, want feasible", d.Action)
+	}
+	if d := EvaluateLatency(samples, 5, 5, 6); d.Action != TimingDisabled {
+		t.Errorf("max 5s: Action = %v, want disabled", d.Action)
+	}
+}
+
+func TestStdDev(t *testing.T) {
+	if got := stdDev(ms(2, 4, 4, 4, 5, 5, 7, 9)); got.Round(time.Microsecond) != 2138*time.Microsecond {
+		t.Errorf("stdDev = %v, want ~2.138ms", got)
+	}
+	if got := stdDev(nil); got != 0 {
+		t.Errorf("stdDev(nil) = %v, want 0", got)
+	}
+}
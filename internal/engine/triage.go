@@ -0,0 +1,192 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// Findings whose confidence falls in [triageMinConfidence,
+// triageMaxConfidence] are borderline: the timing-differential oracles and
+// single-signal decisions that take most of a human's triage time.
+const (
+	triageMinConfidence = 0.5
+	triageMaxConfidence = 0.8
+
+	// DefaultTriageRounds is how many times triage repeats a finding's
+	// decisive probes.
+	DefaultTriageRounds = 3
+)
+
+// ControlKind selects the probes a Controller sends.
+type ControlKind int
+
+const (
+	// ControlRepeat sends the finding's decisive probes as detection did.
+	ControlRepeat ControlKind = iota
+
+	// ControlNegative sends a deliberately broken variant of them, close
+	// to the original but invalid SQL wherever it lands, that a genuine
+	// injection does not answer.
+	ControlNegative
+)
+
+// String returns the control's name.
+func (k ControlKind) String() string {
+	if k == ControlNegative {
+		return "negative"
+	}
+	return "repeat"
+}
+
+// ControlRequest asks a technique to re-run the oracle behind one of its
+// findings. Parameter is the parameter probed: Finding's own, or for a
+// placebo control another one that heuristics found inert.
+type ControlRequest struct {
+	TechniqueRequest
+	Finding Vulnerability
+	Kind    ControlKind
+}
+
+// Controller is implemented by techniques whose findings triage can
+// re-check.
+type Controller interface {
+	// Control sends the probes of req.Kind and reports whether the
+	// technique's oracle fired as it did for the finding.
+	Control(ctx context.Context, req *ControlRequest) (fired bool, err error)
+}
+
+// TriageEvidence is the counter-evidence gathered for a borderline finding.
+type TriageEvidence struct {
+	InitialConfidence float64
+
+	Repeats      int // Repeats of the decisive probes, each after a neutral request
+	RepeatsFired int
+
+	NegativeRun   bool // A broken variant of the payload was sent
+	NegativeFired bool
+
+	Placebo      string // Inert parameter the payload was also sent in; empty if none
+	PlaceboFired bool
+
+	Downgraded bool     // The controls contradicted the finding
+	Errors     []string // Controls that could not run
+}
+
+// Summary describes the evidence in one line for reports.
+func (t *TriageEvidence) Summary() string {
+	parts := []string{fmt.Sprintf("reproduced %d/%d", t.RepeatsFired, t.Repeats)}
+	if t.NegativeRun {
+		parts = append(parts, "negative control "+firedWord(t.NegativeFired))
+	}
+	if t.Placebo != "" {
+		parts = append(parts, fmt.Sprintf("placebo %q %s", t.Placebo, firedWord(t.PlaceboFired)))
+	}
+	verdict := "confirmed"
+	if t.Downgraded {
+		verdict = "downgraded"
+	}
+	return fmt.Sprintf("%s (%s; confidence was %.0f%%)", verdict, strings.Join(parts, ", "), t.InitialConfidence*100)
+}
+
+func firedWord(fired bool) string {
+	if fired {
+		return "fired"
+	}
+	return "held"
+}
+
+// confidence recomputes the finding's confidence from the controls. Any
+// control firing, or the finding reproducing less than half the time,
+// collapses it; otherwise each passing control halves the remaining doubt,
+// the repeats in proportion to how often they reproduced.
+func (t *TriageEvidence) confidence() float64 {
+	rate := 0.0
+	if t.Repeats > 0 {
+		rate = float64(t.RepeatsFired) / float64(t.Repeats)
+	}
+	if t.NegativeFired || t.PlaceboFired || rate < 0.5 {
+		return t.InitialConfidence * rate * 0.5
+	}
+	doubt := (1 - t.InitialConfidence) * (1 - 0.5*rate)
+	if t.NegativeRun {
+		doubt *= 0.5
+	}
+	if t.Placebo != "" {
+		doubt *= 0.5
+	}
+	return 1 - doubt
+}
+
+// borderline reports whether v is an injectable finding whose confidence
+// calls for triage.
+func borderline(v Vulnerability) bool {
+	return v.Injectable && v.Confidence >= triageMinConfidence && v.Confidence <= triageMaxConfidence
+}
+
+// triage runs the counter-evidence protocol on a borderline finding made by
+// ctl: it repeats the decisive probes, each after a neutral request, sends
+// the negative control, and sends the payload in placebo, an inert
+// parameter, when there is one. It returns v with its confidence and
+// severity recomputed and the evidence attached. A finding the controls
+// contradict is no longer reported as injectable.
+func (s *Scanner) triage(ctx context.Context, ctl Controller, v Vulnerability, req TechniqueRequest, neutral *transport.Request, placebo *Parameter) Vulnerability {
+	rounds := s.config.TriageRounds
+	if rounds <= 0 {
+		rounds = DefaultTriageRounds
+	}
+	ev := &TriageEvidence{InitialConfidence: v.Confidence}
+
+	control := func(kind ControlKind, param *Parameter) (bool, bool) {
+		r := &ControlRequest{TechniqueRequest: req, Finding: v, Kind: kind}
+		r.Parameter = param
+		fired, err := ctl.Control(ctx, r)
+		if err != nil {
+			ev.Errors = append(ev.Errors, fmt.Sprintf("%s control on %q: %v", kind, param.Name, err))
+			return false, false
+		}
+		return fired, true
+	}
+
+	for range rounds {
+		// The neutral request puts ordinary traffic between repeats, so
+		// caching or rate effects of back-to-back probes cannot carry over.
+		if _, err := req.Client.Do(ctx, neutral); err != nil && ctx.Err() != nil {
+			break
+		}
+		fired, ok := control(ControlRepeat, req.Parameter)
+		if !ok {
+			continue
+		}
+		ev.Repeats++
+		if fired {
+			ev.RepeatsFired++
+		}
+	}
+	if fired, ok := control(ControlNegative, req.Parameter); ok {
+		ev.NegativeRun, ev.NegativeFired = true, fired
+	}
+	if placebo != nil {
+		if fired, ok := control(ControlRepeat, placebo); ok {
+			ev.Placebo, ev.PlaceboFired = placebo.Name, fired
+		}
+	}
+
+	if ev.Repeats == 0 {
+		// Nothing ran: keep the finding as detection reported it.
+		v.Triage = ev
+		return v
+	}
+	v.Confidence = ev.confidence()
+	ev.Downgraded = v.Confidence < triageMinConfidence
+	if ev.Downgraded {
+		v.Injectable = false
+		v.Severity = SeverityInfo
+	} else {
+		v.Severity = classifySeverity(v.Technique, v.Confidence)
+	}
+	v.Triage = ev
+	return v
+}
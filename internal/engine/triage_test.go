@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestTriageEvidence_Confidence(t *testing.T) {
+	tests := []struct {
+		name string
+		ev   TriageEvidence
+		want float64
+	}{
+		{"all controls pass", TriageEvidence{InitialConfidence: 0.7, Repeats: 3, RepeatsFired: 3, NegativeRun: true, Placebo: "page"}, 1 - 0.3*0.5*0.5*0.5},
+		{"no placebo available", TriageEvidence{InitialConfidence: 0.7, Repeats: 3, RepeatsFired: 3, NegativeRun: true}, 1 - 0.3*0.5*0.5},
+		{"reproduced 2 of 3", TriageEvidence{InitialConfidence: 0.6, Repeats: 3, RepeatsFired: 2, NegativeRun: true}, 1 - 0.4*(1-0.5*2.0/3)*0.5},
+		{"negative control fired", TriageEvidence{InitialConfidence: 0.7, Repeats: 3, RepeatsFired: 3, NegativeRun: true, NegativeFired: true}, 0.35},
+		{"placebo fired", TriageEvidence{InitialConfidence: 0.7, Repeats: 2, RepeatsFired: 2, Placebo: "page", PlaceboFired: true}, 0.35},
+		{"rarely reproduced", TriageEvidence{InitialConfidence: 0.8, Repeats: 3, RepeatsFired: 1, NegativeRun: true}, 0.8 / 3 * 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ev.confidence(); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("confidence() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// controllingTechnique is a fakeTechnique that supports triage controls.
+type controllingTechnique struct{ fakeTechnique }
+
+func (*controllingTechnique) Control(context.Context, *ControlRequest) (bool, error) {
+	return true, nil
+}
+
+func TestScanner_HeldForTriage(t *testing.T) {
+	techniques := WithTechniques(&controllingTechnique{fakeTechnique{name: "boolean-blind"}}, &fakeTechnique{name: "error-based"})
+	borderlineFinding := Vulnerability{Technique: "boolean-blind", Injectable: true, Confidence: 0.7}
+	tests := []struct {
+		name   string
+		triage bool
+		v      Vulnerability
+		want   bool
+	}{
+		{"borderline with controls", true, borderlineFinding, true},
+		{"triage off", false, borderlineFinding, false},
+		{"technique without controls", true, Vulnerability{Technique: "error-based", Injectable: true, Confidence: 0.7}, false},
+		{"confident", true, Vulnerability{Technique: "boolean-blind", Injectable: true, Confidence: 0.95}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultScanConfig()
+			cfg.Triage = tt.triage
+			s := NewScanner(nil, cfg, techniques)
+			if got := s.heldForTriage(tt.v); got != tt.want {
+				t.Errorf("heldForTriage = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBorderline(t *testing.T) {
+	tests := []struct {
+		v    Vulnerability
+		want bool
+	}{
+		{Vulnerability{Injectable: true, Confidence: 0.7}, true},
+		{Vulnerability{Injectable: true, Confidence: 0.5}, true},
+		{Vulnerability{Injectable: true, Confidence: 0.8}, true},
+		{Vulnerability{Injectable: true, Confidence: 0.85}, false},
+		{Vulnerability{Injectable: true, Confidence: 0.4}, false},
+		{Vulnerability{Injectable: false, Confidence: 0.7}, false},
+	}
+	for _, tt := range tests {
+		if got := borderline(tt.v); got != tt.want {
+			t.Errorf("borderline(%+v) = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+}
@@ -2,10 +2,18 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"regexp"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/0x6d61/sqleech/internal/payload"
+	"github.com/0x6d61/sqleech/internal/payloadlib"
 	"github.com/0x6d61/sqleech/internal/transport"
 )
 
@@ -15,109 +23,584 @@ type job struct {
 	technique Technique
 	baseline  *transport.Response
 	dbms      string
+	coverage  *payloadlib.Coverage
+	context   payloadlib.Context
+	state     *ParamState
+	sleep     int           // TechniqueRequest.SleepSeconds
+	timeout   time.Duration // TechniqueRequest.Timeout
+	deadline  time.Duration // Bound on the job's run, zero for none
+	level     int           // TechniqueRequest.Level
+	risk      int           // TechniqueRequest.Risk
+	enqueued  time.Time     // Set by submit, for queue-wait timing
+
+	// param, with --stop-at-first, is shared by the jobs of the parameter
+	// and cancelled through stopParam once one of them finds it
+	// injectable, which skips the queued jobs and cancels those running.
+	param     context.Context
+	stopParam context.CancelFunc
+
+	// TechniqueRequest.MatchString, NotMatchString and MatchRegexp
+	matchString    string
+	notMatchString string
+	matchRegexp    *regexp.Regexp
+}
+
+// The default ScanConfig.JobTimeout of a job, and of a time-based one,
+// whose sleep probes make it the longest by far.
+const (
+	DefaultJobTimeout          = 10 * time.Minute
+	DefaultTimeBasedJobTimeout = 30 * time.Minute
+)
+
+// errJobTimeout is recorded for a job that ran past its deadline, and
+// errJobPanic for one whose technique panicked.
+var (
+	errJobTimeout = errors.New("job timed out")
+	errJobPanic   = errors.New("technique panicked")
+)
+
+// maxOutageReruns bounds how often a decision invalidated by a target
+// outage is re-run before the job is given up.
+const maxOutageReruns = 2
+
+// errOutageUnresolved is reported for a job whose every run overlapped a
+// target outage window.
+var errOutageUnresolved = errors.New("decision overlapped a target outage on every run")
+
+// poolState is a stage of the worker pool lifecycle:
+// created -> running -> draining -> closed.
+type poolState int
+
+const (
+	poolCreated  poolState = iota // Workers not started
+	poolRunning                   // Accepting jobs
+	poolDraining                  // No new jobs; queued and in-flight jobs finishing
+	poolClosed                    // Workers exited, results channel closed
+)
+
+// String returns the state name.
+func (s poolState) String() string {
+	return [...]string{"created", "running", "draining", "closed"}[s]
 }
 
+// errPoolState is returned for a lifecycle call made in the wrong state.
+var errPoolState = errors.New("invalid worker pool state")
+
+// errJobAbandoned is recorded for a job cancelled or skipped by Drain.
+var errJobAbandoned = errors.New("abandoned at drain deadline")
+
+// stopFirstReason is the SkippedJob reason of a job skipped or cancelled
+// because its parameter was already found injectable.
+const stopFirstReason = "parameter already found injectable (--stop-at-first)"
+
+// errParamFound cancels the running jobs of a parameter found injectable.
+var errParamFound = errors.New("parameter already found injectable")
+
 // workerPool manages concurrent technique execution across multiple workers.
+//
+// Both channels are bounded by the worker count, so a slow results consumer
+// holds back the workers and, through the full jobs queue, the submitter.
+// Jobs run under contexts owned by the pool, not the caller's, so that on
+// cancellation Drain can let in-flight jobs finish before cancelling them.
 type workerPool struct {
 	workers int
 	jobs    chan job
 	results chan Vulnerability
+	done    chan struct{} // Closed once every worker has exited
 	wg      sync.WaitGroup
+
+	// outages, when set, invalidates decisions whose probes overlapped a
+	// target-unavailability window; healthProbe is the request used to
+	// detect recovery.
+	outages     *transport.OutageMonitor
+	healthProbe *transport.Request
+
+	// progress is passed to techniques as TechniqueRequest.Progress.
+	progress func(msg string)
+
+	// logger receives the failures of jobs, which are otherwise dropped.
+	logger *slog.Logger
+
+	// emit, when set, is sent EventTechniqueStart and EventTechniqueEnd
+	// around each job run.
+	emit func(Event)
+
+	// heldForTriage, when set, reports whether a finding is held for
+	// triage; such a finding does not stop its parameter (see
+	// ScanConfig.StopAtFirst).
+	heldForTriage func(Vulnerability) bool
+
+	// baselineCookies are the cookies the target had set when the
+	// baseline was taken, noted in the evidence of every finding.
+	baselineCookies string
+
+	jobCtx    context.Context // Parent of every job's context
+	cancelJob context.CancelFunc
+	skipQueue atomic.Bool // Set by Drain: queued jobs are abandoned unstarted
+
+	// lifeMu guards state. submit holds it while blocked on a full queue,
+	// so workers must never take it; stop, closed by close and Drain,
+	// releases a blocked submit.
+	lifeMu   sync.Mutex
+	state    poolState
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	mu         sync.Mutex
+	errs       []error // Jobs abandoned because of outages, refusals or Drain
+	skipped    []SkippedJob
+	timings    map[string]*TechniqueTiming
+	jobTimes   []JobTiming
+	unanswered map[string]int // Probes that got no response, by failure
 }
 
-// newWorkerPool creates a pool with the given number of workers.
-// The jobs channel is buffered at workers*2 to allow some pipelining.
+// newWorkerPool creates a pool with the given number of workers. Both
+// channels are buffered at the worker count.
 func newWorkerPool(workers int) *workerPool {
 	if workers <= 0 {
 		workers = 1
 	}
 	return &workerPool{
-		workers: workers,
-		jobs:    make(chan job, workers*2),
-		results: make(chan Vulnerability, workers*2),
+		workers:    workers,
+		jobs:       make(chan job, workers),
+		results:    make(chan Vulnerability, workers),
+		done:       make(chan struct{}),
+		stop:       make(chan struct{}),
+		timings:    make(map[string]*TechniqueTiming),
+		unanswered: make(map[string]int),
+		logger:     slog.New(slog.DiscardHandler),
 	}
 }
 
-// start launches all worker goroutines. Each worker reads jobs from the
+// start launches the worker goroutines. Each worker reads jobs from the
 // jobs channel, executes the technique's Detect method, and sends any
-// resulting Vulnerability to the results channel.
-func (p *workerPool) start(ctx context.Context, client transport.Client, target *ScanTarget) {
+// resulting Vulnerability to the results channel, which is closed once all
+// workers have exited. Values are inherited from ctx but not its
+// cancellation; see Drain.
+func (p *workerPool) start(ctx context.Context, client transport.Client, target *ScanTarget) error {
+	p.lifeMu.Lock()
+	defer p.lifeMu.Unlock()
+	if p.state != poolCreated {
+		return fmt.Errorf("start: %w: %s", errPoolState, p.state)
+	}
+	p.state = poolRunning
+	p.jobCtx, p.cancelJob = context.WithCancel(context.WithoutCancel(ctx))
+
 	for i := 0; i < p.workers; i++ {
 		p.wg.Add(1)
-		go p.worker(ctx, client, target)
+		go p.worker(client, target)
 	}
+	go func() {
+		p.wg.Wait()
+		p.cancelJob()
+		close(p.results)
+		p.lifeMu.Lock()
+		p.state = poolClosed
+		p.lifeMu.Unlock()
+		close(p.done)
+	}()
+	return nil
 }
 
 // worker is the main loop for a single worker goroutine.
-func (p *workerPool) worker(ctx context.Context, client transport.Client, target *ScanTarget) {
+func (p *workerPool) worker(client transport.Client, target *ScanTarget) {
 	defer p.wg.Done()
 
 	for j := range p.jobs {
-		// Recover from panics so one bad job does not crash the pool.
-		func() {
-			defer func() {
-				if r := recover(); r != nil {
-					slog.Error("worker recovered from panic",
-						"technique", j.technique.Name(),
-						"parameter", j.parameter.Name,
-						"panic", fmt.Sprintf("%v", r),
-					)
-				}
-			}()
-
-			// Check for context cancellation before running detection.
-			if ctx.Err() != nil {
-				return
-			}
-
-			req := &TechniqueRequest{
-				Target:    target,
-				Parameter: &j.parameter,
-				Baseline:  j.baseline,
-				DBMS:      j.dbms,
-				Client:    client,
-			}
-
-			result, err := j.technique.Detect(ctx, req)
-			if err != nil {
-				slog.Debug("technique detection error",
-					"technique", j.technique.Name(),
-					"parameter", j.parameter.Name,
-					"error", err,
-				)
-				return
-			}
-
-			vuln := Vulnerability{
-				Parameter:  j.parameter,
-				Technique:  j.technique.Name(),
-				DBMS:       j.dbms,
-				Injectable: result.Injectable,
-				Confidence: result.Confidence,
-				Evidence:   result.Evidence,
-				Payload:    result.Payload,
-			}
-
-			if result.Injectable {
-				vuln.Severity = classifySeverity(j.technique.Name(), result.Confidence)
-			}
-
-			p.results <- vuln
-		}()
-	}
-}
-
-// submit adds a job to the queue. It blocks if the jobs channel is full.
-func (p *workerPool) submit(j job) {
-	p.jobs <- j
-}
-
-// close signals that no more jobs will be submitted, then waits for all
-// workers to finish and closes the results channel.
-func (p *workerPool) close() {
+		if p.skipQueue.Load() {
+			p.abandon(j, errJobAbandoned)
+			continue
+		}
+		if j.param != nil && j.param.Err() != nil {
+			p.skip(j, stopFirstReason)
+			continue
+		}
+		p.run(client, target, j)
+	}
+}
+
+// run executes one job and delivers its result. The job runs under a
+// context bounded by its deadline; a job that runs past it, or whose
+// technique panics, is recorded among the job errors.
+func (p *workerPool) run(client transport.Client, target *ScanTarget, j job) {
+	started := time.Now()
+	var jobErr error // Why the job produced no verdict, for its timing
+	defer func() {
+		p.recordTiming(j, started.Sub(j.enqueued), time.Since(started), jobErr)
+	}()
+
+	// Recover from panics so one bad job does not crash the pool.
+	defer func() {
+		if r := recover(); r != nil {
+			p.logger.Error("worker recovered from panic",
+				"technique", j.technique.Name(),
+				"param", j.parameter.Name,
+				"panic", fmt.Sprintf("%v", r),
+			)
+			jobErr = fmt.Errorf("%w: %v", errJobPanic, r)
+			p.abandon(j, jobErr)
+			p.emitJob(Event{Kind: EventTechniqueEnd, Err: jobErr}, j)
+		}
+	}()
+
+	ctx := p.jobCtx
+	if j.param != nil {
+		var cancel context.CancelCauseFunc
+		ctx, cancel = context.WithCancelCause(ctx)
+		defer cancel(nil)
+		defer context.AfterFunc(j.param, func() { cancel(errParamFound) })()
+	}
+	if j.deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.deadline)
+		defer cancel()
+	}
+	protocol := &protocolRecorder{Client: client}
+	req := &TechniqueRequest{
+		Target:    target,
+		Parameter: &j.parameter,
+		Baseline:  j.baseline,
+		DBMS:      j.dbms,
+		Client:    &probeCounter{Client: protocol, pool: p, technique: j.technique.Name()},
+		Coverage:  j.coverage,
+		Context:   j.context,
+		State:     j.state,
+
+		SleepSeconds: j.sleep,
+		Timeout:      j.timeout,
+		Level:        j.level,
+		Risk:         j.risk,
+
+		MatchString:    j.matchString,
+		NotMatchString: j.notMatchString,
+		MatchRegexp:    j.matchRegexp,
+		Progress:       p.progress,
+	}
+
+	p.emitJob(Event{Kind: EventTechniqueStart}, j)
+	result, err := p.detect(ctx, j, req)
+	switch {
+	case p.jobCtx.Err() != nil:
+		// Force-cancelled by Drain: whatever the technique returned was
+		// decided on a cut-short probe sequence.
+		err = errJobAbandoned
+	case context.Cause(ctx) == errParamFound:
+		// A sibling found the parameter first: the job is cut short and
+		// counted as skipped.
+		jobErr = errParamFound
+		p.skip(j, stopFirstReason)
+		p.emitJob(Event{Kind: EventTechniqueEnd, Err: jobErr}, j)
+		return
+	case ctx.Err() != nil:
+		err = fmt.Errorf("%w after %s", errJobTimeout, j.deadline)
+		p.logger.Warn("job timed out",
+			"technique", j.technique.Name(),
+			"param", j.parameter.Name,
+			"timeout", j.deadline,
+		)
+	}
+	end := Event{Kind: EventTechniqueEnd, Err: err}
+	if result != nil && err == nil {
+		end.Injectable, end.Confidence = result.Injectable, result.Confidence
+		end.Payload = result.Payload
+	}
+	p.emitJob(end, j)
+	jobErr = err
+	var refused *payload.WriteViolation
+	if errors.Is(err, errJobAbandoned) || errors.Is(err, errJobTimeout) || errors.Is(err, errOutageUnresolved) ||
+		errors.Is(err, transport.ErrTargetUnavailable) || errors.As(err, &refused) {
+		p.abandon(j, err)
+		return
+	}
+	if err != nil {
+		p.logger.Warn("technique detection failed",
+			"technique", j.technique.Name(),
+			"param", j.parameter.Name,
+			"err", err,
+		)
+		return
+	}
+
+	vuln := Vulnerability{
+		Parameter:  j.parameter,
+		Technique:  j.technique.Name(),
+		DBMS:       j.dbms,
+		Injectable: result.Injectable,
+		Confidence: result.Confidence,
+		Evidence:   result.Evidence,
+		Payload:    result.Payload,
+		Protocol:   protocol.last(),
+	}
+
+	if result.Injectable {
+		if p.baselineCookies != "" {
+			vuln.Evidence += fmt.Sprintf(" [baseline cookies: %s]", p.baselineCookies)
+		}
+		vuln.Severity = classifySeverity(j.technique.Name(), result.Confidence)
+		if prefix, ok := j.state.Boundary(); ok {
+			vuln.Boundary = &prefix
+		}
+		if j.stopParam != nil && (p.heldForTriage == nil || !p.heldForTriage(vuln)) {
+			j.stopParam()
+		}
+	}
+
+	select {
+	case p.results <- vuln:
+	case <-p.jobCtx.Done():
+		jobErr = errJobAbandoned
+		p.abandon(j, jobErr)
+	}
+}
+
+// emitJob sends ev, about job j, to p.emit if set.
+func (p *workerPool) emitJob(ev Event, j job) {
+	if p.emit == nil {
+		return
+	}
+	ev.Parameter, ev.Technique, ev.DBMS = &j.parameter, j.technique.Name(), j.dbms
+	p.emit(ev)
+}
+
+// abandon records err as the reason job j produced no result.
+func (p *workerPool) abandon(j job, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.errs = append(p.errs, fmt.Errorf("%s on %q: %w", j.technique.Name(), j.parameter.Name, err))
+}
+
+// skip records job j as not run, for reason.
+func (p *workerPool) skip(j job, reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.skipped = append(p.skipped, SkippedJob{Parameter: j.parameter, Technique: j.technique.Name(), Reason: reason})
+}
+
+// recordTiming records the queue wait, execution time and error of job j,
+// and adds the times to its technique's totals.
+func (p *workerPool) recordTiming(j job, wait, exec time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	technique := j.technique.Name()
+	p.jobTimes = append(p.jobTimes, JobTiming{Parameter: j.parameter, Technique: technique, QueueWait: wait, Exec: exec, Err: err})
+	t, ok := p.timings[technique]
+	if !ok {
+		t = &TechniqueTiming{Technique: technique}
+		p.timings[technique] = t
+	}
+	t.Jobs++
+	t.QueueWait += wait
+	t.Exec += exec
+	t.MaxExec = max(t.MaxExec, exec)
+}
+
+// detect runs the job's technique. A decision whose probes overlapped a
+// target outage window is discarded: the worker waits until the health
+// probe sees the target recover, then runs the technique again. An outage
+// the technique's probes only made suspected is settled by the health
+// probe first, so that a filter answering payloads with 503 does not cost
+// the decision.
+func (p *workerPool) detect(ctx context.Context, j job, req *TechniqueRequest) (*DetectionResult, error) {
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		result, err := j.technique.Detect(ctx, req)
+		end := time.Now()
+		if p.outages == nil || ctx.Err() != nil {
+			return result, err
+		}
+		p.outages.Confirm(ctx, p.healthProbe)
+		if !p.outages.Overlaps(start, end) {
+			return result, err
+		}
+		if attempt == maxOutageReruns {
+			return nil, errOutageUnresolved
+		}
+
+		p.logger.Debug("decision overlapped target outage, re-running",
+			"technique", j.technique.Name(),
+			"param", j.parameter.Name,
+			"attempt", attempt+1,
+		)
+		if err := p.outages.WaitHealthy(ctx, p.healthProbe); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// jobErrors returns the errors of jobs abandoned because of outages,
+// read-only refusals or Drain. It must be called after the results channel
+// is drained.
+func (p *workerPool) jobErrors() []error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.errs
+}
+
+// skippedJobs returns the jobs the workers skipped without running. It
+// must be called after the results channel is drained.
+func (p *workerPool) skippedJobs() []SkippedJob {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.skipped
+}
+
+// probeCounter counts the probes of a job that got no response. A
+// technique moves on past a failed probe, so without it a target dropping
+// connections would go unnoticed.
+type probeCounter struct {
+	transport.Client
+	pool      *workerPool
+	technique string
+}
+
+func (c *probeCounter) Do(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	resp, err := c.Client.Do(ctx, req)
+	if err != nil && ctx.Err() == nil {
+		var refused *payload.WriteViolation
+		if !errors.As(err, &refused) && !errors.Is(err, transport.ErrTargetUnavailable) {
+			c.pool.recordUnanswered(c.technique, transport.ClassifyFailure(err), err)
+		}
+	}
+	return resp, err
+}
+
+// protocolRecorder notes the protocol responses come in, for the findings
+// decided on them.
+type protocolRecorder struct {
+	transport.Client
+	protocol atomic.Pointer[string]
+}
+
+func (c *protocolRecorder) Do(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	resp, err := c.Client.Do(ctx, req)
+	if err == nil && resp != nil && resp.Protocol != "" {
+		c.protocol.Store(&resp.Protocol)
+	}
+	return resp, err
+}
+
+// last returns the protocol of the last response, or "" before any.
+func (c *protocolRecorder) last() string {
+	if p := c.protocol.Load(); p != nil {
+		return *p
+	}
+	return ""
+}
+
+// recordUnanswered counts a probe that got no response.
+func (p *workerPool) recordUnanswered(technique string, f transport.Failure, err error) {
+	p.logger.Debug("probe got no response", "technique", technique, "failure", f, "err", err)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.unanswered[f.String()]++
+}
+
+// unansweredProbes returns the counts of probes that got no response, or
+// nil when every probe was answered. It must be called after the results
+// channel is drained.
+func (p *workerPool) unansweredProbes() map[string]int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.unanswered) == 0 {
+		return nil
+	}
+	return maps.Clone(p.unanswered)
+}
+
+// jobTimings returns the timings of every job run, in the order they
+// finished. It must be called after the results channel is drained.
+func (p *workerPool) jobTimings() []JobTiming {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.jobTimes
+}
+
+// techniqueTimings returns the per-technique timing totals sorted by
+// technique name. It must be called after the results channel is drained.
+func (p *workerPool) techniqueTimings() []TechniqueTiming {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]TechniqueTiming, 0, len(p.timings))
+	for _, t := range p.timings {
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, k int) bool { return out[i].Technique < out[k].Technique })
+	return out
+}
+
+// submit adds a job to the queue, blocking while the queue is full. It
+// fails if the pool is not running or ctx is cancelled first.
+func (p *workerPool) submit(ctx context.Context, j job) error {
+	// Holding the lock across the send keeps close from closing the jobs
+	// channel under it.
+	p.lifeMu.Lock()
+	defer p.lifeMu.Unlock()
+	if p.state != poolRunning {
+		return fmt.Errorf("submit: %w: %s", errPoolState, p.state)
+	}
+	j.enqueued = time.Now()
+	select {
+	case p.jobs <- j:
+		return nil
+	case <-p.stop:
+		return fmt.Errorf("submit: %w: pool stopped", errPoolState)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// stopSubmits releases any submit blocked on a full queue.
+func (p *workerPool) stopSubmits() {
+	p.stopOnce.Do(func() { close(p.stop) })
+}
+
+// close stops accepting jobs. Queued and in-flight jobs still run; the
+// results channel is closed once they finish. Closing a pool that is not
+// running returns an error.
+func (p *workerPool) close() error {
+	p.stopSubmits()
+	p.lifeMu.Lock()
+	defer p.lifeMu.Unlock()
+	if p.state != poolRunning {
+		return fmt.Errorf("close: %w: %s", errPoolState, p.state)
+	}
+	p.state = poolDraining
 	close(p.jobs)
-	p.wg.Wait()
-	close(p.results)
+	return nil
+}
+
+// Drain stops accepting jobs, abandons queued jobs that have not started,
+// and waits for in-flight jobs until ctx is done. Jobs still running then
+// have their contexts cancelled and are recorded as abandoned; Drain
+// returns ctx's error once their workers exit, or nil if every in-flight
+// job finished in time. Results of finished jobs are still delivered, so
+// the results channel must keep being consumed.
+func (p *workerPool) Drain(ctx context.Context) error {
+	p.skipQueue.Store(true)
+	p.stopSubmits()
+	p.lifeMu.Lock()
+	switch p.state {
+	case poolCreated:
+		p.state = poolClosed
+		close(p.jobs)
+		close(p.results)
+		close(p.done)
+		p.lifeMu.Unlock()
+		return nil
+	case poolRunning:
+		p.state = poolDraining
+		close(p.jobs)
+	}
+	p.lifeMu.Unlock()
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		p.cancelJob()
+		<-p.done
+		return ctx.Err()
+	}
 }
 
 // classifySeverity assigns a severity level based on technique and confidence.
@@ -0,0 +1,494 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeTechnique runs detect for every job; a nil detect returns an
+// injectable result at once.
+type fakeTechnique struct {
+	name   string
+	detect func(ctx context.Context) (*DetectionResult, error)
+	calls  atomic.Int64
+}
+
+func (f *fakeTechnique) Name() string  { return f.name }
+func (f *fakeTechnique) Priority() int { return 1 }
+func (f *fakeTechnique) Detect(ctx context.Context, _ *TechniqueRequest) (*DetectionResult, error) {
+	f.calls.Add(1)
+	if f.detect == nil {
+		return &DetectionResult{Injectable: true, Confidence: 0.9}, nil
+	}
+	return f.detect(ctx)
+}
+
+func startPool(t *testing.T, workers int) *workerPool {
+	t.Helper()
+	p := newWorkerPool(workers)
+	if err := p.start(context.Background(), nil, &ScanTarget{URL: "http://pool.test/"}); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	return p
+}
+
+func testJob(tech Technique, i int) job {
+	return job{parameter: Parameter{Name: fmt.Sprintf("p%d", i)}, technique: tech}
+}
+
+func TestWorkerPool_SlowConsumerBackpressure(t *testing.T) {
+	const workers, jobs = 4, 64
+	p := startPool(t, workers)
+	tech := &fakeTechnique{name: "fast"}
+
+	submitted := make(chan error, 1)
+	go func() {
+		defer p.close()
+		for i := 0; i < jobs; i++ {
+			if err := p.submit(context.Background(), testJob(tech, i)); err != nil {
+				submitted <- err
+				return
+			}
+		}
+		submitted <- nil
+	}()
+
+	// Without a consumer, the workers block on the results buffer and the
+	// submitter on the jobs buffer.
+	time.Sleep(50 * time.Millisecond)
+	if calls := tech.calls.Load(); calls > 2*workers+workers {
+		t.Errorf("ran %d jobs without a consumer, want at most %d", calls, 3*workers)
+	}
+	select {
+	case err := <-submitted:
+		t.Fatalf("submitter finished without a consumer: %v", err)
+	default:
+	}
+
+	got := 0
+	timeout := time.After(10 * time.Second)
+	for got < jobs {
+		select {
+		case _, ok := <-p.results:
+			if !ok {
+				t.Fatalf("results closed after %d of %d", got, jobs)
+			}
+			got++
+			time.Sleep(time.Millisecond)
+		case <-timeout:
+			t.Fatalf("deadlock: received %d of %d results", got, jobs)
+		}
+	}
+	if err := <-submitted; err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	<-p.done
+	if timings := p.techniqueTimings(); len(timings) != 1 || timings[0].Jobs != jobs {
+		t.Errorf("timings = %+v, want %d jobs for one technique", timings, jobs)
+	}
+}
+
+func TestWorkerPool_DrainDeadlineCancelsHungJob(t *testing.T) {
+	p := startPool(t, 2)
+	hung := &fakeTechnique{name: "hung", detect: func(ctx context.Context) (*DetectionResult, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+	quick := &fakeTechnique{name: "quick"}
+
+	for i, tech := range []Technique{hung, quick} {
+		if err := p.submit(context.Background(), testJob(tech, i)); err != nil {
+			t.Fatalf("submit: %v", err)
+		}
+	}
+	var results []Vulnerability
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for v := range p.results {
+			results = append(results, v)
+		}
+	}()
+	for hung.calls.Load() == 0 || quick.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := p.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Drain = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Drain took %v", elapsed)
+	}
+	<-collected
+
+	if len(results) != 1 || results[0].Technique != "quick" {
+		t.Errorf("results = %+v, want only the quick job", results)
+	}
+	errs := p.jobErrors()
+	if len(errs) != 1 || !errors.Is(errs[0], errJobAbandoned) {
+		t.Fatalf("job errors = %v, want one abandoned job", errs)
+	}
+	if timings := p.techniqueTimings(); len(timings) != 2 {
+		t.Errorf("timings = %+v, want both techniques", timings)
+	}
+}
+
+func TestWorkerPool_DrainSkipsQueuedJobs(t *testing.T) {
+	p := startPool(t, 1)
+	release := make(chan struct{})
+	blocking := &fakeTechnique{name: "blocking", detect: func(ctx context.Context) (*DetectionResult, error) {
+		<-release
+		return &DetectionResult{}, nil
+	}}
+	if err := p.submit(context.Background(), testJob(blocking, 0)); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	for blocking.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := p.submit(context.Background(), testJob(blocking, 1)); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+
+	go func() {
+		for range p.results {
+		}
+	}()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+	}()
+	if err := p.Drain(context.Background()); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	if calls := blocking.calls.Load(); calls != 1 {
+		t.Errorf("ran %d jobs, want the queued one skipped", calls)
+	}
+	if errs := p.jobErrors(); len(errs) != 1 || !errors.Is(errs[0], errJobAbandoned) {
+		t.Errorf("job errors = %v, want the queued job abandoned", errs)
+	}
+}
+
+func TestWorkerPool_StopAtFirstSkipsFoundParameter(t *testing.T) {
+	p := startPool(t, 1)
+	found := &fakeTechnique{name: "found"}
+	later := &fakeTechnique{name: "later"}
+
+	id, stopID := context.WithCancel(context.Background())
+	defer stopID()
+	cat, stopCat := context.WithCancel(context.Background())
+	defer stopCat()
+	jobs := []job{
+		{parameter: Parameter{Name: "id"}, technique: found, param: id, stopParam: stopID},
+		{parameter: Parameter{Name: "id"}, technique: later, param: id, stopParam: stopID},
+		{parameter: Parameter{Name: "cat"}, technique: later, param: cat, stopParam: stopCat},
+	}
+	go func() {
+		defer p.close()
+		for _, j := range jobs {
+			if err := p.submit(context.Background(), j); err != nil {
+				return
+			}
+		}
+	}()
+	results := 0
+	for range p.results {
+		results++
+	}
+
+	if results != 2 || later.calls.Load() != 1 {
+		t.Errorf("got %d results, later ran %d times; want 2 and once, on cat", results, later.calls.Load())
+	}
+	skipped := p.skippedJobs()
+	if len(skipped) != 1 || skipped[0].Parameter.Name != "id" || skipped[0].Technique != "later" {
+		t.Errorf("skipped jobs = %+v, want later on id", skipped)
+	}
+}
+
+// TestWorkerPool_StopAtFirstCancelsRunningJobs runs every job at once, at
+// the default thread count. Both findings are borderline, but only held's
+// technique supports triage: the finding on id cancels the job still
+// running on it, while the one on cat, held for triage, leaves its
+// sibling to finish.
+func TestWorkerPool_StopAtFirstCancelsRunningJobs(t *testing.T) {
+	p := startPool(t, DefaultScanConfig().Threads)
+	p.heldForTriage = func(v Vulnerability) bool { return borderline(v) && v.Technique == "held" }
+	release := make(chan struct{})
+	slow := &fakeTechnique{name: "slow", detect: func(ctx context.Context) (*DetectionResult, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-release:
+			return &DetectionResult{}, nil
+		}
+	}}
+	// Both decide once the slow jobs are running.
+	started := func(r *DetectionResult) func(context.Context) (*DetectionResult, error) {
+		return func(context.Context) (*DetectionResult, error) {
+			for slow.calls.Load() < 2 {
+				time.Sleep(time.Millisecond)
+			}
+			return r, nil
+		}
+	}
+	found := &fakeTechnique{name: "found", detect: started(&DetectionResult{Injectable: true, Confidence: 0.6})}
+	held := &fakeTechnique{name: "held", detect: started(&DetectionResult{Injectable: true, Confidence: 0.6})}
+
+	id, stopID := context.WithCancel(context.Background())
+	defer stopID()
+	cat, stopCat := context.WithCancel(context.Background())
+	defer stopCat()
+	jobs := []job{
+		{parameter: Parameter{Name: "id"}, technique: slow, param: id, stopParam: stopID},
+		{parameter: Parameter{Name: "cat"}, technique: slow, param: cat, stopParam: stopCat},
+		{parameter: Parameter{Name: "id"}, technique: found, param: id, stopParam: stopID},
+		{parameter: Parameter{Name: "cat"}, technique: held, param: cat, stopParam: stopCat},
+	}
+	go func() {
+		defer p.close()
+		for _, j := range jobs {
+			if err := p.submit(context.Background(), j); err != nil {
+				return
+			}
+		}
+	}()
+	go func() {
+		select {
+		case <-id.Done():
+			time.Sleep(20 * time.Millisecond)
+		case <-time.After(time.Second):
+		}
+		close(release)
+	}()
+	var got []string
+	for v := range p.results {
+		got = append(got, v.Technique+" on "+v.Parameter.Name)
+	}
+
+	slices.Sort(got)
+	if want := []string{"found on id", "held on cat", "slow on cat"}; !slices.Equal(got, want) {
+		t.Errorf("results = %q, want %q", got, want)
+	}
+	skipped := p.skippedJobs()
+	if len(skipped) != 1 || skipped[0].Parameter.Name != "id" || skipped[0].Technique != "slow" {
+		t.Errorf("skipped jobs = %+v, want slow on id", skipped)
+	}
+	if errs := p.jobErrors(); len(errs) != 0 {
+		t.Errorf("job errors = %v, want none", errs)
+	}
+}
+
+func TestWorkerPool_PanicAndTimeout(t *testing.T) {
+	p := startPool(t, 2)
+	panicking := &fakeTechnique{name: "panicking", detect: func(ctx context.Context) (*DetectionResult, error) {
+		panic("index out of range")
+	}}
+	hanging := &fakeTechnique{name: "hanging", detect: func(ctx context.Context) (*DetectionResult, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+	fine := &fakeTechnique{name: "fine"}
+
+	go func() {
+		defer p.close()
+		for i, tech := range []Technique{panicking, hanging, fine} {
+			j := testJob(tech, i)
+			j.deadline = 20 * time.Millisecond
+			if err := p.submit(context.Background(), j); err != nil {
+				return
+			}
+		}
+	}()
+	results := 0
+	for range p.results {
+		results++
+	}
+
+	if results != 1 {
+		t.Errorf("got %d results, want the fine job's only", results)
+	}
+	var panicked, timedOut bool
+	for _, err := range p.jobErrors() {
+		panicked = panicked || errors.Is(err, errJobPanic) && strings.Contains(err.Error(), `panicking on "p0"`)
+		timedOut = timedOut || errors.Is(err, errJobTimeout) && strings.Contains(err.Error(), `hanging on "p1"`)
+	}
+	if !panicked || !timedOut {
+		t.Errorf("job errors = %v, want the panic and the timeout, naming technique and parameter", p.jobErrors())
+	}
+	timings := make(map[string]JobTiming)
+	for _, jt := range p.jobTimings() {
+		timings[jt.Technique] = jt
+	}
+	if jt := timings["hanging"]; !errors.Is(jt.Err, errJobTimeout) || jt.Exec < 20*time.Millisecond {
+		t.Errorf("hanging job timing = %+v, want the timeout after at least its deadline", jt)
+	}
+	if jt := timings["panicking"]; !errors.Is(jt.Err, errJobPanic) {
+		t.Errorf("panicking job timing = %+v, want the panic", jt)
+	}
+	if jt, ok := timings["fine"]; !ok || jt.Err != nil {
+		t.Errorf("fine job timing = %+v, %v, want one without error", jt, ok)
+	}
+}
+
+func TestWorkerPool_Lifecycle(t *testing.T) {
+	p := newWorkerPool(2)
+	tech := &fakeTechnique{name: "fast"}
+	target := &ScanTarget{URL: "http://pool.test/"}
+
+	if err := p.submit(context.Background(), testJob(tech, 0)); !errors.Is(err, errPoolState) {
+		t.Errorf("submit before start = %v, want state error", err)
+	}
+	if err := p.close(); !errors.Is(err, errPoolState) {
+		t.Errorf("close before start = %v, want state error", err)
+	}
+	if err := p.start(context.Background(), nil, target); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if err := p.start(context.Background(), nil, target); !errors.Is(err, errPoolState) {
+		t.Errorf("second start = %v, want state error", err)
+	}
+	if err := p.close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if err := p.close(); !errors.Is(err, errPoolState) {
+		t.Errorf("double close = %v, want state error", err)
+	}
+	if err := p.submit(context.Background(), testJob(tech, 1)); !errors.Is(err, errPoolState) {
+		t.Errorf("submit after close = %v, want state error", err)
+	}
+	for range p.results {
+	}
+	if err := p.Drain(context.Background()); err != nil {
+		t.Errorf("Drain after close = %v", err)
+	}
+	if err := p.close(); !errors.Is(err, errPoolState) {
+		t.Errorf("close after drain = %v, want state error", err)
+	}
+}
+
+func TestWorkerPool_DrainBeforeStart(t *testing.T) {
+	p := newWorkerPool(2)
+	if err := p.Drain(context.Background()); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	if _, ok := <-p.results; ok {
+		t.Error("results channel still open")
+	}
+	if err := p.start(context.Background(), nil, &ScanTarget{}); !errors.Is(err, errPoolState) {
+		t.Errorf("start after drain = %v, want state error", err)
+	}
+}
+
+func TestWorkerPool_SubmitUnblockedByDrain(t *testing.T) {
+	p := startPool(t, 1)
+	release := make(chan struct{})
+	blocking := &fakeTechnique{name: "blocking", detect: func(ctx context.Context) (*DetectionResult, error) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return &DetectionResult{}, nil
+	}}
+
+	// One job running and one queued leave the next submit blocked.
+	submitErr := make(chan error, 1)
+	go func() {
+		for i := 0; ; i++ {
+			if err := p.submit(context.Background(), testJob(blocking, i)); err != nil {
+				submitErr <- err
+				return
+			}
+		}
+	}()
+	go func() {
+		for range p.results {
+		}
+	}()
+	for blocking.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Drain = %v, want deadline exceeded", err)
+	}
+	select {
+	case err := <-submitErr:
+		if !errors.Is(err, errPoolState) {
+			t.Errorf("blocked submit = %v, want state error", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("submit still blocked after Drain")
+	}
+	close(release)
+}
+
+// TestWorkerPool_Stress runs many jobs through 32 workers with a slow
+// consumer and a concurrent drain; run it with -race.
+func TestWorkerPool_Stress(t *testing.T) {
+	const workers, jobs = 32, 2000
+	for _, drain := range []bool{false, true} {
+		t.Run(fmt.Sprintf("drain=%v", drain), func(t *testing.T) {
+			p := startPool(t, workers)
+			techs := []*fakeTechnique{
+				{name: "a"},
+				{name: "b", detect: func(ctx context.Context) (*DetectionResult, error) {
+					select {
+					case <-time.After(100 * time.Microsecond):
+					case <-ctx.Done():
+					}
+					return &DetectionResult{}, nil
+				}},
+			}
+
+			go func() {
+				defer p.close()
+				for i := 0; i < jobs; i++ {
+					if p.submit(context.Background(), testJob(techs[i%2], i)) != nil {
+						return
+					}
+				}
+			}()
+			if drain {
+				go func() {
+					time.Sleep(5 * time.Millisecond)
+					ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+					defer cancel()
+					_ = p.Drain(ctx)
+				}()
+			}
+
+			got := 0
+			for range p.results {
+				got++
+				if got%100 == 0 {
+					time.Sleep(time.Millisecond)
+				}
+			}
+			abandoned := len(p.jobErrors())
+			if !drain && (got != jobs || abandoned != 0) {
+				t.Errorf("got %d results and %d abandoned, want %d results", got, abandoned, jobs)
+			}
+			if got+abandoned > jobs {
+				t.Errorf("got %d results and %d abandoned for %d jobs", got, abandoned, jobs)
+			}
+			ran := 0
+			for _, tt := range p.techniqueTimings() {
+				ran += tt.Jobs
+			}
+			if int64(ran) != techs[0].calls.Load()+techs[1].calls.Load() {
+				t.Errorf("timings count %d jobs, techniques ran %d", ran, techs[0].calls.Load()+techs[1].calls.Load())
+			}
+		})
+	}
+}
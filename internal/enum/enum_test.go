@@ -0,0 +1,376 @@
+package enum
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/0x6d61/sqleech/internal/dbms"
+	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/jsonpath"
+)
+
+// catalogExtractor answers the queries the Enumerator sends from a fixed
+// list of rows, costing two requests apiece. After cancelAfter row reads
+// (when set) it cancels the context.
+type catalogExtractor struct {
+	rows        map[string][]string // list query -> rows
+	cancel      context.CancelFunc
+	cancelAfter int
+	reads       int
+}
+
+func (c *catalogExtractor) Extract(_ context.Context, _ *engine.ScanTarget, _ engine.Vulnerability, query string) (*engine.ExtractionResult, error) {
+	for list, rows := range c.rows {
+		if query == fmt.Sprintf("SELECT COUNT(*) FROM (%s) t", list) {
+			return &engine.ExtractionResult{Value: fmt.Sprint(len(rows)), Requests: 2}, nil
+		}
+		for i, row := range rows {
+			if query == fmt.Sprintf("%s ORDER BY 1 LIMIT 1 OFFSET %d", list, i) {
+				c.reads++
+				if c.cancel != nil && c.reads == c.cancelAfter {
+					c.cancel()
+				}
+				return &engine.ExtractionResult{Value: row, Requests: 2}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("unexpected query %q", query)
+}
+
+func TestNew_UnknownDBMS(t *testing.T) {
+	_, err := New(&catalogExtractor{}, &engine.ScanTarget{}, engine.Vulnerability{DBMS: "Informix"})
+	if !errors.Is(err, ErrUnknownDBMS) {
+		t.Errorf("New error = %v, want ErrUnknownDBMS", err)
+	}
+}
+
+func TestEnumerator_Identity(t *testing.T) {
+	ext := queryExtractor{
+		"TO_CHAR((SELECT banner FROM v$version WHERE rownum=1))": "Oracle Database 19c",
+		"TO_CHAR((USER))":                     "SCOTT",
+		"TO_CHAR((ORA_DATABASE_NAME))":        "ORCL",
+		"TO_CHAR((UTL_INADDR.GET_HOST_NAME))": "db01",
+	}
+	en, err := New(ext, &engine.ScanTarget{}, engine.Vulnerability{DBMS: "Oracle"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name string
+		read func(context.Context) (string, error)
+		want string
+	}{
+		{"Banner", en.Banner, "Oracle Database 19c"},
+		{"CurrentUser", en.CurrentUser, "SCOTT"},
+		{"CurrentDB", en.CurrentDB, "ORCL"},
+		{"Hostname", en.Hostname, "db01"},
+	} {
+		if got, err := tc.read(ctx); err != nil || got != tc.want {
+			t.Errorf("%s = %q, %v; want %q", tc.name, got, err, tc.want)
+		}
+	}
+}
+
+func TestEnumerator_Lists(t *testing.T) {
+	ext := &catalogExtractor{rows: map[string][]string{
+		"SELECT schema_name FROM information_schema.schemata":                                                 {"information_schema", "shop"},
+		"SELECT table_name FROM information_schema.tables WHERE table_schema='shop'":                          {"products", "users"},
+		"SELECT column_name FROM information_schema.columns WHERE table_schema='shop' AND table_name='users'": {"id", "password"},
+	}}
+	en, err := New(ext, &engine.ScanTarget{}, engine.Vulnerability{DBMS: "MySQL"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	dbs, err := en.Databases(ctx)
+	if err != nil || !slices.Equal(dbs, []string{"information_schema", "shop"}) {
+		t.Errorf("Databases = %q, %v", dbs, err)
+	}
+	tables, err := en.Tables(ctx, "shop")
+	if err != nil || !slices.Equal(tables, []string{"products", "users"}) {
+		t.Errorf("Tables = %q, %v", tables, err)
+	}
+	cols, err := en.Columns(ctx, "shop", "users")
+	if err != nil || !slices.Equal(cols, []string{"id", "password"}) {
+		t.Errorf("Columns = %q, %v", cols, err)
+	}
+	// Three counts and six rows at two requests each.
+	if en.Requests() != 18 {
+		t.Errorf("Requests() = %d, want 18", en.Requests())
+	}
+}
+
+func TestEnumerator_Search(t *testing.T) {
+	ext := &catalogExtractor{rows: map[string][]string{
+		"SELECT CONCAT(table_schema,'.',table_name) FROM information_schema.tables WHERE LOWER(table_name) LIKE '%user%'": {
+			"mysql.user", "shop.users",
+		},
+		"SELECT CONCAT(table_schema,'.',table_name,'.',column_name) FROM information_schema.columns WHERE LOWER(column_name) LIKE '%pass%'": {
+			"shop.members.passphrase", "shop.users.password", "shop.users.password_reset",
+		},
+	}}
+	en, err := New(ext, &engine.ScanTarget{}, engine.Vulnerability{DBMS: "MySQL"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	tables, err := en.SearchTables(ctx, "USER")
+	if err != nil || !slices.Equal(tables, []string{"mysql.user", "shop.users"}) {
+		t.Errorf("SearchTables = %q, %v", tables, err)
+	}
+	cols, err := en.SearchColumns(ctx, "pass")
+	if err != nil || !slices.Equal(cols, []string{"shop.members.passphrase", "shop.users.password", "shop.users.password_reset"}) {
+		t.Errorf("SearchColumns = %q, %v", cols, err)
+	}
+	// Two counts and five rows, each page one row.
+	if ext.reads != 5 || en.Requests() != 14 {
+		t.Errorf("reads = %d, Requests() = %d; want 5 and 14", ext.reads, en.Requests())
+	}
+}
+
+func TestEnumerator_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ext := &catalogExtractor{
+		rows:        map[string][]string{"SELECT schema_name FROM information_schema.schemata": {"a", "b", "c"}},
+		cancel:      cancel,
+		cancelAfter: 1,
+	}
+	en, err := New(ext, &engine.ScanTarget{}, engine.Vulnerability{DBMS: "MySQL"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	dbs, err := en.Databases(ctx)
+	if !slices.Equal(dbs, []string{"a"}) {
+		t.Errorf("Databases = %q, want the row read before cancellation", dbs)
+	}
+	var partial *PartialError
+	if !errors.As(err, &partial) {
+		t.Fatalf("error = %v, want a *PartialError", err)
+	}
+	if partial.Read != 1 || partial.Total != 3 || partial.Requests != 4 || !errors.Is(err, context.Canceled) {
+		t.Errorf("PartialError = %+v, want 1 of 3 rows in 4 requests, cancelled", partial)
+	}
+}
+
+func TestEnumerator_CountFailure(t *testing.T) {
+	en, err := New(&catalogExtractor{}, &engine.ScanTarget{}, engine.Vulnerability{DBMS: "PostgreSQL"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	dbs, err := en.Databases(context.Background())
+	var partial *PartialError
+	if len(dbs) != 0 || !errors.As(err, &partial) || partial.Total != 0 {
+		t.Errorf("Databases = %q, %v; want no rows and a PartialError", dbs, err)
+	}
+}
+
+// queryExtractor answers each query from a fixed map, at one request each.
+type queryExtractor map[string]string
+
+func (q queryExtractor) Extract(_ context.Context, _ *engine.ScanTarget, _ engine.Vulnerability, query string) (*engine.ExtractionResult, error) {
+	v, ok := q[query]
+	if !ok {
+		return nil, fmt.Errorf("unexpected query %q", query)
+	}
+	return &engine.ExtractionResult{Value: v, Requests: 1}, nil
+}
+
+func TestEnumerator_DumpTable(t *testing.T) {
+	row := `SELECT COALESCE(CAST("a" AS TEXT),'NULL:s9')||'|:s9:|'||COALESCE(CAST("b" AS TEXT),'NULL:s9') FROM "t" ORDER BY 1`
+	hexRow := `SELECT COALESCE(encode(convert_to(CAST("a" AS TEXT),'UTF8'),'hex'),'NULL:s9')||'|:s9:|'||COALESCE(encode(convert_to(CAST("b" AS TEXT),'UTF8'),'hex'),'NULL:s9') FROM "t" ORDER BY 1`
+	ext := queryExtractor{
+		`SELECT COUNT(*) FROM "t"`:   "2",
+		row + " LIMIT 1 OFFSET 0":    "x|:s9:|NULL:s9",
+		row + " LIMIT 1 OFFSET 1":    "y|:s9:|p|:s9:|q",
+		hexRow + " LIMIT 1 OFFSET 1": "79|:s9:|707c3a73393a7c71",
+	}
+	en, err := New(ext, &engine.ScanTarget{}, engine.Vulnerability{DBMS: "PostgreSQL"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := en.DumpTable(context.Background(), "public", "t", []string{"a", "b"}, &buf); err != nil {
+		t.Fatalf("DumpTable: %v", err)
+	}
+	if want := "a,b\nx,\ny,p|:s9:|q\n"; buf.String() != want {
+		t.Errorf("DumpTable wrote %q, want %q", buf.String(), want)
+	}
+	if en.Requests() != 4 {
+		t.Errorf("Requests() = %d, want 4", en.Requests())
+	}
+}
+
+func TestEnumerator_DumpFilter(t *testing.T) {
+	row := `SELECT COALESCE(CAST("a" AS TEXT),'NULL:s9')||'|:s9:|'||COALESCE(CAST("b" AS TEXT),'NULL:s9') FROM "t" ORDER BY 1`
+	ext := queryExtractor{
+		`SELECT COUNT(*) FROM "t"`: "1",
+		row + " LIMIT 1 OFFSET 0":  `1|:s9:|{"mail":"a@b.test"}`,
+	}
+	en, err := New(ext, &engine.ScanTarget{}, engine.Vulnerability{DBMS: "PostgreSQL"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	f, err := jsonpath.Parse("$.mail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := en.Dump(context.Background(), "public", "t", []string{"a", "b"}, DumpOptions{Filter: f}, &buf); err != nil {
+		t.Fatalf("Dump: %v", err)
+	}
+	// The filter selects from the JSON field; the other is written raw.
+	if want := "a,b\n1,a@b.test\n"; buf.String() != want {
+		t.Errorf("Dump wrote %q, want %q", buf.String(), want)
+	}
+}
+
+// fixedExtractor answers every query with value.
+type fixedExtractor string
+
+func (f fixedExtractor) Extract(context.Context, *engine.ScanTarget, engine.Vulnerability, string) (*engine.ExtractionResult, error) {
+	return &engine.ExtractionResult{Value: string(f), Requests: 1}, nil
+}
+
+func TestEnumerator_IsDBA(t *testing.T) {
+	tests := []struct {
+		name, value string
+		want        bool
+	}{
+		{"MySQL", "Y", true},
+		{"MySQL", "N", false},
+		{"MySQL", "1", true},
+		{"MySQL", "0", false},
+		{"MSSQL", "1", true},
+		{"PostgreSQL", "on", true},
+		{"PostgreSQL", "off", false},
+		{"PostgreSQL", "true", true},
+		{"Oracle", "2", true},
+	}
+	for _, tt := range tests {
+		en, err := New(fixedExtractor(tt.value), &engine.ScanTarget{}, engine.Vulnerability{DBMS: tt.name})
+		if err != nil {
+			t.Fatalf("New(%s): %v", tt.name, err)
+		}
+		got, err := en.IsDBA(context.Background())
+		if err != nil {
+			t.Errorf("%s %q: IsDBA error: %v", tt.name, tt.value, err)
+			continue
+		}
+		if got.IsDBA != tt.want || got.Value != tt.value || got.Query != dbms.Registry(tt.name).IsDBAQuery() {
+			t.Errorf("%s %q: IsDBA = %+v, want %v", tt.name, tt.value, got, tt.want)
+		}
+	}
+
+	en, err := New(fixedExtractor("<html>"), &engine.ScanTarget{}, engine.Vulnerability{DBMS: "MySQL"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := en.IsDBA(context.Background()); err == nil {
+		t.Error("IsDBA of a non-boolean value: want an error")
+	}
+}
+
+func TestEnumerator_Query(t *testing.T) {
+	ext := &catalogExtractor{rows: map[string][]string{
+		"SELECT * FROM (SELECT username FROM users WHERE id<3) q": {"admin", "guest"},
+	}}
+	en, err := New(ext, &engine.ScanTarget{}, engine.Vulnerability{DBMS: "MySQL"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	rows, err := en.Query(context.Background(), " SELECT username FROM users WHERE id<3;")
+	if err != nil || !slices.Equal(rows, []string{"admin", "guest"}) {
+		t.Errorf("Query(SELECT) = %q, %v", rows, err)
+	}
+
+	en, err = New(queryExtractor{"CAST((@@version) AS CHAR)": "8.0.32"}, &engine.ScanTarget{}, engine.Vulnerability{DBMS: "MySQL"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	rows, err = en.Query(context.Background(), "@@version")
+	if err != nil || !slices.Equal(rows, []string{"8.0.32"}) {
+		t.Errorf("Query(@@version) = %q, %v", rows, err)
+	}
+}
+
+func TestEnumerator_FileRead(t *testing.T) {
+	ext := queryExtractor{
+		"CAST((HEX(LOAD_FILE('/etc/passwd'))) AS CHAR)":                    "726F6F743A783A303A300A",
+		"CAST((HEX(LOAD_FILE('/var/www/logo.png'))) AS CHAR)":              "89504E470D0A1A0A00FF",
+		"CAST((HEX(LOAD_FILE('/root/.ssh/id_rsa'))) AS CHAR)":              "",
+		"CAST((encode(pg_read_binary_file('/etc/passwd'),'hex')) AS TEXT)": "726f6f743a783a303a300a",
+	}
+	tests := []struct {
+		dbms, path string
+		want       []byte
+	}{
+		{"MySQL", "/etc/passwd", []byte("root:x:0:0\n")},
+		{"MariaDB", "/var/www/logo.png", []byte("\x89PNG\r\n\x1a\n\x00\xff")},
+		{"PostgreSQL", "/etc/passwd", []byte("root:x:0:0\n")},
+	}
+	for _, tt := range tests {
+		en, err := New(ext, &engine.ScanTarget{}, engine.Vulnerability{DBMS: tt.dbms})
+		if err != nil {
+			t.Fatalf("New(%s): %v", tt.dbms, err)
+		}
+		got, err := en.FileRead(context.Background(), tt.path)
+		if err != nil || !bytes.Equal(got, tt.want) {
+			t.Errorf("%s FileRead(%s) = %q, %v; want %q", tt.dbms, tt.path, got, err, tt.want)
+		}
+	}
+
+	en, err := New(ext, &engine.ScanTarget{}, engine.Vulnerability{DBMS: "MySQL"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := en.FileRead(context.Background(), "/root/.ssh/id_rsa"); !errors.Is(err, ErrFileUnreadable) {
+		t.Errorf("FileRead of an unreadable file: error = %v, want ErrFileUnreadable", err)
+	}
+	for _, name := range []string{"MSSQL", "CockroachDB", "SQLite"} {
+		en, err := New(ext, &engine.ScanTarget{}, engine.Vulnerability{DBMS: name})
+		if err != nil {
+			t.Fatalf("New(%s): %v", name, err)
+		}
+		if _, err := en.FileRead(context.Background(), "/etc/passwd"); !errors.Is(err, ErrFileReadUnsupported) {
+			t.Errorf("%s FileRead: error = %v, want ErrFileReadUnsupported", name, err)
+		}
+	}
+}
+
+func TestRowRange(t *testing.T) {
+	tests := []struct {
+		r          RowRange
+		valid      bool
+		first, end int // Of 10 rows
+	}{
+		{RowRange{}, true, 0, 10},
+		{RowRange{Start: 3, Stop: 5}, true, 2, 5},
+		{RowRange{Start: 4, Stop: 4}, true, 3, 4},
+		{RowRange{Start: 8}, true, 7, 10},
+		{RowRange{Stop: 2}, true, 0, 2},
+		{RowRange{Start: 9, Stop: 50}, true, 8, 10},
+		{RowRange{Start: 12, Stop: 15}, true, 10, 10},
+		{RowRange{Start: 5, Stop: 3}, false, 0, 0},
+		{RowRange{Start: -1}, false, 0, 0},
+	}
+	for _, tt := range tests {
+		if err := tt.r.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%+v: Validate() = %v, want valid %v", tt.r, err, tt.valid)
+		}
+		if !tt.valid {
+			continue
+		}
+		if first, end := tt.r.bounds(10); first != tt.first || end != tt.end {
+			t.Errorf("%+v: bounds(10) = %d, %d; want %d, %d", tt.r, first, end, tt.first, tt.end)
+		}
+	}
+}
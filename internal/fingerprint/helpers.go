@@ -1,11 +1,16 @@
 package fingerprint
 
 import (
+	"bytes"
 	"context"
 	"net/url"
 
 	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/graphql"
+	"github.com/0x6d61/sqleech/internal/rawquery"
+	"github.com/0x6d61/sqleech/internal/reqparam"
 	"github.com/0x6d61/sqleech/internal/transport"
+	"github.com/0x6d61/sqleech/internal/xmlbody"
 )
 
 // sendProbe sends a request with a modified parameter value and returns the response.
@@ -41,49 +46,83 @@ func buildRequest(target *engine.ScanTarget, param *engine.Parameter, payload st
 		}
 	}
 
+	payload = param.Encode(payload)
 	switch param.Location {
 	case engine.LocationQuery:
 		req.URL = modifyQueryParam(target.URL, param.Name, payload)
 	case engine.LocationBody:
 		req.Body = modifyBodyParam(target.Body, param.Name, payload)
+	case engine.LocationPath:
+		req.URL = reqparam.SetPath(target.URL, param.Name, payload)
+	case engine.LocationCookie:
+		req.Cookies = reqparam.SetCookie(req.Cookies, param.Name, payload)
+	case engine.LocationHeader:
+		req.Headers = reqparam.SetHeader(req.Headers, param.Name, payload)
+	case engine.LocationXML:
+		req.Body = modifyXMLParam(target.Body, param.Name, payload, target.RawXML)
+	case engine.LocationGraphQL:
+		req.Body = modifyGraphQLParam(target.Body, param.Name, payload)
+	case engine.LocationCustom:
+		target.Markers.Apply(req, map[string]string{param.Name: payload})
 	}
 
 	return req
 }
 
-// modifyQueryParam replaces the value of a named query parameter in the URL.
+// modifyQueryParam replaces the value of a named query parameter in the URL,
+// leaving the order and encoding of the other parameters as they were.
 func modifyQueryParam(rawURL, paramName, newValue string) string {
 	parsed, err := url.Parse(rawURL)
 	if err != nil {
 		return rawURL
 	}
-
-	q := parsed.Query()
-	q.Set(paramName, newValue)
-	parsed.RawQuery = q.Encode()
-
+	parsed.RawQuery = rawquery.Set(parsed.RawQuery, paramName, newValue)
 	return parsed.String()
 }
 
 // modifyBodyParam replaces the value of a named parameter in a
-// application/x-www-form-urlencoded body.
+// application/x-www-form-urlencoded body, leaving the order and encoding
+// of the other parameters as they were.
 func modifyBodyParam(body, paramName, newValue string) string {
-	values, err := url.ParseQuery(body)
+	return rawquery.Set(body, paramName, newValue)
+}
+
+// modifyXMLParam replaces the text of the leaf element at path in an XML
+// body, leaving the body unchanged when there is none.
+func modifyXMLParam(body, path, newValue string, raw bool) string {
+	modified, err := xmlbody.Set(body, path, newValue, raw)
 	if err != nil {
 		return body
 	}
+	return modified
+}
 
-	values.Set(paramName, newValue)
-	return values.Encode()
+// modifyGraphQLParam sets the named variable of a GraphQL request body,
+// leaving the body unchanged when there is none.
+func modifyGraphQLParam(body, path, newValue string) string {
+	modified, err := graphql.Set(body, path, newValue)
+	if err != nil {
+		return body
+	}
+	return modified
 }
 
 // responseSimilar returns true when the probe response status code matches
 // the baseline and the body lengths are within a reasonable tolerance.
 // This is used as a lightweight similarity check for behavioural probes.
-func responseSimilar(baseline, probe *transport.Response) bool {
+func responseSimilar(req *FingerprintRequest, probe *transport.Response) bool {
+	if req.Baseline == nil || probe == nil {
+		return false
+	}
+	// A probe is "accepted" if the server answers with a valid page status.
+	return engine.StatusValid(req.ValidStatusCodes, probe.StatusCode)
+}
+
+// responseEqual returns true when the probe response has the baseline's
+// status code and body, i.e. the probe did not change the page.
+func responseEqual(baseline, probe *transport.Response) bool {
 	if baseline == nil || probe == nil {
 		return false
 	}
-	// A probe is "accepted" if the server responds with a 2xx status.
-	return probe.StatusCode >= 200 && probe.StatusCode < 300
+	return probe.StatusCode == baseline.StatusCode && bytes.Equal(probe.Body, baseline.Body)
 }
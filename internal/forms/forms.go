@@ -0,0 +1,284 @@
+// Package forms reads the HTML forms of a page and turns them into the
+// scan targets submitting them would send: every field a browser would
+// send, with its default value or, when empty, a filler its type accepts.
+//
+// Hidden fields, such as anti-CSRF tokens and view state, are sent as the
+// page set them and left out of the parameters to test unless asked for.
+package forms
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/0x6d61/sqleech/internal/detector"
+	"github.com/0x6d61/sqleech/internal/engine"
+)
+
+// Form is an HTML form: where it is submitted, how, and the fields a
+// browser would send, in document order.
+type Form struct {
+	Action *url.URL
+	Method string // GET or POST
+	Fields []Field
+}
+
+// Field is a form field and its default value.
+type Field struct {
+	Name  string
+	Value string
+	Type  string // The input type, lowercased; "select" or "textarea" for those
+}
+
+// Hidden reports whether f is a hidden input.
+func (f Field) Hidden() bool { return f.Type == "hidden" }
+
+// skippedInputs are the input types whose values a submission leaves out
+// or that cannot carry a payload.
+var skippedInputs = map[string]bool{
+	"submit": true, "button": true, "image": true, "reset": true, "file": true,
+}
+
+// fillers are the values of empty fields by input type; other types are
+// filled with defaultFiller.
+var fillers = map[string]string{
+	"number":         "1",
+	"range":          "1",
+	"email":          "test@example.com",
+	"url":            "http://example.com/",
+	"tel":            "5551234567",
+	"date":           "2000-01-01",
+	"month":          "2000-01",
+	"week":           "2000-W01",
+	"time":           "00:00",
+	"datetime-local": "2000-01-01T00:00",
+	"color":          "#000000",
+}
+
+// Parse returns the forms of an HTML page at base with their fields. An
+// action resolves against base, or a <base href>, and a form without one
+// submits to the page itself; forms submitted to other than http(s) URLs
+// are left out. Checkboxes count when checked, a radio group by its
+// checked button or else its first, and a select by its selected option or
+// else its first.
+func Parse(base *url.URL, body []byte) []Form {
+	var (
+		forms    []Form
+		cur      *Form
+		radios   map[string]int // Radio group name -> field index in cur
+		selName  string         // Name of the open select, if any
+		selIdx   = -1           // Its field index once it has a value
+		option   bool           // In an option without a value attribute
+		textarea = -1           // Field index of the open textarea
+		baseSet  bool
+		page     = base
+	)
+
+	z := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if cur != nil {
+				forms = append(forms, *cur)
+			}
+			return forms
+
+		case html.TextToken:
+			switch {
+			case cur != nil && textarea >= 0:
+				cur.Fields[textarea].Value += string(z.Text())
+			case cur != nil && option:
+				cur.Fields = append(cur.Fields, Field{Name: selName, Value: strings.TrimSpace(string(z.Text())), Type: "select"})
+				selIdx, option = len(cur.Fields)-1, false
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "form":
+				if cur != nil {
+					forms = append(forms, *cur)
+					cur = nil
+				}
+			case "select":
+				selName, selIdx, option = "", -1, false
+			case "textarea":
+				textarea = -1
+			case "option":
+				option = false
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			attrs := attributes(z)
+			switch string(name) {
+			case "base":
+				if href, ok := attrs["href"]; ok && !baseSet {
+					if u, err := base.Parse(strings.TrimSpace(href)); err == nil {
+						base, baseSet = u, true
+					}
+				}
+			case "form":
+				if cur != nil {
+					forms = append(forms, *cur)
+				}
+				// Without an action, a form submits to the page, <base
+				// href> notwithstanding.
+				action, err := base.Parse(strings.TrimSpace(attrs["action"]))
+				if strings.TrimSpace(attrs["action"]) == "" {
+					action, err = page.Parse("")
+				}
+				if err != nil || (action.Scheme != "http" && action.Scheme != "https") {
+					cur = nil
+					continue
+				}
+				action.Fragment, action.RawFragment = "", ""
+				method := http.MethodGet
+				if strings.EqualFold(attrs["method"], "post") {
+					method = http.MethodPost
+				}
+				cur = &Form{Action: action, Method: method}
+				radios = make(map[string]int)
+			case "input":
+				n := attrs["name"]
+				typ := strings.ToLower(attrs["type"])
+				if typ == "" {
+					typ = "text"
+				}
+				_, checked := attrs["checked"]
+				if cur == nil || n == "" || skippedInputs[typ] || (typ == "checkbox" && !checked) {
+					continue
+				}
+				if typ == "radio" {
+					// The first button of a group stands in until one is
+					// checked.
+					if i, ok := radios[n]; ok {
+						if checked {
+							cur.Fields[i].Value = attrs["value"]
+						}
+						continue
+					}
+					radios[n] = len(cur.Fields)
+				}
+				cur.Fields = append(cur.Fields, Field{Name: n, Value: attrs["value"], Type: typ})
+			case "textarea":
+				if cur != nil && attrs["name"] != "" {
+					cur.Fields = append(cur.Fields, Field{Name: attrs["name"], Type: "textarea"})
+					textarea = len(cur.Fields) - 1
+				}
+			case "select":
+				if cur != nil && attrs["name"] != "" {
+					selName, selIdx = attrs["name"], -1
+				}
+			case "option":
+				if cur == nil || selName == "" {
+					continue
+				}
+				value, hasValue := attrs["value"]
+				_, selected := attrs["selected"]
+				switch {
+				case selIdx < 0 && !hasValue:
+					option = true
+				case selIdx < 0:
+					cur.Fields = append(cur.Fields, Field{Name: selName, Value: value, Type: "select"})
+					selIdx = len(cur.Fields) - 1
+				case selected && hasValue:
+					cur.Fields[selIdx].Value = value
+				}
+			}
+		}
+	}
+}
+
+// attributes returns the attributes of the current tag of z, keys
+// lowercased; the first of a repeated attribute wins, as in browsers.
+func attributes(z *html.Tokenizer) map[string]string {
+	attrs := make(map[string]string)
+	for {
+		k, v, more := z.TagAttr()
+		if _, ok := attrs[string(k)]; !ok && len(k) > 0 {
+			attrs[string(k)] = string(v)
+		}
+		if !more {
+			return attrs
+		}
+	}
+}
+
+// Target returns the request submitting f sends: a GET form's fields
+// replace the query of its action, a POST form's are its urlencoded body.
+// Hidden fields keep their values and, unless testHidden, are left out of
+// the target's parameters. Target returns nil when f has no field to test.
+func (f Form) Target(testHidden bool) *engine.ScanTarget {
+	pairs := make([]string, 0, len(f.Fields))
+	hidden := make(map[string]bool)
+	for _, fd := range f.Fields {
+		v := fd.Value
+		if v == "" && !fd.Hidden() {
+			v = filler(fd.Type)
+		}
+		pairs = append(pairs, url.QueryEscape(fd.Name)+"="+url.QueryEscape(v))
+		if fd.Hidden() && !testHidden {
+			hidden[fd.Name] = true
+		}
+	}
+	encoded := strings.Join(pairs, "&")
+
+	t := &engine.ScanTarget{Method: f.Method}
+	u := *f.Action
+	loc := engine.LocationBody
+	if f.Method == http.MethodGet {
+		u.RawQuery = encoded
+		loc = engine.LocationQuery
+	} else {
+		t.Body = encoded
+		t.ContentType = "application/x-www-form-urlencoded"
+	}
+	t.URL = u.String()
+
+	params := detector.ParseParameters(t.URL, t.Body, t.ContentType)
+	if len(hidden) == 0 {
+		if len(params) == 0 {
+			return nil
+		}
+		return t
+	}
+	for _, p := range params {
+		// A hidden field of a visible one's name is tested as that one.
+		if !(hidden[p.Name] && p.Location == loc && !visible(f.Fields, p.Name)) {
+			t.Parameters = append(t.Parameters, p)
+		}
+	}
+	if len(t.Parameters) == 0 {
+		return nil
+	}
+	return t
+}
+
+// visible reports whether fields has a field named name that is not
+// hidden.
+func visible(fields []Field, name string) bool {
+	for _, fd := range fields {
+		if fd.Name == name && !fd.Hidden() {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultFiller fills empty text fields. A word rather than a number keeps
+// a field the application quotes from being taken for a numeric one.
+const defaultFiller = "test"
+
+// filler returns the value an empty field of the given input type is
+// submitted with.
+func filler(typ string) string {
+	if v, ok := fillers[typ]; ok {
+		return v
+	}
+	return defaultFiller
+}
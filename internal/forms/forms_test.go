@@ -0,0 +1,120 @@
+package forms
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+)
+
+func TestParse(t *testing.T) {
+	base, _ := url.Parse("http://shop.test/account/")
+	body := []byte(`<html><head><base href="/members/"></head><body>
+<form method="post" action="login.php#top">
+  <input type="hidden" name="csrf" value="t0k3n">
+  <input name="user">
+  <input type="password" name="pass">
+  <input type="checkbox" name="remember" value="yes">
+  <input type="checkbox" name="terms" value="ok" checked>
+  <input type="radio" name="plan" value="free">
+  <input type="radio" name="plan" value="pro" checked>
+  <select name="lang"><option value="en">English</option><option value="fr" selected>French</option></select>
+  <select name="size"><option>Small</option><option>Large</option></select>
+  <textarea name="note">hello</textarea>
+  <input type="file" name="avatar">
+  <input type="submit" name="go" value="Log in">
+</form>
+<form><input name="q" value="shoes"></form>
+<form action="javascript:void(0)"><input name="x"></form>
+</body></html>`)
+
+	forms := Parse(base, body)
+	if len(forms) != 2 {
+		t.Fatalf("got %d forms, want 2", len(forms))
+	}
+
+	login := forms[0]
+	if login.Method != "POST" || login.Action.String() != "http://shop.test/members/login.php" {
+		t.Errorf("form 0 is %s %s, want POST http://shop.test/members/login.php", login.Method, login.Action)
+	}
+	wantFields := []Field{
+		{"csrf", "t0k3n", "hidden"}, {"user", "", "text"}, {"pass", "", "password"},
+		{"terms", "ok", "checkbox"}, {"plan", "pro", "radio"}, {"lang", "fr", "select"},
+		{"size", "Small", "select"}, {"note", "hello", "textarea"},
+	}
+	if !reflect.DeepEqual(login.Fields, wantFields) {
+		t.Errorf("form 0 fields = %v, want %v", login.Fields, wantFields)
+	}
+
+	// A form without an action submits to the page itself.
+	search := forms[1]
+	if search.Method != "GET" || search.Action.String() != "http://shop.test/account/" {
+		t.Errorf("form 1 is %s %s, want GET http://shop.test/account/", search.Method, search.Action)
+	}
+	if want := []Field{{"q", "shoes", "text"}}; !reflect.DeepEqual(search.Fields, want) {
+		t.Errorf("form 1 fields = %v, want %v", search.Fields, want)
+	}
+}
+
+func TestTarget(t *testing.T) {
+	action, _ := url.Parse("http://shop.test/login?next=%2Fcart")
+	f := Form{Action: action, Method: "POST", Fields: []Field{
+		{"token", "abc", "hidden"},
+		{"email", "", "email"},
+		{"pass", "", "password"},
+		{"qty", "", "number"},
+		{"state", "", "hidden"},
+	}}
+
+	tgt := f.Target(false)
+	if tgt.URL != "http://shop.test/login?next=%2Fcart" || tgt.Method != "POST" {
+		t.Errorf("target is %s %s", tgt.Method, tgt.URL)
+	}
+	if want := "token=abc&email=test%40example.com&pass=test&qty=1&state="; tgt.Body != want {
+		t.Errorf("Body = %q, want %q", tgt.Body, want)
+	}
+	if tgt.ContentType != "application/x-www-form-urlencoded" {
+		t.Errorf("ContentType = %q", tgt.ContentType)
+	}
+	var names []string
+	for _, p := range tgt.Parameters {
+		names = append(names, p.Location.String()+":"+p.Name)
+	}
+	if want := []string{"query:next", "body:email", "body:pass", "body:qty"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("parameters = %v, want %v", names, want)
+	}
+
+	// Hidden fields are tested when asked, and then the engine parses
+	// every parameter itself.
+	if tgt := f.Target(true); len(tgt.Parameters) != 0 {
+		t.Errorf("Target(true) parameters = %v, want none preset", tgt.Parameters)
+	}
+}
+
+func TestTarget_GET(t *testing.T) {
+	action, _ := url.Parse("http://shop.test/search?old=1")
+	f := Form{Action: action, Method: "GET", Fields: []Field{{"q", "", "search"}, {"sort", "name", "hidden"}}}
+
+	tgt := f.Target(false)
+	if tgt.URL != "http://shop.test/search?q=test&sort=name" || tgt.Body != "" {
+		t.Errorf("target is %s %s body %q", tgt.Method, tgt.URL, tgt.Body)
+	}
+	if len(tgt.Parameters) != 1 || tgt.Parameters[0].Name != "q" || tgt.Parameters[0].Location != engine.LocationQuery {
+		t.Errorf("parameters = %v, want q alone", tgt.Parameters)
+	}
+}
+
+func TestTarget_NothingToTest(t *testing.T) {
+	action, _ := url.Parse("http://shop.test/cart")
+	if tgt := (Form{Action: action, Method: "POST"}).Target(false); tgt != nil {
+		t.Errorf("form without fields: target %+v", tgt)
+	}
+	f := Form{Action: action, Method: "POST", Fields: []Field{{"token", "abc", "hidden"}}}
+	if tgt := f.Target(false); tgt != nil {
+		t.Errorf("form of hidden fields: target %+v", tgt)
+	}
+	if tgt := f.Target(true); tgt == nil {
+		t.Error("form of hidden fields tested: no target")
+	}
+}
@@ -0,0 +1,181 @@
+// Package graphql finds and rewrites the variables of GraphQL requests:
+// JSON bodies of the form {"query": "...", "variables": {...}}. Only the
+// variables are parameters; the query text is never changed. Variables
+// are named by their path in the variables object, with dots into nested
+// objects and [n] into arrays: id, filter.name, ids[1].
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrNoVariable is returned by Set for a path that names no variable.
+var ErrNoVariable = errors.New("graphql: no variable at path")
+
+// Field is a variable: a string, number or boolean leaf of the variables
+// object.
+type Field struct {
+	Path  string
+	Value string // Strings as they are, numbers and booleans as written
+}
+
+// IsRequest reports whether body is a GraphQL request: a JSON object with
+// a query string and a variables object.
+func IsRequest(body string) bool {
+	doc, err := decode(body)
+	if err != nil {
+		return false
+	}
+	_, isQuery := doc["query"].(string)
+	_, hasVars := doc["variables"].(map[string]any)
+	return isQuery && hasVars
+}
+
+// Variables returns the variables of body ordered by path. The body needs
+// a variables object but no query, as persisted queries send only a hash
+// of theirs.
+func Variables(body string) ([]Field, error) {
+	doc, err := decode(body)
+	if err != nil {
+		return nil, err
+	}
+	vars, ok := doc["variables"].(map[string]any)
+	if !ok {
+		return nil, errors.New("graphql: body has no variables object")
+	}
+	fields := leaves("", vars, nil)
+	slices.SortFunc(fields, func(a, b Field) int { return strings.Compare(a.Path, b.Path) })
+	return fields, nil
+}
+
+// Set returns body with the variable at path set to the string value,
+// re-serialized as JSON. Everything else, the query included, keeps its
+// value.
+func Set(body, path, value string) (string, error) {
+	doc, err := decode(body)
+	if err != nil {
+		return "", err
+	}
+	var v any = doc["variables"]
+	var parent any
+	var key any
+	for _, step := range splitPath(path) {
+		parent, key = v, step
+		switch node := v.(type) {
+		case map[string]any:
+			name, ok := step.(string)
+			if !ok {
+				return "", fmt.Errorf("%w %s", ErrNoVariable, path)
+			}
+			v, ok = node[name]
+			if !ok {
+				return "", fmt.Errorf("%w %s", ErrNoVariable, path)
+			}
+		case []any:
+			i, ok := step.(int)
+			if !ok || i < 0 || i >= len(node) {
+				return "", fmt.Errorf("%w %s", ErrNoVariable, path)
+			}
+			v = node[i]
+		default:
+			return "", fmt.Errorf("%w %s", ErrNoVariable, path)
+		}
+	}
+	if parent == nil || !isLeaf(v) {
+		return "", fmt.Errorf("%w %s", ErrNoVariable, path)
+	}
+	switch node := parent.(type) {
+	case map[string]any:
+		node[key.(string)] = value
+	case []any:
+		node[key.(int)] = value
+	}
+	return encode(doc)
+}
+
+// decode decodes a JSON object body, keeping numbers as written.
+func decode(body string) (map[string]any, error) {
+	dec := json.NewDecoder(strings.NewReader(body))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("graphql: %w", err)
+	}
+	if doc == nil {
+		return nil, errors.New("graphql: body is not a JSON object")
+	}
+	return doc, nil
+}
+
+// encode serializes doc without escaping <, > and &, which would rewrite
+// the query text.
+func encode(doc map[string]any) (string, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return "", fmt.Errorf("graphql: %w", err)
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// leaves appends the leaves under v, whose path is prefix, to out.
+func leaves(prefix string, v any, out []Field) []Field {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			path := k
+			if prefix != "" {
+				path = prefix + "." + k
+			}
+			out = leaves(path, e, out)
+		}
+	case []any:
+		for i, e := range v {
+			out = leaves(prefix+"["+strconv.Itoa(i)+"]", e, out)
+		}
+	case string:
+		out = append(out, Field{prefix, v})
+	case json.Number:
+		out = append(out, Field{prefix, v.String()})
+	case bool:
+		out = append(out, Field{prefix, strconv.FormatBool(v)})
+	}
+	return out
+}
+
+// isLeaf reports whether v is a variable Set may replace.
+func isLeaf(v any) bool {
+	switch v.(type) {
+	case string, json.Number, bool:
+		return true
+	}
+	return false
+}
+
+// splitPath splits filter.ids[1] into "filter", "ids", 1.
+func splitPath(path string) []any {
+	var steps []any
+	for _, part := range strings.Split(path, ".") {
+		name, rest, _ := strings.Cut(part, "[")
+		if name != "" {
+			steps = append(steps, name)
+		}
+		for rest != "" {
+			idx, after, _ := strings.Cut(rest, "]")
+			i, err := strconv.Atoi(idx)
+			if err != nil {
+				i = -1
+			}
+			steps = append(steps, i)
+			rest = strings.TrimPrefix(after, "[")
+		}
+	}
+	return steps
+}
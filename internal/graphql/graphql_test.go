@@ -0,0 +1,97 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const request = `{"query":"query Products($filter: ProductFilter!, $first: Int) { products(filter: $filter, first: $first) { id name } } # a<b && c>d","variables":{"filter":{"name":"Widget","tags":["new","sale"],"price":{"max":9.99}},"first":10,"inStock":true,"cursor":null}}`
+
+func TestIsRequest(t *testing.T) {
+	for body, want := range map[string]bool{
+		request:                     true,
+		`{"query":"{ me { id } }"}`: false,
+		`{"variables":{"id":1}}`:    false,
+		`{"query":"{ me { id } }","variables":1}`: false,
+		`id=1&query=x`:                   false,
+		`[{"query":"x","variables":{}}]`: false,
+	} {
+		if got := IsRequest(body); got != want {
+			t.Errorf("IsRequest(%s) = %v, want %v", body, got, want)
+		}
+	}
+}
+
+func TestVariables(t *testing.T) {
+	fields, err := Variables(request)
+	if err != nil {
+		t.Fatalf("Variables: %v", err)
+	}
+	want := []Field{
+		{"filter.name", "Widget"},
+		{"filter.price.max", "9.99"},
+		{"filter.tags[0]", "new"},
+		{"filter.tags[1]", "sale"},
+		{"first", "10"},
+		{"inStock", "true"},
+	}
+	if !slices.Equal(fields, want) {
+		t.Errorf("Variables =\n%q\nwant\n%q", fields, want)
+	}
+
+	// Persisted queries carry no query text.
+	fields, err = Variables(`{"operationName":"Me","variables":{"id":"7"},"extensions":{"persistedQuery":{"version":1}}}`)
+	if err != nil || !slices.Equal(fields, []Field{{"id", "7"}}) {
+		t.Errorf("Variables of a persisted query = %q, %v", fields, err)
+	}
+}
+
+func TestSet(t *testing.T) {
+	for _, tt := range []struct{ path, value string }{
+		{"filter.name", `Widget' AND "1"="1`},
+		{"filter.tags[1]", "sale' OR 1=1-- -"},
+		{"filter.price.max", "9.99 AND 1=1"},
+		{"first", "10 <script>"},
+	} {
+		body, err := Set(request, tt.path, tt.value)
+		if err != nil {
+			t.Fatalf("Set(%s): %v", tt.path, err)
+		}
+		var got, orig map[string]any
+		if err := json.Unmarshal([]byte(body), &got); err != nil {
+			t.Fatalf("Set(%s) produced invalid JSON: %v\n%s", tt.path, err, body)
+		}
+		_ = json.Unmarshal([]byte(request), &orig)
+		if got["query"] != orig["query"] || !strings.Contains(body, "a<b && c>d") {
+			t.Errorf("Set(%s) changed the query: %s", tt.path, body)
+		}
+
+		fields, _ := Variables(body)
+		origFields, _ := Variables(request)
+		for i, f := range fields {
+			want := origFields[i]
+			if f.Path == tt.path {
+				want.Value = tt.value
+			}
+			if f != want {
+				t.Errorf("Set(%s): variable %q, want %q", tt.path, f, want)
+			}
+		}
+	}
+
+	for _, path := range []string{"filter", "filter.tags", "filter.tags[2]", "filter.tags[x]", "cursor", "missing", "query"} {
+		if _, err := Set(request, path, "1"); !errors.Is(err, ErrNoVariable) {
+			t.Errorf("Set(%s): error = %v, want ErrNoVariable", path, err)
+		}
+	}
+}
+
+func TestSplitPath(t *testing.T) {
+	if got, want := splitPath("a.b[1][2].c"), []any{"a", "b", 1, 2, "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("splitPath = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,190 @@
+// Package jsonpath evaluates a small JSONPath subset against extracted
+// values: dot paths and array indices, e.g. $.profile.emails[0] or
+// $["display name"][-1]. Filters, wildcards and recursive descent are not
+// supported.
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrNotJSON is returned by Apply for a value that does not parse as JSON.
+var ErrNotJSON = errors.New("value is not JSON")
+
+// ErrTruncated is returned by Apply for a value that is the start of a JSON
+// document cut short, as extraction length caps leave it.
+var ErrTruncated = errors.New("JSON value is truncated")
+
+// ErrNoMatch is returned when the path selects nothing in the document.
+var ErrNoMatch = errors.New("path does not match")
+
+// Path is a parsed path expression.
+type Path struct {
+	expr  string
+	steps []step
+}
+
+// step is one key or index of a path.
+type step struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// String returns the expression the path was parsed from.
+func (p *Path) String() string { return p.expr }
+
+// Parse parses a path. The leading $ is optional; keys follow a dot or are
+// quoted in brackets (with \ escaping the quote and itself), and indices
+// are integers in brackets, negative ones counting from the end.
+func Parse(expr string) (*Path, error) {
+	p := &Path{expr: expr}
+	s := strings.TrimSpace(expr)
+	s = strings.TrimPrefix(s, "$")
+	i := 0
+	for i < len(s) {
+		switch s[i] {
+		case '.':
+			i++
+			start := i
+			for i < len(s) && s[i] != '.' && s[i] != '[' && s[i] != ']' {
+				i++
+			}
+			if i == start {
+				return nil, fmt.Errorf("path %q: empty key at offset %d", expr, start)
+			}
+			p.steps = append(p.steps, step{key: s[start:i]})
+		case '[':
+			st, n, err := parseBracket(s[i:])
+			if err != nil {
+				return nil, fmt.Errorf("path %q: %w", expr, err)
+			}
+			p.steps = append(p.steps, st)
+			i += n
+		default:
+			if i == 0 {
+				// A bare leading key, as in "user.name".
+				s = "." + s
+				continue
+			}
+			return nil, fmt.Errorf("path %q: unexpected %q at offset %d", expr, s[i], i)
+		}
+	}
+	return p, nil
+}
+
+// parseBracket parses a [..] step at the start of s and returns it with
+// the number of bytes consumed.
+func parseBracket(s string) (step, int, error) {
+	if len(s) > 1 && (s[1] == '"' || s[1] == '\'') {
+		quote := s[1]
+		var key strings.Builder
+		for i := 2; i < len(s); i++ {
+			switch c := s[i]; {
+			case c == '\\' && i+1 < len(s):
+				i++
+				key.WriteByte(s[i])
+			case c == quote:
+				if i+1 >= len(s) || s[i+1] != ']' {
+					return step{}, 0, errors.New("quoted key not followed by ]")
+				}
+				return step{key: key.String()}, i + 2, nil
+			default:
+				key.WriteByte(c)
+			}
+		}
+		return step{}, 0, errors.New("unterminated quoted key")
+	}
+
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return step{}, 0, errors.New("unterminated [")
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(s[1:end]))
+	if err != nil {
+		return step{}, 0, fmt.Errorf("invalid index %q", s[1:end])
+	}
+	return step{index: n, isIndex: true}, end + 1, nil
+}
+
+// Eval returns the value the path selects in a document decoded by
+// encoding/json. It wraps ErrNoMatch, naming the first step that failed.
+func (p *Path) Eval(doc any) (any, error) {
+	cur := doc
+	at := "$"
+	for _, st := range p.steps {
+		if st.isIndex {
+			arr, ok := cur.([]any)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s is not an array", ErrNoMatch, at)
+			}
+			i := st.index
+			if i < 0 {
+				i += len(arr)
+			}
+			if i < 0 || i >= len(arr) {
+				return nil, fmt.Errorf("%w: index %d out of range at %s (length %d)", ErrNoMatch, st.index, at, len(arr))
+			}
+			cur = arr[i]
+			at += "[" + strconv.Itoa(st.index) + "]"
+			continue
+		}
+
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not an object", ErrNoMatch, at)
+		}
+		v, ok := obj[st.key]
+		if !ok {
+			return nil, fmt.Errorf("%w: no key %q at %s", ErrNoMatch, st.key, at)
+		}
+		cur = v
+		at += "." + st.key
+	}
+	return cur, nil
+}
+
+// Apply parses value as a JSON document and returns what the path selects
+// in it: a string as-is, anything else as compact JSON. It wraps
+// ErrNotJSON, ErrTruncated or ErrNoMatch when the filter cannot apply.
+func (p *Path) Apply(value string) (string, error) {
+	dec := json.NewDecoder(strings.NewReader(value))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) && looksLikeJSON(value) {
+			return "", ErrTruncated
+		}
+		return "", ErrNotJSON
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return "", fmt.Errorf("%w: trailing data after the document", ErrNotJSON)
+	}
+
+	v, err := p.Eval(doc)
+	if err != nil {
+		return "", err
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// looksLikeJSON reports whether value starts like an object or array.
+func looksLikeJSON(value string) bool {
+	s := strings.TrimSpace(value)
+	return s != "" && (s[0] == '{' || s[0] == '[')
+}
@@ -0,0 +1,144 @@
+package jsonpath
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const profile = `{
+	"name": "Admin",
+	"roles": ["admin", "billing"],
+	"address": {"city": "Paris", "geo": [48.85, 2.35]},
+	"display name": "The \"Boss\"",
+	"it's": true,
+	"orders": [{"id": 7, "items": [{"sku": "W-1"}]}],
+	"nothing": null
+}`
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"$", `{"address":{"city":"Paris","geo":[48.85,2.35]},"display name":"The \"Boss\"","it's":true,"name":"Admin","nothing":null,"orders":[{"id":7,"items":[{"sku":"W-1"}]}],"roles":["admin","billing"]}`},
+		{"$.name", "Admin"},
+		{"name", "Admin"},
+		{"$.address.city", "Paris"},
+		{"$.address", `{"city":"Paris","geo":[48.85,2.35]}`},
+		{"$.address.geo[1]", "2.35"},
+		{"$.roles[0]", "admin"},
+		{"$.roles[-1]", "billing"},
+		{"$.orders[0].items[0].sku", "W-1"},
+		{"$.orders[0].id", "7"},
+		{`$["display name"]`, `The "Boss"`},
+		{`$['it\'s']`, "true"},
+		{`$["address"]["city"]`, "Paris"},
+		{"$.nothing", "null"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+			p, err := Parse(tt.path)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			got, err := p.Apply(profile)
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Apply = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply_NoMatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path string
+		msg  string
+	}{
+		{"$.missing", `no key "missing" at $`},
+		{"$.address.zip", `no key "zip" at $.address`},
+		{"$.roles[2]", "index 2 out of range at $.roles (length 2)"},
+		{"$.roles[-3]", "index -3 out of range"},
+		{"$.name.first", "$.name is not an object"},
+		{"$.address[0]", "$.address is not an array"},
+		{"$.nothing.x", "$.nothing is not an object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+			p, err := Parse(tt.path)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			_, err = p.Apply(profile)
+			if !errors.Is(err, ErrNoMatch) {
+				t.Fatalf("Apply = %v, want ErrNoMatch", err)
+			}
+			if !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("error %q does not contain %q", err, tt.msg)
+			}
+		})
+	}
+}
+
+func TestApply_NotJSON(t *testing.T) {
+	t.Parallel()
+
+	p, err := Parse("$.a")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	for _, value := range []string{"", "8.0.32", "admin", `{"a":1} trailing`, "{a:1}"} {
+		if _, err := p.Apply(value); !errors.Is(err, ErrNotJSON) {
+			t.Errorf("Apply(%q) = %v, want ErrNotJSON", value, err)
+		}
+	}
+}
+
+func TestApply_Truncated(t *testing.T) {
+	t.Parallel()
+
+	// Boolean-blind extraction stops at 1024 characters.
+	huge := `{"a":"` + strings.Repeat("x", 2000) + `"}`
+	p, err := Parse("$.a")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if _, err := p.Apply(huge[:1024]); !errors.Is(err, ErrTruncated) {
+		t.Errorf("Apply(truncated) = %v, want ErrTruncated", err)
+	}
+	got, err := p.Apply(huge)
+	if err != nil || len(got) != 2000 {
+		t.Errorf("Apply(full) = %d bytes, %v; want 2000 bytes", len(got), err)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	t.Parallel()
+
+	for _, expr := range []string{"$.", "$.a..b", "$[", "$[x]", `$["a]`, `$["a"x`, "$.a]"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", expr)
+		}
+	}
+}
+
+func TestPath_String(t *testing.T) {
+	t.Parallel()
+
+	p, err := Parse("$.a[0]")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if p.String() != "$.a[0]" {
+		t.Errorf("String = %q", p.String())
+	}
+}
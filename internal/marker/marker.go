@@ -0,0 +1,236 @@
+// Package marker finds the * markers users put in the URL, body, cookies
+// and headers of a request to say exactly where to inject, as in
+// /user/1*/profile or {"name":"bob*"}. The text just before a marker, back
+// to the nearest delimiter such as / = & or a quote, is the original value
+// of its injection point; probes replace value and marker together. \* is
+// a literal asterisk.
+package marker
+
+import (
+	"maps"
+	"mime"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// delimiters end the value before a marker.
+const delimiters = "/?&=;,:#\"'()[]{}<> \t\r\n"
+
+// Point is an injection point.
+type Point struct {
+	Name  string // #1*, #2*, ... in request order
+	Value string // Original value, decoded as the part it sits in
+}
+
+// point is a Point and how its part encodes values.
+type point struct {
+	Point
+	raw    string              // Value as written
+	escape func(string) string // nil for parts that take values verbatim
+}
+
+// Text is a string with injection points.
+type Text struct {
+	lits   []string // Text around the points, one more than points
+	points []point
+}
+
+// Request is a request with injection points.
+type Request struct {
+	URL     Text
+	Body    Text
+	Cookies map[string]Text // Only cookies with markers or escaped asterisks
+	Headers map[string]Text // Only headers with markers or escaped asterisks
+}
+
+// codec encodes values for a part and decodes the values written in it.
+type codec struct {
+	escape   func(string) string
+	unescape func(string) (string, error)
+}
+
+var (
+	pathCodec  = &codec{url.PathEscape, url.PathUnescape}
+	queryCodec = &codec{url.QueryEscape, url.QueryUnescape}
+)
+
+// Parse finds the markers in a request. Points are numbered in order: the
+// URL, the body, then cookies and headers by name. Values are URL-decoded
+// in the URL, in cookies (whose values are URL-encoded) and in bodies of
+// contentType application/x-www-form-urlencoded or none. The Accept header
+// is left alone.
+func Parse(rawURL, body, contentType string, cookies, headers map[string]string) *Request {
+	p := &parser{}
+	r := &Request{URL: p.parseURL(rawURL)}
+	r.Body = p.parse(body, bodyCodec(contentType))
+	r.Cookies = p.parseMap(cookies, queryCodec)
+	// The media ranges of Accept (*/*, text/*) are not markers.
+	headers = maps.Clone(headers)
+	maps.DeleteFunc(headers, func(name, _ string) bool { return strings.EqualFold(name, "Accept") })
+	r.Headers = p.parseMap(headers, nil)
+	return r
+}
+
+// Points returns the injection points of r in order.
+func (r *Request) Points() []Point {
+	var points []Point
+	texts := []Text{r.URL, r.Body}
+	for _, m := range []map[string]Text{r.Cookies, r.Headers} {
+		for _, name := range slices.Sorted(maps.Keys(m)) {
+			texts = append(texts, m[name])
+		}
+	}
+	for _, t := range texts {
+		for _, p := range t.points {
+			points = append(points, p.Point)
+		}
+	}
+	return points
+}
+
+// Apply sets the URL, body, cookies and headers of req that carry
+// injection points, with the points named in values set to those values
+// and the others to their original ones. With no values it sets the
+// original request, asterisks unescaped. req's cookie and header maps
+// are written to. A nil Request leaves req as it is.
+func (r *Request) Apply(req *transport.Request, values map[string]string) {
+	if r == nil {
+		return
+	}
+	req.URL = r.URL.Render(values)
+	req.Body = r.Body.Render(values)
+	req.Cookies = renderMap(req.Cookies, r.Cookies, values)
+	req.Headers = renderMap(req.Headers, r.Headers, values)
+}
+
+// Render returns t with the points named in values set to those values,
+// encoded for the part, and the others to their original values.
+func (t Text) Render(values map[string]string) string {
+	var b strings.Builder
+	for i, lit := range t.lits {
+		b.WriteString(lit)
+		if i == len(t.points) {
+			break
+		}
+		p := t.points[i]
+		v, ok := values[p.Name]
+		switch {
+		case !ok:
+			b.WriteString(p.raw)
+		case p.escape != nil:
+			b.WriteString(p.escape(v))
+		default:
+			b.WriteString(v)
+		}
+	}
+	return b.String()
+}
+
+// parser numbers the points of a request across its parts.
+type parser struct {
+	n int
+}
+
+// parseURL parses a URL, whose points encode values as path segments
+// before the query and as query values after it.
+func (p *parser) parseURL(rawURL string) Text {
+	t := p.parse(rawURL, pathCodec)
+	inQuery := false
+	for i := range t.points {
+		inQuery = inQuery || strings.Contains(t.lits[i], "?")
+		if inQuery {
+			t.points[i].escape = queryCodec.escape
+			t.points[i].Value = decode(t.points[i].raw, queryCodec)
+		}
+	}
+	return t
+}
+
+// parse splits s at its markers, decoding values with c.
+func (p *parser) parse(s string, c *codec) Text {
+	var (
+		t   Text
+		lit strings.Builder
+	)
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == '*':
+			lit.WriteByte('*')
+			i++
+		case s[i] == '*':
+			text := lit.String()
+			start := strings.LastIndexAny(text, delimiters) + 1
+			p.n++
+			pt := point{Point: Point{Name: "#" + strconv.Itoa(p.n) + "*"}, raw: text[start:]}
+			pt.Value = decode(pt.raw, c)
+			if c != nil {
+				pt.escape = c.escape
+			}
+			t.lits = append(t.lits, text[:start])
+			t.points = append(t.points, pt)
+			lit.Reset()
+		default:
+			lit.WriteByte(s[i])
+		}
+	}
+	t.lits = append(t.lits, lit.String())
+	return t
+}
+
+// parseMap parses the values of m in name order, keeping those with
+// markers or escaped asterisks.
+func (p *parser) parseMap(m map[string]string, c *codec) map[string]Text {
+	var texts map[string]Text
+	for _, name := range slices.Sorted(maps.Keys(m)) {
+		if !strings.Contains(m[name], "*") {
+			continue
+		}
+		if texts == nil {
+			texts = make(map[string]Text)
+		}
+		texts[name] = p.parse(m[name], c)
+	}
+	return texts
+}
+
+// bodyCodec returns the codec of a body of contentType: form bodies
+// encode values, others take them verbatim.
+func bodyCodec(contentType string) *codec {
+	if contentType == "" {
+		return queryCodec
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType == "application/x-www-form-urlencoded" {
+		return queryCodec
+	}
+	return nil
+}
+
+// decode decodes raw with c, leaving it as written when it does not
+// decode.
+func decode(raw string, c *codec) string {
+	if c == nil {
+		return raw
+	}
+	if v, err := c.unescape(raw); err == nil {
+		return v
+	}
+	return raw
+}
+
+// renderMap renders texts into m, which it creates if needed.
+func renderMap(m map[string]string, texts map[string]Text, values map[string]string) map[string]string {
+	if len(texts) == 0 {
+		return m
+	}
+	if m == nil {
+		m = make(map[string]string, len(texts))
+	}
+	for name, t := range texts {
+		m[name] = t.Render(values)
+	}
+	return m
+}
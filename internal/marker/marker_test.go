@@ -0,0 +1,106 @@
+package marker
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+func TestParse_PathSegment(t *testing.T) {
+	r := Parse("http://example.test/user/1*/profile?tab=a%20b", "", "", nil, nil)
+	if got, want := r.Points(), []Point{{"#1*", "1"}}; !slices.Equal(got, want) {
+		t.Fatalf("Points = %v, want %v", got, want)
+	}
+
+	req := &transport.Request{}
+	r.Apply(req, nil)
+	if want := "http://example.test/user/1/profile?tab=a%20b"; req.URL != want {
+		t.Errorf("baseline URL = %q, want %q", req.URL, want)
+	}
+	r.Apply(req, map[string]string{"#1*": "1 AND 1=1/x"})
+	if want := "http://example.test/user/1%20AND%201=1%2Fx/profile?tab=a%20b"; req.URL != want {
+		t.Errorf("probe URL = %q, want %q", req.URL, want)
+	}
+}
+
+func TestParse_QueryValue(t *testing.T) {
+	r := Parse("http://example.test/list?sort=na%20me*&page=2", "", "", nil, nil)
+	if got, want := r.Points(), []Point{{"#1*", "na me"}}; !slices.Equal(got, want) {
+		t.Fatalf("Points = %v, want %v", got, want)
+	}
+	req := &transport.Request{}
+	r.Apply(req, map[string]string{"#1*": "name' AND '1'='1"})
+	if want := "http://example.test/list?sort=name%27+AND+%271%27%3D%271&page=2"; req.URL != want {
+		t.Errorf("probe URL = %q, want %q", req.URL, want)
+	}
+}
+
+func TestParse_JSONString(t *testing.T) {
+	body := `{"user":{"name":"bob*","tags":["a"]},"note":"5 \* 3"}`
+	r := Parse("http://example.test/api", body, "application/json", nil, nil)
+	if got, want := r.Points(), []Point{{"#1*", "bob"}}; !slices.Equal(got, want) {
+		t.Fatalf("Points = %v, want %v", got, want)
+	}
+
+	req := &transport.Request{}
+	r.Apply(req, nil)
+	if want := `{"user":{"name":"bob","tags":["a"]},"note":"5 * 3"}`; req.Body != want {
+		t.Errorf("baseline body = %s, want %s", req.Body, want)
+	}
+	r.Apply(req, map[string]string{"#1*": "bob' OR 'a'='a"})
+	if want := `{"user":{"name":"bob' OR 'a'='a","tags":["a"]},"note":"5 * 3"}`; req.Body != want {
+		t.Errorf("probe body = %s, want %s", req.Body, want)
+	}
+}
+
+func TestParse_MultipleMarkers(t *testing.T) {
+	r := Parse("http://example.test/shop/7*/item", "qty=2*&note=x", "",
+		map[string]string{"lang": "en*", "sid": "abc"},
+		map[string]string{"X-Forwarded-For": "127.0.0.1*", "Accept": "*/*"})
+
+	want := []Point{{"#1*", "7"}, {"#2*", "2"}, {"#3*", "en"}, {"#4*", "127.0.0.1"}}
+	if got := r.Points(); !slices.Equal(got, want) {
+		t.Fatalf("Points = %v, want %v", got, want)
+	}
+
+	// Each point is rendered on its own, the others at their values.
+	cookies := map[string]string{"lang": "en*", "sid": "abc"}
+	headers := map[string]string{"X-Forwarded-For": "127.0.0.1*", "Accept": "*/*"}
+	req := &transport.Request{Cookies: maps.Clone(cookies), Headers: maps.Clone(headers)}
+	r.Apply(req, map[string]string{"#3*": "en';x"})
+	if req.URL != "http://example.test/shop/7/item" || req.Body != "qty=2&note=x" {
+		t.Errorf("other points changed: %q %q", req.URL, req.Body)
+	}
+	if req.Cookies["lang"] != "en%27%3Bx" || req.Cookies["sid"] != "abc" {
+		t.Errorf("cookies = %v, want lang URL-encoded", req.Cookies)
+	}
+	if req.Headers["X-Forwarded-For"] != "127.0.0.1" || req.Headers["Accept"] != "*/*" {
+		t.Errorf("headers = %v", req.Headers)
+	}
+
+	req = &transport.Request{Headers: maps.Clone(headers)}
+	r.Apply(req, map[string]string{"#4*": "127.0.0.1' AND 1=1-- -", "#2*": "2 OR 1=1"})
+	if req.Headers["X-Forwarded-For"] != "127.0.0.1' AND 1=1-- -" || req.Body != "qty=2+OR+1%3D1&note=x" {
+		t.Errorf("probe = %q %v", req.Body, req.Headers)
+	}
+}
+
+func TestParse_NoMarkers(t *testing.T) {
+	r := Parse("http://example.test/files/a\\*b?id=1", "id=1", "", map[string]string{"a": "b"}, nil)
+	if points := r.Points(); len(points) != 0 {
+		t.Fatalf("Points = %v, want none", points)
+	}
+	req := &transport.Request{}
+	r.Apply(req, nil)
+	if req.URL != "http://example.test/files/a*b?id=1" || req.Body != "id=1" {
+		t.Errorf("baseline = %q %q", req.URL, req.Body)
+	}
+
+	var nilReq *Request
+	nilReq.Apply(req, map[string]string{"#1*": "x"})
+	if req.URL != "http://example.test/files/a*b?id=1" {
+		t.Errorf("nil Request changed the request: %q", req.URL)
+	}
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// ContentType is the media type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// HandlerOptions configures Handler.
+type HandlerOptions struct {
+	// Token, when set, is the bearer token a scrape must present in its
+	// Authorization header.
+	Token string
+
+	// Exempt serves metrics without checking Token, for scrapers inside
+	// a trusted network that the rest of the service still authenticates.
+	Exempt bool
+}
+
+// Handler serves the registry's metrics on GET and HEAD.
+func Handler(reg *Registry, opts HandlerOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if opts.Token != "" && !opts.Exempt && !validBearer(r, opts.Token) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="sqleech"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", ContentType)
+		if r.Method == http.MethodHead {
+			return
+		}
+		_, _ = reg.WriteTo(w)
+	})
+}
+
+// validBearer reports whether r carries token as its bearer credentials.
+func validBearer(r *http.Request, token string) bool {
+	scheme, cred, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(cred)), []byte(token)) == 1
+}
@@ -0,0 +1,231 @@
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// scrape fetches srv's metrics and returns each sample's value keyed by its
+// name and labels as written, e.g. `sqleech_http_requests_total{host="a"}`.
+func scrape(t *testing.T, srv *httptest.Server) map[string]float64 {
+	t.Helper()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("scrape: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("scrape status = %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != ContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, ContentType)
+	}
+	samples := make(map[string]float64)
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		v, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			t.Fatalf("bad sample %q: %v", line, err)
+		}
+		samples[line[:i]] = v
+	}
+	return samples
+}
+
+func TestScanMetrics_ScrapeDuringScan(t *testing.T) {
+	m := New(Options{})
+	srv := httptest.NewServer(Handler(m.Registry(), HandlerOptions{}))
+	defer srv.Close()
+
+	start := time.Now().Add(-90 * time.Second)
+	a := m.Collector(&engine.ScanTarget{URL: "http://Shop.example:8080/item?id=1"})
+	b := m.Collector(&engine.ScanTarget{URL: "https://blog.example/post?p=2"})
+	m.SetQueued(3)
+
+	before := scrape(t, srv)
+	if got := before["sqleech_scans_active"]; got != 2 {
+		t.Errorf("scans_active = %v, want 2", got)
+	}
+	if got := before["sqleech_scans_queued"]; got != 3 {
+		t.Errorf("scans_queued = %v, want 3", got)
+	}
+
+	a.AddFinding(engine.Vulnerability{Technique: "error-based", Severity: engine.SeverityCritical, Injectable: true})
+	a.AddFinding(engine.Vulnerability{Technique: "boolean-blind", Severity: engine.SeverityHigh, Injectable: false})
+	a.AddError(errors.New("probe failed"))
+	a.Finalize(engine.ScanStats{
+		StartTime:    start,
+		EndTime:      start.Add(80 * time.Second),
+		ScanRequests: 120,
+		Unanswered:   map[string]int{transport.FailureReset.String(): 2},
+		Outages:      []transport.Outage{{Start: start, End: start.Add(time.Second)}, {Start: start}},
+	})
+
+	mid := scrape(t, srv)
+	want := map[string]float64{
+		"sqleech_scans_active": 1,
+		"sqleech_scans_total":  1,
+		`sqleech_findings_total{technique="error-based",severity="critical"}`:        1,
+		`sqleech_http_requests_total{host="shop.example:8080"}`:                      120,
+		`sqleech_errors_total{kind="scan"}`:                                          1,
+		`sqleech_errors_total{kind="unanswered_reset"}`:                              2,
+		`sqleech_circuit_transitions_total{host="shop.example:8080",state="open"}`:   2,
+		`sqleech_circuit_transitions_total{host="shop.example:8080",state="closed"}`: 1,
+		`sqleech_scan_duration_seconds_bucket{le="60"}`:                              0,
+		`sqleech_scan_duration_seconds_bucket{le="120"}`:                             1,
+		`sqleech_scan_duration_seconds_bucket{le="+Inf"}`:                            1,
+		`sqleech_scan_duration_seconds_sum`:                                          80,
+		`sqleech_scan_duration_seconds_count`:                                        1,
+	}
+	for k, v := range want {
+		if got, ok := mid[k]; !ok || got != v {
+			t.Errorf("%s = %v (present %v), want %v", k, got, ok, v)
+		}
+	}
+	if _, ok := mid[`sqleech_findings_total{technique="boolean-blind",severity="high"}`]; ok {
+		t.Error("non-injectable verdict counted as a finding")
+	}
+
+	b.AddFinding(engine.Vulnerability{Technique: "error-based", Severity: engine.SeverityCritical, Injectable: true})
+	b.Finalize(engine.ScanStats{StartTime: start, EndTime: start.Add(2 * time.Second), ScanRequests: 40})
+
+	after := scrape(t, srv)
+	if got := after["sqleech_scans_active"]; got != 0 {
+		t.Errorf("scans_active = %v, want 0", got)
+	}
+	// Counters never go down between scrapes.
+	for k, v := range mid {
+		if strings.Contains(k, "_total") || strings.Contains(k, "_bucket") || strings.Contains(k, "_count") {
+			if after[k] < v {
+				t.Errorf("%s went down from %v to %v", k, v, after[k])
+			}
+		}
+	}
+	if got := after[`sqleech_findings_total{technique="error-based",severity="critical"}`]; got != 2 {
+		t.Errorf("error-based findings = %v, want 2", got)
+	}
+	if got := after[`sqleech_http_requests_total{host="blog.example"}`]; got != 40 {
+		t.Errorf("blog.example requests = %v, want 40", got)
+	}
+}
+
+func TestScanMetrics_HostCardinality(t *testing.T) {
+	m := New(Options{MaxHosts: 5})
+	for i := range 200 {
+		c := m.Collector(&engine.ScanTarget{URL: fmt.Sprintf("http://host%d.example/?id=1", i)})
+		c.Finalize(engine.ScanStats{ScanRequests: 1})
+	}
+
+	srv := httptest.NewServer(Handler(m.Registry(), HandlerOptions{}))
+	defer srv.Close()
+	samples := scrape(t, srv)
+
+	hosts := 0
+	for k := range samples {
+		if strings.HasPrefix(k, "sqleech_http_requests_total{") {
+			hosts++
+		}
+	}
+	if hosts != 6 {
+		t.Errorf("host series = %d, want 5 hosts plus %q", hosts, OtherHost)
+	}
+	if got := samples[`sqleech_http_requests_total{host="other"}`]; got != 195 {
+		t.Errorf("other requests = %v, want 195", got)
+	}
+	if got := samples[`sqleech_http_requests_total{host="host0.example"}`]; got != 1 {
+		t.Errorf("host0 requests = %v, want 1", got)
+	}
+}
+
+func TestHandler_BearerToken(t *testing.T) {
+	reg := NewRegistry()
+	reg.NewGauge("up", "Always one.").Set(1)
+
+	tests := []struct {
+		name   string
+		opts   HandlerOptions
+		auth   string
+		method string
+		want   int
+	}{
+		{"no token configured", HandlerOptions{}, "", http.MethodGet, http.StatusOK},
+		{"valid token", HandlerOptions{Token: "s3cret"}, "Bearer s3cret", http.MethodGet, http.StatusOK},
+		{"scheme is case-insensitive", HandlerOptions{Token: "s3cret"}, "bearer s3cret", http.MethodGet, http.StatusOK},
+		{"missing token", HandlerOptions{Token: "s3cret"}, "", http.MethodGet, http.StatusUnauthorized},
+		{"wrong token", HandlerOptions{Token: "s3cret"}, "Bearer nope", http.MethodGet, http.StatusUnauthorized},
+		{"basic auth", HandlerOptions{Token: "s3cret"}, "Basic czNjcmV0", http.MethodGet, http.StatusUnauthorized},
+		{"exempt", HandlerOptions{Token: "s3cret", Exempt: true}, "", http.MethodGet, http.StatusOK},
+		{"post", HandlerOptions{}, "", http.MethodPost, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/metrics", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			Handler(reg, tt.opts).ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without WWW-Authenticate")
+			}
+		})
+	}
+}
+
+func TestRegistry_WriteTo(t *testing.T) {
+	reg := NewRegistry()
+	c := reg.NewCounter("c_total", "A counter\nwith a newline.", "k")
+	c.Inc(`quo"te`)
+	c.Inc(`back\slash`)
+	c.Add(-5, `quo"te`)
+	reg.NewHistogram("h_seconds", "A histogram.", []float64{0.5, 1}).Observe(0.25)
+
+	var b strings.Builder
+	if _, err := reg.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP c_total A counter\nwith a newline.
+# TYPE c_total counter
+c_total{k="back\\slash"} 1
+c_total{k="quo\"te"} 1
+# HELP h_seconds A histogram.
+# TYPE h_seconds histogram
+h_seconds_bucket{le="0.5"} 1
+h_seconds_bucket{le="1"} 1
+h_seconds_bucket{le="+Inf"} 1
+h_seconds_sum 0.25
+h_seconds_count 1
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteTo:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHandler_Head(t *testing.T) {
+	reg := NewRegistry()
+	reg.NewGauge("up", "Always one.").Set(1)
+	rec := httptest.NewRecorder()
+	Handler(reg, HandlerOptions{}).ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/metrics", nil))
+	if body, _ := io.ReadAll(rec.Body); rec.Code != http.StatusOK || len(body) != 0 {
+		t.Errorf("HEAD = %d with %d body bytes", rec.Code, len(body))
+	}
+}
@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+)
+
+const (
+	// DefaultMaxHosts bounds the distinct host label values. Hosts seen
+	// after the first DefaultMaxHosts share the OtherHost label.
+	DefaultMaxHosts = 50
+
+	// OtherHost is the host label of hosts past the bound.
+	OtherHost = "other"
+)
+
+// DurationBuckets are the upper bounds, in seconds, of the scan duration
+// histogram: from a quick error-based hit to a long time-based crawl.
+var DurationBuckets = []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600}
+
+// Options configures ScanMetrics. Zero values take defaults.
+type Options struct {
+	// MaxHosts bounds the distinct values of the host label.
+	MaxHosts int
+}
+
+// ScanMetrics keeps the process-wide scan metrics. Each scan reports into
+// it through the collector returned by Collector, so the counters move on
+// collector events and at Finalize, never on the request path.
+type ScanMetrics struct {
+	reg   *Registry
+	hosts *hostLabels
+
+	active   *Gauge
+	queued   *Gauge
+	scans    *Counter
+	findings *Counter
+	requests *Counter
+	errors   *Counter
+	duration *Histogram
+	breaker  *Counter
+}
+
+// New creates the scan metrics in a fresh registry.
+func New(opts Options) *ScanMetrics {
+	if opts.MaxHosts <= 0 {
+		opts.MaxHosts = DefaultMaxHosts
+	}
+	reg := NewRegistry()
+	return &ScanMetrics{
+		reg:   reg,
+		hosts: &hostLabels{max: opts.MaxHosts, seen: make(map[string]bool)},
+
+		active:   reg.NewGauge("sqleech_scans_active", "Scans currently running."),
+		queued:   reg.NewGauge("sqleech_scans_queued", "Scans waiting to run."),
+		scans:    reg.NewCounter("sqleech_scans_total", "Scans finished."),
+		findings: reg.NewCounter("sqleech_findings_total", "Injectable findings, by technique and severity.", "technique", "severity"),
+		requests: reg.NewCounter("sqleech_http_requests_total", "HTTP requests sent, by target host.", "host"),
+		errors:   reg.NewCounter("sqleech_errors_total", "Scan errors and unanswered probes, by kind.", "kind"),
+		duration: reg.NewHistogram("sqleech_scan_duration_seconds", "Duration of finished scans.", DurationBuckets),
+		breaker:  reg.NewCounter("sqleech_circuit_transitions_total", "Target availability circuit transitions, by target host and new state.", "host", "state"),
+	}
+}
+
+// Registry returns the registry the metrics are kept in.
+func (m *ScanMetrics) Registry() *Registry { return m.reg }
+
+// SetQueued sets the number of scans waiting to run.
+func (m *ScanMetrics) SetQueued(n int) { m.queued.Set(float64(n)) }
+
+// Collector returns a collector that reports one scan of target into m.
+// The scan counts as active from this call until Finalize.
+func (m *ScanMetrics) Collector(target *engine.ScanTarget) engine.ResultCollector {
+	m.active.Add(1)
+	return &scanCollector{m: m, host: m.hosts.label(targetHost(target))}
+}
+
+// scanCollector updates ScanMetrics from one scan's events.
+type scanCollector struct {
+	m    *ScanMetrics
+	host string
+}
+
+func (c *scanCollector) AddFinding(v engine.Vulnerability) {
+	if v.Injectable {
+		c.m.findings.Inc(v.Technique, strings.ToLower(v.Severity.String()))
+	}
+}
+
+func (c *scanCollector) AddError(error) { c.m.errors.Inc("scan") }
+
+func (c *scanCollector) AddWarning(string) {}
+
+func (c *scanCollector) SetDBMS(string, string) {}
+
+func (c *scanCollector) Finalize(stats engine.ScanStats) {
+	c.m.active.Add(-1)
+	c.m.scans.Inc()
+	c.m.requests.Add(float64(stats.ScanRequests), c.host)
+	for failure, n := range stats.Unanswered {
+		c.m.errors.Add(float64(n), "unanswered_"+failure)
+	}
+	// An outage window opens the circuit to the target and its end closes
+	// it again.
+	for _, o := range stats.Outages {
+		c.m.breaker.Inc(c.host, "open")
+		if !o.End.IsZero() {
+			c.m.breaker.Inc(c.host, "closed")
+		}
+	}
+	if !stats.StartTime.IsZero() && !stats.EndTime.IsZero() {
+		c.m.duration.Observe(stats.EndTime.Sub(stats.StartTime).Seconds())
+	}
+}
+
+// hostLabels hands out host label values, admitting hosts first come first
+// served up to max and mapping the rest to OtherHost, so a scan of many
+// targets cannot grow the series without bound.
+type hostLabels struct {
+	max int
+
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func (h *hostLabels) label(host string) string {
+	if host == "" {
+		return OtherHost
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.seen[host] {
+		return host
+	}
+	if len(h.seen) >= h.max {
+		return OtherHost
+	}
+	h.seen[host] = true
+	return host
+}
+
+// targetHost returns the lowercased host, with any port, of target's URL.
+func targetHost(target *engine.ScanTarget) string {
+	if target == nil {
+		return ""
+	}
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
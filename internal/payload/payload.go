@@ -10,6 +10,7 @@ type Payload struct {
 	Encoded   string // Final form after encoding
 	Technique string // Which technique generated this (e.g., "error-based")
 	DBMS      string // Target DBMS (e.g., "MySQL")
+	Logic     string // Connective joining Core to the original condition ("AND" or "OR"), if any
 }
 
 // String returns the full payload string (Prefix + Core + Suffix).
@@ -24,6 +25,7 @@ type Builder struct {
 	suffix    string
 	technique string
 	dbms      string
+	logic     string
 	encoders  []Encoder
 }
 
@@ -62,6 +64,13 @@ func (b *Builder) WithDBMS(dbms string) *Builder {
 	return b
 }
 
+// WithLogic records the connective ("AND" or "OR") joining the core to
+// the original condition.
+func (b *Builder) WithLogic(logic string) *Builder {
+	b.logic = logic
+	return b
+}
+
 // WithEncoder adds an encoder to the chain.
 func (b *Builder) WithEncoder(enc Encoder) *Builder {
 	b.encoders = append(b.encoders, enc)
@@ -76,6 +85,7 @@ func (b *Builder) Build() *Payload {
 		Suffix:    b.suffix,
 		Technique: b.technique,
 		DBMS:      b.dbms,
+		Logic:     b.logic,
 	}
 
 	raw := p.String()
@@ -140,3 +140,14 @@ func TestCommonBoundaries(t *testing.T) {
 		}
 	}
 }
+
+func TestBuilder_Logic(t *testing.T) {
+	t.Parallel()
+	p := NewBuilder().WithCore(" OR 1=1").WithLogic("OR").Build()
+	if p.Logic != "OR" {
+		t.Errorf("Logic = %q, want %q", p.Logic, "OR")
+	}
+	if got := NewBuilder().Build().Logic; got != "" {
+		t.Errorf("Logic without WithLogic = %q, want empty", got)
+	}
+}
@@ -0,0 +1,239 @@
+package payload
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WriteViolation reports a SQL token that can modify the target's data,
+// schema or files. It is returned by CheckReadOnly.
+type WriteViolation struct {
+	Token  string // Offending token, upper-cased (e.g. "DROP", "INTO OUTFILE")
+	Offset int    // Byte offset of the token in the checked string
+}
+
+// Error implements the error interface.
+func (v *WriteViolation) Error() string {
+	return fmt.Sprintf("read-only mode refuses mutating SQL %q at offset %d", v.Token, v.Offset)
+}
+
+// mutatingWords are statement verbs and functions that write. INSERT and
+// REPLACE are also string functions, so they are only refused when not
+// followed by "(".
+var mutatingWords = map[string]bool{
+	"ALTER":         true,
+	"CALL":          true,
+	"COPY":          true,
+	"CREATE":        true,
+	"DELETE":        true,
+	"DROP":          true,
+	"EXEC":          true,
+	"EXECUTE":       true,
+	"GRANT":         true,
+	"INSERT":        true,
+	"MERGE":         true,
+	"PREPARE":       true,
+	"RENAME":        true,
+	"REPLACE":       true,
+	"REVOKE":        true,
+	"SHUTDOWN":      true,
+	"TRUNCATE":      true,
+	"UPDATE":        true,
+	"PG_FILE_WRITE": true,
+	"PG_WRITE_FILE": true,
+	"SP_CONFIGURE":  true,
+	"XP_CMDSHELL":   true,
+}
+
+// CheckReadOnly tokenizes sql and returns a *WriteViolation for the first
+// token that would make the statement write: a mutating verb, a
+// file-writing function or a SELECT ... INTO. String literals, quoted
+// identifiers and comments are skipped, so SELECT 'UPDATE' passes, while
+// MySQL executable comments (/*!50000 DROP */) are read as code.
+//
+// A payload usually starts inside the application's own string literal
+// (e.g. "';DROP TABLE x-- "), so sql starting with a quote is read as if
+// that quote closed an open literal. Otherwise it is read from the
+// outside, and if that leaves a literal open the context is ambiguous:
+// sql is read both ways and refused if either reading writes. Backslashes
+// are not treated as escapes, so the check errs on the side of refusing.
+func CheckReadOnly(sql string) error {
+	trimmed := strings.TrimLeft(sql, " \t\r\n")
+	if trimmed != "" && (trimmed[0] == '\'' || trimmed[0] == '"') {
+		return readInside(sql)
+	}
+	v, open := scanReadOnly(sql, 0)
+	if v != nil {
+		return v
+	}
+	if open != 0 {
+		return readInside(sql)
+	}
+	return nil
+}
+
+// readInside checks sql as if the literal closed by its first quote were
+// already open.
+func readInside(sql string) error {
+	i := strings.IndexAny(sql, "'\"")
+	if i < 0 {
+		return nil
+	}
+	v, _ := scanReadOnly(sql[i:], sql[i])
+	if v == nil {
+		return nil
+	}
+	v.Offset += i
+	return v
+}
+
+// CheckReadOnly checks the full payload string. See the package-level
+// CheckReadOnly.
+func (p *Payload) CheckReadOnly() error {
+	return CheckReadOnly(p.String())
+}
+
+// scanReadOnly walks sql, starting inside a literal closed by quote when
+// quote is non-zero. It returns the first violation and the quote of a
+// literal left open at the end, if any.
+func scanReadOnly(sql string, quote byte) (*WriteViolation, byte) {
+	i := 0
+	if quote != 0 {
+		end := closeQuote(sql, 0, quote)
+		if end < 0 {
+			return nil, quote
+		}
+		i = end
+	}
+
+	var first *WriteViolation
+	var prev string // previous word, for INTO OUTFILE
+	for i < len(sql) {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := closeQuote(sql, i+1, c)
+			if end < 0 {
+				return first, c
+			}
+			i = end
+			prev = ""
+		case c == '[':
+			end := strings.IndexByte(sql[i:], ']')
+			if end < 0 {
+				return first, 0
+			}
+			i += end + 1
+			prev = ""
+		case strings.HasPrefix(sql[i:], "/*!"):
+			// MySQL executable comment: skip the marker and version.
+			i += 3
+			for i < len(sql) && isDigit(sql[i]) {
+				i++
+			}
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return first, 0
+			}
+			i += 2 + end + 2
+		case strings.HasPrefix(sql[i:], "--") || c == '#':
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				return first, 0
+			}
+			i += end + 1
+		case isDigit(c):
+			// Numbers, including hex literals like 0x44524f50.
+			for i < len(sql) && isWordByte(sql[i]) {
+				i++
+			}
+			prev = ""
+		case c == '@' || c == '.':
+			// Variables and qualified names are never verbs.
+			i++
+			for i < len(sql) && (sql[i] == '@' || isWordByte(sql[i])) {
+				i++
+			}
+			prev = ""
+		case isWordByte(c):
+			start := i
+			for i < len(sql) && isWordByte(sql[i]) {
+				i++
+			}
+			word := strings.ToUpper(sql[start:i])
+			if v := checkWord(sql, start, i, word, prev); v != nil && first == nil {
+				first = v
+			}
+			prev = word
+		default:
+			i++
+		}
+	}
+	return first, 0
+}
+
+// checkWord decides whether word, spanning sql[start:end], writes.
+func checkWord(sql string, start, end int, word, prev string) *WriteViolation {
+	if prev == "INTO" && (word == "OUTFILE" || word == "DUMPFILE") {
+		return nil // already refused at INTO
+	}
+	next := nextSignificant(sql, end)
+	switch word {
+	case "INTO":
+		// SELECT ... INTO @var only assigns a variable; any other INTO
+		// target is a table or a file.
+		if strings.HasPrefix(next, "@") {
+			return nil
+		}
+		token := "INTO"
+		if w := leadingWord(next); w == "OUTFILE" || w == "DUMPFILE" {
+			token += " " + w
+		}
+		return &WriteViolation{Token: token, Offset: start}
+	case "INSERT", "REPLACE":
+		if strings.HasPrefix(next, "(") {
+			return nil
+		}
+	}
+	if mutatingWords[word] {
+		return &WriteViolation{Token: word, Offset: start}
+	}
+	return nil
+}
+
+// closeQuote returns the index just past the quote that closes a literal
+// whose body starts at i, or -1. Doubled quotes are escapes.
+func closeQuote(sql string, i int, quote byte) int {
+	for i < len(sql) {
+		if sql[i] == quote {
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i += 2
+				continue
+			}
+			return i + 1
+		}
+		i++
+	}
+	return -1
+}
+
+// nextSignificant returns sql from the first non-space byte at or after i.
+func nextSignificant(sql string, i int) string {
+	return strings.TrimLeft(sql[i:], " \t\r\n\f\v")
+}
+
+// leadingWord returns the upper-cased word at the start of s.
+func leadingWord(s string) string {
+	n := 0
+	for n < len(s) && isWordByte(s[n]) {
+		n++
+	}
+	return strings.ToUpper(s[:n])
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || isDigit(c) || (c|0x20 >= 'a' && c|0x20 <= 'z')
+}
@@ -0,0 +1,96 @@
+package payload
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckReadOnly(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		sql   string
+		token string // "" means the input passes
+	}{
+		// Read-only inputs.
+		{"plain select", "SELECT name FROM users WHERE id=1", ""},
+		{"verb in string literal", "SELECT 'UPDATE'", ""},
+		{"verb in doubled-quote literal", "SELECT 'it''s DROP time'", ""},
+		{"verb in double-quoted literal", `SELECT "DELETE FROM x"`, ""},
+		{"verb in backtick identifier", "SELECT `update` FROM t", ""},
+		{"verb in bracket identifier", "SELECT [delete] FROM t", ""},
+		{"verb in block comment", "1 /* DROP TABLE users */ AND 1=1", ""},
+		{"verb in line comment", "1 AND 1=1-- DROP TABLE users", ""},
+		{"verb in hash comment", "1 AND 1=1#DELETE FROM users", ""},
+		{"hex literal", "1 AND name=0x44524f50205441424c45", ""},
+		{"hex string literal", "1 AND name=X'44524f50'", ""},
+		{"replace function", "1 AND REPLACE(name,'a','b')='x'", ""},
+		{"insert function", "1 AND INSERT (name,1,1,'x')='x'", ""},
+		{"qualified column", "SELECT t.update FROM t", ""},
+		{"into variable", "1 AND (SELECT 1 INTO @x)", ""},
+		{"boolean boundary", "' AND 1=1 AND '1'='1", ""},
+		{"stacked waitfor", "1;WAITFOR DELAY '0:0:5'-- -", ""},
+		{"stacked waitfor in string context", "';WAITFOR DELAY '0:0:5'-- -", ""},
+		{"stacked select", "1; SELECT pg_sleep(5)-- -", ""},
+		{"unterminated comment suffix", "1 AND 1=1/*", ""},
+
+		// Mutating inputs.
+		{"update", "UPDATE users SET name='x'", "UPDATE"},
+		{"lower case verb", "1; delete from users", "DELETE"},
+		{"stacked drop", "1; DROP TABLE users-- -", "DROP"},
+		{"stacked drop in string context", "';DROP TABLE x--", "DROP"},
+		{"stacked insert in double-quote context", `";INSERT INTO t VALUES (1)-- -`, "INSERT"},
+		{"executable comment", "1 /*!50000 DROP */ TABLE users", "DROP"},
+		{"executable comment without version", "1;/*!DELETE FROM users*/", "DELETE"},
+		{"into outfile", "1 UNION SELECT 1 INTO OUTFILE '/tmp/x'", "INTO OUTFILE"},
+		{"into dumpfile", "1 UNION SELECT 1 INTO DUMPFILE '/tmp/x'", "INTO DUMPFILE"},
+		{"select into table", "1; SELECT * INTO backup FROM users", "INTO"},
+		{"xp_cmdshell", "1; EXEC master..xp_cmdshell 'dir'", "EXEC"},
+		{"xp_cmdshell without exec", "1; xp_cmdshell 'dir'", "XP_CMDSHELL"},
+		{"pg file write", "1 AND pg_write_file('/tmp/x','y')", "PG_WRITE_FILE"},
+		{"insert statement", "1; INSERT INTO t VALUES (1)", "INSERT"},
+		{"truncate", "1;TRUNCATE users", "TRUNCATE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := CheckReadOnly(tt.sql)
+			if tt.token == "" {
+				if err != nil {
+					t.Fatalf("CheckReadOnly(%q) = %v, want nil", tt.sql, err)
+				}
+				return
+			}
+			var v *WriteViolation
+			if !errors.As(err, &v) {
+				t.Fatalf("CheckReadOnly(%q) = %v, want *WriteViolation", tt.sql, err)
+			}
+			if v.Token != tt.token {
+				t.Errorf("Token = %q, want %q", v.Token, tt.token)
+			}
+			if got := strings.ToUpper(tt.sql[v.Offset:]); !strings.HasPrefix(got, strings.SplitN(tt.token, " ", 2)[0]) {
+				t.Errorf("Offset %d points at %q, want %q", v.Offset, tt.sql[v.Offset:], tt.token)
+			}
+			if !strings.Contains(err.Error(), tt.token) {
+				t.Errorf("error %q does not name token %q", err, tt.token)
+			}
+		})
+	}
+}
+
+func TestPayload_CheckReadOnly(t *testing.T) {
+	t.Parallel()
+
+	ok := NewBuilder().WithPrefix("'").WithCore(" AND 'a'='a").WithSuffix("-- -").Build()
+	if err := ok.CheckReadOnly(); err != nil {
+		t.Errorf("read-only payload: %v", err)
+	}
+
+	bad := NewBuilder().WithPrefix("';").WithCore("DROP TABLE users").WithSuffix("-- -").Build()
+	if err := bad.CheckReadOnly(); err == nil {
+		t.Error("expected violation for stacked DROP payload")
+	}
+}
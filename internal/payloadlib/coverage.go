@@ -0,0 +1,85 @@
+package payloadlib
+
+import (
+	"sort"
+	"sync"
+)
+
+// Status is the per-entry outcome recorded during a scan.
+type Status struct {
+	Tried     int `json:"tried"`     // Number of detection attempts using the entry
+	Succeeded int `json:"succeeded"` // Number of attempts confirmed injectable
+}
+
+// Coverage records which corpus entries a scan tried and which succeeded.
+// It is safe for concurrent use. A nil *Coverage ignores all calls, so
+// techniques can record unconditionally.
+type Coverage struct {
+	mu     sync.Mutex
+	status map[string]*Status
+}
+
+// NewCoverage creates an empty coverage tracker.
+func NewCoverage() *Coverage {
+	return &Coverage{status: make(map[string]*Status)}
+}
+
+// Tried records that the entries with the given IDs were used in a probe.
+func (c *Coverage) Tried(ids ...string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range ids {
+		c.entry(id).Tried++
+	}
+}
+
+// Succeeded records that the entries with the given IDs led to a detection.
+func (c *Coverage) Succeeded(ids ...string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range ids {
+		c.entry(id).Succeeded++
+	}
+}
+
+func (c *Coverage) entry(id string) *Status {
+	s, ok := c.status[id]
+	if !ok {
+		s = &Status{}
+		c.status[id] = s
+	}
+	return s
+}
+
+// Snapshot returns a copy of the recorded status, keyed by entry ID.
+func (c *Coverage) Snapshot() map[string]Status {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]Status, len(c.status))
+	for id, s := range c.status {
+		out[id] = *s
+	}
+	return out
+}
+
+// TriedIDs returns the sorted IDs of all entries tried at least once.
+func (c *Coverage) TriedIDs() []string {
+	snap := c.Snapshot()
+	ids := make([]string, 0, len(snap))
+	for id, s := range snap {
+		if s.Tried > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
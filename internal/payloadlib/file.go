@@ -0,0 +1,48 @@
+package payloadlib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Load decodes user payload entries from a JSON array. Entries are validated
+// when added to a corpus, not here.
+func Load(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&entries); err != nil {
+		return nil, fmt.Errorf("decoding payload file: %w", err)
+	}
+	return entries, nil
+}
+
+// LoadFile reads user payload entries from a JSON file.
+func LoadFile(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries, err := Load(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// WithFile returns a copy of c extended with the entries in the given file.
+func (c *Corpus) WithFile(path string) (*Corpus, error) {
+	entries, err := LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	clone := c.Clone()
+	if err := clone.Add(entries...); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return clone, nil
+}
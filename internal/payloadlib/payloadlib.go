@@ -0,0 +1,411 @@
+// Package payloadlib is the structured corpus of every payload, boundary and
+// template sqleech knows. Each entry carries a stable ID and the metadata
+// needed to decide whether it applies to a target: technique, DBMS, SQL
+// context, risk and level, and DBMS version constraints.
+//
+// Techniques select entries through a Filter instead of keeping their own
+// literal tables, so the corpus can answer which payloads exist, which are
+// disabled at a given risk level, and (together with Coverage) which were
+// tried against a target.
+package payloadlib
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/0x6d61/sqleech/internal/payload"
+)
+
+// Kind classifies a corpus entry.
+type Kind string
+
+const (
+	// KindBoundary is a prefix/suffix pair that escapes the SQL context.
+	KindBoundary Kind = "boundary"
+
+	// KindErrorTemplate is an error-based expression with a {{.Query}}
+	// placeholder for the expression to extract.
+	KindErrorTemplate Kind = "error-template"
+
+	// KindExpression replaces the parameter value outright, for contexts
+	// such as LIMIT where nothing can be appended to it. Its template holds
+	// {{.Value}} for the original value and {{.Query}} for the technique's
+	// expression: a condition for the blind techniques, a rendered error
+	// template for error-based.
+	KindExpression Kind = "expression"
+)
+
+// Context is the SQL context an entry is meant to escape or run in.
+type Context string
+
+const (
+	ContextNumeric Context = "numeric"  // WHERE id=1
+	ContextString  Context = "string"   // WHERE name='x'
+	ContextLike    Context = "like"     // WHERE name LIKE '%x%'
+	ContextOrderBy Context = "order-by" // ORDER BY x
+	ContextLimit   Context = "limit"    // LIMIT x / OFFSET x
+)
+
+// Risk and level defaults. Entries default to the lowest risk and level; a
+// scan at the defaults selects exactly those entries.
+const (
+	DefaultRisk  = 1
+	DefaultLevel = 1
+	MaxRisk      = 3
+	MaxLevel     = 5
+)
+
+// Technique names, matching the Name() of each technique.
+const (
+	TechniqueError   = "error-based"
+	TechniqueBoolean = "boolean-blind"
+	TechniqueTime    = "time-based"
+	TechniqueUnion   = "union-based"
+)
+
+// techniqueCodes maps the single-character codes accepted by --technique to
+// technique names.
+var techniqueCodes = map[string]string{
+	"E": TechniqueError,
+	"B": TechniqueBoolean,
+	"T": TechniqueTime,
+	"U": TechniqueUnion,
+}
+
+// TechniqueName resolves a single-character technique code (E, B, T, U) to
+// its technique name. Names are returned unchanged.
+func TechniqueName(s string) string {
+	if name, ok := techniqueCodes[strings.ToUpper(s)]; ok {
+		return name
+	}
+	return s
+}
+
+// Entry is a single corpus item.
+type Entry struct {
+	ID          string    `json:"id"`
+	Kind        Kind      `json:"kind"`
+	Techniques  []string  `json:"techniques"`
+	DBMS        []string  `json:"dbms,omitempty"` // Empty = any DBMS
+	Contexts    []Context `json:"contexts,omitempty"`
+	Risk        int       `json:"risk"`
+	Level       int       `json:"level"`
+	MinVersion  string    `json:"min_version,omitempty"` // Inclusive; empty = no bound
+	MaxVersion  string    `json:"max_version,omitempty"` // Inclusive; empty = no bound
+	Description string    `json:"description"`
+
+	// Boundary fields.
+	Prefix string `json:"prefix,omitempty"`
+	Suffix string `json:"suffix,omitempty"`
+
+	// Template fields.
+	Name     string `json:"name,omitempty"`     // Short name, e.g. "extractvalue"
+	Template string `json:"template,omitempty"` // Contains {{.Query}}, and {{.Value}} for expressions
+	Columns  int    `json:"columns,omitempty"`
+}
+
+// QueryPlaceholder is the placeholder for the extracted expression in
+// template entries.
+const QueryPlaceholder = "{{.Query}}"
+
+// ValuePlaceholder is the placeholder for the original parameter value in
+// expression entries.
+const ValuePlaceholder = "{{.Value}}"
+
+// Render substitutes value and query into an expression entry's template.
+func (e *Entry) Render(value, query string) string {
+	return strings.NewReplacer(ValuePlaceholder, value, QueryPlaceholder, query).Replace(e.Template)
+}
+
+// Validate checks that the entry has all required fields.
+func (e *Entry) Validate() error {
+	switch {
+	case e.ID == "":
+		return errors.New("missing id")
+	case e.Description == "":
+		return fmt.Errorf("%s: missing description", e.ID)
+	case len(e.Techniques) == 0:
+		return fmt.Errorf("%s: missing techniques", e.ID)
+	case e.Risk < 1 || e.Risk > MaxRisk:
+		return fmt.Errorf("%s: risk %d out of range 1-%d", e.ID, e.Risk, MaxRisk)
+	case e.Level < 1 || e.Level > MaxLevel:
+		return fmt.Errorf("%s: level %d out of range 1-%d", e.ID, e.Level, MaxLevel)
+	}
+
+	switch e.Kind {
+	case KindBoundary:
+		if e.Prefix == "" && e.Suffix == "" {
+			return fmt.Errorf("%s: boundary has neither prefix nor suffix", e.ID)
+		}
+	case KindErrorTemplate:
+		if e.Name == "" {
+			return fmt.Errorf("%s: missing name", e.ID)
+		}
+		if !strings.Contains(e.Template, QueryPlaceholder) {
+			return fmt.Errorf("%s: template missing %s", e.ID, QueryPlaceholder)
+		}
+		if len(e.DBMS) != 1 {
+			return fmt.Errorf("%s: template must target exactly one DBMS", e.ID)
+		}
+	case KindExpression:
+		if !strings.Contains(e.Template, QueryPlaceholder) {
+			return fmt.Errorf("%s: template missing %s", e.ID, QueryPlaceholder)
+		}
+		if len(e.Contexts) == 0 {
+			return fmt.Errorf("%s: expression must name its contexts", e.ID)
+		}
+	default:
+		return fmt.Errorf("%s: unknown kind %q", e.ID, e.Kind)
+	}
+	return nil
+}
+
+// AppliesTo reports whether the entry is usable against the given DBMS.
+// An empty name (DBMS unknown) matches every entry.
+func (e *Entry) AppliesTo(dbms string) bool {
+	if dbms == "" || len(e.DBMS) == 0 {
+		return true
+	}
+	for _, d := range e.DBMS {
+		if strings.EqualFold(d, dbms) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckReadOnly reports whether the entry's SQL could modify the target.
+// Boundaries are checked around a neutral condition and templates with a
+// neutral query, so the violation names the entry and the offending token.
+func (e *Entry) CheckReadOnly() error {
+	var sql string
+	switch e.Kind {
+	case KindErrorTemplate:
+		sql = strings.ReplaceAll(e.Template, QueryPlaceholder, "1")
+	case KindExpression:
+		sql = e.Render("1", "1=1")
+	default:
+		sql = e.Prefix + " AND 1=1 " + e.Suffix
+	}
+	if err := payload.CheckReadOnly(sql); err != nil {
+		return fmt.Errorf("%s: %w", e.ID, err)
+	}
+	return nil
+}
+
+// --------------------------------------------------------------------------
+// Filter
+// --------------------------------------------------------------------------
+
+// Filter selects corpus entries. Zero-valued fields do not constrain.
+type Filter struct {
+	Kind      Kind
+	Technique string  // Name or single-character code
+	DBMS      string  // Entries for other DBMS are excluded
+	Context   Context // Entries listing other contexts are excluded
+	MaxRisk   int
+	MaxLevel  int
+	Version   string // DBMS version, checked against Min/MaxVersion
+}
+
+// fork is a DBMS forked from another, or compatible with it: it inherits
+// the parent's boundary entries, version-checked against the parent
+// version it split from or emulates rather than its own numbering.
+// Templates are not inherited; a fork lists its own.
+type fork struct {
+	parent  string
+	version string
+}
+
+// forks maps lower-cased fork names to their parent.
+var forks = map[string]fork{
+	"mariadb":     {parent: "MySQL", version: "5.5"},
+	"cockroachdb": {parent: "PostgreSQL", version: "13.0"},
+}
+
+// Match reports whether e satisfies the filter.
+func (f Filter) Match(e *Entry) bool {
+	if f.Kind != "" && e.Kind != f.Kind {
+		return false
+	}
+	if f.Technique != "" && !containsFold(e.Techniques, TechniqueName(f.Technique)) {
+		return false
+	}
+	version := f.Version
+	if !e.AppliesTo(f.DBMS) {
+		fk, ok := forks[strings.ToLower(f.DBMS)]
+		if !ok || e.Kind == KindErrorTemplate || !e.AppliesTo(fk.parent) {
+			return false
+		}
+		version = fk.version
+	}
+	if f.Context != "" && len(e.Contexts) > 0 && !containsContext(e.Contexts, f.Context) {
+		return false
+	}
+	if f.MaxRisk > 0 && e.Risk > f.MaxRisk {
+		return false
+	}
+	if f.MaxLevel > 0 && e.Level > f.MaxLevel {
+		return false
+	}
+	if version != "" {
+		if e.MinVersion != "" && compareVersions(version, e.MinVersion) < 0 {
+			return false
+		}
+		if e.MaxVersion != "" && compareVersions(version, e.MaxVersion) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsContext(list []Context, c Context) bool {
+	for _, v := range list {
+		if v == c {
+			return true
+		}
+	}
+	return false
+}
+
+// compareVersions compares dotted numeric versions ("8.0.32" vs "5.7").
+// Missing components count as zero; non-numeric suffixes are ignored.
+func compareVersions(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		na, nb := versionPart(pa, i), versionPart(pb, i)
+		switch {
+		case na < nb:
+			return -1
+		case na > nb:
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionPart(parts []string, i int) int {
+	if i >= len(parts) {
+		return 0
+	}
+	s := parts[i]
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.Atoi(s[:end])
+	return n
+}
+
+// --------------------------------------------------------------------------
+// Corpus
+// --------------------------------------------------------------------------
+
+// Corpus is an ordered, ID-indexed collection of entries. Order is
+// significant: techniques try selected entries in corpus order.
+type Corpus struct {
+	entries []Entry
+	byID    map[string]int
+}
+
+// New creates a corpus from entries, validating each one.
+func New(entries ...Entry) (*Corpus, error) {
+	c := &Corpus{byID: make(map[string]int, len(entries))}
+	if err := c.Add(entries...); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Add validates and appends entries. Nothing is added if any entry is
+// invalid or reuses an existing ID.
+func (c *Corpus) Add(entries ...Entry) error {
+	entries = append([]Entry(nil), entries...)
+	seen := make(map[string]bool, len(entries))
+	for i := range entries {
+		e := &entries[i]
+		if e.Risk == 0 {
+			e.Risk = DefaultRisk
+		}
+		if e.Level == 0 {
+			e.Level = DefaultLevel
+		}
+		if err := e.Validate(); err != nil {
+			return fmt.Errorf("invalid payload entry: %w", err)
+		}
+		if _, dup := c.byID[e.ID]; dup || seen[e.ID] {
+			return fmt.Errorf("duplicate payload id %q", e.ID)
+		}
+		seen[e.ID] = true
+	}
+	for _, e := range entries {
+		c.byID[e.ID] = len(c.entries)
+		c.entries = append(c.entries, e)
+	}
+	return nil
+}
+
+// Entries returns a copy of all entries in corpus order.
+func (c *Corpus) Entries() []Entry {
+	return append([]Entry(nil), c.entries...)
+}
+
+// Len returns the number of entries.
+func (c *Corpus) Len() int {
+	return len(c.entries)
+}
+
+// Get returns the entry with the given ID.
+func (c *Corpus) Get(id string) (Entry, bool) {
+	i, ok := c.byID[id]
+	if !ok {
+		return Entry{}, false
+	}
+	return c.entries[i], true
+}
+
+// Select returns the entries matching f, in corpus order.
+func (c *Corpus) Select(f Filter) []Entry {
+	var out []Entry
+	for i := range c.entries {
+		if f.Match(&c.entries[i]) {
+			out = append(out, c.entries[i])
+		}
+	}
+	return out
+}
+
+// CheckReadOnly returns the first entry whose SQL could modify the target,
+// as reported by Entry.CheckReadOnly.
+func (c *Corpus) CheckReadOnly() error {
+	for i := range c.entries {
+		if err := c.entries[i].CheckReadOnly(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clone returns an independent copy of the corpus, e.g. to extend the
+// default corpus with user payloads.
+func (c *Corpus) Clone() *Corpus {
+	clone := &Corpus{
+		entries: c.Entries(),
+		byID:    make(map[string]int, len(c.byID)),
+	}
+	for id, i := range c.byID {
+		clone.byID[id] = i
+	}
+	return clone
+}
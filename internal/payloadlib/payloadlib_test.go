@@ -0,0 +1,351 @@
+package payloadlib
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/0x6d61/sqleech/internal/payload"
+)
+
+func TestDefault_Integrity(t *testing.T) {
+	c := Default()
+	if c.Len() == 0 {
+		t.Fatal("default corpus is empty")
+	}
+
+	seen := make(map[string]bool)
+	for _, e := range c.Entries() {
+		if seen[e.ID] {
+			t.Errorf("duplicate ID %q", e.ID)
+		}
+		seen[e.ID] = true
+
+		if err := e.Validate(); err != nil {
+			t.Errorf("invalid entry: %v", err)
+		}
+		for _, tech := range e.Techniques {
+			if _, ok := map[string]bool{
+				TechniqueError: true, TechniqueBoolean: true, TechniqueTime: true, TechniqueUnion: true,
+			}[tech]; !ok {
+				t.Errorf("%s: unknown technique %q", e.ID, tech)
+			}
+		}
+		if got, ok := c.Get(e.ID); !ok || got.ID != e.ID {
+			t.Errorf("Get(%q) = %v, %v", e.ID, got.ID, ok)
+		}
+	}
+}
+
+func TestDefault_EveryTechniqueHasBoundaries(t *testing.T) {
+	for _, tech := range []string{TechniqueError, TechniqueBoolean, TechniqueTime, TechniqueUnion} {
+		if len(Default().Select(DefaultFilter(KindBoundary, tech, ""))) == 0 {
+			t.Errorf("no default boundaries for %s", tech)
+		}
+	}
+	for _, dbms := range []string{"MySQL", "PostgreSQL", "MSSQL", "Oracle", "SQLite"} {
+		if len(Default().Select(DefaultFilter(KindErrorTemplate, TechniqueError, dbms))) == 0 {
+			t.Errorf("no error templates for %s", dbms)
+		}
+	}
+}
+
+func TestScanFilter_Levels(t *testing.T) {
+	for _, tech := range []string{TechniqueError, TechniqueBoolean, TechniqueTime, TechniqueUnion} {
+		prev := 0
+		for level := 1; level <= MaxLevel; level++ {
+			n := len(Default().Select(ScanFilter(KindBoundary, tech, "MySQL", level, DefaultRisk)))
+			if n < prev {
+				t.Errorf("%s: level %d selects %d boundaries, fewer than level %d's %d", tech, level, n, level-1, prev)
+			}
+			prev = n
+		}
+		if def, top := len(Default().Select(DefaultFilter(KindBoundary, tech, "MySQL"))), prev; def >= top {
+			t.Errorf("%s: level %d adds no boundaries to the default %d", tech, MaxLevel, def)
+		}
+		if got, want := ScanFilter(KindBoundary, tech, "", 0, 0), DefaultFilter(KindBoundary, tech, ""); got != want {
+			t.Errorf("%s: ScanFilter at level and risk 0 = %+v, want the default %+v", tech, got, want)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	c, err := New(
+		Entry{ID: "b.any", Kind: KindBoundary, Techniques: []string{TechniqueBoolean}, Prefix: "'", Contexts: []Context{ContextString}, Description: "d"},
+		Entry{ID: "b.mysql", Kind: KindBoundary, Techniques: []string{TechniqueError}, DBMS: []string{"MySQL"}, Suffix: "#", Description: "d"},
+		Entry{ID: "b.risky", Kind: KindBoundary, Techniques: []string{TechniqueBoolean}, Suffix: ";", Risk: 3, Level: 2, Description: "d"},
+		Entry{ID: "t.new", Kind: KindErrorTemplate, Techniques: []string{TechniqueError}, DBMS: []string{"MySQL"}, Name: "n", Template: "f({{.Query}})", MinVersion: "5.1", MaxVersion: "8", Description: "d"},
+	)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"no filter", Filter{}, []string{"b.any", "b.mysql", "b.risky", "t.new"}},
+		{"kind", Filter{Kind: KindErrorTemplate}, []string{"t.new"}},
+		{"technique code", Filter{Technique: "E"}, []string{"b.mysql", "t.new"}},
+		{"technique name", Filter{Technique: TechniqueBoolean}, []string{"b.any", "b.risky"}},
+		{"dbms excludes other dbms", Filter{DBMS: "postgresql"}, []string{"b.any", "b.risky"}},
+		{"dbms case-insensitive", Filter{DBMS: "mysql", Kind: KindBoundary}, []string{"b.any", "b.mysql", "b.risky"}},
+		{"context", Filter{Context: ContextNumeric}, []string{"b.mysql", "b.risky", "t.new"}},
+		{"risk", Filter{MaxRisk: 1}, []string{"b.any", "b.mysql", "t.new"}},
+		{"level", Filter{MaxLevel: 1}, []string{"b.any", "b.mysql", "t.new"}},
+		{"version too old", Filter{Kind: KindErrorTemplate, Version: "5.0.96"}, nil},
+		{"version in range", Filter{Kind: KindErrorTemplate, Version: "5.7.44-log"}, []string{"t.new"}},
+		{"version too new", Filter{Kind: KindErrorTemplate, Version: "8.0.32"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, e := range c.Select(tt.filter) {
+				got = append(got, e.ID)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Select() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilter_Fork(t *testing.T) {
+	c, err := New(
+		Entry{ID: "b.mysql", Kind: KindBoundary, Techniques: []string{TechniqueError}, DBMS: []string{"MySQL"}, Suffix: "#", Description: "d"},
+		Entry{ID: "b.mysql8", Kind: KindBoundary, Techniques: []string{TechniqueError}, DBMS: []string{"MySQL"}, Suffix: "-- ", MinVersion: "8.0", Description: "d"},
+		Entry{ID: "t.mysql", Kind: KindErrorTemplate, Techniques: []string{TechniqueError}, DBMS: []string{"MySQL"}, Name: "n", Template: "f({{.Query}})", Description: "d"},
+		Entry{ID: "t.mariadb", Kind: KindErrorTemplate, Techniques: []string{TechniqueError}, DBMS: []string{"MariaDB"}, Name: "n", Template: "g({{.Query}})", Description: "d"},
+	)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"mariadb inherits mysql boundaries", Filter{DBMS: "MariaDB", Kind: KindBoundary}, []string{"b.mysql"}},
+		{"inherited entries checked against fork point", Filter{DBMS: "MariaDB", Kind: KindBoundary, Version: "10.6.12"}, []string{"b.mysql"}},
+		{"templates not inherited", Filter{DBMS: "mariadb", Kind: KindErrorTemplate}, []string{"t.mariadb"}},
+		{"mysql excludes mariadb templates", Filter{DBMS: "MySQL", Kind: KindErrorTemplate}, []string{"t.mysql"}},
+		{"mysql version gate", Filter{DBMS: "MySQL", Kind: KindBoundary, Version: "8.0.32"}, []string{"b.mysql", "b.mysql8"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, e := range c.Select(tt.filter) {
+				got = append(got, e.ID)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Select() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdd_Rejects(t *testing.T) {
+	valid := Entry{ID: "x", Kind: KindBoundary, Techniques: []string{TechniqueBoolean}, Prefix: "'", Description: "d"}
+
+	tests := []struct {
+		name  string
+		entry Entry
+	}{
+		{"missing id", Entry{Kind: KindBoundary, Techniques: []string{"b"}, Prefix: "'", Description: "d"}},
+		{"missing description", Entry{ID: "y", Kind: KindBoundary, Techniques: []string{"b"}, Prefix: "'"}},
+		{"missing techniques", Entry{ID: "y", Kind: KindBoundary, Prefix: "'", Description: "d"}},
+		{"unknown kind", Entry{ID: "y", Kind: "other", Techniques: []string{"b"}, Description: "d"}},
+		{"empty boundary", Entry{ID: "y", Kind: KindBoundary, Techniques: []string{"b"}, Description: "d"}},
+		{"risk out of range", Entry{ID: "y", Kind: KindBoundary, Techniques: []string{"b"}, Prefix: "'", Risk: 4, Description: "d"}},
+		{"template without placeholder", Entry{ID: "y", Kind: KindErrorTemplate, Techniques: []string{"E"}, DBMS: []string{"MySQL"}, Name: "n", Template: "f()", Description: "d"}},
+		{"template without dbms", Entry{ID: "y", Kind: KindErrorTemplate, Techniques: []string{"E"}, Name: "n", Template: "f({{.Query}})", Description: "d"}},
+		{"expression without placeholder", Entry{ID: "y", Kind: KindExpression, Techniques: []string{"B"}, Contexts: []Context{ContextLimit}, Template: "{{.Value}}", Description: "d"}},
+		{"expression without context", Entry{ID: "y", Kind: KindExpression, Techniques: []string{"B"}, Template: "{{.Query}}", Description: "d"}},
+		{"duplicate id", valid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(valid)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			if err := c.Add(tt.entry); err == nil {
+				t.Error("Add() error = nil, want rejection")
+			}
+			if c.Len() != 1 {
+				t.Errorf("Len() = %d after rejected Add, want 1", c.Len())
+			}
+		})
+	}
+}
+
+func TestWithFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "payloads.json")
+	data := `[{"id":"user.squote.semicolon","kind":"boundary","techniques":["boolean-blind"],
+		"prefix":"'","suffix":";-- -","risk":2,"description":"Stacked terminator"}]`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Default().WithFile(path)
+	if err != nil {
+		t.Fatalf("WithFile: %v", err)
+	}
+	if c.Len() != Default().Len()+1 {
+		t.Errorf("Len() = %d, want %d", c.Len(), Default().Len()+1)
+	}
+	e, ok := c.Get("user.squote.semicolon")
+	if !ok || e.Risk != 2 || e.Level != DefaultLevel {
+		t.Errorf("loaded entry = %+v, %v", e, ok)
+	}
+	if _, ok := Default().Get("user.squote.semicolon"); ok {
+		t.Error("WithFile must not modify the default corpus")
+	}
+	if got := c.Select(DefaultFilter(KindBoundary, TechniqueBoolean, "")); len(got) != len(Default().Select(DefaultFilter(KindBoundary, TechniqueBoolean, ""))) {
+		t.Error("risk 2 user entry should be excluded at the default risk")
+	}
+}
+
+func TestWithFile_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	cases := map[string]string{
+		"unknown-field.json": `[{"id":"a","kind":"boundary","techniques":["boolean-blind"],"prefix":"'","description":"d","bogus":1}]`,
+		"duplicate.json":     `[{"id":"bnd.numeric.comment","kind":"boundary","techniques":["boolean-blind"],"prefix":"'","description":"d"}]`,
+		"invalid.json":       `[{"id":"a","kind":"boundary"}]`,
+	}
+	for name, data := range cases {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Default().WithFile(path); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if _, err := Default().WithFile(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("missing file: expected error")
+	}
+}
+
+func TestDefault_ReadOnly(t *testing.T) {
+	t.Parallel()
+	if err := Default().CheckReadOnly(); err != nil {
+		t.Errorf("default corpus must be read-only: %v", err)
+	}
+}
+
+func TestEntry_CheckReadOnly(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		entry Entry
+		token string
+	}{
+		{"stacked boundary", Entry{ID: "b", Kind: KindBoundary, Prefix: "';", Suffix: "-- -"}, ""},
+		{"verb in literal", Entry{ID: "b", Kind: KindBoundary, Prefix: "'", Suffix: "AND 'DROP'='DROP"}, ""},
+		{"insert suffix", Entry{ID: "user.insert", Kind: KindBoundary, Prefix: "';", Suffix: "INSERT INTO log VALUES (1)-- -"}, "INSERT"},
+		{"outfile template", Entry{ID: "user.outfile", Kind: KindErrorTemplate, Template: "UNION SELECT {{.Query}} INTO OUTFILE '/tmp/x'"}, "INTO OUTFILE"},
+		{"outfile expression", Entry{ID: "user.limit", Kind: KindExpression, Template: "{{.Value}} INTO OUTFILE '/tmp/x'"}, "INTO OUTFILE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.entry.CheckReadOnly()
+			if tt.token == "" {
+				if err != nil {
+					t.Errorf("CheckReadOnly() = %v, want nil", err)
+				}
+				return
+			}
+			var v *payload.WriteViolation
+			if !errors.As(err, &v) || v.Token != tt.token {
+				t.Fatalf("CheckReadOnly() = %v, want violation on %q", err, tt.token)
+			}
+			if !strings.HasPrefix(err.Error(), tt.entry.ID+":") {
+				t.Errorf("error %q does not name entry %q", err, tt.entry.ID)
+			}
+		})
+	}
+}
+
+func TestEntry_Render(t *testing.T) {
+	e, ok := Default().Get("expr.limit.case")
+	if !ok {
+		t.Fatal("expr.limit.case missing")
+	}
+	want := "(CASE WHEN (1=1) THEN 10 WHEN 10=0 THEN 1 ELSE 0 END)"
+	if got := e.Render("10", "1=1"); got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	// PROCEDURE ANALYSE is the one MySQL way into LIMIT, and only up to 5.7.
+	f := DefaultFilter(KindExpression, TechniqueError, "MySQL")
+	f.Context = ContextLimit
+	if got := Default().Select(f); len(got) != 1 || got[0].ID != "expr.limit.mysql.procedure-analyse" {
+		t.Errorf("MySQL LIMIT error expressions = %v", got)
+	}
+	f.Version = "8.0.32"
+	if got := Default().Select(f); len(got) != 0 {
+		t.Errorf("MySQL 8.0 LIMIT error expressions = %v, want none", got)
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	var nilCov *Coverage
+	nilCov.Tried("a")
+	nilCov.Succeeded("a")
+	if nilCov.Snapshot() != nil || len(nilCov.TriedIDs()) != 0 {
+		t.Error("nil Coverage should record nothing")
+	}
+
+	c := NewCoverage()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Tried("b", "a")
+		}()
+	}
+	wg.Wait()
+	c.Succeeded("a")
+
+	snap := c.Snapshot()
+	if snap["a"] != (Status{Tried: 50, Succeeded: 1}) || snap["b"] != (Status{Tried: 50}) {
+		t.Errorf("Snapshot() = %+v", snap)
+	}
+	if got := strings.Join(c.TriedIDs(), ","); got != "a,b" {
+		t.Errorf("TriedIDs() = %q, want %q", got, "a,b")
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"8.0.32", "5.1", 1},
+		{"5.1", "5.1.0", 0},
+		{"5.0.96", "5.1", -1},
+		{"10.4.32-MariaDB", "10.4", 1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestTechniqueName(t *testing.T) {
+	if got := TechniqueName("e"); got != TechniqueError {
+		t.Errorf("TechniqueName(e) = %q", got)
+	}
+	if got := TechniqueName("union-based"); got != TechniqueUnion {
+		t.Errorf("TechniqueName(union-based) = %q", got)
+	}
+}
@@ -0,0 +1,123 @@
+// Package rawquery parses and rewrites parameter values in URL query
+// strings and application/x-www-form-urlencoded bodies, which share the
+// format, as they were written. Unlike url.Values.Encode, it keeps the
+// pairs in their original order and every byte outside the replaced values
+// as it was: an untouched %2b stays %2b and a literal + stays +.
+//
+// Pairs are separated by & or, as in legacy Java applications, ;. Keys
+// ending in [] are PHP-style array elements, named by their position among
+// the elements of their key: id[]=1&id[]=2 holds id[0] and id[1].
+package rawquery
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Pair is a parameter of a query string, its name and value decoded.
+type Pair struct {
+	Name  string
+	Value string
+}
+
+// Parse returns the pairs of raw in order. A pair without = has an empty
+// value, and empty pairs are skipped; a key or value that does not decode
+// is taken as written.
+func Parse(raw string) []Pair {
+	var pairs []Pair
+	for _, seg := range split(raw) {
+		if seg.text == "" {
+			continue
+		}
+		_, value, _ := strings.Cut(seg.text, "=")
+		pairs = append(pairs, Pair{Name: seg.name, Value: decode(value)})
+	}
+	return pairs
+}
+
+// Set returns raw with the value of every pair named name replaced by
+// value, query-escaped, or with name=value appended when there is none,
+// after a ; when raw separates its pairs with ; alone. Names are compared
+// decoded, so a%5Bid%5D matches "a[id]", and id[1] names the second
+// element of an id[] array unless a key is literally id[1].
+func Set(raw, name, value string) string {
+	escaped := url.QueryEscape(value)
+	segs := split(raw)
+	exact := false
+	for _, seg := range segs {
+		if seg.text != "" && seg.key == name {
+			exact = true
+			break
+		}
+	}
+	found := false
+	var b strings.Builder
+	for _, seg := range segs {
+		b.WriteString(seg.sep)
+		if seg.text != "" && (seg.key == name || !exact && seg.name == name) {
+			key, _, _ := strings.Cut(seg.text, "=")
+			b.WriteString(key + "=" + escaped)
+			found = true
+			continue
+		}
+		b.WriteString(seg.text)
+	}
+	if found {
+		return b.String()
+	}
+	pair := url.QueryEscape(name) + "=" + escaped
+	switch {
+	case raw == "":
+		return pair
+	case strings.Contains(raw, ";") && !strings.Contains(raw, "&"):
+		return raw + ";" + pair
+	}
+	return raw + "&" + pair
+}
+
+// segment is a pair of a query string as written, with the separator
+// before it.
+type segment struct {
+	sep  string // "" for the first pair, else & or ;
+	text string // The pair as written
+	key  string // Its key, decoded
+	name string // The key, array elements indexed
+}
+
+// split splits raw into its pairs.
+func split(raw string) []segment {
+	var segs []segment
+	counts := make(map[string]int)
+	sep := ""
+	for {
+		i := strings.IndexAny(raw, "&;")
+		text := raw
+		if i >= 0 {
+			text = raw[:i]
+		}
+		seg := segment{sep: sep, text: text}
+		if text != "" {
+			k, _, _ := strings.Cut(text, "=")
+			seg.key = decode(k)
+			seg.name = seg.key
+			if base, ok := strings.CutSuffix(seg.key, "[]"); ok {
+				seg.name = base + "[" + strconv.Itoa(counts[seg.key]) + "]"
+				counts[seg.key]++
+			}
+		}
+		segs = append(segs, seg)
+		if i < 0 {
+			return segs
+		}
+		sep, raw = raw[i:i+1], raw[i+1:]
+	}
+}
+
+// decode returns s query-unescaped, or as written when it does not decode.
+func decode(s string) string {
+	if d, err := url.QueryUnescape(s); err == nil {
+		return d
+	}
+	return s
+}
@@ -0,0 +1,61 @@
+package rawquery
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		param string
+		value string
+		want  string
+	}{
+		{"order kept", "b=2&a=1&c=3", "a", "1 AND 1=1", "b=2&a=1+AND+1%3D1&c=3"},
+		{"encoding of other pairs kept", "q=C%2b%2b&tag=a+b&id=1", "id", "2", "q=C%2b%2b&tag=a+b&id=2"},
+		{"payload escaped", "id=1", "id", "1' OR '1'='1", "id=1%27+OR+%271%27%3D%271"},
+		{"literal plus in payload", "id=1", "id", "1+1", "id=1%2B1"},
+		{"encoded key", "a%5Bid%5D=1&b=2", "a[id]", "7", "a%5Bid%5D=7&b=2"},
+		{"every duplicate", "id=1&x=0&id=2", "id", "3", "id=3&x=0&id=3"},
+		{"no value", "flag&id=1", "flag", "x", "flag=x&id=1"},
+		{"absent", "id=1", "name", "a b", "id=1&name=a+b"},
+		{"empty", "", "id", "1", "id=1"},
+		{"semicolon separators kept", "a=1;b=2&c=3", "b", "4", "a=1;b=4&c=3"},
+		{"appended after semicolon", "a=1;b=2", "c", "3", "a=1;b=2;c=3"},
+		{"array element", "id[]=1&x=0&id%5B%5D=2", "id[1]", "3", "id[]=1&x=0&id%5B%5D=3"},
+		{"whole array", "id[]=1&id[]=2", "id[]", "3", "id[]=3&id[]=3"},
+		{"explicit index wins", "id[1]=a&id[]=b&id[]=c", "id[1]", "d", "id[1]=d&id[]=b&id[]=c"},
+		{"array element absent", "id[]=1", "id[1]", "2", "id[]=1&id%5B1%5D=2"},
+		{"empty pairs kept", "a=1&&b=2;", "b", "3", "a=1&&b=3;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Set(tt.raw, tt.param, tt.value); got != tt.want {
+				t.Errorf("Set(%q, %q, %q) = %q, want %q", tt.raw, tt.param, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want []Pair
+	}{
+		{"", nil},
+		{"b=2&a=1", []Pair{{"b", "2"}, {"a", "1"}}},
+		{"a=1;b=x+y&c=%27", []Pair{{"a", "1"}, {"b", "x y"}, {"c", "'"}}},
+		{"id[]=1&name=x&id%5B%5D=2", []Pair{{"id[0]", "1"}, {"name", "x"}, {"id[1]", "2"}}},
+		{"a[]=1&b[]=2&a[]=3", []Pair{{"a[0]", "1"}, {"b[0]", "2"}, {"a[1]", "3"}}},
+		{"id[0]=1&id=2&id=3", []Pair{{"id[0]", "1"}, {"id", "2"}, {"id", "3"}}},
+		{"flag&;&q=100%", []Pair{{"flag", ""}, {"q", "100%"}}},
+	}
+	for _, tt := range tests {
+		got := Parse(tt.raw)
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("Parse(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// Package rawrequest parses raw HTTP/1.1 requests as proxies such as Burp
+// Suite save them: a request line, headers, a blank line and the body.
+//
+// Saved requests are forgiving about their framing. Lines may end in \r\n
+// or \n, a body may have been edited after its Content-Length was
+// written, and editors append a final newline; a chunked body is decoded.
+// The body itself is kept byte for byte, so the \r\n boundaries of a
+// multipart body and any * injection markers survive.
+package rawrequest
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Request is a parsed raw request.
+type Request struct {
+	Method string
+	// URL is absolute: the request target resolved against the Host
+	// header.
+	URL string
+	// Headers holds the headers under their canonical names (see
+	// textproto.CanonicalMIMEHeaderKey), less Host and Cookie, which live
+	// in URL and Cookies, and the framing and connection headers the
+	// client sets itself.
+	Headers map[string]string
+	Cookies map[string]string
+	Body    string
+	// ContentType is the Content-Type header, if any.
+	ContentType string
+}
+
+// dropped are the headers Parse leaves out of Request.Headers. The client
+// computes the framing of the body it sends and manages its connections,
+// and asking for compressed responses by hand would leave them undecoded.
+var dropped = map[string]bool{
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+	"Connection":        true,
+	"Keep-Alive":        true,
+	"Proxy-Connection":  true,
+	"Accept-Encoding":   true,
+}
+
+// ParseFile parses the raw request saved in the file at path. See Parse.
+func ParseFile(path string, forceSSL bool) (*Request, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	req, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if rest, ok := strings.CutPrefix(req.URL, "http://"); ok && forceSSL {
+		req.URL = "https://" + rest
+	}
+	return req, nil
+}
+
+// Parse parses a raw request. The request target is taken as is when it
+// is an absolute URL, as in requests written for a proxy; otherwise the
+// scheme is https when the Host header names port 443 and http if not.
+func Parse(data []byte) (*Request, error) {
+	head, body := cutHead(bytes.TrimLeft(data, "\r\n"))
+	if head == "" {
+		return nil, errors.New("rawrequest: empty request")
+	}
+
+	lines := strings.Split(head, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	fields := strings.Fields(lines[0])
+	if len(fields) != 3 || !strings.HasPrefix(fields[2], "HTTP/") {
+		return nil, fmt.Errorf("rawrequest: malformed request line %q", lines[0])
+	}
+	req := &Request{
+		Method:  fields[0],
+		Headers: make(map[string]string),
+		Cookies: make(map[string]string),
+	}
+	var host, contentLength string
+	chunked := false
+	for _, line := range lines[1:] {
+		if line == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("rawrequest: malformed header line %q", line)
+		}
+		name = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		switch name {
+		case "Host":
+			host = value
+		case "Cookie":
+			for _, pair := range strings.Split(value, ";") {
+				k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok {
+					req.Cookies[strings.TrimSpace(k)] = strings.TrimSpace(v)
+				}
+			}
+		case "Content-Length":
+			contentLength = value
+		case "Transfer-Encoding":
+			chunked = strings.EqualFold(value, "chunked")
+		}
+		if name == "Host" || name == "Cookie" || dropped[name] {
+			continue
+		}
+		if prev, ok := req.Headers[name]; ok {
+			value = prev + ", " + value
+		}
+		req.Headers[name] = value
+	}
+	req.ContentType = req.Headers["Content-Type"]
+
+	target := fields[1]
+	switch {
+	case strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://"):
+		req.URL = target
+	case host == "":
+		return nil, errors.New("rawrequest: no Host header")
+	case strings.HasSuffix(host, ":443"):
+		req.URL = "https://" + strings.TrimSuffix(host, ":443") + target
+	default:
+		req.URL = "http://" + host + target
+	}
+
+	if chunked {
+		decoded, err := decodeChunked(body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body = decoded
+		return req, nil
+	}
+	req.Body = fitLength(body, contentLength)
+	return req, nil
+}
+
+// cutHead splits data at the first empty line, which may end in \r\n or
+// \n, into the head and the body after it. A request without one is all
+// head.
+func cutHead(data []byte) (head string, body []byte) {
+	for i := 0; i < len(data); {
+		end := bytes.IndexByte(data[i:], '\n')
+		if end < 0 {
+			break
+		}
+		if line := bytes.TrimSuffix(data[i:i+end], []byte("\r")); len(line) == 0 {
+			return string(data[:i]), data[i+end+1:]
+		}
+		i += end + 1
+	}
+	return string(data), nil
+}
+
+// fitLength resolves body against the declared Content-Length. A body of
+// exactly that length is kept, as is the declared length of a longer one
+// whose excess is only the line endings editors append. Any other
+// mismatch means the body was edited after it was saved: it is kept as
+// written, less a final line ending, and its length recomputed on sending.
+func fitLength(body []byte, contentLength string) string {
+	if n, err := strconv.Atoi(contentLength); err == nil && n >= 0 {
+		if n == len(body) {
+			return string(body)
+		}
+		if n < len(body) && strings.Trim(string(body[n:]), "\r\n") == "" {
+			return string(body[:n])
+		}
+	}
+	return strings.TrimRight(string(body), "\r\n")
+}
+
+// decodeChunked decodes a chunked body. Size lines may carry chunk
+// extensions and, like the rest of the request, end in \r\n or \n.
+func decodeChunked(body []byte) (string, error) {
+	r := bufio.NewReader(bytes.NewReader(body))
+	var out strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil && line == "" {
+			return "", errors.New("rawrequest: chunked body ends without a last chunk")
+		}
+		size, _, _ := strings.Cut(strings.TrimRight(line, "\r\n"), ";")
+		n, err := strconv.ParseUint(strings.TrimSpace(size), 16, 32)
+		if err != nil {
+			return "", fmt.Errorf("rawrequest: malformed chunk size %q", strings.TrimSpace(line))
+		}
+		if n == 0 {
+			return out.String(), nil
+		}
+		chunk := make([]byte, n)
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return "", errors.New("rawrequest: chunk shorter than its size")
+		}
+		out.Write(chunk)
+		// The chunk data is followed by a line ending of its own.
+		if rest, _ := r.ReadString('\n'); strings.TrimRight(rest, "\r\n") != "" {
+			return "", errors.New("rawrequest: chunk longer than its size")
+		}
+	}
+}
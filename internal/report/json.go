@@ -22,14 +22,35 @@ func (r *JSONReporter) Format() string {
 
 // jsonOutput is the top-level JSON structure.
 type jsonOutput struct {
-	SchemaVersion   string     `json:"schema_version"`
-	Tool            string     `json:"tool"`
-	Target          jsonTarget `json:"target"`
-	DBMS            *jsonDBMS  `json:"dbms,omitempty"`
-	Scan            jsonScan   `json:"scan"`
-	Vulnerabilities []jsonVuln `json:"vulnerabilities"`
+	SchemaVersion   string      `json:"schema_version"`
+	Tool            string      `json:"tool"`
+	Target          jsonTarget  `json:"target"`
+	DBMS            *jsonDBMS   `json:"dbms,omitempty"`
+	Scan            jsonScan    `json:"scan"`
+	Vulnerabilities []jsonVuln  `json:"vulnerabilities"`
 	Summary         jsonSummary `json:"summary"`
-	Errors          []string   `json:"errors,omitempty"`
+	Errors          []string    `json:"errors,omitempty"`
+	Warnings        []string    `json:"warnings,omitempty"`
+	Skipped         []jsonSkip  `json:"skipped,omitempty"`
+
+	// TargetProfile is environment context, not a finding.
+	TargetProfile *jsonProfile `json:"target_profile,omitempty"`
+
+	DBA         *jsonDBA         `json:"dba,omitempty"`
+	Extractions []jsonExtraction `json:"extractions,omitempty"`
+	Transport   *jsonTransport   `json:"transport,omitempty"`
+	Jobs        []jsonJob        `json:"jobs,omitempty"`
+
+	// Sources and Conflicts are set on merged reports.
+	Sources   []jsonSource `json:"sources,omitempty"`
+	Conflicts []string     `json:"conflicts,omitempty"`
+}
+
+// jsonSource represents a report a merged report was built from.
+type jsonSource struct {
+	Name   string     `json:"name"`
+	Target jsonTarget `json:"target"`
+	DBMS   *jsonDBMS  `json:"dbms,omitempty"`
 }
 
 // jsonTarget represents the scan target in JSON.
@@ -40,8 +61,31 @@ type jsonTarget struct {
 
 // jsonDBMS represents the detected DBMS in JSON.
 type jsonDBMS struct {
-	Name    string `json:"name"`
-	Version string `json:"version,omitempty"`
+	Name        string `json:"name"`
+	Version     string `json:"version,omitempty"`
+	Banner      string `json:"banner,omitempty"`
+	CurrentUser string `json:"current_user,omitempty"`
+	CurrentDB   string `json:"current_db,omitempty"`
+	Hostname    string `json:"hostname,omitempty"`
+}
+
+// jsonDBA represents the DBA privilege check in JSON.
+type jsonDBA struct {
+	IsDBA bool   `json:"is_dba"`
+	Query string `json:"query"`
+	Value string `json:"value"`
+}
+
+// jsonExtraction represents the result of a user query in JSON.
+type jsonExtraction struct {
+	Query     string   `json:"query"`
+	Values    []string `json:"values"`
+	Technique string   `json:"technique"`
+	Parameter string   `json:"parameter"`
+
+	Filter      string   `json:"filter,omitempty"`
+	Raw         []string `json:"raw_values,omitempty"`
+	FilterNotes []string `json:"filter_notes,omitempty"`
 }
 
 // jsonScan represents scan metadata in JSON.
@@ -50,6 +94,35 @@ type jsonScan struct {
 	EndTime         time.Time `json:"end_time"`
 	DurationSeconds float64   `json:"duration_seconds"`
 	TotalRequests   int64     `json:"total_requests"`
+	UnsafeWrites    bool      `json:"unsafe_writes"`
+
+	UnansweredProbes int   `json:"unanswered_probes,omitempty"`
+	Truncated        bool  `json:"truncated,omitempty"`
+	RequestBudget    int64 `json:"request_budget,omitempty"`
+}
+
+// jsonJob represents the timing of a detection job in JSON, durations in
+// seconds.
+type jsonJob struct {
+	Parameter    jsonParam `json:"parameter"`
+	Technique    string    `json:"technique"`
+	QueueSeconds float64   `json:"queue_seconds"`
+	ExecSeconds  float64   `json:"exec_seconds"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// jsonTransport represents the HTTP client's statistics in JSON, durations
+// in seconds.
+type jsonTransport struct {
+	StatusCounts  map[int]int64 `json:"status_counts"`
+	Errors        int64         `json:"errors"`
+	Retries       int64         `json:"retries"`
+	BytesReceived int64         `json:"bytes_received"`
+	MinSeconds    float64       `json:"min_seconds"`
+	MaxSeconds    float64       `json:"max_seconds"`
+	P50Seconds    float64       `json:"p50_seconds,omitempty"`
+	P95Seconds    float64       `json:"p95_seconds,omitempty"`
+	P99Seconds    float64       `json:"p99_seconds,omitempty"`
 }
 
 // jsonVuln represents a vulnerability in JSON.
@@ -61,6 +134,53 @@ type jsonVuln struct {
 	Confidence float64   `json:"confidence"`
 	Severity   string    `json:"severity"`
 	Evidence   string    `json:"evidence"`
+	Protocol   string    `json:"protocol,omitempty"`
+
+	ConfirmedBy []string `json:"confirmed_by,omitempty"`
+
+	PairedParameter *jsonParam `json:"paired_parameter,omitempty"`
+	PairedPayload   string     `json:"paired_payload,omitempty"`
+
+	Triage *jsonTriage `json:"triage,omitempty"`
+
+	// Target and Sources attribute a finding of a merged report.
+	Target  *jsonTarget `json:"target,omitempty"`
+	Sources []string    `json:"sources,omitempty"`
+}
+
+// jsonSkip represents a parameter skipped for safety in JSON.
+type jsonSkip struct {
+	Parameter jsonParam   `json:"parameter"`
+	Reason    string      `json:"reason"`
+	Target    *jsonTarget `json:"target,omitempty"`
+}
+
+// jsonProfile represents the target profile in JSON. Informational is
+// always true, marking the section as context rather than findings.
+type jsonProfile struct {
+	Informational  bool     `json:"informational"`
+	URL            string   `json:"url"`
+	Redirects      []string `json:"redirects,omitempty"`
+	Protocol       string   `json:"protocol,omitempty"`
+	Server         string   `json:"server,omitempty"`
+	PoweredBy      string   `json:"powered_by,omitempty"`
+	PresentHeaders []string `json:"security_headers_present,omitempty"`
+	MissingHeaders []string `json:"security_headers_missing,omitempty"`
+	TLS            *jsonTLS `json:"tls,omitempty"`
+
+	ClosesConnections bool `json:"closes_connections,omitempty"`
+}
+
+// jsonTLS represents the TLS connection and certificate in JSON.
+type jsonTLS struct {
+	Version     string    `json:"version"`
+	CipherSuite string    `json:"cipher_suite"`
+	Subject     string    `json:"subject,omitempty"`
+	Issuer      string    `json:"issuer,omitempty"`
+	NotBefore   time.Time `json:"not_before"`
+	NotAfter    time.Time `json:"not_after"`
+	DNSNames    []string  `json:"dns_names,omitempty"`
+	Issues      []string  `json:"issues,omitempty"`
 }
 
 // jsonParam represents a parameter in JSON.
@@ -70,10 +190,24 @@ type jsonParam struct {
 	Type     string `json:"type"`
 }
 
+// jsonTriage represents the triage evidence of a finding in JSON.
+type jsonTriage struct {
+	Summary           string  `json:"summary"`
+	InitialConfidence float64 `json:"initial_confidence"`
+	Repeats           int     `json:"repeats"`
+	RepeatsFired      int     `json:"repeats_fired"`
+	NegativeRun       bool    `json:"negative_run"`
+	NegativeFired     bool    `json:"negative_fired"`
+	Placebo           string  `json:"placebo,omitempty"`
+	PlaceboFired      bool    `json:"placebo_fired"`
+	Downgraded        bool    `json:"downgraded"`
+}
+
 // jsonSummary represents the summary in JSON.
 type jsonSummary struct {
 	TotalVulnerabilities int `json:"total_vulnerabilities"`
 	AffectedParameters   int `json:"affected_parameters"`
+	SkippedParameters    int `json:"skipped_parameters,omitempty"`
 }
 
 // paramTypeString converts a ParameterType to a human-readable string.
@@ -90,64 +224,115 @@ func paramTypeString(t engine.ParameterType) string {
 
 // Generate writes JSON scan results to w.
 func (r *JSONReporter) Generate(ctx context.Context, result *engine.ScanResult, w io.Writer) error {
+	return r.Render(ctx, NewView(result), w)
+}
+
+// Render writes a report view as JSON to w.
+func (r *JSONReporter) Render(ctx context.Context, v *View, w io.Writer) error {
 	if err := ctx.Err(); err != nil {
 		return err
 	}
 
-	duration := result.EndTime.Sub(result.StartTime)
-
 	output := jsonOutput{
-		SchemaVersion: "1.0",
-		Tool:          "sqleech",
-		Target: jsonTarget{
-			URL:    result.Target.URL,
-			Method: result.Target.Method,
-		},
+		SchemaVersion: v.SchemaVersion,
+		Tool:          v.Tool,
+		Target:        jsonTarget(v.Target),
+		DBMS:          newJSONDBMS(v.DBMS),
 		Scan: jsonScan{
-			StartTime:       result.StartTime,
-			EndTime:         result.EndTime,
-			DurationSeconds: duration.Seconds(),
-			TotalRequests:   result.RequestCount,
-		},
-		Vulnerabilities: make([]jsonVuln, 0, len(result.Vulnerabilities)),
-		Summary: jsonSummary{
-			TotalVulnerabilities: len(result.Vulnerabilities),
-			AffectedParameters:  countAffectedParameters(result.Vulnerabilities),
+			StartTime:       v.Scan.StartTime,
+			EndTime:         v.Scan.EndTime,
+			DurationSeconds: v.Scan.DurationSeconds,
+			TotalRequests:   v.Scan.TotalRequests,
+			UnsafeWrites:    v.Scan.UnsafeWrites,
+
+			UnansweredProbes: v.Scan.UnansweredProbes,
+			Truncated:        v.Scan.Truncated,
+			RequestBudget:    v.Scan.RequestBudget,
 		},
+		Vulnerabilities: make([]jsonVuln, 0, len(v.Vulnerabilities)),
+		Summary:         jsonSummary(v.Summary),
+		Errors:          v.Errors,
+		Warnings:        v.Warnings,
+		Conflicts:       v.Conflicts,
 	}
 
-	// DBMS (omitted if not detected)
-	if result.DBMS != "" {
-		output.DBMS = &jsonDBMS{
-			Name:    result.DBMS,
-			Version: result.DBMSVersion,
+	for _, vv := range v.Vulnerabilities {
+		jv := jsonVuln{
+			Parameter:  jsonParam(vv.Parameter),
+			Technique:  vv.Technique,
+			DBMS:       vv.DBMS,
+			Payload:    vv.Payload,
+			Confidence: vv.Confidence,
+			Severity:   vv.Severity,
+			Evidence:   vv.Evidence,
+			Protocol:   vv.Protocol,
+			Sources:    vv.Sources,
+
+			ConfirmedBy: vv.ConfirmedBy,
 		}
+		if vv.PairedParameter != nil {
+			p := jsonParam(*vv.PairedParameter)
+			jv.PairedParameter = &p
+			jv.PairedPayload = vv.PairedPayload
+		}
+		if vv.Triage != nil {
+			t := jsonTriage(*vv.Triage)
+			jv.Triage = &t
+		}
+		if vv.Target != nil {
+			t := jsonTarget(*vv.Target)
+			jv.Target = &t
+		}
+		output.Vulnerabilities = append(output.Vulnerabilities, jv)
 	}
 
-	// Vulnerabilities
-	for _, v := range result.Vulnerabilities {
-		output.Vulnerabilities = append(output.Vulnerabilities, jsonVuln{
-			Parameter: jsonParam{
-				Name:     v.Parameter.Name,
-				Location: v.Parameter.Location.String(),
-				Type:     paramTypeString(v.Parameter.Type),
-			},
-			Technique:  v.Technique,
-			DBMS:       v.DBMS,
-			Payload:    v.Payload,
-			Confidence: v.Confidence,
-			Severity:   v.Severity.String(),
-			Evidence:   v.Evidence,
-		})
+	for _, sk := range v.Skipped {
+		js := jsonSkip{Parameter: jsonParam(sk.Parameter), Reason: sk.Reason}
+		if sk.Target != nil {
+			t := jsonTarget(*sk.Target)
+			js.Target = &t
+		}
+		output.Skipped = append(output.Skipped, js)
 	}
 
-	// Errors
-	if len(result.Errors) > 0 {
-		output.Errors = make([]string, len(result.Errors))
-		for i, e := range result.Errors {
-			output.Errors[i] = e.Error()
+	output.TargetProfile = newJSONProfile(v.Profile)
+	if v.DBA != nil {
+		d := jsonDBA(*v.DBA)
+		output.DBA = &d
+	}
+	for _, x := range v.Extractions {
+		output.Extractions = append(output.Extractions, jsonExtraction(x))
+	}
+	if t := v.Transport; t != nil {
+		output.Transport = &jsonTransport{
+			StatusCounts:  t.StatusCounts,
+			Errors:        t.Errors,
+			Retries:       t.Retries,
+			BytesReceived: t.BytesReceived,
+			MinSeconds:    t.MinDuration.Seconds(),
+			MaxSeconds:    t.MaxDuration.Seconds(),
+			P50Seconds:    t.P50Duration.Seconds(),
+			P95Seconds:    t.P95Duration.Seconds(),
+			P99Seconds:    t.P99Duration.Seconds(),
 		}
 	}
+	for _, job := range v.Jobs {
+		output.Jobs = append(output.Jobs, jsonJob{
+			Parameter:    jsonParam(job.Parameter),
+			Technique:    job.Technique,
+			QueueSeconds: job.QueueWait.Seconds(),
+			ExecSeconds:  job.Exec.Seconds(),
+			Error:        job.Error,
+		})
+	}
+
+	for _, src := range v.Sources {
+		output.Sources = append(output.Sources, jsonSource{
+			Name:   src.Name,
+			Target: jsonTarget(src.Target),
+			DBMS:   newJSONDBMS(src.DBMS),
+		})
+	}
 
 	enc := json.NewEncoder(w)
 	if !r.Compact {
@@ -155,3 +340,36 @@ func (r *JSONReporter) Generate(ctx context.Context, result *engine.ScanResult,
 	}
 	return enc.Encode(output)
 }
+
+// newJSONProfile returns the JSON form of p, or nil when there is none.
+func newJSONProfile(p *ViewProfile) *jsonProfile {
+	if p == nil {
+		return nil
+	}
+	jp := &jsonProfile{
+		Informational:  true,
+		URL:            p.URL,
+		Redirects:      p.Redirects,
+		Protocol:       p.Protocol,
+		Server:         p.Server,
+		PoweredBy:      p.PoweredBy,
+		PresentHeaders: p.PresentHeaders,
+		MissingHeaders: p.MissingHeaders,
+
+		ClosesConnections: p.ClosesConnections,
+	}
+	if p.TLS != nil {
+		t := jsonTLS(*p.TLS)
+		jp.TLS = &t
+	}
+	return jp
+}
+
+// newJSONDBMS returns the JSON form of d, or nil when no DBMS was detected.
+func newJSONDBMS(d ViewDBMS) *jsonDBMS {
+	if d.Name == "" {
+		return nil
+	}
+	j := jsonDBMS(d)
+	return &j
+}
@@ -4,7 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/0x6d61/sqleech/internal/engine"
 )
@@ -203,6 +207,83 @@ func TestJSONReporter_Generate_DBMS(t *testing.T) {
 	}
 }
 
+func TestJSONReporter_Generate_DBMSBanner(t *testing.T) {
+	r := &JSONReporter{}
+	result := SampleResult()
+
+	var buf bytes.Buffer
+	if err := r.Generate(context.Background(), result, &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+
+	var output jsonOutput
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("failed to unmarshal JSON: %v", err)
+	}
+	want := jsonDBMS{
+		Name: "MySQL", Version: "8.0.32", Banner: "8.0.32-0ubuntu0.22.04.2",
+		CurrentUser: "shop@localhost", CurrentDB: "shop", Hostname: "db01",
+	}
+	if output.DBMS == nil || *output.DBMS != want {
+		t.Errorf("dbms = %+v, want %+v", output.DBMS, want)
+	}
+	if output.DBA == nil || output.DBA.IsDBA || output.DBA.Value != "0" || output.DBA.Query == "" {
+		t.Errorf("dba = %+v, want not a DBA with its evidence query", output.DBA)
+	}
+}
+
+func TestJSONReporter_Generate_Extractions(t *testing.T) {
+	r := &JSONReporter{}
+
+	var buf bytes.Buffer
+	if err := r.Generate(context.Background(), SampleResult(), &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+
+	var output jsonOutput
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("failed to unmarshal JSON: %v", err)
+	}
+	if len(output.Extractions) != 1 {
+		t.Fatalf("extractions = %+v, want one", output.Extractions)
+	}
+	x := output.Extractions[0]
+	if x.Query != "SELECT username FROM users" || strings.Join(x.Values, ",") != "admin,guest" ||
+		x.Technique != "error-based" || x.Parameter != "id" {
+		t.Errorf("extraction = %+v", x)
+	}
+}
+
+func TestJSONReporter_Generate_FilteredExtraction(t *testing.T) {
+	r := &JSONReporter{}
+	result := SampleResult()
+	result.Extractions = []engine.Extraction{filteredExtraction()}
+
+	var buf bytes.Buffer
+	if err := r.Generate(context.Background(), result, &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	var output jsonOutput
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("failed to unmarshal JSON: %v", err)
+	}
+	want := filteredExtraction()
+	x := output.Extractions[0]
+	if x.Filter != want.Filter || !slices.Equal(x.Values, want.Values) || !slices.Equal(x.Raw, want.Raw) ||
+		!slices.Equal(x.FilterNotes, want.FilterNotes) {
+		t.Errorf("extraction = %+v, want %+v", x, want)
+	}
+
+	// Without a filter the raw values are not repeated.
+	buf.Reset()
+	if err := r.Generate(context.Background(), SampleResult(), &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if strings.Contains(buf.String(), "raw_values") {
+		t.Errorf("unfiltered extraction has raw_values:\n%s", buf.String())
+	}
+}
+
 func TestJSONReporter_Generate_DBMSOmitted(t *testing.T) {
 	r := &JSONReporter{}
 	result := newEmptyScanResult()
@@ -247,6 +328,25 @@ func TestJSONReporter_Generate_Scan(t *testing.T) {
 	}
 }
 
+func TestJSONReporter_Generate_UnsafeWrites(t *testing.T) {
+	r := &JSONReporter{}
+	for _, unsafe := range []bool{false, true} {
+		result := newTestScanResult()
+		result.UnsafeWrites = unsafe
+
+		var buf bytes.Buffer
+		if err := r.Generate(context.Background(), result, &buf); err != nil {
+			t.Fatalf("Generate() error: %v", err)
+		}
+		// Always present, so consumers never mistake a missing key for
+		// a read-only scan.
+		want := fmt.Sprintf(`"unsafe_writes": %v`, unsafe)
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %s:\n%s", want, buf.String())
+		}
+	}
+}
+
 func TestJSONReporter_Generate_PrettyPrint(t *testing.T) {
 	r := &JSONReporter{Compact: false}
 	result := newTestScanResult()
@@ -323,6 +423,32 @@ func TestJSONReporter_Generate_Errors(t *testing.T) {
 	}
 }
 
+func TestJSONReporter_Generate_Warnings(t *testing.T) {
+	r := &JSONReporter{}
+	result := newTestScanResult()
+
+	var buf bytes.Buffer
+	if err := r.Generate(context.Background(), result, &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if strings.Contains(buf.String(), `"warnings"`) {
+		t.Error("warnings should be omitted when there are none")
+	}
+
+	result.Warnings = []string{"time-based: disabled"}
+	buf.Reset()
+	if err := r.Generate(context.Background(), result, &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	var output jsonOutput
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("failed to unmarshal JSON: %v", err)
+	}
+	if len(output.Warnings) != 1 || output.Warnings[0] != "time-based: disabled" {
+		t.Errorf("warnings = %v, want [time-based: disabled]", output.Warnings)
+	}
+}
+
 func TestJSONReporter_Generate_MultipleParameters(t *testing.T) {
 	r := &JSONReporter{}
 	result := newTestScanResult()
@@ -362,6 +488,43 @@ func TestJSONReporter_Generate_MultipleParameters(t *testing.T) {
 	}
 }
 
+func TestJSONReporter_Generate_PairedParameter(t *testing.T) {
+	r := &JSONReporter{}
+
+	var buf bytes.Buffer
+	if err := r.Generate(context.Background(), newSplitScanResult(), &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+
+	var output jsonOutput
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("failed to unmarshal JSON: %v", err)
+	}
+
+	v := output.Vulnerabilities[0]
+	if v.PairedParameter == nil {
+		t.Fatal("paired_parameter missing")
+	}
+	if v.PairedParameter.Name != "city" {
+		t.Errorf("paired_parameter.name = %q, want %q", v.PairedParameter.Name, "city")
+	}
+	if v.Payload != "admin'/*" || v.PairedPayload != "*/ AND 1=1-- -" {
+		t.Errorf("payload halves = %q / %q", v.Payload, v.PairedPayload)
+	}
+}
+
+func TestJSONReporter_Generate_PairedParameterOmitted(t *testing.T) {
+	r := &JSONReporter{}
+
+	var buf bytes.Buffer
+	if err := r.Generate(context.Background(), newTestScanResult(), &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("paired_parameter")) {
+		t.Errorf("paired_parameter should be omitted for single-parameter findings:\n%s", buf.String())
+	}
+}
+
 // containsNewlineAndIndent checks if the string has indentation.
 func containsNewlineAndIndent(s string) bool {
 	lines := splitLines(s)
@@ -386,3 +549,147 @@ func splitLines(s string) []string {
 	}
 	return lines
 }
+
+func TestJSONReporter_Generate_Skipped(t *testing.T) {
+	r := &JSONReporter{}
+	result := newTestScanResult()
+	result.Skipped = []engine.SkippedParameter{{
+		Parameter: engine.Parameter{Name: "amount", Location: engine.LocationBody, Type: engine.TypeFloat},
+		Reason:    `name matches action verb "amount"`,
+	}}
+
+	var buf bytes.Buffer
+	if err := r.Generate(context.Background(), result, &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+
+	var output jsonOutput
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("failed to unmarshal JSON: %v", err)
+	}
+	if output.Summary.SkippedParameters != 1 || len(output.Skipped) != 1 {
+		t.Fatalf("summary %+v, skipped %+v; want one skipped parameter", output.Summary, output.Skipped)
+	}
+	sk := output.Skipped[0]
+	if sk.Parameter.Name != "amount" || sk.Parameter.Location != "body" || sk.Reason != result.Skipped[0].Reason {
+		t.Errorf("skipped[0] = %+v", sk)
+	}
+}
+
+func TestJSONReporter_Generate_Profile(t *testing.T) {
+	r := &JSONReporter{}
+	result := newTestScanResult()
+	result.Profile = &engine.TargetProfile{
+		URL:            "http://example.com/",
+		Protocol:       "HTTP/1.1",
+		Server:         "Apache",
+		MissingHeaders: []string{"Content-Security-Policy"},
+	}
+
+	var buf bytes.Buffer
+	if err := r.Generate(context.Background(), result, &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+
+	var output jsonOutput
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("failed to unmarshal JSON: %v", err)
+	}
+	p := output.TargetProfile
+	if p == nil || !p.Informational || p.Server != "Apache" || p.TLS != nil {
+		t.Fatalf("target_profile = %+v", p)
+	}
+	if strings.Contains(buf.String(), `"tls"`) {
+		t.Errorf("plain HTTP profile should omit tls:\n%s", buf.String())
+	}
+	if output.Summary.TotalVulnerabilities != len(result.Vulnerabilities) {
+		t.Errorf("summary = %+v", output.Summary)
+	}
+}
+
+func TestJSONReporter_Generate_Transport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&JSONReporter{}).Generate(context.Background(), SampleResult(), &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	var output jsonOutput
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("failed to unmarshal JSON: %v", err)
+	}
+	tr := output.Transport
+	if tr == nil {
+		t.Fatal("transport missing")
+	}
+	if tr.StatusCounts[200] != 81 || tr.StatusCounts[500] != 5 || tr.Errors != 1 || tr.Retries != 2 || tr.BytesReceived != 412_300 {
+		t.Errorf("transport = %+v", tr)
+	}
+	if seconds(tr.MinSeconds) != 12*time.Millisecond || seconds(tr.P95Seconds) != 95*time.Millisecond || seconds(tr.MaxSeconds) != 1840*time.Millisecond {
+		t.Errorf("transport times = %v, %v, %v, want 0.012, 0.095, 1.84", tr.MinSeconds, tr.P95Seconds, tr.MaxSeconds)
+	}
+
+	buf.Reset()
+	if err := (&JSONReporter{}).Generate(context.Background(), newTestScanResult(), &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if strings.Contains(buf.String(), `"transport"`) {
+		t.Errorf("transport written for a scan without transport statistics:\n%s", buf.String())
+	}
+}
+
+func TestJSONReporter_Generate_Protocol(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&JSONReporter{}).Generate(context.Background(), SampleResult(), &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	var output jsonOutput
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("failed to unmarshal JSON: %v", err)
+	}
+	if got := output.Vulnerabilities[0].Protocol; got != "HTTP/1.1" {
+		t.Errorf("vulnerabilities[0].protocol = %q, want HTTP/1.1", got)
+	}
+	if strings.Count(buf.String(), `"protocol"`) != 2 {
+		t.Errorf("want protocol on the first finding and the profile only:\n%s", buf.String())
+	}
+}
+
+func TestJSONReporter_Generate_ConfirmedBy(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&JSONReporter{}).Generate(context.Background(), SampleResult(), &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+
+	var output jsonOutput
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("failed to unmarshal JSON: %v", err)
+	}
+	want := []string{"error-based", "boolean-blind", "union-based"}
+	if got := output.Vulnerabilities[0].ConfirmedBy; !slices.Equal(got, want) {
+		t.Errorf("confirmed_by = %q, want %q", got, want)
+	}
+	if got := output.Vulnerabilities[1].ConfirmedBy; got != nil {
+		t.Errorf("confirmed_by = %q on a finding without it, want it omitted", got)
+	}
+}
+
+func TestJSONReporter_Generate_Jobs(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&JSONReporter{}).Generate(context.Background(), SampleResult(), &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+
+	var output jsonOutput
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("failed to unmarshal JSON: %v", err)
+	}
+	if len(output.Jobs) != 2 {
+		t.Fatalf("jobs = %+v, want the sample's 2", output.Jobs)
+	}
+	done, timedOut := output.Jobs[0], output.Jobs[1]
+	if done.Parameter.Name != "id" || done.Technique != "error-based" || done.ExecSeconds != 0.31 || done.Error != "" {
+		t.Errorf("jobs[0] = %+v, want error-based on id in 0.31s without error", done)
+	}
+	if timedOut.ExecSeconds != 1800 || timedOut.Error != "job timed out after 30m0s" {
+		t.Errorf("jobs[1] = %+v, want the time-based timeout after 1800s", timedOut)
+	}
+}
@@ -0,0 +1,329 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+)
+
+// Source is a report to merge, named for attribution, usually by its file.
+type Source struct {
+	Name string
+	View *View
+}
+
+// FindingID identifies a finding across reports: the same injection point
+// and technique on the same target. target is the report's target, used
+// when the finding carries none of its own.
+func (v ViewVuln) FindingID(target ViewTarget) string {
+	if v.Target != nil {
+		target = *v.Target
+	}
+	key := strings.Join([]string{
+		target.Method, target.URL,
+		v.Parameter.Location, v.Parameter.Name,
+		v.Technique,
+	}, "\x00")
+	if v.PairedParameter != nil {
+		key += "\x00" + v.PairedParameter.Location + "\x00" + v.PairedParameter.Name
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Merge combines reports into one view. Findings with the same FindingID
+// are reported once, with the details of the most confident one; every
+// finding records its target and the reports that found it. Scan times
+// span all reports and request counts add up, as do transport counts;
+// response time percentiles and job timings are dropped. Parameters skipped for
+// safety are listed once per target. The target profile and DBA check of
+// the most recent scan are kept when all reports share one target. Metadata the reports
+// disagree on -- the tool, or the DBMS of one target -- is listed in
+// Conflicts. A merged report may itself be merged again.
+func Merge(sources []Source) *View {
+	m := &View{
+		SchemaVersion:   ViewSchemaVersion,
+		Vulnerabilities: []ViewVuln{},
+	}
+
+	index := make(map[string]int) // FindingID -> position in m.Vulnerabilities
+	skipped := make(map[string]bool)
+	tools := make(map[string][]string)
+	var toolOrder []string
+	var profile *ViewProfile
+	var profileEnd time.Time
+	var dba *ViewDBA
+	var dbaEnd time.Time
+
+	for _, src := range sources {
+		v := src.View
+		if _, ok := tools[v.Tool]; !ok {
+			toolOrder = append(toolOrder, v.Tool)
+		}
+		tools[v.Tool] = append(tools[v.Tool], src.Name)
+
+		// A merged report contributes its own sources.
+		if len(v.Sources) > 0 {
+			m.Sources = append(m.Sources, v.Sources...)
+		} else {
+			m.Sources = append(m.Sources, ViewSource{Name: src.Name, Target: v.Target, DBMS: v.DBMS})
+		}
+
+		if m.Scan.StartTime.IsZero() || v.Scan.StartTime.Before(m.Scan.StartTime) {
+			m.Scan.StartTime = v.Scan.StartTime
+		}
+		if v.Scan.EndTime.After(m.Scan.EndTime) {
+			m.Scan.EndTime = v.Scan.EndTime
+		}
+		m.Scan.TotalRequests += v.Scan.TotalRequests
+		if v.Profile != nil && (profile == nil || v.Scan.EndTime.After(profileEnd)) {
+			profile, profileEnd = v.Profile, v.Scan.EndTime
+		}
+		if v.DBA != nil && (dba == nil || v.Scan.EndTime.After(dbaEnd)) {
+			dba, dbaEnd = v.DBA, v.Scan.EndTime
+		}
+		m.Extractions = append(m.Extractions, v.Extractions...)
+		m.Scan.UnsafeWrites = m.Scan.UnsafeWrites || v.Scan.UnsafeWrites
+		m.Scan.UnansweredProbes += v.Scan.UnansweredProbes
+		m.Scan.Truncated = m.Scan.Truncated || v.Scan.Truncated
+		m.Scan.RequestBudget = max(m.Scan.RequestBudget, v.Scan.RequestBudget)
+		m.Transport = mergeTransport(m.Transport, v.Transport)
+
+		for _, e := range v.Errors {
+			if len(v.Sources) > 0 {
+				m.Errors = append(m.Errors, e)
+			} else {
+				m.Errors = append(m.Errors, src.Name+": "+e)
+			}
+		}
+		for _, w := range v.Warnings {
+			if len(v.Sources) > 0 {
+				m.Warnings = append(m.Warnings, w)
+			} else {
+				m.Warnings = append(m.Warnings, src.Name+": "+w)
+			}
+		}
+		m.Conflicts = append(m.Conflicts, v.Conflicts...)
+
+		for _, sk := range v.Skipped {
+			if sk.Target == nil {
+				t := v.Target
+				sk.Target = &t
+			}
+			if !skipped[skipKey(sk)] {
+				skipped[skipKey(sk)] = true
+				m.Skipped = append(m.Skipped, sk)
+			}
+		}
+
+		for _, vv := range v.Vulnerabilities {
+			vv = attribute(vv, v.Target, src.Name)
+			id := vv.FindingID(v.Target)
+			i, seen := index[id]
+			if !seen {
+				index[id] = len(m.Vulnerabilities)
+				m.Vulnerabilities = append(m.Vulnerabilities, vv)
+				continue
+			}
+			prev := m.Vulnerabilities[i]
+			found := appendNew(prev.Sources, vv.Sources...)
+			if vv.Confidence > prev.Confidence {
+				prev = vv
+			}
+			prev.Sources = found
+			m.Vulnerabilities[i] = prev
+		}
+	}
+
+	m.Tool = "sqleech"
+	if len(toolOrder) > 0 {
+		m.Tool = toolOrder[0]
+	}
+	if len(toolOrder) > 1 {
+		parts := make([]string, len(toolOrder))
+		for i, tool := range toolOrder {
+			parts[i] = fmt.Sprintf("%s (%s)", tool, strings.Join(tools[tool], ", "))
+		}
+		m.Conflicts = append(m.Conflicts, "tool: "+strings.Join(parts, " vs "))
+	}
+
+	// The target and DBMS carry over when every report agrees on them.
+	targets := make(map[ViewTarget]bool)
+	for _, src := range m.Sources {
+		targets[src.Target] = true
+	}
+	if len(targets) == 1 {
+		m.Target = m.Sources[0].Target
+		m.Profile = profile
+		m.DBA = dba
+	}
+	m.DBMS, m.Conflicts = mergeDBMS(m.Sources, m.Conflicts)
+	// Conflicts of merged inputs are found again over their sources.
+	m.Conflicts = appendNew(nil, m.Conflicts...)
+
+	m.Scan.Duration = m.Scan.EndTime.Sub(m.Scan.StartTime)
+	m.Scan.DurationSeconds = m.Scan.Duration.Seconds()
+	m.Summary = ViewSummary{
+		TotalVulnerabilities: len(m.Vulnerabilities),
+		AffectedParameters:   countMergedParameters(m.Vulnerabilities),
+		SkippedParameters:    len(m.Skipped),
+	}
+	return m
+}
+
+// MergeResults merges the results of one batch scan (see
+// engine.Scanner.ScanAll), each named by its target URL. The scans shared
+// a client, whose statistics each result holds as they stood when it
+// ended, so the transport statistics are those of the last to end rather
+// than a sum.
+func MergeResults(results []*engine.ScanResult) *View {
+	sources := make([]Source, len(results))
+	var last *engine.ScanResult
+	for i, r := range results {
+		sources[i] = Source{Name: r.Target.URL, View: NewView(r)}
+		if r.Transport != nil && (last == nil || r.EndTime.After(last.EndTime)) {
+			last = r
+		}
+	}
+	m := Merge(sources)
+	if last != nil {
+		m.Transport = NewView(last).Transport
+	}
+	return m
+}
+
+// attribute returns vv with its target and source set, unless a merge
+// already set them.
+func attribute(vv ViewVuln, target ViewTarget, source string) ViewVuln {
+	if vv.Target == nil {
+		t := target
+		vv.Target = &t
+	}
+	if len(vv.Sources) == 0 {
+		vv.Sources = []string{source}
+	} else {
+		vv.Sources = append([]string(nil), vv.Sources...)
+	}
+	return vv
+}
+
+// mergeTransport adds the transport statistics of v to m, either of which
+// may be nil. Percentiles do not add up and are left out.
+func mergeTransport(m, v *ViewTransport) *ViewTransport {
+	if v == nil {
+		return m
+	}
+	if m == nil {
+		return &ViewTransport{
+			StatusCounts:  maps.Clone(v.StatusCounts),
+			Errors:        v.Errors,
+			Retries:       v.Retries,
+			BytesReceived: v.BytesReceived,
+			MinDuration:   v.MinDuration,
+			MaxDuration:   v.MaxDuration,
+		}
+	}
+	if m.StatusCounts == nil {
+		m.StatusCounts = make(map[int]int64)
+	}
+	for code, n := range v.StatusCounts {
+		m.StatusCounts[code] += n
+	}
+	m.Errors += v.Errors
+	m.Retries += v.Retries
+	m.BytesReceived += v.BytesReceived
+	if m.MinDuration == 0 || v.MinDuration > 0 && v.MinDuration < m.MinDuration {
+		m.MinDuration = v.MinDuration
+	}
+	m.MaxDuration = max(m.MaxDuration, v.MaxDuration)
+	return m
+}
+
+// mergeDBMS returns the DBMS every source detected, or the zero ViewDBMS
+// unless they all agree. Sources of one target that detected different
+// DBMSs are added to conflicts; a DBMS per target is expected otherwise,
+// and so is a scan that detected none.
+func mergeDBMS(sources []ViewSource, conflicts []string) (ViewDBMS, []string) {
+	var common ViewDBMS
+	agree := true
+	byTarget := make(map[ViewTarget][]ViewSource)
+	var order []ViewTarget
+	for i, src := range sources {
+		if i == 0 {
+			common = src.DBMS
+		} else if src.DBMS != common {
+			agree = false
+		}
+		if src.DBMS.Name == "" {
+			continue
+		}
+		if _, ok := byTarget[src.Target]; !ok {
+			order = append(order, src.Target)
+		}
+		byTarget[src.Target] = append(byTarget[src.Target], src)
+	}
+
+	for _, target := range order {
+		srcs := byTarget[target]
+		differ := false
+		for _, src := range srcs[1:] {
+			if src.DBMS != srcs[0].DBMS {
+				differ = true
+			}
+		}
+		if !differ {
+			continue
+		}
+		parts := make([]string, len(srcs))
+		for i, src := range srcs {
+			parts[i] = fmt.Sprintf("%s (%s)", strings.TrimSpace(src.DBMS.Name+" "+src.DBMS.Version), src.Name)
+		}
+		conflicts = append(conflicts, fmt.Sprintf("dbms of %s %s: %s", target.Method, target.URL, strings.Join(parts, " vs ")))
+	}
+
+	if !agree {
+		return ViewDBMS{}, conflicts
+	}
+	return common, conflicts
+}
+
+// skipKey identifies a skipped parameter of a merged view.
+func skipKey(sk ViewSkipped) string {
+	return strings.Join([]string{sk.Target.Method, sk.Target.URL, sk.Parameter.Location, sk.Parameter.Name}, "\x00")
+}
+
+// countMergedParameters counts distinct parameters, per target, that have
+// findings.
+func countMergedParameters(vulns []ViewVuln) int {
+	seen := make(map[string]struct{})
+	for _, v := range vulns {
+		key := v.Parameter.Name + ":" + v.Parameter.Location
+		if v.Target != nil {
+			key = v.Target.Method + " " + v.Target.URL + "\x00" + key
+		}
+		seen[key] = struct{}{}
+	}
+	return len(seen)
+}
+
+// appendNew appends the items of add not already in list.
+func appendNew(list []string, add ...string) []string {
+	for _, s := range add {
+		found := false
+		for _, have := range list {
+			if have == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, s)
+		}
+	}
+	return list
+}
@@ -0,0 +1,304 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// renderJSON renders v as a JSON report.
+func renderJSON(t *testing.T, v *View) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := (&JSONReporter{}).Render(context.Background(), v, &buf); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	return buf.String()
+}
+
+func TestMerge_Deduplicates(t *testing.T) {
+	first := newTestScanResult() // error-based 0.95, boolean-blind 0.85 on id
+
+	second := newTestScanResult()
+	second.StartTime = first.StartTime.Add(time.Hour)
+	second.EndTime = second.StartTime.Add(10 * time.Second)
+	second.RequestCount = 53
+	second.Vulnerabilities[0].Confidence = 0.9 // less confident: first kept
+	second.Vulnerabilities[1].Confidence = 0.99
+	second.Vulnerabilities[1].Evidence = "Stable TRUE/FALSE difference"
+	second.Errors = []error{errors.New("time-based: timeout")}
+	second.Warnings = []string{"time-based: disabled"}
+
+	m := Merge([]Source{
+		{Name: "a.json", View: readBack(t, first)},
+		{Name: "b.json", View: readBack(t, second)},
+	})
+
+	if len(m.Vulnerabilities) != 2 || m.Summary.TotalVulnerabilities != 2 || m.Summary.AffectedParameters != 1 {
+		t.Fatalf("merged %d findings (summary %+v), want 2 in 1 parameter", len(m.Vulnerabilities), m.Summary)
+	}
+	errBased, boolBlind := m.Vulnerabilities[0], m.Vulnerabilities[1]
+	if errBased.Confidence != 0.95 || boolBlind.Confidence != 0.99 || boolBlind.Evidence != "Stable TRUE/FALSE difference" {
+		t.Errorf("did not keep the most confident findings: %+v", m.Vulnerabilities)
+	}
+	for _, v := range m.Vulnerabilities {
+		if !reflect.DeepEqual(v.Sources, []string{"a.json", "b.json"}) {
+			t.Errorf("%s: sources = %v", v.Technique, v.Sources)
+		}
+		if v.Target == nil || v.Target.URL != first.Target.URL {
+			t.Errorf("%s: target = %+v", v.Technique, v.Target)
+		}
+	}
+
+	if m.Target.URL != first.Target.URL || m.DBMS.Name != "MySQL" || len(m.Conflicts) != 0 {
+		t.Errorf("target %+v, dbms %+v, conflicts %v", m.Target, m.DBMS, m.Conflicts)
+	}
+	if m.Scan.TotalRequests != first.RequestCount+53 {
+		t.Errorf("TotalRequests = %d", m.Scan.TotalRequests)
+	}
+	if !m.Scan.StartTime.Equal(first.StartTime) || !m.Scan.EndTime.Equal(second.EndTime) {
+		t.Errorf("scan spans %v to %v", m.Scan.StartTime, m.Scan.EndTime)
+	}
+	if len(m.Errors) != 1 || m.Errors[0] != "b.json: time-based: timeout" {
+		t.Errorf("errors = %v", m.Errors)
+	}
+	if len(m.Warnings) != 1 || m.Warnings[0] != "b.json: time-based: disabled" {
+		t.Errorf("warnings = %v", m.Warnings)
+	}
+}
+
+func TestMerge_Targets(t *testing.T) {
+	split := newSplitScanResult()
+	m := Merge([]Source{
+		{Name: "a.json", View: readBack(t, newTestScanResult())},
+		{Name: "b.json", View: readBack(t, split)},
+	})
+
+	if m.Target != (ViewTarget{}) || m.DBMS != (ViewDBMS{}) {
+		t.Errorf("target %+v, dbms %+v; want none for different targets", m.Target, m.DBMS)
+	}
+	if len(m.Conflicts) != 0 {
+		t.Errorf("conflicts = %v; different targets do not conflict", m.Conflicts)
+	}
+	if len(m.Sources) != 2 || m.Sources[1].Target.URL != split.Target.URL {
+		t.Errorf("sources = %+v", m.Sources)
+	}
+	if len(m.Vulnerabilities) != 3 || m.Vulnerabilities[2].Target.URL != split.Target.URL {
+		t.Errorf("findings = %+v", m.Vulnerabilities)
+	}
+	if m.Summary.AffectedParameters != 2 {
+		t.Errorf("AffectedParameters = %d, want 2", m.Summary.AffectedParameters)
+	}
+
+	var buf bytes.Buffer
+	if err := (&TextReporter{}).Render(context.Background(), m, &buf); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	for _, want := range []string{
+		"Merged from 2 reports:\n  - a.json (GET http://example.com/page?id=1)",
+		"  Target:     GET http://example.com/safe?name=test\n",
+		"  Found in:   b.json\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("text output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestMerge_Conflicts(t *testing.T) {
+	pg := newTestScanResult()
+	pg.DBMS, pg.DBMSVersion = "PostgreSQL", "15.3"
+	other := readBack(t, newTestScanResult())
+	other.Tool = "sqleech-fork"
+
+	m := Merge([]Source{
+		{Name: "a.json", View: readBack(t, newTestScanResult())},
+		{Name: "b.json", View: readBack(t, pg)},
+		{Name: "c.json", View: other},
+	})
+
+	want := []string{
+		"tool: sqleech (a.json, b.json) vs sqleech-fork (c.json)",
+		"dbms of GET http://example.com/page?id=1: MySQL 8.0.32 (a.json) vs PostgreSQL 15.3 (b.json) vs MySQL 8.0.32 (c.json)",
+	}
+	if !reflect.DeepEqual(m.Conflicts, want) {
+		t.Errorf("conflicts =\n%q\nwant\n%q", m.Conflicts, want)
+	}
+	if m.DBMS != (ViewDBMS{}) {
+		t.Errorf("DBMS = %+v, want none when reports disagree", m.DBMS)
+	}
+
+	// The conflicts survive the JSON report.
+	back, err := ReadJSON(strings.NewReader(renderJSON(t, m)))
+	if err != nil {
+		t.Fatalf("ReadJSON: %v", err)
+	}
+	if !reflect.DeepEqual(back.Conflicts, want) {
+		t.Errorf("conflicts after round trip = %q", back.Conflicts)
+	}
+}
+
+func TestMerge_RoundTrip(t *testing.T) {
+	a := readBack(t, newTestScanResult())
+	b := readBack(t, newSplitScanResult())
+	c := newTestScanResult()
+	c.Vulnerabilities[0].Confidence = 1
+	cv := readBack(t, c)
+
+	merged := Merge([]Source{{Name: "a.json", View: a}, {Name: "b.json", View: b}})
+	out := renderJSON(t, merged)
+
+	// Converting the merged report again reproduces it.
+	back, err := ReadJSON(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("ReadJSON: %v", err)
+	}
+	if again := renderJSON(t, back); again != out {
+		t.Errorf("second conversion differs:\n%s\nwant\n%s", again, out)
+	}
+
+	// Merging a merged report is the same as merging its inputs.
+	stepwise := renderJSON(t, Merge([]Source{{Name: "ab.json", View: back}, {Name: "c.json", View: cv}}))
+	direct := renderJSON(t, Merge([]Source{{Name: "a.json", View: a}, {Name: "b.json", View: b}, {Name: "c.json", View: cv}}))
+	if stepwise != direct {
+		t.Errorf("stepwise merge differs:\n%s\nwant\n%s", stepwise, direct)
+	}
+}
+
+func TestViewVuln_FindingID(t *testing.T) {
+	target := ViewTarget{URL: "http://example.com/page?id=1", Method: "GET"}
+	v := NewView(newTestScanResult()).Vulnerabilities[0]
+
+	id := v.FindingID(target)
+	if len(id) != 16 {
+		t.Errorf("FindingID = %q, want 16 hex digits", id)
+	}
+	other := v
+	other.Payload, other.Confidence = "different payload", 0.1
+	if other.FindingID(target) != id {
+		t.Error("FindingID depends on the payload")
+	}
+	if v.FindingID(ViewTarget{URL: "http://other/", Method: "GET"}) == id {
+		t.Error("FindingID ignores the target")
+	}
+	other.Target = &ViewTarget{URL: "http://other/", Method: "GET"}
+	if other.FindingID(target) == id {
+		t.Error("FindingID ignores the finding's own target")
+	}
+	paired := NewView(newSplitScanResult()).Vulnerabilities[0]
+	unpaired := paired
+	unpaired.PairedParameter = nil
+	if paired.FindingID(target) == unpaired.FindingID(target) {
+		t.Error("FindingID ignores the paired parameter")
+	}
+}
+
+func TestMerge_Skipped(t *testing.T) {
+	other := SampleResult()
+	other.Target.URL = "http://example.com/other?delete=0"
+
+	m := Merge([]Source{
+		{Name: "a.json", View: readBack(t, SampleResult())},
+		{Name: "b.json", View: readBack(t, SampleResult())},
+		{Name: "c.json", View: readBack(t, other)},
+	})
+
+	// The same parameter skipped twice on one target is listed once.
+	if len(m.Skipped) != 2 || m.Summary.SkippedParameters != 2 {
+		t.Fatalf("skipped = %+v (summary %d), want 2", m.Skipped, m.Summary.SkippedParameters)
+	}
+	if m.Skipped[1].Target == nil || m.Skipped[1].Target.URL != other.Target.URL {
+		t.Errorf("skipped[1] target = %+v", m.Skipped[1].Target)
+	}
+
+	back, err := ReadJSON(strings.NewReader(renderJSON(t, m)))
+	if err != nil {
+		t.Fatalf("ReadJSON: %v", err)
+	}
+	if !reflect.DeepEqual(back.Skipped, m.Skipped) {
+		t.Errorf("skipped after round trip = %+v", back.Skipped)
+	}
+}
+
+func TestMerge_Profile(t *testing.T) {
+	older := SampleResult()
+	older.StartTime = older.StartTime.Add(-time.Hour)
+	older.EndTime = older.EndTime.Add(-time.Hour)
+	older.Profile.Server = "nginx/1.14.0"
+
+	m := Merge([]Source{
+		{Name: "new.json", View: readBack(t, SampleResult())},
+		{Name: "old.json", View: readBack(t, older)},
+	})
+	if m.Profile == nil || m.Profile.Server != "nginx/1.18.0" {
+		t.Errorf("profile = %+v, want the most recent scan's", m.Profile)
+	}
+
+	other := SampleResult()
+	other.Target.URL = "http://example.com/other"
+	m = Merge([]Source{
+		{Name: "a.json", View: readBack(t, SampleResult())},
+		{Name: "b.json", View: readBack(t, other)},
+	})
+	if m.Profile != nil {
+		t.Errorf("profile over two targets = %+v, want nil", m.Profile)
+	}
+}
+
+func TestMerge_Transport(t *testing.T) {
+	other := SampleResult()
+	other.Transport.StatusCounts = map[int]int64{200: 10, 403: 4}
+	other.Transport.MinDuration = 5 * time.Millisecond
+	other.Transport.MaxDuration = time.Second
+
+	m := Merge([]Source{
+		{Name: "a.json", View: readBack(t, SampleResult())},
+		{Name: "b.json", View: readBack(t, other)},
+		{Name: "c.json", View: readBack(t, newTestScanResult())},
+	})
+	tr := m.Transport
+	if tr == nil {
+		t.Fatal("transport missing")
+	}
+	if want := map[int]int64{200: 91, 403: 4, 500: 5}; !reflect.DeepEqual(tr.StatusCounts, want) {
+		t.Errorf("status counts = %v, want %v", tr.StatusCounts, want)
+	}
+	if tr.Errors != 2 || tr.Retries != 4 || tr.BytesReceived != 2*412_300 {
+		t.Errorf("transport = %+v", tr)
+	}
+	if tr.MinDuration != 5*time.Millisecond || tr.MaxDuration != 1840*time.Millisecond || tr.P50Duration != 0 {
+		t.Errorf("times: min %v, max %v, p50 %v; want 5ms, 1.84s and no percentile", tr.MinDuration, tr.MaxDuration, tr.P50Duration)
+	}
+}
+
+func TestMergeResults(t *testing.T) {
+	first := SampleResult()
+	last := newSplitScanResult()
+	last.EndTime = first.EndTime.Add(time.Minute)
+	last.Transport = &transport.TransportStats{
+		StatusCounts: map[int]int64{200: 120},
+		P95Duration:  300 * time.Millisecond,
+	}
+
+	m := MergeResults([]*engine.ScanResult{first, last})
+	if len(m.Sources) != 2 || m.Sources[0].Name != first.Target.URL || m.Sources[1].Name != last.Target.URL {
+		t.Errorf("sources = %+v, want one per target, named by URL", m.Sources)
+	}
+	if len(m.Vulnerabilities) != len(first.Vulnerabilities)+len(last.Vulnerabilities) {
+		t.Errorf("merged %d findings", len(m.Vulnerabilities))
+	}
+	if m.Scan.TotalRequests != first.RequestCount+last.RequestCount {
+		t.Errorf("TotalRequests = %d", m.Scan.TotalRequests)
+	}
+	// The shared client's statistics are not added up.
+	if tr := m.Transport; tr == nil || !reflect.DeepEqual(tr.StatusCounts, map[int]int64{200: 120}) || tr.P95Duration != 300*time.Millisecond {
+		t.Errorf("transport = %+v, want that of the last scan", tr)
+	}
+}
@@ -0,0 +1,214 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// ErrSchemaVersion is returned by ReadJSON for a report written with a
+// schema version this build cannot read.
+var ErrSchemaVersion = errors.New("unsupported report schema version")
+
+// ReadJSON parses a report written by JSONReporter back into a View,
+// validating it first. Rendering the View with JSONReporter reproduces the
+// report.
+func ReadJSON(r io.Reader) (*View, error) {
+	var in jsonOutput
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, fmt.Errorf("parse report: %w", err)
+	}
+	if err := validateJSON(&in); err != nil {
+		return nil, err
+	}
+
+	v := &View{
+		SchemaVersion: in.SchemaVersion,
+		Tool:          in.Tool,
+		Target:        ViewTarget(in.Target),
+		Scan: ViewScan{
+			StartTime:       in.Scan.StartTime,
+			EndTime:         in.Scan.EndTime,
+			Duration:        in.Scan.EndTime.Sub(in.Scan.StartTime),
+			DurationSeconds: in.Scan.DurationSeconds,
+			TotalRequests:   in.Scan.TotalRequests,
+			UnsafeWrites:    in.Scan.UnsafeWrites,
+
+			UnansweredProbes: in.Scan.UnansweredProbes,
+			Truncated:        in.Scan.Truncated,
+			RequestBudget:    in.Scan.RequestBudget,
+		},
+		Vulnerabilities: make([]ViewVuln, 0, len(in.Vulnerabilities)),
+		Summary:         ViewSummary(in.Summary),
+		Errors:          in.Errors,
+		Warnings:        in.Warnings,
+		Conflicts:       in.Conflicts,
+	}
+	if in.DBMS != nil {
+		v.DBMS = ViewDBMS(*in.DBMS)
+	}
+	if in.DBA != nil {
+		d := ViewDBA(*in.DBA)
+		v.DBA = &d
+	}
+	for _, x := range in.Extractions {
+		v.Extractions = append(v.Extractions, ViewExtraction(x))
+	}
+	if t := in.Transport; t != nil {
+		v.Transport = &ViewTransport{
+			StatusCounts:  t.StatusCounts,
+			Errors:        t.Errors,
+			Retries:       t.Retries,
+			BytesReceived: t.BytesReceived,
+			MinDuration:   seconds(t.MinSeconds),
+			MaxDuration:   seconds(t.MaxSeconds),
+			P50Duration:   seconds(t.P50Seconds),
+			P95Duration:   seconds(t.P95Seconds),
+			P99Duration:   seconds(t.P99Seconds),
+		}
+	}
+	for _, job := range in.Jobs {
+		v.Jobs = append(v.Jobs, ViewJob{
+			Parameter: ViewParam(job.Parameter),
+			Technique: job.Technique,
+			QueueWait: seconds(job.QueueSeconds),
+			Exec:      seconds(job.ExecSeconds),
+			Error:     job.Error,
+		})
+	}
+
+	for _, jv := range in.Vulnerabilities {
+		vv := ViewVuln{
+			Parameter:  ViewParam(jv.Parameter),
+			Technique:  jv.Technique,
+			DBMS:       jv.DBMS,
+			Payload:    jv.Payload,
+			Confidence: jv.Confidence,
+			Severity:   jv.Severity,
+			Evidence:   jv.Evidence,
+			Protocol:   jv.Protocol,
+			Sources:    jv.Sources,
+
+			ConfirmedBy: jv.ConfirmedBy,
+		}
+		if jv.PairedParameter != nil {
+			p := ViewParam(*jv.PairedParameter)
+			vv.PairedParameter = &p
+			vv.PairedPayload = jv.PairedPayload
+		}
+		if jv.Triage != nil {
+			t := ViewTriage(*jv.Triage)
+			vv.Triage = &t
+		}
+		if jv.Target != nil {
+			t := ViewTarget(*jv.Target)
+			vv.Target = &t
+		}
+		v.Vulnerabilities = append(v.Vulnerabilities, vv)
+	}
+
+	for _, js := range in.Skipped {
+		sk := ViewSkipped{Parameter: ViewParam(js.Parameter), Reason: js.Reason}
+		if js.Target != nil {
+			t := ViewTarget(*js.Target)
+			sk.Target = &t
+		}
+		v.Skipped = append(v.Skipped, sk)
+	}
+
+	if jp := in.TargetProfile; jp != nil {
+		v.Profile = &ViewProfile{
+			URL:            jp.URL,
+			Redirects:      jp.Redirects,
+			Protocol:       jp.Protocol,
+			Server:         jp.Server,
+			PoweredBy:      jp.PoweredBy,
+			PresentHeaders: jp.PresentHeaders,
+			MissingHeaders: jp.MissingHeaders,
+
+			ClosesConnections: jp.ClosesConnections,
+		}
+		if jp.TLS != nil {
+			t := ViewTLS(*jp.TLS)
+			v.Profile.TLS = &t
+		}
+	}
+
+	for _, src := range in.Sources {
+		vs := ViewSource{Name: src.Name, Target: ViewTarget(src.Target)}
+		if src.DBMS != nil {
+			vs.DBMS = ViewDBMS(*src.DBMS)
+		}
+		v.Sources = append(v.Sources, vs)
+	}
+
+	return v, nil
+}
+
+// seconds converts a duration in seconds, as reports write them, rounded
+// to the nanosecond so that it writes back the same.
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Round(s * float64(time.Second)))
+}
+
+// ReadJSONFile reads the JSON report at path; see ReadJSON.
+func ReadJSONFile(path string) (*View, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	v, err := ReadJSON(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return v, nil
+}
+
+// validSeverities are the severity names reports carry.
+var validSeverities = map[string]bool{
+	"CRITICAL": true, "HIGH": true, "MEDIUM": true, "LOW": true, "INFO": true,
+}
+
+// validateJSON checks a decoded report against the schema JSONReporter
+// writes.
+func validateJSON(in *jsonOutput) error {
+	if in.SchemaVersion == "" {
+		return errors.New("not a sqleech report: missing schema_version")
+	}
+	if in.SchemaVersion != ViewSchemaVersion {
+		return fmt.Errorf("%w %q (this build reads %q)", ErrSchemaVersion, in.SchemaVersion, ViewSchemaVersion)
+	}
+	if in.Tool == "" {
+		return errors.New("invalid report: missing tool")
+	}
+	if in.Target.URL == "" && len(in.Sources) == 0 {
+		return errors.New("invalid report: missing target.url")
+	}
+	if in.Summary.TotalVulnerabilities != len(in.Vulnerabilities) {
+		return fmt.Errorf("invalid report: summary counts %d vulnerabilities, report lists %d",
+			in.Summary.TotalVulnerabilities, len(in.Vulnerabilities))
+	}
+	if in.Summary.SkippedParameters != len(in.Skipped) {
+		return fmt.Errorf("invalid report: summary counts %d skipped parameters, report lists %d",
+			in.Summary.SkippedParameters, len(in.Skipped))
+	}
+	for i, jv := range in.Vulnerabilities {
+		switch {
+		case jv.Parameter.Name == "":
+			return fmt.Errorf("invalid report: vulnerabilities[%d]: missing parameter name", i)
+		case jv.Technique == "":
+			return fmt.Errorf("invalid report: vulnerabilities[%d]: missing technique", i)
+		case !validSeverities[jv.Severity]:
+			return fmt.Errorf("invalid report: vulnerabilities[%d]: unknown severity %q", i, jv.Severity)
+		case jv.Confidence < 0 || jv.Confidence > 1:
+			return fmt.Errorf("invalid report: vulnerabilities[%d]: confidence %v out of range", i, jv.Confidence)
+		}
+	}
+	return nil
+}
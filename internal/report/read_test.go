@@ -0,0 +1,127 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+)
+
+// readBack writes result as a JSON report and reads it back.
+func readBack(t *testing.T, result *engine.ScanResult) *View {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := (&JSONReporter{}).Generate(context.Background(), result, &buf); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	v, err := ReadJSON(&buf)
+	if err != nil {
+		t.Fatalf("ReadJSON: %v\n%s", err, buf.String())
+	}
+	return v
+}
+
+func TestReadJSON_Fidelity(t *testing.T) {
+	unsafe := SampleResult()
+	unsafe.UnsafeWrites = true
+	unsafe.Warnings = []string{"time-based: raised the sleep from 5s to 8s"}
+	unsafe.Unanswered = map[string]int{"reset": 2, "closed": 1}
+	unsafe.Profile.ClosesConnections = true
+	unsafe.Extractions = append(unsafe.Extractions, filteredExtraction())
+	results := map[string]*engine.ScanResult{
+		"vulns":  newTestScanResult(),
+		"empty":  newEmptyScanResult(),
+		"split":  newSplitScanResult(),
+		"triage": newTriagedScanResult(),
+		"sample": unsafe,
+	}
+
+	htmlTmpl, err := NewTemplateReporter(filepath.Join(examplesDir, "report.html.tmpl"))
+	if err != nil {
+		t.Fatalf("NewTemplateReporter: %v", err)
+	}
+	reporters := []Reporter{
+		&TextReporter{},
+		&JSONReporter{},
+		&JSONReporter{Compact: true},
+		htmlTmpl,
+	}
+
+	// A report converted from JSON renders exactly as the scan would have.
+	for name, result := range results {
+		view := readBack(t, result)
+		for _, r := range reporters {
+			var direct, converted bytes.Buffer
+			if err := r.Generate(context.Background(), result, &direct); err != nil {
+				t.Fatalf("%s/%s: Generate: %v", name, r.Format(), err)
+			}
+			if err := r.Render(context.Background(), view, &converted); err != nil {
+				t.Fatalf("%s/%s: Render: %v", name, r.Format(), err)
+			}
+			if direct.String() != converted.String() {
+				t.Errorf("%s/%s: converted output differs\n--- scan ---\n%s\n--- converted ---\n%s",
+					name, r.Format(), direct.String(), converted.String())
+			}
+		}
+	}
+}
+
+func TestReadJSON_SchemaVersion(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&JSONReporter{}).Generate(context.Background(), newTestScanResult(), &buf); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	doc := strings.Replace(buf.String(), `"schema_version": "1.0"`, `"schema_version": "2.0"`, 1)
+
+	_, err := ReadJSON(strings.NewReader(doc))
+	if !errors.Is(err, ErrSchemaVersion) {
+		t.Fatalf("ReadJSON = %v, want ErrSchemaVersion", err)
+	}
+	if !strings.Contains(err.Error(), `"2.0"`) {
+		t.Errorf("error %q does not name the version", err)
+	}
+}
+
+func TestReadJSON_Invalid(t *testing.T) {
+	vuln := func(confidence, severity string) string {
+		return fmt.Sprintf(`{"parameter":{"name":"id","location":"query","type":"integer"},"technique":"error-based",`+
+			`"dbms":"MySQL","payload":"x","confidence":%s,"severity":%q,"evidence":""}`, confidence, severity)
+	}
+	doc := func(total int, vulns ...string) string {
+		return fmt.Sprintf(`{"schema_version":"1.0","tool":"sqleech","target":{"url":"http://t/?id=1","method":"GET"},"scan":{},`+
+			`"vulnerabilities":[%s],"summary":{"total_vulnerabilities":%d,"affected_parameters":1}}`, strings.Join(vulns, ","), total)
+	}
+	if _, err := ReadJSON(strings.NewReader(doc(1, vuln("0.9", "HIGH")))); err != nil {
+		t.Fatalf("valid document rejected: %v", err)
+	}
+
+	tests := []struct {
+		name, doc, msg string
+	}{
+		{"not json", "<html>", "parse report"},
+		{"not a report", `{"foo":1}`, "missing schema_version"},
+		{"no target", `{"schema_version":"1.0","tool":"sqleech","target":{},"vulnerabilities":[],"summary":{}}`, "missing target.url"},
+		{"bad confidence", doc(1, vuln("1.5", "HIGH")), "confidence 1.5 out of range"},
+		{"bad severity", doc(1, vuln("0.9", "SEVERE")), `unknown severity "SEVERE"`},
+		{"summary mismatch", doc(3, vuln("0.9", "HIGH")), "summary counts 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadJSON(strings.NewReader(tt.doc))
+			if err == nil || !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("ReadJSON = %v, want error containing %q", err, tt.msg)
+			}
+		})
+	}
+}
+
+func TestReadJSONFile_Missing(t *testing.T) {
+	if _, err := ReadJSONFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}
@@ -17,16 +17,23 @@ type Reporter interface {
 
 	// Generate writes the formatted scan result to w.
 	Generate(ctx context.Context, result *engine.ScanResult, w io.Writer) error
+
+	// Render writes a report view to w, for reports read back from JSON
+	// or merged. Generate renders NewView(result).
+	Render(ctx context.Context, v *View, w io.Writer) error
 }
 
 // New creates a reporter by format name ("text" or "json").
-// The format name is case-insensitive.
+// The format name is case-insensitive. The "template" format needs a
+// template file and is created with NewTemplateReporter instead.
 func New(format string) (Reporter, error) {
 	switch strings.ToLower(format) {
 	case "text":
 		return &TextReporter{}, nil
 	case "json":
 		return &JSONReporter{}, nil
+	case "template":
+		return nil, fmt.Errorf("report format %q requires a template file", format)
 	default:
 		return nil, fmt.Errorf("unsupported report format: %q", format)
 	}
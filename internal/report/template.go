@@ -0,0 +1,195 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+)
+
+// htmlTemplateSuffix selects html/template (with contextual auto-escaping)
+// instead of text/template.
+const htmlTemplateSuffix = ".html.tmpl"
+
+// TemplateReporter renders scan results with a user-supplied Go template.
+// The template receives a *View as its data.
+type TemplateReporter struct {
+	name string
+	html bool
+	tmpl templateExecutor
+}
+
+// templateExecutor is the common subset of text/template and html/template.
+type templateExecutor interface {
+	Execute(w io.Writer, data any) error
+}
+
+// TemplateError is a template parse or execution error annotated with the
+// template name and the line it occurred on.
+type TemplateError struct {
+	Name string
+	Line int // 0 when the underlying error carries no position
+	Msg  string
+}
+
+// Error returns "name:line: msg".
+func (e *TemplateError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.Name, e.Line, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", e.Name, e.Msg)
+}
+
+// templateErrPattern matches the position prefix text/template and
+// html/template put on their errors, e.g.
+// "template: report.tmpl:3:14: executing ...".
+var templateErrPattern = regexp.MustCompile(`(?s)^(?:html/)?template: ?[^:]*:(\d+)(?::\d+)?: (.*)$`)
+
+// TemplateFuncs returns the helper functions available to report templates.
+func TemplateFuncs() map[string]any {
+	return map[string]any{
+		"formatTime":    formatTime,
+		"truncate":      truncate,
+		"severityColor": severityColor,
+		"jsonEscape":    jsonEscape,
+	}
+}
+
+// NewTemplateReporter reads and parses the template file at path.
+// Files ending in ".html.tmpl" are parsed with html/template.
+func NewTemplateReporter(path string) (*TemplateReporter, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read template: %w", err)
+	}
+	return ParseTemplate(filepath.Base(path), string(src))
+}
+
+// ParseTemplate parses template source. The name decides the engine in the
+// same way as the file name does for NewTemplateReporter.
+func ParseTemplate(name, src string) (*TemplateReporter, error) {
+	r := &TemplateReporter{
+		name: name,
+		html: strings.HasSuffix(name, htmlTemplateSuffix),
+	}
+
+	var err error
+	if r.html {
+		r.tmpl, err = htmltemplate.New(name).Funcs(TemplateFuncs()).Parse(src)
+	} else {
+		r.tmpl, err = texttemplate.New(name).Funcs(TemplateFuncs()).Parse(src)
+	}
+	if err != nil {
+		return nil, r.wrapError(err)
+	}
+	return r, nil
+}
+
+// Format returns "template".
+func (r *TemplateReporter) Format() string {
+	return "template"
+}
+
+// Generate executes the template against the View of result and writes the
+// output to w. Nothing is written if execution fails.
+func (r *TemplateReporter) Generate(ctx context.Context, result *engine.ScanResult, w io.Writer) error {
+	return r.Render(ctx, NewView(result), w)
+}
+
+// Render executes the template against v and writes the output to w.
+// Nothing is written if execution fails.
+func (r *TemplateReporter) Render(ctx context.Context, v *View, w io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := r.tmpl.Execute(&buf, v); err != nil {
+		return r.wrapError(err)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Check executes the template against SampleResult, discarding the output.
+func (r *TemplateReporter) Check() error {
+	return r.Generate(context.Background(), SampleResult(), io.Discard)
+}
+
+// wrapError converts a template error into a *TemplateError carrying the
+// line number.
+func (r *TemplateReporter) wrapError(err error) error {
+	var htmlErr *htmltemplate.Error
+	if errors.As(err, &htmlErr) && htmlErr.Line > 0 {
+		return &TemplateError{Name: r.name, Line: htmlErr.Line, Msg: htmlErr.Description}
+	}
+
+	msg := err.Error()
+	if m := templateErrPattern.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return &TemplateError{Name: r.name, Line: line, Msg: m[2]}
+	}
+	return &TemplateError{Name: r.name, Msg: msg}
+}
+
+// formatTime formats t as RFC 3339 in UTC, or with the given layout.
+func formatTime(t time.Time, layout ...string) string {
+	if len(layout) > 0 {
+		return t.Format(layout[0])
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// truncate shortens s to at most n runes, appending "..." when cut.
+// The argument order allows pipelines: {{.Payload | truncate 40}}.
+func truncate(n int, s string) string {
+	runes := []rune(s)
+	if n < 0 || len(runes) <= n {
+		return s
+	}
+	if n <= 3 {
+		return string(runes[:n])
+	}
+	return string(runes[:n-3]) + "..."
+}
+
+// severityColor returns a hex colour for a severity name.
+func severityColor(severity string) string {
+	switch strings.ToUpper(severity) {
+	case "CRITICAL":
+		return "#b71c1c"
+	case "HIGH":
+		return "#e65100"
+	case "MEDIUM":
+		return "#f9a825"
+	case "LOW":
+		return "#1565c0"
+	default:
+		return "#607d8b"
+	}
+}
+
+// jsonEscape escapes s for use inside a JSON string literal, without the
+// surrounding quotes.
+func jsonEscape(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return ""
+	}
+	out := strings.TrimSuffix(buf.String(), "\n")
+	return out[1 : len(out)-1]
+}
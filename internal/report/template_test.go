@@ -0,0 +1,215 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+)
+
+const examplesDir = "../../examples/templates"
+
+// newScriptScanResult returns a result whose payload contains HTML markup.
+func newScriptScanResult() *engine.ScanResult {
+	r := newTestScanResult()
+	r.Vulnerabilities = r.Vulnerabilities[:1]
+	r.Vulnerabilities[0].Payload = "1<script>alert(1)</script>"
+	return r
+}
+
+func TestTemplateReporter_Format(t *testing.T) {
+	r, err := ParseTemplate("r.tmpl", "")
+	if err != nil {
+		t.Fatalf("ParseTemplate: %v", err)
+	}
+	if r.Format() != "template" {
+		t.Errorf("Format() = %q, want %q", r.Format(), "template")
+	}
+}
+
+func TestTemplateReporter_TextNotEscaped(t *testing.T) {
+	r, err := ParseTemplate("r.tmpl", "{{range .Vulnerabilities}}{{.Payload}}{{end}}")
+	if err != nil {
+		t.Fatalf("ParseTemplate: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := r.Generate(context.Background(), newScriptScanResult(), &buf); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if got := buf.String(); got != "1<script>alert(1)</script>" {
+		t.Errorf("text output = %q, want payload untouched", got)
+	}
+}
+
+func TestTemplateReporter_HTMLEscaped(t *testing.T) {
+	r, err := ParseTemplate("r.html.tmpl", "<p>{{range .Vulnerabilities}}{{.Payload}}{{end}}</p>")
+	if err != nil {
+		t.Fatalf("ParseTemplate: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := r.Generate(context.Background(), newScriptScanResult(), &buf); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	got := buf.String()
+	if strings.Contains(got, "<script>") {
+		t.Errorf("HTML output contains unescaped <script>: %q", got)
+	}
+	if !strings.Contains(got, "&lt;script&gt;") {
+		t.Errorf("HTML output should contain escaped payload, got %q", got)
+	}
+}
+
+func TestTemplateReporter_View(t *testing.T) {
+	src := "{{.SchemaVersion}}|{{.Target.URL}}|{{.DBMS.Name}}|{{.Scan.TotalRequests}}|" +
+		"{{.Summary.TotalVulnerabilities}}|{{(index .Vulnerabilities 0).Severity}}"
+	r, err := ParseTemplate("r.tmpl", src)
+	if err != nil {
+		t.Fatalf("ParseTemplate: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := r.Generate(context.Background(), newTestScanResult(), &buf); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	want := "1.0|http://example.com/page?id=1|MySQL|147|2|CRITICAL"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	ts := time.Date(2026, 2, 18, 10, 0, 0, 0, time.FixedZone("JST", 9*3600))
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"formatTime default", `{{formatTime .}}`, "2026-02-18T01:00:00Z"},
+		{"formatTime layout", `{{formatTime . "2006-01-02"}}`, "2026-02-18"},
+		{"truncate short", `{{"abc" | truncate 10}}`, "abc"},
+		{"truncate long", `{{"abcdefghij" | truncate 6}}`, "abc..."},
+		{"truncate tiny", `{{"abcdefghij" | truncate 2}}`, "ab"},
+		{"severityColor", `{{severityColor "CRITICAL"}}`, "#b71c1c"},
+		{"severityColor lower", `{{severityColor "low"}}`, "#1565c0"},
+		{"severityColor unknown", `{{severityColor "?"}}`, "#607d8b"},
+		{"jsonEscape", `{{jsonEscape "a\"b\\c\n<d>"}}`, `a\"b\\c\n<d>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ParseTemplate("f.tmpl", tt.src)
+			if err != nil {
+				t.Fatalf("ParseTemplate: %v", err)
+			}
+			var buf bytes.Buffer
+			if err := r.tmpl.Execute(&buf, ts); err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateReporter_ParseErrorLine(t *testing.T) {
+	for _, name := range []string{"broken.tmpl", "broken.html.tmpl"} {
+		_, err := ParseTemplate(name, "line one\nline two\n{{if .Target}}\n{{nosuchfunc}}\n")
+		var tErr *TemplateError
+		if !errors.As(err, &tErr) {
+			t.Fatalf("%s: expected *TemplateError, got %v", name, err)
+		}
+		if tErr.Line != 4 {
+			t.Errorf("%s: Line = %d, want 4 (%v)", name, tErr.Line, err)
+		}
+		if !strings.HasPrefix(err.Error(), name+":4: ") {
+			t.Errorf("%s: error %q should start with name:line", name, err)
+		}
+	}
+}
+
+func TestTemplateReporter_ExecuteErrorLine(t *testing.T) {
+	r, err := ParseTemplate("exec.tmpl", "ok\n\n{{.NoSuchField}}\n")
+	if err != nil {
+		t.Fatalf("ParseTemplate: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = r.Generate(context.Background(), newTestScanResult(), &buf)
+	var tErr *TemplateError
+	if !errors.As(err, &tErr) {
+		t.Fatalf("expected *TemplateError, got %v", err)
+	}
+	if tErr.Line != 3 {
+		t.Errorf("Line = %d, want 3 (%v)", tErr.Line, err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("no output should be written on failure, got %q", buf.String())
+	}
+}
+
+func TestTemplateReporter_Check(t *testing.T) {
+	r, err := ParseTemplate("c.tmpl", "{{range .Vulnerabilities}}{{.PairedParameter.Name}}{{end}}")
+	if err != nil {
+		t.Fatalf("ParseTemplate: %v", err)
+	}
+	// The first sample finding has no paired parameter: dereferencing nil fails.
+	if err := r.Check(); err == nil {
+		t.Error("Check should fail on nil pointer access")
+	}
+}
+
+func TestNewTemplateReporter_MissingFile(t *testing.T) {
+	if _, err := NewTemplateReporter(filepath.Join(t.TempDir(), "missing.tmpl")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestExampleTemplates(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join(examplesDir, "*.tmpl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) < 2 {
+		t.Fatalf("expected example templates in %s, found %d", examplesDir, len(paths))
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			r, err := NewTemplateReporter(path)
+			if err != nil {
+				t.Fatalf("NewTemplateReporter: %v", err)
+			}
+			if err := r.Check(); err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+
+			var buf bytes.Buffer
+			if err := r.Generate(context.Background(), newScriptScanResult(), &buf); err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+			out := buf.String()
+			if !strings.Contains(out, "http://example.com/page?id=1") {
+				t.Errorf("output missing target URL:\n%s", out)
+			}
+			if strings.HasSuffix(path, htmlTemplateSuffix) == strings.Contains(out, "<script>") {
+				t.Errorf("unexpected payload escaping in output:\n%s", out)
+			}
+
+			buf.Reset()
+			if err := r.Generate(context.Background(), newEmptyScanResult(), &buf); err != nil {
+				t.Fatalf("Generate (empty): %v", err)
+			}
+			if !strings.Contains(buf.String(), "No vulnerabilities found.") {
+				t.Errorf("empty output missing no-findings message:\n%s", buf.String())
+			}
+		})
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"maps"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/0x6d61/sqleech/internal/engine"
 )
@@ -28,6 +31,11 @@ func (r *TextReporter) Format() string {
 
 // Generate writes formatted scan results to w.
 func (r *TextReporter) Generate(ctx context.Context, result *engine.ScanResult, w io.Writer) error {
+	return r.Render(ctx, NewView(result), w)
+}
+
+// Render writes a report view as text to w.
+func (r *TextReporter) Render(ctx context.Context, v *View, w io.Writer) error {
 	if err := ctx.Err(); err != nil {
 		return err
 	}
@@ -42,59 +50,227 @@ func (r *TextReporter) Generate(ctx context.Context, result *engine.ScanResult,
 	fmt.Fprintln(b, "sqleech - SQL Injection Scanner Results")
 	fmt.Fprintln(b, doubleBar)
 
-	// Target info
-	fmt.Fprintf(b, "Target: %s\n", result.Target.URL)
-	fmt.Fprintf(b, "Method: %s\n", result.Target.Method)
+	if v.Scan.UnsafeWrites {
+		fmt.Fprintln(b, "WARNING: read-only guard disabled (--unsafe-allow-writes);")
+		fmt.Fprintln(b, "         payloads may have modified the target.")
+		fmt.Fprintln(b, singleBar)
+	}
+
+	// Target info; a merged report over several targets lists its sources
+	if v.Target.URL != "" {
+		fmt.Fprintf(b, "Target: %s\n", v.Target.URL)
+		fmt.Fprintf(b, "Method: %s\n", v.Target.Method)
+	}
+	if len(v.Sources) > 0 {
+		fmt.Fprintf(b, "Merged from %d reports:\n", len(v.Sources))
+		for _, src := range v.Sources {
+			fmt.Fprintf(b, "  - %s (%s %s)\n", src.Name, src.Target.Method, src.Target.URL)
+		}
+	}
 
-	if result.DBMS != "" {
-		dbmsInfo := result.DBMS
-		if result.DBMSVersion != "" {
-			dbmsInfo += " " + result.DBMSVersion
+	if v.DBMS.Name != "" {
+		dbmsInfo := v.DBMS.Name
+		if v.DBMS.Version != "" {
+			dbmsInfo += " " + v.DBMS.Version
 		}
 		fmt.Fprintf(b, "DBMS:   %s\n", dbmsInfo)
+		for _, f := range []struct{ label, value string }{
+			{"Banner", v.DBMS.Banner},
+			{"User", v.DBMS.CurrentUser},
+			{"Database", v.DBMS.CurrentDB},
+			{"Hostname", v.DBMS.Hostname},
+		} {
+			if f.value != "" {
+				fmt.Fprintf(b, "  %-9s %s\n", f.label+":", f.value)
+			}
+		}
+		if d := v.DBA; d != nil {
+			answer := "no"
+			if d.IsDBA {
+				answer = "yes"
+			}
+			fmt.Fprintf(b, "  DBA:      %s (%s = %s)\n", answer, d.Query, d.Value)
+		}
 	}
 
-	duration := result.EndTime.Sub(result.StartTime)
-	fmt.Fprintf(b, "Duration: %.1fs\n", duration.Seconds())
-	fmt.Fprintf(b, "Requests: %d\n", result.RequestCount)
+	fmt.Fprintf(b, "Duration: %.1fs\n", v.Scan.DurationSeconds)
+	if v.Scan.UnansweredProbes > 0 {
+		fmt.Fprintf(b, "Requests: %d (%d probes unanswered)\n", v.Scan.TotalRequests, v.Scan.UnansweredProbes)
+	} else {
+		fmt.Fprintf(b, "Requests: %d\n", v.Scan.TotalRequests)
+	}
+	if v.Scan.Truncated {
+		fmt.Fprintf(b, "Stopped at the request budget of %d (--max-requests); findings may be incomplete\n", v.Scan.RequestBudget)
+	}
+	if t := v.Transport; t != nil {
+		writeTransport(b, t)
+	}
 
 	// Vulnerabilities
-	if len(result.Vulnerabilities) == 0 {
+	if len(v.Vulnerabilities) == 0 {
 		fmt.Fprintln(b, singleBar)
 		fmt.Fprintln(b, "No vulnerabilities found.")
 	} else {
-		for _, vuln := range result.Vulnerabilities {
+		for _, vuln := range v.Vulnerabilities {
 			fmt.Fprintln(b, singleBar)
-			fmt.Fprintf(b, "[%s] SQL Injection Found!\n", vuln.Severity.String())
-			fmt.Fprintf(b, "  Parameter:  %s (%s)\n", vuln.Parameter.Name, vuln.Parameter.Location.String())
+			fmt.Fprintf(b, "[%s] SQL Injection Found!\n", vuln.Severity)
+			if vuln.Target != nil {
+				fmt.Fprintf(b, "  Target:     %s %s\n", vuln.Target.Method, vuln.Target.URL)
+			}
+			fmt.Fprintf(b, "  Parameter:  %s (%s)\n", vuln.Parameter.Name, vuln.Parameter.Location)
 			fmt.Fprintf(b, "  Technique:  %s\n", vuln.Technique)
+			if len(vuln.ConfirmedBy) > 1 {
+				fmt.Fprintf(b, "  Confirmed by: %s\n", strings.Join(vuln.ConfirmedBy, ", "))
+			}
+			if vuln.Protocol != "" {
+				fmt.Fprintf(b, "  Protocol:   %s\n", vuln.Protocol)
+			}
 			fmt.Fprintf(b, "  DBMS:       %s\n", vuln.DBMS)
 			fmt.Fprintf(b, "  Payload:    %s\n", vuln.Payload)
+			if vuln.PairedParameter != nil {
+				fmt.Fprintf(b, "  Paired:     %s (%s)\n", vuln.PairedParameter.Name, vuln.PairedParameter.Location)
+				fmt.Fprintf(b, "  Paired payload: %s\n", vuln.PairedPayload)
+			}
 			fmt.Fprintf(b, "  Confidence: %.0f%%\n", vuln.Confidence*100)
 			fmt.Fprintf(b, "  Evidence:   %s\n", vuln.Evidence)
+			if vuln.Triage != nil {
+				fmt.Fprintf(b, "  Triage:     %s\n", vuln.Triage.Summary)
+			}
+			if len(vuln.Sources) > 0 {
+				fmt.Fprintf(b, "  Found in:   %s\n", strings.Join(vuln.Sources, ", "))
+			}
+		}
+	}
+
+	// Extractions section
+	if len(v.Extractions) > 0 {
+		fmt.Fprintln(b, singleBar)
+		fmt.Fprintln(b, "Query results:")
+		for _, x := range v.Extractions {
+			fmt.Fprintf(b, "  %s (%s on %s): %d rows\n", x.Query, x.Technique, x.Parameter, len(x.Values))
+			if x.Filter != "" {
+				fmt.Fprintf(b, "  Filter:     %s\n", x.Filter)
+			}
+			for i, val := range x.Values {
+				fmt.Fprintf(b, "    %s\n", val)
+				if i < len(x.FilterNotes) && x.FilterNotes[i] != "" {
+					fmt.Fprintf(b, "      (%s; raw value shown)\n", x.FilterNotes[i])
+				}
+			}
+		}
+	}
+
+	// Skipped section
+	if len(v.Skipped) > 0 {
+		fmt.Fprintln(b, singleBar)
+		fmt.Fprintln(b, "Skipped for safety (not tested):")
+		for _, sk := range v.Skipped {
+			fmt.Fprintf(b, "  - %s (%s): %s\n", sk.Parameter.Name, sk.Parameter.Location, sk.Reason)
+			if sk.Target != nil {
+				fmt.Fprintf(b, "    on %s %s\n", sk.Target.Method, sk.Target.URL)
+			}
+		}
+		fmt.Fprintln(b, "  Allow with --allow-param NAME, or --batch --allow-risky-params.")
+	}
+
+	// Target profile section
+	if p := v.Profile; p != nil {
+		fmt.Fprintln(b, singleBar)
+		fmt.Fprintln(b, "Target profile (informational, not findings):")
+		if len(p.Redirects) > 0 {
+			fmt.Fprintf(b, "  Redirects:  %s -> %s\n", strings.Join(p.Redirects, " -> "), p.URL)
+		}
+		if p.Server != "" {
+			fmt.Fprintf(b, "  Server:     %s\n", p.Server)
+		}
+		if p.PoweredBy != "" {
+			fmt.Fprintf(b, "  Powered by: %s\n", p.PoweredBy)
+		}
+		if p.ClosesConnections {
+			fmt.Fprintf(b, "  Connections: closed after each response (%s)\n", p.Protocol)
+		}
+		if t := p.TLS; t != nil {
+			fmt.Fprintf(b, "  TLS:        %s, %s\n", t.Version, t.CipherSuite)
+			if t.Subject != "" {
+				fmt.Fprintf(b, "  Cert:       %s, issued by %s\n", t.Subject, t.Issuer)
+				fmt.Fprintf(b, "  Valid:      %s to %s\n", t.NotBefore.UTC().Format(time.DateOnly), t.NotAfter.UTC().Format(time.DateOnly))
+			}
+			if len(t.DNSNames) > 0 {
+				fmt.Fprintf(b, "  Names:      %s\n", strings.Join(t.DNSNames, ", "))
+			}
+			for _, issue := range t.Issues {
+				fmt.Fprintf(b, "  TLS issue:  %s\n", issue)
+			}
+		}
+		if len(p.MissingHeaders) > 0 {
+			fmt.Fprintf(b, "  Missing security headers: %s\n", strings.Join(p.MissingHeaders, ", "))
 		}
 	}
 
 	// Errors section
-	if len(result.Errors) > 0 {
+	if len(v.Errors) > 0 {
 		fmt.Fprintln(b, singleBar)
 		fmt.Fprintln(b, "Errors:")
-		for _, e := range result.Errors {
-			fmt.Fprintf(b, "  - %s\n", e.Error())
+		for _, e := range v.Errors {
+			fmt.Fprintf(b, "  - %s\n", e)
+		}
+	}
+
+	// Warnings section
+	if len(v.Warnings) > 0 {
+		fmt.Fprintln(b, singleBar)
+		fmt.Fprintln(b, "Warnings:")
+		for _, w := range v.Warnings {
+			fmt.Fprintf(b, "  - %s\n", w)
+		}
+	}
+
+	// Conflicts section
+	if len(v.Conflicts) > 0 {
+		fmt.Fprintln(b, singleBar)
+		fmt.Fprintln(b, "Conflicts between merged reports:")
+		for _, c := range v.Conflicts {
+			fmt.Fprintf(b, "  - %s\n", c)
 		}
 	}
 
 	// Summary
 	fmt.Fprintln(b, doubleBar)
-	vulnCount := len(result.Vulnerabilities)
-	paramCount := countAffectedParameters(result.Vulnerabilities)
-	fmt.Fprintf(b, "Summary: %d vulnerabilities found in %d parameter(s)\n", vulnCount, paramCount)
+	fmt.Fprintf(b, "Summary: %d vulnerabilities found in %d parameter(s)\n", v.Summary.TotalVulnerabilities, v.Summary.AffectedParameters)
+	if v.Summary.SkippedParameters > 0 {
+		fmt.Fprintf(b, "         %d parameter(s) skipped for safety; coverage is incomplete\n", v.Summary.SkippedParameters)
+	}
 	fmt.Fprintln(b, doubleBar)
 
 	_, err := io.WriteString(w, b.String())
 	return err
 }
 
+// writeTransport writes the transport statistics under the request count:
+// responses by status code, failures and retries, bytes received and
+// response times.
+func writeTransport(b *strings.Builder, t *ViewTransport) {
+	codes := slices.Sorted(maps.Keys(t.StatusCounts))
+	statuses := make([]string, len(codes))
+	for i, code := range codes {
+		statuses[i] = fmt.Sprintf("%d x%d", code, t.StatusCounts[code])
+	}
+	if len(statuses) > 0 {
+		fmt.Fprintf(b, "  Status:   %s\n", strings.Join(statuses, ", "))
+	}
+	if t.Errors > 0 || t.Retries > 0 {
+		fmt.Fprintf(b, "  Failed:   %d (%d retries)\n", t.Errors, t.Retries)
+	}
+	fmt.Fprintf(b, "  Received: %d KB\n", t.BytesReceived/1024)
+	if t.MaxDuration > 0 {
+		times := fmt.Sprintf("min %.2fs", t.MinDuration.Seconds())
+		if t.P50Duration > 0 {
+			times += fmt.Sprintf(", p50 %.2fs, p95 %.2fs, p99 %.2fs", t.P50Duration.Seconds(), t.P95Duration.Seconds(), t.P99Duration.Seconds())
+		}
+		fmt.Fprintf(b, "  Times:    %s, max %.2fs\n", times, t.MaxDuration.Seconds())
+	}
+}
+
 // countAffectedParameters counts distinct parameters that have vulnerabilities.
 func countAffectedParameters(vulns []engine.Vulnerability) int {
 	seen := make(map[string]struct{})
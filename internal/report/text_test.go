@@ -95,6 +95,27 @@ func newEmptyScanResult() *engine.ScanResult {
 	}
 }
 
+// newSplitScanResult creates a ScanResult with a cross-parameter finding.
+func newSplitScanResult() *engine.ScanResult {
+	result := newEmptyScanResult()
+	city := engine.Parameter{Name: "city", Value: "paris", Location: engine.LocationQuery}
+	result.Vulnerabilities = []engine.Vulnerability{
+		{
+			Parameter:       engine.Parameter{Name: "name", Value: "admin", Location: engine.LocationQuery},
+			Technique:       "split-comment-bridge",
+			DBMS:            "MySQL",
+			Payload:         "admin'/*",
+			Confidence:      0.85,
+			Severity:        engine.SeverityHigh,
+			Evidence:        "comment-bridge: TRUE and FALSE tails in \"city\" produce distinct pages",
+			Injectable:      true,
+			PairedParameter: &city,
+			PairedPayload:   "*/ AND 1=1-- -",
+		},
+	}
+	return result
+}
+
 func TestTextReporter_Format(t *testing.T) {
 	r := &TextReporter{}
 	if got := r.Format(); got != "text" {
@@ -271,3 +292,312 @@ func TestTextReporter_Generate_Errors(t *testing.T) {
 		t.Errorf("output should contain errors section, got:\n%s", output)
 	}
 }
+
+func TestTextReporter_Generate_Warnings(t *testing.T) {
+	r := &TextReporter{}
+	result := newTestScanResult()
+	result.Warnings = []string{"time-based: disabled, latency jitter 4s"}
+
+	var buf bytes.Buffer
+	if err := r.Generate(context.Background(), result, &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "Warnings:") || !strings.Contains(output, "  - time-based: disabled, latency jitter 4s") {
+		t.Errorf("output should contain warnings section, got:\n%s", output)
+	}
+}
+
+func TestTextReporter_Generate_DBMSBanner(t *testing.T) {
+	r := &TextReporter{}
+
+	var buf bytes.Buffer
+	if err := r.Generate(context.Background(), SampleResult(), &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{
+		"  Banner:   8.0.32-0ubuntu0.22.04.2\n",
+		"  User:     shop@localhost\n",
+		"  Database: shop\n",
+		"  Hostname: db01\n",
+		"  DBA:      no (",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestTextReporter_Generate_Extractions(t *testing.T) {
+	r := &TextReporter{}
+
+	var buf bytes.Buffer
+	if err := r.Generate(context.Background(), SampleResult(), &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	want := "Query results:\n" +
+		"  SELECT username FROM users (error-based on id): 2 rows\n" +
+		"    admin\n" +
+		"    guest\n"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("output missing %q:\n%s", want, buf.String())
+	}
+}
+
+// filteredExtraction is an --extract-filter extraction of two rows, the
+// second of which the filter did not apply to.
+func filteredExtraction() engine.Extraction {
+	return engine.Extraction{
+		Query:       "SELECT data FROM profiles",
+		Values:      []string{"ops@shop.test", "n/a"},
+		Technique:   "union-based",
+		Parameter:   "id",
+		Filter:      "$.contact.emails[1]",
+		Raw:         []string{`{"contact":{"emails":["admin@shop.test","ops@shop.test"]}}`, "n/a"},
+		FilterNotes: []string{"", "filter $.contact.emails[1] not applied: value is not JSON"},
+	}
+}
+
+func TestTextReporter_Generate_FilteredExtraction(t *testing.T) {
+	r := &TextReporter{}
+	result := SampleResult()
+	result.Extractions = []engine.Extraction{filteredExtraction()}
+
+	var buf bytes.Buffer
+	if err := r.Generate(context.Background(), result, &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	want := "  SELECT data FROM profiles (union-based on id): 2 rows\n" +
+		"  Filter:     $.contact.emails[1]\n" +
+		"    ops@shop.test\n" +
+		"    n/a\n" +
+		"      (filter $.contact.emails[1] not applied: value is not JSON; raw value shown)\n"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("output missing %q:\n%s", want, buf.String())
+	}
+}
+
+func TestTextReporter_Generate_Connections(t *testing.T) {
+	r := &TextReporter{}
+	result := SampleResult()
+	result.Profile.Protocol = "HTTP/1.0"
+	result.Profile.ClosesConnections = true
+	result.Unanswered = map[string]int{"reset": 2, "closed": 1}
+
+	var buf bytes.Buffer
+	if err := r.Generate(context.Background(), result, &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{
+		"(3 probes unanswered)",
+		"Connections: closed after each response (HTTP/1.0)",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestTextReporter_Generate_UnsafeWrites(t *testing.T) {
+	r := &TextReporter{}
+	result := newTestScanResult()
+
+	var buf bytes.Buffer
+	if err := r.Generate(context.Background(), result, &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if strings.Contains(buf.String(), "WARNING") {
+		t.Errorf("read-only scan should not carry a warning, got:\n%s", buf.String())
+	}
+
+	result.UnsafeWrites = true
+	buf.Reset()
+	if err := r.Generate(context.Background(), result, &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	output := buf.String()
+	warning := strings.Index(output, "WARNING: read-only guard disabled")
+	if warning < 0 || warning > strings.Index(output, "Target:") {
+		t.Errorf("warning should lead the report, got:\n%s", output)
+	}
+}
+
+func TestTextReporter_Generate_PairedParameter(t *testing.T) {
+	r := &TextReporter{}
+
+	var buf bytes.Buffer
+	if err := r.Generate(context.Background(), newSplitScanResult(), &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"Parameter:  name (query)",
+		"Payload:    admin'/*",
+		"Paired:     city (query)",
+		"Paired payload: */ AND 1=1-- -",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\noutput:\n%s", want, out)
+		}
+	}
+}
+
+// newTriagedScanResult creates a ScanResult with a triaged timing finding.
+func newTriagedScanResult() *engine.ScanResult {
+	result := newEmptyScanResult()
+	result.Vulnerabilities = []engine.Vulnerability{
+		{
+			Parameter:  engine.Parameter{Name: "id", Value: "1", Location: engine.LocationQuery},
+			Technique:  "boolean-blind",
+			DBMS:       "MySQL",
+			Payload:    " AND 1=1-- -",
+			Confidence: 0.96,
+			Severity:   engine.SeverityCritical,
+			Evidence:   "identical pages, but TRUE condition (1=1) took median 400ms",
+			Injectable: true,
+			Triage: &engine.TriageEvidence{
+				InitialConfidence: 0.7,
+				Repeats:           3,
+				RepeatsFired:      3,
+				NegativeRun:       true,
+				Placebo:           "page",
+			},
+		},
+	}
+	return result
+}
+
+func TestTextReporter_Generate_Triage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&TextReporter{}).Generate(context.Background(), newTriagedScanResult(), &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	want := `Triage:     confirmed (reproduced 3/3, negative control held, placebo "page" held; confidence was 70%)`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("output missing %q\noutput:\n%s", want, buf.String())
+	}
+}
+
+func TestTextReporter_Generate_Skipped(t *testing.T) {
+	r := &TextReporter{}
+	result := newTestScanResult()
+
+	var buf bytes.Buffer
+	if err := r.Generate(context.Background(), result, &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if strings.Contains(buf.String(), "Skipped") || strings.Contains(buf.String(), "incomplete") {
+		t.Errorf("full-coverage scan should not mention skipped parameters, got:\n%s", buf.String())
+	}
+
+	result.Skipped = []engine.SkippedParameter{{
+		Parameter: engine.Parameter{Name: "delete", Location: engine.LocationQuery},
+		Reason:    `name matches action verb "delete"`,
+	}}
+	buf.Reset()
+	if err := r.Generate(context.Background(), result, &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"Skipped for safety (not tested):\n  - delete (query): name matches action verb \"delete\"\n",
+		"1 parameter(s) skipped for safety; coverage is incomplete",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\noutput:\n%s", want, out)
+		}
+	}
+}
+
+func TestTextReporter_Generate_Profile(t *testing.T) {
+	r := &TextReporter{}
+	result := newTestScanResult()
+
+	var buf bytes.Buffer
+	if err := r.Generate(context.Background(), result, &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if strings.Contains(buf.String(), "Target profile") {
+		t.Errorf("scan without a profile should not render one, got:\n%s", buf.String())
+	}
+
+	result.Profile = SampleResult().Profile
+	buf.Reset()
+	if err := r.Generate(context.Background(), result, &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"Target profile (informational, not findings):\n",
+		"  Redirects:  http://example.com/item?id=1&name=admin&city=paris -> https://example.com/",
+		"  Server:     nginx/1.18.0\n  Powered by: PHP/7.4.3\n",
+		"  TLS:        TLS 1.2, TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256\n",
+		"  Valid:      2023-10-01 to 2023-12-31\n",
+		"  TLS issue:  certificate expired on 2023-12-31\n",
+		"  Missing security headers: Strict-Transport-Security, Content-Security-Policy,",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\noutput:\n%s", want, out)
+		}
+	}
+	// Environment context never counts towards the findings.
+	if !strings.Contains(out, "Summary: 2 vulnerabilities found in 1 parameter(s)") {
+		t.Errorf("summary changed by profile:\n%s", out)
+	}
+}
+
+func TestTextReporter_Generate_Transport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&TextReporter{}).Generate(context.Background(), SampleResult(), &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{
+		"  Status:   200 x81, 500 x5\n",
+		"  Failed:   1 (2 retries)\n",
+		"  Received: 402 KB\n",
+		"  Times:    min 0.01s, p50 0.03s, p95 0.10s, p99 0.41s, max 1.84s\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+
+	var empty bytes.Buffer
+	if err := (&TextReporter{}).Generate(context.Background(), newTestScanResult(), &empty); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if strings.Contains(empty.String(), "Status:") {
+		t.Errorf("output without transport statistics has a Status line:\n%s", empty.String())
+	}
+}
+
+func TestTextReporter_Generate_Protocol(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&TextReporter{}).Generate(context.Background(), SampleResult(), &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	// The sample's first finding records its protocol, the second not.
+	if got := strings.Count(buf.String(), "  Protocol:   HTTP/1.1\n"); got != 1 {
+		t.Errorf("output has %d Protocol lines, want 1:\n%s", got, buf.String())
+	}
+}
+
+func TestTextReporter_Generate_ConfirmedBy(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&TextReporter{}).Generate(context.Background(), SampleResult(), &buf); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	// Only the sample's first finding was confirmed by other techniques.
+	want := "  Confirmed by: error-based, boolean-blind, union-based\n"
+	if got := strings.Count(buf.String(), "  Confirmed by:"); got != 1 || !strings.Contains(buf.String(), want) {
+		t.Errorf("output has %d Confirmed by lines, want 1 of %q:\n%s", got, want, buf.String())
+	}
+}
@@ -0,0 +1,504 @@
+package report
+
+import (
+	"errors"
+	"time"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// ViewSchemaVersion is the version of the View data model exposed to user
+// templates. It tracks the JSON report's schema_version.
+const ViewSchemaVersion = "1.0"
+
+// View is the stable data model passed to user-supplied report templates.
+// Field names are part of the template contract: fields may be added, but
+// existing ones are never renamed or removed within a schema version.
+type View struct {
+	SchemaVersion   string
+	Tool            string
+	Target          ViewTarget
+	DBMS            ViewDBMS // Zero value when no DBMS was detected
+	Scan            ViewScan
+	Vulnerabilities []ViewVuln
+	Summary         ViewSummary
+	Errors          []string
+
+	// Warnings are conditions that did not stop the scan but limit what it
+	// says, such as a technique disabled for the target's latency.
+	Warnings []string
+
+	// Skipped lists the parameters left unprobed because they look
+	// state-changing; the scan says nothing about them.
+	Skipped []ViewSkipped
+
+	// Profile is the target environment seen in the baseline response. It
+	// is context for the reader, never a finding; nil when not captured.
+	Profile *ViewProfile
+
+	// DBA is whether the DBMS user has DBA privileges; nil when not
+	// checked (--is-dba).
+	DBA *ViewDBA
+
+	// Extractions are the results of user queries (--sql-query).
+	Extractions []ViewExtraction
+
+	// Transport is the HTTP client's statistics; nil when not recorded.
+	Transport *ViewTransport
+
+	// Jobs are the timings of the scan's detection jobs, each a technique
+	// testing a parameter, in the order they finished.
+	Jobs []ViewJob
+
+	// Sources lists the reports a merged view was built from, and
+	// Conflicts the metadata they disagree on. Both are nil for a single
+	// scan.
+	Sources   []ViewSource
+	Conflicts []string
+}
+
+// ViewSource describes a report a merged view was built from.
+type ViewSource struct {
+	Name   string // File name of the report
+	Target ViewTarget
+	DBMS   ViewDBMS
+}
+
+// ViewTarget describes the scanned target.
+type ViewTarget struct {
+	URL    string
+	Method string
+}
+
+// ViewDBMS describes the detected back-end DBMS. Banner, CurrentUser,
+// CurrentDB and Hostname are read from it when the scan asked (--banner).
+type ViewDBMS struct {
+	Name        string
+	Version     string
+	Banner      string
+	CurrentUser string
+	CurrentDB   string
+	Hostname    string
+}
+
+// ViewDBA is the answer to whether the DBMS user is a DBA, with the query
+// that gave it as evidence.
+type ViewDBA struct {
+	IsDBA bool
+	Query string
+	Value string
+}
+
+// ViewExtraction is the result of a user query read through a finding.
+type ViewExtraction struct {
+	Query     string
+	Values    []string
+	Technique string
+	Parameter string
+
+	// Filter is the --extract-filter path; Raw and FilterNotes are set
+	// with it.
+	Filter      string
+	Raw         []string
+	FilterNotes []string
+}
+
+// ViewScan holds scan timing and request statistics.
+type ViewScan struct {
+	StartTime       time.Time
+	EndTime         time.Time
+	Duration        time.Duration
+	DurationSeconds float64
+	TotalRequests   int64
+	UnsafeWrites    bool // Read-only guard was disabled; payloads may have written
+
+	// UnansweredProbes is the number of technique probes that got no
+	// response; techniques could not judge them.
+	UnansweredProbes int
+
+	// Truncated is set when the scan stopped on spending RequestBudget
+	// requests (--max-requests), so its findings may be incomplete.
+	Truncated     bool
+	RequestBudget int64
+}
+
+// ViewVuln describes a single finding.
+type ViewVuln struct {
+	Parameter  ViewParam
+	Technique  string
+	DBMS       string
+	Payload    string
+	Confidence float64 // 0.0 - 1.0
+	Severity   string  // CRITICAL, HIGH, MEDIUM, LOW or INFO
+	Evidence   string
+	Protocol   string // HTTP version the finding was decided over, if known
+
+	// ConfirmedBy lists every technique that found the parameter
+	// injectable, Technique among them.
+	ConfirmedBy []string
+
+	// PairedParameter and PairedPayload are set for cross-parameter findings.
+	PairedParameter *ViewParam
+	PairedPayload   string
+
+	// Triage is the counter-evidence gathered for a borderline finding.
+	Triage *ViewTriage
+
+	// Target and Sources attribute a finding of a merged view to the
+	// target it was found on and the reports that found it.
+	Target  *ViewTarget
+	Sources []string
+}
+
+// ViewJob is the timing of a detection job: how long it waited for a
+// worker and ran, and the error it ended on, such as a timeout or a
+// panic, if it decided nothing.
+type ViewJob struct {
+	Parameter ViewParam
+	Technique string
+	QueueWait time.Duration
+	Exec      time.Duration
+	Error     string
+}
+
+// ViewSkipped describes a parameter the scan did not probe, and why.
+type ViewSkipped struct {
+	Parameter ViewParam
+	Reason    string
+
+	// Target is set on merged views, as for ViewVuln.
+	Target *ViewTarget
+}
+
+// ViewProfile describes the target environment: banners, security
+// headers, redirects and TLS.
+type ViewProfile struct {
+	URL            string
+	Redirects      []string // URLs that redirected to URL, oldest first
+	Protocol       string
+	Server         string
+	PoweredBy      string
+	PresentHeaders []string // Security headers the target sent
+	MissingHeaders []string // Security headers it did not
+	TLS            *ViewTLS // Nil for plain HTTP
+
+	// ClosesConnections is set when the target closed the connection
+	// after the baseline response; the scan then used one per request.
+	ClosesConnections bool
+}
+
+// ViewTLS describes the target's TLS connection and certificate.
+type ViewTLS struct {
+	Version     string
+	CipherSuite string
+	Subject     string
+	Issuer      string
+	NotBefore   time.Time
+	NotAfter    time.Time
+	DNSNames    []string
+	Issues      []string // Misconfigurations such as an expired certificate
+}
+
+// ViewParam describes an injectable parameter.
+type ViewParam struct {
+	Name     string
+	Location string // query, body, header or cookie
+	Type     string // string, integer or float
+}
+
+// ViewTriage describes the controls run on a borderline finding.
+type ViewTriage struct {
+	Summary           string
+	InitialConfidence float64
+	Repeats           int
+	RepeatsFired      int
+	NegativeRun       bool
+	NegativeFired     bool
+	Placebo           string // Inert parameter also probed; empty if none
+	PlaceboFired      bool
+	Downgraded        bool
+}
+
+// ViewTransport holds the HTTP client's statistics: responses by status
+// code, requests that got none, retries, response body bytes read and
+// response times. Percentiles are zero when unknown, as in a merged view.
+type ViewTransport struct {
+	StatusCounts  map[int]int64
+	Errors        int64
+	Retries       int64
+	BytesReceived int64
+	MinDuration   time.Duration
+	MaxDuration   time.Duration
+	P50Duration   time.Duration
+	P95Duration   time.Duration
+	P99Duration   time.Duration
+}
+
+// ViewSummary holds aggregate counts.
+type ViewSummary struct {
+	TotalVulnerabilities int
+	AffectedParameters   int
+	SkippedParameters    int // Parameters not tested, listed in View.Skipped
+}
+
+// NewView converts a scan result into the template data model.
+func NewView(result *engine.ScanResult) *View {
+	duration := result.EndTime.Sub(result.StartTime)
+
+	v := &View{
+		SchemaVersion: ViewSchemaVersion,
+		Tool:          "sqleech",
+		Target: ViewTarget{
+			URL:    result.Target.URL,
+			Method: result.Target.Method,
+		},
+		DBMS: ViewDBMS{
+			Name:        result.DBMS,
+			Version:     result.DBMSVersion,
+			Banner:      result.Banner,
+			CurrentUser: result.CurrentUser,
+			CurrentDB:   result.CurrentDB,
+			Hostname:    result.Hostname,
+		},
+		Scan: ViewScan{
+			StartTime:       result.StartTime,
+			EndTime:         result.EndTime,
+			Duration:        duration,
+			DurationSeconds: duration.Seconds(),
+			TotalRequests:   result.RequestCount,
+			UnsafeWrites:    result.UnsafeWrites,
+
+			UnansweredProbes: countProbes(result.Unanswered),
+			Truncated:        result.Truncated,
+			RequestBudget:    result.RequestBudget,
+		},
+		Vulnerabilities: make([]ViewVuln, 0, len(result.Vulnerabilities)),
+		Summary: ViewSummary{
+			TotalVulnerabilities: len(result.Vulnerabilities),
+			AffectedParameters:   countAffectedParameters(result.Vulnerabilities),
+			SkippedParameters:    len(result.Skipped),
+		},
+	}
+
+	for _, vuln := range result.Vulnerabilities {
+		vv := ViewVuln{
+			Parameter:  newViewParam(vuln.Parameter),
+			Technique:  vuln.Technique,
+			DBMS:       vuln.DBMS,
+			Payload:    vuln.Payload,
+			Confidence: vuln.Confidence,
+			Severity:   vuln.Severity.String(),
+			Evidence:   vuln.Evidence,
+			Protocol:   vuln.Protocol,
+
+			ConfirmedBy: vuln.ConfirmedBy,
+		}
+		if vuln.PairedParameter != nil {
+			p := newViewParam(*vuln.PairedParameter)
+			vv.PairedParameter = &p
+			vv.PairedPayload = vuln.PairedPayload
+		}
+		if t := vuln.Triage; t != nil {
+			vv.Triage = &ViewTriage{
+				Summary:           t.Summary(),
+				InitialConfidence: t.InitialConfidence,
+				Repeats:           t.Repeats,
+				RepeatsFired:      t.RepeatsFired,
+				NegativeRun:       t.NegativeRun,
+				NegativeFired:     t.NegativeFired,
+				Placebo:           t.Placebo,
+				PlaceboFired:      t.PlaceboFired,
+				Downgraded:        t.Downgraded,
+			}
+		}
+		v.Vulnerabilities = append(v.Vulnerabilities, vv)
+	}
+
+	for _, sp := range result.Skipped {
+		v.Skipped = append(v.Skipped, ViewSkipped{
+			Parameter: newViewParam(sp.Parameter),
+			Reason:    sp.Reason,
+		})
+	}
+
+	for _, jt := range result.JobTimings {
+		job := ViewJob{
+			Parameter: newViewParam(jt.Parameter),
+			Technique: jt.Technique,
+			QueueWait: jt.QueueWait,
+			Exec:      jt.Exec,
+		}
+		if jt.Err != nil {
+			job.Error = jt.Err.Error()
+		}
+		v.Jobs = append(v.Jobs, job)
+	}
+
+	for _, err := range result.Errors {
+		v.Errors = append(v.Errors, err.Error())
+	}
+	v.Warnings = result.Warnings
+
+	v.Profile = newViewProfile(result.Profile)
+	if result.DBA != nil {
+		d := ViewDBA(*result.DBA)
+		v.DBA = &d
+	}
+	for _, x := range result.Extractions {
+		v.Extractions = append(v.Extractions, ViewExtraction(x))
+	}
+	if st := result.Transport; st != nil {
+		v.Transport = &ViewTransport{
+			StatusCounts:  st.StatusCounts,
+			Errors:        st.ErrorCount,
+			Retries:       st.Retries,
+			BytesReceived: st.BytesReceived,
+			MinDuration:   st.MinDuration,
+			MaxDuration:   st.MaxDuration,
+			P50Duration:   st.P50Duration,
+			P95Duration:   st.P95Duration,
+			P99Duration:   st.P99Duration,
+		}
+	}
+
+	return v
+}
+
+func newViewProfile(p *engine.TargetProfile) *ViewProfile {
+	if p == nil {
+		return nil
+	}
+	vp := &ViewProfile{
+		URL:            p.URL,
+		Redirects:      p.Redirects,
+		Protocol:       p.Protocol,
+		Server:         p.Server,
+		PoweredBy:      p.PoweredBy,
+		PresentHeaders: p.PresentHeaders,
+		MissingHeaders: p.MissingHeaders,
+
+		ClosesConnections: p.ClosesConnections,
+	}
+	if p.TLS != nil {
+		t := ViewTLS(*p.TLS)
+		vp.TLS = &t
+	}
+	return vp
+}
+
+// countProbes returns the total of per-failure probe counts.
+func countProbes(counts map[string]int) int {
+	n := 0
+	for _, c := range counts {
+		n += c
+	}
+	return n
+}
+
+func newViewParam(p engine.Parameter) ViewParam {
+	return ViewParam{
+		Name:     p.Name,
+		Location: p.Location.String(),
+		Type:     paramTypeString(p.Type),
+	}
+}
+
+// SampleResult returns a synthetic scan result used to validate templates
+// without scanning. It exercises every field of the View, including a
+// payload with HTML metacharacters, a cross-parameter finding, a
+// parameter skipped for safety and a target profile.
+func SampleResult() *engine.ScanResult {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	id := engine.Parameter{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger}
+	paired := engine.Parameter{Name: "city", Value: "paris", Location: engine.LocationQuery, Type: engine.TypeString}
+
+	return &engine.ScanResult{
+		Target: engine.ScanTarget{
+			URL:    "http://example.com/item?id=1&name=admin&city=paris",
+			Method: "GET",
+		},
+		DBMS:        "MySQL",
+		DBMSVersion: "8.0.32",
+		Banner:      "8.0.32-0ubuntu0.22.04.2",
+		CurrentUser: "shop@localhost",
+		CurrentDB:   "shop",
+		Hostname:    "db01",
+		Extractions: []engine.Extraction{
+			{Query: "SELECT username FROM users", Values: []string{"admin", "guest"}, Technique: "error-based", Parameter: "id"},
+		},
+		DBA:          &engine.DBACheck{IsDBA: false, Query: "(SELECT COUNT(*) FROM information_schema.user_privileges WHERE privilege_type='SUPER')", Value: "0"},
+		StartTime:    start,
+		EndTime:      start.Add(4200 * time.Millisecond),
+		RequestCount: 87,
+		Transport: &transport.TransportStats{
+			TotalRequests: 87,
+			StatusCounts:  map[int]int64{200: 81, 500: 5},
+			ErrorCount:    1,
+			Retries:       2,
+			BytesReceived: 412_300,
+			MinDuration:   12 * time.Millisecond,
+			MaxDuration:   1840 * time.Millisecond,
+			P50Duration:   31 * time.Millisecond,
+			P95Duration:   95 * time.Millisecond,
+			P99Duration:   410 * time.Millisecond,
+		},
+		JobTimings: []engine.JobTiming{
+			{Parameter: id, Technique: "error-based", QueueWait: 2 * time.Millisecond, Exec: 310 * time.Millisecond},
+			{Parameter: id, Technique: "time-based", QueueWait: 4 * time.Millisecond, Exec: 30 * time.Minute, Err: errors.New("job timed out after 30m0s")},
+		},
+		Vulnerabilities: []engine.Vulnerability{
+			{
+				Parameter:  engine.Parameter{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+				Technique:  "error-based",
+				DBMS:       "MySQL",
+				Payload:    "1 AND EXTRACTVALUE(1,CONCAT(0x7e,VERSION()))",
+				Confidence: 0.95,
+				Severity:   engine.SeverityCritical,
+				Evidence:   "XPATH syntax error: '~8.0.32~'",
+				Protocol:   "HTTP/1.1",
+				Injectable: true,
+
+				ConfirmedBy: []string{"error-based", "boolean-blind", "union-based"},
+			},
+			{
+				Parameter:       engine.Parameter{Name: "name", Value: "admin", Location: engine.LocationQuery, Type: engine.TypeString},
+				Technique:       "split-comment-bridge",
+				DBMS:            "MySQL",
+				Payload:         "admin'/*",
+				Confidence:      0.85,
+				Severity:        engine.SeverityHigh,
+				Evidence:        "TRUE/FALSE tails differ: <td>admin</td> vs <td></td>",
+				Injectable:      true,
+				PairedParameter: &paired,
+				PairedPayload:   "*/ AND 1=1-- -",
+			},
+		},
+		Errors: []error{errors.New("union-based: column count not found")},
+		Skipped: []engine.SkippedParameter{
+			{
+				Parameter: engine.Parameter{Name: "delete", Value: "0", Location: engine.LocationQuery, Type: engine.TypeInteger},
+				Reason:    `name matches action verb "delete"`,
+			},
+		},
+		Profile: &engine.TargetProfile{
+			URL:            "https://example.com/item?id=1&name=admin&city=paris",
+			Redirects:      []string{"http://example.com/item?id=1&name=admin&city=paris"},
+			Protocol:       "HTTP/1.1",
+			Server:         "nginx/1.18.0",
+			PoweredBy:      "PHP/7.4.3",
+			PresentHeaders: []string{"X-Frame-Options"},
+			MissingHeaders: []string{"Strict-Transport-Security", "Content-Security-Policy", "X-Content-Type-Options", "Referrer-Policy", "Permissions-Policy"},
+			TLS: &engine.TLSProfile{
+				Version:     "TLS 1.2",
+				CipherSuite: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+				Subject:     "CN=example.com",
+				Issuer:      "CN=R3,O=Let's Encrypt,C=US",
+				NotBefore:   start.AddDate(0, -3, 0),
+				NotAfter:    start.AddDate(0, 0, -1),
+				DNSNames:    []string{"example.com", "www.example.com"},
+				Issues:      []string{"certificate expired on 2023-12-31"},
+			},
+		},
+	}
+}
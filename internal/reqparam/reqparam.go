@@ -0,0 +1,63 @@
+// Package reqparam sets the value of a parameter in the parts of a request
+// that have no format package of their own: path segments, cookies and
+// headers. Query strings and form bodies are rewritten by rawquery, XML
+// bodies by xmlbody and GraphQL variables by graphql.
+package reqparam
+
+import (
+	"net/url"
+	"strings"
+)
+
+// SetPath replaces the path segment the * of name marks with value
+// path-escaped, so that its spaces and slashes stay inside the segment and
+// routing still reaches the handler. The URL is returned unchanged when it
+// does not parse or name marks no segment of it.
+func SetPath(rawURL, name, value string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	segments := strings.Split(parsed.EscapedPath(), "/")
+	for i, seg := range strings.Split(name, "/") {
+		if seg != "*" || i >= len(segments) {
+			continue
+		}
+		segments[i] = url.PathEscape(value)
+		escaped := strings.Join(segments, "/")
+		path, err := url.PathUnescape(escaped)
+		if err != nil {
+			return rawURL
+		}
+		parsed.Path, parsed.RawPath = path, escaped
+		return parsed.String()
+	}
+	return rawURL
+}
+
+// SetCookie sets the named cookie in cookies, a copy of the target's, to
+// value URL-encoded: cookie values cannot carry the spaces, quotes and
+// semicolons of payloads, and applications decode them. A nil cookies is
+// allocated.
+func SetCookie(cookies map[string]string, name, value string) map[string]string {
+	if cookies == nil {
+		cookies = make(map[string]string, 1)
+	}
+	cookies[name] = url.QueryEscape(value)
+	return cookies
+}
+
+// SetHeader sets the named header in headers, a copy of the target's, to
+// value. Header values lose their trailing whitespace in transit, so a
+// "-- " comment ending value gets a "-" to keep its space. A nil headers
+// is allocated.
+func SetHeader(headers map[string]string, name, value string) map[string]string {
+	if headers == nil {
+		headers = make(map[string]string, 1)
+	}
+	if strings.HasSuffix(value, "-- ") {
+		value += "-"
+	}
+	headers[name] = value
+	return headers
+}
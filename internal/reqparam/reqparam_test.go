@@ -0,0 +1,45 @@
+package reqparam
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestSetPath(t *testing.T) {
+	tests := []struct {
+		url, name, value, want string
+	}{
+		// Spaces and slashes are escaped, keeping the value in its segment
+		{"http://example.com/api/users/123/orders?sort=a%20b", "/api/users/*/orders", "123 AND 1=1/*",
+			"http://example.com/api/users/123%20AND%201=1%2F%2A/orders?sort=a%20b"},
+		{"http://example.com/item/5", "/item/*", "5'", "http://example.com/item/5%27"},
+		// No marked segment: unchanged
+		{"http://example.com/item/5", "/item/5", "x", "http://example.com/item/5"},
+		{"http://example.com/item", "/item/*", "x", "http://example.com/item"},
+	}
+	for _, tt := range tests {
+		if got := SetPath(tt.url, tt.name, tt.value); got != tt.want {
+			t.Errorf("SetPath(%q, %q, %q) = %q, want %q", tt.url, tt.name, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestSetCookie(t *testing.T) {
+	got := SetCookie(map[string]string{"cart_id": "7", "lang": "en"}, "cart_id", "7' AND '1'='1;")
+	if want := map[string]string{"cart_id": "7%27+AND+%271%27%3D%271%3B", "lang": "en"}; !maps.Equal(got, want) {
+		t.Errorf("SetCookie = %v, want %v", got, want)
+	}
+	if got := SetCookie(nil, "id", "1"); got["id"] != "1" {
+		t.Errorf("SetCookie(nil) = %v", got)
+	}
+}
+
+func TestSetHeader(t *testing.T) {
+	got := SetHeader(map[string]string{"Accept": "*/*"}, "X-Id", "1' OR 1=1-- ")
+	if want := map[string]string{"Accept": "*/*", "X-Id": "1' OR 1=1-- -"}; !maps.Equal(got, want) {
+		t.Errorf("SetHeader = %v, want %v", got, want)
+	}
+	if got := SetHeader(nil, "X-Id", "1"); got["X-Id"] != "1" {
+		t.Errorf("SetHeader(nil) = %v", got)
+	}
+}
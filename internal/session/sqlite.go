@@ -59,6 +59,21 @@ func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
 		return nil, fmt.Errorf("session: create index: %w", err)
 	}
 
+	// Dump progress is kept apart from the sessions, one row per table.
+	createDumpsSQL := `
+		CREATE TABLE IF NOT EXISTS dump_offsets (
+			target_url  TEXT NOT NULL,
+			table_name  TEXT NOT NULL,
+			next_offset INTEGER NOT NULL,
+			updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (target_url, table_name)
+		);
+	`
+	if _, err := db.Exec(createDumpsSQL); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("session: create dump table: %w", err)
+	}
+
 	return &SQLiteStore{db: db}, nil
 }
 
@@ -193,6 +208,46 @@ func (s *SQLiteStore) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// SaveDumpOffset records next as the row the dump of table from targetURL
+// resumes at, or forgets the dump when next is zero.
+func (s *SQLiteStore) SaveDumpOffset(ctx context.Context, targetURL, table string, next int) error {
+	if next == 0 {
+		query := `DELETE FROM dump_offsets WHERE target_url = ? AND table_name = ?`
+		if _, err := s.db.ExecContext(ctx, query, targetURL, table); err != nil {
+			return fmt.Errorf("session: clear dump offset: %w", err)
+		}
+		return nil
+	}
+
+	query := `
+		INSERT INTO dump_offsets (target_url, table_name, next_offset, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(target_url, table_name) DO UPDATE SET
+			next_offset = excluded.next_offset,
+			updated_at  = excluded.updated_at
+	`
+	_, err := s.db.ExecContext(ctx, query, targetURL, table, next, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("session: save dump offset: %w", err)
+	}
+	return nil
+}
+
+// LoadDumpOffset returns the row the dump of table from targetURL resumes
+// at, or zero when none was saved.
+func (s *SQLiteStore) LoadDumpOffset(ctx context.Context, targetURL, table string) (int, error) {
+	query := `SELECT next_offset FROM dump_offsets WHERE target_url = ? AND table_name = ?`
+	var next int
+	err := s.db.QueryRowContext(ctx, query, targetURL, table).Scan(&next)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("session: load dump offset: %w", err)
+	}
+	return next, nil
+}
+
 // Close closes the underlying database connection.
 func (s *SQLiteStore) Close() error {
 	if s.db != nil {
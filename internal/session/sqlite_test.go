@@ -439,3 +439,43 @@ func TestSQLiteStore_EmptyID(t *testing.T) {
 		t.Errorf("TargetURL = %q, want %q", loaded.TargetURL, "http://example.com/auto-id")
 	}
 }
+
+func TestSQLiteStore_DumpOffset(t *testing.T) {
+	store, err := NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	const target = "http://example.com/item?id=1"
+
+	if next, err := store.LoadDumpOffset(ctx, target, "shop.users"); err != nil || next != 0 {
+		t.Fatalf("LoadDumpOffset before any save = %d, %v; want 0", next, err)
+	}
+	for _, next := range []int{3, 4} {
+		if err := store.SaveDumpOffset(ctx, target, "shop.users", next); err != nil {
+			t.Fatalf("SaveDumpOffset(%d): %v", next, err)
+		}
+	}
+	if err := store.SaveDumpOffset(ctx, target, "shop.orders", 7); err != nil {
+		t.Fatalf("SaveDumpOffset: %v", err)
+	}
+	if next, err := store.LoadDumpOffset(ctx, target, "shop.users"); err != nil || next != 4 {
+		t.Errorf("LoadDumpOffset(shop.users) = %d, %v; want the last save, 4", next, err)
+	}
+	if next, err := store.LoadDumpOffset(ctx, "http://other.test/", "shop.users"); err != nil || next != 0 {
+		t.Errorf("LoadDumpOffset for another target = %d, %v; want 0", next, err)
+	}
+
+	// Saving zero forgets a finished dump.
+	if err := store.SaveDumpOffset(ctx, target, "shop.users", 0); err != nil {
+		t.Fatalf("SaveDumpOffset(0): %v", err)
+	}
+	if next, err := store.LoadDumpOffset(ctx, target, "shop.users"); err != nil || next != 0 {
+		t.Errorf("LoadDumpOffset after clearing = %d, %v; want 0", next, err)
+	}
+	if next, _ := store.LoadDumpOffset(ctx, target, "shop.orders"); next != 7 {
+		t.Errorf("LoadDumpOffset(shop.orders) = %d, want 7", next)
+	}
+}
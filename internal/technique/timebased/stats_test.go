@@ -0,0 +1,61 @@
+package timebased
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func ms(vs ...int) []time.Duration {
+	out := make([]time.Duration, len(vs))
+	for i, v := range vs {
+		out[i] = time.Duration(v) * time.Millisecond
+	}
+	return out
+}
+
+func TestSummarize(t *testing.T) {
+	s := Summarize(ms(10, 30, 20, 40))
+	if s.N != 4 || s.Median != 25*time.Millisecond || s.Mean != 25*time.Millisecond {
+		t.Errorf("Summarize = %+v", s)
+	}
+	// Sample standard deviation of 10, 20, 30, 40 is sqrt(500/3) ms.
+	if want := time.Duration(math.Sqrt(500.0/3) * float64(time.Millisecond)); s.StdDev.Round(time.Microsecond) != want.Round(time.Microsecond) {
+		t.Errorf("StdDev = %v, want %v", s.StdDev, want)
+	}
+	if odd := Summarize(ms(5, 1, 3)); odd.Median != 3*time.Millisecond {
+		t.Errorf("odd median = %v", odd.Median)
+	}
+	if empty := Summarize(nil); empty != (Sample{}) {
+		t.Errorf("Summarize(nil) = %+v", empty)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name       string
+		slow, fast []time.Duration
+		want       bool
+	}{
+		{"clear difference", ms(401, 415, 398, 420, 407, 412), ms(21, 35, 18, 40, 27, 22), true},
+		{"jitter only", ms(21, 45, 18, 40, 27, 22), ms(25, 35, 38, 20, 31, 24), false},
+		{"below gap", ms(80, 82, 81, 79, 80, 81), ms(20, 21, 19, 20, 21, 20), false},
+		{"one outlier", ms(20, 22, 900, 21, 19, 20), ms(21, 20, 19, 22, 20, 21), false},
+		{"inverted", ms(21, 35, 18, 40), ms(401, 415, 398, 420), false},
+		{"too few", ms(400, 410), ms(20, 21), false},
+	}
+	for _, tt := range tests {
+		d := Compare(tt.slow, tt.fast)
+		if got := d.Significant(4, 2, 100*time.Millisecond); got != tt.want {
+			t.Errorf("%s: Significant = %v, want %v (t=%.1f d=%.1f)", tt.name, got, tt.want, d.T, d.Effect)
+		}
+	}
+
+	d := Compare(ms(400, 400, 400), ms(20, 20, 20))
+	if !math.IsInf(d.Effect, 1) || !d.Significant(4, 2, 100*time.Millisecond) {
+		t.Errorf("constant samples: %+v", d)
+	}
+	if got := d.Threshold(); got != 210*time.Millisecond {
+		t.Errorf("Threshold = %v, want 210ms", got)
+	}
+}
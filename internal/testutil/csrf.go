@@ -0,0 +1,51 @@
+package testutil
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"html/template"
+	"net/http"
+	"sync"
+)
+
+var tmplCSRF = template.Must(template.New("").Parse(
+	`<html><body><h1>{{.Title}}</h1><form method="post"><input type="hidden" name="{{.Name}}" value="{{.Token}}"></form></body></html>`))
+
+// RequireCSRFToken wraps next like a web framework's CSRF protection with
+// per-request tokens: every request must carry, in the query or form body
+// parameter param, the token most recently handed out, which it then
+// uses up. A request without one gets a form carrying a fresh token; one
+// with a stale token gets the same form under a 403.
+func RequireCSRFToken(next http.Handler, param string) http.Handler {
+	var mu sync.Mutex
+	current := ""
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		token, sent := r.Form[param]
+
+		mu.Lock()
+		valid := sent && current != "" && token[0] == current
+		if valid {
+			current = ""
+		} else {
+			b := make([]byte, 16)
+			_, _ = rand.Read(b)
+			current = hex.EncodeToString(b)
+		}
+		fresh := current
+		mu.Unlock()
+
+		switch {
+		case valid:
+			next.ServeHTTP(w, r)
+		case sent:
+			w.WriteHeader(http.StatusForbidden)
+			_ = tmplCSRF.Execute(w, map[string]string{"Title": "Session expired", "Name": param, "Token": fresh})
+		default:
+			_ = tmplCSRF.Execute(w, map[string]string{"Title": "Form", "Name": param, "Token": fresh})
+		}
+	})
+}
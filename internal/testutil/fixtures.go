@@ -0,0 +1,212 @@
+package testutil
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/0x6d61/sqleech/internal/testutil/sqlmock"
+)
+
+// mockMemberCount is the number of rows seeded into the members table,
+// enough for their usernames to overflow MySQL's default
+// group_concat_max_len of 1024 bytes.
+const mockMemberCount = 120
+
+// Fixture databases behind the mock endpoints. sqlmock never modifies a
+// DB, so each is shared by all requests.
+var (
+	// shopMySQL backs the MySQL endpoints. It has an information_schema
+	// describing the shop tables, for enumeration.
+	shopMySQL = &sqlmock.DB{
+		Dialect:  sqlmock.MySQL,
+		Version:  mockVersionMySQL,
+		User:     "root@localhost",
+		Database: "shop",
+		Hostname: "db01",
+		Files:    serverFiles(),
+		Tables:   withInformationSchema("shop", shopTables()),
+	}
+
+	// shopMySQL57 backs the endpoints that need a pre-8.0 MySQL, which
+	// still has PROCEDURE ANALYSE.
+	shopMySQL57 = &sqlmock.DB{
+		Dialect:  sqlmock.MySQL,
+		Version:  mockVersionMySQL57,
+		User:     "root@localhost",
+		Database: "shop",
+		Hostname: "db01",
+		Tables:   shopTables(),
+	}
+
+	// shopMariaDB backs the MariaDB endpoints.
+	shopMariaDB = &sqlmock.DB{
+		Dialect:  sqlmock.MariaDB,
+		Version:  mockVersionMariaDB,
+		User:     "root@localhost",
+		Database: "shop",
+		Hostname: "db01",
+		Tables:   shopTables(),
+	}
+
+	// shopPostgres backs the PostgreSQL endpoints.
+	shopPostgres = &sqlmock.DB{
+		Dialect:  sqlmock.PostgreSQL,
+		Version:  mockVersionPostgreSQL,
+		User:     "postgres",
+		Database: "shop",
+		Hostname: "db01",
+		Files:    serverFiles(),
+		Tables:   shopTables(),
+	}
+
+	// shopMSSQL backs the MSSQL endpoints.
+	shopMSSQL = &sqlmock.DB{
+		Dialect:  sqlmock.MSSQL,
+		Version:  mockVersionMSSQL,
+		User:     "sa",
+		Database: "shop",
+		Hostname: "DB01",
+		Tables:   shopTables(),
+	}
+
+	// shopMSSQLTimed backs the MSSQL time-based endpoint, whose stacked
+	// WAITFOR statements sleep.
+	shopMSSQLTimed = &sqlmock.DB{
+		Dialect:  sqlmock.MSSQL,
+		Version:  mockVersionMSSQL,
+		User:     "sa",
+		Database: "shop",
+		Hostname: "DB01",
+		MaxSleep: timebasedSleepCap,
+		Tables:   shopTables(),
+	}
+
+	// shopOracle backs the Oracle endpoint.
+	shopOracle = &sqlmock.DB{
+		Dialect:  sqlmock.Oracle,
+		Version:  mockVersionOracle,
+		User:     "SHOP",
+		Database: "ORCL",
+		Hostname: "db01",
+		Tables:   shopTables(),
+	}
+
+	// shopGeneric backs the time-based endpoints, which answer both MySQL
+	// and PostgreSQL sleep probes.
+	shopGeneric = &sqlmock.DB{
+		Dialect:  sqlmock.Generic,
+		Version:  mockVersionMySQL,
+		User:     "root@localhost",
+		Database: "shop",
+		Hostname: "db01",
+		MaxSleep: timebasedSleepCap,
+		Tables:   shopTables(),
+	}
+)
+
+// mockPasswd is /etc/passwd on the fixture database servers.
+const mockPasswd = "root:x:0:0:root:/root:/bin/bash\nmysql:x:27:27:MySQL Server:/var/lib/mysql:/bin/false\n"
+
+// serverFiles returns the files the MySQL and PostgreSQL fixtures' file
+// functions read: a text file and a binary one, whose bytes are not valid
+// UTF-8.
+func serverFiles() map[string]string {
+	return map[string]string{
+		"/etc/passwd":       mockPasswd,
+		"/var/www/logo.png": "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\xff",
+	}
+}
+
+// shopTables returns the tables shared by every fixture database.
+func shopTables() map[string]*sqlmock.Table {
+	return map[string]*sqlmock.Table{
+		"products": {
+			Columns: []string{"id", "name", "price"},
+			Rows: [][]sqlmock.Value{
+				{int64(1), "Widget", 9.99},
+				{int64(2), "Gadget", 24.5},
+				{int64(3), "Sprocket", 4.75},
+				{int64(4), "Wide widget", 12.0},
+			},
+		},
+		"users": {
+			Columns: []string{"id", "username", "name", "password"},
+			Rows: [][]sqlmock.Value{
+				{int64(1), "admin", "Admin", "s3cret"},
+				{int64(2), "guest", "Guest", "guest"},
+			},
+		},
+		"contacts": {
+			Columns: []string{"name", "city"},
+			Rows: [][]sqlmock.Value{
+				{"admin", "paris"},
+			},
+		},
+		"profiles": {
+			Columns: []string{"user_id", "data"},
+			Rows: [][]sqlmock.Value{
+				{int64(1), `{"display":"Admin <root>","contact":{"emails":["admin@shop.test","ops@shop.test"],"city":"Paris"},"tags":["staff"]}`},
+			},
+		},
+		"settings": {
+			Columns: []string{"user_id", "locale"},
+			Rows: [][]sqlmock.Value{
+				{int64(1), "ja"},
+			},
+		},
+		"members": {
+			Columns: []string{"id", "username"},
+			Rows:    seedMembers(mockMemberCount),
+		},
+		// notes seeds the values a table dump must round-trip: a NULL, a
+		// quote and comma for the CSV writer, and the dump's own field
+		// delimiter.
+		"notes": {
+			Columns: []string{"id", "author", "body"},
+			Rows: [][]sqlmock.Value{
+				{int64(1), "admin", `say "hi", then leave`},
+				{int64(2), nil, "unsigned"},
+				{int64(3), "guest", "a|:s9:|b"},
+			},
+		},
+	}
+}
+
+// withInformationSchema adds to tables, the tables of database schema, the
+// information_schema views enumeration queries read: schemata, tables and
+// columns. Qualified names resolve to their last part, so
+// information_schema.tables finds the "tables" fixture.
+func withInformationSchema(schema string, tables map[string]*sqlmock.Table) map[string]*sqlmock.Table {
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	schemata := &sqlmock.Table{
+		Columns: []string{"schema_name"},
+		Rows:    [][]sqlmock.Value{{"information_schema"}, {schema}},
+	}
+	tableRows := &sqlmock.Table{Columns: []string{"table_schema", "table_name"}}
+	columnRows := &sqlmock.Table{Columns: []string{"table_schema", "table_name", "column_name"}}
+	for _, name := range names {
+		tableRows.Rows = append(tableRows.Rows, []sqlmock.Value{schema, name})
+		for _, col := range tables[name].Columns {
+			columnRows.Rows = append(columnRows.Rows, []sqlmock.Value{schema, name, col})
+		}
+	}
+
+	tables["schemata"] = schemata
+	tables["tables"] = tableRows
+	tables["columns"] = columnRows
+	return tables
+}
+
+// seedMembers returns n member rows, member001 to memberNNN.
+func seedMembers(n int) [][]sqlmock.Value {
+	rows := make([][]sqlmock.Value, n)
+	for i := range rows {
+		rows[i] = []sqlmock.Value{int64(i + 1), fmt.Sprintf("member%03d", i+1)}
+	}
+	return rows
+}
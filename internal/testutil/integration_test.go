@@ -3,19 +3,36 @@ package testutil
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/0x6d61/sqleech/internal/crawler"
+	"github.com/0x6d61/sqleech/internal/dbms"
 	"github.com/0x6d61/sqleech/internal/detector"
 	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/enum"
 	"github.com/0x6d61/sqleech/internal/fingerprint"
+	"github.com/0x6d61/sqleech/internal/forms"
+	"github.com/0x6d61/sqleech/internal/jsonpath"
+	"github.com/0x6d61/sqleech/internal/marker"
+	"github.com/0x6d61/sqleech/internal/payloadlib"
 	"github.com/0x6d61/sqleech/internal/report"
+	"github.com/0x6d61/sqleech/internal/session"
 	"github.com/0x6d61/sqleech/internal/technique"
 	"github.com/0x6d61/sqleech/internal/technique/boolean"
+	"github.com/0x6d61/sqleech/internal/technique/crossparam"
 	"github.com/0x6d61/sqleech/internal/technique/errorbased"
 	"github.com/0x6d61/sqleech/internal/technique/timebased"
 	"github.com/0x6d61/sqleech/internal/technique/union"
@@ -43,6 +60,18 @@ func (a *techniqueAdapter) Detect(ctx context.Context, req *engine.TechniqueRequ
 		Baseline:  req.Baseline,
 		DBMS:      req.DBMS,
 		Client:    req.Client,
+		Context:   req.Context,
+		State:     req.State,
+
+		SleepSeconds: req.SleepSeconds,
+		Level:        req.Level,
+		Risk:         req.Risk,
+
+		MatchString:    req.MatchString,
+		NotMatchString: req.NotMatchString,
+		MatchRegexp:    req.MatchRegexp,
+
+		Progress: req.Progress,
 	}
 	r, err := a.inner.Detect(ctx, innerReq)
 	if err != nil {
@@ -61,6 +90,35 @@ func (a *techniqueAdapter) Detect(ctx context.Context, req *engine.TechniqueRequ
 }
 
 // wrapTechniques converts a slice of technique.Technique into engine.Technique.
+func (a *techniqueAdapter) Extract(ctx context.Context, req *engine.ExtractionRequest) (*engine.ExtractionResult, error) {
+	r, err := a.inner.Extract(ctx, &technique.ExtractionRequest{
+		InjectionRequest: technique.InjectionRequest{
+			Target:    req.Target,
+			Parameter: req.Parameter,
+			Baseline:  req.Baseline,
+			DBMS:      req.DBMS,
+			Client:    req.Client,
+			Coverage:  req.Coverage,
+			Context:   req.Context,
+			State:     req.State,
+
+			SleepSeconds: req.SleepSeconds,
+			Level:        req.Level,
+			Risk:         req.Risk,
+
+			MatchString:    req.MatchString,
+			NotMatchString: req.NotMatchString,
+			MatchRegexp:    req.MatchRegexp,
+			Progress:       req.Progress,
+		},
+		Query: req.Query,
+	})
+	if r == nil {
+		return nil, err
+	}
+	return &engine.ExtractionResult{Value: r.Value, Partial: r.Partial, Requests: r.Requests}, err
+}
+
 func wrapTechniques(techs ...technique.Technique) []engine.Technique {
 	out := make([]engine.Technique, len(techs))
 	for i, t := range techs {
@@ -88,6 +146,7 @@ func makeHeuristicFunc(client transport.Client) engine.HeuristicDetectorFunc {
 				ErrorSignatures: r.ErrorSignatures,
 				PageRatio:       r.PageRatio,
 				IsInjectable:    r.IsInjectable,
+				Context:         r.Context,
 			}
 		}
 		return out, nil
@@ -106,6 +165,7 @@ func makeDBMSIdentifier() engine.DBMSIdentifierFunc {
 			Version:    info.Version,
 			Banner:     info.Banner,
 			Confidence: info.Confidence,
+			Tentative:  info.Tentative,
 		}
 	}
 }
@@ -150,9 +210,40 @@ func newFullScanner(client transport.Client, config *engine.ScanConfig) *engine.
 		engine.WithHeuristicDetector(makeHeuristicFunc(client)),
 		engine.WithDBMSIdentifier(makeDBMSIdentifier()),
 		engine.WithFingerprinter(makeFingerprinter()),
+		engine.WithCrossParamDetector(makeCrossParamDetector()),
 	)
 }
 
+// makeCrossParamDetector creates a CrossParamDetectorFunc using the real
+// crossparam package.
+func makeCrossParamDetector() engine.CrossParamDetectorFunc {
+	det := crossparam.New()
+	return func(ctx context.Context, target *engine.ScanTarget, params []engine.Parameter, dbmsName string, client transport.Client) ([]engine.Vulnerability, error) {
+		findings, err := det.Detect(ctx, &crossparam.Request{
+			Target:     target,
+			Parameters: params,
+			DBMS:       dbmsName,
+			Client:     client,
+		})
+		vulns := make([]engine.Vulnerability, len(findings))
+		for i, f := range findings {
+			second := f.Second
+			vulns[i] = engine.Vulnerability{
+				Parameter:       f.First,
+				Technique:       "split-" + f.Pattern,
+				DBMS:            dbmsName,
+				Payload:         f.FirstPayload,
+				Confidence:      f.Confidence,
+				Evidence:        f.Evidence,
+				Injectable:      true,
+				PairedParameter: &second,
+				PairedPayload:   f.SecondPayload,
+			}
+		}
+		return vulns, err
+	}
+}
+
 // --------------------------------------------------------------------------
 // Test transport client
 // --------------------------------------------------------------------------
@@ -447,6 +538,437 @@ func TestIntegration_MultipleParameters(t *testing.T) {
 	}
 }
 
+// TestIntegration_CookieParameter scans /vuln/cookie with its cookies as
+// parameters: the id cookie the query reads is injectable, the session
+// and lang cookies it ignores are not, and extraction through the finding
+// carries its payloads in the cookie.
+func TestIntegration_CookieParameter(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+	cfg := engine.DefaultScanConfig()
+	scanner := newFullScanner(client, cfg)
+
+	cookies := map[string]string{"id": "1", "session": "d41d8cd98f00b204", "lang": "en"}
+	target := &engine.ScanTarget{
+		URL:        srv.URL + "/vuln/cookie",
+		Method:     "GET",
+		Cookies:    cookies,
+		Parameters: detector.ParseCookies(cookies),
+	}
+
+	ctx := context.Background()
+	result, err := scanner.Scan(ctx, target)
+	if err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+
+	var finding *engine.Vulnerability
+	for i, vuln := range result.Vulnerabilities {
+		if !vuln.Injectable {
+			continue
+		}
+		if vuln.Parameter.Name != "id" || vuln.Parameter.Location != engine.LocationCookie {
+			t.Errorf("expected only the id cookie injectable, got %s %q by %s",
+				vuln.Parameter.Location, vuln.Parameter.Name, vuln.Technique)
+			continue
+		}
+		if finding == nil && vuln.Technique == "error-based" {
+			finding = &result.Vulnerabilities[i]
+		}
+	}
+	if finding == nil {
+		t.Fatalf("expected an error-based finding on the id cookie, got %+v", result.Vulnerabilities)
+	}
+
+	res, err := scanner.Extract(ctx, target, *finding, "@@version")
+	if err != nil {
+		t.Fatalf("Extract returned error: %v", err)
+	}
+	if res.Value != mockVersionMySQL {
+		t.Errorf("Extract = %q, want %q", res.Value, mockVersionMySQL)
+	}
+}
+
+// TestIntegration_LevelLocations scans targets injectable only through a
+// cookie or the User-Agent header: their level adds them to the parameters
+// from CookieLevel and HeaderLevel on.
+func TestIntegration_LevelLocations(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	tests := []struct {
+		path     string
+		location engine.ParameterLocation
+		name     string
+		level    int
+	}{
+		{"/vuln/cookie", engine.LocationCookie, "id", engine.CookieLevel},
+		{"/vuln/user-agent", engine.LocationHeader, "User-Agent", engine.HeaderLevel},
+	}
+	for _, tt := range tests {
+		for _, level := range []int{tt.level - 1, tt.level} {
+			cfg := engine.DefaultScanConfig()
+			cfg.Level = level
+			result, err := newFullScanner(newTestClient(), cfg).Scan(context.Background(), &engine.ScanTarget{
+				URL:     srv.URL + tt.path,
+				Method:  "GET",
+				Cookies: map[string]string{"id": "1", "lang": "en"},
+				// A product name, since the mock database evaluates the
+				// error-based payloads only against rows the query matches.
+				Headers: map[string]string{"User-Agent": "Widget"},
+			})
+			if err != nil {
+				t.Fatalf("%s at level %d: Scan returned error: %v", tt.path, level, err)
+			}
+			found := false
+			for _, vuln := range result.Vulnerabilities {
+				if !vuln.Injectable {
+					continue
+				}
+				if vuln.Parameter.Location != tt.location || vuln.Parameter.Name != tt.name {
+					t.Errorf("%s at level %d: unexpected finding on %s %q", tt.path, level, vuln.Parameter.Location, vuln.Parameter.Name)
+					continue
+				}
+				found = true
+			}
+			if want := level >= tt.level; found != want {
+				t.Errorf("%s at level %d: %s %q found injectable = %v, want %v", tt.path, level, tt.location, tt.name, found, want)
+			}
+		}
+	}
+}
+
+// probeRecorder records the URLs of the requests sent through it.
+type probeRecorder struct {
+	*testTransportClient
+	mu   sync.Mutex
+	urls []string
+}
+
+func (c *probeRecorder) Do(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	c.mu.Lock()
+	c.urls = append(c.urls, req.URL)
+	c.mu.Unlock()
+	return c.testTransportClient.Do(ctx, req)
+}
+
+// TestIntegration_LevelRisk scans /vuln/safe, where every technique runs
+// its whole course, at each level and risk: the requests grow with the
+// level, and the OR conditions and heavy queries risk gates are sent only
+// from the risk that enables them.
+func TestIntegration_LevelRisk(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	scan := func(level, risk int) (int64, []string) {
+		t.Helper()
+		client := &probeRecorder{testTransportClient: newTestClient()}
+		cfg := engine.DefaultScanConfig()
+		cfg.Threads = 1
+		cfg.ForceTest = true
+		cfg.DBMSHint = "MySQL"
+		cfg.Level, cfg.Risk = level, risk
+		result, err := newFullScanner(client, cfg).Scan(context.Background(), &engine.ScanTarget{
+			URL:    srv.URL + "/vuln/safe?id=1",
+			Method: "GET",
+		})
+		if err != nil {
+			t.Fatalf("level %d, risk %d: Scan returned error: %v", level, risk, err)
+		}
+		return result.RequestCount, client.urls
+	}
+	sent := func(urls []string, token string) bool {
+		return slices.ContainsFunc(urls, func(u string) bool {
+			q, _ := url.QueryUnescape(u)
+			return strings.Contains(q, token)
+		})
+	}
+
+	var prev int64
+	for level := payloadlib.DefaultLevel; level <= payloadlib.MaxLevel; level++ {
+		n, urls := scan(level, 1)
+		t.Logf("level %d: %d requests", level, n)
+		if n <= prev {
+			t.Errorf("level %d sent %d requests, want more than the %d of level %d", level, n, prev, level-1)
+		}
+		prev = n
+		for _, token := range []string{" OR ", "BENCHMARK(", " RLIKE "} {
+			if sent(urls, token) {
+				t.Errorf("level %d, risk 1: sent %q", level, token)
+			}
+		}
+	}
+
+	for _, tt := range []struct {
+		token string
+		risk  int
+	}{
+		{"BENCHMARK(", timebased.HeavyRisk},
+		{" OR ", boolean.ORRisk},
+	} {
+		if _, urls := scan(payloadlib.DefaultLevel, tt.risk-1); sent(urls, tt.token) {
+			t.Errorf("risk %d: sent %q", tt.risk-1, tt.token)
+		}
+		if _, urls := scan(payloadlib.DefaultLevel, tt.risk); !sent(urls, tt.token) {
+			t.Errorf("risk %d: never sent %q", tt.risk, tt.token)
+		}
+	}
+}
+
+func TestIntegration_SOAPBody(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+	cfg := engine.DefaultScanConfig()
+	scanner := newFullScanner(client, cfg)
+
+	target := &engine.ScanTarget{
+		URL:         srv.URL + "/vuln/soap",
+		Method:      "POST",
+		ContentType: "text/xml; charset=utf-8",
+		Body: `<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Body><GetProduct><id>1</id><currency>EUR</currency></GetProduct></soap:Body>
+</soap:Envelope>`,
+	}
+
+	ctx := context.Background()
+	result, err := scanner.Scan(ctx, target)
+	if err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+
+	var finding *engine.Vulnerability
+	for i, vuln := range result.Vulnerabilities {
+		if !vuln.Injectable {
+			continue
+		}
+		if vuln.Parameter.Name != "/Envelope/Body/GetProduct/id" || vuln.Parameter.Location != engine.LocationXML {
+			t.Errorf("expected only the id element injectable, got %s %q by %s",
+				vuln.Parameter.Location, vuln.Parameter.Name, vuln.Technique)
+			continue
+		}
+		if finding == nil && vuln.Technique == "error-based" {
+			finding = &result.Vulnerabilities[i]
+		}
+	}
+	if finding == nil {
+		t.Fatalf("expected an error-based finding on the id element, got %+v", result.Vulnerabilities)
+	}
+
+	res, err := scanner.Extract(ctx, target, *finding, "@@version")
+	if err != nil {
+		t.Fatalf("Extract returned error: %v", err)
+	}
+	if res.Value != mockVersionMySQL {
+		t.Errorf("Extract = %q, want %q", res.Value, mockVersionMySQL)
+	}
+}
+
+func TestIntegration_CustomMarker(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+	cfg := engine.DefaultScanConfig()
+	scanner := newFullScanner(client, cfg)
+
+	// The marker in the path is the only injection point; tab is not
+	// tested.
+	markers := marker.Parse(srv.URL+"/vuln/item/1*/details?tab=specs", "", "", nil, nil)
+	target := &engine.ScanTarget{
+		URL:        srv.URL + "/vuln/item/1/details?tab=specs",
+		Method:     "GET",
+		Markers:    markers,
+		Parameters: detector.ParseMarkers(markers),
+	}
+
+	ctx := context.Background()
+	result, err := scanner.Scan(ctx, target)
+	if err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+
+	var finding *engine.Vulnerability
+	for i, vuln := range result.Vulnerabilities {
+		if !vuln.Injectable {
+			continue
+		}
+		if vuln.Parameter.Name != "#1*" || vuln.Parameter.Location != engine.LocationCustom {
+			t.Errorf("expected only the marker injectable, got %s %q by %s",
+				vuln.Parameter.Location, vuln.Parameter.Name, vuln.Technique)
+			continue
+		}
+		if finding == nil && vuln.Technique == "error-based" {
+			finding = &result.Vulnerabilities[i]
+		}
+	}
+	if finding == nil {
+		t.Fatalf("expected an error-based finding on the marker, got %+v", result.Vulnerabilities)
+	}
+
+	res, err := scanner.Extract(ctx, target, *finding, "@@version")
+	if err != nil {
+		t.Fatalf("Extract returned error: %v", err)
+	}
+	if res.Value != mockVersionMySQL {
+		t.Errorf("Extract = %q, want %q", res.Value, mockVersionMySQL)
+	}
+}
+
+func TestIntegration_PathSegment(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+	cfg := engine.DefaultScanConfig()
+	scanner := newFullScanner(client, cfg)
+
+	// FALSE probes and broken payloads answer 404; the segment is still
+	// found injectable through the difference.
+	rawURL := srv.URL + "/vuln/path/1?view=full"
+	target := &engine.ScanTarget{
+		URL:    rawURL,
+		Method: "GET",
+		Parameters: append(detector.ParseParameters(rawURL, "", ""),
+			detector.ParsePathParameters(rawURL, detector.PathSegmentsNumeric)...),
+	}
+
+	ctx := context.Background()
+	result, err := scanner.Scan(ctx, target)
+	if err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+
+	var finding *engine.Vulnerability
+	for i, vuln := range result.Vulnerabilities {
+		if !vuln.Injectable {
+			continue
+		}
+		if vuln.Parameter.Name != "/vuln/path/*" || vuln.Parameter.Location != engine.LocationPath {
+			t.Errorf("expected only the id segment injectable, got %s %q by %s",
+				vuln.Parameter.Location, vuln.Parameter.Name, vuln.Technique)
+			continue
+		}
+		if finding == nil && vuln.Technique == "boolean-blind" {
+			finding = &result.Vulnerabilities[i]
+		}
+	}
+	if finding == nil {
+		t.Fatalf("expected a boolean-blind finding on the id segment, got %+v", result.Vulnerabilities)
+	}
+
+	res, err := scanner.Extract(ctx, target, *finding, "@@version")
+	if err != nil {
+		t.Fatalf("Extract returned error: %v", err)
+	}
+	if res.Value != mockVersionMySQL {
+		t.Errorf("Extract = %q, want %q", res.Value, mockVersionMySQL)
+	}
+}
+
+func TestIntegration_GraphQLVariables(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+	cfg := engine.DefaultScanConfig()
+	cfg.Techniques = []string{"B"}
+	scanner := newFullScanner(client, cfg)
+
+	// Tracing timings change on every response; the boolean oracle still
+	// tells TRUE from FALSE by the data alone.
+	target := &engine.ScanTarget{
+		URL:         srv.URL + "/vuln/graphql",
+		Method:      "POST",
+		ContentType: "application/json",
+		Body:        `{"query":"query($filter: ProductFilter!, $first: Int) { products(filter: $filter, first: $first) { id name } }","variables":{"filter":{"id":"1"},"first":10}}`,
+	}
+
+	ctx := context.Background()
+	result, err := scanner.Scan(ctx, target)
+	if err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+
+	var finding *engine.Vulnerability
+	for i, vuln := range result.Vulnerabilities {
+		if !vuln.Injectable {
+			continue
+		}
+		if vuln.Parameter.Name != "filter.id" || vuln.Parameter.Location != engine.LocationGraphQL {
+			t.Errorf("expected only the filter.id variable injectable, got %s %q by %s",
+				vuln.Parameter.Location, vuln.Parameter.Name, vuln.Technique)
+			continue
+		}
+		if finding == nil {
+			finding = &result.Vulnerabilities[i]
+		}
+	}
+	if finding == nil {
+		t.Fatalf("expected a boolean-blind finding on filter.id, got %+v", result.Vulnerabilities)
+	}
+
+	res, err := scanner.Extract(ctx, target, *finding, "@@version")
+	if err != nil {
+		t.Fatalf("Extract returned error: %v", err)
+	}
+	if res.Value != mockVersionMySQL {
+		t.Errorf("Extract = %q, want %q", res.Value, mockVersionMySQL)
+	}
+}
+
+func TestIntegration_CrossParameterSplit(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	target := func() *engine.ScanTarget {
+		return &engine.ScanTarget{
+			URL:    srv.URL + "/vuln/split?name=admin&city=paris",
+			Method: "GET",
+		}
+	}
+
+	// Without the opt-in, neither parameter is confirmed on its own.
+	cfg := engine.DefaultScanConfig()
+	result, err := newFullScanner(newTestClient(), cfg).Scan(context.Background(), target())
+	if err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+	for _, v := range result.Vulnerabilities {
+		if v.Injectable {
+			t.Fatalf("expected no single-parameter finding, got %+v", v)
+		}
+	}
+
+	cfg = engine.DefaultScanConfig()
+	cfg.CrossParam = true
+	cfg.Risk = 3
+	result, err = newFullScanner(newTestClient(), cfg).Scan(context.Background(), target())
+	if err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+
+	var split *engine.Vulnerability
+	for i, v := range result.Vulnerabilities {
+		if v.Injectable && v.PairedParameter != nil {
+			split = &result.Vulnerabilities[i]
+		}
+	}
+	if split == nil {
+		t.Fatalf("expected a cross-parameter finding, got %+v", result.Vulnerabilities)
+	}
+	if split.Parameter.Name != "name" || split.PairedParameter.Name != "city" {
+		t.Errorf("pair = (%s, %s), want (name, city)", split.Parameter.Name, split.PairedParameter.Name)
+	}
+	if split.Payload == "" || split.PairedPayload == "" {
+		t.Errorf("both payload halves should be recorded, got %q / %q", split.Payload, split.PairedPayload)
+	}
+}
+
 func TestIntegration_JSONReport(t *testing.T) {
 	srv := NewVulnServer()
 	defer srv.Close()
@@ -591,67 +1113,228 @@ func TestIntegration_ContextCancellation(t *testing.T) {
 	}
 }
 
-func TestIntegration_PostParameter(t *testing.T) {
+func TestIntegration_ScanAll(t *testing.T) {
 	srv := NewVulnServer()
 	defer srv.Close()
 
-	client := newTestClient()
+	client, err := transport.NewClient(transport.ClientOptions{})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer client.Close()
 	cfg := engine.DefaultScanConfig()
 	scanner := newFullScanner(client, cfg)
 
-	target := &engine.ScanTarget{
-		URL:         srv.URL + "/vuln/post",
-		Method:      "POST",
-		Body:        "username=admin&password=secret",
-		ContentType: "application/x-www-form-urlencoded",
+	targets := []*engine.ScanTarget{
+		{URL: srv.URL + "/vuln/error-mysql?id=1", Method: "GET"},
+		{URL: srv.URL + "/vuln/error-postgres?id=1", Method: "GET"},
+		{URL: srv.URL + "/vuln/boolean?id=1", Method: "GET"},
 	}
-
-	ctx := context.Background()
-	result, err := scanner.Scan(ctx, target)
+	results, summary, err := scanner.ScanAll(context.Background(), targets)
 	if err != nil {
-		t.Fatalf("Scan returned error: %v", err)
+		t.Fatalf("ScanAll: %v", err)
 	}
-
-	if result == nil {
-		t.Fatal("Scan returned nil result")
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
 	}
 
-	// Assert: vulnerability found in POST body parameter
-	var foundInjectable bool
-	for _, vuln := range result.Vulnerabilities {
-		if vuln.Injectable && vuln.Parameter.Name == "username" {
-			foundInjectable = true
-			break
+	// The PostgreSQL endpoint's own errors outweigh the DBMS found first on
+	// the host; the boolean-blind endpoint, which shows none, takes that
+	// DBMS instead of fingerprinting.
+	for i, want := range []string{"MySQL", "PostgreSQL", "MySQL"} {
+		r := results[i]
+		if r.Target.URL != targets[i].URL {
+			t.Errorf("results[%d] is %s, want %s", i, r.Target.URL, targets[i].URL)
+		}
+		if r.DBMS != want {
+			t.Errorf("%s: DBMS = %q, want %q", r.Target.URL, r.DBMS, want)
 		}
 	}
+	if summary.VulnerableTargets != 3 || summary.CompletedTargets != 3 {
+		t.Errorf("summary = %+v, want all 3 targets completed and vulnerable", summary)
+	}
+	if st := client.Stats(); summary.TotalRequests != st.TotalRequests {
+		t.Errorf("TotalRequests = %d, want the client's %d", summary.TotalRequests, st.TotalRequests)
+	}
 
-	if !foundInjectable {
-		t.Error("expected 'username' POST parameter to be injectable")
-		t.Logf("vulnerabilities found: %d", len(result.Vulnerabilities))
-		for _, v := range result.Vulnerabilities {
-			t.Logf("  param=%s technique=%s injectable=%v", v.Parameter.Name, v.Technique, v.Injectable)
-		}
+	var buf bytes.Buffer
+	if err := (&report.TextReporter{}).Render(context.Background(), report.MergeResults(results), &buf); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Merged from 3 reports:") {
+		t.Errorf("text report does not list the 3 targets:\n%s", buf.String())
 	}
 }
 
-func TestIntegration_TimeBased_MySQL(t *testing.T) {
+func TestIntegration_CrawlAndScan(t *testing.T) {
 	srv := NewVulnServer()
 	defer srv.Close()
 
 	client := newTestClient()
+	scanner := newFullScanner(client, engine.DefaultScanConfig())
+
+	// The product page is two clicks from the index: the crawl must fetch
+	// the listing to find it.
+	for _, tc := range []struct {
+		depth      int
+		vulnerable []string
+	}{
+		{depth: 1},
+		{depth: 2, vulnerable: []string{srv.URL + "/shop/product?id=1"}},
+	} {
+		t.Run(fmt.Sprintf("depth %d", tc.depth), func(t *testing.T) {
+			c := crawler.New(client, crawler.Options{
+				Depth:   tc.depth,
+				Exclude: regexp.MustCompile(`logout`),
+			})
+			targets, err := c.Crawl(context.Background(), srv.URL+"/shop/")
+			if err != nil {
+				t.Fatalf("Crawl: %v", err)
+			}
+			for _, target := range targets {
+				if strings.Contains(target.URL, "/shop/admin") || strings.Contains(target.URL, "logout") {
+					t.Errorf("crawl targeted %s", target.URL)
+				}
+			}
 
-	// Use short sleep (1s) with low tolerance so integration test stays fast.
-	// The VulnServer handler sleeps min(n, 1s), so threshold = 0 + 0.3*1s = 300ms.
-	// ForceTest=true bypasses heuristics: timebased endpoints show no content difference,
-	// so heuristics would mark them as non-injectable without this flag.
-	cfg := engine.DefaultScanConfig()
-	cfg.ForceTest = true
-	scanner := engine.NewScanner(client, cfg,
-		engine.WithTechniques(wrapTechniques(timebased.NewWithConfig(1, 0.3))...),
-		engine.WithParameterParser(makeParamParser()),
-		engine.WithHeuristicDetector(makeHeuristicFunc(client)),
-		engine.WithDBMSIdentifier(makeDBMSIdentifier()),
-		engine.WithFingerprinter(makeFingerprinter()),
+			results, _, err := scanner.ScanAll(context.Background(), targets)
+			if err != nil {
+				t.Fatalf("ScanAll: %v", err)
+			}
+			var vulnerable []string
+			for _, r := range results {
+				injectable := false
+				for _, v := range r.Vulnerabilities {
+					if !v.Injectable {
+						continue
+					}
+					injectable = true
+					if v.Parameter.Name != "id" {
+						t.Errorf("%s: injectable parameter %q, want id", r.Target.URL, v.Parameter.Name)
+					}
+				}
+				if injectable {
+					vulnerable = append(vulnerable, r.Target.URL)
+				}
+			}
+			if !slices.Equal(vulnerable, tc.vulnerable) {
+				t.Errorf("vulnerable targets = %v, want %v", vulnerable, tc.vulnerable)
+			}
+		})
+	}
+}
+
+func TestIntegration_LoginForm(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+	resp, err := client.Do(context.Background(), &transport.Request{Method: "GET", URL: srv.URL + "/vuln/login-form"})
+	if err != nil {
+		t.Fatalf("fetching the form: %v", err)
+	}
+	base, _ := url.Parse(srv.URL + "/vuln/login-form")
+	parsed := forms.Parse(base, resp.Body)
+	if len(parsed) != 1 {
+		t.Fatalf("got %d forms, want 1", len(parsed))
+	}
+	target := parsed[0].Target(false)
+	if target.Method != "POST" || target.URL != srv.URL+"/vuln/login" {
+		t.Fatalf("form target is %s %s, want POST %s/vuln/login", target.Method, target.URL, srv.URL)
+	}
+
+	// No user is called as the form is filled in, so the login finds no
+	// row for payloads to act on but the one a UNION adds.
+	scanner := engine.NewScanner(client, engine.DefaultScanConfig(),
+		engine.WithTechniques(wrapTechniques(errorbased.New(), boolean.New(), union.New())...),
+		engine.WithParameterParser(makeParamParser()),
+		engine.WithHeuristicDetector(makeHeuristicFunc(client)),
+		engine.WithDBMSIdentifier(makeDBMSIdentifier()),
+		engine.WithFingerprinter(makeFingerprinter()),
+	)
+	result, err := scanner.Scan(context.Background(), target)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	found := false
+	for _, v := range result.Vulnerabilities {
+		if v.Parameter.Name == "csrf" {
+			t.Errorf("the hidden csrf field was tested with %s", v.Technique)
+		}
+		if !v.Injectable {
+			continue
+		}
+		if v.Parameter.Name != "username" || v.Parameter.Location != engine.LocationBody {
+			t.Errorf("injectable %s parameter %q, want body parameter username", v.Parameter.Location, v.Parameter.Name)
+		}
+		found = true
+	}
+	if !found {
+		t.Error("the username field of the login form was not found injectable")
+	}
+}
+
+func TestIntegration_PostParameter(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+	cfg := engine.DefaultScanConfig()
+	scanner := newFullScanner(client, cfg)
+
+	target := &engine.ScanTarget{
+		URL:         srv.URL + "/vuln/post",
+		Method:      "POST",
+		Body:        "username=admin&password=secret",
+		ContentType: "application/x-www-form-urlencoded",
+	}
+
+	ctx := context.Background()
+	result, err := scanner.Scan(ctx, target)
+	if err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+
+	if result == nil {
+		t.Fatal("Scan returned nil result")
+	}
+
+	// Assert: vulnerability found in POST body parameter
+	var foundInjectable bool
+	for _, vuln := range result.Vulnerabilities {
+		if vuln.Injectable && vuln.Parameter.Name == "username" {
+			foundInjectable = true
+			break
+		}
+	}
+
+	if !foundInjectable {
+		t.Error("expected 'username' POST parameter to be injectable")
+		t.Logf("vulnerabilities found: %d", len(result.Vulnerabilities))
+		for _, v := range result.Vulnerabilities {
+			t.Logf("  param=%s technique=%s injectable=%v", v.Parameter.Name, v.Technique, v.Injectable)
+		}
+	}
+}
+
+func TestIntegration_TimeBased_MySQL(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+
+	// Use short sleep (1s) with low tolerance so integration test stays fast.
+	// The VulnServer handler sleeps min(n, 1s), so threshold = 0 + 0.3*1s = 300ms.
+	// ForceTest=true bypasses heuristics: timebased endpoints show no content difference,
+	// so heuristics would mark them as non-injectable without this flag.
+	cfg := engine.DefaultScanConfig()
+	cfg.ForceTest = true
+	scanner := engine.NewScanner(client, cfg,
+		engine.WithTechniques(wrapTechniques(timebased.NewWithConfig(1, 0.3, 0, 0))...),
+		engine.WithParameterParser(makeParamParser()),
+		engine.WithHeuristicDetector(makeHeuristicFunc(client)),
+		engine.WithDBMSIdentifier(makeDBMSIdentifier()),
+		engine.WithFingerprinter(makeFingerprinter()),
 	)
 
 	target := &engine.ScanTarget{
@@ -732,6 +1415,109 @@ func TestIntegration_ErrorBased_MSSQL(t *testing.T) {
 	}
 }
 
+// TestIntegration_ErrorBased_Oracle scans /vuln/error-oracle, then reads
+// USER back through the error-based and UNION techniques, whose probes must
+// select FROM dual.
+func TestIntegration_ErrorBased_Oracle(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+	scanner := newFullScanner(client, engine.DefaultScanConfig())
+	result, err := scanner.Scan(context.Background(), &engine.ScanTarget{
+		URL:    srv.URL + "/vuln/error-oracle?id=1",
+		Method: "GET",
+	})
+	if err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+	if !injectableTechniques(result)["error-based"] {
+		t.Error("expected error-based technique to detect Oracle vulnerability")
+		for _, v := range result.Vulnerabilities {
+			t.Logf("  param=%s technique=%s injectable=%v", v.Parameter.Name, v.Technique, v.Injectable)
+		}
+	}
+	if !strings.Contains(result.DBMS, "Oracle") {
+		t.Errorf("DBMS = %q, want to contain 'Oracle'", result.DBMS)
+	}
+
+	target := &engine.ScanTarget{URL: srv.URL + "/vuln/error-oracle?id=1", Method: "GET"}
+	baseline, err := client.Do(context.Background(), &transport.Request{Method: "GET", URL: target.URL})
+	if err != nil {
+		t.Fatalf("baseline: %v", err)
+	}
+	for _, tech := range []technique.Technique{errorbased.New(), union.New()} {
+		t.Run(tech.Name(), func(t *testing.T) {
+			req := technique.InjectionRequest{
+				Target:    target,
+				Parameter: &engine.Parameter{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+				Baseline:  baseline,
+				DBMS:      "Oracle",
+				Client:    client,
+				Coverage:  payloadlib.NewCoverage(),
+			}
+			det, err := tech.Detect(context.Background(), &req)
+			if err != nil {
+				t.Fatalf("Detect: %v", err)
+			}
+			if !det.Injectable {
+				t.Fatal("expected detection")
+			}
+			res, err := tech.Extract(context.Background(), &technique.ExtractionRequest{
+				InjectionRequest: req,
+				Query:            "USER",
+			})
+			if err != nil {
+				t.Fatalf("Extract: %v", err)
+			}
+			if res.Value != "SHOP" {
+				t.Errorf("extracted %q, want %q", res.Value, "SHOP")
+			}
+		})
+	}
+}
+
+func TestIntegration_NoQuote(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+	target := &engine.ScanTarget{URL: srv.URL + "/vuln/noquote?id=1", Method: "GET"}
+	baseline, err := client.Do(context.Background(), &transport.Request{Method: "GET", URL: target.URL})
+	if err != nil {
+		t.Fatalf("baseline: %v", err)
+	}
+	for _, tech := range []technique.Technique{errorbased.New(), union.New()} {
+		t.Run(tech.Name(), func(t *testing.T) {
+			req := technique.InjectionRequest{
+				Target:    target,
+				Parameter: &engine.Parameter{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+				Baseline:  baseline,
+				DBMS:      "MySQL",
+				Client:    client,
+				Coverage:  payloadlib.NewCoverage(),
+			}
+			det, err := tech.Detect(context.Background(), &req)
+			if err != nil {
+				t.Fatalf("Detect: %v", err)
+			}
+			if !det.Injectable {
+				t.Fatal("expected detection with quotes blocked")
+			}
+			res, err := tech.Extract(context.Background(), &technique.ExtractionRequest{
+				InjectionRequest: req,
+				Query:            "SELECT password FROM users WHERE username='admin'",
+			})
+			if err != nil {
+				t.Fatalf("Extract: %v", err)
+			}
+			if res.Value != "s3cret" {
+				t.Errorf("extracted %q, want %q", res.Value, "s3cret")
+			}
+		})
+	}
+}
+
 func TestIntegration_TimeBased_PostgreSQL(t *testing.T) {
 	srv := NewVulnServer()
 	defer srv.Close()
@@ -741,7 +1527,7 @@ func TestIntegration_TimeBased_PostgreSQL(t *testing.T) {
 	cfg := engine.DefaultScanConfig()
 	cfg.ForceTest = true
 	scanner := engine.NewScanner(client, cfg,
-		engine.WithTechniques(wrapTechniques(timebased.NewWithConfig(1, 0.3))...),
+		engine.WithTechniques(wrapTechniques(timebased.NewWithConfig(1, 0.3, 0, 0))...),
 		engine.WithParameterParser(makeParamParser()),
 		engine.WithHeuristicDetector(makeHeuristicFunc(client)),
 		engine.WithDBMSIdentifier(makeDBMSIdentifier()),
@@ -775,14 +1561,144 @@ func TestIntegration_TimeBased_PostgreSQL(t *testing.T) {
 	t.Logf("request count: %d", result.RequestCount)
 }
 
-func TestIntegration_UnionBased_MySQL(t *testing.T) {
+// TestIntegration_TimeBased_MSSQL detects /vuln/timebased-mssql, where
+// only a stacked WAITFOR sleeps: the inline heavy query fails there.
+func TestIntegration_TimeBased_MSSQL(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	req := technique.InjectionRequest{
+		Target:    &engine.ScanTarget{URL: srv.URL + "/vuln/timebased-mssql?id=1", Method: "GET"},
+		Parameter: &engine.Parameter{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+		DBMS:      "MSSQL",
+		Client:    newTestClient(),
+	}
+	det, err := timebased.NewWithConfig(1, 0.3, 0, 0).Detect(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("Detect: %v", err)
+	}
+	if !det.Injectable {
+		t.Fatal("expected time-based technique to detect vulnerability on /vuln/timebased-mssql")
+	}
+	if !strings.Contains(det.Evidence, "via stacked WAITFOR DELAY") {
+		t.Errorf("evidence does not name the stacked WAITFOR: %s", det.Evidence)
+	}
+	if p := det.Payload.String(); !strings.Contains(p, "; IF (1=1) WAITFOR DELAY") {
+		t.Errorf("payload = %q, want a stacked WAITFOR", p)
+	}
+}
+
+// TestIntegration_TimeBased_HeavyQuery detects /vuln/timebased-nosleep,
+// which blocks SLEEP, with a calibrated BENCHMARK at HeavyRisk.
+func TestIntegration_TimeBased_HeavyQuery(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	req := technique.InjectionRequest{
+		Target:    &engine.ScanTarget{URL: srv.URL + "/vuln/timebased-nosleep?id=1", Method: "GET"},
+		Parameter: &engine.Parameter{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+		DBMS:      "MySQL",
+		Client:    newTestClient(),
+		Risk:      timebased.HeavyRisk,
+	}
+	det, err := timebased.NewWithConfig(1, 0.3, 0, 0).Detect(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("Detect: %v", err)
+	}
+	if !det.Injectable {
+		t.Fatal("expected time-based technique to detect vulnerability on /vuln/timebased-nosleep")
+	}
+	if !strings.Contains(det.Evidence, "BENCHMARK heavy query") {
+		t.Errorf("evidence does not name the heavy query: %s", det.Evidence)
+	}
+	if p := det.Payload.String(); !strings.Contains(p, "BENCHMARK(") || strings.Contains(p, "SLEEP(") {
+		t.Errorf("payload = %q, want a BENCHMARK without SLEEP", p)
+	}
+}
+
+// statelessTechnique hides the parameter state the techniques share, to
+// scan as if each technique searched for the boundary on its own.
+type statelessTechnique struct{ engine.Technique }
+
+func (s statelessTechnique) Detect(ctx context.Context, req *engine.TechniqueRequest) (*engine.DetectionResult, error) {
+	r := *req
+	r.State = nil
+	return s.Technique.Detect(ctx, &r)
+}
+
+// TestIntegration_SharedBoundary scans /vuln/paren, where only the ')
+// boundary works, at level 3, the lowest to try it, with and without the
+// shared parameter state: once error-based detection finds the ')
+// boundary, the later techniques try it first and skip the others, such
+// as the heavy queries time-based tries on each at risk 2. Boolean-blind
+// is left out, as its quoted conditions cannot be closed by a comment
+// suffix.
+func TestIntegration_SharedBoundary(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	scan := func(shared bool) *engine.ScanResult {
+		t.Helper()
+		techs := wrapTechniques(errorbased.New(), timebased.NewWithConfig(1, 0.3, 0, 0), union.New())
+		if !shared {
+			for i, tech := range techs {
+				techs[i] = statelessTechnique{tech}
+			}
+		}
+		client := newTestClient()
+		cfg := engine.DefaultScanConfig()
+		cfg.Threads = 1 // Techniques run one after another, in priority order
+		cfg.Level = 3
+		cfg.Risk = timebased.HeavyRisk
+		cfg.ParamInclude = []string{"name"} // Not the headers level 3 tests
+		cfg.PerTechnique = true             // Every technique's payload is checked
+		scanner := engine.NewScanner(client, cfg,
+			engine.WithTechniques(techs...),
+			engine.WithParameterParser(makeParamParser()),
+			engine.WithHeuristicDetector(makeHeuristicFunc(client)),
+			engine.WithDBMSIdentifier(makeDBMSIdentifier()),
+			engine.WithFingerprinter(makeFingerprinter()),
+		)
+		result, err := scanner.Scan(context.Background(), &engine.ScanTarget{
+			URL:    srv.URL + "/vuln/paren?name=Widget",
+			Method: "GET",
+		})
+		if err != nil {
+			t.Fatalf("Scan returned error: %v", err)
+		}
+		found := make(map[string]bool)
+		for _, v := range result.Vulnerabilities {
+			if v.Injectable {
+				found[v.Technique] = true
+				if shared && !strings.Contains(v.Payload, "')") {
+					t.Errorf("%s payload %q does not use the ') boundary", v.Technique, v.Payload)
+				}
+			}
+		}
+		for _, name := range []string{"error-based", "time-based", "union-based"} {
+			if !found[name] {
+				t.Errorf("shared=%v: %s found no injection", shared, name)
+			}
+		}
+		return result
+	}
+
+	alone := scan(false)
+	shared := scan(true)
+	t.Logf("requests: %d with a shared boundary, %d without", shared.RequestCount, alone.RequestCount)
+	if saved := alone.RequestCount - shared.RequestCount; saved*5 < alone.RequestCount {
+		t.Errorf("shared boundary saved %d requests (%d vs %d), want at least a fifth", saved, shared.RequestCount, alone.RequestCount)
+	}
+}
+
+// TestIntegration_Enumerate lists the catalog of the fake
+// information_schema, and runs user queries, through a union-based finding.
+func TestIntegration_Enumerate(t *testing.T) {
 	srv := NewVulnServer()
 	defer srv.Close()
 
 	client := newTestClient()
 	cfg := engine.DefaultScanConfig()
-	// ForceTest=true bypasses heuristics: the UNION endpoint returns the same
-	// page for single-quote probes, so heuristics would skip it without this flag.
 	cfg.ForceTest = true
 	scanner := engine.NewScanner(client, cfg,
 		engine.WithTechniques(wrapTechniques(union.New())...),
@@ -791,38 +1707,118 @@ func TestIntegration_UnionBased_MySQL(t *testing.T) {
 		engine.WithDBMSIdentifier(makeDBMSIdentifier()),
 		engine.WithFingerprinter(makeFingerprinter()),
 	)
-
-	target := &engine.ScanTarget{
-		URL:    srv.URL + "/vuln/union-mysql?id=1",
-		Method: "GET",
+	target := &engine.ScanTarget{URL: srv.URL + "/vuln/union-mysql-errors?id=1", Method: "GET"}
+	result, err := scanner.Scan(context.Background(), target)
+	if err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+	var finding *engine.Vulnerability
+	for i, v := range result.Vulnerabilities {
+		if v.Injectable && v.Technique == "union-based" {
+			finding = &result.Vulnerabilities[i]
+		}
+	}
+	if finding == nil {
+		t.Fatal("expected a union-based finding on /vuln/union-mysql-errors")
 	}
 
+	en, err := enum.New(scanner, target, *finding)
+	if err != nil {
+		t.Fatalf("enum.New: %v", err)
+	}
 	ctx := context.Background()
-	result, err := scanner.Scan(ctx, target)
+	if banner, err := en.Banner(ctx); err != nil || banner != "8.0.32" {
+		t.Errorf("Banner = %q, %v; want 8.0.32", banner, err)
+	}
+	dbs, err := en.Databases(ctx)
 	if err != nil {
-		t.Fatalf("Scan returned error: %v", err)
+		t.Fatalf("Databases: %v", err)
 	}
-	if result == nil {
-		t.Fatal("Scan returned nil result")
+	if want := []string{"information_schema", "shop"}; !slices.Equal(dbs, want) {
+		t.Errorf("Databases = %q, want %q", dbs, want)
+	}
+	tables, err := en.Tables(ctx, "shop")
+	if err != nil {
+		t.Fatalf("Tables: %v", err)
 	}
+	if !slices.Contains(tables, "users") || slices.Contains(tables, "schemata") {
+		t.Errorf("Tables(shop) = %q, want the shop tables", tables)
+	}
+	cols, err := en.Columns(ctx, "shop", "users")
+	if err != nil {
+		t.Fatalf("Columns: %v", err)
+	}
+	if want := []string{"id", "name", "password", "username"}; !slices.Equal(cols, want) {
+		t.Errorf("Columns(shop, users) = %q, want %q", cols, want)
+	}
+	if rows, err := en.Query(ctx, "@@version"); err != nil || !slices.Equal(rows, []string{"8.0.32"}) {
+		t.Errorf("Query(@@version) = %q, %v; want 8.0.32", rows, err)
+	}
+	rows, err := en.Query(ctx, "SELECT username FROM shop.users")
+	if want := []string{"admin", "guest"}; err != nil || !slices.Equal(rows, want) {
+		t.Errorf("Query(SELECT username) = %q, %v; want %q", rows, err, want)
+	}
+	if en.Requests() == 0 {
+		t.Error("Requests() = 0, want the extraction requests counted")
+	}
+}
 
-	var foundUnion bool
-	for _, vuln := range result.Vulnerabilities {
-		if vuln.Injectable && vuln.Technique == "union-based" {
-			foundUnion = true
+// TestIntegration_ExtractChain reads @@version through an error-based
+// finding on an endpoint whose WAF blocks the XPath functions error-based
+// extraction reads through: the chain falls back to union-based.
+func TestIntegration_ExtractChain(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+	cfg := engine.DefaultScanConfig()
+	cfg.ForceTest = true
+	cfg.PerTechnique = true // The union-based finding's own boundary is used
+	scanner := engine.NewScanner(client, cfg,
+		engine.WithTechniques(wrapTechniques(errorbased.New(), union.New())...),
+		engine.WithParameterParser(makeParamParser()),
+		engine.WithHeuristicDetector(makeHeuristicFunc(client)),
+		engine.WithDBMSIdentifier(makeDBMSIdentifier()),
+		engine.WithFingerprinter(makeFingerprinter()),
+	)
+	target := &engine.ScanTarget{URL: srv.URL + "/vuln/union-mysql?id=1", Method: "GET"}
+	result, err := scanner.Scan(context.Background(), target)
+	if err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+	var finding *engine.Vulnerability
+	for i, v := range result.Vulnerabilities {
+		if v.Injectable && v.Technique == "union-based" {
+			finding = &result.Vulnerabilities[i]
 		}
 	}
+	if finding == nil {
+		t.Fatal("expected a union-based finding on /vuln/union-mysql")
+	}
+	if finding.Boundary == nil {
+		t.Error("finding has no boundary recorded")
+	}
 
-	if !foundUnion {
-		t.Error("expected union-based technique to detect vulnerability on /vuln/union-mysql")
-		for _, v := range result.Vulnerabilities {
-			t.Logf("  param=%s technique=%s injectable=%v", v.Parameter.Name, v.Technique, v.Injectable)
-		}
+	// The finding, as if error-based had made it, on the same query behind
+	// the WAF.
+	vuln := *finding
+	vuln.Technique = "error-based"
+	filtered := &engine.ScanTarget{URL: srv.URL + "/vuln/union-xpath?id=1", Method: "GET"}
+	res, err := scanner.ExtractChain(context.Background(), filtered, vuln, "@@version", engine.ChainOptions{})
+	if err != nil {
+		t.Fatalf("ExtractChain: %v", err)
+	}
+	if res.Value != "8.0.32" || res.Technique != "union-based" {
+		t.Errorf("result = %+v, want 8.0.32 from union-based", res)
+	}
+	if len(res.Attempts) != 2 || res.Attempts[0].Technique != "error-based" || res.Requests <= res.Attempts[1].Requests {
+		t.Errorf("Attempts = %+v, Requests = %d; want error-based tried first and its requests counted", res.Attempts, res.Requests)
 	}
-	t.Logf("request count: %d", result.RequestCount)
 }
 
-func TestIntegration_UnionBased_PostgreSQL(t *testing.T) {
+// TestIntegration_DumpTable dumps the seeded notes table to CSV through a
+// union-based finding, then resumes a dump cancelled after its first row.
+func TestIntegration_DumpTable(t *testing.T) {
 	srv := NewVulnServer()
 	defer srv.Close()
 
@@ -836,33 +1832,1408 @@ func TestIntegration_UnionBased_PostgreSQL(t *testing.T) {
 		engine.WithDBMSIdentifier(makeDBMSIdentifier()),
 		engine.WithFingerprinter(makeFingerprinter()),
 	)
-
-	target := &engine.ScanTarget{
-		URL:    srv.URL + "/vuln/union-postgres?id=1",
-		Method: "GET",
-	}
-
-	ctx := context.Background()
-	result, err := scanner.Scan(ctx, target)
+	target := &engine.ScanTarget{URL: srv.URL + "/vuln/union-mysql-errors?id=1", Method: "GET"}
+	result, err := scanner.Scan(context.Background(), target)
 	if err != nil {
 		t.Fatalf("Scan returned error: %v", err)
 	}
-	if result == nil {
-		t.Fatal("Scan returned nil result")
+	var finding *engine.Vulnerability
+	for i, v := range result.Vulnerabilities {
+		if v.Injectable && v.Technique == "union-based" {
+			finding = &result.Vulnerabilities[i]
+		}
+	}
+	if finding == nil {
+		t.Fatal("expected a union-based finding on /vuln/union-mysql-errors")
 	}
 
-	var foundUnion bool
-	for _, vuln := range result.Vulnerabilities {
-		if vuln.Injectable && vuln.Technique == "union-based" {
-			foundUnion = true
-		}
+	en, err := enum.New(scanner, target, *finding)
+	if err != nil {
+		t.Fatalf("enum.New: %v", err)
 	}
+	columns := []string{"id", "author", "body"}
+	want := "id,author,body\n" +
+		"1,admin,\"say \"\"hi\"\", then leave\"\n" +
+		"2,,unsigned\n" +
+		"3,guest,a|:s9:|b\n"
 
-	if !foundUnion {
-		t.Error("expected union-based technique to detect vulnerability on /vuln/union-postgres")
-		for _, v := range result.Vulnerabilities {
-			t.Logf("  param=%s technique=%s injectable=%v", v.Parameter.Name, v.Technique, v.Injectable)
-		}
+	var buf bytes.Buffer
+	if err := en.DumpTable(context.Background(), "shop", "notes", columns, &buf); err != nil {
+		t.Fatalf("DumpTable: %v", err)
+	}
+	if buf.String() != want {
+		t.Errorf("DumpTable wrote\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	// Cancel after the count and first row, then resume from the offset.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cut := &cancellingExtractor{ext: scanner, cancel: cancel, after: 2}
+	en, err = enum.New(cut, target, *finding)
+	if err != nil {
+		t.Fatalf("enum.New: %v", err)
+	}
+	buf.Reset()
+	err = en.DumpTable(ctx, "shop", "notes", columns, &buf)
+	var partial *enum.PartialError
+	if !errors.As(err, &partial) || partial.Offset != 1 {
+		t.Fatalf("cancelled DumpTable error = %v, want a PartialError at offset 1", err)
+	}
+	en, err = enum.New(scanner, target, *finding)
+	if err != nil {
+		t.Fatalf("enum.New: %v", err)
+	}
+	if err := en.DumpTableFrom(context.Background(), "shop", "notes", columns, partial.Offset, &buf); err != nil {
+		t.Fatalf("DumpTableFrom: %v", err)
+	}
+	if buf.String() != want {
+		t.Errorf("resumed dump wrote\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+// TestIntegration_DumpRange dumps rows 3-5 of the seeded members table,
+// then dumps the table again cut short by cancellation and resumed from
+// the offset a session store saved.
+func TestIntegration_DumpRange(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+	scanner, target, finding := unionMySQLFinding(t, srv)
+
+	en, err := enum.New(scanner, target, finding)
+	if err != nil {
+		t.Fatalf("enum.New: %v", err)
+	}
+	columns := []string{"id", "username"}
+	rows := enum.RowRange{Start: 3, Stop: 5}
+	// Rows are read in the order of their text, id first.
+	want := "# rows 3-5 of 120\n" +
+		"id,username\n" +
+		"102,member102\n" +
+		"103,member103\n" +
+		"104,member104\n"
+
+	var buf bytes.Buffer
+	if err := en.Dump(context.Background(), "shop", "members", columns, enum.DumpOptions{Range: rows}, &buf); err != nil {
+		t.Fatalf("Dump: %v", err)
+	}
+	if buf.String() != want {
+		t.Errorf("Dump wrote\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	// Cancel after the count and the first two rows of the range; the
+	// store keeps the offset of the third.
+	store, err := session.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	defer store.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opts := enum.DumpOptions{
+		Range: rows,
+		Progress: func(next int) {
+			if err := store.SaveDumpOffset(context.Background(), target.URL, "shop.members", next); err != nil {
+				t.Errorf("SaveDumpOffset: %v", err)
+			}
+		},
+	}
+	en, err = enum.New(&cancellingExtractor{ext: scanner, cancel: cancel, after: 3}, target, finding)
+	if err != nil {
+		t.Fatalf("enum.New: %v", err)
+	}
+	buf.Reset()
+	err = en.Dump(ctx, "shop", "members", columns, opts, &buf)
+	var partial *enum.PartialError
+	if !errors.As(err, &partial) || partial.Read != 2 || partial.Total != 3 {
+		t.Fatalf("cancelled Dump error = %v, want a PartialError after 2 of 3 rows", err)
+	}
+
+	opts.Offset, err = store.LoadDumpOffset(context.Background(), target.URL, "shop.members")
+	if err != nil || opts.Offset != partial.Offset || opts.Offset != 4 {
+		t.Fatalf("LoadDumpOffset = %d, %v; want 4, the PartialError's offset", opts.Offset, err)
+	}
+	en, err = enum.New(scanner, target, finding)
+	if err != nil {
+		t.Fatalf("enum.New: %v", err)
+	}
+	if err := en.Dump(context.Background(), "shop", "members", columns, opts, &buf); err != nil {
+		t.Fatalf("resumed Dump: %v", err)
+	}
+	if buf.String() != want {
+		t.Errorf("resumed dump wrote\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+// TestIntegration_FileRead reads a text and a binary file off the MySQL
+// and PostgreSQL endpoints, whose file functions read the fixture's files,
+// through the union-based findings.
+func TestIntegration_FileRead(t *testing.T) {
+	srv := httptest.NewServer(VulnHandler())
+	defer srv.Close()
+
+	files := serverFiles()
+	for _, path := range []string{"/vuln/union-mysql-errors", "/vuln/union-postgres-errors"} {
+		scanner, target, finding := unionFinding(t, srv, path)
+		en, err := enum.New(scanner, target, finding)
+		if err != nil {
+			t.Fatalf("%s: enum.New: %v", path, err)
+		}
+		for _, file := range []string{"/etc/passwd", "/var/www/logo.png"} {
+			got, err := en.FileRead(context.Background(), file)
+			if err != nil {
+				t.Errorf("%s: FileRead(%s): %v", path, file, err)
+				continue
+			}
+			if string(got) != files[file] {
+				t.Errorf("%s: FileRead(%s) = %q, want %q", path, file, got, files[file])
+			}
+		}
+		if _, err := en.FileRead(context.Background(), "/etc/shadow"); !errors.Is(err, enum.ErrFileUnreadable) {
+			t.Errorf("%s: FileRead(/etc/shadow) error = %v, want ErrFileUnreadable", path, err)
+		}
+	}
+}
+
+// unionMySQLFinding scans /vuln/union-mysql-errors on srv with the union-based
+// technique and returns the scanner, target and union-based finding.
+func unionMySQLFinding(t *testing.T, srv *httptest.Server) (*engine.Scanner, *engine.ScanTarget, engine.Vulnerability) {
+	t.Helper()
+	return unionFinding(t, srv, "/vuln/union-mysql-errors")
+}
+
+// unionFinding scans the endpoint at path on srv with the union-based
+// technique and returns the scanner, target and union-based finding, its
+// DBMS filled in from the scan's.
+func unionFinding(t *testing.T, srv *httptest.Server, path string) (*engine.Scanner, *engine.ScanTarget, engine.Vulnerability) {
+	t.Helper()
+	client := newTestClient()
+	cfg := engine.DefaultScanConfig()
+	cfg.ForceTest = true
+	scanner := engine.NewScanner(client, cfg,
+		engine.WithTechniques(wrapTechniques(union.New())...),
+		engine.WithParameterParser(makeParamParser()),
+		engine.WithHeuristicDetector(makeHeuristicFunc(client)),
+		engine.WithDBMSIdentifier(makeDBMSIdentifier()),
+		engine.WithFingerprinter(makeFingerprinter()),
+	)
+	target := &engine.ScanTarget{URL: srv.URL + path + "?id=1", Method: "GET"}
+	result, err := scanner.Scan(context.Background(), target)
+	if err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+	for _, v := range result.Vulnerabilities {
+		if v.Injectable && v.Technique == "union-based" {
+			if v.DBMS == "" {
+				v.DBMS = result.DBMS
+			}
+			return scanner, target, v
+		}
+	}
+	t.Fatalf("expected a union-based finding on %s", path)
+	return nil, nil, engine.Vulnerability{}
+}
+
+// cancellingExtractor passes extractions through to ext, cancelling the
+// context once after extractions have completed.
+type cancellingExtractor struct {
+	ext    enum.Extractor
+	cancel context.CancelFunc
+	after  int
+	calls  int
+}
+
+func (c *cancellingExtractor) Extract(ctx context.Context, target *engine.ScanTarget, vuln engine.Vulnerability, query string) (*engine.ExtractionResult, error) {
+	res, err := c.ext.Extract(ctx, target, vuln, query)
+	c.calls++
+	if c.calls == c.after {
+		c.cancel()
+	}
+	return res, err
+}
+
+func TestIntegration_UnionBased_MySQL(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+	cfg := engine.DefaultScanConfig()
+	// ForceTest=true bypasses heuristics: the UNION endpoint returns the same
+	// page for single-quote probes, so heuristics would skip it without this flag.
+	cfg.ForceTest = true
+	scanner := engine.NewScanner(client, cfg,
+		engine.WithTechniques(wrapTechniques(union.New())...),
+		engine.WithParameterParser(makeParamParser()),
+		engine.WithHeuristicDetector(makeHeuristicFunc(client)),
+		engine.WithDBMSIdentifier(makeDBMSIdentifier()),
+		engine.WithFingerprinter(makeFingerprinter()),
+	)
+
+	target := &engine.ScanTarget{
+		URL:    srv.URL + "/vuln/union-mysql?id=1",
+		Method: "GET",
+	}
+
+	ctx := context.Background()
+	result, err := scanner.Scan(ctx, target)
+	if err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+	if result == nil {
+		t.Fatal("Scan returned nil result")
+	}
+
+	var foundUnion bool
+	for _, vuln := range result.Vulnerabilities {
+		if vuln.Injectable && vuln.Technique == "union-based" {
+			foundUnion = true
+		}
+	}
+
+	if !foundUnion {
+		t.Error("expected union-based technique to detect vulnerability on /vuln/union-mysql")
+		for _, v := range result.Vulnerabilities {
+			t.Logf("  param=%s technique=%s injectable=%v", v.Parameter.Name, v.Technique, v.Injectable)
+		}
+	}
+	t.Logf("request count: %d", result.RequestCount)
+}
+
+func TestIntegration_UnionBased_PostgreSQL(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+	cfg := engine.DefaultScanConfig()
+	cfg.ForceTest = true
+	scanner := engine.NewScanner(client, cfg,
+		engine.WithTechniques(wrapTechniques(union.New())...),
+		engine.WithParameterParser(makeParamParser()),
+		engine.WithHeuristicDetector(makeHeuristicFunc(client)),
+		engine.WithDBMSIdentifier(makeDBMSIdentifier()),
+		engine.WithFingerprinter(makeFingerprinter()),
+	)
+
+	target := &engine.ScanTarget{
+		URL:    srv.URL + "/vuln/union-postgres?id=1",
+		Method: "GET",
+	}
+
+	ctx := context.Background()
+	result, err := scanner.Scan(ctx, target)
+	if err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+	if result == nil {
+		t.Fatal("Scan returned nil result")
+	}
+
+	var foundUnion bool
+	for _, vuln := range result.Vulnerabilities {
+		if vuln.Injectable && vuln.Technique == "union-based" {
+			foundUnion = true
+		}
+	}
+
+	if !foundUnion {
+		t.Error("expected union-based technique to detect vulnerability on /vuln/union-postgres")
+		for _, v := range result.Vulnerabilities {
+			t.Logf("  param=%s technique=%s injectable=%v", v.Parameter.Name, v.Technique, v.Injectable)
+		}
+	}
+	t.Logf("request count: %d", result.RequestCount)
+}
+
+func TestIntegration_UnionBased_OrderByFiltered(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+	cfg := engine.DefaultScanConfig()
+	cfg.ForceTest = true
+	scanner := engine.NewScanner(client, cfg,
+		engine.WithTechniques(wrapTechniques(union.New())...),
+		engine.WithParameterParser(makeParamParser()),
+		engine.WithHeuristicDetector(makeHeuristicFunc(client)),
+		engine.WithDBMSIdentifier(makeDBMSIdentifier()),
+		engine.WithFingerprinter(makeFingerprinter()),
+	)
+
+	result, err := scanner.Scan(context.Background(), &engine.ScanTarget{
+		URL:    srv.URL + "/vuln/union-filtered?id=1",
+		Method: "GET",
+	})
+	if err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+
+	var union *engine.Vulnerability
+	for i, vuln := range result.Vulnerabilities {
+		if vuln.Injectable && vuln.Technique == "union-based" {
+			union = &result.Vulnerabilities[i]
+		}
+	}
+	if union == nil {
+		t.Fatal("expected union-based technique to detect vulnerability on /vuln/union-filtered")
+	}
+	if !strings.Contains(union.Evidence, "2 columns (found by UNION SELECT NULL)") {
+		t.Errorf("Evidence = %q, want the UNION SELECT NULL column count", union.Evidence)
+	}
+}
+
+func TestIntegration_UnionExtract_JSONFilter(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+	target := &engine.ScanTarget{URL: srv.URL + "/vuln/union-mysql?id=1", Method: "GET"}
+	baseline, err := client.Do(context.Background(), &transport.Request{Method: "GET", URL: target.URL})
+	if err != nil {
+		t.Fatalf("baseline: %v", err)
+	}
+	extract := func(query, filter string) *technique.ExtractionResult {
+		t.Helper()
+		f, err := jsonpath.Parse(filter)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", filter, err)
+		}
+		res, err := technique.Extract(context.Background(), union.New(), &technique.ExtractionRequest{
+			InjectionRequest: technique.InjectionRequest{
+				Target:    target,
+				Parameter: &engine.Parameter{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+				Baseline:  baseline,
+				DBMS:      "MySQL",
+				Client:    client,
+			},
+			Query:  query,
+			Filter: f,
+		})
+		if err != nil {
+			t.Fatalf("Extract(%q): %v", query, err)
+		}
+		return res
+	}
+
+	res := extract("(SELECT data FROM profiles WHERE user_id=1)", "$.contact.emails[1]")
+	if !strings.HasPrefix(res.Value, `{"display":"Admin <root>"`) {
+		t.Errorf("raw value = %q, want the JSON document", res.Value)
+	}
+	if res.Filtered != "ops@shop.test" || res.FilterNote != "" {
+		t.Errorf("Filtered = %q (note %q), want ops@shop.test", res.Filtered, res.FilterNote)
+	}
+	if res.Display() != "ops@shop.test" {
+		t.Errorf("Display = %q", res.Display())
+	}
+
+	res = extract("@@version", "$.contact")
+	if res.Filtered != "" || !strings.Contains(res.FilterNote, "not JSON") {
+		t.Errorf("non-JSON value: Filtered = %q, note %q", res.Filtered, res.FilterNote)
+	}
+	if res.Display() != mockVersionMySQL {
+		t.Errorf("Display = %q, want the raw value %q", res.Display(), mockVersionMySQL)
+	}
+}
+
+// TestIntegration_UnionExtractAll reads whole tables through the UNION
+// endpoints: in one request when the aggregate fits, and row by row when
+// MySQL's group_concat_max_len truncates the seeded members table.
+func TestIntegration_UnionExtractAll(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+	extractAll := func(path, dbmsName, query string) *union.RowsResult {
+		t.Helper()
+		target := &engine.ScanTarget{URL: srv.URL + path + "?id=1", Method: "GET"}
+		baseline, err := client.Do(context.Background(), &transport.Request{Method: "GET", URL: target.URL})
+		if err != nil {
+			t.Fatalf("baseline: %v", err)
+		}
+		res, err := union.New().ExtractAll(context.Background(), &technique.ExtractionRequest{
+			InjectionRequest: technique.InjectionRequest{
+				Target:    target,
+				Parameter: &engine.Parameter{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+				Baseline:  baseline,
+				DBMS:      dbmsName,
+				Client:    client,
+			},
+			Query: query,
+		})
+		if err != nil {
+			t.Fatalf("ExtractAll(%q): %v", query, err)
+		}
+		return res
+	}
+
+	for _, tt := range []struct{ path, dbms string }{
+		{"/vuln/union-mysql", "MySQL"},
+		{"/vuln/union-postgres", "PostgreSQL"},
+	} {
+		res := extractAll(tt.path, tt.dbms, "SELECT username FROM users")
+		if got := strings.Join(res.Rows, ","); got != "admin,guest" || res.Paged || res.Partial {
+			t.Errorf("%s: rows %q (paged %v, partial %v), want admin,guest in one request", tt.path, got, res.Paged, res.Partial)
+		}
+	}
+
+	res := extractAll("/vuln/union-mysql", "MySQL", "SELECT name FROM products WHERE id>99")
+	if len(res.Rows) != 0 || res.Partial {
+		t.Errorf("no matching rows: got %q (partial %v)", res.Rows, res.Partial)
+	}
+
+	res = extractAll("/vuln/union-mysql", "MySQL", "SELECT username FROM members")
+	if !res.Paged {
+		t.Error("members overflow group_concat_max_len; expected the LIMIT/OFFSET fallback")
+	}
+	if len(res.Rows) != mockMemberCount || res.Rows[0] != "member001" || res.Rows[mockMemberCount-1] != fmt.Sprintf("member%03d", mockMemberCount) {
+		t.Errorf("got %d rows (%q...), want member001..member%03d", len(res.Rows), res.Rows[:min(3, len(res.Rows))], mockMemberCount)
+	}
+	t.Logf("members: %d rows in %d requests", len(res.Rows), res.Requests)
+}
+
+func TestIntegration_NonceHeaders(t *testing.T) {
+	srv := httptest.NewServer(RequireNonce(VulnHandler(), "X-Nonce", "X-Timestamp", 30*time.Second))
+	defer srv.Close()
+
+	scan := func(nonces []transport.NonceHeader) *engine.ScanResult {
+		t.Helper()
+		client, err := transport.NewClient(transport.ClientOptions{Timeout: 10 * time.Second, NonceHeaders: nonces})
+		if err != nil {
+			t.Fatalf("NewClient: %v", err)
+		}
+		scanner := engine.NewScanner(client, engine.DefaultScanConfig(),
+			engine.WithTechniques(wrapTechniques(errorbased.New(), boolean.New(), union.New())...),
+			engine.WithParameterParser(makeParamParser()),
+			engine.WithHeuristicDetector(makeHeuristicFunc(client)),
+			engine.WithDBMSIdentifier(makeDBMSIdentifier()),
+			engine.WithFingerprinter(makeFingerprinter()),
+		)
+		result, err := scanner.Scan(context.Background(), &engine.ScanTarget{
+			URL:    srv.URL + "/vuln/union-mysql-errors?id=1",
+			Method: "GET",
+		})
+		if err != nil {
+			t.Fatalf("Scan returned error: %v", err)
+		}
+		return result
+	}
+
+	// Without nonces every probe is refused and nothing is found.
+	if result := scan(nil); len(injectableTechniques(result)) != 0 {
+		t.Fatalf("scan without nonces found %v", injectableTechniques(result))
+	}
+
+	var nonces []transport.NonceHeader
+	for _, spec := range []string{"X-Nonce:uuid", "X-Timestamp:epoch-ms"} {
+		h, err := transport.ParseNonceHeader(spec)
+		if err != nil {
+			t.Fatalf("ParseNonceHeader(%q): %v", spec, err)
+		}
+		nonces = append(nonces, h)
+	}
+	result := scan(nonces)
+	found := injectableTechniques(result)
+	for _, want := range []string{"error-based", "boolean-blind", "union-based"} {
+		if !found[want] {
+			t.Errorf("%s not detected through the nonce gateway; found %v", want, found)
+		}
+	}
+	if len(result.Errors) > 0 {
+		t.Errorf("unexpected errors: %v", result.Errors)
+	}
+}
+
+func TestIntegration_CSRFToken(t *testing.T) {
+	srv := httptest.NewServer(RequireCSRFToken(VulnHandler(), "csrf"))
+	defer srv.Close()
+	targetURL := srv.URL + "/vuln/union-mysql-errors?id=1&csrf=0badc0de"
+
+	scan := func(csrf bool) *engine.ScanResult {
+		t.Helper()
+		base, err := transport.NewClient(transport.ClientOptions{Timeout: 10 * time.Second})
+		if err != nil {
+			t.Fatalf("NewClient: %v", err)
+		}
+		var client transport.Client = base
+		if csrf {
+			client = transport.NewCSRFClient(client, transport.CSRFOptions{Name: "csrf", URL: targetURL})
+		}
+		cfg := engine.DefaultScanConfig()
+		cfg.ParamExclude = []string{"csrf"}
+		scanner := engine.NewScanner(client, cfg,
+			engine.WithTechniques(wrapTechniques(errorbased.New(), boolean.New(), union.New())...),
+			engine.WithParameterParser(makeParamParser()),
+			engine.WithHeuristicDetector(makeHeuristicFunc(client)),
+			engine.WithDBMSIdentifier(makeDBMSIdentifier()),
+			engine.WithFingerprinter(makeFingerprinter()),
+		)
+		result, err := scanner.Scan(context.Background(), &engine.ScanTarget{URL: targetURL, Method: "GET"})
+		if err != nil {
+			t.Fatalf("Scan returned error: %v", err)
+		}
+		return result
+	}
+
+	// The token in the URL is stale, so every probe sent with it is
+	// refused and nothing is found.
+	if result := scan(false); len(injectableTechniques(result)) != 0 {
+		t.Fatalf("scan without CSRF handling found %v", injectableTechniques(result))
+	}
+
+	result := scan(true)
+	found := injectableTechniques(result)
+	for _, want := range []string{"error-based", "boolean-blind", "union-based"} {
+		if !found[want] {
+			t.Errorf("%s not detected with fresh CSRF tokens; found %v", want, found)
+		}
+	}
+	if len(result.Errors) > 0 {
+		t.Errorf("unexpected errors: %v", result.Errors)
+	}
+}
+
+func TestIntegration_SessionExpiry(t *testing.T) {
+	srv := httptest.NewServer(RequireLogin(VulnHandler(), 10))
+	defer srv.Close()
+	targetURL := srv.URL + "/vuln/union-mysql-errors?id=1"
+
+	scan := func(keep bool) *engine.ScanResult {
+		t.Helper()
+		base, err := transport.NewClient(transport.ClientOptions{Timeout: 10 * time.Second, FollowRedirects: true})
+		if err != nil {
+			t.Fatalf("NewClient: %v", err)
+		}
+		var client transport.Client = base
+		if keep {
+			client = transport.NewSessionKeeper(client, transport.LoginOptions{
+				URL:       srv.URL + LoginPath,
+				Body:      LoginData,
+				LoggedOut: regexp.MustCompile(`Please sign in`),
+			})
+		}
+		scanner := engine.NewScanner(client, engine.DefaultScanConfig(),
+			engine.WithTechniques(wrapTechniques(errorbased.New(), boolean.New(), union.New())...),
+			engine.WithParameterParser(makeParamParser()),
+			engine.WithHeuristicDetector(makeHeuristicFunc(client)),
+			engine.WithDBMSIdentifier(makeDBMSIdentifier()),
+			engine.WithFingerprinter(makeFingerprinter()),
+		)
+		result, err := scanner.Scan(context.Background(), &engine.ScanTarget{URL: targetURL, Method: "GET"})
+		if err != nil {
+			t.Fatalf("Scan returned error: %v", err)
+		}
+		return result
+	}
+
+	// Logged out, every probe gets the same login page.
+	if result := scan(false); len(injectableTechniques(result)) != 0 {
+		t.Fatalf("scan without a session found %v", injectableTechniques(result))
+	}
+
+	// Sessions last 10 requests, so the scan has to log in again and
+	// again.
+	result := scan(true)
+	found := injectableTechniques(result)
+	for _, want := range []string{"error-based", "boolean-blind", "union-based"} {
+		if !found[want] {
+			t.Errorf("%s not detected across session expiries; found %v", want, found)
+		}
+	}
+	if len(result.Errors) > 0 {
+		t.Errorf("unexpected errors: %v", result.Errors)
+	}
+}
+
+// injectableTechniques returns the techniques with an injectable finding.
+func TestIntegration_MariaDBFingerprint(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	tests := []struct {
+		path      string
+		dbms      string
+		version   string
+		technique string
+	}{
+		// Error pages name MariaDB; the error-based probes then run the
+		// MariaDB templates.
+		{"/vuln/error-mariadb", "MariaDB", "", "error-based"},
+		// MySQL errors alone are tentative; the fingerprinter's banner
+		// probe confirms MySQL and reads the version.
+		{"/vuln/error-mysql", "MySQL", mockVersionMySQL, "error-based"},
+		// Generic error pages: the active JSON_DETAILED probe decides.
+		{"/vuln/masked-mariadb", "MariaDB", "", "boolean-blind"},
+		{"/vuln/masked-mysql", "MySQL", "", "boolean-blind"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			scanner := newFullScanner(newTestClient(), engine.DefaultScanConfig())
+			result, err := scanner.Scan(context.Background(), &engine.ScanTarget{
+				URL:    srv.URL + tt.path + "?id=1",
+				Method: "GET",
+			})
+			if err != nil {
+				t.Fatalf("Scan returned error: %v", err)
+			}
+			if result.DBMS != tt.dbms || result.DBMSVersion != tt.version {
+				t.Errorf("DBMS = %s %q, want %s %q", result.DBMS, result.DBMSVersion, tt.dbms, tt.version)
+			}
+			if !injectableTechniques(result)[tt.technique] {
+				t.Errorf("expected %s technique to detect vulnerability", tt.technique)
+			}
+		})
+	}
+}
+
+func TestIntegration_ErrorBased_MariaDBTemplates(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+	tests := []struct {
+		path, dbms     string
+		want, notTried string
+	}{
+		{"/vuln/error-mariadb", "MariaDB", "err.mariadb.", "err.mysql."},
+		{"/vuln/error-mysql", "MySQL", "err.mysql.", "err.mariadb."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dbms, func(t *testing.T) {
+			cov := payloadlib.NewCoverage()
+			req := technique.InjectionRequest{
+				Target:    &engine.ScanTarget{URL: srv.URL + tt.path + "?id=1", Method: "GET"},
+				Parameter: &engine.Parameter{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+				DBMS:      tt.dbms,
+				Client:    client,
+				Coverage:  cov,
+			}
+			det, err := errorbased.New().Detect(context.Background(), &req)
+			if err != nil {
+				t.Fatalf("Detect: %v", err)
+			}
+			if !det.Injectable {
+				t.Fatal("expected error-based detection")
+			}
+
+			var succeeded bool
+			for id, st := range cov.Snapshot() {
+				if strings.HasPrefix(id, tt.notTried) {
+					t.Errorf("%s template %s was tried", tt.dbms, id)
+				}
+				if strings.HasPrefix(id, tt.want) && st.Succeeded > 0 {
+					succeeded = true
+				}
+			}
+			if !succeeded {
+				t.Errorf("no %s* template succeeded: %v", tt.want, cov.TriedIDs())
+			}
+
+			res, err := errorbased.New().Extract(context.Background(), &technique.ExtractionRequest{
+				InjectionRequest: req,
+				Query:            "@@version",
+			})
+			if err != nil {
+				t.Fatalf("Extract: %v", err)
+			}
+			if _, version := dbms.ParseMySQLBanner(res.Value); !strings.HasPrefix(res.Value, version) || version == "" {
+				t.Errorf("extracted %q, want a %s banner", res.Value, tt.dbms)
+			}
+			if name, _ := dbms.ParseMySQLBanner(res.Value); name != tt.dbms {
+				t.Errorf("extracted banner %q names %q, want %q", res.Value, name, tt.dbms)
+			}
+		})
+	}
+}
+
+// TestIntegration_BooleanExtractBits reads /vuln/boolean in both extraction
+// modes; bit mode spends a fixed number of requests.
+func TestIntegration_BooleanExtractBits(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+	target := &engine.ScanTarget{URL: srv.URL + "/vuln/boolean?id=1", Method: "GET"}
+	baseline, err := client.Do(context.Background(), &transport.Request{Method: "GET", URL: target.URL})
+	if err != nil {
+		t.Fatalf("baseline: %v", err)
+	}
+	req := technique.InjectionRequest{
+		Target:    target,
+		Parameter: &engine.Parameter{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+		Baseline:  baseline,
+		DBMS:      "MySQL",
+		Client:    client,
+	}
+
+	for _, mode := range []boolean.ExtractMode{boolean.ExtractBinarySearch, boolean.ExtractBits} {
+		res, err := boolean.NewWithConfig(mode).Extract(context.Background(), &technique.ExtractionRequest{
+			InjectionRequest: req,
+			Query:            "@@version",
+		})
+		if err != nil {
+			t.Fatalf("mode %d: Extract: %v", mode, err)
+		}
+		if res.Value != mockVersionMySQL {
+			t.Errorf("mode %d: extracted %q, want %q", mode, res.Value, mockVersionMySQL)
+		}
+		if want := 11 + 7*len(mockVersionMySQL); mode == boolean.ExtractBits && res.Requests != want {
+			t.Errorf("bit mode spent %d requests, want %d", res.Requests, want)
+		}
+		t.Logf("mode %d: %d requests", mode, res.Requests)
+	}
+}
+
+// TestIntegration_MaxRequests gives /vuln/boolean a request budget that
+// lasts through the scan but runs out while boolean-blind reads @@version:
+// the extraction returns the characters read so far, marked partial, and
+// the next scan stops at once with a truncated result and no error.
+func TestIntegration_MaxRequests(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	ctx := context.Background()
+	target := func() *engine.ScanTarget {
+		return &engine.ScanTarget{URL: srv.URL + "/vuln/boolean?id=1", Method: "GET"}
+	}
+	cfg := engine.DefaultScanConfig()
+	cfg.Techniques = []string{"B"}
+
+	// The unlimited scan's requests, heuristic probes included, leave room
+	// for a few characters of the extraction.
+	client := &probeRecorder{testTransportClient: newTestClient()}
+	if _, err := newFullScanner(client, cfg).Scan(ctx, target()); err != nil {
+		t.Fatalf("unlimited Scan returned error: %v", err)
+	}
+	cfg.MaxRequests = int64(len(client.urls)) + 40
+	scanner := newFullScanner(newTestClient(), cfg)
+
+	scanned := target()
+	result, err := scanner.Scan(ctx, scanned)
+	if err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+	if result.Truncated || scanner.BudgetSpent() {
+		t.Fatalf("scan within a budget of %d was truncated", cfg.MaxRequests)
+	}
+	var finding *engine.Vulnerability
+	for i, vuln := range result.Vulnerabilities {
+		if vuln.Injectable && vuln.Technique == "boolean-blind" {
+			finding = &result.Vulnerabilities[i]
+		}
+	}
+	if finding == nil {
+		t.Fatalf("expected a boolean-blind finding, got %+v", result.Vulnerabilities)
+	}
+
+	res, err := scanner.Extract(ctx, scanned, *finding, "@@version")
+	if !errors.Is(err, engine.ErrRequestBudget) {
+		t.Fatalf("Extract error = %v, want ErrRequestBudget", err)
+	}
+	if res == nil || !res.Partial || res.Value == "" || !strings.HasPrefix(mockVersionMySQL, res.Value) || res.Value == mockVersionMySQL {
+		t.Fatalf("Extract = %+v, want a partial prefix of %q", res, mockVersionMySQL)
+	}
+	if !scanner.BudgetSpent() {
+		t.Error("BudgetSpent = false after the extraction ran out")
+	}
+
+	result, err = scanner.Scan(ctx, target())
+	if err != nil {
+		t.Fatalf("Scan past the budget returned error: %v", err)
+	}
+	if !result.Truncated || result.RequestBudget != cfg.MaxRequests {
+		t.Errorf("Truncated, RequestBudget = %v, %d, want true, %d", result.Truncated, result.RequestBudget, cfg.MaxRequests)
+	}
+	if len(result.Errors) == 0 || !errors.Is(result.Errors[0], engine.ErrRequestBudget) {
+		t.Errorf("Errors = %v, want the refused baseline request", result.Errors)
+	}
+}
+
+// TestIntegration_MaxRequests_MidScan stops a scan of /vuln/boolean a few
+// requests into boolean-blind: the result is truncated, and the scan
+// sends nothing more through the scanner once the budget is spent.
+func TestIntegration_MaxRequests_MidScan(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	cfg := engine.DefaultScanConfig()
+	cfg.Techniques = []string{"B"}
+	cfg.MaxRequests = 5
+	client := &probeRecorder{testTransportClient: newTestClient()}
+	scanner := engine.NewScanner(client, cfg,
+		engine.WithTechniques(wrapTechniques(boolean.New())...),
+		engine.WithParameterParser(makeParamParser()),
+	)
+	result, err := scanner.Scan(context.Background(), &engine.ScanTarget{URL: srv.URL + "/vuln/boolean?id=1", Method: "GET"})
+	if err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+	if !result.Truncated || result.RequestBudget != 5 {
+		t.Errorf("Truncated, RequestBudget = %v, %d, want true, 5", result.Truncated, result.RequestBudget)
+	}
+	if n := len(client.urls); n != 5 {
+		t.Errorf("scanner sent %d requests, want the budget of 5", n)
+	}
+	for _, v := range result.Vulnerabilities {
+		if v.Injectable {
+			t.Errorf("unexpected finding decided past the budget: %+v", v)
+		}
+	}
+}
+
+// TestIntegration_StopAtFirst scans /vuln/error-mysql with and without
+// --stop-at-first: once error-based, which runs first, finds id
+// injectable, boolean-blind and time-based are skipped on it.
+func TestIntegration_StopAtFirst(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	scan := func(stopAtFirst bool) *engine.ScanResult {
+		t.Helper()
+		cfg := engine.DefaultScanConfig()
+		cfg.Threads = 1 // Techniques run one after another, in priority order
+		cfg.StopAtFirst = stopAtFirst
+		client := newTestClient()
+		result, err := newFullScanner(client, cfg).Scan(context.Background(), &engine.ScanTarget{
+			URL:    srv.URL + "/vuln/error-mysql?id=1",
+			Method: "GET",
+		})
+		if err != nil {
+			t.Fatalf("Scan returned error: %v", err)
+		}
+		if found := injectableTechniques(result); !found["error-based"] {
+			t.Errorf("stopAtFirst=%v: error-based found no injection; found %v", stopAtFirst, found)
+		}
+		return result
+	}
+
+	all := scan(false)
+	first := scan(true)
+	var ran []string
+	for _, timing := range first.TechniqueTimings {
+		ran = append(ran, timing.Technique)
+	}
+	if want := []string{"error-based"}; !slices.Equal(ran, want) {
+		t.Errorf("techniques run = %q, want %q", ran, want)
+	}
+	var skipped []string
+	for _, job := range first.SkippedJobs {
+		skipped = append(skipped, job.Technique)
+	}
+	if want := []string{"boolean-blind", "time-based"}; !slices.Equal(skipped, want) {
+		t.Errorf("techniques skipped = %q, want %q", skipped, want)
+	}
+	t.Logf("requests: %d stopping at the first finding, %d without", first.RequestCount, all.RequestCount)
+	if first.RequestCount >= all.RequestCount {
+		t.Errorf("stop at first sent %d requests, want fewer than the %d of a full scan", first.RequestCount, all.RequestCount)
+	}
+}
+
+// TestIntegration_BooleanStatusOracle detects and reads /vuln/boolean-status,
+// whose page is identical for TRUE and FALSE and only the status differs.
+func TestIntegration_BooleanStatusOracle(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+	target := &engine.ScanTarget{URL: srv.URL + "/vuln/boolean-status?id=1", Method: "GET"}
+	baseline, err := client.Do(context.Background(), &transport.Request{Method: "GET", URL: target.URL})
+	if err != nil {
+		t.Fatalf("baseline: %v", err)
+	}
+	req := technique.InjectionRequest{
+		Target:    target,
+		Parameter: &engine.Parameter{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+		Baseline:  baseline,
+		DBMS:      "MySQL",
+		Client:    client,
+	}
+
+	det, err := boolean.New().Detect(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("Detect: %v", err)
+	}
+	if !det.Injectable {
+		t.Fatal("expected the status code oracle to detect the injection")
+	}
+	if !strings.Contains(det.Evidence, "HTTP 404") {
+		t.Errorf("evidence %q does not name the FALSE status", det.Evidence)
+	}
+
+	res, err := boolean.New().Extract(context.Background(), &technique.ExtractionRequest{
+		InjectionRequest: req,
+		Query:            "@@version",
+	})
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if res.Value != mockVersionMySQL {
+		t.Errorf("extracted %q, want %q", res.Value, mockVersionMySQL)
+	}
+}
+
+// TestIntegration_BooleanJSONAPI detects and reads /vuln/api/products,
+// whose JSON bodies differ on every response by their request ID.
+func TestIntegration_BooleanJSONAPI(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+	target := &engine.ScanTarget{URL: srv.URL + "/vuln/api/products?id=1", Method: "GET"}
+	baseline, err := client.Do(context.Background(), &transport.Request{Method: "GET", URL: target.URL})
+	if err != nil {
+		t.Fatalf("baseline: %v", err)
+	}
+	req := technique.InjectionRequest{
+		Target:    target,
+		Parameter: &engine.Parameter{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+		Baseline:  baseline,
+		DBMS:      "MySQL",
+		Client:    client,
+	}
+
+	det, err := boolean.New().Detect(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("Detect: %v", err)
+	}
+	if !det.Injectable {
+		t.Fatal("expected the JSON comparison to detect the injection")
+	}
+
+	res, err := boolean.New().Extract(context.Background(), &technique.ExtractionRequest{
+		InjectionRequest: req,
+		Query:            "@@version",
+	})
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if res.Value != mockVersionMySQL {
+		t.Errorf("extracted %q, want %q", res.Value, mockVersionMySQL)
+	}
+}
+
+// TestIntegration_BooleanTimingOracle detects and reads /vuln/costly, whose
+// page never changes, through its natural TRUE/FALSE query cost.
+func TestIntegration_BooleanTimingOracle(t *testing.T) {
+	srv := NewVulnServer()
+	t.Cleanup(srv.Close)
+
+	target := &engine.ScanTarget{URL: srv.URL + "/vuln/costly?id=1", Method: "GET"}
+	baseline, err := newTestClient().Do(context.Background(), &transport.Request{Method: "GET", URL: target.URL})
+	if err != nil {
+		t.Fatalf("baseline: %v", err)
+	}
+	newRequest := func() technique.InjectionRequest {
+		return technique.InjectionRequest{
+			Target:    target,
+			Parameter: &engine.Parameter{Name: "id", Value: "1", Location: engine.LocationQuery, Type: engine.TypeInteger},
+			Baseline:  baseline,
+			DBMS:      "MySQL",
+			Client:    newTestClient(),
+		}
+	}
+
+	// Every TRUE probe costs 400ms: detect and extract side by side.
+	t.Run("detect", func(t *testing.T) {
+		t.Parallel()
+		req := newRequest()
+		det, err := boolean.NewWithRisk(boolean.TimingRisk).Detect(context.Background(), &req)
+		if err != nil {
+			t.Fatalf("Detect: %v", err)
+		}
+		if !det.Injectable || !strings.Contains(det.Evidence, "identical pages") {
+			t.Fatalf("expected a timing detection, got %+v", det)
+		}
+		t.Logf("evidence: %s", det.Evidence)
+	})
+	t.Run("extract", func(t *testing.T) {
+		t.Parallel()
+		res, err := boolean.NewWithRisk(boolean.TimingRisk).Extract(context.Background(), &technique.ExtractionRequest{
+			InjectionRequest: newRequest(),
+			Query:            "SUBSTRING(DATABASE(),1,2)",
+		})
+		if err != nil {
+			t.Fatalf("Extract: %v", err)
+		}
+		if res.Value != "sh" {
+			t.Errorf("extracted %q, want %q", res.Value, "sh")
+		}
+	})
+}
+
+func injectableTechniques(result *engine.ScanResult) map[string]bool {
+	found := make(map[string]bool)
+	for _, v := range result.Vulnerabilities {
+		if v.Injectable {
+			found[v.Technique] = true
+			for _, name := range v.ConfirmedBy {
+				found[name] = true
+			}
+		}
+	}
+	return found
+}
+
+// withoutContext wraps a HeuristicDetectorFunc, dropping its context hints
+// as the scanner did before it had them.
+func withoutContext(h engine.HeuristicDetectorFunc) engine.HeuristicDetectorFunc {
+	return func(ctx context.Context, target *engine.ScanTarget) ([]engine.HeuristicResult, error) {
+		results, err := h(ctx, target)
+		for i := range results {
+			results[i].Context = ""
+		}
+		return results, err
+	}
+}
+
+func TestIntegration_LimitContext(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		path      string
+		technique string // Expected detection; empty for none
+		evidence  string
+	}{
+		{"postgres limit", "/vuln/page-postgres?limit=3", "boolean-blind", "TRUE condition"},
+		{"mysql 5.7 offset", "/vuln/page-mysql?offset=0", "error-based", "5.7.44"},
+		{"safe limit", "/vuln/page-safe?limit=3", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scan := func(hints bool) *engine.ScanResult {
+				client := newTestClient()
+				heuristic := makeHeuristicFunc(client)
+				cfg := engine.DefaultScanConfig()
+				if !hints {
+					heuristic = withoutContext(heuristic)
+					cfg.ForceTest = true // Run the techniques regardless
+				}
+				scanner := engine.NewScanner(client, cfg,
+					engine.WithTechniques(wrapTechniques(errorbased.New(), boolean.New(), timebased.NewWithConfig(1, 0.3, 0, 0))...),
+					engine.WithParameterParser(makeParamParser()),
+					engine.WithHeuristicDetector(heuristic),
+					engine.WithDBMSIdentifier(makeDBMSIdentifier()),
+					engine.WithFingerprinter(makeFingerprinter()),
+				)
+				result, err := scanner.Scan(context.Background(), &engine.ScanTarget{URL: srv.URL + tt.path, Method: "GET"})
+				if err != nil {
+					t.Fatalf("Scan returned error: %v", err)
+				}
+				return result
+			}
+
+			for _, v := range scan(false).Vulnerabilities {
+				if v.Injectable {
+					t.Errorf("without context hints: %s detected (%s), want a miss", v.Technique, v.Payload)
+				}
+			}
+
+			var found *engine.Vulnerability
+			for _, v := range scan(true).Vulnerabilities {
+				if v.Injectable {
+					found = &v
+					break
+				}
+			}
+			switch {
+			case tt.technique == "" && found != nil:
+				t.Errorf("false positive: %s (%s)", found.Technique, found.Payload)
+			case tt.technique != "" && found == nil:
+				t.Errorf("expected %s detection with the LIMIT hint", tt.technique)
+			case found != nil && (found.Technique != tt.technique || !strings.Contains(found.Evidence, tt.evidence)):
+				t.Errorf("got %s %q (evidence %q), want %s with %q", found.Technique, found.Payload, found.Evidence, tt.technique, tt.evidence)
+			}
+		})
+	}
+}
+
+// forbiddenWriter answers every response with 403 Forbidden, whatever
+// status the handler chose.
+type forbiddenWriter struct {
+	http.ResponseWriter
+	wrote bool
+}
+
+func (w *forbiddenWriter) WriteHeader(int) {
+	if !w.wrote {
+		w.wrote = true
+		w.ResponseWriter.WriteHeader(http.StatusForbidden)
+	}
+}
+
+func (w *forbiddenWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusForbidden)
+	return w.ResponseWriter.Write(b)
+}
+
+func TestIntegration_ValidStatusCodes(t *testing.T) {
+	// The whole application, served behind a constant 403.
+	app := VulnHandler()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		app.ServeHTTP(&forbiddenWriter{ResponseWriter: w}, r)
+	}))
+	defer srv.Close()
+
+	scan := func(valid []int) *engine.ScanResult {
+		cfg := engine.DefaultScanConfig()
+		cfg.ValidStatusCodes = valid
+		result, err := newFullScanner(newTestClient(), cfg).Scan(context.Background(), &engine.ScanTarget{
+			URL:    srv.URL + "/vuln/error-mysql?id=1",
+			Method: "GET",
+		})
+		if err != nil {
+			t.Fatalf("Scan returned error: %v", err)
+		}
+		return result
+	}
+
+	// By default the 403 baseline is an error page and the target is skipped.
+	result := scan(nil)
+	if len(result.Vulnerabilities) != 0 {
+		t.Errorf("default run reported %d vulnerabilities, want the target skipped", len(result.Vulnerabilities))
+	}
+	if len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Error(), "status 403") {
+		t.Errorf("Errors = %v, want one naming the 403 baseline", result.Errors)
+	}
+
+	result = scan([]int{200, 403})
+	if len(result.Errors) != 0 {
+		t.Errorf("Errors = %v, want none with 403 allowlisted", result.Errors)
+	}
+	if !injectableTechniques(result)["error-based"] {
+		t.Errorf("expected error-based detection with 403 allowlisted, got %+v", result.Vulnerabilities)
+	}
+	if !strings.Contains(result.DBMS, "MySQL") {
+		t.Errorf("DBMS = %q, want to contain 'MySQL'", result.DBMS)
+	}
+}
+
+func TestIntegration_Base64Param(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	rawURL := srv.URL + "/vuln/base64?data=" + url.QueryEscape(base64.StdEncoding.EncodeToString([]byte("1")))
+	params := detector.ParseParameters(rawURL, "", "")
+	scan := func(params []engine.Parameter) (*engine.Scanner, *engine.ScanTarget, *engine.ScanResult) {
+		t.Helper()
+		scanner := newFullScanner(newTestClient(), engine.DefaultScanConfig())
+		target := &engine.ScanTarget{URL: rawURL, Method: "GET", Parameters: params}
+		result, err := scanner.Scan(context.Background(), target)
+		if err != nil {
+			t.Fatalf("Scan returned error: %v", err)
+		}
+		return scanner, target, result
+	}
+
+	// Payloads appended to the token break its encoding and never reach
+	// the query.
+	_, _, result := scan(params)
+	for _, vuln := range result.Vulnerabilities {
+		if vuln.Injectable {
+			t.Errorf("expected no finding without decoding, got %q by %s", vuln.Parameter.Name, vuln.Technique)
+		}
+	}
+
+	scanner, target, result := scan(detector.DecodeBase64(params, nil, true))
+	var finding *engine.Vulnerability
+	for i, vuln := range result.Vulnerabilities {
+		if vuln.Injectable && vuln.Technique == "error-based" {
+			finding = &result.Vulnerabilities[i]
+			break
+		}
+	}
+	if finding == nil {
+		t.Fatalf("expected an error-based finding on the decoded data, got %+v", result.Vulnerabilities)
+	}
+	if !finding.Parameter.Base64 || finding.Parameter.Value != "1" {
+		t.Errorf("finding parameter = %+v, want data decoded to 1", finding.Parameter)
+	}
+
+	res, err := scanner.Extract(context.Background(), target, *finding, "@@version")
+	if err != nil {
+		t.Fatalf("Extract returned error: %v", err)
+	}
+	if res.Value != mockVersionMySQL {
+		t.Errorf("Extract = %q, want %q", res.Value, mockVersionMySQL)
+	}
+}
+
+func TestIntegration_RawQueryPreserved(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	client := newTestClient()
+	scanner := newFullScanner(client, engine.DefaultScanConfig())
+
+	// The gateway rejects any request whose query string is not as the
+	// client wrote it, so probes must change nothing but the tested value.
+	rawURL := srv.URL + "/vuln/signed?b=2&a=1&ts=" + signedTimestamp + "&id=1"
+	target := &engine.ScanTarget{URL: rawURL, Method: "GET"}
+
+	ctx := context.Background()
+	result, err := scanner.Scan(ctx, target)
+	if err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+
+	var finding *engine.Vulnerability
+	for i, vuln := range result.Vulnerabilities {
+		if vuln.Injectable && vuln.Parameter.Name == "id" && vuln.Technique == "error-based" {
+			finding = &result.Vulnerabilities[i]
+			break
+		}
+	}
+	if finding == nil {
+		t.Fatalf("expected an error-based finding on id, got %+v", result.Vulnerabilities)
+	}
+
+	res, err := scanner.Extract(ctx, target, *finding, "@@version")
+	if err != nil {
+		t.Fatalf("Extract returned error: %v", err)
+	}
+	if res.Value != mockVersionMySQL {
+		t.Errorf("Extract = %q, want %q", res.Value, mockVersionMySQL)
+	}
+}
+
+func TestIntegration_PutBody(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	jsonMarkers := marker.Parse(srv.URL+"/vuln/put", `{"id":"1*"}`, "application/json", nil, nil)
+	tests := []struct {
+		name   string
+		target *engine.ScanTarget
+		param  string
+	}{
+		{
+			name: "form",
+			target: &engine.ScanTarget{
+				URL:         srv.URL + "/vuln/put",
+				Method:      "PUT",
+				Body:        "id=1",
+				ContentType: "application/x-www-form-urlencoded",
+			},
+			param: "id",
+		},
+		{
+			name: "json",
+			target: &engine.ScanTarget{
+				URL:         srv.URL + "/vuln/put",
+				Method:      "PUT",
+				Body:        `{"id":"1"}`,
+				ContentType: "application/json",
+				Markers:     jsonMarkers,
+				Parameters:  detector.ParseMarkers(jsonMarkers),
+			},
+			param: "#1*",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Every parameter of a PUT is state-changing; the read-only
+			// guard still checks the payloads.
+			cfg := engine.DefaultScanConfig()
+			cfg.AllowRiskyParams = true
+			scanner := newFullScanner(newTestClient(), cfg)
+
+			ctx := context.Background()
+			result, err := scanner.Scan(ctx, tt.target)
+			if err != nil {
+				t.Fatalf("Scan returned error: %v", err)
+			}
+			var finding *engine.Vulnerability
+			for i, vuln := range result.Vulnerabilities {
+				if vuln.Injectable && vuln.Parameter.Name == tt.param && vuln.Technique == "error-based" {
+					finding = &result.Vulnerabilities[i]
+					break
+				}
+			}
+			if finding == nil {
+				t.Fatalf("expected an error-based finding on %s, got %+v", tt.param, result.Vulnerabilities)
+			}
+
+			res, err := scanner.Extract(ctx, tt.target, *finding, "@@version")
+			if err != nil {
+				t.Fatalf("Extract returned error: %v", err)
+			}
+			if res.Value != mockVersionMySQL {
+				t.Errorf("Extract = %q, want %q", res.Value, mockVersionMySQL)
+			}
+		})
+	}
+}
+
+func TestIntegration_DeleteQuery(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	target := func() *engine.ScanTarget {
+		return &engine.ScanTarget{URL: srv.URL + "/vuln/delete?id=1", Method: "DELETE"}
+	}
+	ctx := context.Background()
+
+	// By default the parameters of a DELETE are left alone.
+	result, err := newFullScanner(newTestClient(), engine.DefaultScanConfig()).Scan(ctx, target())
+	if err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+	if len(result.Skipped) != 1 || len(result.Vulnerabilities) != 0 {
+		t.Fatalf("expected id skipped and no findings, got %+v, %+v", result.Skipped, result.Vulnerabilities)
+	}
+
+	cfg := engine.DefaultScanConfig()
+	cfg.AllowParams = []string{"id"}
+	result, err = newFullScanner(newTestClient(), cfg).Scan(ctx, target())
+	if err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+	found := false
+	for _, vuln := range result.Vulnerabilities {
+		if vuln.Injectable && vuln.Parameter.Name == "id" && vuln.Parameter.Location == engine.LocationQuery {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected id found injectable under DELETE, got %+v", result.Vulnerabilities)
+	}
+}
+
+func TestIntegration_ArrayElement(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	cfg := engine.DefaultScanConfig()
+	cfg.Level = 2 // For the ) boundary closing the IN list
+	scanner := newFullScanner(newTestClient(), cfg)
+	target := &engine.ScanTarget{URL: srv.URL + "/vuln/array?id[]=1&id[]=2", Method: "GET"}
+
+	ctx := context.Background()
+	result, err := scanner.Scan(ctx, target)
+	if err != nil {
+		t.Fatalf("Scan returned error: %v", err)
+	}
+	var finding *engine.Vulnerability
+	for i, vuln := range result.Vulnerabilities {
+		if !vuln.Injectable {
+			continue
+		}
+		if vuln.Parameter.Name != "id[1]" {
+			t.Errorf("unexpected finding on %s (%s)", vuln.Parameter.Name, vuln.Technique)
+			continue
+		}
+		if vuln.Technique == "error-based" {
+			finding = &result.Vulnerabilities[i]
+		}
+	}
+	if finding == nil {
+		t.Fatalf("expected an error-based finding on id[1], got %+v", result.Vulnerabilities)
+	}
+
+	res, err := scanner.Extract(ctx, target, *finding, "@@version")
+	if err != nil {
+		t.Fatalf("Extract returned error: %v", err)
+	}
+	if res.Value != mockVersionMySQL {
+		t.Errorf("Extract = %q, want %q", res.Value, mockVersionMySQL)
 	}
-	t.Logf("request count: %d", result.RequestCount)
 }
@@ -0,0 +1,62 @@
+package testutil
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+)
+
+// Login credentials and session cookie of RequireLogin.
+const (
+	LoginPath     = "/login"
+	LoginData     = "user=admin&pass=admin"
+	SessionCookie = "SESSIONID"
+)
+
+const loginPage = `<html><body><h1>Please sign in</h1><form method="post" action="/login"><input name="user"><input name="pass" type="password"></form></body></html>`
+
+// RequireLogin wraps next like an application with short-lived sessions.
+// A POST of LoginData to LoginPath sets a fresh SessionCookie and
+// redirects to /; a session serves n requests and then expires. A request
+// without a live session gets the login page, under a 200 as many
+// applications serve it.
+func RequireLogin(next http.Handler, n int) http.Handler {
+	var mu sync.Mutex
+	sessions := make(map[string]int) // Requests left, by session ID
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == LoginPath && r.Method == http.MethodPost {
+			if r.PostFormValue("user") != "admin" || r.PostFormValue("pass") != "admin" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(loginPage))
+				return
+			}
+			b := make([]byte, 16)
+			_, _ = rand.Read(b)
+			id := hex.EncodeToString(b)
+			mu.Lock()
+			sessions[id] = n
+			mu.Unlock()
+			http.SetCookie(w, &http.Cookie{Name: SessionCookie, Value: id, Path: "/"})
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+
+		live := false
+		if ck, err := r.Cookie(SessionCookie); err == nil {
+			mu.Lock()
+			if left := sessions[ck.Value]; left > 0 {
+				sessions[ck.Value] = left - 1
+				live = true
+			} else {
+				delete(sessions, ck.Value)
+			}
+			mu.Unlock()
+		}
+		if !live {
+			_, _ = w.Write([]byte(loginPage))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,33 @@
+package testutil
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RequireNonce wraps next like an anti-replay gateway in front of a mobile
+// API: every request must carry a nonceHeader value never seen before and a
+// timestampHeader in Unix milliseconds within window of the server clock.
+// Anything else gets the same bare 403 a WAF block would.
+func RequireNonce(next http.Handler, nonceHeader, timestampHeader string, window time.Duration) http.Handler {
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce := r.Header.Get(nonceHeader)
+		ms, err := strconv.ParseInt(r.Header.Get(timestampHeader), 10, 64)
+		age := time.Since(time.UnixMilli(ms))
+
+		mu.Lock()
+		replayed := seen[nonce]
+		seen[nonce] = true
+		mu.Unlock()
+
+		if nonce == "" || replayed || err != nil || age > window || age < -window {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,285 @@
+package sqlmock
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrorKind classifies query errors so handlers can choose an error page.
+type ErrorKind int
+
+const (
+	// SyntaxError is a statement outside the supported grammar.
+	SyntaxError ErrorKind = iota + 1
+	// UnclosedQuote is an unterminated string literal.
+	UnclosedQuote
+	// UnknownTable is a table missing from the fixture.
+	UnknownTable
+	// UnknownColumn is an unresolved column name or an ORDER BY position
+	// past the end of the select list.
+	UnknownColumn
+	// UnknownFunction is a function the dialect does not provide.
+	UnknownFunction
+	// UnknownVariable is an unknown @@ system variable.
+	UnknownVariable
+	// ConversionError is a string that cannot be converted to a number.
+	ConversionError
+	// XPathError is an invalid XPath passed to EXTRACTVALUE or UPDATEXML.
+	XPathError
+	// ColumnCountMismatch is a UNION of selects with different column
+	// counts, or a subquery with more than one column.
+	ColumnCountMismatch
+	// CardinalityError is a scalar subquery returning more than one row.
+	CardinalityError
+	// TypeMismatch is an argument of the wrong type, such as a condition
+	// as a PostgreSQL LIMIT.
+	TypeMismatch
+	// LookupError is a failed lookup by an Oracle package function, such
+	// as UTL_INADDR resolving a host name, that quotes what it looked up.
+	LookupError
+)
+
+// Error is a query error with a message worded like the dialect's own.
+type Error struct {
+	Kind ErrorKind
+	// Near is the detail the message quotes: the text at a syntax error,
+	// the unknown name, the value that failed conversion or the XPath.
+	Near string
+	msg  string
+}
+
+func (e *Error) Error() string { return e.msg }
+
+// mysqlSyntax is the prefix of every MySQL parse error; MariaDB names
+// itself in place of the first %s.
+const mysqlSyntax = "You have an error in your SQL syntax; check the manual that corresponds to your %s server version for the right syntax to use near '%s' at line 1"
+
+// mysqlSyntaxMsg formats mysqlSyntax for the dialect.
+func mysqlSyntaxMsg(d Dialect, rest string) string {
+	server := "MySQL"
+	if d == MariaDB {
+		server = "MariaDB"
+	}
+	return fmt.Sprintf(mysqlSyntax, server, rest)
+}
+
+// syntaxError reports a parse failure at the given token text; rest is the
+// query from that point on, which MySQL quotes instead.
+func syntaxError(d Dialect, token, rest string) *Error {
+	switch d {
+	case PostgreSQL:
+		if token == "" {
+			return &Error{Kind: SyntaxError, msg: "syntax error at end of input"}
+		}
+		return &Error{Kind: SyntaxError, Near: token, msg: fmt.Sprintf("syntax error at or near %q", token)}
+	case MSSQL:
+		return &Error{Kind: SyntaxError, Near: token, msg: fmt.Sprintf("Incorrect syntax near '%s'.", token)}
+	case Oracle:
+		if token == "" {
+			return &Error{Kind: SyntaxError, msg: "ORA-00921: unexpected end of SQL command"}
+		}
+		return &Error{Kind: SyntaxError, Near: token, msg: "ORA-00933: SQL command not properly ended"}
+	default:
+		return &Error{Kind: SyntaxError, Near: rest, msg: mysqlSyntaxMsg(d, rest)}
+	}
+}
+
+// unclosedQuote reports an unterminated literal; rest starts at its opening
+// quote.
+func unclosedQuote(d Dialect, rest string) *Error {
+	switch d {
+	case PostgreSQL:
+		return &Error{Kind: UnclosedQuote, Near: rest, msg: fmt.Sprintf("unterminated quoted string at or near %q", rest)}
+	case MSSQL:
+		return &Error{Kind: UnclosedQuote, Near: rest[1:], msg: fmt.Sprintf("Unclosed quotation mark after the character string '%s'.", rest[1:])}
+	case Oracle:
+		return &Error{Kind: UnclosedQuote, Near: rest, msg: "ORA-01756: quoted string not properly terminated"}
+	default:
+		return &Error{Kind: UnclosedQuote, Near: rest, msg: mysqlSyntaxMsg(d, rest)}
+	}
+}
+
+// unclosedDollarQuote reports a PostgreSQL dollar-quoted string without
+// its closing delimiter.
+func unclosedDollarQuote(rest string) *Error {
+	return &Error{Kind: UnclosedQuote, Near: rest, msg: fmt.Sprintf("unterminated dollar-quoted string at or near %q", rest)}
+}
+
+// unknownColumn reports an unresolved column in clause ("where clause",
+// "field list", ...).
+func unknownColumn(d Dialect, name, clause string) *Error {
+	switch d {
+	case PostgreSQL:
+		return &Error{Kind: UnknownColumn, Near: name, msg: fmt.Sprintf("column %q does not exist", name)}
+	case MSSQL:
+		return &Error{Kind: UnknownColumn, Near: name, msg: fmt.Sprintf("Invalid column name '%s'.", name)}
+	case Oracle:
+		return &Error{Kind: UnknownColumn, Near: name, msg: invalidIdentifier(name)}
+	default:
+		return &Error{Kind: UnknownColumn, Near: name, msg: fmt.Sprintf("Unknown column '%s' in '%s'", name, clause)}
+	}
+}
+
+// orderPosition reports ORDER BY n with n past the end of the select list.
+func orderPosition(d Dialect, n int64) *Error {
+	near := fmt.Sprint(n)
+	switch d {
+	case PostgreSQL:
+		return &Error{Kind: UnknownColumn, Near: near, msg: fmt.Sprintf("ORDER BY position %d is not in select list", n)}
+	case MSSQL:
+		return &Error{Kind: UnknownColumn, Near: near, msg: fmt.Sprintf("The ORDER BY position number %d is out of range of the number of items in the select list.", n)}
+	case Oracle:
+		return &Error{Kind: UnknownColumn, Near: near, msg: "ORA-01785: ORDER BY item must be the number of a SELECT-list expression"}
+	default:
+		return &Error{Kind: UnknownColumn, Near: near, msg: fmt.Sprintf("Unknown column '%d' in 'order clause'", n)}
+	}
+}
+
+func unknownTable(d Dialect, database, name string) *Error {
+	switch d {
+	case PostgreSQL:
+		return &Error{Kind: UnknownTable, Near: name, msg: fmt.Sprintf("relation %q does not exist", name)}
+	case MSSQL:
+		return &Error{Kind: UnknownTable, Near: name, msg: fmt.Sprintf("Invalid object name '%s'.", name)}
+	case Oracle:
+		return &Error{Kind: UnknownTable, Near: name, msg: "ORA-00942: table or view does not exist"}
+	default:
+		return &Error{Kind: UnknownTable, Near: name, msg: fmt.Sprintf("Table '%s.%s' doesn't exist", database, name)}
+	}
+}
+
+func unknownFunction(d Dialect, name string) *Error {
+	switch d {
+	case PostgreSQL:
+		return &Error{Kind: UnknownFunction, Near: name, msg: fmt.Sprintf("function %s does not exist", strings.ToLower(name))}
+	case MSSQL:
+		return &Error{Kind: UnknownFunction, Near: name, msg: fmt.Sprintf("'%s' is not a recognized built-in function name.", name)}
+	case Oracle:
+		return &Error{Kind: UnknownFunction, Near: name, msg: invalidIdentifier(name)}
+	default:
+		return &Error{Kind: UnknownFunction, Near: name, msg: fmt.Sprintf("FUNCTION %s does not exist", name)}
+	}
+}
+
+// paramCount reports a call with the wrong number of arguments.
+func paramCount(d Dialect, name string) *Error {
+	switch d {
+	case PostgreSQL:
+		return &Error{Kind: UnknownFunction, Near: name, msg: fmt.Sprintf("function %s does not exist", strings.ToLower(name))}
+	case MSSQL:
+		return &Error{Kind: UnknownFunction, Near: name, msg: fmt.Sprintf("The %s function requires a different number of arguments.", name)}
+	case Oracle:
+		return &Error{Kind: UnknownFunction, Near: name, msg: "ORA-00909: invalid number of arguments"}
+	default:
+		return &Error{Kind: UnknownFunction, Near: name, msg: fmt.Sprintf("Incorrect parameter count in the call to native function '%s'", name)}
+	}
+}
+
+func unknownVariable(d Dialect, name string) *Error {
+	switch d {
+	case PostgreSQL:
+		return &Error{Kind: UnknownVariable, Near: name, msg: fmt.Sprintf("unrecognized configuration parameter %q", name)}
+	case MSSQL:
+		return &Error{Kind: UnknownVariable, Near: name, msg: fmt.Sprintf("Must declare the scalar variable %q.", name)}
+	case Oracle:
+		return &Error{Kind: UnknownVariable, Near: name, msg: invalidIdentifier(name)}
+	}
+	return &Error{Kind: UnknownVariable, Near: name, msg: fmt.Sprintf("Unknown system variable '%s'", strings.TrimLeft(name, "@"))}
+}
+
+// conversionError reports value failing conversion to an integer.
+func conversionError(d Dialect, value string) *Error {
+	return conversionErrorTo(d, value, "integer")
+}
+
+// conversionErrorTo reports value failing conversion to the PostgreSQL
+// type pgType. PostgreSQL quotes the value as is; Oracle does not quote it.
+func conversionErrorTo(d Dialect, value, pgType string) *Error {
+	switch d {
+	case MSSQL:
+		return &Error{Kind: ConversionError, Near: value, msg: fmt.Sprintf("Conversion failed when converting the nvarchar value '%s' to data type int.", value)}
+	case Oracle:
+		return &Error{Kind: ConversionError, Near: value, msg: "ORA-01722: invalid number"}
+	}
+	return &Error{Kind: ConversionError, Near: value, msg: fmt.Sprintf("invalid input syntax for type %s: \"%s\"", pgType, value)}
+}
+
+// delayError reports a WAITFOR DELAY time that is no time of day.
+func delayError() *Error {
+	return &Error{Kind: ConversionError, msg: "Conversion failed when converting date and/or time from character string."}
+}
+
+// xpathError reports an invalid XPath. MySQL quotes at most 32 characters.
+func xpathError(xpath string) *Error {
+	if len(xpath) > 32 {
+		xpath = xpath[:32]
+	}
+	return &Error{Kind: XPathError, Near: xpath, msg: fmt.Sprintf("XPATH syntax error: '%s'", xpath)}
+}
+
+// invalidIdentifier is Oracle's message for an unknown column or function,
+// which it upper-cases like any unquoted identifier.
+func invalidIdentifier(name string) string {
+	return fmt.Sprintf("ORA-00904: %q: invalid identifier", strings.ToUpper(name))
+}
+
+// missingFrom reports an Oracle SELECT without a FROM clause.
+func missingFrom(token string) *Error {
+	return &Error{Kind: SyntaxError, Near: token, msg: "ORA-00923: FROM keyword not found where expected"}
+}
+
+// hostUnknown reports UTL_INADDR failing to resolve host.
+func hostUnknown(host string) *Error {
+	return &Error{Kind: LookupError, Near: host, msg: fmt.Sprintf("ORA-29257: host %s unknown", host)}
+}
+
+// thesaurusUnknown reports CTXSYS.DRITHSX.SN asked for a thesaurus that
+// does not exist.
+func thesaurusUnknown(name string) *Error {
+	return &Error{Kind: LookupError, Near: name, msg: fmt.Sprintf("ORA-20000: Oracle Text error:\nDRG-11701: thesaurus %s does not exist", name)}
+}
+
+// limitArgument reports a condition as the argument of a PostgreSQL LIMIT
+// or OFFSET clause.
+func limitArgument(clause string) *Error {
+	return &Error{Kind: TypeMismatch, Near: clause, msg: fmt.Sprintf("argument of %s must be type bigint, not type boolean", clause)}
+}
+
+func unionColumns(d Dialect) *Error {
+	switch d {
+	case PostgreSQL:
+		return &Error{Kind: ColumnCountMismatch, msg: "each UNION query must have the same number of columns"}
+	case MSSQL:
+		return &Error{Kind: ColumnCountMismatch, msg: "All queries combined using a UNION, INTERSECT or EXCEPT operator must have an equal number of expressions in their target lists."}
+	case Oracle:
+		return &Error{Kind: ColumnCountMismatch, msg: "ORA-01789: query block has incorrect number of result columns"}
+	default:
+		return &Error{Kind: ColumnCountMismatch, msg: "The used SELECT statements have a different number of columns"}
+	}
+}
+
+func subqueryColumns(d Dialect) *Error {
+	switch d {
+	case PostgreSQL:
+		return &Error{Kind: ColumnCountMismatch, msg: "subquery must return only one column"}
+	case MSSQL:
+		return &Error{Kind: ColumnCountMismatch, msg: "Only one expression can be specified in the select list when the subquery is not introduced with EXISTS."}
+	case Oracle:
+		return &Error{Kind: ColumnCountMismatch, msg: "ORA-00913: too many values"}
+	default:
+		return &Error{Kind: ColumnCountMismatch, msg: "Operand should contain 1 column(s)"}
+	}
+}
+
+func subqueryRows(d Dialect) *Error {
+	switch d {
+	case PostgreSQL:
+		return &Error{Kind: CardinalityError, msg: "more than one row returned by a subquery used as an expression"}
+	case MSSQL:
+		return &Error{Kind: CardinalityError, msg: "Subquery returned more than 1 value. This is not permitted when the subquery follows =, !=, <, <= , >, >= or when the subquery is used as an expression."}
+	case Oracle:
+		return &Error{Kind: CardinalityError, msg: "ORA-01427: single-row subquery returns more than one row"}
+	default:
+		return &Error{Kind: CardinalityError, msg: "Subquery returns more than 1 row"}
+	}
+}
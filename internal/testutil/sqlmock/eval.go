@@ -0,0 +1,1112 @@
+package sqlmock
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSleepSeconds bounds a single sleep call so huge arguments cannot
+// overflow the accumulated duration. DB.MaxSleep applies on top.
+const maxSleepSeconds = 3600
+
+// scope is one row of a FROM source, chained to the scopes of enclosing
+// queries for correlated subqueries.
+type scope struct {
+	table, alias string
+	cols         []string
+	row          []Value
+	parent       *scope
+}
+
+// rowCtx is what an expression is evaluated against: the current row and,
+// inside an aggregate query, the rows of the group.
+type rowCtx struct {
+	sc      *scope
+	group   []*scope
+	grouped bool // group is set, even if empty
+}
+
+type evaluator struct {
+	db     *DB
+	sleep  time.Duration
+	clause string // for unknown column messages
+	rownum int64  // Oracle ROWNUM of the row being filtered or projected
+}
+
+func (ev *evaluator) dialect() Dialect { return ev.db.Dialect }
+
+// runQuery evaluates q and returns its column names and rows.
+func (ev *evaluator) runQuery(q *query, outer *scope) ([]string, [][]Value, error) {
+	// A lone SELECT keeps its source rows so ORDER BY can use columns
+	// outside the select list, and applies TOP after sorting.
+	single := len(q.selects) == 1
+	cols, rows, srcs, err := ev.runSelect(q.selects[0], outer, single)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, core := range q.selects[1:] {
+		c, r, _, err := ev.runSelect(core, outer, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(c) != len(cols) {
+			return nil, nil, unionColumns(ev.dialect())
+		}
+		rows = append(rows, r...)
+		if !q.unionAll[i] {
+			rows = distinct(rows)
+		}
+	}
+	if !single {
+		srcs = nil
+	}
+
+	if len(q.orderBy) > 0 {
+		if err := ev.order(q.orderBy, cols, rows, srcs, outer); err != nil {
+			return nil, nil, err
+		}
+	}
+	if single && q.selects[0].top != nil {
+		n, err := ev.constInt(q.selects[0].top)
+		if err != nil {
+			return nil, nil, err
+		}
+		rows = window(rows, 0, n)
+	}
+
+	if q.limit != nil {
+		limit, err := ev.constInt(q.limit)
+		if err != nil {
+			return nil, nil, err
+		}
+		var offset int64
+		if q.offset != nil {
+			if offset, err = ev.constInt(q.offset); err != nil {
+				return nil, nil, err
+			}
+		}
+		rows = window(rows, offset, limit)
+	}
+
+	// PROCEDURE ANALYSE only matters here for evaluating its arguments,
+	// whose errors surface; the analysis it returns instead of the rows is
+	// not modelled. MySQL 8.0 removed it.
+	if q.procedure != nil {
+		if ev.dialect() == MySQL && versionMajor(ev.db.Version) >= 8 {
+			return nil, nil, syntaxError(MySQL, "PROCEDURE", q.procedureAt)
+		}
+		for _, a := range q.procedure.args {
+			if _, err := ev.eval(a, rowCtx{}); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return cols, rows, nil
+}
+
+// versionMajor returns the leading number of a version string, or 0.
+func versionMajor(version string) int {
+	major, _, _ := strings.Cut(version, ".")
+	n, _ := strconv.Atoi(major)
+	return n
+}
+
+// window returns rows[offset:offset+limit], clipped.
+func window(rows [][]Value, offset, limit int64) [][]Value {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > int64(len(rows)) {
+		return nil
+	}
+	rows = rows[offset:]
+	if limit >= 0 && limit < int64(len(rows)) {
+		rows = rows[:limit]
+	}
+	return rows
+}
+
+// constInt evaluates a LIMIT, OFFSET or TOP argument.
+func (ev *evaluator) constInt(x expr) (int64, error) {
+	v, err := ev.eval(x, rowCtx{})
+	if err != nil {
+		return 0, err
+	}
+	n, err := ev.toInt(v)
+	if err != nil {
+		return 0, err
+	}
+	if n == nil {
+		return 0, nil
+	}
+	return n.(int64), nil
+}
+
+// order sorts rows by the ORDER BY items. An integer literal is a select
+// list position; anything else is evaluated against the output columns,
+// falling back to the row's source scope when srcs is set.
+func (ev *evaluator) order(items []orderItem, cols []string, rows [][]Value, srcs []*scope, outer *scope) error {
+	keys := make([][]Value, len(rows))
+	for i, row := range rows {
+		sc := &scope{cols: cols, row: row, parent: outer}
+		if srcs != nil {
+			sc.parent = srcs[i]
+		}
+		for _, item := range items {
+			if lit, ok := item.x.(*literal); ok {
+				if n, isInt := lit.v.(int64); isInt {
+					if n < 1 || n > int64(len(cols)) {
+						return orderPosition(ev.dialect(), n)
+					}
+					keys[i] = append(keys[i], row[n-1])
+					continue
+				}
+			}
+			ev.clause = "order clause"
+			v, err := ev.eval(item.x, rowCtx{sc: sc})
+			if err != nil {
+				return err
+			}
+			keys[i] = append(keys[i], v)
+		}
+	}
+	if len(rows) == 0 {
+		// Positions are still checked against an empty result.
+		for _, item := range items {
+			if lit, ok := item.x.(*literal); ok {
+				if n, isInt := lit.v.(int64); isInt && (n < 1 || n > int64(len(cols))) {
+					return orderPosition(ev.dialect(), n)
+				}
+			}
+		}
+		return nil
+	}
+
+	idx := make([]int, len(rows))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		for k, item := range items {
+			c := ev.sortCompare(keys[idx[a]][k], keys[idx[b]][k])
+			if c == 0 {
+				continue
+			}
+			if item.desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+	sorted := make([][]Value, len(rows))
+	var sortedSrcs []*scope
+	if srcs != nil {
+		sortedSrcs = make([]*scope, len(srcs))
+	}
+	for i, j := range idx {
+		sorted[i] = rows[j]
+		if srcs != nil {
+			sortedSrcs[i] = srcs[j]
+		}
+	}
+	copy(rows, sorted)
+	copy(srcs, sortedSrcs)
+	return nil
+}
+
+// sortCompare orders values for ORDER BY: NULL first, then numbers before
+// strings, never failing.
+func (ev *evaluator) sortCompare(a, b Value) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	as, aStr := a.(string)
+	bs, bStr := b.(string)
+	switch {
+	case aStr && bStr:
+		return ev.compareStrings(as, bs)
+	case aStr:
+		return 1
+	case bStr:
+		return -1
+	}
+	return cmpFloat(toFloat(a), toFloat(b))
+}
+
+// runSelect evaluates one SELECT of a query. Unless the rows were
+// aggregated or deduplicated, srcs holds the source scope of each row.
+// With deferTop set, TOP is left to the caller.
+func (ev *evaluator) runSelect(core *selectCore, outer *scope, deferTop bool) (cols []string, rows [][]Value, srcs []*scope, err error) {
+	source, err := ev.source(core.from, outer)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer func(rownum int64) { ev.rownum = rownum }(ev.rownum)
+
+	var matched []*scope
+	for _, sc := range source {
+		// ROWNUM in WHERE is the number the row gets if it matches.
+		ev.rownum = int64(len(matched) + 1)
+		if core.where != nil {
+			ev.clause = "where clause"
+			v, err := ev.eval(core.where, rowCtx{sc: sc})
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if t, _ := ev.truth(v); !t {
+				continue
+			}
+		}
+		matched = append(matched, sc)
+	}
+
+	var exprs []expr
+	for _, item := range core.items {
+		if item.star {
+			if core.from == nil {
+				return nil, nil, nil, syntaxError(ev.dialect(), "*", "*")
+			}
+			for i, c := range source0(source, core, ev).cols {
+				cols = append(cols, c)
+				exprs = append(exprs, &positionRef{i: i})
+			}
+			continue
+		}
+		cols = append(cols, columnName(item))
+		exprs = append(exprs, item.x)
+	}
+
+	ev.clause = "field list"
+	if hasAggregate(exprs) {
+		first := &scope{parent: outer}
+		if len(matched) > 0 {
+			first = matched[0]
+		} else if len(source) > 0 {
+			first = &scope{table: source[0].table, alias: source[0].alias, cols: source[0].cols, row: make([]Value, len(source[0].cols)), parent: outer}
+		}
+		row, err := ev.project(exprs, rowCtx{sc: first, group: matched, grouped: true})
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		rows = append(rows, row)
+	} else {
+		for i, sc := range matched {
+			ev.rownum = int64(i + 1)
+			row, err := ev.project(exprs, rowCtx{sc: sc})
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			rows = append(rows, row)
+			srcs = append(srcs, sc)
+		}
+	}
+
+	if core.distinct {
+		rows, srcs = distinct(rows), nil
+	}
+	if core.top != nil && !deferTop {
+		n, err := ev.constInt(core.top)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		rows, srcs = window(rows, 0, n), nil
+	}
+	return cols, rows, srcs, nil
+}
+
+// source0 returns a scope describing the FROM source's columns, even when
+// it has no rows.
+func source0(source []*scope, core *selectCore, ev *evaluator) *scope {
+	if len(source) > 0 {
+		return source[0]
+	}
+	if core.from != nil && core.from.table != "" {
+		if t, ok := ev.db.table(core.from.table); ok {
+			return &scope{cols: t.Columns}
+		}
+	}
+	return &scope{}
+}
+
+func (ev *evaluator) project(exprs []expr, ctx rowCtx) ([]Value, error) {
+	row := make([]Value, len(exprs))
+	for i, x := range exprs {
+		v, err := ev.eval(x, ctx)
+		if err != nil {
+			return nil, err
+		}
+		row[i] = v
+	}
+	return row, nil
+}
+
+// columnName is the output name of a select item.
+func columnName(item selectItem) string {
+	if item.alias != "" {
+		return item.alias
+	}
+	switch x := item.x.(type) {
+	case *columnRef:
+		return x.name
+	case *funcCall:
+		return strings.ToLower(x.name)
+	case *variable:
+		return x.name
+	}
+	return "?column?"
+}
+
+// source returns the rows of a FROM item, one scope each.
+func (ev *evaluator) source(from *fromItem, outer *scope) ([]*scope, error) {
+	if from == nil {
+		return []*scope{{parent: outer}}, nil
+	}
+	switch {
+	case from.fn != nil:
+		v, err := ev.eval(from.fn, rowCtx{sc: outer})
+		if err != nil {
+			return nil, err
+		}
+		name := strings.ToLower(from.fn.name)
+		return []*scope{{table: name, alias: from.alias, cols: []string{name}, row: []Value{v}, parent: outer}}, nil
+	case from.sub != nil:
+		cols, rows, err := ev.runQuery(from.sub, outer)
+		if err != nil {
+			return nil, err
+		}
+		scopes := make([]*scope, len(rows))
+		for i, row := range rows {
+			scopes[i] = &scope{alias: from.alias, cols: cols, row: row, parent: outer}
+		}
+		return scopes, nil
+	}
+	t, ok := ev.db.table(from.table)
+	if !ok {
+		return nil, unknownTable(ev.dialect(), ev.db.Database, from.table)
+	}
+	scopes := make([]*scope, len(t.Rows))
+	for i, row := range t.Rows {
+		scopes[i] = &scope{table: from.table, alias: from.alias, cols: t.Columns, row: row, parent: outer}
+	}
+	return scopes, nil
+}
+
+// distinct removes duplicate rows, keeping the first occurrence.
+func distinct(rows [][]Value) [][]Value {
+	seen := make(map[string]bool, len(rows))
+	out := rows[:0:0]
+	for _, row := range rows {
+		var b strings.Builder
+		for _, v := range row {
+			switch v.(type) {
+			case nil:
+				b.WriteString("n")
+			case string:
+				b.WriteString("s")
+			default:
+				b.WriteString("d")
+			}
+			b.WriteString(strconv.Quote(Format(v)))
+		}
+		if k := b.String(); !seen[k] {
+			seen[k] = true
+			out = append(out, row)
+		}
+	}
+	return out
+}
+
+// lookup resolves a column reference through the scope chain.
+func (ev *evaluator) lookup(ref *columnRef, sc *scope) (Value, error) {
+	for s := sc; s != nil; s = s.parent {
+		if ref.table != "" && !strings.EqualFold(ref.table, s.alias) && !strings.EqualFold(ref.table, s.table) {
+			continue
+		}
+		for i, c := range s.cols {
+			if strings.EqualFold(c, ref.name) {
+				return s.row[i], nil
+			}
+		}
+	}
+	name := ref.name
+	if ref.table != "" {
+		name = ref.table + "." + ref.name
+	}
+	return nil, unknownColumn(ev.dialect(), name, ev.clause)
+}
+
+// eval evaluates an expression.
+func (ev *evaluator) eval(x expr, ctx rowCtx) (Value, error) {
+	switch x := x.(type) {
+	case *literal:
+		return x.v, nil
+	case *variable:
+		return ev.variable(x.name)
+	case *columnRef:
+		return ev.lookup(x, ctx.sc)
+	case *positionRef:
+		if x.i >= len(ctx.sc.row) {
+			return nil, nil
+		}
+		return ctx.sc.row[x.i], nil
+	case *unaryExpr:
+		v, err := ev.eval(x.x, ctx)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		if x.op == "NOT" {
+			t, _ := ev.truth(v)
+			return boolValue(!t), nil
+		}
+		n, err := ev.toNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := n.(int64); ok {
+			return -i, nil
+		}
+		return -n.(float64), nil
+	case *binaryExpr:
+		return ev.binary(x, ctx)
+	case *likeExpr:
+		return ev.like(x, ctx)
+	case *inExpr:
+		return ev.in(x, ctx)
+	case *betweenExpr:
+		v, err := ev.eval(x.x, ctx)
+		if err != nil {
+			return nil, err
+		}
+		lo, err := ev.eval(x.lo, ctx)
+		if err != nil {
+			return nil, err
+		}
+		hi, err := ev.eval(x.hi, ctx)
+		if err != nil {
+			return nil, err
+		}
+		c1, ok1, err := ev.compare(v, lo)
+		if err != nil {
+			return nil, err
+		}
+		c2, ok2, err := ev.compare(v, hi)
+		if err != nil || !ok1 || !ok2 {
+			return nil, err
+		}
+		return boolValue((c1 >= 0 && c2 <= 0) != x.not), nil
+	case *isNullExpr:
+		v, err := ev.eval(x.x, ctx)
+		if err != nil {
+			return nil, err
+		}
+		return boolValue((v == nil) != x.not), nil
+	case *caseExpr:
+		return ev.caseValue(x, ctx)
+	case *castExpr:
+		v, err := ev.eval(x.x, ctx)
+		if err != nil {
+			return nil, err
+		}
+		return ev.cast(v, x.typ)
+	case *funcCall:
+		return ev.call(x, ctx)
+	case *subqueryExpr:
+		cols, rows, err := ev.runQuery(x.q, ctx.sc)
+		if err != nil {
+			return nil, err
+		}
+		if len(cols) != 1 {
+			return nil, subqueryColumns(ev.dialect())
+		}
+		switch len(rows) {
+		case 0:
+			return nil, nil
+		case 1:
+			return rows[0][0], nil
+		}
+		return nil, subqueryRows(ev.dialect())
+	case *existsExpr:
+		_, rows, err := ev.runQuery(x.q, ctx.sc)
+		if err != nil {
+			return nil, err
+		}
+		return boolValue(len(rows) > 0), nil
+	}
+	return nil, syntaxError(ev.dialect(), "", "")
+}
+
+// variable resolves @@system variables; MySQL user variables are NULL.
+func (ev *evaluator) variable(name string) (Value, error) {
+	d := ev.dialect()
+	if !strings.HasPrefix(name, "@@") {
+		if d == MSSQL {
+			return nil, unknownVariable(d, name)
+		}
+		return nil, nil
+	}
+	switch strings.ToLower(name[2:]) {
+	case "version":
+		return ev.db.Version, nil
+	case "hostname":
+		if d != MSSQL {
+			return ev.db.Hostname, nil
+		}
+	case "servername":
+		if d != MySQL {
+			return ev.db.Hostname, nil
+		}
+	}
+	return nil, unknownVariable(d, name)
+}
+
+func (ev *evaluator) binary(x *binaryExpr, ctx rowCtx) (Value, error) {
+	switch x.op {
+	case "AND", "OR":
+		l, err := ev.eval(x.l, ctx)
+		if err != nil {
+			return nil, err
+		}
+		lt, lnull := ev.truth(l)
+		// Short-circuit like the real engines do for constant-false AND
+		// and constant-true OR, so a guarded SLEEP is never evaluated.
+		if !lnull && lt == (x.op == "OR") {
+			return boolValue(lt), nil
+		}
+		r, err := ev.eval(x.r, ctx)
+		if err != nil {
+			return nil, err
+		}
+		rt, rnull := ev.truth(r)
+		if !rnull && rt == (x.op == "OR") {
+			return boolValue(rt), nil
+		}
+		if lnull || rnull {
+			return nil, nil
+		}
+		return boolValue(rt), nil
+	}
+
+	l, err := ev.eval(x.l, ctx)
+	if err != nil {
+		return nil, err
+	}
+	r, err := ev.eval(x.r, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if x.op == "<=>" {
+		if l == nil || r == nil {
+			return boolValue(l == nil && r == nil), nil
+		}
+		c, _, err := ev.compare(l, r)
+		return boolValue(c == 0), err
+	}
+	if comparisons[x.op] {
+		c, ok, err := ev.compare(l, r)
+		if err != nil || !ok {
+			return nil, err
+		}
+		switch x.op {
+		case "=":
+			return boolValue(c == 0), nil
+		case "<>", "!=":
+			return boolValue(c != 0), nil
+		case "<":
+			return boolValue(c < 0), nil
+		case ">":
+			return boolValue(c > 0), nil
+		case "<=":
+			return boolValue(c <= 0), nil
+		default:
+			return boolValue(c >= 0), nil
+		}
+	}
+
+	if x.op == "CONCAT" && ev.dialect() == Oracle {
+		// Oracle treats NULL as the empty string.
+		return Format(l) + Format(r), nil
+	}
+	if l == nil || r == nil {
+		return nil, nil
+	}
+	if x.op == "CONCAT" {
+		return Format(l) + Format(r), nil
+	}
+	if x.op == "+" && ev.dialect() == MSSQL {
+		ls, lStr := l.(string)
+		rs, rStr := r.(string)
+		if lStr && rStr {
+			return ls + rs, nil
+		}
+	}
+	return ev.arith(x.op, l, r)
+}
+
+// arith applies an arithmetic operator to non-NULL operands. Division by
+// zero yields NULL.
+func (ev *evaluator) arith(op string, l, r Value) (Value, error) {
+	ln, err := ev.toNumber(l)
+	if err != nil {
+		return nil, err
+	}
+	rn, err := ev.toNumber(r)
+	if err != nil {
+		return nil, err
+	}
+	li, lInt := ln.(int64)
+	ri, rInt := rn.(int64)
+	if lInt && rInt {
+		switch op {
+		case "+":
+			return li + ri, nil
+		case "-":
+			return li - ri, nil
+		case "*":
+			return li * ri, nil
+		case "&":
+			return li & ri, nil
+		case "%", "DIV":
+			if ri == 0 {
+				return nil, nil
+			}
+			if op == "%" {
+				return li % ri, nil
+			}
+			return li / ri, nil
+		case "/":
+			if ri == 0 {
+				return nil, nil
+			}
+			if ev.dialect().mysqlLike() {
+				return float64(li) / float64(ri), nil
+			}
+			return li / ri, nil
+		}
+	}
+	lf, rf := toFloat(ln), toFloat(rn)
+	switch op {
+	case "&":
+		return roundInt(lf) & roundInt(rf), nil
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	}
+	if rf == 0 {
+		return nil, nil
+	}
+	switch op {
+	case "%":
+		return math.Mod(lf, rf), nil
+	case "DIV":
+		return int64(lf / rf), nil
+	}
+	return lf / rf, nil
+}
+
+func (ev *evaluator) like(x *likeExpr, ctx rowCtx) (Value, error) {
+	v, err := ev.eval(x.x, ctx)
+	if err != nil {
+		return nil, err
+	}
+	p, err := ev.eval(x.pattern, ctx)
+	if err != nil || v == nil || p == nil {
+		return nil, err
+	}
+	s, pattern := Format(v), Format(p)
+	if x.fold {
+		s, pattern = strings.ToLower(s), strings.ToLower(pattern)
+	}
+	return boolValue(likeMatch([]rune(s), []rune(pattern)) != x.not), nil
+}
+
+// likeMatch matches s against a LIKE pattern: % is any run, _ any single
+// character and a backslash escapes the next pattern character.
+func likeMatch(s, p []rune) bool {
+	// Iterative matcher with single-star backtracking.
+	si, pi := 0, 0
+	starP, starS := -1, 0
+	for si < len(s) {
+		switch {
+		case pi < len(p) && p[pi] == '%':
+			starP, starS = pi, si
+			pi++
+		case pi < len(p) && p[pi] == '\\' && pi+1 < len(p) && p[pi+1] == s[si]:
+			pi += 2
+			si++
+		case pi < len(p) && p[pi] != '\\' && (p[pi] == '_' || p[pi] == s[si]):
+			pi++
+			si++
+		case starP != -1:
+			pi = starP + 1
+			starS++
+			si = starS
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '%' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+func (ev *evaluator) in(x *inExpr, ctx rowCtx) (Value, error) {
+	v, err := ev.eval(x.x, ctx)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []Value
+	if x.sub != nil {
+		cols, rows, err := ev.runQuery(x.sub, ctx.sc)
+		if err != nil {
+			return nil, err
+		}
+		if len(cols) != 1 {
+			return nil, subqueryColumns(ev.dialect())
+		}
+		for _, row := range rows {
+			candidates = append(candidates, row[0])
+		}
+	} else {
+		for _, item := range x.list {
+			c, err := ev.eval(item, ctx)
+			if err != nil {
+				return nil, err
+			}
+			candidates = append(candidates, c)
+		}
+	}
+	if v == nil {
+		return nil, nil
+	}
+	sawNull := false
+	for _, c := range candidates {
+		cmp, ok, err := ev.compare(v, c)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			sawNull = true
+			continue
+		}
+		if cmp == 0 {
+			return boolValue(!x.not), nil
+		}
+	}
+	if sawNull {
+		return nil, nil
+	}
+	return boolValue(x.not), nil
+}
+
+// caseValue evaluates CASE (and IF/IIF) lazily: only the chosen branch runs.
+func (ev *evaluator) caseValue(x *caseExpr, ctx rowCtx) (Value, error) {
+	var operand Value
+	if x.operand != nil {
+		var err error
+		if operand, err = ev.eval(x.operand, ctx); err != nil {
+			return nil, err
+		}
+	}
+	for _, w := range x.whens {
+		c, err := ev.eval(w.cond, ctx)
+		if err != nil {
+			return nil, err
+		}
+		var hit bool
+		if x.operand != nil {
+			cmp, ok, err := ev.compare(operand, c)
+			if err != nil {
+				return nil, err
+			}
+			hit = ok && cmp == 0
+		} else {
+			hit, _ = ev.truth(c)
+		}
+		if hit {
+			return ev.eval(w.then, ctx)
+		}
+	}
+	if x.els == nil {
+		return nil, nil
+	}
+	return ev.eval(x.els, ctx)
+}
+
+// addSleep records a sleep of the given seconds.
+func (ev *evaluator) addSleep(v Value) error {
+	if v == nil {
+		return nil
+	}
+	n, err := ev.toNumber(v)
+	if err != nil {
+		return err
+	}
+	secs := math.Max(0, math.Min(toFloat(n), maxSleepSeconds))
+	ev.sleep += time.Duration(secs * float64(time.Second))
+	return nil
+}
+
+// runWait runs a stacked WAITFOR DELAY: the delay is recorded as a sleep
+// when its guard holds.
+func (ev *evaluator) runWait(w waitStmt) error {
+	if w.cond != nil {
+		v, err := ev.eval(w.cond, rowCtx{})
+		if err != nil {
+			return err
+		}
+		if t, _ := ev.truth(v); !t {
+			return nil
+		}
+	}
+	secs, ok := parseDelay(w.delay)
+	if !ok {
+		return delayError()
+	}
+	return ev.addSleep(secs)
+}
+
+// parseDelay parses a WAITFOR DELAY time, hh:mm[:ss[.fff]], into seconds.
+func parseDelay(s string) (float64, bool) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, false
+	}
+	var secs float64
+	for i, part := range parts {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 || (i < len(parts)-1 && n != math.Trunc(n)) {
+			return 0, false
+		}
+		secs = secs*60 + n
+	}
+	if len(parts) == 2 {
+		secs *= 60
+	}
+	return secs, true
+}
+
+// --------------------------------------------------------------------------
+// Coercion
+// --------------------------------------------------------------------------
+
+// boolValue is the int64 1/0 a predicate yields.
+func boolValue(b bool) Value {
+	if b {
+		return int64(1)
+	}
+	return int64(0)
+}
+
+// truth interprets v as a condition. NULL is neither true nor false.
+func (ev *evaluator) truth(v Value) (t, null bool) {
+	switch v := v.(type) {
+	case nil:
+		return false, true
+	case int64:
+		return v != 0, false
+	case float64:
+		return v != 0, false
+	case string:
+		if strings.EqualFold(v, "true") || v == "t" {
+			return true, false
+		}
+		return toFloat(leadingNumber(v)) != 0, false
+	}
+	return false, true
+}
+
+// toNumber converts v to int64 or float64. MySQL reads the leading number
+// of a string ("8.0.32" is 8.0, "abc" is 0); the other dialects reject
+// strings that are not numbers.
+func (ev *evaluator) toNumber(v Value) (Value, error) {
+	switch v := v.(type) {
+	case int64, float64:
+		return v, nil
+	case string:
+		if ev.dialect().mysqlLike() {
+			return leadingNumber(v), nil
+		}
+		s := strings.TrimSpace(v)
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, nil
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			return f, nil
+		}
+		return nil, conversionError(ev.dialect(), v)
+	}
+	return int64(0), nil
+}
+
+// toInt converts v to an int64 (or nil for NULL), rounding fractions.
+// Outside MySQL a string must be an integer literal.
+func (ev *evaluator) toInt(v Value) (Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case int64:
+		return v, nil
+	case float64:
+		return roundInt(v), nil
+	case string:
+		if !ev.dialect().mysqlLike() {
+			i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return nil, conversionError(ev.dialect(), v)
+			}
+			return i, nil
+		}
+		return ev.toInt(leadingNumber(v))
+	}
+	return int64(0), nil
+}
+
+// roundInt rounds f to the nearest int64, saturating at the type's limits.
+func roundInt(f float64) int64 {
+	switch {
+	case math.IsNaN(f):
+		return 0
+	case f >= math.MaxInt64:
+		return math.MaxInt64
+	case f <= math.MinInt64:
+		return math.MinInt64
+	}
+	return int64(math.Round(f))
+}
+
+// leadingNumber parses the numeric prefix of s the way MySQL does.
+func leadingNumber(s string) Value {
+	s = strings.TrimLeft(s, " \t\n\r")
+	i := 0
+	if i < len(s) && (s[i] == '-' || s[i] == '+') {
+		i++
+	}
+	start := i
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	intEnd := i
+	if i < len(s) && s[i] == '.' {
+		j := i + 1
+		for j < len(s) && isDigit(s[j]) {
+			j++
+		}
+		if j > i+1 || intEnd > start {
+			i = j
+		}
+	}
+	if i == start {
+		return int64(0)
+	}
+	if i == intEnd {
+		if n, err := strconv.ParseInt(s[:i], 10, 64); err == nil {
+			return n
+		}
+	}
+	f, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return int64(0)
+	}
+	return f
+}
+
+func toFloat(v Value) float64 {
+	switch v := v.(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	case string:
+		return toFloat(leadingNumber(v))
+	}
+	return 0
+}
+
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareStrings compares with the dialect's default collation: case
+// insensitive except on PostgreSQL and Oracle.
+func (ev *evaluator) compareStrings(a, b string) int {
+	if ev.dialect() != PostgreSQL && ev.dialect() != Oracle {
+		a, b = strings.ToLower(a), strings.ToLower(b)
+	}
+	return strings.Compare(a, b)
+}
+
+// compare orders a and b. ok is false when either is NULL.
+func (ev *evaluator) compare(a, b Value) (c int, ok bool, err error) {
+	if a == nil || b == nil {
+		return 0, false, nil
+	}
+	as, aStr := a.(string)
+	bs, bStr := b.(string)
+	if aStr && bStr {
+		return ev.compareStrings(as, bs), true, nil
+	}
+	an, err := ev.toNumber(a)
+	if err != nil {
+		return 0, false, err
+	}
+	bn, err := ev.toNumber(b)
+	if err != nil {
+		return 0, false, err
+	}
+	ai, aInt := an.(int64)
+	bi, bInt := bn.(int64)
+	if aInt && bInt {
+		switch {
+		case ai < bi:
+			return -1, true, nil
+		case ai > bi:
+			return 1, true, nil
+		}
+		return 0, true, nil
+	}
+	return cmpFloat(toFloat(an), toFloat(bn)), true, nil
+}
+
+// cast converts v to the named value type.
+func (ev *evaluator) cast(v Value, typ string) (Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	switch typ {
+	case "text":
+		return Format(v), nil
+	case "int":
+		return ev.toInt(v)
+	case "numeric":
+		n, err := ev.toNumber(v)
+		if err != nil {
+			return nil, conversionErrorTo(ev.dialect(), Format(v), "numeric")
+		}
+		return n, nil
+	}
+	n, err := ev.toNumber(v)
+	if err != nil {
+		return nil, err
+	}
+	return toFloat(n), nil
+}
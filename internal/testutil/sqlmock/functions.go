@@ -0,0 +1,711 @@
+package sqlmock
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// function is a scalar function. Arguments are evaluated before the call.
+type function struct {
+	// dialects lists the dialects providing the function; empty means all.
+	// Generic provides every function.
+	dialects []Dialect
+	// minArgs and maxArgs bound the argument count; maxArgs < 0 means
+	// variadic.
+	minArgs, maxArgs int
+	// nullable functions see NULL arguments; the others return NULL when
+	// any argument is NULL.
+	nullable bool
+	call     func(ev *evaluator, args []Value) (Value, error)
+}
+
+var (
+	onlyMySQL    = []Dialect{MySQL}
+	onlyPostgres = []Dialect{PostgreSQL}
+	onlyMSSQL    = []Dialect{MSSQL}
+	onlyMariaDB  = []Dialect{MariaDB}
+	onlyOracle   = []Dialect{Oracle}
+	mysqlPG      = []Dialect{MySQL, PostgreSQL}
+	mysqlMSSQL   = []Dialect{MySQL, MSSQL}
+	mysqlPGOra   = []Dialect{MySQL, PostgreSQL, Oracle}
+	pgOracle     = []Dialect{PostgreSQL, Oracle}
+)
+
+// functions are the scalar functions probes use, by upper-cased name.
+var functions = map[string]function{
+	// Strings.
+	"ASCII":            {minArgs: 1, maxArgs: 1, call: fnASCII},
+	"ORD":              {dialects: onlyMySQL, minArgs: 1, maxArgs: 1, call: fnASCII},
+	"SUBSTRING":        {minArgs: 2, maxArgs: 3, call: fnSubstring},
+	"SUBSTR":           {dialects: mysqlPGOra, minArgs: 2, maxArgs: 3, call: fnSubstring},
+	"MID":              {dialects: onlyMySQL, minArgs: 2, maxArgs: 3, call: fnSubstring},
+	"LENGTH":           {dialects: mysqlPGOra, minArgs: 1, maxArgs: 1, call: fnLength},
+	"CHAR_LENGTH":      {dialects: mysqlPG, minArgs: 1, maxArgs: 1, call: fnCharLength},
+	"CHARACTER_LENGTH": {dialects: mysqlPG, minArgs: 1, maxArgs: 1, call: fnCharLength},
+	"LEN":              {dialects: onlyMSSQL, minArgs: 1, maxArgs: 1, call: fnLen},
+	"CHAR":             {dialects: mysqlMSSQL, minArgs: 1, maxArgs: -1, call: fnChar},
+	"CHR":              {dialects: pgOracle, minArgs: 1, maxArgs: 1, call: fnChar},
+	"CONCAT":           {minArgs: 1, maxArgs: -1, nullable: true, call: fnConcat},
+	"LOWER":            {minArgs: 1, maxArgs: 1, call: fnLower},
+	"UPPER":            {minArgs: 1, maxArgs: 1, call: fnUpper},
+	"REPLACE":          {minArgs: 3, maxArgs: 3, call: fnReplace},
+	"CONV":             {dialects: onlyMySQL, minArgs: 3, maxArgs: 3, call: fnConv},
+
+	// JSON. JSON_DETAILED pretty-prints in MariaDB; returning the document
+	// unchanged is enough for probes that only need it to exist.
+	"JSON_DETAILED": {dialects: onlyMariaDB, minArgs: 1, maxArgs: 2, call: fnFirst},
+
+	// NULL handling.
+	"COALESCE": {minArgs: 1, maxArgs: -1, nullable: true, call: fnCoalesce},
+	"IFNULL":   {dialects: onlyMySQL, minArgs: 2, maxArgs: 2, nullable: true, call: fnCoalesce},
+	"ISNULL":   {dialects: onlyMSSQL, minArgs: 2, maxArgs: 2, nullable: true, call: fnCoalesce},
+	"NVL":      {dialects: onlyOracle, minArgs: 2, maxArgs: 2, nullable: true, call: fnCoalesce},
+	"NULLIF":   {minArgs: 2, maxArgs: 2, nullable: true, call: fnNullIf},
+
+	// Delays. They record the delay instead of blocking.
+	"SLEEP":    {dialects: onlyMySQL, minArgs: 1, maxArgs: 1, call: fnSleep},
+	"PG_SLEEP": {dialects: onlyPostgres, minArgs: 1, maxArgs: 1, call: fnPgSleep},
+
+	"DBMS_PIPE.RECEIVE_MESSAGE": {dialects: onlyOracle, minArgs: 2, maxArgs: 2, call: fnReceiveMessage},
+
+	// Heavy queries. BENCHMARK records the time its iterations would take;
+	// MD5 is only there to give it an expression to repeat.
+	"BENCHMARK": {dialects: onlyMySQL, minArgs: 2, maxArgs: 2, call: fnBenchmark},
+	"MD5":       {dialects: mysqlPG, minArgs: 1, maxArgs: 1, call: fnMD5},
+	"HEX":       {dialects: onlyMySQL, minArgs: 1, maxArgs: 1, call: fnHex},
+	"ENCODE":    {dialects: onlyPostgres, minArgs: 2, maxArgs: 2, call: fnEncode},
+
+	// Files, read from DB.Files. Values are byte strings, so the text and
+	// bytea reads of PostgreSQL return the same.
+	"LOAD_FILE":           {dialects: onlyMySQL, minArgs: 1, maxArgs: 1, call: fnReadFile},
+	"PG_READ_FILE":        {dialects: onlyPostgres, minArgs: 1, maxArgs: 1, call: fnReadFile},
+	"PG_READ_BINARY_FILE": {dialects: onlyPostgres, minArgs: 1, maxArgs: 1, call: fnReadFile},
+
+	// Identity.
+	"VERSION":          {dialects: mysqlPG, maxArgs: 0, call: identity(func(db *DB) string { return db.Version })},
+	"DATABASE":         {dialects: onlyMySQL, maxArgs: 0, call: identity(func(db *DB) string { return db.Database })},
+	"SCHEMA":           {dialects: onlyMySQL, maxArgs: 0, call: identity(func(db *DB) string { return db.Database })},
+	"CURRENT_DATABASE": {dialects: onlyPostgres, maxArgs: 0, call: identity(func(db *DB) string { return db.Database })},
+	"DB_NAME":          {dialects: onlyMSSQL, maxArgs: 0, call: identity(func(db *DB) string { return db.Database })},
+	"USER":             {maxArgs: 0, call: identity(func(db *DB) string { return db.User })},
+	"CURRENT_USER":     {maxArgs: 0, call: identity(func(db *DB) string { return db.User })},
+	"SESSION_USER":     {dialects: mysqlPG, maxArgs: 0, call: identity(func(db *DB) string { return db.User })},
+	"SYSTEM_USER":      {dialects: mysqlMSSQL, maxArgs: 0, call: identity(func(db *DB) string { return db.User })},
+	"USER_NAME":        {dialects: onlyMSSQL, maxArgs: 0, call: identity(func(db *DB) string { return db.User })},
+	"SUSER_NAME":       {dialects: onlyMSSQL, maxArgs: 0, call: identity(func(db *DB) string { return db.User })},
+	"CURRENT_SETTING":  {dialects: onlyPostgres, minArgs: 1, maxArgs: 1, call: fnCurrentSetting},
+
+	// Oracle pseudocolumns, which take no parentheses (see oracleNiladic).
+	"ORA_DATABASE_NAME": {dialects: onlyOracle, maxArgs: 0, call: identity(func(db *DB) string { return db.Database })},
+	"ROWNUM":            {dialects: onlyOracle, maxArgs: 0, call: fnRownum},
+
+	// Oracle packages: lookups that fail quoting their argument, which is
+	// what error-based probes abuse.
+	"UTL_INADDR.GET_HOST_NAME": {dialects: onlyOracle, maxArgs: 1, call: fnGetHostName},
+	"CTXSYS.DRITHSX.SN":        {dialects: onlyOracle, minArgs: 2, maxArgs: 2, call: fnDrithsxSN},
+
+	// XML: the XPath argument is validated, which is what error-based
+	// probes abuse.
+	"EXTRACTVALUE": {dialects: onlyMySQL, minArgs: 2, maxArgs: 2, call: fnExtractValue},
+	"UPDATEXML":    {dialects: onlyMySQL, minArgs: 3, maxArgs: 3, call: fnUpdateXML},
+}
+
+func init() {
+	// query_to_xml runs a query, whose evaluation calls functions: it is
+	// registered here to break the initialization cycle.
+	functions["QUERY_TO_XML"] = function{dialects: onlyPostgres, minArgs: 4, maxArgs: 4, call: fnQueryToXML}
+}
+
+// aggregates are evaluated over the rows of a group.
+var aggregates = map[string]bool{
+	"COUNT": true, "SUM": true, "MIN": true, "MAX": true, "AVG": true,
+	"GROUP_CONCAT": true, "STRING_AGG": true,
+}
+
+// stringAggregates are the aggregates joining a group's values, with the
+// dialects providing them.
+var stringAggregates = map[string]function{
+	"GROUP_CONCAT": {dialects: onlyMySQL, minArgs: 1, maxArgs: -1},
+	"STRING_AGG":   {dialects: []Dialect{PostgreSQL, MSSQL}, minArgs: 2, maxArgs: 2},
+}
+
+// available reports whether f exists in dialect d. MariaDB has every
+// MySQL function.
+func (f function) available(d Dialect) bool {
+	if d == Generic || len(f.dialects) == 0 {
+		return true
+	}
+	for _, fd := range f.dialects {
+		if fd == d || fd == MySQL && d == MariaDB {
+			return true
+		}
+	}
+	return false
+}
+
+// call evaluates a function call.
+func (ev *evaluator) call(x *funcCall, ctx rowCtx) (Value, error) {
+	d := ev.dialect()
+	if aggregates[x.name] {
+		return ev.aggregate(x, ctx)
+	}
+	f, ok := functions[x.name]
+	if !ok || !f.available(d) {
+		return nil, unknownFunction(d, x.name)
+	}
+	if x.star || len(x.args) < f.minArgs || (f.maxArgs >= 0 && len(x.args) > f.maxArgs) {
+		return nil, paramCount(d, x.name)
+	}
+	args := make([]Value, len(x.args))
+	for i, a := range x.args {
+		v, err := ev.eval(a, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil && !f.nullable {
+			return nil, nil
+		}
+		args[i] = v
+	}
+	return f.call(ev, args)
+}
+
+// aggregate evaluates COUNT, SUM, MIN, MAX, AVG, GROUP_CONCAT or
+// STRING_AGG over ctx.group.
+func (ev *evaluator) aggregate(x *funcCall, ctx rowCtx) (Value, error) {
+	if !ctx.grouped && ctx.sc != nil {
+		// Outside an aggregate select list the group is the current row.
+		ctx.group = []*scope{ctx.sc}
+	}
+	if f, ok := stringAggregates[x.name]; ok {
+		if !f.available(ev.dialect()) {
+			return nil, unknownFunction(ev.dialect(), x.name)
+		}
+		if x.star || len(x.args) < f.minArgs || (f.maxArgs >= 0 && len(x.args) > f.maxArgs) {
+			return nil, paramCount(ev.dialect(), x.name)
+		}
+		return ev.stringAgg(x, ctx)
+	}
+	if x.star {
+		if x.name != "COUNT" {
+			return nil, syntaxError(ev.dialect(), "*", "*")
+		}
+		return int64(len(ctx.group)), nil
+	}
+	if len(x.args) != 1 {
+		return nil, paramCount(ev.dialect(), x.name)
+	}
+
+	var count int64
+	var result Value
+	var sum float64
+	allInt := true
+	for _, sc := range ctx.group {
+		v, err := ev.eval(x.args[0], rowCtx{sc: sc})
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			continue
+		}
+		count++
+		switch x.name {
+		case "MIN", "MAX":
+			if result == nil {
+				result = v
+				continue
+			}
+			c := ev.sortCompare(v, result)
+			if (x.name == "MIN" && c < 0) || (x.name == "MAX" && c > 0) {
+				result = v
+			}
+		case "SUM", "AVG":
+			n, err := ev.toNumber(v)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := n.(int64); !ok {
+				allInt = false
+			}
+			sum += toFloat(n)
+		}
+	}
+	switch x.name {
+	case "COUNT":
+		return count, nil
+	case "SUM":
+		if count == 0 {
+			return nil, nil
+		}
+		if allInt {
+			return roundInt(sum), nil
+		}
+		return sum, nil
+	case "AVG":
+		if count == 0 {
+			return nil, nil
+		}
+		return sum / float64(count), nil
+	}
+	return result, nil
+}
+
+// stringAgg joins the values of GROUP_CONCAT(x[, ...] [SEPARATOR sep]) or
+// STRING_AGG(x, sep) over ctx.group, skipping NULLs. GROUP_CONCAT
+// concatenates its arguments per row, separates rows with a comma by
+// default and truncates the result to DB.GroupConcatMaxLen bytes.
+func (ev *evaluator) stringAgg(x *funcCall, ctx rowCtx) (Value, error) {
+	vals, sepExpr := x.args, x.sep
+	if x.name == "STRING_AGG" {
+		vals, sepExpr = x.args[:1], x.args[1]
+	}
+	sep := ","
+	if sepExpr != nil {
+		v, err := ev.eval(sepExpr, rowCtx{})
+		if err != nil {
+			return nil, err
+		}
+		sep = Format(v)
+	}
+
+	var parts []string
+	for _, sc := range ctx.group {
+		var b strings.Builder
+		null := false
+		for _, a := range vals {
+			v, err := ev.eval(a, rowCtx{sc: sc})
+			if err != nil {
+				return nil, err
+			}
+			if v == nil {
+				null = true
+				break
+			}
+			b.WriteString(Format(v))
+		}
+		if !null {
+			parts = append(parts, b.String())
+		}
+	}
+	if len(parts) == 0 {
+		return nil, nil
+	}
+	s := strings.Join(parts, sep)
+	if x.name == "GROUP_CONCAT" {
+		if limit := ev.db.groupConcatMaxLen(); len(s) > limit {
+			s = s[:limit]
+		}
+	}
+	return s, nil
+}
+
+// hasAggregate reports whether any of exprs calls an aggregate outside a
+// subquery.
+func hasAggregate(exprs []expr) bool {
+	for _, x := range exprs {
+		if containsAggregate(x) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAggregate(x expr) bool {
+	switch x := x.(type) {
+	case *funcCall:
+		if aggregates[x.name] {
+			return true
+		}
+		return hasAggregate(x.args)
+	case *unaryExpr:
+		return containsAggregate(x.x)
+	case *binaryExpr:
+		return containsAggregate(x.l) || containsAggregate(x.r)
+	case *likeExpr:
+		return containsAggregate(x.x) || containsAggregate(x.pattern)
+	case *inExpr:
+		return containsAggregate(x.x) || hasAggregate(x.list)
+	case *betweenExpr:
+		return containsAggregate(x.x) || containsAggregate(x.lo) || containsAggregate(x.hi)
+	case *isNullExpr:
+		return containsAggregate(x.x)
+	case *castExpr:
+		return containsAggregate(x.x)
+	case *caseExpr:
+		if x.operand != nil && containsAggregate(x.operand) || x.els != nil && containsAggregate(x.els) {
+			return true
+		}
+		for _, w := range x.whens {
+			if containsAggregate(w.cond) || containsAggregate(w.then) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// --------------------------------------------------------------------------
+// Function implementations
+// --------------------------------------------------------------------------
+
+func fnASCII(_ *evaluator, args []Value) (Value, error) {
+	s := Format(args[0])
+	if s == "" {
+		return int64(0), nil
+	}
+	return int64(s[0]), nil
+}
+
+// fnSubstring implements SUBSTRING(s, pos[, n]) over characters. MySQL
+// and Oracle count a negative pos from the end, and MySQL returns "" for
+// pos 0 where Oracle starts at 1; the other dialects treat the range
+// [pos, pos+n) and clip it to the string.
+func fnSubstring(ev *evaluator, args []Value) (Value, error) {
+	r := []rune(Format(args[0]))
+	pos, err := ev.toInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	p := pos.(int64)
+	n := int64(len(r)) + 1
+	if len(args) == 3 {
+		v, err := ev.toInt(args[2])
+		if err != nil {
+			return nil, err
+		}
+		n = v.(int64)
+	}
+
+	if p == 0 && ev.dialect() == Oracle {
+		p = 1
+	}
+
+	var start, end int64
+	if ev.dialect().mysqlLike() || ev.dialect() == Oracle {
+		switch {
+		case p > 0:
+			start = p - 1
+		case p < 0:
+			start = int64(len(r)) + p
+		default:
+			return "", nil
+		}
+		if start < 0 || n <= 0 {
+			return "", nil
+		}
+		end = start + n
+	} else {
+		if n < 0 {
+			return "", nil
+		}
+		start, end = p-1, p-1+n
+		if start < 0 {
+			start = 0
+		}
+	}
+	if end > int64(len(r)) || end < start {
+		end = int64(len(r))
+	}
+	if start >= int64(len(r)) {
+		return "", nil
+	}
+	return string(r[start:end]), nil
+}
+
+// fnLength is LENGTH: bytes on MySQL, characters on PostgreSQL and
+// Oracle.
+func fnLength(ev *evaluator, args []Value) (Value, error) {
+	if ev.dialect() == PostgreSQL || ev.dialect() == Oracle {
+		return fnCharLength(ev, args)
+	}
+	return int64(len(Format(args[0]))), nil
+}
+
+func fnCharLength(_ *evaluator, args []Value) (Value, error) {
+	return int64(utf8.RuneCountInString(Format(args[0]))), nil
+}
+
+// fnLen is MSSQL LEN, which ignores trailing spaces.
+func fnLen(_ *evaluator, args []Value) (Value, error) {
+	return int64(utf8.RuneCountInString(strings.TrimRight(Format(args[0]), " "))), nil
+}
+
+// fnChar builds a string from character codes (CHAR, CHR).
+func fnChar(ev *evaluator, args []Value) (Value, error) {
+	var b strings.Builder
+	for _, a := range args {
+		v, err := ev.toInt(a)
+		if err != nil {
+			return nil, err
+		}
+		if c := v.(int64); c >= 0 && c <= utf8.MaxRune {
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String(), nil
+}
+
+// fnConcat joins its arguments. MySQL returns NULL if any is NULL; the
+// other dialects skip NULLs.
+func fnConcat(ev *evaluator, args []Value) (Value, error) {
+	var b strings.Builder
+	for _, a := range args {
+		if a == nil && ev.dialect().mysqlLike() {
+			return nil, nil
+		}
+		b.WriteString(Format(a))
+	}
+	return b.String(), nil
+}
+
+func fnLower(_ *evaluator, args []Value) (Value, error) {
+	return strings.ToLower(Format(args[0])), nil
+}
+
+func fnUpper(_ *evaluator, args []Value) (Value, error) {
+	return strings.ToUpper(Format(args[0])), nil
+}
+
+func fnReplace(_ *evaluator, args []Value) (Value, error) {
+	from := Format(args[1])
+	if from == "" {
+		return Format(args[0]), nil
+	}
+	return strings.ReplaceAll(Format(args[0]), from, Format(args[2])), nil
+}
+
+// fnConv converts a number between bases 2 to 36, with upper-case digits
+// as MySQL prints them.
+func fnConv(ev *evaluator, args []Value) (Value, error) {
+	var base [2]int
+	for i, a := range args[1:] {
+		n, err := ev.toInt(a)
+		if err != nil {
+			return nil, err
+		}
+		b := n.(int64)
+		if b < 0 {
+			b = -b
+		}
+		if b < 2 || b > 36 {
+			return nil, nil
+		}
+		base[i] = int(b)
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(Format(args[0])), base[0], 64)
+	if err != nil {
+		return "0", nil
+	}
+	return strings.ToUpper(strconv.FormatInt(n, base[1])), nil
+}
+
+// fnCurrentSetting knows the one setting fingerprinting asks for.
+func fnCurrentSetting(ev *evaluator, args []Value) (Value, error) {
+	name := Format(args[0])
+	if !strings.EqualFold(name, "server_version") {
+		return nil, unknownVariable(ev.dialect(), name)
+	}
+	return ev.db.Version, nil
+}
+
+// xsiNamespace is the namespace query_to_xml declares for xsi:nil.
+const xsiNamespace = `xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"`
+
+// fnQueryToXML runs the query in its first argument and maps the result
+// to XML like PostgreSQL's query_to_xml(query, nulls, tableforest,
+// targetns): one element per column, named after it, in a <row> element
+// per row, all wrapped in <table> unless tableforest is set.
+func fnQueryToXML(ev *evaluator, args []Value) (Value, error) {
+	q, stacked, err := parse(Format(args[0]), ev.dialect())
+	if err != nil {
+		return nil, err
+	}
+	if len(stacked) > 0 {
+		return nil, syntaxError(ev.dialect(), ";", ";")
+	}
+	cols, rows, err := ev.runQuery(q, nil)
+	if err != nil {
+		return nil, err
+	}
+	nulls, _ := ev.truth(args[1])
+	forest, _ := ev.truth(args[2])
+
+	xmlText := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	var b strings.Builder
+	if !forest {
+		b.WriteString("<table " + xsiNamespace + ">\n\n")
+	}
+	for _, row := range rows {
+		if forest {
+			b.WriteString("<row " + xsiNamespace + ">\n\n")
+		} else {
+			b.WriteString("<row>\n")
+		}
+		for i, v := range row {
+			switch {
+			case v != nil:
+				b.WriteString("  <" + cols[i] + ">" + xmlText.Replace(Format(v)) + "</" + cols[i] + ">\n")
+			case nulls:
+				b.WriteString("  <" + cols[i] + ` xsi:nil="true"/>` + "\n")
+			}
+		}
+		b.WriteString("</row>\n\n")
+	}
+	if !forest {
+		b.WriteString("</table>\n")
+	}
+	return b.String(), nil
+}
+
+func fnFirst(_ *evaluator, args []Value) (Value, error) {
+	return args[0], nil
+}
+
+func fnCoalesce(_ *evaluator, args []Value) (Value, error) {
+	for _, a := range args {
+		if a != nil {
+			return a, nil
+		}
+	}
+	return nil, nil
+}
+
+func fnNullIf(ev *evaluator, args []Value) (Value, error) {
+	c, ok, err := ev.compare(args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	if ok && c == 0 {
+		return nil, nil
+	}
+	return args[0], nil
+}
+
+// fnSleep is MySQL SLEEP, which returns 0.
+func fnSleep(ev *evaluator, args []Value) (Value, error) {
+	if err := ev.addSleep(args[0]); err != nil {
+		return nil, err
+	}
+	return int64(0), nil
+}
+
+// fnPgSleep is PostgreSQL PG_SLEEP. It returns void, which is not NULL and
+// prints as an empty string.
+func fnPgSleep(ev *evaluator, args []Value) (Value, error) {
+	return "", ev.addSleep(args[0])
+}
+
+// fnBenchmark is MySQL BENCHMARK(count, expr), which returns 0 after
+// evaluating expr count times, here at DB.BenchmarkRate iterations a second.
+func fnBenchmark(ev *evaluator, args []Value) (Value, error) {
+	n, err := ev.toNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if err := ev.addSleep(toFloat(n) / float64(ev.db.benchmarkRate())); err != nil {
+		return nil, err
+	}
+	return int64(0), nil
+}
+
+func fnMD5(_ *evaluator, args []Value) (Value, error) {
+	sum := md5.Sum([]byte(Format(args[0])))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// fnHex is MySQL HEX: the hexadecimal digits of an integer, or of the
+// bytes of anything else, in upper case.
+func fnHex(_ *evaluator, args []Value) (Value, error) {
+	if n, ok := args[0].(int64); ok {
+		return strings.ToUpper(strconv.FormatInt(n, 16)), nil
+	}
+	return strings.ToUpper(hex.EncodeToString([]byte(Format(args[0])))), nil
+}
+
+// fnEncode is PostgreSQL encode(data, format), for the hex format.
+func fnEncode(_ *evaluator, args []Value) (Value, error) {
+	if f := Format(args[1]); !strings.EqualFold(f, "hex") {
+		return nil, &Error{Kind: TypeMismatch, Near: f, msg: fmt.Sprintf("unrecognized encoding: %q", f)}
+	}
+	return hex.EncodeToString([]byte(Format(args[0]))), nil
+}
+
+// fnReadFile is LOAD_FILE(path) and PostgreSQL's pg_read_file(path) and
+// pg_read_binary_file(path): the contents of path in DB.Files, or NULL.
+func fnReadFile(ev *evaluator, args []Value) (Value, error) {
+	content, ok := ev.db.Files[Format(args[0])]
+	if !ok {
+		return nil, nil
+	}
+	return content, nil
+}
+
+// fnReceiveMessage is Oracle DBMS_PIPE.RECEIVE_MESSAGE(pipe, timeout).
+// Nothing is ever sent to the pipe, so it waits out the timeout and
+// returns 1, the timeout status.
+func fnReceiveMessage(ev *evaluator, args []Value) (Value, error) {
+	if err := ev.addSleep(args[1]); err != nil {
+		return nil, err
+	}
+	return int64(1), nil
+}
+
+// fnRownum is Oracle's ROWNUM pseudocolumn.
+func fnRownum(ev *evaluator, _ []Value) (Value, error) {
+	return ev.rownum, nil
+}
+
+// fnGetHostName is UTL_INADDR.GET_HOST_NAME: the database host's name
+// without an argument. The fixture resolves no addresses, so a lookup
+// fails quoting the address.
+func fnGetHostName(ev *evaluator, args []Value) (Value, error) {
+	if len(args) == 0 {
+		return ev.db.Hostname, nil
+	}
+	return nil, hostUnknown(Format(args[0]))
+}
+
+// fnDrithsxSN is CTXSYS.DRITHSX.SN(id, thesaurus). The fixture has no
+// Oracle Text thesauri, so it fails quoting the name.
+func fnDrithsxSN(_ *evaluator, args []Value) (Value, error) {
+	return nil, thesaurusUnknown(Format(args[1]))
+}
+
+// identity returns a function answering a DB identity field.
+func identity(field func(*DB) string) func(*evaluator, []Value) (Value, error) {
+	return func(ev *evaluator, _ []Value) (Value, error) {
+		return field(ev.db), nil
+	}
+}
+
+// checkXPath fails with an XPath error quoting the expression from its
+// first character that cannot appear in an XPath.
+func checkXPath(xpath string) error {
+	for i, c := range xpath {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+			strings.ContainsRune("/@.*:_-[]()='\" ,|", c)) {
+			return xpathError(xpath[i:])
+		}
+	}
+	return nil
+}
+
+// fnExtractValue validates the XPath and finds nothing.
+func fnExtractValue(_ *evaluator, args []Value) (Value, error) {
+	if err := checkXPath(Format(args[1])); err != nil {
+		return nil, err
+	}
+	return "", nil
+}
+
+// fnUpdateXML validates the XPath and returns the document unchanged.
+func fnUpdateXML(_ *evaluator, args []Value) (Value, error) {
+	if err := checkXPath(Format(args[1])); err != nil {
+		return nil, err
+	}
+	return Format(args[0]), nil
+}
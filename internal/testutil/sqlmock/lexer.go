@@ -0,0 +1,281 @@
+package sqlmock
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// tokenKind is the lexical class of a token.
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokQuotedIdent
+	tokNumber
+	tokString
+	tokVariable
+	tokOp
+)
+
+// token is a lexed token. For strings text is the decoded value; otherwise
+// it is the source text.
+type token struct {
+	kind     tokenKind
+	text     string
+	pos, end int // byte offsets of the source text in the query
+}
+
+// lex splits sql into tokens, dropping whitespace and comments. The last
+// token is always tokEOF.
+func lex(sql string, d Dialect) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(sql) {
+		c := sql[i]
+		before := len(toks)
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+			i++
+
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end == -1 {
+				return nil, syntaxError(d, sql[i:], sql[i:])
+			}
+			i += end + 4
+
+		case strings.HasPrefix(sql[i:], "--") && lineComment(sql[i+2:], d):
+			i = skipLine(sql, i)
+
+		case c == '#' && d.mysqlLike():
+			i = skipLine(sql, i)
+
+		case c == '\'':
+			s, n, ok := lexString(sql[i:], '\'', d.mysqlLike())
+			if !ok {
+				return nil, unclosedQuote(d, sql[i:])
+			}
+			toks = append(toks, token{kind: tokString, text: s, pos: i})
+			i += n
+
+		case (c == 'N' || c == 'n') && d == MSSQL && strings.HasPrefix(sql[i+1:], "'"):
+			s, n, ok := lexString(sql[i+1:], '\'', false)
+			if !ok {
+				return nil, unclosedQuote(d, sql[i+1:])
+			}
+			toks = append(toks, token{kind: tokString, text: s, pos: i})
+			i += n + 1
+
+		case c == '"':
+			s, n, ok := lexString(sql[i:], '"', d.mysqlLike())
+			if !ok {
+				return nil, unclosedQuote(d, sql[i:])
+			}
+			kind := tokQuotedIdent
+			if d.mysqlLike() {
+				kind = tokString
+			}
+			toks = append(toks, token{kind: kind, text: s, pos: i})
+			i += n
+
+		case c == '`' && d.mysqlLike(), c == '[' && (d == MSSQL || d == Generic):
+			closer := byte('`')
+			if c == '[' {
+				closer = ']'
+			}
+			end := strings.IndexByte(sql[i+1:], closer)
+			if end == -1 {
+				return nil, syntaxError(d, sql[i:], sql[i:])
+			}
+			toks = append(toks, token{kind: tokQuotedIdent, text: sql[i+1 : i+1+end], pos: i})
+			i += end + 2
+
+		case c == '0' && i+1 < len(sql) && (sql[i+1] == 'x' || sql[i+1] == 'X') && d != PostgreSQL && d != Oracle:
+			j := i + 2
+			for j < len(sql) && isHexDigit(sql[j]) {
+				j++
+			}
+			digits := sql[i+2 : j]
+			if len(digits)%2 == 1 {
+				digits = "0" + digits
+			}
+			b, err := hex.DecodeString(digits)
+			if err != nil || j == i+2 || (j < len(sql) && isIdentByte(sql[j])) {
+				return nil, syntaxError(d, sql[i:j], sql[i:])
+			}
+			toks = append(toks, token{kind: tokString, text: string(b), pos: i})
+			i = j
+
+		case isDigit(c) || (c == '.' && i+1 < len(sql) && isDigit(sql[i+1])):
+			j := i
+			for j < len(sql) && isDigit(sql[j]) {
+				j++
+			}
+			if j < len(sql) && sql[j] == '.' {
+				j++
+				for j < len(sql) && isDigit(sql[j]) {
+					j++
+				}
+			}
+			if j < len(sql) && (sql[j] == 'e' || sql[j] == 'E') {
+				k := j + 1
+				if k < len(sql) && (sql[k] == '+' || sql[k] == '-') {
+					k++
+				}
+				if k < len(sql) && isDigit(sql[k]) {
+					for j = k; j < len(sql) && isDigit(sql[j]); j++ {
+					}
+				}
+			}
+			toks = append(toks, token{kind: tokNumber, text: sql[i:j], pos: i})
+			i = j
+
+		case c == '$' && d == PostgreSQL:
+			s, n, ok := lexDollarString(sql[i:])
+			if !ok {
+				return nil, unclosedDollarQuote(sql[i:])
+			}
+			toks = append(toks, token{kind: tokString, text: s, pos: i})
+			i += n
+
+		case c == '@' && d != PostgreSQL && d != Oracle:
+			j := i + 1
+			if j < len(sql) && sql[j] == '@' {
+				j++
+			}
+			start := j
+			for j < len(sql) && isIdentByte(sql[j]) {
+				j++
+			}
+			if j == start {
+				return nil, syntaxError(d, sql[i:j], sql[i:])
+			}
+			toks = append(toks, token{kind: tokVariable, text: sql[i:j], pos: i})
+			i = j
+
+		case isIdentStart(c):
+			j := i
+			for j < len(sql) && isIdentByte(sql[j]) {
+				j++
+			}
+			toks = append(toks, token{kind: tokIdent, text: sql[i:j], pos: i})
+			i = j
+
+		default:
+			op := lexOp(sql[i:], d)
+			if op == "" {
+				return nil, syntaxError(d, sql[i:i+1], sql[i:])
+			}
+			toks = append(toks, token{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		}
+		if len(toks) > before {
+			toks[before].end = i
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: len(sql), end: len(sql)}), nil
+}
+
+// lineComment reports whether "--" followed by rest starts a comment. MySQL
+// requires whitespace (or the end of input) after the dashes.
+func lineComment(rest string, d Dialect) bool {
+	if !d.mysqlLike() {
+		return true
+	}
+	return rest == "" || rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\n' || rest[0] == '\r'
+}
+
+// skipLine returns the offset just past the line containing offset i.
+func skipLine(sql string, i int) int {
+	if nl := strings.IndexByte(sql[i:], '\n'); nl != -1 {
+		return i + nl + 1
+	}
+	return len(sql)
+}
+
+// lexString decodes a literal starting at s[0] == quote. A doubled quote
+// escapes the quote; with backslash set, so does a backslash (MySQL). It
+// returns the decoded value and the number of bytes consumed.
+func lexString(s string, quote byte, backslash bool) (string, int, bool) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == quote:
+			if i+1 < len(s) && s[i+1] == quote {
+				b.WriteByte(quote)
+				i++
+				continue
+			}
+			return b.String(), i + 1, true
+		case s[i] == '\\' && backslash && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case '0':
+				b.WriteByte(0)
+			case '%', '_':
+				// MySQL keeps the backslash so LIKE still sees the escape.
+				b.WriteByte('\\')
+				b.WriteByte(s[i])
+			default:
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", 0, false
+}
+
+// lexOp returns the operator at the start of s, longest match first, or ""
+// if s does not start with one.
+func lexOp(s string, d Dialect) string {
+	for _, op := range []string{"<=>", "<>", "!=", "<=", ">=", "||", "::"} {
+		if strings.HasPrefix(s, op) {
+			if op == "::" && d != PostgreSQL && d != Generic {
+				continue
+			}
+			if op == "<=>" && !d.mysqlLike() {
+				continue
+			}
+			return op
+		}
+	}
+	if strings.IndexByte("(),.;*/%+-=<>&", s[0]) != -1 {
+		return s[:1]
+	}
+	return ""
+}
+
+// lexDollarString lexes a PostgreSQL dollar-quoted string, $$...$$ or
+// $tag$...$tag$, at the start of s and returns its contents and length.
+func lexDollarString(s string) (string, int, bool) {
+	end := strings.IndexByte(s[1:], '$')
+	if end == -1 {
+		return "", 0, false
+	}
+	delim := s[:end+2]
+	for _, c := range []byte(delim[1 : len(delim)-1]) {
+		if !isIdentByte(c) || c == '$' {
+			return "", 0, false
+		}
+	}
+	body := strings.Index(s[len(delim):], delim)
+	if body == -1 {
+		return "", 0, false
+	}
+	return s[len(delim) : len(delim)+body], 2*len(delim) + body, true
+}
+
+func isDigit(c byte) bool    { return c >= '0' && c <= '9' }
+func isHexDigit(c byte) bool { return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') }
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '$'
+}
+
+func isIdentByte(c byte) bool { return isIdentStart(c) || isDigit(c) }
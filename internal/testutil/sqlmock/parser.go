@@ -0,0 +1,1099 @@
+package sqlmock
+
+import (
+	"strconv"
+	"strings"
+)
+
+// maxDepth bounds expression nesting so hostile input cannot exhaust the
+// stack.
+const maxDepth = 64
+
+// query is a SELECT statement, possibly a UNION of several.
+type query struct {
+	selects  []*selectCore
+	orderBy  []orderItem
+	limit    expr
+	offset   expr
+	unionAll []bool // unionAll[i] joins selects[i] and selects[i+1]
+
+	// procedure is a MySQL PROCEDURE ANALYSE(...) clause, and procedureAt
+	// the query text from PROCEDURE on for the error MySQL 8.0 gives.
+	procedure   *funcCall
+	procedureAt string
+}
+
+// waitStmt is a statement MSSQL runs after the query in the same batch:
+// WAITFOR DELAY, optionally guarded by IF.
+type waitStmt struct {
+	cond  expr // nil runs the WAITFOR unconditionally
+	delay string
+}
+
+type selectCore struct {
+	distinct bool
+	top      expr
+	items    []selectItem
+	from     *fromItem
+	where    expr
+}
+
+type selectItem struct {
+	x     expr
+	alias string
+	star  bool // * or table.*
+}
+
+// fromItem is a table, a table function (PG_SLEEP) or a derived table.
+type fromItem struct {
+	table string
+	alias string
+	fn    *funcCall
+	sub   *query
+}
+
+type orderItem struct {
+	x    expr
+	desc bool
+}
+
+// expr is an expression node.
+type expr interface{}
+
+type (
+	literal   struct{ v Value }
+	variable  struct{ name string }
+	columnRef struct{ table, name string }
+	// positionRef is a column of a star-expanded select list, resolved by
+	// position because derived tables may repeat names.
+	positionRef struct{ i int }
+	unaryExpr   struct {
+		op string // "-" or "NOT"
+		x  expr
+	}
+	binaryExpr struct {
+		op   string // upper-cased operator; "CONCAT" for PostgreSQL ||
+		l, r expr
+	}
+	likeExpr struct {
+		x, pattern expr
+		not, fold  bool
+	}
+	inExpr struct {
+		x    expr
+		list []expr
+		sub  *query
+		not  bool
+	}
+	betweenExpr struct {
+		x, lo, hi expr
+		not       bool
+	}
+	isNullExpr struct {
+		x   expr
+		not bool
+	}
+	caseExpr struct {
+		operand expr
+		whens   []whenClause
+		els     expr
+	}
+	castExpr struct {
+		x   expr
+		typ string // "int", "text", "numeric" or "float"
+	}
+	funcCall struct {
+		name string // upper-cased
+		args []expr
+		star bool // COUNT(*)
+		sep  expr // GROUP_CONCAT(... SEPARATOR sep)
+	}
+	subqueryExpr struct{ q *query }
+	existsExpr   struct{ q *query }
+)
+
+type whenClause struct{ cond, then expr }
+
+// reserved are the keywords that cannot be column names or aliases.
+var reserved = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "AND": true, "OR": true, "NOT": true,
+	"UNION": true, "ALL": true, "ORDER": true, "BY": true, "LIMIT": true, "OFFSET": true,
+	"AS": true, "CASE": true, "WHEN": true, "THEN": true, "ELSE": true, "END": true,
+	"IS": true, "NULL": true, "LIKE": true, "ILIKE": true, "IN": true, "BETWEEN": true,
+	"ASC": true, "DESC": true, "DISTINCT": true, "TOP": true, "EXISTS": true,
+	"GROUP": true, "HAVING": true, "JOIN": true, "ON": true, "INTO": true,
+	"DIV": true, "MOD": true, "TRUE": true, "FALSE": true, "WAITFOR": true,
+}
+
+// niladic are functions the dialects allow without parentheses.
+var niladic = map[string]bool{
+	"CURRENT_USER": true, "SESSION_USER": true, "SYSTEM_USER": true, "USER": true,
+}
+
+// oracleNiladic are the pseudocolumns and functions Oracle allows without
+// parentheses, package functions by their qualified name.
+var oracleNiladic = map[string]bool{
+	"ROWNUM": true, "ORA_DATABASE_NAME": true, "UTL_INADDR.GET_HOST_NAME": true,
+}
+
+type parser struct {
+	sql   string
+	d     Dialect
+	toks  []token
+	i     int
+	depth int
+}
+
+// parse parses a single statement, optionally terminated by a semicolon.
+// MSSQL also takes stacked WAITFOR statements after it, which parse returns
+// in order.
+func parse(sql string, d Dialect) (*query, []waitStmt, error) {
+	toks, err := lex(sql, d)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &parser{sql: sql, d: d, toks: toks}
+	q, err := p.parseQuery()
+	if err != nil {
+		return nil, nil, err
+	}
+	var stacked []waitStmt
+	for p.acceptOp(";") {
+		if d != MSSQL || p.peek().kind == tokEOF {
+			break
+		}
+		w, err := p.parseWait()
+		if err != nil {
+			return nil, nil, err
+		}
+		stacked = append(stacked, w)
+	}
+	if p.peek().kind != tokEOF {
+		return nil, nil, p.fail()
+	}
+	return q, stacked, nil
+}
+
+// parseWait parses [IF condition] WAITFOR DELAY 'time'.
+func (p *parser) parseWait() (waitStmt, error) {
+	var w waitStmt
+	if p.acceptKeyword("IF") {
+		cond, err := p.parseExpr()
+		if err != nil {
+			return w, err
+		}
+		w.cond = cond
+	}
+	if err := p.expectKeyword("WAITFOR"); err != nil {
+		return w, err
+	}
+	if err := p.expectKeyword("DELAY"); err != nil {
+		return w, err
+	}
+	if p.peek().kind != tokString {
+		return w, p.fail()
+	}
+	w.delay = p.next().text
+	return w, nil
+}
+
+func (p *parser) peek() token { return p.toks[p.i] }
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// fail returns a syntax error at the current token.
+func (p *parser) fail() error {
+	t := p.peek()
+	return syntaxError(p.d, p.sql[t.pos:t.end], p.sql[t.pos:])
+}
+
+// isKeyword reports whether the current token is the unquoted keyword kw.
+func (p *parser) isKeyword(kw string) bool {
+	t := p.peek()
+	return t.kind == tokIdent && strings.EqualFold(t.text, kw)
+}
+
+func (p *parser) acceptKeyword(kw string) bool {
+	if p.isKeyword(kw) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(kw string) error {
+	if !p.acceptKeyword(kw) {
+		return p.fail()
+	}
+	return nil
+}
+
+func (p *parser) isOp(op string) bool {
+	t := p.peek()
+	return t.kind == tokOp && t.text == op
+}
+
+func (p *parser) acceptOp(op string) bool {
+	if p.isOp(op) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectOp(op string) error {
+	if !p.acceptOp(op) {
+		return p.fail()
+	}
+	return nil
+}
+
+// enter guards recursion; callers must defer p.leave().
+func (p *parser) enter() error {
+	p.depth++
+	if p.depth > maxDepth {
+		return p.fail()
+	}
+	return nil
+}
+
+func (p *parser) leave() { p.depth-- }
+
+func (p *parser) parseQuery() (*query, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	core, err := p.parseSelect()
+	if err != nil {
+		return nil, err
+	}
+	q := &query{selects: []*selectCore{core}}
+	for p.acceptKeyword("UNION") {
+		all := p.acceptKeyword("ALL")
+		if !all {
+			p.acceptKeyword("DISTINCT")
+		}
+		core, err := p.parseSelect()
+		if err != nil {
+			return nil, err
+		}
+		q.selects = append(q.selects, core)
+		q.unionAll = append(q.unionAll, all)
+	}
+
+	if p.acceptKeyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			x, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			item := orderItem{x: x}
+			if p.acceptKeyword("DESC") {
+				item.desc = true
+			} else {
+				p.acceptKeyword("ASC")
+			}
+			q.orderBy = append(q.orderBy, item)
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+	}
+
+	if p.d != MSSQL && p.d != Oracle && p.acceptKeyword("LIMIT") {
+		first, err := p.parseLimitArg("LIMIT")
+		if err != nil {
+			return nil, err
+		}
+		q.limit = first
+		switch {
+		case p.d.mysqlLike() && p.acceptOp(","):
+			q.offset = first
+			if q.limit, err = p.parseLimitArg("LIMIT"); err != nil {
+				return nil, err
+			}
+		case p.acceptKeyword("OFFSET"):
+			if q.offset, err = p.parseLimitArg("OFFSET"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if p.d.mysqlLike() && p.isKeyword("PROCEDURE") {
+		q.procedureAt = p.sql[p.peek().pos:]
+		p.i++
+		if !p.isKeyword("ANALYSE") {
+			return nil, p.fail()
+		}
+		x, err := p.parseKeywordOrName()
+		if err != nil {
+			return nil, err
+		}
+		call, ok := x.(*funcCall)
+		if !ok {
+			return nil, p.fail()
+		}
+		q.procedure = call
+	}
+	return q, nil
+}
+
+// parseLimitArg parses the argument of a LIMIT or OFFSET clause. MySQL and
+// MariaDB take only an integer literal there; PostgreSQL takes any
+// expression but a condition.
+func (p *parser) parseLimitArg(clause string) (expr, error) {
+	if p.d == MySQL || p.d == MariaDB {
+		t := p.peek()
+		n, err := strconv.ParseInt(t.text, 10, 64)
+		if t.kind != tokNumber || err != nil {
+			return nil, p.fail()
+		}
+		p.i++
+		return &literal{v: n}, nil
+	}
+	x, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.d == PostgreSQL && isCondition(x) {
+		return nil, limitArgument(clause)
+	}
+	return x, nil
+}
+
+// isCondition reports whether x is a predicate, which PostgreSQL types as
+// boolean.
+func isCondition(x expr) bool {
+	switch x := x.(type) {
+	case *binaryExpr:
+		return x.op == "AND" || x.op == "OR" || comparisons[x.op]
+	case *unaryExpr:
+		return x.op == "NOT"
+	case *likeExpr, *inExpr, *betweenExpr, *isNullExpr, *existsExpr:
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseSelect() (*selectCore, error) {
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+	s := &selectCore{}
+	if p.acceptKeyword("DISTINCT") {
+		s.distinct = true
+	} else {
+		p.acceptKeyword("ALL")
+	}
+	if (p.d == MSSQL || p.d == Generic) && p.acceptKeyword("TOP") {
+		top, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		s.top = top
+	}
+
+	for {
+		item, err := p.parseSelectItem()
+		if err != nil {
+			return nil, err
+		}
+		s.items = append(s.items, item)
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+
+	if p.acceptKeyword("FROM") {
+		from, err := p.parseFrom()
+		if err != nil {
+			return nil, err
+		}
+		s.from = from
+	} else if p.d == Oracle {
+		t := p.peek()
+		return nil, missingFrom(p.sql[t.pos:t.end])
+	}
+	if p.acceptKeyword("WHERE") {
+		where, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		s.where = where
+	}
+	return s, nil
+}
+
+func (p *parser) parseSelectItem() (selectItem, error) {
+	if p.acceptOp("*") {
+		return selectItem{star: true}, nil
+	}
+	if t := p.peek(); (t.kind == tokIdent || t.kind == tokQuotedIdent) && p.i+2 < len(p.toks) &&
+		p.toks[p.i+1].kind == tokOp && p.toks[p.i+1].text == "." &&
+		p.toks[p.i+2].kind == tokOp && p.toks[p.i+2].text == "*" {
+		p.i += 3
+		return selectItem{star: true}, nil
+	}
+	x, err := p.parseExpr()
+	if err != nil {
+		return selectItem{}, err
+	}
+	item := selectItem{x: x}
+	alias, ok, err := p.parseAlias()
+	if err != nil {
+		return selectItem{}, err
+	}
+	if ok {
+		item.alias = alias
+	}
+	return item, nil
+}
+
+// parseAlias parses an optional [AS] alias.
+func (p *parser) parseAlias() (string, bool, error) {
+	as := p.acceptKeyword("AS")
+	t := p.peek()
+	switch {
+	case t.kind == tokQuotedIdent, t.kind == tokIdent && !reserved[strings.ToUpper(t.text)]:
+		p.i++
+		return t.text, true, nil
+	case t.kind == tokString && as:
+		p.i++
+		return t.text, true, nil
+	case as:
+		return "", false, p.fail()
+	}
+	return "", false, nil
+}
+
+func (p *parser) parseFrom() (*fromItem, error) {
+	from := &fromItem{}
+	switch t := p.peek(); {
+	case p.isOp("("):
+		p.i++
+		sub, err := p.parseQuery()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, err
+		}
+		from.sub = sub
+	case t.kind == tokIdent && !reserved[strings.ToUpper(t.text)], t.kind == tokQuotedIdent:
+		p.i++
+		name := t.text
+		if p.isOp("(") && t.kind == tokIdent {
+			fn, err := p.parseCall(strings.ToUpper(name))
+			if err != nil {
+				return nil, err
+			}
+			from.fn = fn
+			break
+		}
+		// Qualified names (db.table, master..sysdatabases) resolve to the
+		// last part.
+		for p.acceptOp(".") {
+			p.acceptOp(".")
+			t := p.next()
+			if t.kind != tokIdent && t.kind != tokQuotedIdent {
+				p.i--
+				return nil, p.fail()
+			}
+			name = t.text
+		}
+		from.table = name
+	default:
+		return nil, p.fail()
+	}
+	alias, _, err := p.parseAlias()
+	if err != nil {
+		return nil, err
+	}
+	from.alias = alias
+	return from, nil
+}
+
+// parseExpr parses a full expression (lowest precedence: OR).
+func (p *parser) parseExpr() (expr, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") || (p.d.mysqlLike() && p.acceptOp("||")) {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: "OR", l: left, r: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: "AND", l: left, r: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (expr, error) {
+	if p.acceptKeyword("NOT") {
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{op: "NOT", x: x}, nil
+	}
+	return p.parsePredicate()
+}
+
+// comparisons are the binary comparison operators.
+var comparisons = map[string]bool{"=": true, "<>": true, "!=": true, "<": true, ">": true, "<=": true, ">=": true, "<=>": true}
+
+func (p *parser) parsePredicate() (expr, error) {
+	left, err := p.parseBitAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind == tokOp && comparisons[t.text] {
+			p.i++
+			right, err := p.parseBitAnd()
+			if err != nil {
+				return nil, err
+			}
+			left = &binaryExpr{op: t.text, l: left, r: right}
+			continue
+		}
+
+		save := p.i
+		not := p.acceptKeyword("NOT")
+		switch {
+		case p.isKeyword("LIKE"), p.isKeyword("ILIKE") && p.d == PostgreSQL:
+			fold := p.d != PostgreSQL || p.isKeyword("ILIKE")
+			p.i++
+			pattern, err := p.parseBitAnd()
+			if err != nil {
+				return nil, err
+			}
+			left = &likeExpr{x: left, pattern: pattern, not: not, fold: fold}
+		case p.acceptKeyword("IN"):
+			in, err := p.parseIn(left, not)
+			if err != nil {
+				return nil, err
+			}
+			left = in
+		case p.acceptKeyword("BETWEEN"):
+			lo, err := p.parseBitAnd()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectKeyword("AND"); err != nil {
+				return nil, err
+			}
+			hi, err := p.parseBitAnd()
+			if err != nil {
+				return nil, err
+			}
+			left = &betweenExpr{x: left, lo: lo, hi: hi, not: not}
+		case !not && p.acceptKeyword("IS"):
+			isNot := p.acceptKeyword("NOT")
+			if err := p.expectKeyword("NULL"); err != nil {
+				return nil, err
+			}
+			left = &isNullExpr{x: left, not: isNot}
+		default:
+			p.i = save
+			return left, nil
+		}
+	}
+}
+
+func (p *parser) parseIn(x expr, not bool) (expr, error) {
+	if err := p.expectOp("("); err != nil {
+		return nil, err
+	}
+	in := &inExpr{x: x, not: not}
+	if p.isKeyword("SELECT") {
+		sub, err := p.parseQuery()
+		if err != nil {
+			return nil, err
+		}
+		in.sub = sub
+	} else {
+		for {
+			item, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			in.list = append(in.list, item)
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+	}
+	if err := p.expectOp(")"); err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+// parseBitAnd parses bitwise AND, which binds looser than arithmetic and
+// tighter than comparisons, as in MySQL and PostgreSQL.
+func (p *parser) parseBitAnd() (expr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptOp("&") {
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: "&", l: left, r: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAdditive() (expr, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var op string
+		switch {
+		case p.acceptOp("+"):
+			op = "+"
+		case p.acceptOp("-"):
+			op = "-"
+		case (p.d == PostgreSQL || p.d == Oracle) && p.acceptOp("||"):
+			op = "CONCAT"
+		default:
+			return left, nil
+		}
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: op, l: left, r: right}
+	}
+}
+
+func (p *parser) parseMultiplicative() (expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var op string
+		switch {
+		case p.acceptOp("*"):
+			op = "*"
+		case p.acceptOp("/"):
+			op = "/"
+		case p.acceptOp("%"), p.d.mysqlLike() && p.acceptKeyword("MOD"):
+			op = "%"
+		case p.d.mysqlLike() && p.acceptKeyword("DIV"):
+			op = "DIV"
+		default:
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: op, l: left, r: right}
+	}
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	if p.isOp("-") || p.isOp("+") {
+		op := p.next().text
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if op == "+" {
+			return x, nil
+		}
+		return &unaryExpr{op: "-", x: x}, nil
+	}
+
+	x, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptOp("::") {
+		typ, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		x = &castExpr{x: x, typ: typ}
+	}
+	return x, nil
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokNumber:
+		p.i++
+		if !strings.Contains(t.text, ".") {
+			if n, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+				return &literal{v: n}, nil
+			}
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			p.i--
+			return nil, p.fail()
+		}
+		return &literal{v: f}, nil
+
+	case tokString:
+		p.i++
+		return &literal{v: t.text}, nil
+
+	case tokVariable:
+		p.i++
+		return &variable{name: t.text}, nil
+
+	case tokQuotedIdent:
+		p.i++
+		return p.parseColumn(t.text)
+
+	case tokOp:
+		if t.text != "(" {
+			return nil, p.fail()
+		}
+		p.i++
+		var x expr
+		if p.isKeyword("SELECT") {
+			q, err := p.parseQuery()
+			if err != nil {
+				return nil, err
+			}
+			x = &subqueryExpr{q: q}
+		} else {
+			var err error
+			if x, err = p.parseExpr(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, err
+		}
+		return x, nil
+
+	case tokIdent:
+		return p.parseKeywordOrName()
+	}
+	return nil, p.fail()
+}
+
+func (p *parser) parseKeywordOrName() (expr, error) {
+	t := p.next()
+	name := strings.ToUpper(t.text)
+	call := p.isOp("(")
+
+	switch {
+	case name == "NULL":
+		return &literal{v: nil}, nil
+	case name == "TRUE":
+		return &literal{v: int64(1)}, nil
+	case name == "FALSE":
+		return &literal{v: int64(0)}, nil
+	case name == "CASE":
+		return p.parseCase()
+	case name == "EXISTS" && call:
+		p.i++
+		q, err := p.parseQuery()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, err
+		}
+		return &existsExpr{q: q}, nil
+	case name == "CAST" && call:
+		p.i++
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("AS"); err != nil {
+			return nil, err
+		}
+		typ, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, err
+		}
+		return &castExpr{x: x, typ: typ}, nil
+	case name == "CONVERT" && call:
+		return p.parseConvert()
+	case name == "SUBSTRING" && call:
+		return p.parseSubstring()
+	case (name == "IF" && p.d.mysqlLike() || name == "IIF" && (p.d == MSSQL || p.d == Generic)) && call:
+		fn, err := p.parseCall(name)
+		if err != nil {
+			return nil, err
+		}
+		if len(fn.args) != 3 {
+			return nil, paramCount(p.d, name)
+		}
+		return &caseExpr{whens: []whenClause{{cond: fn.args[0], then: fn.args[1]}}, els: fn.args[2]}, nil
+	case p.d == Oracle && p.isOp("."):
+		if fn, ok, err := p.parsePackageCall(name); ok || err != nil {
+			return fn, err
+		}
+	case call:
+		return p.parseCall(name)
+	case niladic[name] && !(name == "USER" && (p.d == MySQL || p.d == MariaDB)),
+		oracleNiladic[name] && p.d == Oracle:
+		return &funcCall{name: name}, nil
+	case reserved[name]:
+		p.i--
+		return nil, p.fail()
+	}
+	return p.parseColumn(t.text)
+}
+
+// parsePackageCall parses the rest of a call to an Oracle package function
+// such as UTL_INADDR.GET_HOST_NAME(...), whose first name part was pkg. It
+// reports false, consuming nothing, for a qualified column reference.
+func (p *parser) parsePackageCall(pkg string) (expr, bool, error) {
+	save := p.i
+	name := pkg
+	for p.isOp(".") && p.toks[p.i+1].kind == tokIdent {
+		name += "." + strings.ToUpper(p.toks[p.i+1].text)
+		p.i += 2
+	}
+	switch {
+	case p.isOp("(") && name != pkg:
+		fn, err := p.parseCall(name)
+		return fn, true, err
+	case oracleNiladic[name]:
+		return &funcCall{name: name}, true, nil
+	}
+	p.i = save
+	return nil, false, nil
+}
+
+// parseColumn parses the rest of a possibly qualified column reference.
+func (p *parser) parseColumn(first string) (expr, error) {
+	if !p.acceptOp(".") {
+		return &columnRef{name: first}, nil
+	}
+	t := p.next()
+	if t.kind != tokIdent && t.kind != tokQuotedIdent {
+		p.i--
+		return nil, p.fail()
+	}
+	return &columnRef{table: first, name: t.text}, nil
+}
+
+// parseCall parses an argument list; the current token is "(".
+func (p *parser) parseCall(name string) (*funcCall, error) {
+	if err := p.expectOp("("); err != nil {
+		return nil, err
+	}
+	fn := &funcCall{name: name}
+	if p.acceptOp(")") {
+		return fn, nil
+	}
+	if p.acceptOp("*") {
+		fn.star = true
+		return fn, p.expectOp(")")
+	}
+	p.acceptKeyword("DISTINCT")
+	for {
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		fn.args = append(fn.args, x)
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+	if name == "GROUP_CONCAT" && p.acceptKeyword("SEPARATOR") {
+		sep, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		fn.sep = sep
+	}
+	return fn, p.expectOp(")")
+}
+
+// parseSubstring accepts both SUBSTRING(s, pos, len) and the standard
+// SUBSTRING(s FROM pos FOR len).
+func (p *parser) parseSubstring() (expr, error) {
+	p.i++ // (
+	s, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	fn := &funcCall{name: "SUBSTRING", args: []expr{s}}
+	fromFor := p.acceptKeyword("FROM")
+	if fromFor || p.acceptOp(",") {
+		pos, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		fn.args = append(fn.args, pos)
+		if (fromFor && p.acceptKeyword("FOR")) || (!fromFor && p.acceptOp(",")) {
+			n, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			fn.args = append(fn.args, n)
+		}
+	}
+	return fn, p.expectOp(")")
+}
+
+// parseConvert parses CONVERT(type, expr) (MSSQL) or CONVERT(expr, type)
+// (MySQL).
+func (p *parser) parseConvert() (expr, error) {
+	p.i++ // (
+	if p.d == MSSQL || p.d == Generic {
+		save := p.i
+		if typ, err := p.parseType(); err == nil && p.acceptOp(",") {
+			x, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			return &castExpr{x: x, typ: typ}, p.expectOp(")")
+		}
+		p.i = save
+	}
+	if p.d == MSSQL {
+		return nil, p.fail()
+	}
+	x, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectOp(","); err != nil {
+		return nil, err
+	}
+	typ, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	return &castExpr{x: x, typ: typ}, p.expectOp(")")
+}
+
+// typeNames maps SQL type names to the value type a cast produces.
+var typeNames = map[string]string{
+	"INT": "int", "INTEGER": "int", "SIGNED": "int", "UNSIGNED": "int",
+	"BIGINT": "int", "SMALLINT": "int", "TINYINT": "int", "INT4": "int", "INT8": "int",
+	"CHAR": "text", "VARCHAR": "text", "NCHAR": "text", "NVARCHAR": "text",
+	"TEXT": "text", "CHARACTER": "text", "BINARY": "text", "VARCHAR2": "text",
+	"DECIMAL": "numeric", "NUMERIC": "numeric", "FLOAT": "float", "REAL": "float", "DOUBLE": "float",
+}
+
+// parseType parses a type name with optional modifiers, e.g. SIGNED
+// INTEGER, VARCHAR(32), NVARCHAR(MAX), DOUBLE PRECISION.
+func (p *parser) parseType() (string, error) {
+	t := p.peek()
+	typ, ok := typeNames[strings.ToUpper(t.text)]
+	if t.kind != tokIdent || !ok {
+		return "", p.fail()
+	}
+	p.i++
+	for _, kw := range []string{"INTEGER", "INT", "PRECISION", "VARYING"} {
+		if p.acceptKeyword(kw) {
+			break
+		}
+	}
+	if p.acceptOp("(") {
+		for {
+			if t := p.next(); t.kind != tokNumber && !(t.kind == tokIdent && strings.EqualFold(t.text, "MAX")) {
+				p.i--
+				return "", p.fail()
+			}
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+		if err := p.expectOp(")"); err != nil {
+			return "", err
+		}
+	}
+	return typ, nil
+}
+
+func (p *parser) parseCase() (expr, error) {
+	c := &caseExpr{}
+	if !p.isKeyword("WHEN") {
+		operand, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		c.operand = operand
+	}
+	for p.acceptKeyword("WHEN") {
+		cond, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("THEN"); err != nil {
+			return nil, err
+		}
+		then, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		c.whens = append(c.whens, whenClause{cond: cond, then: then})
+	}
+	if len(c.whens) == 0 {
+		return nil, p.fail()
+	}
+	if p.acceptKeyword("ELSE") {
+		els, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		c.els = els
+	}
+	return c, p.expectKeyword("END")
+}
@@ -0,0 +1,241 @@
+// Package sqlmock is a small SQL evaluator for the mock vulnerable server.
+//
+// The mock endpoints in testutil splice user input into a SQL statement the
+// way a vulnerable application would, and run the statement here against an
+// in-memory fixture. Probes are therefore answered by evaluating what they
+// say -- nested SUBSTRING offsets, IF/CASE around SLEEP, ORDER BY positions,
+// UNION column counts, LIKE patterns -- rather than by matching payload
+// substrings.
+//
+// The supported subset is what injection probes use:
+//
+//   - SELECT [DISTINCT|TOP n] items [FROM table|function] [WHERE cond]
+//     [UNION [ALL] SELECT ...] [ORDER BY n|expr] [LIMIT n [OFFSET m]]
+//     [PROCEDURE ANALYSE(...)], with MySQL's LIMIT taking literals only
+//   - boolean logic with SQL NULL semantics, comparisons, LIKE, IN, BETWEEN,
+//     IS NULL, arithmetic, bitwise AND and string concatenation
+//   - scalar subqueries, EXISTS, CASE, IF/IIF, CAST, CONVERT and ::type
+//   - string, identity, XML (including PostgreSQL's query_to_xml), file
+//     reading, sleep, BENCHMARK
+//     and aggregate functions (see functions),
+//     including GROUP_CONCAT ... SEPARATOR and STRING_AGG
+//   - for MSSQL, stacked [IF cond] WAITFOR DELAY 'hh:mm:ss' statements after
+//     the query, which add to the sleep but not to the result
+//   - for Oracle, the mandatory FROM (dual), ROWNUM and the package
+//     functions probes call (UTL_INADDR, CTXSYS.DRITHSX.SN, DBMS_PIPE)
+//
+// Anything outside it is a syntax error, reported in the dialect's words so
+// that error pages look like the real thing. Sleep functions do not block:
+// the requested delay is returned in Result.Sleep for the caller to apply.
+package sqlmock
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect selects the lexical rules, functions and error messages of a DBMS.
+type Dialect int
+
+const (
+	// Generic lexes like MySQL but accepts every dialect's functions, for
+	// endpoints that must answer probes written for any DBMS.
+	Generic Dialect = iota
+	MySQL
+	PostgreSQL
+	MSSQL
+	// MariaDB is MySQL with MariaDB's error wording, its own functions
+	// and the Sequence engine's seq_N_to_M tables.
+	MariaDB
+	// Oracle requires FROM in every SELECT, concatenates with || and has
+	// the dual and v$version views.
+	Oracle
+)
+
+// String returns the DBMS name.
+func (d Dialect) String() string {
+	switch d {
+	case MySQL:
+		return "MySQL"
+	case PostgreSQL:
+		return "PostgreSQL"
+	case MSSQL:
+		return "MSSQL"
+	case MariaDB:
+		return "MariaDB"
+	case Oracle:
+		return "Oracle"
+	default:
+		return "Generic"
+	}
+}
+
+// mysqlLike reports whether d follows MySQL lexing and coercion rules.
+func (d Dialect) mysqlLike() bool { return d == Generic || d == MySQL || d == MariaDB }
+
+// Value is a SQL value: nil (NULL), int64, float64 or string. Comparisons
+// and other predicates yield int64 1 or 0.
+type Value = any
+
+// Table is a fixture table. Column names are matched case-insensitively.
+type Table struct {
+	Columns []string
+	Rows    [][]Value
+}
+
+// DB is a fixture database. The zero value is an empty Generic database.
+type DB struct {
+	Dialect Dialect
+	Tables  map[string]*Table
+
+	// Version, User, Database and Hostname answer the identity functions
+	// and variables of the dialect (VERSION(), @@version, CURRENT_USER,
+	// DB_NAME(), @@hostname, ...).
+	Version  string
+	User     string
+	Database string
+	Hostname string
+
+	// MaxSleep caps the total delay a query can request. Zero means no cap.
+	MaxSleep time.Duration
+
+	// BenchmarkRate is how many BENCHMARK iterations take a second. Zero
+	// means 10,000,000.
+	BenchmarkRate int
+
+	// Files are the server's files by path, for the file-reading
+	// functions (LOAD_FILE, pg_read_file, pg_read_binary_file). A path
+	// missing from Files reads as NULL, as a file MySQL cannot read does.
+	Files map[string]string
+
+	// GroupConcatMaxLen truncates GROUP_CONCAT results to that many bytes,
+	// like MySQL's group_concat_max_len. Zero means MySQL's default, 1024.
+	GroupConcatMaxLen int
+}
+
+// groupConcatMaxLen returns the GROUP_CONCAT length limit in effect.
+func (db *DB) groupConcatMaxLen() int {
+	if db.GroupConcatMaxLen > 0 {
+		return db.GroupConcatMaxLen
+	}
+	return 1024
+}
+
+// benchmarkRate returns the BENCHMARK iteration rate in effect.
+func (db *DB) benchmarkRate() int {
+	if db.BenchmarkRate > 0 {
+		return db.BenchmarkRate
+	}
+	return 10_000_000
+}
+
+// Result is the outcome of a query.
+type Result struct {
+	Columns []string
+	Rows    [][]Value
+
+	// Sleep is the total delay requested by sleep functions, capped at
+	// DB.MaxSleep.
+	Sleep time.Duration
+}
+
+// Query parses and runs sql. Errors are *Error values.
+func (db *DB) Query(sql string) (*Result, error) {
+	q, stacked, err := parse(sql, db.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	ev := &evaluator{db: db}
+	cols, rows, err := ev.runQuery(q, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range stacked {
+		if err := ev.runWait(w); err != nil {
+			return nil, err
+		}
+	}
+	sleep := ev.sleep
+	if db.MaxSleep > 0 && sleep > db.MaxSleep {
+		sleep = db.MaxSleep
+	}
+	return &Result{Columns: cols, Rows: rows, Sleep: sleep}, nil
+}
+
+// table looks up a fixture table case-insensitively. MariaDB databases
+// also have the Sequence engine's tables, Oracle databases dual and
+// v$version.
+func (db *DB) table(name string) (*Table, bool) {
+	if t, ok := db.Tables[name]; ok {
+		return t, true
+	}
+	for n, t := range db.Tables {
+		if strings.EqualFold(n, name) {
+			return t, true
+		}
+	}
+	switch db.Dialect {
+	case MariaDB:
+		return sequenceTable(name)
+	case Oracle:
+		return db.oracleView(name)
+	}
+	return nil, false
+}
+
+// oracleView returns the Oracle dictionary views probes select from: dual,
+// whose one row makes a SELECT of expressions return one row, and
+// v$version, whose banner is DB.Version.
+func (db *DB) oracleView(name string) (*Table, bool) {
+	switch strings.ToLower(name) {
+	case "dual":
+		return &Table{Columns: []string{"dummy"}, Rows: [][]Value{{"X"}}}, true
+	case "v$version":
+		return &Table{Columns: []string{"banner"}, Rows: [][]Value{{db.Version}}}, true
+	}
+	return nil, false
+}
+
+// seqPattern matches a Sequence engine table name.
+var seqPattern = regexp.MustCompile(`(?i)^seq_(\d+)_to_(\d+)$`)
+
+// maxSeqRows bounds the rows of a generated sequence table.
+const maxSeqRows = 10000
+
+// sequenceTable generates seq_N_to_M: one "seq" column counting from N to
+// M.
+func sequenceTable(name string) (*Table, bool) {
+	m := seqPattern.FindStringSubmatch(name)
+	if m == nil {
+		return nil, false
+	}
+	from, _ := strconv.ParseInt(m[1], 10, 64)
+	to, _ := strconv.ParseInt(m[2], 10, 64)
+	if to < from || to-from >= maxSeqRows {
+		return nil, false
+	}
+	t := &Table{Columns: []string{"seq"}}
+	for i := from; i <= to; i++ {
+		t.Rows = append(t.Rows, []Value{i})
+	}
+	return t, true
+}
+
+// Format renders a value the way a web page would show it: NULL as the
+// empty string and numbers without trailing zeros.
+func Format(v Value) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,777 @@
+package sqlmock
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestDB returns a small shop fixture for dialect d.
+func newTestDB(d Dialect) *DB {
+	return &DB{
+		Dialect:  d,
+		Version:  "8.0.32",
+		User:     "root@localhost",
+		Database: "shop",
+		Hostname: "db01",
+		MaxSleep: 2 * time.Second,
+		Files:    map[string]string{"/etc/hostname": "db01\n"},
+		Tables: map[string]*Table{
+			"products": {
+				Columns: []string{"id", "name", "price"},
+				Rows: [][]Value{
+					{int64(1), "Widget", 9.99},
+					{int64(2), "Wide gadget", 24.5},
+					{int64(3), "Sprocket", nil},
+				},
+			},
+			"users": {
+				Columns: []string{"id", "username", "password"},
+				Rows: [][]Value{
+					{int64(1), "admin", "s3cret"},
+					{int64(2), "guest", "guest"},
+				},
+			},
+		},
+	}
+}
+
+// queryRows runs sql and returns the formatted rows, one string per row
+// with columns joined by "|".
+func queryRows(t *testing.T, db *DB, sql string) []string {
+	t.Helper()
+	res, err := db.Query(sql)
+	if err != nil {
+		t.Fatalf("Query(%q): %v", sql, err)
+	}
+	rows := make([]string, len(res.Rows))
+	for i, row := range res.Rows {
+		cells := make([]string, len(row))
+		for j, v := range row {
+			cells[j] = Format(v)
+		}
+		rows[i] = strings.Join(cells, "|")
+	}
+	return rows
+}
+
+// scalar runs "SELECT <x>" and returns the formatted value.
+func scalar(t *testing.T, db *DB, x string) string {
+	t.Helper()
+	rows := queryRows(t, db, "SELECT "+x)
+	if len(rows) != 1 {
+		t.Fatalf("SELECT %s: %d rows, want 1", x, len(rows))
+	}
+	return rows[0]
+}
+
+// queryErr runs sql and returns the *Error it fails with.
+func queryErr(t *testing.T, db *DB, sql string) *Error {
+	t.Helper()
+	_, err := db.Query(sql)
+	if err == nil {
+		t.Fatalf("Query(%q): expected error", sql)
+	}
+	var qe *Error
+	if !errors.As(err, &qe) {
+		t.Fatalf("Query(%q): error %T is not *Error", sql, err)
+	}
+	return qe
+}
+
+func TestQuery_WhereConditions(t *testing.T) {
+	db := newTestDB(MySQL)
+	tests := []struct {
+		where string
+		want  int
+	}{
+		{"id=1", 1},
+		{"id=1 AND 1=1", 1},
+		{"id=1 AND 1=2", 0},
+		{"id=1 OR 1=1", 3},
+		{"id=1 AND NOT 1=2", 1},
+		{"id=1 AND 'a'='A'", 1}, // case-insensitive collation
+		{"id=1 AND '1'='1'", 1},
+		{"id=1 AND NULL", 0},
+		{"NOT (id=1 AND NULL)", 2}, // NULL AND TRUE is NULL, NOT NULL is NULL
+		{"price IS NULL", 1},
+		{"price IS NOT NULL AND price > 10", 1},
+		{"id IN (1, 3)", 2},
+		{"id NOT IN (1, NULL)", 0},
+		{"id BETWEEN 2 AND 3", 2},
+		{"id=(SELECT id FROM users WHERE username='guest')", 1},
+		{"EXISTS(SELECT 1 FROM users WHERE users.id=products.id)", 2},
+		{"id IN (SELECT id FROM users)", 2},
+		{"1 <=> 1 AND NULL <=> NULL", 3},
+		{"id='1abc'", 1}, // MySQL reads the leading number
+	}
+	for _, tt := range tests {
+		rows := queryRows(t, db, "SELECT name FROM products WHERE "+tt.where)
+		if len(rows) != tt.want {
+			t.Errorf("WHERE %s: %d rows %v, want %d", tt.where, len(rows), rows, tt.want)
+		}
+	}
+}
+
+func TestQuery_StringFunctions(t *testing.T) {
+	tests := []struct {
+		d    Dialect
+		expr string
+		want string
+	}{
+		{MySQL, "ASCII(SUBSTRING((@@version),1,1))", "56"},
+		{MySQL, "ASCII(SUBSTRING(@@version,3,1))>47", "1"},
+		{MySQL, "LENGTH((@@version))", "6"},
+		{MySQL, "SUBSTRING(SUBSTRING('abcdef',2,4),2,2)", "cd"},
+		{MySQL, "SUBSTRING('abcdef',-2)", "ef"},
+		{MySQL, "SUBSTRING('abcdef',0,2)", ""},
+		{MySQL, "MID('abcdef',2,1)", "b"},
+		{MySQL, "ORD(SUBSTRING(DATABASE(),1,1))", "115"},
+		{MySQL, "CONCAT(CHAR(126),(@@version),CHAR(126))", "~8.0.32~"},
+		{MySQL, "CONCAT('a',NULL)", ""},
+		{MySQL, "CONCAT(0x7e,USER())", "~root@localhost"},
+		{MySQL, "LENGTH('héllo')", "6"},
+		{MySQL, "CHAR_LENGTH('héllo')", "5"},
+		{MySQL, "IFNULL(NULL,'x')", "x"},
+		{MySQL, "UPPER(LOWER('MiXeD'))", "MIXED"},
+		{MySQL, "CONV(10,10,36)='a'", "1"},
+		{MySQL, "CONV('ff',16,2)", "11111111"},
+		{MySQL, "(SELECT COUNT(*) FROM products)", "3"},
+		{MySQL, "(SELECT username FROM users ORDER BY id DESC LIMIT 1)", "guest"},
+		{MySQL, "(SELECT username FROM users LIMIT 1,1)", "guest"},
+		{MySQL, "HEX('a|b')", "617C62"},
+		{MySQL, "HEX(255)", "FF"},
+		{MySQL, "HEX(LOAD_FILE('/etc/hostname'))", "646230310A"},
+		{MySQL, "LOAD_FILE('/etc/shadow') IS NULL", "1"},
+		{PostgreSQL, "chr(126)||(version())||chr(126)", "~8.0.32~"},
+		{PostgreSQL, "SUBSTRING('abcdef' FROM 2 FOR 3)", "bcd"},
+		{PostgreSQL, "SUBSTRING('abcdef',0,2)", "a"},
+		{PostgreSQL, "LENGTH('héllo')", "5"},
+		{PostgreSQL, "CURRENT_USER", "root@localhost"},
+		{PostgreSQL, "'1'::int + 1", "2"},
+		{PostgreSQL, "CONCAT('a',NULL,'b')", "ab"},
+		{PostgreSQL, "CURRENT_SETTING('server_version')", "8.0.32"},
+		{PostgreSQL, "PG_SLEEP(0) IS NOT NULL", "1"},
+		{PostgreSQL, "MD5('x')", "9dd4e461268c8034f5c8564e155c67a6"},
+		{PostgreSQL, "encode(pg_read_binary_file('/etc/hostname'),'hex')", "646230310a"},
+		{PostgreSQL, "pg_read_file('/etc/hostname')", "db01\n"},
+		{MSSQL, "CHAR(126)+CAST((@@version) AS NVARCHAR(MAX))+CHAR(126)", "~8.0.32~"},
+		{MSSQL, "LEN('ab  ')", "2"},
+		{MSSQL, "DB_NAME()", "shop"},
+		{MSSQL, "SYSTEM_USER", "root@localhost"},
+		{MSSQL, "IIF(1=1,'y','n')", "y"},
+		{MSSQL, "@@SERVERNAME", "db01"},
+		{MSSQL, "(SELECT TOP 1 username FROM users ORDER BY id DESC)", "guest"},
+		{Generic, "ASCII(SUBSTRING(VERSION(),1,1))", "56"},
+	}
+	for _, tt := range tests {
+		if got := scalar(t, newTestDB(tt.d), tt.expr); got != tt.want {
+			t.Errorf("%s: SELECT %s = %q, want %q", tt.d, tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestQuery_Arithmetic(t *testing.T) {
+	tests := []struct {
+		d    Dialect
+		expr string
+		want string
+	}{
+		{MySQL, "1+2*3", "7"},
+		{MySQL, "(1+2)*3", "9"},
+		{MySQL, "7/2", "3.5"},
+		{MySQL, "7 DIV 2", "3"},
+		{MySQL, "7 MOD 4", "3"},
+		{MySQL, "-(-3)", "3"},
+		{MySQL, "1/0", ""},
+		{MySQL, "'8.0.32'+0", "8"},
+		{PostgreSQL, "7/2", "3"},
+		{MSSQL, "'a'+'b'", "ab"},
+		{MySQL, "CAST('12abc' AS SIGNED)", "12"},
+		{MySQL, "CONVERT('7', UNSIGNED INTEGER)", "7"},
+		{MSSQL, "CONVERT(INT, '42')", "42"},
+		{PostgreSQL, "CAST(2.6 AS INT)", "3"},
+		{MySQL, "1e3+0.5", "1000.5"},
+		{MySQL, "99999999999999999999", "100000000000000000000"},
+		{MySQL, "ASCII('W')&64", "64"},
+		{PostgreSQL, "ASCII('W')&32", "0"},
+		{MSSQL, "(ASCII('W')&1)>0", "1"},
+		{MySQL, "1+2&3", "3"},
+		{MySQL, "6&3=2", "1"},
+	}
+	for _, tt := range tests {
+		if got := scalar(t, newTestDB(tt.d), tt.expr); got != tt.want {
+			t.Errorf("%s: SELECT %s = %q, want %q", tt.d, tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestQuery_Conditionals(t *testing.T) {
+	db := newTestDB(MySQL)
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"IF(1=1,'a','b')", "a"},
+		{"IF(NULL,'a','b')", "b"},
+		{"CASE WHEN 1=2 THEN 'a' WHEN 2=2 THEN 'b' ELSE 'c' END", "b"},
+		{"CASE 3 WHEN 1 THEN 'one' WHEN 3 THEN 'three' END", "three"},
+		{"CASE WHEN 1=2 THEN 'a' END", ""},
+	}
+	for _, tt := range tests {
+		if got := scalar(t, db, tt.expr); got != tt.want {
+			t.Errorf("SELECT %s = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestQuery_Sleep(t *testing.T) {
+	tests := []struct {
+		d    Dialect
+		sql  string
+		want time.Duration
+	}{
+		{MySQL, "SELECT id FROM products WHERE id=1 AND IF(1=1,SLEEP(1),0)", time.Second},
+		{MySQL, "SELECT id FROM products WHERE id=1 AND IF(1=2,SLEEP(1),0)", 0},
+		{MySQL, "SELECT id FROM products WHERE id=1 AND IF(ASCII(SUBSTRING(@@version,1,1))>55,SLEEP(1),0)", time.Second},
+		{MySQL, "SELECT id FROM products WHERE id=1 AND SLEEP(0.5)", 500 * time.Millisecond},
+		// The guard short-circuits: rows other than id=1 never sleep.
+		{MySQL, "SELECT id FROM products WHERE id=2 AND SLEEP(1)", time.Second},
+		{MySQL, "SELECT id FROM products WHERE 1=2 AND SLEEP(1)", 0},
+		// Capped at MaxSleep.
+		{MySQL, "SELECT id FROM products WHERE SLEEP(5)", 2 * time.Second},
+		{MySQL, "SELECT SLEEP(-1)", 0},
+		// BENCHMARK runs 10,000,000 iterations a second.
+		{MySQL, "SELECT id FROM products WHERE id=1 AND IF(1=1,BENCHMARK(5000000,MD5('x')),0)", 500 * time.Millisecond},
+		{MySQL, "SELECT id FROM products WHERE id=1 AND IF(1=2,BENCHMARK(5000000,MD5('x')),0)", 0},
+		{PostgreSQL, "SELECT id FROM products WHERE id=1 AND 1=(CASE WHEN (1=1) THEN (SELECT 1 FROM PG_SLEEP(1)) ELSE 1 END)", time.Second},
+		{PostgreSQL, "SELECT id FROM products WHERE id=1 AND 1=(CASE WHEN (1=2) THEN (SELECT 1 FROM PG_SLEEP(1)) ELSE 1 END)", 0},
+		{Generic, "SELECT 1 FROM PG_SLEEP(1) WHERE IF(1=1,SLEEP(1),0)=0", 2 * time.Second},
+		// MSSQL stacks WAITFOR DELAY after the query.
+		{MSSQL, "SELECT id FROM products WHERE id=1; IF (1=1) WAITFOR DELAY '0:0:1' -- -", time.Second},
+		{MSSQL, "SELECT id FROM products WHERE id=1; IF (1=2) WAITFOR DELAY '0:0:1' -- -", 0},
+		{MSSQL, "SELECT id FROM products WHERE id=1; IF (LEN(@@version)>3) WAITFOR DELAY '0:00:00.5';", 500 * time.Millisecond},
+		{MSSQL, "SELECT id FROM products WHERE 1=2; WAITFOR DELAY '00:01'", 2 * time.Second},
+	}
+	for _, tt := range tests {
+		res, err := newTestDB(tt.d).Query(tt.sql)
+		if err != nil {
+			t.Errorf("%s: Query(%q): %v", tt.d, tt.sql, err)
+			continue
+		}
+		if res.Sleep != tt.want {
+			t.Errorf("%s: Query(%q).Sleep = %v, want %v", tt.d, tt.sql, res.Sleep, tt.want)
+		}
+	}
+}
+
+func TestQuery_OrderByPosition(t *testing.T) {
+	for _, d := range []Dialect{MySQL, PostgreSQL, MSSQL} {
+		db := newTestDB(d)
+		for n := 1; n <= 3; n++ {
+			sql := "SELECT id, name, price FROM products WHERE id=1 ORDER BY " + string(rune('0'+n))
+			if _, err := db.Query(sql); err != nil {
+				t.Errorf("%s: ORDER BY %d: %v", d, n, err)
+			}
+		}
+		e := queryErr(t, db, "SELECT id, name, price FROM products WHERE id=1 ORDER BY 4")
+		if e.Kind != UnknownColumn || e.Near != "4" {
+			t.Errorf("%s: ORDER BY 4: kind %v near %q, want UnknownColumn near 4", d, e.Kind, e.Near)
+		}
+		// Positions are checked even when nothing matches.
+		if e := queryErr(t, db, "SELECT id FROM products WHERE 1=2 ORDER BY 2"); e.Kind != UnknownColumn {
+			t.Errorf("%s: empty ORDER BY 2: kind %v", d, e.Kind)
+		}
+	}
+
+	if got := queryErr(t, newTestDB(MySQL), "SELECT id FROM products ORDER BY 3").Error(); got != "Unknown column '3' in 'order clause'" {
+		t.Errorf("MySQL message = %q", got)
+	}
+
+	rows := queryRows(t, newTestDB(MySQL), "SELECT id, name FROM products ORDER BY 2 DESC")
+	if strings.Join(rows, ",") != "1|Widget,2|Wide gadget,3|Sprocket" {
+		t.Errorf("ORDER BY 2 DESC = %v", rows)
+	}
+	rows = queryRows(t, newTestDB(MySQL), "SELECT id, price FROM products ORDER BY price")
+	if strings.Join(rows, ",") != "3|,1|9.99,2|24.5" {
+		t.Errorf("ORDER BY price = %v, want NULL first", rows)
+	}
+}
+
+func TestQuery_Union(t *testing.T) {
+	db := newTestDB(MySQL)
+	rows := queryRows(t, db, "SELECT id, name FROM products WHERE id=1 UNION SELECT NULL,CONCAT(0x7e,(SELECT password FROM users LIMIT 1),0x7e)")
+	if strings.Join(rows, ",") != "1|Widget,|~s3cret~" {
+		t.Errorf("UNION rows = %v", rows)
+	}
+
+	rows = queryRows(t, db, "SELECT 1 UNION SELECT 1 UNION ALL SELECT 1")
+	if len(rows) != 2 {
+		t.Errorf("UNION/UNION ALL rows = %v, want 2", rows)
+	}
+
+	for _, d := range []Dialect{MySQL, PostgreSQL, MSSQL} {
+		e := queryErr(t, newTestDB(d), "SELECT id, name FROM products WHERE id=1 UNION SELECT NULL")
+		if e.Kind != ColumnCountMismatch {
+			t.Errorf("%s: UNION with 1 column: kind %v, want ColumnCountMismatch", d, e.Kind)
+		}
+	}
+
+	rows = queryRows(t, db, "SELECT id, name FROM products WHERE id=-1 UNION ALL SELECT id, username FROM users ORDER BY 1 DESC LIMIT 1")
+	if strings.Join(rows, ",") != "2|guest" {
+		t.Errorf("UNION ORDER BY LIMIT = %v", rows)
+	}
+}
+
+func TestQuery_LimitArguments(t *testing.T) {
+	pg := newTestDB(PostgreSQL)
+	for sql, want := range map[string]int{
+		"SELECT id FROM products LIMIT (CASE WHEN (1=1) THEN 2 WHEN 2=0 THEN 1 ELSE 0 END)": 2,
+		"SELECT id FROM products LIMIT (CASE WHEN (1=2) THEN 2 WHEN 2=0 THEN 1 ELSE 0 END)": 0,
+		"SELECT id FROM products LIMIT 3 OFFSET 1+1":                                        1,
+	} {
+		if rows := queryRows(t, pg, sql); len(rows) != want {
+			t.Errorf("%s: %d rows, want %d", sql, len(rows), want)
+		}
+	}
+	e := queryErr(t, pg, "SELECT id FROM products LIMIT 2 AND 1=1")
+	if e.Kind != TypeMismatch || e.Error() != "argument of LIMIT must be type bigint, not type boolean" {
+		t.Errorf("boolean LIMIT: %v (kind %v)", e, e.Kind)
+	}
+	e = queryErr(t, pg, "SELECT id FROM products LIMIT 2 OFFSET 0=0")
+	if e.Error() != "argument of OFFSET must be type bigint, not type boolean" {
+		t.Errorf("boolean OFFSET: %v", e)
+	}
+	e = queryErr(t, pg, "SELECT id FROM products LIMIT CAST((version()) AS INT)")
+	if e.Kind != ConversionError || e.Near != "8.0.32" {
+		t.Errorf("cast in LIMIT: %v", e)
+	}
+
+	// MySQL takes integer literals only, so no condition gets in.
+	my := newTestDB(MySQL)
+	if rows := queryRows(t, my, "SELECT id FROM products LIMIT 1,2"); len(rows) != 2 || rows[0] != "2" {
+		t.Errorf("LIMIT 1,2 = %v", rows)
+	}
+	for _, sql := range []string{
+		"SELECT id FROM products LIMIT 2 AND 1=1",
+		"SELECT id FROM products LIMIT (CASE WHEN (1=1) THEN 2 ELSE 0 END)",
+		"SELECT id FROM products LIMIT 1+1",
+	} {
+		if e := queryErr(t, my, sql); e.Kind != SyntaxError {
+			t.Errorf("%s: %v, want a syntax error", sql, e)
+		}
+	}
+	if rows := queryRows(t, &DB{Dialect: Generic, Tables: my.Tables}, "SELECT id FROM products LIMIT 1+1"); len(rows) != 2 {
+		t.Errorf("Generic LIMIT 1+1 = %v", rows)
+	}
+}
+
+func TestQuery_ProcedureAnalyse(t *testing.T) {
+	const sql = "SELECT id FROM products LIMIT 2 PROCEDURE ANALYSE(extractvalue(1,concat(0x7e,(@@version))),1)"
+	legacy := newTestDB(MySQL)
+	legacy.Version = "5.7.44"
+	if e := queryErr(t, legacy, sql); e.Kind != XPathError || e.Error() != "XPATH syntax error: '~5.7.44'" {
+		t.Errorf("MySQL 5.7: %v", e)
+	}
+	if rows := queryRows(t, legacy, "SELECT id FROM products LIMIT 2 PROCEDURE ANALYSE(1,1)"); len(rows) != 2 {
+		t.Errorf("PROCEDURE ANALYSE(1,1) = %v", rows)
+	}
+
+	e := queryErr(t, newTestDB(MySQL), sql)
+	if e.Kind != SyntaxError || !strings.HasPrefix(e.Near, "PROCEDURE ANALYSE(") {
+		t.Errorf("MySQL 8.0: %v", e)
+	}
+	if e := queryErr(t, newTestDB(PostgreSQL), sql); e.Kind != SyntaxError {
+		t.Errorf("PostgreSQL: %v", e)
+	}
+}
+
+func TestQuery_Like(t *testing.T) {
+	tests := []struct {
+		d       Dialect
+		pattern string
+		want    string
+	}{
+		{MySQL, "'%wid%'", "Widget,Wide gadget"},
+		{MySQL, "'W_dget'", "Widget"},
+		{MySQL, "'%'", "Widget,Wide gadget,Sprocket"},
+		{MySQL, "'%et'", "Widget,Wide gadget,Sprocket"},
+		{MySQL, "'_id%'", "Widget,Wide gadget"},
+		{MySQL, "'%z%'", ""},
+		{MySQL, "'100\\%'", ""},
+		{PostgreSQL, "'%wid%'", ""}, // case-sensitive
+		{PostgreSQL, "'%Wid%'", "Widget,Wide gadget"},
+	}
+	for _, tt := range tests {
+		rows := queryRows(t, newTestDB(tt.d), "SELECT name FROM products WHERE name LIKE "+tt.pattern)
+		if got := strings.Join(rows, ","); got != tt.want {
+			t.Errorf("%s: LIKE %s = %q, want %q", tt.d, tt.pattern, got, tt.want)
+		}
+	}
+	if got := scalar(t, newTestDB(PostgreSQL), "'Widget' ILIKE '%wid%'"); got != "1" {
+		t.Errorf("ILIKE = %q", got)
+	}
+	if got := scalar(t, newTestDB(MySQL), "'a%c' LIKE 'a\\%c' AND 'abc' NOT LIKE 'a\\%c'"); got != "1" {
+		t.Errorf("escaped %% = %q", got)
+	}
+}
+
+func TestLikeMatch(t *testing.T) {
+	tests := []struct {
+		s, p string
+		want bool
+	}{
+		{"", "", true},
+		{"", "%", true},
+		{"abc", "a%c", true},
+		{"abc", "%b%", true},
+		{"abc", "a_c", true},
+		{"abc", "a_", false},
+		{"aXbXc", "a%b%c", true},
+		{"abcabd", "%abd", true},
+		{"a_c", "a\\_c", true},
+		{"abc", "a\\_c", false},
+	}
+	for _, tt := range tests {
+		if got := likeMatch([]rune(tt.s), []rune(tt.p)); got != tt.want {
+			t.Errorf("likeMatch(%q, %q) = %v, want %v", tt.s, tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestQuery_Aggregates(t *testing.T) {
+	db := newTestDB(MySQL)
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"SELECT COUNT(*) FROM products", "3"},
+		{"SELECT COUNT(price) FROM products", "2"},
+		{"SELECT COUNT(*) FROM products WHERE 1=2", "0"},
+		{"SELECT SUM(id), MIN(name), MAX(id) FROM products", "6|Sprocket|3"},
+		{"SELECT AVG(id) FROM products", "2"},
+		{"SELECT SUM(id) FROM products WHERE 1=2", ""},
+		{"SELECT COUNT(*) FROM products WHERE name LIKE '%w%' AND 1=1", "2"},
+		{"SELECT COUNT(*)+1 FROM users", "3"},
+	}
+	for _, tt := range tests {
+		rows := queryRows(t, db, tt.sql)
+		if got := strings.Join(rows, ","); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestQuery_StringAggregates(t *testing.T) {
+	tests := []struct {
+		d    Dialect
+		sql  string
+		want string
+	}{
+		{MySQL, "SELECT GROUP_CONCAT(name) FROM products", "Widget,Wide gadget,Sprocket"},
+		{MySQL, "SELECT GROUP_CONCAT(username SEPARATOR 0x7e) FROM users", "admin~guest"},
+		{MySQL, "SELECT GROUP_CONCAT(id,':',price SEPARATOR ';') FROM products", "1:9.99;2:24.5"},
+		{MySQL, "SELECT GROUP_CONCAT(name) FROM products WHERE 1=2", ""},
+		{MySQL, "SELECT CONCAT(CHAR(126),(SELECT GROUP_CONCAT(username SEPARATOR 0x7e) FROM users),CHAR(126))", "~admin~guest~"},
+		{MariaDB, "SELECT GROUP_CONCAT(username) FROM users", "admin,guest"},
+		{PostgreSQL, "SELECT string_agg(username, chr(126)) FROM users", "admin~guest"},
+		{PostgreSQL, "SELECT string_agg((price)::text, ',') FROM products", "9.99,24.5"},
+		{MSSQL, "SELECT STRING_AGG(CAST(id AS NVARCHAR(MAX)), CHAR(126)) FROM users", "1~2"},
+	}
+	for _, tt := range tests {
+		rows := queryRows(t, newTestDB(tt.d), tt.sql)
+		if got := strings.Join(rows, ","); got != tt.want {
+			t.Errorf("%s: %s = %q, want %q", tt.d, tt.sql, got, tt.want)
+		}
+	}
+
+	db := newTestDB(MySQL)
+	db.GroupConcatMaxLen = 8
+	if got := scalar(t, db, "(SELECT GROUP_CONCAT(name) FROM products)"); got != "Widget,W" {
+		t.Errorf("GROUP_CONCAT with group_concat_max_len 8 = %q, want %q", got, "Widget,W")
+	}
+
+	for _, tt := range []struct {
+		d   Dialect
+		sql string
+	}{
+		{PostgreSQL, "SELECT GROUP_CONCAT(name) FROM products"},
+		{MySQL, "SELECT STRING_AGG(name, ',') FROM products"},
+		{MSSQL, "SELECT STRING_AGG(name) FROM products"},
+	} {
+		if e := queryErr(t, newTestDB(tt.d), tt.sql); e.Kind != UnknownFunction {
+			t.Errorf("%s: %s: error kind %v, want UnknownFunction", tt.d, tt.sql, e.Kind)
+		}
+	}
+}
+
+func TestQuery_QueryToXML(t *testing.T) {
+	db := newTestDB(PostgreSQL)
+	tests := []struct {
+		x    string
+		want string
+	}{
+		{"query_to_xml($$SELECT (version()) AS v$$,true,true,$$$$)",
+			"<row xmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\">\n\n  <v>8.0.32</v>\n</row>\n\n"},
+		{"query_to_xml('SELECT name, price FROM products WHERE id>2',true,false,'')",
+			"<table xmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\">\n\n<row>\n  <name>Sprocket</name>\n  <price xsi:nil=\"true\"/>\n</row>\n\n</table>\n"},
+		{"query_to_xml($q$SELECT 'a<b' AS v$q$,false,true,'')",
+			"<row xmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\">\n\n  <v>a&lt;b</v>\n</row>\n\n"},
+	}
+	for _, tt := range tests {
+		if got := scalar(t, db, tt.x); got != tt.want {
+			t.Errorf("SELECT %s = %q, want %q", tt.x, got, tt.want)
+		}
+	}
+
+	e := queryErr(t, db, "SELECT CAST(query_to_xml($$SELECT (version()) AS v$$,true,true,$$$$)::text AS INT)")
+	if e.Kind != ConversionError || !strings.Contains(e.Error(), "<v>8.0.32</v>") {
+		t.Errorf("cast of query_to_xml: %v, want a conversion error quoting the XML", e)
+	}
+	if e := queryErr(t, newTestDB(MySQL), "SELECT query_to_xml('SELECT 1',true,true,'')"); e.Kind != UnknownFunction {
+		t.Errorf("query_to_xml on MySQL: error kind %v, want UnknownFunction", e.Kind)
+	}
+}
+
+func TestQuery_ErrorKinds(t *testing.T) {
+	tests := []struct {
+		d    Dialect
+		sql  string
+		kind ErrorKind
+		msg  string
+	}{
+		{MySQL, "SELECT id FROM products WHERE id=1'", UnclosedQuote,
+			"You have an error in your SQL syntax; check the manual that corresponds to your MySQL server version for the right syntax to use near ''' at line 1"},
+		{MySQL, "SELECT id FROM products WHERE id=1 AND", SyntaxError,
+			"You have an error in your SQL syntax; check the manual that corresponds to your MySQL server version for the right syntax to use near '' at line 1"},
+		{MySQL, "SELECT id FROM products WHERE id=1) AND (1=1", SyntaxError,
+			"You have an error in your SQL syntax; check the manual that corresponds to your MySQL server version for the right syntax to use near ') AND (1=1' at line 1"},
+		{PostgreSQL, "SELECT id FROM products WHERE id=1 AND", SyntaxError, "syntax error at end of input"},
+		{PostgreSQL, "SELECT id FROM products WHERE id=1 1", SyntaxError, `syntax error at or near "1"`},
+		{PostgreSQL, "SELECT id FROM products WHERE id='1", UnclosedQuote, `unterminated quoted string at or near "'1"`},
+		{MSSQL, "SELECT id FROM products WHERE id=1'", UnclosedQuote, "Unclosed quotation mark after the character string ''."},
+		{MSSQL, "SELECT id FROM products WHERE id=1 #", SyntaxError, "Incorrect syntax near '#'."},
+		{MySQL, "SELECT id FROM products WHERE id=1 AND extractvalue(1,concat(0x7e,(@@version)))", XPathError, "XPATH syntax error: '~8.0.32'"},
+		{MySQL, "SELECT updatexml(1,concat(0x7e,(SELECT REPEAT('a',40))),1)", UnknownFunction, "FUNCTION REPEAT does not exist"},
+		{MySQL, "SELECT extractvalue(1,concat(0x7e,(SELECT CONCAT(password,password,password,password,password,password) FROM users WHERE id=1)))", XPathError,
+			"XPATH syntax error: '~s3crets3crets3crets3crets3crets'"},
+		{PostgreSQL, "SELECT id FROM products WHERE id=1 AND CAST((version()) AS INT)=1", ConversionError, `invalid input syntax for type integer: "8.0.32"`},
+		{PostgreSQL, "SELECT CAST(chr(126)||(version())||chr(126) AS NUMERIC)", ConversionError, `invalid input syntax for type numeric: "~8.0.32~"`},
+		{PostgreSQL, "SELECT $$abc", UnclosedQuote, `unterminated dollar-quoted string at or near "$$abc"`},
+		{PostgreSQL, "SELECT id FROM products WHERE id='abc'", ConversionError, `invalid input syntax for type integer: "abc"`},
+		{MSSQL, "SELECT id FROM products WHERE id=1 AND 1=CONVERT(INT,(@@version))", ConversionError,
+			"Conversion failed when converting the nvarchar value '8.0.32' to data type int."},
+		{PostgreSQL, "SELECT extractvalue(1,'/a')", UnknownFunction, "function extractvalue does not exist"},
+		{MSSQL, "SELECT SLEEP(1)", UnknownFunction, "'SLEEP' is not a recognized built-in function name."},
+		{MSSQL, "SELECT 1; WAITFOR DELAY 'soon'", ConversionError, "Conversion failed when converting date and/or time from character string."},
+		{MSSQL, "SELECT 1; SELECT 2", SyntaxError, "Incorrect syntax near 'SELECT'."},
+		{MySQL, "SELECT 1; WAITFOR DELAY '0:0:1'", SyntaxError,
+			"You have an error in your SQL syntax; check the manual that corresponds to your MySQL server version for the right syntax to use near 'WAITFOR DELAY '0:0:1'' at line 1"},
+		{MySQL, "SELECT nope FROM products", UnknownColumn, "Unknown column 'nope' in 'field list'"},
+		{MySQL, "SELECT id FROM products WHERE x.id=1", UnknownColumn, "Unknown column 'x.id' in 'where clause'"},
+		{MySQL, "SELECT id FROM nope", UnknownTable, "Table 'shop.nope' doesn't exist"},
+		{MySQL, "SELECT @@nope", UnknownVariable, "Unknown system variable 'nope'"},
+		{PostgreSQL, "SELECT CURRENT_SETTING('nope')", UnknownVariable, `unrecognized configuration parameter "nope"`},
+		{MySQL, "SELECT (SELECT id FROM products)", CardinalityError, "Subquery returns more than 1 row"},
+		{MySQL, "SELECT (SELECT id, name FROM products WHERE id=1)", ColumnCountMismatch, "Operand should contain 1 column(s)"},
+		{MySQL, "SELECT IF(1=1,2)", UnknownFunction, "Incorrect parameter count in the call to native function 'IF'"},
+		{MySQL, "SELECT ASCII()", UnknownFunction, "Incorrect parameter count in the call to native function 'ASCII'"},
+		{MariaDB, "SELECT id FROM products WHERE id=1'", UnclosedQuote,
+			"You have an error in your SQL syntax; check the manual that corresponds to your MariaDB server version for the right syntax to use near ''' at line 1"},
+		{MySQL, "SELECT JSON_DETAILED('[]')", UnknownFunction, "FUNCTION JSON_DETAILED does not exist"},
+		{MySQL, "SELECT seq FROM seq_1_to_3", UnknownTable, "Table 'shop.seq_1_to_3' doesn't exist"},
+		{MySQL, "SELECT id FROM products WHERE id=1 /* unterminated", SyntaxError,
+			"You have an error in your SQL syntax; check the manual that corresponds to your MySQL server version for the right syntax to use near '/* unterminated' at line 1"},
+		{Oracle, "SELECT id FROM products WHERE id=1'", UnclosedQuote, "ORA-01756: quoted string not properly terminated"},
+		{Oracle, "SELECT id FROM products WHERE id=1 #", SyntaxError, "ORA-00933: SQL command not properly ended"},
+		{Oracle, "SELECT 1", SyntaxError, "ORA-00923: FROM keyword not found where expected"},
+		{Oracle, "SELECT id FROM products WHERE id=1 UNION SELECT NULL", SyntaxError, "ORA-00923: FROM keyword not found where expected"},
+		{Oracle, "SELECT id FROM products UNION SELECT 1,2 FROM dual", ColumnCountMismatch, "ORA-01789: query block has incorrect number of result columns"},
+		{Oracle, "SELECT id FROM products ORDER BY 2", UnknownColumn, "ORA-01785: ORDER BY item must be the number of a SELECT-list expression"},
+		{Oracle, "SELECT nope FROM products", UnknownColumn, `ORA-00904: "NOPE": invalid identifier`},
+		{Oracle, "SELECT SLEEP(1) FROM dual", UnknownFunction, `ORA-00904: "SLEEP": invalid identifier`},
+		{Oracle, "SELECT id FROM nope", UnknownTable, "ORA-00942: table or view does not exist"},
+		{Oracle, "SELECT id FROM products WHERE id='abc'", ConversionError, "ORA-01722: invalid number"},
+		{Oracle, "SELECT id FROM products WHERE id=1 AND 1=UTL_INADDR.GET_HOST_NAME(CHR(126)||(SELECT banner FROM v$version WHERE rownum=1)||CHR(126))", LookupError,
+			"ORA-29257: host ~8.0.32~ unknown"},
+		{Oracle, "SELECT id FROM products WHERE id=1 AND 1=CTXSYS.DRITHSX.SN(1,CHR(126)||(USER)||CHR(126))", LookupError,
+			"ORA-20000: Oracle Text error:\nDRG-11701: thesaurus ~root@localhost~ does not exist"},
+	}
+	for _, tt := range tests {
+		e := queryErr(t, newTestDB(tt.d), tt.sql)
+		if e.Kind != tt.kind {
+			t.Errorf("%s: %q: kind = %v, want %v (%v)", tt.d, tt.sql, e.Kind, tt.kind, e)
+		}
+		if e.Error() != tt.msg {
+			t.Errorf("%s: %q:\n got %s\nwant %s", tt.d, tt.sql, e.Error(), tt.msg)
+		}
+	}
+}
+
+func TestQuery_MariaDB(t *testing.T) {
+	db := newTestDB(MariaDB)
+	for _, tt := range []struct{ x, want string }{
+		{"JSON_DETAILED('[1]')", "[1]"},
+		{"CONV(10,10,36)", "A"},
+		{"(SELECT COUNT(*) FROM seq_1_to_5)", "5"},
+		{"(SELECT MAX(seq) FROM seq_3_to_7)", "7"},
+	} {
+		if got := scalar(t, db, tt.x); got != tt.want {
+			t.Errorf("SELECT %s = %q, want %q", tt.x, got, tt.want)
+		}
+	}
+}
+
+func TestQuery_Oracle(t *testing.T) {
+	db := newTestDB(Oracle)
+	for _, tt := range []struct{ x, want string }{
+		{"'a'||NULL||'b' FROM dual", "ab"},
+		{"SUBSTR('Widget',0,3) FROM dual", "Wid"},
+		{"SUBSTR('Widget',-3,2) FROM dual", "ge"},
+		{"LENGTH('Wide gadget') FROM dual", "11"},
+		{"CHR(65)||ASCII('a') FROM dual", "A97"},
+		{"NVL(NULL,'x') FROM dual", "x"},
+		{"UTL_INADDR.GET_HOST_NAME FROM dual", "db01"},
+		{"ORA_DATABASE_NAME FROM dual", "shop"},
+		{"banner FROM v$version WHERE rownum=1", "8.0.32"},
+		{"name FROM (SELECT t.*,ROWNUM rn FROM products t WHERE ROWNUM<=2) WHERE rn>1", "Wide gadget"},
+		{"COUNT(*) FROM products WHERE ROWNUM<=2", "2"},
+	} {
+		if got := scalar(t, db, tt.x); got != tt.want {
+			t.Errorf("SELECT %s = %q, want %q", tt.x, got, tt.want)
+		}
+	}
+
+	res, err := db.Query("SELECT id FROM products WHERE id=1 AND 1=DBMS_PIPE.RECEIVE_MESSAGE(CHR(95),1)")
+	if err != nil {
+		t.Fatalf("DBMS_PIPE.RECEIVE_MESSAGE: %v", err)
+	}
+	if res.Sleep != time.Second || len(res.Rows) != 1 {
+		t.Errorf("DBMS_PIPE.RECEIVE_MESSAGE: sleep %v, %d rows; want 1s and the row", res.Sleep, len(res.Rows))
+	}
+}
+
+func TestQuery_Comments(t *testing.T) {
+	tests := []struct {
+		d    Dialect
+		sql  string
+		rows int
+	}{
+		{MySQL, "SELECT id FROM products WHERE id=1 -- -", 1},
+		{MySQL, "SELECT id FROM products WHERE id=1 OR 1=1#' AND name='x'", 3},
+		{MySQL, "SELECT id FROM products WHERE id=1/**/OR/**/1=1", 3},
+		{MySQL, "SELECT id FROM products WHERE name='Widget'/*' AND x='*/ AND 1=1-- -'", 1},
+		{PostgreSQL, "SELECT id FROM products WHERE id=1 OR 1=1--", 3},
+		{MSSQL, "SELECT id FROM products WHERE id=1 OR 1=1;--", 3},
+	}
+	for _, tt := range tests {
+		if rows := queryRows(t, newTestDB(tt.d), tt.sql); len(rows) != tt.rows {
+			t.Errorf("%s: %q: %d rows, want %d", tt.d, tt.sql, len(rows), tt.rows)
+		}
+	}
+	// MySQL needs whitespace after "--".
+	if e := queryErr(t, newTestDB(MySQL), "SELECT id FROM products WHERE id=1 --x"); e.Kind != UnknownColumn {
+		t.Errorf("MySQL --x: kind %v, want UnknownColumn (1 - -x)", e.Kind)
+	}
+}
+
+func TestQuery_MalformedInput(t *testing.T) {
+	inputs := []string{
+		"",
+		";",
+		"SELECT",
+		"SELECT (",
+		"SELECT )",
+		"SELECT 1 FROM",
+		"SELECT * ",
+		"SELECT 1 UNION",
+		"SELECT 1 ORDER BY",
+		"SELECT 1 LIMIT",
+		"SELECT CASE END",
+		"SELECT CAST(1 AS BLOB)",
+		"SELECT CONVERT(",
+		"SELECT @",
+		"SELECT `unterminated",
+		"SELECT 1; SELECT 2",
+		"SELECT 1 1",
+		"SELECT 'a' 'b'",
+		"SELECT 1 = = 1",
+		"SELECT " + strings.Repeat("(", 200) + "1" + strings.Repeat(")", 200),
+		"SELECT " + strings.Repeat("-", 200) + "1",
+		"SELECT " + strings.Repeat("NOT ", 200) + "1",
+		"SELECT 1 FROM products p WHERE p.",
+		"SELECT SUBSTRING('a' FROM)",
+		"SELECT 1 IN ()",
+		"SELECT 1 BETWEEN 1",
+		"SELECT 1 IS",
+		"\x00\xff",
+		"SELECT 'é",
+	}
+	for _, d := range []Dialect{Generic, MySQL, PostgreSQL, MSSQL} {
+		db := newTestDB(d)
+		for _, in := range inputs {
+			res, err := db.Query(in)
+			if err == nil {
+				t.Errorf("%s: Query(%q) = %v, want error", d, in, res.Rows)
+				continue
+			}
+			var qe *Error
+			if !errors.As(err, &qe) || qe.Kind == 0 || qe.Error() == "" {
+				t.Errorf("%s: Query(%q): error %#v is not a classified *Error", d, in, err)
+			}
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		v    Value
+		want string
+	}{
+		{nil, ""},
+		{int64(-3), "-3"},
+		{2.50, "2.5"},
+		{"x", "x"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.v); got != tt.want {
+			t.Errorf("Format(%v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
+
+func FuzzQuery(f *testing.F) {
+	seeds := []string{
+		"1",
+		"1'",
+		"1 AND 1=1",
+		"1 AND ASCII(SUBSTRING((@@version),1,1))>55",
+		"1 AND IF(1=1,SLEEP(5),0)",
+		"1 AND 1=(CASE WHEN (1=1) THEN (SELECT 1 FROM PG_SLEEP(5)) ELSE 1 END)",
+		"1 ORDER BY 3-- -",
+		"1 UNION SELECT NULL,CONCAT(CHAR(126),(@@version),CHAR(126))-- -",
+		"1 AND extractvalue(1,concat(0x7e,(@@version)))",
+		"1') AND ('a'='a",
+		"1/*",
+	}
+	for _, s := range seeds {
+		f.Add(s)
+	}
+	dbs := []*DB{newTestDB(Generic), newTestDB(MySQL), newTestDB(PostgreSQL), newTestDB(MSSQL)}
+	f.Fuzz(func(t *testing.T, input string) {
+		for _, db := range dbs {
+			res, err := db.Query("SELECT id, name FROM products WHERE id=" + input)
+			if err != nil {
+				var qe *Error
+				if !errors.As(err, &qe) {
+					t.Fatalf("%s: error %T is not *Error", db.Dialect, err)
+				}
+				continue
+			}
+			if res.Sleep < 0 || res.Sleep > db.MaxSleep {
+				t.Fatalf("%s: Sleep = %v outside [0, %v]", db.Dialect, res.Sleep, db.MaxSleep)
+			}
+		}
+	})
+}
@@ -1,206 +1,772 @@
 // Package testutil provides test utilities including a mock vulnerable web
 // server for integration testing of the sqleech SQL injection scanner.
 //
+// The endpoints splice their parameters into SQL statements and evaluate
+// them with the sqlmock package against in-memory fixtures, so probes get
+// the answers a real database would give.
+//
 // SECURITY NOTE: This package is for testing only. The mock server
 // intentionally simulates SQL-injectable endpoints. All user-derived
-// values embedded in responses are HTML-escaped via html/template.
+// values embedded in responses are HTML-escaped via html/template, or via
+// errorText for database error messages; encoding/json escapes them in
+// JSON responses.
 package testutil
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"html/template"
+	"math/rand/v2"
 	"net/http"
 	"net/http/httptest"
-	"regexp"
+	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/0x6d61/sqleech/internal/testutil/sqlmock"
 )
 
 // mockVersionMySQL is the fake MySQL version returned by the mock server.
 const mockVersionMySQL = "8.0.32"
 
+// mockVersionMySQL57 is the fake version of the legacy MySQL endpoints.
+const mockVersionMySQL57 = "5.7.44"
+
+// mockVersionMariaDB is the fake MariaDB version returned by the mock server.
+const mockVersionMariaDB = "10.6.12-MariaDB-1:10.6.12+maria~ubu2004"
+
 // mockVersionPostgreSQL is the fake PostgreSQL version returned by the mock server.
 const mockVersionPostgreSQL = "PostgreSQL 15.3"
 
 // mockVersionMSSQL is the fake MSSQL version string returned by the mock server.
 const mockVersionMSSQL = "Microsoft SQL Server 2019 (RTM-CU18) - 15.0.4261.1"
 
+// mockVersionOracle is the fake Oracle banner returned by the mock server.
+const mockVersionOracle = "Oracle Database 19c Enterprise Edition Release 19.0.0.0.0 - Production"
+
 // timebasedSleepCap is the maximum simulated delay for time-based handlers.
 // Kept short (1s) to keep integration tests fast.
 const timebasedSleepCap = 1 * time.Second
 
-// unionSentinel is the marker string used by the UNION technique to identify
-// string-accepting columns. Must match technique/union.sentinel.
-const unionSentinel = "sqleech3z9"
-
-// unionNumCols is the number of columns simulated by the UNION endpoints.
-const unionNumCols = 2
-
-// sleepSecondsPattern extracts the seconds argument from SLEEP(n) or PG_SLEEP(n).
-var sleepSecondsPattern = regexp.MustCompile(`(?i)(?:PG_)?SLEEP\((\d+)\)`)
-
 // Response templates using html/template for safe HTML rendering.
 // Templates with {{.}} safely escape any dynamic data passed to them.
-// Templates without interpolation render static content only.
+// Templates without interpolation render static content only. Result pages
+// get the rows as [][]string.
 var tmplMap = template.Must(template.New("").Parse(`
-{{define "mysql-xpath-error"}}<html><body><h1>Error</h1><p>XPATH syntax error: '~` + mockVersionMySQL + `~'</p></body></html>{{end}}
-{{define "mysql-syntax-error"}}<html><body><h1>Error</h1><p>You have an error in your SQL syntax; check the manual that corresponds to your MySQL server version for the right syntax to use near '{{.}}'</p></body></html>{{end}}
-{{define "mysql-normal"}}<html><body><h1>Products</h1><p>Product: Widget (ID: 1)</p></body></html>{{end}}
+{{define "sql-error"}}<html><body><h1>Error</h1><p>{{.}}</p></body></html>{{end}}
+{{define "mysql-xpath-error"}}<html><body><h1>Error</h1><p>XPATH syntax error: '{{.}}~'</p></body></html>{{end}}
+{{define "mysql-normal"}}<html><body><h1>Products</h1>{{range .}}<p>Product: {{index . 1}} (ID: {{index . 0}})</p>{{end}}</body></html>{{end}}
 {{define "mysql-false"}}<html><body><h1>Products</h1><p>No results found.</p></body></html>{{end}}
-{{define "pg-cast-error"}}<html><body><h1>Error</h1><p>invalid input syntax for type integer: "` + mockVersionPostgreSQL + `"</p></body></html>{{end}}
-{{define "pg-syntax-error"}}<html><body><h1>Error</h1><p>ERROR: syntax error at or near "{{.}}"</p></body></html>{{end}}
-{{define "pg-normal"}}<html><body><h1>Users</h1><p>User: admin (ID: 1)</p></body></html>{{end}}
+{{define "pg-normal"}}<html><body><h1>Users</h1>{{range .}}<p>User: {{index . 1}} (ID: {{index . 0}})</p>{{end}}</body></html>{{end}}
 {{define "pg-false"}}<html><body><h1>Users</h1><p>No user found.</p></body></html>{{end}}
-{{define "bool-normal"}}<html><body><h1>Store</h1><p>Welcome! Your item: Widget</p></body></html>{{end}}
+{{define "bool-normal"}}<html><body><h1>Store</h1>{{range .}}<p>Welcome! Your item: {{index . 0}}</p>{{end}}</body></html>{{end}}
 {{define "bool-false"}}<html><body><h1>Store</h1><p>No items found.</p></body></html>{{end}}
-{{define "multi-normal"}}<html><body><h1>Results</h1><p>Item: Widget (ID: 1, Name: default)</p></body></html>{{end}}
+{{define "multi-normal"}}<html><body><h1>Results</h1>{{range .}}<p>Item: {{index . 1}} (ID: {{index . 0}}, Name: default)</p>{{end}}</body></html>{{end}}
 {{define "multi-false"}}<html><body><h1>Results</h1><p>No results found.</p></body></html>{{end}}
-{{define "post-normal"}}<html><body><h1>Login</h1><p>Welcome back, admin!</p></body></html>{{end}}
+{{define "post-normal"}}<html><body><h1>Login</h1>{{range .}}<p>Welcome back, {{index . 0}}!</p>{{end}}</body></html>{{end}}
 {{define "post-false"}}<html><body><h1>Login</h1><p>Login failed. Invalid credentials.</p></body></html>{{end}}
+{{define "generic-error"}}<html><body><h1>Error</h1><p>You have an error in your SQL syntax. Please try again later.</p></body></html>{{end}}
 {{define "post-error"}}<html><body><h1>Error</h1><p>You have an error in your SQL syntax</p></body></html>{{end}}
+{{define "forbidden"}}<html><body><h1>Forbidden</h1><p>Request blocked.</p></body></html>{{end}}
 {{define "safe"}}<html><body><h1>Product</h1><p>Product details for item 42</p></body></html>{{end}}
+{{define "status-page"}}<html><body><h1>Store</h1><p>Thank you for shopping with us.</p></body></html>{{end}}
 {{define "timebased-normal"}}<html><body><h1>Results</h1><p>Record found.</p></body></html>{{end}}
-{{define "mssql-convert-error"}}<html><body><h1>Error</h1><p>Conversion failed when converting the nvarchar value '` + mockVersionMSSQL + `' to data type int.</p></body></html>{{end}}
-{{define "mssql-syntax-error"}}<html><body><h1>Error</h1><p>Unclosed quotation mark after the character string '{{.}}'.</p></body></html>{{end}}
-{{define "mssql-normal"}}<html><body><h1>Results</h1><p>Row: 1</p></body></html>{{end}}
+{{define "mssql-normal"}}<html><body><h1>Results</h1>{{range .}}<p>Row: {{index . 0}}</p>{{end}}</body></html>{{end}}
 {{define "mssql-false"}}<html><body><h1>Results</h1><p>No rows found.</p></body></html>{{end}}
+{{define "union-mysql"}}<html><body><h1>Products</h1>{{range .}}<p>ID: {{index . 0}} | Name: {{index . 1}}</p>{{end}}</body></html>{{end}}
 {{define "union-order-error"}}<html><body><h1>Error</h1><p>Unknown column '{{.}}' in 'order clause'</p></body></html>{{end}}
-{{define "union-mysql-normal"}}<html><body><h1>Products</h1><p>ID: 1 | Name: Widget</p></body></html>{{end}}
-{{define "union-mysql-sentinel"}}<html><body><h1>Products</h1><p>ID: 1 | Name: ` + unionSentinel + `</p></body></html>{{end}}
-{{define "union-mysql-injected"}}<html><body><h1>Products</h1><p>ID: 1 | Name: ~` + mockVersionMySQL + `~</p></body></html>{{end}}
-{{define "union-pg-normal"}}<html><body><h1>Users</h1><p>ID: 1 | Name: Admin</p></body></html>{{end}}
-{{define "union-pg-sentinel"}}<html><body><h1>Users</h1><p>ID: 1 | Name: ` + unionSentinel + `</p></body></html>{{end}}
-{{define "union-pg-injected"}}<html><body><h1>Users</h1><p>ID: 1 | Name: ~` + mockVersionPostgreSQL + `~</p></body></html>{{end}}
+{{define "union-pg"}}<html><body><h1>Users</h1>{{range .}}<p>ID: {{index . 0}} | Name: {{index . 1}}</p>{{end}}</body></html>{{end}}
+{{define "oracle-normal"}}<html><body><h1>Catalogue</h1>{{range .}}<p>Item {{index . 0}}: {{index . 1}}</p>{{end}}</body></html>{{end}}
+{{define "split-found"}}<html><body><h1>Directory</h1>{{range .}}<p>Contact: {{index . 0}} (Paris)</p>{{end}}</body></html>{{end}}
+{{define "split-empty"}}<html><body><h1>Directory</h1><p>No contacts match.</p></body></html>{{end}}
+{{define "like-found"}}<html><body><h1>Search</h1>{{range .}}<p>{{index . 1}} ({{index . 2}})</p>{{end}}</body></html>{{end}}
+{{define "like-empty"}}<html><body><h1>Search</h1><p>No products match your search.</p></body></html>{{end}}
+{{define "page-found"}}<html><body><h1>Catalog</h1>{{range .}}<p>{{index . 1}} (ID: {{index . 0}})</p>{{end}}</body></html>{{end}}
+{{define "page-empty"}}<html><body><h1>Catalog</h1><p>No more products.</p></body></html>{{end}}
+{{define "page-invalid"}}<html><body><h1>Catalog</h1><p>Invalid page size.</p></body></html>{{end}}
+{{define "count"}}<html><body><h1>Catalog</h1>{{range .}}<p>{{index . 0}} products in stock</p>{{end}}</body></html>{{end}}
+{{define "login-form"}}<html><head><title>Sign in</title></head><body><h1>Sign in</h1>
+<form method="post" action="login"><input type="hidden" name="csrf" value="f3a9c1">
+<label>User <input type="text" name="username"></label><label>Password <input type="password" name="password"></label>
+<input type="submit" value="Sign in"></form></body></html>{{end}}
+{{define "shop-index"}}<html><head><title>Shop</title></head><body><h1>Shop</h1>
+<ul><li><a href="products">Products</a></li><li><a href="/shop/products#top">Top products</a></li>
+<li><a href="admin?id=1">Admin</a></li><li><a href="logout?token=abc">Log out</a></li>
+<li><a href="https://example.com/partner?ref=shop">Partner</a></li></ul></body></html>{{end}}
+{{define "shop-products"}}<html><head><title>Products</title></head><body><h1>Products</h1>
+<ul><li><a href="/shop/product?id=1">Widget</a></li><li><a href="/shop/product?id=2">Gadget</a></li></ul>
+<form action="/shop/search"><input type="text" name="q"><input type="submit" value="Search"></form>
+</body></html>{{end}}
+{{define "locale-rows"}}{{range .}}
+<tr><td>SKU-{{.}}</td><td>{{.}}00</td></tr>{{end}}{{end}}
+{{define "locale-ja"}}<html lang="ja">
+<head><title>アカウント設定</title></head>
+<body><table>{{template "locale-rows" .}}
+</table>
+<p>おかえりなさい。表示言語はプロフィールの設定から読み込まれています。</p>
+</body></html>{{end}}
+{{define "locale-en"}}<html lang="en">
+<head><title>Account settings</title></head>
+<body><table>{{template "locale-rows" .}}
+</table>
+<p>Welcome back. The language of this page is loaded from your profile settings.</p>
+</body></html>{{end}}
 `))
 
-// asciiSubstringPattern extracts position and comparison value from boolean
-// blind probes like: ASCII(SUBSTRING(...,<pos>,1))><value>
-var asciiSubstringPattern = regexp.MustCompile(`(?i)ASCII\(SUBSTRING\([^,]+,(\d+),1\)\)\s*>\s*(\d+)`)
-
-// lengthPattern extracts the comparison value from LENGTH probes like:
-// LENGTH(...)><value>  -- supports nested parentheses up to one level deep.
-var lengthPattern = regexp.MustCompile(`(?i)LENGTH\((?:[^()]*\([^)]*\))*[^)]*\)\s*>\s*(\d+)`)
-
 // NewVulnServer creates a mock HTTP server simulating a vulnerable web
 // application. The server handles multiple endpoints that simulate different
 // types of SQL injection vulnerabilities. The returned *httptest.Server
 // should be closed after use.
 func NewVulnServer() *httptest.Server {
+	return httptest.NewServer(VulnHandler())
+}
+
+// VulnHandler returns the handler behind NewVulnServer, for mounting the
+// mock endpoints into another server.
+func VulnHandler() http.Handler {
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("/vuln/error-mysql", handleErrorMySQL)
-	mux.HandleFunc("/vuln/error-postgres", handleErrorPostgres)
-	mux.HandleFunc("/vuln/boolean", handleBoolean)
+	mux.Handle("/vuln/error-mysql", errorMySQL)
+	mux.Handle("/vuln/cookie", cookieMySQL)
+	mux.Handle("/vuln/user-agent", userAgentMySQL)
+	mux.Handle("/vuln/soap", soapMySQL)
+	mux.Handle("/vuln/item/{id}/details", pathMySQL)
+	mux.Handle("/vuln/path/{id}", restItem)
+	mux.Handle("/vuln/json", jsonMySQL)
+	mux.Handle("/vuln/base64", base64MySQL)
+	mux.HandleFunc("/vuln/signed", handleSigned)
+	mux.HandleFunc("PUT /vuln/put", handlePut)
+	mux.HandleFunc("/vuln/array", handleArray)
+	// errorMySQL answering DELETE only, as REST APIs route it.
+	mux.Handle("DELETE /vuln/delete", errorMySQL)
+	mux.Handle("/vuln/error-postgres", errorPostgres)
+	mux.Handle("/vuln/boolean", booleanBlind)
+	mux.Handle("/vuln/boolean-status", booleanStatus)
 	mux.HandleFunc("/vuln/safe", handleSafe)
-	mux.HandleFunc("/vuln/multi", handleMulti)
-	mux.HandleFunc("/vuln/post", handlePost)
-	mux.HandleFunc("/vuln/timebased-mysql", handleTimeBasedMySQL)
-	mux.HandleFunc("/vuln/timebased-postgres", handleTimeBasedPostgres)
-	mux.HandleFunc("/vuln/error-mssql", handleErrorMSSQL)
-	mux.HandleFunc("/vuln/union-mysql", handleUnionMySQL)
-	mux.HandleFunc("/vuln/union-postgres", handleUnionPostgres)
+	mux.Handle("/vuln/multi", multiParam)
+	mux.Handle("/vuln/post", postLogin)
+	mux.Handle("/vuln/timebased-mysql", timeBased)
+	mux.Handle("/vuln/timebased-postgres", timeBased)
+	mux.Handle("/vuln/timebased-mssql", timeBasedMSSQL)
+	mux.Handle("/vuln/timebased-nosleep", timeBasedNoSleep)
+	mux.Handle("/vuln/error-mssql", errorMSSQL)
+	mux.Handle("/vuln/error-oracle", errorOracle)
+	mux.Handle("/vuln/union-mysql", unionMySQL)
+	mux.Handle("/vuln/union-postgres", unionPostgres)
+	mux.Handle("/vuln/union-mysql-errors", unionMySQLErrors)
+	mux.Handle("/vuln/union-postgres-errors", unionPostgresErrors)
+	mux.HandleFunc("/vuln/split", handleSplit)
+	mux.HandleFunc("/vuln/locale", handleLocale)
+	mux.Handle("/vuln/like", likeSearch)
+	mux.Handle("/vuln/union-capped", unionCapped)
+	mux.Handle("/vuln/union-filtered", unionFiltered)
+	mux.Handle("/vuln/union-xpath", unionNoXPath)
+	mux.Handle("/vuln/noquote", noQuote)
+	mux.Handle("/vuln/paren", parenString)
+	mux.HandleFunc("/vuln/api/products", handleAPIProducts)
+	mux.HandleFunc("/vuln/graphql", handleGraphQL)
+	mux.Handle("/vuln/count", countWrapped)
+	mux.Handle("/vuln/error-mariadb", errorMariaDB)
+	mux.Handle("/vuln/masked-mysql", maskedMySQL)
+	mux.Handle("/vuln/masked-mariadb", maskedMariaDB)
+	mux.Handle("/vuln/costly", costlyJoin)
+	mux.Handle("/vuln/page-postgres", pagePostgres)
+	mux.Handle("/vuln/page-mysql", pageMySQL)
+	mux.HandleFunc("/vuln/page-safe", handlePageSafe)
+	// The form of loginMySQL, with a hidden token it ignores.
+	mux.HandleFunc("/vuln/login-form", func(w http.ResponseWriter, _ *http.Request) { execTemplate(w, "login-form", nil) })
+	mux.Handle("POST /vuln/login", loginMySQL)
+
+	// A small site for crawling: the injectable product page is two clicks
+	// from the index, and the admin page, disallowed by robots.txt, one.
+	mux.HandleFunc("/robots.txt", handleRobots)
+	mux.HandleFunc("/shop/{$}", func(w http.ResponseWriter, _ *http.Request) { execTemplate(w, "shop-index", nil) })
+	mux.HandleFunc("/shop/products", func(w http.ResponseWriter, _ *http.Request) { execTemplate(w, "shop-products", nil) })
+	mux.Handle("/shop/product", errorMySQL)
+	mux.Handle("/shop/admin", errorMySQL)
+	mux.HandleFunc("/shop/search", handleSafe)
+	mux.HandleFunc("/shop/logout", handleSafe)
 
-	return httptest.NewServer(mux)
+	return mux
 }
 
 // execTemplate renders a named template with optional data to the ResponseWriter.
 func execTemplate(w http.ResponseWriter, name string, data any) {
+	execTemplateStatus(w, http.StatusOK, name, data)
+}
+
+// execTemplateStatus is execTemplate with the given status code.
+func execTemplateStatus(w http.ResponseWriter, status int, name string, data any) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(status)
 	tmplMap.ExecuteTemplate(w, name, data) //nolint:errcheck
 }
 
-// handleErrorMySQL simulates a MySQL error-based injectable endpoint.
+// runSQL evaluates sql against db. Every sqlmock error is an *sqlmock.Error.
+func runSQL(db *sqlmock.DB, sql string) (*sqlmock.Result, *sqlmock.Error) {
+	res, err := db.Query(sql)
+	if err != nil {
+		var qe *sqlmock.Error
+		if !errors.As(err, &qe) {
+			qe = &sqlmock.Error{Kind: sqlmock.SyntaxError}
+		}
+		return nil, qe
+	}
+	return res, nil
+}
+
+// formatRows renders result rows as strings for the templates, keeping at
+// most max rows when max > 0.
+func formatRows(rows [][]sqlmock.Value, max int) [][]string {
+	if max > 0 && len(rows) > max {
+		rows = rows[:max]
+	}
+	out := make([][]string, len(rows))
+	for i, row := range rows {
+		out[i] = make([]string, len(row))
+		for j, v := range row {
+			out[i][j] = sqlmock.Format(v)
+		}
+	}
+	return out
+}
+
+// sqlEndpoint is a mock endpoint declared as data: one request parameter is
+// spliced into a query, the query runs against a fixture and the outcome
+// picks the page.
+type sqlEndpoint struct {
+	db    *sqlmock.DB
+	param string
+	// cookie reads param from the cookie of that name, URL-decoded as
+	// PHP decodes cookie values, instead of from the query or form.
+	cookie bool
+	// header reads param from the request header of that name.
+	header bool
+	// soap reads param from the first element of that local name in an
+	// XML request body, entities decoded, as a SOAP toolkit would.
+	soap bool
+	// path reads param from the path wildcard of that name; jsonBody from
+	// the string member of that name of a JSON object body.
+	path, jsonBody bool
+	// query is the statement the application builds, with %s where the
+	// parameter goes.
+	query string
+	// prepare is the application's own handling of the parameter before it
+	// is spliced in; nil splices it verbatim.
+	prepare func(string) string
+	// block, when set, rejects a parameter with a generic 403 page before
+	// the query runs, as a WAF in front of the application would.
+	block func(string) bool
+	// found renders the rows of a non-empty result, empty an empty one.
+	found, empty string
+	// emptyStatus is the status code of the empty page; zero means 200.
+	emptyStatus int
+	// maxRows caps the rows the page shows; zero shows them all.
+	maxRows int
+	// fallback, when set, is the query whose rows the page shows in place
+	// of the empty page, as an application falling back to its default
+	// listing would.
+	fallback string
+	// onError renders a query error; nil renders the empty page, as an
+	// application that swallows database errors would.
+	onError func(w http.ResponseWriter, err *sqlmock.Error)
+	// orderError shows the MySQL error for an ORDER BY position past the
+	// select list, whatever the dialect, in place of onError.
+	orderError bool
+	// sleep makes the response wait for the delay the query requested.
+	sleep bool
+	// cost, when set, makes the response wait for the query's own cost,
+	// given whether it found rows.
+	cost func(found bool) time.Duration
+}
+
+func (e *sqlEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	value := r.FormValue(e.param)
+	if e.cookie {
+		value = ""
+		if c, err := r.Cookie(e.param); err == nil {
+			value = c.Value
+			if v, err := url.QueryUnescape(c.Value); err == nil {
+				value = v
+			}
+		}
+	}
+	switch {
+	case e.header:
+		value = r.Header.Get(e.param)
+	case e.soap:
+		value = soapArg(r, e.param)
+	case e.path:
+		value = r.PathValue(e.param)
+	case e.jsonBody:
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		value = body[e.param]
+	}
+	if e.block != nil && e.block(value) {
+		execTemplateStatus(w, http.StatusForbidden, "forbidden", nil)
+		return
+	}
+	if e.prepare != nil {
+		value = e.prepare(value)
+	}
+
+	res, qerr := runSQL(e.db, fmt.Sprintf(e.query, value))
+	if e.cost != nil {
+		time.Sleep(e.cost(qerr == nil && len(res.Rows) > 0))
+	}
+	switch {
+	case qerr != nil && e.orderError && qerr.Kind == sqlmock.UnknownColumn && isPosition(qerr.Near):
+		execTemplate(w, "union-order-error", qerr.Near)
+		return
+	case qerr != nil && e.onError != nil:
+		e.onError(w, qerr)
+		return
+	case qerr != nil:
+		e.renderEmpty(w)
+		return
+	}
+
+	if e.sleep {
+		time.Sleep(res.Sleep)
+	}
+	if len(res.Rows) == 0 {
+		e.renderEmpty(w)
+		return
+	}
+	execTemplate(w, e.found, formatRows(res.Rows, e.maxRows))
+}
+
+// soapArg returns the text of the first element named name in the XML
+// body of r, or "" when there is none.
+func soapArg(r *http.Request, name string) string {
+	d := xml.NewDecoder(r.Body)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return ""
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Local == name {
+			var text string
+			if err := d.DecodeElement(&text, &se); err != nil {
+				return ""
+			}
+			return text
+		}
+	}
+}
+
+// isPosition reports whether the name an error quotes is an ORDER BY
+// position rather than a column.
+func isPosition(near string) bool {
+	_, err := strconv.Atoi(near)
+	return err == nil
+}
+
+// renderEmpty renders the rows of e.fallback, or else the empty page with
+// e.emptyStatus.
+func (e *sqlEndpoint) renderEmpty(w http.ResponseWriter) {
+	if e.fallback != "" {
+		if res, err := runSQL(e.db, e.fallback); err == nil && len(res.Rows) > 0 {
+			execTemplate(w, e.found, formatRows(res.Rows, e.maxRows))
+			return
+		}
+	}
+	status := e.emptyStatus
+	if status == 0 {
+		status = http.StatusOK
+	}
+	execTemplateStatus(w, status, e.empty, nil)
+}
+
+// errorText escapes a database error message for element content. Quotes
+// are left as they are, as on real error pages, so that the quoted values
+// error-based probes extract survive.
+func errorText(msg string) template.HTML {
+	return template.HTML(strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(msg)) //nolint:gosec
+}
+
+// showError renders the database's own error message, prefixed as the
+// application's error page does.
+func showError(prefix string) func(http.ResponseWriter, *sqlmock.Error) {
+	return func(w http.ResponseWriter, err *sqlmock.Error) {
+		execTemplate(w, "sql-error", errorText(prefix+err.Error()))
+	}
+}
+
+// showMySQLError renders a MySQL error page. XPath errors keep the closing
+// "~" marker the error-based tests and recorded cassettes were written
+// against.
+func showMySQLError(w http.ResponseWriter, err *sqlmock.Error) {
+	if err.Kind == sqlmock.XPathError {
+		execTemplate(w, "mysql-xpath-error", errorText(err.Near))
+		return
+	}
+	execTemplate(w, "sql-error", errorText(err.Error()))
+}
+
+// pgQueryFailed prefixes PostgreSQL errors the way PHP's pg_query reports
+// them.
+const pgQueryFailed = "Warning: pg_query(): Query failed: ERROR:  "
+
+// errorMySQL simulates a MySQL error-based injectable endpoint.
 //
 // GET /vuln/error-mysql?id=X
-//   - Normal: returns HTML with "Product: Widget (ID: 1)"
-//   - If X contains "'": returns MySQL syntax error
-//   - If X contains "extractvalue": returns XPATH error with version
-//   - If X contains "updatexml": returns XPATH error with version
-//   - If X contains "AND 1=1" or "1=1": returns normal page
-//   - If X contains "AND 1=2" or "1=2" (but not "1=1"): returns "No results found."
-//   - If X contains "SLEEP": returns normal page (no actual delay)
-func handleErrorMySQL(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
+//
+//	SELECT id, name FROM products WHERE id=X
+//
+// Database errors, including the XPATH errors EXTRACTVALUE and UPDATEXML
+// raise, are shown verbatim.
+var errorMySQL = &sqlEndpoint{
+	db:      shopMySQL,
+	param:   "id",
+	query:   "SELECT id, name FROM products WHERE id=%s",
+	found:   "mysql-normal",
+	empty:   "mysql-false",
+	onError: showMySQLError,
+}
 
-	switch {
-	case containsCI(id, "extractvalue") || containsCI(id, "updatexml"):
-		execTemplate(w, "mysql-xpath-error", nil)
-	case strings.Contains(id, "'"):
-		execTemplate(w, "mysql-syntax-error", id)
-	case containsCI(id, "AND 1=2") || containsFalseCondition(id):
+// cookieMySQL is errorMySQL reading id from a cookie. Other cookies, such
+// as the session and lang cookies a browser would send, are ignored.
+//
+// GET /vuln/cookie (Cookie: id=X)
+//
+//	SELECT id, name FROM products WHERE id=X
+var cookieMySQL = &sqlEndpoint{
+	db:      shopMySQL,
+	param:   "id",
+	cookie:  true,
+	query:   "SELECT id, name FROM products WHERE id=%s",
+	found:   "mysql-normal",
+	empty:   "mysql-false",
+	onError: showMySQLError,
+}
+
+// userAgentMySQL is errorMySQL splicing the User-Agent header into a
+// quoted string, a header the application trusts like its own data.
+// Nothing in the query string or cookies reaches the query.
+//
+// GET /vuln/user-agent (User-Agent: X)
+//
+//	SELECT id, name FROM products WHERE name='X'
+var userAgentMySQL = &sqlEndpoint{
+	db:      shopMySQL,
+	param:   "User-Agent",
+	header:  true,
+	query:   "SELECT id, name FROM products WHERE name='%s'",
+	found:   "mysql-normal",
+	empty:   "mysql-false",
+	onError: showMySQLError,
+}
+
+// soapMySQL is errorMySQL behind a SOAP interface: id is an argument of
+// the GetProduct call, the other arguments are ignored.
+//
+// POST /vuln/soap (text/xml: <Envelope><Body><GetProduct><id>X</id>...)
+//
+//	SELECT id, name FROM products WHERE id=X
+var soapMySQL = &sqlEndpoint{
+	db:      shopMySQL,
+	param:   "id",
+	soap:    true,
+	query:   "SELECT id, name FROM products WHERE id=%s",
+	found:   "mysql-normal",
+	empty:   "mysql-false",
+	onError: showMySQLError,
+}
+
+// pathMySQL is errorMySQL reading id from a path segment, as routers of
+// "pretty" URLs do; only a custom injection marker reaches it.
+//
+// GET /vuln/item/X/details
+//
+//	SELECT id, name FROM products WHERE id=X
+var pathMySQL = &sqlEndpoint{
+	db:      shopMySQL,
+	param:   "id",
+	path:    true,
+	query:   "SELECT id, name FROM products WHERE id=%s",
+	found:   "mysql-normal",
+	empty:   "mysql-false",
+	onError: showMySQLError,
+}
+
+// restItem is a REST-style MySQL endpoint reading id from its last path
+// segment. An id that matches no row answers 404, as does a query error;
+// database errors are never shown.
+//
+// GET /vuln/path/X
+//
+//	SELECT id, name FROM products WHERE id=X
+var restItem = &sqlEndpoint{
+	db:          shopMySQL,
+	param:       "id",
+	path:        true,
+	query:       "SELECT id, name FROM products WHERE id=%s",
+	found:       "mysql-normal",
+	empty:       "mysql-false",
+	emptyStatus: http.StatusNotFound,
+}
+
+// jsonMySQL is a MySQL endpoint reading a quoted name from a JSON body;
+// only a custom injection marker reaches it.
+//
+// POST /vuln/json (application/json: {"name":"X"})
+//
+//	SELECT id, name FROM products WHERE name='X'
+var jsonMySQL = &sqlEndpoint{
+	db:       shopMySQL,
+	param:    "name",
+	jsonBody: true,
+	query:    "SELECT id, name FROM products WHERE name='%s'",
+	found:    "mysql-normal",
+	empty:    "mysql-false",
+	onError:  showMySQLError,
+}
+
+// base64MySQL is errorMySQL behind an opaque token: data carries the id
+// base64-encoded, and the application decodes it before splicing it in.
+// A token that does not decode becomes an empty id, so payloads appended
+// to the token itself only ever produce the same syntax error.
+//
+// GET /vuln/base64?data=X (X = base64 of ID)
+//
+//	SELECT id, name FROM products WHERE id=ID
+var base64MySQL = &sqlEndpoint{
+	db:    shopMySQL,
+	param: "data",
+	query: "SELECT id, name FROM products WHERE id=%s",
+	prepare: func(v string) string {
+		id, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return ""
+		}
+		return string(id)
+	},
+	found:   "mysql-normal",
+	empty:   "mysql-false",
+	onError: showMySQLError,
+}
+
+// putForm and putJSON are the form and JSON halves of /vuln/put.
+var (
+	putForm = &sqlEndpoint{
+		db:      shopMySQL,
+		param:   "id",
+		query:   "SELECT id, name FROM products WHERE id=%s",
+		found:   "mysql-normal",
+		empty:   "mysql-false",
+		onError: showMySQLError,
+	}
+	putJSON = &sqlEndpoint{
+		db:       shopMySQL,
+		param:    "id",
+		jsonBody: true,
+		query:    "SELECT id, name FROM products WHERE id=%s",
+		found:    "mysql-normal",
+		empty:    "mysql-false",
+		onError:  showMySQLError,
+	}
+)
+
+// handlePut simulates a REST update endpoint that reads id from a form
+// or, sent as application/json, a JSON body, and shows MySQL errors. It
+// answers PUT only; other methods get 405.
+//
+// PUT /vuln/put (id=X, or application/json: {"id":"X"})
+//
+//	SELECT id, name FROM products WHERE id=X
+func handlePut(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		putJSON.ServeHTTP(w, r)
+		return
+	}
+	putForm.ServeHTTP(w, r)
+}
+
+// handleArray simulates a PHP endpoint taking an id[] array whose first
+// element goes through intval() and whose second is spliced in as is, so
+// only the second is injectable. It shows MySQL errors.
+//
+// GET /vuln/array?id[]=X&id[]=Y
+//
+//	SELECT id, name FROM products WHERE id IN (intval(X), Y)
+func handleArray(w http.ResponseWriter, r *http.Request) {
+	ids := r.URL.Query()["id[]"]
+	if len(ids) < 2 {
 		execTemplate(w, "mysql-false", nil)
-	case containsCI(id, "AND 1=1") || containsTrueCondition(id):
-		execTemplate(w, "mysql-normal", nil)
-	case containsCI(id, "SLEEP"):
-		execTemplate(w, "mysql-normal", nil)
+		return
+	}
+	first, _ := strconv.Atoi(ids[0])
+	res, qerr := runSQL(shopMySQL, fmt.Sprintf("SELECT id, name FROM products WHERE id IN (%d, %s)", first, ids[1]))
+	switch {
+	case qerr != nil:
+		showMySQLError(w, qerr)
+	case len(res.Rows) > 0:
+		execTemplate(w, "mysql-normal", formatRows(res.Rows, 0))
 	default:
-		execTemplate(w, "mysql-normal", nil)
+		execTemplate(w, "mysql-false", nil)
 	}
 }
 
-// handleErrorPostgres simulates a PostgreSQL error-based injectable endpoint.
+// errorMariaDB simulates a MariaDB error-based injectable endpoint, the
+// MariaDB counterpart of /vuln/error-mysql.
+//
+// GET /vuln/error-mariadb?id=X
+//
+//	SELECT id, name FROM products WHERE id=X
+var errorMariaDB = &sqlEndpoint{
+	db:      shopMariaDB,
+	param:   "id",
+	query:   "SELECT id, name FROM products WHERE id=%s",
+	found:   "mysql-normal",
+	empty:   "mysql-false",
+	onError: showMySQLError,
+}
+
+// errorPostgres simulates a PostgreSQL error-based injectable endpoint.
 //
 // GET /vuln/error-postgres?id=X
-//   - Normal: returns HTML with "User: admin (ID: 1)"
-//   - If X contains "'": returns PostgreSQL syntax error
-//   - If X contains "CAST(": returns invalid input syntax error with version
-//   - If X contains "AND 1=1": returns normal page
-//   - If X contains "AND 1=2": returns different page
-//   - If X contains "pg_sleep": returns normal page
-func handleErrorPostgres(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
+//
+//	SELECT id, username FROM users WHERE id=X
+//
+// Database errors, including CAST failures quoting the bad value, are shown
+// verbatim.
+var errorPostgres = &sqlEndpoint{
+	db:      shopPostgres,
+	param:   "id",
+	query:   "SELECT id, username FROM users WHERE id=%s",
+	found:   "pg-normal",
+	empty:   "pg-false",
+	onError: showError(pgQueryFailed),
+}
 
-	switch {
-	case containsCI(id, "CAST("):
-		execTemplate(w, "pg-cast-error", nil)
-	case strings.Contains(id, "'"):
-		execTemplate(w, "pg-syntax-error", id)
-	case containsCI(id, "AND 1=2") || containsFalseCondition(id):
-		execTemplate(w, "pg-false", nil)
-	case containsCI(id, "AND 1=1") || containsTrueCondition(id):
-		execTemplate(w, "pg-normal", nil)
-	case containsCI(id, "pg_sleep"):
-		execTemplate(w, "pg-normal", nil)
-	default:
-		execTemplate(w, "pg-normal", nil)
-	}
+// errorMSSQL simulates an MSSQL error-based injectable endpoint.
+//
+// GET /vuln/error-mssql?id=X
+//
+//	SELECT id FROM products WHERE id=X
+//
+// Database errors, including CONVERT and CAST failures quoting the bad
+// value, are shown verbatim.
+var errorMSSQL = &sqlEndpoint{
+	db:      shopMSSQL,
+	param:   "id",
+	query:   "SELECT id FROM products WHERE id=%s",
+	found:   "mssql-normal",
+	empty:   "mssql-false",
+	onError: showError(""),
 }
 
-// handleBoolean simulates a boolean-blind injectable endpoint.
+// errorOracle simulates an Oracle error-based injectable endpoint. Every
+// SELECT needs a FROM, so UNION probes must select FROM dual.
+//
+// GET /vuln/error-oracle?id=X
+//
+//	SELECT id, name FROM products WHERE id=X
+//
+// Database errors, including the thesaurus and host lookup failures quoting
+// the bad name, are shown verbatim.
+var errorOracle = &sqlEndpoint{
+	db:      shopOracle,
+	param:   "id",
+	query:   "SELECT id, name FROM products WHERE id=%s",
+	found:   "oracle-normal",
+	empty:   "oracle-normal",
+	onError: showError(""),
+}
+
+// booleanBlind simulates a boolean-blind injectable endpoint: matching rows
+// show the item, anything else -- including a database error -- shows the
+// "No items found." page.
 //
 // GET /vuln/boolean?id=X
-//   - Normal: returns "Welcome! Your item: Widget"
-//   - If X contains "AND 1=1": same as normal
-//   - If X contains "AND 1=2": returns "No items found."
-//   - If X contains "ASCII(SUBSTRING": evaluates against mock version data
-//   - If X contains "LENGTH": compares against length of mock version
-func handleBoolean(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
+//
+//	SELECT name FROM products WHERE id=X
+var booleanBlind = &sqlEndpoint{
+	db:    shopMySQL,
+	param: "id",
+	query: "SELECT name FROM products WHERE id=%s",
+	found: "bool-normal",
+	empty: "bool-false",
+}
 
-	switch {
-	case containsCI(id, "ASCII(SUBSTRING"):
-		if evaluateASCIISubstring(id, mockVersionMySQL) {
-			execTemplate(w, "bool-normal", nil)
-		} else {
-			execTemplate(w, "bool-false", nil)
-		}
-	case containsCI(id, "LENGTH("):
-		if evaluateLength(id, mockVersionMySQL) {
-			execTemplate(w, "bool-normal", nil)
-		} else {
-			execTemplate(w, "bool-false", nil)
-		}
-	case containsCI(id, "AND 1=2") || containsFalseCondition(id):
-		execTemplate(w, "bool-false", nil)
-	case containsCI(id, "AND 1=1") || containsTrueCondition(id):
-		execTemplate(w, "bool-normal", nil)
-	default:
-		execTemplate(w, "bool-normal", nil)
+// booleanStatus simulates a boolean-blind injectable endpoint whose page
+// never changes: matching rows answer 200 and anything else, including a
+// database error, 404 with the same body, so only the status code tells
+// TRUE from FALSE.
+//
+// GET /vuln/boolean-status?id=X
+//
+//	SELECT name FROM products WHERE id=X
+var booleanStatus = &sqlEndpoint{
+	db:          shopMySQL,
+	param:       "id",
+	query:       "SELECT name FROM products WHERE id=%s",
+	found:       "status-page",
+	empty:       "status-page",
+	emptyStatus: http.StatusNotFound,
+}
+
+// maskedMySQL and maskedMariaDB simulate error-based injectable endpoints
+// of an application that replaces database errors with a generic page:
+// the error shows the endpoint is MySQL-family but neither the server's
+// name nor extracted values, so telling MariaDB from MySQL takes active
+// probes.
+//
+// GET /vuln/masked-mysql?id=X
+// GET /vuln/masked-mariadb?id=X
+//
+//	SELECT name FROM products WHERE id=X
+var (
+	maskedMySQL   = maskedEndpoint(shopMySQL)
+	maskedMariaDB = maskedEndpoint(shopMariaDB)
+)
+
+func maskedEndpoint(db *sqlmock.DB) *sqlEndpoint {
+	return &sqlEndpoint{
+		db:    db,
+		param: "id",
+		query: "SELECT name FROM products WHERE id=%s",
+		found: "bool-normal",
+		empty: "bool-false",
+		onError: func(w http.ResponseWriter, _ *sqlmock.Error) {
+			execTemplate(w, "generic-error", nil)
+		},
 	}
 }
 
+// signedTimestamp is the ts parameter /vuln/signed expects, as signed:
+// unescaped, + and colons included.
+const signedTimestamp = "2026-10-15T09:00:00+02:00"
+
+// handleSigned is errorMySQL behind an API gateway that verifies a request
+// signature over the raw query string, as the client wrote it: b must come
+// before a, and ts must arrive byte for byte as signedTimestamp. Anything
+// else is rejected with a 400 before the application runs.
+//
+// GET /vuln/signed?b=2&a=1&ts=2026-10-15T09:00:00+02:00&id=X
+//
+//	SELECT id, name FROM products WHERE id=X
+func handleSigned(w http.ResponseWriter, r *http.Request) {
+	raw := "&" + r.URL.RawQuery + "&"
+	b, a := strings.Index(raw, "&b="), strings.Index(raw, "&a=")
+	if b < 0 || a < b || !strings.Contains(raw, "&ts="+signedTimestamp+"&") {
+		http.Error(w, "invalid request signature", http.StatusBadRequest)
+		return
+	}
+	errorMySQL.ServeHTTP(w, r)
+}
+
 // handleSafe simulates a non-injectable endpoint. It always returns the
 // same page regardless of input -- the parameter is not interpolated into SQL.
 //
@@ -209,294 +775,564 @@ func handleSafe(w http.ResponseWriter, _ *http.Request) {
 	execTemplate(w, "safe", nil)
 }
 
-// handleMulti simulates an endpoint with multiple parameters where only
+// handleRobots keeps crawlers out of the shop's admin pages.
+//
+// GET /robots.txt
+func handleRobots(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, "User-agent: *\nDisallow: /shop/admin\n")
+}
+
+// loginMySQL simulates the login form of /vuln/login-form, whose
+// "username" field is MySQL error-based injectable. Unlike postLogin, it
+// needs no known user name: the database errors give it away whatever the
+// form is filled with.
+//
+// POST /vuln/login
+// Body: csrf=T&username=X&password=Y (application/x-www-form-urlencoded)
+//
+//	SELECT username FROM users WHERE username='X'
+var loginMySQL = &sqlEndpoint{
+	db:      shopMySQL,
+	param:   "username",
+	query:   "SELECT username FROM users WHERE username='%s'",
+	found:   "post-normal",
+	empty:   "post-false",
+	onError: showMySQLError,
+}
+
+// multiParam simulates an endpoint with multiple parameters where only
 // "id" is injectable (MySQL error-based) and "name" is not.
 //
 // GET /vuln/multi?id=X&name=Y
-func handleMulti(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
-
-	// Only id affects the response; name is ignored in SQL context.
-	switch {
-	case containsCI(id, "extractvalue") || containsCI(id, "updatexml"):
-		execTemplate(w, "mysql-xpath-error", nil)
-	case strings.Contains(id, "'"):
-		execTemplate(w, "mysql-syntax-error", id)
-	case containsCI(id, "AND 1=2") || containsFalseCondition(id):
-		execTemplate(w, "multi-false", nil)
-	case containsCI(id, "AND 1=1") || containsTrueCondition(id):
-		execTemplate(w, "multi-normal", nil)
-	default:
-		execTemplate(w, "multi-normal", nil)
-	}
+//
+//	SELECT id, name FROM products WHERE id=X
+var multiParam = &sqlEndpoint{
+	db:      shopMySQL,
+	param:   "id",
+	query:   "SELECT id, name FROM products WHERE id=%s",
+	found:   "multi-normal",
+	empty:   "multi-false",
+	onError: showMySQLError,
 }
 
-// handlePost simulates a POST endpoint where the "username" body parameter
-// is injectable via boolean-blind technique.
+// postLogin simulates a POST login form where the "username" body
+// parameter is injectable via boolean-blind technique.
 //
 // POST /vuln/post
 // Body: username=X&password=Y (application/x-www-form-urlencoded)
-func handlePost(w http.ResponseWriter, r *http.Request) {
-	if err := r.ParseForm(); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
-	}
-
-	username := r.FormValue("username")
-
-	switch {
-	case containsCI(username, "ASCII(SUBSTRING"):
-		if evaluateASCIISubstring(username, mockVersionMySQL) {
-			execTemplate(w, "post-normal", nil)
-		} else {
-			execTemplate(w, "post-false", nil)
-		}
-	case containsCI(username, "LENGTH("):
-		if evaluateLength(username, mockVersionMySQL) {
-			execTemplate(w, "post-normal", nil)
-		} else {
-			execTemplate(w, "post-false", nil)
+//
+//	SELECT username FROM users WHERE username='<first word of X>'<rest of X>
+//
+// The application quotes only the first word of the username, so anything
+// after a space lands outside the literal.
+var postLogin = &sqlEndpoint{
+	db:    shopMySQL,
+	param: "username",
+	query: "SELECT username FROM users WHERE username=%s",
+	prepare: func(v string) string {
+		word, rest, _ := strings.Cut(v, " ")
+		if rest != "" {
+			rest = " " + rest
 		}
-	case containsCI(username, "AND 1=2") || containsFalseCondition(username):
-		execTemplate(w, "post-false", nil)
-	case containsCI(username, "AND 1=1") || containsTrueCondition(username):
-		execTemplate(w, "post-normal", nil)
-	case strings.Contains(username, "'"):
-		// Single quote causes a SQL error, which looks different from normal
+		return "'" + word + "'" + rest
+	},
+	found: "post-normal",
+	empty: "post-false",
+	onError: func(w http.ResponseWriter, _ *sqlmock.Error) {
 		execTemplate(w, "post-error", nil)
-	default:
-		execTemplate(w, "post-normal", nil)
-	}
+	},
 }
 
-// --------------------------------------------------------------------------
-// Helper functions
-// --------------------------------------------------------------------------
-
-// containsCI performs a case-insensitive contains check.
-func containsCI(s, substr string) bool {
-	return strings.Contains(strings.ToUpper(s), strings.ToUpper(substr))
+// timeBased simulates a time-based blind injectable endpoint. The page
+// never changes; a query that calls SLEEP or PG_SLEEP delays the response
+// by the requested time, capped at timebasedSleepCap.
+//
+// GET /vuln/timebased-mysql?id=X
+// GET /vuln/timebased-postgres?id=X
+//
+//	SELECT id FROM products WHERE id=X
+var timeBased = &sqlEndpoint{
+	db:    shopGeneric,
+	param: "id",
+	query: "SELECT id FROM products WHERE id=%s",
+	found: "timebased-normal",
+	empty: "timebased-normal",
+	sleep: true,
 }
 
-// containsTrueCondition checks for quoted true conditions like '1'='1'.
-func containsTrueCondition(s string) bool {
-	return strings.Contains(s, "'1'='1")
+// timeBasedNoSleep is timeBased behind a WAF that blocks SLEEP with a
+// generic 403 page, so only a heavy query such as BENCHMARK can delay the
+// response. BENCHMARK runs 10,000,000 iterations a second, capped at
+// timebasedSleepCap.
+//
+// GET /vuln/timebased-nosleep?id=X
+//
+//	SELECT id FROM products WHERE id=X
+var timeBasedNoSleep = &sqlEndpoint{
+	db:    shopGeneric,
+	param: "id",
+	query: "SELECT id FROM products WHERE id=%s",
+	block: func(v string) bool { return strings.Contains(strings.ToUpper(v), "SLEEP(") },
+	found: "timebased-normal",
+	empty: "timebased-normal",
+	sleep: true,
 }
 
-// containsFalseCondition checks for quoted false conditions like '1'='2'.
-func containsFalseCondition(s string) bool {
-	return strings.Contains(s, "'1'='2")
+// timeBasedMSSQL simulates an MSSQL time-based blind injectable endpoint
+// whose driver runs stacked statements. The page never changes; a stacked
+// IF (condition) WAITFOR DELAY delays the response when the condition
+// holds, capped at timebasedSleepCap. Inline heavy queries fail: the
+// fixture has no information_schema to cross join.
+//
+// GET /vuln/timebased-mssql?id=X
+//
+//	SELECT id FROM products WHERE id=X
+var timeBasedMSSQL = &sqlEndpoint{
+	db:    shopMSSQLTimed,
+	param: "id",
+	query: "SELECT id FROM products WHERE id=%s",
+	found: "timebased-normal",
+	empty: "timebased-normal",
+	sleep: true,
 }
 
-// evaluateASCIISubstring evaluates an ASCII(SUBSTRING(...,pos,1))>val probe
-// against the mock data. Returns true if the ASCII value of the character at
-// the given position is greater than the comparison value.
-func evaluateASCIISubstring(input, mockData string) bool {
-	matches := asciiSubstringPattern.FindStringSubmatch(input)
-	if len(matches) < 3 {
-		return false
-	}
+// costlyJoin simulates an endpoint whose page never changes but whose
+// query cost does: when the WHERE clause matches, the application runs an
+// expensive join over the rows (costlyFound), and when it does not, it
+// returns at once (costlyEmpty). Both carry up to costlyJitter of
+// deterministic jitter.
+//
+// GET /vuln/costly?id=X
+//
+//	SELECT name FROM products WHERE id=X
+var costlyJoin = &sqlEndpoint{
+	db:    shopMySQL,
+	param: "id",
+	query: "SELECT name FROM products WHERE id=%s",
+	found: "timebased-normal",
+	empty: "timebased-normal",
+	cost: func(found bool) time.Duration {
+		if found {
+			return costlyFound + costJitter()
+		}
+		return costlyEmpty + costJitter()
+	},
+}
 
-	pos, err := strconv.Atoi(matches[1])
-	if err != nil || pos < 1 || pos > len(mockData) {
-		return false
-	}
+const (
+	costlyFound  = 400 * time.Millisecond
+	costlyEmpty  = 20 * time.Millisecond
+	costlyJitter = 30 * time.Millisecond
+)
 
-	cmpVal, err := strconv.Atoi(matches[2])
-	if err != nil {
-		return false
-	}
+var (
+	costMu  sync.Mutex
+	costRNG = rand.New(rand.NewPCG(1, 2))
+)
 
-	charVal := int(mockData[pos-1])
-	return charVal > cmpVal
+// costJitter returns the next jitter of the costly endpoint, from a fixed
+// seed.
+func costJitter() time.Duration {
+	costMu.Lock()
+	defer costMu.Unlock()
+	return time.Duration(costRNG.Int64N(int64(costlyJitter)))
 }
 
-// evaluateLength evaluates a LENGTH(...)>val probe against the mock data.
-// Returns true if the length of the mock data is greater than the comparison value.
-func evaluateLength(input, mockData string) bool {
-	matches := lengthPattern.FindStringSubmatch(input)
-	if len(matches) < 2 {
-		return false
-	}
-
-	cmpVal, err := strconv.Atoi(matches[1])
-	if err != nil {
-		return false
-	}
+// unionMySQL simulates a MySQL UNION-based injectable endpoint listing
+// every row of a two-column query. ORDER BY past the second column fails
+// with the MySQL error, the only database error shown; an empty result or
+// any other error, such as a UNION SELECT with the wrong column count,
+// shows the first product instead, so the page answers nothing but UNION
+// SELECTs. GROUP_CONCAT over the seeded members table exceeds the default
+// group_concat_max_len and comes back truncated.
+//
+// GET /vuln/union-mysql?id=X
+//
+//	SELECT id, name FROM products WHERE id=X
+var unionMySQL = &sqlEndpoint{
+	db:         shopMySQL,
+	param:      "id",
+	query:      "SELECT id, name FROM products WHERE id=%s",
+	fallback:   "SELECT id, name FROM products WHERE id=1",
+	found:      "union-mysql",
+	empty:      "union-mysql",
+	orderError: true,
+}
 
-	return len(mockData) > cmpVal
+// unionPostgres is the PostgreSQL counterpart of unionMySQL. Its ORDER BY
+// error is worded as MySQL's too.
+//
+// GET /vuln/union-postgres?id=X
+//
+//	SELECT id, name FROM users WHERE id=X
+var unionPostgres = &sqlEndpoint{
+	db:         shopPostgres,
+	param:      "id",
+	query:      "SELECT id, name FROM users WHERE id=%s",
+	fallback:   "SELECT id, name FROM users WHERE id=1",
+	found:      "union-pg",
+	empty:      "union-pg",
+	orderError: true,
 }
 
-// shouldTimebasedSleep returns true when the payload should cause a DB sleep,
-// mirroring real DBMS behaviour:
-//   - IF(1=1,SLEEP(n),0)   → true condition  → sleep
-//   - IF(1=2,SLEEP(n),0)   → false condition → no sleep
-//   - CASE WHEN (1=1) THEN (SELECT 1 FROM PG_SLEEP(n)) ELSE 1 END → sleep
-//   - CASE WHEN (1=2) THEN (SELECT 1 FROM PG_SLEEP(n)) ELSE 1 END → no sleep
-func shouldTimebasedSleep(payload string) (seconds int, ok bool) {
-	matches := sleepSecondsPattern.FindStringSubmatch(payload)
-	if len(matches) < 2 {
-		return 0, false
+// unionMySQLErrors and unionPostgresErrors are unionMySQL and unionPostgres
+// showing every database error verbatim, so that error-based probes and
+// fingerprinting work on them too.
+//
+// GET /vuln/union-mysql-errors?id=X
+// GET /vuln/union-postgres-errors?id=X
+var (
+	unionMySQLErrors = &sqlEndpoint{
+		db:      shopMySQL,
+		param:   "id",
+		query:   "SELECT id, name FROM products WHERE id=%s",
+		found:   "union-mysql",
+		empty:   "union-mysql",
+		onError: showError(""),
 	}
-	n, err := strconv.Atoi(matches[1])
-	if err != nil || n <= 0 {
-		return 0, false
+	unionPostgresErrors = &sqlEndpoint{
+		db:      shopPostgres,
+		param:   "id",
+		query:   "SELECT id, name FROM users WHERE id=%s",
+		found:   "union-pg",
+		empty:   "union-pg",
+		onError: showError(pgQueryFailed),
 	}
+)
 
-	upper := strings.ToUpper(payload)
-	hasSleep := strings.Contains(upper, "SLEEP(")
-	isTrueCond := strings.Contains(upper, "1=1")
-	isFalseCond := strings.Contains(upper, "1=2")
+// unionCapped is unionMySQL with a page that shows only the first row, so
+// UNION output is visible only when the original row is filtered out.
+//
+// GET /vuln/union-capped?id=X
+//
+//	SELECT id, name FROM products WHERE id=X
+var unionCapped = &sqlEndpoint{
+	db:      shopMySQL,
+	param:   "id",
+	query:   "SELECT id, name FROM products WHERE id=%s",
+	found:   "union-mysql",
+	empty:   "union-mysql",
+	maxRows: 1,
+	onError: showError(""),
+}
 
-	// Sleep only when the condition is TRUE (contains 1=1, not only 1=2).
-	if hasSleep && isTrueCond && !isFalseCond {
-		return n, true
-	}
-	return 0, false
+// unionFiltered is unionMySQL behind a WAF that blocks ORDER BY with a
+// generic 403 page, so the column count can only be found with UNION
+// SELECT NULL probes.
+//
+// GET /vuln/union-filtered?id=X
+//
+//	SELECT id, name FROM products WHERE id=X
+var unionFiltered = &sqlEndpoint{
+	db:      shopMySQL,
+	param:   "id",
+	query:   "SELECT id, name FROM products WHERE id=%s",
+	block:   func(v string) bool { return strings.Contains(strings.ToUpper(v), "ORDER BY") },
+	found:   "union-mysql",
+	empty:   "union-mysql",
+	onError: showError(""),
 }
 
-// handleTimeBasedMySQL simulates a MySQL time-based blind injectable endpoint.
+// unionNoXPath is unionMySQL behind a WAF that blocks EXTRACTVALUE and
+// UPDATEXML with a generic 403 page, so the XPath errors error-based
+// extraction reads through never come back while UNION still works.
 //
-// GET /vuln/timebased-mysql?id=X
-//   - TRUE condition (1=1 + SLEEP): sleeps min(n, cap) seconds
-//   - FALSE condition (1=2 + SLEEP): responds immediately (no sleep)
+// GET /vuln/union-xpath?id=X
 //
-// This matches real MySQL IF(condition, SLEEP(n), 0) evaluation semantics.
-func handleTimeBasedMySQL(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
+//	SELECT id, name FROM products WHERE id=X
+var unionNoXPath = &sqlEndpoint{
+	db:    shopMySQL,
+	param: "id",
+	query: "SELECT id, name FROM products WHERE id=%s",
+	block: func(v string) bool {
+		v = strings.ToUpper(v)
+		return strings.Contains(v, "EXTRACTVALUE") || strings.Contains(v, "UPDATEXML")
+	},
+	found:   "union-mysql",
+	empty:   "union-mysql",
+	onError: showError(""),
+}
 
-	if n, ok := shouldTimebasedSleep(id); ok {
-		d := time.Duration(n) * time.Second
-		if d > timebasedSleepCap {
-			d = timebasedSleepCap
-		}
-		time.Sleep(d)
-	}
+// noQuote is a MySQL endpoint behind a WAF that blocks any single quote
+// with a generic 403 page. Rows and database errors are shown, so UNION
+// and error-based injection work once their string literals are
+// hex-encoded.
+//
+// GET /vuln/noquote?id=X
+//
+//	SELECT id, name FROM products WHERE id=X
+var noQuote = &sqlEndpoint{
+	db:      shopMySQL,
+	param:   "id",
+	query:   "SELECT id, name FROM products WHERE id=%s",
+	block:   func(v string) bool { return strings.Contains(v, "'") },
+	found:   "union-mysql",
+	empty:   "union-mysql",
+	onError: showMySQLError,
+}
 
-	execTemplate(w, "timebased-normal", nil)
+// parenString simulates a MySQL endpoint that wraps the quoted value in
+// parentheses, so that only the ') boundary, the last in the corpus,
+// escapes it. Rows and database errors are shown.
+//
+// GET /vuln/paren?name=X
+//
+//	SELECT id, name FROM products WHERE (name='X')
+var parenString = &sqlEndpoint{
+	db:      shopMySQL,
+	param:   "name",
+	query:   "SELECT id, name FROM products WHERE (name='%s')",
+	sleep:   true,
+	found:   "union-mysql",
+	empty:   "mysql-false",
+	onError: showMySQLError,
 }
 
-// handleTimeBasedPostgres simulates a PostgreSQL time-based blind injectable endpoint.
+// likeSearch simulates a product search injectable inside a LIKE pattern.
 //
-// GET /vuln/timebased-postgres?id=X
-//   - TRUE condition: sleeps min(n, cap) seconds
-//   - FALSE condition: responds immediately
-func handleTimeBasedPostgres(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
+// GET /vuln/like?q=X
+//
+//	SELECT id, name, price FROM products WHERE name LIKE '%X%'
+var likeSearch = &sqlEndpoint{
+	db:      shopMySQL,
+	param:   "q",
+	query:   "SELECT id, name, price FROM products WHERE name LIKE '%%%s%%'",
+	found:   "like-found",
+	empty:   "like-empty",
+	onError: showError(""),
+}
 
-	if n, ok := shouldTimebasedSleep(id); ok {
-		d := time.Duration(n) * time.Second
-		if d > timebasedSleepCap {
-			d = timebasedSleepCap
-		}
-		time.Sleep(d)
-	}
+// countWrapped simulates a query wrapped in an aggregate: the page shows a
+// count, never the rows, so only the number reflects the condition.
+//
+// GET /vuln/count?id=X
+//
+//	SELECT COUNT(*) FROM products WHERE id=X
+var countWrapped = &sqlEndpoint{
+	db:    shopMySQL,
+	param: "id",
+	query: "SELECT COUNT(*) FROM products WHERE id=%s",
+	found: "count",
+	empty: "count",
+}
 
-	execTemplate(w, "timebased-normal", nil)
+// pagePostgres simulates a PostgreSQL product listing whose page size is
+// spliced into LIMIT. Database errors show the empty page.
+//
+// GET /vuln/page-postgres?limit=X
+//
+//	SELECT id, name FROM products ORDER BY id LIMIT X
+//
+// No quote or appended condition survives there, but LIMIT takes any
+// integer expression.
+var pagePostgres = &sqlEndpoint{
+	db:    shopPostgres,
+	param: "limit",
+	query: "SELECT id, name FROM products ORDER BY id LIMIT %s",
+	found: "page-found",
+	empty: "page-empty",
 }
 
-// handleErrorMSSQL simulates an MSSQL error-based injectable endpoint.
+// pageMySQL simulates a MySQL 5.7 product listing whose offset is spliced
+// into LIMIT. Database errors are shown verbatim.
 //
-// GET /vuln/error-mssql?id=X
-//   - If X contains CONVERT(INT,...) or CAST(... AS INT): returns MSSQL type-conversion error
-//   - If X contains "'": returns MSSQL unclosed quotation mark error
-//   - If X contains "AND 1=2": returns empty result
-//   - Otherwise: returns normal result
-func handleErrorMSSQL(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
+// GET /vuln/page-mysql?offset=X
+//
+//	SELECT id, name FROM products ORDER BY id LIMIT 2 OFFSET X
+//
+// MySQL takes only integer literals there; PROCEDURE ANALYSE after the
+// value is the way in.
+var pageMySQL = &sqlEndpoint{
+	db:      shopMySQL57,
+	param:   "offset",
+	query:   "SELECT id, name FROM products ORDER BY id LIMIT 2 OFFSET %s",
+	found:   "page-found",
+	empty:   "page-empty",
+	onError: showMySQLError,
+}
 
-	switch {
-	case containsCI(id, "CONVERT(INT") || (containsCI(id, "CAST(") && containsCI(id, "AS INT")):
-		execTemplate(w, "mssql-convert-error", nil)
-	case strings.Contains(id, "'"):
-		execTemplate(w, "mssql-syntax-error", id)
-	case containsCI(id, "AND 1=2") || containsFalseCondition(id):
-		execTemplate(w, "mssql-false", nil)
-	case containsCI(id, "AND 1=1") || containsTrueCondition(id):
-		execTemplate(w, "mssql-normal", nil)
-	default:
-		execTemplate(w, "mssql-normal", nil)
+// handlePageSafe is the safe counterpart of pagePostgres: the page size is
+// parsed as an integer first, and anything else gets the "Invalid page
+// size." page.
+//
+// GET /vuln/page-safe?limit=X
+func handlePageSafe(w http.ResponseWriter, r *http.Request) {
+	n, err := strconv.Atoi(r.FormValue("limit"))
+	if err != nil || n < 0 {
+		execTemplate(w, "page-invalid", nil)
+		return
+	}
+	res, qerr := runSQL(shopPostgres, fmt.Sprintf("SELECT id, name FROM products ORDER BY id LIMIT %d", n))
+	if qerr != nil || len(res.Rows) == 0 {
+		execTemplate(w, "page-empty", nil)
+		return
 	}
+	execTemplate(w, "page-found", formatRows(res.Rows, 0))
 }
 
-// handleUnionMySQL simulates a MySQL UNION-based injectable endpoint.
+// splitNameMaxLen is the length cap the /vuln/split endpoint applies to the
+// "name" parameter.
+const splitNameMaxLen = 12
+
+// handleSplit simulates a query that concatenates two parameters into one
+// WHERE clause with different sanitisation per parameter:
 //
-// GET /vuln/union-mysql?id=X
-//   - ORDER BY N where N <= 2: normal response (2-column query)
-//   - ORDER BY N where N > 2: error response (column out of range)
-//   - UNION SELECT containing sentinel: response includes sentinel
-//   - UNION SELECT (other): response includes ~mockVersionMySQL~ markers
-//   - Otherwise: normal product listing
-func handleUnionMySQL(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
-	upper := strings.ToUpper(id)
+//	SELECT name FROM contacts WHERE name='<name>' AND city='<city>'
+//
+// GET /vuln/split?name=X&city=Y
+//   - name: quotes pass through, but "-" and "#" are stripped and the value
+//     is cut to splitNameMaxLen characters
+//   - city: single quotes are escaped by doubling
+//
+// Neither parameter alone can carry a full payload, but a comment bridge
+// split across both (name=admin'/*, city=*/ AND 1=1-- -) succeeds.
+func handleSplit(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
 
-	if n, ok := parseVulnOrderByN(upper); ok {
-		if n > unionNumCols {
-			execTemplate(w, "union-order-error", n)
-			return
-		}
-		execTemplate(w, "union-mysql-normal", nil)
-		return
+	name := strings.NewReplacer("-", "", "#", "").Replace(q.Get("name"))
+	if len(name) > splitNameMaxLen {
+		name = name[:splitNameMaxLen]
 	}
+	city := strings.ReplaceAll(q.Get("city"), "'", "''")
 
-	if containsCI(id, "UNION") && containsCI(id, "SELECT") {
-		if strings.Contains(id, unionSentinel) {
-			execTemplate(w, "union-mysql-sentinel", nil)
-			return
-		}
-		execTemplate(w, "union-mysql-injected", nil)
-		return
+	res, qerr := runSQL(shopMySQL, "SELECT name FROM contacts WHERE name='"+name+"' AND city='"+city+"'")
+	switch {
+	case qerr != nil:
+		execTemplate(w, "sql-error", errorText(qerr.Error()))
+	case len(res.Rows) > 0:
+		execTemplate(w, "split-found", formatRows(res.Rows, 0))
+	default:
+		execTemplate(w, "split-empty", nil)
 	}
-
-	execTemplate(w, "union-mysql-normal", nil)
 }
 
-// handleUnionPostgres simulates a PostgreSQL UNION-based injectable endpoint.
+// localeRows are the language-neutral table rows shared by both locale
+// pages. There are enough of them that the translated lines keep the body
+// ratio just above the boolean technique's 0.95 threshold.
+var localeRows = func() []int {
+	rows := make([]int, 55)
+	for i := range rows {
+		rows[i] = 1001 + i
+	}
+	return rows
+}()
+
+// handleLocale simulates a boolean-injectable endpoint whose vulnerable
+// query loads the user's locale settings. TRUE keeps the user's language
+// (Japanese); FALSE or a broken query finds no row and the page falls back
+// to the default language (English). Only a few lines are translated, so
+// the pages are nearly identical by body ratio.
 //
-// GET /vuln/union-postgres?id=X
-//   - ORDER BY N where N <= 2: normal response (2-column query)
-//   - ORDER BY N where N > 2: error response (column out of range)
-//   - UNION SELECT containing sentinel: response includes sentinel
-//   - UNION SELECT (other): response includes ~mockVersionPostgreSQL~ markers
-//   - Otherwise: normal user listing
-func handleUnionPostgres(w http.ResponseWriter, r *http.Request) {
+// GET /vuln/locale?id=X
+//
+//	SELECT locale FROM settings WHERE user_id=X
+func handleLocale(w http.ResponseWriter, r *http.Request) {
 	id := r.URL.Query().Get("id")
-	upper := strings.ToUpper(id)
 
-	if n, ok := parseVulnOrderByN(upper); ok {
-		if n > unionNumCols {
-			execTemplate(w, "union-order-error", n)
-			return
-		}
-		execTemplate(w, "union-pg-normal", nil)
+	res, qerr := runSQL(shopMySQL, "SELECT locale FROM settings WHERE user_id="+id)
+	if qerr == nil && len(res.Rows) > 0 {
+		w.Header().Set("Content-Language", "ja")
+		execTemplate(w, "locale-ja", localeRows)
 		return
 	}
+	w.Header().Set("Content-Language", "en")
+	execTemplate(w, "locale-en", localeRows)
+}
 
-	if containsCI(id, "UNION") && containsCI(id, "SELECT") {
-		if strings.Contains(id, unionSentinel) {
-			execTemplate(w, "union-pg-sentinel", nil)
-			return
-		}
-		execTemplate(w, "union-pg-injected", nil)
+// handleGraphQL simulates a GraphQL API whose products resolver splices
+// the nested filter.id variable, an ID sent as a string, into its query;
+// first is only validated by the schema and never reaches SQL. A persisted
+// query hash may stand in for the query. Every response carries Apollo
+// tracing timings that differ from one request to the next, and database
+// errors come back in the errors array, as resolver exceptions do.
+//
+// POST /vuln/graphql ({"query": "...", "variables": {"filter": {"id": "X"}, "first": N}})
+//
+//	SELECT id, name FROM products WHERE id=X
+func handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	type product struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	var req struct {
+		Query     string `json:"query"`
+		Variables struct {
+			Filter struct {
+				ID string `json:"id"`
+			} `json:"filter"`
+		} `json:"variables"`
+		Extensions struct {
+			PersistedQuery struct {
+				Hash string `json:"sha256Hash"`
+			} `json:"persistedQuery"`
+		} `json:"extensions"`
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.Query == "" && req.Extensions.PersistedQuery.Hash == "") {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]any{ //nolint:errcheck
+			"errors": []map[string]string{{"message": "Must provide query string."}},
+		})
 		return
 	}
 
-	execTemplate(w, "union-pg-normal", nil)
+	start := time.Now().UTC()
+	resp := map[string]any{}
+	res, qerr := runSQL(shopMySQL, "SELECT id, name FROM products WHERE id="+req.Variables.Filter.ID)
+	if qerr != nil {
+		resp["data"] = nil
+		resp["errors"] = []map[string]any{{"message": qerr.Error(), "path": []string{"products"}}}
+	} else {
+		products := []product{}
+		for _, row := range formatRows(res.Rows, 0) {
+			products = append(products, product{ID: row[0], Name: row[1]})
+		}
+		resp["data"] = map[string]any{"products": products}
+	}
+	offset, duration := rand.IntN(50000), rand.IntN(900000)
+	resp["extensions"] = map[string]any{
+		"tracing": map[string]any{
+			"version":   1,
+			"startTime": start.Format(time.RFC3339Nano),
+			"endTime":   start.Add(time.Duration(duration)).Format(time.RFC3339Nano),
+			"duration":  duration,
+			"execution": map[string]any{
+				"resolvers": []map[string]any{{
+					"path": []string{"products"}, "parentType": "Query", "fieldName": "products",
+					"returnType": "[Product!]!", "startOffset": offset, "duration": duration - offset,
+				}},
+			},
+		},
+	}
+	json.NewEncoder(w).Encode(resp) //nolint:errcheck
 }
 
-// parseVulnOrderByN extracts N from "ORDER BY N" in an upper-case string.
-// Returns (0, false) if no ORDER BY clause is found or parsing fails.
-func parseVulnOrderByN(upper string) (int, bool) {
-	const token = "ORDER BY"
-	idx := strings.Index(upper, token)
-	if idx == -1 {
-		return 0, false
+// handleAPIProducts simulates a boolean-injectable JSON API. Every response
+// carries a fresh request ID and timestamp, so no two bodies are alike
+// byte for byte; only the data array tells TRUE from FALSE. Database errors
+// answer an empty array.
+//
+// GET /vuln/api/products?id=X
+//
+//	SELECT id, name FROM products WHERE id=X
+func handleAPIProducts(w http.ResponseWriter, r *http.Request) {
+	type product struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
 	}
-	rest := strings.TrimSpace(upper[idx+len(token):])
-	var n int
-	if _, err := fmt.Sscan(rest, &n); err != nil {
-		return 0, false
+	data := []product{}
+	res, qerr := runSQL(shopMySQL, "SELECT id, name FROM products WHERE id="+r.URL.Query().Get("id"))
+	if qerr == nil {
+		for _, row := range formatRows(res.Rows, 0) {
+			data = append(data, product{ID: row[0], Name: row[1]})
+		}
 	}
-	return n, true
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{ //nolint:errcheck
+		"data":      data,
+		"count":     len(data),
+		"requestId": fmt.Sprintf("req-%016x", rand.Uint64()),
+		"timestamp": time.Now().UTC().Format(time.RFC3339Nano),
+	})
 }
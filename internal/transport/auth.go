@@ -0,0 +1,181 @@
+package transport
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// authenticator holds the credentials of ClientOptions.AuthType.
+type authenticator struct {
+	header string           // Authorization value; "" for NTLM
+	ntlm   *ntlmCredentials // Set for NTLM
+}
+
+// newAuthenticator parses AuthCredentials for authType: user:password
+// for basic, the token for bearer and [DOMAIN\]user:password for ntlm.
+// It returns nil when no authentication is configured.
+func newAuthenticator(authType, credentials string) (*authenticator, error) {
+	switch strings.ToLower(authType) {
+	case "":
+		if credentials != "" {
+			return nil, errors.New("auth credentials given without an auth type")
+		}
+		return nil, nil
+	case "basic":
+		if !strings.Contains(credentials, ":") {
+			return nil, errors.New("basic auth credentials must be user:password")
+		}
+		return &authenticator{header: "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))}, nil
+	case "bearer":
+		if credentials == "" {
+			return nil, errors.New("bearer auth requires a token")
+		}
+		return &authenticator{header: "Bearer " + credentials}, nil
+	case "ntlm":
+		account, password, ok := strings.Cut(credentials, ":")
+		if !ok || account == "" {
+			return nil, errors.New(`ntlm auth credentials must be [DOMAIN\]user:password`)
+		}
+		domain, user, found := strings.Cut(account, `\`)
+		if !found {
+			domain, user = "", account
+		}
+		return &authenticator{ntlm: &ntlmCredentials{domain: domain, user: user, password: password}}, nil
+	}
+	return nil, fmt.Errorf("unknown auth type %q (want basic, bearer or ntlm)", authType)
+}
+
+// authTransport authenticates the requests it round-trips to the origin
+// of the request that started their redirect chain. A redirect to any
+// other scheme, host or port is sent without credentials.
+type authTransport struct {
+	next http.RoundTripper
+	auth *authenticator
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !sameOrigin(req) {
+		return t.next.RoundTrip(req)
+	}
+	if t.auth.ntlm != nil {
+		return t.roundTripNTLM(req)
+	}
+	r := req.Clone(req.Context())
+	r.Header.Set("Authorization", t.auth.header)
+	return t.next.RoundTrip(r)
+}
+
+// CloseIdleConnections closes the idle connections of the wrapped
+// transport.
+func (t *authTransport) CloseIdleConnections() { closeIdle(t.next) }
+
+// roundTripNTLM sends req as is, since NTLM authenticates connections and
+// a kept-alive one may already be, and runs the handshake when the server
+// asks for it: a negotiate leg, then req again answering the challenge.
+// Both legs go out on the connection the 401 came back on, which is the
+// one the pool hands out next.
+func (t *authTransport) roundTripNTLM(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	scheme, _ := ntlmToken(resp)
+	if scheme == "" || (req.Body != nil && req.GetBody == nil) {
+		return resp, nil
+	}
+	drain(resp)
+
+	negotiate, err := replay(req)
+	if err != nil {
+		return nil, err
+	}
+	negotiate.Header.Set("Authorization", scheme+" "+base64.StdEncoding.EncodeToString(ntlmNegotiate()))
+	negotiate.Close = false
+	resp, err = t.next.RoundTrip(negotiate)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	_, token := ntlmToken(resp)
+	if token == nil {
+		return resp, nil
+	}
+	drain(resp)
+
+	challenge, err := parseNTLMChallenge(token)
+	if err != nil {
+		return nil, fmt.Errorf("NTLM authentication: %w", err)
+	}
+	msg, err := t.auth.ntlm.authenticate(challenge)
+	if err != nil {
+		return nil, fmt.Errorf("NTLM authentication: %w", err)
+	}
+	authenticate, err := replay(req)
+	if err != nil {
+		return nil, err
+	}
+	authenticate.Header.Set("Authorization", scheme+" "+base64.StdEncoding.EncodeToString(msg))
+	return t.next.RoundTrip(authenticate)
+}
+
+// ntlmToken returns the scheme, NTLM or Negotiate, under which a 401
+// offers NTLM, and the token that comes with it, if any.
+func ntlmToken(resp *http.Response) (string, []byte) {
+	for _, v := range resp.Header.Values("WWW-Authenticate") {
+		scheme, param, _ := strings.Cut(strings.TrimSpace(v), " ")
+		if !strings.EqualFold(scheme, "NTLM") && !strings.EqualFold(scheme, "Negotiate") {
+			continue
+		}
+		token, err := base64.StdEncoding.DecodeString(strings.TrimSpace(param))
+		if err != nil || len(token) == 0 {
+			token = nil
+		}
+		return scheme, token
+	}
+	return "", nil
+}
+
+// replay returns a copy of req with a fresh body.
+func replay(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
+// drain reads and closes the body of a response that is answered on the
+// same connection, so the connection returns to the pool.
+func drain(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	resp.Body.Close()
+}
+
+// sameOrigin reports whether req goes to the scheme, host and port of the
+// first request of its redirect chain.
+func sameOrigin(req *http.Request) bool {
+	first := req
+	for first.Response != nil && first.Response.Request != nil {
+		first = first.Response.Request
+	}
+	return origin(first) == origin(req)
+}
+
+func origin(req *http.Request) string {
+	port := req.URL.Port()
+	if port == "" {
+		port = "80"
+		if req.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return req.URL.Scheme + "://" + net.JoinHostPort(strings.ToLower(req.URL.Hostname()), port)
+}
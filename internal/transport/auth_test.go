@@ -0,0 +1,241 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+func TestClient_BasicAuth(t *testing.T) {
+	var mu sync.Mutex
+	var leaked []string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		leaked = append(leaked, r.Header.Get("Authorization"))
+		mu.Unlock()
+	}))
+	defer other.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "s3cret:x" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	mux.Handle("/redirect", http.RedirectHandler("/ok", http.StatusFound))
+	mux.Handle("/away", http.RedirectHandler(other.URL+"/", http.StatusFound))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, FollowRedirects: true, AuthType: "basic", AuthCredentials: "admin:s3cret:x"})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	ctx := context.Background()
+	for _, path := range []string{"/ok", "/redirect"} {
+		resp, err := c.Do(ctx, &Request{URL: srv.URL + path})
+		if err != nil {
+			t.Fatalf("Do(%s): %v", path, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Do(%s) = %d, want 200", path, resp.StatusCode)
+		}
+	}
+
+	if _, err := c.Do(ctx, &Request{URL: srv.URL + "/away"}); err != nil {
+		t.Fatalf("Do(/away): %v", err)
+	}
+	if len(leaked) != 1 || leaked[0] != "" {
+		t.Errorf("cross-origin redirect target got Authorization %q, want none", leaked)
+	}
+}
+
+func TestClient_BearerAuth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, AuthType: "Bearer", AuthCredentials: "eyJhbGciOi.x.y"})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	resp, err := c.Do(context.Background(), &Request{URL: srv.URL})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := string(resp.Body); got != "Bearer eyJhbGciOi.x.y" {
+		t.Errorf("Authorization = %q", got)
+	}
+}
+
+func TestClient_AuthOptionErrors(t *testing.T) {
+	tests := []struct {
+		authType, cred, wantErr string
+	}{
+		{"digest", "a:b", "unknown auth type"},
+		{"basic", "admin", "user:password"},
+		{"bearer", "", "requires a token"},
+		{"ntlm", `CORP\admin`, "user:password"},
+		{"", "admin:pw", "without an auth type"},
+	}
+	for _, tt := range tests {
+		_, err := NewClient(ClientOptions{AuthType: tt.authType, AuthCredentials: tt.cred})
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("NewClient(%q, %q) = %v, want an error containing %q", tt.authType, tt.cred, err, tt.wantErr)
+		}
+	}
+}
+
+// ntlmServer is a scripted NTLM endpoint. It challenges a connection that
+// sends a NEGOTIATE_MESSAGE, checks the NTLMv2 proof of the account's
+// password in the AUTHENTICATE_MESSAGE that follows on the same
+// connection, and from then on serves that connection unchallenged.
+type ntlmServer struct {
+	*httptest.Server
+	password string
+
+	mu         sync.Mutex
+	challenged map[string]bool // Remote addresses sent a challenge
+	authed     map[string]bool // Remote addresses authenticated
+	negotiates int
+}
+
+var ntlmServerChallenge = []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+func newNTLMServer(t *testing.T, password string) *ntlmServer {
+	t.Helper()
+	s := &ntlmServer{password: password, challenged: map[string]bool{}, authed: map[string]bool{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *ntlmServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	welcome := func() { w.Write([]byte("welcome " + string(body))) }
+
+	if s.authed[r.RemoteAddr] {
+		welcome()
+		return
+	}
+	param, ok := strings.CutPrefix(r.Header.Get("Authorization"), "NTLM ")
+	msg, _ := base64.StdEncoding.DecodeString(param)
+	switch {
+	case ok && len(msg) >= 12 && msg[8] == 1:
+		s.negotiates++
+		s.challenged[r.RemoteAddr] = true
+		w.Header().Set("WWW-Authenticate", "NTLM "+base64.StdEncoding.EncodeToString(ntlmChallengeMessage()))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	case ok && len(msg) >= 64 && msg[8] == 3 && s.challenged[r.RemoteAddr] && s.verify(msg):
+		s.authed[r.RemoteAddr] = true
+		welcome()
+		return
+	}
+	w.Header().Set("WWW-Authenticate", "NTLM")
+	w.WriteHeader(http.StatusUnauthorized)
+}
+
+// verify checks the NTLMv2 response of an AUTHENTICATE_MESSAGE.
+func (s *ntlmServer) verify(msg []byte) bool {
+	field := func(i int) []byte {
+		at := 12 + 8*i
+		n := int(binary.LittleEndian.Uint16(msg[at:]))
+		off := int(binary.LittleEndian.Uint32(msg[at+4:]))
+		return msg[off : off+n]
+	}
+	nt, domain, user := field(1), decodeUTF16(field(2)), decodeUTF16(field(3))
+	if domain != "CORP" || user != "admin" || len(nt) < 16 {
+		return false
+	}
+	h := hmac.New(md5.New, ntowfv2(user, s.password, domain))
+	h.Write(ntlmServerChallenge)
+	h.Write(nt[16:])
+	return hmac.Equal(h.Sum(nil), nt[:16])
+}
+
+// ntlmChallengeMessage returns a CHALLENGE_MESSAGE carrying
+// ntlmServerChallenge and a target info with a domain name.
+func ntlmChallengeMessage() []byte {
+	info := append(avPair(2, utf16le("CORP")), 0, 0, 0, 0)
+	msg := make([]byte, 48)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 2)
+	binary.LittleEndian.PutUint32(msg[20:], ntlmNegotiateFlags)
+	copy(msg[24:], ntlmServerChallenge)
+	binary.LittleEndian.PutUint16(msg[40:], uint16(len(info)))
+	binary.LittleEndian.PutUint16(msg[42:], uint16(len(info)))
+	binary.LittleEndian.PutUint32(msg[44:], 48)
+	return append(msg, info...)
+}
+
+func decodeUTF16(b []byte) string {
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+func TestClient_NTLMAuth(t *testing.T) {
+	srv := newNTLMServer(t, "Passw0rd")
+	c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, AuthType: "ntlm", AuthCredentials: `CORP\admin:Passw0rd`})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	ctx := context.Background()
+
+	resp, err := c.Do(ctx, &Request{Method: http.MethodPost, URL: srv.URL, Body: "id=1", ContentType: "application/x-www-form-urlencoded"})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := string(resp.Body); resp.StatusCode != http.StatusOK || got != "welcome id=1" {
+		t.Fatalf("got %d %q, want the POST body past the handshake", resp.StatusCode, got)
+	}
+
+	// The connection is authenticated now; the next request needs no
+	// handshake.
+	if _, err := c.Do(ctx, &Request{URL: srv.URL + "/?id=2"}); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.negotiates != 1 {
+		t.Errorf("server saw %d handshakes, want 1", srv.negotiates)
+	}
+}
+
+func TestClient_NTLMWrongPassword(t *testing.T) {
+	srv := newNTLMServer(t, "Passw0rd")
+	c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, AuthType: "ntlm", AuthCredentials: `CORP\admin:guess`})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	resp, err := c.Do(context.Background(), &Request{URL: srv.URL})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401 for a wrong password", resp.StatusCode)
+	}
+	if bytes.Contains(resp.Body, []byte("welcome")) {
+		t.Errorf("body = %q", resp.Body)
+	}
+}
@@ -0,0 +1,107 @@
+package transport
+
+import (
+	"bytes"
+	"hash/maphash"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+	"weak"
+)
+
+// BodyStore shares the bytes of identical response bodies. A scan sends
+// many probes whose pages come back byte for byte the same, and keeps many
+// of them alive at once: baselines, heuristic results, responses awaiting
+// comparison. Interned through a store, they all hold one copy.
+//
+// Bodies returned by Intern are shared and must not be modified.
+//
+// The store only holds weak references: a body is evicted once no response
+// refers to it any more, so its lifetime is that of the longest-held
+// response, not that of the store.
+type BodyStore struct {
+	seed    maphash.Seed
+	mu      sync.Mutex
+	entries map[uint64]storedBody
+
+	hits  atomic.Int64
+	saved atomic.Int64
+}
+
+// storedBody is a weak reference to an interned body's first byte, with
+// its length.
+type storedBody struct {
+	ptr weak.Pointer[byte]
+	n   int
+}
+
+// BodyStoreStats describes how much a BodyStore has shared.
+type BodyStoreStats struct {
+	Bodies     int   // Distinct bodies still referenced
+	Hits       int64 // Bodies replaced by an interned copy
+	SavedBytes int64 // Bytes not kept thanks to those hits
+}
+
+// NewBodyStore creates an empty store.
+func NewBodyStore() *BodyStore {
+	return &BodyStore{
+		seed:    maphash.MakeSeed(),
+		entries: make(map[uint64]storedBody),
+	}
+}
+
+// Intern returns the stored body equal to b, or stores b and returns it
+// when there is none. Empty bodies are returned as they are.
+func (s *BodyStore) Intern(b []byte) []byte {
+	if len(b) == 0 {
+		return b
+	}
+	key := maphash.Bytes(s.seed, b)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok {
+		if p := e.ptr.Value(); p != nil {
+			stored := unsafe.Slice(p, e.n)
+			if bytes.Equal(stored, b) {
+				s.hits.Add(1)
+				s.saved.Add(int64(len(b)))
+				return stored
+			}
+			// A hash collision: keep b to itself rather than evict a body
+			// that is still in use.
+			return b
+		}
+	}
+
+	// Trim spare capacity so an append by a careless holder cannot write
+	// into memory another response shares.
+	b = b[:len(b):len(b)]
+	first := &b[0]
+	s.entries[key] = storedBody{ptr: weak.Make(first), n: len(b)}
+	runtime.AddCleanup(first, s.evict, key)
+	return b
+}
+
+// evict drops the entry for key once its body has been collected, unless
+// the key has since been taken by a live body.
+func (s *BodyStore) evict(key uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok && e.ptr.Value() == nil {
+		delete(s.entries, key)
+	}
+}
+
+// Stats returns the store's counters.
+func (s *BodyStore) Stats() BodyStoreStats {
+	s.mu.Lock()
+	bodies := len(s.entries)
+	s.mu.Unlock()
+	return BodyStoreStats{
+		Bodies:     bodies,
+		Hits:       s.hits.Load(),
+		SavedBytes: s.saved.Load(),
+	}
+}
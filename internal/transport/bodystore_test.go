@@ -0,0 +1,67 @@
+package transport
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestBodyStore_SharesIdenticalBodies(t *testing.T) {
+	s := NewBodyStore()
+	a := s.Intern([]byte("<html>same page</html>"))
+	b := s.Intern([]byte("<html>same page</html>"))
+	if &a[0] != &b[0] {
+		t.Error("identical bodies should share one backing array")
+	}
+	c := s.Intern([]byte("<html>other page</html>"))
+	if &a[0] == &c[0] || string(c) != "<html>other page</html>" {
+		t.Errorf("distinct body was merged: %q", c)
+	}
+	if cap(a) != len(a) {
+		t.Errorf("cap = %d, want %d so appends cannot write into the shared body", cap(a), len(a))
+	}
+
+	st := s.Stats()
+	if st.Bodies != 2 || st.Hits != 1 || st.SavedBytes != int64(len(a)) {
+		t.Errorf("stats = %+v, want 2 bodies, 1 hit, %d bytes saved", st, len(a))
+	}
+	runtime.KeepAlive(b)
+	runtime.KeepAlive(c)
+}
+
+func TestBodyStore_Empty(t *testing.T) {
+	s := NewBodyStore()
+	if got := s.Intern(nil); got != nil {
+		t.Errorf("Intern(nil) = %v, want nil", got)
+	}
+	if got := s.Intern([]byte{}); len(got) != 0 {
+		t.Errorf("Intern(empty) = %v", got)
+	}
+	if st := s.Stats(); st.Bodies != 0 {
+		t.Errorf("empty bodies were stored: %+v", st)
+	}
+}
+
+func TestBodyStore_EvictsUnreferenced(t *testing.T) {
+	s := NewBodyStore()
+	s.Intern(bytes.Repeat([]byte("x"), 64<<10))
+
+	// The body is unreferenced; its entry goes once the collector has run
+	// the cleanup.
+	deadline := time.Now().Add(2 * time.Second)
+	for s.Stats().Bodies != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("entry still stored after GC: %+v", s.Stats())
+		}
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The same content interned again starts a fresh entry.
+	b := s.Intern(bytes.Repeat([]byte("x"), 64<<10))
+	if st := s.Stats(); st.Bodies != 1 || st.Hits != 0 {
+		t.Errorf("stats = %+v, want one fresh body", st)
+	}
+	runtime.KeepAlive(b)
+}
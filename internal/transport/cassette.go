@@ -0,0 +1,340 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cassette is a recording of request/response exchanges made through a
+// Client. It is captured by a Recorder and served back by a Replayer, so a
+// scan can be repeated without the target. Exchanges are recorded at the
+// Client boundary: transport-internal retries are not visible in it.
+//
+// On disk, response bodies are stored once in a table and referenced by
+// index, since a scan sees the same few pages hundreds of times.
+type Cassette struct {
+	Interactions []Interaction
+}
+
+// cassetteFile is the JSON form of a Cassette.
+type cassetteFile struct {
+	Bodies       []string            `json:"bodies"`
+	Interactions []storedInteraction `json:"interactions"`
+}
+
+type storedInteraction struct {
+	Request  RecordedRequest `json:"request"`
+	Response storedResponse  `json:"response"`
+}
+
+type storedResponse struct {
+	RecordedResponse
+	Body int `json:"body"` // Index into cassetteFile.Bodies
+}
+
+// Interaction is a single recorded exchange.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest holds the parts of a Request that identify it on replay.
+type RecordedRequest struct {
+	Method      string            `json:"method"`
+	URL         string            `json:"url"`
+	Body        string            `json:"body,omitempty"`
+	ContentType string            `json:"content_type,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Cookies     map[string]string `json:"cookies,omitempty"`
+}
+
+// RecordedResponse is a Response as stored in a cassette. Error is set
+// instead of a response when the request failed.
+type RecordedResponse struct {
+	StatusCode int           `json:"status,omitempty"`
+	Headers    http.Header   `json:"headers,omitempty"`
+	Body       string        `json:"-"`
+	Duration   time.Duration `json:"duration,omitempty"`
+	TTFB       time.Duration `json:"ttfb,omitempty"`
+	Streamed   bool          `json:"streamed,omitempty"`
+	Close      bool          `json:"close,omitempty"`
+	URL        string        `json:"url,omitempty"`
+	Protocol   string        `json:"protocol,omitempty"`
+	Anomalies  []Anomaly     `json:"anomalies,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// ErrNotRecorded is returned by a Replayer for a request that does not
+// appear in its cassette.
+var ErrNotRecorded = errors.New("request not recorded")
+
+// MarshalJSON implements json.Marshaler.
+func (c *Cassette) MarshalJSON() ([]byte, error) {
+	f := cassetteFile{Bodies: []string{}, Interactions: []storedInteraction{}}
+	index := make(map[string]int)
+	for _, in := range c.Interactions {
+		i, ok := index[in.Response.Body]
+		if !ok {
+			i = len(f.Bodies)
+			index[in.Response.Body] = i
+			f.Bodies = append(f.Bodies, in.Response.Body)
+		}
+		f.Interactions = append(f.Interactions, storedInteraction{
+			Request:  in.Request,
+			Response: storedResponse{RecordedResponse: in.Response, Body: i},
+		})
+	}
+	// Pages are stored as-is rather than with <, > and & escaped.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(f); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *Cassette) UnmarshalJSON(data []byte) error {
+	var f cassetteFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	c.Interactions = make([]Interaction, 0, len(f.Interactions))
+	for n, in := range f.Interactions {
+		if in.Response.Body < 0 || in.Response.Body >= len(f.Bodies) {
+			return fmt.Errorf("interaction %d: body index %d out of range", n, in.Response.Body)
+		}
+		resp := in.Response.RecordedResponse
+		resp.Body = f.Bodies[in.Response.Body]
+		c.Interactions = append(c.Interactions, Interaction{Request: in.Request, Response: resp})
+	}
+	return nil
+}
+
+// LoadCassette reads a cassette from a JSON file.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parse cassette %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to path as indented JSON.
+func (c *Cassette) Save(path string) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// recordRequest converts req into its recorded form.
+func recordRequest(req *Request) RecordedRequest {
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	return RecordedRequest{
+		Method:      method,
+		URL:         req.URL,
+		Body:        req.Body,
+		ContentType: req.ContentType,
+		Headers:     req.Headers,
+		Cookies:     req.Cookies,
+	}
+}
+
+// key identifies the request for replay matching. Header and cookie order
+// does not matter.
+func (r RecordedRequest) key() string {
+	var b strings.Builder
+	b.WriteString(r.Method + " " + r.URL + "\n" + r.ContentType + "\n" + r.Body)
+	for _, m := range []map[string]string{r.Headers, r.Cookies} {
+		names := make([]string, 0, len(m))
+		for name := range m {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		b.WriteString("\n")
+		for _, name := range names {
+			b.WriteString(name + "=" + m[name] + ";")
+		}
+	}
+	return b.String()
+}
+
+// Recorder wraps a Client and records every exchange it forwards.
+type Recorder struct {
+	inner Client
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder wraps inner.
+func NewRecorder(inner Client) *Recorder {
+	return &Recorder{inner: inner}
+}
+
+// Do forwards the request and records the outcome.
+func (r *Recorder) Do(ctx context.Context, req *Request) (*Response, error) {
+	in := Interaction{Request: recordRequest(req.Clone())}
+	resp, err := r.inner.Do(ctx, req)
+	if err != nil && ctx.Err() != nil {
+		// Cancelled by the caller: nothing the target did.
+		return resp, err
+	}
+
+	if err != nil {
+		in.Response.Error = err.Error()
+	} else {
+		in.Response = RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    resp.Headers.Clone(),
+			Body:       string(resp.Body),
+			Duration:   resp.Duration,
+			TTFB:       resp.TTFB,
+			Streamed:   resp.Streamed,
+			Close:      resp.Close,
+			URL:        resp.URL,
+			Protocol:   resp.Protocol,
+			Anomalies:  append([]Anomaly(nil), resp.Anomalies...),
+		}
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, in)
+	r.mu.Unlock()
+	return resp, err
+}
+
+// Cassette returns a copy of the exchanges recorded so far.
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Cassette{Interactions: append([]Interaction(nil), r.cassette.Interactions...)}
+}
+
+// SetProxy forwards to the wrapped client.
+func (r *Recorder) SetProxy(proxyURL string) error { return r.inner.SetProxy(proxyURL) }
+
+// SetRateLimit forwards to the wrapped client.
+func (r *Recorder) SetRateLimit(rps float64) { r.inner.SetRateLimit(rps) }
+
+// Stats forwards to the wrapped client.
+func (r *Recorder) Stats() *TransportStats { return r.inner.Stats() }
+
+// Replayer is a Client that answers from a cassette instead of the network.
+// Requests are matched on method, URL, content type, body, headers and
+// cookies. Repeated identical requests get their recorded responses in
+// order; once those run out the last one is repeated. Unmatched requests
+// fail with ErrNotRecorded.
+type Replayer struct {
+	mu       sync.Mutex
+	queues   map[string][]RecordedResponse
+	served   map[string]int
+	requests int64
+	duration time.Duration
+	missed   []string
+}
+
+// NewReplayer returns a Replayer serving c.
+func NewReplayer(c *Cassette) *Replayer {
+	r := &Replayer{
+		queues: make(map[string][]RecordedResponse),
+		served: make(map[string]int),
+	}
+	for _, in := range c.Interactions {
+		k := in.Request.key()
+		r.queues[k] = append(r.queues[k], in.Response)
+	}
+	return r
+}
+
+// Do answers req from the cassette.
+func (r *Replayer) Do(ctx context.Context, req *Request) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	rr := recordRequest(req)
+	k := rr.key()
+
+	r.mu.Lock()
+	r.requests++
+	queue, ok := r.queues[k]
+	if !ok {
+		r.missed = append(r.missed, rr.Method+" "+rr.URL)
+		r.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, rr.Method, rr.URL)
+	}
+	i := r.served[k]
+	if i >= len(queue) {
+		i = len(queue) - 1
+	}
+	r.served[k]++
+	rec := queue[i]
+	r.duration += rec.Duration
+	r.mu.Unlock()
+
+	if rec.Error != "" {
+		return nil, errors.New(rec.Error)
+	}
+	return &Response{
+		StatusCode:    rec.StatusCode,
+		Headers:       rec.Headers.Clone(),
+		Body:          []byte(rec.Body),
+		ContentLength: int64(len(rec.Body)),
+		Duration:      rec.Duration,
+		TTFB:          rec.TTFB,
+		Streamed:      rec.Streamed,
+		Close:         rec.Close,
+		URL:           rec.URL,
+		Protocol:      rec.Protocol,
+		Anomalies:     append([]Anomaly(nil), rec.Anomalies...),
+	}, nil
+}
+
+// Missed returns the requests that had no recording, in the order they
+// were made.
+func (r *Replayer) Missed() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.missed...)
+}
+
+// SetProxy is a no-op: a Replayer never touches the network.
+func (r *Replayer) SetProxy(string) error { return nil }
+
+// SetRateLimit is a no-op: a Replayer never touches the network.
+func (r *Replayer) SetRateLimit(float64) {}
+
+// Stats reports the requests answered, including unmatched ones, and the
+// recorded durations of the responses served.
+func (r *Replayer) Stats() *TransportStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := &TransportStats{TotalRequests: r.requests, TotalDuration: r.duration}
+	if r.requests > 0 {
+		stats.AvgDuration = time.Duration(int64(r.duration) / r.requests)
+	}
+	return stats
+}
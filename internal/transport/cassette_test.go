@@ -0,0 +1,110 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRecorderReplayer_RoundTrip(t *testing.T) {
+	var hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := hits.Add(1)
+		w.Header().Set("X-Hit", fmt.Sprint(n))
+		fmt.Fprintf(w, "%s %s hit %d", r.Method, r.URL.Query().Get("id"), n)
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(ClientOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := NewRecorder(client)
+	ctx := context.Background()
+
+	requests := []*Request{
+		{URL: srv.URL + "?id=1"},
+		{URL: srv.URL + "?id=1"},
+		{Method: "POST", URL: srv.URL, Body: "id=2", ContentType: "application/x-www-form-urlencoded"},
+		{URL: srv.URL + "?id=3", Cookies: map[string]string{"a": "1", "b": "2"}},
+	}
+	var live []string
+	for _, req := range requests {
+		resp, err := rec.Do(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		live = append(live, resp.BodyString())
+	}
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	if err := rec.Cassette().Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		t.Fatalf("LoadCassette: %v", err)
+	}
+
+	rep := NewReplayer(cassette)
+	for i, req := range requests {
+		resp, err := rep.Do(ctx, req.Clone())
+		if err != nil {
+			t.Fatalf("replay %d: %v", i, err)
+		}
+		if resp.BodyString() != live[i] {
+			t.Errorf("replay %d body = %q, want %q", i, resp.BodyString(), live[i])
+		}
+	}
+
+	// Identical requests beyond the recording repeat the last response.
+	resp, err := rep.Do(ctx, &Request{Method: "GET", URL: srv.URL + "?id=1"})
+	if err != nil || resp.BodyString() != live[1] {
+		t.Errorf("exhausted replay = %v, %v; want %q", resp, err, live[1])
+	}
+	if hits.Load() != int64(len(requests)) {
+		t.Errorf("replay reached the server: %d hits", hits.Load())
+	}
+	if got := rep.Stats().TotalRequests; got != int64(len(requests)+1) {
+		t.Errorf("TotalRequests = %d, want %d", got, len(requests)+1)
+	}
+}
+
+func TestReplayer_NotRecorded(t *testing.T) {
+	rep := NewReplayer(&Cassette{Interactions: []Interaction{{
+		Request:  RecordedRequest{Method: "GET", URL: "http://target.test/?id=1", Cookies: map[string]string{"s": "x"}},
+		Response: RecordedResponse{StatusCode: 200, Body: "ok"},
+	}}})
+	ctx := context.Background()
+
+	if _, err := rep.Do(ctx, &Request{URL: "http://target.test/?id=1", Cookies: map[string]string{"s": "x"}}); err != nil {
+		t.Fatalf("recorded request: %v", err)
+	}
+	for _, req := range []*Request{
+		{URL: "http://target.test/?id=2"},
+		{URL: "http://target.test/?id=1"},
+		{Method: "POST", URL: "http://target.test/?id=1", Cookies: map[string]string{"s": "x"}},
+	} {
+		if _, err := rep.Do(ctx, req); !errors.Is(err, ErrNotRecorded) {
+			t.Errorf("%s %s %v: err = %v, want ErrNotRecorded", req.Method, req.URL, req.Cookies, err)
+		}
+	}
+	if got := len(rep.Missed()); got != 3 {
+		t.Errorf("Missed() has %d entries, want 3", got)
+	}
+}
+
+func TestReplayer_RecordedError(t *testing.T) {
+	rep := NewReplayer(&Cassette{Interactions: []Interaction{{
+		Request:  RecordedRequest{Method: "GET", URL: "http://target.test/"},
+		Response: RecordedResponse{Error: "connection reset"},
+	}}})
+	if _, err := rep.Do(context.Background(), &Request{URL: "http://target.test/"}); err == nil || err.Error() != "connection reset" {
+		t.Errorf("err = %v, want recorded error", err)
+	}
+}
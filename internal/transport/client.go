@@ -2,16 +2,23 @@ package transport
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
 	"io"
+	"maps"
+	"math/rand/v2"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/time/rate"
+
+	"github.com/0x6d61/sqleech/internal/rawquery"
 )
 
 // Client is the interface for the HTTP transport layer. All injection
@@ -35,6 +42,35 @@ type TransportStats struct {
 	TotalRequests int64
 	TotalDuration time.Duration
 	AvgDuration   time.Duration
+
+	// ConnsOpened and ConnsReused count requests that dialed a new
+	// connection versus those served over a pooled keep-alive connection.
+	// A new connection adds handshake time to the request's Duration.
+	ConnsOpened int64
+	ConnsReused int64
+
+	// Retries counts the requests sent again after a transient failure
+	// (see ClientOptions.Retries); each is also in TotalRequests.
+	Retries int64
+
+	// StatusCounts counts the responses by status code, and ErrorCount the
+	// requests that got none: refused, reset or timed out, each attempt
+	// counted. TotalRequests counts the responses alone.
+	StatusCounts map[int]int64
+	ErrorCount   int64
+
+	// BytesReceived is the number of response body bytes read, including
+	// those discarded past MaxResponseBytes.
+	BytesReceived int64
+
+	// MinDuration and MaxDuration bound the response durations, and
+	// P50Duration, P95Duration and P99Duration are their percentiles,
+	// estimated from a sample of up to maxDurationSamples of them.
+	MinDuration time.Duration
+	MaxDuration time.Duration
+	P50Duration time.Duration
+	P95Duration time.Duration
+	P99Duration time.Duration
 }
 
 // ClientOptions holds configuration for creating a new DefaultClient.
@@ -42,22 +78,178 @@ type ClientOptions struct {
 	// Timeout is the default timeout for all requests.
 	Timeout time.Duration
 
-	// ProxyURL is the proxy URL (HTTP or SOCKS5).
+	// ProxyURL is the proxy URL: http:// or https:// for an HTTP proxy,
+	// socks5:// or socks5h:// for a SOCKS5 proxy, with the target host
+	// names resolved locally or by the proxy. Credentials in it are used.
 	ProxyURL string
 
+	// HostOverride is sent as the Host header of every request, and as
+	// the TLS server name, in place of the host of its URL: requests to
+	// http://10.0.0.5/ reach the virtual host HostOverride names there.
+	HostOverride string
+
+	// Resolve pins host names to IP addresses: connections to a host it
+	// names go to its address, without a DNS lookup, while URLs, the Host
+	// header, TLS server names and cookies keep the name. Through a SOCKS5
+	// proxy the proxy is asked for the address; an HTTP proxy resolves
+	// target names itself.
+	Resolve map[string]string
+
 	// FollowRedirects controls whether redirects are followed.
 	FollowRedirects bool
 
+	// ForceHTTP1 and ForceHTTP2 pin the protocol spoken to targets, which
+	// a WAF may treat differently; they are mutually exclusive. By default
+	// HTTP/2 is used over TLS with targets that offer it and HTTP/1.1
+	// otherwise. ForceHTTP2 fails against targets that do not negotiate
+	// HTTP/2 and speaks it unencrypted (h2c, with prior knowledge) to
+	// http:// URLs.
+	ForceHTTP1 bool
+	ForceHTTP2 bool
+
 	// InsecureSkipVerify disables TLS certificate verification.
 	InsecureSkipVerify bool
 
+	// ClientCertFile and ClientKeyFile are the PEM certificate and key
+	// presented to targets that require a client certificate. The key may
+	// be in the certificate file, in which case ClientKeyFile is empty.
+	ClientCertFile string
+	ClientKeyFile  string
+
+	// AuthType is the HTTP authentication scheme, basic, bearer or ntlm,
+	// with AuthCredentials: user:password, a token or
+	// [DOMAIN\]user:password. Credentials go with every request to the
+	// target and redirects to its origin, never across origins.
+	AuthType        string
+	AuthCredentials string
+
+	// CACertFile holds PEM certificates of the authorities, such as an
+	// internal CA, trusted alongside the system's to verify targets.
+	CACertFile string
+
 	// RandomUserAgent enables random User-Agent header selection.
 	RandomUserAgent bool
 
 	// MaxRPS is the maximum requests per second (0 = unlimited).
 	MaxRPS float64
+
+	// Delay is waited before every request, plus a random extra of up to
+	// Jitter: 2s and 1s space requests 2 to 3 seconds apart. Concurrent
+	// requests take turns waiting, so the spacing holds for any number of
+	// workers. The wait is over before a request's Duration is measured.
+	Delay  time.Duration
+	Jitter time.Duration
+
+	// UseCookieJar keeps the cookies targets set, such as a rotated
+	// session ID or a load balancer's affinity cookie, and sends them with
+	// later requests in place of the request's own cookies of the same
+	// names, unless a request changed the value it was first sent with,
+	// as a probe injecting into the cookie does (see Cookies).
+	UseCookieJar bool
+
+	// KeepConditionalHeaders disables stripping of If-None-Match,
+	// If-Modified-Since and related validators from outgoing requests.
+	KeepConditionalHeaders bool
+
+	// Threads is the number of workers that will share the client. It sizes
+	// the idle connection pool when MaxIdleConnsPerHost is not set, so each
+	// worker can keep its connection alive between requests.
+	Threads int
+
+	// MaxConnsPerHost limits the connections per host, whether dialing,
+	// active or idle (0 = unlimited). Requests beyond it wait for a
+	// connection to become free.
+	MaxConnsPerHost int
+
+	// MaxIdleConnsPerHost is the number of keep-alive connections kept per
+	// host (0 = Threads, or DefaultMaxIdleConnsPerHost if Threads is unset).
+	MaxIdleConnsPerHost int
+
+	// IdleConnTimeout is how long an idle connection stays in the pool
+	// (0 = DefaultIdleConnTimeout).
+	IdleConnTimeout time.Duration
+
+	// DisableKeepAlives opens a new connection for every request.
+	DisableKeepAlives bool
+
+	// Retries is how many times an idempotent request is sent again after
+	// a refused, reset or timed-out connection or a RetryStatus answer
+	// (0 = never). RetryBackoff is the first retry's maximum wait, doubled
+	// for each one after (0 = 500ms); a Retry-After header overrides it.
+	Retries      int
+	RetryBackoff time.Duration
+
+	// RetryStatus are the status codes retried (nil = DefaultRetryStatus).
+	RetryStatus []int
+
+	// NonceHeaders are generated afresh for every request sent, including
+	// cache-busting retries, overriding any header of the same name.
+	NonceHeaders []NonceHeader
+
+	// TrafficLogPath, when set, is a file every request sent and the
+	// response to it, or its error, are appended to, under the request ID
+	// of the Response. Bodies are cut at 64 KiB. RedactTraffic leaves the
+	// values of the Authorization and Cookie headers, and their proxy and
+	// Set-Cookie kin, out of it.
+	TrafficLogPath string
+	RedactTraffic  bool
+
+	// BodyStore, when set, interns every response body so identical pages
+	// share one copy. Such bodies must be treated as read-only.
+	BodyStore *BodyStore
+
+	// MaxResponseBytes caps the body read of every response (0 =
+	// DefaultMaxResponseBytes, negative = no cap). The rest of a longer
+	// body is discarded and the response marked Truncated.
+	MaxResponseBytes int64
+}
+
+// DefaultMaxResponseBytes is the default cap on response bodies, enough for
+// any page worth comparing and small enough for every worker to hold one.
+const DefaultMaxResponseBytes = 5 << 20
+
+// maxDrainBytes is how much of a body past the cap is read and discarded
+// to keep its connection for reuse; a longer rest closes the connection.
+const maxDrainBytes = 256 << 10
+
+// Connection pool defaults. net/http keeps only two idle connections per
+// host, so a scanner with more workers than that against a single target
+// would constantly close and redial connections.
+const (
+	DefaultMaxIdleConnsPerHost = 10
+	DefaultIdleConnTimeout     = 90 * time.Second
+)
+
+// protocols returns the protocols a client with opts speaks, or nil for
+// the default of HTTP/1.1 and HTTP/2 over TLS.
+func protocols(opts ClientOptions) *http.Protocols {
+	var p http.Protocols
+	switch {
+	case opts.ForceHTTP1:
+		p.SetHTTP1(true)
+	case opts.ForceHTTP2:
+		p.SetHTTP2(true)
+		p.SetUnencryptedHTTP2(true)
+	default:
+		return nil
+	}
+	return &p
 }
 
+// conditionalHeaders are request validators that let a server answer
+// 304 Not Modified with an empty body. They never make sense for probes.
+var conditionalHeaders = []string{
+	"If-None-Match",
+	"If-Modified-Since",
+	"If-Match",
+	"If-Unmodified-Since",
+	"If-Range",
+}
+
+// cacheBustParam is the query parameter added when retrying a request that
+// came back 304 Not Modified.
+const cacheBustParam = "_sqleech"
+
 // DefaultClient is the default implementation of the Client interface,
 // backed by net/http.
 type DefaultClient struct {
@@ -67,30 +259,84 @@ type DefaultClient struct {
 	mu              sync.RWMutex
 	totalRequests   int64
 	totalDurationNs int64
+	connsOpened     int64
+	connsReused     int64
+	retries         int64
+	errors          int64
+	bytesReceived   int64
+	statusCounts    map[int]int64
+	durations       durationSample
+
+	// transport is the connection pool under httpClient's round trippers.
+	transport *http.Transport
+
+	// auth authenticates requests; nil without ClientOptions.AuthType.
+	auth *authenticator
+
+	// jar keeps the cookies targets set; nil without
+	// ClientOptions.UseCookieJar.
+	jar *cookieJar
+
+	// lastID is the ID of the last request sent.
+	lastID atomic.Uint64
+
+	// traffic logs every exchange; nil without ClientOptions.TrafficLogPath.
+	traffic *trafficLog
+
+	// noKeepAlive is set by DisableKeepAlives.
+	noKeepAlive atomic.Bool
+
+	// pace holds the turn to wait out Delay and Jitter; nil without them.
+	pace chan struct{}
 }
 
 // NewClient creates a new DefaultClient with the given options.
 func NewClient(opts ClientOptions) (*DefaultClient, error) {
+	idlePerHost := opts.MaxIdleConnsPerHost
+	if idlePerHost <= 0 {
+		idlePerHost = opts.Threads
+	}
+	if idlePerHost <= 0 {
+		idlePerHost = DefaultMaxIdleConnsPerHost
+	}
+	idleTimeout := opts.IdleConnTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultIdleConnTimeout
+	}
+
+	if opts.ForceHTTP1 && opts.ForceHTTP2 {
+		return nil, fmt.Errorf("ForceHTTP1 and ForceHTTP2 are mutually exclusive")
+	}
+	tlsCfg, err := tlsConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := newAuthenticator(opts.AuthType, opts.AuthCredentials)
+	if err != nil {
+		return nil, err
+	}
 	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: opts.InsecureSkipVerify,
-		},
+		TLSClientConfig: tlsCfg,
+		Protocols:       protocols(opts),
 		// Enable HTTP/2 by default via ForceAttemptHTTP2
-		ForceAttemptHTTP2: true,
+		ForceAttemptHTTP2:   !opts.ForceHTTP1,
+		MaxIdleConns:        max(100, idlePerHost),
+		MaxIdleConnsPerHost: idlePerHost,
+		MaxConnsPerHost:     opts.MaxConnsPerHost,
+		IdleConnTimeout:     idleTimeout,
+		DisableKeepAlives:   opts.DisableKeepAlives,
 	}
 
 	// Configure proxy if provided.
 	if opts.ProxyURL != "" {
-		proxyURL, err := url.Parse(opts.ProxyURL)
-		if err != nil {
-			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		if err := configureProxy(transport, opts.ProxyURL); err != nil {
+			return nil, err
 		}
-		transport.Proxy = http.ProxyURL(proxyURL)
 	}
+	pinHosts(transport, opts.Resolve)
 
 	client := &http.Client{
-		Transport: transport,
-		Timeout:   opts.Timeout,
+		Timeout: opts.Timeout,
 	}
 
 	// Configure redirect policy.
@@ -102,13 +348,27 @@ func NewClient(opts ClientOptions) (*DefaultClient, error) {
 
 	dc := &DefaultClient{
 		httpClient: client,
+		transport:  transport,
 		opts:       opts,
+		auth:       auth,
 	}
+	if opts.UseCookieJar {
+		dc.jar = newCookieJar()
+	}
+	if opts.TrafficLogPath != "" {
+		if dc.traffic, err = openTrafficLog(opts.TrafficLogPath, opts.RedactTraffic); err != nil {
+			return nil, err
+		}
+	}
+	client.Transport = dc.roundTripper(transport)
 
 	// Configure rate limiter if specified.
 	if opts.MaxRPS > 0 {
 		dc.limiter = rate.NewLimiter(rate.Limit(opts.MaxRPS), 1)
 	}
+	if opts.Delay > 0 || opts.Jitter > 0 {
+		dc.pace = make(chan struct{}, 1)
+	}
 
 	return dc, nil
 }
@@ -116,7 +376,36 @@ func NewClient(opts ClientOptions) (*DefaultClient, error) {
 // Do sends an HTTP request and returns the response. It applies rate
 // limiting, timing measurement, custom headers, cookies, and optional
 // per-request overrides.
+//
+// Idempotent requests that fail in transit or get a retried status code
+// are sent again as ClientOptions.Retries allows (see attempt).
+//
+// A 304 Not Modified response is retried once with Cache-Control: no-cache
+// and a cache-busting query parameter. If the retry is still 304, the
+// response is returned with AnomalyNotModified set.
 func (c *DefaultClient) Do(ctx context.Context, req *Request) (*Response, error) {
+	resp, err := c.attempt(ctx, req, false)
+	if err != nil || resp.StatusCode != http.StatusNotModified {
+		return resp, err
+	}
+
+	resp, err = c.attempt(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		resp.Anomalies = append(resp.Anomalies, AnomalyNotModified)
+	}
+	return resp, nil
+}
+
+// send performs a single HTTP round trip. When bustCache is set, the request
+// carries Cache-Control: no-cache and a unique cache-busting parameter.
+func (c *DefaultClient) send(ctx context.Context, req *Request, bustCache bool) (*Response, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, fmt.Errorf("request delay: %w", err)
+	}
+
 	// Rate limiting
 	if c.limiter != nil {
 		if err := c.limiter.Wait(ctx); err != nil {
@@ -135,7 +424,19 @@ func (c *DefaultClient) Do(ctx context.Context, req *Request) (*Response, error)
 		method = http.MethodGet
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, method, req.URL, bodyReader)
+	rawURL := req.URL
+	if bustCache {
+		rawURL = addCacheBuster(rawURL)
+	}
+
+	// firstByte is set by the trace on every hop of a redirect chain, so it
+	// ends up holding the final response's first byte.
+	var firstByte time.Time
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn:              c.countConn,
+		GotFirstResponseByte: func() { firstByte = time.Now() },
+	})
+	httpReq, err := http.NewRequestWithContext(ctx, method, rawURL, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
@@ -150,6 +451,36 @@ func (c *DefaultClient) Do(ctx context.Context, req *Request) (*Response, error)
 		httpReq.Header.Set(k, v)
 	}
 
+	if c.opts.HostOverride != "" {
+		httpReq.Host = c.opts.HostOverride
+	}
+
+	if c.noKeepAlive.Load() {
+		httpReq.Close = true
+	}
+
+	// Strip conditional headers so the server cannot answer 304.
+	if !c.opts.KeepConditionalHeaders {
+		for _, h := range conditionalHeaders {
+			httpReq.Header.Del(h)
+		}
+	}
+	if bustCache {
+		httpReq.Header.Set("Cache-Control", "no-cache")
+		httpReq.Header.Set("Pragma", "no-cache")
+	}
+
+	// Generate per-request nonces last, so they are never reused.
+	var nonces map[string]string
+	for _, h := range c.opts.NonceHeaders {
+		if nonces == nil {
+			nonces = make(map[string]string, len(c.opts.NonceHeaders))
+		}
+		v := h.Generate()
+		httpReq.Header.Set(h.Name, v)
+		nonces[h.Name] = v
+	}
+
 	// Set cookies.
 	for name, value := range req.Cookies {
 		httpReq.AddCookie(&http.Cookie{Name: name, Value: value})
@@ -162,7 +493,7 @@ func (c *DefaultClient) Do(ctx context.Context, req *Request) (*Response, error)
 
 	// Determine which HTTP client to use. If we need per-request overrides
 	// for redirect policy or timeout, we create a shallow copy.
-	httpClient := c.httpClient
+	httpClient := c.client()
 	needCustomClient := false
 
 	if req.FollowRedirects != nil {
@@ -173,7 +504,7 @@ func (c *DefaultClient) Do(ctx context.Context, req *Request) (*Response, error)
 	}
 
 	if needCustomClient {
-		cc := *c.httpClient
+		cc := *httpClient
 		if req.Timeout > 0 {
 			cc.Timeout = req.Timeout
 		}
@@ -190,62 +521,256 @@ func (c *DefaultClient) Do(ctx context.Context, req *Request) (*Response, error)
 	}
 
 	// Perform the request with timing.
+	id := c.lastID.Add(1)
 	start := time.Now()
 	httpResp, err := httpClient.Do(httpReq)
-	duration := time.Since(start)
-
 	if err != nil {
+		c.logTraffic(id, start, httpReq, req.Body, nil, nil, err)
+		c.countError()
 		return nil, err
 	}
 	defer httpResp.Body.Close()
 
-	// Read the response body.
-	body, err := io.ReadAll(httpResp.Body)
+	// Read the response body, up to the cap. A body that fails partway,
+	// e.g. a streamed one still arriving when the timeout hits, is kept and
+	// flagged unless the caller cancelled.
+	limit := c.opts.MaxResponseBytes
+	if limit == 0 {
+		limit = DefaultMaxResponseBytes
+	}
+	var body []byte
+	truncated := false
+	if limit < 0 {
+		body, err = io.ReadAll(httpResp.Body)
+	} else {
+		body, err = io.ReadAll(io.LimitReader(httpResp.Body, limit+1))
+		if truncated = int64(len(body)) > limit; truncated {
+			body = body[:limit]
+		}
+	}
+	duration := time.Since(start)
+	received := int64(len(body))
+	if truncated {
+		n, _ := io.Copy(io.Discard, io.LimitReader(httpResp.Body, maxDrainBytes))
+		received += n
+	}
+	var anomalies []Anomaly
 	if err != nil {
-		return nil, fmt.Errorf("reading response body: %w", err)
+		if ctx.Err() != nil {
+			err = fmt.Errorf("reading response body: %w", err)
+			c.logTraffic(id, start, httpReq, req.Body, nil, nil, err)
+			c.countError()
+			return nil, err
+		}
+		anomalies = append(anomalies, AnomalyTruncated)
+	}
+
+	// Decode the body as the server encoded it: a client that set its own
+	// Accept-Encoding gets compressed bodies net/http leaves alone, and
+	// pages in a legacy charset would not match UTF-8 keywords.
+	headers := httpResp.Header
+	contentLength := httpResp.ContentLength
+	body, encoding, cut := decodeContent(headers, body, truncated || err != nil, limit)
+	if encoding != "" {
+		truncated = truncated || cut
+		headers = headers.Clone()
+		headers.Del("Content-Encoding")
+		headers.Del("Content-Length")
+		contentLength = -1
+	}
+	body, bodyCharset := decodeCharset(headers.Get("Content-Type"), body)
+	if c.opts.BodyStore != nil {
+		body = c.opts.BodyStore.Intern(body)
+	}
+	var ttfb time.Duration
+	if !firstByte.IsZero() {
+		ttfb = firstByte.Sub(start)
 	}
 
 	// Determine protocol version string.
 	protocol := fmt.Sprintf("HTTP/%d.%d", httpResp.ProtoMajor, httpResp.ProtoMinor)
 
 	resp := &Response{
-		StatusCode:    httpResp.StatusCode,
-		Headers:       httpResp.Header,
-		Body:          body,
-		ContentLength: httpResp.ContentLength,
-		Duration:      duration,
-		URL:           httpResp.Request.URL.String(),
-		Protocol:      protocol,
+		StatusCode:      httpResp.StatusCode,
+		Headers:         headers,
+		Body:            body,
+		ContentLength:   contentLength,
+		Duration:        duration,
+		TTFB:            ttfb,
+		Streamed:        httpResp.ContentLength < 0,
+		Close:           httpResp.Close,
+		URL:             httpResp.Request.URL.String(),
+		Protocol:        protocol,
+		Redirects:       redirectChain(httpResp.Request),
+		TLS:             httpResp.TLS,
+		Nonces:          nonces,
+		Anomalies:       anomalies,
+		Truncated:       truncated,
+		RequestID:       id,
+		ContentEncoding: encoding,
+		Charset:         bodyCharset,
 	}
+	c.logTraffic(id, start, httpReq, req.Body, httpResp, body, nil)
 
 	// Update statistics.
 	c.mu.Lock()
 	c.totalRequests++
 	c.totalDurationNs += duration.Nanoseconds()
+	c.bytesReceived += received
+	if c.statusCounts == nil {
+		c.statusCounts = make(map[int]int64)
+	}
+	c.statusCounts[resp.StatusCode]++
+	c.durations.add(duration)
 	c.mu.Unlock()
 
 	return resp, nil
 }
 
-// SetProxy configures an HTTP or SOCKS5 proxy for subsequent requests.
-func (c *DefaultClient) SetProxy(proxyURL string) error {
-	parsedURL, err := url.Parse(proxyURL)
-	if err != nil {
-		return fmt.Errorf("invalid proxy URL: %w", err)
+// logTraffic records an exchange in the traffic log, if there is one.
+func (c *DefaultClient) logTraffic(id uint64, start time.Time, req *http.Request, reqBody string, resp *http.Response, body []byte, err error) {
+	if c.traffic != nil {
+		c.traffic.record(id, start, req, reqBody, resp, body, time.Since(start), err)
 	}
-	if parsedURL.Scheme == "" || parsedURL.Host == "" {
-		return fmt.Errorf("invalid proxy URL: missing scheme or host")
+}
+
+// Close closes the traffic log. The client must not be used after.
+func (c *DefaultClient) Close() error {
+	if c.traffic == nil {
+		return nil
 	}
+	return c.traffic.Close()
+}
 
-	transport, ok := c.httpClient.Transport.(*http.Transport)
-	if !ok {
-		return fmt.Errorf("cannot set proxy: transport is not *http.Transport")
+// wait sleeps Delay plus up to Jitter, in turn with concurrent requests,
+// and returns early with the context's error when it is done.
+func (c *DefaultClient) wait(ctx context.Context) error {
+	if c.pace == nil {
+		return nil
 	}
+	select {
+	case c.pace <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-c.pace }()
 
-	transport.Proxy = http.ProxyURL(parsedURL)
+	d := c.opts.Delay
+	if c.opts.Jitter > 0 {
+		d += rand.N(c.opts.Jitter + 1)
+	}
+	return sleep(ctx, d)
+}
+
+// countError records a request that got no response.
+func (c *DefaultClient) countError() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errors++
+}
+
+// countConn records whether a request got a fresh or a pooled connection.
+func (c *DefaultClient) countConn(info httptrace.GotConnInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if info.Reused {
+		c.connsReused++
+	} else {
+		c.connsOpened++
+	}
+}
+
+// redirectChain returns the URLs of the requests that redirected to req,
+// oldest first.
+func redirectChain(req *http.Request) []string {
+	var chain []string
+	for r := req.Response; r != nil && r.Request != nil; r = r.Request.Response {
+		chain = append(chain, r.Request.URL.String())
+	}
+	slices.Reverse(chain)
+	return chain
+}
+
+// addCacheBuster appends a unique query parameter to rawURL. The existing
+// pairs are left as written, so the retry is the probe the technique built.
+func addCacheBuster(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	parsed.RawQuery = rawquery.Set(parsed.RawQuery, cacheBustParam, strconv.FormatInt(time.Now().UnixNano(), 36))
+	return parsed.String()
+}
+
+// SetProxy configures an HTTP or SOCKS5 proxy for subsequent requests
+// (see ClientOptions.ProxyURL). They go out over a new connection pool:
+// the idle connections of the old one are closed, and those of requests
+// still in flight idle out after IdleConnTimeout.
+func (c *DefaultClient) SetProxy(proxyURL string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	old := c.transport
+	transport := old.Clone()
+	if err := configureProxy(transport, proxyURL); err != nil {
+		return err
+	}
+	pinHosts(transport, c.opts.Resolve)
+	client := *c.httpClient
+	client.Transport = c.roundTripper(transport)
+	c.httpClient = &client
+	c.transport = transport
+	old.CloseIdleConnections()
 	return nil
 }
 
+// roundTripper returns t wrapped with the client's cookie jar and
+// authentication, which sit beneath redirects so every hop has them.
+func (c *DefaultClient) roundTripper(t *http.Transport) http.RoundTripper {
+	var rt http.RoundTripper = t
+	if c.jar != nil {
+		rt = &jarTransport{next: rt, jar: c.jar}
+	}
+	if c.auth != nil {
+		rt = &authTransport{next: rt, auth: c.auth}
+	}
+	return rt
+}
+
+// Cookies returns the cookies the target set that the client would send
+// to rawURL, or nil without ClientOptions.UseCookieJar.
+func (c *DefaultClient) Cookies(rawURL string) []*http.Cookie {
+	if c.jar == nil {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	return c.jar.jar.Cookies(u)
+}
+
+// client returns the http.Client requests are sent with.
+func (c *DefaultClient) client() *http.Client {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.httpClient
+}
+
+// DisableKeepAlives makes every subsequent request use a new connection
+// and close it after the response, for targets that mishandle reused
+// ones. It is safe to call while requests are in flight.
+func (c *DefaultClient) DisableKeepAlives() {
+	c.noKeepAlive.Store(true)
+	c.client().CloseIdleConnections()
+}
+
+// CloseIdleConnections closes pooled keep-alive connections, so the next
+// request dials afresh.
+func (c *DefaultClient) CloseIdleConnections() {
+	c.client().CloseIdleConnections()
+}
+
 // SetRateLimit sets the maximum number of requests per second.
 // A value of 0 or less disables rate limiting.
 func (c *DefaultClient) SetRateLimit(rps float64) {
@@ -264,9 +789,20 @@ func (c *DefaultClient) Stats() *TransportStats {
 	stats := &TransportStats{
 		TotalRequests: c.totalRequests,
 		TotalDuration: time.Duration(c.totalDurationNs),
+		ConnsOpened:   c.connsOpened,
+		ConnsReused:   c.connsReused,
+		Retries:       c.retries,
+		StatusCounts:  maps.Clone(c.statusCounts),
+		ErrorCount:    c.errors,
+		BytesReceived: c.bytesReceived,
 	}
 	if c.totalRequests > 0 {
 		stats.AvgDuration = time.Duration(c.totalDurationNs / c.totalRequests)
 	}
+	sorted := c.durations.sorted()
+	stats.MinDuration, stats.MaxDuration = c.durations.min, c.durations.max
+	stats.P50Duration = percentile(sorted, 50)
+	stats.P95Duration = percentile(sorted, 95)
+	stats.P99Duration = percentile(sorted, 99)
 	return stats
 }
@@ -2,11 +2,17 @@ package transport
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -91,6 +97,30 @@ func TestPOSTWithBody(t *testing.T) {
 	}
 }
 
+func TestBodyWithOtherMethods(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s %s", r.Method, r.Header.Get("Content-Type"), body)
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t)
+	for _, method := range []string{"PUT", "PATCH", "DELETE"} {
+		resp, err := c.Do(context.Background(), &Request{
+			Method:      method,
+			URL:         srv.URL + "/items/1",
+			Body:        "id=1",
+			ContentType: "application/x-www-form-urlencoded",
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+		if want := method + " application/x-www-form-urlencoded id=1"; resp.BodyString() != want {
+			t.Errorf("%s: server saw %q, want %q", method, resp.BodyString(), want)
+		}
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Custom headers and cookies
 // ---------------------------------------------------------------------------
@@ -149,6 +179,77 @@ func TestResponseTimingMeasurement(t *testing.T) {
 	if resp.Duration < 40*time.Millisecond {
 		t.Errorf("Duration = %v, expected at least ~50ms", resp.Duration)
 	}
+	if resp.TTFB < 40*time.Millisecond || resp.TTFB > resp.Duration {
+		t.Errorf("TTFB = %v, want between ~50ms and Duration %v", resp.TTFB, resp.Duration)
+	}
+	if resp.Streamed {
+		t.Error("Streamed = true for a response with a declared length")
+	}
+}
+
+func TestResponseTiming_TrickledBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		for range 4 {
+			time.Sleep(25 * time.Millisecond)
+			io.WriteString(w, "chunk\n")
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t)
+	resp, err := c.Do(context.Background(), &Request{URL: srv.URL})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if resp.Duration < 90*time.Millisecond {
+		t.Errorf("Duration = %v, want the whole ~100ms trickle", resp.Duration)
+	}
+	if resp.TTFB > 50*time.Millisecond {
+		t.Errorf("TTFB = %v, want the immediate headers", resp.TTFB)
+	}
+	if !resp.Streamed {
+		t.Error("Streamed = false for a chunked response")
+	}
+	if resp.Anomalous() {
+		t.Errorf("Anomalies = %v, want none", resp.Anomalies)
+	}
+	if got := strings.Count(resp.BodyString(), "chunk"); got != 4 {
+		t.Errorf("body has %d chunks, want 4", got)
+	}
+}
+
+func TestResponseTiming_TruncatedBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "partial")
+		w.(http.Flusher).Flush()
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t)
+	resp, err := c.Do(context.Background(), &Request{URL: srv.URL, Timeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if !slices.Contains(resp.Anomalies, AnomalyTruncated) {
+		t.Errorf("Anomalies = %v, want %q", resp.Anomalies, AnomalyTruncated)
+	}
+	if resp.BodyString() != "partial" {
+		t.Errorf("Body = %q, want the bytes read before the timeout", resp.BodyString())
+	}
+
+	// A caller cancelling mid-body still gets an error.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := c.Do(ctx, &Request{URL: srv.URL}); err == nil {
+		t.Error("Do with a cancelled context returned no error")
+	}
 }
 
 // ---------------------------------------------------------------------------
@@ -186,6 +287,12 @@ func TestRedirectFollowing(t *testing.T) {
 	if !strings.HasSuffix(resp.URL, "/final") {
 		t.Errorf("follow: URL = %q, want suffix /final", resp.URL)
 	}
+	if want := []string{srv.URL + "/redirect"}; !slices.Equal(resp.Redirects, want) {
+		t.Errorf("follow: Redirects = %v, want %v", resp.Redirects, want)
+	}
+	if resp.TLS != nil {
+		t.Error("follow: TLS set for a plain HTTP response")
+	}
 }
 
 func TestRedirectNotFollowing(t *testing.T) {
@@ -437,6 +544,9 @@ func TestTLSInsecureSkipVerifyWithHTTPS(t *testing.T) {
 	if resp.BodyString() != "secure" {
 		t.Errorf("Body = %q, want %q", resp.BodyString(), "secure")
 	}
+	if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
+		t.Errorf("TLS = %+v, want the connection state with the server certificate", resp.TLS)
+	}
 }
 
 // ---------------------------------------------------------------------------
@@ -584,6 +694,69 @@ func TestSetRateLimit(t *testing.T) {
 	}
 }
 
+func TestDelay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	const delay, jitter, n = 40 * time.Millisecond, 20 * time.Millisecond, 5
+	c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, Delay: delay, Jitter: jitter, MaxRPS: 1000})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	// Concurrent requests still wait their turn.
+	start := time.Now()
+	var wg sync.WaitGroup
+	durations := make([]time.Duration, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := c.Do(context.Background(), &Request{URL: srv.URL})
+			if err != nil {
+				t.Errorf("Do #%d: %v", i, err)
+				return
+			}
+			durations[i] = resp.Duration
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < n*delay {
+		t.Errorf("%d requests with a %v delay took %v, want at least %v", n, delay, elapsed, n*delay)
+	}
+	// The wait is not part of the measured response time.
+	for i, d := range durations {
+		if d >= delay {
+			t.Errorf("request #%d: Duration = %v, includes the delay", i, d)
+		}
+	}
+}
+
+func TestDelay_Cancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request sent despite the cancelled delay")
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, Delay: time.Hour})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = c.Do(ctx, &Request{URL: srv.URL})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Do error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("cancelled delay returned after %v", elapsed)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Context cancellation
 // ---------------------------------------------------------------------------
@@ -666,3 +839,515 @@ func TestResponseHeaders(t *testing.T) {
 		t.Errorf("X-Server header = %q, want %q", got, "sqleech-test")
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Conditional requests / 304 Not Modified
+// ---------------------------------------------------------------------------
+
+// newETagServer returns a server that answers 304 with an empty body to any
+// request whose If-None-Match matches its ETag. lastReq records the last
+// request seen.
+func newETagServer(lastReq *atomic.Pointer[http.Request]) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastReq.Store(r)
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("<html><body>full page</body></html>"))
+	}))
+}
+
+// meddlingTransport simulates a proxy that adds validators to outgoing
+// requests after the client has built them. With onlyCached set, it only
+// adds them for URLs it has already seen, like a cache would.
+type meddlingTransport struct {
+	inner      http.RoundTripper
+	onlyCached bool
+	seen       map[string]bool
+}
+
+func (m *meddlingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	u := r.URL.String()
+	if !m.onlyCached || m.seen[u] {
+		r = r.Clone(r.Context())
+		r.Header.Set("If-None-Match", `"v1"`)
+	}
+	m.seen[u] = true
+	return m.inner.RoundTrip(r)
+}
+
+func withMeddlingProxy(c *DefaultClient, onlyCached bool) {
+	c.httpClient.Transport = &meddlingTransport{
+		inner:      c.httpClient.Transport,
+		onlyCached: onlyCached,
+		seen:       make(map[string]bool),
+	}
+}
+
+func TestConditionalHeadersStripped(t *testing.T) {
+	var lastReq atomic.Pointer[http.Request]
+	srv := newETagServer(&lastReq)
+	defer srv.Close()
+
+	req := &Request{
+		Method: "GET",
+		URL:    srv.URL,
+		Headers: map[string]string{
+			"If-None-Match":     `"v1"`,
+			"If-Modified-Since": "Mon, 01 Jan 2024 00:00:00 GMT",
+			"X-Custom":          "kept",
+		},
+	}
+
+	c := newTestClient(t)
+	resp, err := c.Do(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Anomalous() {
+		t.Errorf("StatusCode = %d, Anomalies = %v; want 200 and none", resp.StatusCode, resp.Anomalies)
+	}
+	got := lastReq.Load()
+	for _, h := range conditionalHeaders {
+		if v := got.Header.Get(h); v != "" {
+			t.Errorf("%s = %q reached the server, want stripped", h, v)
+		}
+	}
+	if got.Header.Get("X-Custom") != "kept" {
+		t.Error("non-conditional header was stripped")
+	}
+
+	keep, _ := NewClient(ClientOptions{Timeout: 5 * time.Second, KeepConditionalHeaders: true})
+	if _, err := keep.Do(context.Background(), req); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if lastReq.Load().Header.Get("If-None-Match") == "" {
+		t.Error("KeepConditionalHeaders should preserve If-None-Match")
+	}
+}
+
+func TestNotModifiedRetry(t *testing.T) {
+	var lastReq atomic.Pointer[http.Request]
+	srv := newETagServer(&lastReq)
+	defer srv.Close()
+
+	c := newTestClient(t)
+	withMeddlingProxy(c, true)
+
+	req := &Request{Method: "GET", URL: srv.URL + "/page?id=1"}
+	first, err := c.Do(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	// The proxy now has the URL cached and adds a validator: the first
+	// attempt gets 304, the cache-busted retry gets the full page.
+	second, err := c.Do(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+
+	if second.StatusCode != http.StatusOK || second.Anomalous() {
+		t.Fatalf("retry: StatusCode = %d, Anomalies = %v; want 200 and none", second.StatusCode, second.Anomalies)
+	}
+	if string(second.Body) != string(first.Body) {
+		t.Errorf("retry body = %q, want %q", second.Body, first.Body)
+	}
+
+	retry := lastReq.Load()
+	if retry.URL.Query().Get(cacheBustParam) == "" {
+		t.Errorf("retry URL %q lacks cache-busting parameter", retry.URL)
+	}
+	if retry.URL.Query().Get("id") != "1" {
+		t.Errorf("retry URL %q lost original parameters", retry.URL)
+	}
+	if retry.Header.Get("Cache-Control") != "no-cache" {
+		t.Errorf("retry Cache-Control = %q, want no-cache", retry.Header.Get("Cache-Control"))
+	}
+	if got := c.Stats().TotalRequests; got != 3 {
+		t.Errorf("TotalRequests = %d, want 3 (including retry)", got)
+	}
+}
+
+// TestNotModifiedRetryKeepsQuery checks that the cache-busted retry sends
+// the probe's query as written: its pair order, its encoding and a pair
+// after a ; all survive, the buster appended after them.
+func TestNotModifiedRetryKeepsQuery(t *testing.T) {
+	var lastReq atomic.Pointer[http.Request]
+	srv := newETagServer(&lastReq)
+	defer srv.Close()
+
+	c := newTestClient(t)
+	withMeddlingProxy(c, true)
+
+	const query = "z=9&id=1%27+OR+%271%27=%271;jsessionid=ab&a=%2f"
+	req := &Request{Method: "GET", URL: srv.URL + "/page?" + query}
+	for i := 0; i < 2; i++ {
+		if _, err := c.Do(context.Background(), req); err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+	}
+
+	retry := lastReq.Load().URL.RawQuery
+	prefix := query + "&" + cacheBustParam + "="
+	if !strings.HasPrefix(retry, prefix) || len(retry) == len(prefix) {
+		t.Errorf("retry query = %q, want %q followed by the buster", retry, prefix)
+	}
+}
+
+func TestNotModifiedAnomaly(t *testing.T) {
+	var lastReq atomic.Pointer[http.Request]
+	srv := newETagServer(&lastReq)
+	defer srv.Close()
+
+	c := newTestClient(t)
+	withMeddlingProxy(c, false)
+
+	resp, err := c.Do(context.Background(), &Request{Method: "GET", URL: srv.URL})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if resp.StatusCode != http.StatusNotModified {
+		t.Fatalf("StatusCode = %d, want 304", resp.StatusCode)
+	}
+	if !resp.Anomalous() || resp.Anomalies[0] != AnomalyNotModified {
+		t.Errorf("Anomalies = %v, want [%s]", resp.Anomalies, AnomalyNotModified)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Connection pooling
+// ---------------------------------------------------------------------------
+
+// countingListener counts accepted connections.
+type countingListener struct {
+	net.Listener
+	accepted atomic.Int64
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		l.accepted.Add(1)
+	}
+	return conn, err
+}
+
+// runPooledLoad sends total requests from threads concurrent workers, the
+// way a scan does, and returns the number of connections the server
+// accepted.
+func runPooledLoad(t *testing.T, opts ClientOptions, threads, total int) (int64, *TransportStats) {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(2 * time.Millisecond)
+		fmt.Fprint(w, "ok")
+	}))
+	ln := &countingListener{Listener: srv.Listener}
+	srv.Listener = ln
+	srv.Start()
+	defer srv.Close()
+
+	opts.Timeout = 5 * time.Second
+	c, err := NewClient(opts)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	jobs := make(chan int, total)
+	for i := 0; i < total; i++ {
+		jobs <- i
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for w := 0; w < threads; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if _, err := c.Do(context.Background(), &Request{URL: fmt.Sprintf("%s/?id=%d", srv.URL, i)}); err != nil {
+					t.Errorf("Do: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return ln.accepted.Load(), c.Stats()
+}
+
+func TestConnectionPool_ReusesPerThread(t *testing.T) {
+	const threads, total = 10, 50
+	accepted, stats := runPooledLoad(t, ClientOptions{Threads: threads}, threads, total)
+
+	// A few extra dials can race with connections being returned to the
+	// pool, but nowhere near one per request.
+	if accepted > threads+threads/2 {
+		t.Errorf("server accepted %d connections for %d workers, want about %d", accepted, threads, threads)
+	}
+	if stats.ConnsOpened != accepted {
+		t.Errorf("ConnsOpened = %d, want %d (accepted)", stats.ConnsOpened, accepted)
+	}
+	if stats.ConnsOpened+stats.ConnsReused != total {
+		t.Errorf("ConnsOpened+ConnsReused = %d, want %d", stats.ConnsOpened+stats.ConnsReused, total)
+	}
+}
+
+func TestConnectionPool_DisableKeepAlives(t *testing.T) {
+	const threads, total = 10, 50
+	accepted, stats := runPooledLoad(t, ClientOptions{Threads: threads, DisableKeepAlives: true}, threads, total)
+
+	if accepted != total {
+		t.Errorf("server accepted %d connections, want one per request (%d)", accepted, total)
+	}
+	if stats.ConnsReused != 0 {
+		t.Errorf("ConnsReused = %d, want 0 with keep-alives disabled", stats.ConnsReused)
+	}
+}
+
+func TestConnectionPool_MaxConnsPerHost(t *testing.T) {
+	const threads, total = 10, 50
+	accepted, _ := runPooledLoad(t, ClientOptions{Threads: threads, MaxConnsPerHost: 3}, threads, total)
+
+	if accepted > 3 {
+		t.Errorf("server accepted %d connections, want at most MaxConnsPerHost (3)", accepted)
+	}
+}
+
+func TestNewClient_PoolDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     ClientOptions
+		wantIdle int
+	}{
+		{"default", ClientOptions{}, DefaultMaxIdleConnsPerHost},
+		{"threads hint", ClientOptions{Threads: 32}, 32},
+		{"explicit wins", ClientOptions{Threads: 32, MaxIdleConnsPerHost: 4}, 4},
+	}
+	for _, tt := range tests {
+		c, err := NewClient(tt.opts)
+		if err != nil {
+			t.Fatalf("%s: NewClient: %v", tt.name, err)
+		}
+		tr := c.httpClient.Transport.(*http.Transport)
+		if tr.MaxIdleConnsPerHost != tt.wantIdle {
+			t.Errorf("%s: MaxIdleConnsPerHost = %d, want %d", tt.name, tr.MaxIdleConnsPerHost, tt.wantIdle)
+		}
+		if tr.IdleConnTimeout != DefaultIdleConnTimeout {
+			t.Errorf("%s: IdleConnTimeout = %v, want %v", tt.name, tr.IdleConnTimeout, DefaultIdleConnTimeout)
+		}
+	}
+}
+
+func TestBodyStore_Client(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<html>page for %s</html>", r.URL.Query().Get("id")[:1])
+	}))
+	defer server.Close()
+
+	store := NewBodyStore()
+	c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, BodyStore: store})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	get := func(id string) *Response {
+		resp, err := c.Do(context.Background(), &Request{Method: "GET", URL: server.URL + "/?id=" + id})
+		if err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+		return resp
+	}
+
+	a, b, other := get("1"), get("1+AND+1=1"), get("2")
+	if &a.Body[0] != &b.Body[0] {
+		t.Errorf("identical responses should share their body: %q, %q", a.Body, b.Body)
+	}
+	if string(other.Body) != "<html>page for 2</html>" {
+		t.Errorf("other body = %q", other.Body)
+	}
+	if st := store.Stats(); st.Hits != 1 {
+		t.Errorf("hits = %d, want 1", st.Hits)
+	}
+}
+
+func TestDisableKeepAlives(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+	c := newTestClient(t)
+	get := func() *Response {
+		resp, err := c.Do(context.Background(), &Request{Method: "GET", URL: server.URL})
+		if err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+		return resp
+	}
+
+	if resp := get(); resp.Close {
+		t.Error("keep-alive response reported as closing")
+	}
+	get()
+	if st := c.Stats(); st.ConnsReused != 1 {
+		t.Fatalf("ConnsReused = %d before disabling, want 1", st.ConnsReused)
+	}
+
+	c.DisableKeepAlives()
+	for range 3 {
+		if resp := get(); !resp.Close {
+			t.Error("response after DisableKeepAlives should close its connection")
+		}
+	}
+	if st := c.Stats(); st.ConnsReused != 1 || st.ConnsOpened != 4 {
+		t.Errorf("opened %d, reused %d; want a new connection per request", st.ConnsOpened, st.ConnsReused)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Response size cap
+// ---------------------------------------------------------------------------
+
+func TestMaxResponseBytes_HugeStream(t *testing.T) {
+	const total = 50 << 20
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chunk := []byte(strings.Repeat("row,data,0123456789\n", 1<<10))
+		for sent := 0; sent < total; sent += len(chunk) {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+	c, err := NewClient(ClientOptions{Timeout: 10 * time.Second, MaxResponseBytes: 1 << 20})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	resp, err := c.Do(context.Background(), &Request{URL: srv.URL})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	runtime.ReadMemStats(&after)
+
+	if len(resp.Body) != 1<<20 || !resp.Truncated {
+		t.Errorf("got %d bytes, Truncated=%v; want the 1 MiB cap, truncated", len(resp.Body), resp.Truncated)
+	}
+	if resp.Anomalous() {
+		t.Errorf("anomalies %v: a capped body is still a page", resp.Anomalies)
+	}
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 16<<20 {
+		t.Errorf("reading a 50 MiB body allocated %d MiB, want it bounded by the cap", alloc>>20)
+	}
+}
+
+func TestMaxResponseBytes_KeepsConnection(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 10<<10)))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		limit     int64
+		wantLen   int
+		truncated bool
+	}{
+		{1 << 10, 1 << 10, true},
+		{10 << 10, 10 << 10, false},
+		{-1, 10 << 10, false},
+		{0, 10 << 10, false},
+	}
+	for _, tt := range tests {
+		c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, MaxResponseBytes: tt.limit})
+		if err != nil {
+			t.Fatalf("NewClient: %v", err)
+		}
+		for range 2 {
+			resp, err := c.Do(context.Background(), &Request{URL: srv.URL})
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if len(resp.Body) != tt.wantLen || resp.Truncated != tt.truncated {
+				t.Errorf("limit %d: got %d bytes, Truncated=%v; want %d, %v", tt.limit, len(resp.Body), resp.Truncated, tt.wantLen, tt.truncated)
+			}
+		}
+		// The rest of the body was drained, so the connection was reused.
+		if st := c.Stats(); st.ConnsReused != 1 {
+			t.Errorf("limit %d: %d connections reused, want 1", tt.limit, st.ConnsReused)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Protocol selection
+// ---------------------------------------------------------------------------
+
+func TestClient_Protocols(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Proto)
+	})
+	// A TLS server offering HTTP/2 and HTTP/1.1, one offering HTTP/1.1
+	// alone, and a plaintext one that also takes h2c with prior knowledge.
+	both := httptest.NewUnstartedServer(echo)
+	both.EnableHTTP2 = true
+	both.StartTLS()
+	defer both.Close()
+	h1 := httptest.NewUnstartedServer(echo)
+	h1.Config.ErrorLog = log.New(io.Discard, "", 0)
+	h1.StartTLS()
+	defer h1.Close()
+	h2c := httptest.NewUnstartedServer(echo)
+	h2c.Config.Protocols = new(http.Protocols)
+	h2c.Config.Protocols.SetHTTP1(true)
+	h2c.Config.Protocols.SetUnencryptedHTTP2(true)
+	h2c.Start()
+	defer h2c.Close()
+
+	tests := []struct {
+		name  string
+		url   string
+		http1 bool
+		http2 bool
+		want  string // "" when the request fails
+	}{
+		{"default TLS", both.URL, false, false, "HTTP/2.0"},
+		{"forced HTTP/1.1", both.URL, true, false, "HTTP/1.1"},
+		{"forced HTTP/2", both.URL, false, true, "HTTP/2.0"},
+		{"HTTP/1.1-only server", h1.URL, false, false, "HTTP/1.1"},
+		{"forced HTTP/2 to an HTTP/1.1-only server", h1.URL, false, true, ""},
+		{"default plaintext", h2c.URL, false, false, "HTTP/1.1"},
+		{"h2c", h2c.URL, false, true, "HTTP/2.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClient(ClientOptions{
+				Timeout:            5 * time.Second,
+				InsecureSkipVerify: true,
+				ForceHTTP1:         tt.http1,
+				ForceHTTP2:         tt.http2,
+			})
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+			resp, err := c.Do(context.Background(), &Request{URL: tt.url})
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("Do succeeded over %s, want an error", resp.Protocol)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if resp.Protocol != tt.want || string(resp.Body) != tt.want {
+				t.Errorf("Protocol = %s, server saw %s; want %s", resp.Protocol, resp.Body, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewClient(ClientOptions{ForceHTTP1: true, ForceHTTP2: true}); err == nil {
+		t.Error("NewClient with ForceHTTP1 and ForceHTTP2 succeeded")
+	}
+}
@@ -0,0 +1,94 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// cookieJar keeps the cookies targets set, for ClientOptions.UseCookieJar.
+type cookieJar struct {
+	jar *cookiejar.Jar
+
+	mu    sync.Mutex
+	given map[string]string // Value each cookie was first sent with, by name
+}
+
+func newCookieJar() *cookieJar {
+	// Without a public suffix list the jar treats every domain as its
+	// own, which is all a scan of one target needs.
+	jar, _ := cookiejar.New(nil)
+	return &cookieJar{jar: jar, given: make(map[string]string)}
+}
+
+// merge returns the Cookie header to send to u in place of header: its
+// cookies, each replaced by the jar's cookie of the same name unless its
+// value differs from the one the cookie was first sent with, followed by
+// the jar's other cookies. The header is rewritten as written, so a
+// payload in a cookie value reaches the target untouched.
+func (j *cookieJar) merge(u *url.URL, header string) string {
+	stored := make(map[string]string)
+	var order []string
+	for _, ck := range j.jar.Cookies(u) {
+		stored[ck.Name] = ck.Value
+		order = append(order, ck.Name)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var pairs []string
+	sent := make(map[string]bool)
+	if header != "" {
+		for _, pair := range strings.Split(header, ";") {
+			pair = strings.TrimSpace(pair)
+			name, value, _ := strings.Cut(pair, "=")
+			if given, ok := j.given[name]; !ok {
+				j.given[name] = value
+			} else if v, ok := stored[name]; ok && value == given {
+				pair = name + "=" + v
+			}
+			pairs = append(pairs, pair)
+			sent[name] = true
+		}
+	}
+	for _, name := range order {
+		if !sent[name] {
+			pairs = append(pairs, name+"="+stored[name])
+		}
+	}
+	return strings.Join(pairs, "; ")
+}
+
+// jarTransport sends the cookies of its jar with every request it
+// round-trips and stores the cookies every response sets.
+type jarTransport struct {
+	next http.RoundTripper
+	jar  *cookieJar
+}
+
+func (t *jarTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	if header := t.jar.merge(req.URL, strings.Join(req.Header.Values("Cookie"), "; ")); header != "" {
+		r.Header.Set("Cookie", header)
+	}
+	resp, err := t.next.RoundTrip(r)
+	if err == nil {
+		if cookies := resp.Cookies(); len(cookies) > 0 {
+			t.jar.jar.SetCookies(req.URL, cookies)
+		}
+	}
+	return resp, err
+}
+
+// CloseIdleConnections closes the idle connections of the wrapped
+// transport.
+func (t *jarTransport) CloseIdleConnections() { closeIdle(t.next) }
+
+// closeIdle closes the idle connections of rt, if it keeps any.
+func closeIdle(rt http.RoundTripper) {
+	if c, ok := rt.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,126 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newRotatingSessionServer returns a server that rotates its session cookie
+// every 3 requests, refusing any but the current one, and echoes the
+// request's other cookie.
+func newRotatingSessionServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	session, served := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if ck, err := r.Cookie("sid"); err != nil || ck.Value != fmt.Sprintf("s%d", session) {
+			http.Error(w, "session expired", http.StatusForbidden)
+			return
+		}
+		if served++; served%3 == 0 {
+			session++
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: fmt.Sprintf("s%d", session)})
+		}
+		theme, _ := r.Cookie("theme")
+		fmt.Fprintf(w, "ok theme=%s", theme.Value)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCookieJar_RotatedSession(t *testing.T) {
+	for _, jar := range []bool{false, true} {
+		t.Run(fmt.Sprintf("jar=%v", jar), func(t *testing.T) {
+			srv := newRotatingSessionServer(t)
+			c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, UseCookieJar: jar})
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+			req := &Request{URL: srv.URL, Cookies: map[string]string{"sid": "s0", "theme": "dark"}}
+			ok := 0
+			for range 10 {
+				resp, err := c.Do(context.Background(), req)
+				if err != nil {
+					t.Fatalf("Do: %v", err)
+				}
+				if resp.StatusCode == http.StatusOK {
+					ok++
+					if got := string(resp.Body); got != "ok theme=dark" {
+						t.Errorf("body = %q, want the user's theme cookie kept", got)
+					}
+				}
+			}
+			switch {
+			case jar && ok != 10:
+				t.Errorf("%d of 10 requests got past the rotation with the jar, want all", ok)
+			case !jar && ok != 3:
+				t.Errorf("%d of 10 requests succeeded without the jar, want the 3 before the rotation", ok)
+			}
+
+			cookies := c.Cookies(srv.URL)
+			if !jar {
+				if cookies != nil {
+					t.Errorf("Cookies() = %v without a jar", cookies)
+				}
+				return
+			}
+			if len(cookies) != 1 || cookies[0].Name != "sid" || cookies[0].Value != "s3" {
+				t.Errorf("Cookies() = %v, want sid=s3", cookies)
+			}
+		})
+	}
+}
+
+func TestCookieJar_Concurrent(t *testing.T) {
+	srv := newRotatingSessionServer(t)
+	c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, UseCookieJar: true})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	// Concurrent requests may race a rotation; the jar must stay
+	// consistent and the session recoverable.
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 5 {
+				c.Do(context.Background(), &Request{URL: srv.URL, Cookies: map[string]string{"sid": "s0", "theme": "x"}})
+				c.Cookies(srv.URL)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCookieJar_Merge(t *testing.T) {
+	u, _ := url.Parse("http://target/")
+	j := newCookieJar()
+	if got := j.merge(u, "sid=s0; theme=dark"); got != "sid=s0; theme=dark" {
+		t.Errorf("merge before any Set-Cookie = %q", got)
+	}
+	j.jar.SetCookies(u, []*http.Cookie{{Name: "sid", Value: "s1"}, {Name: "lb", Value: "node2"}})
+
+	tests := []struct {
+		header, want string
+	}{
+		{"sid=s0; theme=dark", "sid=s1; theme=dark; lb=node2"},
+		// A probe changed the cookie: its payload goes out untouched.
+		{"sid=s0' AND 1=1-- ; theme=dark", "sid=s0' AND 1=1--; theme=dark; lb=node2"},
+		{"theme=light", "theme=light; sid=s1; lb=node2"},
+		{"", "sid=s1; lb=node2"},
+	}
+	for _, tt := range tests {
+		if got := j.merge(u, tt.header); got != tt.want {
+			t.Errorf("merge(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
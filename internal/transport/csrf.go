@@ -0,0 +1,185 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/0x6d61/sqleech/internal/rawquery"
+)
+
+// CSRFOptions configures a CSRFClient.
+type CSRFOptions struct {
+	// Name is the parameter, cookie or header that carries the token.
+	Name string
+
+	// URL is the page the token is read from.
+	URL string
+
+	// Pattern extracts the token from the page: its first non-empty
+	// submatch, or the whole match when it has no groups. nil matches a
+	// hidden input or a meta tag named Name.
+	Pattern *regexp.Regexp
+}
+
+// CSRFClient wraps a Client for targets that require a fresh anti-CSRF
+// token with every request. Before each request it fetches the token page
+// with the request's headers and cookies, reads the token off it and
+// substitutes it for the value of Name: the form body or query parameter,
+// header or cookie of that name, in that order, or a parameter appended to
+// the form body, else the query, when the request has none. Cookies the
+// page sets, such as a rotated session or the cookie half of a
+// double-submit token, are sent along.
+//
+// Requests are sent one at a time, since a token fetched for one request
+// may invalidate the token another is about to use.
+type CSRFClient struct {
+	inner   Client
+	opts    CSRFOptions
+	pattern *regexp.Regexp
+
+	mu sync.Mutex // Held from fetching a token to using it
+}
+
+// NewCSRFClient wraps inner.
+func NewCSRFClient(inner Client, opts CSRFOptions) *CSRFClient {
+	pattern := opts.Pattern
+	if pattern == nil {
+		pattern = tokenPattern(opts.Name)
+	}
+	return &CSRFClient{inner: inner, opts: opts, pattern: pattern}
+}
+
+// tokenPattern matches the value of a hidden input named name, with its
+// attributes in either order, or the content of a meta tag named name.
+func tokenPattern(name string) *regexp.Regexp {
+	n := regexp.QuoteMeta(name)
+	return regexp.MustCompile(`(?is)` +
+		`<input\b[^>]*?\bname=["']?` + n + `["'\s/>][^>]*?\bvalue=["']?([^"'\s>]*)` +
+		`|<input\b[^>]*?\bvalue=["']?([^"'\s>]*)[^>]*?\bname=["']?` + n + `["'\s/>]` +
+		`|<meta\b[^>]*?\bname=["']?` + n + `["'\s/>][^>]*?\bcontent=["']?([^"'\s>]*)`)
+}
+
+// Do fetches a fresh token and sends req with it.
+func (c *CSRFClient) Do(ctx context.Context, req *Request) (*Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	token, cookies, err := c.fetch(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return c.inner.Do(ctx, withToken(req, c.opts.Name, token, cookies))
+}
+
+// fetch requests the token page as req would be sent and returns the
+// token and the cookies the page set. A page that sets a cookie named
+// Name but shows no token hands out the cookie's value.
+func (c *CSRFClient) fetch(ctx context.Context, req *Request) (string, map[string]string, error) {
+	resp, err := c.inner.Do(ctx, &Request{
+		Method:  http.MethodGet,
+		URL:     c.opts.URL,
+		Headers: req.Headers,
+		Cookies: req.Cookies,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("fetching CSRF token: %w", err)
+	}
+	var cookies map[string]string
+	for _, line := range resp.Headers.Values("Set-Cookie") {
+		if ck, err := http.ParseSetCookie(line); err == nil {
+			if cookies == nil {
+				cookies = make(map[string]string)
+			}
+			cookies[ck.Name] = ck.Value
+		}
+	}
+	token := c.extract(resp.Body)
+	if token == "" {
+		token = cookies[c.opts.Name]
+	}
+	if token == "" {
+		return "", nil, fmt.Errorf("CSRF token %q not found at %s", c.opts.Name, c.opts.URL)
+	}
+	return token, cookies, nil
+}
+
+// extract returns the token in body, or "" when the pattern finds none.
+func (c *CSRFClient) extract(body []byte) string {
+	m := c.pattern.FindSubmatch(body)
+	switch {
+	case m == nil:
+		return ""
+	case len(m) == 1:
+		return html.UnescapeString(string(m[0]))
+	}
+	for _, g := range m[1:] {
+		if len(g) > 0 {
+			return html.UnescapeString(string(g))
+		}
+	}
+	return ""
+}
+
+// withToken returns a copy of req carrying the given cookies and token
+// as the value of name.
+func withToken(req *Request, name, token string, cookies map[string]string) *Request {
+	out := req.Clone()
+	if len(cookies) > 0 && out.Cookies == nil {
+		out.Cookies = make(map[string]string, len(cookies))
+	}
+	for k, v := range cookies {
+		out.Cookies[k] = v
+	}
+
+	// The query is rewritten as written, so a payload elsewhere in the
+	// URL is not re-encoded.
+	base, query, _ := strings.Cut(out.URL, "?")
+	form := out.Body != "" && strings.Contains(strings.ToLower(out.ContentType), "x-www-form-urlencoded")
+	switch {
+	case form && hasPair(out.Body, name):
+		out.Body = rawquery.Set(out.Body, name, token)
+		return out
+	case hasPair(query, name):
+		out.URL = base + "?" + rawquery.Set(query, name, token)
+		return out
+	}
+	for k := range out.Headers {
+		if strings.EqualFold(k, name) {
+			out.Headers[k] = token
+			return out
+		}
+	}
+	if _, ok := out.Cookies[name]; ok {
+		out.Cookies[name] = token
+		return out
+	}
+	if form {
+		out.Body = rawquery.Set(out.Body, name, token)
+	} else {
+		out.URL = base + "?" + rawquery.Set(query, name, token)
+	}
+	return out
+}
+
+// hasPair reports whether the query string raw has a pair named name.
+func hasPair(raw, name string) bool {
+	values, _ := url.ParseQuery(raw)
+	_, ok := values[name]
+	return ok
+}
+
+// SetProxy configures the proxy of the wrapped client.
+func (c *CSRFClient) SetProxy(proxyURL string) error { return c.inner.SetProxy(proxyURL) }
+
+// SetRateLimit sets the rate limit of the wrapped client.
+func (c *CSRFClient) SetRateLimit(rps float64) { c.inner.SetRateLimit(rps) }
+
+// Stats returns the statistics of the wrapped client, token fetches
+// included.
+func (c *CSRFClient) Stats() *TransportStats { return c.inner.Stats() }
@@ -0,0 +1,157 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCSRFClient_Extract(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		page    string
+		want    string
+	}{
+		{"hidden input", "", `<form><input type="hidden" name="csrf" value="a1b2"></form>`, "a1b2"},
+		{"value first", "", `<input value='a1b2' type=hidden name=csrf />`, "a1b2"},
+		{"meta tag", "", `<meta name="csrf" content="a1b2">`, "a1b2"},
+		{"entities decoded", "", `<input name="csrf" value="a+b&#x2F;c=">`, "a+b/c="},
+		{"longer name not matched", "", `<input name="csrf_old" value="x"><input name="csrf" value="y">`, "y"},
+		{"absent", "", `<input name="user" value="x">`, ""},
+		{"custom group", `token: "([0-9a-f]+)"`, `var config = {token: "c0ffee"};`, "c0ffee"},
+		{"custom whole match", `[0-9a-f]{8}`, `nonce 1234abcd here`, "1234abcd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := CSRFOptions{Name: "csrf"}
+			if tt.pattern != "" {
+				opts.Pattern = regexp.MustCompile(tt.pattern)
+			}
+			if got := NewCSRFClient(nil, opts).extract([]byte(tt.page)); got != tt.want {
+				t.Errorf("extract() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithToken(t *testing.T) {
+	const form = "application/x-www-form-urlencoded"
+	tests := []struct {
+		name string
+		req  Request
+		want Request
+	}{
+		{
+			"query",
+			Request{URL: "http://t/p?id=1%27&csrf=old&b=2"},
+			Request{URL: "http://t/p?id=1%27&csrf=new&b=2"},
+		},
+		{
+			"form body",
+			Request{URL: "http://t/p?csrf=old", Body: "id=1&csrf=old", ContentType: form},
+			Request{URL: "http://t/p?csrf=old", Body: "id=1&csrf=new", ContentType: form},
+		},
+		{
+			"header",
+			Request{URL: "http://t/p?id=1", Headers: map[string]string{"CSRF": "old"}},
+			Request{URL: "http://t/p?id=1", Headers: map[string]string{"CSRF": "new"}},
+		},
+		{
+			"cookie",
+			Request{URL: "http://t/p?id=1", Cookies: map[string]string{"csrf": "old"}},
+			Request{URL: "http://t/p?id=1", Cookies: map[string]string{"csrf": "new"}},
+		},
+		{
+			"appended to form body",
+			Request{URL: "http://t/p", Body: "id=1", ContentType: form},
+			Request{URL: "http://t/p", Body: "id=1&csrf=new", ContentType: form},
+		},
+		{
+			"appended to query",
+			Request{URL: "http://t/p", Body: `{"id":1}`, ContentType: "application/json"},
+			Request{URL: "http://t/p?csrf=new", Body: `{"id":1}`, ContentType: "application/json"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := withToken(&tt.req, "csrf", "new", nil)
+			if got.URL != tt.want.URL || got.Body != tt.want.Body ||
+				fmt.Sprint(got.Headers) != fmt.Sprint(tt.want.Headers) || fmt.Sprint(got.Cookies) != fmt.Sprint(tt.want.Cookies) {
+				t.Errorf("withToken() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	req := &Request{URL: "http://t/p?csrf=old", Cookies: map[string]string{"session": "s1"}}
+	got := withToken(req, "csrf", "new", map[string]string{"session": "s2", "csrf": "new"})
+	if got.URL != "http://t/p?csrf=new" || got.Cookies["session"] != "s2" || got.Cookies["csrf"] != "new" {
+		t.Errorf("withToken() with page cookies = %+v", got)
+	}
+	if req.Cookies["session"] != "s1" {
+		t.Error("withToken() modified the request")
+	}
+}
+
+// newRotatingServer serves a fresh token on /form and accepts a request
+// to any other path only with the latest one, rejecting the rest with 403.
+func newRotatingServer() *httptest.Server {
+	var mu sync.Mutex
+	current, n := "", 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/form" {
+			n++
+			current = fmt.Sprintf("tok%d", n)
+			fmt.Fprintf(w, `<input type="hidden" name="csrf" value="%s">`, current)
+			return
+		}
+		if current == "" || r.FormValue("csrf") != current {
+			http.Error(w, "stale token", http.StatusForbidden)
+			return
+		}
+		current = ""
+		fmt.Fprint(w, "ok")
+	}))
+}
+
+func TestCSRFClient_Do(t *testing.T) {
+	srv := newRotatingServer()
+	defer srv.Close()
+
+	inner, err := NewClient(ClientOptions{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	client := NewCSRFClient(inner, CSRFOptions{Name: "csrf", URL: srv.URL + "/form"})
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Do(context.Background(), &Request{URL: fmt.Sprintf("%s/act?id=%d&csrf=stale", srv.URL, i)})
+			if err != nil {
+				t.Errorf("Do: %v", err)
+				return
+			}
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("status = %d, want 200", resp.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+
+	client = NewCSRFClient(inner, CSRFOptions{Name: "token", URL: srv.URL + "/form"})
+	if _, err := client.Do(context.Background(), &Request{URL: srv.URL + "/act"}); err == nil ||
+		!strings.Contains(err.Error(), `"token" not found`) {
+		t.Errorf("Do() with no token on the page = %v, want a not-found error", err)
+	}
+}
@@ -0,0 +1,132 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"golang.org/x/net/html/charset"
+)
+
+// decodeContent undoes the Content-Encoding of body: gzip, deflate and br,
+// applied in any order. It returns body as is and an empty encoding when
+// the coding is one it does not know or the body does not decode, unless
+// the body was cut short: then what decoded before the cut is kept. The
+// decoded body is capped at limit bytes (none when negative), and
+// truncated reports whether it was cut there.
+func decodeContent(header http.Header, body []byte, cut bool, limit int64) (decoded []byte, encoding string, truncated bool) {
+	var codings []string
+	for _, v := range header.Values("Content-Encoding") {
+		for _, c := range strings.Split(v, ",") {
+			c = strings.ToLower(strings.TrimSpace(c))
+			if c != "" && c != "identity" {
+				codings = append(codings, c)
+			}
+		}
+	}
+	if len(codings) == 0 {
+		return body, "", false
+	}
+
+	// Codings are listed in the order they were applied.
+	var r io.Reader = bytes.NewReader(body)
+	for i := len(codings) - 1; i >= 0; i-- {
+		var err error
+		switch codings[i] {
+		case "gzip", "x-gzip":
+			r, err = gzip.NewReader(r)
+		case "deflate":
+			r = inflater(r)
+		case "br":
+			r = brotli.NewReader(r)
+		default:
+			return body, "", false
+		}
+		if err != nil {
+			return body, "", false
+		}
+	}
+	if limit >= 0 {
+		r = io.LimitReader(r, limit+1)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil && !cut {
+		return body, "", false
+	}
+	if limit >= 0 && int64(len(out)) > limit {
+		out, truncated = out[:limit], true
+	}
+	return out, strings.Join(codings, ", "), truncated
+}
+
+// inflater reads a deflate body. The coding is zlib-wrapped, but servers
+// often send the raw deflate stream instead.
+func inflater(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	if head, err := br.Peek(2); err == nil && head[0]&0x0f == 8 && binary.BigEndian.Uint16(head)%31 == 0 {
+		if zr, err := zlib.NewReader(br); err == nil {
+			return zr
+		}
+	}
+	return flate.NewReader(br)
+}
+
+// metaCharset finds the charset of an HTML page declared in a meta tag,
+// <meta charset=...> or <meta http-equiv="Content-Type" content="...;
+// charset=...">.
+var metaCharset = regexp.MustCompile(`(?i)<meta\b[^>]*?\bcharset\s*=\s*["']?([\w.:-]+)`)
+
+// metaSniffBytes is how far into a page metaCharset looks, as browsers do.
+const metaSniffBytes = 1024
+
+// decodeCharset converts a text body to UTF-8 from the charset declared by
+// contentType or, for HTML, a meta tag near the start of the page. It
+// returns the body as is and an empty charset when there is nothing to
+// convert: a binary type, no or an unknown declaration, or UTF-8.
+func decodeCharset(contentType string, body []byte) ([]byte, string) {
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if !textual(mediaType) {
+		return body, ""
+	}
+	label := params["charset"]
+	if label == "" && (mediaType == "" || strings.Contains(mediaType, "html")) {
+		if m := metaCharset.FindSubmatch(body[:min(len(body), metaSniffBytes)]); m != nil {
+			label = string(m[1])
+		}
+	}
+	if label == "" {
+		return body, ""
+	}
+	enc, name := charset.Lookup(label)
+	if enc == nil || name == "utf-8" {
+		return body, ""
+	}
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return body, ""
+	}
+	return decoded, name
+}
+
+// textual reports whether bodies of mediaType are text: text/*, HTML, XML,
+// JSON and JavaScript, or no declared type at all.
+func textual(mediaType string) bool {
+	if mediaType == "" || strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	for _, s := range []string{"html", "xml", "json", "javascript"} {
+		if strings.Contains(mediaType, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package transport
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// compress returns s compressed with the named coding.
+func compress(t *testing.T, coding string, s []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch coding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	default:
+		t.Fatalf("unknown coding %q", coding)
+	}
+	w.Write(s)
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestDecodeContent(t *testing.T) {
+	page := []byte(strings.Repeat("You have an error in your SQL syntax; ", 50))
+	stacked := compress(t, "br", compress(t, "gzip", page))
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		want     []byte
+		wantEnc  string
+	}{
+		{"gzip", "gzip", compress(t, "gzip", page), page, "gzip"},
+		{"x-gzip", "X-Gzip", compress(t, "gzip", page), page, "x-gzip"},
+		{"deflate", "deflate", compress(t, "deflate", page), page, "deflate"},
+		{"raw deflate", "deflate", compress(t, "raw-deflate", page), page, "deflate"},
+		{"brotli", "br", compress(t, "br", page), page, "br"},
+		{"stacked", "gzip, br", stacked, page, "gzip, br"},
+		{"identity", "identity", page, page, ""},
+		{"none", "", page, page, ""},
+		{"unknown coding", "zstd", []byte("\x28\xb5\x2f\xfd"), []byte("\x28\xb5\x2f\xfd"), ""},
+		{"not compressed", "gzip", page, page, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			if tt.encoding != "" {
+				h.Set("Content-Encoding", tt.encoding)
+			}
+			got, enc, truncated := decodeContent(h, tt.body, false, -1)
+			if !bytes.Equal(got, tt.want) || enc != tt.wantEnc || truncated {
+				t.Errorf("decodeContent = %.40q…, %q, %v; want %.40q…, %q", got, enc, truncated, tt.want, tt.wantEnc)
+			}
+		})
+	}
+}
+
+func TestDecodeContent_Limits(t *testing.T) {
+	var b strings.Builder
+	for i := range 5000 {
+		fmt.Fprintf(&b, "%d,", i*i)
+	}
+	page := []byte(b.String())
+	h := http.Header{"Content-Encoding": {"gzip"}}
+	gz := compress(t, "gzip", page)
+
+	// A body that decompresses past the cap is cut there.
+	got, enc, truncated := decodeContent(h, gz, false, 4096)
+	if len(got) != 4096 || enc != "gzip" || !truncated {
+		t.Errorf("capped: %d bytes, %q, truncated %v; want 4096 bytes of gzip, truncated", len(got), enc, truncated)
+	}
+
+	// A compressed body cut short keeps what decoded before the cut.
+	got, enc, _ = decodeContent(h, gz[:len(gz)/2], true, -1)
+	if len(got) == 0 || !bytes.HasPrefix(page, got) || enc != "gzip" {
+		t.Errorf("cut: %d bytes, %q; want a prefix of the page", len(got), enc)
+	}
+}
+
+func TestDecodeCharset(t *testing.T) {
+	sjis, _ := japanese.ShiftJIS.NewEncoder().String("<p>エラー: 田中</p>")
+	latin1, _ := charmap.ISO8859_1.NewEncoder().String("<p>Café</p>")
+	meta := `<html><head><meta http-equiv="Content-Type" content="text/html; charset=Shift_JIS"></head>` + sjis
+	meta5 := `<html><head><meta charset='shift_jis'>` + sjis
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+		wantCharset string
+	}{
+		{"header", "text/html; charset=Shift_JIS", sjis, "<p>エラー: 田中</p>", "shift_jis"},
+		{"latin-1", "text/plain; charset=ISO-8859-1", latin1, "<p>Café</p>", "windows-1252"},
+		{"http-equiv", "text/html", meta, strings.TrimSuffix(meta, sjis) + "<p>エラー: 田中</p>", "shift_jis"},
+		{"meta charset", "", meta5, strings.TrimSuffix(meta5, sjis) + "<p>エラー: 田中</p>", "shift_jis"},
+		{"header wins", "text/html; charset=iso-8859-1", `<meta charset="shift_jis">` + latin1, `<meta charset="shift_jis"><p>Café</p>`, "windows-1252"},
+		{"utf-8", "text/html; charset=utf-8", "<p>Café</p>", "<p>Café</p>", ""},
+		{"undeclared", "text/html", sjis, sjis, ""},
+		{"unknown", "text/html; charset=x-klingon", sjis, sjis, ""},
+		{"binary", "image/png; charset=shift_jis", sjis, sjis, ""},
+		{"json", "application/json; charset=shift_jis", sjis, "<p>エラー: 田中</p>", "shift_jis"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, cs := decodeCharset(tt.contentType, []byte(tt.body))
+			if string(got) != tt.want || cs != tt.wantCharset {
+				t.Errorf("decodeCharset = %q, %q; want %q, %q", got, cs, tt.want, tt.wantCharset)
+			}
+		})
+	}
+}
+
+func TestClient_DecodesBodies(t *testing.T) {
+	page := "<p>You have an error in your SQL syntax near 'エラー'</p>"
+	sjis, _ := japanese.ShiftJIS.NewEncoder().String(page)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=Shift_JIS")
+		// Answer in the first coding asked for, as the client set it.
+		coding, _, _ := strings.Cut(r.Header.Get("Accept-Encoding"), ",")
+		w.Header().Set("Content-Encoding", coding)
+		w.Write(compress(t, coding, []byte(sjis)))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t)
+	for _, coding := range []string{"gzip", "deflate", "br"} {
+		resp, err := c.Do(context.Background(), &Request{
+			URL:     srv.URL,
+			Headers: map[string]string{"Accept-Encoding": coding + ", identity"},
+		})
+		if err != nil {
+			t.Fatalf("%s: Do: %v", coding, err)
+		}
+		if string(resp.Body) != page {
+			t.Errorf("%s: body = %q, want %q", coding, resp.Body, page)
+		}
+		if resp.ContentEncoding != coding || resp.Charset != "shift_jis" {
+			t.Errorf("%s: ContentEncoding, Charset = %q, %q", coding, resp.ContentEncoding, resp.Charset)
+		}
+		if resp.Headers.Get("Content-Encoding") != "" || resp.Headers.Get("Content-Length") != "" || resp.ContentLength != -1 {
+			t.Errorf("%s: headers %v, ContentLength %d; want the encoding and length dropped", coding, resp.Headers, resp.ContentLength)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// Failure classifies why a request got no response.
+type Failure int
+
+const (
+	FailureNone    Failure = iota // The request did not fail
+	FailureClosed                 // The server closed the connection before answering in full
+	FailureReset                  // The connection was reset or aborted
+	FailureTimeout                // No answer within the timeout
+	FailureOther                  // Any other error, e.g. a refused connection
+)
+
+// String returns the failure name.
+func (f Failure) String() string {
+	return [...]string{"none", "closed", "reset", "timeout", "other"}[f]
+}
+
+// ClassifyFailure tells what kind of connection failure err, as returned
+// by Client.Do, reports. Targets that only speak HTTP/1.0 or drop
+// keep-alive connections show up as closed and reset connections.
+func ClassifyFailure(err error) Failure {
+	switch {
+	case err == nil:
+		return FailureNone
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNABORTED), errors.Is(err, syscall.EPIPE):
+		return FailureReset
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF),
+		strings.Contains(err.Error(), "server closed idle connection"):
+		return FailureClosed
+	case errors.Is(err, context.DeadlineExceeded):
+		return FailureTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return FailureTimeout
+	}
+	return FailureOther
+}
@@ -0,0 +1,76 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		err  error
+		want Failure
+	}{
+		{nil, FailureNone},
+		{fmt.Errorf("sending request: %w", io.EOF), FailureClosed},
+		{io.ErrUnexpectedEOF, FailureClosed},
+		{errors.New("http: server closed idle connection"), FailureClosed},
+		{&net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, FailureReset},
+		{&net.OpError{Op: "write", Err: os.NewSyscallError("write", syscall.EPIPE)}, FailureReset},
+		{context.DeadlineExceeded, FailureTimeout},
+		{&net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, FailureOther},
+	}
+	for _, tt := range tests {
+		if got := ClassifyFailure(tt.err); got != tt.want {
+			t.Errorf("ClassifyFailure(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// TestClassifyFailure_Client classifies the errors the client actually
+// returns for a server that resets or closes without answering.
+func TestClassifyFailure_Client(t *testing.T) {
+	tests := []struct {
+		name   string
+		hangUp func(net.Conn)
+		want   Failure
+	}{
+		{"reset", func(c net.Conn) { c.(*net.TCPConn).SetLinger(0); c.Close() }, FailureReset},
+		{"closed", func(c net.Conn) { c.Close() }, FailureClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("Listen: %v", err)
+			}
+			defer ln.Close()
+			go func() {
+				for {
+					conn, err := ln.Accept()
+					if err != nil {
+						return
+					}
+					// Let the request arrive, then hang up without a word.
+					conn.Read(make([]byte, 4096))
+					tt.hangUp(conn)
+				}
+			}()
+
+			c, err := NewClient(ClientOptions{Timeout: 5 * time.Second})
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+			_, err = c.Do(context.Background(), &Request{Method: "GET", URL: "http://" + ln.Addr().String() + "/"})
+			if got := ClassifyFailure(err); got != tt.want {
+				t.Errorf("ClassifyFailure(%v) = %v, want %v", err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sync"
+)
+
+// LoginOptions configures a SessionKeeper.
+type LoginOptions struct {
+	// URL, Method, Body and ContentType make up the login request. Method
+	// defaults to POST with a body and GET without.
+	URL         string
+	Method      string
+	Body        string
+	ContentType string
+
+	// LoggedOut matches the body of a response served to a client whose
+	// session has expired, such as the login page.
+	LoggedOut *regexp.Regexp
+}
+
+// SessionKeeper wraps a Client to keep a scan logged in to targets whose
+// sessions expire. A response matching LoggedOut has the keeper send the
+// login request, without following its redirect so the cookies it sets
+// are seen, and send the request again with those cookies. From then on
+// every request carries them, replacing its own cookies of the same
+// names.
+//
+// Concurrent requests that find the session expired log in once between
+// them.
+type SessionKeeper struct {
+	inner Client
+	opts  LoginOptions
+
+	mu      sync.Mutex
+	cookies map[string]string // Set by the last login
+	gen     int               // Incremented by every login
+}
+
+// NewSessionKeeper wraps inner.
+func NewSessionKeeper(inner Client, opts LoginOptions) *SessionKeeper {
+	if opts.Method == "" {
+		opts.Method = http.MethodGet
+		if opts.Body != "" {
+			opts.Method = http.MethodPost
+		}
+	}
+	if opts.Body != "" && opts.ContentType == "" {
+		opts.ContentType = "application/x-www-form-urlencoded"
+	}
+	return &SessionKeeper{inner: inner, opts: opts}
+}
+
+// Do sends req with the session's cookies, logging in again and resending
+// it once when the response shows the session has expired. A response
+// that still does is an error rather than a page to draw conclusions
+// from.
+func (k *SessionKeeper) Do(ctx context.Context, req *Request) (*Response, error) {
+	out, gen := k.withSession(req)
+	resp, err := k.inner.Do(ctx, out)
+	if err != nil || !k.opts.LoggedOut.Match(resp.Body) {
+		return resp, err
+	}
+	if err := k.login(ctx, req, gen); err != nil {
+		return nil, err
+	}
+	out, _ = k.withSession(req)
+	resp, err = k.inner.Do(ctx, out)
+	if err != nil {
+		return nil, err
+	}
+	if k.opts.LoggedOut.Match(resp.Body) {
+		return nil, fmt.Errorf("still logged out after logging in at %s", k.opts.URL)
+	}
+	return resp, nil
+}
+
+// withSession returns a copy of req carrying the session's cookies, and
+// the login they came from.
+func (k *SessionKeeper) withSession(req *Request) (*Request, int) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if len(k.cookies) == 0 {
+		return req, k.gen
+	}
+	out := req.Clone()
+	if out.Cookies == nil {
+		out.Cookies = make(map[string]string, len(k.cookies))
+	}
+	for name, value := range k.cookies {
+		out.Cookies[name] = value
+	}
+	return out, k.gen
+}
+
+// login sends the login request, unless another request has logged in
+// since gen, and keeps the cookies it sets.
+func (k *SessionKeeper) login(ctx context.Context, req *Request, gen int) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.gen != gen {
+		return nil
+	}
+
+	noRedirect := false
+	login := &Request{
+		Method:          k.opts.Method,
+		URL:             k.opts.URL,
+		Body:            k.opts.Body,
+		ContentType:     k.opts.ContentType,
+		Headers:         req.Headers,
+		FollowRedirects: &noRedirect,
+	}
+	resp, err := k.inner.Do(ctx, login)
+	if err != nil {
+		return fmt.Errorf("logging in: %w", err)
+	}
+	cookies := make(map[string]string, len(k.cookies))
+	for name, value := range k.cookies {
+		cookies[name] = value
+	}
+	set := false
+	for _, line := range resp.Headers.Values("Set-Cookie") {
+		if ck, err := http.ParseSetCookie(line); err == nil {
+			cookies[ck.Name] = ck.Value
+			set = true
+		}
+	}
+	if !set {
+		return fmt.Errorf("logging in: %s set no cookie", k.opts.URL)
+	}
+	k.cookies = cookies
+	k.gen++
+	return nil
+}
+
+// SetProxy configures the proxy of the wrapped client.
+func (k *SessionKeeper) SetProxy(proxyURL string) error { return k.inner.SetProxy(proxyURL) }
+
+// SetRateLimit sets the rate limit of the wrapped client.
+func (k *SessionKeeper) SetRateLimit(rps float64) { k.inner.SetRateLimit(rps) }
+
+// Stats returns the statistics of the wrapped client, logins included.
+func (k *SessionKeeper) Stats() *TransportStats { return k.inner.Stats() }
@@ -0,0 +1,138 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newLoginServer serves "secret" to requests carrying the session cookie
+// of the last login and a login page to the rest. A POST to /login
+// starts a new session, ending the previous one, and redirects to /.
+func newLoginServer(t *testing.T, setCookie bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var logins atomic.Int32
+	var mu sync.Mutex
+	session := ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/login" {
+			if r.Method != http.MethodPost || r.PostFormValue("user") != "admin" {
+				http.Error(w, "bad login", http.StatusBadRequest)
+				return
+			}
+			n := logins.Add(1)
+			session = fmt.Sprintf("s%d", n)
+			if setCookie {
+				http.SetCookie(w, &http.Cookie{Name: "sid", Value: session})
+			}
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+		if ck, err := r.Cookie("sid"); err != nil || ck.Value != session {
+			w.Write([]byte("<h1>Please sign in</h1>"))
+			return
+		}
+		fmt.Fprintf(w, "secret theme=%s", r.Header.Get("X-Theme"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &logins
+}
+
+func newKeeper(t *testing.T, srv *httptest.Server) *SessionKeeper {
+	t.Helper()
+	c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, FollowRedirects: true})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return NewSessionKeeper(c, LoginOptions{
+		URL:       srv.URL + "/login",
+		Body:      "user=admin",
+		LoggedOut: regexp.MustCompile(`Please sign in`),
+	})
+}
+
+func TestSessionKeeper_LogsInAgain(t *testing.T) {
+	srv, logins := newLoginServer(t, true)
+	k := newKeeper(t, srv)
+	ctx := context.Background()
+
+	// The stale cookie of the request is replaced by the session's.
+	req := &Request{URL: srv.URL + "/", Cookies: map[string]string{"sid": "expired"}, Headers: map[string]string{"X-Theme": "dark"}}
+	for i := range 3 {
+		resp, err := k.Do(ctx, req)
+		if err != nil {
+			t.Fatalf("Do #%d: %v", i, err)
+		}
+		if got := string(resp.Body); got != "secret theme=dark" {
+			t.Errorf("Do #%d body = %q", i, got)
+		}
+	}
+	if n := logins.Load(); n != 1 {
+		t.Errorf("logged in %d times, want once", n)
+	}
+	if req.Cookies["sid"] != "expired" {
+		t.Errorf("request cookies modified: %v", req.Cookies)
+	}
+
+	// The session ends when someone else logs in.
+	http.Post(srv.URL+"/login", "application/x-www-form-urlencoded", strings.NewReader("user=admin"))
+	if resp, err := k.Do(ctx, req); err != nil || !strings.HasPrefix(string(resp.Body), "secret") {
+		t.Fatalf("Do after expiry = %v, %v", resp, err)
+	}
+	if n := logins.Load(); n != 3 {
+		t.Errorf("logins = %d, want the keeper to log in again", n)
+	}
+}
+
+func TestSessionKeeper_ConcurrentExpiry(t *testing.T) {
+	srv, logins := newLoginServer(t, true)
+	k := newKeeper(t, srv)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := k.Do(context.Background(), &Request{URL: srv.URL + "/"}); err != nil {
+				t.Errorf("Do: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := logins.Load(); n != 1 {
+		t.Errorf("logged in %d times for 8 concurrent requests, want once", n)
+	}
+}
+
+func TestSessionKeeper_LoginFails(t *testing.T) {
+	srv, _ := newLoginServer(t, false)
+	k := newKeeper(t, srv)
+
+	_, err := k.Do(context.Background(), &Request{URL: srv.URL + "/"})
+	if err == nil || !strings.Contains(err.Error(), "set no cookie") {
+		t.Errorf("Do = %v, want a login that sets no cookie reported", err)
+	}
+
+	// A login whose session does not get past the login page.
+	stuck := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "guest"})
+		}
+		w.Write([]byte("<h1>Please sign in</h1>"))
+	}))
+	defer stuck.Close()
+	k = newKeeper(t, stuck)
+	_, err = k.Do(context.Background(), &Request{URL: stuck.URL + "/"})
+	if err == nil || !strings.Contains(err.Error(), "still logged out") {
+		t.Errorf("Do = %v, want the login page after logging in reported", err)
+	}
+}
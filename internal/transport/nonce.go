@@ -0,0 +1,81 @@
+package transport
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NonceFormat is the value format of a generated nonce header.
+type NonceFormat string
+
+const (
+	// NonceUUID is a random (version 4) UUID.
+	NonceUUID NonceFormat = "uuid"
+
+	// NonceEpochMillis is the send time in Unix milliseconds, for
+	// timestamp headers checked against a freshness window.
+	NonceEpochMillis NonceFormat = "epoch-ms"
+
+	// NonceRandomHex is a string of random hex digits; the length is
+	// given in the spec as random-hex-N.
+	NonceRandomHex NonceFormat = "random-hex"
+)
+
+// maxNonceHexLen bounds random-hex-N.
+const maxNonceHexLen = 256
+
+// NonceHeader is a header whose value is generated fresh for every request
+// the client sends, for targets that reject replayed or missing nonces.
+type NonceHeader struct {
+	Name   string
+	Format NonceFormat
+	HexLen int // Number of hex digits for NonceRandomHex
+}
+
+// ParseNonceHeader parses a NAME[:format] spec, where format is uuid (the
+// default), epoch-ms or random-hex-N.
+func ParseNonceHeader(spec string) (NonceHeader, error) {
+	name, format, _ := strings.Cut(spec, ":")
+	name = strings.TrimSpace(name)
+	format = strings.ToLower(strings.TrimSpace(format))
+	if name == "" {
+		return NonceHeader{}, fmt.Errorf("nonce header %q: missing header name", spec)
+	}
+
+	h := NonceHeader{Name: name, Format: NonceUUID}
+	switch {
+	case format == "" || format == string(NonceUUID):
+	case format == string(NonceEpochMillis):
+		h.Format = NonceEpochMillis
+	case strings.HasPrefix(format, string(NonceRandomHex)+"-"):
+		n, err := strconv.Atoi(strings.TrimPrefix(format, string(NonceRandomHex)+"-"))
+		if err != nil || n < 1 || n > maxNonceHexLen {
+			return NonceHeader{}, fmt.Errorf("nonce header %q: random-hex length must be 1-%d", spec, maxNonceHexLen)
+		}
+		h.Format = NonceRandomHex
+		h.HexLen = n
+	default:
+		return NonceHeader{}, fmt.Errorf("nonce header %q: unknown format %q (use uuid, epoch-ms or random-hex-N)", spec, format)
+	}
+	return h, nil
+}
+
+// Generate returns a fresh value for the header.
+func (h NonceHeader) Generate() string {
+	switch h.Format {
+	case NonceEpochMillis:
+		return strconv.FormatInt(time.Now().UnixMilli(), 10)
+	case NonceRandomHex:
+		b := make([]byte, (h.HexLen+1)/2)
+		_, _ = rand.Read(b)
+		return hex.EncodeToString(b)[:h.HexLen]
+	default:
+		return uuid.NewString()
+	}
+}
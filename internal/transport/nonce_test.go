@@ -0,0 +1,108 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseNonceHeader(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    NonceHeader
+		wantErr bool
+	}{
+		{"X-Nonce", NonceHeader{Name: "X-Nonce", Format: NonceUUID}, false},
+		{"X-Nonce:uuid", NonceHeader{Name: "X-Nonce", Format: NonceUUID}, false},
+		{"X-Timestamp:epoch-ms", NonceHeader{Name: "X-Timestamp", Format: NonceEpochMillis}, false},
+		{"X-Request-Id:random-hex-16", NonceHeader{Name: "X-Request-Id", Format: NonceRandomHex, HexLen: 16}, false},
+		{" X-Nonce : UUID ", NonceHeader{Name: "X-Nonce", Format: NonceUUID}, false},
+		{"", NonceHeader{}, true},
+		{":uuid", NonceHeader{}, true},
+		{"X-Nonce:guid", NonceHeader{}, true},
+		{"X-Nonce:random-hex", NonceHeader{}, true},
+		{"X-Nonce:random-hex-0", NonceHeader{}, true},
+		{"X-Nonce:random-hex-999", NonceHeader{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseNonceHeader(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseNonceHeader(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseNonceHeader(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestNonceHeader_Generate(t *testing.T) {
+	uuidRe := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	uuid := NonceHeader{Name: "X-Nonce", Format: NonceUUID}
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		v := uuid.Generate()
+		if !uuidRe.MatchString(v) {
+			t.Fatalf("uuid nonce %q is not a version 4 UUID", v)
+		}
+		if seen[v] {
+			t.Fatalf("uuid nonce %q repeated", v)
+		}
+		seen[v] = true
+	}
+
+	hex := NonceHeader{Name: "X-Nonce", Format: NonceRandomHex, HexLen: 7}
+	if v := hex.Generate(); !regexp.MustCompile(`^[0-9a-f]{7}$`).MatchString(v) {
+		t.Errorf("random-hex-7 nonce = %q", v)
+	}
+
+	before := time.Now().UnixMilli()
+	ms, err := strconv.ParseInt(NonceHeader{Name: "X-Timestamp", Format: NonceEpochMillis}.Generate(), 10, 64)
+	if err != nil || ms < before || ms > time.Now().UnixMilli() {
+		t.Errorf("epoch-ms nonce = %d (%v), want current time", ms, err)
+	}
+}
+
+func TestClient_NonceHeaders(t *testing.T) {
+	var mu sync.Mutex
+	received := make(map[string]bool)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		nonce := r.Header.Get("X-Nonce")
+		if nonce == "" || received[nonce] {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		received[nonce] = true
+		_, _ = w.Write([]byte(nonce))
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(ClientOptions{NonceHeaders: []NonceHeader{{Name: "X-Nonce", Format: NonceUUID}}})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	req := &Request{URL: srv.URL, Headers: map[string]string{"X-Nonce": "stale"}}
+	for i := 0; i < 5; i++ {
+		resp, err := client.Do(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("request %d: status %d, nonce was reused", i, resp.StatusCode)
+		}
+		// The response records the value that was actually sent.
+		if got := resp.Nonces["X-Nonce"]; got != string(resp.Body) {
+			t.Errorf("Nonces[X-Nonce] = %q, server saw %q", got, resp.Body)
+		}
+	}
+	if req.Headers["X-Nonce"] != "stale" {
+		t.Error("Do modified the caller's request headers")
+	}
+}
@@ -0,0 +1,230 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// NTLM negotiate flags (MS-NLMP 2.2.2.5) sqleech asks for.
+const (
+	ntlmUnicode            = 0x00000001
+	ntlmRequestTarget      = 0x00000004
+	ntlmNTLM               = 0x00000200
+	ntlmAlwaysSign         = 0x00008000
+	ntlmExtendedSessionSec = 0x00080000
+	ntlmTargetInfo         = 0x00800000
+	ntlm128                = 0x20000000
+	ntlm56                 = 0x80000000
+
+	ntlmNegotiateFlags = ntlmUnicode | ntlmRequestTarget | ntlmNTLM | ntlmAlwaysSign |
+		ntlmExtendedSessionSec | ntlmTargetInfo | ntlm128 | ntlm56
+)
+
+// AV_PAIR ids (MS-NLMP 2.2.2.1) read from a challenge's target info.
+const (
+	ntlmAvEOL       uint16 = 0
+	ntlmAvTimestamp uint16 = 7
+)
+
+var ntlmSignature = []byte("NTLMSSP\x00")
+
+// ntlmNegotiate returns the NEGOTIATE_MESSAGE that opens a handshake.
+func ntlmNegotiate() []byte {
+	msg := make([]byte, 32)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 1)
+	binary.LittleEndian.PutUint32(msg[12:], ntlmNegotiateFlags)
+	return msg
+}
+
+// ntlmChallenge is the part of a CHALLENGE_MESSAGE the answer depends on.
+type ntlmChallenge struct {
+	flags      uint32
+	challenge  []byte
+	targetInfo []byte
+}
+
+// parseNTLMChallenge parses a CHALLENGE_MESSAGE.
+func parseNTLMChallenge(msg []byte) (*ntlmChallenge, error) {
+	if len(msg) < 32 || !bytes.Equal(msg[:8], ntlmSignature) || binary.LittleEndian.Uint32(msg[8:]) != 2 {
+		return nil, errors.New("malformed NTLM challenge")
+	}
+	c := &ntlmChallenge{
+		flags:     binary.LittleEndian.Uint32(msg[20:]),
+		challenge: msg[24:32],
+	}
+	if len(msg) >= 48 {
+		n := int(binary.LittleEndian.Uint16(msg[40:]))
+		off := int(binary.LittleEndian.Uint32(msg[44:]))
+		if off+n > len(msg) {
+			return nil, errors.New("malformed NTLM challenge: target info out of bounds")
+		}
+		c.targetInfo = msg[off : off+n]
+	}
+	return c, nil
+}
+
+// ntlmCredentials are the account an NTLM handshake authenticates as.
+type ntlmCredentials struct {
+	domain, user, password string
+}
+
+// authenticate returns the AUTHENTICATE_MESSAGE that answers c with an
+// NTLMv2 response.
+func (cr *ntlmCredentials) authenticate(c *ntlmChallenge) ([]byte, error) {
+	clientChallenge := make([]byte, 8)
+	if _, err := rand.Read(clientChallenge); err != nil {
+		return nil, err
+	}
+	timestamp, serverTime := ntlmAvPair(c.targetInfo, ntlmAvTimestamp)
+	if !serverTime {
+		timestamp = ntlmFiletime(time.Now())
+	}
+	key := ntowfv2(cr.user, cr.password, cr.domain)
+	nt, lm := ntlmv2Response(key, c.challenge, clientChallenge, timestamp, c.targetInfo)
+	if serverTime {
+		// A server that sends its time expects no LMv2 response.
+		lm = make([]byte, 24)
+	}
+
+	flags := c.flags & ntlmNegotiateFlags
+	encode := func(s string) []byte {
+		if flags&ntlmUnicode != 0 {
+			return utf16le(s)
+		}
+		return []byte(s)
+	}
+	fields := [][]byte{lm, nt, encode(cr.domain), encode(cr.user), nil, nil}
+
+	msg := make([]byte, 64)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 3)
+	for i, f := range fields {
+		at := 12 + 8*i
+		binary.LittleEndian.PutUint16(msg[at:], uint16(len(f)))
+		binary.LittleEndian.PutUint16(msg[at+2:], uint16(len(f)))
+		binary.LittleEndian.PutUint32(msg[at+4:], uint32(len(msg)))
+		msg = append(msg, f...)
+	}
+	binary.LittleEndian.PutUint32(msg[60:], flags)
+	return msg, nil
+}
+
+// ntlmAvPair returns the value of the AV_PAIR id in the target info.
+func ntlmAvPair(info []byte, id uint16) ([]byte, bool) {
+	for len(info) >= 4 {
+		avID := binary.LittleEndian.Uint16(info)
+		n := int(binary.LittleEndian.Uint16(info[2:]))
+		if avID == ntlmAvEOL || 4+n > len(info) {
+			break
+		}
+		if avID == id {
+			return info[4 : 4+n], true
+		}
+		info = info[4+n:]
+	}
+	return nil, false
+}
+
+// ntlmFiletime returns t as a little-endian FILETIME, the 100ns intervals
+// since 1601.
+func ntlmFiletime(t time.Time) []byte {
+	const epochDelta = 116444736000000000 // 1601 to 1970 in 100ns
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, uint64(t.UnixNano()/100+epochDelta))
+	return b
+}
+
+// ntowfv2 is the NTLMv2 response key of an account (MS-NLMP 3.3.2).
+func ntowfv2(user, password, domain string) []byte {
+	h := hmac.New(md5.New, md4(utf16le(password)))
+	h.Write(utf16le(strings.ToUpper(user) + domain))
+	return h.Sum(nil)
+}
+
+// ntlmv2Response computes the NTLMv2 and LMv2 responses to a server
+// challenge (MS-NLMP 3.3.2).
+func ntlmv2Response(key, serverChallenge, clientChallenge, timestamp, targetInfo []byte) (nt, lm []byte) {
+	temp := []byte{1, 1, 0, 0, 0, 0, 0, 0}
+	temp = append(temp, timestamp...)
+	temp = append(temp, clientChallenge...)
+	temp = append(temp, 0, 0, 0, 0)
+	temp = append(temp, targetInfo...)
+	temp = append(temp, 0, 0, 0, 0)
+
+	h := hmac.New(md5.New, key)
+	h.Write(serverChallenge)
+	h.Write(temp)
+	nt = append(h.Sum(nil), temp...)
+
+	h.Reset()
+	h.Write(serverChallenge)
+	h.Write(clientChallenge)
+	lm = append(h.Sum(nil), clientChallenge...)
+	return nt, lm
+}
+
+// utf16le encodes s as UTF-16, little-endian, as NTLM strings are.
+func utf16le(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	b := make([]byte, 2*len(units))
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(b[2*i:], u)
+	}
+	return b
+}
+
+// md4 returns the MD4 digest of msg (RFC 1320), which NTLM hashes
+// passwords with and the standard library does not provide.
+func md4(msg []byte) []byte {
+	n := len(msg)
+	msg = append(msg[:n:n], 0x80)
+	for len(msg)%64 != 56 {
+		msg = append(msg, 0)
+	}
+	msg = binary.LittleEndian.AppendUint64(msg, uint64(n)*8)
+
+	a, b, c, d := uint32(0x67452301), uint32(0xefcdab89), uint32(0x98badcfe), uint32(0x10325476)
+	var x [16]uint32
+	for len(msg) > 0 {
+		for i := range x {
+			x[i] = binary.LittleEndian.Uint32(msg[4*i:])
+		}
+		aa, bb, cc, dd := a, b, c, d
+
+		round1 := [4]int{3, 7, 11, 19}
+		for i := 0; i < 16; i++ {
+			f := (b & c) | (^b & d)
+			a, b, c, d = d, bits.RotateLeft32(a+f+x[i], round1[i%4]), b, c
+		}
+		round2 := [4]int{3, 5, 9, 13}
+		for i := 0; i < 16; i++ {
+			g := (b & c) | (b & d) | (c & d)
+			k := (i%4)*4 + i/4
+			a, b, c, d = d, bits.RotateLeft32(a+g+x[k]+0x5a827999, round2[i%4]), b, c
+		}
+		round3 := [4]int{3, 9, 11, 15}
+		order := [16]int{0, 8, 4, 12, 2, 10, 6, 14, 1, 9, 5, 13, 3, 11, 7, 15}
+		for i := 0; i < 16; i++ {
+			h := b ^ c ^ d
+			a, b, c, d = d, bits.RotateLeft32(a+h+x[order[i]]+0x6ed9eba1, round3[i%4]), b, c
+		}
+
+		a, b, c, d = a+aa, b+bb, c+cc, d+dd
+		msg = msg[64:]
+	}
+	out := make([]byte, 16)
+	binary.LittleEndian.PutUint32(out[0:], a)
+	binary.LittleEndian.PutUint32(out[4:], b)
+	binary.LittleEndian.PutUint32(out[8:], c)
+	binary.LittleEndian.PutUint32(out[12:], d)
+	return out
+}
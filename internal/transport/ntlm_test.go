@@ -0,0 +1,54 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestMD4(t *testing.T) {
+	// RFC 1320, appendix A.5.
+	tests := map[string]string{
+		"":    "31d6cfe0d16ae931b73c59d7e0c089c0",
+		"a":   "bde52cb31de33e46245e05fbdbd6fb24",
+		"abc": "a448017aaf21d8525fc10ae87aa6729d",
+		"12345678901234567890123456789012345678901234567890123456789012345678901234567890": "e33b4ddc9c38f2199c3e7b164fcc0536",
+	}
+	for in, want := range tests {
+		if got := hex.EncodeToString(md4([]byte(in))); got != want {
+			t.Errorf("md4(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestNTLMv2Response(t *testing.T) {
+	// MS-NLMP 4.2.4: NTLMv2 authentication.
+	unhex := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	if got, want := md4(utf16le("Password")), unhex("a4f49c406510bdcab6824ee7c30fd852"); !bytes.Equal(got, want) {
+		t.Errorf("NT hash = %x, want %x", got, want)
+	}
+	key := ntowfv2("User", "Password", "Domain")
+	if want := unhex("0c868a403bfd7a93a3001ef22ef02e3f"); !bytes.Equal(key, want) {
+		t.Fatalf("NTOWFv2 = %x, want %x", key, want)
+	}
+
+	targetInfo := append(append(avPair(2, utf16le("Domain")), avPair(1, utf16le("Server"))...), 0, 0, 0, 0)
+	nt, lm := ntlmv2Response(key, unhex("0123456789abcdef"), unhex("aaaaaaaaaaaaaaaa"), make([]byte, 8), targetInfo)
+	if want := unhex("68cd0ab851e51c96aabc927bebef6a1c"); !bytes.Equal(nt[:16], want) {
+		t.Errorf("NTProofStr = %x, want %x", nt[:16], want)
+	}
+	if want := unhex("86c35097ac9cec102554764a57cccc19aaaaaaaaaaaaaaaa"); !bytes.Equal(lm, want) {
+		t.Errorf("LMv2 response = %x, want %x", lm, want)
+	}
+}
+
+// avPair encodes an NTLM AV_PAIR.
+func avPair(id uint16, value []byte) []byte {
+	return append([]byte{byte(id), byte(id >> 8), byte(len(value)), byte(len(value) >> 8)}, value...)
+}
@@ -0,0 +1,289 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Outage is a window during which the target was unavailable: connections
+// were refused or a reverse proxy answered 502/503. End is zero while the
+// window is still open.
+type Outage struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Overlaps reports whether the outage intersects the interval [start, end].
+// An open window overlaps every interval ending after it started.
+func (o Outage) Overlaps(start, end time.Time) bool {
+	if end.Before(o.Start) {
+		return false
+	}
+	return o.End.IsZero() || !o.End.Before(start)
+}
+
+// OutageOptions configures an OutageMonitor.
+type OutageOptions struct {
+	// ProbeInterval is the delay between health probes while the target is
+	// unavailable. A larger Retry-After from the target takes precedence.
+	ProbeInterval time.Duration
+
+	// MaxWait bounds how long WaitHealthy waits for the target to recover.
+	MaxWait time.Duration
+
+	// Burst is the number of consecutive unavailable outcomes that open a
+	// window. A single 503 is as likely a filter answering a payload as an
+	// outage; a healthy response in between starts the count over.
+	Burst int
+}
+
+// Default outage monitor settings.
+const (
+	defaultProbeInterval = time.Second
+	defaultMaxWait       = 2 * time.Minute
+	defaultBurst         = 3
+
+	// maxRetryAfter caps the delay a target can request via Retry-After.
+	maxRetryAfter = time.Minute
+)
+
+// ErrTargetUnavailable is returned by WaitHealthy when the target does not
+// recover within MaxWait.
+var ErrTargetUnavailable = errors.New("target unavailable")
+
+// OutageMonitor wraps a Client and records target-unavailability windows.
+// A window opens once Burst consecutive outcomes were connection-refused,
+// reset, 502 or 503, starting at the first of them, and closes on the next
+// healthy response, whichever request produced it. Fewer unavailable
+// outcomes leave an outage suspected until Confirm settles it.
+//
+// Responses counted in a window are flagged with AnomalyUnavailable. Since probes
+// just before or after a flagged response may also be affected, callers that
+// make decisions from several probes should check Overlaps for the time they
+// were probing and discard those decisions.
+type OutageMonitor struct {
+	inner Client
+	opts  OutageOptions
+
+	mu         sync.Mutex
+	windows    []Outage
+	retryAfter time.Duration // Latest Retry-After hint of the open window
+
+	// streak counts the consecutive unavailable outcomes, the first of
+	// which came at streakStart.
+	streak      int
+	streakStart time.Time
+
+	probeMu sync.Mutex // Serialises health probing across waiters
+}
+
+// NewOutageMonitor wraps inner. Zero options take defaults.
+func NewOutageMonitor(inner Client, opts OutageOptions) *OutageMonitor {
+	if opts.ProbeInterval <= 0 {
+		opts.ProbeInterval = defaultProbeInterval
+	}
+	if opts.MaxWait <= 0 {
+		opts.MaxWait = defaultMaxWait
+	}
+	if opts.Burst <= 0 {
+		opts.Burst = defaultBurst
+	}
+	return &OutageMonitor{inner: inner, opts: opts}
+}
+
+// Do sends the request through the wrapped client and records the outcome.
+func (m *OutageMonitor) Do(ctx context.Context, req *Request) (*Response, error) {
+	resp, err := m.inner.Do(ctx, req)
+	if err != nil && ctx.Err() != nil {
+		// Our own cancellation says nothing about the target.
+		return resp, err
+	}
+	if m.observe(resp, err) {
+		resp.Anomalies = append(resp.Anomalies, AnomalyUnavailable)
+	}
+	return resp, err
+}
+
+// observe updates the window state from a single request outcome and
+// reports whether resp is an unavailability response within a window.
+func (m *OutageMonitor) observe(resp *Response, err error) bool {
+	unavailable := IsUnavailable(resp, err)
+
+	m.mu.Lock()
+	wasOpen := m.openLocked()
+	switch {
+	case unavailable:
+		if m.streak == 0 {
+			m.streakStart = time.Now()
+		}
+		m.streak++
+		if !wasOpen && m.streak >= m.opts.Burst {
+			m.windows = append(m.windows, Outage{Start: m.streakStart})
+			m.retryAfter = 0
+		}
+	case err == nil:
+		m.streak = 0
+		if wasOpen {
+			m.windows[len(m.windows)-1].End = time.Now()
+		}
+	}
+	open := m.openLocked()
+	if open && unavailable && resp != nil {
+		if d := parseRetryAfter(resp.Headers.Get("Retry-After"), time.Now()); d > 0 {
+			m.retryAfter = d
+		}
+	}
+	m.mu.Unlock()
+
+	// A restarting server is gone: pooled keep-alive connections to it are
+	// dead, and a Connection: close answer means the proxy is dropping ours.
+	if open && unavailable && (!wasOpen || (resp != nil && strings.EqualFold(resp.Headers.Get("Connection"), "close"))) {
+		if cic, ok := m.inner.(interface{ CloseIdleConnections() }); ok {
+			cic.CloseIdleConnections()
+		}
+	}
+	return open && unavailable && resp != nil
+}
+
+func (m *OutageMonitor) openLocked() bool {
+	return len(m.windows) > 0 && m.windows[len(m.windows)-1].End.IsZero()
+}
+
+// Unavailable reports whether an outage window is currently open.
+func (m *OutageMonitor) Unavailable() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.openLocked()
+}
+
+// Windows returns a copy of all recorded windows in chronological order.
+func (m *OutageMonitor) Windows() []Outage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Outage(nil), m.windows...)
+}
+
+// Overlaps reports whether any recorded window intersects [start, end].
+func (m *OutageMonitor) Overlaps(start, end time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, w := range m.windows {
+		if w.Overlaps(start, end) {
+			return true
+		}
+	}
+	return false
+}
+
+// Confirm settles a suspected outage: while unavailable outcomes have been
+// seen but too few to open a window, it sends probe as a health check. A
+// healthy answer means the target is up and the failures were answers to
+// particular requests; further unavailable ones open the window. It
+// reports whether a window is open on return.
+func (m *OutageMonitor) Confirm(ctx context.Context, probe *Request) bool {
+	if probe == nil {
+		return m.Unavailable()
+	}
+	m.probeMu.Lock()
+	defer m.probeMu.Unlock()
+
+	for ctx.Err() == nil {
+		m.mu.Lock()
+		suspected := m.streak > 0 && !m.openLocked()
+		m.mu.Unlock()
+		if !suspected {
+			break
+		}
+		if _, err := m.Do(ctx, probe.Clone()); err != nil && !IsUnavailable(nil, err) {
+			// Neither healthy nor unavailable: the suspicion stands.
+			break
+		}
+	}
+	return m.Unavailable()
+}
+
+// WaitHealthy blocks until the open window, if any, closes. While waiting it
+// sends probe as a health check every ProbeInterval, or after the target's
+// Retry-After delay when that is longer. Concurrent callers share a single
+// prober. It returns ErrTargetUnavailable if the target has not recovered
+// within MaxWait.
+func (m *OutageMonitor) WaitHealthy(ctx context.Context, probe *Request) error {
+	m.probeMu.Lock()
+	defer m.probeMu.Unlock()
+
+	deadline := time.Now().Add(m.opts.MaxWait)
+	for m.Unavailable() {
+		m.mu.Lock()
+		wait := m.opts.ProbeInterval
+		if m.retryAfter > wait {
+			wait = m.retryAfter
+		}
+		m.mu.Unlock()
+
+		if time.Now().Add(wait).After(deadline) {
+			return fmt.Errorf("%w: no recovery within %s", ErrTargetUnavailable, m.opts.MaxWait)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		_, _ = m.Do(ctx, probe.Clone())
+	}
+	return nil
+}
+
+// SetProxy forwards to the wrapped client.
+func (m *OutageMonitor) SetProxy(proxyURL string) error { return m.inner.SetProxy(proxyURL) }
+
+// SetRateLimit forwards to the wrapped client.
+func (m *OutageMonitor) SetRateLimit(rps float64) { m.inner.SetRateLimit(rps) }
+
+// Stats forwards to the wrapped client.
+func (m *OutageMonitor) Stats() *TransportStats { return m.inner.Stats() }
+
+// IsUnavailable classifies a request outcome as target unavailability:
+// connection refused or reset, or a 502/503 from a reverse proxy.
+func IsUnavailable(resp *Response, err error) bool {
+	if err != nil {
+		return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET)
+	}
+	if resp == nil {
+		return false
+	}
+	return resp.StatusCode == http.StatusBadGateway || resp.StatusCode == http.StatusServiceUnavailable
+}
+
+// parseRetryAfter parses a Retry-After value given in seconds or as an
+// HTTP date. It returns 0 for missing or invalid values and caps the
+// result at maxRetryAfter.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	var d time.Duration
+	if secs, err := strconv.Atoi(v); err == nil {
+		d = time.Duration(secs) * time.Second
+	} else if t, err := http.ParseTime(v); err == nil {
+		d = t.Sub(now)
+	}
+	if d < 0 {
+		return 0
+	}
+	if d > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return d
+}
@@ -0,0 +1,319 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFlappingServer returns a server that answers status while down is set
+// and 200 otherwise.
+func newFlappingServer(down *atomic.Bool, status int, retryAfter string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+}
+
+func newOutageClient(t *testing.T, opts OutageOptions) *OutageMonitor {
+	t.Helper()
+	c, err := NewClient(ClientOptions{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return NewOutageMonitor(c, opts)
+}
+
+func TestIsUnavailable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	_, refused := http.Get("http://" + addr + "/")
+
+	tests := []struct {
+		name string
+		resp *Response
+		err  error
+		want bool
+	}{
+		{"connection refused", nil, refused, true},
+		{"other error", nil, errors.New("tls: handshake failure"), false},
+		{"502", &Response{StatusCode: http.StatusBadGateway}, nil, true},
+		{"503", &Response{StatusCode: http.StatusServiceUnavailable}, nil, true},
+		{"500", &Response{StatusCode: http.StatusInternalServerError}, nil, false},
+		{"200", &Response{StatusCode: http.StatusOK}, nil, false},
+	}
+	for _, tt := range tests {
+		if got := IsUnavailable(tt.resp, tt.err); got != tt.want {
+			t.Errorf("%s: IsUnavailable = %v, want %v (err=%v)", tt.name, got, tt.want, tt.err)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		v    string
+		want time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"garbage", 0},
+		{"-5", 0},
+		{"3600", maxRetryAfter},
+		{now.Add(10 * time.Second).Format(http.TimeFormat), 10 * time.Second},
+		{now.Add(-10 * time.Second).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.v, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestOutage_Overlaps(t *testing.T) {
+	base := time.Now()
+	at := func(s int) time.Time { return base.Add(time.Duration(s) * time.Second) }
+	closed := Outage{Start: at(10), End: at(20)}
+	open := Outage{Start: at(10)}
+
+	tests := []struct {
+		o          Outage
+		start, end int
+		want       bool
+	}{
+		{closed, 0, 5, false},
+		{closed, 0, 10, true},
+		{closed, 15, 16, true},
+		{closed, 20, 30, true},
+		{closed, 21, 30, false},
+		{open, 0, 5, false},
+		{open, 50, 60, true},
+	}
+	for i, tt := range tests {
+		if got := tt.o.Overlaps(at(tt.start), at(tt.end)); got != tt.want {
+			t.Errorf("case %d: Overlaps(%d, %d) = %v, want %v", i, tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+func TestOutageMonitor_Window(t *testing.T) {
+	var down atomic.Bool
+	srv := newFlappingServer(&down, http.StatusBadGateway, "")
+	defer srv.Close()
+
+	m := newOutageClient(t, OutageOptions{})
+	ctx := context.Background()
+	req := &Request{URL: srv.URL}
+
+	if _, err := m.Do(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if m.Unavailable() || len(m.Windows()) != 0 {
+		t.Fatal("no window expected while the target is up")
+	}
+
+	down.Store(true)
+	var resp *Response
+	for i := 0; i < defaultBurst; i++ {
+		if m.Unavailable() {
+			t.Fatalf("window open after %d 502s, want %d", i, defaultBurst)
+		}
+		var err error
+		if resp, err = m.Do(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !resp.Anomalous() || resp.Anomalies[0] != AnomalyUnavailable {
+		t.Errorf("502 during outage should be flagged, got %v", resp.Anomalies)
+	}
+	if !m.Unavailable() {
+		t.Errorf("window should be open after %d 502s", defaultBurst)
+	}
+	_, _ = m.Do(ctx, req) // a longer burst extends the same window
+
+	down.Store(false)
+	if _, err := m.Do(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	windows := m.Windows()
+	if len(windows) != 1 || windows[0].End.IsZero() {
+		t.Fatalf("Windows() = %+v, want one closed window", windows)
+	}
+	if !m.Overlaps(windows[0].Start.Add(-time.Second), windows[0].Start) {
+		t.Error("interval ending at window start should overlap")
+	}
+	if m.Overlaps(windows[0].End.Add(time.Millisecond), windows[0].End.Add(time.Second)) {
+		t.Error("interval after the window should not overlap")
+	}
+}
+
+// TestOutageMonitor_PayloadBlocks checks that a filter answering payloads
+// with 503 does not open a window: the benign requests in between, or the
+// health probe of Confirm, are answered.
+func TestOutageMonitor_PayloadBlocks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.RawQuery, "UNION") {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer srv.Close()
+
+	m := newOutageClient(t, OutageOptions{})
+	ctx := context.Background()
+	blocked := &Request{URL: srv.URL + "/?id=1+UNION+SELECT+1"}
+	benign := &Request{URL: srv.URL + "/?id=1"}
+
+	for i := 0; i < 5; i++ {
+		resp, err := m.Do(ctx, blocked)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Anomalous() {
+			t.Errorf("blocked payload flagged %v, want the 503 passed through", resp.Anomalies)
+		}
+		if _, err := m.Do(ctx, benign); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(m.Windows()) != 0 {
+		t.Fatalf("Windows() = %+v, want none for blocked payloads", m.Windows())
+	}
+
+	// Back-to-back blocks only make an outage suspected, which the health
+	// probe dismisses.
+	for i := 0; i < defaultBurst-1; i++ {
+		_, _ = m.Do(ctx, blocked)
+	}
+	if m.Confirm(ctx, benign) {
+		t.Error("Confirm = true, want the suspicion dismissed by a healthy probe")
+	}
+	if len(m.Windows()) != 0 {
+		t.Errorf("Windows() = %+v, want none", m.Windows())
+	}
+}
+
+func TestOutageMonitor_ConfirmOpensWindow(t *testing.T) {
+	var down atomic.Bool
+	srv := newFlappingServer(&down, http.StatusServiceUnavailable, "")
+	defer srv.Close()
+
+	m := newOutageClient(t, OutageOptions{})
+	ctx := context.Background()
+	req := &Request{URL: srv.URL}
+
+	down.Store(true)
+	before := time.Now()
+	_, _ = m.Do(ctx, req)
+	after := time.Now()
+	if m.Unavailable() {
+		t.Fatal("a single 503 should not open a window")
+	}
+	if !m.Confirm(ctx, req) {
+		t.Fatal("Confirm = false, want the health probe to confirm the outage")
+	}
+	windows := m.Windows()
+	if len(windows) != 1 || windows[0].Start.Before(before) || windows[0].Start.After(after) {
+		t.Errorf("Windows() = %+v, want one starting at the first 503", windows)
+	}
+}
+
+func TestOutageMonitor_ConnectionRefusedRestart(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	addr := srv.Listener.Addr().String()
+	srv.Close()
+
+	m := newOutageClient(t, OutageOptions{ProbeInterval: 20 * time.Millisecond, MaxWait: 5 * time.Second, Burst: 1})
+	ctx := context.Background()
+	probe := &Request{URL: "http://" + addr + "/"}
+
+	if _, err := m.Do(ctx, probe); err == nil {
+		t.Fatal("expected connection refused")
+	}
+	if !m.Unavailable() {
+		t.Fatal("connection refused should open a window")
+	}
+
+	// The app server comes back on the same address.
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return
+		}
+		restarted := &httptest.Server{
+			Listener: ln,
+			Config: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, "ok")
+			})},
+		}
+		restarted.Start()
+		t.Cleanup(restarted.Close)
+	}()
+
+	if err := m.WaitHealthy(ctx, probe); err != nil {
+		t.Fatalf("WaitHealthy: %v", err)
+	}
+	if m.Unavailable() {
+		t.Error("health probe should have closed the window")
+	}
+}
+
+func TestOutageMonitor_WaitHealthyHonorsRetryAfter(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	srv := newFlappingServer(&down, http.StatusServiceUnavailable, "1")
+	defer srv.Close()
+	time.AfterFunc(50*time.Millisecond, func() { down.Store(false) })
+
+	m := newOutageClient(t, OutageOptions{ProbeInterval: 10 * time.Millisecond, MaxWait: 5 * time.Second, Burst: 1})
+	ctx := context.Background()
+	req := &Request{URL: srv.URL}
+	if _, err := m.Do(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if err := m.WaitHealthy(ctx, req); err != nil {
+		t.Fatalf("WaitHealthy: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("WaitHealthy returned after %v, want >= Retry-After (1s)", elapsed)
+	}
+}
+
+func TestOutageMonitor_WaitHealthyGivesUp(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	srv := newFlappingServer(&down, http.StatusBadGateway, "")
+	defer srv.Close()
+
+	m := newOutageClient(t, OutageOptions{ProbeInterval: 10 * time.Millisecond, MaxWait: 100 * time.Millisecond, Burst: 1})
+	ctx := context.Background()
+	req := &Request{URL: srv.URL}
+	_, _ = m.Do(ctx, req)
+
+	if err := m.WaitHealthy(ctx, req); !errors.Is(err, ErrTargetUnavailable) {
+		t.Errorf("WaitHealthy error = %v, want ErrTargetUnavailable", err)
+	}
+}
@@ -0,0 +1,109 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// dialer opens the connections of every client, to targets and proxies.
+var dialer = &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+
+// configureProxy routes the connections of t through the proxy at rawURL.
+// Supported are HTTP and HTTPS proxies and SOCKS5 proxies, such as the
+// tunnels of ssh -D: socks5:// resolves target host names locally,
+// socks5h:// on the proxy, for names only the far side knows. Credentials
+// in the URL are sent to the proxy.
+func configureProxy(t *http.Transport, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid proxy URL: missing scheme or host")
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		t.Proxy = http.ProxyURL(u)
+		t.DialContext = dialer.DialContext
+	case "socks5", "socks5h":
+		d, err := proxy.FromURL(u, dialer)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		dial := socksDial(d.(proxy.ContextDialer), u.Host)
+		if u.Scheme == "socks5" {
+			dial = resolveLocally(dial)
+		}
+		t.Proxy = nil
+		t.DialContext = dial
+	default:
+		return fmt.Errorf("invalid proxy URL: unsupported scheme %q (use http, https, socks5 or socks5h)", u.Scheme)
+	}
+	return nil
+}
+
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// socksDial dials through d, naming the proxy in its errors: a refused
+// connection would otherwise read as one to the target.
+func socksDial(d proxy.ContextDialer, proxyHost string) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := d.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, fmt.Errorf("SOCKS5 proxy %s: %w", proxyHost, err)
+		}
+		return conn, nil
+	}
+}
+
+// resolveLocally makes dial connect to the address of the host it is
+// given, looked up here, so the proxy only ever sees IP addresses.
+func resolveLocally(dial dialFunc) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if net.ParseIP(host) == nil {
+			ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+			if err != nil {
+				return nil, err
+			}
+			host = ips[0].IP.String()
+		}
+		return dial(ctx, network, net.JoinHostPort(host, port))
+	}
+}
+
+// pinHosts makes t connect to the address resolve pins a host to, in
+// place of the host its dialer is asked for. The proxy a host of resolve
+// names is pinned too.
+func pinHosts(t *http.Transport, resolve map[string]string) {
+	if len(resolve) == 0 {
+		return
+	}
+	pinned := make(map[string]string, len(resolve))
+	for host, ip := range resolve {
+		pinned[strings.ToLower(host)] = ip
+	}
+	dial := t.DialContext
+	if dial == nil {
+		dial = dialer.DialContext
+	}
+	t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if host, port, err := net.SplitHostPort(addr); err == nil {
+			if ip, ok := pinned[strings.ToLower(host)]; ok {
+				addr = net.JoinHostPort(ip, port)
+			}
+		}
+		return dial(ctx, network, addr)
+	}
+}
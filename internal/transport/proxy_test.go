@@ -0,0 +1,403 @@
+package transport
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// socksServer is a minimal SOCKS5 proxy (RFC 1928 CONNECT, with RFC 1929
+// username/password authentication when user is set) that tunnels every
+// connection to backend, whatever address the client asked for, and
+// records those addresses.
+type socksServer struct {
+	ln         net.Listener
+	backend    string
+	user, pass string
+
+	mu      sync.Mutex
+	targets []string
+	open    atomic.Int32 // Tunnels currently open
+}
+
+func newSocksServer(t *testing.T, backend, user, pass string) *socksServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &socksServer{ln: ln, backend: backend, user: user, pass: pass}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *socksServer) url(scheme string) string {
+	if s.user != "" {
+		return fmt.Sprintf("%s://%s:%s@%s", scheme, s.user, s.pass, s.ln.Addr())
+	}
+	return scheme + "://" + s.ln.Addr().String()
+}
+
+func (s *socksServer) requested() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.targets...)
+}
+
+func (s *socksServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	// Greeting: version, methods.
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(conn, head); err != nil {
+		return
+	}
+	methods := make([]byte, head[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return
+	}
+	if s.user == "" {
+		conn.Write([]byte{5, 0})
+	} else {
+		conn.Write([]byte{5, 2})
+		// Username/password: version, ulen, user, plen, pass.
+		b := make([]byte, 2)
+		if _, err := io.ReadFull(conn, b); err != nil {
+			return
+		}
+		user := make([]byte, b[1])
+		io.ReadFull(conn, user)
+		io.ReadFull(conn, b[:1])
+		pass := make([]byte, b[0])
+		io.ReadFull(conn, pass)
+		if string(user) != s.user || string(pass) != s.pass {
+			conn.Write([]byte{1, 1})
+			return
+		}
+		conn.Write([]byte{1, 0})
+	}
+
+	// Request: version, CONNECT, reserved, address type, address, port.
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(conn, req); err != nil {
+		return
+	}
+	var host string
+	switch req[3] {
+	case 1:
+		ip := make([]byte, 4)
+		io.ReadFull(conn, ip)
+		host = net.IP(ip).String()
+	case 4:
+		ip := make([]byte, 16)
+		io.ReadFull(conn, ip)
+		host = net.IP(ip).String()
+	case 3:
+		n := make([]byte, 1)
+		io.ReadFull(conn, n)
+		name := make([]byte, n[0])
+		io.ReadFull(conn, name)
+		host = string(name)
+	default:
+		return
+	}
+	port := make([]byte, 2)
+	io.ReadFull(conn, port)
+	s.mu.Lock()
+	s.targets = append(s.targets, net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+	s.mu.Unlock()
+
+	up, err := net.Dial("tcp", s.backend)
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer up.Close()
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+
+	s.open.Add(1)
+	defer s.open.Add(-1)
+	done := make(chan struct{}, 2)
+	go func() { io.Copy(up, conn); done <- struct{}{} }()
+	go func() { io.Copy(conn, up); done <- struct{}{} }()
+	<-done
+}
+
+func newProxyBackend(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "host=%s", r.Host)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient_SOCKS5(t *testing.T) {
+	backend := newProxyBackend(t)
+	_, port, _ := net.SplitHostPort(backend.Listener.Addr().String())
+
+	tests := []struct {
+		scheme     string
+		user, pass string
+		want       string // Address the proxy is asked for, "" for an IP
+	}{
+		{"socks5h", "", "", "internal.corp:" + port},
+		{"socks5", "", "", ""},
+		{"socks5h", "pivot", "s3cret", "internal.corp:" + port},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scheme+"/"+tt.user, func(t *testing.T) {
+			proxy := newSocksServer(t, backend.Listener.Addr().String(), tt.user, tt.pass)
+			c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, ProxyURL: proxy.url(tt.scheme)})
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+			// internal.corp only resolves on the far side of the proxy; for
+			// socks5 the test asks for 127.0.0.1 by a name that resolves here.
+			host := "internal.corp"
+			if tt.scheme == "socks5" {
+				host = "localhost"
+			}
+			resp, err := c.Do(context.Background(), &Request{URL: "http://" + host + ":" + port + "/"})
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if got := string(resp.Body); got != "host="+host+":"+port {
+				t.Errorf("body = %q", got)
+			}
+			got := proxy.requested()
+			if len(got) != 1 {
+				t.Fatalf("proxy asked for %v, want one tunnel", got)
+			}
+			if tt.want == "" {
+				// localhost may resolve to 127.0.0.1 or ::1.
+				if h, _, _ := net.SplitHostPort(got[0]); net.ParseIP(h) == nil {
+					t.Errorf("proxy asked for %s, want an IP address", got[0])
+				}
+			} else if got[0] != tt.want {
+				t.Errorf("proxy asked for %s, want %s", got[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_SOCKS5Errors(t *testing.T) {
+	backend := newProxyBackend(t)
+
+	proxy := newSocksServer(t, backend.Listener.Addr().String(), "pivot", "s3cret")
+	c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, ProxyURL: "socks5h://pivot:wrong@" + proxy.ln.Addr().String()})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if _, err := c.Do(context.Background(), &Request{URL: backend.URL}); err == nil {
+		t.Error("Do with wrong proxy credentials succeeded")
+	}
+
+	// A port nothing listens on.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dead := ln.Addr().String()
+	ln.Close()
+	c, err = NewClient(ClientOptions{Timeout: 5 * time.Second, ProxyURL: "socks5://" + dead})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	_, err = c.Do(context.Background(), &Request{URL: backend.URL})
+	if err == nil || !strings.Contains(err.Error(), "SOCKS5 proxy "+dead) {
+		t.Errorf("Do through an unreachable proxy = %v, want an error naming the proxy", err)
+	}
+	if !IsUnavailable(nil, err) {
+		t.Errorf("IsUnavailable(%v) = false, want the refused connection recognised", err)
+	}
+
+	for _, bad := range []string{"ftp://127.0.0.1:21", "socks5://", "://x"} {
+		if _, err := NewClient(ClientOptions{ProxyURL: bad}); err == nil {
+			t.Errorf("NewClient with proxy %q succeeded", bad)
+		}
+		if err := c.SetProxy(bad); err == nil {
+			t.Errorf("SetProxy(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestClient_SetProxySwitchesPool(t *testing.T) {
+	backend := newProxyBackend(t)
+	first := newSocksServer(t, backend.Listener.Addr().String(), "", "")
+	second := newSocksServer(t, backend.Listener.Addr().String(), "", "")
+
+	c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, ProxyURL: first.url("socks5h")})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	ctx := context.Background()
+	for range 3 {
+		if _, err := c.Do(ctx, &Request{URL: backend.URL}); err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+	}
+	if n := len(first.requested()); n != 1 {
+		t.Fatalf("first proxy got %d tunnels, want 1 kept alive", n)
+	}
+
+	if err := c.SetProxy(second.url("socks5h")); err != nil {
+		t.Fatalf("SetProxy: %v", err)
+	}
+	if _, err := c.Do(ctx, &Request{URL: backend.URL}); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if n := len(second.requested()); n != 1 {
+		t.Errorf("second proxy got %d tunnels, want 1", n)
+	}
+	if n := len(first.requested()); n != 1 {
+		t.Errorf("first proxy got %d tunnels after the switch, want none new", n)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for first.open.Load() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the old pool's tunnel is still open after the switch")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// newHostEcho starts a TLS server answering with the Host header and TLS
+// server name of each request, and returns it with a CA file trusting it.
+// Its certificate is for example.com and 127.0.0.1.
+func newHostEcho(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "host=%s sni=%s", r.Host, r.TLS.ServerName)
+	}))
+	// Rejected handshakes are expected; keep them out of the test log.
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	writePEM(t, ca, "CERTIFICATE", srv.Certificate().Raw)
+	return srv, ca
+}
+
+func TestClient_Resolve(t *testing.T) {
+	srv, ca := newHostEcho(t)
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	// Neither name resolves: the requests reach the server only through
+	// the pinned address.
+	c, err := NewClient(ClientOptions{
+		Timeout:    5 * time.Second,
+		CACertFile: ca,
+		Resolve:    map[string]string{"Example.com": "127.0.0.1", "app.invalid": "127.0.0.1"},
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	for _, host := range []string{"example.com", "EXAMPLE.com"} {
+		resp, err := c.Do(context.Background(), &Request{URL: "https://" + host + ":" + port + "/item?id=1"})
+		if err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+		if want := "host=" + host + ":" + port + " sni=" + host; string(resp.Body) != want {
+			t.Errorf("body = %q, want %q", resp.Body, want)
+		}
+		if resp.URL != "https://"+host+":"+port+"/item?id=1" {
+			t.Errorf("URL = %s, want the host name kept", resp.URL)
+		}
+	}
+
+	// A certificate for another name is still refused.
+	if _, err := c.Do(context.Background(), &Request{URL: "https://app.invalid:" + port + "/"}); err == nil {
+		t.Error("Do to a pinned name the certificate does not cover succeeded")
+	}
+}
+
+func TestClient_ResolveThroughSOCKS(t *testing.T) {
+	backend := newProxyBackend(t)
+	_, port, _ := net.SplitHostPort(backend.Listener.Addr().String())
+	proxy := newSocksServer(t, backend.Listener.Addr().String(), "", "")
+
+	c, err := NewClient(ClientOptions{
+		Timeout:  5 * time.Second,
+		ProxyURL: proxy.url("socks5h"),
+		Resolve:  map[string]string{"app.invalid": "10.1.2.3"},
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	resp, err := c.Do(context.Background(), &Request{URL: "http://app.invalid:" + port + "/"})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if string(resp.Body) != "host=app.invalid:"+port {
+		t.Errorf("body = %q", resp.Body)
+	}
+	if got := proxy.requested(); len(got) != 1 || got[0] != "10.1.2.3:"+port {
+		t.Errorf("proxy asked for %v, want the pinned address", got)
+	}
+
+	// The pin survives a change of proxy.
+	other := newSocksServer(t, backend.Listener.Addr().String(), "", "")
+	if err := c.SetProxy(other.url("socks5h")); err != nil {
+		t.Fatalf("SetProxy: %v", err)
+	}
+	if _, err := c.Do(context.Background(), &Request{URL: "http://app.invalid:" + port + "/"}); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := other.requested(); len(got) != 1 || got[0] != "10.1.2.3:"+port {
+		t.Errorf("new proxy asked for %v, want the pinned address", got)
+	}
+}
+
+func TestClient_HostOverride(t *testing.T) {
+	srv, ca := newHostEcho(t)
+
+	c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, CACertFile: ca, HostOverride: "example.com"})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	resp, err := c.Do(context.Background(), &Request{URL: srv.URL + "/item?id=1"})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := string(resp.Body); got != "host=example.com sni=example.com" {
+		t.Errorf("body = %q, want the override as Host and server name", got)
+	}
+	if resp.URL != srv.URL+"/item?id=1" {
+		t.Errorf("URL = %s, want the URL requested", resp.URL)
+	}
+
+	// The server name drops a port given with the host.
+	c, err = NewClient(ClientOptions{Timeout: 5 * time.Second, CACertFile: ca, HostOverride: "example.com:8443"})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	resp, err = c.Do(context.Background(), &Request{URL: srv.URL})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := string(resp.Body); got != "host=example.com:8443 sni=example.com" {
+		t.Errorf("body = %q", got)
+	}
+}
@@ -1,6 +1,7 @@
 package transport
 
 import (
+	"crypto/tls"
 	"net/http"
 	"time"
 )
@@ -13,20 +14,112 @@ type Response struct {
 	// Headers contains the response headers.
 	Headers http.Header
 
-	// Body is the raw response body.
+	// Body is the response body, decoded from its ContentEncoding and
+	// converted to UTF-8 from its Charset.
 	Body []byte
 
-	// ContentLength is the content length from the response header.
+	// ContentLength is the content length from the response header, or -1
+	// when unknown or the body was decompressed.
 	ContentLength int64
 
-	// Duration is the precise round-trip time for the request.
+	// ContentEncoding is the Content-Encoding the body arrived in, such as
+	// "gzip", before it was decompressed; the Content-Encoding and
+	// Content-Length headers are removed from Headers then. Empty when the
+	// body was not compressed, or not in a coding the client decodes
+	// (gzip, deflate and br), in which case Body is as received.
+	ContentEncoding string
+
+	// Charset is the character set the body was converted to UTF-8 from,
+	// as declared by the Content-Type header or, for HTML, a meta tag.
+	// Empty when the body was left as received: UTF-8, undeclared or
+	// binary.
+	Charset string
+
+	// Duration is the total time for the request, from sending it to
+	// reading the last byte of the body.
 	Duration time.Duration
 
+	// TTFB is the time from sending the request to the first byte of the
+	// response. A server that flushes headers early and trickles the body
+	// answers with a short TTFB and a long Duration; one that works before
+	// answering, like a database sleep, delays both. Zero when unknown.
+	TTFB time.Duration
+
+	// Streamed reports that the body arrived without a declared length,
+	// chunked or read until the connection closed, so its read time
+	// depends on how the server paced it.
+	Streamed bool
+
+	// Close reports that the server closes the connection after this
+	// response, as HTTP/1.0 servers without keep-alive do.
+	Close bool
+
 	// URL is the final URL after any redirects.
 	URL string
 
 	// Protocol is the protocol version (e.g., "HTTP/1.1", "HTTP/2.0").
 	Protocol string
+
+	// Redirects are the URLs that redirected to URL, in the order they
+	// were requested. Empty when the request was not redirected.
+	Redirects []string
+
+	// TLS is the state of the connection the response arrived on, or nil
+	// for plain HTTP.
+	TLS *tls.ConnectionState
+
+	// Truncated reports that Body is the first ClientOptions.MaxResponseBytes
+	// of a longer body, the rest discarded. Unlike AnomalyTruncated, the
+	// page arrived whole; comparisons should stick to the part read (see
+	// detector.DiffEngine.ResponseRatio).
+	Truncated bool
+
+	// RequestID numbers the request among those the client sent, from 1,
+	// as in its traffic log (see ClientOptions.TrafficLogPath); 0 when
+	// the response did not come from a DefaultClient.
+	RequestID uint64
+
+	// Nonces holds the values of the nonce headers generated for this
+	// request (see ClientOptions.NonceHeaders), keyed by header name, so
+	// the exact request that was sent can be reproduced.
+	Nonces map[string]string
+
+	// Anomalies lists conditions that make the response unsuitable for
+	// comparison against other responses. Empty for normal responses.
+	Anomalies []Anomaly
+}
+
+// Anomaly describes why a response should not be compared.
+type Anomaly string
+
+const (
+	// AnomalyNotModified marks a 304 Not Modified response that persisted
+	// after a cache-busting retry. Its body is empty, so any similarity
+	// score against a full page is meaningless.
+	AnomalyNotModified Anomaly = "not-modified"
+
+	// AnomalyUnavailable marks a 502/503 answered while the target was
+	// down, e.g. by a reverse proxy in front of a restarting app server.
+	AnomalyUnavailable Anomaly = "unavailable"
+
+	// AnomalyTruncated marks a response whose body stopped partway, e.g.
+	// when the request timed out while a streamed body was still arriving.
+	// Body holds what was read before the failure.
+	AnomalyTruncated Anomaly = "truncated"
+)
+
+// Anomalous reports whether the response has any anomalies.
+func (r *Response) Anomalous() bool {
+	return len(r.Anomalies) > 0
+}
+
+// FirstByte returns TTFB, or Duration for a response that did not record
+// it.
+func (r *Response) FirstByte() time.Duration {
+	if r.TTFB > 0 {
+		return r.TTFB
+	}
+	return r.Duration
 }
 
 // BodyString returns the response body as a string.
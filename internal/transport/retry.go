@@ -0,0 +1,110 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// DefaultRetryStatus are the status codes retried when
+// ClientOptions.RetryStatus is not set: rate limiting and the answers of
+// a reverse proxy whose backend failed.
+var DefaultRetryStatus = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// Retry defaults.
+const (
+	defaultRetryBackoff = 500 * time.Millisecond
+
+	// maxRetryBackoff caps the exponential backoff between attempts.
+	maxRetryBackoff = 30 * time.Second
+)
+
+// idempotentMethods are the methods whose requests may be sent again.
+var idempotentMethods = []string{
+	"", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+	http.MethodPut, http.MethodDelete,
+}
+
+// attempt sends req, and again up to Retries times while it fails with a
+// retryable error or status. The response is the last attempt's, its
+// Duration that attempt's own. Attempts are spaced by exponential backoff
+// with full jitter, or the Retry-After the server asked for. A request
+// that runs out a Timeout of its own is not retried: the caller chose how
+// long it may take, and a time-based probe may run it out on purpose.
+func (c *DefaultClient) attempt(ctx context.Context, req *Request, bustCache bool) (*Response, error) {
+	retries := c.opts.Retries
+	if !slices.Contains(idempotentMethods, req.Method) {
+		retries = 0
+	}
+	for n := 0; ; n++ {
+		resp, err := c.send(ctx, req, bustCache)
+		if n == retries || ctx.Err() != nil || !c.retryable(resp, err) {
+			return resp, err
+		}
+		if req.Timeout > 0 && ClassifyFailure(err) == FailureTimeout {
+			return resp, err
+		}
+
+		wait := c.backoff(n)
+		if resp != nil {
+			if d := parseRetryAfter(resp.Headers.Get("Retry-After"), time.Now()); d > 0 {
+				wait = d
+			}
+		}
+		c.mu.Lock()
+		c.retries++
+		c.mu.Unlock()
+		if err := sleep(ctx, wait); err != nil {
+			return resp, err
+		}
+	}
+}
+
+// retryable reports whether a request that got resp and err is worth
+// sending again: its connection was refused, reset, closed early or timed
+// out, or it was answered with one of the retried status codes.
+func (c *DefaultClient) retryable(resp *Response, err error) bool {
+	if err != nil {
+		return errors.Is(err, syscall.ECONNREFUSED) || ClassifyFailure(err) != FailureOther
+	}
+	status := c.opts.RetryStatus
+	if status == nil {
+		status = DefaultRetryStatus
+	}
+	return slices.Contains(status, resp.StatusCode)
+}
+
+// backoff returns the wait before retry n+1: a random duration up to
+// RetryBackoff doubled n times, capped at maxRetryBackoff.
+func (c *DefaultClient) backoff(n int) time.Duration {
+	base := c.opts.RetryBackoff
+	if base <= 0 {
+		base = defaultRetryBackoff
+	}
+	ceiling := maxRetryBackoff
+	if n < 16 {
+		ceiling = min(base<<n, maxRetryBackoff)
+	}
+	return rand.N(ceiling + 1)
+}
+
+// sleep waits for d, or returns the context's error when it is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,178 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFlakyServer returns a server that answers the first fail requests with
+// fail(w, r) and the rest with 200, and a count of the requests it got.
+func newFlakyServer(t *testing.T, fail int32, answer func(w http.ResponseWriter, r *http.Request)) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var n atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n.Add(1) <= fail {
+			answer(w, r)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &n
+}
+
+func newRetryClient(t *testing.T, retries int) *DefaultClient {
+	t.Helper()
+	c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, Retries: retries, RetryBackoff: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return c
+}
+
+func TestRetry_TransientFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer func(w http.ResponseWriter, r *http.Request)
+	}{
+		{"bad gateway", func(w http.ResponseWriter, _ *http.Request) {
+			// Slow failures must not count in the final Duration.
+			time.Sleep(100 * time.Millisecond)
+			w.WriteHeader(http.StatusBadGateway)
+		}},
+		{"connection reset", func(w http.ResponseWriter, _ *http.Request) {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, n := newFlakyServer(t, 2, tt.answer)
+			c := newRetryClient(t, 3)
+
+			resp, err := c.Do(context.Background(), &Request{URL: srv.URL})
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if resp.StatusCode != http.StatusOK || string(resp.Body) != "ok" {
+				t.Errorf("got %d %q, want the third attempt's 200", resp.StatusCode, resp.Body)
+			}
+			if got := n.Load(); got != 3 {
+				t.Errorf("server got %d requests, want 3", got)
+			}
+			if resp.Duration >= 100*time.Millisecond {
+				t.Errorf("Duration = %v, want the final attempt's alone", resp.Duration)
+			}
+			if got := c.Stats().Retries; got != 2 {
+				t.Errorf("Stats().Retries = %d, want 2", got)
+			}
+		})
+	}
+}
+
+func TestRetry_RetryAfter(t *testing.T) {
+	srv, _ := newFlakyServer(t, 1, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	c := newRetryClient(t, 1)
+
+	start := time.Now()
+	resp, err := c.Do(context.Background(), &Request{URL: srv.URL})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want the 1s Retry-After honoured", elapsed)
+	}
+}
+
+func TestRetry_Exhausted(t *testing.T) {
+	srv, n := newFlakyServer(t, 100, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	c := newRetryClient(t, 2)
+
+	resp, err := c.Do(context.Background(), &Request{URL: srv.URL})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want the last attempt's 503", resp.StatusCode)
+	}
+	if got := n.Load(); got != 3 {
+		t.Errorf("server got %d requests, want 3", got)
+	}
+	if got := c.Stats().Retries; got != 2 {
+		t.Errorf("Stats().Retries = %d, want 2", got)
+	}
+}
+
+func TestRetry_Skipped(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    func(url string) *Request
+		status int
+		opts   []int
+	}{
+		{"post", func(url string) *Request { return &Request{Method: http.MethodPost, URL: url, Body: "id=1"} }, http.StatusBadGateway, nil},
+		{"status not retried", func(url string) *Request { return &Request{URL: url} }, http.StatusInternalServerError, nil},
+		{"custom status list", func(url string) *Request { return &Request{URL: url} }, http.StatusBadGateway, []int{http.StatusInternalServerError}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, n := newFlakyServer(t, 1, func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+			})
+			c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, Retries: 3, RetryBackoff: time.Millisecond, RetryStatus: tt.opts})
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+			resp, err := c.Do(context.Background(), tt.req(srv.URL))
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if resp.StatusCode != tt.status || n.Load() != 1 {
+				t.Errorf("got %d after %d requests, want %d after 1", resp.StatusCode, n.Load(), tt.status)
+			}
+		})
+	}
+}
+
+func TestRetry_Backoff(t *testing.T) {
+	c := &DefaultClient{opts: ClientOptions{RetryBackoff: 100 * time.Millisecond}}
+	for n, ceiling := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond} {
+		for range 50 {
+			if d := c.backoff(n); d < 0 || d > ceiling {
+				t.Fatalf("backoff(%d) = %v, want within [0, %v]", n, d, ceiling)
+			}
+		}
+	}
+	if d := c.backoff(40); d > maxRetryBackoff {
+		t.Errorf("backoff(40) = %v, want capped at %v", d, maxRetryBackoff)
+	}
+}
+
+func TestRetry_OwnTimeout(t *testing.T) {
+	srv, n := newFlakyServer(t, 1, func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+	})
+	c := newRetryClient(t, 3)
+
+	_, err := c.Do(context.Background(), &Request{URL: srv.URL, Timeout: 50 * time.Millisecond})
+	if ClassifyFailure(err) != FailureTimeout {
+		t.Fatalf("Do = %v, want a timeout", err)
+	}
+	if got := n.Load(); got != 1 {
+		t.Errorf("server got %d requests, want 1: a request's own timeout is not retried", got)
+	}
+}
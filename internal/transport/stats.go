@@ -0,0 +1,58 @@
+package transport
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+// maxDurationSamples bounds the response durations a client keeps for
+// TransportStats percentiles. Past it, each new duration replaces a random
+// kept one (reservoir sampling), so the sample stays uniform over the
+// whole run in constant memory.
+const maxDurationSamples = 10000
+
+// durationSample tracks the bounds of the durations added and a uniform
+// sample of them. The zero value is empty; it is not safe for concurrent
+// use.
+type durationSample struct {
+	seen     int64
+	min, max time.Duration
+	kept     []time.Duration
+}
+
+// add records d.
+func (s *durationSample) add(d time.Duration) {
+	s.seen++
+	if s.seen == 1 || d < s.min {
+		s.min = d
+	}
+	if d > s.max {
+		s.max = d
+	}
+	if len(s.kept) < maxDurationSamples {
+		s.kept = append(s.kept, d)
+		return
+	}
+	if i := rand.Int64N(s.seen); i < maxDurationSamples {
+		s.kept[i] = d
+	}
+}
+
+// sorted returns the kept durations in ascending order.
+func (s *durationSample) sorted() []time.Duration {
+	sorted := slices.Clone(s.kept)
+	slices.Sort(sorted)
+	return sorted
+}
+
+// percentile returns the nearest-rank p-th percentile, p between 0 and
+// 100, of sorted, or zero when it is empty.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
@@ -0,0 +1,153 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStats_Breakdown(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/drop":
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+		default:
+			w.Write([]byte(strings.Repeat("x", 100)))
+		}
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t)
+	ctx := context.Background()
+	for _, path := range []string{"/", "/", "/", "/missing"} {
+		if _, err := c.Do(ctx, &Request{URL: srv.URL + path}); err != nil {
+			t.Fatalf("Do %s: %v", path, err)
+		}
+	}
+	if _, err := c.Do(ctx, &Request{Method: "POST", URL: srv.URL + "/drop"}); err == nil {
+		t.Fatal("Do /drop succeeded, want the dropped connection reported")
+	}
+
+	stats := c.Stats()
+	if stats.StatusCounts[200] != 3 || stats.StatusCounts[404] != 1 || len(stats.StatusCounts) != 2 {
+		t.Errorf("StatusCounts = %v, want 200:3 404:1", stats.StatusCounts)
+	}
+	if stats.ErrorCount != 1 {
+		t.Errorf("ErrorCount = %d, want 1", stats.ErrorCount)
+	}
+	if stats.BytesReceived < 300 {
+		t.Errorf("BytesReceived = %d, want at least the 300 body bytes", stats.BytesReceived)
+	}
+	if stats.MinDuration <= 0 || stats.MinDuration > stats.P50Duration ||
+		stats.P50Duration > stats.P95Duration || stats.P95Duration > stats.P99Duration ||
+		stats.P99Duration > stats.MaxDuration {
+		t.Errorf("durations out of order: min %v p50 %v p95 %v p99 %v max %v",
+			stats.MinDuration, stats.P50Duration, stats.P95Duration, stats.P99Duration, stats.MaxDuration)
+	}
+
+	// The map is a copy.
+	stats.StatusCounts[200] = 0
+	if c.Stats().StatusCounts[200] != 3 {
+		t.Error("changing the returned StatusCounts changed the client's")
+	}
+}
+
+func TestStats_Concurrent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t)
+	const workers, each = 8, 25
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range each {
+				url := srv.URL
+				if (w+i)%5 == 0 {
+					url += "?fail=1"
+				}
+				if _, err := c.Do(context.Background(), &Request{URL: url}); err != nil {
+					t.Errorf("Do: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := c.Stats()
+	if stats.TotalRequests != workers*each {
+		t.Errorf("TotalRequests = %d, want %d", stats.TotalRequests, workers*each)
+	}
+	if got := stats.StatusCounts[200] + stats.StatusCounts[500]; got != workers*each {
+		t.Errorf("StatusCounts = %v, want %d responses", stats.StatusCounts, workers*each)
+	}
+	if stats.StatusCounts[500] != workers*each/5 {
+		t.Errorf("500 responses = %d, want %d", stats.StatusCounts[500], workers*each/5)
+	}
+	if stats.BytesReceived != 2*workers*each {
+		t.Errorf("BytesReceived = %d, want %d", stats.BytesReceived, 2*workers*each)
+	}
+}
+
+func TestDurationSample(t *testing.T) {
+	var s durationSample
+	if got := percentile(s.sorted(), 50); got != 0 {
+		t.Errorf("percentile of an empty sample = %v, want 0", got)
+	}
+	for i := 100; i >= 1; i-- {
+		s.add(time.Duration(i) * time.Millisecond)
+	}
+	sorted := s.sorted()
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0, time.Millisecond},
+		{50, 50 * time.Millisecond},
+		{95, 95 * time.Millisecond},
+		{99, 99 * time.Millisecond},
+		{100, 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if s.min != time.Millisecond || s.max != 100*time.Millisecond {
+		t.Errorf("min, max = %v, %v, want 1ms, 100ms", s.min, s.max)
+	}
+}
+
+func TestDurationSample_Bounded(t *testing.T) {
+	var s durationSample
+	n := 3 * maxDurationSamples
+	for i := range n {
+		s.add(time.Duration(i+1) * time.Microsecond)
+	}
+	if len(s.kept) != maxDurationSamples {
+		t.Fatalf("kept %d durations, want %d", len(s.kept), maxDurationSamples)
+	}
+	if s.seen != int64(n) || s.max != time.Duration(n)*time.Microsecond || s.min != time.Microsecond {
+		t.Errorf("seen %d, min %v, max %v; want every duration counted", s.seen, s.min, s.max)
+	}
+	// A uniform sample puts the median near the middle of the run; one
+	// that kept only the first durations would put it at a sixth.
+	mid := time.Duration(n/2) * time.Microsecond
+	if got := percentile(s.sorted(), 50); got < mid*8/10 || got > mid*12/10 {
+		t.Errorf("median = %v, want about %v", got, mid)
+	}
+}
@@ -0,0 +1,55 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+// tlsConfig returns the TLS configuration of a client with opts: its
+// client certificate for targets behind mutual TLS, the system roots plus
+// CACertFile's certificates to verify targets with, and the server name
+// of HostOverride.
+func tlsConfig(opts ClientOptions) (*tls.Config, error) {
+	cfg := &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify}
+	if opts.HostOverride != "" {
+		cfg.ServerName = opts.HostOverride
+		if host, _, err := net.SplitHostPort(opts.HostOverride); err == nil {
+			cfg.ServerName = host
+		}
+	}
+
+	if opts.ClientCertFile != "" || opts.ClientKeyFile != "" {
+		if opts.ClientCertFile == "" {
+			return nil, errors.New("client key given without a client certificate")
+		}
+		keyFile := opts.ClientKeyFile
+		if keyFile == "" {
+			keyFile = opts.ClientCertFile
+		}
+		cert, err := tls.LoadX509KeyPair(opts.ClientCertFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate %s with key %s: %w", opts.ClientCertFile, keyFile, err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if opts.CACertFile != "" {
+		data, err := os.ReadFile(opts.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading CA certificates: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("loading CA certificates: no PEM certificate in %s", opts.CACertFile)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
@@ -0,0 +1,201 @@
+package transport
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testPKI is a CA and the server and client certificates it issued, the
+// latter two also written to PEM files in a temporary directory.
+type testPKI struct {
+	dir    string
+	pool   *x509.CertPool
+	server tls.Certificate
+}
+
+// issue returns a certificate for name signed by parent (self-signed when
+// nil), and its key.
+func issue(t *testing.T, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, serial int64) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func writePEM(t *testing.T, path, typ string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	dir := t.TempDir()
+	ca, caKey := issue(t, "test CA", nil, nil, 1)
+	srv, srvKey := issue(t, "server", ca, caKey, 2)
+	cli, cliKey := issue(t, "client", ca, caKey, 3)
+	_, otherKey := issue(t, "other", ca, caKey, 4)
+
+	writePEM(t, filepath.Join(dir, "ca.pem"), "CERTIFICATE", ca.Raw)
+	writePEM(t, filepath.Join(dir, "client.pem"), "CERTIFICATE", cli.Raw)
+	for name, key := range map[string]*ecdsa.PrivateKey{"client.key": cliKey, "other.key": otherKey} {
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writePEM(t, filepath.Join(dir, name), "EC PRIVATE KEY", der)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	return &testPKI{
+		dir:    dir,
+		pool:   pool,
+		server: tls.Certificate{Certificate: [][]byte{srv.Raw}, PrivateKey: srvKey},
+	}
+}
+
+func (p *testPKI) path(name string) string { return filepath.Join(p.dir, name) }
+
+// newMTLSServer starts a server that answers only clients presenting a
+// certificate issued by the test CA.
+func newMTLSServer(t *testing.T, pki *testPKI) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello " + r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{pki.server},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pki.pool,
+	}
+	// The rejected handshakes are expected; keep them out of the test log.
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient_ClientCertificate(t *testing.T) {
+	pki := newTestPKI(t)
+	srv := newMTLSServer(t, pki)
+
+	tests := []struct {
+		name    string
+		opts    ClientOptions
+		wantErr string // "" when the request succeeds
+	}{
+		{"cert and CA", ClientOptions{ClientCertFile: pki.path("client.pem"), ClientKeyFile: pki.path("client.key"), CACertFile: pki.path("ca.pem")}, ""},
+		{"no cert", ClientOptions{CACertFile: pki.path("ca.pem")}, "certificate required"},
+		{"no CA", ClientOptions{ClientCertFile: pki.path("client.pem"), ClientKeyFile: pki.path("client.key")}, "certificate"},
+		{"cert, CA skipped", ClientOptions{ClientCertFile: pki.path("client.pem"), ClientKeyFile: pki.path("client.key"), InsecureSkipVerify: true}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Timeout = 5 * time.Second
+			c, err := NewClient(tt.opts)
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+			resp, err := c.Do(context.Background(), &Request{URL: srv.URL})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Do = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if got := string(resp.Body); got != "hello client" {
+				t.Errorf("body = %q, want the server to see the client certificate", got)
+			}
+		})
+	}
+}
+
+func TestClient_ClientCertificateCombinedPEM(t *testing.T) {
+	pki := newTestPKI(t)
+	srv := newMTLSServer(t, pki)
+
+	cert, _ := os.ReadFile(pki.path("client.pem"))
+	key, _ := os.ReadFile(pki.path("client.key"))
+	combined := pki.path("combined.pem")
+	if err := os.WriteFile(combined, append(cert, key...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, ClientCertFile: combined, CACertFile: pki.path("ca.pem")})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if _, err := c.Do(context.Background(), &Request{URL: srv.URL}); err != nil {
+		t.Errorf("Do with the key in the certificate file: %v", err)
+	}
+}
+
+func TestClient_TLSFileErrors(t *testing.T) {
+	pki := newTestPKI(t)
+
+	tests := []struct {
+		name    string
+		opts    ClientOptions
+		wantErr string
+	}{
+		{"mismatched key", ClientOptions{ClientCertFile: pki.path("client.pem"), ClientKeyFile: pki.path("other.key")}, "private key does not match public key"},
+		{"missing cert", ClientOptions{ClientCertFile: pki.path("nope.pem"), ClientKeyFile: pki.path("client.key")}, "nope.pem"},
+		{"key without cert", ClientOptions{ClientKeyFile: pki.path("client.key")}, "without a client certificate"},
+		{"missing CA", ClientOptions{CACertFile: pki.path("nope.pem")}, "nope.pem"},
+		{"CA not PEM", ClientOptions{CACertFile: pki.path("client.key")}, "no PEM certificate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewClient = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// trafficBodyCap is how much of a request or response body the traffic
+// log keeps.
+const trafficBodyCap = 64 << 10
+
+// redactedHeaders are the headers whose values ClientOptions.RedactTraffic
+// leaves out of the traffic log.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// trafficLog appends every exchange of a client to a file, for
+// ClientOptions.TrafficLogPath.
+type trafficLog struct {
+	redact bool
+
+	mu sync.Mutex // Keeps each exchange in one piece
+	f  *os.File
+}
+
+func openTrafficLog(path string, redact bool) (*trafficLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening traffic log: %w", err)
+	}
+	return &trafficLog{redact: redact, f: f}, nil
+}
+
+// record appends request id, sent at start with body reqBody, and the
+// response to it, or the error it failed with.
+func (l *trafficLog) record(id uint64, start time.Time, req *http.Request, reqBody string, resp *http.Response, respBody []byte, duration time.Duration, err error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "=== #%d %s\n", id, start.UTC().Format(time.RFC3339Nano))
+	fmt.Fprintf(&b, "%s %s %s\n", req.Method, req.URL, req.Proto)
+	fmt.Fprintf(&b, "Host: %s\n", req.URL.Host)
+	l.writeHeaders(&b, req.Header)
+	writeBody(&b, []byte(reqBody))
+
+	if err != nil {
+		fmt.Fprintf(&b, "--- #%d error after %s: %v\n\n", id, duration, err)
+	} else {
+		fmt.Fprintf(&b, "--- #%d response after %s\n", id, duration)
+		fmt.Fprintf(&b, "%s %s\n", resp.Proto, resp.Status)
+		l.writeHeaders(&b, resp.Header)
+		writeBody(&b, respBody)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.f.Write(b.Bytes())
+}
+
+// writeHeaders writes h sorted by name, redacted if the log is.
+func (l *trafficLog) writeHeaders(b *bytes.Buffer, h http.Header) {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		for _, v := range h[name] {
+			if l.redact && slices.ContainsFunc(redactedHeaders, func(r string) bool { return strings.EqualFold(r, name) }) {
+				v = "[redacted]"
+			}
+			fmt.Fprintf(b, "%s: %s\n", name, v)
+		}
+	}
+	b.WriteByte('\n')
+}
+
+// writeBody writes body, cut at trafficBodyCap, and a blank line.
+func writeBody(b *bytes.Buffer, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+	if len(body) > trafficBodyCap {
+		b.Write(body[:trafficBodyCap])
+		fmt.Fprintf(b, "\n[... %d more bytes]", len(body)-trafficBodyCap)
+	} else {
+		b.Write(body)
+	}
+	b.WriteString("\n\n")
+}
+
+// Close closes the file.
+func (l *trafficLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
@@ -0,0 +1,186 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTrafficClient(t *testing.T, redact bool) (*DefaultClient, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "traffic.log")
+	c, err := NewClient(ClientOptions{Timeout: 5 * time.Second, TrafficLogPath: path, RedactTraffic: redact})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c, path
+}
+
+func readLog(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+var entryID = regexp.MustCompile(`(?m)^=== #(\d+) `)
+
+func TestTrafficLog_Ordering(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Echo", r.URL.Query().Get("id"))
+		fmt.Fprintf(w, "page %s", r.URL.Query().Get("id"))
+	}))
+	defer srv.Close()
+	c, path := newTrafficClient(t, false)
+
+	for i := 1; i <= 3; i++ {
+		req := &Request{URL: fmt.Sprintf("%s/p?id=%d", srv.URL, i)}
+		if i == 2 {
+			req = &Request{Method: http.MethodPost, URL: srv.URL + "/p?id=2", Body: "name=x'", ContentType: "application/x-www-form-urlencoded"}
+		}
+		resp, err := c.Do(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+		if resp.RequestID != uint64(i) {
+			t.Errorf("request %d: RequestID = %d", i, resp.RequestID)
+		}
+	}
+
+	log := readLog(t, path)
+	var ids []string
+	for _, m := range entryID.FindAllStringSubmatch(log, -1) {
+		ids = append(ids, m[1])
+	}
+	if strings.Join(ids, ",") != "1,2,3" {
+		t.Errorf("entries %v, want 1,2,3 in order", ids)
+	}
+	for _, want := range []string{
+		"GET " + srv.URL + "/p?id=1 HTTP/1.1\n",
+		"POST " + srv.URL + "/p?id=2 HTTP/1.1\n",
+		"Content-Type: application/x-www-form-urlencoded\n\nname=x'\n",
+		"--- #2 response after ",
+		"HTTP/1.1 200 OK\n",
+		"X-Echo: 3\n",
+		"\n\npage 3\n",
+	} {
+		if !strings.Contains(log, want) {
+			t.Errorf("log lacks %q:\n%s", want, log)
+		}
+	}
+
+	// A request that fails is logged with its error.
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	dead := ln.Addr().String()
+	ln.Close()
+	if _, err := c.Do(context.Background(), &Request{URL: "http://" + dead + "/"}); err == nil {
+		t.Fatal("Do against a closed port succeeded")
+	}
+	if log := readLog(t, path); !strings.Contains(log, "--- #4 error after ") {
+		t.Errorf("failed request not logged:\n%s", log)
+	}
+}
+
+func TestTrafficLog_Redaction(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "sid", Value: "server-secret"})
+	}))
+	defer srv.Close()
+	req := &Request{
+		URL:     srv.URL,
+		Headers: map[string]string{"Authorization": "Bearer token-secret", "X-Trace": "visible"},
+		Cookies: map[string]string{"sid": "cookie-secret"},
+	}
+
+	for _, redact := range []bool{false, true} {
+		c, path := newTrafficClient(t, redact)
+		if _, err := c.Do(context.Background(), req); err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+		log := readLog(t, path)
+		if !strings.Contains(log, "X-Trace: visible") {
+			t.Errorf("redact=%v: other headers missing:\n%s", redact, log)
+		}
+		for _, secret := range []string{"token-secret", "cookie-secret", "server-secret"} {
+			if strings.Contains(log, secret) == redact {
+				t.Errorf("redact=%v: %s logged = %v:\n%s", redact, secret, !redact, log)
+			}
+		}
+		if redact && strings.Count(log, "[redacted]") != 3 {
+			t.Errorf("want Authorization, Cookie and Set-Cookie redacted:\n%s", log)
+		}
+	}
+}
+
+func TestTrafficLog_TruncatesBodies(t *testing.T) {
+	huge := strings.Repeat("A", 1<<20)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(huge))
+	}))
+	defer srv.Close()
+	c, path := newTrafficClient(t, false)
+
+	resp, err := c.Do(context.Background(), &Request{Method: http.MethodPost, URL: srv.URL, Body: huge})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if len(resp.Body) != len(huge) {
+		t.Errorf("response body cut to %d bytes, want the cap on the log only", len(resp.Body))
+	}
+	log := readLog(t, path)
+	if len(log) > 2*trafficBodyCap+4096 {
+		t.Errorf("log is %d bytes, want both bodies cut at %d", len(log), trafficBodyCap)
+	}
+	if n := strings.Count(log, fmt.Sprintf("[... %d more bytes]", len(huge)-trafficBodyCap)); n != 2 {
+		t.Errorf("found %d truncation notes, want 2", n)
+	}
+}
+
+func TestTrafficLog_Concurrent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat(r.URL.Query().Get("n"), 1000)))
+	}))
+	defer srv.Close()
+	c, path := newTrafficClient(t, false)
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Do(context.Background(), &Request{URL: fmt.Sprintf("%s/?n=%c", srv.URL, 'a'+i)})
+		}()
+	}
+	wg.Wait()
+
+	// Every exchange is written whole: its request is followed by its own
+	// response.
+	entries := strings.Split(readLog(t, path), "=== #")[1:]
+	if len(entries) != 20 {
+		t.Fatalf("%d entries, want 20", len(entries))
+	}
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		id, _, _ := strings.Cut(e, " ")
+		if seen[id] {
+			t.Errorf("request #%s logged twice", id)
+		}
+		seen[id] = true
+		n := regexp.MustCompile(`\?n=(\w)`).FindStringSubmatch(e)
+		if n == nil || !strings.Contains(e, "--- #"+id+" response") || !strings.Contains(e, strings.Repeat(n[1], 1000)) {
+			t.Errorf("entry #%s is not whole:\n%.300s", id, e)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// Package xmlbody finds and rewrites the text of leaf elements in XML
+// request bodies, such as the arguments of a SOAP call. Elements are named
+// by simplified XPaths of their local names, e.g. /Envelope/Body/GetUser/id,
+// with a 1-based [n] on the second and later siblings of the same name:
+// /order/item[2]. Attributes are not parameters.
+//
+// Rewriting splices the new text between the element's tags and leaves the
+// rest of the document byte for byte as it was, namespace prefixes and
+// whitespace included.
+package xmlbody
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// ErrNoElement is returned by Set for a path that names no leaf element.
+var ErrNoElement = errors.New("xmlbody: no leaf element at path")
+
+// Field is a leaf element: one without child elements.
+type Field struct {
+	Path  string
+	Value string // Text content, entities decoded
+}
+
+// leaf is a Field and where its text sits in the document.
+type leaf struct {
+	Field
+	start, end int    // Byte range of the text between the tags
+	selfClose  bool   // <name/>: start and end are the range of "/>"
+	rawName    string // Name as written, prefix included, for closing a self-closed element
+}
+
+// IsXML reports whether contentType is an XML media type: text/xml,
+// application/xml, application/soap+xml or any other +xml type.
+func IsXML(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "text/xml" || mediaType == "application/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// LooksLikeXML reports whether body starts like an XML document, for
+// bodies sent without a Content-Type.
+func LooksLikeXML(body string) bool {
+	body = strings.TrimSpace(body)
+	return strings.HasPrefix(body, "<?xml") || strings.HasPrefix(body, "<") && strings.HasSuffix(body, ">")
+}
+
+// Fields returns the leaf elements of body in document order.
+func Fields(body string) ([]Field, error) {
+	leaves, err := scan(body)
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]Field, len(leaves))
+	for i, l := range leaves {
+		fields[i] = l.Field
+	}
+	return fields, nil
+}
+
+// Set returns body with the text of the leaf element at path replaced by
+// value. The value is escaped for element content (&, < and >) unless raw
+// is set, for backends that read the text without decoding entities.
+func Set(body, path, value string, raw bool) (string, error) {
+	leaves, err := scan(body)
+	if err != nil {
+		return "", err
+	}
+	if !raw {
+		value = Escape(value)
+	}
+	for _, l := range leaves {
+		if l.Path != path {
+			continue
+		}
+		if l.selfClose {
+			return body[:l.start] + ">" + value + "</" + l.rawName + ">" + body[l.end:], nil
+		}
+		return body[:l.start] + value + body[l.end:], nil
+	}
+	return "", fmt.Errorf("%w %s", ErrNoElement, path)
+}
+
+// Escape escapes s for element content. Quotes are left as they are: they
+// need no escaping in text, and SQL payloads are full of them.
+func Escape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// frame is an open element during scan.
+type frame struct {
+	path     string
+	counts   map[string]int // Child elements seen, by local name
+	hasChild bool
+	text     strings.Builder
+	start    int // Offset just past the start tag
+	rawName  string
+}
+
+// scan returns the leaf elements of body with the byte ranges of their
+// text.
+func scan(body string) ([]leaf, error) {
+	d := xml.NewDecoder(strings.NewReader(body))
+	d.Strict = false
+
+	var (
+		leaves []leaf
+		stack  []*frame
+	)
+	root := &frame{counts: map[string]int{}}
+	for {
+		tokStart := int(d.InputOffset())
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("xmlbody: %w", err)
+		}
+		tokEnd := int(d.InputOffset())
+
+		parent := root
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1]
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			parent.hasChild = true
+			parent.counts[t.Name.Local]++
+			path := parent.path + "/" + t.Name.Local
+			if n := parent.counts[t.Name.Local]; n > 1 {
+				path += "[" + strconv.Itoa(n) + "]"
+			}
+			f := &frame{path: path, counts: map[string]int{}, start: tokEnd, rawName: rawName(body[tokStart:tokEnd])}
+			if strings.HasSuffix(body[tokStart:tokEnd], "/>") {
+				// A self-closed element; the decoder follows it with an
+				// EndElement at the same offset.
+				f.start = tokEnd - 2
+			}
+			stack = append(stack, f)
+		case xml.CharData:
+			parent.text.Write(t)
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("xmlbody: unexpected end element </%s>", t.Name.Local)
+			}
+			f := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if f.hasChild {
+				continue
+			}
+			l := leaf{Field: Field{Path: f.path, Value: f.text.String()}, start: f.start, end: tokStart, rawName: f.rawName}
+			if tokStart == tokEnd {
+				l.selfClose, l.end = true, tokEnd
+			}
+			leaves = append(leaves, l)
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("xmlbody: unclosed element %s", stack[len(stack)-1].path)
+	}
+	return leaves, nil
+}
+
+// rawName returns the element name of a start tag as written.
+func rawName(tag string) string {
+	name := strings.TrimPrefix(tag, "<")
+	if i := strings.IndexAny(name, " \t\r\n/>"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
@@ -0,0 +1,116 @@
+package xmlbody
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const envelope = `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:u="urn:users">
+  <soap:Header><u:Token>abc&amp;def</u:Token></soap:Header>
+  <soap:Body>
+    <u:GetUser>
+      <u:id>1</u:id>
+      <u:tag>a</u:tag>
+      <u:tag><![CDATA[b<c]]></u:tag>
+      <u:note/>
+    </u:GetUser>
+  </soap:Body>
+</soap:Envelope>`
+
+func TestFields(t *testing.T) {
+	fields, err := Fields(envelope)
+	if err != nil {
+		t.Fatalf("Fields: %v", err)
+	}
+	want := []Field{
+		{"/Envelope/Header/Token", "abc&def"},
+		{"/Envelope/Body/GetUser/id", "1"},
+		{"/Envelope/Body/GetUser/tag", "a"},
+		{"/Envelope/Body/GetUser/tag[2]", "b<c"},
+		{"/Envelope/Body/GetUser/note", ""},
+	}
+	if !slices.Equal(fields, want) {
+		t.Errorf("Fields =\n%q\nwant\n%q", fields, want)
+	}
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		path, value string
+		raw         bool
+		want        Field
+	}{
+		{"/Envelope/Body/GetUser/id", "1' AND 1<2 AND 'a'='a", false, Field{"/Envelope/Body/GetUser/id", "1' AND 1<2 AND 'a'='a"}},
+		{"/Envelope/Body/GetUser/tag[2]", "x&y", false, Field{"/Envelope/Body/GetUser/tag[2]", "x&y"}},
+		{"/Envelope/Body/GetUser/note", "1 OR 1=1", false, Field{"/Envelope/Body/GetUser/note", "1 OR 1=1"}},
+		{"/Envelope/Body/GetUser/id", "1&#39;", true, Field{"/Envelope/Body/GetUser/id", "1'"}},
+	}
+	for _, tt := range tests {
+		body, err := Set(envelope, tt.path, tt.value, tt.raw)
+		if err != nil {
+			t.Fatalf("Set(%s, %q): %v", tt.path, tt.value, err)
+		}
+		// The envelope still parses, with only the one value changed.
+		fields, err := Fields(body)
+		if err != nil {
+			t.Fatalf("Set(%s, %q) broke the document: %v\n%s", tt.path, tt.value, err, body)
+		}
+		orig, _ := Fields(envelope)
+		if len(fields) != len(orig) {
+			t.Fatalf("Set(%s): %d fields, want %d", tt.path, len(fields), len(orig))
+		}
+		for i, f := range fields {
+			want := orig[i]
+			if f.Path == tt.path {
+				want = tt.want
+			}
+			if f != want {
+				t.Errorf("Set(%s, %q): field %q, want %q", tt.path, tt.value, f, want)
+			}
+		}
+	}
+
+	body, err := Set(envelope, "/Envelope/Body/GetUser/id", "2", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Replace(envelope, "<u:id>1</u:id>", "<u:id>2</u:id>", 1); body != want {
+		t.Errorf("Set changed more than the value:\n%s", body)
+	}
+
+	if _, err := Set(envelope, "/Envelope/Body/GetUser", "1", false); !errors.Is(err, ErrNoElement) {
+		t.Errorf("Set of a non-leaf: error = %v, want ErrNoElement", err)
+	}
+	if _, err := Set("<a><b>1</b>", "/a/b", "2", false); err == nil {
+		t.Error("Set of a malformed document: want an error")
+	}
+}
+
+func TestEscape(t *testing.T) {
+	if got, want := Escape(`1' AND "x"<>'&'`), `1' AND "x"&lt;&gt;'&amp;'`; got != want {
+		t.Errorf("Escape = %q, want %q", got, want)
+	}
+}
+
+func TestIsXML(t *testing.T) {
+	for ct, want := range map[string]bool{
+		"text/xml":                             true,
+		"text/xml; charset=utf-8":              true,
+		"application/xml":                      true,
+		`application/soap+xml; action="urn:x"`: true,
+		"application/atom+xml":                 true,
+		"application/json":                     false,
+		"application/x-www-form-urlencoded":    false,
+		"":                                     false,
+	} {
+		if got := IsXML(ct); got != want {
+			t.Errorf("IsXML(%q) = %v, want %v", ct, got, want)
+		}
+	}
+	if !LooksLikeXML("  <?xml version=\"1.0\"?><a/>") || !LooksLikeXML("<a>1</a>") || LooksLikeXML("a=1&b=<2>") {
+		t.Error("LooksLikeXML misjudged a body")
+	}
+}
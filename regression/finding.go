@@ -0,0 +1,61 @@
+package regression
+
+import (
+	"sort"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+)
+
+// Outcome is the report-independent result of one scan: only what the
+// goldens pin down, in a stable order.
+type Outcome struct {
+	DBMS     string
+	Findings []Finding
+	Requests int64
+
+	// Unrecorded lists requests the scan made that the cassette has no
+	// answer for. They mean the scan no longer probes the way it did when
+	// the entry was recorded.
+	Unrecorded []string
+}
+
+// Finding is a normalized vulnerability.
+type Finding struct {
+	ID         string
+	Technique  string
+	Confidence float64
+}
+
+// FindingID identifies a finding independently of payload text, evidence
+// and report format: location and name of the parameter (and its pair, for
+// cross-parameter findings) plus the technique, e.g. "query:id:error-based".
+func FindingID(v engine.Vulnerability) string {
+	id := v.Parameter.Location.String() + ":" + v.Parameter.Name
+	if v.PairedParameter != nil {
+		id += "+" + v.PairedParameter.Location.String() + ":" + v.PairedParameter.Name
+	}
+	return id + ":" + v.Technique
+}
+
+// Normalize reduces a scan result to an Outcome. Only injectable results
+// become findings, sorted by ID.
+func Normalize(result *engine.ScanResult) *Outcome {
+	o := &Outcome{
+		DBMS:     result.DBMS,
+		Requests: result.RequestCount,
+	}
+	for _, v := range result.Vulnerabilities {
+		if !v.Injectable {
+			continue
+		}
+		o.Findings = append(o.Findings, Finding{
+			ID:         FindingID(v),
+			Technique:  v.Technique,
+			Confidence: v.Confidence,
+		})
+	}
+	sort.SliceStable(o.Findings, func(i, j int) bool {
+		return o.Findings[i].ID < o.Findings[j].ID
+	})
+	return o
+}
@@ -0,0 +1,143 @@
+package regression
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+// Tolerances applied when a golden is generated from an outcome. Goldens
+// store the resulting bands, so an individual golden can be widened by hand
+// where a target is known to be noisy.
+const (
+	// ConfidenceTolerance is the allowed drift of a finding's confidence.
+	ConfidenceTolerance = 0.05
+
+	// RequestTolerance is the allowed relative drift of the request count,
+	// but never less than MinRequestSlack requests either way.
+	RequestTolerance = 0.10
+	MinRequestSlack  = 5
+)
+
+// Band is an inclusive range of accepted values.
+type Band struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// Contains reports whether v lies within the band.
+func (b Band) Contains(v float64) bool {
+	return v >= b.Min && v <= b.Max
+}
+
+// String formats the band as [min, max].
+func (b Band) String() string {
+	return fmt.Sprintf("[%g, %g]", b.Min, b.Max)
+}
+
+// Golden is the expected outcome of a corpus entry.
+type Golden struct {
+	DBMS     string          `json:"dbms"`
+	Requests Band            `json:"requests"`
+	Findings []GoldenFinding `json:"findings"`
+}
+
+// GoldenFinding is an expected finding.
+type GoldenFinding struct {
+	ID         string `json:"id"`
+	Technique  string `json:"technique"`
+	Confidence Band   `json:"confidence"`
+}
+
+// NewGolden builds a golden from o using the default tolerances.
+func NewGolden(o *Outcome) *Golden {
+	slack := math.Max(math.Round(float64(o.Requests)*RequestTolerance), MinRequestSlack)
+	g := &Golden{
+		DBMS:     o.DBMS,
+		Requests: Band{Min: math.Max(float64(o.Requests)-slack, 0), Max: float64(o.Requests) + slack},
+		Findings: []GoldenFinding{},
+	}
+	for _, f := range o.Findings {
+		g.Findings = append(g.Findings, GoldenFinding{
+			ID:        f.ID,
+			Technique: f.Technique,
+			Confidence: Band{
+				Min: round2(math.Max(f.Confidence-ConfidenceTolerance, 0)),
+				Max: round2(math.Min(f.Confidence+ConfidenceTolerance, 1)),
+			},
+		})
+	}
+	return g
+}
+
+// round2 rounds to two decimals so goldens don't carry float noise.
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// LoadGolden reads a golden file.
+func LoadGolden(path string) (*Golden, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var g Golden
+	if err := json.Unmarshal(data, &g); err != nil {
+		return nil, fmt.Errorf("parse golden %s: %w", path, err)
+	}
+	return &g, nil
+}
+
+// Save writes the golden to path as indented JSON.
+func (g *Golden) Save(path string) error {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Compare checks o against g and returns one line per difference, empty if
+// o is within tolerance:
+//
+//   - query:id:error-based          expected finding missing
+//   - query:name:boolean-blind      finding not in the golden
+//     ~ query:id:error-based          confidence outside its band
+//     ~ dbms / ~ requests             scan-level drift
+func Compare(g *Golden, o *Outcome) []string {
+	var diff []string
+	if o.DBMS != g.DBMS {
+		diff = append(diff, fmt.Sprintf("~ dbms: got %q, want %q", o.DBMS, g.DBMS))
+	}
+
+	// Findings are matched by ID as a multiset: the n-th occurrence of an ID
+	// in the golden pairs with the n-th in the outcome.
+	got := make(map[string][]Finding)
+	for _, f := range o.Findings {
+		got[f.ID] = append(got[f.ID], f)
+	}
+	for _, want := range g.Findings {
+		matches := got[want.ID]
+		if len(matches) == 0 {
+			diff = append(diff, fmt.Sprintf("- %s (%s, confidence %s): missing", want.ID, want.Technique, want.Confidence))
+			continue
+		}
+		f := matches[0]
+		got[want.ID] = matches[1:]
+		if !want.Confidence.Contains(f.Confidence) {
+			diff = append(diff, fmt.Sprintf("~ %s: confidence %.2f outside %s", want.ID, f.Confidence, want.Confidence))
+		}
+	}
+	for _, f := range o.Findings {
+		if rest := got[f.ID]; len(rest) > 0 {
+			diff = append(diff, fmt.Sprintf("+ %s (%s, confidence %.2f): unexpected", rest[0].ID, rest[0].Technique, rest[0].Confidence))
+			got[f.ID] = rest[1:]
+		}
+	}
+
+	if !g.Requests.Contains(float64(o.Requests)) {
+		diff = append(diff, fmt.Sprintf("~ requests: got %d, want %s", o.Requests, g.Requests))
+	}
+	return diff
+}
@@ -0,0 +1,119 @@
+package regression
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewGolden_Bands(t *testing.T) {
+	g := NewGolden(&Outcome{
+		DBMS:     "MySQL",
+		Requests: 200,
+		Findings: []Finding{
+			{ID: "query:id:error-based", Technique: "error-based", Confidence: 0.98},
+			{ID: "query:id:time-based", Technique: "time-based", Confidence: 0.02},
+		},
+	})
+	if g.Requests != (Band{Min: 180, Max: 220}) {
+		t.Errorf("Requests = %v, want [180, 220]", g.Requests)
+	}
+	if got := g.Findings[0].Confidence; got != (Band{Min: 0.93, Max: 1}) {
+		t.Errorf("confidence band = %v, want clamped to [0.93, 1]", got)
+	}
+	if got := g.Findings[1].Confidence; got != (Band{Min: 0, Max: 0.07}) {
+		t.Errorf("confidence band = %v, want clamped to [0, 0.07]", got)
+	}
+
+	small := NewGolden(&Outcome{Requests: 12})
+	if small.Requests != (Band{Min: 7, Max: 17}) {
+		t.Errorf("Requests = %v, want MinRequestSlack applied", small.Requests)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	golden := NewGolden(&Outcome{
+		DBMS:     "MySQL",
+		Requests: 100,
+		Findings: []Finding{
+			{ID: "query:id:boolean-blind", Technique: "boolean-blind", Confidence: 0.9},
+			{ID: "query:id:error-based", Technique: "error-based", Confidence: 0.95},
+		},
+	})
+
+	tests := []struct {
+		name    string
+		outcome Outcome
+		want    []string
+	}{
+		{
+			name: "within tolerance",
+			outcome: Outcome{DBMS: "MySQL", Requests: 108, Findings: []Finding{
+				{ID: "query:id:boolean-blind", Technique: "boolean-blind", Confidence: 0.86},
+				{ID: "query:id:error-based", Technique: "error-based", Confidence: 1},
+			}},
+		},
+		{
+			name: "confidence drift",
+			outcome: Outcome{DBMS: "MySQL", Requests: 100, Findings: []Finding{
+				{ID: "query:id:boolean-blind", Technique: "boolean-blind", Confidence: 0.8},
+				{ID: "query:id:error-based", Technique: "error-based", Confidence: 0.95},
+			}},
+			want: []string{"~ query:id:boolean-blind: confidence 0.80 outside [0.85, 0.95]"},
+		},
+		{
+			name: "missing and unexpected",
+			outcome: Outcome{DBMS: "MySQL", Requests: 100, Findings: []Finding{
+				{ID: "query:id:error-based", Technique: "error-based", Confidence: 0.95},
+				{ID: "query:name:union-based", Technique: "union-based", Confidence: 0.9},
+			}},
+			want: []string{
+				"- query:id:boolean-blind (boolean-blind, confidence [0.85, 0.95]): missing",
+				"+ query:name:union-based (union-based, confidence 0.90): unexpected",
+			},
+		},
+		{
+			name: "duplicate finding",
+			outcome: Outcome{DBMS: "MySQL", Requests: 100, Findings: []Finding{
+				{ID: "query:id:boolean-blind", Technique: "boolean-blind", Confidence: 0.9},
+				{ID: "query:id:boolean-blind", Technique: "boolean-blind", Confidence: 0.7},
+				{ID: "query:id:error-based", Technique: "error-based", Confidence: 0.95},
+			}},
+			want: []string{"+ query:id:boolean-blind (boolean-blind, confidence 0.70): unexpected"},
+		},
+		{
+			name: "scan level drift",
+			outcome: Outcome{DBMS: "PostgreSQL", Requests: 150, Findings: []Finding{
+				{ID: "query:id:boolean-blind", Technique: "boolean-blind", Confidence: 0.9},
+				{ID: "query:id:error-based", Technique: "error-based", Confidence: 0.95},
+			}},
+			want: []string{
+				`~ dbms: got "PostgreSQL", want "MySQL"`,
+				"~ requests: got 150, want [90, 110]",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compare(golden, &tt.outcome)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Compare() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestGolden_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "g.json")
+	g := NewGolden(&Outcome{DBMS: "MSSQL", Requests: 30})
+	if err := g.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadGolden(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.DBMS != "MSSQL" || loaded.Requests != g.Requests || len(loaded.Findings) != 0 {
+		t.Errorf("loaded %+v, want %+v", loaded, g)
+	}
+}
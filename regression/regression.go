@@ -0,0 +1,159 @@
+// Package regression replays recorded target behavior through the full
+// scanner and compares the findings against golden files, so a change to
+// detection logic cannot silently change what a standard scan finds.
+//
+// Each corpus entry under testdata/corpus is a scan target, its scan
+// configuration and a cassette of the exchanges recorded while scanning it.
+// The matching file under testdata/golden lists the expected findings, each
+// with a confidence band, and a band for the request count:
+//
+//	go test ./regression/...                    # replay and compare
+//	go test ./regression/... -update            # accept new behavior
+//	go test ./regression/... -record -update    # re-record, then accept
+//
+// This harness is the required gate for any change that affects techniques,
+// heuristics or boundaries: if it fails, either fix the change or regenerate
+// the goldens with -update and justify the diff in review.
+package regression
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/0x6d61/sqleech/internal/cli"
+	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// Entry is one corpus entry: a target, how to scan it, and the recorded
+// exchanges to replay.
+type Entry struct {
+	// Name is the file name without extension. It is not stored.
+	Name string `json:"-"`
+
+	Description string              `json:"description"`
+	Target      Target              `json:"target"`
+	Config      Config              `json:"config"`
+	Cassette    *transport.Cassette `json:"cassette,omitempty"`
+}
+
+// Target is the request that starts the scan.
+type Target struct {
+	URL         string            `json:"url"`
+	Method      string            `json:"method,omitempty"`
+	Body        string            `json:"body,omitempty"`
+	ContentType string            `json:"content_type,omitempty"`
+	Cookies     map[string]string `json:"cookies,omitempty"`
+}
+
+// Config holds the scan options an entry overrides.
+type Config struct {
+	DBMS       string   `json:"dbms,omitempty"`
+	Techniques []string `json:"techniques,omitempty"`
+	ForceTest  bool     `json:"force_test,omitempty"`
+}
+
+// scanConfig returns the engine configuration for c. Scans run on a single
+// worker so that identical requests see their recorded responses in order,
+// and report every technique's finding, which the goldens compare.
+func (c Config) scanConfig() *engine.ScanConfig {
+	cfg := engine.DefaultScanConfig()
+	cfg.Threads = 1
+	cfg.PerTechnique = true
+	cfg.DBMSHint = c.DBMS
+	cfg.Techniques = c.Techniques
+	cfg.ForceTest = c.ForceTest
+	return cfg
+}
+
+// LoadCorpus reads every *.json entry in dir, sorted by name.
+func LoadCorpus(dir string) ([]*Entry, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	entries := make([]*Entry, 0, len(paths))
+	for _, path := range paths {
+		e, err := LoadEntry(path)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// LoadEntry reads a single corpus entry.
+func LoadEntry(path string) (*Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("parse corpus entry %s: %w", path, err)
+	}
+	if e.Target.URL == "" {
+		return nil, fmt.Errorf("corpus entry %s: missing target url", path)
+	}
+	e.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return &e, nil
+}
+
+// Save writes the entry to path as indented JSON.
+func (e *Entry) Save(path string) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(e); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// scan runs the shipped scanner against the entry's target through client.
+func (e *Entry) scan(ctx context.Context, client transport.Client) (*engine.ScanResult, error) {
+	scanner := cli.NewScanner(client, e.Config.scanConfig())
+	return scanner.Scan(ctx, &engine.ScanTarget{
+		URL:         e.Target.URL,
+		Method:      e.Target.Method,
+		Body:        e.Target.Body,
+		ContentType: e.Target.ContentType,
+		Cookies:     e.Target.Cookies,
+	})
+}
+
+// Record scans the entry's target through client and replaces the entry's
+// cassette with the exchanges made.
+func (e *Entry) Record(ctx context.Context, client transport.Client) error {
+	rec := transport.NewRecorder(client)
+	if _, err := e.scan(ctx, rec); err != nil {
+		return fmt.Errorf("record %s: %w", e.Name, err)
+	}
+	e.Cassette = rec.Cassette()
+	return nil
+}
+
+// Replay scans the entry's target against its cassette.
+func (e *Entry) Replay(ctx context.Context) (*Outcome, error) {
+	if e.Cassette == nil {
+		return nil, fmt.Errorf("replay %s: no cassette recorded", e.Name)
+	}
+	rep := transport.NewReplayer(e.Cassette)
+	result, err := e.scan(ctx, rep)
+	if err != nil {
+		return nil, fmt.Errorf("replay %s: %w", e.Name, err)
+	}
+	o := Normalize(result)
+	o.Unrecorded = rep.Missed()
+	return o, nil
+}
@@ -0,0 +1,147 @@
+package regression
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0x6d61/sqleech/internal/engine"
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+var (
+	update = flag.Bool("update", false, "regenerate golden files from the current scan behavior")
+	record = flag.Bool("record", false, "re-record corpus cassettes against the in-process target server")
+)
+
+const (
+	corpusDir = "testdata/corpus"
+	goldenDir = "testdata/golden"
+)
+
+// TestCorpus replays every corpus entry through the full scanner and diffs
+// the findings against its golden.
+func TestCorpus(t *testing.T) {
+	entries, err := LoadCorpus(corpusDir)
+	if err != nil {
+		t.Fatalf("LoadCorpus: %v", err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("empty corpus")
+	}
+	if *record {
+		recordCorpus(t, entries)
+	}
+
+	for _, e := range entries {
+		t.Run(e.Name, func(t *testing.T) {
+			outcome, err := e.Replay(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join(goldenDir, e.Name+".json")
+			if *update {
+				if err := NewGolden(outcome).Save(path); err != nil {
+					t.Fatal(err)
+				}
+			}
+			golden, err := LoadGolden(path)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+
+			diff := Compare(golden, outcome)
+			if len(diff) == 0 {
+				return
+			}
+			msg := "scan behavior differs from " + path + ":\n  " + strings.Join(diff, "\n  ")
+			if n := len(outcome.Unrecorded); n > 0 {
+				msg += "\n" + unrecordedNote(outcome.Unrecorded)
+			}
+			t.Errorf("%s\nIf the change is intended, run: go test ./regression/... -update", msg)
+		})
+	}
+}
+
+// unrecordedNote summarizes requests missing from a cassette.
+func unrecordedNote(missed []string) string {
+	const show = 3
+	note := "the scan now sends requests the cassette has no answer for, e.g.:"
+	for i, m := range missed {
+		if i == show {
+			break
+		}
+		note += "\n    " + m
+	}
+	return note + "\nre-record with -record if the probing change is intended"
+}
+
+// recordCorpus re-records every entry against the in-process target server.
+func recordCorpus(t *testing.T, entries []*Entry) {
+	t.Helper()
+	srv := newTargetServer()
+	defer srv.Close()
+
+	client, err := transport.NewClient(transport.ClientOptions{Timeout: 30 * time.Second})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	for _, e := range entries {
+		if err := e.Record(context.Background(), &rewriteClient{Client: client, live: srv.URL}); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.Save(filepath.Join(corpusDir, e.Name+".json")); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadCorpus_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "bad.json"), []byte(`{"description":"no target"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCorpus(dir); err == nil {
+		t.Error("LoadCorpus: expected error for entry without target url")
+	}
+}
+
+func TestReplay_WithoutCassette(t *testing.T) {
+	e := &Entry{Name: "x", Target: Target{URL: corpusBase + "/?id=1"}}
+	if _, err := e.Replay(context.Background()); err == nil {
+		t.Error("Replay: expected error for entry without cassette")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	id := engine.Parameter{Name: "id", Location: engine.LocationQuery}
+	name := engine.Parameter{Name: "name", Location: engine.LocationBody}
+	result := &engine.ScanResult{
+		DBMS:         "MySQL",
+		RequestCount: 42,
+		Vulnerabilities: []engine.Vulnerability{
+			{Parameter: id, Technique: "time-based", Confidence: 0.9, Payload: "p1", Evidence: "e1", Injectable: true},
+			{Parameter: name, Technique: "boolean-blind", Confidence: 0.8, PairedParameter: &id, Injectable: true},
+			{Parameter: id, Technique: "union-based"},
+			{Parameter: id, Technique: "error-based", Confidence: 0.95, Injectable: true},
+		},
+	}
+
+	o := Normalize(result)
+	var ids []string
+	for _, f := range o.Findings {
+		ids = append(ids, f.ID)
+	}
+	want := "body:name+query:id:boolean-blind,query:id:error-based,query:id:time-based"
+	if got := strings.Join(ids, ","); got != want {
+		t.Errorf("finding IDs = %s, want %s", got, want)
+	}
+	if o.DBMS != "MySQL" || o.Requests != 42 {
+		t.Errorf("Normalize() = %+v", o)
+	}
+}
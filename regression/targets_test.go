@@ -0,0 +1,171 @@
+package regression
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0x6d61/sqleech/internal/testutil"
+	"github.com/0x6d61/sqleech/internal/transport"
+)
+
+// corpusBase is the origin corpus targets are recorded under. Recording
+// rewrites it to the live target server so cassettes don't depend on the
+// port httptest happened to pick.
+const corpusBase = "http://regression.test"
+
+// newTargetServer serves the testutil mock endpoints under /vuln/ and the
+// edge cases below under /edge/.
+func newTargetServer() *httptest.Server {
+	vuln := testutil.VulnHandler()
+	mux := http.NewServeMux()
+	mux.Handle("/vuln/", vuln)
+	mux.Handle("/edge/dynamic", dynamicPage(vuln))
+	mux.HandleFunc("/edge/like", handleLike)
+	mux.HandleFunc("/edge/api/items", handleJSONAPI)
+	mux.Handle("/edge/cached", &staleCache{next: vuln, seen: make(map[string]bool)})
+	mux.HandleFunc("/edge/sleep", handleSleep)
+	return httptest.NewServer(mux)
+}
+
+// serveAs runs h for r as if r had been sent to path, and returns the body.
+func serveAs(h http.Handler, r *http.Request, path string) string {
+	r2 := r.Clone(r.Context())
+	r2.URL.Path = path
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r2)
+	return rec.Body.String()
+}
+
+// falseCondition reports whether an injected condition evaluates to false.
+func falseCondition(v string) bool {
+	return strings.Contains(strings.ToUpper(v), "1=2") || strings.Contains(v, "'1'='2")
+}
+
+// dynamicPage wraps /vuln/boolean and stamps every page with a nonce and the
+// server time, so no two responses are byte-identical.
+func dynamicPage(vuln http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce := make([]byte, 8)
+		_, _ = rand.Read(nonce)
+		body := serveAs(vuln, r, "/vuln/boolean")
+		body = strings.Replace(body, "</body>",
+			fmt.Sprintf("<p>Rendered %s</p><!-- csrf %s --></body>",
+				time.Now().Format(time.RFC3339Nano), hex.EncodeToString(nonce)), 1)
+		fmt.Fprint(w, body)
+	})
+}
+
+// sleepPattern extracts the seconds argument from SLEEP(n) or PG_SLEEP(n).
+var sleepPattern = regexp.MustCompile(`(?i)(?:PG_)?SLEEP\((\d+)\)`)
+
+// handleSleep simulates a time-based blind endpoint that, unlike the
+// testutil one, sleeps for the full requested time, as the default
+// time-based settings of the scan command require.
+//
+// GET /edge/sleep?id=X
+func handleSleep(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if m := sleepPattern.FindStringSubmatch(id); m != nil && strings.Contains(id, "1=1") && !falseCondition(id) {
+		n, _ := strconv.Atoi(m[1])
+		time.Sleep(time.Duration(n) * time.Second)
+	}
+	fmt.Fprint(w, "<html><body><h1>Results</h1><p>Record found.</p></body></html>")
+}
+
+var likeItems = []string{"Widget", "Wide gadget", "Sprocket", "Gizmo"}
+
+var likeTmpl = template.Must(template.New("").Parse(`
+{{define "results"}}<html><body><h1>Search</h1><ul>{{range .}}<li>{{.}}</li>{{else}}<li>No items match.</li>{{end}}</ul></body></html>{{end}}
+{{define "syntax"}}<html><body><h1>Error</h1><p>You have an error in your SQL syntax; check the manual that corresponds to your MySQL server version for the right syntax to use near '{{.}}%'' at line 1</p></body></html>{{end}}
+{{define "xpath"}}<html><body><h1>Error</h1><p>XPATH syntax error: '~8.0.32~'</p></body></html>{{end}}
+`))
+
+// handleLike simulates SELECT name FROM items WHERE name LIKE '%q%' on
+// MySQL. The baseline term matches several rows, and any change to it
+// changes the result set.
+//
+// GET /edge/like?q=X
+func handleLike(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	term, injected, quoted := strings.Cut(q, "'")
+
+	switch {
+	case strings.Contains(strings.ToLower(q), "extractvalue") || strings.Contains(strings.ToLower(q), "updatexml"):
+		_ = likeTmpl.ExecuteTemplate(w, "xpath", nil)
+	case quoted && !strings.HasSuffix(injected, "-- -") && !strings.HasSuffix(injected, "#"):
+		_ = likeTmpl.ExecuteTemplate(w, "syntax", injected)
+	case quoted && falseCondition(injected):
+		_ = likeTmpl.ExecuteTemplate(w, "results", nil)
+	default:
+		var rows []string
+		for _, item := range likeItems {
+			if strings.Contains(strings.ToLower(item), strings.ToLower(term)) {
+				rows = append(rows, item)
+			}
+		}
+		_ = likeTmpl.ExecuteTemplate(w, "results", rows)
+	}
+}
+
+// handleJSONAPI simulates a REST endpoint whose id is interpolated into a
+// numeric WHERE clause and whose answers are JSON.
+//
+// GET /edge/api/items?id=X
+func handleJSONAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if falseCondition(r.URL.Query().Get("id")) {
+		fmt.Fprint(w, `{"items":[],"total":0}`)
+		return
+	}
+	fmt.Fprint(w, `{"items":[{"id":1,"name":"Widget","price":9.99,"tags":["tools","home"]}],"total":1}`)
+}
+
+// staleCache sits in front of /vuln/boolean like a misconfigured cache:
+// once it has seen a query it answers 304 Not Modified, even to requests
+// without conditional headers, unless the client sends no-cache.
+type staleCache struct {
+	next http.Handler
+
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func (c *staleCache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	stale := c.seen[r.URL.RawQuery] && r.Header.Get("Cache-Control") != "no-cache"
+	c.seen[r.URL.RawQuery] = true
+	c.mu.Unlock()
+
+	w.Header().Set("ETag", `"v1"`)
+	if stale {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	fmt.Fprint(w, serveAs(c.next, r, "/vuln/boolean"))
+}
+
+// rewriteClient maps corpusBase to the live target server while recording.
+type rewriteClient struct {
+	transport.Client
+	live string
+}
+
+func (c *rewriteClient) Do(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	live := req.Clone()
+	live.URL = strings.Replace(live.URL, corpusBase, c.live, 1)
+	resp, err := c.Client.Do(ctx, live)
+	if resp != nil {
+		resp.URL = strings.Replace(resp.URL, c.live, corpusBase, 1)
+	}
+	return resp, err
+}